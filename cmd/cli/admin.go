@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+)
+
+// adminCommand groups helpers for server administrators
+func adminCommand() *cli.Command {
+	return &cli.Command{
+		Name:     "admin",
+		Usage:    "Administer a server (requires an administrator token)",
+		Commands: []*cli.Command{backupCommand(), restoreCommand()},
+	}
+}
+
+// adminFlags are the flags shared by the admin commands
+func adminFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "token",
+			Usage:    "personal access token of an administrator",
+			Sources:  cli.EnvVars("STASIS_TOKEN"),
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "owner",
+			Usage: "only the repositories of this user",
+		},
+		&cli.StringFlag{
+			Name:  "repo",
+			Usage: "only this repository of --owner",
+		},
+	}
+}
+
+// backupCommand backs up repositories on the server
+func backupCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "backup",
+		Usage:     "Back up repositories to the server's backup directory",
+		ArgsUsage: "<server-url>",
+		Description: "Writes a git bundle and a manifest of the metadata of each selected repository " +
+			"(every repository without --owner) under backups.directory on the server. Repositories " +
+			"whose refs did not change since their last backup keep their bundle.",
+		Flags:  adminFlags(),
+		Action: runBackup,
+	}
+}
+
+// restoreCommand restores repositories from their backups
+func restoreCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "restore",
+		Usage:     "Restore repositories from the server's backup directory",
+		ArgsUsage: "<server-url>",
+		Description: "Recreates the selected repositories (every backed up repository without --owner) " +
+			"from their bundle and manifest after checking the bundle checksums.",
+		Flags: append(adminFlags(), &cli.StringFlag{
+			Name:  "on-conflict",
+			Usage: "what to do with repositories that exist already: skip them, or fail without restoring anything",
+			Value: "skip",
+		}),
+		Action: runRestore,
+	}
+}
+
+func runBackup(ctx context.Context, cmd *cli.Command) error {
+	serverURL, err := adminServerURL(cmd)
+	if err != nil {
+		return err
+	}
+
+	var resp dto.BackupResponse
+	req := dto.BackupRequest{Owner: cmd.String("owner"), Repo: cmd.String("repo")}
+	if err := postAdmin(ctx, serverURL, "/api/v1/admin/backups", cmd.String("token"), req, &resp); err != nil {
+		return err
+	}
+
+	out := cmd.Root().Writer
+	if len(resp.Results) == 0 {
+		fmt.Fprintln(out, "No repositories")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tSTATUS\tSIZE\tSHA256\tERROR")
+	for _, result := range resp.Results {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n",
+			result.Repository,
+			result.Status,
+			result.Size,
+			orDash(truncate(result.SHA256, 17)),
+			orDash(result.Error),
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if resp.Failed > 0 {
+		return fmt.Errorf("%d of %d repositories failed to back up", resp.Failed, len(resp.Results))
+	}
+	return nil
+}
+
+func runRestore(ctx context.Context, cmd *cli.Command) error {
+	serverURL, err := adminServerURL(cmd)
+	if err != nil {
+		return err
+	}
+
+	var resp dto.RestoreBackupResponse
+	req := dto.RestoreBackupRequest{
+		BackupRequest: dto.BackupRequest{Owner: cmd.String("owner"), Repo: cmd.String("repo")},
+		OnConflict:    cmd.String("on-conflict"),
+	}
+	if err := postAdmin(ctx, serverURL, "/api/v1/admin/backups/restore", cmd.String("token"), req, &resp); err != nil {
+		return err
+	}
+
+	out := cmd.Root().Writer
+	if len(resp.Results) == 0 {
+		fmt.Fprintln(out, "No backups")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tSTATUS\tDETAILS")
+	for _, result := range resp.Results {
+		details := result.Warnings
+		if result.Error != "" {
+			details = append([]string{result.Error}, details...)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.Repository, result.Status, orDash(strings.Join(details, "; ")))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if resp.Failed > 0 {
+		return fmt.Errorf("%d of %d repositories failed to restore", resp.Failed, len(resp.Results))
+	}
+	return nil
+}
+
+// adminServerURL parses the <server-url> argument of an admin command
+func adminServerURL(cmd *cli.Command) (*url.URL, error) {
+	if cmd.Args().Len() != 1 {
+		return nil, fmt.Errorf("expected exactly one argument: <server-url>")
+	}
+	serverURL, err := url.Parse(cmd.Args().First())
+	if err != nil || serverURL.Host == "" {
+		return nil, fmt.Errorf("invalid server URL %q", cmd.Args().First())
+	}
+	return serverURL, nil
+}
+
+// postAdmin sends an admin request and decodes its response into out. There
+// is no timeout: backups of large servers take a while.
+func postAdmin(ctx context.Context, serverURL *url.URL, path, token string, body, out any) error {
+	endpoint := strings.TrimSuffix(serverURL.String(), "/") + path
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("%s returned %s: %s", endpoint, resp.Status, apiErr.Message)
+		}
+		return fmt.Errorf("%s returned %s", endpoint, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
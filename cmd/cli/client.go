@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v3"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+)
+
+// clientCommand groups helpers for users of a git server
+func clientCommand() *cli.Command {
+	return &cli.Command{
+		Name:     "client",
+		Usage:    "Helpers for setting up a client of a git server",
+		Commands: []*cli.Command{trustCommand()},
+	}
+}
+
+// trustCommand adds the SSH host keys of a server to known_hosts
+func trustCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "trust",
+		Usage:     "Add the SSH host keys of a server to known_hosts",
+		ArgsUsage: "<server-url>",
+		Description: "Fetches the SSH host keys published by the server over HTTPS, shows their fingerprints " +
+			"and, once confirmed, appends them to known_hosts so the first SSH connection does not " +
+			"have to be trusted blindly.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "known-hosts",
+				Usage: "known_hosts file to append to",
+				Value: defaultKnownHostsPath(),
+			},
+			&cli.StringFlag{
+				Name:  "ssh-host",
+				Usage: "host name used for SSH, if it differs from the host of the server URL",
+			},
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y"},
+				Usage:   "add the keys without asking for confirmation",
+			},
+			&cli.BoolFlag{
+				Name:  "allow-http",
+				Usage: "allow fetching the keys over plain HTTP, which defeats the purpose outside of local testing",
+			},
+		},
+		Action: runTrust,
+	}
+}
+
+func runTrust(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 1 {
+		return fmt.Errorf("expected exactly one argument: <server-url>")
+	}
+
+	serverURL, err := url.Parse(cmd.Args().First())
+	if err != nil || serverURL.Host == "" {
+		return fmt.Errorf("invalid server URL %q", cmd.Args().First())
+	}
+	switch serverURL.Scheme {
+	case "https":
+	case "http":
+		if !cmd.Bool("allow-http") {
+			return fmt.Errorf("refusing to fetch host keys over plain HTTP; use https:// or --allow-http")
+		}
+	default:
+		return fmt.Errorf("unsupported URL scheme %q", serverURL.Scheme)
+	}
+
+	hostKeys, err := fetchSSHHostKeys(ctx, serverURL)
+	if err != nil {
+		return err
+	}
+	if !hostKeys.Enabled {
+		return fmt.Errorf("SSH is not enabled on %s", serverURL.Host)
+	}
+	if len(hostKeys.HostKeys) == 0 {
+		return fmt.Errorf("%s has not published any SSH host keys", serverURL.Host)
+	}
+
+	sshHost := cmd.String("ssh-host")
+	if sshHost == "" {
+		sshHost = serverURL.Hostname()
+	}
+	// Normalize writes [host]:port for any port other than 22
+	address := knownhosts.Normalize(net.JoinHostPort(sshHost, strconv.Itoa(hostKeys.Port)))
+
+	knownHostsPath := cmd.String("known-hosts")
+	existing, err := os.ReadFile(knownHostsPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", knownHostsPath, err)
+	}
+	present := make(map[string]bool)
+	for _, line := range strings.Split(string(existing), "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+
+	out := cmd.Root().Writer
+	fmt.Fprintf(out, "SSH host keys of %s:\n", address)
+
+	var lines []string
+	for _, hostKey := range hostKeys.HostKeys {
+		key, _, _, _, err := gossh.ParseAuthorizedKey([]byte(hostKey.PublicKey))
+		if err != nil {
+			return fmt.Errorf("server returned an invalid %s host key: %w", hostKey.Type, err)
+		}
+		fingerprint := gossh.FingerprintSHA256(key)
+		if fingerprint != hostKey.Fingerprint {
+			return fmt.Errorf("fingerprint of the %s host key does not match the published fingerprint", key.Type())
+		}
+
+		line := knownhosts.Line([]string{address}, key)
+		if present[line] {
+			fmt.Fprintf(out, "  %s %s (already trusted)\n", fingerprint, key.Type())
+			continue
+		}
+		fmt.Fprintf(out, "  %s %s\n", fingerprint, key.Type())
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		fmt.Fprintf(out, "All host keys are already in %s\n", knownHostsPath)
+		return nil
+	}
+
+	if !cmd.Bool("yes") {
+		fmt.Fprintf(out, "Add %d key(s) to %s? [y/N] ", len(lines), knownHostsPath)
+		in := cmd.Root().Reader
+		if in == nil {
+			in = os.Stdin
+		}
+		answer, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read answer: %w", err)
+		}
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			fmt.Fprintln(out, "Aborted, nothing was added")
+			return nil
+		}
+	}
+
+	if err := appendKnownHosts(knownHostsPath, existing, lines); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Added %d key(s) to %s\n", len(lines), knownHostsPath)
+	return nil
+}
+
+// fetchSSHHostKeys fetches the SSH host keys a server publishes
+func fetchSSHHostKeys(ctx context.Context, serverURL *url.URL) (*dto.SSHHostKeysResponse, error) {
+	endpoint := strings.TrimSuffix(serverURL.String(), "/") + "/api/v1/ssh-host-keys"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch host keys: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch host keys: %s returned %s", endpoint, resp.Status)
+	}
+
+	var hostKeys dto.SSHHostKeysResponse
+	if err := json.NewDecoder(resp.Body).Decode(&hostKeys); err != nil {
+		return nil, fmt.Errorf("failed to decode host keys: %w", err)
+	}
+	return &hostKeys, nil
+}
+
+// appendKnownHosts appends lines to a known_hosts file, creating it and its
+// directory with the permissions ssh expects if needed
+func appendKnownHosts(path string, existing []byte, lines []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	if len(existing) > 0 && existing[len(existing)-1] != '\n' {
+		b.WriteString("\n")
+	}
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	if _, err := f.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// defaultKnownHostsPath returns the known_hosts file of the current user
+func defaultKnownHostsPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "known_hosts"
+	}
+	return filepath.Join(home, ".ssh", "known_hosts")
+}
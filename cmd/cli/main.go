@@ -12,6 +12,11 @@ func main() {
 	cmd := &cli.Command{
 		Name:  "git-server",
 		Usage: "A simple Git server application",
+		Commands: []*cli.Command{
+			clientCommand(),
+			tokensCommand(),
+			adminCommand(),
+		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			cmd.Writer.Write([]byte("Git server CLI\n"))
 			return nil
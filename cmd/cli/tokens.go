@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+)
+
+// tokensCommand groups helpers for managing personal access tokens
+func tokensCommand() *cli.Command {
+	return &cli.Command{
+		Name:     "tokens",
+		Usage:    "Manage your personal access tokens",
+		Commands: []*cli.Command{listTokensCommand()},
+	}
+}
+
+// listTokensCommand lists the personal access tokens of the authenticated user
+func listTokensCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "list",
+		Usage:     "List your personal access tokens and where they were last used from",
+		ArgsUsage: "<server-url>",
+		Description: "Shows each token with its last use (time, client IP and user agent) and an " +
+			"approximate usage count, so tokens used from unexpected places stand out.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "token",
+				Usage:    "personal access token to authenticate with",
+				Sources:  cli.EnvVars("STASIS_TOKEN"),
+				Required: true,
+			},
+		},
+		Action: runListTokens,
+	}
+}
+
+func runListTokens(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 1 {
+		return fmt.Errorf("expected exactly one argument: <server-url>")
+	}
+
+	serverURL, err := url.Parse(cmd.Args().First())
+	if err != nil || serverURL.Host == "" {
+		return fmt.Errorf("invalid server URL %q", cmd.Args().First())
+	}
+
+	tokens, err := fetchTokens(ctx, serverURL, cmd.String("token"))
+	if err != nil {
+		return err
+	}
+
+	out := cmd.Root().Writer
+	if len(tokens.Tokens) == 0 {
+		fmt.Fprintln(out, "No tokens")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tLAST USED\tIP\tUSER AGENT\tUSES\tEXPIRES")
+	for _, token := range tokens.Tokens {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			token.ID,
+			token.Name,
+			formatTime(token.LastUsed, "never"),
+			orDash(token.LastUsedIP),
+			orDash(truncate(token.LastUserAgent, 40)),
+			token.UsageCount,
+			formatTime(token.ExpiresAt, "never"),
+		)
+	}
+	return w.Flush()
+}
+
+// fetchTokens fetches the tokens of the user a token belongs to
+func fetchTokens(ctx context.Context, serverURL *url.URL, token string) (*dto.ListTokensResponse, error) {
+	endpoint := strings.TrimSuffix(serverURL.String(), "/") + "/api/v1/tokens"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tokens: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch tokens: %s returned %s", endpoint, resp.Status)
+	}
+
+	var tokens dto.ListTokensResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode tokens: %w", err)
+	}
+	return &tokens, nil
+}
+
+// formatTime formats an optional time, or returns fallback if it is unset
+func formatTime(t *time.Time, fallback string) string {
+	if t == nil {
+		return fallback
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+// orDash returns s, or "-" if it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,222 @@
+// Command i18n-check validates the message catalogs of pkg/i18n against the
+// code. It collects the literal messages of error responses ({"error": ...,
+// "message": "..."}) and fails if the English catalog misses any of them, or if
+// another catalog translates a message the English catalog does not know.
+// Messages missing from non-English catalogs are reported but allowed, since
+// they fall back to English.
+//
+// Run it with go generate ./pkg/i18n, or directly from the module root:
+//
+//	go run ./cmd/i18n-check [-update]
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	root := flag.String("root", ".", "module root to scan")
+	update := flag.Bool("update", false, "add missing messages to the English catalog")
+	flag.Parse()
+
+	localesDir := filepath.Join(*root, "pkg", "i18n", "locales")
+
+	messages, err := collectMessages(*root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "i18n-check: %v\n", err)
+		os.Exit(1)
+	}
+
+	catalogs, err := loadCatalogs(localesDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "i18n-check: %v\n", err)
+		os.Exit(1)
+	}
+	english, ok := catalogs["en"]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "i18n-check: missing %s\n", filepath.Join(localesDir, "en.json"))
+		os.Exit(1)
+	}
+
+	failed := false
+
+	var missing []string
+	for msg := range messages {
+		if _, ok := english[msg]; !ok {
+			missing = append(missing, msg)
+		}
+	}
+	sort.Strings(missing)
+
+	if len(missing) > 0 && *update {
+		for _, msg := range missing {
+			english[msg] = msg
+		}
+		if err := writeCatalog(filepath.Join(localesDir, "en.json"), english); err != nil {
+			fmt.Fprintf(os.Stderr, "i18n-check: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("i18n-check: added %d messages to en.json\n", len(missing))
+		missing = nil
+	}
+	for _, msg := range missing {
+		fmt.Fprintf(os.Stderr, "en.json: missing message %q (used at %s)\n", msg, messages[msg])
+		failed = true
+	}
+
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		if locale != "en" {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales)
+
+	for _, locale := range locales {
+		untranslated := 0
+		for msg := range english {
+			if catalogs[locale][msg] == "" {
+				untranslated++
+			}
+		}
+		for msg := range catalogs[locale] {
+			if _, ok := english[msg]; !ok {
+				fmt.Fprintf(os.Stderr, "%s.json: message %q is not in en.json\n", locale, msg)
+				failed = true
+			}
+		}
+		if untranslated > 0 {
+			fmt.Printf("i18n-check: %s: %d of %d messages untranslated (English is used)\n", locale, untranslated, len(english))
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// collectMessages returns the literal error response messages in Go files
+// under root, with the position of one use each
+func collectMessages(root string) (map[string]string, error) {
+	messages := make(map[string]string)
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", "vendor", "node_modules", "testdata":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+
+		ast.Inspect(file, func(n ast.Node) bool {
+			lit, ok := n.(*ast.CompositeLit)
+			if !ok {
+				return true
+			}
+
+			var message *ast.BasicLit
+			hasError := false
+			for _, elt := range lit.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				if !ok {
+					continue
+				}
+				switch stringLit(kv.Key) {
+				case "error":
+					hasError = true
+				case "message":
+					if v, ok := kv.Value.(*ast.BasicLit); ok && v.Kind == token.STRING {
+						message = v
+					}
+				}
+			}
+
+			if hasError && message != nil {
+				if msg := stringLit(message); msg != "" {
+					if _, seen := messages[msg]; !seen {
+						messages[msg] = fset.Position(message.Pos()).String()
+					}
+				}
+			}
+			return true
+		})
+		return nil
+	})
+
+	return messages, err
+}
+
+// stringLit returns the value of a string literal expression, or "" for anything else
+func stringLit(expr ast.Expr) string {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return ""
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+// loadCatalogs reads every <locale>.json catalog in dir
+func loadCatalogs(dir string) (map[string]map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	catalogs := make(map[string]map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		catalogs[strings.TrimSuffix(entry.Name(), ".json")] = catalog
+	}
+	return catalogs, nil
+}
+
+// writeCatalog writes a catalog with sorted keys
+func writeCatalog(path string, catalog map[string]string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(catalog); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
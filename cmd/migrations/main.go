@@ -15,6 +15,33 @@ func main() {
 		&models.Repository{},
 		&models.SSHKey{},
 		&models.Token{},
+		&models.RepoAnnotation{},
+		&models.ContributionEvent{},
+		&models.CIPipelineJob{},
+		&models.AuthorMapping{},
+		&models.RepositoryCollaborator{},
+		&models.PushAttempt{},
+		&models.BranchProtection{},
+		&models.PullRequest{},
+		&models.Issue{},
+		&models.IssueComment{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.UserExport{},
+		&models.AuditEvent{},
+		&models.RepoBulkTask{},
+		&models.RepoImport{},
+		&models.HousekeepingTask{},
+		&models.CommitStatus{},
+		&models.GPGKey{},
+		&models.LargeFileAddition{},
+		&models.CIJobToken{},
+		&models.CIArtifact{},
+		&models.Session{},
+		&models.RepoLanguage{},
+		&models.RepoRedirect{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
 	)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to load gorm schema: %v\n", err)
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
@@ -13,12 +14,17 @@ import (
 
 	"github.com/bravo68web/stasis/internal/injectable"
 	"github.com/bravo68web/stasis/internal/server"
+	"github.com/bravo68web/stasis/internal/transport/http/apiversion"
 	"github.com/bravo68web/stasis/internal/transport/http/router"
 	sshserver "github.com/bravo68web/stasis/internal/transport/ssh"
 	"github.com/bravo68web/stasis/pkg/logger"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate-legacy" {
+		os.Exit(migrateLegacy(os.Args[2:]))
+	}
+
 	// Initialize server (this also initializes the logger)
 	s := server.New()
 	log := s.Logger
@@ -28,36 +34,97 @@ func main() {
 		logger.Bool("development", s.Config.Logging.Development),
 	)
 
-	// Create router and register routes
-	r := router.NewRouter(s)
-	r.RegisterRoutes()
+	// Create a channel for shutdown signals
+	done := make(chan os.Signal, 1)
+	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
-	if err := s.OpenAPIGenerator.Generate().SaveToFile("docs/openapi.yaml"); err != nil {
-		log.Error("Failed to write OpenAPI schema",
+	// The listener serves the health endpoints alone until every route is
+	// registered, so with degraded startup it can start before the database
+	addr := ":" + strconv.Itoa(s.Config.Server.Port)
+	httpHandler := server.NewSwapHandler(router.DegradedHandler(s))
+	httpSrv := &http.Server{Addr: addr, Handler: httpHandler}
+	startHTTP := func() {
+		go func() {
+			log.Info("Starting HTTP server",
+				logger.String("address", addr),
+				logger.String("mode", s.Config.Server.Mode),
+			)
+			if err := httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error("HTTP server error",
+					logger.Error(err),
+					logger.String("address", addr),
+				)
+			}
+		}()
+	}
+
+	if s.Config.Server.DegradedStartup {
+		s.Readiness.Set(server.StateDegraded, "waiting for database")
+		startHTTP()
+	}
+
+	// Wait for the database, giving up after the connect timeout or on a
+	// shutdown signal
+	connectCtx, cancelConnect := context.WithTimeout(context.Background(), s.Config.Database.ConnectTimeout())
+	connectCtx, stopConnect := signal.NotifyContext(connectCtx, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	err := s.ConnectDatabase(connectCtx)
+	interrupted := errors.Is(connectCtx.Err(), context.Canceled)
+	stopConnect()
+	cancelConnect()
+	if err != nil {
+		if interrupted {
+			log.Info("Shutdown requested while waiting for the database")
+			_ = httpSrv.Close()
+			_ = s.Close()
+			return
+		}
+		log.Fatal("Failed to initialize database",
 			logger.Error(err),
+			logger.Duration("connect_timeout", s.Config.Database.ConnectTimeout()),
 		)
 	}
 
-	log.Info("Routes registered successfully")
+	// Create the services shared by the HTTP and SSH servers
+	deps := injectable.LoadDependencies(s.Config, s.DB)
 
-	// Create a channel for shutdown signals
-	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	// Create router and register routes
+	r := router.NewRouter(s, deps)
+	r.RegisterRoutes()
 
-	// Start HTTP server in a goroutine
-	go func() {
-		addr := ":" + strconv.Itoa(s.Config.Server.Port)
-		log.Info("Starting HTTP server",
-			logger.String("address", addr),
-			logger.String("mode", s.Config.Server.Mode),
+	// One spec per API version, and the spec of unversioned requests
+	defaultVersion := apiversion.Default(s.Config.Server.APIVersionDefault)
+	if err := s.OpenAPIGenerator.GenerateVersion(defaultVersion).SaveToFile("docs/openapi.yaml"); err != nil {
+		log.Error("Failed to write OpenAPI schema",
+			logger.Error(err),
 		)
-		if err := s.Engine.Run(addr); err != nil {
-			log.Error("HTTP server error",
+	}
+	for _, version := range apiversion.Supported {
+		if err := s.OpenAPIGenerator.GenerateVersion(version).SaveToFile(router.OpenAPIVersionFile(version)); err != nil {
+			log.Error("Failed to write OpenAPI schema",
 				logger.Error(err),
-				logger.String("address", addr),
+				logger.String("version", version),
 			)
 		}
-	}()
+	}
+
+	// Routes without docs appear in the spec with a bare default response
+	if undocumented := s.OpenAPIGenerator.Undocumented(); len(undocumented) > 0 {
+		log.Warn("Routes missing from the OpenAPI docs",
+			logger.Strings("routes", undocumented),
+		)
+	}
+	if unrouted := s.OpenAPIGenerator.Unrouted(); len(unrouted) > 0 {
+		log.Warn("OpenAPI docs registered for routes that do not exist",
+			logger.Strings("routes", unrouted),
+		)
+	}
+
+	log.Info("Routes registered successfully")
+
+	httpHandler.Swap(s.Engine.Handler())
+	if !s.Config.Server.DegradedStartup {
+		startHTTP()
+	}
 
 	// Start SSH server if enabled
 	var sshSrv *sshserver.Server
@@ -67,18 +134,25 @@ func main() {
 			logger.String("host_key_path", s.Config.SSH.HostKeyPath),
 		)
 
-		// Load dependencies for SSH server
-		deps := injectable.LoadDependencies(s.Config, s.DB)
-
-		var err error
 		sshSrv, err = sshserver.NewServer(
 			&s.Config.SSH,
 			&s.Config.Storage,
 			deps.AuthService,
 			deps.RepoService,
+			deps.TagProtection,
+			deps.BranchProtection,
+			deps.Contributions,
+			deps.Licenses,
+			deps.PinnedLinks,
+			deps.Languages,
 			deps.CIService,
 			deps.GitService,
-			deps.Storage,
+			deps.GitProtocol,
+			deps.StorageBackends,
+			deps.PushAttempts,
+			deps.Quotas,
+			deps.DegradedMode,
+			deps.EventBus,
 		)
 		if err != nil {
 			log.Error("Failed to create SSH server",
@@ -100,6 +174,8 @@ func main() {
 		log.Info("SSH server is disabled")
 	}
 
+	s.Readiness.Set(server.StateReady, "")
+
 	log.Info("Servers started successfully. Press Ctrl+C to shutdown.",
 		logger.Int("http_port", s.Config.Server.Port),
 		logger.Bool("ssh_enabled", s.Config.SSH.Enabled),
@@ -112,6 +188,7 @@ func main() {
 	)
 
 	log.Info("Initiating graceful shutdown...")
+	s.Readiness.Set(server.StateDegraded, "shutting down")
 
 	// Create shutdown context with timeout
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -129,6 +206,17 @@ func main() {
 		}
 	}
 
+	log.Info("Shutting down HTTP server...")
+	if err := httpSrv.Shutdown(shutdownCtx); err != nil {
+		log.Error("HTTP server shutdown error",
+			logger.Error(err),
+		)
+	}
+
+	// Stop background work, deliver queued events, then flush audit events,
+	// spooling anything the sinks cannot take, and send queued emails
+	deps.Close()
+
 	// Close server resources (including logger)
 	if err := s.Close(); err != nil {
 		log.Error("Error closing server resources",
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bravo68web/stasis/internal/infrastructure/legacy"
+	"github.com/bravo68web/stasis/internal/infrastructure/storage"
+	"github.com/bravo68web/stasis/internal/server"
+)
+
+// migrateLegacy runs "server migrate-legacy", which imports a legacy githut
+// server into the configured database and storage and prints the
+// reconciliation report, and returns the exit status. The database must be
+// migrated, as the server does on start in production.
+func migrateLegacy(args []string) int {
+	flags := flag.NewFlagSet("migrate-legacy", flag.ContinueOnError)
+	dsn := flags.String("dsn", os.Getenv("GITHUT_POSTGRES_DSN"), "DSN of the legacy database (default $GITHUT_POSTGRES_DSN)")
+	reposRoot := flags.String("repos-root", "data/repos", "directory of the legacy repositories, laid out as {owner}/{repo}.git")
+	dryRun := flags.Bool("dry-run", false, "report what would be migrated without writing anything")
+	reportPath := flags.String("report", "", "write the report to this file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *dsn == "" {
+		fmt.Fprintln(os.Stderr, "migrate-legacy: --dsn is required")
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s := server.New()
+	defer s.Close()
+
+	connectCtx, cancel := context.WithTimeout(ctx, s.Config.Database.ConnectTimeout())
+	err := s.ConnectDatabase(connectCtx)
+	cancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-legacy: %v\n", err)
+		return 1
+	}
+
+	legacyDB, err := legacy.Open(*dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-legacy: %v\n", err)
+		return 1
+	}
+	if sqlDB, err := legacyDB.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	registry, err := storage.NewRegistry(&s.Config.Storage)
+	if err == nil {
+		err = registry.Check(ctx)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-legacy: %v\n", err)
+		return 1
+	}
+
+	report, err := legacy.NewMigrator(legacyDB, s.DB.DB(), registry, *reposRoot).Run(ctx, *dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-legacy: %v\n", err)
+		return 1
+	}
+
+	var out io.Writer = os.Stdout
+	if *reportPath != "" {
+		f, err := os.Create(*reportPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate-legacy: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+	if _, err := report.WriteTo(out); err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-legacy: failed to write the report: %v\n", err)
+		return 1
+	}
+	if !report.Reconciled() {
+		return 1
+	}
+	return 0
+}
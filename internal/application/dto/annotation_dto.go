@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// CreateAnnotationRequest represents a request to add an annotation to a repository
+type CreateAnnotationRequest struct {
+	Key   string `json:"key" binding:"required,min=1,max=64"`
+	Value string `json:"value" binding:"required"`
+}
+
+// UpdateAnnotationRequest represents a request to change an annotation value
+type UpdateAnnotationRequest struct {
+	Value string `json:"value" binding:"required"`
+}
+
+// AnnotationResponse represents a repository annotation in API responses
+type AnnotationResponse struct {
+	Key       string     `json:"key"`
+	Value     string     `json:"value"`
+	AuthorID  *uuid.UUID `json:"author_id,omitempty"`
+	Author    string     `json:"author,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// AnnotationListResponse represents a list of repository annotations
+type AnnotationListResponse struct {
+	Annotations []AnnotationResponse `json:"annotations"`
+	Total       int                  `json:"total"`
+}
+
+// AnnotationFromModel converts a RepoAnnotation model to AnnotationResponse DTO
+func AnnotationFromModel(a *models.RepoAnnotation) AnnotationResponse {
+	resp := AnnotationResponse{
+		Key:       a.Key,
+		Value:     a.Value,
+		AuthorID:  a.AuthorID,
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+	}
+	if a.Author != nil {
+		resp.Author = a.Author.Username
+	}
+	return resp
+}
+
+// AnnotationListFromModels converts a slice of RepoAnnotation models to AnnotationListResponse
+func AnnotationListFromModels(annotations []*models.RepoAnnotation) AnnotationListResponse {
+	responses := make([]AnnotationResponse, len(annotations))
+	for i, a := range annotations {
+		responses[i] = AnnotationFromModel(a)
+	}
+	return AnnotationListResponse{
+		Annotations: responses,
+		Total:       len(responses),
+	}
+}
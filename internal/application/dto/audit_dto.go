@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+// AuditSinkHealthResponse represents the delivery state of an audit sink
+type AuditSinkHealthResponse struct {
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	Backlog     int        `json:"backlog"`
+	Spooled     int        `json:"spooled"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// AuditSinkListResponse represents the audit sink health listing
+type AuditSinkListResponse struct {
+	Sinks []AuditSinkHealthResponse `json:"sinks"`
+}
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+)
+
+// AuditEventResponse represents an action recorded in the audit trail
+type AuditEventResponse struct {
+	ID           string         `json:"id"`
+	ActorID      string         `json:"actor_id,omitempty"`
+	Actor        string         `json:"actor"`
+	RepositoryID string         `json:"repository_id,omitempty"`
+	Action       string         `json:"action"`
+	Metadata     map[string]any `json:"metadata"`
+	IP           string         `json:"ip,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+// ListAuditEventsResponse represents a page of audit events
+type ListAuditEventsResponse struct {
+	Events     []AuditEventResponse `json:"events"`
+	Page       int                  `json:"page"`     // Deprecated: use pagination
+	PerPage    int                  `json:"per_page"` // Deprecated: use pagination
+	Total      int64                `json:"total"`    // Deprecated: use pagination
+	Pagination Pagination           `json:"pagination"`
+}
+
+// AuditEventFromModel converts a models.AuditEvent to AuditEventResponse
+func AuditEventFromModel(event *models.AuditEvent) AuditEventResponse {
+	resp := AuditEventResponse{
+		ID:        event.ID.String(),
+		Actor:     event.Actor,
+		Action:    event.Action,
+		Metadata:  event.Metadata,
+		IP:        event.IP,
+		CreatedAt: event.CreatedAt,
+	}
+	if event.ActorID != nil {
+		resp.ActorID = event.ActorID.String()
+	}
+	if event.RepositoryID != nil {
+		resp.RepositoryID = event.RepositoryID.String()
+	}
+	if resp.Metadata == nil {
+		resp.Metadata = map[string]any{}
+	}
+	return resp
+}
@@ -22,6 +22,18 @@ type OIDCCallbackResponse struct {
 	Message string   `json:"message"`
 }
 
+// SessionRefreshResponse represents the response after refreshing a session
+type SessionRefreshResponse struct {
+	Token string   `json:"token"`
+	User  UserInfo `json:"user"`
+}
+
+// LogoutResponse represents the response after logging out
+type LogoutResponse struct {
+	Message   string `json:"message"`
+	LogoutURL string `json:"logout_url,omitempty"` // Logout URL of the identity provider, to end its session too
+}
+
 // OIDCConfigResponse represents the OIDC configuration status
 type OIDCConfigResponse struct {
 	OIDCEnabled     bool `json:"oidc_enabled"`
@@ -31,7 +43,7 @@ type OIDCConfigResponse struct {
 // CreateTokenRequest represents the request body for creating a token
 type CreateTokenRequest struct {
 	Name      string   `json:"name" binding:"required,min=1,max=255"`
-	Scopes    []string `json:"scopes"`     // optional, empty = all repos
+	Scopes    []string `json:"scopes"`     // optional: repo:read, repo:write, repo:admin, admin; empty = repo:read and repo:write
 	ExpiresIn *int     `json:"expires_in"` // optional, days until expiration
 }
 
@@ -48,12 +60,15 @@ type TokenResponse struct {
 
 // TokenInfo represents access token information (without the actual token)
 type TokenInfo struct {
-	ID        uuid.UUID  `json:"id"`
-	Name      string     `json:"name"`
-	Scopes    []string   `json:"scopes"`
-	ExpiresAt *time.Time `json:"expires_at,omitempty"`
-	LastUsed  *time.Time `json:"last_used,omitempty"`
-	CreatedAt time.Time  `json:"created_at"`
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	Scopes        []string   `json:"scopes"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	LastUsed      *time.Time `json:"last_used,omitempty"`
+	LastUsedIP    string     `json:"last_used_ip,omitempty"`
+	LastUserAgent string     `json:"last_user_agent,omitempty"`
+	UsageCount    int64      `json:"usage_count"` // Approximate; uses are written at most once a minute
+	CreatedAt     time.Time  `json:"created_at"`
 }
 
 // ListTokensResponse represents a list of user tokens
@@ -67,12 +82,15 @@ func TokenInfoFromModels(tokens []*models.Token) []TokenInfo {
 
 	for _, t := range tokens {
 		tokenInfo = append(tokenInfo, TokenInfo{
-			ID:        t.ID,
-			Name:      t.Name,
-			Scopes:    []string(t.Scope),
-			ExpiresAt: t.ExpiresAt,
-			LastUsed:  t.LastUsed,
-			CreatedAt: t.CreatedAt,
+			ID:            t.ID,
+			Name:          t.Name,
+			Scopes:        []string(t.Scope),
+			ExpiresAt:     t.ExpiresAt,
+			LastUsed:      t.LastUsed,
+			LastUsedIP:    t.LastUsedIP,
+			LastUserAgent: t.LastUserAgent,
+			UsageCount:    t.UsageCount,
+			CreatedAt:     t.CreatedAt,
 		})
 	}
 
@@ -87,10 +105,24 @@ type AddSSHKeyRequest struct {
 
 // SSHKeyInfo represents SSH key information
 type SSHKeyInfo struct {
-	ID          uuid.UUID `json:"id"`
-	Title       string    `json:"title"`
-	Fingerprint string    `json:"fingerprint"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	KeyType     string     `json:"key_type"`    // e.g. ssh-ed25519, ssh-rsa
+	Fingerprint string     `json:"fingerprint"` // SHA256:..., as shown by ssh-add -l
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// SSHKeyInfoFromModel converts an SSHKey model to SSHKeyInfo
+func SSHKeyInfoFromModel(key *models.SSHKey) SSHKeyInfo {
+	return SSHKeyInfo{
+		ID:          key.ID,
+		Title:       key.Title,
+		KeyType:     key.KeyType,
+		Fingerprint: key.Fingerprint,
+		LastUsedAt:  key.LastUsedAt,
+		CreatedAt:   key.CreatedAt,
+	}
 }
 
 // AddSSHKeyResponse represents a response after adding an SSH key
@@ -125,11 +157,11 @@ type AuthenticatedUser struct {
 	Scopes   []string  `json:"scopes,omitempty"` // For token-based auth
 }
 
-// ErrorResponse represents an error response
+// ErrorResponse is the envelope of REST API errors
 type ErrorResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
+	Error   string `json:"error"`             // Machine-readable code, such as not_found or validation_error
+	Message string `json:"message"`           // Translated per Accept-Language
+	Details any    `json:"details,omitempty"` // A string or an object, depending on the error
 }
 
 // SuccessResponse represents a generic success response
@@ -149,3 +181,16 @@ type ValidateTokenResponse struct {
 type UpdateUserRequest struct {
 	Username *string `json:"username,omitempty" binding:"omitempty,min=1,max=255"`
 }
+
+// UpdateUserSettingsRequest represents a request to update the current user's settings
+type UpdateUserSettingsRequest struct {
+	ShowPrivateContributions *bool   `json:"show_private_contributions,omitempty"`
+	Locale                   *string `json:"locale,omitempty" binding:"omitempty,max=16"`
+}
+
+// UserSettingsResponse represents the current user's settings
+type UserSettingsResponse struct {
+	ShowPrivateContributions bool     `json:"show_private_contributions"`
+	Locale                   string   `json:"locale"`
+	AvailableLocales         []string `json:"available_locales"`
+}
@@ -0,0 +1,95 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// CommitAuthorResponse is the canonical identity of a commit author after
+// applying the repository's .mailmap and server-side author mappings
+type CommitAuthorResponse struct {
+	Name     string     `json:"name"`
+	Email    string     `json:"email"`
+	UserID   *uuid.UUID `json:"user_id,omitempty"` // Set if the identity belongs to a user
+	Username string     `json:"username,omitempty"`
+}
+
+// SetAuthorMappingRequest represents a request to map a commit email to a user
+type SetAuthorMappingRequest struct {
+	Email    string `json:"email" binding:"required,email,max=255"`
+	Username string `json:"username" binding:"required"`
+}
+
+// AuthorMappingResponse represents a server-side author mapping
+type AuthorMappingResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AuthorMappingListResponse represents the author mappings of a repository
+type AuthorMappingListResponse struct {
+	Mappings []AuthorMappingResponse `json:"mappings"`
+	Total    int                     `json:"total"`
+}
+
+// ContributorResponse represents a contributor to the default branch of a repository
+type ContributorResponse struct {
+	Name     string     `json:"name"`
+	Email    string     `json:"email"`
+	UserID   *uuid.UUID `json:"user_id,omitempty"`
+	Username string     `json:"username,omitempty"`
+	Commits  int        `json:"commits"`
+}
+
+// ContributorListResponse represents the contributors of a repository
+type ContributorListResponse struct {
+	Contributors   []ContributorResponse `json:"contributors"`
+	Total          int                   `json:"total"`
+	CommitsScanned int                   `json:"commits_scanned"`
+	Truncated      bool                  `json:"truncated"` // True if older history was not scanned
+}
+
+// UnmappedAuthorResponse represents a commit author email that does not resolve to a user
+type UnmappedAuthorResponse struct {
+	Email    string    `json:"email"`
+	Name     string    `json:"name"` // Most recent name used with the email
+	Commits  int       `json:"commits"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// UnmappedAuthorListResponse represents the unmapped author emails of recent history
+type UnmappedAuthorListResponse struct {
+	Authors        []UnmappedAuthorResponse `json:"authors"`
+	Total          int                      `json:"total"`
+	CommitsScanned int                      `json:"commits_scanned"`
+}
+
+// AuthorMappingFromModel converts a models.AuthorMapping to AuthorMappingResponse
+func AuthorMappingFromModel(m *models.AuthorMapping) AuthorMappingResponse {
+	return AuthorMappingResponse{
+		ID:        m.ID,
+		Email:     m.Email,
+		UserID:    m.UserID,
+		Username:  m.User.Username,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+}
+
+// AuthorMappingListFromModels converts author mappings to AuthorMappingListResponse
+func AuthorMappingListFromModels(mappings []*models.AuthorMapping) AuthorMappingListResponse {
+	resp := AuthorMappingListResponse{
+		Mappings: make([]AuthorMappingResponse, len(mappings)),
+		Total:    len(mappings),
+	}
+	for i, m := range mappings {
+		resp.Mappings[i] = AuthorMappingFromModel(m)
+	}
+	return resp
+}
@@ -0,0 +1,43 @@
+package dto
+
+// BackupRequest selects the repositories to back up or restore: one
+// repository, the repositories of an owner, or all of them when both are empty
+type BackupRequest struct {
+	Owner string `json:"owner"`
+	Repo  string `json:"repo"` // Requires owner
+}
+
+// RestoreBackupRequest selects the backed up repositories to restore
+type RestoreBackupRequest struct {
+	BackupRequest
+	OnConflict string `json:"on_conflict" binding:"omitempty,oneof=skip fail"` // skip (default) leaves existing repositories alone, fail restores nothing if any exists
+}
+
+// BackupResultResponse is the outcome of the backup of a repository
+type BackupResultResponse struct {
+	Repository string `json:"repository"`
+	Status     string `json:"status"` // written, unchanged, failed
+	Size       int64  `json:"size"`   // Size of the bundle, 0 for a repository without refs
+	SHA256     string `json:"sha256,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// BackupResponse lists the outcome of a backup
+type BackupResponse struct {
+	Results []BackupResultResponse `json:"results"`
+	Failed  int                    `json:"failed"`
+}
+
+// RestoreResultResponse is the outcome of the restore of a repository
+type RestoreResultResponse struct {
+	Repository string   `json:"repository"`
+	Status     string   `json:"status"` // restored, skipped, failed
+	Error      string   `json:"error,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"` // Metadata that could not be restored
+}
+
+// RestoreBackupResponse lists the outcome of a restore
+type RestoreBackupResponse struct {
+	Results []RestoreResultResponse `json:"results"`
+	Failed  int                     `json:"failed"`
+}
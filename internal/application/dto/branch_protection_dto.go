@@ -0,0 +1,76 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// BranchProtectionRequest represents a request to create or replace a branch protection
+type BranchProtectionRequest struct {
+	Pattern        string `json:"pattern" binding:"required"` // Branch name glob, e.g. "main" or "release/*"
+	BlockForcePush *bool  `json:"block_force_push,omitempty"` // Refuse non-fast-forward updates (default true)
+	BlockDeletion  *bool  `json:"block_deletion,omitempty"`   // Refuse deleting matching branches (default true)
+	RequireAdmin   bool   `json:"require_admin"`              // Only repository administrators may push
+}
+
+// ToModel converts the request to a models.BranchProtection, blocking force
+// pushes and deletion unless the request says otherwise
+func (r BranchProtectionRequest) ToModel() *models.BranchProtection {
+	protection := &models.BranchProtection{
+		Pattern:        r.Pattern,
+		BlockForcePush: true,
+		BlockDeletion:  true,
+		RequireAdmin:   r.RequireAdmin,
+	}
+	if r.BlockForcePush != nil {
+		protection.BlockForcePush = *r.BlockForcePush
+	}
+	if r.BlockDeletion != nil {
+		protection.BlockDeletion = *r.BlockDeletion
+	}
+	return protection
+}
+
+// BranchProtectionResponse represents a branch protection of a repository
+type BranchProtectionResponse struct {
+	ID             uuid.UUID `json:"id"`
+	Pattern        string    `json:"pattern"`
+	BlockForcePush bool      `json:"block_force_push"`
+	BlockDeletion  bool      `json:"block_deletion"`
+	RequireAdmin   bool      `json:"require_admin"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// BranchProtectionListResponse represents the branch protections of a repository
+type BranchProtectionListResponse struct {
+	Protections []BranchProtectionResponse `json:"protections"`
+	Total       int                        `json:"total"`
+}
+
+// BranchProtectionFromModel converts a models.BranchProtection to BranchProtectionResponse
+func BranchProtectionFromModel(p *models.BranchProtection) BranchProtectionResponse {
+	return BranchProtectionResponse{
+		ID:             p.ID,
+		Pattern:        p.Pattern,
+		BlockForcePush: p.BlockForcePush,
+		BlockDeletion:  p.BlockDeletion,
+		RequireAdmin:   p.RequireAdmin,
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
+
+// BranchProtectionListFromModels converts branch protections to BranchProtectionListResponse
+func BranchProtectionListFromModels(protections []*models.BranchProtection) BranchProtectionListResponse {
+	resp := BranchProtectionListResponse{
+		Protections: make([]BranchProtectionResponse, 0, len(protections)),
+		Total:       len(protections),
+	}
+	for _, p := range protections {
+		resp.Protections = append(resp.Protections, BranchProtectionFromModel(p))
+	}
+	return resp
+}
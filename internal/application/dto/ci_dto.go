@@ -23,6 +23,7 @@ type CIJobResponse struct {
 	FinishedAt      *time.Time           `json:"finished_at,omitempty"`
 	Error           string               `json:"error,omitempty"`
 	DurationSeconds float64              `json:"duration_seconds,omitempty"`
+	TransferBytes   *int64               `json:"transfer_bytes,omitempty"` // Bytes the job fetched of its repository
 	Steps           []CIStepResponse     `json:"steps,omitempty"`
 	Artifacts       []CIArtifactResponse `json:"artifacts,omitempty"`
 }
@@ -53,12 +54,6 @@ type CIJobListResponse struct {
 	Pagination Pagination      `json:"pagination"`
 }
 
-// Pagination represents pagination info
-type Pagination struct {
-	Limit  int `json:"limit"`
-	Offset int `json:"offset"`
-}
-
 // CILogEntryResponse represents a single log line
 type CILogEntryResponse struct {
 	Timestamp time.Time `json:"timestamp"`
@@ -113,3 +108,26 @@ type CIWebhookJobUpdateRequest struct {
 	Status string    `json:"status"`
 	Event  string    `json:"event"`
 }
+
+// CIRunResponse represents the stage progress of a staged CI run
+type CIRunResponse struct {
+	RunID  uuid.UUID         `json:"run_id"`
+	Status string            `json:"status"`
+	Stages []CIStageResponse `json:"stages"`
+}
+
+// CIStageResponse represents a stage of a CI run
+type CIStageResponse struct {
+	Name   string             `json:"name"`
+	Needs  []string           `json:"needs"`
+	Status string             `json:"status"`
+	Jobs   []CIRunJobResponse `json:"jobs"`
+}
+
+// CIRunJobResponse represents a job of a CI run stage
+type CIRunJobResponse struct {
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+}
@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// AddCollaboratorRequest represents a request to grant a user access to a repository
+type AddCollaboratorRequest struct {
+	Username   string `json:"username" binding:"required"`
+	Permission string `json:"permission" binding:"required,oneof=read write admin"`
+}
+
+// CollaboratorResponse represents a collaborator of a repository
+type CollaboratorResponse struct {
+	UserID     uuid.UUID `json:"user_id"`
+	Username   string    `json:"username"`
+	Permission string    `json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// CollaboratorListResponse represents the collaborators of a repository
+type CollaboratorListResponse struct {
+	Collaborators []CollaboratorResponse `json:"collaborators"`
+	Total         int                    `json:"total"`
+}
+
+// CollaboratorFromModel converts a models.RepositoryCollaborator to CollaboratorResponse
+func CollaboratorFromModel(c *models.RepositoryCollaborator) CollaboratorResponse {
+	return CollaboratorResponse{
+		UserID:     c.UserID,
+		Username:   c.User.Username,
+		Permission: string(c.Permission),
+		CreatedAt:  c.CreatedAt,
+		UpdatedAt:  c.UpdatedAt,
+	}
+}
+
+// CollaboratorListFromModels converts collaborators to CollaboratorListResponse
+func CollaboratorListFromModels(collaborators []*models.RepositoryCollaborator) CollaboratorListResponse {
+	resp := CollaboratorListResponse{
+		Collaborators: make([]CollaboratorResponse, 0, len(collaborators)),
+		Total:         len(collaborators),
+	}
+	for _, c := range collaborators {
+		resp.Collaborators = append(resp.Collaborators, CollaboratorFromModel(c))
+	}
+	return resp
+}
@@ -0,0 +1,85 @@
+package dto
+
+import "time"
+
+// Commit shapes of API version 2025-06-01 and later, where the author and
+// committer of a commit are objects. Handlers convert the flat shapes with
+// Split when the request negotiated such a version.
+
+// CommitPersonResponse is the author or committer of a commit
+type CommitPersonResponse struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+// SplitCommitResponse represents a commit with nested author and committer
+type SplitCommitResponse struct {
+	Hash         string               `json:"hash"`
+	ShortHash    string               `json:"short_hash"`
+	Message      string               `json:"message"`
+	Author       CommitPersonResponse `json:"author"`
+	Committer    CommitPersonResponse `json:"committer"`
+	ParentHashes []string             `json:"parent_hashes"`
+
+	// ResolvedAuthor is the author after .mailmap and author mapping resolution
+	ResolvedAuthor *CommitAuthorResponse `json:"resolved_author,omitempty"`
+
+	// Verification is the outcome of checking the signature of the commit
+	Verification *CommitVerificationResponse `json:"verification,omitempty"`
+}
+
+// SplitCommitListResponse represents a list of commits with nested authors
+// and committers
+type SplitCommitListResponse struct {
+	CommitListResponse
+	Commits []SplitCommitResponse `json:"commits"`
+}
+
+// SplitCompareResponse represents a comparison whose commits have nested
+// authors and committers
+type SplitCompareResponse struct {
+	CompareResponse
+	Commits []SplitCommitResponse `json:"commits"`
+}
+
+// Split converts a commit to the nested shape
+func (c CommitResponse) Split() SplitCommitResponse {
+	return SplitCommitResponse{
+		Hash:      c.Hash,
+		ShortHash: c.ShortHash,
+		Message:   c.Message,
+		Author: CommitPersonResponse{
+			Name:  c.Author,
+			Email: c.AuthorEmail,
+			Date:  c.AuthorDate,
+		},
+		Committer: CommitPersonResponse{
+			Name:  c.Committer,
+			Email: c.CommitterEmail,
+			Date:  c.CommitterDate,
+		},
+		ParentHashes:   c.ParentHashes,
+		ResolvedAuthor: c.ResolvedAuthor,
+		Verification:   c.Verification,
+	}
+}
+
+// Split converts the commits of a list to the nested shape
+func (r CommitListResponse) Split() SplitCommitListResponse {
+	return SplitCommitListResponse{CommitListResponse: r, Commits: splitCommits(r.Commits)}
+}
+
+// Split converts the commits of a comparison to the nested shape
+func (r CompareResponse) Split() SplitCompareResponse {
+	return SplitCompareResponse{CompareResponse: r, Commits: splitCommits(r.Commits)}
+}
+
+// splitCommits converts commits to the nested shape
+func splitCommits(commits []CommitResponse) []SplitCommitResponse {
+	split := make([]SplitCommitResponse, len(commits))
+	for i, c := range commits {
+		split[i] = c.Split()
+	}
+	return split
+}
@@ -0,0 +1,82 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+)
+
+// CreateCommitStatusRequest is a status to add to a commit
+type CreateCommitStatusRequest struct {
+	State       string `json:"state" binding:"required"` // pending, success, failure or error
+	Context     string `json:"context,omitempty"`        // Check name, e.g. ci/build; default "default"
+	Description string `json:"description,omitempty"`
+	TargetURL   string `json:"target_url,omitempty"` // Absolute http(s) URL with the details
+}
+
+// ToModel converts the request to a status of a commit
+func (r *CreateCommitStatusRequest) ToModel(sha string) *models.CommitStatus {
+	return &models.CommitStatus{
+		SHA:         sha,
+		State:       r.State,
+		Context:     r.Context,
+		Description: r.Description,
+		TargetURL:   r.TargetURL,
+	}
+}
+
+// CommitStatusResponse represents a status of a commit
+type CommitStatusResponse struct {
+	ID          string    `json:"id"`
+	SHA         string    `json:"sha"`
+	State       string    `json:"state"`
+	Context     string    `json:"context"`
+	Description string    `json:"description,omitempty"`
+	TargetURL   string    `json:"target_url,omitempty"`
+	CreatorID   string    `json:"creator_id,omitempty"`
+	Creator     string    `json:"creator"` // Username, or "ci"
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ListCommitStatusesResponse represents a page of the statuses of a commit
+type ListCommitStatusesResponse struct {
+	Statuses   []CommitStatusResponse `json:"statuses"`
+	Pagination Pagination             `json:"pagination"`
+}
+
+// CombinedCommitStatusResponse represents the state of a commit across its
+// contexts, with a page of the latest status of each context
+type CombinedCommitStatusResponse struct {
+	SHA        string                 `json:"sha"`
+	State      string                 `json:"state"` // failure, pending or success
+	TotalCount int                    `json:"total_count"`
+	Statuses   []CommitStatusResponse `json:"statuses"`
+	Pagination Pagination             `json:"pagination"`
+}
+
+// CommitStatusFromModel converts a models.CommitStatus to CommitStatusResponse
+func CommitStatusFromModel(status *models.CommitStatus) CommitStatusResponse {
+	resp := CommitStatusResponse{
+		ID:          status.ID.String(),
+		SHA:         status.SHA,
+		State:       status.State,
+		Context:     status.Context,
+		Description: status.Description,
+		TargetURL:   status.TargetURL,
+		Creator:     status.Creator,
+		CreatedAt:   status.CreatedAt,
+	}
+	if status.CreatorID != nil {
+		resp.CreatorID = status.CreatorID.String()
+	}
+	return resp
+}
+
+// CommitStatusesFromModels converts statuses to their responses
+func CommitStatusesFromModels(statuses []*models.CommitStatus) []CommitStatusResponse {
+	responses := make([]CommitStatusResponse, 0, len(statuses))
+	for _, status := range statuses {
+		responses = append(responses, CommitStatusFromModel(status))
+	}
+	return responses
+}
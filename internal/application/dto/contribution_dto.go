@@ -0,0 +1,17 @@
+package dto
+
+// ContributionDayResponse represents the number of contributions on a single day (UTC)
+type ContributionDayResponse struct {
+	Date  string `json:"date"` // YYYY-MM-DD
+	Count int64  `json:"count"`
+}
+
+// ContributionCalendarResponse represents the contribution calendar of a user
+type ContributionCalendarResponse struct {
+	Username        string                    `json:"username"`
+	From            string                    `json:"from"`
+	To              string                    `json:"to"`
+	Total           int64                     `json:"total"`
+	IncludesPrivate bool                      `json:"includes_private"`
+	Days            []ContributionDayResponse `json:"days"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+// EventTypeMetricsResponse represents the delivery counters of an event type
+type EventTypeMetricsResponse struct {
+	Type      string `json:"type"`
+	Published uint64 `json:"published"`
+	Consumed  uint64 `json:"consumed"` // Deliveries handled by subscribers
+	Dropped   uint64 `json:"dropped"`  // Deliveries lost to full queues or failures
+}
+
+// EventMetricsResponse represents the event bus metrics
+type EventMetricsResponse struct {
+	Types []EventTypeMetricsResponse `json:"types"`
+}
@@ -0,0 +1,79 @@
+package dto
+
+import "encoding/xml"
+
+// JSONFeedVersion is the version URL of the JSON Feed format served
+const JSONFeedVersion = "https://jsonfeed.org/version/1.1"
+
+// AtomFeed represents an Atom feed (RFC 4287)
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"` // RFC 3339
+	Author  AtomPerson  `xml:"author"`
+	Links   []AtomLink  `xml:"link"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+// AtomPerson represents the author of an Atom feed or entry
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+// AtomLink represents a link of an Atom feed or entry
+type AtomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+// AtomEntry represents an entry of an Atom feed
+type AtomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`   // RFC 3339
+	Published string      `xml:"published"` // RFC 3339
+	Author    *AtomPerson `xml:"author,omitempty"`
+	Links     []AtomLink  `xml:"link"`
+	Content   AtomContent `xml:"content"`
+}
+
+// AtomContent represents the content of an Atom entry
+type AtomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// JSONFeed represents a JSON Feed
+type JSONFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url"`
+	FeedURL     string           `json:"feed_url"`
+	Authors     []JSONFeedAuthor `json:"authors"`
+	Items       []JSONFeedItem   `json:"items"`
+}
+
+// JSONFeedAuthor represents the author of a JSON Feed or item
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// JSONFeedItem represents an item of a JSON Feed
+type JSONFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	DatePublished string           `json:"date_published"` // RFC 3339
+	DateModified  string           `json:"date_modified"`  // RFC 3339
+	Authors       []JSONFeedAuthor `json:"authors,omitempty"`
+}
+
+// FeedTokenResponse represents the feed token of a user. Readers pass it as
+// the token query parameter of feed URLs to read the feeds of the private
+// repositories the user can read.
+type FeedTokenResponse struct {
+	Token string `json:"token"`
+}
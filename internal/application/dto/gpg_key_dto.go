@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+)
+
+// AddGPGKeyRequest represents a request to add a GPG key
+type AddGPGKeyRequest struct {
+	ArmoredPublicKey string `json:"armored_public_key" binding:"required,max=65536"`
+}
+
+// GPGKeyInfo represents GPG key information
+type GPGKeyInfo struct {
+	ID          uuid.UUID  `json:"id"`
+	KeyID       string     `json:"key_id"`
+	Fingerprint string     `json:"fingerprint"`
+	SubkeyIDs   []string   `json:"subkey_ids"`
+	Emails      []string   `json:"emails"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ListGPGKeysResponse represents a list of user GPG keys
+type ListGPGKeysResponse struct {
+	Keys  []GPGKeyInfo `json:"keys"`
+	Total int          `json:"total"`
+}
+
+// GPGKeyFromModel converts a models.GPGKey to GPGKeyInfo
+func GPGKeyFromModel(k *models.GPGKey) GPGKeyInfo {
+	subkeyIDs := k.SubkeyIDs
+	if subkeyIDs == nil {
+		subkeyIDs = []string{}
+	}
+	return GPGKeyInfo{
+		ID:          k.ID,
+		KeyID:       k.KeyID,
+		Fingerprint: k.Fingerprint,
+		SubkeyIDs:   subkeyIDs,
+		Emails:      k.Emails,
+		ExpiresAt:   k.ExpiresAt,
+		CreatedAt:   k.CreatedAt,
+	}
+}
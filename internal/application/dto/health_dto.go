@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// ReadinessResponse represents the startup state of the server
+type ReadinessResponse struct {
+	State        string                `json:"state"`                   // starting, degraded or ready
+	Reason       string                `json:"reason,omitempty"`        // Why the server is not ready
+	DegradedMode *DegradedModeResponse `json:"degraded_mode,omitempty"` // Set if degraded mode is enabled
+}
+
+// DegradedModeResponse represents the state of degraded mode, which serves
+// public repositories read-only while the database is unavailable
+type DegradedModeResponse struct {
+	Active               bool                `json:"active"`
+	Serving              bool                `json:"serving"` // Active with a snapshot fresh enough to serve from
+	Since                *time.Time          `json:"since,omitempty"`
+	SnapshotTakenAt      *time.Time          `json:"snapshot_taken_at,omitempty"`
+	SnapshotRepositories int                 `json:"snapshot_repositories"`
+	Metrics              DegradedModeMetrics `json:"metrics"`
+}
+
+// DegradedModeMetrics represents the activity of degraded mode since the
+// server started
+type DegradedModeMetrics struct {
+	Episodes         int64   `json:"episodes"`
+	DegradedSeconds  float64 `json:"degraded_seconds"`
+	ServedFetches    int64   `json:"served_fetches"`
+	RejectedRequests int64   `json:"rejected_requests"`
+}
@@ -0,0 +1,35 @@
+package dto
+
+import "time"
+
+// HousekeepingMetricsResponse counts the runs of a housekeeping task by the
+// replica serving the request since it started
+type HousekeepingMetricsResponse struct {
+	Runs            uint64 `json:"runs"`
+	Failures        uint64 `json:"failures"`
+	Skipped         uint64 `json:"skipped"` // Due runs left to another replica holding the task
+	TotalDurationMs int64  `json:"total_duration_ms"`
+}
+
+// HousekeepingTaskResponse represents a housekeeping task, its schedule and
+// its last run on any replica
+type HousekeepingTaskResponse struct {
+	Name           string                      `json:"name"`
+	Description    string                      `json:"description"`
+	IntervalSecs   int64                       `json:"interval_seconds"`
+	JitterSecs     int64                       `json:"jitter_seconds"`
+	NextRunAt      *time.Time                  `json:"next_run_at,omitempty"` // Unset until the task first runs
+	LastStartedAt  *time.Time                  `json:"last_started_at,omitempty"`
+	LastDurationMs int64                       `json:"last_duration_ms"`
+	LastOutcome    string                      `json:"last_outcome,omitempty"` // succeeded or failed
+	LastError      string                      `json:"last_error,omitempty"`
+	LastRunBy      string                      `json:"last_run_by,omitempty"` // Host name of the replica
+	RunCount       int64                       `json:"run_count"`
+	FailureCount   int64                       `json:"failure_count"`
+	Metrics        HousekeepingMetricsResponse `json:"metrics"`
+}
+
+// HousekeepingTaskListResponse lists the housekeeping tasks
+type HousekeepingTaskListResponse struct {
+	Tasks []HousekeepingTaskResponse `json:"tasks"`
+}
@@ -0,0 +1,112 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// CreateIssueRequest represents a request to open an issue
+type CreateIssueRequest struct {
+	Title  string   `json:"title" binding:"required"`
+	Body   string   `json:"body"` // Raw Markdown
+	Labels []string `json:"labels"`
+}
+
+// ToModel converts the request to a models.Issue
+func (r CreateIssueRequest) ToModel() *models.Issue {
+	return &models.Issue{
+		Title:  r.Title,
+		Body:   r.Body,
+		Labels: r.Labels,
+	}
+}
+
+// UpdateIssueRequest represents a request to update an issue; omitted fields are left unchanged
+type UpdateIssueRequest struct {
+	Title  *string   `json:"title,omitempty"`
+	Body   *string   `json:"body,omitempty"`
+	Labels *[]string `json:"labels,omitempty"` // Replaces all labels
+	State  *string   `json:"state,omitempty"`  // open or closed
+}
+
+// IssueResponse represents an issue of a repository
+type IssueResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	State     string     `json:"state"` // open, closed
+	Labels    []string   `json:"labels"`
+	AuthorID  *uuid.UUID `json:"author_id,omitempty"`
+	Author    string     `json:"author,omitempty"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// IssueListResponse represents a page of issues
+type IssueListResponse struct {
+	Issues     []IssueResponse `json:"issues"`
+	Pagination Pagination      `json:"pagination"`
+}
+
+// IssueFromModel converts a models.Issue to IssueResponse
+func IssueFromModel(issue *models.Issue) IssueResponse {
+	resp := IssueResponse{
+		ID:        issue.ID,
+		Number:    issue.Number,
+		Title:     issue.Title,
+		Body:      issue.Body,
+		State:     issue.State,
+		Labels:    issue.Labels,
+		AuthorID:  issue.AuthorID,
+		ClosedAt:  issue.ClosedAt,
+		CreatedAt: issue.CreatedAt,
+		UpdatedAt: issue.UpdatedAt,
+	}
+	if resp.Labels == nil {
+		resp.Labels = []string{}
+	}
+	if issue.Author != nil {
+		resp.Author = issue.Author.Username
+	}
+	return resp
+}
+
+// CreateIssueCommentRequest represents a request to comment on an issue
+type CreateIssueCommentRequest struct {
+	Body string `json:"body" binding:"required"` // Raw Markdown
+}
+
+// IssueCommentResponse represents a comment on an issue
+type IssueCommentResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Body      string     `json:"body"`
+	AuthorID  *uuid.UUID `json:"author_id,omitempty"`
+	Author    string     `json:"author,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// IssueCommentListResponse represents a page of comments on an issue
+type IssueCommentListResponse struct {
+	Comments   []IssueCommentResponse `json:"comments"`
+	Pagination Pagination             `json:"pagination"`
+}
+
+// IssueCommentFromModel converts a models.IssueComment to IssueCommentResponse
+func IssueCommentFromModel(comment *models.IssueComment) IssueCommentResponse {
+	resp := IssueCommentResponse{
+		ID:        comment.ID,
+		Body:      comment.Body,
+		AuthorID:  comment.AuthorID,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+	}
+	if comment.Author != nil {
+		resp.Author = comment.Author.Username
+	}
+	return resp
+}
@@ -0,0 +1,55 @@
+package dto
+
+// LFSBatchRequest represents a Git LFS batch API request
+type LFSBatchRequest struct {
+	Operation string             `json:"operation"`           // upload, download
+	Transfers []string           `json:"transfers,omitempty"` // Transfer adapters the client supports; only basic is offered
+	Ref       *LFSRef            `json:"ref,omitempty"`
+	Objects   []LFSObjectRequest `json:"objects"`
+	HashAlgo  string             `json:"hash_algo,omitempty"` // Only sha256 is supported
+}
+
+// LFSRef is the ref a Git LFS batch request is made for
+type LFSRef struct {
+	Name string `json:"name"`
+}
+
+// LFSObjectRequest identifies a Git LFS object
+type LFSObjectRequest struct {
+	OID  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+// LFSBatchResponse represents a Git LFS batch API response
+type LFSBatchResponse struct {
+	Transfer string              `json:"transfer"`
+	Objects  []LFSObjectResponse `json:"objects"`
+	HashAlgo string              `json:"hash_algo"`
+}
+
+// LFSObjectResponse tells the client what to do with a Git LFS object.
+// Uploads of objects the server already has come without actions.
+type LFSObjectResponse struct {
+	OID           string               `json:"oid"`
+	Size          int64                `json:"size"`
+	Authenticated bool                 `json:"authenticated,omitempty"`
+	Actions       map[string]LFSAction `json:"actions,omitempty"` // upload, verify, download
+	Error         *LFSObjectError      `json:"error,omitempty"`
+}
+
+// LFSAction is a request the client makes to transfer a Git LFS object
+type LFSAction struct {
+	Href   string            `json:"href"`
+	Header map[string]string `json:"header,omitempty"`
+}
+
+// LFSObjectError explains why a Git LFS object cannot be transferred
+type LFSObjectError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// LFSErrorResponse represents a Git LFS API error
+type LFSErrorResponse struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,13 @@
+package dto
+
+// LicenseCountResponse is the number of repositories with a license
+type LicenseCountResponse struct {
+	License string `json:"license"` // SPDX identifier, or "none" for repositories without a license
+	Count   int64  `json:"count"`
+}
+
+// LicenseCountListResponse lists the number of repositories per license across the instance
+type LicenseCountListResponse struct {
+	Licenses []LicenseCountResponse `json:"licenses"`
+	Total    int64                  `json:"total"` // Number of repositories
+}
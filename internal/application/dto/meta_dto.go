@@ -0,0 +1,31 @@
+package dto
+
+// SSHHostKeyResponse represents a public host key of the SSH server
+type SSHHostKeyResponse struct {
+	Type        string `json:"type"`        // Key algorithm, e.g. ssh-ed25519
+	PublicKey   string `json:"public_key"`  // "<type> <base64>", as in known_hosts
+	Fingerprint string `json:"fingerprint"` // SHA256 fingerprint, e.g. SHA256:...
+}
+
+// SSHHostKeysResponse represents the host keys of the SSH server and where it listens
+type SSHHostKeysResponse struct {
+	Enabled  bool                 `json:"enabled"`
+	Port     int                  `json:"port"`
+	HostKeys []SSHHostKeyResponse `json:"host_keys"`
+}
+
+// MetaResponse represents public information about the server
+type MetaResponse struct {
+	SSH SSHHostKeysResponse `json:"ssh"`
+}
+
+// SigningKeyResponse represents the instance key the server signs the commits
+// it creates with
+type SigningKeyResponse struct {
+	Enabled        bool   `json:"enabled"`
+	Format         string `json:"format,omitempty"`     // gpg or ssh
+	PublicKey      string `json:"public_key,omitempty"` // ASCII-armored (gpg) or authorized_keys format (ssh)
+	KeyID          string `json:"key_id,omitempty"`     // GPG long key ID or SSH key fingerprint
+	CommitterName  string `json:"committer_name,omitempty"`
+	CommitterEmail string `json:"committer_email,omitempty"` // Committer email of the signed commits
+}
@@ -0,0 +1,87 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// NotificationResponse represents a notification of the current user
+type NotificationResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	Type          string     `json:"type"` // mention, ci_job_failed, pull_request_merged, pull_request_closed
+	Title         string     `json:"title"`
+	RepositoryID  *uuid.UUID `json:"repository_id,omitempty"`
+	Repository    string     `json:"repository,omitempty"` // owner/name
+	IssueID       *uuid.UUID `json:"issue_id,omitempty"`
+	PullRequestID *uuid.UUID `json:"pull_request_id,omitempty"`
+	CIJobID       *uuid.UUID `json:"ci_job_id,omitempty"`
+	Number        int        `json:"number,omitempty"` // Number of the issue or pull request
+	Actor         string     `json:"actor,omitempty"`
+	Read          bool       `json:"read"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// NotificationListResponse represents a page of notifications
+type NotificationListResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	Pagination    Pagination             `json:"pagination"`
+}
+
+// NotificationCountResponse represents the number of unread notifications
+type NotificationCountResponse struct {
+	Unread int64 `json:"unread"`
+}
+
+// NotificationsMarkedResponse represents the result of marking all notifications read
+type NotificationsMarkedResponse struct {
+	Marked int64 `json:"marked"` // Notifications that were unread
+}
+
+// NotificationFromModel converts a models.Notification to NotificationResponse
+func NotificationFromModel(n *models.Notification) NotificationResponse {
+	resp := NotificationResponse{
+		ID:            n.ID,
+		Type:          n.Type,
+		Title:         n.Title,
+		RepositoryID:  n.RepositoryID,
+		IssueID:       n.IssueID,
+		PullRequestID: n.PullRequestID,
+		CIJobID:       n.CIJobID,
+		Number:        n.Number,
+		Read:          n.Read,
+		CreatedAt:     n.CreatedAt,
+	}
+	if n.Repository != nil {
+		resp.Repository = n.Repository.GetFullName()
+	}
+	if n.Actor != nil {
+		resp.Actor = n.Actor.Username
+	}
+	return resp
+}
+
+// NotificationPreferencesResponse represents the email notification preferences of the current user
+type NotificationPreferencesResponse struct {
+	EmailCIFailures    bool `json:"email_ci_failures"`   // CI jobs failing on the default branch
+	EmailCollaborators bool `json:"email_collaborators"` // Being added as a collaborator
+	EmailTransfers     bool `json:"email_transfers"`     // Repositories transferred to the user
+}
+
+// UpdateNotificationPreferencesRequest represents a request to change
+// notification preferences; omitted fields are left unchanged
+type UpdateNotificationPreferencesRequest struct {
+	EmailCIFailures    *bool `json:"email_ci_failures,omitempty"`
+	EmailCollaborators *bool `json:"email_collaborators,omitempty"`
+	EmailTransfers     *bool `json:"email_transfers,omitempty"`
+}
+
+// NotificationPreferencesFromModel converts a models.NotificationPreference to NotificationPreferencesResponse
+func NotificationPreferencesFromModel(p *models.NotificationPreference) NotificationPreferencesResponse {
+	return NotificationPreferencesResponse{
+		EmailCIFailures:    p.EmailCIFailures,
+		EmailCollaborators: p.EmailCollaborators,
+		EmailTransfers:     p.EmailTransfers,
+	}
+}
@@ -0,0 +1,11 @@
+package dto
+
+import "time"
+
+// OnboardingResponse represents the onboarding state of the current user
+type OnboardingResponse struct {
+	Enabled     bool          `json:"enabled"`
+	Onboarded   bool          `json:"onboarded"`
+	OnboardedAt *time.Time    `json:"onboarded_at,omitempty"` // When the user was onboarded or skipped it
+	Repository  *RepoResponse `json:"repository,omitempty"`   // The playground repository, if it exists
+}
@@ -0,0 +1,12 @@
+package dto
+
+// Pagination represents pagination info: the page of items returned by a
+// list endpoint
+type Pagination struct {
+	Page    int    `json:"page"`
+	PerPage int    `json:"per_page"`
+	Limit   int    `json:"limit"` // Same as per_page, for limit/offset clients
+	Offset  int    `json:"offset"`
+	Total   *int64 `json:"total,omitempty"` // Only where counting is cheap
+	HasMore bool   `json:"has_more"`
+}
@@ -0,0 +1,56 @@
+package dto
+
+import "github.com/bravo68web/stasis/internal/domain/models"
+
+// PinnedLinkRequest represents a pinned link: a path within the repository or an external URL
+type PinnedLinkRequest struct {
+	Title string `json:"title"`
+	Path  string `json:"path,omitempty"` // File or directory on the default branch
+	URL   string `json:"url,omitempty"`  // Absolute http(s) URL
+}
+
+// UpdatePinnedLinksRequest represents a request to replace the pinned links of a repository
+type UpdatePinnedLinksRequest struct {
+	Links []PinnedLinkRequest `json:"links"` // In display order
+}
+
+// PinnedLinkResponse represents a pinned link
+type PinnedLinkResponse struct {
+	Title  string `json:"title"`
+	Path   string `json:"path,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Broken bool   `json:"broken"` // Path no longer exists on the default branch
+}
+
+// PinnedLinksResponse represents the pinned links of a repository
+type PinnedLinksResponse struct {
+	Links []PinnedLinkResponse `json:"links"`
+}
+
+// PinnedLinksFromModel converts the pinned links of a repository to PinnedLinksResponse
+func PinnedLinksFromModel(repo *models.Repository) PinnedLinksResponse {
+	return PinnedLinksResponse{Links: pinnedLinkResponses(repo.PinnedLinks)}
+}
+
+// ToModel converts the request to the pinned links it sets
+func (r UpdatePinnedLinksRequest) ToModel() []models.PinnedLink {
+	links := make([]models.PinnedLink, len(r.Links))
+	for i, link := range r.Links {
+		links[i] = models.PinnedLink{Title: link.Title, Path: link.Path, URL: link.URL}
+	}
+	return links
+}
+
+// pinnedLinkResponses converts pinned links to responses, in order
+func pinnedLinkResponses(links []models.PinnedLink) []PinnedLinkResponse {
+	responses := make([]PinnedLinkResponse, len(links))
+	for i, link := range links {
+		responses[i] = PinnedLinkResponse{
+			Title:  link.Title,
+			Path:   link.Path,
+			URL:    link.URL,
+			Broken: link.Broken,
+		}
+	}
+	return responses
+}
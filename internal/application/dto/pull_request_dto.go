@@ -0,0 +1,82 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// CreatePullRequestRequest represents a request to open a pull request
+type CreatePullRequestRequest struct {
+	Title        string `json:"title" binding:"required"`
+	Body         string `json:"body"`
+	SourceBranch string `json:"source_branch" binding:"required"` // Branch with the changes
+	TargetBranch string `json:"target_branch" binding:"required"` // Branch to merge the changes into
+}
+
+// ToModel converts the request to a models.PullRequest
+func (r CreatePullRequestRequest) ToModel() *models.PullRequest {
+	return &models.PullRequest{
+		Title:        r.Title,
+		Body:         r.Body,
+		SourceBranch: r.SourceBranch,
+		TargetBranch: r.TargetBranch,
+	}
+}
+
+// PullRequestResponse represents a pull request of a repository
+type PullRequestResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	Number          int        `json:"number"`
+	Title           string     `json:"title"`
+	Body            string     `json:"body"`
+	SourceBranch    string     `json:"source_branch"`
+	TargetBranch    string     `json:"target_branch"`
+	State           string     `json:"state"` // open, closed, merged
+	AuthorID        *uuid.UUID `json:"author_id,omitempty"`
+	Author          string     `json:"author,omitempty"`
+	MergedCommitSHA string     `json:"merged_commit_sha,omitempty"`
+	MergedByID      *uuid.UUID `json:"merged_by_id,omitempty"`
+	MergedBy        string     `json:"merged_by,omitempty"`
+	MergedAt        *time.Time `json:"merged_at,omitempty"`
+	ClosedAt        *time.Time `json:"closed_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// PullRequestListResponse represents a page of pull requests
+type PullRequestListResponse struct {
+	PullRequests []PullRequestResponse `json:"pull_requests"`
+	Page         int                   `json:"page"`     // Deprecated: use pagination
+	PerPage      int                   `json:"per_page"` // Deprecated: use pagination
+	Total        int64                 `json:"total"`    // Deprecated: use pagination
+	Pagination   Pagination            `json:"pagination"`
+}
+
+// PullRequestFromModel converts a models.PullRequest to PullRequestResponse
+func PullRequestFromModel(pr *models.PullRequest) PullRequestResponse {
+	resp := PullRequestResponse{
+		ID:              pr.ID,
+		Number:          pr.Number,
+		Title:           pr.Title,
+		Body:            pr.Body,
+		SourceBranch:    pr.SourceBranch,
+		TargetBranch:    pr.TargetBranch,
+		State:           pr.State,
+		AuthorID:        pr.AuthorID,
+		MergedCommitSHA: pr.MergedCommitSHA,
+		MergedByID:      pr.MergedByID,
+		MergedAt:        pr.MergedAt,
+		ClosedAt:        pr.ClosedAt,
+		CreatedAt:       pr.CreatedAt,
+		UpdatedAt:       pr.UpdatedAt,
+	}
+	if pr.Author != nil {
+		resp.Author = pr.Author.Username
+	}
+	if pr.MergedBy != nil {
+		resp.MergedBy = pr.MergedBy.Username
+	}
+	return resp
+}
@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+)
+
+// PushAttemptResponse represents a recorded push to a repository
+type PushAttemptResponse struct {
+	ID              string             `json:"id"`
+	PusherID        string             `json:"pusher_id,omitempty"`
+	Pusher          string             `json:"pusher"`
+	Transport       string             `json:"transport"` // http, ssh
+	Outcome         string             `json:"outcome"`   // accepted, rejected
+	Refs            []models.PushedRef `json:"refs"`
+	Error           string             `json:"error,omitempty"`
+	Output          string             `json:"output"`
+	OutputTruncated bool               `json:"output_truncated"`
+	StartedAt       time.Time          `json:"started_at"`
+	FinishedAt      time.Time          `json:"finished_at"`
+}
+
+// ListPushAttemptsResponse represents a page of push attempts
+type ListPushAttemptsResponse struct {
+	PushAttempts []PushAttemptResponse `json:"push_attempts"`
+	Page         int                   `json:"page"`     // Deprecated: use pagination
+	PerPage      int                   `json:"per_page"` // Deprecated: use pagination
+	Total        int                   `json:"total"`    // Deprecated: items on this page
+	Pagination   Pagination            `json:"pagination"`
+}
+
+// PushAttemptFromModel converts a models.PushAttempt to PushAttemptResponse
+func PushAttemptFromModel(attempt *models.PushAttempt) PushAttemptResponse {
+	resp := PushAttemptResponse{
+		ID:              attempt.ID.String(),
+		Pusher:          attempt.Pusher,
+		Transport:       attempt.Transport,
+		Outcome:         attempt.Outcome,
+		Refs:            attempt.Refs,
+		Error:           attempt.Error,
+		Output:          attempt.Output,
+		OutputTruncated: attempt.OutputTruncated,
+		StartedAt:       attempt.StartedAt,
+		FinishedAt:      attempt.FinishedAt,
+	}
+	if attempt.PusherID != nil {
+		resp.PusherID = attempt.PusherID.String()
+	}
+	if resp.Refs == nil {
+		resp.Refs = []models.PushedRef{}
+	}
+	return resp
+}
@@ -0,0 +1,91 @@
+package dto
+
+import (
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/service"
+)
+
+// PushPolicyRequest represents the rules of a push policy. Rules left out or
+// zero are not enforced; a request without rules removes the policy.
+type PushPolicyRequest struct {
+	MaxFileSize          int64    `json:"max_file_size"`          // Largest file a pushed commit may add or change, in bytes
+	BlockedPaths         []string `json:"blocked_paths"`          // Globs: without a slash they match any file or directory name (*.pem), with one the path from the root (config/secrets)
+	CommitMessagePattern string   `json:"commit_message_pattern"` // Regular expression (RE2) the message of every pushed commit must match
+	DenyNonFastForward   bool     `json:"deny_non_fast_forward"`  // Refuse force pushes to branches
+}
+
+// ToModel converts the request to a models.PushPolicy
+func (r *PushPolicyRequest) ToModel() *models.PushPolicy {
+	return &models.PushPolicy{
+		MaxFileSize:          r.MaxFileSize,
+		BlockedPaths:         r.BlockedPaths,
+		CommitMessagePattern: r.CommitMessagePattern,
+		DenyNonFastForward:   r.DenyNonFastForward,
+	}
+}
+
+// PushPolicyResponse represents the push policy of a repository
+type PushPolicyResponse struct {
+	Enabled              bool     `json:"enabled"` // The repository has a policy with at least one rule
+	MaxFileSize          int64    `json:"max_file_size"`
+	BlockedPaths         []string `json:"blocked_paths"`
+	CommitMessagePattern string   `json:"commit_message_pattern"`
+	DenyNonFastForward   bool     `json:"deny_non_fast_forward"`
+}
+
+// PushPolicyFromModel converts the push policy of a repository to PushPolicyResponse
+func PushPolicyFromModel(repo *models.Repository) PushPolicyResponse {
+	resp := PushPolicyResponse{BlockedPaths: []string{}}
+	if policy := repo.PushPolicy; !policy.IsZero() {
+		resp.Enabled = true
+		resp.MaxFileSize = policy.MaxFileSize
+		resp.CommitMessagePattern = policy.CommitMessagePattern
+		resp.DenyNonFastForward = policy.DenyNonFastForward
+		if policy.BlockedPaths != nil {
+			resp.BlockedPaths = policy.BlockedPaths
+		}
+	}
+	return resp
+}
+
+// CheckPushPolicyRequest represents a dry run of a push policy against a
+// push of head to ref over base
+type CheckPushPolicyRequest struct {
+	Ref    string             `json:"ref"`                     // Branch or full ref name the push would update; decides whether deny_non_fast_forward applies
+	Base   string             `json:"base"`                    // Current tip of the ref; empty for a new ref, checking every commit reachable from head
+	Head   string             `json:"head" binding:"required"` // Revision pushed
+	Policy *PushPolicyRequest `json:"policy,omitempty"`        // Policy to evaluate instead of the saved one
+}
+
+// PushPolicyViolationResponse represents a pushed commit breaking a rule
+type PushPolicyViolationResponse struct {
+	Ref     string `json:"ref,omitempty"`
+	Commit  string `json:"commit"`
+	Rule    string `json:"rule"` // max_file_size, blocked_path, commit_message_pattern or deny_non_fast_forward
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// CheckPushPolicyResponse represents the result of a dry run of a push policy
+type CheckPushPolicyResponse struct {
+	Allowed    bool                          `json:"allowed"`
+	Violations []PushPolicyViolationResponse `json:"violations"` // At most 100
+}
+
+// CheckPushPolicyFromService converts the violations of a dry run to CheckPushPolicyResponse
+func CheckPushPolicyFromService(violations []service.PushPolicyViolation) CheckPushPolicyResponse {
+	resp := CheckPushPolicyResponse{
+		Allowed:    len(violations) == 0,
+		Violations: make([]PushPolicyViolationResponse, len(violations)),
+	}
+	for i, v := range violations {
+		resp.Violations[i] = PushPolicyViolationResponse{
+			Ref:     v.RefName,
+			Commit:  v.Commit,
+			Rule:    v.Rule,
+			Path:    v.Path,
+			Message: v.Message,
+		}
+	}
+	return resp
+}
@@ -0,0 +1,15 @@
+package dto
+
+// UpdateRepoQuotaRequest sets the size quota override of a repository
+type UpdateRepoQuotaRequest struct {
+	QuotaBytes *int64 `json:"quota_bytes"` // Limit in bytes; 0 lifts the limit, null restores the configured quota
+}
+
+// RepoQuotaResponse describes the disk usage of a repository against its quota
+type RepoQuotaResponse struct {
+	ID         string `json:"id"`
+	FullName   string `json:"full_name"`
+	SizeBytes  int64  `json:"size_bytes"`  // Disk usage measured after the last push
+	QuotaBytes int64  `json:"quota_bytes"` // Effective limit (0 = unlimited)
+	Override   bool   `json:"override"`    // The limit was set by an administrator
+}
@@ -0,0 +1,87 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+)
+
+// RepoBulkRequest is an administrative operation on many repositories, named
+// in repositories or selected by filter
+type RepoBulkRequest struct {
+	Operation    string          `json:"operation" binding:"required"` // set_visibility, transfer or delete
+	IsPrivate    *bool           `json:"is_private,omitempty"`         // Visibility to set, for set_visibility
+	NewOwner     string          `json:"new_owner,omitempty"`          // Username to transfer to, for transfer
+	Repositories []string        `json:"repositories,omitempty"`       // owner/name of each repository
+	Filter       *RepoBulkFilter `json:"filter,omitempty"`
+	DryRun       bool            `json:"dry_run"` // Only list the repositories the operation would apply to
+}
+
+// RepoBulkFilter selects the repositories of an owner
+type RepoBulkFilter struct {
+	Owner           string  `json:"owner"`
+	AnnotationKey   string  `json:"annotation_key,omitempty"`   // Only repositories with this annotation...
+	AnnotationValue string  `json:"annotation_value,omitempty"` // ...with this value
+	License         *string `json:"license,omitempty"`          // Only repositories with this license; "" for unlicensed ones
+}
+
+// RepoBulkPreviewResponse lists the repositories a dry-run bulk operation would apply to
+type RepoBulkPreviewResponse struct {
+	Operation    string   `json:"operation"`
+	Repositories []string `json:"repositories"`
+	NotFound     []string `json:"not_found"`
+}
+
+// RepoBulkResultResponse is the outcome of a bulk operation for one repository
+type RepoBulkResultResponse struct {
+	RepositoryID string `json:"repository_id,omitempty"`
+	FullName     string `json:"full_name"`
+	Outcome      string `json:"outcome"` // succeeded or failed
+	Error        string `json:"error,omitempty"`
+}
+
+// RepoBulkTaskResponse represents a bulk operation and the results so far
+type RepoBulkTaskResponse struct {
+	ID          string                   `json:"id"`
+	Operation   string                   `json:"operation"`
+	Status      string                   `json:"status"` // pending, running or completed
+	RequestedBy string                   `json:"requested_by"`
+	Total       int                      `json:"total"`
+	Succeeded   int                      `json:"succeeded"`
+	Failed      int                      `json:"failed"`
+	Results     []RepoBulkResultResponse `json:"results"`
+	CreatedAt   time.Time                `json:"created_at"`
+	CompletedAt *time.Time               `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time               `json:"expires_at,omitempty"`
+}
+
+// RepoBulkTaskFromModel converts a bulk task to its response
+func RepoBulkTaskFromModel(task *models.RepoBulkTask) RepoBulkTaskResponse {
+	resp := RepoBulkTaskResponse{
+		ID:          task.ID.String(),
+		Operation:   task.Operation,
+		Status:      task.Status,
+		RequestedBy: task.RequestedBy,
+		Total:       len(task.Targets),
+		Results:     make([]RepoBulkResultResponse, 0, len(task.Results)),
+		CreatedAt:   task.CreatedAt,
+		CompletedAt: task.CompletedAt,
+		ExpiresAt:   task.ExpiresAt,
+	}
+	for _, result := range task.Results {
+		out := RepoBulkResultResponse{FullName: result.FullName, Outcome: result.Outcome, Error: result.Error}
+		if result.RepositoryID != nil {
+			out.RepositoryID = result.RepositoryID.String()
+		} else {
+			// Named repositories that were not found are not targets
+			resp.Total++
+		}
+		if result.Outcome == models.RepoBulkResultFailed {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+		resp.Results = append(resp.Results, out)
+	}
+	return resp
+}
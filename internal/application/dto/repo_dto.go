@@ -1,7 +1,12 @@
 package dto
 
 import (
+	"cmp"
 	"encoding/base64"
+	"math"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/bravo68web/stasis/internal/domain/models"
@@ -18,45 +23,90 @@ type CreateRepoRequest struct {
 
 // UpdateRepoRequest represents a request to update a repository
 type UpdateRepoRequest struct {
+	Name          *string `json:"name,omitempty"` // Renames the repository; the former name is redirected for repos.rename_redirect_days
 	Description   *string `json:"description,omitempty"`
 	IsPrivate     *bool   `json:"is_private,omitempty"`
 	DefaultBranch *string `json:"default_branch,omitempty"`
+	License       *string `json:"license,omitempty"` // SPDX identifier overriding the detected license, "none" for no license, "" to use the detected license
+
+	LargeFileHintsDisabled *bool `json:"large_file_hints_disabled,omitempty"` // Stop warning pushers about large files not stored with Git LFS
 }
 
-// ImportRepoRequest represents a request to import a repository from an external Git source
+// ImportRepoRequest represents a request to import a repository from an
+// external Git source. The credentials are used for the clone only, unless
+// the repository is a mirror, which needs them to keep syncing.
 type ImportRepoRequest struct {
 	Name        string `json:"name" binding:"required,min=1,max=100"`
 	Description string `json:"description" binding:"max=500"`
 	IsPrivate   bool   `json:"is_private"`
-	CloneURL    string `json:"clone_url" binding:"required,url"`
-	Username    string `json:"username,omitempty"` // Optional: for authentication
-	Password    string `json:"password,omitempty"` // Optional: for authentication (can be personal access token)
-	Mirror      bool   `json:"mirror"`             // If true, creates a mirror repository
+	CloneURL    string `json:"clone_url" binding:"required,url"` // http, https, git or ssh URL without credentials
+	Username    string `json:"username,omitempty"`               // Optional: for authentication
+	Password    string `json:"password,omitempty"`               // Optional: for authentication (can be personal access token)
+	Mirror      bool   `json:"mirror"`                           // If true, creates a mirror repository
+}
+
+// ImportArchiveRequest represents the form fields of a multipart request
+// creating a repository from an uploaded archive, sent before the archive
+type ImportArchiveRequest struct {
+	Name        string `form:"name"`
+	Description string `form:"description"`
+	IsPrivate   bool   `form:"is_private"`
+}
+
+// ForkRepoRequest represents a request to fork a repository into the namespace of the caller
+type ForkRepoRequest struct {
+	Name string `json:"name,omitempty" binding:"max=100"` // Optional: defaults to the name of the source repository
+}
+
+// TransferRepoRequest represents a request to transfer a repository to another user
+type TransferRepoRequest struct {
+	NewOwner string `json:"new_owner" binding:"required"` // Username of the new owner
 }
 
 // RepoResponse represents the response for repository data
 type RepoResponse struct {
-	ID              uuid.UUID  `json:"id"`
-	Name            string     `json:"name"`
-	Owner           string     `json:"owner"`
-	OwnerID         uuid.UUID  `json:"owner_id"`
-	IsPrivate       bool       `json:"is_private"`
-	Description     string     `json:"description"`
-	DefaultBranch   string     `json:"default_branch"`
-	CloneURL        string     `json:"clone_url"`
-	SSHURL          string     `json:"ssh_url"`
-	GitPath         string     `json:"git_path,omitempty"`
-	MirrorEnabled   bool       `json:"mirror_enabled"`
-	MirrorDirection string     `json:"mirror_direction,omitempty"`
-	UpstreamURL     string     `json:"upstream_url,omitempty"`
-	DownstreamURL   string     `json:"downstream_url,omitempty"`
-	SyncInterval    int        `json:"sync_interval"`
-	SyncSchedule    string     `json:"sync_schedule,omitempty"`
-	LastSyncedAt    *time.Time `json:"last_synced_at,omitempty"`
-	NextSyncAt      *time.Time `json:"next_sync_at,omitempty"`
-	SyncStatus      string     `json:"sync_status,omitempty"`
-	CreatedAt       time.Time  `json:"created_at"`
-	UpdatedAt       time.Time  `json:"updated_at"`
+	ID              uuid.UUID            `json:"id"`
+	Name            string               `json:"name"`
+	Owner           string               `json:"owner"`
+	OwnerID         uuid.UUID            `json:"owner_id"`
+	IsPrivate       bool                 `json:"is_private"`
+	Description     string               `json:"description"`
+	DefaultBranch   string               `json:"default_branch"`
+	CloneURL        string               `json:"clone_url"`
+	SSHURL          string               `json:"ssh_url"`
+	GitPath         string               `json:"git_path,omitempty"`
+	MirrorEnabled   bool                 `json:"mirror_enabled"`
+	MirrorDirection string               `json:"mirror_direction,omitempty"`
+	UpstreamURL     string               `json:"upstream_url,omitempty"`
+	DownstreamURL   string               `json:"downstream_url,omitempty"`
+	SyncInterval    int                  `json:"sync_interval"`
+	SyncSchedule    string               `json:"sync_schedule,omitempty"`
+	LastSyncedAt    *time.Time           `json:"last_synced_at,omitempty"`
+	NextSyncAt      *time.Time           `json:"next_sync_at,omitempty"`
+	SyncStatus      string               `json:"sync_status,omitempty"`
+	License         string               `json:"license,omitempty"`          // Effective SPDX identifier
+	DetectedLicense string               `json:"detected_license,omitempty"` // SPDX identifier detected from the license file
+	LicenseOverride string               `json:"license_override,omitempty"` // SPDX identifier set by an owner, or "none"
+	Annotations     map[string]string    `json:"annotations,omitempty"`      // Listed annotation keys only
+	PinnedLinks     []PinnedLinkResponse `json:"pinned_links,omitempty"`     // Quick links, in display order
+	Topics          []string             `json:"topics"`                     // Normalized topics
+	PrimaryLanguage string               `json:"primary_language,omitempty"` // Language with the most bytes on the default branch, once computed
+	SizeBytes       int64                `json:"size_bytes"`                 // Disk usage measured after the last push
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+
+	LargeFileHintsDisabled bool `json:"large_file_hints_disabled"` // Pushes get no warnings about large files not stored with Git LFS
+
+	Parent *RepoParentResponse `json:"parent,omitempty"` // Repository this one was forked from, if loaded
+
+	IsEmpty *bool `json:"is_empty,omitempty"` // The repository has no branches or tags yet; set for a single repository only
+}
+
+// RepoParentResponse identifies the repository a fork was created from
+type RepoParentResponse struct {
+	ID    uuid.UUID `json:"id"`
+	Owner string    `json:"owner"`
+	Name  string    `json:"name"`
 }
 
 // RepoListResponse represents a paginated list of repositories
@@ -68,6 +118,17 @@ type RepoListResponse struct {
 	TotalPages   int            `json:"total_pages"`
 }
 
+// RepoPageResponse represents a page of a paginated listing of repositories:
+// public repositories, search results and forks
+type RepoPageResponse struct {
+	Repositories []RepoResponse `json:"repositories"`
+	Query        string         `json:"query,omitempty"` // Search query, for search results
+	Page         int            `json:"page"`            // Same as pagination.page
+	PerPage      int            `json:"per_page"`        // Same as pagination.per_page
+	Total        int            `json:"total"`           // Repositories on this page
+	Pagination   Pagination     `json:"pagination"`
+}
+
 // BranchRequest represents a request to create a new branch
 type BranchRequest struct {
 	Name       string `json:"name" binding:"required,min=1,max=255"`
@@ -96,11 +157,12 @@ type TagRequest struct {
 
 // TagResponse represents the response for tag data
 type TagResponse struct {
-	Name        string `json:"name"`
-	Hash        string `json:"hash"`
-	Message     string `json:"message,omitempty"`
-	Tagger      string `json:"tagger,omitempty"`
-	IsAnnotated bool   `json:"is_annotated"`
+	Name        string    `json:"name"`
+	Hash        string    `json:"hash"`
+	Message     string    `json:"message,omitempty"`
+	Tagger      string    `json:"tagger,omitempty"`
+	Date        time.Time `json:"date"`
+	IsAnnotated bool      `json:"is_annotated"`
 }
 
 // TagListResponse represents a list of tags
@@ -121,13 +183,33 @@ type CommitResponse struct {
 	CommitterEmail string    `json:"committer_email"`
 	CommitterDate  time.Time `json:"committer_date"`
 	ParentHashes   []string  `json:"parent_hashes"`
+
+	// ResolvedAuthor is the author after .mailmap and author mapping resolution
+	ResolvedAuthor *CommitAuthorResponse `json:"resolved_author,omitempty"`
+
+	// Verification is the outcome of checking the signature of the commit
+	Verification *CommitVerificationResponse `json:"verification,omitempty"`
+}
+
+// CommitVerificationResponse is the signature verification of a commit
+// against the GPG and SSH keys of the user with its author email, or against
+// the instance signing key for the commits created by the server
+type CommitVerificationResponse struct {
+	Verified bool   `json:"verified"`
+	Signed   bool   `json:"signed"`
+	Reason   string `json:"reason"`             // unsigned, unknown_key, bad_signature or valid
+	Format   string `json:"format,omitempty"`   // gpg or ssh
+	KeyID    string `json:"key_id,omitempty"`   // GPG key ID or SSH key fingerprint of the signature
+	Signer   string `json:"signer,omitempty"`   // Username of the owner of the key of a valid signature
+	Instance bool   `json:"instance,omitempty"` // Validly signed by the instance signing key of the server
 }
 
 // CommitListResponse represents a list of commits
 type CommitListResponse struct {
-	Commits []CommitResponse `json:"commits"`
-	Total   int              `json:"total"`
-	Ref     string           `json:"ref"`
+	Commits    []CommitResponse `json:"commits"`
+	Total      int              `json:"total"` // Deprecated: commits on this page
+	Ref        string           `json:"ref"`
+	Pagination Pagination       `json:"pagination"`
 }
 
 // TreeEntryResponse represents a tree entry (file or directory) in API responses
@@ -158,6 +240,14 @@ type FileContentResponse struct {
 	IsBinary bool   `json:"is_binary"`
 	Encoding string `json:"encoding"` // "utf-8" or "base64"
 	Ref      string `json:"ref"`
+
+	// Truncated is set for files above repos.max_inline_file_size, returned
+	// without content; RawURL streams them
+	Truncated bool   `json:"truncated"`
+	RawURL    string `json:"raw_url"` // API path of the raw file endpoint serving the file
+
+	// Highlight is set when highlighting was requested (?highlight=true)
+	Highlight *HighlightResponse `json:"highlight,omitempty"`
 }
 
 // CommitFromService converts a service.Commit to CommitResponse DTO
@@ -263,10 +353,22 @@ func BlameFromService(lines []service.BlameLine, path, ref string) BlameResponse
 type DiffResponse struct {
 	CommitHash   string         `json:"commit_hash"`
 	Content      string         `json:"content"`
+	Truncated    bool           `json:"truncated"` // True if content was cut at the diff size limit
 	FilesChanged int            `json:"files_changed"`
 	Additions    int            `json:"additions"`
 	Deletions    int            `json:"deletions"`
 	Files        []DiffFileInfo `json:"files,omitempty"`
+	MergeBase    string         `json:"merge_base,omitempty"`    // Set for <base>...<head> comparisons
+	NoMergeBase  bool           `json:"no_merge_base,omitempty"` // True if base and head share no history
+}
+
+// CompareResponse represents a comparison of two revisions in API responses
+type CompareResponse struct {
+	DiffResponse
+	Base         string           `json:"base"`
+	Head         string           `json:"head"`
+	Commits      []CommitResponse `json:"commits"`       // Newest first
+	TotalCommits int              `json:"total_commits"` // May exceed the number of commits listed
 }
 
 // DiffFileInfo represents a single file's diff information in API responses
@@ -295,7 +397,9 @@ func DiffFromService(d *service.DiffResult) DiffResponse {
 
 	return DiffResponse{
 		CommitHash:   d.CommitHash,
+		MergeBase:    d.MergeBase,
 		Content:      d.Content,
+		Truncated:    d.Truncated,
 		FilesChanged: d.FilesChanged,
 		Additions:    d.Additions,
 		Deletions:    d.Deletions,
@@ -303,6 +407,22 @@ func DiffFromService(d *service.DiffResult) DiffResponse {
 	}
 }
 
+// CompareFromService converts a service.Comparison to CompareResponse DTO
+func CompareFromService(c *service.Comparison) CompareResponse {
+	commits := make([]CommitResponse, 0, len(c.Commits))
+	for _, commit := range c.Commits {
+		commits = append(commits, CommitFromService(commit))
+	}
+
+	return CompareResponse{
+		DiffResponse: DiffFromService(c.Diff),
+		Base:         c.Base,
+		Head:         c.Head,
+		Commits:      commits,
+		TotalCommits: c.TotalCommits,
+	}
+}
+
 // FileContentFromService converts a service.FileContent to FileContentResponse DTO
 func FileContentFromService(f *service.FileContent, ref string) FileContentResponse {
 	content := string(f.Content)
@@ -319,6 +439,8 @@ func FileContentFromService(f *service.FileContent, ref string) FileContentRespo
 		IsBinary: f.IsBinary,
 		Encoding: f.Encoding,
 		Ref:      ref,
+
+		Truncated: f.Truncated,
 	}
 }
 
@@ -341,8 +463,16 @@ func RepoFromModel(repo *models.Repository, baseURL, sshHost string, sshPort int
 		LastSyncedAt:    repo.LastSyncedAt,
 		NextSyncAt:      repo.GetNextSyncTime(),
 		SyncStatus:      repo.SyncStatus,
+		License:         repo.License,
+		DetectedLicense: repo.DetectedLicense,
+		LicenseOverride: repo.LicenseOverride,
+		SizeBytes:       repo.SizeBytes,
+		PinnedLinks:     pinnedLinkResponses(repo.PinnedLinks),
+		Topics:          topicResponses(repo.Topics),
 		CreatedAt:       repo.CreatedAt,
 		UpdatedAt:       repo.UpdatedAt,
+
+		LargeFileHintsDisabled: repo.LargeFileHintsDisabled,
 	}
 
 	// Set owner username if available
@@ -350,6 +480,14 @@ func RepoFromModel(repo *models.Repository, baseURL, sshHost string, sshPort int
 		response.Owner = repo.Owner.Username
 	}
 
+	if parent := repo.ForkedFrom; parent != nil {
+		response.Parent = &RepoParentResponse{
+			ID:    parent.ID,
+			Owner: parent.Owner.Username,
+			Name:  parent.Name,
+		}
+	}
+
 	// Generate clone URLs
 	if baseURL != "" && response.Owner != "" {
 		response.CloneURL = buildCloneURL(baseURL, response.Owner, repo.Name)
@@ -397,9 +535,99 @@ func buildSSHURL(host string, port int, owner, name string) string {
 
 // RepoStatsResponse represents repository statistics
 type RepoStatsResponse struct {
-	BranchCount int   `json:"branch_count"`
-	TagCount    int   `json:"tag_count"`
-	DiskUsage   int64 `json:"disk_usage"`
+	BranchCount     int   `json:"branch_count"`
+	TagCount        int   `json:"tag_count"`
+	DiskUsage       int64 `json:"disk_usage"`
+	LargeFilesAdded int64 `json:"large_files_added"` // Large files pushed without Git LFS in the last 30 days
+	ForkCount       int64 `json:"fork_count"`        // Direct forks, including private ones
+}
+
+// ContributorStatResponse is the activity of an author in the history of a ref
+type ContributorStatResponse struct {
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	Commits     int       `json:"commits"`
+	Additions   int64     `json:"additions"`
+	Deletions   int64     `json:"deletions"`
+	FirstCommit time.Time `json:"first_commit"`
+	LastCommit  time.Time `json:"last_commit"`
+}
+
+// WeeklyCommitsResponse is the number of commits authored in a week
+type WeeklyCommitsResponse struct {
+	Week    time.Time `json:"week"` // Sunday 00:00 UTC
+	Commits int       `json:"commits"`
+}
+
+// ContributorStatsResponse is the contributor statistics of a ref
+type ContributorStatsResponse struct {
+	Ref          string                    `json:"ref"`
+	Commit       string                    `json:"commit"` // Tip the statistics were computed from
+	Contributors []ContributorStatResponse `json:"contributors"`
+	Weeks        []WeeklyCommitsResponse   `json:"weeks"` // Last 52 weeks, oldest first
+}
+
+// ContributorStatsFromService converts a service.ContributorActivity to ContributorStatsResponse
+func ContributorStatsFromService(a *service.ContributorActivity, ref string) ContributorStatsResponse {
+	resp := ContributorStatsResponse{
+		Ref:          ref,
+		Commit:       a.Tip,
+		Contributors: make([]ContributorStatResponse, len(a.Contributors)),
+		Weeks:        make([]WeeklyCommitsResponse, len(a.Weeks)),
+	}
+	for i, c := range a.Contributors {
+		resp.Contributors[i] = ContributorStatResponse{
+			Name:        c.Name,
+			Email:       c.Email,
+			Commits:     c.Commits,
+			Additions:   c.Additions,
+			Deletions:   c.Deletions,
+			FirstCommit: c.FirstCommit,
+			LastCommit:  c.LastCommit,
+		}
+	}
+	for i, w := range a.Weeks {
+		resp.Weeks[i] = WeeklyCommitsResponse{Week: w.Week, Commits: w.Commits}
+	}
+	return resp
+}
+
+// LanguageResponse is the bytes of source code of a language
+type LanguageResponse struct {
+	Language   string  `json:"language"`
+	Bytes      int64   `json:"bytes"`
+	Percentage float64 `json:"percentage"` // Of the bytes of all languages, rounded to 0.1
+}
+
+// LanguagesResponse is the language breakdown of the tree of a ref
+type LanguagesResponse struct {
+	Ref       string             `json:"ref"`
+	Commit    string             `json:"commit"`    // Tip the breakdown was computed from
+	Languages []LanguageResponse `json:"languages"` // Most bytes first
+}
+
+// LanguagesFromService converts a service.LanguageBreakdown to
+// LanguagesResponse, most bytes first
+func LanguagesFromService(b *service.LanguageBreakdown, ref string) LanguagesResponse {
+	var total int64
+	resp := LanguagesResponse{
+		Ref:       ref,
+		Commit:    b.Tip,
+		Languages: make([]LanguageResponse, 0, len(b.Bytes)),
+	}
+	for language, bytes := range b.Bytes {
+		resp.Languages = append(resp.Languages, LanguageResponse{Language: language, Bytes: bytes})
+		total += bytes
+	}
+	slices.SortFunc(resp.Languages, func(a, b LanguageResponse) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), cmp.Compare(a.Language, b.Language))
+	})
+	if total > 0 {
+		for i := range resp.Languages {
+			resp.Languages[i].Percentage = math.Round(float64(resp.Languages[i].Bytes)*1000/float64(total)) / 10
+		}
+	}
+	return resp
 }
 
 // Validate validates the CreateRepoRequest
@@ -416,6 +644,37 @@ func (r *CreateRepoRequest) Validate() error {
 	return nil
 }
 
+// Validate validates the UpdateRepoRequest
+func (r *UpdateRepoRequest) Validate() error {
+	if r.Name == nil {
+		return nil
+	}
+	if *r.Name == "" {
+		return ErrNameRequired
+	}
+	if len(*r.Name) > 100 {
+		return ErrNameTooLong
+	}
+	if !isValidRepoName(*r.Name) {
+		return ErrInvalidRepoName
+	}
+	return nil
+}
+
+// Validate validates the ForkRepoRequest
+func (r *ForkRepoRequest) Validate() error {
+	if r.Name == "" {
+		return nil
+	}
+	if len(r.Name) > 100 {
+		return ErrNameTooLong
+	}
+	if !isValidRepoName(r.Name) {
+		return ErrInvalidRepoName
+	}
+	return nil
+}
+
 // Validate validates the ImportRepoRequest
 func (r *ImportRepoRequest) Validate() error {
 	if r.Name == "" {
@@ -430,6 +689,36 @@ func (r *ImportRepoRequest) Validate() error {
 	if r.CloneURL == "" {
 		return ErrCloneURLRequired
 	}
+	u, err := url.Parse(r.CloneURL)
+	if err != nil {
+		return ErrInvalidCloneURL
+	}
+	// Local paths and file:// URLs would read repositories of the server
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "git", "ssh":
+	default:
+		return ErrInvalidCloneURL
+	}
+	if u.User != nil {
+		return ErrCloneURLCredentials
+	}
+	return nil
+}
+
+// Validate validates the ImportArchiveRequest
+func (r *ImportArchiveRequest) Validate() error {
+	if r.Name == "" {
+		return ErrNameRequired
+	}
+	if len(r.Name) > 100 {
+		return ErrNameTooLong
+	}
+	if !isValidRepoName(r.Name) {
+		return ErrInvalidRepoName
+	}
+	if len(r.Description) > 500 {
+		return ErrDescriptionTooLong
+	}
 	return nil
 }
 
@@ -490,6 +779,10 @@ var (
 	ErrNameTooLong      = &ValidationError{Field: "name", Message: "name must be 100 characters or less"}
 	ErrInvalidRepoName  = &ValidationError{Field: "name", Message: "name contains invalid characters"}
 	ErrCloneURLRequired = &ValidationError{Field: "clone_url", Message: "clone URL is required"}
+
+	ErrInvalidCloneURL     = &ValidationError{Field: "clone_url", Message: "clone URL must be an http, https, git or ssh URL"}
+	ErrCloneURLCredentials = &ValidationError{Field: "clone_url", Message: "pass credentials in username and password, not in the clone URL"}
+	ErrDescriptionTooLong  = &ValidationError{Field: "description", Message: "description must be 500 characters or less"}
 )
 
 // ValidationError represents a validation error
@@ -502,3 +795,11 @@ type ValidationError struct {
 func (e *ValidationError) Error() string {
 	return e.Message
 }
+
+// HighlightResponse represents server-side syntax highlighting of a file
+type HighlightResponse struct {
+	Language      string `json:"language"`                 // Detected language name, "plaintext" if unknown
+	HTML          string `json:"html,omitempty"`           // Sanitized HTML with #L<n> line anchors
+	Skipped       bool   `json:"skipped"`                  // True if the file was not highlighted
+	SkippedReason string `json:"skipped_reason,omitempty"` // binary, too_large, error
+}
@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// RepoImportResponse represents the progress of a repository import
+type RepoImportResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	Name         string     `json:"name"`
+	Source       string     `json:"source"` // url, archive
+	CloneURL     string     `json:"clone_url,omitempty"`
+	ArchiveName  string     `json:"archive_name,omitempty"`
+	Mirror       bool       `json:"mirror"`
+	IsPrivate    bool       `json:"is_private"`
+	Status       string     `json:"status"`          // pending, running, completed, failed
+	Error        string     `json:"error,omitempty"` // Why the import failed
+	RepositoryID *uuid.UUID `json:"repository_id,omitempty"`
+	Repository   string     `json:"repository,omitempty"` // owner/name once completed
+	CreatedAt    time.Time  `json:"created_at"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+// RepoImportListResponse represents a page of repository imports
+type RepoImportListResponse struct {
+	Imports    []RepoImportResponse `json:"imports"`
+	Pagination Pagination           `json:"pagination"`
+}
+
+// RepoImportFromModel converts a models.RepoImport of owner to RepoImportResponse
+func RepoImportFromModel(imp *models.RepoImport, owner string) RepoImportResponse {
+	resp := RepoImportResponse{
+		ID:           imp.ID,
+		Name:         imp.Name,
+		Source:       imp.Source,
+		CloneURL:     imp.CloneURL,
+		ArchiveName:  imp.ArchiveName,
+		Mirror:       imp.Mirror,
+		IsPrivate:    imp.IsPrivate,
+		Status:       imp.Status,
+		Error:        imp.Error,
+		RepositoryID: imp.RepositoryID,
+		CreatedAt:    imp.CreatedAt,
+		StartedAt:    imp.StartedAt,
+		CompletedAt:  imp.CompletedAt,
+	}
+	if imp.RepositoryID != nil {
+		resp.Repository = owner + "/" + imp.Name
+	}
+	return resp
+}
@@ -0,0 +1,79 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+)
+
+// StorageBackendResponse describes a storage backend
+type StorageBackendResponse struct {
+	Name         string `json:"name"`
+	Type         string `json:"type,omitempty"`      // filesystem, s3; empty for backends that are no longer configured
+	BasePath     string `json:"base_path,omitempty"` // Repository root, or the local cache of S3 backends
+	Configured   bool   `json:"configured"`
+	Repositories int64  `json:"repositories"` // Number of repositories living on the backend
+}
+
+// StorageBackendListResponse lists the storage backends
+type StorageBackendListResponse struct {
+	Backends []StorageBackendResponse `json:"backends"`
+}
+
+// MigrateRepoStorageRequest moves a repository to another storage backend
+type MigrateRepoStorageRequest struct {
+	Backend string `json:"backend"` // Target backend; empty lets the placement rules pick it
+}
+
+// RepoStorageResponse describes where a repository lives
+type RepoStorageResponse struct {
+	ID             string `json:"id"`
+	FullName       string `json:"full_name"`
+	StorageBackend string `json:"storage_backend"`
+	GitPath        string `json:"git_path"`
+}
+
+// StaleFileResponse describes a lock or temporary file left in a repository
+// by a killed git process
+type StaleFileResponse struct {
+	Path       string    `json:"path"` // Relative to the repository
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// StaleFilesResponse lists the stale files removed from a repository
+type StaleFilesResponse struct {
+	FullName string              `json:"full_name"`
+	Removed  []StaleFileResponse `json:"removed"`
+}
+
+// ObjectCountsResponse describes how the objects of a repository are stored
+type ObjectCountsResponse struct {
+	LooseObjects  int64 `json:"loose_objects"`
+	LooseSize     int64 `json:"loose_size"` // Bytes
+	PackedObjects int64 `json:"packed_objects"`
+	Packs         int64 `json:"packs"`
+	PackSize      int64 `json:"pack_size"` // Bytes
+	Garbage       int64 `json:"garbage"`   // Files in the object directory that are neither objects nor packs
+}
+
+// ObjectCountsFromService converts object counts to their response
+func ObjectCountsFromService(counts *service.ObjectCounts) ObjectCountsResponse {
+	return ObjectCountsResponse{
+		LooseObjects:  counts.LooseObjects,
+		LooseSize:     counts.LooseSize,
+		PackedObjects: counts.PackedObjects,
+		Packs:         counts.Packs,
+		PackSize:      counts.PackSize,
+		Garbage:       counts.Garbage,
+	}
+}
+
+// RepoGCResponse is the outcome of a garbage collection of a repository
+type RepoGCResponse struct {
+	FullName   string               `json:"full_name"`
+	Strategy   string               `json:"strategy"` // auto, repack
+	DurationMS int64                `json:"duration_ms"`
+	Before     ObjectCountsResponse `json:"before"`
+	After      ObjectCountsResponse `json:"after"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import "github.com/bravo68web/stasis/internal/domain/models"
+
+// UpdateProtectedTagsRequest represents a request to replace the tag protection settings of a repository
+type UpdateProtectedTagsRequest struct {
+	Patterns  []string `json:"patterns"`  // Glob patterns, e.g. "v*" or "release/*"
+	Overrides []string `json:"overrides"` // Usernames or roles ("role:admin", "role:owner")
+}
+
+// ProtectedTagsResponse represents the tag protection settings of a repository
+type ProtectedTagsResponse struct {
+	Patterns  []string `json:"patterns"`
+	Overrides []string `json:"overrides"`
+}
+
+// ProtectedTagsFromModel converts the tag protection settings of a repository to ProtectedTagsResponse
+func ProtectedTagsFromModel(repo *models.Repository) ProtectedTagsResponse {
+	resp := ProtectedTagsResponse{
+		Patterns:  repo.ProtectedTagPatterns,
+		Overrides: repo.ProtectedTagOverrides,
+	}
+	if resp.Patterns == nil {
+		resp.Patterns = []string{}
+	}
+	if resp.Overrides == nil {
+		resp.Overrides = []string{}
+	}
+	return resp
+}
@@ -0,0 +1,46 @@
+package dto
+
+import "github.com/bravo68web/stasis/internal/domain/models"
+
+// UpdateTopicsRequest represents a request to replace the topics of a repository
+type UpdateTopicsRequest struct {
+	Topics []string `json:"topics"` // Lowercased, spaces turned into hyphens and deduplicated by the server
+}
+
+// TopicsResponse represents the topics of a repository
+type TopicsResponse struct {
+	Topics []string `json:"topics"`
+}
+
+// TopicCountResponse is the number of public repositories tagged with a topic
+type TopicCountResponse struct {
+	Topic string `json:"topic"`
+	Count int64  `json:"count"`
+}
+
+// TopicCountListResponse lists the most used topics of public repositories
+type TopicCountListResponse struct {
+	Topics []TopicCountResponse `json:"topics"`
+}
+
+// TopicsFromModel converts the topics of a repository to TopicsResponse
+func TopicsFromModel(repo *models.Repository) TopicsResponse {
+	return TopicsResponse{Topics: topicResponses(repo.Topics)}
+}
+
+// TopicCountsFromModel converts topic counts to TopicCountListResponse
+func TopicCountsFromModel(counts []models.TopicCount) TopicCountListResponse {
+	resp := TopicCountListResponse{Topics: make([]TopicCountResponse, len(counts))}
+	for i, count := range counts {
+		resp.Topics[i] = TopicCountResponse{Topic: count.Topic, Count: count.Count}
+	}
+	return resp
+}
+
+// topicResponses returns topics, never nil so they serialize as a JSON array
+func topicResponses(topics []string) []string {
+	if topics == nil {
+		return []string{}
+	}
+	return topics
+}
@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"github.com/bravo68web/stasis/internal/domain/models"
+)
+
+// UploadPackSettingsRequest represents the upload-pack settings of a
+// repository. Settings left out keep their value.
+type UploadPackSettingsRequest struct {
+	AllowFilter              *bool `json:"allow_filter"`                 // Allow partial clones and fetches (--filter=blob:none, ...)
+	AllowReachableSHA1InWant *bool `json:"allow_reachable_sha1_in_want"` // Allow fetches of objects by ID if a ref reaches them, as partial clones need
+	AllowAnySHA1InWant       *bool `json:"allow_any_sha1_in_want"`       // Allow fetches of any object by ID, even one no ref reaches
+}
+
+// ApplyTo returns settings with the settings of the request applied
+func (r *UploadPackSettingsRequest) ApplyTo(settings models.UploadPackSettings) models.UploadPackSettings {
+	if r.AllowFilter != nil {
+		settings.AllowFilter = *r.AllowFilter
+	}
+	if r.AllowReachableSHA1InWant != nil {
+		settings.AllowReachableSHA1InWant = *r.AllowReachableSHA1InWant
+	}
+	if r.AllowAnySHA1InWant != nil {
+		settings.AllowAnySHA1InWant = *r.AllowAnySHA1InWant
+	}
+	return settings
+}
+
+// UploadPackSettingsResponse represents the upload-pack settings of a repository
+type UploadPackSettingsResponse struct {
+	AllowFilter              bool `json:"allow_filter"`
+	AllowReachableSHA1InWant bool `json:"allow_reachable_sha1_in_want"`
+	AllowAnySHA1InWant       bool `json:"allow_any_sha1_in_want"`
+}
+
+// UploadPackSettingsFromModel converts the upload-pack settings of a repository to UploadPackSettingsResponse
+func UploadPackSettingsFromModel(repo *models.Repository) UploadPackSettingsResponse {
+	return UploadPackSettingsResponse{
+		AllowFilter:              repo.UploadPack.AllowFilter,
+		AllowReachableSHA1InWant: repo.UploadPack.AllowReachableSHA1InWant,
+		AllowAnySHA1InWant:       repo.UploadPack.AllowAnySHA1InWant,
+	}
+}
@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+)
+
+// AdminUserResponse represents a user as seen by site administrators
+type AdminUserResponse struct {
+	ID           string     `json:"id"`
+	Username     string     `json:"username"`
+	Email        string     `json:"email"`
+	IsAdmin      bool       `json:"is_admin"`
+	OIDCLinked   bool       `json:"oidc_linked"`            // Whether the user signed in with OIDC at least once
+	Repositories *int64     `json:"repositories,omitempty"` // Owned repositories; only in single-user responses
+	OnboardedAt  *time.Time `json:"onboarded_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// ListAdminUsersResponse represents a page of users
+type ListAdminUsersResponse struct {
+	Users      []AdminUserResponse `json:"users"`
+	Pagination Pagination          `json:"pagination"`
+}
+
+// CreateAdminUserRequest represents a request to provision a user. The user
+// is linked to their OIDC identity by email on their first sign-in.
+type CreateAdminUserRequest struct {
+	Username string `json:"username" binding:"required,max=50"`
+	Email    string `json:"email" binding:"required,max=255"`
+	IsAdmin  bool   `json:"is_admin"`
+}
+
+// UpdateAdminUserRequest represents a change of a user by an administrator
+type UpdateAdminUserRequest struct {
+	Email   *string `json:"email,omitempty" binding:"omitempty,max=255"`
+	IsAdmin *bool   `json:"is_admin,omitempty"`
+}
+
+// DeleteAdminUserResponse represents the outcome of deleting a user
+type DeleteAdminUserResponse struct {
+	Message             string   `json:"message"`
+	DeletedRepositories []string `json:"deleted_repositories"` // Owned repositories deleted with force
+}
+
+// AdminUserFromModel converts a models.User to AdminUserResponse
+func AdminUserFromModel(user *models.User) AdminUserResponse {
+	return AdminUserResponse{
+		ID:          user.ID.String(),
+		Username:    user.Username,
+		Email:       user.Email,
+		IsAdmin:     user.IsAdmin,
+		OIDCLinked:  user.OIDCSubject != "",
+		OnboardedAt: user.OnboardedAt,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}
+}
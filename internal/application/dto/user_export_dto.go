@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+)
+
+// RequestUserExportRequest represents a request for a data export
+type RequestUserExportRequest struct {
+	IncludeRepositories bool `json:"include_repositories"` // Include git bundles of the owned repositories
+}
+
+// UserExportResponse represents a data export of a user
+type UserExportResponse struct {
+	ID                  string     `json:"id"`
+	Status              string     `json:"status"` // pending, running, ready, failed
+	IncludeRepositories bool       `json:"include_repositories"`
+	RequestedByAdmin    bool       `json:"requested_by_admin"`
+	Size                int64      `json:"size,omitempty"`
+	Error               string     `json:"error,omitempty"`
+	DownloadURL         string     `json:"download_url,omitempty"` // Signed link, valid until expires_at
+	CreatedAt           time.Time  `json:"created_at"`
+	CompletedAt         *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt           *time.Time `json:"expires_at,omitempty"`
+}
+
+// ListUserExportsResponse represents the data exports of a user
+type ListUserExportsResponse struct {
+	Exports []UserExportResponse `json:"exports"`
+}
+
+// UserExportFromModel converts a models.UserExport to UserExportResponse
+func UserExportFromModel(export *models.UserExport, downloadURL string) UserExportResponse {
+	return UserExportResponse{
+		ID:                  export.ID.String(),
+		Status:              export.Status,
+		IncludeRepositories: export.IncludeRepositories,
+		RequestedByAdmin:    export.RequestedByID != nil,
+		Size:                export.Size,
+		Error:               export.Error,
+		DownloadURL:         downloadURL,
+		CreatedAt:           export.CreatedAt,
+		CompletedAt:         export.CompletedAt,
+		ExpiresAt:           export.ExpiresAt,
+	}
+}
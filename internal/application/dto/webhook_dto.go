@@ -0,0 +1,168 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// WebhookRequest represents a request to create or replace a webhook
+type WebhookRequest struct {
+	URL        string   `json:"url" binding:"required"`    // http or https URL the events are posted to
+	Secret     string   `json:"secret,omitempty"`          // Signs the deliveries; required on creation, kept when omitted on update
+	Events     []string `json:"events" binding:"required"` // ci_job.queued, ci_job.started, ci_job.succeeded, ci_job.failed, ci_job.cancelled
+	RefPattern string   `json:"ref_pattern,omitempty"`     // Branch or tag name glob, e.g. "main" or "release/*"; empty for every ref
+	Active     *bool    `json:"active,omitempty"`          // Send events (default true)
+}
+
+// ToModel converts the request to a models.Webhook, active unless the
+// request says otherwise
+func (r WebhookRequest) ToModel() *models.Webhook {
+	hook := &models.Webhook{
+		URL:        r.URL,
+		Secret:     r.Secret,
+		Events:     r.Events,
+		RefPattern: r.RefPattern,
+		Active:     true,
+	}
+	if r.Active != nil {
+		hook.Active = *r.Active
+	}
+	return hook
+}
+
+// WebhookResponse represents a webhook of a repository; its secret is never
+// returned
+type WebhookResponse struct {
+	ID         uuid.UUID `json:"id"`
+	URL        string    `json:"url"`
+	Events     []string  `json:"events"`
+	RefPattern string    `json:"ref_pattern"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// WebhookListResponse represents the webhooks of a repository
+type WebhookListResponse struct {
+	Hooks []WebhookResponse `json:"hooks"`
+	Total int               `json:"total"`
+}
+
+// WebhookFromModel converts a models.Webhook to WebhookResponse
+func WebhookFromModel(h *models.Webhook) WebhookResponse {
+	resp := WebhookResponse{
+		ID:         h.ID,
+		URL:        h.URL,
+		Events:     h.Events,
+		RefPattern: h.RefPattern,
+		Active:     h.Active,
+		CreatedAt:  h.CreatedAt,
+		UpdatedAt:  h.UpdatedAt,
+	}
+	if resp.Events == nil {
+		resp.Events = []string{}
+	}
+	return resp
+}
+
+// WebhookListFromModels converts webhooks to WebhookListResponse
+func WebhookListFromModels(hooks []*models.Webhook) WebhookListResponse {
+	resp := WebhookListResponse{
+		Hooks: make([]WebhookResponse, 0, len(hooks)),
+		Total: len(hooks),
+	}
+	for _, h := range hooks {
+		resp.Hooks = append(resp.Hooks, WebhookFromModel(h))
+	}
+	return resp
+}
+
+// WebhookDeliveryResponse represents an event sent to a webhook, with the
+// payload posted and the outcome of its last attempt
+type WebhookDeliveryResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	Event         string     `json:"event"`
+	Payload       string     `json:"payload"` // JSON body as posted and signed
+	Attempts      int        `json:"attempts"`
+	StatusCode    *int       `json:"status_code,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	Delivered     bool       `json:"delivered"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ListWebhookDeliveriesResponse represents a page of the deliveries of a webhook
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+	Pagination Pagination                `json:"pagination"`
+}
+
+// WebhookDeliveryFromModel converts a models.WebhookDelivery to WebhookDeliveryResponse
+func WebhookDeliveryFromModel(d *models.WebhookDelivery) WebhookDeliveryResponse {
+	return WebhookDeliveryResponse{
+		ID:            d.ID,
+		Event:         d.Event,
+		Payload:       d.Payload,
+		Attempts:      d.Attempts,
+		StatusCode:    d.StatusCode,
+		Error:         d.Error,
+		Delivered:     d.DeliveredAt != nil,
+		DeliveredAt:   d.DeliveredAt,
+		LastAttemptAt: d.LastAttemptAt,
+		CreatedAt:     d.CreatedAt,
+	}
+}
+
+// CIJobWebhookPayload is the body posted to webhooks for ci_job.* events
+type CIJobWebhookPayload struct {
+	Event       string                 `json:"event"`
+	DeliveryID  uuid.UUID              `json:"delivery_id"` // Same for every attempt of the delivery
+	OccurredAt  time.Time              `json:"occurred_at"`
+	Job         CIJobWebhookJob        `json:"job"`
+	Run         *CIJobWebhookRun       `json:"run,omitempty"` // Absent for jobs submitted before runs were recorded
+	Repository  CIJobWebhookRepository `json:"repository"`
+	Commit      CIJobWebhookCommit     `json:"commit"`
+	TriggeredBy *CIJobWebhookTriggerer `json:"triggered_by,omitempty"`
+}
+
+// CIJobWebhookJob is the CI job of a webhook payload
+type CIJobWebhookJob struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name,omitempty"`  // Empty outside staged runs
+	Stage      string     `json:"stage,omitempty"` // Empty outside staged runs
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	URL        string     `json:"url,omitempty"` // Empty without a web URL configured
+}
+
+// CIJobWebhookRun is the run of the CI job of a webhook payload
+type CIJobWebhookRun struct {
+	ID uuid.UUID `json:"id"`
+}
+
+// CIJobWebhookRepository is the repository of a webhook payload
+type CIJobWebhookRepository struct {
+	ID       uuid.UUID `json:"id"`
+	Owner    string    `json:"owner"`
+	Name     string    `json:"name"`
+	FullName string    `json:"full_name"`
+	Private  bool      `json:"private"`
+	URL      string    `json:"url,omitempty"` // Empty without a web URL configured
+}
+
+// CIJobWebhookCommit is the commit and ref a CI job of a webhook payload runs on
+type CIJobWebhookCommit struct {
+	SHA     string `json:"sha"`
+	Ref     string `json:"ref"`
+	RefType string `json:"ref_type"` // branch or tag
+}
+
+// CIJobWebhookTriggerer is the user who triggered the CI job of a webhook payload
+type CIJobWebhookTriggerer struct {
+	Username string `json:"username"`
+}
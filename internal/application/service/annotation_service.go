@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// maxAnnotationKeyLength is the maximum length of an annotation key
+const maxAnnotationKeyLength = 64
+
+// AnnotationService handles repository annotation operations
+type AnnotationService struct {
+	annotationRepo repository.RepoAnnotationRepository
+	config         *config.AnnotationsConfig
+	log            *logger.Logger
+}
+
+// NewAnnotationService creates a new AnnotationService instance
+func NewAnnotationService(
+	annotationRepo repository.RepoAnnotationRepository,
+	cfg *config.AnnotationsConfig,
+) *AnnotationService {
+	return &AnnotationService{
+		annotationRepo: annotationRepo,
+		config:         cfg,
+		log:            logger.Get().WithFields(logger.Component("annotation-service")),
+	}
+}
+
+// ListAnnotations lists all annotations of a repository
+func (s *AnnotationService) ListAnnotations(ctx context.Context, repo *models.Repository) ([]*models.RepoAnnotation, error) {
+	return s.annotationRepo.ListByRepository(ctx, repo.ID)
+}
+
+// GetAnnotation returns a single annotation of a repository
+func (s *AnnotationService) GetAnnotation(ctx context.Context, repo *models.Repository, key string) (*models.RepoAnnotation, error) {
+	return s.annotationRepo.FindByRepoAndKey(ctx, repo.ID, key)
+}
+
+// CreateAnnotation adds a new annotation to a repository
+func (s *AnnotationService) CreateAnnotation(ctx context.Context, repo *models.Repository, authorID uuid.UUID, key, value string) (*models.RepoAnnotation, error) {
+	if err := s.validateKey(key); err != nil {
+		return nil, err
+	}
+	if err := s.validateValue(value); err != nil {
+		return nil, err
+	}
+
+	_, err := s.annotationRepo.FindByRepoAndKey(ctx, repo.ID, key)
+	if err == nil {
+		return nil, apperrors.Conflict("annotation already exists", apperrors.ErrAnnotationExists)
+	}
+	if !apperrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to check annotation existence: %w", err)
+	}
+
+	annotation := &models.RepoAnnotation{
+		RepositoryID: repo.ID,
+		Key:          key,
+		Value:        value,
+		AuthorID:     &authorID,
+	}
+	if err := s.annotationRepo.Create(ctx, annotation); err != nil {
+		s.log.WithContext(ctx).Error("Failed to create annotation",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+			logger.String("key", key),
+		)
+		return nil, err
+	}
+
+	s.log.WithContext(ctx).Info("Annotation created",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("key", key),
+		logger.String("author_id", authorID.String()),
+	)
+
+	return s.annotationRepo.FindByRepoAndKey(ctx, repo.ID, key)
+}
+
+// UpdateAnnotation replaces the value of an existing annotation
+func (s *AnnotationService) UpdateAnnotation(ctx context.Context, repo *models.Repository, authorID uuid.UUID, key, value string) (*models.RepoAnnotation, error) {
+	if err := s.validateValue(value); err != nil {
+		return nil, err
+	}
+
+	annotation, err := s.annotationRepo.FindByRepoAndKey(ctx, repo.ID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	annotation.Value = value
+	annotation.AuthorID = &authorID
+	if err := s.annotationRepo.Update(ctx, annotation); err != nil {
+		s.log.WithContext(ctx).Error("Failed to update annotation",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+			logger.String("key", key),
+		)
+		return nil, err
+	}
+
+	s.log.WithContext(ctx).Info("Annotation updated",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("key", key),
+		logger.String("author_id", authorID.String()),
+	)
+
+	return s.annotationRepo.FindByRepoAndKey(ctx, repo.ID, key)
+}
+
+// DeleteAnnotation removes an annotation from a repository
+func (s *AnnotationService) DeleteAnnotation(ctx context.Context, repo *models.Repository, key string) error {
+	if err := s.annotationRepo.Delete(ctx, repo.ID, key); err != nil {
+		return err
+	}
+
+	s.log.WithContext(ctx).Info("Annotation deleted",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("key", key),
+	)
+
+	return nil
+}
+
+// ListedAnnotations returns the configured listed annotations for a set of repositories,
+// keyed by repository ID
+func (s *AnnotationService) ListedAnnotations(ctx context.Context, repos []*models.Repository) (map[uuid.UUID]map[string]string, error) {
+	result := make(map[uuid.UUID]map[string]string)
+	if len(s.config.ListedKeys) == 0 || len(repos) == 0 {
+		return result, nil
+	}
+
+	repoIDs := make([]uuid.UUID, len(repos))
+	for i, repo := range repos {
+		repoIDs[i] = repo.ID
+	}
+
+	annotations, err := s.annotationRepo.ListByRepositoriesAndKeys(ctx, repoIDs, s.config.ListedKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range annotations {
+		if result[a.RepositoryID] == nil {
+			result[a.RepositoryID] = make(map[string]string)
+		}
+		result[a.RepositoryID][a.Key] = a.Value
+	}
+
+	return result, nil
+}
+
+// ParseFilter parses a listing filter in the form "key:value".
+// Only listed annotation keys can be used for filtering.
+func (s *AnnotationService) ParseFilter(filter string) (string, string, error) {
+	key, value, ok := strings.Cut(filter, ":")
+	if !ok || key == "" || value == "" {
+		return "", "", apperrors.BadRequest("annotation filter must be in the form key:value", apperrors.ErrInvalidInput)
+	}
+	if !s.config.IsListedKey(key) {
+		return "", "", apperrors.BadRequest(fmt.Sprintf("annotation key '%s' cannot be used as a filter", key), apperrors.ErrInvalidInput)
+	}
+	return key, value, nil
+}
+
+// validateKey checks that an annotation key is well-formed
+func (s *AnnotationService) validateKey(key string) error {
+	if key == "" {
+		return apperrors.BadRequest("annotation key is required", apperrors.ErrInvalidInput)
+	}
+	if len(key) > maxAnnotationKeyLength {
+		return apperrors.BadRequest(fmt.Sprintf("annotation key must be %d characters or less", maxAnnotationKeyLength), apperrors.ErrInvalidInput)
+	}
+	for _, c := range key {
+		if !isValidAnnotationKeyChar(c) {
+			return apperrors.BadRequest("annotation key may only contain lowercase letters, digits, '.', '-' and '_'", apperrors.ErrInvalidInput)
+		}
+	}
+	return nil
+}
+
+// validateValue checks that an annotation value is within the configured size cap
+func (s *AnnotationService) validateValue(value string) error {
+	if value == "" {
+		return apperrors.BadRequest("annotation value is required", apperrors.ErrInvalidInput)
+	}
+	if maxLen := s.config.GetMaxValueLength(); len(value) > maxLen {
+		return apperrors.BadRequest(fmt.Sprintf("annotation value must be %d bytes or less", maxLen), apperrors.ErrInvalidInput)
+	}
+	return nil
+}
+
+// isValidAnnotationKeyChar checks if a character is valid in an annotation key
+func isValidAnnotationKeyChar(c rune) bool {
+	return (c >= 'a' && c <= 'z') ||
+		(c >= '0' && c <= '9') ||
+		c == '-' || c == '_' || c == '.'
+}
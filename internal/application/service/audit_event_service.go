@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// auditEventWriteTimeout bounds the background write of an audit event
+const auditEventWriteTimeout = 30 * time.Second
+
+// AuditEventService records significant actions of users as audit events,
+// which feed the activity of repositories and the audit trail of the server.
+// Writes are best effort: a failed write is logged and never fails the action.
+type AuditEventService struct {
+	auditEventRepo repository.AuditEventRepository
+	log            *logger.Logger
+}
+
+// NewAuditEventService creates a new AuditEventService instance recording
+// the pushes, and the repositories created by pushes, published on subscriber
+func NewAuditEventService(auditEventRepo repository.AuditEventRepository, subscriber events.Subscriber) *AuditEventService {
+	s := &AuditEventService{
+		auditEventRepo: auditEventRepo,
+		log:            logger.Get().WithFields(logger.Component("audit-events")),
+	}
+
+	subscriber.Subscribe("audit-events", s.recordPush, events.SubscribeOptions{
+		Types:      []string{events.TypeRepositoryPushed},
+		MaxRetries: 3,
+	})
+	subscriber.Subscribe("audit-events-created-on-push", s.recordCreatedOnPush, events.SubscribeOptions{
+		Types:      []string{events.TypeRepositoryCreated},
+		MaxRetries: 3,
+	})
+
+	return s
+}
+
+// Record stores an audit event in the background so it never delays the action
+func (s *AuditEventService) Record(event *models.AuditEvent) {
+	if event.Actor == "" {
+		event.Actor = "anonymous"
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), auditEventWriteTimeout)
+		defer cancel()
+
+		if err := s.auditEventRepo.Create(ctx, event); err != nil {
+			s.log.Warn("Failed to record audit event",
+				logger.Error(err),
+				logger.String("action", event.Action),
+				logger.String("actor", event.Actor),
+			)
+		}
+	}()
+}
+
+// ListEvents lists the audit events matching a filter, most recent first,
+// with the total number of matching events
+func (s *AuditEventService) ListEvents(ctx context.Context, filter repository.AuditEventFilter, limit, offset int) ([]*models.AuditEvent, int64, error) {
+	return s.auditEventRepo.List(ctx, filter, limit, offset)
+}
+
+// recordPush records the ref updates of a push from either transport
+func (s *AuditEventService) recordPush(ctx context.Context, env events.Envelope) error {
+	push, ok := env.Event.(events.RepositoryPushed)
+	if !ok {
+		return nil
+	}
+
+	refs := make([]map[string]string, len(push.Refs))
+	for i, ref := range push.Refs {
+		refs[i] = map[string]string{
+			"ref_name": ref.RefName,
+			"old_hash": ref.OldHash,
+			"new_hash": ref.NewHash,
+		}
+	}
+
+	metadata := map[string]any{
+		"repository": push.Owner + "/" + push.Name,
+		"transport":  push.Transport,
+		"refs":       refs,
+	}
+	if len(push.Rejected) > 0 {
+		rejected := make([]map[string]string, len(push.Rejected))
+		for i, ref := range push.Rejected {
+			rejected[i] = map[string]string{
+				"ref_name": ref.RefName,
+				"old_hash": ref.OldHash,
+				"new_hash": ref.NewHash,
+				"reason":   ref.Reason,
+			}
+		}
+		metadata["rejected"] = rejected
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, auditEventWriteTimeout)
+	defer cancel()
+
+	return s.auditEventRepo.Create(ctx, &models.AuditEvent{
+		ActorID:      push.PusherID,
+		Actor:        push.Pusher,
+		RepositoryID: &push.RepositoryID,
+		Action:       models.AuditActionGitPush,
+		Metadata:     metadata,
+		IP:           push.RemoteIP,
+		CreatedAt:    env.OccurredAt,
+	})
+}
+
+// recordCreatedOnPush records the repositories created by a push into the
+// pusher's own namespace, which no API handler sees. The owner is the pusher.
+func (s *AuditEventService) recordCreatedOnPush(ctx context.Context, env events.Envelope) error {
+	created, ok := env.Event.(events.RepositoryCreated)
+	if !ok || created.Source != "push" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, auditEventWriteTimeout)
+	defer cancel()
+
+	return s.auditEventRepo.Create(ctx, &models.AuditEvent{
+		ActorID:      &created.OwnerID,
+		Actor:        created.Owner,
+		RepositoryID: &created.RepositoryID,
+		Action:       models.AuditActionRepoCreate,
+		Metadata: map[string]any{
+			"repository": created.Owner + "/" + created.Name,
+			"is_private": created.IsPrivate,
+			"source":     created.Source,
+		},
+		CreatedAt: env.OccurredAt,
+	})
+}
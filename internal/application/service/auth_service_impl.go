@@ -21,6 +21,8 @@ type AuthServiceImpl struct {
 	userRepo    repository.UserRepository
 	sshKeyRepo  repository.SSHKeyRepository
 	tokenRepo   repository.TokenRepository
+	ciJobTokens repository.CIJobTokenRepository
+	tokenUsage  *TokenUsageTracker
 	oidcService *OIDCService
 	config      *config.OIDCConfig
 	log         *logger.Logger
@@ -31,6 +33,8 @@ func NewAuthService(
 	userRepo repository.UserRepository,
 	sshKeyRepo repository.SSHKeyRepository,
 	tokenRepo repository.TokenRepository,
+	ciJobTokens repository.CIJobTokenRepository,
+	tokenUsage *TokenUsageTracker,
 	oidcService *OIDCService,
 	oidcConfig *config.OIDCConfig,
 ) *AuthServiceImpl {
@@ -38,6 +42,8 @@ func NewAuthService(
 		userRepo:    userRepo,
 		sshKeyRepo:  sshKeyRepo,
 		tokenRepo:   tokenRepo,
+		ciJobTokens: ciJobTokens,
+		tokenUsage:  tokenUsage,
 		oidcService: oidcService,
 		config:      oidcConfig,
 		log:         logger.Get().WithFields(logger.Component("auth-service")),
@@ -45,8 +51,8 @@ func NewAuthService(
 }
 
 // AuthenticateToken authenticates a user using an access token (PAT)
-func (s *AuthServiceImpl) AuthenticateToken(ctx context.Context, token string) (*models.User, error) {
-	s.log.Debug("Authenticating user via access token (PAT)")
+func (s *AuthServiceImpl) AuthenticateToken(ctx context.Context, token string, origin service.RequestOrigin) (*models.User, error) {
+	s.log.WithContext(ctx).Debug("Authenticating user via access token (PAT)")
 
 	// Hash the token to look it up
 	hashedToken := s.hashToken(token)
@@ -55,10 +61,10 @@ func (s *AuthServiceImpl) AuthenticateToken(ctx context.Context, token string) (
 	tokenRecord, err := s.tokenRepo.FindByHashedToken(ctx, hashedToken)
 	if err != nil {
 		if apperrors.IsNotFound(err) {
-			s.log.Debug("Token not found in database")
+			s.log.WithContext(ctx).Debug("Token not found in database")
 			return nil, apperrors.Unauthorized("invalid token", apperrors.ErrInvalidCredentials)
 		}
-		s.log.Error("Failed to find token in database",
+		s.log.WithContext(ctx).Error("Failed to find token in database",
 			logger.Error(err),
 		)
 		return nil, fmt.Errorf("failed to find token: %w", err)
@@ -66,36 +72,41 @@ func (s *AuthServiceImpl) AuthenticateToken(ctx context.Context, token string) (
 
 	// Check if token is expired
 	if tokenRecord.ExpiresAt != nil && tokenRecord.ExpiresAt.Before(time.Now()) {
-		s.log.Debug("Token has expired",
+		s.log.WithContext(ctx).Debug("Token has expired",
 			logger.String("token_id", tokenRecord.ID.String()),
 			logger.Time("expired_at", *tokenRecord.ExpiresAt),
 		)
 		return nil, apperrors.Unauthorized("token has expired", apperrors.ErrInvalidCredentials)
 	}
 
-	// Update last used timestamp (fire and forget)
-	go func() {
-		_ = s.tokenRepo.UpdateLastUsed(context.Background(), tokenRecord.ID)
-	}()
+	// Record the use (written in the background, at most once a minute)
+	s.tokenUsage.Touch(tokenRecord, origin)
 
 	// Get the user associated with this token
 	user, err := s.userRepo.FindByID(ctx, tokenRecord.UserID)
 	if err != nil {
 		if apperrors.IsNotFound(err) {
-			s.log.Warn("User not found for valid token",
+			s.log.WithContext(ctx).Warn("User not found for valid token",
 				logger.String("token_id", tokenRecord.ID.String()),
 				logger.String("user_id", tokenRecord.UserID.String()),
 			)
 			return nil, apperrors.Unauthorized("user not found for token", apperrors.ErrInvalidCredentials)
 		}
-		s.log.Error("Failed to find user for token",
+		s.log.WithContext(ctx).Error("Failed to find user for token",
 			logger.Error(err),
 			logger.String("user_id", tokenRecord.UserID.String()),
 		)
 		return nil, fmt.Errorf("failed to find user for token: %w", err)
 	}
 
-	s.log.Info("User authenticated via PAT",
+	// The scopes of the token limit the request; site administration needs
+	// the admin scope
+	user.Token = tokenRecord
+	if !tokenRecord.AllowsAdmin() {
+		user.IsAdmin = false
+	}
+
+	s.log.WithContext(ctx).Info("User authenticated via PAT",
 		logger.String("user_id", user.ID.String()),
 		logger.String("username", user.Username),
 		logger.String("token_id", tokenRecord.ID.String()),
@@ -104,12 +115,32 @@ func (s *AuthServiceImpl) AuthenticateToken(ctx context.Context, token string) (
 	return user, nil
 }
 
+// AuthenticateCIJobToken validates the token in the clone URL of a CI job
+func (s *AuthServiceImpl) AuthenticateCIJobToken(ctx context.Context, token string) (*models.CIJobToken, error) {
+	jobToken, err := s.ciJobTokens.FindByHashedToken(ctx, s.hashToken(token))
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil, apperrors.Unauthorized("invalid CI job token", apperrors.ErrInvalidCredentials)
+		}
+		return nil, fmt.Errorf("failed to find CI job token: %w", err)
+	}
+
+	if !jobToken.IsValid(time.Now()) {
+		s.log.WithContext(ctx).Debug("CI job token has expired or was revoked",
+			logger.String("job_id", jobToken.JobID.String()),
+		)
+		return nil, apperrors.Unauthorized("CI job token has expired", apperrors.ErrInvalidCredentials)
+	}
+
+	return jobToken, nil
+}
+
 // AuthenticateSSH authenticates a user using their SSH public key fingerprint
 // The publicKey parameter should be the SSH key fingerprint (e.g., SHA256:xxx format)
 func (s *AuthServiceImpl) AuthenticateSSH(ctx context.Context, publicKey []byte) (*models.User, error) {
 	fingerprint := string(publicKey)
 
-	s.log.Debug("Authenticating user via SSH key",
+	s.log.WithContext(ctx).Debug("Authenticating user via SSH key",
 		logger.String("fingerprint", fingerprint),
 	)
 
@@ -117,12 +148,12 @@ func (s *AuthServiceImpl) AuthenticateSSH(ctx context.Context, publicKey []byte)
 	sshKey, err := s.sshKeyRepo.FindByFingerprint(ctx, fingerprint)
 	if err != nil {
 		if apperrors.IsNotFound(err) {
-			s.log.Debug("SSH key not found",
+			s.log.WithContext(ctx).Debug("SSH key not found",
 				logger.String("fingerprint", fingerprint),
 			)
 			return nil, apperrors.Unauthorized("ssh key not recognized", apperrors.ErrInvalidCredentials)
 		}
-		s.log.Error("Failed to find SSH key",
+		s.log.WithContext(ctx).Error("Failed to find SSH key",
 			logger.Error(err),
 			logger.String("fingerprint", fingerprint),
 		)
@@ -131,27 +162,27 @@ func (s *AuthServiceImpl) AuthenticateSSH(ctx context.Context, publicKey []byte)
 
 	// Update last used timestamp (fire and forget, don't fail auth on this)
 	go func() {
-		_ = s.sshKeyRepo.UpdateLastUsed(context.Background(), sshKey.ID)
+		_ = s.sshKeyRepo.UpdateLastUsed(context.WithoutCancel(ctx), sshKey.ID)
 	}()
 
 	// Get the user associated with this key
 	user, err := s.userRepo.FindByID(ctx, sshKey.UserID)
 	if err != nil {
 		if apperrors.IsNotFound(err) {
-			s.log.Warn("User not found for valid SSH key",
+			s.log.WithContext(ctx).Warn("User not found for valid SSH key",
 				logger.String("ssh_key_id", sshKey.ID.String()),
 				logger.String("user_id", sshKey.UserID.String()),
 			)
 			return nil, apperrors.Unauthorized("user not found for ssh key", apperrors.ErrInvalidCredentials)
 		}
-		s.log.Error("Failed to find user for SSH key",
+		s.log.WithContext(ctx).Error("Failed to find user for SSH key",
 			logger.Error(err),
 			logger.String("user_id", sshKey.UserID.String()),
 		)
 		return nil, fmt.Errorf("failed to find user for ssh key: %w", err)
 	}
 
-	s.log.Info("User authenticated via SSH key",
+	s.log.WithContext(ctx).Info("User authenticated via SSH key",
 		logger.String("user_id", user.ID.String()),
 		logger.String("username", user.Username),
 		logger.String("ssh_key_id", sshKey.ID.String()),
@@ -167,26 +198,34 @@ func (s *AuthServiceImpl) AuthenticateSSHByFingerprint(ctx context.Context, fing
 
 // AuthenticateSession authenticates a user using a session JWT (from OIDC login)
 func (s *AuthServiceImpl) AuthenticateSession(ctx context.Context, sessionToken string) (*models.User, error) {
-	s.log.Debug("Authenticating user via session token")
+	s.log.WithContext(ctx).Debug("Authenticating user via session token")
 
 	if s.oidcService == nil {
-		s.log.Debug("OIDC service not configured")
+		s.log.WithContext(ctx).Debug("OIDC service not configured")
 		return nil, apperrors.Unauthorized("OIDC not configured", apperrors.ErrInvalidCredentials)
 	}
 
 	// Validate the session token
 	claims, err := s.oidcService.ValidateSessionToken(sessionToken)
 	if err != nil {
-		s.log.Debug("Invalid session token",
+		s.log.WithContext(ctx).Debug("Invalid session token",
 			logger.Error(err),
 		)
 		return nil, apperrors.Unauthorized("invalid session token", err)
 	}
 
+	// The session may have been logged out, or ended of idleness
+	if err := s.oidcService.CheckSession(ctx, claims); err != nil {
+		s.log.WithContext(ctx).Debug("Session of session token is not active",
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
 	// Parse user ID from claims
 	userID, err := uuid.Parse(claims.UserID)
 	if err != nil {
-		s.log.Warn("Invalid user ID in session token",
+		s.log.WithContext(ctx).Warn("Invalid user ID in session token",
 			logger.String("user_id_claim", claims.UserID),
 			logger.Error(err),
 		)
@@ -197,19 +236,19 @@ func (s *AuthServiceImpl) AuthenticateSession(ctx context.Context, sessionToken
 	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		if apperrors.IsNotFound(err) {
-			s.log.Warn("User not found for valid session token",
+			s.log.WithContext(ctx).Warn("User not found for valid session token",
 				logger.String("user_id", userID.String()),
 			)
 			return nil, apperrors.Unauthorized("user not found", apperrors.ErrInvalidCredentials)
 		}
-		s.log.Error("Failed to find user for session token",
+		s.log.WithContext(ctx).Error("Failed to find user for session token",
 			logger.Error(err),
 			logger.String("user_id", userID.String()),
 		)
 		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
 
-	s.log.Info("User authenticated via session token",
+	s.log.WithContext(ctx).Info("User authenticated via session token",
 		logger.String("user_id", user.ID.String()),
 		logger.String("username", user.Username),
 	)
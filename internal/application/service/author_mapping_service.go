@@ -0,0 +1,426 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+	"github.com/bravo68web/stasis/pkg/mailmap"
+)
+
+const (
+	// mailmapPath is the path of the mailmap file on the default branch
+	mailmapPath = ".mailmap"
+
+	// maxContributorCommits bounds the history scanned for contributor stats
+	maxContributorCommits = 10000
+
+	// maxUnmappedScanCommits is the recent history scanned for unmapped author emails
+	maxUnmappedScanCommits = 1000
+
+	// authorCommitPageSize is the number of commits read per step while scanning history
+	authorCommitPageSize = 500
+
+	// authorResolverTTL bounds how long user lookups of a resolver are reused,
+	// so new accounts are picked up without a push
+	authorResolverTTL = 10 * time.Minute
+
+	// maxCachedResolvers bounds the number of repositories with a cached resolver
+	maxCachedResolvers = 1000
+)
+
+// AuthorMappingService resolves commit authors to canonical identities and users.
+// Identities are mapped by the .mailmap of the default branch first; server-side
+// mappings managed by the repository owner then assign commit emails to users.
+// Resolution is cached per repository by default branch head and mapping version.
+type AuthorMappingService struct {
+	mappingRepo repository.AuthorMappingRepository
+	userRepo    repository.UserRepository
+	gitService  service.GitService
+	log         *logger.Logger
+
+	mu        sync.Mutex
+	resolvers map[uuid.UUID]*authorResolver
+}
+
+// NewAuthorMappingService creates a new AuthorMappingService instance
+func NewAuthorMappingService(
+	mappingRepo repository.AuthorMappingRepository,
+	userRepo repository.UserRepository,
+	gitService service.GitService,
+) *AuthorMappingService {
+	return &AuthorMappingService{
+		mappingRepo: mappingRepo,
+		userRepo:    userRepo,
+		gitService:  gitService,
+		log:         logger.Get().WithFields(logger.Component("author-mapping-service")),
+		resolvers:   make(map[uuid.UUID]*authorResolver),
+	}
+}
+
+// ListMappings returns the server-side author mappings of a repository
+func (s *AuthorMappingService) ListMappings(ctx context.Context, repo *models.Repository) ([]*models.AuthorMapping, error) {
+	return s.mappingRepo.ListByRepository(ctx, repo.ID)
+}
+
+// SetMapping maps a commit email to the user with the given username
+func (s *AuthorMappingService) SetMapping(ctx context.Context, repo *models.Repository, email, username string) (*models.AuthorMapping, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if _, err := mail.ParseAddress(email); err != nil {
+		return nil, apperrors.BadRequest(fmt.Sprintf("invalid email %q", email), apperrors.ErrInvalidInput)
+	}
+
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil, apperrors.BadRequest(fmt.Sprintf("user %q does not exist", username), apperrors.ErrInvalidInput)
+		}
+		return nil, err
+	}
+
+	mapping := &models.AuthorMapping{
+		RepositoryID: repo.ID,
+		Email:        email,
+		UserID:       user.ID,
+	}
+	if err := s.mappingRepo.Upsert(ctx, mapping); err != nil {
+		s.log.WithContext(ctx).Error("Failed to save author mapping",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		return nil, err
+	}
+	mapping.User = *user
+
+	s.log.WithContext(ctx).Info("Author mapping saved",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("email", email),
+		logger.String("user", user.Username),
+	)
+	return mapping, nil
+}
+
+// DeleteMapping removes a server-side author mapping
+func (s *AuthorMappingService) DeleteMapping(ctx context.Context, repo *models.Repository, id uuid.UUID) error {
+	return s.mappingRepo.Delete(ctx, repo.ID, id)
+}
+
+// ResolveCommits sets the resolved author of commits. Commits are left
+// unresolved if the mapping cannot be loaded.
+func (s *AuthorMappingService) ResolveCommits(ctx context.Context, repo *models.Repository, commits []dto.CommitResponse) {
+	resolver, _, err := s.resolver(ctx, repo)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to load author mapping",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		return
+	}
+
+	for i := range commits {
+		author := resolver.resolve(ctx, commits[i].Author, commits[i].AuthorEmail)
+		commits[i].ResolvedAuthor = &author
+	}
+}
+
+// ListContributors returns the authors of the default branch with their commit
+// counts, merging identities that resolve to the same user or canonical email
+func (s *AuthorMappingService) ListContributors(ctx context.Context, repo *models.Repository) (*dto.ContributorListResponse, error) {
+	resolver, branch, err := s.resolver(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver.mu.Lock()
+	cached := resolver.contributors
+	resolver.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	byIdentity := make(map[string]*dto.ContributorResponse)
+	scanned, truncated, err := s.walkCommits(ctx, repo, branch, maxContributorCommits, func(c service.Commit) {
+		author := resolver.resolve(ctx, c.Author, c.AuthorEmail)
+		key := "email:" + strings.ToLower(author.Email)
+		if author.UserID != nil {
+			key = "user:" + author.UserID.String()
+		}
+
+		contributor, ok := byIdentity[key]
+		if !ok {
+			contributor = &dto.ContributorResponse{
+				Name:     author.Name,
+				Email:    author.Email,
+				UserID:   author.UserID,
+				Username: author.Username,
+			}
+			byIdentity[key] = contributor
+		}
+		contributor.Commits++
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.ContributorListResponse{
+		Contributors:   make([]dto.ContributorResponse, 0, len(byIdentity)),
+		CommitsScanned: scanned,
+		Truncated:      truncated,
+	}
+	for _, contributor := range byIdentity {
+		resp.Contributors = append(resp.Contributors, *contributor)
+	}
+	sort.Slice(resp.Contributors, func(i, j int) bool {
+		a, b := resp.Contributors[i], resp.Contributors[j]
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		return a.Email < b.Email
+	})
+	resp.Total = len(resp.Contributors)
+
+	resolver.mu.Lock()
+	resolver.contributors = resp
+	resolver.mu.Unlock()
+
+	return resp, nil
+}
+
+// ListUnmappedAuthors returns the author emails of recent default branch
+// history that resolve to no user, most frequent first
+func (s *AuthorMappingService) ListUnmappedAuthors(ctx context.Context, repo *models.Repository) (*dto.UnmappedAuthorListResponse, error) {
+	resolver, branch, err := s.resolver(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	byEmail := make(map[string]*dto.UnmappedAuthorResponse)
+	scanned, _, err := s.walkCommits(ctx, repo, branch, maxUnmappedScanCommits, func(c service.Commit) {
+		author := resolver.resolve(ctx, c.Author, c.AuthorEmail)
+		if author.UserID != nil || author.Email == "" {
+			return
+		}
+
+		key := strings.ToLower(author.Email)
+		unmapped, ok := byEmail[key]
+		if !ok {
+			// History is walked newest first, so the first name seen is the latest
+			unmapped = &dto.UnmappedAuthorResponse{
+				Email:    key,
+				Name:     author.Name,
+				LastSeen: c.AuthorDate,
+			}
+			byEmail[key] = unmapped
+		}
+		unmapped.Commits++
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.UnmappedAuthorListResponse{
+		Authors:        make([]dto.UnmappedAuthorResponse, 0, len(byEmail)),
+		CommitsScanned: scanned,
+	}
+	for _, unmapped := range byEmail {
+		resp.Authors = append(resp.Authors, *unmapped)
+	}
+	sort.Slice(resp.Authors, func(i, j int) bool {
+		a, b := resp.Authors[i], resp.Authors[j]
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		return a.Email < b.Email
+	})
+	resp.Total = len(resp.Authors)
+
+	return resp, nil
+}
+
+// resolver returns the cached author resolver of a repository, rebuilding it
+// when the default branch moved or the server-side mappings changed
+func (s *AuthorMappingService) resolver(ctx context.Context, repo *models.Repository) (*authorResolver, string, error) {
+	branch, head, err := s.defaultBranchHead(ctx, repo)
+	if err != nil {
+		return nil, "", err
+	}
+	key := fmt.Sprintf("%s/%d", head, repo.AuthorMappingsVersion)
+
+	s.mu.Lock()
+	cached, ok := s.resolvers[repo.ID]
+	s.mu.Unlock()
+	if ok && cached.key == key && time.Since(cached.createdAt) < authorResolverTTL {
+		return cached, branch, nil
+	}
+
+	resolver := &authorResolver{
+		key:       key,
+		createdAt: time.Now(),
+		userRepo:  s.userRepo,
+		mapped:    make(map[string]*models.User),
+		users:     make(map[string]*models.User),
+	}
+
+	if head != "" {
+		file, err := s.gitService.GetFileContent(ctx, repo.GitPath, head, mailmapPath)
+		if err == nil && !file.IsBinary {
+			resolver.mailmap = mailmap.Parse(file.Content)
+		}
+	}
+
+	mappings, err := s.mappingRepo.ListByRepository(ctx, repo.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, m := range mappings {
+		user := m.User
+		resolver.mapped[m.Email] = &user
+	}
+
+	s.mu.Lock()
+	if len(s.resolvers) >= maxCachedResolvers {
+		clear(s.resolvers)
+	}
+	s.resolvers[repo.ID] = resolver
+	s.mu.Unlock()
+
+	return resolver, branch, nil
+}
+
+// defaultBranchHead returns the default branch and its head commit. Both are
+// empty for repositories without commits.
+func (s *AuthorMappingService) defaultBranchHead(ctx context.Context, repo *models.Repository) (string, string, error) {
+	branchName := repo.DefaultBranch
+	if branchName == "" {
+		head, err := s.gitService.GetHEADBranch(ctx, repo.GitPath)
+		if err != nil {
+			return "", "", err
+		}
+		branchName = head
+	}
+
+	exists, err := s.gitService.BranchExists(ctx, repo.GitPath, branchName)
+	if err != nil {
+		return "", "", err
+	}
+	if !exists {
+		return "", "", nil
+	}
+
+	branch, err := s.gitService.GetBranch(ctx, repo.GitPath, branchName)
+	if err != nil {
+		return "", "", err
+	}
+	return branchName, branch.Hash, nil
+}
+
+// walkCommits calls fn for up to limit commits of a branch, newest first.
+// It returns the number of commits visited and whether history was cut off.
+func (s *AuthorMappingService) walkCommits(ctx context.Context, repo *models.Repository, branch string, limit int, fn func(service.Commit)) (int, bool, error) {
+	if branch == "" {
+		return 0, false, nil
+	}
+
+	scanned := 0
+	for scanned < limit {
+		commits, err := s.gitService.GetCommits(ctx, repo.GitPath, branch, min(authorCommitPageSize, limit-scanned), scanned)
+		if err != nil {
+			return scanned, false, err
+		}
+		for _, c := range commits {
+			fn(c)
+		}
+		scanned += len(commits)
+
+		if len(commits) < authorCommitPageSize {
+			return scanned, false, nil
+		}
+	}
+
+	// Check whether anything is left beyond the limit
+	more, err := s.gitService.GetCommits(ctx, repo.GitPath, branch, 1, scanned)
+	if err != nil {
+		return scanned, false, err
+	}
+	return scanned, len(more) > 0, nil
+}
+
+// authorResolver maps commit identities of one repository state to canonical
+// identities and users
+type authorResolver struct {
+	key       string // Default branch head and mapping version
+	createdAt time.Time
+	mailmap   *mailmap.Mailmap
+	mapped    map[string]*models.User // Server-side mappings by lowercased email
+	userRepo  repository.UserRepository
+
+	mu           sync.Mutex
+	users        map[string]*models.User // User lookups by email, nil if no user
+	contributors *dto.ContributorListResponse
+}
+
+// resolve returns the canonical identity of a commit author. Server-side
+// mappings are checked for both the commit email and the mailmap email before
+// falling back to the user owning the canonical email.
+func (r *authorResolver) resolve(ctx context.Context, name, email string) dto.CommitAuthorResponse {
+	canonicalName, canonicalEmail := r.mailmap.Map(name, email)
+	author := dto.CommitAuthorResponse{Name: canonicalName, Email: canonicalEmail}
+
+	user := r.mapped[strings.ToLower(email)]
+	if user == nil {
+		user = r.mapped[strings.ToLower(canonicalEmail)]
+	}
+	if user == nil {
+		user = r.findUser(ctx, canonicalEmail)
+	}
+
+	if user != nil {
+		author.UserID = &user.ID
+		author.Username = user.Username
+	}
+	return author
+}
+
+// findUser looks up the user owning an email, caching the result
+func (r *authorResolver) findUser(ctx context.Context, email string) *models.User {
+	if email == "" {
+		return nil
+	}
+	key := strings.ToLower(email)
+
+	r.mu.Lock()
+	user, ok := r.users[key]
+	r.mu.Unlock()
+	if ok {
+		return user
+	}
+
+	user, err := r.userRepo.FindByEmail(ctx, email)
+	if err != nil && key != email {
+		user, err = r.userRepo.FindByEmail(ctx, key)
+	}
+	if err != nil {
+		if !apperrors.IsNotFound(err) {
+			// Do not cache transient failures
+			return nil
+		}
+		user = nil
+	}
+
+	r.mu.Lock()
+	r.users[key] = user
+	r.mu.Unlock()
+	return user
+}
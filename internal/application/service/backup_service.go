@@ -0,0 +1,835 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// backupManifestVersion is the version of the manifest format written
+	backupManifestVersion = 1
+
+	// backupManifestFile is the name of the manifest in the backup directory
+	// of a repository
+	backupManifestFile = "manifest.json"
+
+	// backupPageSize is the number of repositories read at a time while
+	// backing up every repository
+	backupPageSize = 100
+)
+
+// Outcomes of the backup of a repository
+const (
+	BackupStatusWritten   = "written"   // A new bundle was written
+	BackupStatusUnchanged = "unchanged" // The refs did not change, the previous bundle was kept
+	BackupStatusFailed    = "failed"
+)
+
+// Outcomes of the restore of a repository
+const (
+	RestoreStatusRestored = "restored"
+	RestoreStatusSkipped  = "skipped" // The repository exists already
+	RestoreStatusFailed   = "failed"
+)
+
+// What a restore does with repositories that exist already
+const (
+	RestoreConflictSkip = "skip" // Leave them alone and restore the others
+	RestoreConflictFail = "fail" // Restore nothing
+)
+
+// BackupManifest describes the backup of a repository: its metadata and the
+// git bundle holding its refs and objects
+type BackupManifest struct {
+	Version       int                  `json:"version"`
+	CreatedAt     time.Time            `json:"created_at"`
+	Repository    BackupRepository     `json:"repository"`
+	Collaborators []BackupCollaborator `json:"collaborators"`
+	Bundle        *BackupBundle        `json:"bundle,omitempty"` // nil for a repository without refs
+}
+
+// BackupRepository is the metadata of a backed up repository. Mirror settings
+// are left out as they hold credentials.
+type BackupRepository struct {
+	ID                     string                    `json:"id"`
+	Owner                  string                    `json:"owner"`
+	Name                   string                    `json:"name"`
+	Description            string                    `json:"description"`
+	IsPrivate              bool                      `json:"is_private"`
+	DefaultBranch          string                    `json:"default_branch"`
+	ForkedFrom             string                    `json:"forked_from,omitempty"` // Full name of the parent repository
+	Topics                 []string                  `json:"topics"`
+	LicenseOverride        string                    `json:"license_override,omitempty"`
+	ProtectedTagPatterns   []string                  `json:"protected_tag_patterns,omitempty"`
+	ProtectedTagOverrides  []string                  `json:"protected_tag_overrides,omitempty"`
+	PushPolicy             *models.PushPolicy        `json:"push_policy,omitempty"`
+	PinnedLinks            []models.PinnedLink       `json:"pinned_links,omitempty"`
+	UploadPack             models.UploadPackSettings `json:"upload_pack"`
+	LargeFileHintsDisabled bool                      `json:"large_file_hints_disabled"`
+	QuotaBytes             *int64                    `json:"quota_bytes,omitempty"`
+	CreatedAt              time.Time                 `json:"created_at"`
+}
+
+// BackupCollaborator is a collaborator of a backed up repository
+type BackupCollaborator struct {
+	Username   string                `json:"username"`
+	Permission models.RepoPermission `json:"permission"`
+}
+
+// BackupBundle describes the git bundle of a backed up repository
+type BackupBundle struct {
+	File       string            `json:"file"` // Name of the bundle, next to the manifest
+	Size       int64             `json:"size"`
+	SHA256     string            `json:"sha256"`
+	Refs       map[string]string `json:"refs"`        // Refs in the bundle, name to object ID
+	RefsDigest string            `json:"refs_digest"` // SHA-256 of the refs, see refsDigest
+}
+
+// BackupResult is the outcome of the backup of a repository
+type BackupResult struct {
+	Repository string
+	Status     string
+	Size       int64  // Size of the bundle, 0 without refs
+	SHA256     string // Checksum of the bundle
+	Error      string
+}
+
+// RestoreResult is the outcome of the restore of a repository
+type RestoreResult struct {
+	Repository string
+	Status     string
+	Error      string
+	Warnings   []string // Metadata that could not be restored, such as collaborators without an account
+}
+
+// BackupService writes backups of repositories, a git bundle and a manifest
+// of their metadata each, to the default storage backend and restores them.
+// A backup only writes a new bundle when the refs of a repository changed
+// since the previous one.
+type BackupService struct {
+	repoRepo         repository.RepoRepository
+	userRepo         repository.UserRepository
+	collaboratorRepo repository.CollaboratorRepository
+	gitService       service.GitService
+	storage          service.StorageService
+	repoStorage      *StorageBackendService
+	audit            service.AuditRecorder
+	publisher        events.Publisher
+	cfg              *config.BackupsConfig
+	log              *logger.Logger
+}
+
+// NewBackupService creates a new BackupService instance. Backups are written
+// to storage under the configured directory.
+func NewBackupService(
+	repoRepo repository.RepoRepository,
+	userRepo repository.UserRepository,
+	collaboratorRepo repository.CollaboratorRepository,
+	gitService service.GitService,
+	storage service.StorageService,
+	repoStorage *StorageBackendService,
+	audit service.AuditRecorder,
+	publisher events.Publisher,
+	cfg *config.BackupsConfig,
+) *BackupService {
+	return &BackupService{
+		repoRepo:         repoRepo,
+		userRepo:         userRepo,
+		collaboratorRepo: collaboratorRepo,
+		gitService:       gitService,
+		storage:          storage,
+		repoStorage:      repoStorage,
+		audit:            audit,
+		publisher:        publisher,
+		cfg:              cfg,
+		log:              logger.Get().WithFields(logger.Component("backups")),
+	}
+}
+
+// Backup backs up the repository owner/name, every repository of owner when
+// name is empty, or every repository when both are empty. A failure to back
+// up one repository is reported in its result and does not stop the others.
+func (s *BackupService) Backup(ctx context.Context, owner, name string, actor *models.User) ([]BackupResult, error) {
+	if err := validateBackupScope(owner, name); err != nil {
+		return nil, err
+	}
+	repos, err := s.scope(ctx, owner, name)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BackupResult, 0, len(repos))
+	for _, repo := range repos {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		result := s.backupRepository(ctx, repo)
+		if result.Status == BackupStatusFailed {
+			s.log.WithContext(ctx).Error("Failed to back up repository",
+				logger.String("repository", result.Repository),
+				logger.String("error", result.Error),
+			)
+		}
+		s.record(actor, "backup.create", result.Repository, result.Status, nil)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// scope returns the repositories a backup covers
+func (s *BackupService) scope(ctx context.Context, owner, name string) ([]*models.Repository, error) {
+	if name != "" {
+		repo, err := s.repoRepo.FindByOwnerUsernameAndName(ctx, owner, name)
+		if err != nil {
+			return nil, err
+		}
+		return []*models.Repository{repo}, nil
+	}
+
+	if owner != "" {
+		user, err := s.userRepo.FindByUsername(ctx, owner)
+		if err != nil {
+			return nil, err
+		}
+		repos, err := s.repoRepo.FindByOwner(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories of %s: %w", owner, err)
+		}
+		return repos, nil
+	}
+
+	var repos []*models.Repository
+	for offset := 0; ; offset += backupPageSize {
+		page, err := s.repoRepo.ListAll(ctx, backupPageSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories: %w", err)
+		}
+		repos = append(repos, page...)
+		if len(page) < backupPageSize {
+			return repos, nil
+		}
+	}
+}
+
+// backupRepository writes the bundle, if the refs changed, and the manifest of
+// a repository
+func (s *BackupService) backupRepository(ctx context.Context, repo *models.Repository) BackupResult {
+	result := BackupResult{Repository: repo.GetFullName()}
+	fail := func(err error) BackupResult {
+		result.Status = BackupStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	release, err := s.repoStorage.AcquireRepository(repo)
+	if err != nil {
+		return fail(err)
+	}
+	defer release()
+
+	allRefs, err := s.gitService.GetRefs(ctx, repo.GitPath)
+	if err != nil {
+		return fail(fmt.Errorf("failed to read refs: %w", err))
+	}
+	refs := make(map[string]string, len(allRefs))
+	for name, value := range allRefs {
+		if strings.HasPrefix(name, "refs/") {
+			refs[name] = value
+		}
+	}
+
+	dir := s.repoDir(repo.Owner.Username, repo.Name)
+	previous, err := s.readManifest(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		// A damaged manifest is replaced by the new one
+		s.log.WithContext(ctx).Warn("Ignoring unreadable backup manifest",
+			logger.Error(err),
+			logger.String("repository", result.Repository),
+		)
+		previous = nil
+	}
+
+	manifest := &BackupManifest{
+		Version:    backupManifestVersion,
+		CreatedAt:  time.Now().UTC(),
+		Repository: backupRepositoryFromModel(repo),
+	}
+	if repo.ForkedFromID != nil && repo.ForkedFrom == nil {
+		if parent, err := s.repoRepo.FindByID(ctx, *repo.ForkedFromID); err == nil {
+			manifest.Repository.ForkedFrom = parent.GetFullName()
+		}
+	}
+	if manifest.Collaborators, err = s.backupCollaborators(ctx, repo); err != nil {
+		return fail(err)
+	}
+
+	result.Status = BackupStatusWritten
+	if len(refs) > 0 {
+		digest := refsDigest(refs)
+		if previous != nil && previous.Bundle != nil && previous.Bundle.RefsDigest == digest && s.bundleExists(dir, previous.Bundle) {
+			manifest.Bundle = previous.Bundle
+			result.Status = BackupStatusUnchanged
+		} else {
+			bundle, err := s.writeBundle(ctx, repo, dir, digest)
+			if err != nil {
+				return fail(err)
+			}
+			bundle.Refs = refs
+			manifest.Bundle = bundle
+		}
+		result.Size = manifest.Bundle.Size
+		result.SHA256 = manifest.Bundle.SHA256
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fail(fmt.Errorf("failed to encode manifest: %w", err))
+	}
+	if err := s.storage.WriteFile(path.Join(dir, backupManifestFile), data); err != nil {
+		return fail(fmt.Errorf("failed to write manifest: %w", err))
+	}
+
+	// The previous bundle is only removed once the manifest no longer refers to it
+	if previous != nil && previous.Bundle != nil && (manifest.Bundle == nil || previous.Bundle.File != manifest.Bundle.File) {
+		if err := s.storage.DeleteFile(path.Join(dir, previous.Bundle.File)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			s.log.WithContext(ctx).Warn("Failed to delete previous backup bundle",
+				logger.Error(err),
+				logger.String("repository", result.Repository),
+				logger.String("file", previous.Bundle.File),
+			)
+		}
+	}
+	return result
+}
+
+// writeBundle streams the bundle of a repository to the storage, computing
+// its checksum on the way
+func (s *BackupService) writeBundle(ctx context.Context, repo *models.Repository, dir, digest string) (*BackupBundle, error) {
+	// Named after the refs so a new bundle never overwrites the one the
+	// current manifest refers to
+	file := digest[:16] + ".bundle"
+	bundlePath := path.Join(dir, file)
+
+	w, err := s.storage.CreateFile(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bundle: %w", err)
+	}
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(w, hash)}
+	err = s.gitService.CreateBundle(ctx, repo.GitPath, counter)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if deleteErr := s.storage.DeleteFile(bundlePath); deleteErr != nil && !errors.Is(deleteErr, fs.ErrNotExist) {
+			s.log.WithContext(ctx).Warn("Failed to delete partial backup bundle",
+				logger.Error(deleteErr),
+				logger.String("path", bundlePath),
+			)
+		}
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	return &BackupBundle{
+		File:       file,
+		Size:       counter.n,
+		SHA256:     hex.EncodeToString(hash.Sum(nil)),
+		RefsDigest: digest,
+	}, nil
+}
+
+// bundleExists returns true if the bundle a manifest refers to is still in
+// the storage with the size recorded
+func (s *BackupService) bundleExists(dir string, bundle *BackupBundle) bool {
+	size, err := s.storage.Size(path.Join(dir, bundle.File))
+	return err == nil && size == bundle.Size
+}
+
+// backupCollaborators lists the collaborators of a repository for its manifest
+func (s *BackupService) backupCollaborators(ctx context.Context, repo *models.Repository) ([]BackupCollaborator, error) {
+	collaborators, err := s.collaboratorRepo.ListByRepository(ctx, repo.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collaborators: %w", err)
+	}
+	result := make([]BackupCollaborator, 0, len(collaborators))
+	for _, collaborator := range collaborators {
+		result = append(result, BackupCollaborator{
+			Username:   collaborator.User.Username,
+			Permission: collaborator.Permission,
+		})
+	}
+	return result, nil
+}
+
+// Restore recreates the backed up repository owner/name, the repositories of
+// owner when name is empty, or every backed up repository when both are
+// empty. Repositories that exist already are skipped, or fail the whole
+// restore before anything is restored when onConflict is RestoreConflictFail.
+// Bundles are checked against the checksums of their manifest first.
+func (s *BackupService) Restore(ctx context.Context, owner, name, onConflict string, actor *models.User) ([]RestoreResult, error) {
+	if onConflict == "" {
+		onConflict = RestoreConflictSkip
+	}
+	if onConflict != RestoreConflictSkip && onConflict != RestoreConflictFail {
+		return nil, apperrors.BadRequest("on_conflict must be skip or fail", apperrors.ErrInvalidInput)
+	}
+	if err := validateBackupScope(owner, name); err != nil {
+		return nil, err
+	}
+
+	dirs, err := s.backedUp(owner, name)
+	if err != nil {
+		return nil, err
+	}
+
+	type pending struct {
+		manifest *BackupManifest
+		owner    *models.User
+		result   RestoreResult
+	}
+	var (
+		all       []*pending
+		conflicts []string
+	)
+	for _, dir := range dirs {
+		p := &pending{result: RestoreResult{Repository: dir.owner + "/" + dir.name}}
+		all = append(all, p)
+
+		manifest, err := s.readManifest(s.repoDir(dir.owner, dir.name))
+		if err != nil {
+			p.result.Status = RestoreStatusFailed
+			p.result.Error = fmt.Sprintf("failed to read manifest: %v", err)
+			continue
+		}
+		if manifest.Version > backupManifestVersion {
+			p.result.Status = RestoreStatusFailed
+			p.result.Error = fmt.Sprintf("unsupported manifest version %d", manifest.Version)
+			continue
+		}
+		p.manifest = manifest
+
+		user, err := s.userRepo.FindByUsername(ctx, manifest.Repository.Owner)
+		if err != nil {
+			p.result.Status = RestoreStatusFailed
+			if apperrors.IsNotFound(err) {
+				p.result.Error = fmt.Sprintf("owner %s does not exist", manifest.Repository.Owner)
+			} else {
+				p.result.Error = fmt.Sprintf("failed to find owner: %v", err)
+			}
+			continue
+		}
+		p.owner = user
+
+		exists, err := s.repoRepo.ExistsByOwnerAndName(ctx, user.ID, manifest.Repository.Name)
+		if err != nil {
+			p.result.Status = RestoreStatusFailed
+			p.result.Error = fmt.Sprintf("failed to check repository existence: %v", err)
+			continue
+		}
+		if exists {
+			p.result.Status = RestoreStatusSkipped
+			p.result.Error = "repository already exists"
+			conflicts = append(conflicts, p.result.Repository)
+		}
+	}
+
+	if len(conflicts) > 0 && onConflict == RestoreConflictFail {
+		return nil, apperrors.Conflict(fmt.Sprintf("repositories already exist: %s", strings.Join(conflicts, ", ")), apperrors.ErrRepositoryExists)
+	}
+
+	results := make([]RestoreResult, 0, len(all))
+	for _, p := range all {
+		if p.result.Status == "" {
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+			p.result.Warnings, err = s.restoreRepository(ctx, p.manifest, p.owner)
+			if err != nil {
+				p.result.Status = RestoreStatusFailed
+				p.result.Error = err.Error()
+				s.log.WithContext(ctx).Error("Failed to restore repository",
+					logger.Error(err),
+					logger.String("repository", p.result.Repository),
+				)
+			} else {
+				p.result.Status = RestoreStatusRestored
+			}
+		}
+		s.record(actor, "backup.restore", p.result.Repository, p.result.Status, map[string]string{"on_conflict": onConflict})
+		results = append(results, p.result)
+	}
+	return results, nil
+}
+
+// backupDir is the owner and name of a backed up repository
+type backupDir struct {
+	owner, name string
+}
+
+// backedUp lists the backed up repositories a restore covers
+func (s *BackupService) backedUp(owner, name string) ([]backupDir, error) {
+	if name != "" {
+		if !s.hasManifest(owner, name) {
+			return nil, apperrors.NotFound("backup", apperrors.ErrNotFound)
+		}
+		return []backupDir{{owner: owner, name: name}}, nil
+	}
+
+	owners := []string{owner}
+	if owner == "" {
+		entries, err := s.readDir(s.cfg.Directory)
+		if err != nil {
+			return nil, err
+		}
+		owners = entries
+	}
+
+	var dirs []backupDir
+	for _, o := range owners {
+		names, err := s.readDir(path.Join(s.cfg.Directory, o))
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range names {
+			if s.hasManifest(o, n) {
+				dirs = append(dirs, backupDir{owner: o, name: n})
+			}
+		}
+	}
+	if owner != "" && len(dirs) == 0 {
+		return nil, apperrors.NotFound("backup", apperrors.ErrNotFound)
+	}
+	return dirs, nil
+}
+
+// readDir returns the sorted names of the subdirectories of a backup
+// directory, none if it does not exist
+func (s *BackupService) readDir(dir string) ([]string, error) {
+	exists, err := s.storage.Exists(dir)
+	if err != nil {
+		return nil, apperrors.StorageError("list backups", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	entries, err := s.storage.ReadDir(dir)
+	if err != nil {
+		return nil, apperrors.StorageError("list backups", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// hasManifest returns true if a repository has been backed up
+func (s *BackupService) hasManifest(owner, name string) bool {
+	exists, err := s.storage.Exists(path.Join(s.repoDir(owner, name), backupManifestFile))
+	return err == nil && exists
+}
+
+// restoreRepository recreates a repository from its backup and returns the
+// metadata that could not be restored
+func (s *BackupService) restoreRepository(ctx context.Context, manifest *BackupManifest, owner *models.User) ([]string, error) {
+	meta := manifest.Repository
+	dir := s.repoDir(meta.Owner, meta.Name)
+
+	var (
+		bundleFile string
+		size       int64
+	)
+	if manifest.Bundle != nil {
+		file, err := s.verifiedBundle(dir, manifest.Bundle)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(file)
+		bundleFile = file
+		size = manifest.Bundle.Size
+	}
+
+	backendName, backend, err := s.repoStorage.Place(owner.Username, size)
+	if err != nil {
+		return nil, err
+	}
+	gitPath := backend.GetRepoPath(owner.Username, meta.Name)
+
+	if exists, err := backend.Exists(gitPath); err != nil {
+		return nil, apperrors.StorageError("check repository path", err)
+	} else if exists {
+		return nil, fmt.Errorf("repository directory %s exists already", gitPath)
+	}
+
+	repo := &models.Repository{
+		Name:                   meta.Name,
+		OwnerID:                owner.ID,
+		IsPrivate:              meta.IsPrivate,
+		Description:            meta.Description,
+		DefaultBranch:          meta.DefaultBranch,
+		GitPath:                gitPath,
+		StorageBackend:         backendName,
+		Topics:                 meta.Topics,
+		LicenseOverride:        meta.LicenseOverride,
+		ProtectedTagPatterns:   meta.ProtectedTagPatterns,
+		ProtectedTagOverrides:  meta.ProtectedTagOverrides,
+		PushPolicy:             meta.PushPolicy,
+		PinnedLinks:            meta.PinnedLinks,
+		UploadPack:             meta.UploadPack,
+		LargeFileHintsDisabled: meta.LargeFileHintsDisabled,
+		QuotaBytes:             meta.QuotaBytes,
+	}
+	if repo.Topics == nil {
+		repo.Topics = []string{}
+	}
+
+	var warnings []string
+	if meta.ForkedFrom != "" {
+		parentOwner, parentName, _ := strings.Cut(meta.ForkedFrom, "/")
+		if parent, err := s.repoRepo.FindByOwnerUsernameAndName(ctx, parentOwner, parentName); err == nil {
+			repo.ForkedFromID = &parent.ID
+		} else {
+			warnings = append(warnings, fmt.Sprintf("parent repository %s does not exist, restored as a standalone repository", meta.ForkedFrom))
+		}
+	}
+
+	if bundleFile != "" {
+		if err := s.gitService.RestoreBundle(ctx, gitPath, bundleFile); err != nil {
+			s.cleanup(ctx, backend, gitPath)
+			return nil, err
+		}
+		if err := s.verifyRefs(ctx, gitPath, manifest.Bundle.Refs); err != nil {
+			s.cleanup(ctx, backend, gitPath)
+			return nil, err
+		}
+		if err := s.gitService.SetHEADBranch(ctx, gitPath, meta.DefaultBranch); err != nil {
+			s.cleanup(ctx, backend, gitPath)
+			return nil, fmt.Errorf("failed to set default branch: %w", err)
+		}
+	} else if err := s.gitService.InitRepository(ctx, gitPath, true, meta.DefaultBranch); err != nil {
+		return nil, fmt.Errorf("failed to initialize git repository: %w", err)
+	}
+	if err := writeUploadPackConfig(ctx, s.gitService, gitPath, repo.UploadPack); err != nil {
+		s.log.WithContext(ctx).Warn("Failed to write upload-pack settings to git config",
+			logger.Error(err),
+			logger.String("git_path", gitPath),
+		)
+	}
+
+	if err := backend.SyncToRemote(gitPath); err != nil {
+		s.log.WithContext(ctx).Warn("Failed to sync restored repository to remote storage",
+			logger.Error(err),
+			logger.String("git_path", gitPath),
+		)
+	}
+
+	if err := s.repoRepo.Create(ctx, repo); err != nil {
+		s.cleanup(ctx, backend, gitPath)
+		return nil, fmt.Errorf("failed to create repository: %w", err)
+	}
+	repo.Owner = *owner
+
+	for _, c := range manifest.Collaborators {
+		user, err := s.userRepo.FindByUsername(ctx, c.Username)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("collaborator %s does not exist", c.Username))
+			continue
+		}
+		if err := s.collaboratorRepo.Upsert(ctx, &models.RepositoryCollaborator{
+			RepositoryID: repo.ID,
+			UserID:       user.ID,
+			Permission:   c.Permission,
+		}); err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to add collaborator %s: %v", c.Username, err))
+		}
+	}
+
+	s.log.WithContext(ctx).Info("Repository restored from backup",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("owner", owner.Username),
+		logger.String("name", repo.Name),
+		logger.String("git_path", gitPath),
+	)
+
+	s.publisher.Publish(events.RepositoryCreated{
+		RepositoryID: repo.ID,
+		OwnerID:      repo.OwnerID,
+		Owner:        owner.Username,
+		Name:         repo.Name,
+		IsPrivate:    repo.IsPrivate,
+		Source:       "restore",
+	})
+	return warnings, nil
+}
+
+// verifiedBundle copies a bundle from the storage to a local temporary file,
+// checking its size and checksum, and returns the path of the file
+func (s *BackupService) verifiedBundle(dir string, bundle *BackupBundle) (string, error) {
+	r, err := s.storage.OpenFile(path.Join(dir, bundle.File))
+	if err != nil {
+		return "", fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer r.Close()
+
+	tmp, err := os.CreateTemp("", "restore-*.bundle")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary bundle: %w", err)
+	}
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		switch sum := hex.EncodeToString(hash.Sum(nil)); {
+		case n != bundle.Size:
+			err = fmt.Errorf("bundle is %d bytes, the manifest records %d", n, bundle.Size)
+		case sum != bundle.SHA256:
+			err = fmt.Errorf("bundle checksum %s does not match the manifest (%s)", sum, bundle.SHA256)
+		}
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// verifyRefs checks that a restored repository holds the refs of its manifest
+func (s *BackupService) verifyRefs(ctx context.Context, gitPath string, want map[string]string) error {
+	got, err := s.gitService.GetRefs(ctx, gitPath)
+	if err != nil {
+		return fmt.Errorf("failed to read restored refs: %w", err)
+	}
+	for name, hash := range want {
+		if got[name] != hash {
+			return fmt.Errorf("restored ref %s is %q, the manifest records %s", name, got[name], hash)
+		}
+	}
+	return nil
+}
+
+// cleanup removes a partially restored repository
+func (s *BackupService) cleanup(ctx context.Context, backend service.StorageService, gitPath string) {
+	if err := backend.DeleteDirectory(gitPath); err != nil {
+		s.log.WithContext(ctx).Error("Failed to clean up repository after failed restore",
+			logger.Error(err),
+			logger.String("git_path", gitPath),
+		)
+	}
+}
+
+// readManifest reads the manifest of a backed up repository
+func (s *BackupService) readManifest(dir string) (*BackupManifest, error) {
+	data, err := s.storage.ReadFile(path.Join(dir, backupManifestFile))
+	if err != nil {
+		if exists, existsErr := s.storage.Exists(path.Join(dir, backupManifestFile)); existsErr == nil && !exists {
+			return nil, fs.ErrNotExist
+		}
+		return nil, err
+	}
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// repoDir returns the backup directory of a repository
+func (s *BackupService) repoDir(owner, name string) string {
+	return path.Join(s.cfg.Directory, owner, name)
+}
+
+// record audits a backup or restore of a repository
+func (s *BackupService) record(actor *models.User, action, resource, outcome string, fields map[string]string) {
+	entry := service.AuditEntry{
+		Category: "admin",
+		Action:   action,
+		Resource: resource,
+		Outcome:  outcome,
+		Fields:   fields,
+	}
+	if actor != nil {
+		entry.ActorID = actor.ID.String()
+		entry.Actor = actor.Username
+	}
+	s.audit.Record(entry)
+}
+
+// validateBackupScope checks the owner and name selecting the repositories of
+// a backup or restore, which are also directory names
+func validateBackupScope(owner, name string) error {
+	if name != "" && owner == "" {
+		return apperrors.BadRequest("an owner is required to select a single repository", apperrors.ErrInvalidInput)
+	}
+	for _, part := range []string{owner, name} {
+		if strings.ContainsAny(part, `/\`) || strings.HasPrefix(part, ".") {
+			return apperrors.BadRequest("invalid owner or repository name", apperrors.ErrInvalidInput)
+		}
+	}
+	return nil
+}
+
+// backupRepositoryFromModel converts a repository to its manifest entry
+func backupRepositoryFromModel(repo *models.Repository) BackupRepository {
+	meta := BackupRepository{
+		ID:                     repo.ID.String(),
+		Owner:                  repo.Owner.Username,
+		Name:                   repo.Name,
+		Description:            repo.Description,
+		IsPrivate:              repo.IsPrivate,
+		DefaultBranch:          repo.DefaultBranch,
+		Topics:                 repo.Topics,
+		LicenseOverride:        repo.LicenseOverride,
+		ProtectedTagPatterns:   repo.ProtectedTagPatterns,
+		ProtectedTagOverrides:  repo.ProtectedTagOverrides,
+		PushPolicy:             repo.PushPolicy,
+		PinnedLinks:            repo.PinnedLinks,
+		UploadPack:             repo.UploadPack,
+		LargeFileHintsDisabled: repo.LargeFileHintsDisabled,
+		QuotaBytes:             repo.QuotaBytes,
+		CreatedAt:              repo.CreatedAt,
+	}
+	if repo.ForkedFrom != nil {
+		meta.ForkedFrom = repo.ForkedFrom.GetFullName()
+	}
+	return meta
+}
+
+// refsDigest returns the SHA-256 of refs, each as a "name object" line in
+// name order
+func refsDigest(refs map[string]string) string {
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hash := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(hash, "%s %s\n", name, refs[name])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/config"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// BadgeProxyPath is the endpoint that serves proxied badges
+const BadgeProxyPath = "/api/badge-proxy"
+
+// maxCachedBadges bounds the number of badges kept in memory
+const maxCachedBadges = 1024
+
+// maxBadgeRedirects is the number of upstream redirects followed per fetch
+const maxBadgeRedirects = 3
+
+// ErrBadgeUpstream indicates the upstream badge host failed or returned an unusable response
+var ErrBadgeUpstream = errors.New("badge upstream error")
+
+// allowedBadgeContentTypes are the upstream content types the proxy re-serves
+var allowedBadgeContentTypes = map[string]bool{
+	"image/svg+xml": true,
+	"image/png":     true,
+}
+
+// badgeSrcPattern matches src attributes of images in rendered HTML
+var badgeSrcPattern = regexp.MustCompile(`(<img\b[^>]*?\bsrc=)(["'])(https://[^"']+)(["'])`)
+
+// Badge is a cached third-party badge image
+type Badge struct {
+	Content     []byte
+	ContentType string
+	ExpiresAt   time.Time
+}
+
+// BadgeProxyService fetches and caches third-party README badges
+type BadgeProxyService struct {
+	config *config.BadgeProxyConfig
+	client *http.Client
+	log    *logger.Logger
+
+	cache   map[string]*Badge
+	cacheMu sync.RWMutex
+}
+
+// NewBadgeProxyService creates a new BadgeProxyService instance
+func NewBadgeProxyService(cfg *config.BadgeProxyConfig) *BadgeProxyService {
+	s := &BadgeProxyService{
+		config: cfg,
+		log:    logger.Get().WithFields(logger.Component("badge-proxy-service")),
+		cache:  make(map[string]*Badge),
+	}
+
+	// No cookie jar: upstream cookies are never stored or sent
+	s.client = &http.Client{
+		Timeout: cfg.Timeout(),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxBadgeRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxBadgeRedirects)
+			}
+			if _, err := s.validateURL(req.URL.String()); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	return s
+}
+
+// IsEnabled returns true if the badge proxy is enabled
+func (s *BadgeProxyService) IsEnabled() bool {
+	return s.config.Enabled
+}
+
+// GetBadge returns the badge at rawURL, serving it from cache when still fresh
+func (s *BadgeProxyService) GetBadge(ctx context.Context, rawURL string) (*Badge, error) {
+	if !s.config.Enabled {
+		return nil, apperrors.NotFound("badge proxy", apperrors.ErrNotFound)
+	}
+
+	u, err := s.validateURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	key := u.String()
+
+	s.cacheMu.RLock()
+	badge, ok := s.cache[key]
+	s.cacheMu.RUnlock()
+	if ok && time.Now().Before(badge.ExpiresAt) {
+		return badge, nil
+	}
+
+	badge, err = s.fetch(ctx, u)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to fetch badge",
+			logger.Error(err),
+			logger.String("host", u.Host),
+		)
+		return nil, err
+	}
+
+	s.store(key, badge)
+	return badge, nil
+}
+
+// RewriteBadgeURLs rewrites image URLs pointing at allowed badge hosts in rendered
+// README HTML so they are served through the proxy. HTML is returned unchanged
+// when the proxy is disabled.
+func (s *BadgeProxyService) RewriteBadgeURLs(html string) string {
+	if !s.config.Enabled {
+		return html
+	}
+
+	return badgeSrcPattern.ReplaceAllStringFunc(html, func(match string) string {
+		parts := badgeSrcPattern.FindStringSubmatch(match)
+		if _, err := s.validateURL(parts[3]); err != nil {
+			return match
+		}
+		return parts[1] + parts[2] + BadgeProxyPath + "?url=" + url.QueryEscape(parts[3]) + parts[4]
+	})
+}
+
+// validateURL checks that a badge URL uses HTTPS and points at an allowed host
+func (s *BadgeProxyService) validateURL(rawURL string) (*url.URL, error) {
+	if rawURL == "" {
+		return nil, apperrors.BadRequest("badge url is required", apperrors.ErrInvalidInput)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, apperrors.BadRequest("invalid badge url", apperrors.ErrInvalidInput)
+	}
+	if u.Scheme != "https" {
+		return nil, apperrors.BadRequest("badge url must use https", apperrors.ErrInvalidInput)
+	}
+	if u.User != nil || u.Port() != "" {
+		return nil, apperrors.BadRequest("badge url must not contain credentials or a port", apperrors.ErrInvalidInput)
+	}
+	if !s.config.IsAllowedHost(u.Hostname()) {
+		return nil, apperrors.Forbidden(fmt.Sprintf("badge host '%s' is not allowed", u.Hostname()), apperrors.ErrForbidden)
+	}
+
+	u.Fragment = ""
+	return u, nil
+}
+
+// fetch downloads a badge from upstream enforcing the size limit and content type
+func (s *BadgeProxyService) fetch(ctx context.Context, u *url.URL) (*Badge, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadgeUpstream, err)
+	}
+	req.Header.Set("Accept", "image/svg+xml,image/png")
+	req.Header.Set("User-Agent", "stasis-badge-proxy")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadgeUpstream, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: upstream returned status %d", ErrBadgeUpstream, resp.StatusCode)
+	}
+
+	contentType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !allowedBadgeContentTypes[contentType] {
+		return nil, fmt.Errorf("%w: unsupported content type %q", ErrBadgeUpstream, resp.Header.Get("Content-Type"))
+	}
+
+	maxSize := s.config.GetMaxSize()
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadgeUpstream, err)
+	}
+	if int64(len(content)) > maxSize {
+		return nil, fmt.Errorf("%w: badge exceeds %d bytes", ErrBadgeUpstream, maxSize)
+	}
+
+	return &Badge{
+		Content:     content,
+		ContentType: contentType,
+		ExpiresAt:   time.Now().Add(s.cacheTTL(resp.Header.Get("Cache-Control"))),
+	}, nil
+}
+
+// cacheTTL derives the cache lifetime from upstream Cache-Control, clamped to the configured range
+func (s *BadgeProxyService) cacheTTL(cacheControl string) time.Duration {
+	ttl := s.config.MinTTL()
+
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if !strings.EqualFold(name, "max-age") && !strings.EqualFold(name, "s-maxage") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+		if err != nil {
+			continue
+		}
+		if d := time.Duration(seconds) * time.Second; d > ttl {
+			ttl = d
+		}
+	}
+
+	return min(ttl, s.config.MaxTTL())
+}
+
+// store caches a badge, evicting expired entries and then the entry closest to
+// expiry when the cache is full
+func (s *BadgeProxyService) store(key string, badge *Badge) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	if _, exists := s.cache[key]; !exists && len(s.cache) >= maxCachedBadges {
+		now := time.Now()
+		var oldestKey string
+		var oldest time.Time
+		for k, b := range s.cache {
+			if now.After(b.ExpiresAt) {
+				delete(s.cache, k)
+				continue
+			}
+			if oldestKey == "" || b.ExpiresAt.Before(oldest) {
+				oldestKey, oldest = k, b.ExpiresAt
+			}
+		}
+		if len(s.cache) >= maxCachedBadges {
+			delete(s.cache, oldestKey)
+		}
+	}
+
+	s.cache[key] = badge
+}
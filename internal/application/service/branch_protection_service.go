@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// maxBranchProtections is the maximum number of branch protections per repository
+	maxBranchProtections = 50
+
+	// maxBranchPatternLength is the longest branch protection pattern allowed
+	maxBranchPatternLength = 255
+
+	// branchRefPrefix is the ref namespace of branches
+	branchRefPrefix = "refs/heads/"
+)
+
+// BranchProtectionService guards branches matching per-repository patterns.
+// Protections apply to every pusher, repository administrators included,
+// except that require_admin leaves pushing to administrators only.
+type BranchProtectionService struct {
+	protectionRepo repository.BranchProtectionRepository
+	repoService    *RepoService
+	log            *logger.Logger
+}
+
+// NewBranchProtectionService creates a new BranchProtectionService instance
+func NewBranchProtectionService(
+	protectionRepo repository.BranchProtectionRepository,
+	repoService *RepoService,
+) *BranchProtectionService {
+	return &BranchProtectionService{
+		protectionRepo: protectionRepo,
+		repoService:    repoService,
+		log:            logger.Get().WithFields(logger.Component("branch-protection-service")),
+	}
+}
+
+// ListProtections returns the branch protections of a repository
+func (s *BranchProtectionService) ListProtections(ctx context.Context, repo *models.Repository) ([]*models.BranchProtection, error) {
+	return s.protectionRepo.ListByRepository(ctx, repo.ID)
+}
+
+// GetProtection returns a branch protection of a repository
+func (s *BranchProtectionService) GetProtection(ctx context.Context, repo *models.Repository, id uuid.UUID) (*models.BranchProtection, error) {
+	return s.protectionRepo.FindByID(ctx, repo.ID, id)
+}
+
+// CreateProtection validates and stores a new branch protection of a repository
+func (s *BranchProtectionService) CreateProtection(ctx context.Context, repo *models.Repository, protection *models.BranchProtection) error {
+	pattern, err := normalizeBranchPattern(protection.Pattern)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.protectionRepo.ListByRepository(ctx, repo.ID)
+	if err != nil {
+		return err
+	}
+	if len(existing) >= maxBranchProtections {
+		return apperrors.BadRequest(fmt.Sprintf("at most %d branch protections are allowed", maxBranchProtections), apperrors.ErrInvalidInput)
+	}
+	if hasBranchPattern(existing, pattern, uuid.Nil) {
+		return apperrors.Conflict("branch protection already exists", apperrors.ErrBranchProtectionExists)
+	}
+
+	protection.RepositoryID = repo.ID
+	protection.Pattern = pattern
+	if err := s.protectionRepo.Create(ctx, protection); err != nil {
+		if !apperrors.IsConflict(err) {
+			s.log.WithContext(ctx).Error("Failed to create branch protection",
+				logger.Error(err),
+				logger.String("repo_id", repo.ID.String()),
+			)
+		}
+		return err
+	}
+
+	s.log.WithContext(ctx).Info("Branch protection created",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("pattern", pattern),
+	)
+	return nil
+}
+
+// UpdateProtection replaces the pattern and rules of a branch protection
+func (s *BranchProtectionService) UpdateProtection(ctx context.Context, repo *models.Repository, id uuid.UUID, changes *models.BranchProtection) (*models.BranchProtection, error) {
+	pattern, err := normalizeBranchPattern(changes.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.protectionRepo.ListByRepository(ctx, repo.ID)
+	if err != nil {
+		return nil, err
+	}
+	if hasBranchPattern(existing, pattern, id) {
+		return nil, apperrors.Conflict("branch protection already exists", apperrors.ErrBranchProtectionExists)
+	}
+
+	protection, err := s.protectionRepo.FindByID(ctx, repo.ID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	protection.Pattern = pattern
+	protection.BlockForcePush = changes.BlockForcePush
+	protection.BlockDeletion = changes.BlockDeletion
+	protection.RequireAdmin = changes.RequireAdmin
+	if err := s.protectionRepo.Update(ctx, protection); err != nil {
+		if !apperrors.IsConflict(err) {
+			s.log.WithContext(ctx).Error("Failed to update branch protection",
+				logger.Error(err),
+				logger.String("repo_id", repo.ID.String()),
+			)
+		}
+		return nil, err
+	}
+
+	s.log.WithContext(ctx).Info("Branch protection updated",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("pattern", pattern),
+	)
+	return protection, nil
+}
+
+// DeleteProtection removes a branch protection of a repository
+func (s *BranchProtectionService) DeleteProtection(ctx context.Context, repo *models.Repository, id uuid.UUID) error {
+	if err := s.protectionRepo.Delete(ctx, repo.ID, id); err != nil {
+		return err
+	}
+
+	s.log.WithContext(ctx).Info("Branch protection deleted",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("protection_id", id.String()),
+	)
+	return nil
+}
+
+// RefCommandCheck returns a push check enforcing the branch protections of
+// the repository. Deletions and pushes by non-administrators are refused
+// before the push is read; force pushes once its commits have been received.
+// transport names the push channel (http, ssh) for the logs.
+func (s *BranchProtectionService) RefCommandCheck(ctx context.Context, repo *models.Repository, user *models.User, transport string) service.RefCommandCheck {
+	protections, err := s.protectionRepo.ListByRepository(ctx, repo.ID)
+	if err != nil {
+		s.log.WithContext(ctx).Error("Failed to load branch protections",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		// Without the protections no branch can be safely updated
+		return func(commands []service.RefCommand) []service.RefRejection {
+			var rejections []service.RefRejection
+			for _, cmd := range commands {
+				if strings.HasPrefix(cmd.RefName, branchRefPrefix) {
+					rejections = append(rejections, service.RefRejection{
+						RefName: cmd.RefName,
+						Reason:  "branch protection is unavailable, try again later",
+					})
+				}
+			}
+			return rejections
+		}
+	}
+	if len(protections) == 0 {
+		return nil
+	}
+
+	// Permissions are only looked up for pushes to admin-only branches
+	var admin *bool
+	isAdmin := func() bool {
+		if admin == nil {
+			allowed := s.repoService.RepositoryPermission(ctx, user, repo).Allows(models.RepoPermissionAdmin)
+			admin = &allowed
+		}
+		return *admin
+	}
+
+	return func(commands []service.RefCommand) []service.RefRejection {
+		var rejections []service.RefRejection
+		rejected := false
+		for _, cmd := range commands {
+			if rejection, ok := checkProtectedBranch(cmd, protections, isAdmin); ok {
+				rejections = append(rejections, rejection)
+				rejected = rejected || !rejection.UnlessFastForward
+			}
+		}
+
+		if rejected {
+			s.log.WithContext(ctx).Info("Push rejected by branch protection",
+				logger.String("repo_id", repo.ID.String()),
+				logger.String("transport", transport),
+			)
+		}
+		return rejections
+	}
+}
+
+// checkProtectedBranch returns the rejection of a ref update by the first
+// protection refusing it. A refusal applying to any update wins over one
+// applying only to force pushes.
+func checkProtectedBranch(cmd service.RefCommand, protections []*models.BranchProtection, isAdmin func() bool) (service.RefRejection, bool) {
+	if !strings.HasPrefix(cmd.RefName, branchRefPrefix) {
+		return service.RefRejection{}, false
+	}
+	branch := strings.TrimPrefix(cmd.RefName, branchRefPrefix)
+
+	var forcePush *service.RefRejection
+	for _, protection := range protections {
+		if ok, _ := path.Match(protection.Pattern, branch); !ok {
+			continue
+		}
+
+		switch {
+		case protection.RequireAdmin && !isAdmin():
+			return service.RefRejection{
+				RefName: cmd.RefName,
+				Reason:  fmt.Sprintf("protected branch can only be pushed to by repository administrators (matches %q)", protection.Pattern),
+			}, true
+		case cmd.IsDelete() && protection.BlockDeletion:
+			return service.RefRejection{
+				RefName: cmd.RefName,
+				Reason:  fmt.Sprintf("protected branch cannot be deleted (matches %q)", protection.Pattern),
+			}, true
+		case !cmd.IsCreate() && !cmd.IsDelete() && protection.BlockForcePush && forcePush == nil:
+			forcePush = &service.RefRejection{
+				RefName:           cmd.RefName,
+				Reason:            fmt.Sprintf("protected branch cannot be force-pushed (matches %q)", protection.Pattern),
+				UnlessFastForward: true,
+			}
+		}
+	}
+
+	if forcePush != nil {
+		return *forcePush, true
+	}
+	return service.RefRejection{}, false
+}
+
+// hasBranchPattern returns true if a protection other than the one with the
+// given ID already uses the pattern
+func hasBranchPattern(protections []*models.BranchProtection, pattern string, except uuid.UUID) bool {
+	for _, protection := range protections {
+		if protection.Pattern == pattern && protection.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeBranchPattern trims and validates a branch glob pattern
+func normalizeBranchPattern(pattern string) (string, error) {
+	pattern = strings.TrimPrefix(strings.TrimSpace(pattern), branchRefPrefix)
+	if pattern == "" {
+		return "", apperrors.BadRequest("branch protection pattern cannot be empty", apperrors.ErrInvalidInput)
+	}
+	if len(pattern) > maxBranchPatternLength {
+		return "", apperrors.BadRequest(fmt.Sprintf("branch protection pattern cannot be longer than %d characters", maxBranchPatternLength), apperrors.ErrInvalidInput)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return "", apperrors.BadRequest(fmt.Sprintf("invalid branch protection pattern %q", pattern), apperrors.ErrInvalidInput)
+	}
+	return pattern, nil
+}
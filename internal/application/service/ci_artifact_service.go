@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+	"github.com/bravo68web/stasis/pkg/metrics"
+)
+
+const (
+	// ciArtifactCleanupInterval is the time between deletions of old CI artifacts
+	ciArtifactCleanupInterval = time.Hour
+
+	// ciArtifactCleanupJitter spreads the deletions of old CI artifacts
+	ciArtifactCleanupJitter = 5 * time.Minute
+
+	// ciOrphanCleanupInterval is the time between deletions of the CI
+	// artifacts of deleted jobs
+	ciOrphanCleanupInterval = 24 * time.Hour
+
+	// ciOrphanCleanupJitter spreads the deletions of the CI artifacts of
+	// deleted jobs
+	ciOrphanCleanupJitter = time.Hour
+
+	// ciArtifactCleanupBatchSize is the number of old CI artifacts deleted at a time
+	ciArtifactCleanupBatchSize = 100
+
+	// ciArtifactMaxNameLength is the longest name of an artifact
+	ciArtifactMaxNameLength = 255
+)
+
+// CIArtifactService stores the artifacts the CI runner uploads, so they
+// outlive the workspace of the runner. Artifacts are streamed to and from
+// the default storage backend, under ci.artifacts_directory/<job_id>/, and
+// kept for ci.retention_days.
+type CIArtifactService struct {
+	artifactRepo repository.CIArtifactRepository
+	tokenRepo    repository.CIJobTokenRepository
+	storage      service.StorageService
+	cfg          *config.CIConfig
+	log          *logger.Logger
+}
+
+// NewCIArtifactService creates a new CIArtifactService instance
+func NewCIArtifactService(
+	artifactRepo repository.CIArtifactRepository,
+	tokenRepo repository.CIJobTokenRepository,
+	storage service.StorageService,
+	cfg *config.CIConfig,
+) *CIArtifactService {
+	return &CIArtifactService{
+		artifactRepo: artifactRepo,
+		tokenRepo:    tokenRepo,
+		storage:      storage,
+		cfg:          cfg,
+		log:          logger.Get().WithFields(logger.Component("ci-artifacts")),
+	}
+}
+
+// Store streams an artifact of a job to storage, replacing an artifact of
+// the job with the same name. checksum is the SHA-256 the runner declares,
+// in hex; an artifact whose content does not match it, or that is larger
+// than ci.max_artifact_size, is discarded.
+func (s *CIArtifactService) Store(ctx context.Context, jobID uuid.UUID, name, contentType, checksum string, body io.Reader) (*models.CIArtifact, error) {
+	if err := validateArtifactName(name); err != nil {
+		return nil, err
+	}
+	checksum = strings.ToLower(strings.TrimSpace(checksum))
+	if len(checksum) != sha256.Size*2 {
+		return nil, apperrors.ValidationError("checksum", "the SHA-256 checksum of the artifact is required, in hex")
+	}
+
+	// The job is known by the token issued when it was submitted
+	token, err := s.tokenRepo.FindByJobID(ctx, jobID)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil, apperrors.NotFound("job", err)
+		}
+		return nil, err
+	}
+
+	// Write to a temporary path first, so a failed upload leaves the
+	// previous artifact of the same name in place
+	storagePath := path.Join(s.cfg.ArtifactsDirectory, jobID.String(), name)
+	uploadPath := path.Join(s.cfg.ArtifactsDirectory, jobID.String(), ".upload-"+uuid.NewString())
+
+	size, actual, err := s.write(uploadPath, body)
+	if err != nil {
+		s.discard(uploadPath)
+		return nil, err
+	}
+	if actual != checksum {
+		s.discard(uploadPath)
+		return nil, apperrors.Unprocessable(
+			fmt.Sprintf("the artifact does not match its checksum: declared %s, received %s", checksum, actual), nil)
+	}
+	if err := s.storage.MoveFile(uploadPath, storagePath); err != nil {
+		s.discard(uploadPath)
+		return nil, apperrors.StorageError("store artifact", err)
+	}
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	artifact := &models.CIArtifact{
+		JobID:        jobID,
+		RepositoryID: token.RepositoryID,
+		Name:         name,
+		Size:         size,
+		Checksum:     actual,
+		ContentType:  contentType,
+		StoragePath:  storagePath,
+		CreatedAt:    time.Now(),
+	}
+	if err := s.artifactRepo.Save(ctx, artifact); err != nil {
+		return nil, err
+	}
+
+	s.log.WithContext(ctx).Info("CI artifact stored",
+		logger.String("job_id", jobID.String()),
+		logger.String("artifact", name),
+		logger.Int64("size", size),
+	)
+	return artifact, nil
+}
+
+// Open opens a stored artifact of a job. The caller must close the returned
+// reader.
+func (s *CIArtifactService) Open(ctx context.Context, jobID uuid.UUID, name string) (*models.CIArtifact, io.ReadCloser, error) {
+	artifact, err := s.artifactRepo.FindByJobAndName(ctx, jobID, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := s.storage.OpenFile(artifact.StoragePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, apperrors.NotFound("artifact", err)
+		}
+		return nil, nil, apperrors.StorageError("open artifact", err)
+	}
+	return artifact, file, nil
+}
+
+// CleanupTask returns the housekeeping task deleting stored CI artifacts
+// older than the CI job history
+func (s *CIArtifactService) CleanupTask() *HousekeepingTask {
+	if s.cfg.RetentionDays <= 0 {
+		return nil
+	}
+	return &HousekeepingTask{
+		Name:        "ci.artifacts",
+		Description: "Delete the stored CI artifacts, and their files, older than ci.retention_days",
+		Interval:    ciArtifactCleanupInterval,
+		Jitter:      ciArtifactCleanupJitter,
+		Run:         s.cleanup,
+	}
+}
+
+// OrphanCleanupTask returns the housekeeping task deleting the stored
+// artifacts, and their files, of jobs whose token record was deleted
+func (s *CIArtifactService) OrphanCleanupTask() *HousekeepingTask {
+	return &HousekeepingTask{
+		Name:        "ci.orphaned_artifacts",
+		Description: "Delete the stored CI artifacts, and their files, of jobs that no longer exist",
+		Interval:    ciOrphanCleanupInterval,
+		Jitter:      ciOrphanCleanupJitter,
+		Run:         s.cleanupOrphans,
+	}
+}
+
+// write streams body to a new file at storagePath and returns its size and
+// SHA-256 checksum. A body over ci.max_artifact_size is cut short with a too
+// large error.
+func (s *CIArtifactService) write(storagePath string, body io.Reader) (int64, string, error) {
+	file, err := s.storage.CreateFile(storagePath)
+	if err != nil {
+		return 0, "", apperrors.StorageError("create artifact", err)
+	}
+
+	// One byte more than allowed tells an oversized upload apart
+	maxSize := s.cfg.MaxArtifactSize
+	if maxSize > 0 {
+		body = io.LimitReader(body, maxSize+1)
+	}
+	hash := sha256.New()
+	size, err := io.Copy(file, io.TeeReader(body, hash))
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		return 0, "", apperrors.StorageError("write artifact", closeErr)
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to receive artifact: %w", err)
+	}
+	if maxSize > 0 && size > maxSize {
+		return 0, "", apperrors.TooLarge(fmt.Sprintf("the artifact is larger than the maximum artifact size of %d bytes", maxSize), nil)
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// discard deletes the file of an upload that failed
+func (s *CIArtifactService) discard(storagePath string) {
+	if err := s.storage.DeleteFile(storagePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		s.log.Warn("Failed to delete failed CI artifact upload",
+			logger.Error(err),
+			logger.String("path", storagePath),
+		)
+	}
+}
+
+// cleanup deletes the artifacts older than the CI job history
+func (s *CIArtifactService) cleanup(ctx context.Context) error {
+	before := time.Now().Add(-time.Duration(s.cfg.RetentionDays) * 24 * time.Hour)
+	deleted, err := s.deleteArtifacts(ctx, func(ctx context.Context) ([]*models.CIArtifact, error) {
+		return s.artifactRepo.ListCreatedBefore(ctx, before, ciArtifactCleanupBatchSize)
+	})
+	metrics.CICleanupDeleted("ci_artifacts", metrics.CICleanupExpired, deleted)
+	if deleted > 0 {
+		s.log.WithContext(ctx).Info("Deleted old CI artifacts", logger.Int64("count", deleted))
+	}
+	return err
+}
+
+// cleanupOrphans deletes the artifacts of jobs that no longer exist
+func (s *CIArtifactService) cleanupOrphans(ctx context.Context) error {
+	deleted, err := s.deleteArtifacts(ctx, func(ctx context.Context) ([]*models.CIArtifact, error) {
+		return s.artifactRepo.ListOrphaned(ctx, ciArtifactCleanupBatchSize)
+	})
+	metrics.CICleanupDeleted("ci_artifacts", metrics.CICleanupOrphaned, deleted)
+	if deleted > 0 {
+		s.log.WithContext(ctx).Info("Deleted CI artifacts of deleted jobs", logger.Int64("count", deleted))
+	}
+	return err
+}
+
+// deleteArtifacts deletes the artifacts list returns, and their files, batch
+// after batch until list returns a partial batch, and returns how many were
+// deleted. Artifacts whose file cannot be deleted are logged and left for the
+// next run.
+func (s *CIArtifactService) deleteArtifacts(ctx context.Context, list func(context.Context) ([]*models.CIArtifact, error)) (int64, error) {
+	var deleted int64
+	for {
+		artifacts, err := list(ctx)
+		if err != nil {
+			return deleted, err
+		}
+
+		progress := false
+		for _, artifact := range artifacts {
+			if err := s.storage.DeleteFile(artifact.StoragePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				s.log.WithContext(ctx).Warn("Failed to delete CI artifact file",
+					logger.Error(err),
+					logger.String("job_id", artifact.JobID.String()),
+					logger.String("artifact", artifact.Name),
+				)
+				continue
+			}
+			if err := s.artifactRepo.Delete(ctx, artifact.ID); err != nil {
+				s.log.WithContext(ctx).Warn("Failed to delete CI artifact",
+					logger.Error(err),
+					logger.String("job_id", artifact.JobID.String()),
+					logger.String("artifact", artifact.Name),
+				)
+				continue
+			}
+			deleted++
+			progress = true
+		}
+
+		// Stop when done, or when the failures would come back in the next batch
+		if len(artifacts) < ciArtifactCleanupBatchSize || !progress {
+			return deleted, nil
+		}
+	}
+}
+
+// validateArtifactName checks an artifact name is a single path element
+func validateArtifactName(name string) error {
+	switch {
+	case name == "", name == ".", name == "..":
+		return apperrors.ValidationError("name", "the artifact name is required")
+	case len(name) > ciArtifactMaxNameLength:
+		return apperrors.ValidationError("name", fmt.Sprintf("the artifact name must be at most %d characters", ciArtifactMaxNameLength))
+	case strings.ContainsAny(name, "/\\\x00"), strings.HasPrefix(name, "."):
+		return apperrors.ValidationError("name", "the artifact name must not contain slashes or start with a dot")
+	}
+	return nil
+}
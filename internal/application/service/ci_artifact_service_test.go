@@ -0,0 +1,195 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/metrics"
+)
+
+// cleanupDeleted returns the rows of a table the CI cleanup counted as
+// deleted for a reason, from the served metrics
+func cleanupDeleted(t *testing.T, table, reason string) float64 {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	series := `stasis_ci_cleanup_deleted_rows_total{reason="` + reason + `",table="` + table + `"} `
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), series); ok {
+			count, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return count
+		}
+	}
+	return 0
+}
+
+// newArtifact returns an artifact of a job stored age ago
+func newArtifact(jobID uuid.UUID, name string, age time.Duration) *models.CIArtifact {
+	return &models.CIArtifact{
+		ID:          uuid.New(),
+		JobID:       jobID,
+		Name:        name,
+		StoragePath: "ci-artifacts/" + jobID.String() + "/" + name,
+		CreatedAt:   time.Now().Add(-age),
+	}
+}
+
+func TestCleanupOrphanedArtifacts(t *testing.T) {
+	job, deletedJob := uuid.New(), uuid.New()
+	tokens := &fakeCIJobTokenRepo{tokens: []*models.CIJobToken{{JobID: job}}}
+
+	kept := newArtifact(job, "report.xml", time.Hour)
+	orphan := newArtifact(deletedJob, "report.xml", time.Hour)
+	missingFile := newArtifact(deletedJob, "coverage.out", time.Hour)
+	undeletable := newArtifact(deletedJob, "build.tar", time.Hour)
+	artifacts := &fakeCIArtifactRepo{tokens: tokens, artifacts: []*models.CIArtifact{undeletable, kept, orphan, missingFile}}
+	// More than a batch, behind an artifact whose file cannot be deleted
+	for i := range ciArtifactCleanupBatchSize + 20 {
+		artifacts.artifacts = append(artifacts.artifacts, newArtifact(deletedJob, "part-"+strconv.Itoa(i), time.Hour))
+	}
+	storage := &fakeStorage{
+		missing:     map[string]bool{missingFile.StoragePath: true},
+		undeletable: map[string]bool{undeletable.StoragePath: true},
+	}
+	s := NewCIArtifactService(artifacts, tokens, storage, &config.CIConfig{RetentionDays: 30})
+	before := cleanupDeleted(t, "ci_artifacts", metrics.CICleanupOrphaned)
+
+	if err := s.OrphanCleanupTask().Run(context.Background()); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+
+	if want := []*models.CIArtifact{undeletable, kept}; !slices.Equal(artifacts.artifacts, want) {
+		t.Errorf("%d artifacts left, want the artifact of the job and the one whose file could not be deleted", len(artifacts.artifacts))
+	}
+	if deleted := len(storage.deleted); deleted != ciArtifactCleanupBatchSize+21 {
+		t.Errorf("deleted %d files, want %d", deleted, ciArtifactCleanupBatchSize+21)
+	}
+	if slices.Contains(storage.deleted, kept.StoragePath) {
+		t.Error("deleted the file of an artifact of an existing job")
+	}
+	if got := cleanupDeleted(t, "ci_artifacts", metrics.CICleanupOrphaned) - before; got != ciArtifactCleanupBatchSize+22 {
+		t.Errorf("counted %v deleted rows, want %d", got, ciArtifactCleanupBatchSize+22)
+	}
+}
+
+func TestCleanupOldArtifacts(t *testing.T) {
+	job, deletedJob := uuid.New(), uuid.New()
+	tokens := &fakeCIJobTokenRepo{tokens: []*models.CIJobToken{{JobID: job}}}
+	recent := newArtifact(job, "recent.xml", time.Hour)
+	old := newArtifact(job, "old.xml", 31*24*time.Hour)
+	recentOrphan := newArtifact(deletedJob, "recent.xml", time.Hour)
+	artifacts := &fakeCIArtifactRepo{tokens: tokens, artifacts: []*models.CIArtifact{recent, old, recentOrphan}}
+	storage := &fakeStorage{}
+	s := NewCIArtifactService(artifacts, tokens, storage, &config.CIConfig{RetentionDays: 30})
+	before := cleanupDeleted(t, "ci_artifacts", metrics.CICleanupExpired)
+
+	if err := s.CleanupTask().Run(context.Background()); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+
+	// Orphans are left to their own task
+	if want := []*models.CIArtifact{recent, recentOrphan}; !slices.Equal(artifacts.artifacts, want) {
+		t.Errorf("artifacts left = %v, want the recent ones", artifacts.artifacts)
+	}
+	if want := []string{old.StoragePath}; !slices.Equal(storage.deleted, want) {
+		t.Errorf("deleted files %v, want %v", storage.deleted, want)
+	}
+	if got := cleanupDeleted(t, "ci_artifacts", metrics.CICleanupExpired) - before; got != 1 {
+		t.Errorf("counted %v deleted rows, want 1", got)
+	}
+}
+
+// countingReader counts the bytes read from it
+type countingReader struct {
+	r    io.Reader
+	read int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += int64(n)
+	return n, err
+}
+
+// An artifact over ci.max_artifact_size is refused once a byte more than the
+// limit was read, and its upload deleted
+func TestStoreArtifactSizeLimit(t *testing.T) {
+	const limit = 1 << 20
+	tests := []struct {
+		name       string
+		maxSize    int64
+		size       int64
+		wantStored bool
+	}{
+		{name: "under the limit", maxSize: limit, size: limit - 1, wantStored: true},
+		{name: "at the limit", maxSize: limit, size: limit, wantStored: true},
+		{name: "over the limit", maxSize: limit, size: limit + 1},
+		{name: "far over the limit", maxSize: limit, size: 64 * limit},
+		{name: "unlimited", size: 8 * limit, wantStored: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := uuid.New()
+			tokens := &fakeCIJobTokenRepo{tokens: []*models.CIJobToken{{JobID: job, RepositoryID: uuid.New()}}}
+			artifacts := &fakeCIArtifactRepo{tokens: tokens}
+			storage := &fakeStorage{}
+			s := NewCIArtifactService(artifacts, tokens, storage, &config.CIConfig{ArtifactsDirectory: "artifacts", MaxArtifactSize: tt.maxSize})
+
+			hash := sha256.New()
+			io.Copy(hash, io.LimitReader(zeros{}, tt.size))
+			body := &countingReader{r: io.LimitReader(zeros{}, tt.size)}
+			artifact, err := s.Store(context.Background(), job, "build.tar", "", hex.EncodeToString(hash.Sum(nil)), body)
+
+			if tt.wantStored {
+				if err != nil {
+					t.Fatalf("Store: %v", err)
+				}
+				if artifact.Size != tt.size || len(artifacts.artifacts) != 1 || len(storage.Moves()) != 1 {
+					t.Errorf("stored %d bytes, %d artifacts, %d moves, want %d bytes stored once", artifact.Size, len(artifacts.artifacts), len(storage.Moves()), tt.size)
+				}
+				return
+			}
+			var appErr *apperrors.AppError
+			if !errors.As(err, &appErr) || appErr.Code != apperrors.CodeTooLarge {
+				t.Fatalf("Store = %v, want a too large error", err)
+			}
+			if body.read != tt.maxSize+1 {
+				t.Errorf("read %d bytes of the upload, want %d", body.read, tt.maxSize+1)
+			}
+			if len(artifacts.artifacts) != 0 || len(storage.Moves()) != 0 {
+				t.Error("oversized artifact stored")
+			}
+			if !slices.Equal(storage.deleted, storage.created) || len(storage.created) != 1 {
+				t.Errorf("deleted %v, want the upload %v", storage.deleted, storage.created)
+			}
+		})
+	}
+}
+
+// zeros is an endless stream of zero bytes
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// ciStatusContext is the commit status context of jobs outside staged runs
+	ciStatusContext = "ci"
+
+	// ciStatusCreator is the creator recorded on commit statuses set by CI
+	ciStatusCreator = "ci"
+)
+
+// pipelineStatusContext returns the commit status context of a job of a
+// staged run, e.g. ci/test/unit
+func pipelineStatusContext(job *models.CIPipelineJob) string {
+	return ciStatusContext + "/" + job.Stage + "/" + job.Name
+}
+
+// ciCommitState returns the commit status state and description of a job
+// with a CI job status
+func ciCommitState(jobID uuid.UUID, jobStatus string) (state, description string) {
+	job := "Job " + jobID.String()[:8]
+	switch jobStatus {
+	case models.CIJobStatusSuccess:
+		return models.CommitStateSuccess, job + " passed"
+	case models.CIJobStatusFailed:
+		return models.CommitStateFailure, job + " failed"
+	case models.CIJobStatusTimedOut:
+		return models.CommitStateFailure, job + " timed out"
+	case models.CIJobStatusCancelled:
+		return models.CommitStateError, job + " was cancelled"
+	case models.CIJobStatusSkipped:
+		return models.CommitStateError, job + " was skipped: a stage it needs did not succeed"
+	case models.CIJobStatusError:
+		return models.CommitStateError, job + " could not be run"
+	case models.CIJobStatusBlocked:
+		return models.CommitStatePending, job + " is waiting for earlier stages"
+	case models.CIJobStatusRunning:
+		return models.CommitStatePending, job + " is running"
+	default:
+		return models.CommitStatePending, job + " is queued"
+	}
+}
+
+// setCommitStatus records the status of a CI job on its commit. Failures are
+// logged: they must not fail the job.
+func (s *CIService) setCommitStatus(ctx context.Context, repoID uuid.UUID, sha, statusContext string, jobID uuid.UUID, jobStatus string) {
+	if s.statuses == nil || sha == "" {
+		return
+	}
+
+	state, description := ciCommitState(jobID, jobStatus)
+	err := s.statuses.RecordStatus(ctx, &models.CommitStatus{
+		RepositoryID: repoID,
+		SHA:          sha,
+		State:        state,
+		Context:      statusContext,
+		Description:  description,
+		Creator:      ciStatusCreator,
+	})
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to set commit status of CI job",
+			logger.Error(err),
+			logger.String("job_id", jobID.String()),
+			logger.String("commit", sha),
+		)
+	}
+}
+
+// setPipelineJobStatus records the status of a job of a staged run on its
+// commit
+func (s *CIService) setPipelineJobStatus(ctx context.Context, jobID uuid.UUID, jobStatus string) {
+	job, err := s.pipelineRepo.FindByID(ctx, jobID)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to find pipeline job", logger.Error(err), logger.String("job_id", jobID.String()))
+		return
+	}
+	s.setCommitStatus(ctx, job.RepositoryID, job.CommitSHA, pipelineStatusContext(job), job.ID, jobStatus)
+}
+
+// setRunnerJobStatus records the status of a job outside staged runs on its
+// commit, which only the runner knows
+func (s *CIService) setRunnerJobStatus(ctx context.Context, jobID uuid.UUID, jobStatus string) {
+	if s.statuses == nil {
+		return
+	}
+
+	job, err := s.GetJob(ctx, jobID)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to get CI job for its commit status",
+			logger.Error(err),
+			logger.String("job_id", jobID.String()),
+		)
+		return
+	}
+	s.setCommitStatus(ctx, job.RepositoryID, job.CommitSHA, ciStatusContext, job.ID, jobStatus)
+}
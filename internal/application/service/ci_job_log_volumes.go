@@ -0,0 +1,73 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// maxTrackedJobLogVolumes bounds the jobs whose reported log volume is kept
+const maxTrackedJobLogVolumes = 10000
+
+// jobLogVolumes counts the log lines and bytes the runner reported for recent
+// CI jobs, to cap them at ci.log_limit_lines and ci.log_limit_mb. Jobs are
+// forgotten least recently reported first.
+type jobLogVolumes struct {
+	mu      sync.Mutex
+	volumes map[uuid.UUID]*list.Element
+	order   *list.List
+}
+
+// jobLogVolume is the log volume reported for a job
+type jobLogVolume struct {
+	jobID    uuid.UUID
+	lines    int64
+	bytes    int64
+	exceeded bool // A cap was reached, later lines are dropped
+}
+
+// newJobLogVolumes creates an empty jobLogVolumes
+func newJobLogVolumes() *jobLogVolumes {
+	return &jobLogVolumes{
+		volumes: make(map[uuid.UUID]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// admit counts a batch of log lines of a job, given their sizes, against the
+// caps (0 disables one) and returns how many lines from the start of the
+// batch are within them. exceeded is true for the batch reaching a cap, and
+// for that batch only.
+func (v *jobLogVolumes) admit(jobID uuid.UUID, sizes []int, maxLines, maxBytes int64) (admitted int, exceeded bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	el, ok := v.volumes[jobID]
+	if ok {
+		v.order.MoveToFront(el)
+	} else {
+		el = v.order.PushFront(&jobLogVolume{jobID: jobID})
+		v.volumes[jobID] = el
+		if v.order.Len() > maxTrackedJobLogVolumes {
+			oldest := v.order.Back()
+			v.order.Remove(oldest)
+			delete(v.volumes, oldest.Value.(*jobLogVolume).jobID)
+		}
+	}
+
+	volume := el.Value.(*jobLogVolume)
+	if volume.exceeded {
+		return 0, false
+	}
+	for _, size := range sizes {
+		if (maxLines > 0 && volume.lines+1 > maxLines) || (maxBytes > 0 && volume.bytes+int64(size) > maxBytes) {
+			volume.exceeded = true
+			return admitted, true
+		}
+		volume.lines++
+		volume.bytes += int64(size)
+		admitted++
+	}
+	return admitted, false
+}
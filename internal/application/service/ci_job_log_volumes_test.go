@@ -0,0 +1,115 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// sizes returns the sizes of n lines of size bytes each
+func sizes(n, size int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = size
+	}
+	return s
+}
+
+func TestJobLogVolumesAdmit(t *testing.T) {
+	type batch struct {
+		sizes        []int
+		wantAdmitted int
+		wantExceeded bool
+	}
+	tests := []struct {
+		name     string
+		maxLines int64
+		maxBytes int64
+		batches  []batch
+	}{
+		{
+			name:     "within the caps",
+			maxLines: 10,
+			maxBytes: 100,
+			batches:  []batch{{sizes(4, 10), 4, false}, {sizes(6, 10), 6, false}},
+		},
+		{
+			name:     "line cap",
+			maxLines: 5,
+			batches: []batch{
+				{sizes(3, 10), 3, false},
+				// The batch reaching the cap is cut, and is the only one
+				// reported as exceeding it
+				{sizes(4, 10), 2, true},
+				{sizes(1, 10), 0, false},
+			},
+		},
+		{
+			name:     "byte cap",
+			maxBytes: 25,
+			batches: []batch{
+				{[]int{10, 10}, 2, false},
+				{[]int{5, 1}, 1, true},
+				{[]int{0}, 0, false},
+			},
+		},
+		{
+			name:     "byte cap reached exactly",
+			maxBytes: 20,
+			batches:  []batch{{[]int{10, 10}, 2, false}, {[]int{1}, 0, true}},
+		},
+		{
+			name:     "first batch over the caps",
+			maxLines: 2,
+			batches:  []batch{{sizes(5, 1), 2, true}, {sizes(5, 1), 0, false}},
+		},
+		{
+			name:    "no caps",
+			batches: []batch{{sizes(100000, 1000), 100000, false}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newJobLogVolumes()
+			job := uuid.New()
+			for i, b := range tt.batches {
+				admitted, exceeded := v.admit(job, b.sizes, tt.maxLines, tt.maxBytes)
+				if admitted != b.wantAdmitted || exceeded != b.wantExceeded {
+					t.Errorf("batch %d: admit = %d, %v, want %d, %v", i, admitted, exceeded, b.wantAdmitted, b.wantExceeded)
+				}
+			}
+
+			// Other jobs have caps of their own
+			if admitted, exceeded := v.admit(uuid.New(), []int{1}, tt.maxLines, tt.maxBytes); admitted != 1 || exceeded {
+				t.Errorf("admit of another job = %d, %v, want 1, false", admitted, exceeded)
+			}
+		})
+	}
+}
+
+func TestJobLogVolumesForgetOldJobs(t *testing.T) {
+	v := newJobLogVolumes()
+	first, recent := uuid.New(), uuid.New()
+	v.admit(first, []int{1}, 1, 0)
+	v.admit(recent, []int{1}, 1, 0)
+	for range maxTrackedJobLogVolumes - 2 {
+		v.admit(uuid.New(), nil, 1, 0)
+	}
+	// Reported again, recent is no longer the least recently reported
+	v.admit(recent, nil, 1, 0)
+	v.admit(uuid.New(), nil, 1, 0)
+
+	if len(v.volumes) != maxTrackedJobLogVolumes || v.order.Len() != maxTrackedJobLogVolumes {
+		t.Fatalf("tracking %d jobs (%d in order), want %d", len(v.volumes), v.order.Len(), maxTrackedJobLogVolumes)
+	}
+	if _, ok := v.volumes[first]; ok {
+		t.Error("least recently reported job still tracked")
+	}
+	// A forgotten job starts over, a tracked one is still capped
+	if admitted, _ := v.admit(first, []int{1}, 1, 0); admitted != 1 {
+		t.Errorf("forgotten job admitted %d lines, want 1", admitted)
+	}
+	if admitted, exceeded := v.admit(recent, []int{1}, 1, 0); admitted != 0 || !exceeded {
+		t.Errorf("tracked job at its cap: admit = %d, %v, want 0, true", admitted, exceeded)
+	}
+}
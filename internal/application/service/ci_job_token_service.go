@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+	"github.com/bravo68web/stasis/pkg/metrics"
+)
+
+const (
+	// ciJobTokenCleanupInterval is the time between deletions of old CI job tokens
+	ciJobTokenCleanupInterval = time.Hour
+
+	// ciJobTokenCleanupJitter spreads the deletions of old CI job tokens
+	ciJobTokenCleanupJitter = 5 * time.Minute
+
+	// ciCallbackTokenGrace is how long after the job timeout the runner may
+	// still report a job, for the reports of a job that timed out
+	ciCallbackTokenGrace = 15 * time.Minute
+
+	// ciJobTokenUser is the user name in the clone URLs of CI jobs; git
+	// needs one, only the token is checked
+	ciJobTokenUser = "ci-job"
+)
+
+// CIJobTokenService issues the tokens CI jobs clone their repository with.
+// A token only allows fetching the repository of its job, so the runner needs
+// no credentials of its own, and the bytes fetched with it are attributed to
+// the job. Tokens expire after ci.job_timeout_minutes and are revoked as soon
+// as the runner reports the job finished. They are kept, revoked, for
+// ci.retention_days to report the transfer of the job.
+//
+// Each job also gets a callback token, which the runner presents when it
+// reports the logs and status of the job, so only the runner the job was
+// submitted to can report it. It stays valid after the job finished, for
+// late reports, until shortly after the job timeout.
+type CIJobTokenService struct {
+	tokenRepo repository.CIJobTokenRepository
+	cfg       *config.CIConfig
+	log       *logger.Logger
+}
+
+// NewCIJobTokenService creates a new CIJobTokenService instance revoking the
+// tokens of the jobs whose completion is published on subscriber
+func NewCIJobTokenService(tokenRepo repository.CIJobTokenRepository, cfg *config.CIConfig, subscriber events.Subscriber) *CIJobTokenService {
+	s := &CIJobTokenService{
+		tokenRepo: tokenRepo,
+		cfg:       cfg,
+		log:       logger.Get().WithFields(logger.Component("ci-job-tokens")),
+	}
+
+	subscriber.Subscribe("ci-job-tokens", s.revokeOnFinish, events.SubscribeOptions{
+		Types:      []string{events.TypeCIJobStatus},
+		MaxRetries: 3,
+	})
+
+	return s
+}
+
+// Issue creates the clone and callback tokens of a job of a run triggered by
+// req and returns them; only their hashes are stored, with what the job runs on
+func (s *CIJobTokenService) Issue(ctx context.Context, jobID, runID uuid.UUID, req *TriggerJobRequest) (cloneToken, callbackToken string, err error) {
+	cloneToken, err = generateCIJobToken(models.CIJobTokenPrefix)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate CI job token: %w", err)
+	}
+	callbackToken, err = generateCIJobToken(models.CICallbackTokenPrefix)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate CI callback token: %w", err)
+	}
+
+	err = s.tokenRepo.Create(ctx, &models.CIJobToken{
+		JobID:         jobID,
+		RunID:         runID,
+		RepositoryID:  req.RepositoryID,
+		Stage:         req.Stage,
+		JobName:       req.JobName,
+		CommitSHA:     req.CommitSHA,
+		RefName:       req.RefName,
+		RefType:       req.RefType,
+		TriggerActor:  req.TriggerActor,
+		Token:         hashToken(cloneToken),
+		CallbackToken: hashToken(callbackToken),
+		ExpiresAt:     time.Now().Add(s.cfg.JobTimeout()),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return cloneToken, callbackToken, nil
+}
+
+// AuthenticateCallback returns the token record of the job a callback token
+// was issued for
+func (s *CIJobTokenService) AuthenticateCallback(ctx context.Context, token string) (*models.CIJobToken, error) {
+	jobToken, err := s.tokenRepo.FindByHashedCallbackToken(ctx, hashToken(token))
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil, apperrors.Unauthorized("invalid CI callback token", apperrors.ErrInvalidCredentials)
+		}
+		return nil, fmt.Errorf("failed to find CI callback token: %w", err)
+	}
+
+	if time.Now().After(jobToken.ExpiresAt.Add(ciCallbackTokenGrace)) {
+		return nil, apperrors.Unauthorized("CI callback token has expired", apperrors.ErrInvalidCredentials)
+	}
+	return jobToken, nil
+}
+
+// AdvanceStatus records status as the status of a job and returns its token
+// record, which records what the job was triggered for. It returns nil if
+// the job already had the status or has finished, so each transition is
+// recorded once across callbacks and server instances, and a not found
+// error for jobs without a token.
+func (s *CIJobTokenService) AdvanceStatus(ctx context.Context, jobID uuid.UUID, status string) (*models.CIJobToken, error) {
+	return s.tokenRepo.AdvanceStatus(ctx, jobID, status)
+}
+
+// CloneURL returns cloneURL with the token of a job as its credentials
+func (s *CIJobTokenService) CloneURL(cloneURL, token string) (string, error) {
+	u, err := url.Parse(cloneURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid clone URL: %w", err)
+	}
+	u.User = url.UserPassword(ciJobTokenUser, token)
+	return u.String(), nil
+}
+
+// Revoke revokes the token of a job, if it has one
+func (s *CIJobTokenService) Revoke(ctx context.Context, jobID uuid.UUID) error {
+	revoked, err := s.tokenRepo.Revoke(ctx, jobID, time.Now())
+	if err != nil {
+		return err
+	}
+	if revoked {
+		s.log.WithContext(ctx).Debug("CI job token revoked", logger.String("job_id", jobID.String()))
+	}
+	return nil
+}
+
+// RecordTransfer attributes the bytes a fetch with the token of a job sent
+// to the job. Failures are logged: the fetch already completed.
+func (s *CIJobTokenService) RecordTransfer(ctx context.Context, token *models.CIJobToken, repository string, bytes int64) {
+	s.log.WithContext(ctx).Info("CI job fetched repository",
+		logger.String("job_id", token.JobID.String()),
+		logger.String("repository", repository),
+		logger.Int64("bytes", bytes),
+	)
+
+	if err := s.tokenRepo.AddTransfer(ctx, token.ID, bytes, time.Now()); err != nil {
+		s.log.WithContext(ctx).Warn("Failed to record transfer of CI job",
+			logger.Error(err),
+			logger.String("job_id", token.JobID.String()),
+		)
+	}
+}
+
+// TransferBytes returns the bytes the fetches of a job were sent, or nil if
+// the job has not fetched its repository with a token
+func (s *CIJobTokenService) TransferBytes(ctx context.Context, jobID uuid.UUID) (*int64, error) {
+	token, err := s.tokenRepo.FindByJobID(ctx, jobID)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if token.LastUsedAt == nil {
+		return nil, nil
+	}
+	return &token.TransferBytes, nil
+}
+
+// CleanupTask returns the housekeeping task deleting CI job tokens older
+// than the CI job history
+func (s *CIJobTokenService) CleanupTask() *HousekeepingTask {
+	if s.cfg.RetentionDays <= 0 {
+		return nil
+	}
+	return &HousekeepingTask{
+		Name:        "ci.job_tokens",
+		Description: "Delete the clone tokens of CI jobs, and their transfer counts, older than ci.retention_days",
+		Interval:    ciJobTokenCleanupInterval,
+		Jitter:      ciJobTokenCleanupJitter,
+		Run:         s.cleanup,
+	}
+}
+
+// revokeOnFinish revokes the token of a job once it finished
+func (s *CIJobTokenService) revokeOnFinish(ctx context.Context, env events.Envelope) error {
+	status, ok := env.Event.(events.CIJobStatus)
+	if !ok || !(&CIJob{Status: status.Status}).IsFinished() {
+		return nil
+	}
+	return s.Revoke(ctx, status.JobID)
+}
+
+// cleanup deletes the tokens older than the CI job history
+func (s *CIJobTokenService) cleanup(ctx context.Context) error {
+	retention := time.Duration(s.cfg.RetentionDays) * 24 * time.Hour
+	deleted, err := s.tokenRepo.DeleteBefore(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return err
+	}
+	metrics.CICleanupDeleted("ci_job_tokens", metrics.CICleanupExpired, deleted)
+	if deleted > 0 {
+		s.log.WithContext(ctx).Info("Deleted old CI job tokens", logger.Int64("count", deleted))
+	}
+	return nil
+}
+
+// generateCIJobToken generates a new token in format {prefix}{32 random hex chars}
+func generateCIJobToken(prefix string) (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(bytes), nil
+}
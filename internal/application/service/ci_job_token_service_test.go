@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+func TestAuthenticateCallback(t *testing.T) {
+	now := time.Now()
+	revokedAt := now.Add(-time.Minute)
+	token := func(callbackToken string, expiresAt time.Time, revokedAt *time.Time) *models.CIJobToken {
+		return &models.CIJobToken{
+			JobID:         uuid.New(),
+			Token:         hashToken("Sj" + callbackToken),
+			CallbackToken: hashToken(callbackToken),
+			ExpiresAt:     expiresAt,
+			RevokedAt:     revokedAt,
+		}
+	}
+	running := token("Sc-running", now.Add(time.Hour), nil)
+	finished := token("Sc-finished", now.Add(time.Hour), &revokedAt)
+	timedOut := token("Sc-timed-out", now.Add(-ciCallbackTokenGrace/2), nil)
+	expired := token("Sc-expired", now.Add(-ciCallbackTokenGrace-time.Minute), nil)
+	s := NewCIJobTokenService(&fakeCIJobTokenRepo{tokens: []*models.CIJobToken{running, finished, timedOut, expired}}, &config.CIConfig{}, &fakeBus{})
+
+	tests := []struct {
+		name  string
+		token string
+		want  *models.CIJobToken
+	}{
+		{name: "running job", token: "Sc-running", want: running},
+		// The runner reports the status of a job after it finished
+		{name: "finished job", token: "Sc-finished", want: finished},
+		{name: "job that timed out, within the grace period", token: "Sc-timed-out", want: timedOut},
+		{name: "expired", token: "Sc-expired"},
+		{name: "unknown", token: "Sc-unknown"},
+		{name: "job token", token: "SjSc-running"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.AuthenticateCallback(context.Background(), tt.token)
+			if tt.want == nil {
+				if !apperrors.IsUnauthorized(err) {
+					t.Fatalf("AuthenticateCallback = %v, %v, want an unauthorized error", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AuthenticateCallback: %v", err)
+			}
+			if got.JobID != tt.want.JobID {
+				t.Errorf("authenticated job %s, want %s", got.JobID, tt.want.JobID)
+			}
+		})
+	}
+}
@@ -0,0 +1,674 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+	"github.com/bravo68web/stasis/pkg/metrics"
+)
+
+const (
+	// maxCIStages is the maximum number of stages in a CI config
+	maxCIStages = 20
+
+	// maxCIStageJobs is the maximum number of jobs per stage
+	maxCIStageJobs = 20
+
+	// ciResolveTimeout bounds resolving a run after a job status update
+	ciResolveTimeout = 2 * time.Minute
+
+	// ciPipelineCleanupInterval is the time between deletions of old staged runs
+	ciPipelineCleanupInterval = 24 * time.Hour
+
+	// ciPipelineCleanupJitter spreads the deletions of old staged runs
+	ciPipelineCleanupJitter = time.Hour
+)
+
+// ciPipelineConfig is the part of the CI config the server reads to order
+// stages. Everything else in the file is interpreted by the CI runner.
+//
+//	stages:
+//	  - build
+//	  - test
+//	  - name: deploy
+//	    needs: [build, test]
+//	    jobs: [staging, production]
+type ciPipelineConfig struct {
+	Stages []ciStageConfig `yaml:"stages"`
+}
+
+// ciStageConfig is a single stage. A stage without needs runs after the stage
+// listed before it; a stage without jobs runs a single job named after itself.
+type ciStageConfig struct {
+	Name  string    `yaml:"name"`
+	Needs *[]string `yaml:"needs"`
+	Jobs  []string  `yaml:"jobs"`
+}
+
+// UnmarshalYAML accepts a stage given as a plain name or as a mapping
+func (c *ciStageConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		c.Name = node.Value
+		return nil
+	}
+	type plain ciStageConfig
+	return node.Decode((*plain)(c))
+}
+
+// ciStage is a validated stage with its dependencies resolved
+type ciStage struct {
+	Name  string
+	Needs []string
+	Jobs  []string
+}
+
+// CIRun is the state of a staged CI run
+type CIRun struct {
+	RunID        uuid.UUID
+	RepositoryID uuid.UUID
+	Status       string
+	Stages       []CIRunStage
+}
+
+// CIRunStage is the state of a single stage of a run
+type CIRunStage struct {
+	Name   string
+	Needs  []string
+	Status string
+	Jobs   []*models.CIPipelineJob
+}
+
+// parseCIPipeline reads the stages of a CI config and orders them so every
+// stage comes after the stages it needs. It returns nil if the config has no
+// stages. Unknown and circular stage references are rejected.
+func parseCIPipeline(content []byte) ([]ciStage, error) {
+	var cfg ciPipelineConfig
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return nil, apperrors.BadRequest(fmt.Sprintf("invalid CI config: %v", err), apperrors.ErrInvalidInput)
+	}
+	if len(cfg.Stages) == 0 {
+		return nil, nil
+	}
+	if len(cfg.Stages) > maxCIStages {
+		return nil, apperrors.BadRequest(fmt.Sprintf("CI config has more than %d stages", maxCIStages), apperrors.ErrInvalidInput)
+	}
+
+	stages := make(map[string]*ciStage, len(cfg.Stages))
+	order := make([]string, 0, len(cfg.Stages))
+	for i, sc := range cfg.Stages {
+		name := strings.TrimSpace(sc.Name)
+		if name == "" {
+			return nil, apperrors.BadRequest(fmt.Sprintf("CI stage %d has no name", i+1), apperrors.ErrInvalidInput)
+		}
+		if _, exists := stages[name]; exists {
+			return nil, apperrors.BadRequest(fmt.Sprintf("CI stage %q is defined more than once", name), apperrors.ErrInvalidInput)
+		}
+
+		stage := &ciStage{Name: name, Jobs: sc.Jobs}
+		if sc.Needs != nil {
+			stage.Needs = *sc.Needs
+		} else if i > 0 {
+			stage.Needs = []string{order[i-1]}
+		}
+		if len(stage.Jobs) == 0 {
+			stage.Jobs = []string{name}
+		}
+		if len(stage.Jobs) > maxCIStageJobs {
+			return nil, apperrors.BadRequest(fmt.Sprintf("CI stage %q has more than %d jobs", name, maxCIStageJobs), apperrors.ErrInvalidInput)
+		}
+		for _, job := range stage.Jobs {
+			if strings.TrimSpace(job) == "" {
+				return nil, apperrors.BadRequest(fmt.Sprintf("CI stage %q has a job without a name", name), apperrors.ErrInvalidInput)
+			}
+		}
+
+		stages[name] = stage
+		order = append(order, name)
+	}
+
+	for _, name := range order {
+		for _, need := range stages[name].Needs {
+			if _, ok := stages[need]; !ok {
+				return nil, apperrors.BadRequest(fmt.Sprintf("CI stage %q needs unknown stage %q", name, need), apperrors.ErrInvalidInput)
+			}
+		}
+	}
+
+	// Depth-first topological sort, reporting the first cycle found
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(stages))
+	sorted := make([]ciStage, 0, len(stages))
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			cycle := append(path[slices.Index(path, name):], name)
+			return apperrors.BadRequest(fmt.Sprintf("circular CI stage dependency: %s", strings.Join(cycle, " -> ")), apperrors.ErrInvalidInput)
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, need := range stages[name].Needs {
+			if err := visit(need); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+
+		sorted = append(sorted, *stages[name])
+		return nil
+	}
+
+	for _, name := range order {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+// loadPipeline reads the stages of the CI config at the commit being built.
+// It returns nil if the config is missing or does not use stages, in which case
+// the whole config runs as a single job.
+func (s *CIService) loadPipeline(ctx context.Context, req *TriggerJobRequest) ([]ciStage, error) {
+	repo, err := s.repoRepo.FindByID(ctx, req.RepositoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repository: %w", err)
+	}
+
+	file, err := s.gitService.GetFileContent(ctx, repo.GitPath, req.CommitSHA, s.config.GetConfigPath())
+	if err != nil {
+		// The runner reports missing configs the way it always did
+		return nil, nil
+	}
+
+	return parseCIPipeline(file.Content)
+}
+
+// triggerPipeline creates the jobs of a staged run and submits the stages
+// that do not need any other stage. Later stages are held back as blocked.
+func (s *CIService) triggerPipeline(ctx context.Context, req *TriggerJobRequest, stages []ciStage) (*CIJob, error) {
+	runID := uuid.New()
+
+	var jobs []*models.CIPipelineJob
+	for i, stage := range stages {
+		status := models.CIJobStatusBlocked
+		if len(stage.Needs) == 0 {
+			status = models.CIJobStatusPending
+		}
+		for _, name := range stage.Jobs {
+			jobs = append(jobs, &models.CIPipelineJob{
+				ID:           uuid.New(),
+				RunID:        runID,
+				RepositoryID: req.RepositoryID,
+				Stage:        stage.Name,
+				StageIndex:   i,
+				Name:         name,
+				Needs:        stage.Needs,
+				Status:       status,
+				CommitSHA:    req.CommitSHA,
+				RefName:      req.RefName,
+				RefType:      req.RefType,
+				TriggerType:  req.TriggerType,
+				TriggerActor: req.TriggerActor,
+				Metadata:     req.Metadata,
+			})
+		}
+	}
+
+	if err := s.pipelineRepo.CreateBatch(ctx, jobs); err != nil {
+		return nil, fmt.Errorf("failed to create pipeline jobs: %w", err)
+	}
+	for _, job := range jobs {
+		s.setCommitStatus(ctx, job.RepositoryID, job.CommitSHA, pipelineStatusContext(job), job.ID, job.Status)
+	}
+
+	s.log.WithContext(ctx).Info("Staged CI run created",
+		logger.String("run_id", runID.String()),
+		logger.Int("stages", len(stages)),
+		logger.Int("jobs", len(jobs)),
+	)
+
+	var first *models.CIPipelineJob
+	for _, job := range jobs {
+		if job.Status != models.CIJobStatusPending {
+			continue
+		}
+		if err := s.submitPipelineJob(ctx, job); err != nil {
+			s.log.WithContext(ctx).Error("Failed to submit pipeline job",
+				logger.Error(err),
+				logger.String("job_id", job.ID.String()),
+				logger.String("run_id", runID.String()),
+			)
+			continue
+		}
+		if first == nil {
+			first = job
+		}
+	}
+
+	if first == nil {
+		// Nothing could be submitted; skip the blocked stages
+		if err := s.ResolveRun(ctx, runID); err != nil {
+			s.log.WithContext(ctx).Warn("Failed to resolve CI run", logger.Error(err), logger.String("run_id", runID.String()))
+		}
+		return nil, fmt.Errorf("failed to submit any job of run %s", runID)
+	}
+
+	return &CIJob{
+		ID:           first.ID,
+		RunID:        runID,
+		RepositoryID: req.RepositoryID,
+		CommitSHA:    req.CommitSHA,
+		RefName:      req.RefName,
+		RefType:      string(req.RefType),
+		TriggerType:  string(req.TriggerType),
+		TriggerActor: req.TriggerActor,
+		Status:       models.CIJobStatusQueued,
+		ConfigPath:   s.config.GetConfigPath(),
+		CreatedAt:    first.CreatedAt,
+	}, nil
+}
+
+// submitPipelineJob submits a pending job to the runner and marks it queued.
+// A job that fails to submit is marked failed so its dependents are skipped.
+func (s *CIService) submitPipelineJob(ctx context.Context, job *models.CIPipelineJob) error {
+	repo, err := s.repoRepo.FindByID(ctx, job.RepositoryID)
+	if err != nil {
+		return fmt.Errorf("failed to find repository: %w", err)
+	}
+
+	submitReq, err := s.buildSubmitRequest(ctx, job.ID, job.RunID, &TriggerJobRequest{
+		RepositoryID: job.RepositoryID,
+		Owner:        repo.Owner.Username,
+		RepoName:     repo.Name,
+		CloneURL:     s.BuildCloneURL(repo.Owner.Username, repo.Name),
+		CommitSHA:    job.CommitSHA,
+		RefName:      job.RefName,
+		RefType:      job.RefType,
+		TriggerType:  job.TriggerType,
+		TriggerActor: job.TriggerActor,
+		Metadata:     job.Metadata,
+		Stage:        job.Stage,
+		JobName:      job.Name,
+	})
+	if err == nil {
+		err = s.submitJob(ctx, submitReq)
+	}
+	if err != nil {
+		msg := err.Error()
+		if updateErr := s.pipelineRepo.UpdateStatus(ctx, job.ID, models.CIJobStatusFailed, &msg); updateErr != nil {
+			s.log.WithContext(ctx).Error("Failed to mark pipeline job as failed", logger.Error(updateErr))
+		}
+		job.Status = models.CIJobStatusFailed
+		s.setCommitStatus(ctx, job.RepositoryID, job.CommitSHA, pipelineStatusContext(job), job.ID, job.Status)
+		return err
+	}
+
+	queued, err := s.pipelineRepo.TransitionStatus(ctx, job.ID, models.CIJobStatusPending, models.CIJobStatusQueued)
+	if err != nil {
+		return err
+	}
+	if !queued {
+		// The run was cancelled while the job was being submitted
+		if err := s.CancelJob(ctx, job.ID); err != nil {
+			s.log.WithContext(ctx).Warn("Failed to cancel job of cancelled run", logger.Error(err), logger.String("job_id", job.ID.String()))
+		}
+		return nil
+	}
+	job.Status = models.CIJobStatusQueued
+	s.setCommitStatus(ctx, job.RepositoryID, job.CommitSHA, pipelineStatusContext(job), job.ID, job.Status)
+
+	s.log.WithContext(ctx).Info("Pipeline job submitted to CI runner",
+		logger.String("job_id", job.ID.String()),
+		logger.String("run_id", job.RunID.String()),
+		logger.String("stage", job.Stage),
+		logger.String("job", job.Name),
+	)
+	return nil
+}
+
+// ResolveRun promotes blocked jobs whose needed stages all succeeded to pending
+// and submits them, and skips blocked jobs whose needed stages did not succeed.
+func (s *CIService) ResolveRun(ctx context.Context, runID uuid.UUID) error {
+	jobs, err := s.pipelineRepo.FindByRunID(ctx, runID)
+	if err != nil {
+		return err
+	}
+
+	// Skipping a stage can settle stages that need it, so repeat until stable
+	for changed := true; changed; {
+		changed = false
+		stageStatus := ciStageStatuses(jobs)
+
+		for _, job := range jobs {
+			if job.Status != models.CIJobStatusBlocked {
+				continue
+			}
+
+			ready, failed := true, false
+			for _, need := range job.Needs {
+				switch stageStatus[need] {
+				case models.CIJobStatusSuccess:
+				case models.CIJobStatusFailed, models.CIJobStatusSkipped, models.CIJobStatusCancelled:
+					failed = true
+				default:
+					ready = false
+				}
+			}
+
+			switch {
+			case failed:
+				ok, err := s.pipelineRepo.TransitionStatus(ctx, job.ID, models.CIJobStatusBlocked, models.CIJobStatusSkipped)
+				if err != nil {
+					return err
+				}
+				if ok {
+					job.Status = models.CIJobStatusSkipped
+					changed = true
+					s.setCommitStatus(ctx, job.RepositoryID, job.CommitSHA, pipelineStatusContext(job), job.ID, job.Status)
+				}
+			case ready:
+				ok, err := s.pipelineRepo.TransitionStatus(ctx, job.ID, models.CIJobStatusBlocked, models.CIJobStatusPending)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					// Promoted or cancelled concurrently
+					continue
+				}
+				job.Status = models.CIJobStatusPending
+				if err := s.submitPipelineJob(ctx, job); err != nil {
+					s.log.WithContext(ctx).Error("Failed to submit pipeline job",
+						logger.Error(err),
+						logger.String("job_id", job.ID.String()),
+						logger.String("run_id", runID.String()),
+					)
+					changed = true
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// RecordJobStatus stores a status reported by the runner for a job of a staged
+// run and, once the job finished, resolves the run in the background. The
+// commit status of the job is updated for all jobs, staged or not.
+func (s *CIService) RecordJobStatus(ctx context.Context, jobID uuid.UUID, status string, jobErr *string) {
+	job, err := s.pipelineRepo.FindByID(ctx, jobID)
+	if err != nil {
+		if !apperrors.IsNotFound(err) {
+			s.log.WithContext(ctx).Error("Failed to find pipeline job", logger.Error(err), logger.String("job_id", jobID.String()))
+			return
+		}
+		if status != "" {
+			s.setRunnerJobStatus(ctx, jobID, status)
+		}
+		return
+	}
+	if job.IsFinished() || status == "" || status == job.Status {
+		return
+	}
+
+	if err := s.pipelineRepo.UpdateStatus(ctx, jobID, status, jobErr); err != nil {
+		s.log.WithContext(ctx).Error("Failed to update pipeline job status", logger.Error(err), logger.String("job_id", jobID.String()))
+		return
+	}
+	s.setCommitStatus(ctx, job.RepositoryID, job.CommitSHA, pipelineStatusContext(job), job.ID, status)
+
+	if !(&CIJob{Status: status}).IsFinished() {
+		return
+	}
+
+	go func() {
+		resolveCtx, cancel := context.WithTimeout(context.Background(), ciResolveTimeout)
+		defer cancel()
+
+		if err := s.ResolveRun(resolveCtx, job.RunID); err != nil {
+			s.log.WithContext(ctx).Error("Failed to resolve CI run",
+				logger.Error(err),
+				logger.String("run_id", job.RunID.String()),
+			)
+		}
+	}()
+}
+
+// GetRun returns the stage progress of a staged run. Statuses of submitted jobs
+// are refreshed from the runner first, in case a status webhook was missed.
+func (s *CIService) GetRun(ctx context.Context, runID uuid.UUID) (*CIRun, error) {
+	if !s.IsEnabled() {
+		return nil, fmt.Errorf("CI integration is not enabled")
+	}
+
+	jobs, err := s.pipelineRepo.FindByRunID(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, apperrors.NotFound("CI run", apperrors.ErrNotFound)
+	}
+
+	refreshed := false
+	for _, job := range jobs {
+		if job.IsFinished() || job.Status == models.CIJobStatusBlocked || job.Status == models.CIJobStatusPending {
+			continue
+		}
+		remote, err := s.GetJob(ctx, job.ID)
+		if err != nil || remote.Status == job.Status {
+			continue
+		}
+		if err := s.pipelineRepo.UpdateStatus(ctx, job.ID, remote.Status, remote.Error); err != nil {
+			return nil, err
+		}
+		refreshed = refreshed || remote.IsFinished()
+	}
+
+	if refreshed {
+		if err := s.ResolveRun(ctx, runID); err != nil {
+			return nil, err
+		}
+	}
+
+	jobs, err = s.pipelineRepo.FindByRunID(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &CIRun{RunID: runID, RepositoryID: jobs[0].RepositoryID}
+	statuses := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		if n := len(run.Stages); n == 0 || run.Stages[n-1].Name != job.Stage {
+			run.Stages = append(run.Stages, CIRunStage{Name: job.Stage, Needs: job.Needs})
+		}
+		stage := &run.Stages[len(run.Stages)-1]
+		stage.Jobs = append(stage.Jobs, job)
+		statuses = append(statuses, job.Status)
+	}
+	for i := range run.Stages {
+		run.Stages[i].Status = aggregateCIStatus(jobStatuses(run.Stages[i].Jobs))
+	}
+	run.Status = aggregateCIStatus(statuses)
+
+	return run, nil
+}
+
+// CancelRun cancels every unfinished job of a staged run, including jobs
+// still blocked on earlier stages
+func (s *CIService) CancelRun(ctx context.Context, runID uuid.UUID) error {
+	if !s.IsEnabled() {
+		return fmt.Errorf("CI integration is not enabled")
+	}
+
+	jobs, err := s.pipelineRepo.FindByRunID(ctx, runID)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		return apperrors.NotFound("CI run", apperrors.ErrNotFound)
+	}
+
+	for _, job := range jobs {
+		switch {
+		case job.IsFinished():
+			continue
+		case job.Status == models.CIJobStatusBlocked || job.Status == models.CIJobStatusPending:
+			// A pending job is cancelled on the runner by its submitter
+			ok, err := s.pipelineRepo.TransitionStatus(ctx, job.ID, job.Status, models.CIJobStatusCancelled)
+			if err != nil {
+				return err
+			}
+			if ok {
+				s.setCommitStatus(ctx, job.RepositoryID, job.CommitSHA, pipelineStatusContext(job), job.ID, models.CIJobStatusCancelled)
+			}
+		default:
+			if err := s.CancelJob(ctx, job.ID); err != nil {
+				return err
+			}
+			if err := s.pipelineRepo.UpdateStatus(ctx, job.ID, models.CIJobStatusCancelled, nil); err != nil {
+				return err
+			}
+			s.setCommitStatus(ctx, job.RepositoryID, job.CommitSHA, pipelineStatusContext(job), job.ID, models.CIJobStatusCancelled)
+		}
+	}
+
+	s.log.WithContext(ctx).Info("CI run cancelled", logger.String("run_id", runID.String()))
+	return nil
+}
+
+// pipelineJobAsCIJob returns a job of a staged run that the runner does not
+// know about yet (blocked, skipped or cancelled before submission)
+func (s *CIService) pipelineJobAsCIJob(ctx context.Context, jobID uuid.UUID) *CIJob {
+	job, err := s.pipelineRepo.FindByID(ctx, jobID)
+	if err != nil {
+		return nil
+	}
+	return &CIJob{
+		ID:           job.ID,
+		RunID:        job.RunID,
+		RepositoryID: job.RepositoryID,
+		CommitSHA:    job.CommitSHA,
+		RefName:      job.RefName,
+		RefType:      string(job.RefType),
+		TriggerType:  string(job.TriggerType),
+		TriggerActor: job.TriggerActor,
+		Status:       job.Status,
+		Error:        job.Error,
+		ConfigPath:   s.config.GetConfigPath(),
+		CreatedAt:    job.CreatedAt,
+	}
+}
+
+// ciStageStatuses returns the aggregated status of every stage of a run
+func ciStageStatuses(jobs []*models.CIPipelineJob) map[string]string {
+	byStage := make(map[string][]string)
+	for _, job := range jobs {
+		byStage[job.Stage] = append(byStage[job.Stage], job.Status)
+	}
+	result := make(map[string]string, len(byStage))
+	for stage, statuses := range byStage {
+		result[stage] = aggregateCIStatus(statuses)
+	}
+	return result
+}
+
+// aggregateCIStatus summarizes job statuses: blocked or running while any job
+// is unfinished, success if all succeeded, and otherwise the worst outcome
+func aggregateCIStatus(statuses []string) string {
+	if len(statuses) == 0 {
+		return models.CIJobStatusPending
+	}
+
+	allBlocked, allSuccess, allSkipped := true, true, true
+	unfinished, failed, cancelled := false, false, false
+	for _, status := range statuses {
+		allBlocked = allBlocked && status == models.CIJobStatusBlocked
+		allSuccess = allSuccess && status == models.CIJobStatusSuccess
+		allSkipped = allSkipped && status == models.CIJobStatusSkipped
+
+		switch status {
+		case models.CIJobStatusSuccess, models.CIJobStatusSkipped:
+		case models.CIJobStatusCancelled:
+			cancelled = true
+		case models.CIJobStatusFailed, models.CIJobStatusTimedOut, models.CIJobStatusError:
+			failed = true
+		default:
+			unfinished = true
+		}
+	}
+
+	switch {
+	case allBlocked:
+		return models.CIJobStatusBlocked
+	case unfinished:
+		return models.CIJobStatusRunning
+	case allSuccess:
+		return models.CIJobStatusSuccess
+	case allSkipped:
+		return models.CIJobStatusSkipped
+	case failed:
+		return models.CIJobStatusFailed
+	case cancelled:
+		return models.CIJobStatusCancelled
+	default:
+		return models.CIJobStatusFailed
+	}
+}
+
+// jobStatuses returns the statuses of jobs
+func jobStatuses(jobs []*models.CIPipelineJob) []string {
+	statuses := make([]string, len(jobs))
+	for i, job := range jobs {
+		statuses[i] = job.Status
+	}
+	return statuses
+}
+
+// PipelineCleanupTask returns the housekeeping task deleting the staged runs
+// older than the CI job history
+func (s *CIService) PipelineCleanupTask() *HousekeepingTask {
+	if s.config.RetentionDays <= 0 {
+		return nil
+	}
+	return &HousekeepingTask{
+		Name:        "ci.pipeline_runs",
+		Description: "Delete the jobs of staged CI runs not updated for ci.retention_days",
+		Interval:    ciPipelineCleanupInterval,
+		Jitter:      ciPipelineCleanupJitter,
+		Run:         s.cleanupPipelineRuns,
+	}
+}
+
+// cleanupPipelineRuns deletes the staged runs older than the CI job history
+func (s *CIService) cleanupPipelineRuns(ctx context.Context) error {
+	retention := time.Duration(s.config.RetentionDays) * 24 * time.Hour
+	deleted, err := s.pipelineRepo.DeleteRunsBefore(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return err
+	}
+	metrics.CICleanupDeleted("ci_pipeline_jobs", metrics.CICleanupExpired, deleted)
+	if deleted > 0 {
+		s.log.WithContext(ctx).Info("Deleted old staged CI runs", logger.Int64("jobs", deleted))
+	}
+	return nil
+}
@@ -5,26 +5,43 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/events"
 	"github.com/bravo68web/stasis/internal/domain/models"
 	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
 	"github.com/bravo68web/stasis/pkg/logger"
+	"github.com/bravo68web/stasis/pkg/metrics"
 	"github.com/go-resty/resty/v2"
 	"github.com/google/uuid"
 )
 
 // CIService handles CI/CD integration with the CI runner
-// All data is fetched directly from the CI server - no local database storage
+// Job data is fetched directly from the CI server; only the jobs of staged runs
+// are tracked locally, so later stages can be held back until earlier ones succeed
 type CIService struct {
-	config   *config.CIConfig
-	client   *resty.Client
-	repoRepo repository.RepoRepository
-	log      *logger.Logger
-
-	// SSE subscribers for real-time updates
+	config       *config.CIConfig
+	client       *resty.Client
+	repoRepo     repository.RepoRepository
+	pipelineRepo repository.CIPipelineRepository
+	gitService   service.GitService
+	statuses     *CommitStatusService
+	jobTokens    *CIJobTokenService
+	artifacts    *CIArtifactService
+	bus          events.Bus
+	log          *logger.Logger
+
+	// Log volume reported per job, to cap it
+	logVolumes *jobLogVolumes
+
+	// SSE subscribers for real-time updates, fed from the event bus
 	subscribers map[uuid.UUID][]chan *JobEvent
 	subMu       sync.RWMutex
 }
@@ -47,6 +64,12 @@ type SubmitJobRequest struct {
 	Timestamp  time.Time      `json:"timestamp"`
 	Priority   string         `json:"priority"`
 	Timeout    *int           `json:"timeout,omitempty"`
+	Stage      string         `json:"stage,omitempty"`    // Stage to run, for staged pipelines
+	JobName    string         `json:"job_name,omitempty"` // Job of the stage to run
+
+	// CallbackToken authenticates the reports of the job: the runner sends
+	// it as a Bearer token to the /api/v1/ci callbacks
+	CallbackToken string `json:"callback_token"`
 }
 
 // RepositoryInfo contains repository information for the CI runner
@@ -137,6 +160,12 @@ type CIRunnerJobsListResponse struct {
 func NewCIService(
 	cfg *config.CIConfig,
 	repoRepo repository.RepoRepository,
+	pipelineRepo repository.CIPipelineRepository,
+	gitService service.GitService,
+	statuses *CommitStatusService,
+	jobTokens *CIJobTokenService,
+	artifacts *CIArtifactService,
+	bus events.Bus,
 ) *CIService {
 	client := resty.New().
 		SetTimeout(cfg.Timeout()).
@@ -149,13 +178,28 @@ func NewCIService(
 		client.SetHeader("X-API-Key", cfg.APIKey)
 	}
 
-	return &CIService{
-		config:      cfg,
-		client:      client,
-		repoRepo:    repoRepo,
-		log:         logger.Get(),
-		subscribers: make(map[uuid.UUID][]chan *JobEvent),
-	}
+	s := &CIService{
+		config:       cfg,
+		client:       client,
+		repoRepo:     repoRepo,
+		pipelineRepo: pipelineRepo,
+		gitService:   gitService,
+		statuses:     statuses,
+		jobTokens:    jobTokens,
+		artifacts:    artifacts,
+		bus:          bus,
+		log:          logger.Get(),
+		logVolumes:   newJobLogVolumes(),
+		subscribers:  make(map[uuid.UUID][]chan *JobEvent),
+	}
+
+	// Stream job events to SSE subscribers; broadcastEvent never blocks
+	bus.Subscribe("ci-job-stream", s.streamJobEvent, events.SubscribeOptions{
+		Types:    []string{events.TypeCIJobStatus, events.TypeCIJobLogs},
+		Delivery: events.DeliverSync,
+	})
+
+	return s
 }
 
 // IsEnabled returns true if CI integration is enabled
@@ -163,15 +207,144 @@ func (s *CIService) IsEnabled() bool {
 	return s.config.IsConfigured()
 }
 
-// TriggerJob creates a new CI job and submits it to the CI runner
+// TriggerJob creates a new CI job and submits it to the CI runner.
+// If the CI config defines stages, a job is created per stage job and only the
+// first stages are submitted; the returned job is the first one submitted.
 func (s *CIService) TriggerJob(ctx context.Context, req *TriggerJobRequest) (*CIJob, error) {
 	if !s.IsEnabled() {
 		return nil, fmt.Errorf("CI integration is not enabled")
 	}
 
+	stages, err := s.loadPipeline(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(stages) > 0 {
+		return s.triggerPipeline(ctx, req, stages)
+	}
+
 	jobID := uuid.New()
 	runID := uuid.New()
 
+	submitReq, err := s.buildSubmitRequest(ctx, jobID, runID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.submitJob(ctx, submitReq); err != nil {
+		return nil, err
+	}
+
+	s.log.WithContext(ctx).Info("Job submitted to CI runner",
+		logger.String("job_id", jobID.String()),
+		logger.String("run_id", runID.String()),
+	)
+	s.setCommitStatus(ctx, req.RepositoryID, req.CommitSHA, ciStatusContext, jobID, models.CIJobStatusQueued)
+
+	// Return a minimal job response
+	return &CIJob{
+		ID:           jobID,
+		RunID:        runID,
+		RepositoryID: req.RepositoryID,
+		CommitSHA:    req.CommitSHA,
+		RefName:      req.RefName,
+		RefType:      string(req.RefType),
+		TriggerType:  string(req.TriggerType),
+		TriggerActor: req.TriggerActor,
+		Status:       "queued",
+		ConfigPath:   s.config.GetConfigPath(),
+		CreatedAt:    time.Now(),
+	}, nil
+}
+
+// TriggerPushJobs triggers a job for every branch or tag updated by a push
+// whose new tip contains the CI config file. Deleted refs and other refs are
+// skipped, and a failure on one ref is logged without stopping the others.
+func (s *CIService) TriggerPushJobs(ctx context.Context, repo *models.Repository, owner, repoName, actor string, updates []service.RefUpdate) []*CIJob {
+	if !s.IsEnabled() {
+		return nil
+	}
+
+	configPath := s.config.GetConfigPath()
+	var jobs []*CIJob
+	for _, update := range updates {
+		if update.IsDelete() {
+			continue
+		}
+
+		var refName string
+		var refType models.CIRefType
+		var triggerType models.CITriggerType
+		if branch, ok := strings.CutPrefix(update.RefName, "refs/heads/"); ok {
+			refName, refType, triggerType = branch, models.CIRefTypeBranch, models.CITriggerTypePush
+		} else if tag, ok := strings.CutPrefix(update.RefName, "refs/tags/"); ok {
+			refName, refType, triggerType = tag, models.CIRefTypeTag, models.CITriggerTypeTag
+		} else {
+			continue
+		}
+
+		if _, err := s.gitService.GetFileContent(ctx, repo.GitPath, update.CommitHash, configPath); err != nil {
+			s.log.WithContext(ctx).Debug("CI config file not found, skipping CI trigger",
+				logger.String("repo", owner+"/"+repoName),
+				logger.String("ref", update.RefName),
+				logger.String("config_path", configPath),
+			)
+			continue
+		}
+
+		metadata := map[string]string{}
+		if commit, err := s.gitService.GetCommit(ctx, repo.GitPath, update.CommitHash); err == nil {
+			metadata["commit_message"] = commit.Message
+			metadata["author"] = commit.Author
+			metadata["author_email"] = commit.AuthorEmail
+		}
+
+		job, err := s.TriggerJob(ctx, &TriggerJobRequest{
+			RepositoryID: repo.ID,
+			Owner:        owner,
+			RepoName:     repoName,
+			CloneURL:     s.BuildCloneURL(owner, repoName),
+			CommitSHA:    update.CommitHash,
+			RefName:      refName,
+			RefType:      refType,
+			TriggerType:  triggerType,
+			TriggerActor: actor,
+			Metadata:     metadata,
+		})
+		if err != nil {
+			s.log.WithContext(ctx).Error("Failed to trigger CI job after push",
+				logger.Error(err),
+				logger.String("repo", owner+"/"+repoName),
+				logger.String("ref", update.RefName),
+				logger.String("commit", update.CommitHash),
+			)
+			continue
+		}
+
+		s.log.WithContext(ctx).Info("CI job triggered by push",
+			logger.String("job_id", job.ID.String()),
+			logger.String("repo", owner+"/"+repoName),
+			logger.String("ref", update.RefName),
+			logger.String("commit", update.CommitHash),
+			logger.String("actor", actor),
+		)
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// buildSubmitRequest builds the runner submission of a job. The clone URL
+// carries a token of the job, so the runner needs no credentials of its own.
+// The callback token lets the runner report the job.
+func (s *CIService) buildSubmitRequest(ctx context.Context, jobID, runID uuid.UUID, req *TriggerJobRequest) (SubmitJobRequest, error) {
+	token, callbackToken, err := s.jobTokens.Issue(ctx, jobID, runID, req)
+	if err != nil {
+		return SubmitJobRequest{}, err
+	}
+	cloneURL, err := s.jobTokens.CloneURL(req.CloneURL, token)
+	if err != nil {
+		return SubmitJobRequest{}, err
+	}
+
 	// Convert ref type to CI runner format
 	refType := "Branch"
 	if req.RefType == models.CIRefTypeTag {
@@ -189,13 +362,13 @@ func (s *CIService) TriggerJob(ctx context.Context, req *TriggerJobRequest) (*CI
 		eventType = "Manual"
 	}
 
-	submitReq := SubmitJobRequest{
+	return SubmitJobRequest{
 		JobID: jobID,
 		RunID: runID,
 		Repository: RepositoryInfo{
 			Owner:     req.Owner,
 			Name:      req.RepoName,
-			CloneURL:  req.CloneURL,
+			CloneURL:  cloneURL,
 			CommitSHA: req.CommitSHA,
 			RefName:   req.RefName,
 			RefType:   refType,
@@ -205,11 +378,34 @@ func (s *CIService) TriggerJob(ctx context.Context, req *TriggerJobRequest) (*CI
 			Actor:     req.TriggerActor,
 			Metadata:  req.Metadata,
 		},
-		ConfigPath: s.config.GetConfigPath(),
-		Timestamp:  time.Now().UTC(),
-		Priority:   "Normal",
+		ConfigPath:    s.config.GetConfigPath(),
+		Stage:         req.Stage,
+		JobName:       req.JobName,
+		Timestamp:     time.Now().UTC(),
+		Priority:      "Normal",
+		CallbackToken: callbackToken,
+	}, nil
+}
+
+// submitJob submits a job to the CI runner. The clone token of a job that
+// could not be submitted is revoked.
+func (s *CIService) submitJob(ctx context.Context, submitReq SubmitJobRequest) error {
+	err := s.postJob(ctx, submitReq)
+	if err != nil {
+		if revokeErr := s.jobTokens.Revoke(ctx, submitReq.JobID); revokeErr != nil {
+			s.log.WithContext(ctx).Warn("Failed to revoke clone token of unsubmitted job",
+				logger.Error(revokeErr),
+				logger.String("job_id", submitReq.JobID.String()),
+			)
+		}
+		return err
 	}
+	metrics.CIJobStatus(models.CIJobStatusQueued)
+	return nil
+}
 
+// postJob sends a job to the CI runner
+func (s *CIService) postJob(ctx context.Context, submitReq SubmitJobRequest) error {
 	url := fmt.Sprintf("%s/api/v1/jobs", s.config.ServerURL)
 
 	resp, err := s.client.R().
@@ -219,32 +415,14 @@ func (s *CIService) TriggerJob(ctx context.Context, req *TriggerJobRequest) (*CI
 		Post(url)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to submit job: %w", err)
+		return fmt.Errorf("failed to submit job: %w", err)
 	}
 
 	if resp.StatusCode() != 200 && resp.StatusCode() != 202 {
-		return nil, fmt.Errorf("CI runner returned status %d: %s", resp.StatusCode(), resp.String())
+		return fmt.Errorf("CI runner returned status %d: %s", resp.StatusCode(), resp.String())
 	}
 
-	s.log.Info("Job submitted to CI runner",
-		logger.String("job_id", jobID.String()),
-		logger.String("run_id", runID.String()),
-	)
-
-	// Return a minimal job response
-	return &CIJob{
-		ID:           jobID,
-		RunID:        runID,
-		RepositoryID: req.RepositoryID,
-		CommitSHA:    req.CommitSHA,
-		RefName:      req.RefName,
-		RefType:      string(req.RefType),
-		TriggerType:  string(req.TriggerType),
-		TriggerActor: req.TriggerActor,
-		Status:       "queued",
-		ConfigPath:   s.config.GetConfigPath(),
-		CreatedAt:    time.Now(),
-	}, nil
+	return nil
 }
 
 // TriggerJobRequest contains the information needed to trigger a CI job
@@ -259,6 +437,10 @@ type TriggerJobRequest struct {
 	TriggerType  models.CITriggerType
 	TriggerActor string
 	Metadata     map[string]string
+
+	// Stage and JobName are set for the jobs of staged runs
+	Stage   string
+	JobName string
 }
 
 // CIJob represents a CI job (fetched from CI server, not stored locally)
@@ -279,6 +461,10 @@ type CIJob struct {
 	FinishedAt   *time.Time   `json:"finished_at,omitempty"`
 	Steps        []CIStep     `json:"steps,omitempty"`
 	Artifacts    []CIArtifact `json:"artifacts,omitempty"`
+
+	// TransferBytes is what the job fetched of its repository, set once
+	// its clone completed
+	TransferBytes *int64 `json:"transfer_bytes,omitempty"`
 }
 
 // CIStep represents a CI job step
@@ -351,6 +537,10 @@ func (s *CIService) GetJob(ctx context.Context, jobID uuid.UUID) (*CIJob, error)
 	}
 
 	if resp.StatusCode() == 404 {
+		// Jobs of later stages are not submitted until their stage is unblocked
+		if job := s.pipelineJobAsCIJob(ctx, jobID); job != nil {
+			return job, nil
+		}
 		return nil, fmt.Errorf("job not found")
 	}
 
@@ -358,7 +548,13 @@ func (s *CIService) GetJob(ctx context.Context, jobID uuid.UUID) (*CIJob, error)
 		return nil, fmt.Errorf("CI runner returned status %d: %s", resp.StatusCode(), resp.String())
 	}
 
-	return s.mapRunnerResponseToJob(&runnerResp), nil
+	job := s.mapRunnerResponseToJob(&runnerResp)
+	transfer, err := s.jobTokens.TransferBytes(ctx, jobID)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to get transfer of CI job", logger.Error(err), logger.String("job_id", jobID.String()))
+	}
+	job.TransferBytes = transfer
+	return job, nil
 }
 
 // GetJobByRunID retrieves a CI job by run ID from the CI server
@@ -368,8 +564,32 @@ func (s *CIService) GetJobByRunID(ctx context.Context, runID uuid.UUID) (*CIJob,
 	return s.GetJob(ctx, runID)
 }
 
-// ListJobsByRepository lists CI jobs for a repository from the CI server
-func (s *CIService) ListJobsByRepository(ctx context.Context, repoID uuid.UUID, limit, offset int) ([]*CIJob, int64, error) {
+// ParseCIJobStatusFilter parses a comma-separated list of job statuses
+// (e.g. "failed,error"). An empty filter matches every status.
+func ParseCIJobStatusFilter(raw string) ([]string, error) {
+	var statuses []string
+	for _, part := range strings.Split(raw, ",") {
+		status := strings.ToLower(strings.TrimSpace(part))
+		if status == "" {
+			continue
+		}
+		if !slices.Contains(models.CIRunnerJobStatuses, status) {
+			return nil, apperrors.BadRequest(
+				fmt.Sprintf("invalid status %q, valid statuses are: %s", status, strings.Join(models.CIRunnerJobStatuses, ", ")),
+				apperrors.ErrInvalidInput,
+			)
+		}
+		if !slices.Contains(statuses, status) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+// ListJobsByRepository lists CI jobs for a repository from the CI server.
+// If statuses is set only jobs in one of them are listed, and the total counts
+// the matching jobs only.
+func (s *CIService) ListJobsByRepository(ctx context.Context, repoID uuid.UUID, statuses []string, limit, offset int) ([]*CIJob, int64, error) {
 	if !s.IsEnabled() {
 		return nil, 0, fmt.Errorf("CI integration is not enabled")
 	}
@@ -382,15 +602,20 @@ func (s *CIService) ListJobsByRepository(ctx context.Context, repoID uuid.UUID,
 
 	url := fmt.Sprintf("%s/api/v1/jobs", s.config.ServerURL)
 
+	params := map[string]string{
+		"owner":  repo.Owner.Username,
+		"repo":   repo.Name,
+		"limit":  fmt.Sprintf("%d", limit),
+		"offset": fmt.Sprintf("%d", offset),
+	}
+	if len(statuses) > 0 {
+		params["status"] = strings.Join(statuses, ",")
+	}
+
 	var listResp CIRunnerJobsListResponse
 	resp, err := s.client.R().
 		SetContext(ctx).
-		SetQueryParams(map[string]string{
-			"owner":  repo.Owner.Username,
-			"repo":   repo.Name,
-			"limit":  fmt.Sprintf("%d", limit),
-			"offset": fmt.Sprintf("%d", offset),
-		}).
+		SetQueryParams(params).
 		SetResult(&listResp).
 		Get(url)
 
@@ -412,15 +637,16 @@ func (s *CIService) ListJobsByRepository(ctx context.Context, repoID uuid.UUID,
 	return jobs, listResp.Total, nil
 }
 
-// ListJobsByRef lists CI jobs for a specific ref from the CI server
-func (s *CIService) ListJobsByRef(ctx context.Context, repoID uuid.UUID, refName string, limit, offset int) ([]*CIJob, error) {
+// ListJobsByRef lists CI jobs for a specific branch or tag from the CI server,
+// along with the total number of jobs for the ref
+func (s *CIService) ListJobsByRef(ctx context.Context, repoID uuid.UUID, refName string, limit, offset int) ([]*CIJob, int64, error) {
 	if !s.IsEnabled() {
-		return nil, fmt.Errorf("CI integration is not enabled")
+		return nil, 0, fmt.Errorf("CI integration is not enabled")
 	}
 
 	repo, err := s.repoRepo.FindByID(ctx, repoID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find repository: %w", err)
+		return nil, 0, fmt.Errorf("failed to find repository: %w", err)
 	}
 
 	url := fmt.Sprintf("%s/api/v1/jobs", s.config.ServerURL)
@@ -439,11 +665,11 @@ func (s *CIService) ListJobsByRef(ctx context.Context, repoID uuid.UUID, refName
 		Get(url)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to list jobs by ref: %w", err)
+		return nil, 0, fmt.Errorf("failed to list jobs by ref: %w", err)
 	}
 
 	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("CI runner returned status %d: %s", resp.StatusCode(), resp.String())
+		return nil, 0, fmt.Errorf("CI runner returned status %d: %s", resp.StatusCode(), resp.String())
 	}
 
 	jobs := make([]*CIJob, 0, len(listResp.Jobs))
@@ -453,7 +679,7 @@ func (s *CIService) ListJobsByRef(ctx context.Context, repoID uuid.UUID, refName
 		jobs = append(jobs, job)
 	}
 
-	return jobs, nil
+	return jobs, listResp.Total, nil
 }
 
 // GetJobLogs retrieves logs for a CI job from the CI server
@@ -546,6 +772,14 @@ func (s *CIService) CancelJob(ctx context.Context, jobID uuid.UUID) error {
 		return fmt.Errorf("CI integration is not enabled")
 	}
 
+	// Blocked jobs of staged runs were never submitted to the runner
+	if ok, err := s.pipelineRepo.TransitionStatus(ctx, jobID, models.CIJobStatusBlocked, models.CIJobStatusCancelled); err != nil {
+		return err
+	} else if ok {
+		s.setPipelineJobStatus(ctx, jobID, models.CIJobStatusCancelled)
+		return nil
+	}
+
 	url := fmt.Sprintf("%s/api/v1/jobs/%s/cancel", s.config.ServerURL, jobID)
 
 	resp, err := s.client.R().
@@ -625,36 +859,65 @@ func (s *CIService) GetJobArtifacts(ctx context.Context, jobID uuid.UUID) ([]*CI
 	return artifacts, nil
 }
 
-// DownloadArtifact downloads an artifact from the CI runner
-func (s *CIService) DownloadArtifact(ctx context.Context, jobID uuid.UUID, artifactName string) ([]byte, string, error) {
+// ArtifactDownload is an artifact being downloaded. The caller must close
+// Body.
+type ArtifactDownload struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64 // -1 if unknown
+}
+
+// StoreArtifact stores an artifact the CI runner uploads for a job, see
+// CIArtifactService.Store
+func (s *CIService) StoreArtifact(ctx context.Context, jobID uuid.UUID, name, contentType, checksum string, body io.Reader) (*models.CIArtifact, error) {
+	return s.artifacts.Store(ctx, jobID, name, contentType, checksum, body)
+}
+
+// DownloadArtifact opens an artifact of a job of a repository, from the
+// artifacts stored on the server first, then from the CI runner. The
+// artifact is streamed, never held in memory. The name must be a valid
+// artifact name, so it cannot reach other paths of the runner's API.
+func (s *CIService) DownloadArtifact(ctx context.Context, jobID, repoID uuid.UUID, artifactName string) (*ArtifactDownload, error) {
+	if err := validateArtifactName(artifactName); err != nil {
+		return nil, err
+	}
+
+	artifact, body, err := s.artifacts.Open(ctx, jobID, artifactName)
+	switch {
+	case err == nil:
+		if artifact.RepositoryID != repoID {
+			body.Close()
+			return nil, apperrors.NotFound("artifact", nil)
+		}
+		return &ArtifactDownload{Body: body, ContentType: artifact.ContentType, Size: artifact.Size}, nil
+	case !apperrors.IsNotFound(err):
+		return nil, err
+	}
+
 	if !s.IsEnabled() {
-		return nil, "", fmt.Errorf("CI integration is not enabled")
+		return nil, fmt.Errorf("CI integration is not enabled")
 	}
 
-	url := fmt.Sprintf("%s/api/v1/jobs/%s/artifacts/%s", s.config.ServerURL, jobID, artifactName)
+	artifactURL := fmt.Sprintf("%s/api/v1/jobs/%s/artifacts/%s", s.config.ServerURL, jobID, url.PathEscape(artifactName))
 
 	resp, err := s.client.R().
 		SetContext(ctx).
 		SetDoNotParseResponse(true).
-		Get(url)
+		Get(artifactURL)
 
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to download artifact: %w", err)
+		return nil, fmt.Errorf("failed to download artifact: %w", err)
 	}
-	defer resp.RawBody().Close()
 
 	if resp.StatusCode() == 404 {
-		return nil, "", fmt.Errorf("artifact not found")
+		resp.RawBody().Close()
+		return nil, apperrors.NotFound("artifact", nil)
 	}
 
 	if resp.StatusCode() != 200 {
-		body, _ := io.ReadAll(resp.RawBody())
-		return nil, "", fmt.Errorf("CI runner returned status %d: %s", resp.StatusCode(), string(body))
-	}
-
-	data, err := io.ReadAll(resp.RawBody())
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to read artifact data: %w", err)
+		defer resp.RawBody().Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.RawBody(), 4096))
+		return nil, fmt.Errorf("CI runner returned status %d: %s", resp.StatusCode(), string(body))
 	}
 
 	contentType := resp.Header().Get("Content-Type")
@@ -662,7 +925,11 @@ func (s *CIService) DownloadArtifact(ctx context.Context, jobID uuid.UUID, artif
 		contentType = "application/octet-stream"
 	}
 
-	return data, contentType, nil
+	return &ArtifactDownload{
+		Body:        resp.RawBody(),
+		ContentType: contentType,
+		Size:        resp.RawResponse.ContentLength,
+	}, nil
 }
 
 // GetJobSteps retrieves steps for a CI job from the CI server
@@ -687,7 +954,7 @@ func (s *CIService) GetJobSteps(ctx context.Context, jobID uuid.UUID) ([]*CIStep
 
 // GetLatestJobByRepository gets the latest job for a repository from the CI server
 func (s *CIService) GetLatestJobByRepository(ctx context.Context, repoID uuid.UUID) (*CIJob, error) {
-	jobs, _, err := s.ListJobsByRepository(ctx, repoID, 1, 0)
+	jobs, _, err := s.ListJobsByRepository(ctx, repoID, nil, 1, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -701,7 +968,7 @@ func (s *CIService) GetLatestJobByRepository(ctx context.Context, repoID uuid.UU
 
 // GetLatestJobByRef gets the latest job for a specific ref from the CI server
 func (s *CIService) GetLatestJobByRef(ctx context.Context, repoID uuid.UUID, refName string) (*CIJob, error) {
-	jobs, err := s.ListJobsByRef(ctx, repoID, refName, 1, 0)
+	jobs, _, err := s.ListJobsByRef(ctx, repoID, refName, 1, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -720,6 +987,7 @@ func (s *CIService) Subscribe(jobID uuid.UUID) chan *JobEvent {
 
 	ch := make(chan *JobEvent, 100)
 	s.subscribers[jobID] = append(s.subscribers[jobID], ch)
+	metrics.CISSESubscriberAdded()
 	return ch
 }
 
@@ -733,6 +1001,7 @@ func (s *CIService) Unsubscribe(jobID uuid.UUID, ch chan *JobEvent) {
 		if sub == ch {
 			close(ch)
 			s.subscribers[jobID] = append(subs[:i], subs[i+1:]...)
+			metrics.CISSESubscriberRemoved()
 			break
 		}
 	}
@@ -760,28 +1029,130 @@ func (s *CIService) broadcastEvent(jobID uuid.UUID, event *JobEvent) {
 	}
 }
 
-// BroadcastLogEvent broadcasts a log event to all subscribers
-func (s *CIService) BroadcastLogEvent(jobID uuid.UUID, log *CILog) {
-	s.broadcastEvent(jobID, &JobEvent{
-		Type:      "log",
-		JobID:     jobID,
-		Timestamp: log.Timestamp,
-		Data:      s.mustMarshal(log),
-	})
+// streamJobEvent passes CI job events from the event bus to the SSE
+// subscribers of the job
+func (s *CIService) streamJobEvent(_ context.Context, env events.Envelope) error {
+	switch e := env.Event.(type) {
+	case events.CIJobLogs:
+		logs := make([]*CILog, len(e.Lines))
+		for i, line := range e.Lines {
+			logs[i] = &CILog{
+				Timestamp: line.Timestamp,
+				Level:     line.Level,
+				StepName:  line.StepName,
+				Message:   line.Message,
+				Sequence:  line.Sequence,
+			}
+		}
+		s.broadcastEvent(e.JobID, &JobEvent{
+			Type:      "logs",
+			JobID:     e.JobID,
+			Timestamp: env.OccurredAt,
+			Data:      s.mustMarshal(logs),
+		})
+	case events.CIJobStatus:
+		s.broadcastEvent(e.JobID, &JobEvent{
+			Type:      "status",
+			JobID:     e.JobID,
+			Timestamp: env.OccurredAt,
+			Data: s.mustMarshal(map[string]interface{}{
+				"status":      e.Status,
+				"started_at":  e.StartedAt,
+				"finished_at": e.FinishedAt,
+			}),
+		})
+	}
+	return nil
 }
 
-// BroadcastStatusEvent broadcasts a status update event to all subscribers
-func (s *CIService) BroadcastStatusEvent(jobID uuid.UUID, status string, startedAt, finishedAt *time.Time) {
-	s.broadcastEvent(jobID, &JobEvent{
-		Type:      "status",
-		JobID:     jobID,
-		Timestamp: time.Now(),
-		Data: s.mustMarshal(map[string]interface{}{
-			"status":      status,
-			"started_at":  startedAt,
-			"finished_at": finishedAt,
-		}),
-	})
+// ciLogLimitMessage is the line ending the logs of a job reported past the
+// caps of ci.log_limit_lines and ci.log_limit_mb
+const ciLogLimitMessage = "log limit exceeded: further lines of this job are dropped"
+
+// BroadcastLogs publishes a batch of log lines of a job, as one event, and
+// returns how many of them were published. Lines past ci.log_limit_lines or
+// ci.log_limit_mb are dropped; the batch reaching the cap ends with a single
+// "log limit exceeded" line in place of the first dropped one.
+func (s *CIService) BroadcastLogs(jobID uuid.UUID, logs []*CILog) int {
+	sizes := make([]int, len(logs))
+	for i, log := range logs {
+		sizes[i] = len(log.Message)
+	}
+	admitted, exceeded := s.logVolumes.admit(jobID, sizes, int64(s.config.LogLimitLines), s.config.LogLimitBytes())
+
+	lines := make([]events.CIJobLogLine, 0, admitted+1)
+	for _, log := range logs[:admitted] {
+		lines = append(lines, events.CIJobLogLine{
+			Timestamp: log.Timestamp,
+			Level:     log.Level,
+			StepName:  log.StepName,
+			Message:   log.Message,
+			Sequence:  log.Sequence,
+		})
+	}
+	if exceeded {
+		s.log.Warn("CI job exceeded the log limit, dropping further lines",
+			logger.String("job_id", jobID.String()),
+			logger.Int("limit_lines", s.config.LogLimitLines),
+			logger.Int("limit_mb", s.config.LogLimitMB),
+		)
+		lines = append(lines, events.CIJobLogLine{
+			Timestamp: time.Now().UTC(),
+			Level:     "warn",
+			Message:   ciLogLimitMessage,
+			Sequence:  logs[admitted].Sequence,
+		})
+	}
+
+	if len(lines) > 0 {
+		s.bus.Publish(events.CIJobLogs{JobID: jobID, Lines: lines})
+	}
+	return admitted
+}
+
+// BroadcastStatusEvent publishes a status update of a job, with the error the
+// runner reported, if any. The status is recorded on the job token first,
+// with a conditional update, and a status the job already had, or a status
+// reported after the job finished, is not published again. Consumers see
+// each transition once even when the runner reports it through several
+// callbacks. The event carries the repository, ref and triggering user of the
+// job, recorded when it was submitted.
+func (s *CIService) BroadcastStatusEvent(ctx context.Context, jobID uuid.UUID, status string, jobErr *string, startedAt, finishedAt *time.Time) {
+	event := events.CIJobStatus{
+		JobID:      jobID,
+		Status:     status,
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+	}
+	if jobErr != nil {
+		event.Error = *jobErr
+	}
+
+	job, err := s.jobTokens.AdvanceStatus(ctx, jobID, status)
+	switch {
+	case err == nil && job == nil:
+		return
+	case err == nil:
+		event.RunID = job.RunID
+		event.RepositoryID = job.RepositoryID
+		event.Stage = job.Stage
+		event.JobName = job.JobName
+		event.CommitSHA = job.CommitSHA
+		event.RefName = job.RefName
+		event.RefType = string(job.RefType)
+		event.TriggeredBy = job.TriggerActor
+	case apperrors.IsNotFound(err):
+		// Jobs submitted before their status was recorded
+	default:
+		s.log.WithContext(ctx).Warn("Failed to record CI job status, publishing it without the job",
+			logger.Error(err),
+			logger.String("job_id", jobID.String()),
+			logger.String("status", status),
+		)
+	}
+
+	metrics.CIJobStatus(status)
+	s.bus.Publish(event)
 }
 
 // GetConfigPath returns the path to the CI config file in repositories
@@ -789,19 +1160,19 @@ func (s *CIService) GetConfigPath() string {
 	return s.config.GetConfigPath()
 }
 
-// BuildCloneURL constructs the clone URL for the CI runner to use
+// BuildCloneURL constructs the clone URL for the CI runner to use, without
+// credentials; jobs are submitted with their own token in it
 func (s *CIService) BuildCloneURL(owner, repoName string) string {
-	baseURL := s.config.GetGitServerURLWithAPIToken()
-	return baseURL + "/" + owner + "/" + repoName + ".git"
+	if s.config.GitServerURL != "" {
+		return fmt.Sprintf("%s/%s/%s.git", s.config.GitServerURL, owner, repoName)
+	}
+	return fmt.Sprintf("http://localhost:8080/%s/%s.git", owner, repoName)
 }
 
 // Helper functions
 
 func (s *CIService) buildCloneURL(repo *models.Repository) string {
-	if s.config.GitServerURL != "" {
-		return fmt.Sprintf("%s/%s/%s.git", s.config.GitServerURL, repo.Owner.Username, repo.Name)
-	}
-	return fmt.Sprintf("http://localhost:8080/%s/%s.git", repo.Owner.Username, repo.Name)
+	return s.BuildCloneURL(repo.Owner.Username, repo.Name)
 }
 
 func (s *CIService) mapRunnerResponseToJob(resp *CIRunnerJobResponse) *CIJob {
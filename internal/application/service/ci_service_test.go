@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/infrastructure/eventbus"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// newTestCIService returns a CIService publishing the status of jobs to bus
+func newTestCIService(bus *fakeBus, jobs ...*models.CIJobToken) *CIService {
+	return &CIService{
+		config:     &config.CIConfig{},
+		jobTokens:  NewCIJobTokenService(&fakeCIJobTokenRepo{tokens: jobs}, &config.CIConfig{}, bus),
+		bus:        bus,
+		log:        logger.Get(),
+		logVolumes: newJobLogVolumes(),
+	}
+}
+
+func TestBroadcastStatusEventCarriesJob(t *testing.T) {
+	job := &models.CIJobToken{
+		JobID:        uuid.New(),
+		RunID:        uuid.New(),
+		RepositoryID: uuid.New(),
+		CommitSHA:    "0123456789abcdef0123456789abcdef01234567",
+		RefName:      "main",
+		RefType:      models.CIRefTypeBranch,
+		TriggerActor: "alice",
+	}
+	bus := &fakeBus{}
+	s := newTestCIService(bus, job)
+	jobErr := "exit status 1"
+	unknown := uuid.New()
+
+	s.BroadcastStatusEvent(context.Background(), job.JobID, models.CIJobStatusFailed, &jobErr, nil, nil)
+	s.BroadcastStatusEvent(context.Background(), unknown, models.CIJobStatusFailed, nil, nil, nil)
+
+	published := bus.Events()
+	if len(published) != 2 {
+		t.Fatalf("published %d events, want 2", len(published))
+	}
+	want := events.CIJobStatus{
+		JobID:        job.JobID,
+		Status:       models.CIJobStatusFailed,
+		Error:        jobErr,
+		RunID:        job.RunID,
+		RepositoryID: job.RepositoryID,
+		CommitSHA:    job.CommitSHA,
+		RefName:      "main",
+		RefType:      "branch",
+		TriggeredBy:  "alice",
+	}
+	if got := published[0]; got != want {
+		t.Errorf("published %+v, want %+v", got, want)
+	}
+	if got := published[1]; got != (events.CIJobStatus{JobID: unknown, Status: models.CIJobStatusFailed}) {
+		t.Errorf("published %+v for an unknown job, want its ID and status only", got)
+	}
+}
+
+func TestBroadcastStatusEventPublishesEachTransitionOnce(t *testing.T) {
+	job := &models.CIJobToken{JobID: uuid.New(), RepositoryID: uuid.New()}
+	bus := &fakeBus{}
+	s := newTestCIService(bus, job)
+	ctx := context.Background()
+
+	s.BroadcastStatusEvent(ctx, job.JobID, models.CIJobStatusQueued, nil, nil, nil)
+	s.BroadcastStatusEvent(ctx, job.JobID, models.CIJobStatusRunning, nil, nil, nil)
+	s.BroadcastStatusEvent(ctx, job.JobID, models.CIJobStatusRunning, nil, nil, nil)
+
+	// The completion callback and the job update webhook both report the
+	// terminal status, concurrently
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.BroadcastStatusEvent(ctx, job.JobID, models.CIJobStatusSuccess, nil, nil, nil)
+		}()
+	}
+	wg.Wait()
+
+	// Reports after the job finished are ignored
+	s.BroadcastStatusEvent(ctx, job.JobID, models.CIJobStatusRunning, nil, nil, nil)
+	s.BroadcastStatusEvent(ctx, job.JobID, models.CIJobStatusFailed, nil, nil, nil)
+
+	var statuses []string
+	for _, event := range bus.Events() {
+		statuses = append(statuses, event.(events.CIJobStatus).Status)
+	}
+	want := []string{models.CIJobStatusQueued, models.CIJobStatusRunning, models.CIJobStatusSuccess}
+	if len(statuses) != len(want) {
+		t.Fatalf("published %v, want %v", statuses, want)
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Fatalf("published %v, want %v", statuses, want)
+		}
+	}
+}
+
+// logBatch returns n log lines numbered from first
+func logBatch(first, n int) []*CILog {
+	logs := make([]*CILog, n)
+	for i := range logs {
+		logs[i] = &CILog{
+			Timestamp: time.Now().UTC(),
+			Level:     "info",
+			Message:   fmt.Sprintf("line %d: %s", first+i, strings.Repeat("x", 60)),
+			Sequence:  uint64(first + i),
+		}
+	}
+	return logs
+}
+
+// Each batch is published as one event; lines past the cap are dropped, the
+// batch reaching it ending with a single marker line
+func TestBroadcastLogsCap(t *testing.T) {
+	bus := &fakeBus{}
+	s := newTestCIService(bus)
+	s.config.LogLimitLines = 5
+	job := uuid.New()
+
+	if accepted := s.BroadcastLogs(job, logBatch(1, 3)); accepted != 3 {
+		t.Errorf("first batch: %d lines accepted, want 3", accepted)
+	}
+	if accepted := s.BroadcastLogs(job, logBatch(4, 4)); accepted != 2 {
+		t.Errorf("batch reaching the cap: %d lines accepted, want 2", accepted)
+	}
+	if accepted := s.BroadcastLogs(job, logBatch(8, 2)); accepted != 0 {
+		t.Errorf("batch past the cap: %d lines accepted, want 0", accepted)
+	}
+
+	published := bus.Events()
+	if len(published) != 2 {
+		t.Fatalf("published %d events, want one per batch with lines", len(published))
+	}
+	var messages []string
+	for i, event := range published {
+		logs, ok := event.(events.CIJobLogs)
+		if !ok || logs.JobID != job {
+			t.Fatalf("event %d = %+v, want the logs of the job", i, event)
+		}
+		for _, line := range logs.Lines {
+			messages = append(messages, line.Message)
+		}
+	}
+	if len(messages) != 6 || messages[5] != ciLogLimitMessage {
+		t.Fatalf("published %d lines %q, want 5 and the marker", len(messages), messages)
+	}
+	if marker := published[1].(events.CIJobLogs).Lines[2]; marker.Sequence != 6 || marker.Level != "warn" {
+		t.Errorf("marker = %+v, want a warning in place of line 6", marker)
+	}
+}
+
+// BenchmarkLogIngest ingests the logs of a chatty job through the event bus
+// to an SSE subscriber, as one event per line as before the logs were
+// batched, and as one event per batch
+func BenchmarkLogIngest(b *testing.B) {
+	const lines = 50000
+	logs := logBatch(1, lines)
+
+	for _, batchSize := range []int{1, 100, 500} {
+		name := fmt.Sprintf("batch-%d", batchSize)
+		if batchSize == 1 {
+			name = "per-line"
+		}
+		b.Run(name, func(b *testing.B) {
+			bus := eventbus.New()
+			defer bus.Close()
+			s := NewCIService(&config.CIConfig{}, nil, nil, nil, nil, nil, nil, bus)
+			// Events a subscriber falls behind on are dropped with a warning,
+			// which is counted rather than logged
+			s.log = logger.NewWithCore(logger.DefaultConfig(), zapcore.NewNopCore())
+
+			sent := (lines + batchSize - 1) / batchSize
+			dropped := 0
+			for b.Loop() {
+				job := uuid.New()
+				ch := s.Subscribe(job)
+				received := make(chan int)
+				go func() {
+					n := 0
+					for range ch {
+						n++
+					}
+					received <- n
+				}()
+				for start := 0; start < lines; start += batchSize {
+					s.BroadcastLogs(job, logs[start:min(start+batchSize, lines)])
+				}
+				s.Unsubscribe(job, ch)
+				dropped += sent - <-received
+			}
+			b.ReportMetric(float64(lines*b.N)/b.Elapsed().Seconds(), "lines/s")
+			b.ReportMetric(float64(dropped)/float64(b.N), "dropped-events/op")
+		})
+	}
+}
+
+// Artifact names are validated before they reach the API of the runner, and
+// escaped in its URL
+func TestDownloadArtifactName(t *testing.T) {
+	var paths []string
+	runner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.Write([]byte("artifact"))
+	}))
+	defer runner.Close()
+
+	cfg := &config.CIConfig{Enabled: true, ServerURL: runner.URL}
+	tokens := &fakeCIJobTokenRepo{}
+	s := &CIService{
+		config:    cfg,
+		client:    resty.New(),
+		artifacts: NewCIArtifactService(&fakeCIArtifactRepo{tokens: tokens}, tokens, &fakeStorage{}, cfg),
+		log:       logger.Get(),
+	}
+	job := uuid.New()
+
+	for _, name := range []string{"", "..", "../cancel", "a/../../cancel", `..\cancel`, ".hidden", "a\x00b"} {
+		if _, err := s.DownloadArtifact(context.Background(), job, uuid.New(), name); !apperrors.IsBadRequest(err) {
+			t.Errorf("DownloadArtifact(%q) = %v, want a bad request", name, err)
+		}
+	}
+	if len(paths) != 0 {
+		t.Fatalf("invalid names reached the runner: %v", paths)
+	}
+
+	for name, want := range map[string]string{
+		"report.xml":    "report.xml",
+		"test report 1": "test%20report%201",
+		"a?b#c%d":       "a%3Fb%23c%25d",
+	} {
+		download, err := s.DownloadArtifact(context.Background(), job, uuid.New(), name)
+		if err != nil {
+			t.Fatalf("DownloadArtifact(%q): %v", name, err)
+		}
+		download.Body.Close()
+		if got := paths[len(paths)-1]; got != "/api/v1/jobs/"+job.String()+"/artifacts/"+want {
+			t.Errorf("DownloadArtifact(%q) requested %s, want the name escaped as %s", name, got, want)
+		}
+	}
+}
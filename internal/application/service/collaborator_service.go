@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// CollaboratorService manages the collaborators of repositories. Permission
+// checks against collaborators are done by RepoService.
+type CollaboratorService struct {
+	collabRepo repository.CollaboratorRepository
+	userRepo   repository.UserRepository
+	publisher  events.Publisher
+	log        *logger.Logger
+}
+
+// NewCollaboratorService creates a new CollaboratorService instance
+// publishing new collaborators on publisher
+func NewCollaboratorService(
+	collabRepo repository.CollaboratorRepository,
+	userRepo repository.UserRepository,
+	publisher events.Publisher,
+) *CollaboratorService {
+	return &CollaboratorService{
+		collabRepo: collabRepo,
+		userRepo:   userRepo,
+		publisher:  publisher,
+		log:        logger.Get().WithFields(logger.Component("collaborator-service")),
+	}
+}
+
+// ListCollaborators returns the collaborators of a repository
+func (s *CollaboratorService) ListCollaborators(ctx context.Context, repo *models.Repository) ([]*models.RepositoryCollaborator, error) {
+	return s.collabRepo.ListByRepository(ctx, repo.ID)
+}
+
+// AddCollaborator grants a user a permission on a repository, replacing any
+// permission they had before
+func (s *CollaboratorService) AddCollaborator(ctx context.Context, repo *models.Repository, username string, permission models.RepoPermission) (*models.RepositoryCollaborator, error) {
+	if !permission.IsValid() {
+		return nil, apperrors.BadRequest(
+			fmt.Sprintf("invalid permission %q, expected read, write or admin", permission),
+			apperrors.ErrInvalidInput,
+		)
+	}
+
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil, apperrors.BadRequest(fmt.Sprintf("user %q does not exist", username), apperrors.ErrInvalidInput)
+		}
+		return nil, err
+	}
+	if user.ID == repo.OwnerID {
+		return nil, apperrors.BadRequest("the repository owner cannot be added as a collaborator", apperrors.ErrInvalidInput)
+	}
+
+	_, err = s.collabRepo.FindByRepositoryAndUser(ctx, repo.ID, user.ID)
+	if err != nil && !apperrors.IsNotFound(err) {
+		return nil, err
+	}
+	isNew := err != nil
+
+	collaborator := &models.RepositoryCollaborator{
+		RepositoryID: repo.ID,
+		UserID:       user.ID,
+		Permission:   permission,
+	}
+	if err := s.collabRepo.Upsert(ctx, collaborator); err != nil {
+		s.log.WithContext(ctx).Error("Failed to save collaborator",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		return nil, err
+	}
+	collaborator.User = *user
+
+	s.log.WithContext(ctx).Info("Collaborator saved",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("user", user.Username),
+		logger.String("permission", string(permission)),
+	)
+	if isNew {
+		s.publisher.Publish(events.CollaboratorAdded{
+			RepositoryID: repo.ID,
+			Owner:        repo.Owner.Username,
+			Name:         repo.Name,
+			UserID:       user.ID,
+			Username:     user.Username,
+			Permission:   string(permission),
+		})
+	}
+	return collaborator, nil
+}
+
+// RemoveCollaborator revokes the access of a collaborator to a repository
+func (s *CollaboratorService) RemoveCollaborator(ctx context.Context, repo *models.Repository, username string) error {
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return apperrors.NotFound("collaborator", apperrors.ErrNotFound)
+		}
+		return err
+	}
+
+	if err := s.collabRepo.Delete(ctx, repo.ID, user.ID); err != nil {
+		return err
+	}
+
+	s.log.WithContext(ctx).Info("Collaborator removed",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("user", user.Username),
+	)
+	return nil
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// maxCachedVerifications bounds the commit signature verifications kept in
+// memory
+const maxCachedVerifications = 20000
+
+// CommitSignatureService verifies commit signatures against the GPG and SSH
+// keys of the user whose email authored the commit. Commits committed by the
+// identity of the instance signing key, the commits created by the server, are
+// verified against that key instead. Verifications are cached per commit and
+// set of keys, so adding or removing a key is seen at once.
+type CommitSignatureService struct {
+	gpgKeyRepo repository.GPGKeyRepository
+	sshKeyRepo repository.SSHKeyRepository
+	userRepo   repository.UserRepository
+	gitService service.GitService
+	instance   *signerKeys // nil if the server does not sign commits
+	cache      *verificationCache
+	log        *logger.Logger
+}
+
+// NewCommitSignatureService creates a new CommitSignatureService instance.
+// signer is the instance signing key, nil if the server does not sign the
+// commits it creates.
+func NewCommitSignatureService(
+	gpgKeyRepo repository.GPGKeyRepository,
+	sshKeyRepo repository.SSHKeyRepository,
+	userRepo repository.UserRepository,
+	gitService service.GitService,
+	signer service.CommitSigner,
+) *CommitSignatureService {
+	return &CommitSignatureService{
+		gpgKeyRepo: gpgKeyRepo,
+		sshKeyRepo: sshKeyRepo,
+		userRepo:   userRepo,
+		gitService: gitService,
+		instance:   instanceKeys(signer),
+		cache:      newVerificationCache(maxCachedVerifications),
+		log:        logger.Get().WithFields(logger.Component("commit-signatures")),
+	}
+}
+
+// signerKeys are the keys that may sign the commits of an author email
+type signerKeys struct {
+	user     *models.User // nil if no user has the email
+	gpg      []string
+	ssh      []string
+	digest   string // Identifies the keys in cache keys
+	email    string // Committer email of the commits of the instance key
+	instance bool   // The instance signing key
+}
+
+// instanceKeys returns the keys of the instance signing key, nil without one
+func instanceKeys(signer service.CommitSigner) *signerKeys {
+	if signer == nil {
+		return nil
+	}
+	keys := &signerKeys{
+		email:    signer.Committer().Email,
+		instance: true,
+	}
+	if signer.Format() == service.SignatureFormatGPG {
+		keys.gpg = []string{signer.PublicKey()}
+	} else {
+		keys.ssh = []string{signer.PublicKey()}
+	}
+	sum := sha256.Sum256([]byte("instance:" + signer.Format() + ":" + signer.KeyID()))
+	keys.digest = hex.EncodeToString(sum[:16])
+	return keys
+}
+
+// keysFor returns the keys a commit is verified against: the instance key for
+// the commits created by the server, the keys of its author otherwise
+func (s *CommitSignatureService) keysFor(commit *dto.CommitResponse, byEmail map[string]*signerKeys) *signerKeys {
+	if s.instance != nil && strings.EqualFold(commit.CommitterEmail, s.instance.email) {
+		return s.instance
+	}
+	return byEmail[commit.AuthorEmail]
+}
+
+// VerifyCommits sets the signature verification of commits. Commits are left
+// unverified if the keys or the commits cannot be read.
+func (s *CommitSignatureService) VerifyCommits(ctx context.Context, repo *models.Repository, commits []dto.CommitResponse) {
+	keysByEmail := make(map[string]*signerKeys)
+	cacheKeys := make([]string, len(commits))
+	var checks []service.SignatureCheck
+	var pending []int
+
+	for i := range commits {
+		keys := s.keysFor(&commits[i], keysByEmail)
+		if keys == nil {
+			email := commits[i].AuthorEmail
+			var err error
+			keys, err = s.signerKeys(ctx, email)
+			if err != nil {
+				s.log.WithContext(ctx).Warn("Failed to load signing keys",
+					logger.Error(err),
+					logger.String("repo_id", repo.ID.String()),
+				)
+				return
+			}
+			keysByEmail[email] = keys
+		}
+
+		cacheKeys[i] = commits[i].Hash + ":" + keys.digest
+		if verification, ok := s.cache.Get(cacheKeys[i]); ok {
+			commits[i].Verification = verificationResponse(verification, keys)
+			continue
+		}
+		checks = append(checks, service.SignatureCheck{
+			CommitHash: commits[i].Hash,
+			GPGKeys:    keys.gpg,
+			SSHKeys:    keys.ssh,
+		})
+		pending = append(pending, i)
+	}
+	if len(checks) == 0 {
+		return
+	}
+
+	verifications, err := s.gitService.VerifyCommitSignatures(ctx, repo.GitPath, checks)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to verify commit signatures",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		return
+	}
+	for j, i := range pending {
+		s.cache.Put(cacheKeys[i], verifications[j])
+		keys := s.keysFor(&commits[i], keysByEmail)
+		commits[i].Verification = verificationResponse(verifications[j], keys)
+	}
+}
+
+// signerKeys loads the keys that may sign commits authored with an email:
+// the SSH keys of the user with the email, and those of their GPG keys with
+// a user ID for the email
+func (s *CommitSignatureService) signerKeys(ctx context.Context, email string) (*signerKeys, error) {
+	keys := &signerKeys{}
+	if email == "" {
+		return keys, nil
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return keys, nil
+		}
+		return nil, err
+	}
+	keys.user = user
+
+	gpgKeys, err := s.gpgKeyRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	sshKeys, err := s.sshKeyRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, key := range gpgKeys {
+		if key.HasEmail(email) {
+			keys.gpg = append(keys.gpg, key.PublicKey)
+			ids = append(ids, "gpg:"+key.Fingerprint)
+		}
+	}
+	for _, key := range sshKeys {
+		keys.ssh = append(keys.ssh, key.PublicKey)
+		ids = append(ids, "ssh:"+key.Fingerprint)
+	}
+	slices.Sort(ids)
+	sum := sha256.Sum256([]byte(strings.Join(ids, "\n")))
+	keys.digest = hex.EncodeToString(sum[:16])
+	return keys, nil
+}
+
+// verificationResponse converts a verification of a commit authored with an
+// email to its response
+func verificationResponse(v service.SignatureVerification, keys *signerKeys) *dto.CommitVerificationResponse {
+	response := &dto.CommitVerificationResponse{
+		Verified: v.Reason == service.SignatureValid,
+		Signed:   v.Signed(),
+		Reason:   v.Reason,
+		Format:   v.Format,
+		KeyID:    v.KeyID,
+	}
+	if response.Verified && keys.user != nil {
+		response.Signer = keys.user.Username
+	}
+	if response.Verified && keys.instance {
+		response.Instance = true
+	}
+	return response
+}
+
+// verificationCache is a fixed-size LRU cache of signature verifications
+type verificationCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+// verificationCacheEntry is a single cached verification
+type verificationCacheEntry struct {
+	key          string
+	verification service.SignatureVerification
+}
+
+// newVerificationCache creates an LRU cache holding up to capacity entries
+func newVerificationCache(capacity int) *verificationCache {
+	return &verificationCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns a cached verification and marks it as recently used
+func (c *verificationCache) Get(key string) (service.SignatureVerification, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return service.SignatureVerification{}, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*verificationCacheEntry).verification, true
+}
+
+// Put stores a verification, evicting the least recently used entry when full
+func (c *verificationCache) Put(key string, verification service.SignatureVerification) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&verificationCacheEntry{key: key, verification: verification})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*verificationCacheEntry).key)
+	}
+}
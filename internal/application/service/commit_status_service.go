@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+const (
+	// defaultStatusContext is the context of statuses created without one
+	defaultStatusContext = "default"
+
+	// maxStatusContextLength is the longest context of a status
+	maxStatusContextLength = 255
+
+	// maxStatusDescriptionLength is the longest description of a status
+	maxStatusDescriptionLength = 255
+
+	// maxStatusTargetURLLength is the longest target URL of a status
+	maxStatusTargetURLLength = 2048
+)
+
+// commitSHAPattern matches a full SHA-1 or SHA-256 commit hash
+var commitSHAPattern = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// CombinedCommitStatus is the state of a commit across all its contexts
+type CombinedCommitStatus struct {
+	State    string                 // One of the models.CommitState* states
+	Statuses []*models.CommitStatus // Latest status of each context, by context
+}
+
+// CommitStatusService records the outcome of checks on commits, reported by
+// CI or by external tools through the API
+type CommitStatusService struct {
+	statusRepo repository.CommitStatusRepository
+	gitService service.GitService
+}
+
+// NewCommitStatusService creates a new CommitStatusService instance
+func NewCommitStatusService(statusRepo repository.CommitStatusRepository, gitService service.GitService) *CommitStatusService {
+	return &CommitStatusService{
+		statusRepo: statusRepo,
+		gitService: gitService,
+	}
+}
+
+// CreateStatus adds a status to a commit of a repository on behalf of a user.
+// The commit must exist in the repository.
+func (s *CommitStatusService) CreateStatus(ctx context.Context, repo *models.Repository, status *models.CommitStatus, creator *models.User) error {
+	status.RepositoryID = repo.ID
+	status.CreatorID = &creator.ID
+	status.Creator = creator.Username
+	if err := normalizeCommitStatus(status); err != nil {
+		return err
+	}
+
+	if _, err := s.gitService.GetCommit(ctx, repo.GitPath, status.SHA); err != nil {
+		return apperrors.Unprocessable(fmt.Sprintf("commit %s not found in repository", status.SHA), err)
+	}
+	return s.statusRepo.Create(ctx, status)
+}
+
+// RecordStatus validates and stores a status without checking that its
+// commit exists, for statuses of commits the server itself saw
+func (s *CommitStatusService) RecordStatus(ctx context.Context, status *models.CommitStatus) error {
+	if err := normalizeCommitStatus(status); err != nil {
+		return err
+	}
+	return s.statusRepo.Create(ctx, status)
+}
+
+// ListStatuses lists every status of a commit, most recent first
+func (s *CommitStatusService) ListStatuses(ctx context.Context, repoID uuid.UUID, sha string, limit, offset int) ([]*models.CommitStatus, error) {
+	return s.statusRepo.ListBySHA(ctx, repoID, strings.ToLower(sha), limit, offset)
+}
+
+// GetCombinedStatus returns the latest status of each context of a commit and
+// the state they add up to
+func (s *CommitStatusService) GetCombinedStatus(ctx context.Context, repoID uuid.UUID, sha string) (*CombinedCommitStatus, error) {
+	statuses, err := s.statusRepo.LatestBySHA(ctx, repoID, strings.ToLower(sha))
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]string, len(statuses))
+	for i, status := range statuses {
+		states[i] = status.State
+	}
+	return &CombinedCommitStatus{State: CombineCommitStates(states), Statuses: statuses}, nil
+}
+
+// CombineCommitStates rolls the states of the contexts of a commit up to one:
+// failure if any failed or errored, pending if any is pending or there are
+// none, and success if all succeeded
+func CombineCommitStates(states []string) string {
+	if len(states) == 0 {
+		return models.CommitStatePending
+	}
+
+	combined := models.CommitStateSuccess
+	for _, state := range states {
+		switch state {
+		case models.CommitStateFailure, models.CommitStateError:
+			return models.CommitStateFailure
+		case models.CommitStatePending:
+			combined = models.CommitStatePending
+		}
+	}
+	return combined
+}
+
+// normalizeCommitStatus trims the fields of a status and checks them
+func normalizeCommitStatus(status *models.CommitStatus) error {
+	status.SHA = strings.ToLower(status.SHA)
+	status.Context = strings.TrimSpace(status.Context)
+	status.Description = strings.TrimSpace(status.Description)
+	status.TargetURL = strings.TrimSpace(status.TargetURL)
+	if status.Context == "" {
+		status.Context = defaultStatusContext
+	}
+
+	if !commitSHAPattern.MatchString(status.SHA) {
+		return apperrors.BadRequest("sha must be a full commit hash", apperrors.ErrInvalidInput)
+	}
+	if !models.IsValidCommitState(status.State) {
+		return apperrors.BadRequest(
+			fmt.Sprintf("state must be one of %s, %s, %s or %s",
+				models.CommitStatePending, models.CommitStateSuccess, models.CommitStateFailure, models.CommitStateError),
+			apperrors.ErrInvalidInput,
+		)
+	}
+	if utf8.RuneCountInString(status.Context) > maxStatusContextLength {
+		return apperrors.BadRequest(
+			fmt.Sprintf("context must be at most %d characters", maxStatusContextLength),
+			apperrors.ErrInvalidInput,
+		)
+	}
+	if utf8.RuneCountInString(status.Description) > maxStatusDescriptionLength {
+		return apperrors.BadRequest(
+			fmt.Sprintf("description must be at most %d characters", maxStatusDescriptionLength),
+			apperrors.ErrInvalidInput,
+		)
+	}
+	if status.TargetURL != "" {
+		if len(status.TargetURL) > maxStatusTargetURLLength {
+			return apperrors.BadRequest(
+				fmt.Sprintf("target_url must be at most %d characters", maxStatusTargetURLLength),
+				apperrors.ErrInvalidInput,
+			)
+		}
+		u, err := url.Parse(status.TargetURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return apperrors.BadRequest("target_url must be an absolute http or https URL", apperrors.ErrInvalidInput)
+		}
+	}
+	return nil
+}
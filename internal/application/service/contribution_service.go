@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// contributionDateLayout is the date format of contribution calendar days
+	contributionDateLayout = "2006-01-02"
+
+	// maxContributionDays is the widest calendar range served per request (one year)
+	maxContributionDays = 366
+
+	// contributionCommitPageSize is the number of commits read per step while indexing
+	contributionCommitPageSize = 200
+
+	// maxIndexedCommits bounds the commits walked when indexing a repository
+	maxIndexedCommits = 20000
+
+	// contributionIndexTimeout bounds indexing a single repository
+	contributionIndexTimeout = 5 * time.Minute
+
+	// contributionIndexSlack is how far before the last indexing run commits are
+	// re-checked, covering pushes of commits with slightly older committer dates
+	contributionIndexSlack = 24 * time.Hour
+)
+
+// ContributionService records user contributions and serves contribution calendars.
+// Commits on default branches are credited to the user whose email matches the
+// commit author; commits older than a year are not recorded.
+type ContributionService struct {
+	contributionRepo repository.ContributionRepository
+	repoRepo         repository.RepoRepository
+	userRepo         repository.UserRepository
+	gitService       service.GitService
+	log              *logger.Logger
+}
+
+// NewContributionService creates a new ContributionService instance
+func NewContributionService(
+	contributionRepo repository.ContributionRepository,
+	repoRepo repository.RepoRepository,
+	userRepo repository.UserRepository,
+	gitService service.GitService,
+) *ContributionService {
+	return &ContributionService{
+		contributionRepo: contributionRepo,
+		repoRepo:         repoRepo,
+		userRepo:         userRepo,
+		gitService:       gitService,
+		log:              logger.Get().WithFields(logger.Component("contribution-service")),
+	}
+}
+
+// GetContributions returns the daily contribution counts of a user between from
+// and to (inclusive, YYYY-MM-DD). Empty bounds default to the year ending today.
+// Private repositories are counted for the user themselves, or for everyone if
+// the user opted in to showing private contributions.
+func (s *ContributionService) GetContributions(ctx context.Context, profile, viewer *models.User, from, to string) (*dto.ContributionCalendarResponse, error) {
+	fromDate, toDate, err := parseContributionRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	includePrivate := profile.ShowPrivateContributions || (viewer != nil && viewer.ID == profile.ID)
+
+	days, err := s.contributionRepo.DailyCounts(ctx, profile.ID, fromDate, toDate.AddDate(0, 0, 1), includePrivate)
+	if err != nil {
+		s.log.WithContext(ctx).Error("Failed to count contributions",
+			logger.Error(err),
+			logger.String("user_id", profile.ID.String()),
+		)
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(days))
+	for _, d := range days {
+		counts[d.Date] = d.Count
+	}
+
+	resp := &dto.ContributionCalendarResponse{
+		Username:        profile.Username,
+		From:            fromDate.Format(contributionDateLayout),
+		To:              toDate.Format(contributionDateLayout),
+		IncludesPrivate: includePrivate,
+		Days:            []dto.ContributionDayResponse{},
+	}
+	for d := fromDate; !d.After(toDate); d = d.AddDate(0, 0, 1) {
+		date := d.Format(contributionDateLayout)
+		resp.Days = append(resp.Days, dto.ContributionDayResponse{Date: date, Count: counts[date]})
+		resp.Total += counts[date]
+	}
+
+	return resp, nil
+}
+
+// IndexRepositoryAsync records new default branch commits of a repository in the background
+func (s *ContributionService) IndexRepositoryAsync(repo *models.Repository) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), contributionIndexTimeout)
+		defer cancel()
+
+		if err := s.IndexRepository(ctx, repo); err != nil {
+			s.log.Warn("Failed to record contributions after push",
+				logger.Error(err),
+				logger.String("repo_id", repo.ID.String()),
+			)
+		}
+	}()
+}
+
+// IndexRepository records default branch commits of a repository as contributions.
+// It walks the branch from its tip and stops at commits that were already
+// recorded, committed before the previous run, or older than the contribution window.
+func (s *ContributionService) IndexRepository(ctx context.Context, repo *models.Repository) error {
+	branch := repo.DefaultBranch
+	if branch == "" {
+		head, err := s.gitService.GetHEADBranch(ctx, repo.GitPath)
+		if err != nil {
+			return err
+		}
+		branch = head
+	}
+
+	exists, err := s.gitService.BranchExists(ctx, repo.GitPath, branch)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		// Empty repository, nothing to credit yet
+		return s.repoRepo.MarkContributionsIndexed(ctx, repo.ID)
+	}
+
+	since := time.Now().UTC().AddDate(-1, 0, 0)
+	var lastIndexed time.Time
+	if repo.ContributionsIndexedAt != nil {
+		lastIndexed = repo.ContributionsIndexedAt.Add(-contributionIndexSlack)
+	}
+	authors := make(map[string]uuid.UUID)
+	recorded := 0
+
+	for offset := 0; offset < maxIndexedCommits; offset += contributionCommitPageSize {
+		commits, err := s.gitService.GetCommits(ctx, repo.GitPath, branch, contributionCommitPageSize, offset)
+		if err != nil {
+			return err
+		}
+		if len(commits) == 0 {
+			break
+		}
+
+		hashes := make([]string, len(commits))
+		for i, c := range commits {
+			hashes[i] = c.Hash
+		}
+		known, err := s.contributionRepo.FindExistingRefs(ctx, repo.ID, models.ContributionKindCommit, hashes)
+		if err != nil {
+			return err
+		}
+
+		var events []*models.ContributionEvent
+		reachedKnown, reachedWindow := true, false
+		for _, c := range commits {
+			if c.AuthorDate.Before(since) || c.CommitterDate.Before(lastIndexed) {
+				reachedWindow = true
+				continue
+			}
+			if known[c.Hash] {
+				continue
+			}
+			reachedKnown = false
+
+			userID, err := s.resolveAuthor(ctx, authors, c.AuthorEmail)
+			if err != nil {
+				return err
+			}
+			if userID == uuid.Nil {
+				continue
+			}
+
+			events = append(events, &models.ContributionEvent{
+				UserID:       userID,
+				RepositoryID: repo.ID,
+				Kind:         models.ContributionKindCommit,
+				Ref:          c.Hash,
+				OccurredAt:   c.AuthorDate.UTC(),
+			})
+		}
+
+		if err := s.contributionRepo.CreateBatch(ctx, events); err != nil {
+			return err
+		}
+		recorded += len(events)
+
+		if reachedKnown || reachedWindow || len(commits) < contributionCommitPageSize {
+			break
+		}
+	}
+
+	if err := s.repoRepo.MarkContributionsIndexed(ctx, repo.ID); err != nil {
+		return err
+	}
+
+	if recorded > 0 {
+		s.log.WithContext(ctx).Debug("Recorded commit contributions",
+			logger.String("repo_id", repo.ID.String()),
+			logger.String("branch", branch),
+			logger.Int("count", recorded),
+		)
+	}
+	return nil
+}
+
+// Backfill indexes every repository that has never been indexed. It is safe to
+// run repeatedly; once all repositories are indexed it is a no-op.
+func (s *ContributionService) Backfill(ctx context.Context) {
+	indexed := 0
+	for {
+		repos, err := s.repoRepo.FindContributionsUnindexed(ctx, 50)
+		if err != nil {
+			s.log.WithContext(ctx).Error("Failed to list repositories for contribution backfill", logger.Error(err))
+			return
+		}
+		if len(repos) == 0 {
+			break
+		}
+
+		for _, repo := range repos {
+			if ctx.Err() != nil {
+				return
+			}
+
+			repoCtx, cancel := context.WithTimeout(ctx, contributionIndexTimeout)
+			err := s.IndexRepository(repoCtx, repo)
+			cancel()
+			if err != nil {
+				s.log.WithContext(ctx).Warn("Failed to backfill contributions",
+					logger.Error(err),
+					logger.String("repo_id", repo.ID.String()),
+				)
+				// Mark anyway so a broken repository does not stall the backfill;
+				// new pushes index it again
+				if err := s.repoRepo.MarkContributionsIndexed(ctx, repo.ID); err != nil {
+					s.log.WithContext(ctx).Error("Failed to mark repository as indexed", logger.Error(err))
+					return
+				}
+			}
+			indexed++
+		}
+	}
+
+	if indexed > 0 {
+		s.log.WithContext(ctx).Info("Contribution backfill completed", logger.Int("repositories", indexed))
+	}
+}
+
+// resolveAuthor maps a commit author email to a user ID, caching lookups.
+// It returns uuid.Nil if no user has the email.
+func (s *ContributionService) resolveAuthor(ctx context.Context, cache map[string]uuid.UUID, email string) (uuid.UUID, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return uuid.Nil, nil
+	}
+	if id, ok := cache[email]; ok {
+		return id, nil
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil && !apperrors.IsNotFound(err) {
+		return uuid.Nil, err
+	}
+
+	id := uuid.Nil
+	if user != nil {
+		id = user.ID
+	}
+	cache[email] = id
+	return id, nil
+}
+
+// parseContributionRange parses a calendar range, defaulting to the year ending today
+func parseContributionRange(from, to string) (time.Time, time.Time, error) {
+	toDate := time.Now().UTC().Truncate(24 * time.Hour)
+	if to != "" {
+		t, err := time.Parse(contributionDateLayout, to)
+		if err != nil {
+			return time.Time{}, time.Time{}, apperrors.BadRequest("invalid 'to' date, expected YYYY-MM-DD", apperrors.ErrInvalidInput)
+		}
+		toDate = t
+	}
+
+	fromDate := toDate.AddDate(-1, 0, 1)
+	if from != "" {
+		f, err := time.Parse(contributionDateLayout, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, apperrors.BadRequest("invalid 'from' date, expected YYYY-MM-DD", apperrors.ErrInvalidInput)
+		}
+		fromDate = f
+	}
+
+	if fromDate.After(toDate) {
+		return time.Time{}, time.Time{}, apperrors.BadRequest("'from' must not be after 'to'", apperrors.ErrInvalidInput)
+	}
+	if days := int(toDate.Sub(fromDate).Hours()/24) + 1; days > maxContributionDays {
+		return time.Time{}, time.Time{}, apperrors.BadRequest("date range cannot exceed one year", apperrors.ErrInvalidInput)
+	}
+
+	return fromDate, toDate, nil
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// degradedModeProbeTimeout bounds a check of the database
+const degradedModeProbeTimeout = 2 * time.Second
+
+// DegradedModeService keeps public repositories fetchable while the database
+// is unreachable. While the database is up it keeps a snapshot of the public
+// repositories: their owner, name and storage location, which is all an
+// anonymous fetch needs. A probe checks the database; once it fails the
+// server is degraded and the git transports serve anonymous fetches from the
+// snapshot while everything else answers 503. When the database is back the
+// snapshot is refreshed and the server leaves degraded mode.
+//
+// The snapshot is only served from up to server.degraded_mode.max_staleness
+// after it was taken, since a repository made private since would otherwise
+// stay public until the database is back.
+type DegradedModeService struct {
+	repoRepo repository.RepoRepository
+	ping     func(context.Context) error
+	cfg      *config.DegradedModeConfig
+	log      *logger.Logger
+
+	snapshot atomic.Pointer[degradedSnapshot]
+	active   atomic.Bool
+	served   atomic.Int64
+	rejected atomic.Int64
+
+	mu           sync.Mutex
+	since        time.Time     // Start of the current episode
+	episodes     int64         // Times the server entered degraded mode
+	degradedTime time.Duration // Time spent degraded in finished episodes
+	lastStale    time.Time     // Last time the age of the snapshot was logged
+}
+
+// degradedSnapshot is the metadata of the public repositories at a point in time
+type degradedSnapshot struct {
+	repos   map[string]*models.Repository // By owner/name
+	takenAt time.Time
+}
+
+// DegradedModeStatus is the state of degraded mode
+type DegradedModeStatus struct {
+	Active               bool
+	Serving              bool       // Active with a snapshot fresh enough to serve from
+	Since                *time.Time // Start of the current episode, if active
+	SnapshotTakenAt      *time.Time // nil until the first snapshot
+	SnapshotRepositories int
+	Metrics              DegradedModeMetrics
+}
+
+// DegradedModeMetrics counts the activity of degraded mode since the server
+// started
+type DegradedModeMetrics struct {
+	Episodes         int64         // Times the server entered degraded mode
+	DegradedTime     time.Duration // Time spent degraded, including the current episode
+	ServedFetches    int64         // Git requests served from the snapshot
+	RejectedRequests int64         // Requests answered 503 because the server was degraded
+}
+
+// NewDegradedModeService creates a new DegradedModeService instance checking
+// the database with ping. Start it with Run.
+func NewDegradedModeService(repoRepo repository.RepoRepository, ping func(context.Context) error, cfg *config.DegradedModeConfig) *DegradedModeService {
+	return &DegradedModeService{
+		repoRepo: repoRepo,
+		ping:     ping,
+		cfg:      cfg,
+		log:      logger.Get().WithFields(logger.Component("degraded-mode")),
+	}
+}
+
+// Run takes the first snapshot, then checks the database and refreshes the
+// snapshot until ctx is done
+func (s *DegradedModeService) Run(ctx context.Context) {
+	s.refresh(ctx)
+	nextRefresh := time.Now().Add(s.cfg.RefreshInterval())
+
+	ticker := time.NewTicker(s.cfg.ProbeInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		probeCtx, cancel := context.WithTimeout(ctx, degradedModeProbeTimeout)
+		err := s.ping(probeCtx)
+		cancel()
+
+		if err != nil {
+			s.enter(err)
+			s.logStaleness()
+			continue
+		}
+
+		if s.active.Load() {
+			s.exit()
+			// Re-sync right away: the snapshot missed the changes made by
+			// other replicas in the meantime
+			s.refresh(ctx)
+			nextRefresh = time.Now().Add(s.cfg.RefreshInterval())
+			continue
+		}
+
+		if !time.Now().Before(nextRefresh) {
+			s.refresh(ctx)
+			nextRefresh = time.Now().Add(s.cfg.RefreshInterval())
+		}
+	}
+}
+
+// Active returns true while the server is degraded. It is false if degraded
+// mode is disabled, that is s is nil.
+func (s *DegradedModeService) Active() bool {
+	return s != nil && s.active.Load()
+}
+
+// Lookup returns a public repository from the snapshot. Any other repository,
+// private or missing, and any repository once the snapshot is too old, is
+// unavailable: the snapshot cannot tell them apart.
+func (s *DegradedModeService) Lookup(owner, name string) (*models.Repository, error) {
+	snapshot := s.snapshot.Load()
+	if snapshot == nil {
+		s.rejected.Add(1)
+		return nil, apperrors.Unavailable("the database is unavailable and no snapshot of the public repositories was taken, try again shortly", nil)
+	}
+	if time.Since(snapshot.takenAt) > s.cfg.MaxStaleness() {
+		s.rejected.Add(1)
+		return nil, apperrors.Unavailable("the database is unavailable for too long to serve public repositories, try again later", nil)
+	}
+
+	repo, ok := snapshot.repos[owner+"/"+name]
+	if !ok {
+		s.rejected.Add(1)
+		return nil, apperrors.Unavailable("the database is unavailable, only public repositories can be fetched, try again shortly", nil)
+	}
+
+	s.served.Add(1)
+	copied := *repo
+	return &copied, nil
+}
+
+// RecordRejection counts a request answered 503 because the server is degraded
+func (s *DegradedModeService) RecordRejection() {
+	s.rejected.Add(1)
+}
+
+// Status returns the state of degraded mode
+func (s *DegradedModeService) Status() DegradedModeStatus {
+	status := DegradedModeStatus{
+		Active: s.active.Load(),
+		Metrics: DegradedModeMetrics{
+			ServedFetches:    s.served.Load(),
+			RejectedRequests: s.rejected.Load(),
+		},
+	}
+
+	if snapshot := s.snapshot.Load(); snapshot != nil {
+		takenAt := snapshot.takenAt
+		status.SnapshotTakenAt = &takenAt
+		status.SnapshotRepositories = len(snapshot.repos)
+		status.Serving = status.Active && time.Since(takenAt) <= s.cfg.MaxStaleness()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status.Metrics.Episodes = s.episodes
+	status.Metrics.DegradedTime = s.degradedTime
+	if status.Active && !s.since.IsZero() {
+		since := s.since
+		status.Since = &since
+		status.Metrics.DegradedTime += time.Since(since)
+	}
+	return status
+}
+
+// enter moves to degraded mode, if not already in it
+func (s *DegradedModeService) enter(cause error) {
+	if s.active.Load() {
+		return
+	}
+
+	s.mu.Lock()
+	s.since = time.Now()
+	s.episodes++
+	s.lastStale = s.since
+	s.mu.Unlock()
+	s.active.Store(true)
+
+	fields := []logger.Field{logger.Error(cause)}
+	if snapshot := s.snapshot.Load(); snapshot != nil {
+		fields = append(fields,
+			logger.Int("repositories", len(snapshot.repos)),
+			logger.Duration("snapshot_age", time.Since(snapshot.takenAt)),
+		)
+	}
+	s.log.Error("Database is unreachable, serving public repositories read-only", fields...)
+}
+
+// exit leaves degraded mode
+func (s *DegradedModeService) exit() {
+	s.active.Store(false)
+
+	s.mu.Lock()
+	duration := time.Since(s.since)
+	s.degradedTime += duration
+	s.since = time.Time{}
+	s.mu.Unlock()
+
+	s.log.Info("Database is reachable again, leaving degraded mode",
+		logger.Duration("duration", duration),
+	)
+}
+
+// refresh replaces the snapshot with the current public repositories. A
+// failure keeps the previous snapshot.
+func (s *DegradedModeService) refresh(ctx context.Context) {
+	repos, err := s.repoRepo.ListPublicLocations(ctx)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to refresh the snapshot of public repositories", logger.Error(err))
+		return
+	}
+
+	snapshot := &degradedSnapshot{
+		repos:   make(map[string]*models.Repository, len(repos)),
+		takenAt: time.Now(),
+	}
+	for _, repo := range repos {
+		snapshot.repos[repo.Owner.Username+"/"+repo.Name] = repo
+	}
+	s.snapshot.Store(snapshot)
+
+	s.log.WithContext(ctx).Debug("Snapshot of public repositories refreshed",
+		logger.Int("repositories", len(snapshot.repos)),
+	)
+}
+
+// logStaleness logs the age of the snapshot while degraded, once per refresh
+// interval
+func (s *DegradedModeService) logStaleness() {
+	snapshot := s.snapshot.Load()
+	if snapshot == nil {
+		return
+	}
+
+	s.mu.Lock()
+	due := time.Since(s.lastStale) >= s.cfg.RefreshInterval()
+	if due {
+		s.lastStale = time.Now()
+	}
+	s.mu.Unlock()
+	if !due {
+		return
+	}
+
+	age := time.Since(snapshot.takenAt)
+	if age > s.cfg.MaxStaleness() {
+		s.log.Error("Snapshot of public repositories is too old to serve from, fetches are refused",
+			logger.Duration("snapshot_age", age),
+			logger.Duration("max_staleness", s.cfg.MaxStaleness()),
+		)
+		return
+	}
+	s.log.Warn("Serving public repositories from a stale snapshot",
+		logger.Duration("snapshot_age", age),
+		logger.Duration("max_staleness", s.cfg.MaxStaleness()),
+	)
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// emailTemplateFS holds the templates of emails, a <name>.txt and a
+// <name>.html per email
+//
+//go:embed templates/email/*.txt templates/email/*.html
+var emailTemplateFS embed.FS
+
+var (
+	emailTextTemplates = texttemplate.Must(texttemplate.ParseFS(emailTemplateFS, "templates/email/*.txt"))
+	emailHTMLTemplates = htmltemplate.Must(htmltemplate.ParseFS(emailTemplateFS, "templates/email/*.html"))
+)
+
+// NotificationPreferenceUpdate holds changes to the notification preferences
+// of a user; nil fields are left unchanged
+type NotificationPreferenceUpdate struct {
+	EmailCIFailures    *bool
+	EmailCollaborators *bool
+	EmailTransfers     *bool
+}
+
+// EmailService emails users about failed CI jobs on default branches, being
+// added as collaborators, and repositories transferred to them. Emails are
+// built from events published on the bus and handed to the mailer's queue,
+// so sending never happens in request handlers. Users opt out per category
+// in their notification preferences.
+type EmailService struct {
+	mailer         service.Mailer
+	preferenceRepo repository.NotificationPreferenceRepository
+	userRepo       repository.UserRepository
+	repoRepo       repository.RepoRepository
+	repoService    *RepoService
+	cfg            *config.EmailConfig
+	log            *logger.Logger
+}
+
+// NewEmailService creates a new EmailService instance emailing about the
+// events published on subscriber when email is enabled
+func NewEmailService(
+	mailer service.Mailer,
+	preferenceRepo repository.NotificationPreferenceRepository,
+	userRepo repository.UserRepository,
+	repoRepo repository.RepoRepository,
+	repoService *RepoService,
+	subscriber events.Subscriber,
+	cfg *config.EmailConfig,
+) *EmailService {
+	s := &EmailService{
+		mailer:         mailer,
+		preferenceRepo: preferenceRepo,
+		userRepo:       userRepo,
+		repoRepo:       repoRepo,
+		repoService:    repoService,
+		cfg:            cfg,
+		log:            logger.Get().WithFields(logger.Component("email-service")),
+	}
+
+	if cfg.Enabled {
+		subscriber.Subscribe("email-ci", s.emailCIJobFailed, events.SubscribeOptions{
+			Types:      []string{events.TypeCIJobStatus},
+			MaxRetries: 3,
+		})
+		subscriber.Subscribe("email-collaborators", s.emailCollaboratorAdded, events.SubscribeOptions{
+			Types:      []string{events.TypeCollaboratorAdded},
+			MaxRetries: 3,
+		})
+		subscriber.Subscribe("email-transfers", s.emailRepositoryTransferred, events.SubscribeOptions{
+			Types:      []string{events.TypeRepositoryTransferred},
+			MaxRetries: 3,
+		})
+	}
+
+	return s
+}
+
+// Preferences returns the notification preferences of a user
+func (s *EmailService) Preferences(ctx context.Context, userID uuid.UUID) (*models.NotificationPreference, error) {
+	preference, err := s.preferenceRepo.FindByUser(ctx, userID)
+	if apperrors.IsNotFound(err) {
+		return models.DefaultNotificationPreference(userID), nil
+	}
+	return preference, err
+}
+
+// UpdatePreferences applies update to the notification preferences of a user
+func (s *EmailService) UpdatePreferences(ctx context.Context, userID uuid.UUID, update NotificationPreferenceUpdate) (*models.NotificationPreference, error) {
+	preference, err := s.Preferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.EmailCIFailures != nil {
+		preference.EmailCIFailures = *update.EmailCIFailures
+	}
+	if update.EmailCollaborators != nil {
+		preference.EmailCollaborators = *update.EmailCollaborators
+	}
+	if update.EmailTransfers != nil {
+		preference.EmailTransfers = *update.EmailTransfers
+	}
+	preference.UpdatedAt = time.Now()
+
+	if err := s.preferenceRepo.Upsert(ctx, preference); err != nil {
+		return nil, err
+	}
+	return preference, nil
+}
+
+// emailCIJobFailed emails the user who triggered a CI job, and the owner of
+// its repository, when it fails on the default branch
+func (s *EmailService) emailCIJobFailed(ctx context.Context, env events.Envelope) error {
+	status, ok := env.Event.(events.CIJobStatus)
+	if !ok {
+		return nil
+	}
+	switch status.Status {
+	case models.CIJobStatusFailed, models.CIJobStatusTimedOut, models.CIJobStatusError:
+	default:
+		return nil
+	}
+
+	repo, err := s.repoRepo.FindByID(ctx, status.RepositoryID)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if status.RefType != string(models.CIRefTypeBranch) || status.RefName != repo.DefaultBranch {
+		return nil
+	}
+
+	recipients := []*models.User{&repo.Owner}
+	if status.TriggeredBy != "" && status.TriggeredBy != repo.Owner.Username {
+		actor, err := s.userRepo.FindByUsername(ctx, status.TriggeredBy)
+		if err != nil && !apperrors.IsNotFound(err) {
+			return err
+		}
+		if actor != nil && s.repoService.HasPermission(ctx, actor, repo, models.RepoPermissionRead) {
+			recipients = append(recipients, actor)
+		}
+	}
+
+	repoURL := s.repoService.RepositoryWebURL(repo.Owner.Username, repo.Name)
+	jobURL := ""
+	if repoURL != "" {
+		jobURL = fmt.Sprintf("%s/ci/%s", repoURL, status.JobID)
+	}
+
+	for _, user := range recipients {
+		err := s.send(ctx, user, models.EmailCategoryCIFailures, "ci_job_failed",
+			fmt.Sprintf("[%s] CI job %s %s on %s", repo.GetFullName(), ciJobLabel(status), strings.ReplaceAll(status.Status, "_", " "), status.RefName),
+			map[string]any{
+				"Username":   user.Username,
+				"Repository": repo.GetFullName(),
+				"JobName":    ciJobLabel(status),
+				"Stage":      status.Stage,
+				"Status":     strings.ReplaceAll(status.Status, "_", " "),
+				"Branch":     status.RefName,
+				"CommitSHA":  status.CommitSHA,
+				"Error":      status.Error,
+				"JobURL":     jobURL,
+			})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emailCollaboratorAdded emails a user added as a collaborator of a repository
+func (s *EmailService) emailCollaboratorAdded(ctx context.Context, env events.Envelope) error {
+	event, ok := env.Event.(events.CollaboratorAdded)
+	if !ok {
+		return nil
+	}
+
+	user, err := s.userRepo.FindByID(ctx, event.UserID)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	fullName := event.Owner + "/" + event.Name
+	return s.send(ctx, user, models.EmailCategoryCollaborators, "collaborator_added",
+		fmt.Sprintf("You now have %s access to %s", event.Permission, fullName),
+		map[string]any{
+			"Username":      user.Username,
+			"Repository":    fullName,
+			"RepositoryURL": s.repoService.RepositoryWebURL(event.Owner, event.Name),
+			"Permission":    event.Permission,
+		})
+}
+
+// emailRepositoryTransferred emails the new owner of a transferred repository
+func (s *EmailService) emailRepositoryTransferred(ctx context.Context, env events.Envelope) error {
+	event, ok := env.Event.(events.RepositoryTransferred)
+	if !ok {
+		return nil
+	}
+
+	user, err := s.userRepo.FindByID(ctx, event.OwnerID)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	fullName := event.Owner + "/" + event.Name
+	return s.send(ctx, user, models.EmailCategoryTransfers, "repository_transferred",
+		fmt.Sprintf("%s/%s was transferred to you", event.PreviousOwner, event.Name),
+		map[string]any{
+			"Username":      user.Username,
+			"Repository":    fullName,
+			"RepositoryURL": s.repoService.RepositoryWebURL(event.Owner, event.Name),
+			"PreviousOwner": event.PreviousOwner,
+			"Name":          event.Name,
+		})
+}
+
+// send renders the email template of a category for a user and queues it,
+// unless the user opted out of the category or has no email address
+func (s *EmailService) send(ctx context.Context, user *models.User, category, template, subject string, data map[string]any) error {
+	if user.Email == "" {
+		return nil
+	}
+	preference, err := s.Preferences(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if !preference.Allows(category) {
+		return nil
+	}
+
+	data["Category"] = category
+	var text, html bytes.Buffer
+	if err := emailTextTemplates.ExecuteTemplate(&text, template+".txt", data); err != nil {
+		return fmt.Errorf("failed to render email %s: %w", template, err)
+	}
+	if err := emailHTMLTemplates.ExecuteTemplate(&html, template+".html", data); err != nil {
+		return fmt.Errorf("failed to render email %s: %w", template, err)
+	}
+
+	if !s.mailer.Enqueue(service.Email{
+		To:       user.Email,
+		Subject:  subject,
+		Text:     text.String(),
+		HTML:     html.String(),
+		Category: category,
+	}) {
+		s.log.WithContext(ctx).Warn("Email not queued",
+			logger.String("category", category),
+			logger.String("user", user.Username),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	mailqueue "github.com/bravo68web/stasis/internal/infrastructure/mail"
+)
+
+// sentEmail is an email written by the directory transport
+type sentEmail struct {
+	To      string
+	Subject string
+	Text    string
+}
+
+// readEmails parses the emails the directory transport wrote to dir, sorted
+// by recipient
+func readEmails(t *testing.T, dir string) []sentEmail {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var emails []sentEmail
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := mail.ReadMessage(f)
+		if err != nil {
+			t.Fatalf("parse %s: %v", file, err)
+		}
+		subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+		if err != nil {
+			t.Fatalf("decode subject of %s: %v", file, err)
+		}
+		_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatalf("parse content type of %s: %v", file, err)
+		}
+		part, err := multipart.NewReader(msg.Body, params["boundary"]).NextPart()
+		if err != nil {
+			t.Fatalf("read text part of %s: %v", file, err)
+		}
+		text, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("read text part of %s: %v", file, err)
+		}
+		f.Close()
+		to, err := mail.ParseAddress(msg.Header.Get("To"))
+		if err != nil {
+			t.Fatalf("parse recipient of %s: %v", file, err)
+		}
+		emails = append(emails, sentEmail{To: to.Address, Subject: subject, Text: string(text)})
+	}
+	slices.SortFunc(emails, func(a, b sentEmail) int { return strings.Compare(a.To, b.To) })
+	return emails
+}
+
+// newTestEmailService returns an EmailService whose emails are written to a
+// directory, and the queue to stop before reading them
+func newTestEmailService(t *testing.T, users *fakeUserRepo, repos *fakeRepoRepo) (*EmailService, *mailqueue.Queue, string) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := &config.EmailConfig{
+		Enabled:             true,
+		Transport:           config.EmailTransportDirectory,
+		Directory:           filepath.Join(dir, "sent"),
+		From:                "Stasis <noreply@example.com>",
+		QueueSize:           10,
+		MaxAttempts:         1,
+		RetryBackoffSeconds: 1,
+		DeadLetterPath:      filepath.Join(dir, "dead-letters.jsonl"),
+	}
+	queue, err := mailqueue.NewQueue(cfg)
+	if err != nil {
+		t.Fatalf("NewQueue: %v", err)
+	}
+	queue.Start()
+	t.Cleanup(queue.Stop)
+
+	s := NewEmailService(queue, fakePreferenceRepo{}, users, repos, newTestRepoService(users, repos), &fakeBus{}, cfg)
+	return s, queue, cfg.Directory
+}
+
+func TestEmailCIJobFailed(t *testing.T) {
+	owner := &models.User{ID: uuid.New(), Username: "owner", Email: "owner@example.com"}
+	alice := &models.User{ID: uuid.New(), Username: "alice", Email: "alice@example.com"}
+	repo := &models.Repository{ID: uuid.New(), Name: "app", OwnerID: owner.ID, Owner: *owner, DefaultBranch: "main"}
+
+	failed := func(ref, actor, jobName, stage string) events.CIJobStatus {
+		return events.CIJobStatus{
+			JobID:        uuid.MustParse("0f1e2d3c-0000-4000-8000-000000000000"),
+			Status:       models.CIJobStatusFailed,
+			Error:        "exit status 1",
+			RepositoryID: repo.ID,
+			Stage:        stage,
+			JobName:      jobName,
+			CommitSHA:    "0123456789abcdef0123456789abcdef01234567",
+			RefName:      ref,
+			RefType:      string(models.CIRefTypeBranch),
+			TriggeredBy:  actor,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		event       events.CIJobStatus
+		wantTo      []string
+		wantSubject string
+		wantText    []string
+	}{
+		{
+			name:        "job outside staged runs",
+			event:       failed("main", "alice", "", ""),
+			wantTo:      []string{"alice@example.com", "owner@example.com"},
+			wantSubject: "[owner/app] CI job 0f1e2d3c failed on main",
+			wantText: []string{
+				"The CI job 0f1e2d3c failed on main, the default branch of owner/app.",
+				"Commit: 0123456789abcdef0123456789abcdef01234567",
+				"Error: exit status 1",
+				"https://git.example.com/owner/app/ci/0f1e2d3c-0000-4000-8000-000000000000",
+			},
+		},
+		{
+			name:        "job of a staged run",
+			event:       failed("main", "owner", "unit", "test"),
+			wantTo:      []string{"owner@example.com"},
+			wantSubject: "[owner/app] CI job unit failed on main",
+			wantText:    []string{"The CI job unit (test) failed on main"},
+		},
+		{name: "other branch", event: failed("feature", "alice", "", "")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUserRepo{users: []*models.User{owner, alice}}
+			repos := &fakeRepoRepo{repos: []*models.Repository{repo}}
+			s, queue, dir := newTestEmailService(t, users, repos)
+
+			if err := s.emailCIJobFailed(context.Background(), envelope(tt.event)); err != nil {
+				t.Fatalf("emailCIJobFailed: %v", err)
+			}
+			queue.Stop()
+
+			emails := readEmails(t, dir)
+			var to []string
+			for _, email := range emails {
+				to = append(to, email.To)
+			}
+			if !slices.Equal(to, tt.wantTo) {
+				t.Fatalf("emailed %v, want %v", to, tt.wantTo)
+			}
+			for _, email := range emails {
+				if email.Subject != tt.wantSubject {
+					t.Errorf("subject = %q, want %q", email.Subject, tt.wantSubject)
+				}
+				for _, want := range tt.wantText {
+					if !strings.Contains(email.Text, want) {
+						t.Errorf("text of email to %s does not contain %q:\n%s", email.To, want, email.Text)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,477 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// The fakes below keep their records in memory. They embed the interface
+// they fake, so calling a method a test did not expect panics.
+
+// fakeUserRepo finds the users it was given and keeps the users created and
+// the updates. Delete removes the user and its repositories from repos, or
+// fails with deleteErr.
+type fakeUserRepo struct {
+	repository.UserRepository
+	users     []*models.User
+	updated   []models.User
+	repos     *fakeRepoRepo
+	deleteErr error
+}
+
+func (f *fakeUserRepo) FindByID(_ context.Context, id uuid.UUID) (*models.User, error) {
+	for _, user := range f.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, apperrors.NotFound("user", apperrors.ErrNotFound)
+}
+
+func (f *fakeUserRepo) FindByUsername(_ context.Context, username string) (*models.User, error) {
+	for _, user := range f.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return nil, apperrors.NotFound("user", apperrors.ErrNotFound)
+}
+
+func (f *fakeUserRepo) FindByEmail(_ context.Context, email string) (*models.User, error) {
+	for _, user := range f.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, apperrors.NotFound("user", apperrors.ErrNotFound)
+}
+
+func (f *fakeUserRepo) FindByOIDCSubject(_ context.Context, subject, issuer string) (*models.User, error) {
+	for _, user := range f.users {
+		if user.OIDCSubject == subject && user.OIDCIssuer == issuer {
+			return user, nil
+		}
+	}
+	return nil, apperrors.NotFound("user", apperrors.ErrNotFound)
+}
+
+func (f *fakeUserRepo) ExistsByUsername(ctx context.Context, username string) (bool, error) {
+	_, err := f.FindByUsername(ctx, username)
+	return err == nil, nil
+}
+
+func (f *fakeUserRepo) Create(_ context.Context, user *models.User) error {
+	user.ID = uuid.New()
+	f.users = append(f.users, user)
+	return nil
+}
+
+// Update keeps the user passed, which the service changed in place
+func (f *fakeUserRepo) Update(_ context.Context, user *models.User) error {
+	f.updated = append(f.updated, *user)
+	return nil
+}
+
+func (f *fakeUserRepo) Delete(_ context.Context, id uuid.UUID, repoIDs []uuid.UUID) error {
+	if f.deleteErr != nil {
+		return f.deleteErr
+	}
+	f.users = slices.DeleteFunc(f.users, func(user *models.User) bool { return user.ID == id })
+	if f.repos != nil {
+		f.repos.repos = slices.DeleteFunc(f.repos.repos, func(repo *models.Repository) bool {
+			return slices.Contains(repoIDs, repo.ID)
+		})
+	}
+	return nil
+}
+
+// fakeRepoRepo finds the repositories it was given
+type fakeRepoRepo struct {
+	repository.RepoRepository
+	repos []*models.Repository
+}
+
+func (f *fakeRepoRepo) FindByID(_ context.Context, id uuid.UUID) (*models.Repository, error) {
+	for _, repo := range f.repos {
+		if repo.ID == id {
+			return repo, nil
+		}
+	}
+	return nil, apperrors.NotFound("repository", apperrors.ErrNotFound)
+}
+
+func (f *fakeRepoRepo) FindByOwner(_ context.Context, ownerID uuid.UUID) ([]*models.Repository, error) {
+	var repos []*models.Repository
+	for _, repo := range f.repos {
+		if repo.OwnerID == ownerID {
+			repos = append(repos, repo)
+		}
+	}
+	return repos, nil
+}
+
+func (f *fakeRepoRepo) ListAll(_ context.Context, limit, offset int) ([]*models.Repository, error) {
+	if offset >= len(f.repos) {
+		return nil, nil
+	}
+	return f.repos[offset:min(offset+limit, len(f.repos))], nil
+}
+
+func (f *fakeRepoRepo) ExistsByOwnerAndName(_ context.Context, ownerID uuid.UUID, name string) (bool, error) {
+	for _, repo := range f.repos {
+		if repo.OwnerID == ownerID && repo.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeRepoRepo) Rename(context.Context, *models.Repository, *models.RepoRedirect) error {
+	return nil
+}
+
+func (f *fakeRepoRepo) UpdateGC(context.Context, uuid.UUID, time.Time, time.Duration) error {
+	return nil
+}
+
+// fakeCollaboratorRepo has no collaborators
+type fakeCollaboratorRepo struct {
+	repository.CollaboratorRepository
+}
+
+func (fakeCollaboratorRepo) FindByRepositoryAndUser(context.Context, uuid.UUID, uuid.UUID) (*models.RepositoryCollaborator, error) {
+	return nil, apperrors.NotFound("collaborator", apperrors.ErrNotFound)
+}
+
+// fakeNotificationRepo keeps the notifications created
+type fakeNotificationRepo struct {
+	repository.NotificationRepository
+	created []*models.Notification
+}
+
+func (f *fakeNotificationRepo) CreateBatch(_ context.Context, notifications []*models.Notification) error {
+	f.created = append(f.created, notifications...)
+	return nil
+}
+
+// fakePreferenceRepo has no stored preferences, so every email is allowed
+type fakePreferenceRepo struct {
+	repository.NotificationPreferenceRepository
+}
+
+func (fakePreferenceRepo) FindByUser(context.Context, uuid.UUID) (*models.NotificationPreference, error) {
+	return nil, apperrors.NotFound("notification preference", apperrors.ErrNotFound)
+}
+
+// fakeSessionRepo keeps the sessions created
+type fakeSessionRepo struct {
+	repository.SessionRepository
+	created []*models.Session
+}
+
+func (f *fakeSessionRepo) Create(_ context.Context, session *models.Session) error {
+	session.ID = uuid.New()
+	f.created = append(f.created, session)
+	return nil
+}
+
+// fakeCIJobTokenRepo finds the job tokens it was given
+type fakeCIJobTokenRepo struct {
+	repository.CIJobTokenRepository
+	mu     sync.Mutex
+	tokens []*models.CIJobToken
+}
+
+func (f *fakeCIJobTokenRepo) AdvanceStatus(_ context.Context, jobID uuid.UUID, status string) (*models.CIJobToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, token := range f.tokens {
+		if token.JobID != jobID {
+			continue
+		}
+		if token.Status == status || slices.Contains(models.CIJobFinishedStatuses, token.Status) {
+			return nil, nil
+		}
+		token.Status = status
+		updated := *token
+		return &updated, nil
+	}
+	return nil, apperrors.NotFound("ci job token", apperrors.ErrNotFound)
+}
+
+func (f *fakeCIJobTokenRepo) FindByJobID(_ context.Context, jobID uuid.UUID) (*models.CIJobToken, error) {
+	for _, token := range f.tokens {
+		if token.JobID == jobID {
+			return token, nil
+		}
+	}
+	return nil, apperrors.NotFound("ci job token", apperrors.ErrNotFound)
+}
+
+func (f *fakeCIJobTokenRepo) FindByHashedCallbackToken(_ context.Context, hashed string) (*models.CIJobToken, error) {
+	for _, token := range f.tokens {
+		if token.CallbackToken == hashed {
+			return token, nil
+		}
+	}
+	return nil, apperrors.NotFound("ci job token", apperrors.ErrNotFound)
+}
+
+func (f *fakeCIJobTokenRepo) Revoke(context.Context, uuid.UUID, time.Time) (bool, error) {
+	return false, nil
+}
+
+// fakeCIArtifactRepo holds the artifacts it was given. Artifacts are orphaned
+// when tokens has no token for their job.
+type fakeCIArtifactRepo struct {
+	repository.CIArtifactRepository
+	tokens    *fakeCIJobTokenRepo
+	artifacts []*models.CIArtifact
+}
+
+func (f *fakeCIArtifactRepo) ListCreatedBefore(_ context.Context, before time.Time, limit int) ([]*models.CIArtifact, error) {
+	var artifacts []*models.CIArtifact
+	for _, artifact := range f.artifacts {
+		if artifact.CreatedAt.Before(before) && len(artifacts) < limit {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	return artifacts, nil
+}
+
+func (f *fakeCIArtifactRepo) ListOrphaned(ctx context.Context, limit int) ([]*models.CIArtifact, error) {
+	var artifacts []*models.CIArtifact
+	for _, artifact := range f.artifacts {
+		if _, err := f.tokens.FindByJobID(ctx, artifact.JobID); err != nil && len(artifacts) < limit {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	return artifacts, nil
+}
+
+func (f *fakeCIArtifactRepo) Save(_ context.Context, artifact *models.CIArtifact) error {
+	f.artifacts = append(f.artifacts, artifact)
+	return nil
+}
+
+func (f *fakeCIArtifactRepo) FindByJobAndName(_ context.Context, jobID uuid.UUID, name string) (*models.CIArtifact, error) {
+	for _, artifact := range f.artifacts {
+		if artifact.JobID == jobID && artifact.Name == name {
+			return artifact, nil
+		}
+	}
+	return nil, apperrors.NotFound("artifact", apperrors.ErrNotFound)
+}
+
+func (f *fakeCIArtifactRepo) Delete(_ context.Context, id uuid.UUID) error {
+	f.artifacts = slices.DeleteFunc(f.artifacts, func(artifact *models.CIArtifact) bool { return artifact.ID == id })
+	return nil
+}
+
+// fakeWebhookRepo finds the webhooks it was given and keeps the deliveries
+// created
+type fakeWebhookRepo struct {
+	repository.WebhookRepository
+	mu         sync.Mutex
+	hooks      []*models.Webhook
+	deliveries map[uuid.UUID]*models.WebhookDelivery
+}
+
+func (f *fakeWebhookRepo) ListByRepository(_ context.Context, repoID uuid.UUID) ([]*models.Webhook, error) {
+	var hooks []*models.Webhook
+	for _, hook := range f.hooks {
+		if hook.RepositoryID == repoID {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, nil
+}
+
+func (f *fakeWebhookRepo) CreateDelivery(_ context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.deliveries == nil {
+		f.deliveries = make(map[uuid.UUID]*models.WebhookDelivery)
+	}
+	if _, ok := f.deliveries[delivery.ID]; ok {
+		return false, nil
+	}
+	stored := *delivery
+	stored.CreatedAt = time.Now()
+	f.deliveries[delivery.ID] = &stored
+	return true, nil
+}
+
+func (f *fakeWebhookRepo) FindDelivery(_ context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored, ok := f.deliveries[id]
+	if !ok {
+		return nil, apperrors.NotFound("webhook delivery", apperrors.ErrNotFound)
+	}
+	delivery := *stored
+	for _, hook := range f.hooks {
+		if hook.ID == delivery.WebhookID {
+			delivery.Webhook = *hook
+		}
+	}
+	return &delivery, nil
+}
+
+func (f *fakeWebhookRepo) UpdateDelivery(_ context.Context, delivery *models.WebhookDelivery) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored := *delivery
+	stored.Webhook = models.Webhook{}
+	f.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+// Deliveries returns the deliveries created for a webhook
+func (f *fakeWebhookRepo) Deliveries(hookID uuid.UUID) []*models.WebhookDelivery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var deliveries []*models.WebhookDelivery
+	for _, delivery := range f.deliveries {
+		if delivery.WebhookID == hookID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries
+}
+
+// fakeBus keeps the events published and registers no subscribers
+type fakeBus struct {
+	mu        sync.Mutex
+	published []events.Event
+}
+
+func (b *fakeBus) Publish(event events.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.published = append(b.published, event)
+}
+
+func (b *fakeBus) Subscribe(string, events.Handler, events.SubscribeOptions) func() {
+	return func() {}
+}
+
+// Events returns the events published so far
+func (b *fakeBus) Events() []events.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]events.Event(nil), b.published...)
+}
+
+// fakeStorageBackends holds the backends it was given, by name
+type fakeStorageBackends struct {
+	service.StorageBackends
+	backends map[string]service.StorageService
+}
+
+func (f fakeStorageBackends) Backend(name string) (service.StorageService, bool) {
+	backend, ok := f.backends[name]
+	return backend, ok
+}
+
+// fakeStorage keeps repositories under /repos and records the moves and
+// deletions. Deleting a missing or undeletable file fails.
+type fakeStorage struct {
+	service.StorageService
+	mu          sync.Mutex
+	created     []string
+	moves       [][2]string
+	deleted     []string
+	missing     map[string]bool
+	undeletable map[string]bool
+}
+
+func (f *fakeStorage) GetRepoPath(owner, repoName string) string {
+	return "/repos/" + owner + "/" + repoName + ".git"
+}
+
+// CreateFile records the file and discards what is written to it
+func (f *fakeStorage) CreateFile(path string) (io.WriteCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.created = append(f.created, path)
+	return nopWriteCloser{io.Discard}, nil
+}
+
+func (f *fakeStorage) MoveFile(src, dst string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.moves = append(f.moves, [2]string{src, dst})
+	return nil
+}
+
+func (f *fakeStorage) DeleteFile(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case f.missing[path]:
+		return fs.ErrNotExist
+	case f.undeletable[path]:
+		return errors.New("permission denied")
+	}
+	f.deleted = append(f.deleted, path)
+	return nil
+}
+
+func (f *fakeStorage) DeleteDirectory(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, path)
+	return nil
+}
+
+// Moves returns the moves made so far
+func (f *fakeStorage) Moves() [][2]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][2]string(nil), f.moves...)
+}
+
+// fakeAudit keeps the audit entries recorded
+type fakeAudit struct {
+	mu      sync.Mutex
+	entries []service.AuditEntry
+}
+
+func (f *fakeAudit) Record(entry service.AuditEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entries = append(f.entries, entry)
+}
+
+// newTestRepoService returns a RepoService checking permissions against
+// users and repos, without collaborators
+func newTestRepoService(users *fakeUserRepo, repos *fakeRepoRepo) *RepoService {
+	return NewRepoService(repos, users, fakeCollaboratorRepo{}, nil, nil, &config.ReposConfig{WebURL: "https://git.example.com"}, &fakeBus{})
+}
+
+// envelope wraps an event as the bus delivers it
+func envelope(event events.Event) events.Envelope {
+	return events.Envelope{ID: uuid.New(), Type: event.EventType(), OccurredAt: time.Now(), Event: event}
+}
+
+// nopWriteCloser adds a no-op Close to an io.Writer
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,377 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yuin/goldmark"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// Feed kinds
+const (
+	FeedReleases = "releases" // Tags of the repository, newest first
+	FeedActivity = "activity" // Audit events of the repository, newest first
+)
+
+const (
+	// feedEntries is the number of entries of a feed
+	feedEntries = 50
+
+	// feedCacheTTL is how long a built feed is served before it is rebuilt
+	feedCacheTTL = time.Minute
+)
+
+// Feed is a feed of a repository, rendered as Atom or JSON Feed by the
+// transport layer
+type Feed struct {
+	ID      string // Stable URN of the feed
+	Kind    string // FeedReleases or FeedActivity
+	Title   string
+	Owner   string // Username of the repository owner, the author of the feed
+	Updated time.Time
+	Entries []FeedEntry
+}
+
+// FeedEntry is a single entry of a feed
+type FeedEntry struct {
+	ID          string // Stable URN of the entry
+	Title       string
+	Author      string // Empty if unknown
+	Ref         string // Tag name of a release, empty for activity
+	Published   time.Time
+	Updated     time.Time
+	ContentHTML string // Escaped HTML
+}
+
+// FeedService builds the release and activity feeds of repositories and
+// manages the feed tokens that let feed readers fetch private feeds. Built
+// feeds are cached briefly; callers check access before serving them.
+type FeedService struct {
+	gitService     service.GitService
+	auditEventRepo repository.AuditEventRepository
+	userRepo       repository.UserRepository
+	markdown       goldmark.Markdown
+
+	mu    sync.Mutex
+	cache map[string]cachedFeed
+}
+
+// cachedFeed is a built feed and when it must be rebuilt
+type cachedFeed struct {
+	feed      *Feed
+	expiresAt time.Time
+}
+
+// NewFeedService creates a new FeedService instance
+func NewFeedService(
+	gitService service.GitService,
+	auditEventRepo repository.AuditEventRepository,
+	userRepo repository.UserRepository,
+) *FeedService {
+	return &FeedService{
+		gitService:     gitService,
+		auditEventRepo: auditEventRepo,
+		userRepo:       userRepo,
+		markdown:       goldmark.New(), // Raw HTML in messages is omitted
+		cache:          make(map[string]cachedFeed),
+	}
+}
+
+// GetFeed returns a feed of a repository, built at most feedCacheTTL ago
+func (s *FeedService) GetFeed(ctx context.Context, repo *models.Repository, kind string) (*Feed, error) {
+	key := repo.ID.String() + ":" + kind
+	now := time.Now()
+
+	s.mu.Lock()
+	cached, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.feed, nil
+	}
+
+	var feed *Feed
+	var err error
+	switch kind {
+	case FeedReleases:
+		feed, err = s.releaseFeed(ctx, repo)
+	case FeedActivity:
+		feed, err = s.activityFeed(ctx, repo)
+	default:
+		return nil, apperrors.BadRequest(fmt.Sprintf("unknown feed %q", kind), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	for k, c := range s.cache {
+		if now.After(c.expiresAt) {
+			delete(s.cache, k)
+		}
+	}
+	s.cache[key] = cachedFeed{feed: feed, expiresAt: now.Add(feedCacheTTL)}
+	s.mu.Unlock()
+	return feed, nil
+}
+
+// releaseFeed builds the feed of the newest tags of a repository. Tag
+// messages are rendered as markdown.
+func (s *FeedService) releaseFeed(ctx context.Context, repo *models.Repository) (*Feed, error) {
+	tags, err := s.gitService.ListTags(ctx, repo.GitPath)
+	if err != nil {
+		return nil, apperrors.GitError("list tags", err)
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].Date.After(tags[j].Date)
+	})
+	if len(tags) > feedEntries {
+		tags = tags[:feedEntries]
+	}
+
+	feed := newFeed(repo, FeedReleases, "Releases")
+	for _, tag := range tags {
+		content := tag.Message
+		if strings.TrimSpace(content) == "" {
+			content = "Tagged commit `" + tag.Hash + "`."
+		}
+		var rendered bytes.Buffer
+		if err := s.markdown.Convert([]byte(content), &rendered); err != nil {
+			return nil, fmt.Errorf("failed to render tag %s: %w", tag.Name, err)
+		}
+
+		feed.Entries = append(feed.Entries, FeedEntry{
+			ID:          feedURN(repo, "release:"+tag.Name),
+			Title:       tag.Name,
+			Author:      taggerName(tag.Tagger),
+			Ref:         tag.Name,
+			Published:   tag.Date,
+			Updated:     tag.Date,
+			ContentHTML: rendered.String(),
+		})
+	}
+	feed.setUpdated(repo)
+	return feed, nil
+}
+
+// activityFeed builds the feed of the newest audit events of a repository.
+// Client IP addresses are never included.
+func (s *FeedService) activityFeed(ctx context.Context, repo *models.Repository) (*Feed, error) {
+	auditEvents, _, err := s.auditEventRepo.List(ctx, repository.AuditEventFilter{RepositoryID: &repo.ID}, feedEntries, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := newFeed(repo, FeedActivity, "Activity")
+	for _, event := range auditEvents {
+		title := event.Actor + " " + describeAuditEvent(event)
+		feed.Entries = append(feed.Entries, FeedEntry{
+			ID:          "urn:uuid:" + event.ID.String(),
+			Title:       title,
+			Author:      event.Actor,
+			Published:   event.CreatedAt,
+			Updated:     event.CreatedAt,
+			ContentHTML: auditEventHTML(event, title),
+		})
+	}
+	feed.setUpdated(repo)
+	return feed, nil
+}
+
+// newFeed creates an empty feed of a repository
+func newFeed(repo *models.Repository, kind, title string) *Feed {
+	return &Feed{
+		ID:    feedURN(repo, "feed:"+kind),
+		Kind:  kind,
+		Title: fmt.Sprintf("%s/%s %s", repo.Owner.Username, repo.Name, title),
+		Owner: repo.Owner.Username,
+	}
+}
+
+// setUpdated sets when a feed last changed: its newest entry, or the
+// repository itself while it has none
+func (f *Feed) setUpdated(repo *models.Repository) {
+	if len(f.Entries) == 0 {
+		f.Updated = repo.UpdatedAt
+		return
+	}
+	f.Updated = f.Entries[0].Updated
+	for _, entry := range f.Entries[1:] {
+		if entry.Updated.After(f.Updated) {
+			f.Updated = entry.Updated
+		}
+	}
+}
+
+// feedURN returns a URN derived from the repository ID, so it survives
+// renames and transfers of the repository
+func feedURN(repo *models.Repository, name string) string {
+	return "urn:uuid:" + uuid.NewSHA1(repo.ID, []byte(name)).String()
+}
+
+// taggerName returns the name of a "Name <email>" tagger
+func taggerName(tagger string) string {
+	name, _, _ := strings.Cut(tagger, " <")
+	return strings.TrimSpace(name)
+}
+
+// describeAuditEvent describes what the actor of an audit event did
+func describeAuditEvent(event *models.AuditEvent) string {
+	str := func(key string) string {
+		value, _ := event.Metadata[key].(string)
+		return value
+	}
+
+	switch event.Action {
+	case models.AuditActionRepoCreate:
+		return "created the repository"
+	case models.AuditActionRepoImport:
+		return "imported the repository"
+	case models.AuditActionRepoVisibility:
+		if private, _ := event.Metadata["is_private"].(bool); private {
+			return "made the repository private"
+		}
+		return "made the repository public"
+	case models.AuditActionRepoFork:
+		return "forked the repository from " + str("source")
+	case models.AuditActionRepoTransfer:
+		return "transferred the repository to " + str("new_owner")
+	case models.AuditActionRepoRename:
+		return "renamed the repository from " + str("old_name")
+	case models.AuditActionRepoDelete:
+		return "deleted the repository"
+	case models.AuditActionBranchCreate:
+		return "created branch " + str("branch")
+	case models.AuditActionBranchDelete:
+		return "deleted branch " + str("branch")
+	case models.AuditActionTagCreate:
+		return "created tag " + str("tag")
+	case models.AuditActionTagDelete:
+		return "deleted tag " + str("tag")
+	case models.AuditActionCollaboratorAdd:
+		return "added collaborator " + str("collaborator")
+	case models.AuditActionCollaboratorRemove:
+		return "removed collaborator " + str("collaborator")
+	case models.AuditActionGitPush:
+		return "pushed to the repository"
+	}
+	return event.Action
+}
+
+// auditEventHTML renders the content of an activity entry: the updated refs
+// of a push, the title otherwise
+func auditEventHTML(event *models.AuditEvent, title string) string {
+	refs, _ := event.Metadata["refs"].([]any)
+	if event.Action != models.AuditActionGitPush || len(refs) == 0 {
+		return "<p>" + html.EscapeString(title) + "</p>"
+	}
+
+	var b strings.Builder
+	b.WriteString("<ul>")
+	for _, r := range refs {
+		ref, _ := r.(map[string]any)
+		name, _ := ref["ref_name"].(string)
+		oldHash, _ := ref["old_hash"].(string)
+		newHash, _ := ref["new_hash"].(string)
+		fmt.Fprintf(&b, "<li><code>%s</code> %s..%s</li>",
+			html.EscapeString(name), html.EscapeString(shortHash(oldHash)), html.EscapeString(shortHash(newHash)))
+	}
+	b.WriteString("</ul>")
+	return b.String()
+}
+
+// shortHash abbreviates a commit hash
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// FeedToken returns the feed token of a user
+func (s *FeedService) FeedToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if user.FeedKey == "" {
+		return "", apperrors.NotFound("feed token", apperrors.ErrNotFound)
+	}
+	return signFeedToken(user), nil
+}
+
+// CreateFeedToken gives a user a new feed token, revoking the previous one
+func (s *FeedService) CreateFeedToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate feed key: %w", err)
+	}
+	user.FeedKey = hex.EncodeToString(key)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return "", err
+	}
+	return signFeedToken(user), nil
+}
+
+// RevokeFeedToken revokes the feed token of a user
+func (s *FeedService) RevokeFeedToken(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.FeedKey == "" {
+		return apperrors.NotFound("feed token", apperrors.ErrNotFound)
+	}
+	user.FeedKey = ""
+	return s.userRepo.Update(ctx, user)
+}
+
+// AuthenticateFeedToken returns the user of a feed token. The token only
+// grants reading feeds.
+func (s *FeedService) AuthenticateFeedToken(ctx context.Context, token string) (*models.User, error) {
+	id, _, ok := strings.Cut(token, ".")
+	userID, err := uuid.Parse(id)
+	if !ok || err != nil {
+		return nil, apperrors.Unauthorized("Invalid feed token", nil)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil, apperrors.Unauthorized("Invalid feed token", nil)
+		}
+		return nil, err
+	}
+	if user.FeedKey == "" || !hmac.Equal([]byte(token), []byte(signFeedToken(user))) {
+		return nil, apperrors.Unauthorized("Invalid feed token", nil)
+	}
+	return user, nil
+}
+
+// signFeedToken returns the feed token of a user: their ID signed with their
+// feed key
+func signFeedToken(user *models.User) string {
+	mac := hmac.New(sha256.New, []byte(user.FeedKey))
+	mac.Write([]byte("feed:" + user.ID.String()))
+	return user.ID.String() + "." + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// gcScanBatch is the number of repositories loaded at a time while looking
+// for repositories to collect
+const gcScanBatch = 100
+
+// GC strategies, see config.ReposConfig.GCStrategy
+const (
+	GCStrategyAuto   = "auto"
+	GCStrategyRepack = "repack"
+)
+
+// GCResult is the outcome of a garbage collection of a repository
+type GCResult struct {
+	Strategy string
+	Duration time.Duration
+	Before   *service.ObjectCounts
+	After    *service.ObjectCounts
+}
+
+// GarbageCollectionService packs the loose objects and merges the packs that
+// accumulate in repositories with every push, which slow clones down. The
+// git.gc housekeeping task collects the repositories above the configured
+// thresholds; administrators can collect one on demand. Repositories with a
+// push in flight in this process are never collected. While a repository is
+// collected, fetches are served the ref advertisement taken before it started.
+type GarbageCollectionService struct {
+	repoRepo   repository.RepoRepository
+	storage    *StorageBackendService
+	gitService service.GitService
+	advertiser service.RefAdvertiser
+	audit      service.AuditRecorder
+	cfg        *config.ReposConfig
+	log        *logger.Logger
+
+	mu      sync.Mutex
+	running map[uuid.UUID]bool // Repositories being collected
+}
+
+// NewGarbageCollectionService creates a new GarbageCollectionService instance
+func NewGarbageCollectionService(
+	repoRepo repository.RepoRepository,
+	storage *StorageBackendService,
+	gitService service.GitService,
+	advertiser service.RefAdvertiser,
+	audit service.AuditRecorder,
+	cfg *config.ReposConfig,
+) *GarbageCollectionService {
+	return &GarbageCollectionService{
+		repoRepo:   repoRepo,
+		storage:    storage,
+		gitService: gitService,
+		advertiser: advertiser,
+		audit:      audit,
+		cfg:        cfg,
+		log:        logger.Get().WithFields(logger.Component("gc-service")),
+		running:    make(map[uuid.UUID]bool),
+	}
+}
+
+// CollectRepository collects a repository now, whatever its object counts,
+// with the configured strategy. It fails with a conflict while a push into
+// the repository or another collection of it is in flight. actor is the
+// administrator asking for it.
+func (s *GarbageCollectionService) CollectRepository(ctx context.Context, repo *models.Repository, actor *models.User) (*GCResult, error) {
+	result, err := s.collect(ctx, repo, false)
+	s.record(repo, actor, result, err)
+	return result, err
+}
+
+// NeedsCollection returns true if a repository has more loose objects or
+// packs than the configured thresholds
+func (s *GarbageCollectionService) NeedsCollection(counts *service.ObjectCounts) bool {
+	return counts.LooseObjects > int64(s.cfg.GCLooseObjects) || counts.Packs > int64(s.cfg.GCPacks)
+}
+
+// collect runs a garbage collection of a repository and records it. With
+// auto set, git gc --auto decides again whether the repository needs it.
+func (s *GarbageCollectionService) collect(ctx context.Context, repo *models.Repository, auto bool) (*GCResult, error) {
+	s.mu.Lock()
+	if s.running[repo.ID] {
+		s.mu.Unlock()
+		return nil, apperrors.Conflict("the repository is being collected already", apperrors.ErrStorageError)
+	}
+	s.running[repo.ID] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, repo.ID)
+		s.mu.Unlock()
+	}()
+
+	// Registered as a git operation, so migrations wait for it. Pushes are
+	// counted once registered, so checking after registering catches every
+	// push that started first; the objects of a push starting later survive
+	// the collection, which keeps unreachable objects.
+	release, err := s.storage.AcquireRepository(repo)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	// Pinned before checking for pushes, so a push starting after the check
+	// drops the advertisement rather than being hidden by it
+	setAdvertisement, unpin := s.storage.PinAdvertisement(repo.ID)
+	defer unpin()
+	if s.storage.PushesInFlight(repo.ID) > 0 {
+		return nil, apperrors.Conflict("a push into the repository is in progress, try again shortly", apperrors.ErrStorageError)
+	}
+	if advertisement, err := s.advertiser.AdvertiseRefs(ctx, repo.GitPath, repo.UploadPack); err == nil {
+		setAdvertisement(advertisement)
+	} else {
+		s.log.WithContext(ctx).Warn("Failed to pin the ref advertisement, fetches will read it during the collection",
+			logger.Error(err),
+			logger.String("repository", repo.GetFullName()),
+		)
+	}
+
+	result := &GCResult{Strategy: s.cfg.GCStrategy}
+	if result.Before, err = s.gitService.CountObjects(ctx, repo.GitPath); err != nil {
+		return nil, apperrors.StorageError("count objects", err)
+	}
+
+	startedAt := time.Now()
+	if s.cfg.GCStrategy == GCStrategyRepack {
+		err = s.gitService.Repack(ctx, repo.GitPath)
+	} else {
+		err = s.gitService.GarbageCollect(ctx, repo.GitPath, service.GCOptions{
+			Auto:             auto,
+			LooseObjectLimit: s.cfg.GCLooseObjects,
+			PackLimit:        s.cfg.GCPacks,
+		})
+	}
+	result.Duration = time.Since(startedAt)
+	if err != nil {
+		return result, apperrors.StorageError("collect garbage", err)
+	}
+
+	if result.After, err = s.gitService.CountObjects(ctx, repo.GitPath); err != nil {
+		return result, apperrors.StorageError("count objects", err)
+	}
+
+	if err := s.repoRepo.UpdateGC(ctx, repo.ID, time.Now(), result.Duration); err != nil {
+		s.log.WithContext(ctx).Warn("Failed to record garbage collection",
+			logger.Error(err),
+			logger.String("repository", repo.GetFullName()),
+		)
+	}
+
+	// Packing changes the disk usage quotas are checked against
+	if backend, err := s.storage.ForRepo(repo); err == nil {
+		if err := backend.SyncToRemote(repo.GitPath); err != nil {
+			s.log.WithContext(ctx).Warn("Failed to sync collected repository to remote storage",
+				logger.Error(err),
+				logger.String("repository", repo.GetFullName()),
+			)
+		}
+		if size, err := backend.GetDiskUsage(repo.GitPath); err == nil {
+			if err := s.repoRepo.UpdateSize(ctx, repo.ID, size); err != nil {
+				s.log.WithContext(ctx).Warn("Failed to record repository size after garbage collection",
+					logger.Error(err),
+					logger.String("repository", repo.GetFullName()),
+				)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Task returns the housekeeping task collecting the repositories above the
+// thresholds, or nil if repositories are not collected on a schedule
+func (s *GarbageCollectionService) Task() *HousekeepingTask {
+	if s.cfg.GCInterval() == 0 {
+		return nil
+	}
+	return &HousekeepingTask{
+		Name:        "git.gc",
+		Description: "Collect the repositories with more loose objects or packs than repos.gc_loose_objects and repos.gc_packs",
+		Interval:    s.cfg.GCInterval(),
+		Jitter:      s.cfg.GCInterval() / 10,
+		Run:         s.run,
+	}
+}
+
+// run collects every repository above the thresholds. Repositories with a
+// push in flight are left for the next run; a repository that cannot be
+// collected does not stop the others.
+func (s *GarbageCollectionService) run(ctx context.Context) error {
+	var scanned, collected, busy, failed int
+	for offset := 0; ; offset += gcScanBatch {
+		repos, err := s.repoRepo.ListAll(ctx, gcScanBatch, offset)
+		if err != nil {
+			return err
+		}
+
+		for _, repo := range repos {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			scanned++
+
+			counts, err := s.gitService.CountObjects(ctx, repo.GitPath)
+			if err != nil {
+				failed++
+				s.log.WithContext(ctx).Warn("Failed to count objects of repository",
+					logger.Error(err),
+					logger.String("repository", repo.GetFullName()),
+				)
+				continue
+			}
+			if !s.NeedsCollection(counts) {
+				continue
+			}
+
+			result, err := s.collect(ctx, repo, true)
+			switch {
+			case apperrors.IsConflict(err):
+				busy++
+			case err != nil:
+				failed++
+				s.log.WithContext(ctx).Warn("Failed to collect repository",
+					logger.Error(err),
+					logger.String("repository", repo.GetFullName()),
+				)
+			default:
+				collected++
+				s.log.WithContext(ctx).Info("Collected repository",
+					logger.String("repository", repo.GetFullName()),
+					logger.Duration("duration", result.Duration),
+					logger.Int64("loose_objects", result.After.LooseObjects),
+					logger.Int64("packs", result.After.Packs),
+				)
+			}
+		}
+
+		if len(repos) < gcScanBatch {
+			break
+		}
+	}
+
+	if collected > 0 || failed > 0 {
+		s.log.WithContext(ctx).Info("Collected repositories",
+			logger.Int("scanned", scanned),
+			logger.Int("collected", collected),
+			logger.Int("busy", busy),
+			logger.Int("failed", failed),
+		)
+	}
+	return nil
+}
+
+// record audits a garbage collection asked for by an administrator
+func (s *GarbageCollectionService) record(repo *models.Repository, actor *models.User, result *GCResult, err error) {
+	entry := service.AuditEntry{
+		Category: "admin",
+		Action:   "repository.gc",
+		Resource: repo.GetFullName(),
+		Outcome:  "success",
+		Fields: map[string]string{
+			"strategy": s.cfg.GCStrategy,
+		},
+	}
+	if err != nil {
+		entry.Outcome = "failure"
+	}
+	if result != nil {
+		entry.Fields["duration_ms"] = strconv.FormatInt(result.Duration.Milliseconds(), 10)
+	}
+	if actor != nil {
+		entry.ActorID = actor.ID.String()
+		entry.Actor = actor.Username
+	}
+	s.audit.Record(entry)
+}
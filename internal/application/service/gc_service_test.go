@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// fakeGCGit counts the repositories repacked, running onRepack during each
+type fakeGCGit struct {
+	service.GitService
+	repacked int
+	onRepack func()
+}
+
+func (f *fakeGCGit) CountObjects(context.Context, string) (*service.ObjectCounts, error) {
+	return &service.ObjectCounts{}, nil
+}
+
+func (f *fakeGCGit) Repack(context.Context, string) error {
+	f.repacked++
+	if f.onRepack != nil {
+		f.onRepack()
+	}
+	return nil
+}
+
+// fakeAdvertiser advertises the refs of every repository as advertisement
+type fakeAdvertiser struct {
+	advertisement []byte
+}
+
+func (f fakeAdvertiser) AdvertiseRefs(context.Context, string, models.UploadPackSettings) ([]byte, error) {
+	return f.advertisement, nil
+}
+
+// newTestGCService returns a GarbageCollectionService repacking repo
+func newTestGCService(repo *models.Repository, git *fakeGCGit) *GarbageCollectionService {
+	repos := &fakeRepoRepo{repos: []*models.Repository{repo}}
+	storage := NewStorageBackendService(fakeStorageBackends{}, repos, git, &fakeAudit{})
+	advertiser := fakeAdvertiser{advertisement: []byte("0000")}
+	return NewGarbageCollectionService(repos, storage, git, advertiser, &fakeAudit{}, &config.ReposConfig{GCStrategy: GCStrategyRepack})
+}
+
+func TestCollectRepositoryWhileBusy(t *testing.T) {
+	tests := []struct {
+		name string
+		// busy starts what is in flight and returns the function ending it
+		busy         func(t *testing.T, s *StorageBackendService, repo *models.Repository) func()
+		wantConflict bool
+	}{
+		{
+			name:         "idle",
+			busy:         func(*testing.T, *StorageBackendService, *models.Repository) func() { return func() {} },
+			wantConflict: false,
+		},
+		{
+			name: "push in flight",
+			busy: func(t *testing.T, s *StorageBackendService, repo *models.Repository) func() {
+				release, err := s.AcquirePush(repo)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return release
+			},
+			wantConflict: true,
+		},
+		{
+			name: "fetch in flight",
+			busy: func(t *testing.T, s *StorageBackendService, repo *models.Repository) func() {
+				release, err := s.AcquireRepository(repo)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return release
+			},
+			wantConflict: false,
+		},
+		{
+			name: "migration in flight",
+			busy: func(t *testing.T, s *StorageBackendService, repo *models.Repository) func() {
+				unlock, err := s.lockForMigration(context.Background(), repo.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return unlock
+			},
+			wantConflict: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &models.Repository{ID: uuid.New(), Name: "app"}
+			git := &fakeGCGit{}
+			s := newTestGCService(repo, git)
+			done := tt.busy(t, s.storage, repo)
+			inFlight := s.storage.OperationsInFlight(repo.ID)
+
+			_, err := s.CollectRepository(context.Background(), repo, nil)
+			if tt.wantConflict {
+				if !apperrors.IsConflict(err) {
+					t.Fatalf("CollectRepository = %v, want a conflict", err)
+				}
+				if git.repacked != 0 {
+					t.Errorf("repacked a busy repository")
+				}
+			} else if err != nil {
+				t.Fatalf("CollectRepository: %v", err)
+			}
+			if got := s.storage.OperationsInFlight(repo.ID); got != inFlight {
+				t.Errorf("%d operations in flight after the collection, want %d", got, inFlight)
+			}
+
+			done()
+			if _, err := s.CollectRepository(context.Background(), repo, nil); err != nil {
+				t.Fatalf("CollectRepository once idle: %v", err)
+			}
+		})
+	}
+}
+
+func TestCollectRepositoryHoldsRepository(t *testing.T) {
+	repo := &models.Repository{ID: uuid.New(), Name: "app"}
+	git := &fakeGCGit{}
+	s := newTestGCService(repo, git)
+
+	// The collection is registered before it checks for pushes, so a push
+	// starting during it is either refused by the check or counted alongside
+	// it; migrations wait for both
+	var inFlight, pushes int
+	git.onRepack = func() {
+		release, err := s.storage.AcquirePush(repo)
+		if err != nil {
+			t.Fatalf("AcquirePush during a collection: %v", err)
+		}
+		defer release()
+		inFlight = s.storage.OperationsInFlight(repo.ID)
+		pushes = s.storage.PushesInFlight(repo.ID)
+
+		if _, err := s.CollectRepository(context.Background(), repo, nil); !apperrors.IsConflict(err) {
+			t.Errorf("concurrent CollectRepository = %v, want a conflict", err)
+		}
+	}
+
+	if _, err := s.CollectRepository(context.Background(), repo, nil); err != nil {
+		t.Fatalf("CollectRepository: %v", err)
+	}
+	if inFlight != 2 || pushes != 1 {
+		t.Errorf("%d operations and %d pushes in flight during the collection, want 2 and 1", inFlight, pushes)
+	}
+	if got := s.storage.OperationsInFlight(repo.ID); got != 0 {
+		t.Errorf("%d operations in flight after the collection, want 0", got)
+	}
+}
+
+func TestCollectRepositoryPinsAdvertisement(t *testing.T) {
+	repo := &models.Repository{ID: uuid.New(), Name: "app"}
+	git := &fakeGCGit{}
+	s := newTestGCService(repo, git)
+
+	var pinned, afterPush []byte
+	git.onRepack = func() {
+		pinned = s.storage.PinnedAdvertisement(repo.ID)
+		// A push changes the refs, so the advertisement is dropped
+		release, err := s.storage.AcquirePush(repo)
+		if err != nil {
+			t.Fatalf("AcquirePush during a collection: %v", err)
+		}
+		release()
+		afterPush = s.storage.PinnedAdvertisement(repo.ID)
+	}
+
+	if _, err := s.CollectRepository(context.Background(), repo, nil); err != nil {
+		t.Fatalf("CollectRepository: %v", err)
+	}
+	if string(pinned) != "0000" {
+		t.Errorf("advertisement pinned during the collection = %q, want %q", pinned, "0000")
+	}
+	if afterPush != nil {
+		t.Errorf("advertisement pinned after a push = %q, want none", afterPush)
+	}
+	if got := s.storage.PinnedAdvertisement(repo.ID); got != nil {
+		t.Errorf("advertisement pinned after the collection = %q, want none", got)
+	}
+
+	// A push dropping the advertisement before it is taken keeps it dropped
+	git.onRepack = nil
+	set, unpin := s.storage.PinAdvertisement(repo.ID)
+	release, err := s.storage.AcquirePush(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	set([]byte("0000"))
+	if got := s.storage.PinnedAdvertisement(repo.ID); got != nil {
+		t.Errorf("advertisement pinned after a push = %q, want none", got)
+	}
+	unpin()
+}
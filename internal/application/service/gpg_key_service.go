@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// GPGKeyService handles GPG key operations
+type GPGKeyService struct {
+	gpgKeyRepo repository.GPGKeyRepository
+}
+
+// NewGPGKeyService creates a new GPGKeyService instance
+func NewGPGKeyService(gpgKeyRepo repository.GPGKeyRepository) *GPGKeyService {
+	return &GPGKeyService{
+		gpgKeyRepo: gpgKeyRepo,
+	}
+}
+
+// AddGPGKey adds an ASCII-armored OpenPGP public key for a user. Commits are
+// verified with the key when their author email is both an email of the key
+// and the email of the user.
+func (s *GPGKeyService) AddGPGKey(ctx context.Context, userID uuid.UUID, armored string) (*models.GPGKey, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil || len(entities) != 1 {
+		return nil, apperrors.BadRequest("gpg key must be a single ASCII-armored OpenPGP public key", apperrors.ErrInvalidGPGKey)
+	}
+	entity := entities[0]
+	if entity.PrivateKey != nil {
+		return nil, apperrors.BadRequest("gpg key must be a public key, not a private key", apperrors.ErrInvalidGPGKey)
+	}
+
+	var emails []string
+	for _, identity := range entity.Identities {
+		email := strings.ToLower(strings.TrimSpace(identity.UserId.Email))
+		if email != "" && !slices.Contains(emails, email) {
+			emails = append(emails, email)
+		}
+	}
+	if len(emails) == 0 {
+		return nil, apperrors.BadRequest("gpg key has no user ID with an email address", apperrors.ErrInvalidGPGKey)
+	}
+	slices.Sort(emails)
+
+	subkeyIDs := make([]string, len(entity.Subkeys))
+	for i, subkey := range entity.Subkeys {
+		subkeyIDs[i] = subkey.PublicKey.KeyIdString()
+	}
+
+	key := &models.GPGKey{
+		UserID:      userID,
+		KeyID:       entity.PrimaryKey.KeyIdString(),
+		Fingerprint: fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint),
+		SubkeyIDs:   subkeyIDs,
+		Emails:      emails,
+		PublicKey:   strings.TrimSpace(armored),
+		ExpiresAt:   gpgKeyExpiry(entity),
+	}
+	if err := s.gpgKeyRepo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ListGPGKeys returns all GPG keys for a user
+func (s *GPGKeyService) ListGPGKeys(ctx context.Context, userID uuid.UUID) ([]*models.GPGKey, error) {
+	return s.gpgKeyRepo.FindByUserID(ctx, userID)
+}
+
+// GetGPGKey returns a specific GPG key by ID
+func (s *GPGKeyService) GetGPGKey(ctx context.Context, keyID uuid.UUID) (*models.GPGKey, error) {
+	return s.gpgKeyRepo.FindByID(ctx, keyID)
+}
+
+// DeleteGPGKey removes a GPG key of a user
+func (s *GPGKeyService) DeleteGPGKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	key, err := s.gpgKeyRepo.FindByID(ctx, keyID)
+	if err != nil {
+		return err
+	}
+	if key.UserID != userID {
+		return apperrors.NotFound("gpg key", apperrors.ErrNotFound)
+	}
+	return s.gpgKeyRepo.Delete(ctx, keyID)
+}
+
+// DeleteGPGKeyAdmin removes a GPG key without ownership check (for admins)
+func (s *GPGKeyService) DeleteGPGKeyAdmin(ctx context.Context, keyID uuid.UUID) error {
+	return s.gpgKeyRepo.Delete(ctx, keyID)
+}
+
+// gpgKeyExpiry returns when the primary key of an entity expires, or nil if
+// it does not
+func gpgKeyExpiry(entity *openpgp.Entity) *time.Time {
+	sig, _ := entity.PrimarySelfSignature()
+	if sig == nil || sig.KeyLifetimeSecs == nil || *sig.KeyLifetimeSecs == 0 {
+		return nil
+	}
+	expiresAt := entity.PrimaryKey.CreationTime.Add(time.Duration(*sig.KeyLifetimeSecs) * time.Second)
+	return &expiresAt
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// highlighterVersion identifies the highlighted HTML format. Bump it whenever the
+// chroma version or formatter options change so cached output is not reused.
+const highlighterVersion = "chroma-2.20.0/1"
+
+// HighlightLinePrefix is the prefix of line anchors in highlighted HTML (#L42)
+const HighlightLinePrefix = "L"
+
+// Reasons for skipping highlighting
+const (
+	HighlightSkippedBinary   = "binary"
+	HighlightSkippedTooLarge = "too_large"
+	HighlightSkippedError    = "error"
+)
+
+// modelineLines is the number of lines at the start and end of a file searched for modelines
+const modelineLines = 5
+
+var (
+	// vimModeline matches e.g. "vim: set ft=python:" or "vi: filetype=sh"
+	vimModeline = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex)(?:[<=>]?\d+)?:.*?\b(?:ft|filetype|syntax)=([\w+#-]+)`)
+
+	// emacsModeline matches e.g. "-*- mode: python; coding: utf-8 -*-" or "-*- ruby -*-"
+	emacsModeline = regexp.MustCompile(`-\*-\s*(?:mode:\s*)?([\w+#-]+)\s*(?:;[^\n]*)?-\*-`)
+)
+
+// HighlightService renders file content as syntax-highlighted HTML.
+// Output uses CSS classes only, so any stylesheet from WriteStylesheet applies,
+// and is cached per blob since blobs are immutable.
+type HighlightService struct {
+	config    *config.HighlightConfig
+	formatter *html.Formatter
+	cache     *highlightCache
+	log       *logger.Logger
+}
+
+// NewHighlightService creates a new HighlightService instance
+func NewHighlightService(cfg *config.HighlightConfig) *HighlightService {
+	return &HighlightService{
+		config: cfg,
+		formatter: html.New(
+			html.WithClasses(true),
+			html.WithAllClasses(true), // Output does not depend on the stylesheet chosen
+			html.WithLineNumbers(true),
+			html.LineNumbersInTable(true),
+			html.WithLinkableLineNumbers(true, HighlightLinePrefix),
+		),
+		cache: newHighlightCache(cfg.GetCacheEntries()),
+		log:   logger.Get().WithFields(logger.Component("highlight-service")),
+	}
+}
+
+// Highlight renders a file as highlighted HTML. Binary files and files above the
+// size cap are not highlighted; the result then only reports why.
+// Token text is HTML-escaped by the formatter, so the HTML is safe to inject.
+func (s *HighlightService) Highlight(file *service.FileContent) *dto.HighlightResponse {
+	if file.IsBinary {
+		return &dto.HighlightResponse{Skipped: true, SkippedReason: HighlightSkippedBinary}
+	}
+
+	lexer := detectLexer(file.Name, file.Content)
+	language := lexer.Config().Name
+
+	if file.Truncated || int64(len(file.Content)) > s.config.GetMaxSizeBytes() {
+		return &dto.HighlightResponse{Language: language, Skipped: true, SkippedReason: HighlightSkippedTooLarge}
+	}
+
+	key := highlighterVersion + "\x00" + language + "\x00" + file.Hash
+	if cached, ok := s.cache.Get(key); ok {
+		return &dto.HighlightResponse{Language: language, HTML: cached}
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, string(file.Content))
+	if err != nil {
+		s.log.Warn("Failed to tokenise file for highlighting",
+			logger.Error(err),
+			logger.Path(file.Path),
+			logger.String("language", language),
+		)
+		return &dto.HighlightResponse{Language: language, Skipped: true, SkippedReason: HighlightSkippedError}
+	}
+
+	var buf bytes.Buffer
+	if err := s.formatter.Format(&buf, styles.Fallback, iterator); err != nil {
+		s.log.Warn("Failed to format highlighted file",
+			logger.Error(err),
+			logger.Path(file.Path),
+		)
+		return &dto.HighlightResponse{Language: language, Skipped: true, SkippedReason: HighlightSkippedError}
+	}
+
+	rendered := buf.String()
+	s.cache.Put(key, rendered)
+
+	return &dto.HighlightResponse{Language: language, HTML: rendered}
+}
+
+// WriteStylesheet writes the CSS for highlighted HTML in the named chroma style
+func (s *HighlightService) WriteStylesheet(w io.Writer, styleName string) error {
+	style, ok := styles.Registry[strings.ToLower(styleName)]
+	if !ok {
+		return apperrors.NotFound(fmt.Sprintf("highlight style %q", styleName), apperrors.ErrNotFound)
+	}
+	return s.formatter.WriteCSS(w, style)
+}
+
+// detectLexer picks a lexer by file name, falling back to vim or emacs
+// modelines, and finally to plain text
+func detectLexer(name string, content []byte) chroma.Lexer {
+	if lexer := lexers.Match(name); lexer != nil {
+		return lexer
+	}
+	if mode := findModeline(content); mode != "" {
+		if lexer := lexers.Get(mode); lexer != nil {
+			return lexer
+		}
+	}
+	return lexers.Fallback
+}
+
+// findModeline returns the file type declared by a modeline near the start or end of the content
+func findModeline(content []byte) string {
+	lines := strings.Split(string(content), "\n")
+
+	candidates := lines
+	if len(lines) > 2*modelineLines {
+		candidates = append(lines[:modelineLines:modelineLines], lines[len(lines)-modelineLines:]...)
+	}
+
+	for _, line := range candidates {
+		if m := vimModeline.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+		if m := emacsModeline.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// highlightCache is a fixed-size LRU cache of highlighted HTML
+type highlightCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+// highlightCacheEntry is a single cached rendering
+type highlightCacheEntry struct {
+	key  string
+	html string
+}
+
+// newHighlightCache creates an LRU cache holding up to capacity entries
+func newHighlightCache(capacity int) *highlightCache {
+	return &highlightCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns a cached rendering and marks it as recently used
+func (c *highlightCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*highlightCacheEntry).html, true
+}
+
+// Put stores a rendering, evicting the least recently used entry when full
+func (c *highlightCache) Put(key, html string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&highlightCacheEntry{key: key, html: html})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*highlightCacheEntry).key)
+	}
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// housekeepingTickInterval is the time between checks for due housekeeping
+// tasks, which bounds how late a task or a manual trigger runs
+const housekeepingTickInterval = 30 * time.Second
+
+// HousekeepingTask is a periodic maintenance job, such as deleting expired
+// rows. Across replicas it runs at most once per interval, on any of them.
+type HousekeepingTask struct {
+	Name        string
+	Description string
+	Interval    time.Duration
+	Jitter      time.Duration // Up to this much is added to each interval at random, so tasks spread out
+	Run         func(ctx context.Context) error
+}
+
+// HousekeepingMetrics counts the runs of a task by this replica since it started
+type HousekeepingMetrics struct {
+	Runs          uint64
+	Failures      uint64
+	Skipped       uint64 // Due runs left to another replica holding the task
+	TotalDuration time.Duration
+}
+
+// HousekeepingStatus is a task with its shared schedule and last run, and
+// the metrics of this replica
+type HousekeepingStatus struct {
+	Task    *HousekeepingTask
+	State   *models.HousekeepingTask // nil until the scheduler has stored the task
+	Metrics HousekeepingMetrics
+}
+
+// housekeepingClock is the time of the scheduler, which tests fast-forward
+type housekeepingClock interface {
+	Now() time.Time
+	// NewTicker returns a channel delivering the time every d, and a
+	// function stopping it
+	NewTicker(d time.Duration) (<-chan time.Time, func())
+}
+
+// systemClock is the wall clock
+type systemClock struct{}
+
+// Now implements housekeepingClock
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTicker implements housekeepingClock
+func (systemClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(d)
+	return ticker.C, ticker.Stop
+}
+
+// housekeepingEntry is a registered task and its metrics
+type housekeepingEntry struct {
+	task    *HousekeepingTask
+	mu      sync.Mutex
+	metrics HousekeepingMetrics
+}
+
+// HousekeepingService runs registered housekeeping tasks when due. The next
+// run and the outcome of the last one are stored, so schedules survive
+// restarts and are shared by all replicas, and each run holds a database lock
+// on its task so only one replica runs it. Due tasks run one after another.
+type HousekeepingService struct {
+	taskRepo repository.HousekeepingTaskRepository
+	entries  map[string]*housekeepingEntry
+	names    []string // Registration order
+	mu       sync.RWMutex
+	wake     chan struct{}
+	clock    housekeepingClock
+	host     string
+	log      *logger.Logger
+}
+
+// NewHousekeepingService creates a new HousekeepingService without tasks
+func NewHousekeepingService(taskRepo repository.HousekeepingTaskRepository) *HousekeepingService {
+	host, _ := os.Hostname()
+	return &HousekeepingService{
+		taskRepo: taskRepo,
+		entries:  make(map[string]*housekeepingEntry),
+		wake:     make(chan struct{}, 1),
+		clock:    systemClock{},
+		host:     host,
+		log:      logger.Get().WithFields(logger.Component("housekeeping")),
+	}
+}
+
+// Register adds a task to the scheduler. Its first run is due at once,
+// unless the task was already stored by an earlier process.
+func (s *HousekeepingService) Register(task *HousekeepingTask) {
+	if task.Name == "" || task.Interval <= 0 || task.Run == nil {
+		panic(fmt.Sprintf("housekeeping: invalid task %q", task.Name))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[task.Name]; ok {
+		panic(fmt.Sprintf("housekeeping: task %q registered twice", task.Name))
+	}
+	s.entries[task.Name] = &housekeepingEntry{task: task}
+	s.names = append(s.names, task.Name)
+}
+
+// ListTasks returns the registered tasks, in registration order
+func (s *HousekeepingService) ListTasks(ctx context.Context) ([]HousekeepingStatus, error) {
+	states, err := s.taskRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]HousekeepingStatus, 0, len(s.names))
+	for _, name := range s.names {
+		entry := s.entries[name]
+		status := HousekeepingStatus{Task: entry.task}
+		if i := slices.IndexFunc(states, func(t *models.HousekeepingTask) bool { return t.Name == name }); i >= 0 {
+			status.State = states[i]
+		}
+		entry.mu.Lock()
+		status.Metrics = entry.metrics
+		entry.mu.Unlock()
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Trigger makes a task due now. This replica runs it right away; another
+// replica may run it instead if it checks first.
+func (s *HousekeepingService) Trigger(ctx context.Context, name string) error {
+	s.mu.RLock()
+	_, ok := s.entries[name]
+	s.mu.RUnlock()
+	if !ok {
+		return apperrors.NotFound("housekeeping task", apperrors.ErrNotFound)
+	}
+
+	if err := s.taskRepo.Reschedule(ctx, name, s.clock.Now()); err != nil {
+		return err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// TriggerGroup makes the tasks of a group due now, such as the ci.* tasks
+// for group ci, and returns their names
+func (s *HousekeepingService) TriggerGroup(ctx context.Context, group string) ([]string, error) {
+	s.mu.RLock()
+	var names []string
+	for _, name := range s.names {
+		if strings.HasPrefix(name, group+".") {
+			names = append(names, name)
+		}
+	}
+	s.mu.RUnlock()
+	if len(names) == 0 {
+		return nil, apperrors.NotFound("housekeeping task", apperrors.ErrNotFound)
+	}
+
+	for _, name := range names {
+		if err := s.taskRepo.Reschedule(ctx, name, s.clock.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return names, nil
+}
+
+// RunScheduler runs the registered tasks when due until ctx is done
+func (s *HousekeepingService) RunScheduler(ctx context.Context) {
+	s.mu.RLock()
+	names := slices.Clone(s.names)
+	s.mu.RUnlock()
+
+	s.log.WithContext(ctx).Info("Housekeeping scheduler started", logger.Strings("tasks", names))
+
+	ticks, stop := s.clock.NewTicker(housekeepingTickInterval)
+	defer stop()
+
+	now := s.clock.Now()
+	for {
+		s.runDue(ctx, now)
+
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticks:
+		case <-s.wake:
+			now = s.clock.Now()
+		}
+	}
+}
+
+// runDue runs the tasks due at now. The scheduler passes the current time;
+// passing a later one runs the tasks as if that time had come.
+func (s *HousekeepingService) runDue(ctx context.Context, now time.Time) {
+	states, err := s.taskRepo.List(ctx)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to list housekeeping tasks", logger.Error(err))
+		return
+	}
+
+	s.mu.RLock()
+	names := slices.Clone(s.names)
+	s.mu.RUnlock()
+
+	for _, name := range names {
+		i := slices.IndexFunc(states, func(t *models.HousekeepingTask) bool { return t.Name == name })
+		if i < 0 {
+			// First run of the task on any replica
+			if err := s.taskRepo.Register(ctx, name, now); err != nil {
+				s.log.WithContext(ctx).Warn("Failed to register housekeeping task",
+					logger.Error(err),
+					logger.String("task", name),
+				)
+				continue
+			}
+		} else if now.Before(states[i].NextRunAt) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.mu.RLock()
+		entry := s.entries[name]
+		s.mu.RUnlock()
+		s.runTask(ctx, entry, now)
+	}
+}
+
+// runTask runs a due task if no other replica is running it, then stores the
+// outcome and the next run
+func (s *HousekeepingService) runTask(ctx context.Context, entry *housekeepingEntry, now time.Time) {
+	task := entry.task
+
+	acquired, err := s.taskRepo.WithLock(ctx, task.Name, func(ctx context.Context) error {
+		// Another replica may have run the task since it was listed
+		state, err := s.taskRepo.FindByName(ctx, task.Name)
+		if err != nil {
+			return err
+		}
+		if now.Before(state.NextRunAt) {
+			return nil
+		}
+
+		started := s.clock.Now()
+		runErr := s.call(ctx, task)
+		duration := s.clock.Now().Sub(started)
+
+		state.NextRunAt = now.Add(task.Interval + jitter(task.Jitter))
+		state.LastStartedAt = &now
+		state.LastDurationMs = duration.Milliseconds()
+		state.LastRunBy = s.host
+		state.RunCount++
+		state.LastOutcome = models.HousekeepingSucceeded
+		state.LastError = ""
+		if runErr != nil {
+			state.LastOutcome = models.HousekeepingFailed
+			state.LastError = runErr.Error()
+			state.FailureCount++
+		}
+
+		entry.mu.Lock()
+		entry.metrics.Runs++
+		entry.metrics.TotalDuration += duration
+		if runErr != nil {
+			entry.metrics.Failures++
+		}
+		entry.mu.Unlock()
+
+		if runErr != nil {
+			s.log.WithContext(ctx).Warn("Housekeeping task failed",
+				logger.Error(runErr),
+				logger.String("task", task.Name),
+				logger.Duration("duration", duration),
+			)
+		} else {
+			s.log.WithContext(ctx).Debug("Housekeeping task completed",
+				logger.String("task", task.Name),
+				logger.Duration("duration", duration),
+			)
+		}
+
+		return s.taskRepo.Update(ctx, state)
+	})
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to run housekeeping task",
+			logger.Error(err),
+			logger.String("task", task.Name),
+		)
+		return
+	}
+	if !acquired {
+		entry.mu.Lock()
+		entry.metrics.Skipped++
+		entry.mu.Unlock()
+	}
+}
+
+// call runs a task bounded by its interval, turning a panic into an error so
+// one broken task does not stop the scheduler
+func (s *HousekeepingService) call(ctx context.Context, task *HousekeepingTask) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, task.Interval)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return task.Run(ctx)
+}
+
+// jitter returns a random duration below limit, or zero
+func jitter(limit time.Duration) time.Duration {
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit)
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// fakeHousekeepingRepo stores tasks in memory
+type fakeHousekeepingRepo struct {
+	mu     sync.Mutex
+	tasks  map[string]*models.HousekeepingTask
+	locked map[string]bool
+}
+
+func newFakeHousekeepingRepo() *fakeHousekeepingRepo {
+	return &fakeHousekeepingRepo{tasks: make(map[string]*models.HousekeepingTask), locked: make(map[string]bool)}
+}
+
+func (f *fakeHousekeepingRepo) Register(_ context.Context, name string, nextRunAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.tasks[name]; !ok {
+		f.tasks[name] = &models.HousekeepingTask{Name: name, NextRunAt: nextRunAt}
+	}
+	return nil
+}
+
+func (f *fakeHousekeepingRepo) FindByName(_ context.Context, name string) (*models.HousekeepingTask, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	task, ok := f.tasks[name]
+	if !ok {
+		return nil, apperrors.NotFound("housekeeping task", apperrors.ErrNotFound)
+	}
+	copy := *task
+	return &copy, nil
+}
+
+func (f *fakeHousekeepingRepo) List(context.Context) ([]*models.HousekeepingTask, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tasks := make([]*models.HousekeepingTask, 0, len(f.tasks))
+	for _, task := range f.tasks {
+		copy := *task
+		tasks = append(tasks, &copy)
+	}
+	return tasks, nil
+}
+
+func (f *fakeHousekeepingRepo) Update(_ context.Context, task *models.HousekeepingTask) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	copy := *task
+	f.tasks[task.Name] = &copy
+	return nil
+}
+
+func (f *fakeHousekeepingRepo) Reschedule(_ context.Context, name string, nextRunAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	task, ok := f.tasks[name]
+	if !ok {
+		return apperrors.NotFound("housekeeping task", apperrors.ErrNotFound)
+	}
+	task.NextRunAt = nextRunAt
+	return nil
+}
+
+func (f *fakeHousekeepingRepo) WithLock(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error) {
+	f.mu.Lock()
+	if f.locked[name] {
+		f.mu.Unlock()
+		return false, nil
+	}
+	f.locked[name] = true
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		delete(f.locked, name)
+		f.mu.Unlock()
+	}()
+	return true, fn(ctx)
+}
+
+// fakeClock is a housekeepingClock whose time only moves with Advance
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	ticks chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), ticks: make(chan time.Time)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTicker(time.Duration) (<-chan time.Time, func()) {
+	return c.ticks, func() {}
+}
+
+// Advance moves the time forward by d in ticks of the scheduler and returns
+// once the scheduler is done with the last one
+func (c *fakeClock) Advance(d time.Duration) {
+	for end := c.Now().Add(d); c.Now().Before(end); {
+		// The time only moves while the scheduler waits: a tick at the
+		// current time is taken once it is done with the previous one, and
+		// finds nothing due
+		c.ticks <- c.Now()
+		c.mu.Lock()
+		c.now = c.now.Add(housekeepingTickInterval)
+		now := c.now
+		c.mu.Unlock()
+		c.ticks <- now
+	}
+	c.ticks <- c.Now()
+}
+
+// runLog records when a task ran, by the clock of the scheduler
+type runLog struct {
+	mu   sync.Mutex
+	runs []time.Time
+}
+
+func (l *runLog) task(clock *fakeClock, err error) func(context.Context) error {
+	return func(context.Context) error {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.runs = append(l.runs, clock.Now())
+		return err
+	}
+}
+
+func (l *runLog) times() []time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]time.Time(nil), l.runs...)
+}
+
+// startScheduler runs the scheduler of s until the test ends
+func startScheduler(t *testing.T, s *HousekeepingService) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s.RunScheduler(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+}
+
+func TestHousekeepingSchedulerFastForward(t *testing.T) {
+	clock := newFakeClock()
+	repo := newFakeHousekeepingRepo()
+	s := NewHousekeepingService(repo)
+	s.clock = clock
+	start := clock.Now()
+
+	var hourly, daily, failing runLog
+	s.Register(&HousekeepingTask{Name: "test.hourly", Interval: time.Hour, Run: hourly.task(clock, nil)})
+	s.Register(&HousekeepingTask{Name: "test.daily", Interval: 24 * time.Hour, Jitter: time.Hour, Run: daily.task(clock, nil)})
+	s.Register(&HousekeepingTask{Name: "test.failing", Interval: 6 * time.Hour, Run: failing.task(clock, errors.New("broken"))})
+	startScheduler(t, s)
+
+	clock.Advance(48 * time.Hour)
+
+	// Every task runs at once, then once per interval
+	runs := hourly.times()
+	if len(runs) != 49 {
+		t.Errorf("hourly task ran %d times in 48 hours, want 49", len(runs))
+	}
+	for i, run := range runs {
+		if want := start.Add(time.Duration(i) * time.Hour); !run.Equal(want) {
+			t.Errorf("hourly run %d at %v, want %v", i, run, want)
+			break
+		}
+	}
+
+	// Jitter delays a run by less than the jitter, and the next interval
+	// counts from the delayed run
+	runs = daily.times()
+	if len(runs) < 2 || len(runs) > 3 {
+		t.Errorf("daily task ran %d times in 48 hours, want 2 or 3", len(runs))
+	}
+	for i := 1; i < len(runs); i++ {
+		if gap := runs[i].Sub(runs[i-1]); gap < 24*time.Hour || gap > 25*time.Hour+housekeepingTickInterval {
+			t.Errorf("daily runs %d and %d are %v apart, want 24h to 25h", i-1, i, gap)
+		}
+	}
+
+	// Failing runs are rescheduled like the others
+	if runs := failing.times(); len(runs) != 9 {
+		t.Errorf("failing task ran %d times in 48 hours, want 9", len(runs))
+	}
+	statuses, err := s.ListTasks(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.Task.Name != "test.failing" {
+			continue
+		}
+		if status.Metrics.Runs != 9 || status.Metrics.Failures != 9 {
+			t.Errorf("failing task metrics = %+v, want 9 runs and 9 failures", status.Metrics)
+		}
+		if status.State.FailureCount != 9 || status.State.LastOutcome != models.HousekeepingFailed || status.State.LastError != "broken" {
+			t.Errorf("failing task state = %+v, want 9 failures, the last with \"broken\"", status.State)
+		}
+		if want := start.Add(54 * time.Hour); !status.State.NextRunAt.Equal(want) {
+			t.Errorf("failing task next due at %v, want %v", status.State.NextRunAt, want)
+		}
+	}
+}
+
+func TestHousekeepingSchedulerTrigger(t *testing.T) {
+	clock := newFakeClock()
+	repo := newFakeHousekeepingRepo()
+	s := NewHousekeepingService(repo)
+	s.clock = clock
+
+	var weekly runLog
+	s.Register(&HousekeepingTask{Name: "test.weekly", Interval: 7 * 24 * time.Hour, Run: weekly.task(clock, nil)})
+	startScheduler(t, s)
+
+	clock.Advance(time.Hour)
+	if runs := weekly.times(); len(runs) != 1 {
+		t.Fatalf("weekly task ran %d times in its first hour, want 1", len(runs))
+	}
+
+	// A triggered task runs at the next check, and its next run counts from then
+	if err := s.Trigger(context.Background(), "test.weekly"); err != nil {
+		t.Fatal(err)
+	}
+	triggeredAt := clock.Now()
+	clock.Advance(2 * housekeepingTickInterval)
+	runs := weekly.times()
+	if len(runs) != 2 || runs[1].After(triggeredAt.Add(housekeepingTickInterval)) {
+		t.Fatalf("weekly task runs %v after a trigger at %v, want a second run at once", runs, triggeredAt)
+	}
+	state, err := repo.FindByName(context.Background(), "test.weekly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := runs[1].Add(7 * 24 * time.Hour); !state.NextRunAt.Equal(want) {
+		t.Errorf("weekly task next due at %v after the trigger, want %v", state.NextRunAt, want)
+	}
+
+	if err := s.Trigger(context.Background(), "test.unknown"); !apperrors.IsNotFound(err) {
+		t.Errorf("Trigger of an unknown task = %v, want not found", err)
+	}
+}
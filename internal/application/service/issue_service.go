@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// maxIssueTitleLength is the longest issue title allowed
+	maxIssueTitleLength = 255
+
+	// maxIssueBodyLength is the longest issue or comment body allowed, in bytes
+	maxIssueBodyLength = 65536
+
+	// maxIssueLabels is the most labels an issue may carry
+	maxIssueLabels = 20
+
+	// maxIssueLabelLength is the longest label allowed
+	maxIssueLabelLength = 50
+)
+
+// IssueUpdate holds the changes to an issue; nil fields are left unchanged
+type IssueUpdate struct {
+	Title  *string
+	Body   *string
+	Labels *[]string
+	State  *string
+}
+
+// IssueService manages the issues of repositories and their comments.
+// Bodies are stored as raw Markdown; rendering them is left to clients.
+type IssueService struct {
+	issueRepo repository.IssueRepository
+	publisher events.Publisher
+	log       *logger.Logger
+}
+
+// NewIssueService creates a new IssueService instance publishing new issues
+// and comments on publisher
+func NewIssueService(issueRepo repository.IssueRepository, publisher events.Publisher) *IssueService {
+	return &IssueService{
+		issueRepo: issueRepo,
+		publisher: publisher,
+		log:       logger.Get().WithFields(logger.Component("issue-service")),
+	}
+}
+
+// CreateIssue validates and opens an issue of author
+func (s *IssueService) CreateIssue(ctx context.Context, repo *models.Repository, author *models.User, issue *models.Issue) error {
+	title, err := normalizeIssueTitle(issue.Title)
+	if err != nil {
+		return err
+	}
+	if err := validateIssueBody(issue.Body); err != nil {
+		return err
+	}
+	labels, err := NormalizeIssueLabels(issue.Labels)
+	if err != nil {
+		return err
+	}
+
+	issue.Title = title
+	issue.Labels = labels
+	issue.RepositoryID = repo.ID
+	issue.AuthorID = &author.ID
+	issue.State = models.IssueOpen
+	if err := s.issueRepo.Create(ctx, issue); err != nil {
+		s.log.WithContext(ctx).Error("Failed to create issue",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		return err
+	}
+	issue.Author = author
+
+	s.log.WithContext(ctx).Info("Issue opened",
+		logger.String("repo_id", repo.ID.String()),
+		logger.Int("number", issue.Number),
+	)
+	s.publisher.Publish(events.IssueOpened{
+		IssueID:      issue.ID,
+		RepositoryID: repo.ID,
+		Number:       issue.Number,
+		AuthorID:     author.ID,
+		Author:       author.Username,
+		Title:        issue.Title,
+		Body:         issue.Body,
+	})
+	return nil
+}
+
+// ListIssues returns a page of the issues of a repository matching filter,
+// newest first, with the total number matching
+func (s *IssueService) ListIssues(ctx context.Context, repo *models.Repository, filter repository.IssueFilter, limit, offset int) ([]*models.Issue, int64, error) {
+	return s.issueRepo.ListByRepository(ctx, repo.ID, filter, limit, offset)
+}
+
+// GetIssue returns an issue of a repository by number
+func (s *IssueService) GetIssue(ctx context.Context, repo *models.Repository, number int) (*models.Issue, error) {
+	return s.issueRepo.FindByNumber(ctx, repo.ID, number)
+}
+
+// UpdateIssue applies update to an issue. Changing the state to closed
+// records when it was closed; reopening clears it.
+func (s *IssueService) UpdateIssue(ctx context.Context, repo *models.Repository, issue *models.Issue, update IssueUpdate) error {
+	if update.Title != nil {
+		title, err := normalizeIssueTitle(*update.Title)
+		if err != nil {
+			return err
+		}
+		issue.Title = title
+	}
+	if update.Body != nil {
+		if err := validateIssueBody(*update.Body); err != nil {
+			return err
+		}
+		issue.Body = *update.Body
+	}
+	if update.Labels != nil {
+		labels, err := NormalizeIssueLabels(*update.Labels)
+		if err != nil {
+			return err
+		}
+		issue.Labels = labels
+	}
+	if update.State != nil && *update.State != issue.State {
+		switch *update.State {
+		case models.IssueOpen:
+			issue.ClosedAt = nil
+		case models.IssueClosed:
+			now := time.Now()
+			issue.ClosedAt = &now
+		default:
+			return apperrors.BadRequest("state must be open or closed", apperrors.ErrInvalidInput)
+		}
+		issue.State = *update.State
+	}
+
+	if err := s.issueRepo.Update(ctx, issue); err != nil {
+		return err
+	}
+
+	s.log.WithContext(ctx).Info("Issue updated",
+		logger.String("repo_id", repo.ID.String()),
+		logger.Int("number", issue.Number),
+		logger.String("state", issue.State),
+	)
+	return nil
+}
+
+// CloseIssue closes an open issue
+func (s *IssueService) CloseIssue(ctx context.Context, repo *models.Repository, issue *models.Issue) error {
+	if issue.State != models.IssueOpen {
+		return apperrors.Conflict("issue is already closed", apperrors.ErrIssueClosed)
+	}
+	state := models.IssueClosed
+	return s.UpdateIssue(ctx, repo, issue, IssueUpdate{State: &state})
+}
+
+// CreateComment validates and adds a comment of author to an issue
+func (s *IssueService) CreateComment(ctx context.Context, issue *models.Issue, author *models.User, body string) (*models.IssueComment, error) {
+	if strings.TrimSpace(body) == "" {
+		return nil, apperrors.BadRequest("comment body is required", apperrors.ErrInvalidInput)
+	}
+	if err := validateIssueBody(body); err != nil {
+		return nil, err
+	}
+
+	comment := &models.IssueComment{
+		IssueID:  issue.ID,
+		AuthorID: &author.ID,
+		Body:     body,
+	}
+	if err := s.issueRepo.CreateComment(ctx, comment); err != nil {
+		s.log.WithContext(ctx).Error("Failed to create issue comment",
+			logger.Error(err),
+			logger.String("issue_id", issue.ID.String()),
+		)
+		return nil, err
+	}
+	comment.Author = author
+
+	s.publisher.Publish(events.IssueCommented{
+		CommentID:    comment.ID,
+		IssueID:      issue.ID,
+		RepositoryID: issue.RepositoryID,
+		Number:       issue.Number,
+		AuthorID:     author.ID,
+		Author:       author.Username,
+		Body:         comment.Body,
+	})
+	return comment, nil
+}
+
+// ListComments returns a page of the comments on an issue, oldest first, with the total number
+func (s *IssueService) ListComments(ctx context.Context, issue *models.Issue, limit, offset int) ([]*models.IssueComment, int64, error) {
+	return s.issueRepo.ListComments(ctx, issue.ID, limit, offset)
+}
+
+// NormalizeIssueLabels trims labels and drops duplicates, keeping their order.
+// It returns a bad request error if a label is empty, too long or contains a
+// comma, which separates labels in list filters, or if there are too many.
+func NormalizeIssueLabels(labels []string) ([]string, error) {
+	normalized := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || len(label) > maxIssueLabelLength || strings.Contains(label, ",") {
+			return nil, apperrors.BadRequest(fmt.Sprintf("labels must be 1 to %d characters without commas", maxIssueLabelLength), apperrors.ErrInvalidInput)
+		}
+		if !slices.Contains(normalized, label) {
+			normalized = append(normalized, label)
+		}
+	}
+	if len(normalized) > maxIssueLabels {
+		return nil, apperrors.BadRequest(fmt.Sprintf("an issue can have at most %d labels", maxIssueLabels), apperrors.ErrInvalidInput)
+	}
+	return normalized, nil
+}
+
+// normalizeIssueTitle trims an issue title and checks its length
+func normalizeIssueTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" || len(title) > maxIssueTitleLength {
+		return "", apperrors.BadRequest(fmt.Sprintf("title must be 1 to %d characters", maxIssueTitleLength), apperrors.ErrInvalidInput)
+	}
+	return title, nil
+}
+
+// validateIssueBody checks the length of an issue or comment body
+func validateIssueBody(body string) error {
+	if len(body) > maxIssueBodyLength {
+		return apperrors.BadRequest(fmt.Sprintf("body must be at most %d bytes", maxIssueBodyLength), apperrors.ErrInvalidInput)
+	}
+	return nil
+}
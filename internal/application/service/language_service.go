@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// languageRefreshTimeout bounds computing the language breakdown of a
+// repository after a push
+const languageRefreshTimeout = 5 * time.Minute
+
+// LanguageService computes the language breakdown of repositories from the
+// names and sizes of their files. The breakdown of the default branch is
+// stored, so listings show the primary language of repositories without
+// reading their trees.
+type LanguageService struct {
+	languageRepo repository.RepoLanguageRepository
+	gitService   service.GitService
+	log          *logger.Logger
+}
+
+// NewLanguageService creates a new LanguageService instance
+func NewLanguageService(languageRepo repository.RepoLanguageRepository, gitService service.GitService) *LanguageService {
+	return &LanguageService{
+		languageRepo: languageRepo,
+		gitService:   gitService,
+		log:          logger.Get().WithFields(logger.Component("language-service")),
+	}
+}
+
+// GetLanguages returns the language breakdown of the tree at ref, the default
+// branch if empty. The breakdown of the default branch is stored when it
+// changed since last stored.
+func (s *LanguageService) GetLanguages(ctx context.Context, repo *models.Repository, ref string) (*service.LanguageBreakdown, error) {
+	breakdown, err := s.gitService.GetLanguageBytes(ctx, repo.GitPath, ref)
+	if err != nil {
+		// Nothing to break down before the first push
+		if empty, emptyErr := s.gitService.IsEmpty(ctx, repo.GitPath); ref == "" && emptyErr == nil && empty {
+			return &service.LanguageBreakdown{Bytes: map[string]int64{}}, nil
+		}
+		return nil, err
+	}
+
+	if ref == "" || ref == repo.DefaultBranch {
+		stored, err := s.languageRepo.ListByRepository(ctx, repo.ID)
+		if err == nil && (len(stored) == 0 || stored[0].CommitHash != breakdown.Tip) {
+			err = s.store(ctx, repo.ID, breakdown)
+		}
+		if err != nil {
+			s.log.WithContext(ctx).Warn("Failed to store repository languages",
+				logger.Error(err),
+				logger.String("repo_id", repo.ID.String()),
+			)
+		}
+	}
+
+	return breakdown, nil
+}
+
+// RefreshAfterPush recomputes and stores the language breakdown of a
+// repository in the background if a push updated its default branch
+func (s *LanguageService) RefreshAfterPush(repo *models.Repository, updates []service.RefUpdate) {
+	for _, update := range updates {
+		if update.RefName != "refs/heads/"+repo.DefaultBranch || update.IsDelete() {
+			continue
+		}
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), languageRefreshTimeout)
+			defer cancel()
+
+			breakdown, err := s.gitService.GetLanguageBytes(ctx, repo.GitPath, update.NewHash)
+			if err == nil {
+				err = s.store(ctx, repo.ID, breakdown)
+			}
+			if err != nil {
+				s.log.Warn("Failed to refresh repository languages after push",
+					logger.Error(err),
+					logger.String("repo_id", repo.ID.String()),
+				)
+			}
+		}()
+		return
+	}
+}
+
+// PrimaryLanguages returns the stored language with the most bytes of each
+// of repos. Repositories whose languages were never computed are left out.
+func (s *LanguageService) PrimaryLanguages(ctx context.Context, repos []*models.Repository) (map[uuid.UUID]string, error) {
+	ids := make([]uuid.UUID, len(repos))
+	for i, repo := range repos {
+		ids[i] = repo.ID
+	}
+	return s.languageRepo.PrimaryLanguages(ctx, ids)
+}
+
+// store replaces the stored language breakdown of a repository
+func (s *LanguageService) store(ctx context.Context, repoID uuid.UUID, breakdown *service.LanguageBreakdown) error {
+	languages := make([]models.RepoLanguage, 0, len(breakdown.Bytes))
+	for language, bytes := range breakdown.Bytes {
+		languages = append(languages, models.RepoLanguage{Language: language, Bytes: bytes})
+	}
+	return s.languageRepo.Replace(ctx, repoID, breakdown.Tip, languages)
+}
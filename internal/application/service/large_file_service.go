@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// LargeFileWindow is the period the large files added to a repository
+	// are counted over; older records are deleted
+	LargeFileWindow = 30 * 24 * time.Hour
+
+	// largeFileCleanupInterval is the time between deletions of old large file records
+	largeFileCleanupInterval = time.Hour
+
+	// largeFileCleanupJitter spreads the deletions of old large file records
+	largeFileCleanupJitter = 5 * time.Minute
+)
+
+// LargeFileService records the files pushes add to git above the large file
+// warning size instead of storing them with Git LFS. Pushers are warned while
+// they push; the records let maintainers see how often it happens.
+type LargeFileService struct {
+	largeFileRepo repository.LargeFileRepository
+	log           *logger.Logger
+}
+
+// NewLargeFileService creates a new LargeFileService instance recording the
+// large files of the pushes published on subscriber
+func NewLargeFileService(largeFileRepo repository.LargeFileRepository, subscriber events.Subscriber) *LargeFileService {
+	s := &LargeFileService{
+		largeFileRepo: largeFileRepo,
+		log:           logger.Get().WithFields(logger.Component("large-files")),
+	}
+
+	subscriber.Subscribe("large-files", s.recordPush, events.SubscribeOptions{
+		Types:      []string{events.TypeRepositoryPushed},
+		MaxRetries: 3,
+	})
+
+	return s
+}
+
+// CountRecent returns the number of large files added to a repository
+// without Git LFS during the last LargeFileWindow
+func (s *LargeFileService) CountRecent(ctx context.Context, repoID uuid.UUID) (int64, error) {
+	return s.largeFileRepo.CountSince(ctx, repoID, time.Now().Add(-LargeFileWindow))
+}
+
+// CleanupTask returns the housekeeping task deleting large file records older
+// than LargeFileWindow
+func (s *LargeFileService) CleanupTask() *HousekeepingTask {
+	return &HousekeepingTask{
+		Name:        "large_files.cleanup",
+		Description: "Delete records of large files pushed without Git LFS more than 30 days ago",
+		Interval:    largeFileCleanupInterval,
+		Jitter:      largeFileCleanupJitter,
+		Run:         s.cleanup,
+	}
+}
+
+// recordPush records the large files added by a push from either transport
+func (s *LargeFileService) recordPush(ctx context.Context, env events.Envelope) error {
+	push, ok := env.Event.(events.RepositoryPushed)
+	if !ok || len(push.LargeFiles) == 0 {
+		return nil
+	}
+
+	additions := make([]*models.LargeFileAddition, len(push.LargeFiles))
+	for i, file := range push.LargeFiles {
+		additions[i] = &models.LargeFileAddition{
+			RepositoryID: push.RepositoryID,
+			PusherID:     push.PusherID,
+			Path:         file.Path,
+			BlobHash:     file.Hash,
+			Size:         file.Size,
+			CreatedAt:    env.OccurredAt,
+		}
+	}
+	return s.largeFileRepo.CreateBatch(ctx, additions)
+}
+
+// cleanup deletes the large file records older than LargeFileWindow
+func (s *LargeFileService) cleanup(ctx context.Context) error {
+	deleted, err := s.largeFileRepo.DeleteBefore(ctx, time.Now().Add(-LargeFileWindow))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.log.WithContext(ctx).Info("Deleted old large file records", logger.Int64("count", deleted))
+	}
+	return nil
+}
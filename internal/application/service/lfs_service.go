@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// lfsObjectsDir is the directory of a repository holding its Git LFS objects
+	lfsObjectsDir = "lfs/objects"
+
+	// lfsUploadsDir is the directory of a repository holding Git LFS uploads
+	// until they are verified
+	lfsUploadsDir = "lfs/tmp"
+)
+
+// lfsOIDPattern matches Git LFS object ids: SHA-256 hashes in lowercase hex
+var lfsOIDPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// LFSObject identifies a Git LFS object by its SHA-256 hash and size in bytes
+type LFSObject struct {
+	OID  string
+	Size int64
+}
+
+// LFSService stores the Git LFS objects of repositories on their storage
+// backend, content-addressed by object id under the repository directory so
+// they move, count towards quotas and are deleted with the repository.
+// Uploads are refused when they would take the repository or its owner over
+// quota, or are larger than repos.max_lfs_object_size.
+type LFSService struct {
+	storage *StorageBackendService
+	quotas  *QuotaService
+	cfg     *config.ReposConfig
+	log     *logger.Logger
+}
+
+// NewLFSService creates a new LFSService instance
+func NewLFSService(storage *StorageBackendService, quotas *QuotaService, cfg *config.ReposConfig) *LFSService {
+	return &LFSService{
+		storage: storage,
+		quotas:  quotas,
+		cfg:     cfg,
+		log:     logger.Get().WithFields(logger.Component("lfs-service")),
+	}
+}
+
+// LFSUploadBudget tracks what the uploads of a batch may still add to a
+// repository
+type LFSUploadBudget struct {
+	maxObjectSize int64
+	headroom      int64
+	limited       bool
+}
+
+// UploadBudget returns the budget of the uploads of a batch to a repository,
+// measured now
+func (s *LFSService) UploadBudget(ctx context.Context, repo *models.Repository) *LFSUploadBudget {
+	headroom, limited := s.quotas.Headroom(ctx, repo)
+	return &LFSUploadBudget{maxObjectSize: s.cfg.MaxLFSObjectSize, headroom: headroom, limited: limited}
+}
+
+// Reserve counts an upload of obj against the budget, or returns a too large
+// error if the object exceeds the maximum object size and an unprocessable
+// error if it does not fit the quota
+func (b *LFSUploadBudget) Reserve(obj LFSObject) error {
+	if b.maxObjectSize > 0 && obj.Size > b.maxObjectSize {
+		return apperrors.TooLarge(fmt.Sprintf("object %s is larger than the maximum object size of %d bytes", obj.OID, b.maxObjectSize), nil)
+	}
+	if b.limited {
+		if obj.Size > b.headroom {
+			return apperrors.Unprocessable(fmt.Sprintf("object %s would exceed the size quota of the repository or its owner", obj.OID), nil)
+		}
+		b.headroom -= obj.Size
+	}
+	return nil
+}
+
+// ValidateObject returns a bad request error if the object id is not a
+// SHA-256 hash or the size is negative
+func (s *LFSService) ValidateObject(obj LFSObject) error {
+	if !lfsOIDPattern.MatchString(obj.OID) {
+		return apperrors.BadRequest("object id must be a SHA-256 hash in lowercase hex", apperrors.ErrInvalidInput)
+	}
+	if obj.Size < 0 {
+		return apperrors.BadRequest("object size must not be negative", apperrors.ErrInvalidInput)
+	}
+	return nil
+}
+
+// Stat returns the size of a stored object, or false if the repository does
+// not have it. The object id must have been validated.
+func (s *LFSService) Stat(repo *models.Repository, oid string) (int64, bool, error) {
+	backend, err := s.storage.ForRepo(repo)
+	if err != nil {
+		return 0, false, err
+	}
+
+	objectPath := lfsObjectPath(repo, oid)
+	exists, err := backend.Exists(objectPath)
+	if err != nil || !exists {
+		return 0, false, err
+	}
+	size, err := backend.Size(objectPath)
+	if err != nil {
+		return 0, false, err
+	}
+	return size, true, nil
+}
+
+// Open opens a stored object for reading and returns its size
+func (s *LFSService) Open(repo *models.Repository, oid string) (io.ReadCloser, int64, error) {
+	if err := s.ValidateObject(LFSObject{OID: oid}); err != nil {
+		return nil, 0, err
+	}
+	size, exists, err := s.Stat(repo, oid)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !exists {
+		return nil, 0, apperrors.NotFound("lfs object", apperrors.ErrNotFound)
+	}
+
+	backend, err := s.storage.ForRepo(repo)
+	if err != nil {
+		return nil, 0, err
+	}
+	reader, err := backend.OpenFile(lfsObjectPath(repo, oid))
+	if err != nil {
+		return nil, 0, err
+	}
+	return reader, size, nil
+}
+
+// Upload stores an object read from r. The content must hash to the object
+// id and be exactly obj.Size bytes long; otherwise nothing is stored and a
+// bad request error is returned. Uploading an object the repository already
+// has is a no-op. An object over the maximum object size or the quota is
+// refused before anything is read.
+func (s *LFSService) Upload(ctx context.Context, repo *models.Repository, obj LFSObject, r io.Reader) error {
+	if err := s.ValidateObject(obj); err != nil {
+		return err
+	}
+	if _, exists, err := s.Stat(repo, obj.OID); err != nil || exists {
+		return err
+	}
+	if err := s.UploadBudget(ctx, repo).Reserve(obj); err != nil {
+		s.log.WithContext(ctx).Info("LFS upload refused",
+			logger.String("repo_id", repo.ID.String()),
+			logger.String("oid", obj.OID),
+			logger.Int64("size", obj.Size),
+			logger.String("reason", err.Error()),
+		)
+		return err
+	}
+
+	backend, err := s.storage.ForRepo(repo)
+	if err != nil {
+		return err
+	}
+
+	// Written aside first so a failed or forged upload never takes the place of the object
+	uploadPath := path.Join(repo.GitPath, lfsUploadsDir, obj.OID+"-"+uuid.NewString())
+	w, err := backend.CreateFile(uploadPath)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	// One byte more than expected tells an oversized upload apart
+	written, err := io.Copy(io.MultiWriter(w, hash), io.LimitReader(r, obj.Size+1))
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = verifyLFSUpload(obj, written, hex.EncodeToString(hash.Sum(nil)))
+	}
+	if err == nil {
+		err = backend.MoveFile(uploadPath, lfsObjectPath(repo, obj.OID))
+	}
+	if err != nil {
+		if removeErr := backend.DeleteFile(uploadPath); removeErr != nil {
+			s.log.Warn("Failed to remove rejected LFS upload",
+				logger.Error(removeErr),
+				logger.String("repo_id", repo.ID.String()),
+				logger.String("path", uploadPath),
+			)
+		}
+		return err
+	}
+
+	s.log.Debug("Stored LFS object",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("oid", obj.OID),
+		logger.Int64("size", obj.Size),
+	)
+	return nil
+}
+
+// Verify returns a not found error unless the repository has the object with
+// the given size
+func (s *LFSService) Verify(repo *models.Repository, obj LFSObject) error {
+	if err := s.ValidateObject(obj); err != nil {
+		return err
+	}
+	size, exists, err := s.Stat(repo, obj.OID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return apperrors.NotFound("lfs object", apperrors.ErrNotFound)
+	}
+	if size != obj.Size {
+		return apperrors.Unprocessable(
+			fmt.Sprintf("object %s has %d bytes, not %d", obj.OID, size, obj.Size),
+			apperrors.ErrInvalidInput,
+		)
+	}
+	return nil
+}
+
+// verifyLFSUpload checks the length and hash of uploaded content against the object
+func verifyLFSUpload(obj LFSObject, written int64, oid string) error {
+	if written > obj.Size {
+		return apperrors.BadRequest(
+			fmt.Sprintf("object %s should have %d bytes, got more", obj.OID, obj.Size),
+			apperrors.ErrInvalidInput,
+		)
+	}
+	if written < obj.Size {
+		return apperrors.BadRequest(
+			fmt.Sprintf("object %s should have %d bytes, got %d", obj.OID, obj.Size, written),
+			apperrors.ErrInvalidInput,
+		)
+	}
+	if oid != obj.OID {
+		return apperrors.BadRequest(
+			fmt.Sprintf("content does not match object id %s", obj.OID),
+			apperrors.ErrInvalidInput,
+		)
+	}
+	return nil
+}
+
+// lfsObjectPath returns where an object of a repository is stored
+func lfsObjectPath(repo *models.Repository, oid string) string {
+	return path.Join(repo.GitPath, lfsObjectsDir, oid[0:2], oid[2:4], oid)
+}
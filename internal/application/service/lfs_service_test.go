@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+func TestLFSUploadBudgetReserve(t *testing.T) {
+	oid := strings.Repeat("a", 64)
+	tests := []struct {
+		name   string
+		budget LFSUploadBudget
+		sizes  []int64
+		// want is the HTTP status of the error for each size, 0 if reserved
+		want []int
+	}{
+		{
+			name:   "unlimited",
+			budget: LFSUploadBudget{},
+			sizes:  []int64{1 << 40},
+			want:   []int{0},
+		},
+		{
+			name:   "over the maximum object size",
+			budget: LFSUploadBudget{maxObjectSize: 100},
+			sizes:  []int64{100, 101},
+			want:   []int{0, http.StatusRequestEntityTooLarge},
+		},
+		{
+			name:   "uploads of a batch share the headroom",
+			budget: LFSUploadBudget{headroom: 100, limited: true},
+			sizes:  []int64{60, 60, 40, 1},
+			want:   []int{0, http.StatusUnprocessableEntity, 0, http.StatusUnprocessableEntity},
+		},
+		{
+			name:   "no headroom left",
+			budget: LFSUploadBudget{limited: true},
+			sizes:  []int64{0, 1},
+			want:   []int{0, http.StatusUnprocessableEntity},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := tt.budget
+			for i, size := range tt.sizes {
+				err := budget.Reserve(LFSObject{OID: oid, Size: size})
+				got := 0
+				var appErr *apperrors.AppError
+				if errors.As(err, &appErr) {
+					got = appErr.HTTPStatus()
+				} else if err != nil {
+					t.Fatalf("Reserve(%d) = %v, want an AppError", size, err)
+				}
+				if got != tt.want[i] {
+					t.Errorf("Reserve(%d) status = %d, want %d", size, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
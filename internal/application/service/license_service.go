@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/license"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// maxLicenseFileSize bounds the license files read for detection; license
+	// texts are far smaller, anything larger is not a license file
+	maxLicenseFileSize = 256 * 1024
+
+	// licenseDetectTimeout bounds detecting the license of a single repository
+	licenseDetectTimeout = time.Minute
+
+	// LicenseFilterNone is the license filter value matching repositories without a license
+	LicenseFilterNone = "none"
+)
+
+// spdxIDPattern matches SPDX license identifiers, including ones that cannot be detected
+var spdxIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.+-]{0,63}$`)
+
+// LicenseService detects the license of repositories from the license file at
+// the root of their default branch. The effective license, which listings are
+// filtered by, is the owner's override if set, otherwise the detected license.
+type LicenseService struct {
+	repoRepo   repository.RepoRepository
+	gitService service.GitService
+	log        *logger.Logger
+}
+
+// NewLicenseService creates a new LicenseService instance
+func NewLicenseService(repoRepo repository.RepoRepository, gitService service.GitService) *LicenseService {
+	return &LicenseService{
+		repoRepo:   repoRepo,
+		gitService: gitService,
+		log:        logger.Get().WithFields(logger.Component("license-service")),
+	}
+}
+
+// DetectLicenseAsync detects the license of a repository in the background
+func (s *LicenseService) DetectLicenseAsync(repo *models.Repository) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), licenseDetectTimeout)
+		defer cancel()
+
+		if err := s.DetectLicense(ctx, repo); err != nil {
+			s.log.Warn("Failed to detect license",
+				logger.Error(err),
+				logger.String("repo_id", repo.ID.String()),
+			)
+		}
+	}()
+}
+
+// DetectLicenseAfterPush detects the license of a repository in the background
+// if a push created its default branch or changed a license file on it
+func (s *LicenseService) DetectLicenseAfterPush(repo *models.Repository, updates []service.RefUpdate) {
+	for _, update := range updates {
+		if update.RefName != "refs/heads/"+repo.DefaultBranch || update.IsDelete() {
+			continue
+		}
+		if update.IsCreate() || repo.LicenseDetectedAt == nil {
+			s.DetectLicenseAsync(repo)
+			return
+		}
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), licenseDetectTimeout)
+			defer cancel()
+
+			paths, err := s.gitService.GetChangedPaths(ctx, repo.GitPath, update.OldHash, update.NewHash)
+			if err != nil {
+				s.log.Warn("Failed to list paths changed by push",
+					logger.Error(err),
+					logger.String("repo_id", repo.ID.String()),
+				)
+				return
+			}
+			for _, path := range paths {
+				// Only license files at the root are considered
+				if !strings.Contains(path, "/") && license.IsLicenseFile(path) {
+					if err := s.DetectLicense(ctx, repo); err != nil {
+						s.log.Warn("Failed to detect license after push",
+							logger.Error(err),
+							logger.String("repo_id", repo.ID.String()),
+						)
+					}
+					return
+				}
+			}
+		}()
+		return
+	}
+}
+
+// DetectLicense detects the license of a repository from the license files at
+// the root of its default branch and stores it
+func (s *LicenseService) DetectLicense(ctx context.Context, repo *models.Repository) error {
+	detected, err := s.detect(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	repo.DetectedLicense = detected
+	repo.License = repo.EffectiveLicense()
+	repo.LicenseDetectedAt = &now
+	if err := s.repoRepo.UpdateLicense(ctx, repo); err != nil {
+		return err
+	}
+
+	s.log.WithContext(ctx).Debug("Detected repository license",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("license", detected),
+	)
+	return nil
+}
+
+// detect returns the SPDX identifier of the license of the default branch, or
+// "" if it has no license file or the license is not recognized
+func (s *LicenseService) detect(ctx context.Context, repo *models.Repository) (string, error) {
+	branch := repo.DefaultBranch
+	if branch == "" {
+		head, err := s.gitService.GetHEADBranch(ctx, repo.GitPath)
+		if err != nil {
+			return "", err
+		}
+		branch = head
+	}
+
+	exists, err := s.gitService.BranchExists(ctx, repo.GitPath, branch)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		// Empty repository
+		return "", nil
+	}
+
+	entries, err := s.gitService.GetTree(ctx, repo.GitPath, branch, "")
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Type == "blob" && entry.Size <= maxLicenseFileSize {
+			names = append(names, entry.Name)
+		}
+	}
+
+	match, err := license.Detect(names, func(name string) ([]byte, error) {
+		file, err := s.gitService.GetFileContent(ctx, repo.GitPath, branch, name)
+		if err != nil {
+			return nil, err
+		}
+		return file.Content, nil
+	})
+	if err != nil || match == nil {
+		return "", err
+	}
+	return match.ID, nil
+}
+
+// Backfill detects the license of every repository that was never inspected.
+// It is safe to run repeatedly; once all repositories are inspected it is a no-op.
+func (s *LicenseService) Backfill(ctx context.Context) {
+	detected := 0
+	for {
+		repos, err := s.repoRepo.FindLicenseUndetected(ctx, 50)
+		if err != nil {
+			s.log.WithContext(ctx).Error("Failed to list repositories for license backfill", logger.Error(err))
+			return
+		}
+		if len(repos) == 0 {
+			break
+		}
+
+		for _, repo := range repos {
+			if ctx.Err() != nil {
+				return
+			}
+
+			repoCtx, cancel := context.WithTimeout(ctx, licenseDetectTimeout)
+			err := s.DetectLicense(repoCtx, repo)
+			cancel()
+			if err != nil {
+				s.log.WithContext(ctx).Warn("Failed to backfill license",
+					logger.Error(err),
+					logger.String("repo_id", repo.ID.String()),
+				)
+				// Store the repository as unlicensed so a broken repository does
+				// not stall the backfill; new pushes detect it again
+				now := time.Now()
+				repo.LicenseDetectedAt = &now
+				repo.License = repo.EffectiveLicense()
+				if err := s.repoRepo.UpdateLicense(ctx, repo); err != nil {
+					s.log.WithContext(ctx).Error("Failed to mark repository license as detected", logger.Error(err))
+					return
+				}
+			}
+			detected++
+		}
+	}
+
+	if detected > 0 {
+		s.log.WithContext(ctx).Info("License backfill completed", logger.Int("repositories", detected))
+	}
+}
+
+// ParseOverride validates a license override. Known licenses are returned in
+// their canonical form, LicenseOverrideNone marks a repository as unlicensed
+// and "" clears the override.
+func (s *LicenseService) ParseOverride(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, models.LicenseOverrideNone) {
+		return strings.ToLower(value), nil
+	}
+	if id, ok := license.Canonical(value); ok {
+		return id, nil
+	}
+	if !spdxIDPattern.MatchString(value) {
+		return "", apperrors.BadRequest("license must be an SPDX license identifier", apperrors.ErrInvalidInput)
+	}
+	return value, nil
+}
+
+// SetOverride stores the license override of a repository, as returned by ParseOverride
+func (s *LicenseService) SetOverride(ctx context.Context, repo *models.Repository, override string) error {
+	repo.LicenseOverride = override
+	repo.License = repo.EffectiveLicense()
+	if err := s.repoRepo.UpdateLicense(ctx, repo); err != nil {
+		s.log.WithContext(ctx).Error("Failed to update license override",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		return err
+	}
+
+	s.log.WithContext(ctx).Info("Updated repository license override",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("override", override),
+	)
+	return nil
+}
+
+// ParseFilter returns the effective license a ?license= filter matches: "" for
+// LicenseFilterNone, the canonical identifier for known licenses, and the
+// value itself otherwise
+func (s *LicenseService) ParseFilter(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, LicenseFilterNone) {
+		return "", nil
+	}
+	if id, ok := license.Canonical(value); ok {
+		return id, nil
+	}
+	if !spdxIDPattern.MatchString(value) {
+		return "", apperrors.BadRequest("license must be an SPDX license identifier or \"none\"", apperrors.ErrInvalidInput)
+	}
+	return value, nil
+}
+
+// CountByLicense returns the number of repositories per effective license
+// across the instance; repositories without a license are counted under ""
+func (s *LicenseService) CountByLicense(ctx context.Context) ([]models.LicenseCount, error) {
+	counts, err := s.repoRepo.CountByLicense(ctx)
+	if err != nil {
+		s.log.WithContext(ctx).Error("Failed to count repositories by license", logger.Error(err))
+		return nil, err
+	}
+	return counts, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/bravo68web/stasis/internal/config"
 	"github.com/bravo68web/stasis/internal/domain/models"
 	domainrepo "github.com/bravo68web/stasis/internal/domain/repository"
 	"github.com/bravo68web/stasis/internal/domain/service"
@@ -17,31 +18,35 @@ import (
 type MirrorSyncService struct {
 	repoRepo   domainrepo.RepoRepository
 	gitService service.GitService
+	cfg        *config.ReposConfig
 	log        *logger.Logger
 }
 
-// NewMirrorSyncService creates a new mirror sync service
+// NewMirrorSyncService creates a new mirror sync service. Upstreams are held
+// to the address rules of imports (repos.import_allow_private_addresses).
 func NewMirrorSyncService(
 	repoRepo domainrepo.RepoRepository,
 	gitService service.GitService,
+	cfg *config.ReposConfig,
 ) *MirrorSyncService {
 	return &MirrorSyncService{
 		repoRepo:   repoRepo,
 		gitService: gitService,
+		cfg:        cfg,
 		log:        logger.Get(),
 	}
 }
 
 // SyncRepository syncs a single mirror repository
 func (s *MirrorSyncService) SyncRepository(ctx context.Context, repoID uuid.UUID) error {
-	s.log.Info("Starting mirror sync",
+	s.log.WithContext(ctx).Info("Starting mirror sync",
 		logger.String("repo_id", repoID.String()),
 	)
 
 	// Get repository
 	repo, err := s.repoRepo.FindByID(ctx, repoID)
 	if err != nil {
-		s.log.Error("Failed to find repository",
+		s.log.WithContext(ctx).Error("Failed to find repository",
 			logger.Error(err),
 			logger.String("repo_id", repoID.String()),
 		)
@@ -50,7 +55,7 @@ func (s *MirrorSyncService) SyncRepository(ctx context.Context, repoID uuid.UUID
 
 	// Verify it's a mirror repository
 	if !repo.CanSync() {
-		s.log.Warn("Repository is not a mirror",
+		s.log.WithContext(ctx).Warn("Repository is not a mirror",
 			logger.String("repo_id", repoID.String()),
 			logger.String("repo_name", repo.Name),
 		)
@@ -59,7 +64,7 @@ func (s *MirrorSyncService) SyncRepository(ctx context.Context, repoID uuid.UUID
 
 	// Check if already syncing
 	if repo.IsSyncing() {
-		s.log.Warn("Repository is already syncing",
+		s.log.WithContext(ctx).Warn("Repository is already syncing",
 			logger.String("repo_id", repoID.String()),
 			logger.String("repo_name", repo.Name),
 		)
@@ -70,7 +75,7 @@ func (s *MirrorSyncService) SyncRepository(ctx context.Context, repoID uuid.UUID
 	repo.SyncStatus = "syncing"
 	repo.SyncError = ""
 	if err := s.repoRepo.Update(ctx, repo); err != nil {
-		s.log.Error("Failed to update sync status",
+		s.log.WithContext(ctx).Error("Failed to update sync status",
 			logger.Error(err),
 			logger.String("repo_id", repoID.String()),
 		)
@@ -85,7 +90,7 @@ func (s *MirrorSyncService) SyncRepository(ctx context.Context, repoID uuid.UUID
 
 // performSync performs the actual sync operation
 func (s *MirrorSyncService) performSync(ctx context.Context, repo *models.Repository) {
-	s.log.Info("Performing mirror sync",
+	s.log.WithContext(ctx).Info("Performing mirror sync",
 		logger.String("repo_id", repo.ID.String()),
 		logger.String("repo_name", repo.Name),
 		logger.String("direction", repo.MirrorDirection),
@@ -95,18 +100,18 @@ func (s *MirrorSyncService) performSync(ctx context.Context, repo *models.Reposi
 
 	// Perform upstream sync (pull from external source)
 	if repo.HasUpstream() {
-		s.log.Debug("Syncing upstream",
+		s.log.WithContext(ctx).Debug("Syncing upstream",
 			logger.String("repo_id", repo.ID.String()),
-			logger.String("upstream_url", repo.UpstreamURL),
+			logger.String("upstream_url", logger.Redact(repo.UpstreamURL)),
 		)
-		if err := s.gitService.FetchMirror(ctx, repo.GitPath, repo.UpstreamURL); err != nil {
-			s.log.Error("Upstream sync failed",
+		if err := s.fetchUpstream(ctx, repo); err != nil {
+			s.log.WithContext(ctx).Error("Upstream sync failed",
 				logger.Error(err),
 				logger.String("repo_id", repo.ID.String()),
 			)
 			syncErr = fmt.Errorf("upstream sync failed: %w", err)
 		} else {
-			s.log.Info("Upstream sync completed",
+			s.log.WithContext(ctx).Info("Upstream sync completed",
 				logger.String("repo_id", repo.ID.String()),
 			)
 		}
@@ -114,12 +119,12 @@ func (s *MirrorSyncService) performSync(ctx context.Context, repo *models.Reposi
 
 	// Perform downstream sync (push to external destination)
 	if repo.HasDownstream() && syncErr == nil {
-		s.log.Debug("Syncing downstream",
+		s.log.WithContext(ctx).Debug("Syncing downstream",
 			logger.String("repo_id", repo.ID.String()),
-			logger.String("downstream_url", repo.DownstreamURL),
+			logger.String("downstream_url", logger.Redact(repo.DownstreamURL)),
 		)
 		if err := s.gitService.PushMirror(ctx, repo.GitPath, repo.DownstreamURL, repo.DownstreamUsername, repo.DownstreamPassword); err != nil {
-			s.log.Error("Downstream sync failed",
+			s.log.WithContext(ctx).Error("Downstream sync failed",
 				logger.Error(err),
 				logger.String("repo_id", repo.ID.String()),
 			)
@@ -129,7 +134,7 @@ func (s *MirrorSyncService) performSync(ctx context.Context, repo *models.Reposi
 				syncErr = fmt.Errorf("downstream sync failed: %w", err)
 			}
 		} else {
-			s.log.Info("Downstream sync completed",
+			s.log.WithContext(ctx).Info("Downstream sync completed",
 				logger.String("repo_id", repo.ID.String()),
 			)
 		}
@@ -140,7 +145,7 @@ func (s *MirrorSyncService) performSync(ctx context.Context, repo *models.Reposi
 	repo.LastSyncedAt = &now
 
 	if syncErr != nil {
-		s.log.Error("Mirror sync failed",
+		s.log.WithContext(ctx).Error("Mirror sync failed",
 			logger.Error(syncErr),
 			logger.String("repo_id", repo.ID.String()),
 			logger.String("repo_name", repo.Name),
@@ -148,7 +153,7 @@ func (s *MirrorSyncService) performSync(ctx context.Context, repo *models.Reposi
 		repo.SyncStatus = "failed"
 		repo.SyncError = syncErr.Error()
 	} else {
-		s.log.Info("Mirror sync completed successfully",
+		s.log.WithContext(ctx).Info("Mirror sync completed successfully",
 			logger.String("repo_id", repo.ID.String()),
 			logger.String("repo_name", repo.Name),
 		)
@@ -158,28 +163,41 @@ func (s *MirrorSyncService) performSync(ctx context.Context, repo *models.Reposi
 
 	// Save updated status
 	if updateErr := s.repoRepo.Update(ctx, repo); updateErr != nil {
-		s.log.Error("Failed to update repository after sync",
+		s.log.WithContext(ctx).Error("Failed to update repository after sync",
 			logger.Error(updateErr),
 			logger.String("repo_id", repo.ID.String()),
 		)
 	}
 }
 
+// fetchUpstream fetches a mirror from its upstream. Unless private addresses
+// are allowed, the upstream must be an http(s) URL and every connection of
+// the fetch must go to a publicly routable address, whatever the host
+// resolved to when the mirror was set up.
+func (s *MirrorSyncService) fetchUpstream(ctx context.Context, repo *models.Repository) error {
+	if !s.cfg.ImportAllowPrivateAddresses {
+		if err := checkRemoteScheme(repo.UpstreamURL); err != nil {
+			return err
+		}
+	}
+	return s.gitService.FetchMirror(ctx, repo.GitPath, repo.UpstreamURL, publicDialControl(s.cfg.ImportAllowPrivateAddresses))
+}
+
 // SyncAllMirrors syncs all mirror repositories that are due for sync
 func (s *MirrorSyncService) SyncAllMirrors(ctx context.Context) error {
-	s.log.Debug("Checking mirror repositories for sync")
+	s.log.WithContext(ctx).Debug("Checking mirror repositories for sync")
 
 	// Get all mirror repositories
 	mirrors, err := s.repoRepo.FindAllMirrors(ctx)
 	if err != nil {
-		s.log.Error("Failed to find mirror repositories",
+		s.log.WithContext(ctx).Error("Failed to find mirror repositories",
 			logger.Error(err),
 		)
 		return fmt.Errorf("failed to find mirror repositories: %w", err)
 	}
 
 	if len(mirrors) == 0 {
-		s.log.Debug("No mirror repositories found")
+		s.log.WithContext(ctx).Debug("No mirror repositories found")
 		return nil
 	}
 
@@ -208,7 +226,7 @@ func (s *MirrorSyncService) SyncAllMirrors(ctx context.Context) error {
 
 		// Trigger sync
 		if err := s.SyncRepository(ctx, repo.ID); err != nil {
-			s.log.Error("Failed to sync mirror",
+			s.log.WithContext(ctx).Error("Failed to sync mirror",
 				logger.Error(err),
 				logger.String("repo_id", repo.ID.String()),
 				logger.String("repo_name", repo.Name),
@@ -220,7 +238,7 @@ func (s *MirrorSyncService) SyncAllMirrors(ctx context.Context) error {
 	}
 
 	if syncCount > 0 || errorCount > 0 {
-		s.log.Info("Mirror sync check completed",
+		s.log.WithContext(ctx).Info("Mirror sync check completed",
 			logger.Int("total_mirrors", len(mirrors)),
 			logger.Int("synced", syncCount),
 			logger.Int("skipped", skippedCount),
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// maxMentionsPerBody bounds the users notified for one body, so a body
+	// listing every user does not fan out to all of them
+	maxMentionsPerBody = 20
+
+	// maxNotificationTitleLength is the longest notification title stored
+	maxNotificationTitleLength = 255
+)
+
+// mentionPattern matches @username mentions that are not part of an email
+// address or a path
+var mentionPattern = regexp.MustCompile(`(?:^|[^a-zA-Z0-9_@./-])@([a-zA-Z][a-zA-Z0-9_-]*)`)
+
+// NotificationService tells users about what concerns them: @mentions in
+// issues, comments and pull requests, the merge or close of their pull
+// requests, and the failure of CI jobs they triggered. Notifications are
+// created from events published on the bus, so creating them never delays
+// or fails the operation that caused them. Users only get notifications
+// about repositories they can read.
+type NotificationService struct {
+	notificationRepo repository.NotificationRepository
+	userRepo         repository.UserRepository
+	repoRepo         repository.RepoRepository
+	repoService      *RepoService
+	log              *logger.Logger
+}
+
+// NewNotificationService creates a new NotificationService instance creating
+// notifications for the events published on subscriber
+func NewNotificationService(
+	notificationRepo repository.NotificationRepository,
+	userRepo repository.UserRepository,
+	repoRepo repository.RepoRepository,
+	repoService *RepoService,
+	subscriber events.Subscriber,
+) *NotificationService {
+	s := &NotificationService{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		repoRepo:         repoRepo,
+		repoService:      repoService,
+		log:              logger.Get().WithFields(logger.Component("notification-service")),
+	}
+
+	subscriber.Subscribe("notifications-mentions", s.notifyMentions, events.SubscribeOptions{
+		Types:      []string{events.TypeIssueOpened, events.TypeIssueCommented, events.TypePullRequestOpened},
+		MaxRetries: 3,
+	})
+	subscriber.Subscribe("notifications-pull-requests", s.notifyPullRequestClosed, events.SubscribeOptions{
+		Types:      []string{events.TypePullRequestClosed},
+		MaxRetries: 3,
+	})
+	subscriber.Subscribe("notifications-ci", s.notifyCIJobFailed, events.SubscribeOptions{
+		Types:      []string{events.TypeCIJobStatus},
+		MaxRetries: 3,
+	})
+
+	return s
+}
+
+// ListNotifications returns a page of the notifications of a user, newest
+// first, optionally only the unread ones, with the total number matching
+func (s *NotificationService) ListNotifications(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit, offset int) ([]*models.Notification, int64, error) {
+	return s.notificationRepo.ListByUser(ctx, userID, unreadOnly, limit, offset)
+}
+
+// UnreadCount returns the number of unread notifications of a user
+func (s *NotificationService) UnreadCount(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.notificationRepo.CountUnread(ctx, userID)
+}
+
+// MarkRead marks a notification of a user as read
+func (s *NotificationService) MarkRead(ctx context.Context, userID, id uuid.UUID) error {
+	return s.notificationRepo.MarkRead(ctx, userID, id)
+}
+
+// MarkAllRead marks every notification of a user as read and returns how many were unread
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.notificationRepo.MarkAllRead(ctx, userID)
+}
+
+// notifyMentions notifies the users @mentioned in a new issue, comment or pull request
+func (s *NotificationService) notifyMentions(ctx context.Context, env events.Envelope) error {
+	template := &models.Notification{Type: models.NotificationMention}
+	var authorID uuid.UUID
+	var body string
+
+	switch event := env.Event.(type) {
+	case events.IssueOpened:
+		template.RepositoryID = &event.RepositoryID
+		template.IssueID = &event.IssueID
+		template.Number = event.Number
+		template.ActorID = &event.AuthorID
+		template.Title = fmt.Sprintf("%s mentioned you in issue #%d: %s", event.Author, event.Number, event.Title)
+		authorID, body = event.AuthorID, event.Body
+	case events.IssueCommented:
+		template.RepositoryID = &event.RepositoryID
+		template.IssueID = &event.IssueID
+		template.Number = event.Number
+		template.ActorID = &event.AuthorID
+		template.Title = fmt.Sprintf("%s mentioned you in a comment on issue #%d", event.Author, event.Number)
+		authorID, body = event.AuthorID, event.Body
+	case events.PullRequestOpened:
+		template.RepositoryID = &event.RepositoryID
+		template.PullRequestID = &event.PullRequestID
+		template.Number = event.Number
+		template.ActorID = &event.AuthorID
+		template.Title = fmt.Sprintf("%s mentioned you in pull request #%d: %s", event.Author, event.Number, event.Title)
+		authorID, body = event.AuthorID, event.Body
+	default:
+		return nil
+	}
+
+	var recipients []*models.User
+	for _, username := range ParseMentions(body) {
+		user, err := s.userRepo.FindByUsername(ctx, username)
+		if err != nil {
+			if apperrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if user.ID != authorID {
+			recipients = append(recipients, user)
+		}
+	}
+	return s.deliver(ctx, template, recipients)
+}
+
+// notifyPullRequestClosed notifies the author of a pull request that someone
+// else merged or closed it
+func (s *NotificationService) notifyPullRequestClosed(ctx context.Context, env events.Envelope) error {
+	event, ok := env.Event.(events.PullRequestClosed)
+	if !ok || event.AuthorID == nil || *event.AuthorID == event.ActorID {
+		return nil
+	}
+
+	author, err := s.userRepo.FindByID(ctx, *event.AuthorID)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	template := &models.Notification{
+		Type:          models.NotificationPullRequestClosed,
+		RepositoryID:  &event.RepositoryID,
+		PullRequestID: &event.PullRequestID,
+		Number:        event.Number,
+		ActorID:       &event.ActorID,
+		Title:         fmt.Sprintf("%s closed your pull request #%d", event.Actor, event.Number),
+	}
+	if event.Merged {
+		template.Type = models.NotificationPullRequestMerged
+		template.Title = fmt.Sprintf("%s merged your pull request #%d", event.Actor, event.Number)
+	}
+	return s.deliver(ctx, template, []*models.User{author})
+}
+
+// notifyCIJobFailed notifies the user who triggered a CI job that it failed
+func (s *NotificationService) notifyCIJobFailed(ctx context.Context, env events.Envelope) error {
+	status, ok := env.Event.(events.CIJobStatus)
+	if !ok || status.TriggeredBy == "" {
+		return nil
+	}
+	switch status.Status {
+	case models.CIJobStatusFailed, models.CIJobStatusTimedOut, models.CIJobStatusError:
+	default:
+		return nil
+	}
+
+	actor, err := s.userRepo.FindByUsername(ctx, status.TriggeredBy)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	template := &models.Notification{
+		Type:         models.NotificationCIJobFailed,
+		RepositoryID: &status.RepositoryID,
+		CIJobID:      &status.JobID,
+		Title:        fmt.Sprintf("CI job %s %s on %s", ciJobLabel(status), strings.ReplaceAll(status.Status, "_", " "), status.RefName),
+	}
+	return s.deliver(ctx, template, []*models.User{actor})
+}
+
+// ciJobLabel names a CI job in notifications: the jobs of staged runs by
+// their name, others by the start of their ID
+func ciJobLabel(status events.CIJobStatus) string {
+	if status.JobName != "" {
+		return status.JobName
+	}
+	return status.JobID.String()[:8]
+}
+
+// deliver stores a copy of template for each recipient that can read its repository
+func (s *NotificationService) deliver(ctx context.Context, template *models.Notification, recipients []*models.User) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	var repo *models.Repository
+	if template.RepositoryID != nil {
+		var err error
+		repo, err = s.repoRepo.FindByID(ctx, *template.RepositoryID)
+		if err != nil {
+			if apperrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	title := truncateNotificationTitle(template.Title)
+	notifications := make([]*models.Notification, 0, len(recipients))
+	for _, user := range recipients {
+		if repo != nil && !s.repoService.HasPermission(ctx, user, repo, models.RepoPermissionRead) {
+			continue
+		}
+		notification := *template
+		notification.UserID = user.ID
+		notification.Title = title
+		notifications = append(notifications, &notification)
+	}
+
+	if err := s.notificationRepo.CreateBatch(ctx, notifications); err != nil {
+		s.log.WithContext(ctx).Error("Failed to create notifications",
+			logger.Error(err),
+			logger.String("type", template.Type),
+		)
+		return err
+	}
+	return nil
+}
+
+// ParseMentions returns the distinct usernames @mentioned in a Markdown body,
+// in order of first mention, at most maxMentionsPerBody of them
+func ParseMentions(body string) []string {
+	var usernames []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		if len(usernames) == maxMentionsPerBody {
+			break
+		}
+		if !slices.ContainsFunc(usernames, func(u string) bool { return strings.EqualFold(u, match[1]) }) {
+			usernames = append(usernames, match[1])
+		}
+	}
+	return usernames
+}
+
+// truncateNotificationTitle shortens a title to the stored length without
+// splitting a character
+func truncateNotificationTitle(title string) string {
+	if len(title) <= maxNotificationTitleLength {
+		return title
+	}
+	cut := maxNotificationTitleLength - len("…")
+	for cut > 0 && !utf8.RuneStart(title[cut]) {
+		cut--
+	}
+	return title[:cut] + "…"
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+)
+
+func TestNotifyCIJobFailed(t *testing.T) {
+	owner := &models.User{ID: uuid.New(), Username: "owner"}
+	alice := &models.User{ID: uuid.New(), Username: "alice"}
+	public := &models.Repository{ID: uuid.New(), Name: "public", OwnerID: owner.ID, Owner: *owner}
+	private := &models.Repository{ID: uuid.New(), Name: "private", OwnerID: owner.ID, Owner: *owner, IsPrivate: true}
+
+	failed := func(repo *models.Repository, status, actor, jobName string) events.CIJobStatus {
+		return events.CIJobStatus{
+			JobID:        uuid.MustParse("0f1e2d3c-0000-4000-8000-000000000000"),
+			Status:       status,
+			RepositoryID: repo.ID,
+			JobName:      jobName,
+			CommitSHA:    "0123456789abcdef0123456789abcdef01234567",
+			RefName:      "main",
+			RefType:      string(models.CIRefTypeBranch),
+			TriggeredBy:  actor,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		event     events.CIJobStatus
+		wantUser  *models.User
+		wantTitle string
+	}{
+		{
+			name:      "job outside staged runs",
+			event:     failed(public, models.CIJobStatusFailed, "alice", ""),
+			wantUser:  alice,
+			wantTitle: "CI job 0f1e2d3c failed on main",
+		},
+		{
+			name:      "job of a staged run",
+			event:     failed(public, models.CIJobStatusTimedOut, "alice", "unit"),
+			wantUser:  alice,
+			wantTitle: "CI job unit timed out on main",
+		},
+		{name: "successful job", event: failed(public, models.CIJobStatusSuccess, "alice", "")},
+		{name: "job without a triggering user", event: failed(public, models.CIJobStatusError, "", "")},
+		{name: "unknown triggering user", event: failed(public, models.CIJobStatusFailed, "bob", "")},
+		{name: "repository the user cannot read", event: failed(private, models.CIJobStatusFailed, "alice", "")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUserRepo{users: []*models.User{owner, alice}}
+			repos := &fakeRepoRepo{repos: []*models.Repository{public, private}}
+			notifications := &fakeNotificationRepo{}
+			s := NewNotificationService(notifications, users, repos, newTestRepoService(users, repos), &fakeBus{})
+
+			if err := s.notifyCIJobFailed(context.Background(), envelope(tt.event)); err != nil {
+				t.Fatalf("notifyCIJobFailed: %v", err)
+			}
+
+			if tt.wantUser == nil {
+				if len(notifications.created) != 0 {
+					t.Fatalf("got %d notifications, want none", len(notifications.created))
+				}
+				return
+			}
+			if len(notifications.created) != 1 {
+				t.Fatalf("got %d notifications, want 1", len(notifications.created))
+			}
+			n := notifications.created[0]
+			if n.UserID != tt.wantUser.ID {
+				t.Errorf("notified user %s, want %s", n.UserID, tt.wantUser.ID)
+			}
+			if n.Type != models.NotificationCIJobFailed {
+				t.Errorf("type = %q, want %q", n.Type, models.NotificationCIJobFailed)
+			}
+			if n.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", n.Title, tt.wantTitle)
+			}
+			if n.RepositoryID == nil || *n.RepositoryID != tt.event.RepositoryID {
+				t.Errorf("repository = %v, want %s", n.RepositoryID, tt.event.RepositoryID)
+			}
+			if n.CIJobID == nil || *n.CIJobID != tt.event.JobID {
+				t.Errorf("job = %v, want %s", n.CIJobID, tt.event.JobID)
+			}
+		})
+	}
+}
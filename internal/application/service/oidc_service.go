@@ -5,13 +5,16 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/events"
 	"github.com/bravo68web/stasis/internal/domain/models"
 	"github.com/bravo68web/stasis/internal/domain/repository"
 	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/oauth2"
@@ -24,6 +27,9 @@ type OIDCService struct {
 	oauth2Cfg   *oauth2.Config
 	verifier    *oidc.IDTokenVerifier
 	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	publisher   events.Publisher
+	log         *logger.Logger
 	initialized bool
 }
 
@@ -37,7 +43,8 @@ type OIDCClaims struct {
 	Picture       string `json:"picture"`
 }
 
-// SessionClaims represents the claims in the session JWT
+// SessionClaims represents the claims in the session JWT. The JWT ID is the
+// ID of the session.
 type SessionClaims struct {
 	jwt.RegisteredClaims
 	UserID   string `json:"user_id"`
@@ -47,10 +54,13 @@ type SessionClaims struct {
 }
 
 // NewOIDCService creates a new OIDCService instance
-func NewOIDCService(cfg *config.OIDCConfig, userRepo repository.UserRepository) *OIDCService {
+func NewOIDCService(cfg *config.OIDCConfig, userRepo repository.UserRepository, sessionRepo repository.SessionRepository, publisher events.Publisher) *OIDCService {
 	return &OIDCService{
 		config:      cfg,
 		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		publisher:   publisher,
+		log:         logger.Get().WithFields(logger.Component("oidc-service")),
 		initialized: false,
 	}
 }
@@ -104,8 +114,9 @@ func (s *OIDCService) GenerateAuthURL() (string, string, error) {
 		return "", "", fmt.Errorf("failed to generate state: %w", err)
 	}
 
-	url := s.oauth2Cfg.AuthCodeURL(state, oauth2.AccessTypeOnline)
-	return url, state, nil
+	// Ask for a refresh token, which sessions are refreshed with
+	authURL := s.oauth2Cfg.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	return authURL, state, nil
 }
 
 // HandleCallback processes the OIDC callback and returns the authenticated user
@@ -142,24 +153,45 @@ func (s *OIDCService) HandleCallback(ctx context.Context, code, state, expectedS
 	if err := idToken.Claims(&claims); err != nil {
 		return nil, "", fmt.Errorf("failed to parse claims: %w", err)
 	}
+	var rawClaims map[string]any
+	if err := idToken.Claims(&rawClaims); err != nil {
+		return nil, "", fmt.Errorf("failed to parse claims: %w", err)
+	}
 
 	// Find or create user
-	user, err := s.findOrCreateUser(ctx, idToken.Issuer, claims)
+	user, created, err := s.findOrCreateUser(ctx, idToken.Issuer, claims, s.adminFromClaims(rawClaims))
 	if err != nil {
 		return nil, "", err
 	}
+	if !created {
+		if err := s.syncAdmin(ctx, user, rawClaims); err != nil {
+			return nil, "", err
+		}
+	}
 
-	// Generate session JWT
-	sessionToken, err := s.GenerateSessionToken(user)
+	// Start the session and generate its JWT
+	session, err := s.createSession(ctx, user, oauth2Token.RefreshToken, rawIDToken)
+	if err != nil {
+		return nil, "", err
+	}
+	sessionToken, err := s.GenerateSessionToken(user, session)
 	if err != nil {
 		return nil, "", err
 	}
 
+	s.publisher.Publish(events.UserSignedIn{
+		UserID:   user.ID,
+		Username: user.Username,
+		Method:   "oidc",
+	})
+
 	return user, sessionToken, nil
 }
 
-// findOrCreateUser finds an existing user or creates a new one based on OIDC claims
-func (s *OIDCService) findOrCreateUser(ctx context.Context, issuer string, claims OIDCClaims) (*models.User, error) {
+// findOrCreateUser finds an existing user or creates a new one based on OIDC
+// claims, and returns whether it was created. A new user is an administrator
+// if isAdmin is true.
+func (s *OIDCService) findOrCreateUser(ctx context.Context, issuer string, claims OIDCClaims, isAdmin bool) (*models.User, bool, error) {
 	// Try to find user by OIDC subject
 	user, err := s.userRepo.FindByOIDCSubject(ctx, claims.Subject, issuer)
 	if err == nil {
@@ -171,12 +203,12 @@ func (s *OIDCService) findOrCreateUser(ctx context.Context, issuer string, claim
 				fmt.Printf("failed to update user email: %v\n", err)
 			}
 		}
-		return user, nil
+		return user, false, nil
 	}
 
 	// User not found, check if it's a "not found" error
 	if !apperrors.IsNotFound(err) {
-		return nil, fmt.Errorf("failed to find user: %w", err)
+		return nil, false, fmt.Errorf("failed to find user: %w", err)
 	}
 
 	// Create new user
@@ -190,9 +222,9 @@ func (s *OIDCService) findOrCreateUser(ctx context.Context, issuer string, claim
 		existingUser.OIDCSubject = claims.Subject
 		existingUser.OIDCIssuer = issuer
 		if err := s.userRepo.Update(ctx, existingUser); err != nil {
-			return nil, fmt.Errorf("failed to link OIDC to existing user: %w", err)
+			return nil, false, fmt.Errorf("failed to link OIDC to existing user: %w", err)
 		}
-		return existingUser, nil
+		return existingUser, false, nil
 	}
 
 	// Create new user
@@ -201,14 +233,14 @@ func (s *OIDCService) findOrCreateUser(ctx context.Context, issuer string, claim
 		Email:       email,
 		OIDCSubject: claims.Subject,
 		OIDCIssuer:  issuer,
-		IsAdmin:     false,
+		IsAdmin:     isAdmin,
 	}
 
 	// Ensure username is unique
 	for i := 0; i < 10; i++ {
 		exists, err := s.userRepo.ExistsByUsername(ctx, newUser.Username)
 		if err != nil {
-			return nil, fmt.Errorf("failed to check username: %w", err)
+			return nil, false, fmt.Errorf("failed to check username: %w", err)
 		}
 		if !exists {
 			break
@@ -218,10 +250,82 @@ func (s *OIDCService) findOrCreateUser(ctx context.Context, issuer string, claim
 	}
 
 	if err := s.userRepo.Create(ctx, newUser); err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, false, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	s.publisher.Publish(events.UserCreated{
+		UserID:   newUser.ID,
+		Username: newUser.Username,
+		Source:   "oidc",
+	})
+
+	return newUser, true, nil
+}
+
+// syncAdmin sets the administrator role of an existing user from the claims
+// of their ID token, if the role is mapped from a claim and synced on login
+func (s *OIDCService) syncAdmin(ctx context.Context, user *models.User, rawClaims map[string]any) error {
+	mapping := &s.config.AdminMapping
+	if !mapping.Enabled() || !mapping.SyncOnLogin {
+		return nil
+	}
+
+	isAdmin := s.adminFromClaims(rawClaims)
+	if user.IsAdmin == isAdmin {
+		return nil
 	}
 
-	return newUser, nil
+	user.IsAdmin = isAdmin
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to sync administrator role: %w", err)
+	}
+	s.log.WithContext(ctx).Info("Administrator role synced from the identity provider",
+		logger.String("user_id", user.ID.String()),
+		logger.String("username", user.Username),
+		logger.Bool("is_admin", isAdmin),
+	)
+	return nil
+}
+
+// adminFromClaims returns true if the mapped claim holds one of the values
+// granting the administrator role. The claim may be a string or a list of
+// strings; a missing claim grants nothing.
+func (s *OIDCService) adminFromClaims(rawClaims map[string]any) bool {
+	mapping := &s.config.AdminMapping
+	if !mapping.Enabled() {
+		return false
+	}
+
+	var value any = rawClaims
+	for _, key := range strings.Split(mapping.Claim, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return false
+		}
+		if value, ok = object[key]; !ok {
+			return false
+		}
+	}
+
+	var granted []string
+	switch v := value.(type) {
+	case string:
+		granted = []string{v}
+	case []any:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				granted = append(granted, str)
+			}
+		}
+	}
+	for _, g := range granted {
+		for _, want := range mapping.Values {
+			if g == want {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // generateUsername generates a username from OIDC claims
@@ -269,15 +373,21 @@ func sanitizeUsername(s string) string {
 	return strings.ToLower(username)
 }
 
-// GenerateSessionToken generates a JWT session token for the user
-func (s *OIDCService) GenerateSessionToken(user *models.User) (string, error) {
+// GenerateSessionToken generates a JWT session token for the user in a
+// session. It expires after oidc.sessions.token_ttl, or with the session.
+func (s *OIDCService) GenerateSessionToken(user *models.User, session *models.Session) (string, error) {
 	now := time.Now()
+	expiresAt := now.Add(s.config.Sessions.TokenTTL())
+	if session.ExpiresAt.Before(expiresAt) {
+		expiresAt = session.ExpiresAt
+	}
 	claims := SessionClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        session.ID.String(),
 			Issuer:    "stasis",
 			Subject:   user.ID.String(),
 			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(24 * time.Hour)), // 24 hour expiry
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			NotBefore: jwt.NewNumericDate(now),
 		},
 		UserID:   user.ID.String(),
@@ -336,12 +446,21 @@ func (s *OIDCService) GetLogoutURL(idTokenHint, postLogoutRedirectURI string) (s
 		return "", nil
 	}
 
-	logoutURL := providerClaims.EndSessionEndpoint
+	logoutURL, err := url.Parse(providerClaims.EndSessionEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid end_session_endpoint: %w", err)
+	}
+	query := logoutURL.Query()
+	if idTokenHint != "" {
+		query.Set("id_token_hint", idTokenHint)
+	}
 	if postLogoutRedirectURI != "" {
-		logoutURL = fmt.Sprintf("%s?post_logout_redirect_uri=%s", logoutURL, postLogoutRedirectURI)
+		query.Set("post_logout_redirect_uri", postLogoutRedirectURI)
+		query.Set("client_id", s.config.ClientID)
 	}
+	logoutURL.RawQuery = query.Encode()
 
-	return logoutURL, nil
+	return logoutURL.String(), nil
 }
 
 // generateRandomState generates a random state string for CSRF protection
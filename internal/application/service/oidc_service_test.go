@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+)
+
+const testIssuer = "https://idp.example.com"
+
+// fakeIdentityProvider is a token endpoint answering each code with an ID
+// token holding the claims given for it, signed with key
+type fakeIdentityProvider struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]jwt.MapClaims
+}
+
+func newFakeIdentityProvider(t *testing.T) *fakeIdentityProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &fakeIdentityProvider{key: key, claims: map[string]jwt.MapClaims{}}
+	idp.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := idp.claims[r.FormValue("code")]
+		if !ok {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		now := time.Now()
+		claims["iss"], claims["aud"] = testIssuer, "stasis"
+		claims["iat"], claims["exp"] = now.Unix(), now.Add(time.Hour).Unix()
+		idToken, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "access", "token_type": "Bearer", "id_token": idToken})
+	}))
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+// newTestOIDCService returns an OIDCService logging in through idp, whose ID
+// tokens it verifies against the key of idp
+func newTestOIDCService(idp *fakeIdentityProvider, mapping config.OIDCAdminMappingConfig, users *fakeUserRepo) *OIDCService {
+	cfg := &config.OIDCConfig{
+		Enabled:      true,
+		ClientID:     "stasis",
+		JWTSecret:    "secret",
+		AdminMapping: mapping,
+		Sessions:     config.OIDCSessionConfig{EncryptionKey: "key", AbsoluteLifetimeHours: 24, IdleTimeoutMinutes: 60, TokenTTLMinutes: 15},
+	}
+	s := NewOIDCService(cfg, users, &fakeSessionRepo{}, &fakeBus{})
+	s.oauth2Cfg = &oauth2.Config{
+		ClientID: "stasis",
+		Endpoint: oauth2.Endpoint{TokenURL: idp.URL + "/token", AuthStyle: oauth2.AuthStyleInParams},
+	}
+	keys := &oidc.StaticKeySet{PublicKeys: []crypto.PublicKey{&idp.key.PublicKey}}
+	s.verifier = oidc.NewVerifier(testIssuer, keys, &oidc.Config{ClientID: "stasis"})
+	s.initialized = true
+	return s
+}
+
+func TestHandleCallbackMapsAdminRole(t *testing.T) {
+	groups := config.OIDCAdminMappingConfig{Claim: "groups", Values: []string{"stasis-admins"}, SyncOnLogin: true}
+	inGroup := []any{"developers", "stasis-admins"}
+
+	tests := []struct {
+		name    string
+		mapping config.OIDCAdminMappingConfig
+		user    *models.User // Existing user, nil to create one
+		claims  jwt.MapClaims
+		want    bool
+		updated bool
+	}{
+		{name: "new user in the group", mapping: groups, claims: jwt.MapClaims{"groups": inGroup}, want: true},
+		{name: "new user outside the group", mapping: groups, claims: jwt.MapClaims{"groups": []any{"developers"}}},
+		{name: "new user without the claim", mapping: groups, claims: jwt.MapClaims{}},
+		{
+			name:    "new user with a string claim",
+			mapping: config.OIDCAdminMappingConfig{Claim: "role", Values: []string{"admin"}},
+			claims:  jwt.MapClaims{"role": "admin"},
+			want:    true,
+		},
+		{
+			name:    "new user with a nested claim",
+			mapping: config.OIDCAdminMappingConfig{Claim: "realm_access.roles", Values: []string{"admin"}},
+			claims:  jwt.MapClaims{"realm_access": map[string]any{"roles": []any{"user", "admin"}}},
+			want:    true,
+		},
+		{name: "new user, mapping disabled", claims: jwt.MapClaims{"groups": inGroup}},
+		{
+			name:    "user added to the group",
+			mapping: groups,
+			user:    &models.User{OIDCSubject: "alice", OIDCIssuer: testIssuer},
+			claims:  jwt.MapClaims{"groups": inGroup},
+			want:    true,
+			updated: true,
+		},
+		{
+			name:    "user removed from the group",
+			mapping: groups,
+			user:    &models.User{OIDCSubject: "alice", OIDCIssuer: testIssuer, IsAdmin: true},
+			claims:  jwt.MapClaims{"groups": []any{"developers"}},
+			updated: true,
+		},
+		{
+			name:    "claim removed",
+			mapping: groups,
+			user:    &models.User{OIDCSubject: "alice", OIDCIssuer: testIssuer, IsAdmin: true},
+			claims:  jwt.MapClaims{},
+			updated: true,
+		},
+		{
+			name:    "administrator still in the group",
+			mapping: groups,
+			user:    &models.User{OIDCSubject: "alice", OIDCIssuer: testIssuer, IsAdmin: true},
+			claims:  jwt.MapClaims{"groups": inGroup},
+			want:    true,
+		},
+		{
+			name:    "user removed from the group, not synced on login",
+			mapping: config.OIDCAdminMappingConfig{Claim: "groups", Values: []string{"stasis-admins"}},
+			user:    &models.User{OIDCSubject: "alice", OIDCIssuer: testIssuer, IsAdmin: true},
+			claims:  jwt.MapClaims{},
+			want:    true,
+		},
+		{
+			name:    "account linked by email",
+			mapping: groups,
+			user:    &models.User{Email: "alice@example.com"},
+			claims:  jwt.MapClaims{"groups": inGroup},
+			want:    true,
+			updated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newFakeIdentityProvider(t)
+			users := &fakeUserRepo{}
+			var wasAdmin bool
+			if tt.user != nil {
+				tt.user.ID, tt.user.Username = uuid.New(), "alice"
+				users.users = append(users.users, tt.user)
+				wasAdmin = tt.user.IsAdmin
+			}
+			s := newTestOIDCService(idp, tt.mapping, users)
+			tt.claims["sub"], tt.claims["email"], tt.claims["preferred_username"] = "alice", "alice@example.com", "alice"
+			idp.claims["code"] = tt.claims
+
+			user, sessionToken, err := s.HandleCallback(context.Background(), "code", "state", "state")
+			if err != nil {
+				t.Fatalf("HandleCallback: %v", err)
+			}
+			if tt.user != nil && user.ID != tt.user.ID {
+				t.Fatalf("logged in as %s, want the existing user %s", user.ID, tt.user.ID)
+			}
+			if len(users.users) != 1 {
+				t.Fatalf("%d users, want 1", len(users.users))
+			}
+			if user.IsAdmin != tt.want {
+				t.Errorf("administrator = %v, want %v", user.IsAdmin, tt.want)
+			}
+
+			var synced bool
+			for _, update := range users.updated {
+				synced = synced || update.IsAdmin != wasAdmin
+			}
+			if synced != tt.updated {
+				t.Errorf("role stored = %v, want %v", synced, tt.updated)
+			}
+
+			claims, err := s.ValidateSessionToken(sessionToken)
+			if err != nil {
+				t.Fatalf("ValidateSessionToken: %v", err)
+			}
+			if claims.IsAdmin != tt.want {
+				t.Errorf("session token administrator = %v, want %v", claims.IsAdmin, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleCallbackRejectsForeignTokens(t *testing.T) {
+	idp := newFakeIdentityProvider(t)
+	other := newFakeIdentityProvider(t)
+	s := newTestOIDCService(idp, config.OIDCAdminMappingConfig{Claim: "groups", Values: []string{"stasis-admins"}}, &fakeUserRepo{})
+	// The token endpoint answers with a token signed by another key
+	s.oauth2Cfg.Endpoint.TokenURL = other.URL + "/token"
+	other.claims["code"] = jwt.MapClaims{"sub": "mallory", "groups": []any{"stasis-admins"}}
+
+	if user, _, err := s.HandleCallback(context.Background(), "code", "state", "state"); err == nil {
+		t.Fatalf("HandleCallback logged in %+v with a token signed by another key", user)
+	}
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// sessionTouchInterval is the least time between two records of the use
+	// of a session, so authenticated requests rarely write
+	sessionTouchInterval = time.Minute
+
+	// sessionCleanupInterval is the time between purges of ended sessions
+	sessionCleanupInterval = time.Hour
+
+	// sessionCleanupJitter spreads the purges of ended sessions
+	sessionCleanupJitter = 5 * time.Minute
+
+	// sessionRevocationTimeout bounds the revocation of a refresh token at
+	// the identity provider on logout
+	sessionRevocationTimeout = 5 * time.Second
+)
+
+// errSessionEnded is the error of a session token whose session ended
+var errSessionEnded = apperrors.Unauthorized("the session has ended, log in again", apperrors.ErrInvalidCredentials)
+
+// createSession starts the session of a login. The tokens of the identity
+// provider are stored encrypted: the refresh token to refresh the session
+// and the ID token for the logout URL.
+func (s *OIDCService) createSession(ctx context.Context, user *models.User, refreshToken, idToken string) (*models.Session, error) {
+	encryptedRefresh, err := s.encryptSessionSecret(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	encryptedID, err := s.encryptSessionSecret(idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		UserID:       user.ID,
+		RefreshToken: encryptedRefresh,
+		IDToken:      encryptedID,
+		ExpiresAt:    now.Add(s.config.Sessions.AbsoluteLifetime()),
+		LastUsedAt:   now,
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return session, nil
+}
+
+// CheckSession checks that the session of valid session token claims is
+// still active and records its use
+func (s *OIDCService) CheckSession(ctx context.Context, claims *SessionClaims) error {
+	session, err := s.activeSession(ctx, claims)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if now.Sub(session.LastUsedAt) >= sessionTouchInterval {
+		if err := s.sessionRepo.Touch(ctx, session.ID, now); err != nil {
+			// The session only risks ending early of idleness
+			s.log.WithContext(ctx).Warn("Failed to record the use of a session", logger.Error(err))
+		}
+	}
+	return nil
+}
+
+// RefreshSession mints a new session token from a session token, expired or
+// not, while its session is active. If the identity provider issued a
+// refresh token the session is refreshed there too, so a user disabled at the
+// identity provider is logged out.
+func (s *OIDCService) RefreshSession(ctx context.Context, sessionToken string) (*models.User, string, error) {
+	claims, err := s.parseSessionToken(sessionToken)
+	if err != nil {
+		return nil, "", err
+	}
+	session, err := s.activeSession(ctx, claims)
+	if err != nil {
+		return nil, "", err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, session.UserID)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil, "", errSessionEnded
+		}
+		return nil, "", fmt.Errorf("failed to find user: %w", err)
+	}
+
+	if err := s.refreshAtProvider(ctx, session); err != nil {
+		return nil, "", err
+	}
+
+	token, err := s.GenerateSessionToken(user, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, token, nil
+}
+
+// EndSession revokes the session of a session token, expired or not, and
+// returns the logout URL of the identity provider, or "" if it has none.
+// An invalid token or ended session only yields the logout URL: logging out
+// twice is not an error.
+func (s *OIDCService) EndSession(ctx context.Context, sessionToken, postLogoutRedirectURI string) (string, error) {
+	var idTokenHint string
+	if claims, err := s.parseSessionToken(sessionToken); err == nil {
+		session, err := s.findSession(ctx, claims)
+		if err != nil && !errors.Is(err, errSessionEnded) {
+			return "", err
+		}
+		if session != nil {
+			if _, err := s.sessionRepo.Revoke(ctx, session.ID, time.Now()); err != nil {
+				return "", fmt.Errorf("failed to revoke session: %w", err)
+			}
+			idTokenHint, _ = s.decryptSessionSecret(session.IDToken)
+			s.revokeAtProvider(ctx, session)
+		}
+	}
+
+	if !s.initialized {
+		return "", nil
+	}
+	return s.GetLogoutURL(idTokenHint, postLogoutRedirectURI)
+}
+
+// SessionCleanupTask returns the housekeeping task deleting the sessions
+// that ended, or nil if OIDC is disabled
+func (s *OIDCService) SessionCleanupTask() *HousekeepingTask {
+	if !s.config.Enabled {
+		return nil
+	}
+	return &HousekeepingTask{
+		Name:        "oidc.sessions",
+		Description: "Delete the sessions revoked, past oidc.sessions.absolute_lifetime or unused for oidc.sessions.idle_timeout",
+		Interval:    sessionCleanupInterval,
+		Jitter:      sessionCleanupJitter,
+		Run:         s.cleanupSessions,
+	}
+}
+
+// cleanupSessions deletes the sessions that ended
+func (s *OIDCService) cleanupSessions(ctx context.Context) error {
+	now := time.Now()
+	deleted, err := s.sessionRepo.DeleteInactive(ctx, now, now.Add(-s.config.Sessions.IdleTimeout()))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.log.WithContext(ctx).Info("Deleted ended sessions", logger.Int64("count", deleted))
+	}
+	return nil
+}
+
+// parseSessionToken returns the claims of a session token signed by this
+// server, expired or not
+func (s *OIDCService) parseSessionToken(tokenString string) (*SessionClaims, error) {
+	claims := &SessionClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(s.config.JWTSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithoutClaimsValidation())
+	if err != nil {
+		return nil, apperrors.Unauthorized("invalid session token", err)
+	}
+	return claims, nil
+}
+
+// findSession returns the session of session token claims, or
+// errSessionEnded if it no longer exists
+func (s *OIDCService) findSession(ctx context.Context, claims *SessionClaims) (*models.Session, error) {
+	// Tokens issued before sessions were stored carry no session
+	id, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, errSessionEnded
+	}
+	session, err := s.sessionRepo.FindByID(ctx, id)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil, errSessionEnded
+		}
+		return nil, fmt.Errorf("failed to find session: %w", err)
+	}
+	if session.UserID.String() != claims.UserID {
+		return nil, errSessionEnded
+	}
+	return session, nil
+}
+
+// activeSession returns the session of session token claims if it is active
+func (s *OIDCService) activeSession(ctx context.Context, claims *SessionClaims) (*models.Session, error) {
+	session, err := s.findSession(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if !session.IsActive(time.Now(), s.config.Sessions.IdleTimeout()) {
+		return nil, errSessionEnded
+	}
+	return session, nil
+}
+
+// refreshAtProvider refreshes a session at the identity provider with its
+// refresh token, if it has one, and stores the tokens issued in exchange. A
+// refusal of the identity provider revokes the session.
+func (s *OIDCService) refreshAtProvider(ctx context.Context, session *models.Session) error {
+	now := time.Now()
+	refreshToken, err := s.decryptSessionSecret(session.RefreshToken)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to decrypt the refresh token of a session, was oidc.sessions.encryption_key changed?",
+			logger.String("session_id", session.ID.String()),
+			logger.Error(err),
+		)
+		s.revokeSession(ctx, session)
+		return errSessionEnded
+	}
+	if refreshToken == "" || !s.initialized {
+		return s.sessionRepo.Touch(ctx, session.ID, now)
+	}
+
+	token, err := s.oauth2Cfg.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.Response != nil && retrieveErr.Response.StatusCode < http.StatusInternalServerError {
+			s.log.WithContext(ctx).Info("Identity provider refused to refresh a session, revoking it",
+				logger.String("session_id", session.ID.String()),
+				logger.String("user_id", session.UserID.String()),
+				logger.Error(err),
+			)
+			s.revokeSession(ctx, session)
+			return errSessionEnded
+		}
+		return apperrors.Unavailable("the identity provider could not be reached to refresh the session, try again shortly", err)
+	}
+
+	// Providers rotating refresh tokens invalidate the one just used
+	if token.RefreshToken != "" {
+		refreshToken = token.RefreshToken
+	}
+	idToken, _ := token.Extra("id_token").(string)
+	if idToken == "" {
+		idToken, _ = s.decryptSessionSecret(session.IDToken)
+	}
+
+	encryptedRefresh, err := s.encryptSessionSecret(refreshToken)
+	if err != nil {
+		return err
+	}
+	encryptedID, err := s.encryptSessionSecret(idToken)
+	if err != nil {
+		return err
+	}
+	return s.sessionRepo.UpdateTokens(ctx, session.ID, encryptedRefresh, encryptedID, now)
+}
+
+// revokeSession revokes a session that can no longer be refreshed
+func (s *OIDCService) revokeSession(ctx context.Context, session *models.Session) {
+	if _, err := s.sessionRepo.Revoke(ctx, session.ID, time.Now()); err != nil {
+		s.log.WithContext(ctx).Warn("Failed to revoke session",
+			logger.String("session_id", session.ID.String()),
+			logger.Error(err),
+		)
+	}
+}
+
+// revokeAtProvider revokes the refresh token of a session at the identity
+// provider (RFC 7009), if it advertises a revocation endpoint. It is best
+// effort: the session is revoked here either way.
+func (s *OIDCService) revokeAtProvider(ctx context.Context, session *models.Session) {
+	if !s.initialized {
+		return
+	}
+	refreshToken, err := s.decryptSessionSecret(session.RefreshToken)
+	if err != nil || refreshToken == "" {
+		return
+	}
+	var providerClaims struct {
+		RevocationEndpoint string `json:"revocation_endpoint"`
+	}
+	if err := s.provider.Claims(&providerClaims); err != nil || providerClaims.RevocationEndpoint == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sessionRevocationTimeout)
+	defer cancel()
+	form := url.Values{"token": {refreshToken}, "token_type_hint": {"refresh_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, providerClaims.RevocationEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to revoke a refresh token at the identity provider", logger.Error(err))
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		s.log.WithContext(ctx).Warn("Identity provider refused to revoke a refresh token", logger.Int("status", resp.StatusCode))
+	}
+}
+
+// sessionCipher returns the AEAD encrypting the secrets of sessions, keyed
+// with oidc.sessions.encryption_key
+func (s *OIDCService) sessionCipher() (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(s.config.Sessions.EncryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptSessionSecret encrypts a token of the identity provider for storage.
+// An empty token stays empty.
+func (s *OIDCService) encryptSessionSecret(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	aead, err := s.sessionCipher()
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt session secret: %w", err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to encrypt session secret: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSessionSecret decrypts a token stored by encryptSessionSecret
+func (s *OIDCService) decryptSessionSecret(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt session secret: %w", err)
+	}
+	aead, err := s.sessionCipher()
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt session secret: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("failed to decrypt session secret: too short")
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt session secret: %w", err)
+	}
+	return string(plaintext), nil
+}
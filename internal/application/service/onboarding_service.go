@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// onboardingTimeout bounds onboarding a user after their first sign-in
+const onboardingTimeout = 2 * time.Minute
+
+// OnboardingStatus is the onboarding state of a user
+type OnboardingStatus struct {
+	Enabled     bool
+	OnboardedAt *time.Time         // nil until the user is onboarded or skips it
+	Repository  *models.Repository // The playground repository, nil if it does not exist
+}
+
+// OnboardingService gives new users a private playground repository with a
+// README and a sample CI pipeline, on their first sign-in or when they ask
+// for it. Onboarding happens at most once per user: the user row records
+// when it completed or was skipped. Every step tolerates having run before,
+// so an interrupted onboarding is finished by the next attempt.
+type OnboardingService struct {
+	repoService  *RepoService
+	userRepo     repository.UserRepository
+	repoRepo     repository.RepoRepository
+	sshKeyRepo   repository.SSHKeyRepository
+	gitService   service.GitService
+	storage      *StorageBackendService
+	quotas       *QuotaService
+	publisher    events.Publisher
+	cfg          *config.OnboardingConfig
+	ciConfigPath string
+	log          *logger.Logger
+}
+
+// NewOnboardingService creates a new OnboardingService instance onboarding
+// users on the first sign-in published on subscriber
+func NewOnboardingService(
+	repoService *RepoService,
+	userRepo repository.UserRepository,
+	repoRepo repository.RepoRepository,
+	sshKeyRepo repository.SSHKeyRepository,
+	gitService service.GitService,
+	storage *StorageBackendService,
+	quotas *QuotaService,
+	publisher events.Publisher,
+	subscriber events.Subscriber,
+	cfg *config.OnboardingConfig,
+	ciConfigPath string,
+) *OnboardingService {
+	s := &OnboardingService{
+		repoService:  repoService,
+		userRepo:     userRepo,
+		repoRepo:     repoRepo,
+		sshKeyRepo:   sshKeyRepo,
+		gitService:   gitService,
+		storage:      storage,
+		quotas:       quotas,
+		publisher:    publisher,
+		cfg:          cfg,
+		ciConfigPath: ciConfigPath,
+		log:          logger.Get().WithFields(logger.Component("onboarding-service")),
+	}
+
+	if cfg.Enabled {
+		subscriber.Subscribe("onboarding", s.onboardOnSignIn, events.SubscribeOptions{
+			Types:      []string{events.TypeUserSignedIn},
+			MaxRetries: 3,
+		})
+	}
+
+	return s
+}
+
+// IsEnabled returns true if users are onboarded
+func (s *OnboardingService) IsEnabled() bool {
+	return s.cfg.Enabled
+}
+
+// Status returns the onboarding state of a user
+func (s *OnboardingService) Status(ctx context.Context, userID uuid.UUID) (*OnboardingStatus, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &OnboardingStatus{Enabled: s.cfg.Enabled, OnboardedAt: user.OnboardedAt}
+	if s.cfg.Enabled {
+		repo, err := s.repoRepo.FindByOwnerAndName(ctx, user.ID, s.cfg.RepoName)
+		if err != nil && !apperrors.IsNotFound(err) {
+			return nil, err
+		}
+		status.Repository = repo
+	}
+	return status, nil
+}
+
+// Onboard creates the playground repository of a user and marks them
+// onboarded. A user who is already onboarded gets their status unchanged, so
+// repeated calls never create a second repository or recreate a deleted one.
+func (s *OnboardingService) Onboard(ctx context.Context, userID uuid.UUID) (*OnboardingStatus, error) {
+	if !s.cfg.Enabled {
+		return nil, apperrors.NotFound("onboarding", apperrors.ErrNotFound)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.OnboardedAt != nil {
+		return s.Status(ctx, user.ID)
+	}
+
+	repo, err := s.provision(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	marked, err := s.userRepo.MarkOnboarded(ctx, user.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		// A concurrent attempt finished first and announced it
+		return s.Status(ctx, user.ID)
+	}
+
+	keys, err := s.sshKeyRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to count SSH keys of onboarded user",
+			logger.Error(err),
+			logger.String("user_id", user.ID.String()),
+		)
+	}
+	s.publisher.Publish(events.UserOnboarded{
+		UserID:       user.ID,
+		Username:     user.Username,
+		Email:        user.Email,
+		RepositoryID: repo.ID,
+		Repository:   repo.Name,
+		SSHKeyCount:  len(keys),
+	})
+
+	s.log.WithContext(ctx).Info("User onboarded",
+		logger.String("user_id", user.ID.String()),
+		logger.String("repository", user.Username+"/"+repo.Name),
+	)
+
+	return &OnboardingStatus{Enabled: true, OnboardedAt: &now, Repository: repo}, nil
+}
+
+// Skip marks a user onboarded without creating the playground repository
+func (s *OnboardingService) Skip(ctx context.Context, userID uuid.UUID) (*OnboardingStatus, error) {
+	if !s.cfg.Enabled {
+		return nil, apperrors.NotFound("onboarding", apperrors.ErrNotFound)
+	}
+
+	if _, err := s.userRepo.MarkOnboarded(ctx, userID, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.Status(ctx, userID)
+}
+
+// provision creates the playground repository of a user with its initial
+// commit. A repository or branch left by an interrupted attempt is reused.
+func (s *OnboardingService) provision(ctx context.Context, user *models.User) (*models.Repository, error) {
+	repo, err := s.repoService.CreateRepository(ctx, user.ID, s.cfg.RepoName, s.cfg.Description, true)
+	if apperrors.IsConflict(err) {
+		repo, err = s.repoRepo.FindByOwnerAndName(ctx, user.ID, s.cfg.RepoName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	repo.Owner = *user
+
+	branch := repo.DefaultBranch
+	if branch == "" {
+		branch = "main"
+	}
+
+	_, err = s.gitService.CreateInitialCommit(ctx, repo.GitPath, branch, s.files(user, repo),
+		"Initial commit", service.Signature{Name: user.Username, Email: user.Email})
+	if err != nil && !apperrors.IsConflict(err) {
+		return nil, apperrors.GitError("create initial commit", err)
+	}
+	if err := s.gitService.SetHEADBranch(ctx, repo.GitPath, branch); err != nil {
+		return nil, apperrors.GitError("set HEAD", err)
+	}
+
+	if backend, err := s.storage.ForRepo(repo); err == nil {
+		if err := backend.SyncToRemote(repo.GitPath); err != nil {
+			s.log.WithContext(ctx).Warn("Failed to sync playground repository to remote storage",
+				logger.Error(err),
+				logger.String("git_path", repo.GitPath),
+			)
+		}
+	}
+
+	// The playground counts toward the quota of its owner like any other
+	// repository
+	if err := s.quotas.RecordSize(ctx, repo); err != nil {
+		s.log.WithContext(ctx).Warn("Failed to record size of playground repository",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+	}
+
+	return repo, nil
+}
+
+// files returns the files of the initial commit of a playground repository
+func (s *OnboardingService) files(user *models.User, repo *models.Repository) map[string][]byte {
+	expand := strings.NewReplacer(
+		"{{username}}", user.Username,
+		"{{repository}}", repo.Name,
+	)
+
+	files := map[string][]byte{
+		"README.md": []byte(expand.Replace(s.cfg.Readme)),
+	}
+	if s.cfg.CIConfig != "" && s.ciConfigPath != "" {
+		files[s.ciConfigPath] = []byte(expand.Replace(s.cfg.CIConfig))
+	}
+	return files
+}
+
+// onboardOnSignIn onboards a user on their first sign-in. Users who already
+// own repositories predate onboarding and are only marked onboarded.
+func (s *OnboardingService) onboardOnSignIn(ctx context.Context, env events.Envelope) error {
+	signIn, ok := env.Event.(events.UserSignedIn)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, onboardingTimeout)
+	defer cancel()
+
+	user, err := s.userRepo.FindByID(ctx, signIn.UserID)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if user.OnboardedAt != nil {
+		return nil
+	}
+
+	count, err := s.repoRepo.CountByOwner(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		_, err := s.userRepo.MarkOnboarded(ctx, user.ID, time.Now())
+		return err
+	}
+
+	_, err = s.Onboard(ctx, user.ID)
+	return err
+}
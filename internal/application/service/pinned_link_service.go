@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// maxPinnedLinks is the maximum number of pinned links per repository
+	maxPinnedLinks = 10
+
+	// maxPinnedLinkTitleLength is the longest pinned link title allowed
+	maxPinnedLinkTitleLength = 100
+
+	// maxPinnedLinkTargetLength is the longest pinned link path or URL allowed
+	maxPinnedLinkTargetLength = 2048
+
+	// pinnedLinkCheckTimeout bounds the background check of pinned links after a push
+	pinnedLinkCheckTimeout = time.Minute
+)
+
+// PinnedLinkService manages the quick links shown on repository pages.
+// In-repository links must exist at the tip of the default branch when they
+// are saved; pushes changing their paths mark them broken, or fixed again.
+type PinnedLinkService struct {
+	repoRepo   repository.RepoRepository
+	gitService service.GitService
+	log        *logger.Logger
+}
+
+// NewPinnedLinkService creates a new PinnedLinkService instance
+func NewPinnedLinkService(repoRepo repository.RepoRepository, gitService service.GitService) *PinnedLinkService {
+	return &PinnedLinkService{
+		repoRepo:   repoRepo,
+		gitService: gitService,
+		log:        logger.Get().WithFields(logger.Component("pinned-link-service")),
+	}
+}
+
+// ReplacePinnedLinks validates links and replaces the pinned links of a
+// repository with them, in the given order
+func (s *PinnedLinkService) ReplacePinnedLinks(ctx context.Context, repo *models.Repository, links []models.PinnedLink) error {
+	if len(links) > maxPinnedLinks {
+		return apperrors.BadRequest(fmt.Sprintf("a repository can have at most %d pinned links", maxPinnedLinks), apperrors.ErrInvalidInput)
+	}
+
+	normalized := make([]models.PinnedLink, 0, len(links))
+	for _, link := range links {
+		link, err := normalizePinnedLink(link)
+		if err != nil {
+			return err
+		}
+		normalized = append(normalized, link)
+	}
+
+	if err := s.checkPathsExist(ctx, repo, normalized); err != nil {
+		return err
+	}
+
+	if err := s.repoRepo.UpdatePinnedLinks(ctx, repo.ID, normalized); err != nil {
+		s.log.WithContext(ctx).Error("Failed to update pinned links",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		return err
+	}
+	repo.PinnedLinks = normalized
+
+	s.log.WithContext(ctx).Info("Pinned links updated",
+		logger.String("repo_id", repo.ID.String()),
+		logger.Int("links", len(normalized)),
+	)
+	return nil
+}
+
+// checkPathsExist returns a bad request error if an in-repository link does
+// not name a file or directory at the tip of the default branch
+func (s *PinnedLinkService) checkPathsExist(ctx context.Context, repo *models.Repository, links []models.PinnedLink) error {
+	var branch *service.Branch
+	for _, link := range links {
+		if !link.IsInRepository() {
+			continue
+		}
+
+		if branch == nil {
+			var err error
+			branch, err = s.gitService.GetBranch(ctx, repo.GitPath, repo.DefaultBranch)
+			if err != nil {
+				return err
+			}
+			if branch == nil {
+				return apperrors.BadRequest(
+					fmt.Sprintf("pinned link paths need commits on the default branch %s", repo.DefaultBranch),
+					apperrors.ErrInvalidInput,
+				)
+			}
+		}
+
+		if !s.pathExists(ctx, repo, branch.Hash, link.Path) {
+			return apperrors.BadRequest(
+				fmt.Sprintf("pinned link path %q does not exist on the default branch %s", link.Path, repo.DefaultBranch),
+				apperrors.ErrInvalidInput,
+			)
+		}
+	}
+	return nil
+}
+
+// pathExists returns true if p names a file or directory of the commit
+func (s *PinnedLinkService) pathExists(ctx context.Context, repo *models.Repository, commit, p string) bool {
+	dir, name := path.Split(p)
+	entries, err := s.gitService.GetTree(ctx, repo.GitPath, commit, strings.TrimSuffix(dir, "/"))
+	if err != nil {
+		// The commit exists, so it is the parent directory that is missing
+		return false
+	}
+	for _, entry := range entries {
+		if entry.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckLinksAfterPush checks the in-repository links of a repository again in
+// the background if a push to the default branch changed their paths
+func (s *PinnedLinkService) CheckLinksAfterPush(repo *models.Repository, updates []service.RefUpdate) {
+	if !hasPathLinks(repo.PinnedLinks) {
+		return
+	}
+
+	for _, update := range updates {
+		if update.RefName != "refs/heads/"+repo.DefaultBranch || update.IsDelete() {
+			continue
+		}
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), pinnedLinkCheckTimeout)
+			defer cancel()
+
+			if !update.IsCreate() {
+				paths, err := s.gitService.GetChangedPaths(ctx, repo.GitPath, update.OldHash, update.NewHash)
+				if err != nil {
+					s.log.Warn("Failed to list paths changed by push",
+						logger.Error(err),
+						logger.String("repo_id", repo.ID.String()),
+					)
+					return
+				}
+				if !touchesPinnedLinks(repo.PinnedLinks, paths) {
+					return
+				}
+			}
+
+			if err := s.CheckLinks(ctx, repo, update.NewHash); err != nil {
+				s.log.Warn("Failed to check pinned links after push",
+					logger.Error(err),
+					logger.String("repo_id", repo.ID.String()),
+				)
+			}
+		}()
+		return
+	}
+}
+
+// CheckLinks marks the in-repository links of a repository broken if their
+// path does not exist at commit, the tip of the default branch, and fixed if
+// it does again. The links are reloaded so a concurrent replacement is not
+// overwritten with the links the push started with.
+func (s *PinnedLinkService) CheckLinks(ctx context.Context, repo *models.Repository, commit string) error {
+	current, err := s.repoRepo.FindByID(ctx, repo.ID)
+	if err != nil {
+		return err
+	}
+
+	links := current.PinnedLinks
+	changed := false
+	for i, link := range links {
+		if !link.IsInRepository() {
+			continue
+		}
+		broken := !s.pathExists(ctx, current, commit, link.Path)
+		if broken != link.Broken {
+			links[i].Broken = broken
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	if err := s.repoRepo.UpdatePinnedLinks(ctx, repo.ID, links); err != nil {
+		return err
+	}
+	repo.PinnedLinks = links
+
+	s.log.WithContext(ctx).Debug("Pinned link status updated",
+		logger.String("repo_id", repo.ID.String()),
+	)
+	return nil
+}
+
+// normalizePinnedLink validates a pinned link and cleans up its title and target
+func normalizePinnedLink(link models.PinnedLink) (models.PinnedLink, error) {
+	title := strings.TrimSpace(link.Title)
+	if title == "" {
+		return link, apperrors.BadRequest("pinned link title is required", apperrors.ErrInvalidInput)
+	}
+	if len(title) > maxPinnedLinkTitleLength {
+		return link, apperrors.BadRequest(
+			fmt.Sprintf("pinned link title must be at most %d characters", maxPinnedLinkTitleLength),
+			apperrors.ErrInvalidInput,
+		)
+	}
+
+	linkPath := strings.TrimSpace(link.Path)
+	linkURL := strings.TrimSpace(link.URL)
+	if (linkPath == "") == (linkURL == "") {
+		return link, apperrors.BadRequest(
+			fmt.Sprintf("pinned link %q needs either a path or a url", title),
+			apperrors.ErrInvalidInput,
+		)
+	}
+	if len(linkPath) > maxPinnedLinkTargetLength || len(linkURL) > maxPinnedLinkTargetLength {
+		return link, apperrors.BadRequest(
+			fmt.Sprintf("pinned link %q target must be at most %d characters", title, maxPinnedLinkTargetLength),
+			apperrors.ErrInvalidInput,
+		)
+	}
+
+	if linkURL != "" {
+		u, err := url.Parse(linkURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return link, apperrors.BadRequest(
+				fmt.Sprintf("pinned link %q url must be an absolute http or https URL", title),
+				apperrors.ErrInvalidInput,
+			)
+		}
+		return models.PinnedLink{Title: title, URL: linkURL}, nil
+	}
+
+	cleaned := path.Clean("/" + linkPath)[1:]
+	if cleaned == "" || slices.Contains(strings.Split(linkPath, "/"), "..") {
+		return link, apperrors.BadRequest(
+			fmt.Sprintf("pinned link %q path must name a file or directory of the repository", title),
+			apperrors.ErrInvalidInput,
+		)
+	}
+	return models.PinnedLink{Title: title, Path: cleaned}, nil
+}
+
+// hasPathLinks returns true if any of the links points into the repository
+func hasPathLinks(links []models.PinnedLink) bool {
+	for _, link := range links {
+		if link.IsInRepository() {
+			return true
+		}
+	}
+	return false
+}
+
+// touchesPinnedLinks returns true if a changed path is the path of an
+// in-repository link or lies below it
+func touchesPinnedLinks(links []models.PinnedLink, changed []string) bool {
+	for _, link := range links {
+		if !link.IsInRepository() {
+			continue
+		}
+		for _, p := range changed {
+			if p == link.Path || strings.HasPrefix(p, link.Path+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// maxPullRequestTitleLength is the longest pull request title allowed
+const maxPullRequestTitleLength = 255
+
+// PullRequestService manages pull requests between the branches of a
+// repository. Merging creates a merge commit in the repository and moves the
+// target branch to it, subject to the branch protections of the target.
+type PullRequestService struct {
+	prRepo     repository.PullRequestRepository
+	branches   *BranchProtectionService
+	gitService service.GitService
+	publisher  events.Publisher
+	log        *logger.Logger
+}
+
+// NewPullRequestService creates a new PullRequestService instance publishing
+// opened, merged and closed pull requests on publisher
+func NewPullRequestService(
+	prRepo repository.PullRequestRepository,
+	branches *BranchProtectionService,
+	gitService service.GitService,
+	publisher events.Publisher,
+) *PullRequestService {
+	return &PullRequestService{
+		prRepo:     prRepo,
+		branches:   branches,
+		gitService: gitService,
+		publisher:  publisher,
+		log:        logger.Get().WithFields(logger.Component("pull-request-service")),
+	}
+}
+
+// CreatePullRequest validates and opens a pull request of author
+func (s *PullRequestService) CreatePullRequest(ctx context.Context, repo *models.Repository, author *models.User, pr *models.PullRequest) error {
+	pr.Title = strings.TrimSpace(pr.Title)
+	if pr.Title == "" || len(pr.Title) > maxPullRequestTitleLength {
+		return apperrors.BadRequest(fmt.Sprintf("title must be 1 to %d characters", maxPullRequestTitleLength), apperrors.ErrInvalidInput)
+	}
+	if pr.SourceBranch == pr.TargetBranch {
+		return apperrors.BadRequest("source and target branches must differ", apperrors.ErrInvalidInput)
+	}
+	for _, branch := range []string{pr.SourceBranch, pr.TargetBranch} {
+		exists, err := s.gitService.BranchExists(ctx, repo.GitPath, branch)
+		if err != nil {
+			return apperrors.GitError("check branch", err)
+		}
+		if !exists {
+			return apperrors.BadRequest(fmt.Sprintf("branch %s does not exist", branch), apperrors.ErrBranchNotFound)
+		}
+	}
+
+	existing, err := s.prRepo.FindOpenByBranches(ctx, repo.ID, pr.SourceBranch, pr.TargetBranch)
+	if err != nil && !apperrors.IsNotFound(err) {
+		return err
+	}
+	if existing != nil {
+		return apperrors.Conflict(fmt.Sprintf("pull request #%d is already open for these branches", existing.Number), apperrors.ErrPullRequestExists)
+	}
+
+	pr.RepositoryID = repo.ID
+	pr.AuthorID = &author.ID
+	pr.State = models.PullRequestOpen
+	if err := s.prRepo.Create(ctx, pr); err != nil {
+		s.log.WithContext(ctx).Error("Failed to create pull request",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		return err
+	}
+	pr.Author = author
+
+	s.log.WithContext(ctx).Info("Pull request opened",
+		logger.String("repo_id", repo.ID.String()),
+		logger.Int("number", pr.Number),
+		logger.String("source", pr.SourceBranch),
+		logger.String("target", pr.TargetBranch),
+	)
+	s.publisher.Publish(events.PullRequestOpened{
+		PullRequestID: pr.ID,
+		RepositoryID:  repo.ID,
+		Number:        pr.Number,
+		AuthorID:      author.ID,
+		Author:        author.Username,
+		Title:         pr.Title,
+		Body:          pr.Body,
+	})
+	return nil
+}
+
+// ListPullRequests returns a page of the pull requests of a repository, newest
+// first, optionally only those in a state ("" for all), with the total number
+func (s *PullRequestService) ListPullRequests(ctx context.Context, repo *models.Repository, state string, limit, offset int) ([]*models.PullRequest, int64, error) {
+	return s.prRepo.ListByRepository(ctx, repo.ID, state, limit, offset)
+}
+
+// GetPullRequest returns a pull request of a repository by number
+func (s *PullRequestService) GetPullRequest(ctx context.Context, repo *models.Repository, number int) (*models.PullRequest, error) {
+	return s.prRepo.FindByNumber(ctx, repo.ID, number)
+}
+
+// ClosePullRequest closes an open pull request of repo without merging it
+func (s *PullRequestService) ClosePullRequest(ctx context.Context, repo *models.Repository, pr *models.PullRequest, closer *models.User) error {
+	if pr.State != models.PullRequestOpen {
+		return apperrors.Conflict("pull request is not open", apperrors.ErrPullRequestNotOpen)
+	}
+
+	now := time.Now()
+	pr.State = models.PullRequestClosed
+	pr.ClosedAt = &now
+	if err := s.prRepo.UpdateState(ctx, pr); err != nil {
+		return err
+	}
+
+	s.log.WithContext(ctx).Info("Pull request closed",
+		logger.String("repo_id", repo.ID.String()),
+		logger.Int("number", pr.Number),
+	)
+	s.publishClosed(repo, pr, closer)
+	return nil
+}
+
+// MergePullRequest merges the source branch of an open pull request into its
+// target with a merge commit authored by merger, and records the merge.
+// It returns a conflict error wrapping errors.ErrMergeConflict, with the
+// conflicting paths in its details, if the branches do not merge cleanly.
+func (s *PullRequestService) MergePullRequest(ctx context.Context, repo *models.Repository, pr *models.PullRequest, merger *models.User) error {
+	if pr.State != models.PullRequestOpen {
+		return apperrors.Conflict("pull request is not open", apperrors.ErrPullRequestNotOpen)
+	}
+
+	target, err := s.gitService.GetBranch(ctx, repo.GitPath, pr.TargetBranch)
+	if err != nil {
+		return apperrors.GitError("get target branch", err)
+	}
+	source, err := s.gitService.GetBranch(ctx, repo.GitPath, pr.SourceBranch)
+	if err != nil {
+		return apperrors.GitError("get source branch", err)
+	}
+	if target == nil || source == nil {
+		return apperrors.Conflict("the source or target branch no longer exists", apperrors.ErrBranchNotFound)
+	}
+
+	message := fmt.Sprintf("Merge pull request #%d from %s\n\n%s\n", pr.Number, pr.SourceBranch, pr.Title)
+	mergeHash, err := s.gitService.CreateMergeCommit(ctx, repo.GitPath, target.Hash, source.Hash, message,
+		service.Signature{Name: merger.Username, Email: merger.Email})
+	if err != nil {
+		return err
+	}
+
+	// The merge commit descends from the target, so only rules refusing any
+	// update of the branch (require_admin) apply
+	refName := branchRefPrefix + pr.TargetBranch
+	if check := s.branches.RefCommandCheck(ctx, repo, merger, "api"); check != nil {
+		for _, rejection := range check([]service.RefCommand{{OldHash: target.Hash, NewHash: mergeHash, RefName: refName}}) {
+			if !rejection.UnlessFastForward {
+				return apperrors.Forbidden(rejection.Reason, apperrors.ErrForbidden)
+			}
+		}
+	}
+
+	if err := s.gitService.UpdateRef(ctx, repo.GitPath, refName, mergeHash, target.Hash); err != nil {
+		if errors.Is(err, apperrors.ErrRefChanged) {
+			return apperrors.Conflict("the target branch changed during the merge, try again", err)
+		}
+		return apperrors.GitError("update target branch", err)
+	}
+
+	now := time.Now()
+	pr.State = models.PullRequestMerged
+	pr.MergedCommitSHA = mergeHash
+	pr.MergedByID = &merger.ID
+	pr.MergedBy = merger
+	pr.MergedAt = &now
+	pr.ClosedAt = &now
+	if err := s.prRepo.UpdateState(ctx, pr); err != nil {
+		// The target branch already moved: the merge happened even if its record failed
+		s.log.WithContext(ctx).Error("Failed to record pull request merge",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+			logger.Int("number", pr.Number),
+			logger.String("merge_commit", mergeHash),
+		)
+		return err
+	}
+
+	s.log.WithContext(ctx).Info("Pull request merged",
+		logger.String("repo_id", repo.ID.String()),
+		logger.Int("number", pr.Number),
+		logger.String("merge_commit", mergeHash),
+		logger.String("merged_by", merger.Username),
+	)
+	s.publishClosed(repo, pr, merger)
+	return nil
+}
+
+// publishClosed publishes that actor merged or closed a pull request
+func (s *PullRequestService) publishClosed(repo *models.Repository, pr *models.PullRequest, actor *models.User) {
+	s.publisher.Publish(events.PullRequestClosed{
+		PullRequestID: pr.ID,
+		RepositoryID:  repo.ID,
+		Number:        pr.Number,
+		AuthorID:      pr.AuthorID,
+		ActorID:       actor.ID,
+		Actor:         actor.Username,
+		Merged:        pr.State == models.PullRequestMerged,
+	})
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// pushAttemptWriteTimeout bounds the background write of a push attempt
+	pushAttemptWriteTimeout = 30 * time.Second
+
+	// pushAttemptCleanupInterval is the time between deletions of expired push attempts
+	pushAttemptCleanupInterval = time.Hour
+
+	// pushAttemptCleanupJitter spreads the deletions of expired push attempts
+	pushAttemptCleanupJitter = 5 * time.Minute
+)
+
+// PushAttemptService records pushes with their outcome and server-side output
+type PushAttemptService struct {
+	pushAttemptRepo repository.PushAttemptRepository
+	cfg             *config.PushAttemptsConfig
+	log             *logger.Logger
+}
+
+// NewPushAttemptService creates a new PushAttemptService instance
+func NewPushAttemptService(pushAttemptRepo repository.PushAttemptRepository, cfg *config.PushAttemptsConfig) *PushAttemptService {
+	return &PushAttemptService{
+		pushAttemptRepo: pushAttemptRepo,
+		cfg:             cfg,
+		log:             logger.Get().WithFields(logger.Component("push-attempts")),
+	}
+}
+
+// Record stores a push attempt in the background so it never delays the push.
+// failure is the server-side error of a push that did not complete, or nil;
+// refused ref updates are already part of the result. Pushes that requested
+// no ref updates are not recorded.
+func (s *PushAttemptService) Record(repo *models.Repository, pusher *models.User, transport string, startedAt time.Time, result *service.PushResult, failure error) {
+	if !s.cfg.Enabled || result == nil || len(result.Commands) == 0 {
+		return
+	}
+
+	attempt := s.buildAttempt(repo, pusher, transport, startedAt, result, failure)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), pushAttemptWriteTimeout)
+		defer cancel()
+
+		if err := s.pushAttemptRepo.Create(ctx, attempt); err != nil {
+			s.log.Warn("Failed to record push attempt",
+				logger.Error(err),
+				logger.String("repo_id", repo.ID.String()),
+				logger.String("outcome", attempt.Outcome),
+			)
+		}
+	}()
+}
+
+// buildAttempt builds the record of a push
+func (s *PushAttemptService) buildAttempt(repo *models.Repository, pusher *models.User, transport string, startedAt time.Time, result *service.PushResult, failure error) *models.PushAttempt {
+	attempt := &models.PushAttempt{
+		RepositoryID: repo.ID,
+		Pusher:       "anonymous",
+		Transport:    transport,
+		Outcome:      models.PushOutcomeRejected,
+		StartedAt:    startedAt,
+		FinishedAt:   time.Now(),
+	}
+	if pusher != nil {
+		attempt.PusherID = &pusher.ID
+		attempt.Pusher = pusher.Username
+	}
+	if failure == nil && result.Accepted() {
+		attempt.Outcome = models.PushOutcomeAccepted
+	}
+	if failure != nil {
+		attempt.Error = logger.Redact(failure.Error())
+	}
+
+	rejections := make(map[string]string)
+	for _, rejected := range result.RejectedCommands() {
+		rejections[rejected.RefName] = rejected.Reason
+	}
+
+	attempt.Refs = make([]models.PushedRef, 0, len(result.Commands))
+	for _, cmd := range result.Commands {
+		ref := models.PushedRef{
+			RefName: cmd.RefName,
+			OldHash: cmd.OldHash,
+			NewHash: cmd.NewHash,
+			Status:  "ok",
+		}
+		if reason, rejected := rejections[cmd.RefName]; rejected {
+			ref.Status = "rejected"
+			ref.Reason = reason
+		}
+		attempt.Refs = append(attempt.Refs, ref)
+	}
+
+	// Redact before cutting so a secret is never stored in part
+	output := logger.Redact(result.Output)
+	attempt.Output, attempt.OutputTruncated = truncateOutput(output, s.cfg.GetMaxOutputSize())
+	attempt.OutputTruncated = attempt.OutputTruncated || result.OutputTruncated
+	return attempt
+}
+
+// truncateOutput cuts output longer than limit bytes to its beginning and end,
+// where the summary and final errors of a push usually are
+func truncateOutput(output string, limit int) (string, bool) {
+	// PostgreSQL text cannot hold NUL bytes
+	output = strings.ReplaceAll(output, "\x00", "")
+	if len(output) <= limit {
+		return output, false
+	}
+
+	marker := fmt.Sprintf("\n[... %d bytes omitted ...]\n", len(output)-limit)
+	head := limit / 2
+	tail := limit - head
+	cut := output[:head] + marker + output[len(output)-tail:]
+	// The cuts may have split multi-byte characters
+	return strings.ToValidUTF8(cut, ""), true
+}
+
+// ListPushAttempts lists the push attempts of a repository, most recent
+// first, optionally only those with an outcome ("" for all)
+func (s *PushAttemptService) ListPushAttempts(ctx context.Context, repoID uuid.UUID, outcome string, limit, offset int) ([]*models.PushAttempt, error) {
+	return s.pushAttemptRepo.ListByRepository(ctx, repoID, outcome, limit, offset)
+}
+
+// CleanupTask returns the housekeeping task deleting push attempts older than
+// the retention period, or nil if push attempts are kept forever
+func (s *PushAttemptService) CleanupTask() *HousekeepingTask {
+	if s.cfg.Retention() == 0 {
+		return nil
+	}
+	return &HousekeepingTask{
+		Name:        "push_attempts.cleanup",
+		Description: "Delete push attempts older than push_attempts.retention_days",
+		Interval:    pushAttemptCleanupInterval,
+		Jitter:      pushAttemptCleanupJitter,
+		Run:         s.cleanup,
+	}
+}
+
+// cleanup deletes the push attempts older than the retention period
+func (s *PushAttemptService) cleanup(ctx context.Context) error {
+	deleted, err := s.pushAttemptRepo.DeleteBefore(ctx, time.Now().Add(-s.cfg.Retention()))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.log.WithContext(ctx).Info("Deleted expired push attempts", logger.Int64("count", deleted))
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// maxBlockedPaths is the maximum number of blocked path globs per push policy
+	maxBlockedPaths = 50
+
+	// maxCommitMessagePatternLength is the longest commit message pattern allowed
+	maxCommitMessagePatternLength = 512
+
+	// maxPushPolicyViolations bounds the violations a dry run returns
+	maxPushPolicyViolations = 100
+)
+
+// PushPolicyService manages the push policies of repositories: rules on file
+// sizes, paths, commit messages and force pushes every push must follow. The
+// git transports enforce the policy of a repository on each push, see
+// git.ReceiveOptions; a dry run evaluates a policy against a range of commits.
+type PushPolicyService struct {
+	repoRepo   repository.RepoRepository
+	gitService service.GitService
+	log        *logger.Logger
+}
+
+// NewPushPolicyService creates a new PushPolicyService instance
+func NewPushPolicyService(repoRepo repository.RepoRepository, gitService service.GitService) *PushPolicyService {
+	return &PushPolicyService{
+		repoRepo:   repoRepo,
+		gitService: gitService,
+		log:        logger.Get().WithFields(logger.Component("push-policy-service")),
+	}
+}
+
+// UpdatePushPolicy validates and replaces the push policy of a repository. A
+// policy without rules removes it.
+func (s *PushPolicyService) UpdatePushPolicy(ctx context.Context, repo *models.Repository, policy *models.PushPolicy) error {
+	policy, err := NormalizePushPolicy(policy)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repoRepo.UpdatePushPolicy(ctx, repo.ID, policy); err != nil {
+		s.log.WithContext(ctx).Error("Failed to update push policy",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		return err
+	}
+	repo.PushPolicy = policy
+
+	s.log.WithContext(ctx).Info("Push policy updated",
+		logger.String("repo_id", repo.ID.String()),
+		logger.Bool("enabled", policy != nil),
+	)
+	return nil
+}
+
+// DryRun evaluates policy, or the policy of the repository if nil, against a
+// push of head to ref over base, as if base were the current tip of ref. ref
+// may be a branch name or a full ref name; base may be empty for a new ref.
+func (s *PushPolicyService) DryRun(ctx context.Context, repo *models.Repository, policy *models.PushPolicy, ref, base, head string) ([]service.PushPolicyViolation, error) {
+	if policy == nil {
+		policy = repo.PushPolicy
+	} else {
+		var err error
+		if policy, err = NormalizePushPolicy(policy); err != nil {
+			return nil, err
+		}
+	}
+	if policy.IsZero() {
+		return []service.PushPolicyViolation{}, nil
+	}
+
+	if strings.TrimSpace(head) == "" {
+		return nil, apperrors.ValidationError("head", "the head revision is required")
+	}
+	if ref != "" && !strings.HasPrefix(ref, "refs/") {
+		ref = "refs/heads/" + ref
+	}
+
+	violations, err := s.gitService.CheckPushPolicy(ctx, repo.GitPath, policy, ref, base, head, maxPushPolicyViolations)
+	if err != nil {
+		return nil, apperrors.NotFound("revision", err)
+	}
+	if violations == nil {
+		violations = []service.PushPolicyViolation{}
+	}
+	return violations, nil
+}
+
+// NormalizePushPolicy trims and validates the rules of a policy. It returns
+// nil for a policy without rules.
+func NormalizePushPolicy(policy *models.PushPolicy) (*models.PushPolicy, error) {
+	if policy == nil {
+		return nil, nil
+	}
+
+	normalized := &models.PushPolicy{
+		MaxFileSize:          policy.MaxFileSize,
+		CommitMessagePattern: strings.TrimSpace(policy.CommitMessagePattern),
+		DenyNonFastForward:   policy.DenyNonFastForward,
+	}
+	if normalized.MaxFileSize < 0 {
+		return nil, apperrors.ValidationError("max_file_size", "the maximum file size cannot be negative")
+	}
+
+	if len(policy.BlockedPaths) > maxBlockedPaths {
+		return nil, apperrors.ValidationError("blocked_paths", fmt.Sprintf("at most %d blocked paths are allowed", maxBlockedPaths))
+	}
+	for _, pattern := range policy.BlockedPaths {
+		pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			return nil, apperrors.ValidationError("blocked_paths", "blocked paths cannot be empty")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, apperrors.ValidationError("blocked_paths", fmt.Sprintf("invalid blocked path %q", pattern))
+		}
+		if !slices.Contains(normalized.BlockedPaths, pattern) {
+			normalized.BlockedPaths = append(normalized.BlockedPaths, pattern)
+		}
+	}
+
+	if len(normalized.CommitMessagePattern) > maxCommitMessagePatternLength {
+		return nil, apperrors.ValidationError("commit_message_pattern", fmt.Sprintf("the commit message pattern must be at most %d characters", maxCommitMessagePatternLength))
+	}
+	if normalized.CommitMessagePattern != "" {
+		if _, err := regexp.Compile(normalized.CommitMessagePattern); err != nil {
+			return nil, apperrors.ValidationError("commit_message_pattern", fmt.Sprintf("invalid regular expression: %v", err))
+		}
+	}
+
+	if normalized.IsZero() {
+		return nil, nil
+	}
+	return normalized, nil
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// repoSizeRecordTimeout bounds the background measurement of a repository after a push
+const repoSizeRecordTimeout = 2 * time.Minute
+
+// RepoQuota describes the disk usage of a repository against its quota
+type RepoQuota struct {
+	SizeBytes  int64 // Disk usage measured after the last push
+	QuotaBytes int64 // Effective limit (0 = unlimited)
+	Override   bool  // The limit was set by an administrator
+}
+
+// QuotaService enforces the disk space quotas of repositories and of the
+// users owning them. A push is refused while the repository or its owner is
+// already over quota: the push crossing a quota is accepted, the next one is
+// not. Deletions are always allowed so owners can clean up.
+//
+// The size of a repository is measured on its storage backend before each
+// push and recorded after it, so the total of an owner is a sum over the
+// database instead of a walk of every repository.
+type QuotaService struct {
+	repoRepo repository.RepoRepository
+	storage  *StorageBackendService
+	audit    service.AuditRecorder
+	cfg      *config.ReposConfig
+	log      *logger.Logger
+}
+
+// NewQuotaService creates a new QuotaService instance
+func NewQuotaService(
+	repoRepo repository.RepoRepository,
+	storage *StorageBackendService,
+	audit service.AuditRecorder,
+	cfg *config.ReposConfig,
+) *QuotaService {
+	return &QuotaService{
+		repoRepo: repoRepo,
+		storage:  storage,
+		audit:    audit,
+		cfg:      cfg,
+		log:      logger.Get().WithFields(logger.Component("quota-service")),
+	}
+}
+
+// Quota returns the recorded disk usage of a repository and its effective quota
+func (s *QuotaService) Quota(repo *models.Repository) RepoQuota {
+	quota := RepoQuota{SizeBytes: repo.SizeBytes, QuotaBytes: s.cfg.RepoQuota}
+	if repo.QuotaBytes != nil {
+		quota.QuotaBytes = *repo.QuotaBytes
+		quota.Override = true
+	}
+	return quota
+}
+
+// RefCommandCheck returns a push check refusing every ref update but
+// deletions while the repository or its owner is over quota, or nil if no
+// quota applies. An administrator override replaces both quotas for the
+// repository. transport names the push channel (http, ssh) for the logs.
+func (s *QuotaService) RefCommandCheck(ctx context.Context, repo *models.Repository, transport string) service.RefCommandCheck {
+	quota := s.Quota(repo)
+	userQuota := s.cfg.UserQuota
+	if quota.Override {
+		userQuota = 0
+	}
+	if quota.QuotaBytes <= 0 && userQuota <= 0 {
+		return nil
+	}
+
+	return func(commands []service.RefCommand) []service.RefRejection {
+		reason := s.overQuota(ctx, repo, quota.QuotaBytes, userQuota)
+		if reason == "" {
+			return nil
+		}
+
+		var rejections []service.RefRejection
+		for _, cmd := range commands {
+			if !cmd.IsDelete() {
+				rejections = append(rejections, service.RefRejection{RefName: cmd.RefName, Reason: reason})
+			}
+		}
+		if len(rejections) > 0 {
+			s.log.WithContext(ctx).Info("Push rejected by size quota",
+				logger.String("repo_id", repo.ID.String()),
+				logger.String("transport", transport),
+				logger.String("reason", reason),
+			)
+		}
+		return rejections
+	}
+}
+
+// overQuota returns why the repository may not grow, or "" if it may. The
+// repository is measured afresh; the other repositories of the owner are
+// counted with their recorded size.
+func (s *QuotaService) overQuota(ctx context.Context, repo *models.Repository, repoQuota, userQuota int64) string {
+	size := s.measure(repo)
+
+	if repoQuota > 0 && size >= repoQuota {
+		return fmt.Sprintf("repository is over its size quota of %d bytes", repoQuota)
+	}
+
+	if userQuota > 0 {
+		total, err := s.repoRepo.SumSizeByOwner(ctx, repo.OwnerID)
+		if err != nil {
+			// The push is not refused because the database is unavailable
+			s.log.WithContext(ctx).Warn("Failed to sum repository sizes of owner",
+				logger.Error(err),
+				logger.String("owner_id", repo.OwnerID.String()),
+			)
+			return ""
+		}
+		if total-repo.SizeBytes+size >= userQuota {
+			return fmt.Sprintf("owner is over their size quota of %d bytes", userQuota)
+		}
+	}
+	return ""
+}
+
+// Headroom returns how many bytes a repository may still grow by before it
+// or its owner reaches their quota, measuring the repository afresh, and
+// false if no quota applies. Unlike pushes, which are refused only once a
+// quota is reached, uploads of known size are held to it.
+func (s *QuotaService) Headroom(ctx context.Context, repo *models.Repository) (int64, bool) {
+	quota := s.Quota(repo)
+	userQuota := s.cfg.UserQuota
+	if quota.Override {
+		userQuota = 0
+	}
+	if quota.QuotaBytes <= 0 && userQuota <= 0 {
+		return 0, false
+	}
+
+	size := s.measure(repo)
+	headroom := int64(math.MaxInt64)
+	if quota.QuotaBytes > 0 {
+		headroom = quota.QuotaBytes - size
+	}
+	if userQuota > 0 {
+		total, err := s.repoRepo.SumSizeByOwner(ctx, repo.OwnerID)
+		if err != nil {
+			// Uploads are not refused because the database is unavailable
+			s.log.WithContext(ctx).Warn("Failed to sum repository sizes of owner",
+				logger.Error(err),
+				logger.String("owner_id", repo.OwnerID.String()),
+			)
+		} else {
+			headroom = min(headroom, userQuota-(total-repo.SizeBytes+size))
+		}
+	}
+	return max(headroom, 0), true
+}
+
+// measure returns the disk usage of a repository, or its recorded size if
+// the storage backend cannot tell
+func (s *QuotaService) measure(repo *models.Repository) int64 {
+	backend, err := s.storage.ForRepo(repo)
+	if err == nil {
+		var size int64
+		if size, err = backend.GetDiskUsage(repo.GitPath); err == nil {
+			return size
+		}
+	}
+	s.log.Warn("Failed to measure repository size",
+		logger.Error(err),
+		logger.String("repo_id", repo.ID.String()),
+	)
+	return repo.SizeBytes
+}
+
+// RecordSizeAsync measures a repository after a push and records its size in
+// the background so it never delays the push
+func (s *QuotaService) RecordSizeAsync(repo *models.Repository) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), repoSizeRecordTimeout)
+		defer cancel()
+
+		if err := s.RecordSize(ctx, repo); err != nil {
+			s.log.Warn("Failed to record repository size after push",
+				logger.Error(err),
+				logger.String("repo_id", repo.ID.String()),
+			)
+		}
+	}()
+}
+
+// RecordSize measures a repository on its storage backend and records its size
+func (s *QuotaService) RecordSize(ctx context.Context, repo *models.Repository) error {
+	backend, err := s.storage.ForRepo(repo)
+	if err != nil {
+		return err
+	}
+	size, err := backend.GetDiskUsage(repo.GitPath)
+	if err != nil {
+		return err
+	}
+	if err := s.repoRepo.UpdateSize(ctx, repo.ID, size); err != nil {
+		return err
+	}
+	repo.SizeBytes = size
+	return nil
+}
+
+// SetQuota sets the quota override of a repository; nil removes it so the
+// configured quota applies again, 0 lifts the limit
+func (s *QuotaService) SetQuota(ctx context.Context, repoID uuid.UUID, quotaBytes *int64, actor *models.User) (*models.Repository, error) {
+	if quotaBytes != nil && *quotaBytes < 0 {
+		return nil, apperrors.BadRequest("quota_bytes must not be negative", apperrors.ErrInvalidInput)
+	}
+
+	repo, err := s.repoRepo.FindByID(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repoRepo.UpdateQuota(ctx, repo.ID, quotaBytes); err != nil {
+		return nil, err
+	}
+	repo.QuotaBytes = quotaBytes
+
+	quota := "default"
+	if quotaBytes != nil {
+		quota = strconv.FormatInt(*quotaBytes, 10)
+	}
+	entry := service.AuditEntry{
+		Category: "admin",
+		Action:   "repository.quota_update",
+		Resource: repo.GetFullName(),
+		Outcome:  "success",
+		Fields:   map[string]string{"quota_bytes": quota},
+	}
+	if actor != nil {
+		entry.ActorID = actor.ID.String()
+		entry.Actor = actor.Username
+	}
+	s.audit.Record(entry)
+
+	s.log.WithContext(ctx).Info("Repository quota updated",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("quota_bytes", quota),
+	)
+	return repo, nil
+}
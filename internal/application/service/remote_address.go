@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+)
+
+// cgnatPrefix is the shared address space of carrier-grade NAT, which is
+// not reachable from the internet either
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// lookupRemoteHost resolves the host of a remote git URL
+var lookupRemoteHost = net.DefaultResolver.LookupNetIP
+
+// isPubliclyRoutable reports whether addr can be reached from the internet:
+// loopback, private, link-local, carrier-grade NAT and unspecified addresses
+// cannot
+func isPubliclyRoutable(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !cgnatPrefix.Contains(addr)
+}
+
+// checkRemoteHost resolves the host of a remote git URL and fails unless
+// every address it resolves to is publicly routable, so the server cannot
+// be made to clone from itself or the services next to it. The host may
+// resolve differently later, so clones are checked again with
+// publicDialControl.
+func checkRemoteHost(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("URL %q has no host", rawURL)
+	}
+
+	addrs, err := lookupRemoteHost(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !isPubliclyRoutable(addr) {
+			return fmt.Errorf("remote address %s of %s is not publicly routable", addr.Unmap(), host)
+		}
+	}
+	return nil
+}
+
+// checkRemoteScheme fails unless a remote git URL is http or https: only
+// those connections go through publicDialControl, while git and ssh remotes
+// would be dialed without it
+func checkRemoteScheme(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("URL scheme %q is not http or https", u.Scheme)
+	}
+	return nil
+}
+
+// publicDialControl returns a dialer control refusing connections to
+// addresses that are not publicly routable, or nil if private addresses are
+// allowed. It checks the address being connected to, after name resolution,
+// so a name resolving, or rebinding, to an internal address is refused too,
+// and so is a redirect to one.
+func publicDialControl(allowPrivate bool) service.DialControl {
+	if allowPrivate {
+		return nil
+	}
+	return func(network, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		addr, err := netip.ParseAddr(host)
+		if err != nil {
+			return err
+		}
+		if !isPubliclyRoutable(addr) {
+			return fmt.Errorf("address %s is not publicly routable", addr.Unmap())
+		}
+		return nil
+	}
+}
@@ -0,0 +1,439 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// maxBulkRepositories is the most repositories a bulk operation may apply to
+	maxBulkRepositories = 1000
+
+	// bulkPollInterval is the time between checks for pending bulk tasks
+	bulkPollInterval = time.Minute
+
+	// bulkCleanupInterval is the time between deletions of expired bulk tasks
+	bulkCleanupInterval = time.Hour
+
+	// bulkCleanupJitter spreads the deletions of expired bulk tasks
+	bulkCleanupJitter = 5 * time.Minute
+
+	// bulkRepoTimeout bounds the operation on one repository of a bulk task
+	bulkRepoTimeout = 5 * time.Minute
+)
+
+// BulkOperation describes an administrative operation on many repositories,
+// named either one by one or by a filter
+type BulkOperation struct {
+	Operation    string   // One of the models.RepoBulk* operations
+	IsPrivate    *bool    // Visibility to set, for set_visibility
+	NewOwner     string   // Username to transfer to, for transfer
+	Repositories []string // owner/name of each repository
+	Filter       *BulkFilter
+}
+
+// BulkFilter selects the repositories of an owner, optionally only those with
+// an annotation or license
+type BulkFilter struct {
+	Owner           string
+	AnnotationKey   string
+	AnnotationValue string
+	License         *string
+}
+
+// RepoBulkService applies administrative operations to many repositories in
+// the background. Each repository goes through the same service calls, locks
+// and audit events as the single-repository endpoints; a failure is recorded
+// for that repository and the others are still processed.
+type RepoBulkService struct {
+	taskRepo    repository.RepoBulkTaskRepository
+	repoRepo    repository.RepoRepository
+	userRepo    repository.UserRepository
+	repoService *RepoService
+	auditEvents *AuditEventService
+	cfg         *config.ReposConfig
+	wake        chan struct{}
+	log         *logger.Logger
+}
+
+// NewRepoBulkService creates a new RepoBulkService instance
+func NewRepoBulkService(
+	taskRepo repository.RepoBulkTaskRepository,
+	repoRepo repository.RepoRepository,
+	userRepo repository.UserRepository,
+	repoService *RepoService,
+	auditEvents *AuditEventService,
+	cfg *config.ReposConfig,
+) *RepoBulkService {
+	return &RepoBulkService{
+		taskRepo:    taskRepo,
+		repoRepo:    repoRepo,
+		userRepo:    userRepo,
+		repoService: repoService,
+		auditEvents: auditEvents,
+		cfg:         cfg,
+		wake:        make(chan struct{}, 1),
+		log:         logger.Get().WithFields(logger.Component("repo-bulk-service")),
+	}
+}
+
+// Plan validates a bulk operation and resolves the repositories it applies
+// to, without changing anything. Named repositories that do not exist are
+// recorded as failed results of the returned task, which is not yet stored.
+func (s *RepoBulkService) Plan(ctx context.Context, op BulkOperation) (*models.RepoBulkTask, error) {
+	task := &models.RepoBulkTask{
+		Operation: op.Operation,
+		Targets:   []models.RepoBulkTarget{},
+		Results:   []models.RepoBulkResult{},
+	}
+
+	switch op.Operation {
+	case models.RepoBulkSetVisibility:
+		if op.IsPrivate == nil {
+			return nil, apperrors.BadRequest("is_private is required to set the visibility", apperrors.ErrInvalidInput)
+		}
+		task.IsPrivate = op.IsPrivate
+	case models.RepoBulkTransfer:
+		if op.NewOwner == "" {
+			return nil, apperrors.BadRequest("new_owner is required to transfer repositories", apperrors.ErrInvalidInput)
+		}
+		owner, err := s.userRepo.FindByUsername(ctx, op.NewOwner)
+		if err != nil {
+			if apperrors.IsNotFound(err) {
+				return nil, apperrors.BadRequest(fmt.Sprintf("user %q does not exist", op.NewOwner), apperrors.ErrInvalidInput)
+			}
+			return nil, err
+		}
+		task.NewOwnerID = &owner.ID
+	case models.RepoBulkDelete:
+	default:
+		return nil, apperrors.BadRequest(
+			fmt.Sprintf("operation must be one of %s, %s, %s", models.RepoBulkSetVisibility, models.RepoBulkTransfer, models.RepoBulkDelete),
+			apperrors.ErrInvalidInput,
+		)
+	}
+
+	if (len(op.Repositories) == 0) == (op.Filter == nil) {
+		return nil, apperrors.BadRequest("either repositories or a filter is required", apperrors.ErrInvalidInput)
+	}
+
+	var err error
+	if op.Filter != nil {
+		task.Targets, err = s.resolveFilter(ctx, op.Filter)
+	} else {
+		task.Targets, task.Results, err = s.resolveNames(ctx, op.Repositories)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(task.Targets)+len(task.Results) > maxBulkRepositories {
+		return nil, apperrors.BadRequest(
+			fmt.Sprintf("a bulk operation can apply to at most %d repositories", maxBulkRepositories),
+			apperrors.ErrInvalidInput,
+		)
+	}
+	if len(task.Targets) == 0 && len(task.Results) == 0 {
+		return nil, apperrors.BadRequest("no repositories match the filter", apperrors.ErrInvalidInput)
+	}
+	return task, nil
+}
+
+// resolveFilter returns the repositories matching a filter
+func (s *RepoBulkService) resolveFilter(ctx context.Context, filter *BulkFilter) ([]models.RepoBulkTarget, error) {
+	if filter.Owner == "" {
+		return nil, apperrors.BadRequest("filter owner is required", apperrors.ErrInvalidInput)
+	}
+	if filter.AnnotationValue != "" && filter.AnnotationKey == "" {
+		return nil, apperrors.BadRequest("filter annotation_value needs an annotation_key", apperrors.ErrInvalidInput)
+	}
+
+	owner, err := s.userRepo.FindByUsername(ctx, filter.Owner)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil, apperrors.BadRequest(fmt.Sprintf("user %q does not exist", filter.Owner), apperrors.ErrInvalidInput)
+		}
+		return nil, err
+	}
+
+	repos, err := s.repoRepo.FindByOwnerFiltered(ctx, owner.ID, repository.RepoFilter{
+		AnnotationKey:   filter.AnnotationKey,
+		AnnotationValue: filter.AnnotationValue,
+		License:         filter.License,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]models.RepoBulkTarget, 0, len(repos))
+	for _, repo := range repos {
+		targets = append(targets, models.RepoBulkTarget{ID: repo.ID, FullName: repo.GetFullName()})
+	}
+	return targets, nil
+}
+
+// resolveNames looks up repositories given as owner/name, once each. Names
+// that do not resolve are returned as failed results.
+func (s *RepoBulkService) resolveNames(ctx context.Context, names []string) ([]models.RepoBulkTarget, []models.RepoBulkResult, error) {
+	if len(names) > maxBulkRepositories {
+		return nil, nil, apperrors.BadRequest(
+			fmt.Sprintf("a bulk operation can apply to at most %d repositories", maxBulkRepositories),
+			apperrors.ErrInvalidInput,
+		)
+	}
+
+	var targets []models.RepoBulkTarget
+	var missing []models.RepoBulkResult
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSuffix(strings.TrimSpace(name), ".git")
+		if seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+
+		owner, repoName, ok := strings.Cut(name, "/")
+		if !ok || owner == "" || repoName == "" || strings.Contains(repoName, "/") {
+			return nil, nil, apperrors.BadRequest(fmt.Sprintf("%q is not an owner/name pair", name), apperrors.ErrInvalidInput)
+		}
+
+		repo, err := s.repoRepo.FindByOwnerUsernameAndName(ctx, owner, repoName)
+		if err != nil {
+			if !apperrors.IsNotFound(err) {
+				return nil, nil, err
+			}
+			missing = append(missing, models.RepoBulkResult{
+				FullName: name,
+				Outcome:  models.RepoBulkResultFailed,
+				Error:    "repository not found",
+			})
+			continue
+		}
+		targets = append(targets, models.RepoBulkTarget{ID: repo.ID, FullName: repo.GetFullName()})
+	}
+	return targets, missing, nil
+}
+
+// Submit stores a planned task and queues it for the bulk worker
+func (s *RepoBulkService) Submit(ctx context.Context, task *models.RepoBulkTask, actor *models.User, ip string) error {
+	task.Status = models.RepoBulkTaskPending
+	task.IP = ip
+	if actor != nil {
+		task.RequestedByID = &actor.ID
+		task.RequestedBy = actor.Username
+	}
+	if err := s.taskRepo.Create(ctx, task); err != nil {
+		return err
+	}
+
+	// Wake the worker; a wake-up already pending covers this task too
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	s.log.WithContext(ctx).Info("Repository bulk task queued",
+		logger.String("task_id", task.ID.String()),
+		logger.String("operation", task.Operation),
+		logger.Int("repositories", len(task.Targets)),
+		logger.String("requested_by", task.RequestedBy),
+	)
+	return nil
+}
+
+// GetTask returns a bulk task with the results recorded so far
+func (s *RepoBulkService) GetTask(ctx context.Context, id uuid.UUID) (*models.RepoBulkTask, error) {
+	return s.taskRepo.FindByID(ctx, id)
+}
+
+// RunWorker processes pending tasks, oldest first, when a task is queued and
+// every minute until ctx is done. Tasks interrupted by a restart resume with
+// the repositories that have no result yet.
+func (s *RepoBulkService) RunWorker(ctx context.Context) {
+	interrupted, err := s.taskRepo.ListByStatus(ctx, models.RepoBulkTaskRunning)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to list interrupted bulk tasks", logger.Error(err))
+	}
+	for _, task := range interrupted {
+		task.Status = models.RepoBulkTaskPending
+		if err := s.taskRepo.Update(ctx, task); err != nil {
+			s.log.WithContext(ctx).Warn("Failed to requeue interrupted bulk task",
+				logger.Error(err),
+				logger.String("task_id", task.ID.String()),
+			)
+		}
+	}
+
+	ticker := time.NewTicker(bulkPollInterval)
+	defer ticker.Stop()
+
+	for {
+		pending, err := s.taskRepo.ListByStatus(ctx, models.RepoBulkTaskPending)
+		if err != nil {
+			s.log.WithContext(ctx).Warn("Failed to list pending bulk tasks", logger.Error(err))
+		}
+		for _, task := range pending {
+			if ctx.Err() != nil {
+				return
+			}
+			s.process(ctx, task)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// process applies a task to each of its repositories without a result,
+// saving the results as it goes
+func (s *RepoBulkService) process(ctx context.Context, task *models.RepoBulkTask) {
+	log := s.log.WithFields(logger.String("task_id", task.ID.String()))
+
+	task.Status = models.RepoBulkTaskRunning
+	if err := s.taskRepo.Update(ctx, task); err != nil {
+		log.Warn("Failed to start bulk task", logger.Error(err))
+		return
+	}
+
+	for _, target := range task.Targets {
+		if ctx.Err() != nil {
+			return
+		}
+		done := slices.ContainsFunc(task.Results, func(r models.RepoBulkResult) bool {
+			return r.RepositoryID != nil && *r.RepositoryID == target.ID
+		})
+		if done {
+			continue
+		}
+
+		result := models.RepoBulkResult{RepositoryID: &target.ID, FullName: target.FullName, Outcome: models.RepoBulkResultSucceeded}
+		if err := s.apply(ctx, task, target); err != nil {
+			log.Warn("Bulk operation failed on repository",
+				logger.Error(err),
+				logger.String("operation", task.Operation),
+				logger.String("repo_id", target.ID.String()),
+			)
+			result.Outcome = models.RepoBulkResultFailed
+			result.Error = err.Error()
+		}
+		task.Results = append(task.Results, result)
+
+		if err := s.taskRepo.Update(ctx, task); err != nil {
+			log.Warn("Failed to save bulk task progress", logger.Error(err))
+		}
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.BulkTaskRetention())
+	task.Status = models.RepoBulkTaskCompleted
+	task.CompletedAt = &now
+	task.ExpiresAt = &expiresAt
+	if err := s.taskRepo.Update(ctx, task); err != nil {
+		log.Warn("Failed to save bulk task result", logger.Error(err))
+		return
+	}
+
+	failed := 0
+	for _, result := range task.Results {
+		if result.Outcome == models.RepoBulkResultFailed {
+			failed++
+		}
+	}
+	log.Info("Repository bulk task completed",
+		logger.String("operation", task.Operation),
+		logger.Int("repositories", len(task.Results)),
+		logger.Int("failed", failed),
+	)
+}
+
+// apply runs the operation of a task on one repository and records an audit
+// event for it, as the single-repository endpoints do
+func (s *RepoBulkService) apply(ctx context.Context, task *models.RepoBulkTask, target models.RepoBulkTarget) error {
+	ctx, cancel := context.WithTimeout(ctx, bulkRepoTimeout)
+	defer cancel()
+
+	repo, err := s.repoRepo.FindByID(ctx, target.ID)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return fmt.Errorf("repository not found")
+		}
+		return err
+	}
+
+	metadata := map[string]any{
+		"repository":   repo.GetFullName(),
+		"bulk_task_id": task.ID.String(),
+	}
+	var action string
+	switch task.Operation {
+	case models.RepoBulkSetVisibility:
+		if _, err := s.repoService.UpdateRepository(ctx, repo.ID, nil, task.IsPrivate, nil, nil); err != nil {
+			return err
+		}
+		action = models.AuditActionRepoVisibility
+		metadata["is_private"] = *task.IsPrivate
+	case models.RepoBulkTransfer:
+		transferred, err := s.repoService.TransferRepository(ctx, repo.ID, *task.NewOwnerID)
+		if err != nil {
+			return err
+		}
+		action = models.AuditActionRepoTransfer
+		metadata["new_owner"] = transferred.Owner.Username
+	case models.RepoBulkDelete:
+		if err := s.repoService.DeleteRepository(ctx, repo.ID); err != nil {
+			return err
+		}
+		action = models.AuditActionRepoDelete
+	default:
+		return fmt.Errorf("unknown bulk operation %q", task.Operation)
+	}
+
+	s.auditEvents.Record(&models.AuditEvent{
+		ActorID:      task.RequestedByID,
+		Actor:        task.RequestedBy,
+		RepositoryID: &repo.ID,
+		Action:       action,
+		Metadata:     metadata,
+		IP:           task.IP,
+	})
+	return nil
+}
+
+// CleanupTask returns the housekeeping task deleting bulk tasks past their
+// retention period
+func (s *RepoBulkService) CleanupTask() *HousekeepingTask {
+	return &HousekeepingTask{
+		Name:        "repo_bulk_tasks.cleanup",
+		Description: "Delete bulk repository tasks finished more than repos.bulk_task_retention_days ago",
+		Interval:    bulkCleanupInterval,
+		Jitter:      bulkCleanupJitter,
+		Run:         s.cleanup,
+	}
+}
+
+// cleanup deletes the bulk tasks that expired by now
+func (s *RepoBulkService) cleanup(ctx context.Context) error {
+	deleted, err := s.taskRepo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.log.WithContext(ctx).Info("Deleted expired bulk tasks", logger.Int64("count", deleted))
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// generatedFile is the content of a large file, generated as it is read,
+// counting the bytes read
+type generatedFile struct {
+	size   int64
+	offset int64
+}
+
+func (f *generatedFile) Read(p []byte) (int, error) {
+	if f.offset >= f.size {
+		return 0, io.EOF
+	}
+	n := int(min(int64(len(p)), f.size-f.offset))
+	for i := range n {
+		p[i] = 'a' + byte((f.offset+int64(i))%26)
+	}
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *generatedFile) Seek(int64, int) (int64, error) { return 0, io.ErrUnexpectedEOF }
+func (f *generatedFile) Close() error                   { return nil }
+
+// fakeFileGit opens the files it was given
+type fakeFileGit struct {
+	service.GitService
+	files map[string]io.ReadSeekCloser
+	sizes map[string]int64
+}
+
+func (g *fakeFileGit) OpenFile(_ context.Context, _, _, filePath string) (*service.FileBlob, error) {
+	content, ok := g.files[filePath]
+	if !ok {
+		return nil, apperrors.NotFound("file", apperrors.ErrNotFound)
+	}
+	return &service.FileBlob{Path: filePath, Name: filePath, Size: g.sizes[filePath], Content: content}, nil
+}
+
+// nopSeekCloser makes a bytes.Reader an io.ReadSeekCloser
+type nopSeekCloser struct{ *bytes.Reader }
+
+func (nopSeekCloser) Close() error { return nil }
+
+func TestGetFileContent(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+	latin1 := []byte("caf\xe9 cr\xe8me\n")
+	large := &generatedFile{size: 100 << 20}
+
+	git := &fakeFileGit{files: map[string]io.ReadSeekCloser{}, sizes: map[string]int64{}}
+	add := func(name string, content []byte) {
+		git.files[name] = nopSeekCloser{bytes.NewReader(content)}
+		git.sizes[name] = int64(len(content))
+	}
+	add("empty.txt", nil)
+	add("README.md", []byte("# demo\n"))
+	add("logo.png", png)
+	add("latin1.txt", latin1)
+	git.files["dump.bin"], git.sizes["dump.bin"] = large, large.size
+
+	s := NewRepoService(&fakeRepoRepo{}, &fakeUserRepo{}, fakeCollaboratorRepo{}, git, nil, &config.ReposConfig{MaxInlineFileSize: 1 << 20}, &fakeBus{})
+	repo := &models.Repository{GitPath: "/repos/owner/app.git"}
+
+	tests := []struct {
+		path      string
+		size      int64
+		binary    bool
+		truncated bool
+		encoding  string
+		content   string // In the response
+	}{
+		{path: "empty.txt", encoding: "utf-8"},
+		{path: "README.md", size: 7, encoding: "utf-8", content: "# demo\n"},
+		{path: "logo.png", size: int64(len(png)), binary: true, encoding: "base64", content: base64.StdEncoding.EncodeToString(png)},
+		{path: "latin1.txt", size: int64(len(latin1)), binary: true, encoding: "base64", content: base64.StdEncoding.EncodeToString(latin1)},
+		{path: "dump.bin", size: 100 << 20, truncated: true, encoding: "utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			file, err := s.GetFileContent(context.Background(), repo, "main", tt.path)
+			if err != nil {
+				t.Fatalf("GetFileContent: %v", err)
+			}
+			if file.Size != tt.size || file.IsBinary != tt.binary || file.Truncated != tt.truncated || file.Encoding != tt.encoding {
+				t.Errorf("size %d, binary %v, truncated %v, encoding %q; want %d, %v, %v, %q",
+					file.Size, file.IsBinary, file.Truncated, file.Encoding, tt.size, tt.binary, tt.truncated, tt.encoding)
+			}
+			if response := dto.FileContentFromService(file, "main"); response.Content != tt.content {
+				t.Errorf("content = %q, want %q", response.Content, tt.content)
+			}
+		})
+	}
+
+	// Only the start of the large file is read, to tell whether it is binary
+	if large.offset > maxBinarySniffLength {
+		t.Errorf("read %d bytes of a file over the inline size cap, want at most %d", large.offset, maxBinarySniffLength)
+	}
+}
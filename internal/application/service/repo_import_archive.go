@@ -0,0 +1,300 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// maxSymlinkTargetLength is the longest symbolic link target an archive may hold
+const maxSymlinkTargetLength = 4096
+
+// archiveLimits bounds what an imported archive may unpack to; 0 is unlimited
+type archiveLimits struct {
+	MaxSize  int64 // Bytes of all files together
+	MaxFiles int   // Files and symbolic links
+}
+
+// unpackArchive unpacks the tar, tar.gz or zip archive at archivePath into
+// dir and returns the directory holding its files: the single top-level
+// directory most archives wrap their files in, or dir itself. Entries
+// escaping dir, entries below symbolic links and .git directories are
+// refused or skipped; only regular files, directories and symbolic links are
+// unpacked.
+func unpackArchive(archivePath, dir string, limits archiveLimits) (string, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	u := &archiveUnpacker{dir: dir, limits: limits}
+	br := bufio.NewReader(file)
+	magic, _ := br.Peek(512)
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")) || bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		info, err := file.Stat()
+		if err != nil {
+			return "", fmt.Errorf("failed to open archive: %w", err)
+		}
+		err = u.unpackZip(file, info.Size())
+		if err != nil {
+			return "", err
+		}
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return "", invalidArchive("the gzip stream is corrupt")
+		}
+		defer gz.Close()
+		if err := u.unpackTar(gz); err != nil {
+			return "", err
+		}
+	case len(magic) >= 262 && string(magic[257:262]) == "ustar":
+		if err := u.unpackTar(br); err != nil {
+			return "", err
+		}
+	default:
+		return "", invalidArchive("the archive must be a tar, tar.gz or zip file")
+	}
+
+	if u.files == 0 {
+		return "", invalidArchive("the archive contains no files")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(dir, entries[0].Name()), nil
+	}
+	return dir, nil
+}
+
+// invalidArchive returns the error of an archive that cannot be imported
+func invalidArchive(message string) error {
+	return apperrors.Unprocessable(message, apperrors.ErrInvalidInput)
+}
+
+// archiveUnpacker writes the entries of an archive below dir, counting them
+// against the limits
+type archiveUnpacker struct {
+	dir    string
+	limits archiveLimits
+	files  int
+	size   int64
+}
+
+// unpackTar unpacks a tar stream
+func (u *archiveUnpacker) unpackTar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return invalidArchive("the tar archive is corrupt")
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = u.mkdir(hdr.Name)
+		case tar.TypeReg:
+			err = u.writeFile(hdr.Name, hdr.FileInfo().Mode(), tr)
+		case tar.TypeSymlink:
+			err = u.symlink(hdr.Name, hdr.Linkname)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// unpackZip unpacks a zip file
+func (u *archiveUnpacker) unpackZip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return invalidArchive("the zip archive is corrupt")
+	}
+
+	for _, f := range zr.File {
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			err = u.mkdir(f.Name)
+		case mode&fs.ModeSymlink != 0:
+			err = u.unpackZipSymlink(f)
+		case mode.IsRegular():
+			err = u.unpackZipFile(f)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unpackZipFile unpacks a regular file of a zip archive
+func (u *archiveUnpacker) unpackZipFile(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return invalidArchive(fmt.Sprintf("%s cannot be read from the zip archive", f.Name))
+	}
+	defer rc.Close()
+	return u.writeFile(f.Name, f.Mode(), rc)
+}
+
+// unpackZipSymlink unpacks a symbolic link of a zip archive, whose target is
+// its content
+func (u *archiveUnpacker) unpackZipSymlink(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return invalidArchive(fmt.Sprintf("%s cannot be read from the zip archive", f.Name))
+	}
+	defer rc.Close()
+	target, err := io.ReadAll(io.LimitReader(rc, maxSymlinkTargetLength+1))
+	if err != nil || len(target) > maxSymlinkTargetLength {
+		return invalidArchive(fmt.Sprintf("%s is not a valid symbolic link", f.Name))
+	}
+	return u.symlink(f.Name, string(target))
+}
+
+// entryPath returns the relative path of an entry, or "" for entries that are
+// skipped
+func (u *archiveUnpacker) entryPath(name string) (string, error) {
+	name = path.Clean(strings.TrimPrefix(name, "./"))
+	if name == "." {
+		return "", nil
+	}
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", invalidArchive(fmt.Sprintf("%s points outside of the archive", name))
+	}
+	for _, part := range strings.Split(name, "/") {
+		// A repository in the archive would be committed as a broken submodule
+		if strings.EqualFold(part, ".git") {
+			return "", nil
+		}
+	}
+	return name, nil
+}
+
+// mkdir creates the directory of an entry with its parents
+func (u *archiveUnpacker) mkdir(name string) error {
+	rel, err := u.entryPath(name)
+	if err != nil || rel == "" {
+		return err
+	}
+	return u.mkdirAll(rel)
+}
+
+// mkdirAll creates a directory below dir with its parents, refusing to go
+// through anything that is not a directory, symbolic links included
+func (u *archiveUnpacker) mkdirAll(rel string) error {
+	current := u.dir
+	for _, part := range strings.Split(rel, "/") {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			if err := os.Mkdir(current, 0o755); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case !info.IsDir():
+			return invalidArchive(fmt.Sprintf("%s is inside a file or symbolic link", rel))
+		}
+	}
+	return nil
+}
+
+// create prepares the path of a file or symbolic link entry, creating its
+// parents and replacing an earlier file of the same name
+func (u *archiveUnpacker) create(name string) (string, error) {
+	rel, err := u.entryPath(name)
+	if err != nil || rel == "" {
+		return "", err
+	}
+
+	u.files++
+	if u.limits.MaxFiles > 0 && u.files > u.limits.MaxFiles {
+		return "", invalidArchive(fmt.Sprintf("the archive holds more than %d files", u.limits.MaxFiles))
+	}
+
+	if parent := path.Dir(rel); parent != "." {
+		if err := u.mkdirAll(parent); err != nil {
+			return "", err
+		}
+	}
+	target := filepath.Join(u.dir, filepath.FromSlash(rel))
+	if info, err := os.Lstat(target); err == nil {
+		if info.IsDir() {
+			return "", invalidArchive(fmt.Sprintf("%s is both a directory and a file", rel))
+		}
+		if err := os.Remove(target); err != nil {
+			return "", err
+		}
+	}
+	return target, nil
+}
+
+// writeFile unpacks a regular file, keeping whether it is executable
+func (u *archiveUnpacker) writeFile(name string, mode fs.FileMode, r io.Reader) error {
+	target, err := u.create(name)
+	if err != nil || target == "" {
+		return err
+	}
+
+	perm := fs.FileMode(0o644)
+	if mode&0o111 != 0 {
+		perm = 0o755
+	}
+	// O_EXCL never follows a symbolic link planted at the path
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	if u.limits.MaxSize > 0 {
+		r = io.LimitReader(r, u.limits.MaxSize-u.size+1)
+	}
+	n, err := io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	u.size += n
+	if u.limits.MaxSize > 0 && u.size > u.limits.MaxSize {
+		return invalidArchive(fmt.Sprintf("the files of the archive take more than %d bytes", u.limits.MaxSize))
+	}
+	if err != nil {
+		return invalidArchive(fmt.Sprintf("%s cannot be read from the archive", name))
+	}
+	return nil
+}
+
+// symlink unpacks a symbolic link. Its target is committed as is and never
+// followed while unpacking.
+func (u *archiveUnpacker) symlink(name, linkname string) error {
+	if linkname == "" || len(linkname) > maxSymlinkTargetLength {
+		return invalidArchive(fmt.Sprintf("%s is not a valid symbolic link", name))
+	}
+	target, err := u.create(name)
+	if err != nil || target == "" {
+		return err
+	}
+	return os.Symlink(linkname, target)
+}
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// importTimeout bounds an import once it started running
+	importTimeout = 30 * time.Minute
+
+	// maxActiveImports is the most imports a user may have pending or running
+	maxActiveImports = 5
+)
+
+// URLImport describes a repository to import from a remote URL. The
+// credentials authenticate the clone and are kept only for a mirror.
+type URLImport struct {
+	Name        string
+	Description string
+	IsPrivate   bool
+	CloneURL    string
+	Username    string
+	Password    string
+	Mirror      bool
+}
+
+// ArchiveImport describes a repository to create from the files of an
+// uploaded tar, tar.gz or zip archive
+type ArchiveImport struct {
+	Name        string
+	Description string
+	IsPrivate   bool
+	ArchiveName string // File name of the upload, for display
+}
+
+// RepoImportService creates repositories from remote URLs and uploaded
+// archives in the background. A failed import leaves neither a git
+// repository nor a repository record behind.
+type RepoImportService struct {
+	importRepo  repository.RepoImportRepository
+	repoService *RepoService
+	auditEvents *AuditEventService
+	cfg         *config.ReposConfig
+	slots       chan struct{}
+	mu          sync.Mutex
+	claimed     map[string]bool // owner ID/name of the imports creating their repository
+	startedAt   time.Time
+	log         *logger.Logger
+}
+
+// NewRepoImportService creates a new RepoImportService instance
+func NewRepoImportService(
+	importRepo repository.RepoImportRepository,
+	repoService *RepoService,
+	auditEvents *AuditEventService,
+	cfg *config.ReposConfig,
+) *RepoImportService {
+	return &RepoImportService{
+		importRepo:  importRepo,
+		repoService: repoService,
+		auditEvents: auditEvents,
+		cfg:         cfg,
+		slots:       make(chan struct{}, cfg.MaxConcurrentImports),
+		claimed:     make(map[string]bool),
+		startedAt:   time.Now(),
+		log:         logger.Get().WithFields(logger.Component("repo-import-service")),
+	}
+}
+
+// ImportFromURL queues the import of a repository from a remote URL for
+// owner. The credentials are handed to the import in memory only.
+func (s *RepoImportService) ImportFromURL(ctx context.Context, owner *models.User, req URLImport, ip string) (*models.RepoImport, error) {
+	imp := &models.RepoImport{
+		OwnerID:     owner.ID,
+		Name:        req.Name,
+		Description: req.Description,
+		IsPrivate:   req.IsPrivate,
+		Source:      models.RepoImportSourceURL,
+		CloneURL:    req.CloneURL,
+		Mirror:      req.Mirror,
+		Status:      models.RepoImportPending,
+		IP:          ip,
+	}
+	if err := s.queue(ctx, owner, imp); err != nil {
+		return nil, err
+	}
+
+	go s.run(imp, func(ctx context.Context, repo *models.Repository) error {
+		return s.repoService.ImportFromURL(ctx, repo, req.CloneURL, req.Username, req.Password, req.Mirror)
+	})
+	return imp, nil
+}
+
+// ImportFromArchive stores the archive read from r and queues the creation
+// of a repository for owner with its files as the initial commit
+func (s *RepoImportService) ImportFromArchive(ctx context.Context, owner *models.User, req ArchiveImport, r io.Reader, ip string) (*models.RepoImport, error) {
+	if err := s.checkQueue(ctx, owner, req.Name); err != nil {
+		return nil, err
+	}
+
+	archivePath, err := s.stageArchive(r)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &models.RepoImport{
+		OwnerID:     owner.ID,
+		Name:        req.Name,
+		Description: req.Description,
+		IsPrivate:   req.IsPrivate,
+		Source:      models.RepoImportSourceArchive,
+		ArchiveName: req.ArchiveName,
+		ArchivePath: archivePath,
+		Status:      models.RepoImportPending,
+		IP:          ip,
+	}
+	if err := s.queue(ctx, owner, imp); err != nil {
+		s.removeArchive(archivePath)
+		return nil, err
+	}
+
+	go s.run(imp, func(ctx context.Context, repo *models.Repository) error {
+		return s.importArchive(ctx, repo, owner, imp)
+	})
+	return imp, nil
+}
+
+// GetImport returns an import of a user
+func (s *RepoImportService) GetImport(ctx context.Context, ownerID, id uuid.UUID) (*models.RepoImport, error) {
+	imp, err := s.importRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if imp.OwnerID != ownerID {
+		return nil, apperrors.NotFound("import", nil)
+	}
+	return imp, nil
+}
+
+// ListImports returns a page of the imports of a user, newest first, with the
+// total number
+func (s *RepoImportService) ListImports(ctx context.Context, ownerID uuid.UUID, limit, offset int) ([]*models.RepoImport, int64, error) {
+	return s.importRepo.ListByOwner(ctx, ownerID, limit, offset)
+}
+
+// FailInterrupted fails the imports a restart interrupted, those queued
+// before the service was created, and deletes what they left behind. Their
+// credentials were never stored, so they cannot be resumed.
+func (s *RepoImportService) FailInterrupted(ctx context.Context) {
+	unfinished, err := s.importRepo.ListByStatus(ctx, models.RepoImportPending, models.RepoImportRunning)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to list interrupted imports", logger.Error(err))
+		return
+	}
+	for _, imp := range unfinished {
+		if !imp.CreatedAt.Before(s.startedAt) {
+			continue
+		}
+		s.finish(ctx, imp, nil, apperrors.Unavailable("the import was interrupted by a server restart, please start it again", nil))
+	}
+}
+
+// checkQueue fails if owner may not queue an import of a repository named
+// name now
+func (s *RepoImportService) checkQueue(ctx context.Context, owner *models.User, name string) error {
+	active, err := s.importRepo.ListActive(ctx, owner.ID)
+	if err != nil {
+		return err
+	}
+	if len(active) >= maxActiveImports {
+		return apperrors.Conflict(fmt.Sprintf("at most %d imports may be in progress at a time", maxActiveImports), nil)
+	}
+	for _, imp := range active {
+		if strings.EqualFold(imp.Name, name) {
+			return apperrors.Conflict(fmt.Sprintf("an import of %s is already in progress", imp.Name), apperrors.ErrRepositoryExists)
+		}
+	}
+
+	// The repository is placed again when the import runs
+	_, err = s.repoService.PrepareImport(ctx, owner.ID, name, "", false)
+	return err
+}
+
+// queue checks and stores a new import
+func (s *RepoImportService) queue(ctx context.Context, owner *models.User, imp *models.RepoImport) error {
+	if err := s.checkQueue(ctx, owner, imp.Name); err != nil {
+		return err
+	}
+	if err := s.importRepo.Create(ctx, imp); err != nil {
+		return err
+	}
+
+	s.log.WithContext(ctx).Info("Repository import queued",
+		logger.String("import_id", imp.ID.String()),
+		logger.String("owner", owner.Username),
+		logger.String("name", imp.Name),
+		logger.String("source", imp.Source),
+	)
+	return nil
+}
+
+// run waits for a free import slot, creates the repository of an import with
+// work and records the outcome
+func (s *RepoImportService) run(imp *models.RepoImport, work func(ctx context.Context, repo *models.Repository) error) {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), importTimeout)
+	defer cancel()
+
+	// Two imports must never share the path of their repository, nor one
+	// clean up what the other created
+	key := imp.OwnerID.String() + "/" + strings.ToLower(imp.Name)
+	if !s.claim(key) {
+		s.finish(ctx, imp, nil, apperrors.Conflict("repository already exists", apperrors.ErrRepositoryExists))
+		return
+	}
+	defer s.release(key)
+
+	repo, err := s.repoService.PrepareImport(ctx, imp.OwnerID, imp.Name, imp.Description, imp.IsPrivate)
+	if err == nil {
+		now := time.Now()
+		imp.Status = models.RepoImportRunning
+		imp.StartedAt = &now
+		imp.GitPath = repo.GitPath
+		imp.StorageBackend = repo.StorageBackend
+		if err = s.importRepo.Update(ctx, imp); err == nil {
+			err = work(ctx, repo)
+		}
+	}
+	if err != nil {
+		repo = nil
+	}
+	s.finish(ctx, imp, repo, err)
+}
+
+// finish records the outcome of an import. A failed import has its git
+// repository and uploaded archive deleted.
+func (s *RepoImportService) finish(ctx context.Context, imp *models.RepoImport, repo *models.Repository, err error) {
+	// The outcome is recorded even if the import ran out of time
+	ctx = context.WithoutCancel(ctx)
+	log := s.log.WithFields(
+		logger.String("import_id", imp.ID.String()),
+		logger.String("name", imp.Name),
+	)
+
+	now := time.Now()
+	imp.CompletedAt = &now
+	if err != nil {
+		log.Warn("Repository import failed", logger.Error(err))
+		imp.Status = models.RepoImportFailed
+		imp.Error = importErrorDetail(err)
+		if imp.GitPath != "" {
+			if discardErr := s.repoService.DiscardImport(ctx, imp.OwnerID, imp.Name, imp.StorageBackend, imp.GitPath); discardErr != nil {
+				log.Error("Failed to clean up after failed import", logger.Error(discardErr))
+			}
+		}
+	} else {
+		imp.Status = models.RepoImportCompleted
+		imp.RepositoryID = &repo.ID
+	}
+	if imp.ArchivePath != "" {
+		s.removeArchive(imp.ArchivePath)
+		imp.ArchivePath = ""
+	}
+
+	if err := s.importRepo.Update(ctx, imp); err != nil {
+		log.Warn("Failed to save import result", logger.Error(err))
+	}
+	if repo == nil {
+		return
+	}
+
+	log.Info("Repository import completed", logger.String("repo_id", repo.ID.String()))
+	metadata := map[string]any{
+		"repository": repo.Owner.Username + "/" + repo.Name,
+		"is_private": repo.IsPrivate,
+		"source":     imp.Source,
+	}
+	if imp.Source == models.RepoImportSourceURL {
+		metadata["mirror"] = imp.Mirror
+		metadata["clone_url"] = logger.Redact(imp.CloneURL)
+	} else {
+		metadata["archive"] = imp.ArchiveName
+	}
+	s.auditEvents.Record(&models.AuditEvent{
+		ActorID:      &repo.OwnerID,
+		Actor:        repo.Owner.Username,
+		RepositoryID: &repo.ID,
+		Action:       models.AuditActionRepoImport,
+		Metadata:     metadata,
+		IP:           imp.IP,
+	})
+}
+
+// importArchive unpacks the archive of an import into a temporary work tree
+// and commits it to the repository
+func (s *RepoImportService) importArchive(ctx context.Context, repo *models.Repository, owner *models.User, imp *models.RepoImport) error {
+	workDir, err := os.MkdirTemp("", "stasis-import-tree-")
+	if err != nil {
+		return fmt.Errorf("failed to create work tree: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	root, err := unpackArchive(imp.ArchivePath, workDir, archiveLimits{
+		MaxSize:  s.cfg.MaxImportSize,
+		MaxFiles: s.cfg.MaxImportFiles,
+	})
+	if err != nil {
+		return err
+	}
+
+	message := "Initial commit"
+	if imp.ArchiveName != "" {
+		message = "Import " + imp.ArchiveName
+	}
+	return s.repoService.ImportWorkTree(ctx, repo, root, message, service.Signature{Name: owner.Username, Email: owner.Email})
+}
+
+// stageArchive writes an uploaded archive to a temporary file until its
+// import runs, refusing archives above repos.max_import_archive_size
+func (s *RepoImportService) stageArchive(r io.Reader) (string, error) {
+	file, err := os.CreateTemp("", "stasis-import-*.archive")
+	if err != nil {
+		return "", apperrors.StorageError("store archive", err)
+	}
+
+	limit := s.cfg.MaxImportArchiveSize
+	if limit > 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+	size, err := io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	switch {
+	case err != nil:
+		s.removeArchive(file.Name())
+		return "", apperrors.StorageError("store archive", err)
+	case limit > 0 && size > limit:
+		s.removeArchive(file.Name())
+		return "", apperrors.BadRequest(fmt.Sprintf("the archive is larger than %d bytes", limit), apperrors.ErrInvalidInput)
+	case size == 0:
+		s.removeArchive(file.Name())
+		return "", apperrors.BadRequest("the archive is empty", apperrors.ErrInvalidInput)
+	}
+	return file.Name(), nil
+}
+
+// removeArchive deletes an uploaded archive
+func (s *RepoImportService) removeArchive(path string) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.log.Warn("Failed to delete uploaded archive",
+			logger.Error(err),
+			logger.String("path", path),
+		)
+	}
+}
+
+// claim reserves the repository name key for one import at a time
+func (s *RepoImportService) claim(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.claimed[key] {
+		return false
+	}
+	s.claimed[key] = true
+	return true
+}
+
+// release frees a repository name reserved by claim
+func (s *RepoImportService) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.claimed, key)
+}
+
+// importErrorDetail returns what the owner of a failed import is told about
+// the failure: the message of errors caused by the request, or a generic one
+// for errors on the side of the server
+func importErrorDetail(err error) string {
+	var appErr *apperrors.AppError
+	switch {
+	case apperrors.IsTimeout(err) || errors.Is(err, context.DeadlineExceeded):
+		return fmt.Sprintf("the import did not finish within %s", importTimeout)
+	case errors.As(err, &appErr) && (appErr.HTTPStatus() < http.StatusInternalServerError || appErr.HTTPStatus() == http.StatusServiceUnavailable):
+		return appErr.Message
+	default:
+		return "the import failed because of a server error"
+	}
+}
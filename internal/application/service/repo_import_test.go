@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/internal/infrastructure/git"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// fakeCloneGitService records the clones asked for and fails them with err
+type fakeCloneGitService struct {
+	service.GitService
+	mu     sync.Mutex
+	clones []string
+	err    error
+}
+
+func (f *fakeCloneGitService) CloneRepository(_ context.Context, source, _, _, _ string, _ bool, _ service.DialControl) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clones = append(f.clones, source)
+	return f.err
+}
+
+func newImportTestService(git service.GitService, cfg *config.ReposConfig) (*RepoService, *models.Repository) {
+	owner := models.User{ID: uuid.New(), Username: "alice"}
+	repo := &models.Repository{Name: "app", OwnerID: owner.ID, Owner: owner, GitPath: "/repos/alice/app.git"}
+	repos := &fakeRepoRepo{}
+	backends := fakeStorageBackends{backends: map[string]service.StorageService{config.DefaultStorageBackend: &fakeStorage{}}}
+	storage := NewStorageBackendService(backends, repos, nil, &fakeAudit{})
+	return NewRepoService(repos, &fakeUserRepo{}, fakeCollaboratorRepo{}, git, storage, cfg, &fakeBus{}), repo
+}
+
+func TestImportFromURLRefusesPrivateAddresses(t *testing.T) {
+	urls := []string{
+		"http://127.0.0.1:8080/repo.git",
+		"https://localhost/repo.git",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.5/repo.git",
+		"http://192.168.1.1:3000/repo.git",
+		"http://100.64.0.1/repo.git",
+		"http://[::1]:9418/repo.git",
+		"http://[::ffff:127.0.0.1]/repo.git",
+		"git://0.0.0.0/repo.git",
+		"ssh://127.0.0.1:22/repo.git",
+	}
+	for _, cloneURL := range urls {
+		t.Run(cloneURL, func(t *testing.T) {
+			git := &fakeCloneGitService{}
+			s, repo := newImportTestService(git, &config.ReposConfig{})
+
+			err := s.ImportFromURL(context.Background(), repo, cloneURL, "", "", false)
+			var appErr *apperrors.AppError
+			if !errors.As(err, &appErr) || appErr.Code != apperrors.CodeUnprocessable {
+				t.Fatalf("ImportFromURL: %v, want the URL refused as unprocessable", err)
+			}
+			if len(git.clones) != 0 {
+				t.Errorf("cloned %v, want no clone", git.clones)
+			}
+		})
+	}
+}
+
+func TestImportFromURLAllowsPrivateAddressesWhenConfigured(t *testing.T) {
+	git := &fakeCloneGitService{err: errors.New("dial tcp 127.0.0.1:8080: connect: connection refused")}
+	s, repo := newImportTestService(git, &config.ReposConfig{ImportAllowPrivateAddresses: true})
+
+	err := s.ImportFromURL(context.Background(), repo, "http://127.0.0.1:8080/repo.git", "", "", false)
+	if len(git.clones) != 1 {
+		t.Fatalf("clones = %v, want one", git.clones)
+	}
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.CodeUnprocessable {
+		t.Fatalf("ImportFromURL: %v, want the failed clone as unprocessable", err)
+	}
+	// The transport error would let users scan the network of the server
+	if strings.Contains(appErr.Message, "127.0.0.1") || strings.Contains(appErr.Message, "refused") {
+		t.Errorf("message %q reveals the transport error", appErr.Message)
+	}
+	if got := importErrorDetail(err); got != appErr.Message {
+		t.Errorf("import error = %q, want %q", got, appErr.Message)
+	}
+}
+
+func TestImportFromURLChecksEveryConnection(t *testing.T) {
+	var hits atomic.Int64
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer internal.Close()
+	_, port, _ := net.SplitHostPort(internal.Listener.Addr().String())
+
+	// The host resolves to a public address when the URL is checked and to
+	// the internal server when it is cloned from
+	lookup := lookupRemoteHost
+	lookupRemoteHost = func(context.Context, string, string) ([]netip.Addr, error) {
+		return []netip.Addr{netip.MustParseAddr("203.0.113.7")}, nil
+	}
+	defer func() { lookupRemoteHost = lookup }()
+
+	s, repo := newImportTestService(git.NewGitOperations(nil, nil), &config.ReposConfig{})
+	repo.GitPath = filepath.Join(t.TempDir(), "app.git")
+
+	err := s.ImportFromURL(context.Background(), repo, "http://localhost:"+port+"/repo.git", "", "", false)
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.CodeUnprocessable {
+		t.Fatalf("ImportFromURL: %v, want the clone refused as unprocessable", err)
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("internal server got %d requests, want none", n)
+	}
+}
+
+// fakeFetchGitService records the dial controls of mirror fetches
+type fakeFetchGitService struct {
+	service.GitService
+	controls []service.DialControl
+}
+
+func (f *fakeFetchGitService) FetchMirror(_ context.Context, _, _ string, control service.DialControl) error {
+	f.controls = append(f.controls, control)
+	return nil
+}
+
+func TestMirrorFetchChecksUpstream(t *testing.T) {
+	tests := []struct {
+		name         string
+		upstream     string
+		allowPrivate bool
+		wantFetch    bool
+		wantControl  bool
+	}{
+		{name: "http upstream", upstream: "https://example.com/repo.git", wantFetch: true, wantControl: true},
+		{name: "ssh upstream", upstream: "ssh://example.com/repo.git"},
+		{name: "private addresses allowed", upstream: "ssh://10.0.0.5/repo.git", allowPrivate: true, wantFetch: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			git := &fakeFetchGitService{}
+			s := NewMirrorSyncService(&fakeRepoRepo{}, git, &config.ReposConfig{ImportAllowPrivateAddresses: tt.allowPrivate})
+			err := s.fetchUpstream(context.Background(), &models.Repository{GitPath: "/repos/alice/app.git", UpstreamURL: tt.upstream})
+			if tt.wantFetch != (err == nil) {
+				t.Fatalf("fetchUpstream: %v, want fetched = %v", err, tt.wantFetch)
+			}
+			if !tt.wantFetch {
+				if len(git.controls) != 0 {
+					t.Errorf("fetched %d times, want none", len(git.controls))
+				}
+				return
+			}
+			if len(git.controls) != 1 || (git.controls[0] != nil) != tt.wantControl {
+				t.Errorf("fetched with controls %v, want one control set = %v", git.controls, tt.wantControl)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// redirectCleanupInterval is the time between deletions of expired repository redirects
+	redirectCleanupInterval = 6 * time.Hour
+
+	// redirectCleanupJitter spreads the deletions of expired repository redirects
+	redirectCleanupJitter = 30 * time.Minute
+
+	// renameDrainTimeout is how long a rename waits for the git operations in
+	// flight on the repository to finish
+	renameDrainTimeout = 30 * time.Second
+)
+
+// RenameRepository renames a repository, moving it on storage. The former
+// name keeps working for repos.rename_redirect_days: git requests for it are
+// served by the repository and API requests are redirected. Like a migration,
+// the rename blocks new git operations on the repository and waits for the
+// ones in flight; it fails with a conflict if they do not finish in time.
+func (s *RepoService) RenameRepository(ctx context.Context, repoID uuid.UUID, newName string) (*models.Repository, error) {
+	s.log.WithContext(ctx).Info("Renaming repository",
+		logger.String("repo_id", repoID.String()),
+		logger.String("new_name", newName),
+	)
+
+	if newName == "" {
+		return nil, apperrors.BadRequest("repository name is required", apperrors.ErrInvalidInput)
+	}
+
+	repo, err := s.repoRepo.FindByID(ctx, repoID)
+	if err != nil {
+		s.log.WithContext(ctx).Error("Failed to find repository for rename",
+			logger.Error(err),
+			logger.String("repo_id", repoID.String()),
+		)
+		return nil, err
+	}
+	if repo.Name == newName {
+		return repo, nil
+	}
+
+	// Check if the owner already has a repo with the new name
+	exists, err := s.repoRepo.ExistsByOwnerAndName(ctx, repo.OwnerID, newName)
+	if err != nil {
+		s.log.WithContext(ctx).Error("Failed to check repository existence for rename",
+			logger.Error(err),
+		)
+		return nil, fmt.Errorf("failed to check repository existence: %w", err)
+	}
+	if exists {
+		s.log.WithContext(ctx).Warn("Rename failed - owner already has repository with the new name",
+			logger.String("owner", repo.Owner.Username),
+			logger.String("new_name", newName),
+		)
+		return nil, apperrors.Conflict("a repository with this name already exists", apperrors.ErrRepositoryExists)
+	}
+
+	// The repository stays on its storage backend
+	backend, err := s.storage.ForRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	drainCtx, cancel := context.WithTimeout(ctx, renameDrainTimeout)
+	defer cancel()
+	unlock, err := s.storage.lockForMigration(drainCtx, repo.ID)
+	if err != nil {
+		if apperrors.IsTimeout(err) && ctx.Err() == nil {
+			return nil, apperrors.Conflict("git operations on the repository are in progress, try again shortly", apperrors.ErrStorageError)
+		}
+		return nil, err
+	}
+	defer unlock()
+
+	oldName := repo.Name
+	oldPath := repo.GitPath
+	newPath := backend.GetRepoPath(repo.Owner.Username, newName)
+
+	// Move git repository
+	s.log.WithContext(ctx).Debug("Moving git repository",
+		logger.String("old_path", oldPath),
+		logger.String("new_path", newPath),
+	)
+	if err := backend.MoveFile(oldPath, newPath); err != nil {
+		s.log.WithContext(ctx).Error("Failed to move git repository",
+			logger.Error(err),
+			logger.String("old_path", oldPath),
+			logger.String("new_path", newPath),
+		)
+		return nil, fmt.Errorf("failed to move repository: %w", err)
+	}
+
+	// Update database record and keep the former name working
+	repo.Name = newName
+	repo.GitPath = newPath
+	redirect := &models.RepoRedirect{
+		OldOwner:  repo.Owner.Username,
+		OldName:   oldName,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.repoRepo.Rename(ctx, repo, redirect); err != nil {
+		s.log.WithContext(ctx).Error("Failed to update repository after move",
+			logger.Error(err),
+		)
+		// Try to move back on failure
+		if moveErr := backend.MoveFile(newPath, oldPath); moveErr != nil {
+			s.log.WithContext(ctx).Error("Failed to rollback repository move",
+				logger.Error(moveErr),
+				logger.String("new_path", newPath),
+				logger.String("old_path", oldPath),
+			)
+		}
+		repo.Name = oldName
+		repo.GitPath = oldPath
+		if apperrors.IsConflict(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update repository: %w", err)
+	}
+
+	s.log.WithContext(ctx).Info("Repository renamed successfully",
+		logger.String("repo_id", repoID.String()),
+		logger.String("owner", repo.Owner.Username),
+		logger.String("old_name", oldName),
+		logger.String("new_name", newName),
+	)
+
+	return repo, nil
+}
+
+// ResolveRepository finds a repository by owner username and name, following
+// the redirect from the former name of a renamed repository
+func (s *RepoService) ResolveRepository(ctx context.Context, ownerUsername, repoName string) (*models.Repository, error) {
+	repo, err := s.repoRepo.FindByOwnerUsernameAndName(ctx, ownerUsername, repoName)
+	if err == nil || !apperrors.IsNotFound(err) {
+		return repo, err
+	}
+
+	redirected, redirectErr := s.FindRedirect(ctx, ownerUsername, repoName)
+	if redirectErr != nil {
+		if apperrors.IsNotFound(redirectErr) {
+			return nil, err
+		}
+		return nil, redirectErr
+	}
+	return redirected, nil
+}
+
+// FindRedirect finds the repository the former name of a renamed repository
+// redirects to, within repos.rename_redirect_days of the rename
+func (s *RepoService) FindRedirect(ctx context.Context, ownerUsername, repoName string) (*models.Repository, error) {
+	var since time.Time
+	if period := s.config.RenameRedirectPeriod(); period > 0 {
+		since = time.Now().Add(-period)
+	}
+	return s.repoRepo.FindByRedirect(ctx, ownerUsername, repoName, since)
+}
+
+// RedirectCleanupTask returns the housekeeping task deleting the redirects
+// from former repository names past repos.rename_redirect_days
+func (s *RepoService) RedirectCleanupTask() *HousekeepingTask {
+	if s.config.RenameRedirectDays <= 0 {
+		return nil
+	}
+	return &HousekeepingTask{
+		Name:        "repo_redirects.cleanup",
+		Description: "Delete the redirects from former names of renamed repositories older than repos.rename_redirect_days",
+		Interval:    redirectCleanupInterval,
+		Jitter:      redirectCleanupJitter,
+		Run:         s.cleanupRedirects,
+	}
+}
+
+// cleanupRedirects deletes the redirects that expired by now
+func (s *RepoService) cleanupRedirects(ctx context.Context) error {
+	deleted, err := s.repoRepo.DeleteRedirectsCreatedBefore(ctx, time.Now().Add(-s.config.RenameRedirectPeriod()))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.log.WithContext(ctx).Info("Deleted expired repository redirects", logger.Int64("count", deleted))
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+func TestRenameRepositoryWaitsForPushes(t *testing.T) {
+	owner := models.User{ID: uuid.New(), Username: "alice"}
+	repo := &models.Repository{ID: uuid.New(), Name: "app", OwnerID: owner.ID, Owner: owner, GitPath: "/repos/alice/app.git"}
+	repos := &fakeRepoRepo{repos: []*models.Repository{repo}}
+	backend := &fakeStorage{}
+	backends := fakeStorageBackends{backends: map[string]service.StorageService{config.DefaultStorageBackend: backend}}
+	storage := NewStorageBackendService(backends, repos, nil, &fakeAudit{})
+	s := NewRepoService(repos, &fakeUserRepo{}, fakeCollaboratorRepo{}, nil, storage, &config.ReposConfig{}, &fakeBus{})
+
+	releasePush, err := storage.AcquirePush(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		repo *models.Repository
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		renamed, err := s.RenameRepository(context.Background(), repo.ID, "service")
+		done <- result{renamed, err}
+	}()
+
+	// The rename waits for the push and refuses new git operations meanwhile
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		release, err := storage.AcquireRepository(repo)
+		if apperrors.IsConflict(err) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("AcquireRepository: %v", err)
+		}
+		release()
+		if time.Now().After(deadline) {
+			t.Fatal("the rename did not block new git operations")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if moves := backend.Moves(); len(moves) != 0 {
+		t.Fatalf("moved %v while a push was in flight", moves)
+	}
+
+	releasePush()
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("RenameRepository: %v", res.err)
+	}
+	if res.repo.Name != "service" || res.repo.GitPath != "/repos/alice/service.git" {
+		t.Errorf("renamed to %s at %s, want service at /repos/alice/service.git", res.repo.Name, res.repo.GitPath)
+	}
+	if moves := backend.Moves(); len(moves) != 1 || moves[0] != [2]string{"/repos/alice/app.git", "/repos/alice/service.git"} {
+		t.Errorf("moves = %v, want one from app.git to service.git", moves)
+	}
+
+	release, err := storage.AcquireRepository(repo)
+	if err != nil {
+		t.Fatalf("AcquireRepository after the rename: %v", err)
+	}
+	release()
+}
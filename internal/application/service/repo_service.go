@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"path/filepath"
 	"strings"
@@ -12,6 +14,8 @@ import (
 	"github.com/robfig/cron/v3"
 
 	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/events"
 	"github.com/bravo68web/stasis/internal/domain/models"
 	"github.com/bravo68web/stasis/internal/domain/repository"
 	"github.com/bravo68web/stasis/internal/domain/service"
@@ -19,12 +23,23 @@ import (
 	"github.com/bravo68web/stasis/pkg/logger"
 )
 
+// maxBinarySniffLength is how much of a file too large to return is read to
+// tell whether it is binary
+const maxBinarySniffLength = 8000
+
+// ErrRawFileTooLarge is returned for a file above repos.max_raw_file_size
+// when large files were not requested
+var ErrRawFileTooLarge = errors.New("file too large")
+
 // RepoService handles repository management operations
 type RepoService struct {
 	repoRepo   repository.RepoRepository
 	userRepo   repository.UserRepository
+	collabRepo repository.CollaboratorRepository
 	gitService service.GitService
-	storage    service.StorageService
+	storage    *StorageBackendService
+	config     *config.ReposConfig
+	publisher  events.Publisher
 	log        *logger.Logger
 }
 
@@ -32,14 +47,20 @@ type RepoService struct {
 func NewRepoService(
 	repoRepo repository.RepoRepository,
 	userRepo repository.UserRepository,
+	collabRepo repository.CollaboratorRepository,
 	gitService service.GitService,
-	storage service.StorageService,
+	storage *StorageBackendService,
+	cfg *config.ReposConfig,
+	publisher events.Publisher,
 ) *RepoService {
 	return &RepoService{
 		repoRepo:   repoRepo,
 		userRepo:   userRepo,
+		collabRepo: collabRepo,
 		gitService: gitService,
 		storage:    storage,
+		config:     cfg,
+		publisher:  publisher,
 		log:        logger.Get().WithFields(logger.Component("repo-service")),
 	}
 }
@@ -51,7 +72,13 @@ func (s *RepoService) GetRepoRepository() repository.RepoRepository {
 
 // CreateRepository creates a new repository for a user
 func (s *RepoService) CreateRepository(ctx context.Context, ownerID uuid.UUID, name, description string, isPrivate bool) (*models.Repository, error) {
-	s.log.Info("Creating repository",
+	return s.createRepository(ctx, ownerID, name, description, isPrivate, "create")
+}
+
+// createRepository creates a new repository for a user and publishes its
+// creation with source as the reason
+func (s *RepoService) createRepository(ctx context.Context, ownerID uuid.UUID, name, description string, isPrivate bool, source string) (*models.Repository, error) {
+	s.log.WithContext(ctx).Info("Creating repository",
 		logger.String("owner_id", ownerID.String()),
 		logger.String("name", name),
 		logger.Bool("is_private", isPrivate),
@@ -59,7 +86,7 @@ func (s *RepoService) CreateRepository(ctx context.Context, ownerID uuid.UUID, n
 
 	// Validate repository name
 	if name == "" {
-		s.log.Warn("Repository creation failed - name is required")
+		s.log.WithContext(ctx).Warn("Repository creation failed - name is required")
 		return nil, apperrors.BadRequest("repository name is required", apperrors.ErrInvalidInput)
 	}
 
@@ -67,12 +94,12 @@ func (s *RepoService) CreateRepository(ctx context.Context, ownerID uuid.UUID, n
 	owner, err := s.userRepo.FindByID(ctx, ownerID)
 	if err != nil {
 		if apperrors.IsNotFound(err) {
-			s.log.Warn("Repository creation failed - owner not found",
+			s.log.WithContext(ctx).Warn("Repository creation failed - owner not found",
 				logger.String("owner_id", ownerID.String()),
 			)
 			return nil, apperrors.NotFound("user", apperrors.ErrNotFound)
 		}
-		s.log.Error("Failed to find owner",
+		s.log.WithContext(ctx).Error("Failed to find owner",
 			logger.Error(err),
 			logger.String("owner_id", ownerID.String()),
 		)
@@ -82,7 +109,7 @@ func (s *RepoService) CreateRepository(ctx context.Context, ownerID uuid.UUID, n
 	// Check if repository already exists for this owner
 	exists, err := s.repoRepo.ExistsByOwnerAndName(ctx, ownerID, name)
 	if err != nil {
-		s.log.Error("Failed to check repository existence",
+		s.log.WithContext(ctx).Error("Failed to check repository existence",
 			logger.Error(err),
 			logger.String("owner_id", ownerID.String()),
 			logger.String("name", name),
@@ -90,40 +117,51 @@ func (s *RepoService) CreateRepository(ctx context.Context, ownerID uuid.UUID, n
 		return nil, fmt.Errorf("failed to check repository existence: %w", err)
 	}
 	if exists {
-		s.log.Warn("Repository already exists",
+		s.log.WithContext(ctx).Warn("Repository already exists",
 			logger.String("owner", owner.Username),
 			logger.String("name", name),
 		)
 		return nil, apperrors.Conflict("repository already exists", apperrors.ErrRepositoryExists)
 	}
+	if err := s.checkRepositoryLimit(ctx, owner); err != nil {
+		return nil, err
+	}
 
-	// Build git path
-	gitPath := s.storage.GetRepoPath(owner.Username, name)
+	// Build git path on the backend the placement rules pick
+	backendName, backend, err := s.storage.Place(owner.Username, 0)
+	if err != nil {
+		return nil, err
+	}
+	gitPath := backend.GetRepoPath(owner.Username, name)
 
 	// Create repository record
 	repo := &models.Repository{
-		Name:        name,
-		OwnerID:     ownerID,
-		IsPrivate:   isPrivate,
-		Description: description,
-		GitPath:     gitPath,
+		Name:           name,
+		OwnerID:        ownerID,
+		IsPrivate:      isPrivate,
+		Description:    description,
+		DefaultBranch:  s.config.DefaultBranch,
+		GitPath:        gitPath,
+		StorageBackend: backendName,
+		UploadPack:     models.DefaultUploadPackSettings(),
 	}
 
 	// Initialize git repository on storage
-	s.log.Debug("Initializing git repository",
+	s.log.WithContext(ctx).Debug("Initializing git repository",
 		logger.String("git_path", gitPath),
 	)
-	if err := s.gitService.InitRepository(ctx, gitPath, true); err != nil {
-		s.log.Error("Failed to initialize git repository",
+	if err := s.gitService.InitRepository(ctx, gitPath, true, s.config.DefaultBranch); err != nil {
+		s.log.WithContext(ctx).Error("Failed to initialize git repository",
 			logger.Error(err),
 			logger.String("git_path", gitPath),
 		)
 		return nil, fmt.Errorf("failed to initialize git repository: %w", err)
 	}
+	s.writeUploadPackConfig(ctx, repo)
 
 	// Sync to remote storage (S3) after initialization
-	if err := s.storage.SyncToRemote(gitPath); err != nil {
-		s.log.Warn("Failed to sync new repository to remote storage",
+	if err := backend.SyncToRemote(gitPath); err != nil {
+		s.log.WithContext(ctx).Warn("Failed to sync new repository to remote storage",
 			logger.Error(err),
 			logger.String("git_path", gitPath),
 		)
@@ -132,13 +170,13 @@ func (s *RepoService) CreateRepository(ctx context.Context, ownerID uuid.UUID, n
 
 	// Save to database
 	if err := s.repoRepo.Create(ctx, repo); err != nil {
-		s.log.Error("Failed to create repository in database",
+		s.log.WithContext(ctx).Error("Failed to create repository in database",
 			logger.Error(err),
 			logger.String("name", name),
 		)
 		// Cleanup git repository if database save fails
-		if cleanupErr := s.storage.DeleteDirectory(gitPath); cleanupErr != nil {
-			s.log.Error("Failed to cleanup git repository after database error",
+		if cleanupErr := backend.DeleteDirectory(gitPath); cleanupErr != nil {
+			s.log.WithContext(ctx).Error("Failed to cleanup git repository after database error",
 				logger.Error(cleanupErr),
 				logger.String("git_path", gitPath),
 			)
@@ -149,47 +187,136 @@ func (s *RepoService) CreateRepository(ctx context.Context, ownerID uuid.UUID, n
 	// Set owner reference
 	repo.Owner = *owner
 
-	s.log.Info("Repository created successfully",
+	s.log.WithContext(ctx).Info("Repository created successfully",
 		logger.String("repo_id", repo.ID.String()),
 		logger.String("owner", owner.Username),
 		logger.String("name", name),
 		logger.String("git_path", gitPath),
 	)
 
+	s.publishCreated(repo, source)
+
 	return repo, nil
 }
 
-// ImportRepository imports a repository from an external Git source
-func (s *RepoService) ImportRepository(ctx context.Context, ownerID uuid.UUID, name, description, cloneURL, username, password string, isPrivate, mirror bool) (*models.Repository, error) {
-	s.log.Info("Importing repository",
-		logger.String("owner_id", ownerID.String()),
+// writeUploadPackConfig writes the upload-pack settings of a new repository to
+// its git config. The transports pass the settings to git anyway, so a failure
+// is not worth failing the creation.
+func (s *RepoService) writeUploadPackConfig(ctx context.Context, repo *models.Repository) {
+	if err := writeUploadPackConfig(ctx, s.gitService, repo.GitPath, repo.UploadPack); err != nil {
+		s.log.WithContext(ctx).Warn("Failed to write upload-pack settings to git config",
+			logger.Error(err),
+			logger.String("git_path", repo.GitPath),
+		)
+	}
+}
+
+// checkRepositoryLimit fails if owner has as many repositories as
+// repos.max_repos_per_user allows
+func (s *RepoService) checkRepositoryLimit(ctx context.Context, owner *models.User) error {
+	if s.config.MaxReposPerUser <= 0 {
+		return nil
+	}
+	count, err := s.repoRepo.CountByOwner(ctx, owner.ID)
+	if err != nil {
+		return fmt.Errorf("failed to count repositories: %w", err)
+	}
+	if count >= int64(s.config.MaxReposPerUser) {
+		s.log.WithContext(ctx).Warn("Repository limit reached",
+			logger.String("owner", owner.Username),
+			logger.Int64("repositories", count),
+		)
+		return apperrors.Forbidden(fmt.Sprintf("%s has reached the limit of %d repositories", owner.Username, s.config.MaxReposPerUser), apperrors.ErrForbidden)
+	}
+	return nil
+}
+
+// RepositoryWebURL returns the page of a repository in the web interface, or
+// "" without a configured repos.web_url
+func (s *RepoService) RepositoryWebURL(owner, name string) string {
+	if s.config.WebURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(s.config.WebURL, "/"), owner, name)
+}
+
+// publishCreated publishes the creation of a repository
+func (s *RepoService) publishCreated(repo *models.Repository, source string) {
+	s.publisher.Publish(events.RepositoryCreated{
+		RepositoryID: repo.ID,
+		OwnerID:      repo.OwnerID,
+		Owner:        repo.Owner.Username,
+		Name:         repo.Name,
+		IsPrivate:    repo.IsPrivate,
+		Source:       source,
+	})
+}
+
+// IsCreateOnPushEnabled returns true if pushes may create missing repositories
+func (s *RepoService) IsCreateOnPushEnabled() bool {
+	return s.config.CreateOnPush
+}
+
+// CanCreateOnPush returns true if a push by user into owner's namespace may
+// create a missing repository. Anonymous and cross-namespace pushes never can,
+// nor pushes with a token without the repo:write scope.
+func (s *RepoService) CanCreateOnPush(user *models.User, owner string) bool {
+	return s.config.CreateOnPush && user != nil && user.Username == owner && user.TokenAllows(models.RepoPermissionWrite)
+}
+
+// CreateRepositoryOnPush creates a missing repository for a push into the
+// pusher's own namespace. The repository is private and goes through the same
+// checks as API creation. It is not removed if the push that triggered it
+// fails, leaving a valid empty repository behind.
+func (s *RepoService) CreateRepositoryOnPush(ctx context.Context, user *models.User, owner, name string) (*models.Repository, error) {
+	if !s.CanCreateOnPush(user, owner) {
+		return nil, apperrors.NotFound("repository", apperrors.ErrNotFound)
+	}
+
+	req := dto.CreateRepoRequest{Name: name}
+	if err := req.Validate(); err != nil {
+		return nil, apperrors.BadRequest(err.Error(), apperrors.ErrInvalidInput)
+	}
+
+	repo, err := s.createRepository(ctx, user.ID, name, "", true, "push")
+	if err != nil {
+		// A concurrent push may have created it first
+		if apperrors.IsConflict(err) {
+			return s.GetRepository(ctx, owner, name)
+		}
+		return nil, err
+	}
+
+	s.log.WithContext(ctx).Info("Repository created on push",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("owner", owner),
 		logger.String("name", name),
-		logger.String("clone_url", cloneURL),
-		logger.Bool("is_private", isPrivate),
-		logger.Bool("mirror", mirror),
+		logger.String("user_id", user.ID.String()),
 	)
 
+	return repo, nil
+}
+
+// PrepareImport checks that a user may create a repository named name and
+// places it on a storage backend. The returned repository, owner included, is
+// not stored yet: ImportFromURL or ImportWorkTree fill and store it.
+func (s *RepoService) PrepareImport(ctx context.Context, ownerID uuid.UUID, name, description string, isPrivate bool) (*models.Repository, error) {
 	// Validate repository name
 	if name == "" {
-		s.log.Warn("Repository import failed - name is required")
+		s.log.WithContext(ctx).Warn("Repository import failed - name is required")
 		return nil, apperrors.BadRequest("repository name is required", apperrors.ErrInvalidInput)
 	}
 
-	if cloneURL == "" {
-		s.log.Warn("Repository import failed - clone URL is required")
-		return nil, apperrors.BadRequest("clone URL is required", apperrors.ErrInvalidInput)
-	}
-
 	// Get owner to verify they exist and get username
 	owner, err := s.userRepo.FindByID(ctx, ownerID)
 	if err != nil {
 		if apperrors.IsNotFound(err) {
-			s.log.Warn("Repository import failed - owner not found",
+			s.log.WithContext(ctx).Warn("Repository import failed - owner not found",
 				logger.String("owner_id", ownerID.String()),
 			)
 			return nil, apperrors.NotFound("user", apperrors.ErrNotFound)
 		}
-		s.log.Error("Failed to find owner",
+		s.log.WithContext(ctx).Error("Failed to find owner",
 			logger.Error(err),
 			logger.String("owner_id", ownerID.String()),
 		)
@@ -199,7 +326,7 @@ func (s *RepoService) ImportRepository(ctx context.Context, ownerID uuid.UUID, n
 	// Check if repository already exists for this owner
 	exists, err := s.repoRepo.ExistsByOwnerAndName(ctx, ownerID, name)
 	if err != nil {
-		s.log.Error("Failed to check repository existence",
+		s.log.WithContext(ctx).Error("Failed to check repository existence",
 			logger.Error(err),
 			logger.String("owner_id", ownerID.String()),
 			logger.String("name", name),
@@ -207,24 +334,79 @@ func (s *RepoService) ImportRepository(ctx context.Context, ownerID uuid.UUID, n
 		return nil, fmt.Errorf("failed to check repository existence: %w", err)
 	}
 	if exists {
-		s.log.Warn("Repository already exists",
+		s.log.WithContext(ctx).Warn("Repository already exists",
 			logger.String("owner", owner.Username),
 			logger.String("name", name),
 		)
 		return nil, apperrors.Conflict("repository already exists", apperrors.ErrRepositoryExists)
 	}
+	if err := s.checkRepositoryLimit(ctx, owner); err != nil {
+		return nil, err
+	}
 
-	// Build git path
-	gitPath := s.storage.GetRepoPath(owner.Username, name)
+	// Build git path on the backend the placement rules pick
+	backendName, backend, err := s.storage.Place(owner.Username, 0)
+	if err != nil {
+		return nil, err
+	}
+	gitPath := backend.GetRepoPath(owner.Username, name)
 
-	// Create repository record
-	repo := &models.Repository{
-		Name:        name,
-		OwnerID:     ownerID,
-		IsPrivate:   isPrivate,
-		Description: description,
-		GitPath:     gitPath,
-		SyncStatus:  "idle",
+	// A directory without a repository record is left by an import still
+	// running; it must not be cloned into or cleaned up by another one
+	if exists, err := backend.Exists(gitPath); err != nil {
+		return nil, apperrors.StorageError("check repository path", err)
+	} else if exists {
+		return nil, apperrors.Conflict("repository already exists", apperrors.ErrRepositoryExists)
+	}
+
+	return &models.Repository{
+		Name:           name,
+		OwnerID:        ownerID,
+		Owner:          *owner,
+		IsPrivate:      isPrivate,
+		Description:    description,
+		GitPath:        gitPath,
+		StorageBackend: backendName,
+		SyncStatus:     "idle",
+		UploadPack:     models.DefaultUploadPackSettings(),
+	}, nil
+}
+
+// ImportFromURL clones all refs of cloneURL into a repository prepared by
+// PrepareImport and stores it. username and password authenticate the clone;
+// they are kept only for a mirror, which goes on syncing from cloneURL. The
+// git repository is deleted again if the import fails.
+func (s *RepoService) ImportFromURL(ctx context.Context, repo *models.Repository, cloneURL, username, password string, mirror bool) error {
+	s.log.WithContext(ctx).Info("Importing repository",
+		logger.String("owner", repo.Owner.Username),
+		logger.String("name", repo.Name),
+		logger.String("clone_url", logger.Redact(cloneURL)),
+		logger.Bool("is_private", repo.IsPrivate),
+		logger.Bool("mirror", mirror),
+	)
+
+	if cloneURL == "" {
+		s.log.WithContext(ctx).Warn("Repository import failed - clone URL is required")
+		return apperrors.BadRequest("clone URL is required", apperrors.ErrInvalidInput)
+	}
+
+	if !s.config.ImportAllowPrivateAddresses {
+		err := checkRemoteScheme(cloneURL)
+		if err == nil {
+			err = checkRemoteHost(ctx, cloneURL)
+		}
+		if err != nil {
+			s.log.WithContext(ctx).Warn("Repository import refused - clone URL is not public",
+				logger.Error(err),
+				logger.String("clone_url", logger.Redact(cloneURL)),
+			)
+			return apperrors.Unprocessable("the clone URL must be an http or https URL of a publicly reachable host", err)
+		}
+	}
+
+	backend, err := s.storage.ForRepo(repo)
+	if err != nil {
+		return err
 	}
 
 	// Set mirror configuration if this is a mirror repository
@@ -237,42 +419,45 @@ func (s *RepoService) ImportRepository(ctx context.Context, ownerID uuid.UUID, n
 		repo.SyncInterval = 3600         // Default 1 hour
 	}
 
-	// Clone repository from external source
-	s.log.Debug("Cloning repository from external source",
-		logger.String("git_path", gitPath),
-		logger.String("clone_url", cloneURL),
+	// Clone every ref into a bare repository, like git clone --mirror
+	s.log.WithContext(ctx).Debug("Cloning repository from external source",
+		logger.String("git_path", repo.GitPath),
+		logger.String("clone_url", logger.Redact(cloneURL)),
 		logger.Bool("mirror", mirror),
 	)
-
-	if err := s.gitService.CloneRepository(ctx, cloneURL, gitPath, username, password, mirror); err != nil {
-		s.log.Error("Failed to clone repository",
+	// The host may resolve differently by the time it is cloned from, or
+	// redirect, so every connection of the clone is checked again
+	control := publicDialControl(s.config.ImportAllowPrivateAddresses)
+	if err := s.gitService.CloneRepository(ctx, cloneURL, repo.GitPath, username, password, true, control); err != nil {
+		s.log.WithContext(ctx).Error("Failed to clone repository",
 			logger.Error(err),
-			logger.String("clone_url", cloneURL),
-			logger.String("git_path", gitPath),
+			logger.String("clone_url", logger.Redact(cloneURL)),
+			logger.String("git_path", repo.GitPath),
 		)
-		return nil, fmt.Errorf("failed to clone repository: %w", err)
+		s.discardImport(ctx, backend, repo.GitPath)
+		// The transport error stays in the log: it would tell users which
+		// hosts and ports the server can reach
+		return apperrors.Unprocessable("could not clone the repository from the clone URL", err)
 	}
 
-	// If it's a mirror, we need to configure it as a bare mirror
+	// If it's a mirror, configure the remote it syncs from
 	if mirror {
-		if err := s.gitService.ConfigureMirror(ctx, gitPath, cloneURL); err != nil {
-			s.log.Error("Failed to configure mirror",
+		if err := s.gitService.ConfigureMirror(ctx, repo.GitPath, cloneURL); err != nil {
+			s.log.WithContext(ctx).Error("Failed to configure mirror",
 				logger.Error(err),
-				logger.String("git_path", gitPath),
+				logger.String("git_path", repo.GitPath),
 			)
-			// Cleanup on failure
-			if cleanupErr := s.storage.DeleteDirectory(gitPath); cleanupErr != nil {
-				s.log.Error("Failed to cleanup repository after mirror configuration error",
-					logger.Error(cleanupErr),
-					logger.String("git_path", gitPath),
-				)
-			}
-			return nil, fmt.Errorf("failed to configure mirror: %w", err)
+			s.discardImport(ctx, backend, repo.GitPath)
+			return apperrors.GitError("configure mirror", err)
 		}
+
+		now := time.Now()
+		repo.LastSyncedAt = &now
+		repo.SyncStatus = "success"
 	}
 
 	// Try to determine default branch from the cloned repository
-	branches, err := s.gitService.ListBranches(ctx, gitPath)
+	branches, err := s.gitService.ListBranches(ctx, repo.GitPath)
 	if err == nil && len(branches) > 0 {
 		// Find the HEAD branch or use the first branch
 		for _, branch := range branches {
@@ -281,47 +466,114 @@ func (s *RepoService) ImportRepository(ctx context.Context, ownerID uuid.UUID, n
 				break
 			}
 		}
-		if repo.DefaultBranch == "" && len(branches) > 0 {
+		if repo.DefaultBranch == "" {
 			repo.DefaultBranch = branches[0].Name
 		}
 	}
 
-	// Set initial sync time for mirrors
-	if mirror {
-		now := time.Now()
-		repo.LastSyncedAt = &now
-		repo.SyncStatus = "success"
+	return s.storeImport(ctx, repo, backend)
+}
+
+// ImportWorkTree commits the files under dir as the initial commit of the
+// default branch of a repository prepared by PrepareImport and stores it. The
+// git repository is deleted again if the import fails.
+func (s *RepoService) ImportWorkTree(ctx context.Context, repo *models.Repository, dir, message string, author service.Signature) error {
+	s.log.WithContext(ctx).Info("Importing repository from files",
+		logger.String("owner", repo.Owner.Username),
+		logger.String("name", repo.Name),
+		logger.Bool("is_private", repo.IsPrivate),
+	)
+
+	backend, err := s.storage.ForRepo(repo)
+	if err != nil {
+		return err
 	}
 
-	// Save to database
-	if err := s.repoRepo.Create(ctx, repo); err != nil {
-		s.log.Error("Failed to create repository in database",
+	repo.DefaultBranch = s.config.DefaultBranch
+	if err := s.gitService.InitRepository(ctx, repo.GitPath, true, repo.DefaultBranch); err != nil {
+		s.log.WithContext(ctx).Error("Failed to initialize git repository",
 			logger.Error(err),
-			logger.String("name", name),
+			logger.String("git_path", repo.GitPath),
 		)
-		// Cleanup git repository if database save fails
-		if cleanupErr := s.storage.DeleteDirectory(gitPath); cleanupErr != nil {
-			s.log.Error("Failed to cleanup git repository after database error",
-				logger.Error(cleanupErr),
-				logger.String("git_path", gitPath),
-			)
-		}
-		return nil, fmt.Errorf("failed to create repository: %w", err)
+		s.discardImport(ctx, backend, repo.GitPath)
+		return apperrors.GitError("initialize repository", err)
+	}
+	if _, err := s.gitService.CommitDirectory(ctx, repo.GitPath, repo.DefaultBranch, dir, message, author); err != nil {
+		s.log.WithContext(ctx).Error("Failed to commit imported files",
+			logger.Error(err),
+			logger.String("git_path", repo.GitPath),
+		)
+		s.discardImport(ctx, backend, repo.GitPath)
+		return apperrors.GitError("commit imported files", err)
 	}
 
-	// Set owner reference
-	repo.Owner = *owner
+	return s.storeImport(ctx, repo, backend)
+}
+
+// storeImport stores the record of an imported repository once its git
+// repository is complete, deleting the git repository if that fails
+func (s *RepoService) storeImport(ctx context.Context, repo *models.Repository, backend service.StorageService) error {
+	s.writeUploadPackConfig(ctx, repo)
 
-	s.log.Info("Repository imported successfully",
+	if err := backend.SyncToRemote(repo.GitPath); err != nil {
+		s.log.WithContext(ctx).Warn("Failed to sync imported repository to remote storage",
+			logger.Error(err),
+			logger.String("git_path", repo.GitPath),
+		)
+	}
+
+	// Save to database, without inserting the preloaded owner again
+	owner := repo.Owner
+	repo.Owner = models.User{}
+	err := s.repoRepo.Create(ctx, repo)
+	repo.Owner = owner
+	if err != nil {
+		s.log.WithContext(ctx).Error("Failed to create repository in database",
+			logger.Error(err),
+			logger.String("name", repo.Name),
+		)
+		s.discardImport(ctx, backend, repo.GitPath)
+		return fmt.Errorf("failed to create repository: %w", err)
+	}
+
+	s.log.WithContext(ctx).Info("Repository imported successfully",
 		logger.String("repo_id", repo.ID.String()),
 		logger.String("owner", owner.Username),
-		logger.String("name", name),
-		logger.String("git_path", gitPath),
-		logger.String("clone_url", cloneURL),
-		logger.Bool("mirror", mirror),
+		logger.String("name", repo.Name),
+		logger.String("git_path", repo.GitPath),
 	)
 
-	return repo, nil
+	s.publishCreated(repo, "import")
+	return nil
+}
+
+// DiscardImport deletes what an interrupted import left at gitPath on a
+// storage backend, unless a stored repository lives there
+func (s *RepoService) DiscardImport(ctx context.Context, ownerID uuid.UUID, name, backendName, gitPath string) error {
+	existing, err := s.repoRepo.FindByOwnerAndName(ctx, ownerID, name)
+	if err == nil && existing.GitPath == gitPath {
+		return nil
+	}
+	if err != nil && !apperrors.IsNotFound(err) {
+		return err
+	}
+
+	backend, err := s.storage.ForRepo(&models.Repository{StorageBackend: backendName})
+	if err != nil {
+		return err
+	}
+	s.discardImport(ctx, backend, gitPath)
+	return nil
+}
+
+// discardImport deletes the git repository of a failed import
+func (s *RepoService) discardImport(ctx context.Context, backend service.StorageService, gitPath string) {
+	if err := backend.DeleteDirectory(gitPath); err != nil {
+		s.log.WithContext(ctx).Error("Failed to cleanup git repository after failed import",
+			logger.Error(err),
+			logger.String("git_path", gitPath),
+		)
+	}
 }
 
 func (s *RepoService) GetRepository(ctx context.Context, ownerUsername, repoName string) (*models.Repository, error) {
@@ -335,14 +587,14 @@ func (s *RepoService) GetRepository(ctx context.Context, ownerUsername, repoName
 
 // UpdateMirrorSettings updates the mirror settings for a repository
 func (s *RepoService) UpdateMirrorSettings(ctx context.Context, repoID uuid.UUID, req *dto.UpdateMirrorSettingsRequest) (*models.Repository, error) {
-	s.log.Info("Updating mirror settings",
+	s.log.WithContext(ctx).Info("Updating mirror settings",
 		logger.String("repo_id", repoID.String()),
 	)
 
 	// Get repository
 	repo, err := s.repoRepo.FindByID(ctx, repoID)
 	if err != nil {
-		s.log.Error("Failed to find repository",
+		s.log.WithContext(ctx).Error("Failed to find repository",
 			logger.Error(err),
 			logger.String("repo_id", repoID.String()),
 		)
@@ -410,14 +662,14 @@ func (s *RepoService) UpdateMirrorSettings(ctx context.Context, repoID uuid.UUID
 
 	// Save updated repository
 	if err := s.repoRepo.Update(ctx, repo); err != nil {
-		s.log.Error("Failed to update repository",
+		s.log.WithContext(ctx).Error("Failed to update repository",
 			logger.Error(err),
 			logger.String("repo_id", repoID.String()),
 		)
 		return nil, err
 	}
 
-	s.log.Info("Mirror settings updated successfully",
+	s.log.WithContext(ctx).Info("Mirror settings updated successfully",
 		logger.String("repo_id", repoID.String()),
 	)
 
@@ -439,8 +691,37 @@ func (s *RepoService) ListPublicRepositories(ctx context.Context, limit, offset
 	return s.repoRepo.ListPublic(ctx, limit, offset)
 }
 
+// ListUserRepositoriesFiltered lists repositories owned by a user matching the filter
+func (s *RepoService) ListUserRepositoriesFiltered(ctx context.Context, ownerID uuid.UUID, filter repository.RepoFilter) ([]*models.Repository, error) {
+	return s.repoRepo.FindByOwnerFiltered(ctx, ownerID, filter)
+}
+
+// ListPublicRepositoriesFiltered lists public repositories matching the filter with pagination
+func (s *RepoService) ListPublicRepositoriesFiltered(ctx context.Context, filter repository.RepoFilter, limit, offset int) ([]*models.Repository, error) {
+	return s.repoRepo.ListPublicFiltered(ctx, filter, limit, offset)
+}
+
+// SearchRepositories finds repositories whose name or description contains
+// query and matching the filter, with pagination. Private repositories are
+// only included if viewer can read them, as its owner, a collaborator or a
+// site administrator, with credentials allowing it; pass nil for anonymous
+// callers.
+func (s *RepoService) SearchRepositories(ctx context.Context, query string, filter repository.RepoFilter, viewer *models.User, limit, offset int) ([]*models.Repository, error) {
+	if viewer != nil && !viewer.TokenAllows(models.RepoPermissionRead) {
+		viewer = nil
+	}
+	return s.repoRepo.Search(ctx, query, filter, viewer, limit, offset)
+}
+
+// ListForks lists the direct forks of a repository with pagination, oldest
+// first. Private forks are only included if viewerID owns them; pass nil for
+// anonymous callers.
+func (s *RepoService) ListForks(ctx context.Context, parentID uuid.UUID, viewerID *uuid.UUID, limit, offset int) ([]*models.Repository, error) {
+	return s.repoRepo.FindForks(ctx, parentID, viewerID, limit, offset)
+}
+
 // UpdateRepository updates a repository's metadata
-func (s *RepoService) UpdateRepository(ctx context.Context, id uuid.UUID, description *string, isPrivate *bool, defaultBranch *string) (*models.Repository, error) {
+func (s *RepoService) UpdateRepository(ctx context.Context, id uuid.UUID, description *string, isPrivate *bool, defaultBranch *string, largeFileHintsDisabled *bool) (*models.Repository, error) {
 	repo, err := s.repoRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -453,11 +734,14 @@ func (s *RepoService) UpdateRepository(ctx context.Context, id uuid.UUID, descri
 	if isPrivate != nil {
 		repo.IsPrivate = *isPrivate
 	}
+	if largeFileHintsDisabled != nil {
+		repo.LargeFileHintsDisabled = *largeFileHintsDisabled
+	}
 	if defaultBranch != nil {
 		// Verify the branch exists before updating
 		exists, err := s.gitService.BranchExists(ctx, repo.GitPath, *defaultBranch)
 		if err != nil {
-			s.log.Error("Failed to check if branch exists",
+			s.log.WithContext(ctx).Error("Failed to check if branch exists",
 				logger.Error(err),
 				logger.String("branch", *defaultBranch),
 			)
@@ -469,7 +753,7 @@ func (s *RepoService) UpdateRepository(ctx context.Context, id uuid.UUID, descri
 
 		// Update git HEAD to point to the new branch
 		if err := s.gitService.SetHEADBranch(ctx, repo.GitPath, *defaultBranch); err != nil {
-			s.log.Error("Failed to set default branch in git",
+			s.log.WithContext(ctx).Error("Failed to set default branch in git",
 				logger.Error(err),
 				logger.String("branch", *defaultBranch),
 			)
@@ -488,49 +772,103 @@ func (s *RepoService) UpdateRepository(ctx context.Context, id uuid.UUID, descri
 
 // DeleteRepository deletes a repository
 func (s *RepoService) DeleteRepository(ctx context.Context, id uuid.UUID) error {
-	s.log.Info("Deleting repository",
+	s.log.WithContext(ctx).Info("Deleting repository",
 		logger.String("repo_id", id.String()),
 	)
 
 	// Get repository to get git path
 	repo, err := s.repoRepo.FindByID(ctx, id)
 	if err != nil {
-		s.log.Error("Failed to find repository for deletion",
+		s.log.WithContext(ctx).Error("Failed to find repository for deletion",
 			logger.Error(err),
 			logger.String("repo_id", id.String()),
 		)
 		return err
 	}
 
-	s.log.Debug("Found repository for deletion",
+	s.log.WithContext(ctx).Debug("Found repository for deletion",
 		logger.String("name", repo.Name),
 		logger.String("git_path", repo.GitPath),
 	)
 
+	backend, err := s.storage.ForRepo(repo)
+	if err != nil {
+		return err
+	}
+
+	// Refuse deletion while the repository is migrated to another backend
+	release, err := s.storage.AcquireRepository(repo)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// Delete from database first
 	if err := s.repoRepo.Delete(ctx, id); err != nil {
-		s.log.Error("Failed to delete repository from database",
+		s.log.WithContext(ctx).Error("Failed to delete repository from database",
 			logger.Error(err),
 			logger.String("repo_id", id.String()),
 		)
 		return fmt.Errorf("failed to delete repository from database: %w", err)
 	}
 
-	// Delete git repository from storage
-	if err := s.storage.DeleteDirectory(repo.GitPath); err != nil {
-		s.log.Error("Failed to delete git repository from storage - manual cleanup may be required",
+	s.removeRepository(ctx, backend, repo)
+	return nil
+}
+
+// DeleteRepositoriesWith deletes repositories along with other records:
+// deleteRecords deletes the records of the repositories in the transaction
+// that deletes its own. The repositories are locked against storage
+// migrations first and removed from storage only once deleteRecords
+// succeeded, so a failure leaves them intact.
+func (s *RepoService) DeleteRepositoriesWith(ctx context.Context, repos []*models.Repository, deleteRecords func() error) error {
+	backends := make([]service.StorageService, len(repos))
+	for i, repo := range repos {
+		backend, err := s.storage.ForRepo(repo)
+		if err != nil {
+			return err
+		}
+		backends[i] = backend
+
+		release, err := s.storage.AcquireRepository(repo)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	if err := deleteRecords(); err != nil {
+		return err
+	}
+
+	for i, repo := range repos {
+		s.removeRepository(ctx, backends[i], repo)
+	}
+	return nil
+}
+
+// removeRepository deletes the git repository of a repository whose record
+// is deleted from storage, and announces the deletion
+func (s *RepoService) removeRepository(ctx context.Context, backend service.StorageService, repo *models.Repository) {
+	if err := backend.DeleteDirectory(repo.GitPath); err != nil {
+		s.log.WithContext(ctx).Error("Failed to delete git repository from storage - manual cleanup may be required",
 			logger.Error(err),
 			logger.String("git_path", repo.GitPath),
 		)
 		// Don't return error since database record is already deleted
 	}
 
-	s.log.Info("Repository deleted successfully",
-		logger.String("repo_id", id.String()),
+	s.log.WithContext(ctx).Info("Repository deleted successfully",
+		logger.String("repo_id", repo.ID.String()),
 		logger.String("name", repo.Name),
 	)
 
-	return nil
+	s.publisher.Publish(events.RepositoryDeleted{
+		RepositoryID: repo.ID,
+		OwnerID:      repo.OwnerID,
+		Owner:        repo.Owner.Username,
+		Name:         repo.Name,
+	})
 }
 
 // CanUserAccessRepository checks if a user can access a repository
@@ -550,14 +888,58 @@ func (s *RepoService) CanUserAccessRepository(ctx context.Context, userID *uuid.
 		return true
 	}
 
-	// TODO: Check collaborator permissions when implemented
+	return s.collaboratorPermission(ctx, *userID, repo).Allows(models.RepoPermission(action))
+}
+
+// RepositoryPermission returns the access a user has to a repository: admin for
+// the owner and site admins, the granted permission for collaborators, and read
+// for everyone else (including anonymous users) on public repositories. Requests
+// authenticated with a scoped token get no more than its scopes allow.
+func (s *RepoService) RepositoryPermission(ctx context.Context, user *models.User, repo *models.Repository) models.RepoPermission {
+	permission := s.userRepositoryPermission(ctx, user, repo)
+	if user != nil && !user.TokenAllows(permission) {
+		permission = user.Token.RepoPermission()
+	}
+	return permission
+}
+
+// userRepositoryPermission returns the access a user has to a repository,
+// regardless of the credentials of the request
+func (s *RepoService) userRepositoryPermission(ctx context.Context, user *models.User, repo *models.Repository) models.RepoPermission {
+	if user != nil && (user.IsAdmin || user.ID == repo.OwnerID) {
+		return models.RepoPermissionAdmin
+	}
 
-	return false
+	permission := models.RepoPermissionNone
+	if user != nil {
+		permission = s.collaboratorPermission(ctx, user.ID, repo)
+	}
+	if !repo.IsPrivate && !permission.Allows(models.RepoPermissionRead) {
+		permission = models.RepoPermissionRead
+	}
+	return permission
 }
 
-// GetRepositoryPath returns the storage path for a repository
-func (s *RepoService) GetRepositoryPath(ownerUsername, repoName string) string {
-	return s.storage.GetRepoPath(ownerUsername, repoName)
+// HasPermission returns true if the user has at least the required access to the repository
+func (s *RepoService) HasPermission(ctx context.Context, user *models.User, repo *models.Repository, required models.RepoPermission) bool {
+	return s.RepositoryPermission(ctx, user, repo).Allows(required)
+}
+
+// collaboratorPermission returns the permission granted to a user as a
+// collaborator. Lookup failures are logged and grant nothing.
+func (s *RepoService) collaboratorPermission(ctx context.Context, userID uuid.UUID, repo *models.Repository) models.RepoPermission {
+	collaborator, err := s.collabRepo.FindByRepositoryAndUser(ctx, repo.ID, userID)
+	if err != nil {
+		if !apperrors.IsNotFound(err) {
+			s.log.WithContext(ctx).Error("Failed to look up collaborator permission",
+				logger.Error(err),
+				logger.String("repo_id", repo.ID.String()),
+				logger.String("user_id", userID.String()),
+			)
+		}
+		return models.RepoPermissionNone
+	}
+	return collaborator.Permission
 }
 
 // RepositoryExists checks if a repository exists
@@ -577,19 +959,39 @@ func (s *RepoService) CountUserRepositories(ctx context.Context, ownerID uuid.UU
 	return s.repoRepo.CountByOwner(ctx, ownerID)
 }
 
+// IsEmpty returns true if a repository has no branches or tags yet, e.g.
+// before its first push
+func (s *RepoService) IsEmpty(ctx context.Context, repo *models.Repository) (bool, error) {
+	return s.gitService.IsEmpty(ctx, repo.GitPath)
+}
+
+// isEmpty returns true if a repository is known to be empty; reads failing
+// on an empty repository answer with empty results instead
+func (s *RepoService) isEmpty(ctx context.Context, repo *models.Repository) bool {
+	empty, err := s.gitService.IsEmpty(ctx, repo.GitPath)
+	return err == nil && empty
+}
+
 // ListBranches lists all branches in a repository
 func (s *RepoService) ListBranches(ctx context.Context, repo *models.Repository) ([]service.Branch, error) {
 	return s.gitService.ListBranches(ctx, repo.GitPath)
 }
 
-// CreateBranch creates a new branch in a repository
+// CreateBranch creates a new branch in a repository unless it has reached the branch limit
 func (s *RepoService) CreateBranch(ctx context.Context, repo *models.Repository, branchName, commitHash string) error {
+	if err := s.checkRefLimit(ctx, repo, "refs/heads/", "branch", s.config.MaxBranches); err != nil {
+		return err
+	}
 	return s.gitService.CreateBranch(ctx, repo.GitPath, branchName, commitHash)
 }
 
 // DeleteBranch deletes a branch from a repository
 func (s *RepoService) DeleteBranch(ctx context.Context, repo *models.Repository, branchName string) error {
-	// Check if it's the default branch
+	// Check if it's the configured default branch, or the one HEAD points to
+	// if they differ, e.g. before the first push set it
+	if repo.DefaultBranch == branchName {
+		return apperrors.BadRequest("cannot delete default branch", apperrors.ErrDefaultBranch)
+	}
 	defaultBranch, err := s.gitService.GetHEADBranch(ctx, repo.GitPath)
 	if err == nil && defaultBranch == branchName {
 		return apperrors.BadRequest("cannot delete default branch", apperrors.ErrDefaultBranch)
@@ -614,7 +1016,7 @@ func (s *RepoService) SetDefaultBranchOnPush(ctx context.Context, repo *models.R
 	// Get the current HEAD from git (which should point to the pushed branch)
 	defaultBranch, err := s.gitService.GetHEADBranch(ctx, repo.GitPath)
 	if err != nil {
-		s.log.Warn("Failed to get default branch from git",
+		s.log.WithContext(ctx).Warn("Failed to get default branch from git",
 			logger.Error(err),
 			logger.String("repo_id", repo.ID.String()),
 		)
@@ -626,7 +1028,7 @@ func (s *RepoService) SetDefaultBranchOnPush(ctx context.Context, repo *models.R
 	if defaultBranch != "" {
 		exists, err := s.gitService.BranchExists(ctx, repo.GitPath, defaultBranch)
 		if err != nil || !exists {
-			s.log.Debug("HEAD points to non-existent branch",
+			s.log.WithContext(ctx).Debug("HEAD points to non-existent branch",
 				logger.String("repo_id", repo.ID.String()),
 				logger.String("branch", defaultBranch),
 			)
@@ -638,7 +1040,7 @@ func (s *RepoService) SetDefaultBranchOnPush(ctx context.Context, repo *models.R
 		// Try to find any branch
 		branches, err := s.gitService.ListBranches(ctx, repo.GitPath)
 		if err != nil || len(branches) == 0 {
-			s.log.Debug("No branches found in repository",
+			s.log.WithContext(ctx).Debug("No branches found in repository",
 				logger.String("repo_id", repo.ID.String()),
 			)
 			return nil
@@ -647,7 +1049,7 @@ func (s *RepoService) SetDefaultBranchOnPush(ctx context.Context, repo *models.R
 
 		// Update git HEAD to point to the actual branch
 		if err := s.gitService.SetHEADBranch(ctx, repo.GitPath, defaultBranch); err != nil {
-			s.log.Error("Failed to set default branch in git",
+			s.log.WithContext(ctx).Error("Failed to set default branch in git",
 				logger.Error(err),
 				logger.String("repo_id", repo.ID.String()),
 				logger.String("branch", defaultBranch),
@@ -659,7 +1061,7 @@ func (s *RepoService) SetDefaultBranchOnPush(ctx context.Context, repo *models.R
 	// Update the repository record
 	repo.DefaultBranch = defaultBranch
 	if err := s.repoRepo.Update(ctx, repo); err != nil {
-		s.log.Error("Failed to update default branch in database",
+		s.log.WithContext(ctx).Error("Failed to update default branch in database",
 			logger.Error(err),
 			logger.String("repo_id", repo.ID.String()),
 			logger.String("branch", defaultBranch),
@@ -667,7 +1069,7 @@ func (s *RepoService) SetDefaultBranchOnPush(ctx context.Context, repo *models.R
 		return nil // Don't fail the push for this
 	}
 
-	s.log.Info("Default branch set after push",
+	s.log.WithContext(ctx).Info("Default branch set after push",
 		logger.String("repo_id", repo.ID.String()),
 		logger.String("branch", defaultBranch),
 	)
@@ -680,11 +1082,50 @@ func (s *RepoService) ListTags(ctx context.Context, repo *models.Repository) ([]
 	return s.gitService.ListTags(ctx, repo.GitPath)
 }
 
-// CreateTag creates a new tag in a repository
+// CreateTag creates a new tag in a repository unless it has reached the tag limit
 func (s *RepoService) CreateTag(ctx context.Context, repo *models.Repository, tagName, commitHash, message string) error {
+	if err := s.checkRefLimit(ctx, repo, "refs/tags/", "tag", s.config.MaxTags); err != nil {
+		return err
+	}
 	return s.gitService.CreateTag(ctx, repo.GitPath, tagName, commitHash, message)
 }
 
+// checkRefLimit returns an unprocessable error if the repository has limit or
+// more refs under prefix (0 = unlimited). kind names the refs in the error.
+func (s *RepoService) checkRefLimit(ctx context.Context, repo *models.Repository, prefix, kind string, limit int) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	refs, err := s.gitService.GetRefs(ctx, repo.GitPath)
+	if err != nil {
+		return fmt.Errorf("failed to list refs: %w", err)
+	}
+	count := 0
+	for name := range refs {
+		if strings.HasPrefix(name, prefix) {
+			count++
+		}
+	}
+	if count < limit {
+		return nil
+	}
+
+	s.log.WithContext(ctx).Warn("Repository ref limit reached",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("kind", kind),
+		logger.Int("limit", limit),
+	)
+	return apperrors.Unprocessable(
+		fmt.Sprintf("repository has reached its limit of %d %ss", limit, kind),
+		apperrors.ErrRefLimitExceeded,
+	).WithDetails(map[string]interface{}{
+		"kind":  kind,
+		"limit": limit,
+		"count": count,
+	})
+}
+
 // DeleteTag deletes a tag from a repository
 func (s *RepoService) DeleteTag(ctx context.Context, repo *models.Repository, tagName string) error {
 	return s.gitService.DeleteTag(ctx, repo.GitPath, tagName)
@@ -703,9 +1144,11 @@ func (s *RepoService) GetRepositoryStats(ctx context.Context, repo *models.Repos
 	}
 
 	// Get disk usage
-	diskUsage, err := s.storage.GetDiskUsage(repo.GitPath)
-	if err != nil {
-		diskUsage = 0
+	var diskUsage int64
+	if backend, err := s.storage.ForRepo(repo); err == nil {
+		if diskUsage, err = backend.GetDiskUsage(repo.GitPath); err != nil {
+			diskUsage = 0
+		}
 	}
 
 	// Calculate total commit count
@@ -717,12 +1160,35 @@ func (s *RepoService) GetRepositoryStats(ctx context.Context, repo *models.Repos
 	// Language Usage Percentage
 	languageUsagePerc := s.calculateLanguageUsagePercentage(ctx, repo)
 
+	forkCount, err := s.repoRepo.CountForks(ctx, repo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Object counts show whether the repository needs a garbage collection
+	objects, err := s.gitService.CountObjects(ctx, repo.GitPath)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to count repository objects",
+			logger.Error(err),
+			logger.String("git_path", repo.GitPath),
+		)
+		objects = &service.ObjectCounts{}
+	}
+
 	return &RepositoryStats{
 		BranchCount:       len(branches),
+		BranchLimit:       s.config.MaxBranches,
 		TagCount:          len(tags),
+		TagLimit:          s.config.MaxTags,
 		DiskUsage:         diskUsage,
 		TotalCommits:      totalCommits,
 		LanguageUsagePerc: languageUsagePerc,
+		ForkCount:         forkCount,
+		LooseObjects:      objects.LooseObjects,
+		PackedObjects:     objects.PackedObjects,
+		PackCount:         objects.Packs,
+		LastGCAt:          repo.LastGCAt,
+		LastGCDurationMS:  repo.LastGCDurationMS,
 	}, nil
 }
 
@@ -870,7 +1336,7 @@ func (s *RepoService) calculateLanguageUsagePercentage(ctx context.Context, repo
 	}
 
 	if err := walk(""); err != nil {
-		s.log.Error("Failed to walk tree for stats",
+		s.log.WithContext(ctx).Error("Failed to walk tree for stats",
 			logger.String("repo_path", repo.GitPath),
 			logger.Error(err),
 		)
@@ -894,15 +1360,24 @@ func (s *RepoService) calculateLanguageUsagePercentage(ctx context.Context, repo
 // RepositoryStats holds statistics for a repository
 type RepositoryStats struct {
 	BranchCount       int                `json:"branch_count"`
+	BranchLimit       int                `json:"branch_limit"` // Most branches the API creates, 0 for unlimited
 	TagCount          int                `json:"tag_count"`
+	TagLimit          int                `json:"tag_limit"` // Most tags the API creates, 0 for unlimited
 	DiskUsage         int64              `json:"disk_usage"`
 	TotalCommits      int                `json:"total_commits"`
 	LanguageUsagePerc map[string]float64 `json:"language_usage_perc"`
+	LargeFilesAdded   int64              `json:"large_files_added"` // Large files pushed without Git LFS in the last 30 days
+	ForkCount         int64              `json:"fork_count"`        // Direct forks, including private ones
+	LooseObjects      int64              `json:"loose_objects"`     // Objects not packed yet, collected by garbage collections
+	PackedObjects     int64              `json:"packed_objects"`
+	PackCount         int64              `json:"pack_count"`
+	LastGCAt          *time.Time         `json:"last_gc_at"` // nil if the repository was never collected
+	LastGCDurationMS  int64              `json:"last_gc_duration_ms"`
 }
 
 // TransferRepository transfers a repository to a new owner
 func (s *RepoService) TransferRepository(ctx context.Context, repoID, newOwnerID uuid.UUID) (*models.Repository, error) {
-	s.log.Info("Transferring repository",
+	s.log.WithContext(ctx).Info("Transferring repository",
 		logger.String("repo_id", repoID.String()),
 		logger.String("new_owner_id", newOwnerID.String()),
 	)
@@ -910,7 +1385,7 @@ func (s *RepoService) TransferRepository(ctx context.Context, repoID, newOwnerID
 	// Get repository
 	repo, err := s.repoRepo.FindByID(ctx, repoID)
 	if err != nil {
-		s.log.Error("Failed to find repository for transfer",
+		s.log.WithContext(ctx).Error("Failed to find repository for transfer",
 			logger.Error(err),
 			logger.String("repo_id", repoID.String()),
 		)
@@ -920,7 +1395,7 @@ func (s *RepoService) TransferRepository(ctx context.Context, repoID, newOwnerID
 	// Get new owner
 	newOwner, err := s.userRepo.FindByID(ctx, newOwnerID)
 	if err != nil {
-		s.log.Error("Failed to find new owner for transfer",
+		s.log.WithContext(ctx).Error("Failed to find new owner for transfer",
 			logger.Error(err),
 			logger.String("new_owner_id", newOwnerID.String()),
 		)
@@ -930,30 +1405,41 @@ func (s *RepoService) TransferRepository(ctx context.Context, repoID, newOwnerID
 	// Check if new owner already has a repo with this name
 	exists, err := s.repoRepo.ExistsByOwnerAndName(ctx, newOwnerID, repo.Name)
 	if err != nil {
-		s.log.Error("Failed to check repository existence for transfer",
+		s.log.WithContext(ctx).Error("Failed to check repository existence for transfer",
 			logger.Error(err),
 		)
 		return nil, fmt.Errorf("failed to check repository existence: %w", err)
 	}
 	if exists {
-		s.log.Warn("Transfer failed - new owner already has repository with same name",
+		s.log.WithContext(ctx).Warn("Transfer failed - new owner already has repository with same name",
 			logger.String("new_owner", newOwner.Username),
 			logger.String("repo_name", repo.Name),
 		)
 		return nil, apperrors.Conflict("new owner already has a repository with this name", apperrors.ErrRepositoryExists)
 	}
 
+	// The repository stays on its storage backend
+	backend, err := s.storage.ForRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	release, err := s.storage.AcquireRepository(repo)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// Get old and new paths
 	oldPath := repo.GitPath
-	newPath := s.storage.GetRepoPath(newOwner.Username, repo.Name)
+	newPath := backend.GetRepoPath(newOwner.Username, repo.Name)
 
 	// Move git repository
-	s.log.Debug("Moving git repository",
+	s.log.WithContext(ctx).Debug("Moving git repository",
 		logger.String("old_path", oldPath),
 		logger.String("new_path", newPath),
 	)
-	if err := s.storage.MoveFile(oldPath, newPath); err != nil {
-		s.log.Error("Failed to move git repository",
+	if err := backend.MoveFile(oldPath, newPath); err != nil {
+		s.log.WithContext(ctx).Error("Failed to move git repository",
 			logger.Error(err),
 			logger.String("old_path", oldPath),
 			logger.String("new_path", newPath),
@@ -962,16 +1448,17 @@ func (s *RepoService) TransferRepository(ctx context.Context, repoID, newOwnerID
 	}
 
 	// Update database record
+	previousOwnerID, previousOwner := repo.OwnerID, repo.Owner.Username
 	repo.OwnerID = newOwnerID
 	repo.GitPath = newPath
 
 	if err := s.repoRepo.Update(ctx, repo); err != nil {
-		s.log.Error("Failed to update repository after move",
+		s.log.WithContext(ctx).Error("Failed to update repository after move",
 			logger.Error(err),
 		)
 		// Try to move back on failure
-		if moveErr := s.storage.MoveFile(newPath, oldPath); moveErr != nil {
-			s.log.Error("Failed to rollback repository move",
+		if moveErr := backend.MoveFile(newPath, oldPath); moveErr != nil {
+			s.log.WithContext(ctx).Error("Failed to rollback repository move",
 				logger.Error(moveErr),
 				logger.String("new_path", newPath),
 				logger.String("old_path", oldPath),
@@ -982,28 +1469,63 @@ func (s *RepoService) TransferRepository(ctx context.Context, repoID, newOwnerID
 
 	repo.Owner = *newOwner
 
-	s.log.Info("Repository transferred successfully",
+	s.log.WithContext(ctx).Info("Repository transferred successfully",
 		logger.String("repo_id", repoID.String()),
 		logger.String("repo_name", repo.Name),
 		logger.String("new_owner", newOwner.Username),
 	)
+	s.publisher.Publish(events.RepositoryTransferred{
+		RepositoryID:    repo.ID,
+		Name:            repo.Name,
+		PreviousOwnerID: previousOwnerID,
+		PreviousOwner:   previousOwner,
+		OwnerID:         newOwner.ID,
+		Owner:           newOwner.Username,
+	})
 
 	return repo, nil
 }
 
+// TransferRepositoryToUser transfers a repository to the user with a
+// username
+func (s *RepoService) TransferRepositoryToUser(ctx context.Context, repoID uuid.UUID, newOwnerUsername string) (*models.Repository, error) {
+	newOwner, err := s.userRepo.FindByUsername(ctx, newOwnerUsername)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil, apperrors.BadRequest(fmt.Sprintf("user %q does not exist", newOwnerUsername), apperrors.ErrInvalidInput)
+		}
+		return nil, err
+	}
+	return s.TransferRepository(ctx, repoID, newOwner.ID)
+}
+
 // GetCommits returns a list of commits for a repository
 func (s *RepoService) GetCommits(ctx context.Context, repo *models.Repository, ref string, limit, offset int) ([]service.Commit, error) {
 	if limit <= 0 {
 		limit = 30
 	}
-	if limit > 100 {
-		limit = 100
+	// One over the largest page, so callers can tell whether another page follows
+	if limit > 101 {
+		limit = 101
 	}
 	if offset < 0 {
 		offset = 0
 	}
 
-	return s.gitService.GetCommits(ctx, repo.GitPath, ref, limit, offset)
+	commits, err := s.gitService.GetCommits(ctx, repo.GitPath, ref, limit, offset)
+	if err != nil && s.isEmpty(ctx, repo) {
+		return []service.Commit{}, nil
+	}
+	return commits, err
+}
+
+// CountCommits returns the number of commits reachable from a ref of a repository
+func (s *RepoService) CountCommits(ctx context.Context, repo *models.Repository, ref string) (int, error) {
+	count, err := s.gitService.CountCommits(ctx, repo.GitPath, ref)
+	if err != nil && s.isEmpty(ctx, repo) {
+		return 0, nil
+	}
+	return count, err
 }
 
 // GetCommit returns a single commit by hash
@@ -1013,12 +1535,76 @@ func (s *RepoService) GetCommit(ctx context.Context, repo *models.Repository, co
 
 // GetTree returns the tree entries for a repository at a given ref and path
 func (s *RepoService) GetTree(ctx context.Context, repo *models.Repository, ref, path string) ([]service.TreeEntry, error) {
-	return s.gitService.GetTree(ctx, repo.GitPath, ref, path)
+	entries, err := s.gitService.GetTree(ctx, repo.GitPath, ref, path)
+	// The root of an empty repository lists nothing, other paths do not exist
+	if err != nil && strings.Trim(path, "/") == "" && s.isEmpty(ctx, repo) {
+		return []service.TreeEntry{}, nil
+	}
+	return entries, err
 }
 
-// GetFileContent returns the content of a file in a repository
+// GetFileContent returns the content of a file in a repository. Files above
+// repos.max_inline_file_size are returned without content, Truncated set;
+// whether they are binary is told from their start.
 func (s *RepoService) GetFileContent(ctx context.Context, repo *models.Repository, ref, filePath string) (*service.FileContent, error) {
-	return s.gitService.GetFileContent(ctx, repo.GitPath, ref, filePath)
+	blob, err := s.gitService.OpenFile(ctx, repo.GitPath, ref, filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Content.Close()
+
+	file := &service.FileContent{
+		Path: blob.Path,
+		Name: blob.Name,
+		Size: blob.Size,
+		Hash: blob.Hash,
+	}
+
+	limit := s.config.MaxInlineFileSize
+	file.Truncated = limit > 0 && blob.Size > limit
+	reader := io.Reader(blob.Content)
+	if file.Truncated {
+		reader = io.LimitReader(blob.Content, maxBinarySniffLength)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file content: %w", err)
+	}
+
+	file.IsBinary = service.IsBinaryContent(content, file.Truncated)
+	file.Encoding = "utf-8"
+	if file.IsBinary {
+		file.Encoding = "base64"
+	}
+	if !file.Truncated {
+		file.Content = content
+	}
+	return file, nil
+}
+
+// OpenRawFile opens a file of a repository for streaming. Files above
+// repos.max_raw_file_size are refused with ErrRawFileTooLarge unless
+// allowLarge is set. The caller must close FileBlob.Content.
+func (s *RepoService) OpenRawFile(ctx context.Context, repo *models.Repository, ref, filePath string, allowLarge bool) (*service.FileBlob, error) {
+	blob, err := s.gitService.OpenFile(ctx, repo.GitPath, ref, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !allowLarge && s.config.MaxRawFileSize > 0 && blob.Size > s.config.MaxRawFileSize {
+		blob.Content.Close()
+		return nil, fmt.Errorf("%w: %d bytes, the limit is %d bytes", ErrRawFileTooLarge, blob.Size, s.config.MaxRawFileSize)
+	}
+	return blob, nil
+}
+
+// GetContributorStats returns the contributor statistics of the history of a
+// ref, within since and until if set. An empty repository has none.
+func (s *RepoService) GetContributorStats(ctx context.Context, repo *models.Repository, ref string, since, until time.Time) (*service.ContributorActivity, error) {
+	activity, err := s.gitService.GetContributorStats(ctx, repo.GitPath, ref, since, until)
+	if err != nil && ref == "" && s.isEmpty(ctx, repo) {
+		return &service.ContributorActivity{Contributors: []service.ContributorStats{}, Weeks: []service.WeeklyCommits{}}, nil
+	}
+	return activity, err
 }
 
 // GetBlame returns blame information for a file in a repository
@@ -1036,9 +1622,80 @@ func (s *RepoService) GetCompareDiff(ctx context.Context, repo *models.Repositor
 	return s.gitService.GetCompareDiff(ctx, repo.GitPath, from, to)
 }
 
+// CompareRefs compares head with base, both revisions of repo
+func (s *RepoService) CompareRefs(ctx context.Context, repo *models.Repository, base, head string) (*service.Comparison, error) {
+	return s.gitService.CompareRefs(ctx, repo.GitPath, base, head)
+}
+
+// GetForkCompareDiff returns the changes of a branch of fork since its merge
+// base with base, a revision of repo
+func (s *RepoService) GetForkCompareDiff(ctx context.Context, repo *models.Repository, base string, fork *models.Repository, branch string) (*service.DiffResult, error) {
+	return s.gitService.GetForkCompareDiff(ctx, repo.GitPath, base, fork.GitPath, branch)
+}
+
+// maxForkDepth bounds the walk up a fork network to its root
+const maxForkDepth = 64
+
+// FindForkByOwner finds the repository owned by ownerUsername in the fork
+// network of repo, that is a repository sharing the same root. If the owner
+// has several, the most recently created one is returned.
+func (s *RepoService) FindForkByOwner(ctx context.Context, repo *models.Repository, ownerUsername string) (*models.Repository, error) {
+	owner, err := s.userRepo.FindByUsername(ctx, ownerUsername)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil, apperrors.NotFound("fork", apperrors.ErrNotFound)
+		}
+		return nil, err
+	}
+
+	root, err := s.forkNetworkRoot(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.repoRepo.FindByOwner(ctx, owner.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		if candidate.ID == repo.ID || (candidate.ForkedFromID == nil && candidate.ID != root) {
+			continue
+		}
+		candidateRoot, err := s.forkNetworkRoot(ctx, candidate)
+		if err != nil {
+			return nil, err
+		}
+		if candidateRoot == root {
+			return candidate, nil
+		}
+	}
+
+	return nil, apperrors.NotFound("fork", apperrors.ErrNotFound)
+}
+
+// forkNetworkRoot returns the ID of the repository at the root of the fork network of repo
+func (s *RepoService) forkNetworkRoot(ctx context.Context, repo *models.Repository) (uuid.UUID, error) {
+	current := repo
+	for range maxForkDepth {
+		if current.ForkedFromID == nil {
+			return current.ID, nil
+		}
+		parent, err := s.repoRepo.FindByID(ctx, *current.ForkedFromID)
+		if err != nil {
+			if apperrors.IsNotFound(err) {
+				// Parent deleted concurrently; treat the fork as the root
+				return current.ID, nil
+			}
+			return uuid.Nil, err
+		}
+		current = parent
+	}
+	return current.ID, nil
+}
+
 // ForkRepository creates a fork of a repository
 func (s *RepoService) ForkRepository(ctx context.Context, sourceRepoID, newOwnerID uuid.UUID, newName string) (*models.Repository, error) {
-	s.log.Info("Forking repository",
+	s.log.WithContext(ctx).Info("Forking repository",
 		logger.String("source_repo_id", sourceRepoID.String()),
 		logger.String("new_owner_id", newOwnerID.String()),
 		logger.String("new_name", newName),
@@ -1047,7 +1704,7 @@ func (s *RepoService) ForkRepository(ctx context.Context, sourceRepoID, newOwner
 	// Get source repository
 	sourceRepo, err := s.repoRepo.FindByID(ctx, sourceRepoID)
 	if err != nil {
-		s.log.Error("Failed to find source repository for fork",
+		s.log.WithContext(ctx).Error("Failed to find source repository for fork",
 			logger.Error(err),
 			logger.String("source_repo_id", sourceRepoID.String()),
 		)
@@ -1068,29 +1725,40 @@ func (s *RepoService) ForkRepository(ctx context.Context, sourceRepoID, newOwner
 	// Check if new owner already has a repo with this name
 	exists, err := s.repoRepo.ExistsByOwnerAndName(ctx, newOwnerID, newName)
 	if err != nil {
-		s.log.Error("Failed to check repository existence for fork",
+		s.log.WithContext(ctx).Error("Failed to check repository existence for fork",
 			logger.Error(err),
 		)
 		return nil, fmt.Errorf("failed to check repository existence: %w", err)
 	}
 	if exists {
-		s.log.Warn("Fork failed - user already has repository with same name",
+		s.log.WithContext(ctx).Warn("Fork failed - user already has repository with same name",
 			logger.String("new_owner", newOwner.Username),
 			logger.String("repo_name", newName),
 		)
 		return nil, apperrors.Conflict("you already have a repository with this name", apperrors.ErrRepositoryExists)
 	}
+	if err := s.checkRepositoryLimit(ctx, newOwner); err != nil {
+		return nil, err
+	}
 
-	// Build new git path
-	newGitPath := s.storage.GetRepoPath(newOwner.Username, newName)
+	// Build new git path on the backend the placement rules pick for the size of the source
+	var sourceSize int64
+	if sourceBackend, err := s.storage.ForRepo(sourceRepo); err == nil {
+		sourceSize, _ = sourceBackend.GetDiskUsage(sourceRepo.GitPath)
+	}
+	backendName, backend, err := s.storage.Place(newOwner.Username, sourceSize)
+	if err != nil {
+		return nil, err
+	}
+	newGitPath := backend.GetRepoPath(newOwner.Username, newName)
 
 	// Clone the repository
-	s.log.Debug("Cloning repository for fork",
+	s.log.WithContext(ctx).Debug("Cloning repository for fork",
 		logger.String("source_path", sourceRepo.GitPath),
 		logger.String("new_path", newGitPath),
 	)
-	if err := s.gitService.CloneRepository(ctx, sourceRepo.GitPath, newGitPath, "", "", false); err != nil {
-		s.log.Error("Failed to clone repository for fork",
+	if err := s.gitService.CloneRepository(ctx, sourceRepo.GitPath, newGitPath, "", "", false, nil); err != nil {
+		s.log.WithContext(ctx).Error("Failed to clone repository for fork",
 			logger.Error(err),
 			logger.String("source_path", sourceRepo.GitPath),
 			logger.String("new_path", newGitPath),
@@ -1100,20 +1768,24 @@ func (s *RepoService) ForkRepository(ctx context.Context, sourceRepoID, newOwner
 
 	// Create repository record
 	newRepo := &models.Repository{
-		Name:        newName,
-		OwnerID:     newOwnerID,
-		IsPrivate:   sourceRepo.IsPrivate,
-		Description: fmt.Sprintf("Fork of %s/%s", sourceRepo.Owner.Username, sourceRepo.Name),
-		GitPath:     newGitPath,
+		Name:           newName,
+		OwnerID:        newOwnerID,
+		IsPrivate:      sourceRepo.IsPrivate,
+		Description:    fmt.Sprintf("Fork of %s/%s", sourceRepo.Owner.Username, sourceRepo.Name),
+		GitPath:        newGitPath,
+		StorageBackend: backendName,
+		ForkedFromID:   &sourceRepo.ID,
+		UploadPack:     models.DefaultUploadPackSettings(),
 	}
+	s.writeUploadPackConfig(ctx, newRepo)
 
 	if err := s.repoRepo.Create(ctx, newRepo); err != nil {
-		s.log.Error("Failed to create forked repository in database",
+		s.log.WithContext(ctx).Error("Failed to create forked repository in database",
 			logger.Error(err),
 		)
 		// Cleanup on failure
-		if cleanupErr := s.storage.DeleteDirectory(newGitPath); cleanupErr != nil {
-			s.log.Error("Failed to cleanup forked repository after database error",
+		if cleanupErr := backend.DeleteDirectory(newGitPath); cleanupErr != nil {
+			s.log.WithContext(ctx).Error("Failed to cleanup forked repository after database error",
 				logger.Error(cleanupErr),
 				logger.String("git_path", newGitPath),
 			)
@@ -1122,8 +1794,10 @@ func (s *RepoService) ForkRepository(ctx context.Context, sourceRepoID, newOwner
 	}
 
 	newRepo.Owner = *newOwner
+	newRepo.ForkedFrom = sourceRepo
+	s.publishCreated(newRepo, "fork")
 
-	s.log.Info("Repository forked successfully",
+	s.log.WithContext(ctx).Info("Repository forked successfully",
 		logger.String("source_repo", fmt.Sprintf("%s/%s", sourceRepo.Owner.Username, sourceRepo.Name)),
 		logger.String("new_repo", fmt.Sprintf("%s/%s", newOwner.Username, newName)),
 		logger.String("new_repo_id", newRepo.ID.String()),
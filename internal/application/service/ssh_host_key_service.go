@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// SSHHostKey is a public host key of the SSH server
+type SSHHostKey struct {
+	Type        string // Key algorithm, e.g. ssh-ed25519
+	PublicKey   string // Key in authorized_keys format ("<type> <base64>")
+	Fingerprint string // SHA256 fingerprint as printed by ssh-keygen -l
+}
+
+// SSHHostKeyService publishes the host keys of the SSH server so clients can
+// add them to known_hosts instead of trusting the server on first use.
+// Keys are derived from the host key files the SSH server loads, read on every
+// call, so they cannot drift from it and a rotated key is published at once.
+type SSHHostKeyService struct {
+	config *config.SSHConfig
+	log    *logger.Logger
+}
+
+// NewSSHHostKeyService creates a new SSHHostKeyService instance
+func NewSSHHostKeyService(cfg *config.SSHConfig) *SSHHostKeyService {
+	return &SSHHostKeyService{
+		config: cfg,
+		log:    logger.Get().WithFields(logger.Component("ssh-host-key-service")),
+	}
+}
+
+// Enabled returns true if the SSH server is enabled
+func (s *SSHHostKeyService) Enabled() bool {
+	return s.config.Enabled
+}
+
+// Port returns the port the SSH server listens on
+func (s *SSHHostKeyService) Port() int {
+	return s.config.Port
+}
+
+// HostKeys returns the public host keys of the SSH server. It returns no keys
+// if SSH is disabled, and skips keys the server has not generated yet.
+func (s *SSHHostKeyService) HostKeys() ([]SSHHostKey, error) {
+	if !s.config.Enabled {
+		return nil, nil
+	}
+
+	var keys []SSHHostKey
+	for _, path := range s.config.HostKeyPaths() {
+		key, err := s.hostKey(path)
+		if err != nil {
+			return nil, err
+		}
+		if key != nil {
+			keys = append(keys, *key)
+		}
+	}
+	return keys, nil
+}
+
+// hostKey returns the public key of the host key file at path, or nil if
+// there is no such file
+func (s *SSHHostKeyService) hostKey(path string) (*SSHHostKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		s.log.Error("Failed to read SSH host key",
+			logger.Error(err),
+			logger.String("host_key_path", path),
+		)
+		return nil, fmt.Errorf("failed to read SSH host key: %w", err)
+	}
+
+	// The SSH server parses the file the same way
+	signer, err := gossh.ParsePrivateKey(data)
+	if err != nil {
+		s.log.Error("Failed to parse SSH host key",
+			logger.Error(err),
+			logger.String("host_key_path", path),
+		)
+		return nil, fmt.Errorf("failed to parse SSH host key: %w", err)
+	}
+
+	key := signer.PublicKey()
+	return &SSHHostKey{
+		Type:        key.Type(),
+		PublicKey:   strings.TrimSpace(string(gossh.MarshalAuthorizedKey(key))),
+		Fingerprint: gossh.FingerprintSHA256(key),
+	}, nil
+}
@@ -1,9 +1,8 @@
 package service
 
 import (
+	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/base64"
 	"fmt"
 	"strings"
 
@@ -47,10 +46,13 @@ type AddSSHKeyResponse struct {
 // AddSSHKey adds a new SSH public key for a user
 func (s *SSHKeyService) AddSSHKey(ctx context.Context, req AddSSHKeyRequest) (*AddSSHKeyResponse, error) {
 	// Validate the public key format
-	parsedKey, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(req.PublicKey))
+	parsedKey, comment, _, rest, err := ssh.ParseAuthorizedKey([]byte(req.PublicKey))
 	if err != nil {
 		return nil, apperrors.BadRequest("invalid ssh key format", apperrors.ErrInvalidSSHKey)
 	}
+	if len(bytes.TrimSpace(rest)) > 0 {
+		return nil, apperrors.BadRequest("only one ssh key can be added at a time", apperrors.ErrInvalidSSHKey)
+	}
 
 	// Generate fingerprint (SHA256 format like OpenSSH)
 	fingerprint := generateFingerprint(parsedKey)
@@ -142,11 +144,10 @@ func (s *SSHKeyService) CountUserSSHKeys(ctx context.Context, userID uuid.UUID)
 }
 
 // generateFingerprint generates a SHA256 fingerprint for an SSH public key
-// Returns the fingerprint in the format "SHA256:base64encodedHash"
+// Returns the fingerprint in the format "SHA256:base64encodedHash", computed
+// like the SSH server does when it looks up the key of a connection
 func generateFingerprint(pubKey ssh.PublicKey) string {
-	hash := sha256.Sum256(pubKey.Marshal())
-	encoded := base64.RawStdEncoding.EncodeToString(hash[:])
-	return "SHA256:" + encoded
+	return ssh.FingerprintSHA256(pubKey)
 }
 
 // ValidateSSHKey validates an SSH public key string without storing it
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// staleFileCleanupInterval is the time between scans of all repositories
+	// for leftover lock and temporary files
+	staleFileCleanupInterval = time.Hour
+
+	// staleFileCleanupJitter spreads the scans for leftover files
+	staleFileCleanupJitter = 5 * time.Minute
+
+	// staleFileScanBatch is the number of repositories loaded at a time
+	// while scanning all of them
+	staleFileScanBatch = 100
+)
+
+// StaleFileService removes the lock and temporary files git leaves in a
+// repository when it is killed midway, e.g. refs/heads/main.lock, which fail
+// every later push to the ref with "cannot lock ref". A file counts as stale
+// once it is older than repos.stale_lock_minutes and no git operation of this
+// process is in flight on the repository; git keeps the files it works on
+// fresh, so the age covers operations of other replicas.
+type StaleFileService struct {
+	repoRepo   repository.RepoRepository
+	storage    *StorageBackendService
+	gitService service.GitService
+	audit      service.AuditRecorder
+	cfg        *config.ReposConfig
+	log        *logger.Logger
+}
+
+// NewStaleFileService creates a new StaleFileService instance
+func NewStaleFileService(
+	repoRepo repository.RepoRepository,
+	storage *StorageBackendService,
+	gitService service.GitService,
+	audit service.AuditRecorder,
+	cfg *config.ReposConfig,
+) *StaleFileService {
+	return &StaleFileService{
+		repoRepo:   repoRepo,
+		storage:    storage,
+		gitService: gitService,
+		audit:      audit,
+		cfg:        cfg,
+		log:        logger.Get().WithFields(logger.Component("stale-file-service")),
+	}
+}
+
+// CleanRepository removes the stale files of a repository and returns them.
+// It fails with a conflict while a git operation is in flight on the
+// repository. actor is the administrator asking for it, nil for the
+// housekeeping task.
+func (s *StaleFileService) CleanRepository(ctx context.Context, repo *models.Repository, actor *models.User) ([]service.StaleFile, error) {
+	if s.cfg.StaleLockAge() == 0 {
+		return nil, apperrors.Conflict("stale file cleanup is disabled by repos.stale_lock_minutes", apperrors.ErrConfigError)
+	}
+	if s.storage.OperationsInFlight(repo.ID) > 0 {
+		return nil, apperrors.Conflict("a git operation is in progress on the repository, try again shortly", apperrors.ErrStorageError)
+	}
+
+	removed, err := s.gitService.RemoveStaleFiles(ctx, repo.GitPath, time.Now().Add(-s.cfg.StaleLockAge()))
+	if len(removed) > 0 {
+		s.record(repo, actor, removed)
+	}
+	if err != nil {
+		return removed, apperrors.StorageError("remove stale files", err)
+	}
+	return removed, nil
+}
+
+// CleanupTask returns the housekeeping task removing the stale files of all
+// repositories, or nil if stale files are never removed
+func (s *StaleFileService) CleanupTask() *HousekeepingTask {
+	if s.cfg.StaleLockAge() == 0 {
+		return nil
+	}
+	return &HousekeepingTask{
+		Name:        "git.stale_files",
+		Description: "Remove lock and temporary files older than repos.stale_lock_minutes left in repositories by killed git processes",
+		Interval:    staleFileCleanupInterval,
+		Jitter:      staleFileCleanupJitter,
+		Run:         s.cleanup,
+	}
+}
+
+// cleanup removes the stale files of every repository. Repositories that
+// are busy are left for the next run; a repository that cannot be cleaned
+// does not stop the others.
+func (s *StaleFileService) cleanup(ctx context.Context) error {
+	var scanned, cleaned, busy, failed int
+	for offset := 0; ; offset += staleFileScanBatch {
+		repos, err := s.repoRepo.ListAll(ctx, staleFileScanBatch, offset)
+		if err != nil {
+			return err
+		}
+
+		for _, repo := range repos {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			scanned++
+
+			removed, err := s.CleanRepository(ctx, repo, nil)
+			switch {
+			case apperrors.IsConflict(err):
+				busy++
+			case err != nil:
+				failed++
+				s.log.WithContext(ctx).Warn("Failed to remove stale files of repository",
+					logger.Error(err),
+					logger.String("repository", repo.GetFullName()),
+				)
+			case len(removed) > 0:
+				cleaned++
+			}
+		}
+
+		if len(repos) < staleFileScanBatch {
+			break
+		}
+	}
+
+	if cleaned > 0 || failed > 0 {
+		s.log.WithContext(ctx).Info("Removed stale files of repositories",
+			logger.Int("scanned", scanned),
+			logger.Int("cleaned", cleaned),
+			logger.Int("busy", busy),
+			logger.Int("failed", failed),
+		)
+	}
+	return nil
+}
+
+// record logs and audits the stale files removed from a repository
+func (s *StaleFileService) record(repo *models.Repository, actor *models.User, removed []service.StaleFile) {
+	paths := make([]string, len(removed))
+	for i, file := range removed {
+		paths[i] = file.Path
+	}
+
+	s.log.Warn("Removed stale files left by interrupted git operations",
+		logger.String("repository", repo.GetFullName()),
+		logger.Strings("files", paths),
+	)
+
+	entry := service.AuditEntry{
+		Category: "admin",
+		Action:   "repository.stale_files_remove",
+		Resource: repo.GetFullName(),
+		Outcome:  "success",
+		Fields: map[string]string{
+			"count": strconv.Itoa(len(removed)),
+			"files": strings.Join(paths, ","),
+		},
+	}
+	if actor != nil {
+		entry.ActorID = actor.ID.String()
+		entry.Actor = actor.Username
+	}
+	s.audit.Record(entry)
+}
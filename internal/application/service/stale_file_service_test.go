@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// fakeStaleGit removes the stale files it was given for each repository
+// path, failing for the paths in failing
+type fakeStaleGit struct {
+	service.GitService
+	mu        sync.Mutex
+	stale     map[string][]service.StaleFile
+	failing   map[string]bool
+	olderThan []time.Time
+	cleaned   []string
+}
+
+func (f *fakeStaleGit) RemoveStaleFiles(_ context.Context, repoPath string, olderThan time.Time) ([]service.StaleFile, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.olderThan = append(f.olderThan, olderThan)
+	if f.failing[repoPath] {
+		return nil, errors.New("permission denied")
+	}
+	f.cleaned = append(f.cleaned, repoPath)
+	removed := f.stale[repoPath]
+	delete(f.stale, repoPath)
+	return removed, nil
+}
+
+// newTestStaleFileService returns a StaleFileService cleaning repos
+func newTestStaleFileService(git *fakeStaleGit, audit *fakeAudit, staleLockMinutes int, repos ...*models.Repository) *StaleFileService {
+	repoRepo := &fakeRepoRepo{repos: repos}
+	storage := NewStorageBackendService(fakeStorageBackends{}, repoRepo, git, audit)
+	return NewStaleFileService(repoRepo, storage, git, audit, &config.ReposConfig{StaleLockMinutes: staleLockMinutes})
+}
+
+// newStaleRepo returns a repository named name stored at /repos/<name>.git
+func newStaleRepo(name string) *models.Repository {
+	return &models.Repository{ID: uuid.New(), Name: name, GitPath: "/repos/" + name + ".git", Owner: models.User{Username: "alice"}}
+}
+
+func TestStaleFileCleanupTask(t *testing.T) {
+	idle, busy, broken, clean := newStaleRepo("idle"), newStaleRepo("busy"), newStaleRepo("broken"), newStaleRepo("clean")
+	repos := []*models.Repository{idle, busy, broken, clean}
+	// More repositories than are loaded at a time
+	for i := range staleFileScanBatch {
+		repos = append(repos, newStaleRepo(fmt.Sprintf("filler-%d", i)))
+	}
+
+	git := &fakeStaleGit{
+		stale: map[string][]service.StaleFile{
+			idle.GitPath: {{Path: "refs/heads/main.lock"}, {Path: "objects/pack/tmp_pack_a1b2c3"}},
+			busy.GitPath: {{Path: "refs/heads/main.lock"}},
+		},
+		failing: map[string]bool{broken.GitPath: true},
+	}
+	audit := &fakeAudit{}
+	s := newTestStaleFileService(git, audit, 60, repos...)
+
+	release, err := s.storage.AcquireRepository(busy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	task := s.CleanupTask()
+	if task == nil || task.Name != "git.stale_files" {
+		t.Fatalf("CleanupTask = %+v, want git.stale_files", task)
+	}
+	start := time.Now()
+	if err := task.Run(context.Background()); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+
+	// Every repository but the busy and the broken one is cleaned, once
+	if len(git.cleaned) != len(repos)-2 || !slices.Contains(git.cleaned, idle.GitPath) || !slices.Contains(git.cleaned, clean.GitPath) {
+		t.Errorf("cleaned %d repositories, want %d including idle and clean", len(git.cleaned), len(repos)-2)
+	}
+	if slices.Contains(git.cleaned, busy.GitPath) {
+		t.Error("cleaned a repository with a git operation in flight")
+	}
+	if _, ok := git.stale[busy.GitPath]; !ok {
+		t.Error("removed the stale files of a busy repository")
+	}
+	for _, olderThan := range git.olderThan {
+		if age := start.Sub(olderThan); age < 59*time.Minute || age > 61*time.Minute {
+			t.Errorf("removed files older than %s, want an hour", age)
+		}
+	}
+
+	// Only repositories that had stale files are audited
+	if len(audit.entries) != 1 {
+		t.Fatalf("recorded %d audit entries, want 1", len(audit.entries))
+	}
+	entry := audit.entries[0]
+	if entry.Action != "repository.stale_files_remove" || entry.Resource != idle.GetFullName() || entry.Actor != "" ||
+		entry.Fields["count"] != "2" || entry.Fields["files"] != "refs/heads/main.lock,objects/pack/tmp_pack_a1b2c3" {
+		t.Errorf("recorded %+v", entry)
+	}
+
+	// The busy repository is cleaned by the next run once idle
+	release()
+	if err := task.Run(context.Background()); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if _, ok := git.stale[busy.GitPath]; ok {
+		t.Error("did not remove the stale files of a repository once idle")
+	}
+}
+
+func TestCleanRepository(t *testing.T) {
+	repo := newStaleRepo("app")
+	admin := &models.User{ID: uuid.New(), Username: "root"}
+
+	t.Run("removes stale files", func(t *testing.T) {
+		git := &fakeStaleGit{stale: map[string][]service.StaleFile{repo.GitPath: {{Path: "packed-refs.lock"}}}}
+		audit := &fakeAudit{}
+		s := newTestStaleFileService(git, audit, 30, repo)
+
+		removed, err := s.CleanRepository(context.Background(), repo, admin)
+		if err != nil {
+			t.Fatalf("CleanRepository: %v", err)
+		}
+		if len(removed) != 1 || removed[0].Path != "packed-refs.lock" {
+			t.Errorf("removed %+v, want packed-refs.lock", removed)
+		}
+		if len(audit.entries) != 1 || audit.entries[0].Actor != "root" || audit.entries[0].ActorID != admin.ID.String() {
+			t.Errorf("recorded %+v, want an entry by root", audit.entries)
+		}
+	})
+
+	t.Run("busy", func(t *testing.T) {
+		git := &fakeStaleGit{}
+		s := newTestStaleFileService(git, &fakeAudit{}, 30, repo)
+		release, err := s.storage.AcquireRepository(repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer release()
+
+		if _, err := s.CleanRepository(context.Background(), repo, admin); !apperrors.IsConflict(err) {
+			t.Errorf("CleanRepository = %v, want a conflict", err)
+		}
+		if len(git.cleaned) != 0 {
+			t.Error("cleaned a repository with a git operation in flight")
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		git := &fakeStaleGit{}
+		s := newTestStaleFileService(git, &fakeAudit{}, 0, repo)
+		if task := s.CleanupTask(); task != nil {
+			t.Errorf("CleanupTask = %s, want none", task.Name)
+		}
+		if _, err := s.CleanRepository(context.Background(), repo, admin); !apperrors.IsConflict(err) {
+			t.Errorf("CleanRepository = %v, want a conflict", err)
+		}
+		if len(git.cleaned) != 0 {
+			t.Error("cleaned a repository with stale file removal disabled")
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		git := &fakeStaleGit{failing: map[string]bool{repo.GitPath: true}}
+		s := newTestStaleFileService(git, &fakeAudit{}, 30, repo)
+		if _, err := s.CleanRepository(context.Background(), repo, admin); err == nil || apperrors.IsConflict(err) {
+			t.Errorf("CleanRepository = %v, want a storage error", err)
+		}
+	})
+}
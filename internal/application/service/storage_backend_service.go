@@ -0,0 +1,465 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// migrationDrainInterval is how often a migration checks whether the git
+// operations in flight on a repository have finished
+const migrationDrainInterval = 100 * time.Millisecond
+
+// StorageBackendInfo describes a storage backend and how many repositories live on it
+type StorageBackendInfo struct {
+	Name         string
+	Type         string
+	BasePath     string
+	Repositories int64
+}
+
+// StorageBackendService resolves the storage backend each repository lives on
+// and migrates repositories between backends. It must be shared by every
+// transport of a process: migrations wait for the git operations in flight
+// that were started through AcquireRepository.
+type StorageBackendService struct {
+	backends   service.StorageBackends
+	repoRepo   repository.RepoRepository
+	gitService service.GitService
+	audit      service.AuditRecorder
+	log        *logger.Logger
+
+	mu        sync.Mutex
+	inFlight  map[uuid.UUID]int                    // Git operations in flight per repository
+	pushes    map[uuid.UUID]int                    // Pushes among the operations in flight per repository
+	migrating map[uuid.UUID]bool                   // Repositories being migrated
+	pinned    map[uuid.UUID]*advertisementSnapshot // Ref advertisements pinned during maintenance
+}
+
+// advertisementSnapshot is a ref advertisement pinned for a repository; data
+// is nil until it has been taken
+type advertisementSnapshot struct {
+	data []byte
+}
+
+// NewStorageBackendService creates a new StorageBackendService instance
+func NewStorageBackendService(
+	backends service.StorageBackends,
+	repoRepo repository.RepoRepository,
+	gitService service.GitService,
+	audit service.AuditRecorder,
+) *StorageBackendService {
+	return &StorageBackendService{
+		backends:   backends,
+		repoRepo:   repoRepo,
+		gitService: gitService,
+		audit:      audit,
+		log:        logger.Get().WithFields(logger.Component("storage-backend-service")),
+		inFlight:   make(map[uuid.UUID]int),
+		pushes:     make(map[uuid.UUID]int),
+		migrating:  make(map[uuid.UUID]bool),
+		pinned:     make(map[uuid.UUID]*advertisementSnapshot),
+	}
+}
+
+// ForRepo returns the storage backend a repository lives on
+func (s *StorageBackendService) ForRepo(repo *models.Repository) (service.StorageService, error) {
+	name := repo.StorageBackend
+	if name == "" {
+		name = config.DefaultStorageBackend
+	}
+	backend, ok := s.backends.Backend(name)
+	if !ok {
+		return nil, apperrors.StorageError(fmt.Sprintf("resolve backend %q", name),
+			fmt.Errorf("storage backend %q of repository %s is not configured", name, repo.ID))
+	}
+	return backend, nil
+}
+
+// Place returns the name of the backend a new repository of owner with a size
+// in bytes is created on, and the backend itself
+func (s *StorageBackendService) Place(owner string, size int64) (string, service.StorageService, error) {
+	name := s.backends.Place(owner, size)
+	backend, ok := s.backends.Backend(name)
+	if !ok {
+		return "", nil, apperrors.StorageError("place repository", fmt.Errorf("storage backend %q is not configured", name))
+	}
+	return name, backend, nil
+}
+
+// AcquireRepository registers a git operation on a repository and returns the
+// function that releases it. It fails while the repository is being migrated.
+func (s *StorageBackendService) AcquireRepository(repo *models.Repository) (func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.migrating[repo.ID] {
+		return nil, apperrors.Conflict("repository storage is being migrated, try again shortly", apperrors.ErrStorageError)
+	}
+	s.inFlight[repo.ID]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.inFlight[repo.ID]--; s.inFlight[repo.ID] <= 0 {
+				delete(s.inFlight, repo.ID)
+			}
+		})
+	}, nil
+}
+
+// AcquirePush is AcquireRepository for a push, which garbage collections of
+// the repository wait for
+func (s *StorageBackendService) AcquirePush(repo *models.Repository) (func(), error) {
+	release, err := s.AcquireRepository(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	// The refs are about to change, so a pinned advertisement would hide
+	// the push from the fetches after it
+	s.mu.Lock()
+	s.pushes[repo.ID]++
+	delete(s.pinned, repo.ID)
+	s.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			if s.pushes[repo.ID]--; s.pushes[repo.ID] <= 0 {
+				delete(s.pushes, repo.ID)
+			}
+			s.mu.Unlock()
+			release()
+		})
+	}, nil
+}
+
+// PinAdvertisement pins a ref advertisement of a repository for the duration
+// of maintenance that churns its refs and packs, so fetches are not slowed
+// down or broken by it. The advertisement is pinned before it is taken:
+// set stores it, unless a push into the repository started in the meantime,
+// and unpin drops it. A push starting later drops it too.
+func (s *StorageBackendService) PinAdvertisement(id uuid.UUID) (set func([]byte), unpin func()) {
+	snapshot := &advertisementSnapshot{}
+	s.mu.Lock()
+	s.pinned[id] = snapshot
+	s.mu.Unlock()
+
+	set = func(data []byte) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.pinned[id] == snapshot {
+			snapshot.data = data
+		}
+	}
+	unpin = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.pinned[id] == snapshot {
+			delete(s.pinned, id)
+		}
+	}
+	return set, unpin
+}
+
+// PinnedAdvertisement returns the ref advertisement pinned for a repository,
+// or nil if there is none
+func (s *StorageBackendService) PinnedAdvertisement(id uuid.UUID) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if snapshot := s.pinned[id]; snapshot != nil {
+		return snapshot.data
+	}
+	return nil
+}
+
+// PushesInFlight returns the number of pushes into a repository in flight in
+// this process
+func (s *StorageBackendService) PushesInFlight(id uuid.UUID) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pushes[id]
+}
+
+// OperationsInFlight returns the number of git operations in flight on a
+// repository that were started through AcquireRepository
+func (s *StorageBackendService) OperationsInFlight(id uuid.UUID) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inFlight[id]
+}
+
+// lockForMigration blocks new git operations on a repository and waits for the
+// ones in flight to finish
+func (s *StorageBackendService) lockForMigration(ctx context.Context, id uuid.UUID) (func(), error) {
+	s.mu.Lock()
+	if s.migrating[id] {
+		s.mu.Unlock()
+		return nil, apperrors.Conflict("repository is already being migrated", apperrors.ErrStorageError)
+	}
+	s.migrating[id] = true
+	s.mu.Unlock()
+
+	unlock := func() {
+		s.mu.Lock()
+		delete(s.migrating, id)
+		s.mu.Unlock()
+	}
+
+	ticker := time.NewTicker(migrationDrainInterval)
+	defer ticker.Stop()
+	for {
+		s.mu.Lock()
+		busy := s.inFlight[id] > 0
+		s.mu.Unlock()
+		if !busy {
+			return unlock, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			unlock()
+			return nil, apperrors.Timeout("wait for git operations", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListBackends returns every backend with the number of repositories on it
+func (s *StorageBackendService) ListBackends(ctx context.Context) ([]StorageBackendInfo, error) {
+	counts, err := s.repoRepo.CountByStorageBackend(ctx)
+	if err != nil {
+		s.log.WithContext(ctx).Error("Failed to count repositories by storage backend", logger.Error(err))
+		return nil, err
+	}
+	byName := make(map[string]int64, len(counts))
+	for _, count := range counts {
+		byName[count.StorageBackend] = count.Count
+	}
+
+	names := s.backends.Names()
+	infos := make([]StorageBackendInfo, 0, len(names))
+	for _, name := range names {
+		backend, _ := s.backends.Backend(name)
+		infos = append(infos, StorageBackendInfo{
+			Name:         name,
+			Type:         s.backends.Type(name),
+			BasePath:     backend.GetBasePath(),
+			Repositories: byName[name],
+		})
+		delete(byName, name)
+	}
+
+	// Repositories on backends that were removed from the configuration
+	for _, name := range slices.Sorted(maps.Keys(byName)) {
+		infos = append(infos, StorageBackendInfo{Name: name, Repositories: byName[name]})
+	}
+	return infos, nil
+}
+
+// CheckRepositories logs repositories living on backends that are not configured
+func (s *StorageBackendService) CheckRepositories(ctx context.Context) error {
+	counts, err := s.repoRepo.CountByStorageBackend(ctx)
+	if err != nil {
+		return err
+	}
+	for _, count := range counts {
+		if _, ok := s.backends.Backend(count.StorageBackend); !ok {
+			s.log.WithContext(ctx).Error("Repositories live on a storage backend that is not configured",
+				logger.String("backend", count.StorageBackend),
+				logger.Int64("repositories", count.Count),
+			)
+		}
+	}
+	return nil
+}
+
+// MigrateRepository moves a repository to another storage backend. With an
+// empty target the placement rules pick the backend for the current size of
+// the repository. Git operations on the repository are refused while it is
+// copied; the copy is verified against the source before the repository is
+// switched over and the source is deleted.
+func (s *StorageBackendService) MigrateRepository(ctx context.Context, repoID uuid.UUID, target string, actor *models.User) (*models.Repository, error) {
+	repo, err := s.repoRepo.FindByID(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := s.ForRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if target == "" {
+		size, err := source.GetDiskUsage(repo.GitPath)
+		if err != nil {
+			size = 0
+		}
+		target = s.backends.Place(repo.Owner.Username, size)
+	}
+	targetStorage, ok := s.backends.Backend(target)
+	if !ok {
+		return nil, apperrors.BadRequest(fmt.Sprintf("unknown storage backend %q", target), apperrors.ErrInvalidInput)
+	}
+	if repo.StorageBackend == target {
+		return nil, apperrors.Conflict("repository already lives on this storage backend", apperrors.ErrStorageError)
+	}
+
+	s.log.WithContext(ctx).Info("Migrating repository storage",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("from", repo.StorageBackend),
+		logger.String("to", target),
+	)
+
+	unlock, err := s.lockForMigration(ctx, repo.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// The repository may have been renamed or transferred while waiting
+	repo, err = s.repoRepo.FindByID(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	oldPath := repo.GitPath
+	newPath := targetStorage.GetRepoPath(repo.Owner.Username, repo.Name)
+	from := repo.StorageBackend
+
+	if err := targetStorage.ImportDirectory(oldPath, newPath); err != nil {
+		s.log.WithContext(ctx).Error("Failed to copy repository to storage backend",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+			logger.String("new_path", newPath),
+		)
+		s.cleanupMigration(targetStorage, newPath)
+		s.recordMigration(repo, actor, from, target, "failure")
+		return nil, apperrors.StorageError("copy repository", err)
+	}
+
+	if err := s.verifyCopy(ctx, oldPath, newPath); err != nil {
+		s.log.WithContext(ctx).Error("Repository copy does not match the source",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+			logger.String("new_path", newPath),
+		)
+		s.cleanupMigration(targetStorage, newPath)
+		s.recordMigration(repo, actor, from, target, "failure")
+		return nil, apperrors.StorageError("verify repository copy", err)
+	}
+
+	if err := s.repoRepo.UpdateStorage(ctx, repo.ID, target, newPath); err != nil {
+		s.log.WithContext(ctx).Error("Failed to switch repository to storage backend",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		s.cleanupMigration(targetStorage, newPath)
+		s.recordMigration(repo, actor, from, target, "failure")
+		return nil, err
+	}
+	repo.StorageBackend = target
+	repo.GitPath = newPath
+
+	if err := source.DeleteDirectory(oldPath); err != nil {
+		s.log.WithContext(ctx).Error("Failed to delete migrated repository from source backend - manual cleanup may be required",
+			logger.Error(err),
+			logger.String("git_path", oldPath),
+		)
+		// The repository already lives on the target backend
+	}
+
+	s.recordMigration(repo, actor, from, target, "success")
+	s.log.WithContext(ctx).Info("Repository storage migrated",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("from", from),
+		logger.String("to", target),
+		logger.String("git_path", newPath),
+	)
+	return repo, nil
+}
+
+// verifyCopy checks that a repository copy has the same HEAD and refs as its source
+func (s *StorageBackendService) verifyCopy(ctx context.Context, oldPath, newPath string) error {
+	oldHead, err := s.gitService.GetHEADRef(ctx, oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to read source HEAD: %w", err)
+	}
+	newHead, err := s.gitService.GetHEADRef(ctx, newPath)
+	if err != nil {
+		return fmt.Errorf("failed to read copied HEAD: %w", err)
+	}
+	if oldHead != newHead {
+		return fmt.Errorf("HEAD differs: %s != %s", newHead, oldHead)
+	}
+
+	oldRefs, err := s.gitService.GetRefs(ctx, oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to read source refs: %w", err)
+	}
+	newRefs, err := s.gitService.GetRefs(ctx, newPath)
+	if err != nil {
+		return fmt.Errorf("failed to read copied refs: %w", err)
+	}
+	if !maps.Equal(oldRefs, newRefs) {
+		return fmt.Errorf("refs differ: %d copied, %d in source", len(newRefs), len(oldRefs))
+	}
+	for _, hash := range newRefs {
+		if strings.HasPrefix(hash, "refs/") {
+			// Symbolic reference
+			continue
+		}
+		exists, err := s.gitService.ObjectExists(ctx, newPath, hash)
+		if err != nil {
+			return fmt.Errorf("failed to check copied object %s: %w", hash, err)
+		}
+		if !exists {
+			return fmt.Errorf("copied repository is missing object %s", hash)
+		}
+	}
+	return nil
+}
+
+// cleanupMigration removes a partial copy from the target backend
+func (s *StorageBackendService) cleanupMigration(target service.StorageService, path string) {
+	if err := target.DeleteDirectory(path); err != nil {
+		s.log.Error("Failed to clean up repository copy after failed migration",
+			logger.Error(err),
+			logger.String("git_path", path),
+		)
+	}
+}
+
+// recordMigration audits a storage migration
+func (s *StorageBackendService) recordMigration(repo *models.Repository, actor *models.User, from, to, outcome string) {
+	entry := service.AuditEntry{
+		Category: "admin",
+		Action:   "repository.storage_migrate",
+		Resource: repo.GetFullName(),
+		Outcome:  outcome,
+		Fields: map[string]string{
+			"from": from,
+			"to":   to,
+		},
+	}
+	if actor != nil {
+		entry.ActorID = actor.ID.String()
+		entry.Actor = actor.Username
+	}
+	s.audit.Record(entry)
+}
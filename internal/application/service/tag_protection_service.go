@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// TagOverrideRoleAdmin allows site administrators to bypass tag protection
+	TagOverrideRoleAdmin = "role:admin"
+
+	// TagOverrideRoleOwner allows the repository owner to bypass tag protection
+	TagOverrideRoleOwner = "role:owner"
+
+	// maxProtectedTagPatterns is the maximum number of patterns per repository
+	maxProtectedTagPatterns = 50
+
+	// maxProtectedTagOverrides is the maximum number of override entries per repository
+	maxProtectedTagOverrides = 50
+
+	// tagRefPrefix is the ref namespace of tags
+	tagRefPrefix = "refs/tags/"
+)
+
+// TagProtectionService makes tags matching per-repository patterns immutable.
+// Protected tags can be created but not moved or deleted, except by users on
+// the repository's override list.
+type TagProtectionService struct {
+	repoRepo repository.RepoRepository
+	audit    service.AuditRecorder
+	log      *logger.Logger
+}
+
+// NewTagProtectionService creates a new TagProtectionService instance
+func NewTagProtectionService(
+	repoRepo repository.RepoRepository,
+	audit service.AuditRecorder,
+) *TagProtectionService {
+	return &TagProtectionService{
+		repoRepo: repoRepo,
+		audit:    audit,
+		log:      logger.Get().WithFields(logger.Component("tag-protection-service")),
+	}
+}
+
+// UpdateProtectedTags validates and replaces the tag protection settings of a repository
+func (s *TagProtectionService) UpdateProtectedTags(ctx context.Context, repo *models.Repository, patterns, overrides []string) error {
+	patterns, err := normalizeTagPatterns(patterns)
+	if err != nil {
+		return err
+	}
+	overrides, err = normalizeTagOverrides(overrides)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repoRepo.UpdateProtectedTags(ctx, repo.ID, patterns, overrides); err != nil {
+		s.log.WithContext(ctx).Error("Failed to update protected tags",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		return err
+	}
+
+	repo.ProtectedTagPatterns = patterns
+	repo.ProtectedTagOverrides = overrides
+
+	s.log.WithContext(ctx).Info("Protected tags updated",
+		logger.String("repo_id", repo.ID.String()),
+		logger.Int("patterns", len(patterns)),
+		logger.Int("overrides", len(overrides)),
+	)
+
+	return nil
+}
+
+// MatchProtectedTag returns the first pattern protecting the tag, if any
+func (s *TagProtectionService) MatchProtectedTag(repo *models.Repository, tagName string) (string, bool) {
+	tagName = strings.TrimPrefix(tagName, tagRefPrefix)
+	for _, pattern := range repo.ProtectedTagPatterns {
+		if ok, _ := path.Match(pattern, tagName); ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// CanOverride returns true if the user may move or delete protected tags of the repository
+func (s *TagProtectionService) CanOverride(repo *models.Repository, user *models.User) bool {
+	if user == nil {
+		return false
+	}
+
+	for _, entry := range repo.ProtectedTagOverrides {
+		switch entry {
+		case TagOverrideRoleAdmin:
+			if user.IsAdmin {
+				return true
+			}
+		case TagOverrideRoleOwner:
+			if user.ID == repo.OwnerID {
+				return true
+			}
+		default:
+			if strings.EqualFold(entry, user.Username) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckTagDeletion returns a forbidden error if the tag is protected and the
+// user cannot override the protection. Overrides are audited.
+func (s *TagProtectionService) CheckTagDeletion(repo *models.Repository, user *models.User, tagName string) error {
+	pattern, protected := s.MatchProtectedTag(repo, tagName)
+	if !protected {
+		return nil
+	}
+
+	if !s.CanOverride(repo, user) {
+		return apperrors.Forbidden(
+			fmt.Sprintf("tag %s is protected by pattern %q and cannot be deleted", tagName, pattern),
+			apperrors.ErrForbidden,
+		)
+	}
+
+	s.recordOverride(repo, user, "api", "delete", tagName, pattern)
+	return nil
+}
+
+// RefCommandCheck returns a push check rejecting updates and deletions of
+// existing protected tags by users who cannot override the protection.
+// transport names the push channel (http, ssh) for the audit trail.
+func (s *TagProtectionService) RefCommandCheck(repo *models.Repository, user *models.User, transport string) service.RefCommandCheck {
+	if len(repo.ProtectedTagPatterns) == 0 {
+		return nil
+	}
+
+	return func(commands []service.RefCommand) []service.RefRejection {
+		var rejections []service.RefRejection
+		var overrides []service.RefCommand
+		canOverride := s.CanOverride(repo, user)
+
+		for _, cmd := range commands {
+			// Creating a tag is always allowed
+			if !strings.HasPrefix(cmd.RefName, tagRefPrefix) || cmd.IsCreate() {
+				continue
+			}
+			pattern, protected := s.MatchProtectedTag(repo, cmd.RefName)
+			if !protected {
+				continue
+			}
+
+			if canOverride {
+				overrides = append(overrides, cmd)
+				continue
+			}
+
+			reason := "protected tag cannot be updated"
+			if cmd.IsDelete() {
+				reason = "protected tag cannot be deleted"
+			}
+			rejections = append(rejections, service.RefRejection{
+				RefName: cmd.RefName,
+				Reason:  fmt.Sprintf("%s (matches %q)", reason, pattern),
+			})
+		}
+
+		if len(rejections) > 0 {
+			s.log.Info("Push rejected by tag protection",
+				logger.String("repo_id", repo.ID.String()),
+				logger.String("transport", transport),
+				logger.Int("rejected_refs", len(rejections)),
+			)
+			return rejections
+		}
+
+		for _, cmd := range overrides {
+			operation := "update"
+			if cmd.IsDelete() {
+				operation = "delete"
+			}
+			pattern, _ := s.MatchProtectedTag(repo, cmd.RefName)
+			s.recordOverride(repo, user, transport, operation, strings.TrimPrefix(cmd.RefName, tagRefPrefix), pattern)
+		}
+		return nil
+	}
+}
+
+// recordOverride logs and audits a change to a protected tag
+func (s *TagProtectionService) recordOverride(repo *models.Repository, user *models.User, transport, operation, tagName, pattern string) {
+	s.log.Warn("Protected tag override",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("user", user.Username),
+		logger.String("tag", tagName),
+		logger.String("operation", operation),
+		logger.String("transport", transport),
+	)
+
+	s.audit.Record(service.AuditEntry{
+		Category: "repository",
+		Action:   "protected_tag.override",
+		ActorID:  user.ID.String(),
+		Actor:    user.Username,
+		Resource: repo.GetFullName(),
+		Outcome:  "success",
+		Fields: map[string]string{
+			"tag":       tagName,
+			"pattern":   pattern,
+			"operation": operation,
+			"transport": transport,
+		},
+	})
+}
+
+// normalizeTagPatterns trims, deduplicates and validates tag glob patterns
+func normalizeTagPatterns(patterns []string) ([]string, error) {
+	if len(patterns) > maxProtectedTagPatterns {
+		return nil, apperrors.BadRequest(fmt.Sprintf("at most %d protected tag patterns are allowed", maxProtectedTagPatterns), apperrors.ErrInvalidInput)
+	}
+
+	result := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(strings.TrimSpace(pattern), tagRefPrefix)
+		if pattern == "" {
+			return nil, apperrors.BadRequest("protected tag patterns cannot be empty", apperrors.ErrInvalidInput)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, apperrors.BadRequest(fmt.Sprintf("invalid protected tag pattern %q", pattern), apperrors.ErrInvalidInput)
+		}
+		if !slices.Contains(result, pattern) {
+			result = append(result, pattern)
+		}
+	}
+	return result, nil
+}
+
+// normalizeTagOverrides trims, deduplicates and validates override entries
+func normalizeTagOverrides(overrides []string) ([]string, error) {
+	if len(overrides) > maxProtectedTagOverrides {
+		return nil, apperrors.BadRequest(fmt.Sprintf("at most %d protected tag overrides are allowed", maxProtectedTagOverrides), apperrors.ErrInvalidInput)
+	}
+
+	result := make([]string, 0, len(overrides))
+	for _, entry := range overrides {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			return nil, apperrors.BadRequest("protected tag overrides cannot be empty", apperrors.ErrInvalidInput)
+		}
+		if strings.HasPrefix(entry, "role:") && entry != TagOverrideRoleAdmin && entry != TagOverrideRoleOwner {
+			return nil, apperrors.BadRequest(fmt.Sprintf("unknown override role %q (expected %s or %s)", entry, TagOverrideRoleAdmin, TagOverrideRoleOwner), apperrors.ErrInvalidInput)
+		}
+		if !slices.Contains(result, entry) {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
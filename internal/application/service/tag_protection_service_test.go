@@ -0,0 +1,151 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/service"
+)
+
+const (
+	zeroHash = "0000000000000000000000000000000000000000"
+	oldHash  = "1111111111111111111111111111111111111111"
+	newHash  = "2222222222222222222222222222222222222222"
+)
+
+func TestMatchProtectedTagOverlappingPatterns(t *testing.T) {
+	s := NewTagProtectionService(&fakeRepoRepo{}, &fakeAudit{})
+	repo := &models.Repository{ProtectedTagPatterns: []string{"v1.*", "v*", "release-*", "v1.2.*"}}
+
+	tests := []struct {
+		tag         string
+		wantPattern string
+		wantMatch   bool
+	}{
+		// The first pattern listed that matches is reported
+		{tag: "v1.2.3", wantPattern: "v1.*", wantMatch: true},
+		{tag: "refs/tags/v1.2.3", wantPattern: "v1.*", wantMatch: true},
+		{tag: "v2.0.0", wantPattern: "v*", wantMatch: true},
+		{tag: "release-2024", wantPattern: "release-*", wantMatch: true},
+		{tag: "nightly", wantMatch: false},
+		// Patterns match whole names, and * does not cross /
+		{tag: "old/v1.0.0", wantMatch: false},
+		{tag: "xv1.0.0", wantMatch: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			pattern, ok := s.MatchProtectedTag(repo, tt.tag)
+			if ok != tt.wantMatch || pattern != tt.wantPattern {
+				t.Errorf("MatchProtectedTag(%q) = %q, %v, want %q, %v", tt.tag, pattern, ok, tt.wantPattern, tt.wantMatch)
+			}
+		})
+	}
+}
+
+// A forced push bundling updates of protected and unprotected tags is refused
+// as a whole for the protected ones, and audited per tag when overridden
+func TestTagProtectionForcedPush(t *testing.T) {
+	owner := &models.User{ID: uuid.New(), Username: "owner"}
+	other := &models.User{ID: uuid.New(), Username: "other"}
+	repo := &models.Repository{
+		ID:                    uuid.New(),
+		Name:                  "app",
+		OwnerID:               owner.ID,
+		Owner:                 *owner,
+		ProtectedTagPatterns:  []string{"v*", "v1.*"},
+		ProtectedTagOverrides: []string{TagOverrideRoleOwner},
+	}
+	commands := []service.RefCommand{
+		{OldHash: oldHash, NewHash: newHash, RefName: "refs/tags/v1.0.0"},  // moved, protected twice
+		{OldHash: oldHash, NewHash: zeroHash, RefName: "refs/tags/v0.9.0"}, // deleted, protected
+		{OldHash: oldHash, NewHash: newHash, RefName: "refs/tags/nightly"}, // moved, unprotected
+		{OldHash: zeroHash, NewHash: newHash, RefName: "refs/tags/v2.0.0"}, // created, protected
+		{OldHash: oldHash, NewHash: newHash, RefName: "refs/heads/v1.0.0"}, // a branch
+	}
+
+	tests := []struct {
+		name           string
+		user           *models.User
+		wantRejections map[string]string
+		wantOverrides  map[string]string
+	}{
+		{
+			name: "without override",
+			user: other,
+			wantRejections: map[string]string{
+				"refs/tags/v1.0.0": `protected tag cannot be updated (matches "v*")`,
+				"refs/tags/v0.9.0": `protected tag cannot be deleted (matches "v*")`,
+			},
+		},
+		{
+			name: "anonymous",
+			user: nil,
+			wantRejections: map[string]string{
+				"refs/tags/v1.0.0": `protected tag cannot be updated (matches "v*")`,
+				"refs/tags/v0.9.0": `protected tag cannot be deleted (matches "v*")`,
+			},
+		},
+		{
+			name:          "with override",
+			user:          owner,
+			wantOverrides: map[string]string{"v1.0.0": "update", "v0.9.0": "delete"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := &fakeAudit{}
+			s := NewTagProtectionService(&fakeRepoRepo{}, audit)
+			rejections := s.RefCommandCheck(repo, tt.user, "http")(commands)
+
+			got := make(map[string]string, len(rejections))
+			for _, r := range rejections {
+				if r.UnlessFastForward {
+					t.Errorf("rejection of %s only applies to non-fast-forwards, want it unconditional", r.RefName)
+				}
+				got[r.RefName] = r.Reason
+			}
+			if len(got) != len(tt.wantRejections) {
+				t.Errorf("rejections = %v, want %v", got, tt.wantRejections)
+			}
+			for ref, reason := range tt.wantRejections {
+				if got[ref] != reason {
+					t.Errorf("rejection of %s = %q, want %q", ref, got[ref], reason)
+				}
+			}
+
+			// Nothing is audited for a refused push
+			overrides := make(map[string]string, len(audit.entries))
+			for _, entry := range audit.entries {
+				if entry.Action != "protected_tag.override" || entry.Fields["transport"] != "http" {
+					t.Errorf("audit entry %s over %s, want protected_tag.override over http", entry.Action, entry.Fields["transport"])
+				}
+				overrides[entry.Fields["tag"]] = entry.Fields["operation"]
+			}
+			if len(overrides) != len(tt.wantOverrides) || len(audit.entries) != len(tt.wantOverrides) {
+				t.Errorf("audited overrides = %v, want %v", overrides, tt.wantOverrides)
+			}
+			for tag, operation := range tt.wantOverrides {
+				if overrides[tag] != operation {
+					t.Errorf("audited override of %s = %q, want %q", tag, overrides[tag], operation)
+				}
+			}
+		})
+	}
+}
+
+func TestTagProtectionWithoutPatterns(t *testing.T) {
+	s := NewTagProtectionService(&fakeRepoRepo{}, &fakeAudit{})
+	if check := s.RefCommandCheck(&models.Repository{}, nil, "ssh"); check != nil {
+		t.Error("RefCommandCheck of a repository without protected tags is not nil")
+	}
+	if err := s.CheckTagDeletion(&models.Repository{}, nil, "v1.0.0"); err != nil {
+		t.Errorf("CheckTagDeletion without protected tags = %v", err)
+	}
+	err := s.CheckTagDeletion(&models.Repository{ProtectedTagPatterns: []string{"v*"}}, nil, "v1.0.0")
+	if err == nil || !strings.Contains(err.Error(), `"v*"`) {
+		t.Errorf("CheckTagDeletion of a protected tag = %v, want it forbidden by \"v*\"", err)
+	}
+}
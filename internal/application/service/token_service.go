@@ -36,10 +36,11 @@ func NewTokenService(
 
 // CreateTokenRequest represents a request to create a new PAT
 type CreateTokenRequest struct {
-	UserID    uuid.UUID
-	Name      string
-	Scopes    []string   // empty = all repos access
-	ExpiresAt *time.Time // nil = never expires
+	UserID      uuid.UUID
+	Name        string
+	Scopes      []string      // models.TokenScope* values, empty = repo:read and repo:write
+	ExpiresAt   *time.Time    // nil = never expires
+	CreatedWith *models.Token // Token the request was authenticated with, nil for sessions
 }
 
 // CreateTokenResponse represents the response after creating a PAT
@@ -55,6 +56,32 @@ func (s *TokenService) CreateToken(ctx context.Context, req CreateTokenRequest)
 		return nil, apperrors.BadRequest("token name is required", apperrors.ErrInvalidInput)
 	}
 
+	// Validate scopes
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !models.IsValidTokenScope(scope) {
+			return nil, apperrors.BadRequest(
+				fmt.Sprintf("unknown token scope %q, expected one of: %s, %s, %s, %s", scope,
+					models.TokenScopeRepoRead, models.TokenScopeRepoWrite, models.TokenScopeRepoAdmin, models.TokenScopeAdmin),
+				apperrors.ErrInvalidInput,
+			)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	// Tokens created without scopes may read and write repositories; the
+	// admin scope is only ever granted when asked for
+	if len(scopes) == 0 {
+		scopes = []string{models.TokenScopeRepoRead, models.TokenScopeRepoWrite}
+	}
+
+	// A request authenticated with a token cannot create a more powerful one
+	if req.CreatedWith != nil && !req.CreatedWith.Includes(&models.Token{Scope: scopes}) {
+		return nil, apperrors.Forbidden("a token cannot create a token with more access than its own", apperrors.ErrForbidden)
+	}
+
 	// Generate random token: Sx{32 random hex chars}
 	rawToken, err := generateRawToken()
 	if err != nil {
@@ -69,7 +96,7 @@ func (s *TokenService) CreateToken(ctx context.Context, req CreateTokenRequest)
 		Name:      req.Name,
 		UserID:    req.UserID,
 		Token:     hashedToken,
-		Scope:     pq.StringArray(req.Scopes),
+		Scope:     pq.StringArray(scopes),
 		ExpiresAt: req.ExpiresAt,
 	}
 
@@ -129,7 +156,7 @@ func (s *TokenService) ValidateToken(ctx context.Context, rawToken string) (*mod
 
 	// Update last used timestamp (fire and forget)
 	go func() {
-		_ = s.tokenRepo.UpdateLastUsed(context.Background(), token.ID)
+		_ = s.tokenRepo.UpdateLastUsed(context.WithoutCancel(ctx), token.ID)
 	}()
 
 	// Get the user
@@ -144,18 +171,6 @@ func (s *TokenService) ValidateToken(ctx context.Context, rawToken string) (*mod
 	return user, token, nil
 }
 
-// CheckTokenScope checks if a token has access to a specific repo
-// Returns true if token has access (either explicit scope or empty scope = all access)
-func (s *TokenService) CheckTokenScope(token *models.Token, ownerRepo string) bool {
-	// Empty scope means access to all repos
-	if len(token.Scope) == 0 {
-		return true
-	}
-
-	// Check if the owner/repo is in the scope list
-	return slices.Contains(token.Scope, ownerRepo)
-}
-
 // generateRawToken generates a new token in format Sx{32 random hex chars}
 func generateRawToken() (string, error) {
 	bytes := make([]byte, 16) // 16 bytes = 32 hex chars
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// tokenUsageWriteInterval is the minimum time between usage writes of a token
+	tokenUsageWriteInterval = time.Minute
+
+	// maxUserAgentLength bounds the stored user agent of a token use
+	maxUserAgentLength = 512
+)
+
+// TokenAnomaly is a use of a token from an origin it was never used from
+type TokenAnomaly struct {
+	Token    *models.Token
+	Location service.GeoLocation
+	Request  service.RequestOrigin
+	UsedAt   time.Time
+}
+
+// TokenAnomalyNotifier notifies token owners of uses from new origins
+// Implementations must not block the caller on delivery
+type TokenAnomalyNotifier interface {
+	NotifyTokenAnomaly(anomaly TokenAnomaly)
+}
+
+// TokenUsageTracker records the last use of personal access tokens and, if a
+// GeoIP lookup is configured, detects uses from origins a token was never used
+// from. Writes happen in the background, at most once per token per interval;
+// uses in between are only counted.
+type TokenUsageTracker struct {
+	tokenRepo repository.TokenRepository
+	geoip     service.GeoIPLookup // nil when anomaly detection is disabled
+	audit     service.AuditRecorder
+	notifier  TokenAnomalyNotifier
+	usage     sync.Map // uuid.UUID -> *tokenUsage
+	log       *logger.Logger
+}
+
+// tokenUsage is the in-memory usage state of a token
+type tokenUsage struct {
+	mu        sync.Mutex
+	lastWrite time.Time
+	pending   int64           // Uses not yet added to the stored usage count
+	origins   map[string]bool // Origins known to be recorded, nil until the first lookup
+}
+
+// NewTokenUsageTracker creates a new TokenUsageTracker instance. geoip and
+// notifier may be nil to disable anomaly detection and notifications.
+func NewTokenUsageTracker(
+	tokenRepo repository.TokenRepository,
+	geoip service.GeoIPLookup,
+	audit service.AuditRecorder,
+	notifier TokenAnomalyNotifier,
+) *TokenUsageTracker {
+	return &TokenUsageTracker{
+		tokenRepo: tokenRepo,
+		geoip:     geoip,
+		audit:     audit,
+		notifier:  notifier,
+		log:       logger.Get().WithFields(logger.Component("token-usage")),
+	}
+}
+
+// Touch records a use of a token. It never blocks on the database.
+func (t *TokenUsageTracker) Touch(token *models.Token, origin service.RequestOrigin) {
+	value, ok := t.usage.Load(token.ID)
+	if !ok {
+		value, _ = t.usage.LoadOrStore(token.ID, &tokenUsage{})
+	}
+	usage := value.(*tokenUsage)
+
+	now := time.Now()
+	usage.mu.Lock()
+	usage.pending++
+	var uses int64
+	if now.Sub(usage.lastWrite) >= tokenUsageWriteInterval {
+		uses = usage.pending
+		usage.pending = 0
+		usage.lastWrite = now
+	}
+	usage.mu.Unlock()
+
+	if uses > 0 {
+		go t.write(token.ID, now, origin, uses)
+	}
+	if t.geoip != nil {
+		t.checkOrigin(token, usage, origin, now)
+	}
+}
+
+// write stores a token use
+func (t *TokenUsageTracker) write(tokenID uuid.UUID, usedAt time.Time, origin service.RequestOrigin, uses int64) {
+	userAgent := origin.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	if err := t.tokenRepo.RecordUsage(context.Background(), tokenID, usedAt, origin.IP, userAgent, uses); err != nil {
+		t.log.Warn("Failed to record token usage",
+			logger.Error(err),
+			logger.String("token_id", tokenID.String()),
+		)
+	}
+}
+
+// checkOrigin records the origin of a token use and reports it if the token
+// was used before but never from there. The first origin of a token is only
+// recorded.
+func (t *TokenUsageTracker) checkOrigin(token *models.Token, usage *tokenUsage, origin service.RequestOrigin, usedAt time.Time) {
+	addr, err := netip.ParseAddr(origin.IP)
+	if err != nil {
+		return
+	}
+	location, ok := t.geoip.Lookup(addr)
+	if !ok {
+		return
+	}
+	key := location.Origin()
+
+	usage.mu.Lock()
+	if usage.origins == nil {
+		usage.origins = make(map[string]bool, len(token.KnownOrigins)+1)
+		for _, known := range token.KnownOrigins {
+			usage.origins[known] = true
+		}
+	}
+	if usage.origins[key] || slices.Contains(token.KnownOrigins, key) {
+		usage.mu.Unlock()
+		return
+	}
+	first := len(usage.origins) == 0
+	usage.origins[key] = true
+	usage.mu.Unlock()
+
+	go func() {
+		if err := t.tokenRepo.AddKnownOrigin(context.Background(), token.ID, key); err != nil {
+			t.log.Warn("Failed to record token origin",
+				logger.Error(err),
+				logger.String("token_id", token.ID.String()),
+			)
+		}
+	}()
+
+	if !first {
+		t.report(TokenAnomaly{
+			Token:    token,
+			Location: location,
+			Request:  origin,
+			UsedAt:   usedAt,
+		})
+	}
+}
+
+// report logs, audits and notifies the owner of a token use from a new origin
+func (t *TokenUsageTracker) report(anomaly TokenAnomaly) {
+	origin := anomaly.Location.Origin()
+
+	t.log.Warn("Token used from a new origin",
+		logger.String("token_id", anomaly.Token.ID.String()),
+		logger.String("user_id", anomaly.Token.UserID.String()),
+		logger.String("origin", origin),
+		logger.ClientIP(anomaly.Request.IP),
+	)
+
+	t.audit.Record(service.AuditEntry{
+		Category: "auth",
+		Action:   "token.new_origin",
+		ActorID:  anomaly.Token.UserID.String(),
+		Resource: "token:" + anomaly.Token.ID.String(),
+		Outcome:  "success",
+		Fields: map[string]string{
+			"token_name": anomaly.Token.Name,
+			"origin":     origin,
+			"country":    anomaly.Location.Country,
+			"ip":         anomaly.Request.IP,
+			"user_agent": anomaly.Request.UserAgent,
+		},
+	})
+
+	if t.notifier != nil {
+		t.notifier.NotifyTokenAnomaly(anomaly)
+	}
+}
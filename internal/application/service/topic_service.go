@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// maxTopics is the maximum number of topics per repository
+	maxTopics = 20
+
+	// maxTopicLength is the longest topic allowed
+	maxTopicLength = 35
+
+	// defaultPopularTopics is the number of topics listed when no limit is given
+	defaultPopularTopics = 30
+
+	// maxPopularTopics is the largest number of topics listed at once
+	maxPopularTopics = 100
+)
+
+// topicPattern matches a normalized topic: lowercase letters, digits and
+// hyphens, starting with a letter or digit
+var topicPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// TopicService manages the topics repositories are tagged with, used to
+// browse and filter the public repositories of the instance
+type TopicService struct {
+	repoRepo repository.RepoRepository
+	log      *logger.Logger
+}
+
+// NewTopicService creates a new TopicService instance
+func NewTopicService(repoRepo repository.RepoRepository) *TopicService {
+	return &TopicService{
+		repoRepo: repoRepo,
+		log:      logger.Get().WithFields(logger.Component("topic-service")),
+	}
+}
+
+// ReplaceTopics normalizes topics and replaces the topics of a repository
+// with them, in the given order without duplicates
+func (s *TopicService) ReplaceTopics(ctx context.Context, repo *models.Repository, topics []string) error {
+	normalized := make([]string, 0, len(topics))
+	seen := make(map[string]bool, len(topics))
+	for _, topic := range topics {
+		topic, err := normalizeTopic(topic)
+		if err != nil {
+			return err
+		}
+		if seen[topic] {
+			continue
+		}
+		seen[topic] = true
+		normalized = append(normalized, topic)
+	}
+	if len(normalized) > maxTopics {
+		return apperrors.BadRequest(fmt.Sprintf("a repository can have at most %d topics", maxTopics), apperrors.ErrInvalidInput)
+	}
+
+	if err := s.repoRepo.UpdateTopics(ctx, repo.ID, normalized); err != nil {
+		s.log.WithContext(ctx).Error("Failed to update topics",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		return err
+	}
+	repo.Topics = normalized
+
+	s.log.WithContext(ctx).Info("Topics updated",
+		logger.String("repo_id", repo.ID.String()),
+		logger.Int("topics", len(normalized)),
+	)
+	return nil
+}
+
+// PopularTopics returns the topics of public repositories, most used first.
+// limit defaults to 30 when not positive and is capped at 100.
+func (s *TopicService) PopularTopics(ctx context.Context, limit int) ([]models.TopicCount, error) {
+	if limit <= 0 {
+		limit = defaultPopularTopics
+	}
+	limit = min(limit, maxPopularTopics)
+	return s.repoRepo.CountByTopic(ctx, limit)
+}
+
+// ParseFilter returns the normalized topic a ?topic= filter matches
+func (s *TopicService) ParseFilter(value string) (string, error) {
+	return normalizeTopic(value)
+}
+
+// normalizeTopic lowercases a topic, turns spaces into hyphens and checks it
+// is made of letters, digits and hyphens
+func normalizeTopic(topic string) (string, error) {
+	topic = strings.ToLower(strings.TrimSpace(topic))
+	topic = strings.Join(strings.Fields(topic), "-")
+	if topic == "" {
+		return "", apperrors.BadRequest("topics must not be empty", apperrors.ErrInvalidInput)
+	}
+	if len(topic) > maxTopicLength {
+		return "", apperrors.BadRequest(
+			fmt.Sprintf("topic %q must be at most %d characters", topic, maxTopicLength),
+			apperrors.ErrInvalidInput,
+		)
+	}
+	if !topicPattern.MatchString(topic) {
+		return "", apperrors.BadRequest(
+			fmt.Sprintf("topic %q must contain only lowercase letters, digits and hyphens, and start with a letter or digit", topic),
+			apperrors.ErrInvalidInput,
+		)
+	}
+	return topic, nil
+}
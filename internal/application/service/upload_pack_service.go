@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// UploadPackService manages the upload-pack settings of repositories: whether
+// clients may make partial clones and fetch objects by ID. The git transports
+// apply the saved settings to every fetch, see git.InfoRefsRequest; the git
+// config of the repository is kept in line for git commands run on it directly.
+type UploadPackService struct {
+	repoRepo   repository.RepoRepository
+	gitService service.GitService
+	log        *logger.Logger
+}
+
+// NewUploadPackService creates a new UploadPackService instance
+func NewUploadPackService(repoRepo repository.RepoRepository, gitService service.GitService) *UploadPackService {
+	return &UploadPackService{
+		repoRepo:   repoRepo,
+		gitService: gitService,
+		log:        logger.Get().WithFields(logger.Component("upload-pack-service")),
+	}
+}
+
+// UpdateSettings replaces the upload-pack settings of a repository and writes
+// them to its git config
+func (s *UploadPackService) UpdateSettings(ctx context.Context, repo *models.Repository, settings models.UploadPackSettings) error {
+	if err := s.repoRepo.UpdateUploadPackSettings(ctx, repo.ID, settings); err != nil {
+		s.log.WithContext(ctx).Error("Failed to update upload-pack settings",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		return err
+	}
+	repo.UploadPack = settings
+
+	// The transports pass the saved settings to git, a stale config only
+	// affects git commands run on the repository directly
+	if err := writeUploadPackConfig(ctx, s.gitService, repo.GitPath, settings); err != nil {
+		s.log.WithContext(ctx).Warn("Failed to write upload-pack settings to git config",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+	}
+
+	s.log.WithContext(ctx).Info("Upload-pack settings updated",
+		logger.String("repo_id", repo.ID.String()),
+		logger.Bool("allow_filter", settings.AllowFilter),
+		logger.Bool("allow_reachable_sha1_in_want", settings.AllowReachableSHA1InWant),
+		logger.Bool("allow_any_sha1_in_want", settings.AllowAnySHA1InWant),
+	)
+	return nil
+}
+
+// writeUploadPackConfig writes upload-pack settings to the git config of the
+// repository at repoPath
+func writeUploadPackConfig(ctx context.Context, gitService service.GitService, repoPath string, settings models.UploadPackSettings) error {
+	// git reads the options in order and clears the reachable bit along with
+	// allowAnySHA1InWant, which must come first
+	options := []struct {
+		key   string
+		value bool
+	}{
+		{"allowAnySHA1InWant", settings.AllowAnySHA1InWant},
+		{"allowReachableSHA1InWant", settings.AllowsReachableSHA1InWant()},
+		{"allowFilter", settings.AllowFilter},
+	}
+	for _, option := range options {
+		if err := gitService.SetConfig(ctx, repoPath, "uploadpack", option.key, strconv.FormatBool(option.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// UserAdminService handles the management of users by site administrators:
+// provisioning users without OIDC, changing their email and admin status and
+// deleting them. The server always keeps at least one administrator.
+type UserAdminService struct {
+	users       *UserService
+	userRepo    repository.UserRepository
+	repoRepo    repository.RepoRepository
+	repoService *RepoService
+	log         *logger.Logger
+}
+
+// NewUserAdminService creates a new UserAdminService instance
+func NewUserAdminService(
+	users *UserService,
+	userRepo repository.UserRepository,
+	repoRepo repository.RepoRepository,
+	repoService *RepoService,
+) *UserAdminService {
+	return &UserAdminService{
+		users:       users,
+		userRepo:    userRepo,
+		repoRepo:    repoRepo,
+		repoService: repoService,
+		log:         logger.Get().WithFields(logger.Component("user-admin-service")),
+	}
+}
+
+// AdminUpdateUserRequest represents a change of a user by an administrator.
+// Nil fields are left unchanged.
+type AdminUpdateUserRequest struct {
+	Email   *string
+	IsAdmin *bool
+}
+
+// UserDeletion is the outcome of deleting a user
+type UserDeletion struct {
+	User         *models.User
+	Repositories []*models.Repository // Owned repositories deleted with the user
+}
+
+// ListUsers lists the users whose username or email contains query, all of
+// them if query is empty, ordered by username
+func (s *UserAdminService) ListUsers(ctx context.Context, query string, limit, offset int) ([]*models.User, int64, error) {
+	query = strings.TrimSpace(query)
+
+	users, err := s.userRepo.Search(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.userRepo.CountSearch(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// GetUser returns a user by ID or username
+func (s *UserAdminService) GetUser(ctx context.Context, ref string) (*models.User, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		user, err := s.userRepo.FindByID(ctx, id)
+		if !apperrors.IsNotFound(err) {
+			return user, err
+		}
+	}
+	return s.userRepo.FindByUsername(ctx, ref)
+}
+
+// CountRepositories returns the number of repositories a user owns
+func (s *UserAdminService) CountRepositories(ctx context.Context, user *models.User) (int64, error) {
+	return s.repoRepo.CountByOwner(ctx, user.ID)
+}
+
+// ProvisionUser creates a user ahead of their first sign-in, for servers
+// without OIDC or to grant access before it
+func (s *UserAdminService) ProvisionUser(ctx context.Context, username, email string, isAdmin bool) (*models.User, error) {
+	return s.users.CreateUser(ctx, CreateUserRequest{
+		Username: strings.TrimSpace(username),
+		Email:    strings.TrimSpace(email),
+		IsAdmin:  isAdmin,
+	})
+}
+
+// UpdateUser changes the email or admin status of a user. Demoting the last
+// administrator is refused.
+func (s *UserAdminService) UpdateUser(ctx context.Context, user *models.User, req AdminUpdateUserRequest) (*models.User, error) {
+	if req.IsAdmin != nil && !*req.IsAdmin && user.IsAdmin {
+		if err := s.ensureOtherAdmin(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.users.UpdateUser(ctx, user.ID, UpdateUserRequest{
+		Email:   req.Email,
+		IsAdmin: req.IsAdmin,
+	})
+}
+
+// DeleteUser deletes a user. A user owning repositories is only deleted with
+// force, which deletes the repositories along with the user: their records go
+// in the transaction deleting the user, and their storage only once it
+// committed, so a user that cannot be deleted keeps their repositories.
+// Deleting the last administrator is refused.
+func (s *UserAdminService) DeleteUser(ctx context.Context, user *models.User, force bool) (*UserDeletion, error) {
+	if user.IsAdmin {
+		if err := s.ensureOtherAdmin(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	repos, err := s.repoRepo.FindByOwner(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(repos) > 0 && !force {
+		return nil, apperrors.Conflict(
+			fmt.Sprintf("user owns %d repositories; transfer or delete them, or delete the user with force", len(repos)),
+			apperrors.ErrInvalidInput,
+		)
+	}
+
+	repoIDs := make([]uuid.UUID, len(repos))
+	for i, repo := range repos {
+		repo.Owner = *user
+		repoIDs[i] = repo.ID
+	}
+	err = s.repoService.DeleteRepositoriesWith(ctx, repos, func() error {
+		return s.users.DeleteUser(ctx, user.ID, repoIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &UserDeletion{User: user, Repositories: repos}, nil
+}
+
+// ensureOtherAdmin refuses the removal of an administrator if they are the
+// last one
+func (s *UserAdminService) ensureOtherAdmin(ctx context.Context) error {
+	admins, err := s.userRepo.CountAdmins(ctx)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return apperrors.Conflict("the last administrator cannot be removed", apperrors.ErrInvalidInput)
+	}
+	return nil
+}
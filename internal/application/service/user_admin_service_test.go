@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// newTestUserAdminService returns a UserAdminService on users and repos, with
+// the repositories on backend
+func newTestUserAdminService(users *fakeUserRepo, repos *fakeRepoRepo, backend *fakeStorage, bus *fakeBus) *UserAdminService {
+	backends := fakeStorageBackends{backends: map[string]service.StorageService{config.DefaultStorageBackend: backend}}
+	storage := NewStorageBackendService(backends, repos, nil, &fakeAudit{})
+	repoService := NewRepoService(repos, users, fakeCollaboratorRepo{}, nil, storage, &config.ReposConfig{}, bus)
+	return NewUserAdminService(NewUserService(users, bus), users, repos, repoService)
+}
+
+func TestDeleteUserWithRepositories(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Username: "alice"}
+	other := &models.Repository{ID: uuid.New(), Name: "kept", OwnerID: uuid.New(), GitPath: "/repos/bob/kept.git"}
+	owned := []*models.Repository{
+		{ID: uuid.New(), Name: "app", OwnerID: user.ID, GitPath: "/repos/alice/app.git"},
+		{ID: uuid.New(), Name: "docs", OwnerID: user.ID, GitPath: "/repos/alice/docs.git"},
+	}
+	repos := &fakeRepoRepo{repos: append([]*models.Repository{other}, owned...)}
+	users := &fakeUserRepo{users: []*models.User{user}, repos: repos}
+	backend := &fakeStorage{}
+	bus := &fakeBus{}
+	s := newTestUserAdminService(users, repos, backend, bus)
+
+	if _, err := s.DeleteUser(context.Background(), user, false); !apperrors.IsConflict(err) {
+		t.Fatalf("DeleteUser without force = %v, want a conflict", err)
+	}
+
+	deletion, err := s.DeleteUser(context.Background(), user, true)
+	if err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if len(deletion.Repositories) != 2 || deletion.Repositories[0].Owner.Username != "alice" {
+		t.Errorf("deleted repositories = %+v, want app and docs of alice", deletion.Repositories)
+	}
+	if len(users.users) != 0 || len(repos.repos) != 1 || repos.repos[0] != other {
+		t.Errorf("left %d users and %d repositories, want none and the other owner's", len(users.users), len(repos.repos))
+	}
+	if want := []string{"/repos/alice/app.git", "/repos/alice/docs.git"}; !slices.Equal(backend.deleted, want) {
+		t.Errorf("storage deleted %v, want %v", backend.deleted, want)
+	}
+	var deleted []string
+	for _, event := range bus.Events() {
+		if e, ok := event.(events.RepositoryDeleted); ok {
+			deleted = append(deleted, e.Owner+"/"+e.Name)
+		}
+	}
+	if want := []string{"alice/app", "alice/docs"}; !slices.Equal(deleted, want) {
+		t.Errorf("repository deletions announced = %v, want %v", deleted, want)
+	}
+}
+
+// A user that cannot be deleted, still referenced by another record, keeps
+// their repositories, records and storage alike
+func TestDeleteUserFailureKeepsRepositories(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Username: "alice"}
+	repo := &models.Repository{ID: uuid.New(), Name: "app", OwnerID: user.ID, GitPath: "/repos/alice/app.git"}
+	repos := &fakeRepoRepo{repos: []*models.Repository{repo}}
+	users := &fakeUserRepo{
+		users:     []*models.User{user},
+		repos:     repos,
+		deleteErr: apperrors.Conflict("user is still referenced by audit_events", apperrors.ErrInvalidInput),
+	}
+	backend := &fakeStorage{}
+	bus := &fakeBus{}
+	s := newTestUserAdminService(users, repos, backend, bus)
+
+	if _, err := s.DeleteUser(context.Background(), user, true); !apperrors.IsConflict(err) {
+		t.Fatalf("DeleteUser = %v, want the conflict of the user deletion", err)
+	}
+	if len(users.users) != 1 || len(repos.repos) != 1 {
+		t.Errorf("left %d users and %d repositories, want both kept", len(users.users), len(repos.repos))
+	}
+	if len(backend.deleted) != 0 {
+		t.Errorf("storage deleted %v, want nothing", backend.deleted)
+	}
+	if published := bus.Events(); len(published) != 0 {
+		t.Errorf("published %v, want nothing", published)
+	}
+	// The repositories were released for migrations again
+	if inFlight := s.repoService.storage.inFlight; len(inFlight) != 0 {
+		t.Errorf("repositories still acquired: %v", inFlight)
+	}
+}
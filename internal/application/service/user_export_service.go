@@ -0,0 +1,606 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// exportRequestInterval is the time a user must wait between export requests
+	exportRequestInterval = 24 * time.Hour
+
+	// exportPollInterval is the time between checks for pending exports
+	exportPollInterval = time.Minute
+
+	// exportCleanupInterval is the time between deletions of expired exports
+	exportCleanupInterval = time.Hour
+
+	// exportCleanupJitter spreads the deletions of expired exports
+	exportCleanupJitter = 5 * time.Minute
+
+	// exportCleanupBatchSize is the number of expired exports deleted at a time
+	exportCleanupBatchSize = 100
+
+	// exportPageSize is the number of records read from the database at a time
+	// while writing an archive
+	exportPageSize = 500
+)
+
+// exportReadme describes the archive to its reader
+const exportReadme = `This archive contains the data this server holds about your account.
+
+profile.json           Your profile and settings
+emails.json            Your email addresses
+ssh_keys.json          Your SSH keys (fingerprints, not the keys)
+tokens.json            Your access tokens (metadata, never the token values)
+repositories.json      The repositories you own
+pull_requests.jsonl    The pull requests you opened, one per line
+contributions.jsonl    Your contribution events, one per line
+push_attempts.jsonl    The pushes you made, one per line
+audit_events.jsonl     The actions you took (repository, branch, tag,
+                       collaborator, token and SSH key changes, pushes),
+                       one per line
+repositories/          Git bundles of your repositories, if requested
+                       (restore with: git clone <name>.bundle)
+
+Security audit entries forwarded to the audit sinks configured by the server
+administrators are not stored by the server itself; ask them for the entries
+about you.
+`
+
+// UserExportService assembles archives of the data of users in the
+// background and serves them through signed, expiring links
+type UserExportService struct {
+	exportRepo       repository.UserExportRepository
+	userRepo         repository.UserRepository
+	sshKeyRepo       repository.SSHKeyRepository
+	tokenRepo        repository.TokenRepository
+	repoRepo         repository.RepoRepository
+	contributionRepo repository.ContributionRepository
+	pullRequestRepo  repository.PullRequestRepository
+	pushAttemptRepo  repository.PushAttemptRepository
+	auditEventRepo   repository.AuditEventRepository
+	gitService       service.GitService
+	storage          service.StorageService
+	publisher        events.Publisher
+	cfg              *config.ExportsConfig
+	secret           []byte
+	wake             chan struct{}
+	log              *logger.Logger
+}
+
+// NewUserExportService creates a new UserExportService instance. Archives are
+// written to storage. Without a configured signing secret a random one is
+// generated, so download links do not survive a restart.
+func NewUserExportService(
+	exportRepo repository.UserExportRepository,
+	userRepo repository.UserRepository,
+	sshKeyRepo repository.SSHKeyRepository,
+	tokenRepo repository.TokenRepository,
+	repoRepo repository.RepoRepository,
+	contributionRepo repository.ContributionRepository,
+	pullRequestRepo repository.PullRequestRepository,
+	pushAttemptRepo repository.PushAttemptRepository,
+	auditEventRepo repository.AuditEventRepository,
+	gitService service.GitService,
+	storage service.StorageService,
+	publisher events.Publisher,
+	cfg *config.ExportsConfig,
+) (*UserExportService, error) {
+	secret := []byte(cfg.SigningSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate export signing secret: %w", err)
+		}
+	}
+
+	return &UserExportService{
+		exportRepo:       exportRepo,
+		userRepo:         userRepo,
+		sshKeyRepo:       sshKeyRepo,
+		tokenRepo:        tokenRepo,
+		repoRepo:         repoRepo,
+		contributionRepo: contributionRepo,
+		pullRequestRepo:  pullRequestRepo,
+		pushAttemptRepo:  pushAttemptRepo,
+		auditEventRepo:   auditEventRepo,
+		gitService:       gitService,
+		storage:          storage,
+		publisher:        publisher,
+		cfg:              cfg,
+		secret:           secret,
+		wake:             make(chan struct{}, 1),
+		log:              logger.Get().WithFields(logger.Component("user-exports")),
+	}, nil
+}
+
+// RequestExport queues an export of the data of user. requestedBy is the
+// administrator exporting another user, or nil when users export their own
+// data, which they may do once per day.
+func (s *UserExportService) RequestExport(ctx context.Context, user, requestedBy *models.User, includeRepositories bool) (*models.UserExport, error) {
+	if requestedBy == nil {
+		count, err := s.exportRepo.CountSelfRequestedSince(ctx, user.ID, time.Now().Add(-exportRequestInterval))
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, apperrors.Conflict("A data export can be requested once per day", apperrors.ErrExportRateLimited)
+		}
+	}
+
+	export := &models.UserExport{
+		UserID:              user.ID,
+		Status:              models.UserExportPending,
+		IncludeRepositories: includeRepositories,
+	}
+	if requestedBy != nil {
+		export.RequestedByID = &requestedBy.ID
+	}
+	if err := s.exportRepo.Create(ctx, export); err != nil {
+		return nil, err
+	}
+
+	// Wake the worker; a wake-up already pending covers this export too
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	s.log.WithContext(ctx).Info("User export requested",
+		logger.String("export_id", export.ID.String()),
+		logger.String("username", user.Username),
+		logger.Bool("by_admin", requestedBy != nil),
+	)
+	return export, nil
+}
+
+// ListExports returns the exports of a user, newest first
+func (s *UserExportService) ListExports(ctx context.Context, userID uuid.UUID) ([]*models.UserExport, error) {
+	return s.exportRepo.ListByUser(ctx, userID)
+}
+
+// GetExport returns an export of a user
+func (s *UserExportService) GetExport(ctx context.Context, userID, id uuid.UUID) (*models.UserExport, error) {
+	export, err := s.exportRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if export.UserID != userID {
+		return nil, apperrors.NotFound("export", nil)
+	}
+	return export, nil
+}
+
+// DownloadURL returns the signed download path of a ready export, valid until
+// the export expires, or "" if the export cannot be downloaded
+func (s *UserExportService) DownloadURL(export *models.UserExport) string {
+	if export.Status != models.UserExportReady || export.ExpiresAt == nil {
+		return ""
+	}
+	expires := export.ExpiresAt.Unix()
+	return fmt.Sprintf("/api/v1/users/exports/%s/download?expires=%d&signature=%s",
+		export.ID, expires, s.sign(export.ID, expires))
+}
+
+// OpenDownload checks the signature of a download link and opens the archive
+// of the export. The caller must close the returned reader.
+func (s *UserExportService) OpenDownload(ctx context.Context, id uuid.UUID, expires int64, signature string) (*models.UserExport, io.ReadCloser, error) {
+	if !hmac.Equal([]byte(signature), []byte(s.sign(id, expires))) {
+		return nil, nil, apperrors.Forbidden("Invalid download link", nil)
+	}
+	if time.Now().Unix() > expires {
+		return nil, nil, apperrors.Forbidden("Download link expired", nil)
+	}
+
+	export, err := s.exportRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if export.Status != models.UserExportReady {
+		return nil, nil, apperrors.NotFound("export", nil)
+	}
+
+	file, err := s.storage.OpenFile(export.StoragePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, apperrors.NotFound("export", err)
+		}
+		return nil, nil, apperrors.StorageError("open export", err)
+	}
+	return export, file, nil
+}
+
+// sign returns the signature of a download link
+func (s *UserExportService) sign(id uuid.UUID, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(id.String() + "." + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RunWorker assembles pending exports, oldest first, when an export is
+// requested and every minute until ctx is done. Exports interrupted by a
+// restart are assembled again.
+func (s *UserExportService) RunWorker(ctx context.Context) {
+	interrupted, err := s.exportRepo.ListByStatus(ctx, models.UserExportRunning)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("Failed to list interrupted exports", logger.Error(err))
+	}
+	for _, export := range interrupted {
+		export.Status = models.UserExportPending
+		if err := s.exportRepo.Update(ctx, export); err != nil {
+			s.log.WithContext(ctx).Warn("Failed to requeue interrupted export",
+				logger.Error(err),
+				logger.String("export_id", export.ID.String()),
+			)
+		}
+	}
+
+	ticker := time.NewTicker(exportPollInterval)
+	defer ticker.Stop()
+
+	for {
+		pending, err := s.exportRepo.ListByStatus(ctx, models.UserExportPending)
+		if err != nil {
+			s.log.WithContext(ctx).Warn("Failed to list pending exports", logger.Error(err))
+		}
+		for _, export := range pending {
+			if ctx.Err() != nil {
+				return
+			}
+			s.process(ctx, export)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// process assembles an export and notifies its user when it is ready
+func (s *UserExportService) process(ctx context.Context, export *models.UserExport) {
+	log := s.log.WithFields(logger.String("export_id", export.ID.String()))
+
+	export.Status = models.UserExportRunning
+	export.StoragePath = path.Join(s.cfg.Directory, export.UserID.String(), export.ID.String()+".zip")
+	if err := s.exportRepo.Update(ctx, export); err != nil {
+		log.Warn("Failed to start export", logger.Error(err))
+		return
+	}
+
+	user, err := s.userRepo.FindByID(ctx, export.UserID)
+	if err == nil {
+		export.Size, err = s.writeArchive(ctx, export, user)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.Retention())
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	if err != nil {
+		log.Error("Failed to assemble export", logger.Error(err))
+		export.Status = models.UserExportFailed
+		export.Error = "The export could not be assembled, please request a new one"
+		export.Size = 0
+		if delErr := s.storage.DeleteFile(export.StoragePath); delErr != nil && !errors.Is(delErr, fs.ErrNotExist) {
+			log.Warn("Failed to delete incomplete export archive", logger.Error(delErr))
+		}
+		export.StoragePath = ""
+	} else {
+		export.Status = models.UserExportReady
+	}
+
+	if err := s.exportRepo.Update(ctx, export); err != nil {
+		log.Warn("Failed to save export result", logger.Error(err))
+		return
+	}
+	if export.Status != models.UserExportReady {
+		return
+	}
+
+	log.Info("User export ready", logger.Int64("size", export.Size))
+	s.publisher.Publish(events.UserExportReady{
+		ExportID:    export.ID,
+		UserID:      user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		DownloadURL: s.DownloadURL(export),
+		ExpiresAt:   expiresAt,
+	})
+}
+
+// exportEmail is an email address in an export
+type exportEmail struct {
+	Email   string `json:"email"`
+	Primary bool   `json:"primary"`
+}
+
+// exportRepository is a repository in an export, without mirror credentials
+type exportRepository struct {
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	IsPrivate     bool       `json:"is_private"`
+	DefaultBranch string     `json:"default_branch"`
+	License       string     `json:"license,omitempty"`
+	ForkedFromID  *uuid.UUID `json:"forked_from_id,omitempty"`
+	MirrorEnabled bool       `json:"mirror_enabled"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Bundle        string     `json:"bundle,omitempty"` // Path of the git bundle in the archive
+}
+
+// writeArchive writes the archive of an export to storage, streaming each
+// part so no more than a page of records is held in memory. It returns the
+// size of the archive.
+func (s *UserExportService) writeArchive(ctx context.Context, export *models.UserExport, user *models.User) (int64, error) {
+	file, err := s.storage.CreateFile(export.StoragePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	counter := &countingWriter{w: file}
+	zw := zip.NewWriter(counter)
+	err = s.writeEntries(ctx, zw, export, user)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return counter.n, err
+}
+
+// writeEntries writes the files of an archive
+func (s *UserExportService) writeEntries(ctx context.Context, zw *zip.Writer, export *models.UserExport, user *models.User) error {
+	w, err := zw.Create("README.txt")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, exportReadme); err != nil {
+		return err
+	}
+
+	if err := writeJSONEntry(zw, "profile.json", user); err != nil {
+		return err
+	}
+	if err := writeJSONEntry(zw, "emails.json", []exportEmail{{Email: user.Email, Primary: true}}); err != nil {
+		return err
+	}
+
+	keys, err := s.sshKeyRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONEntry(zw, "ssh_keys.json", keys); err != nil {
+		return err
+	}
+
+	tokens, err := s.tokenRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONEntry(zw, "tokens.json", tokens); err != nil {
+		return err
+	}
+
+	repos, err := s.repoRepo.FindByOwner(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	records := make([]exportRepository, 0, len(repos))
+	bundled := make([]*models.Repository, 0, len(repos))
+	for _, repo := range repos {
+		record := exportRepository{
+			ID:            repo.ID,
+			Name:          repo.Name,
+			Description:   repo.Description,
+			IsPrivate:     repo.IsPrivate,
+			DefaultBranch: repo.DefaultBranch,
+			License:       repo.License,
+			ForkedFromID:  repo.ForkedFromID,
+			MirrorEnabled: repo.MirrorEnabled,
+			CreatedAt:     repo.CreatedAt,
+			UpdatedAt:     repo.UpdatedAt,
+		}
+		if export.IncludeRepositories {
+			ok, err := s.hasRefs(ctx, repo)
+			if err != nil {
+				return err
+			}
+			// Git cannot bundle a repository without refs
+			if ok {
+				record.Bundle = "repositories/" + repo.Name + ".bundle"
+				bundled = append(bundled, repo)
+			}
+		}
+		records = append(records, record)
+	}
+	if err := writeJSONEntry(zw, "repositories.json", records); err != nil {
+		return err
+	}
+
+	if err := writeJSONLinesEntry(zw, "pull_requests.jsonl", func(limit, offset int) ([]*models.PullRequest, error) {
+		return s.pullRequestRepo.ListByAuthor(ctx, user.ID, limit, offset)
+	}); err != nil {
+		return err
+	}
+	if err := writeJSONLinesEntry(zw, "contributions.jsonl", func(limit, offset int) ([]*models.ContributionEvent, error) {
+		return s.contributionRepo.ListByUser(ctx, user.ID, limit, offset)
+	}); err != nil {
+		return err
+	}
+	if err := writeJSONLinesEntry(zw, "push_attempts.jsonl", func(limit, offset int) ([]*models.PushAttempt, error) {
+		return s.pushAttemptRepo.ListByPusher(ctx, user.ID, limit, offset)
+	}); err != nil {
+		return err
+	}
+	if err := writeJSONLinesEntry(zw, "audit_events.jsonl", func(limit, offset int) ([]*models.AuditEvent, error) {
+		auditEvents, _, err := s.auditEventRepo.List(ctx, repository.AuditEventFilter{ActorID: &user.ID}, limit, offset)
+		return auditEvents, err
+	}); err != nil {
+		return err
+	}
+
+	for _, repo := range bundled {
+		// Bundles are compressed already
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     "repositories/" + repo.Name + ".bundle",
+			Method:   zip.Store,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.gitService.CreateBundle(ctx, repo.GitPath, w); err != nil {
+			return fmt.Errorf("failed to bundle %s: %w", repo.Name, err)
+		}
+	}
+	return nil
+}
+
+// hasRefs returns true if a repository has at least one branch or tag
+func (s *UserExportService) hasRefs(ctx context.Context, repo *models.Repository) (bool, error) {
+	refs, err := s.gitService.GetRefs(ctx, repo.GitPath)
+	if err != nil {
+		return false, err
+	}
+	for name := range refs {
+		if strings.HasPrefix(name, "refs/") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CleanupTask returns the housekeeping task deleting expired exports and
+// their archives
+func (s *UserExportService) CleanupTask() *HousekeepingTask {
+	return &HousekeepingTask{
+		Name:        "user_exports.cleanup",
+		Description: "Delete expired user data exports and their archives",
+		Interval:    exportCleanupInterval,
+		Jitter:      exportCleanupJitter,
+		Run:         s.cleanup,
+	}
+}
+
+// cleanup deletes the exports that expired by now. Exports that cannot be
+// deleted are logged and left for the next run.
+func (s *UserExportService) cleanup(ctx context.Context) error {
+	var deleted int64
+	var listErr error
+	for {
+		expired, err := s.exportRepo.ListExpired(ctx, time.Now(), exportCleanupBatchSize)
+		if err != nil {
+			listErr = err
+			break
+		}
+
+		progress := false
+		for _, export := range expired {
+			if export.StoragePath != "" {
+				if err := s.storage.DeleteFile(export.StoragePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					s.log.WithContext(ctx).Warn("Failed to delete expired export archive",
+						logger.Error(err),
+						logger.String("export_id", export.ID.String()),
+					)
+					continue
+				}
+			}
+			if err := s.exportRepo.Delete(ctx, export.ID); err != nil {
+				s.log.WithContext(ctx).Warn("Failed to delete expired export",
+					logger.Error(err),
+					logger.String("export_id", export.ID.String()),
+				)
+				continue
+			}
+			deleted++
+			progress = true
+		}
+
+		// Stop when done, or when the failures would come back in the next batch
+		if len(expired) < exportCleanupBatchSize || !progress {
+			break
+		}
+	}
+
+	if deleted > 0 {
+		s.log.WithContext(ctx).Info("Deleted expired exports", logger.Int64("count", deleted))
+	}
+	return listErr
+}
+
+// writeJSONEntry writes v to the archive as an indented JSON file
+func writeJSONEntry(zw *zip.Writer, name string, v any) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeJSONLinesEntry writes the records returned by page to the archive, one
+// JSON document per line, reading exportPageSize records at a time
+func writeJSONLinesEntry[T any](zw *zip.Writer, name string, page func(limit, offset int) ([]T, error)) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	for offset := 0; ; offset += exportPageSize {
+		records, err := page(exportPageSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := enc.Encode(record); err != nil {
+				return err
+			}
+		}
+		if len(records) < exportPageSize {
+			return nil
+		}
+	}
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write implements io.Writer
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
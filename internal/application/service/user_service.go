@@ -7,26 +7,31 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/bravo68web/stasis/internal/domain/events"
 	"github.com/bravo68web/stasis/internal/domain/models"
 	"github.com/bravo68web/stasis/internal/domain/repository"
 	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/i18n"
 	"github.com/bravo68web/stasis/pkg/logger"
 	"github.com/google/uuid"
 )
 
 // UserService handles user-related business logic
 type UserService struct {
-	userRepo repository.UserRepository
-	log      *logger.Logger
+	userRepo  repository.UserRepository
+	publisher events.Publisher
+	log       *logger.Logger
 }
 
 // NewUserService creates a new UserService instance
 func NewUserService(
 	userRepo repository.UserRepository,
+	publisher events.Publisher,
 ) *UserService {
 	return &UserService{
-		userRepo: userRepo,
-		log:      logger.Get().WithFields(logger.Component("user-service")),
+		userRepo:  userRepo,
+		publisher: publisher,
+		log:       logger.Get().WithFields(logger.Component("user-service")),
 	}
 }
 
@@ -41,14 +46,16 @@ type CreateUserRequest struct {
 
 // UpdateUserRequest represents a request to update a user
 type UpdateUserRequest struct {
-	Email    *string
-	Username *string
-	IsAdmin  *bool
+	Email                    *string
+	Username                 *string
+	IsAdmin                  *bool
+	ShowPrivateContributions *bool
+	Locale                   *string
 }
 
 // CreateUser creates a new user (typically from OIDC flow)
 func (s *UserService) CreateUser(ctx context.Context, req CreateUserRequest) (*models.User, error) {
-	s.log.Info("Creating new user",
+	s.log.WithContext(ctx).Info("Creating new user",
 		logger.String("username", req.Username),
 		logger.String("email", req.Email),
 		logger.Bool("is_admin", req.IsAdmin),
@@ -56,7 +63,7 @@ func (s *UserService) CreateUser(ctx context.Context, req CreateUserRequest) (*m
 
 	// Validate username
 	if err := s.validateUsername(req.Username); err != nil {
-		s.log.Warn("Username validation failed",
+		s.log.WithContext(ctx).Warn("Username validation failed",
 			logger.String("username", req.Username),
 			logger.Error(err),
 		)
@@ -65,7 +72,7 @@ func (s *UserService) CreateUser(ctx context.Context, req CreateUserRequest) (*m
 
 	// Validate email
 	if err := s.validateEmail(req.Email); err != nil {
-		s.log.Warn("Email validation failed",
+		s.log.WithContext(ctx).Warn("Email validation failed",
 			logger.String("email", req.Email),
 			logger.Error(err),
 		)
@@ -75,14 +82,14 @@ func (s *UserService) CreateUser(ctx context.Context, req CreateUserRequest) (*m
 	// Check if username already exists
 	exists, err := s.userRepo.ExistsByUsername(ctx, req.Username)
 	if err != nil {
-		s.log.Error("Failed to check username existence",
+		s.log.WithContext(ctx).Error("Failed to check username existence",
 			logger.Error(err),
 			logger.String("username", req.Username),
 		)
 		return nil, fmt.Errorf("failed to check username: %w", err)
 	}
 	if exists {
-		s.log.Warn("Username already taken",
+		s.log.WithContext(ctx).Warn("Username already taken",
 			logger.String("username", req.Username),
 		)
 		return nil, apperrors.Conflict("username already taken", apperrors.ErrUserExists)
@@ -91,14 +98,14 @@ func (s *UserService) CreateUser(ctx context.Context, req CreateUserRequest) (*m
 	// Check if email already exists
 	exists, err = s.userRepo.ExistsByEmail(ctx, req.Email)
 	if err != nil {
-		s.log.Error("Failed to check email existence",
+		s.log.WithContext(ctx).Error("Failed to check email existence",
 			logger.Error(err),
 			logger.String("email", req.Email),
 		)
 		return nil, fmt.Errorf("failed to check email: %w", err)
 	}
 	if exists {
-		s.log.Warn("Email already registered",
+		s.log.WithContext(ctx).Warn("Email already registered",
 			logger.String("email", req.Email),
 		)
 		return nil, apperrors.Conflict("email already registered", apperrors.ErrUserExists)
@@ -114,30 +121,36 @@ func (s *UserService) CreateUser(ctx context.Context, req CreateUserRequest) (*m
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
-		s.log.Error("Failed to create user in database",
+		s.log.WithContext(ctx).Error("Failed to create user in database",
 			logger.Error(err),
 			logger.String("username", req.Username),
 		)
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	s.log.Info("User created successfully",
+	s.log.WithContext(ctx).Info("User created successfully",
 		logger.String("user_id", user.ID.String()),
 		logger.String("username", user.Username),
 		logger.String("email", user.Email),
 	)
 
+	s.publisher.Publish(events.UserCreated{
+		UserID:   user.ID,
+		Username: user.Username,
+		Source:   "api",
+	})
+
 	return user, nil
 }
 
 // GetUser retrieves a user by ID
 func (s *UserService) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
-	s.log.Debug("Getting user by ID",
+	s.log.WithContext(ctx).Debug("Getting user by ID",
 		logger.String("user_id", id.String()),
 	)
 	user, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
-		s.log.Debug("User not found",
+		s.log.WithContext(ctx).Debug("User not found",
 			logger.String("user_id", id.String()),
 			logger.Error(err),
 		)
@@ -148,7 +161,7 @@ func (s *UserService) GetUser(ctx context.Context, id uuid.UUID) (*models.User,
 
 // GetUserByUsername retrieves a user by username
 func (s *UserService) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
-	s.log.Debug("Getting user by username",
+	s.log.WithContext(ctx).Debug("Getting user by username",
 		logger.String("username", username),
 	)
 	return s.userRepo.FindByUsername(ctx, username)
@@ -156,7 +169,7 @@ func (s *UserService) GetUserByUsername(ctx context.Context, username string) (*
 
 // GetUserByEmail retrieves a user by email
 func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
-	s.log.Debug("Getting user by email",
+	s.log.WithContext(ctx).Debug("Getting user by email",
 		logger.String("email", email),
 	)
 	return s.userRepo.FindByEmail(ctx, strings.ToLower(email))
@@ -164,7 +177,7 @@ func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*models
 
 // GetUserByOIDCSubject retrieves a user by OIDC subject and issuer
 func (s *UserService) GetUserByOIDCSubject(ctx context.Context, subject, issuer string) (*models.User, error) {
-	s.log.Debug("Getting user by OIDC subject",
+	s.log.WithContext(ctx).Debug("Getting user by OIDC subject",
 		logger.String("subject", subject),
 		logger.String("issuer", issuer),
 	)
@@ -173,14 +186,14 @@ func (s *UserService) GetUserByOIDCSubject(ctx context.Context, subject, issuer
 
 // UpdateUser updates a user's information
 func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, req UpdateUserRequest) (*models.User, error) {
-	s.log.Info("Updating user",
+	s.log.WithContext(ctx).Info("Updating user",
 		logger.String("user_id", id.String()),
 	)
 
 	// Get existing user
 	user, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
-		s.log.Error("Failed to find user for update",
+		s.log.WithContext(ctx).Error("Failed to find user for update",
 			logger.Error(err),
 			logger.String("user_id", id.String()),
 		)
@@ -190,7 +203,7 @@ func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, req UpdateUs
 	// Update email if provided
 	if req.Email != nil {
 		if err := s.validateEmail(*req.Email); err != nil {
-			s.log.Warn("Email validation failed during update",
+			s.log.WithContext(ctx).Warn("Email validation failed during update",
 				logger.Error(err),
 				logger.String("email", *req.Email),
 			)
@@ -202,19 +215,19 @@ func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, req UpdateUs
 			// Check if email is already taken
 			exists, err := s.userRepo.ExistsByEmail(ctx, normalizedEmail)
 			if err != nil {
-				s.log.Error("Failed to check email existence during update",
+				s.log.WithContext(ctx).Error("Failed to check email existence during update",
 					logger.Error(err),
 				)
 				return nil, fmt.Errorf("failed to check email: %w", err)
 			}
 			if exists {
-				s.log.Warn("Email already registered by another user",
+				s.log.WithContext(ctx).Warn("Email already registered by another user",
 					logger.String("email", normalizedEmail),
 				)
 				return nil, apperrors.Conflict("email already registered", apperrors.ErrUserExists)
 			}
 			user.Email = normalizedEmail
-			s.log.Debug("Updating user email",
+			s.log.WithContext(ctx).Debug("Updating user email",
 				logger.String("user_id", id.String()),
 				logger.String("new_email", normalizedEmail),
 			)
@@ -223,17 +236,34 @@ func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, req UpdateUs
 
 	// Update admin status if provided
 	if req.IsAdmin != nil {
-		s.log.Debug("Updating user admin status",
+		s.log.WithContext(ctx).Debug("Updating user admin status",
 			logger.String("user_id", id.String()),
 			logger.Bool("is_admin", *req.IsAdmin),
 		)
 		user.IsAdmin = *req.IsAdmin
 	}
 
+	// Update contribution visibility if provided
+	if req.ShowPrivateContributions != nil {
+		user.ShowPrivateContributions = *req.ShowPrivateContributions
+	}
+
+	// Update locale preference if provided; empty clears it
+	if req.Locale != nil {
+		locale := strings.ToLower(strings.TrimSpace(*req.Locale))
+		if locale != "" && !i18n.Default().Supports(locale) {
+			return nil, apperrors.BadRequest(
+				fmt.Sprintf("unsupported locale %q, expected one of: %s", locale, strings.Join(i18n.Default().Locales(), ", ")),
+				apperrors.ErrInvalidInput,
+			)
+		}
+		user.Locale = locale
+	}
+
 	// Update username if provided
 	if req.Username != nil {
 		if err := s.validateUsername(*req.Username); err != nil {
-			s.log.Warn("Username validation failed during update",
+			s.log.WithContext(ctx).Warn("Username validation failed during update",
 				logger.Error(err),
 				logger.String("username", *req.Username),
 			)
@@ -243,19 +273,19 @@ func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, req UpdateUs
 		// Check if username is already taken
 		exists, err := s.userRepo.ExistsByUsername(ctx, *req.Username)
 		if err != nil {
-			s.log.Error("Failed to check username existence during update",
+			s.log.WithContext(ctx).Error("Failed to check username existence during update",
 				logger.Error(err),
 			)
 			return nil, fmt.Errorf("failed to check username: %w", err)
 		}
 		if exists {
-			s.log.Warn("Username already registered by another user",
+			s.log.WithContext(ctx).Warn("Username already registered by another user",
 				logger.String("username", *req.Username),
 			)
 			return nil, apperrors.Conflict("username already registered", apperrors.ErrUserExists)
 		}
 		user.Username = *req.Username
-		s.log.Debug("Updating user username",
+		s.log.WithContext(ctx).Debug("Updating user username",
 			logger.String("user_id", id.String()),
 			logger.String("new_username", *req.Username),
 		)
@@ -263,14 +293,14 @@ func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, req UpdateUs
 
 	// Save updates
 	if err := s.userRepo.Update(ctx, user); err != nil {
-		s.log.Error("Failed to update user in database",
+		s.log.WithContext(ctx).Error("Failed to update user in database",
 			logger.Error(err),
 			logger.String("user_id", id.String()),
 		)
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
-	s.log.Info("User updated successfully",
+	s.log.WithContext(ctx).Info("User updated successfully",
 		logger.String("user_id", user.ID.String()),
 		logger.String("username", user.Username),
 	)
@@ -278,16 +308,17 @@ func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, req UpdateUs
 	return user, nil
 }
 
-// DeleteUser deletes a user by ID
-func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	s.log.Info("Deleting user",
+// DeleteUser deletes a user by ID, along with the records of the repositories
+// repoIDs they own, in a single transaction
+func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID, repoIDs []uuid.UUID) error {
+	s.log.WithContext(ctx).Info("Deleting user",
 		logger.String("user_id", id.String()),
 	)
 
 	// Check if user exists
 	user, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
-		s.log.Error("Failed to find user for deletion",
+		s.log.WithContext(ctx).Error("Failed to find user for deletion",
 			logger.Error(err),
 			logger.String("user_id", id.String()),
 		)
@@ -295,25 +326,30 @@ func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	}
 
 	// Delete user
-	if err := s.userRepo.Delete(ctx, id); err != nil {
-		s.log.Error("Failed to delete user from database",
+	if err := s.userRepo.Delete(ctx, id, repoIDs); err != nil {
+		s.log.WithContext(ctx).Error("Failed to delete user from database",
 			logger.Error(err),
 			logger.String("user_id", id.String()),
 		)
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
-	s.log.Info("User deleted successfully",
+	s.log.WithContext(ctx).Info("User deleted successfully",
 		logger.String("user_id", id.String()),
 		logger.String("username", user.Username),
 	)
 
+	s.publisher.Publish(events.UserDeleted{
+		UserID:   user.ID,
+		Username: user.Username,
+	})
+
 	return nil
 }
 
 // ListUsers lists users with pagination
 func (s *UserService) ListUsers(ctx context.Context, page, perPage int) ([]*models.User, int64, error) {
-	s.log.Debug("Listing users",
+	s.log.WithContext(ctx).Debug("Listing users",
 		logger.Int("page", page),
 		logger.Int("per_page", perPage),
 	)
@@ -329,7 +365,7 @@ func (s *UserService) ListUsers(ctx context.Context, page, perPage int) ([]*mode
 
 	users, err := s.userRepo.List(ctx, perPage, offset)
 	if err != nil {
-		s.log.Error("Failed to list users",
+		s.log.WithContext(ctx).Error("Failed to list users",
 			logger.Error(err),
 		)
 		return nil, 0, fmt.Errorf("failed to list users: %w", err)
@@ -337,13 +373,13 @@ func (s *UserService) ListUsers(ctx context.Context, page, perPage int) ([]*mode
 
 	total, err := s.userRepo.Count(ctx)
 	if err != nil {
-		s.log.Error("Failed to count users",
+		s.log.WithContext(ctx).Error("Failed to count users",
 			logger.Error(err),
 		)
 		return nil, 0, fmt.Errorf("failed to count users: %w", err)
 	}
 
-	s.log.Debug("Users listed successfully",
+	s.log.WithContext(ctx).Debug("Users listed successfully",
 		logger.Int("count", len(users)),
 		logger.Int64("total", total),
 	)
@@ -371,8 +407,8 @@ func (s *UserService) validateUsername(username string) error {
 		return apperrors.ValidationError("username", "username must start with a letter and contain only letters, numbers, underscores, or hyphens")
 	}
 
-	// Check for reserved usernames
-	reservedUsernames := []string{"admin", "root", "system", "api", "git", "www", "mail", "ftp", "ssh"}
+	// Check for reserved usernames; "imports" would clash with /api/v1/repos/imports
+	reservedUsernames := []string{"admin", "root", "system", "api", "git", "www", "mail", "ftp", "ssh", "imports"}
 	lowerUsername := strings.ToLower(username)
 	if ok := slices.Contains(reservedUsernames, lowerUsername); ok {
 		return apperrors.ValidationError("username", "username is reserved")
@@ -413,14 +449,14 @@ func (s *UserService) CountUsers(ctx context.Context) (int64, error) {
 
 // SetAdminStatus sets the admin status of a user
 func (s *UserService) SetAdminStatus(ctx context.Context, userID uuid.UUID, isAdmin bool) error {
-	s.log.Info("Setting user admin status",
+	s.log.WithContext(ctx).Info("Setting user admin status",
 		logger.String("user_id", userID.String()),
 		logger.Bool("is_admin", isAdmin),
 	)
 
 	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
-		s.log.Error("Failed to find user for admin status update",
+		s.log.WithContext(ctx).Error("Failed to find user for admin status update",
 			logger.Error(err),
 			logger.String("user_id", userID.String()),
 		)
@@ -429,14 +465,14 @@ func (s *UserService) SetAdminStatus(ctx context.Context, userID uuid.UUID, isAd
 
 	user.IsAdmin = isAdmin
 	if err := s.userRepo.Update(ctx, user); err != nil {
-		s.log.Error("Failed to update admin status",
+		s.log.WithContext(ctx).Error("Failed to update admin status",
 			logger.Error(err),
 			logger.String("user_id", userID.String()),
 		)
 		return fmt.Errorf("failed to update admin status: %w", err)
 	}
 
-	s.log.Info("User admin status updated successfully",
+	s.log.WithContext(ctx).Info("User admin status updated successfully",
 		logger.String("user_id", userID.String()),
 		logger.String("username", user.Username),
 		logger.Bool("is_admin", isAdmin),
@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// maxWebhooks is the maximum number of webhooks per repository
+	maxWebhooks = 20
+
+	// maxWebhookURLLength is the longest webhook URL allowed
+	maxWebhookURLLength = 2048
+
+	// maxWebhookSecretLength is the longest webhook secret allowed
+	maxWebhookSecretLength = 255
+
+	// maxWebhookResponseSize is how much of a response body is read before
+	// the connection is closed
+	maxWebhookResponseSize = 64 * 1024
+
+	// webhookCleanupInterval is the time between deletions of old deliveries
+	webhookCleanupInterval = time.Hour
+
+	// webhookCleanupJitter spreads the deletions of old deliveries
+	webhookCleanupJitter = 5 * time.Minute
+
+	// Headers of webhook deliveries
+	webhookEventHeader     = "X-Stasis-Event"
+	webhookDeliveryHeader  = "X-Stasis-Delivery"
+	webhookSignatureHeader = "X-Stasis-Signature-256"
+)
+
+// WebhookService manages the webhooks of repositories and posts CI job events
+// to them. A delivery is stored for each event and webhook, under an ID
+// derived from both, and then sent by a subscriber of the event bus, which
+// retries failed attempts with backoff. Deliveries are attempted one at a
+// time, so a failing webhook delays the others while it is retried. A
+// delivery not sent when the server stops is not resumed.
+type WebhookService struct {
+	hookRepo    repository.WebhookRepository
+	repoRepo    repository.RepoRepository
+	repoService *RepoService
+	publisher   events.Publisher
+	client      *http.Client
+	cfg         *config.WebhooksConfig
+	log         *logger.Logger
+}
+
+// NewWebhookService creates a new WebhookService instance delivering the
+// events published on bus
+func NewWebhookService(
+	hookRepo repository.WebhookRepository,
+	repoRepo repository.RepoRepository,
+	repoService *RepoService,
+	bus events.Bus,
+	cfg *config.WebhooksConfig,
+) *WebhookService {
+	dialer := &net.Dialer{
+		Timeout: cfg.Timeout(),
+		Control: publicDialControl(cfg.AllowPrivateAddresses),
+	}
+	s := &WebhookService{
+		hookRepo:    hookRepo,
+		repoRepo:    repoRepo,
+		repoService: repoService,
+		publisher:   bus,
+		// No proxy, so the address check applies to the webhook itself, and
+		// no redirects: a redirect counts as a failed attempt
+		client: &http.Client{
+			Timeout: cfg.Timeout(),
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: cfg.Timeout(),
+				MaxIdleConns:        10,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cfg: cfg,
+		log: logger.Get().WithFields(logger.Component("webhook-service")),
+	}
+
+	bus.Subscribe("webhooks-ci", s.queueCIJobStatus, events.SubscribeOptions{
+		Types:      []string{events.TypeCIJobStatus},
+		MaxRetries: 3,
+	})
+	bus.Subscribe("webhooks-deliveries", s.deliver, events.SubscribeOptions{
+		Types:      []string{events.TypeWebhookDelivery},
+		QueueSize:  1024,
+		MaxRetries: cfg.GetMaxAttempts() - 1,
+	})
+
+	return s
+}
+
+// ListHooks returns the webhooks of a repository
+func (s *WebhookService) ListHooks(ctx context.Context, repo *models.Repository) ([]*models.Webhook, error) {
+	return s.hookRepo.ListByRepository(ctx, repo.ID)
+}
+
+// GetHook returns a webhook of a repository
+func (s *WebhookService) GetHook(ctx context.Context, repo *models.Repository, id uuid.UUID) (*models.Webhook, error) {
+	return s.hookRepo.FindByID(ctx, repo.ID, id)
+}
+
+// CreateHook validates and stores a new webhook of a repository
+func (s *WebhookService) CreateHook(ctx context.Context, repo *models.Repository, hook *models.Webhook) error {
+	if strings.TrimSpace(hook.Secret) == "" {
+		return apperrors.BadRequest("webhook secret cannot be empty", apperrors.ErrInvalidInput)
+	}
+	if err := normalizeWebhook(hook); err != nil {
+		return err
+	}
+
+	existing, err := s.hookRepo.ListByRepository(ctx, repo.ID)
+	if err != nil {
+		return err
+	}
+	if len(existing) >= maxWebhooks {
+		return apperrors.BadRequest(fmt.Sprintf("at most %d webhooks are allowed", maxWebhooks), apperrors.ErrInvalidInput)
+	}
+
+	hook.RepositoryID = repo.ID
+	if err := s.hookRepo.Create(ctx, hook); err != nil {
+		s.log.WithContext(ctx).Error("Failed to create webhook",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		return err
+	}
+
+	s.log.WithContext(ctx).Info("Webhook created",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("webhook_id", hook.ID.String()),
+	)
+	return nil
+}
+
+// UpdateHook replaces the URL, events, ref pattern and state of a webhook,
+// and its secret unless changes has none
+func (s *WebhookService) UpdateHook(ctx context.Context, repo *models.Repository, id uuid.UUID, changes *models.Webhook) (*models.Webhook, error) {
+	if err := normalizeWebhook(changes); err != nil {
+		return nil, err
+	}
+
+	hook, err := s.hookRepo.FindByID(ctx, repo.ID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	hook.URL = changes.URL
+	hook.Events = changes.Events
+	hook.RefPattern = changes.RefPattern
+	hook.Active = changes.Active
+	if strings.TrimSpace(changes.Secret) != "" {
+		hook.Secret = changes.Secret
+	}
+	if err := s.hookRepo.Update(ctx, hook); err != nil {
+		s.log.WithContext(ctx).Error("Failed to update webhook",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		return nil, err
+	}
+
+	s.log.WithContext(ctx).Info("Webhook updated",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("webhook_id", hook.ID.String()),
+	)
+	return hook, nil
+}
+
+// DeleteHook removes a webhook of a repository with its deliveries
+func (s *WebhookService) DeleteHook(ctx context.Context, repo *models.Repository, id uuid.UUID) error {
+	if err := s.hookRepo.Delete(ctx, repo.ID, id); err != nil {
+		return err
+	}
+
+	s.log.WithContext(ctx).Info("Webhook deleted",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("webhook_id", id.String()),
+	)
+	return nil
+}
+
+// ListDeliveries returns recent deliveries of a webhook of a repository,
+// most recent first
+func (s *WebhookService) ListDeliveries(ctx context.Context, repo *models.Repository, id uuid.UUID, limit, offset int) ([]*models.WebhookDelivery, error) {
+	hook, err := s.hookRepo.FindByID(ctx, repo.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.hookRepo.ListDeliveries(ctx, hook.ID, limit, offset)
+}
+
+// CleanupTask returns the housekeeping task deleting deliveries older than
+// the retention period, or nil if deliveries are kept forever
+func (s *WebhookService) CleanupTask() *HousekeepingTask {
+	if s.cfg.Retention() == 0 {
+		return nil
+	}
+	return &HousekeepingTask{
+		Name:        "webhooks.deliveries",
+		Description: "Delete webhook deliveries older than webhooks.retention_days",
+		Interval:    webhookCleanupInterval,
+		Jitter:      webhookCleanupJitter,
+		Run:         s.cleanup,
+	}
+}
+
+// cleanup deletes the deliveries older than the retention period
+func (s *WebhookService) cleanup(ctx context.Context) error {
+	deleted, err := s.hookRepo.DeleteDeliveriesBefore(ctx, time.Now().Add(-s.cfg.Retention()))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.log.WithContext(ctx).Info("Deleted old webhook deliveries", logger.Int64("count", deleted))
+	}
+	return nil
+}
+
+// queueCIJobStatus stores a delivery of a CI job status for each webhook of
+// the repository of the job subscribing to it, and publishes the deliveries
+// to be sent. Deliveries already stored for the event, when the event is
+// retried, are not published again.
+func (s *WebhookService) queueCIJobStatus(ctx context.Context, env events.Envelope) error {
+	status, ok := env.Event.(events.CIJobStatus)
+	if !ok || status.RepositoryID == uuid.Nil {
+		return nil
+	}
+	event, ok := models.WebhookEventForCIJobStatus(status.Status)
+	if !ok {
+		return nil
+	}
+
+	hooks, err := s.hookRepo.ListByRepository(ctx, status.RepositoryID)
+	if err != nil {
+		return err
+	}
+	hooks = slices.DeleteFunc(hooks, func(hook *models.Webhook) bool {
+		return !hook.Wants(event, status.RefName)
+	})
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	repo, err := s.repoRepo.FindByID(ctx, status.RepositoryID)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, hook := range hooks {
+		delivery := &models.WebhookDelivery{
+			ID:        uuid.NewSHA1(hook.ID, env.ID[:]),
+			WebhookID: hook.ID,
+			Event:     event,
+		}
+		payload, err := json.Marshal(s.ciJobPayload(event, delivery.ID, env.OccurredAt, status, repo))
+		if err != nil {
+			return fmt.Errorf("failed to encode webhook payload: %w", err)
+		}
+		delivery.Payload = string(payload)
+
+		created, err := s.hookRepo.CreateDelivery(ctx, delivery)
+		if err != nil {
+			return err
+		}
+		if created {
+			s.publisher.Publish(events.WebhookDelivery{DeliveryID: delivery.ID})
+		}
+	}
+	return nil
+}
+
+// ciJobPayload builds the payload of a CI job event
+func (s *WebhookService) ciJobPayload(event string, deliveryID uuid.UUID, occurredAt time.Time, status events.CIJobStatus, repo *models.Repository) dto.CIJobWebhookPayload {
+	repoURL := s.repoService.RepositoryWebURL(repo.Owner.Username, repo.Name)
+	payload := dto.CIJobWebhookPayload{
+		Event:      event,
+		DeliveryID: deliveryID,
+		OccurredAt: occurredAt,
+		Job: dto.CIJobWebhookJob{
+			ID:         status.JobID,
+			Name:       status.JobName,
+			Stage:      status.Stage,
+			Status:     status.Status,
+			Error:      status.Error,
+			StartedAt:  status.StartedAt,
+			FinishedAt: status.FinishedAt,
+		},
+		Repository: dto.CIJobWebhookRepository{
+			ID:       repo.ID,
+			Owner:    repo.Owner.Username,
+			Name:     repo.Name,
+			FullName: repo.Owner.Username + "/" + repo.Name,
+			Private:  repo.IsPrivate,
+			URL:      repoURL,
+		},
+		Commit: dto.CIJobWebhookCommit{
+			SHA:     status.CommitSHA,
+			Ref:     status.RefName,
+			RefType: status.RefType,
+		},
+	}
+	if repoURL != "" {
+		payload.Job.URL = fmt.Sprintf("%s/ci/%s", repoURL, status.JobID)
+	}
+	if status.RunID != uuid.Nil {
+		payload.Run = &dto.CIJobWebhookRun{ID: status.RunID}
+	}
+	if status.TriggeredBy != "" {
+		payload.TriggeredBy = &dto.CIJobWebhookTriggerer{Username: status.TriggeredBy}
+	}
+	return payload
+}
+
+// deliver posts a delivery to its webhook and records the attempt. A failed
+// attempt returns an error, so the bus retries the delivery.
+func (s *WebhookService) deliver(ctx context.Context, env events.Envelope) error {
+	event, ok := env.Event.(events.WebhookDelivery)
+	if !ok {
+		return nil
+	}
+
+	delivery, err := s.hookRepo.FindDelivery(ctx, event.DeliveryID)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			// The webhook was deleted
+			return nil
+		}
+		return err
+	}
+	if delivery.DeliveredAt != nil || !delivery.Webhook.Active {
+		return nil
+	}
+
+	statusCode, attemptErr := s.post(ctx, &delivery.Webhook, delivery)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.StatusCode = statusCode
+	delivery.Error = ""
+	if attemptErr != nil {
+		delivery.Error = attemptErr.Error()
+	} else {
+		delivery.DeliveredAt = &now
+	}
+	if err := s.hookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		s.log.WithContext(ctx).Warn("Failed to record webhook delivery attempt",
+			logger.Error(err),
+			logger.String("delivery_id", delivery.ID.String()),
+		)
+	}
+
+	if attemptErr != nil {
+		s.log.WithContext(ctx).Warn("Webhook delivery attempt failed",
+			logger.Error(attemptErr),
+			logger.String("webhook_id", delivery.WebhookID.String()),
+			logger.String("delivery_id", delivery.ID.String()),
+			logger.Int("attempt", delivery.Attempts),
+		)
+		return attemptErr
+	}
+	return nil
+}
+
+// post sends a delivery to a webhook and returns the response status, or
+// nil if there was no response. Responses other than 2xx are errors.
+func (s *WebhookService) post(ctx context.Context, hook *models.Webhook, delivery *models.WebhookDelivery) (*int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Stasis-Webhooks")
+	req.Header.Set(webhookEventHeader, delivery.Event)
+	req.Header.Set(webhookDeliveryHeader, delivery.ID.String())
+	req.Header.Set(webhookSignatureHeader, SignWebhookPayload(hook.Secret, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponseSize))
+
+	statusCode := resp.StatusCode
+	if statusCode < 200 || statusCode > 299 {
+		return &statusCode, fmt.Errorf("webhook responded with status %d", statusCode)
+	}
+	return &statusCode, nil
+}
+
+// SignWebhookPayload returns the signature header value of a payload: the
+// hex HMAC-SHA256 of the body keyed with the webhook secret, prefixed with
+// sha256=
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// normalizeWebhook trims and validates the URL, events and ref pattern of a
+// webhook
+func normalizeWebhook(hook *models.Webhook) error {
+	hook.URL = strings.TrimSpace(hook.URL)
+	if len(hook.URL) > maxWebhookURLLength {
+		return apperrors.BadRequest(fmt.Sprintf("webhook URL cannot be longer than %d characters", maxWebhookURLLength), apperrors.ErrInvalidInput)
+	}
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return apperrors.BadRequest("webhook URL must be an http or https URL without credentials", apperrors.ErrInvalidInput)
+	}
+
+	if len(hook.Secret) > maxWebhookSecretLength {
+		return apperrors.BadRequest(fmt.Sprintf("webhook secret cannot be longer than %d characters", maxWebhookSecretLength), apperrors.ErrInvalidInput)
+	}
+
+	if len(hook.Events) == 0 {
+		return apperrors.BadRequest("webhook must subscribe to at least one event", apperrors.ErrInvalidInput)
+	}
+	var subscribed []string
+	for _, event := range hook.Events {
+		if !slices.Contains(models.WebhookEvents, event) {
+			return apperrors.BadRequest(fmt.Sprintf("unknown webhook event %q", event), apperrors.ErrInvalidInput)
+		}
+		if !slices.Contains(subscribed, event) {
+			subscribed = append(subscribed, event)
+		}
+	}
+	hook.Events = subscribed
+
+	pattern := strings.TrimSpace(hook.RefPattern)
+	pattern = strings.TrimPrefix(pattern, branchRefPrefix)
+	pattern = strings.TrimPrefix(pattern, tagRefPrefix)
+	if len(pattern) > maxBranchPatternLength {
+		return apperrors.BadRequest(fmt.Sprintf("webhook ref pattern cannot be longer than %d characters", maxBranchPatternLength), apperrors.ErrInvalidInput)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return apperrors.BadRequest(fmt.Sprintf("invalid webhook ref pattern %q", pattern), apperrors.ErrInvalidInput)
+	}
+	hook.RefPattern = pattern
+	return nil
+}
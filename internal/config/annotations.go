@@ -0,0 +1,39 @@
+package config
+
+import "slices"
+
+// AnnotationsConfig holds repository annotation configuration
+type AnnotationsConfig struct {
+	// ListedKeys are the annotation keys included in repository responses
+	// and usable as listing filters (e.g., ?annotation=team:payments)
+	ListedKeys []string `mapstructure:"listed_keys"`
+
+	// MaxValueLength is the maximum size of an annotation value in bytes
+	MaxValueLength int `mapstructure:"max_value_length"`
+}
+
+// MaxAnnotationValueLength is the hard upper bound for annotation values.
+// Values are indexed for listing filters, so they must stay well below the
+// PostgreSQL btree entry size limit.
+const MaxAnnotationValueLength = 2048
+
+// DefaultAnnotationsConfig returns default annotation configuration
+func DefaultAnnotationsConfig() AnnotationsConfig {
+	return AnnotationsConfig{
+		ListedKeys:     []string{},
+		MaxValueLength: 1024,
+	}
+}
+
+// IsListedKey returns true if the key is included in repository responses
+func (c *AnnotationsConfig) IsListedKey(key string) bool {
+	return slices.Contains(c.ListedKeys, key)
+}
+
+// GetMaxValueLength returns the maximum annotation value size with default fallback
+func (c *AnnotationsConfig) GetMaxValueLength() int {
+	if c.MaxValueLength <= 0 {
+		return 1024
+	}
+	return c.MaxValueLength
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Audit sink types
+const (
+	AuditSinkSyslog = "syslog"
+	AuditSinkHTTP   = "http"
+)
+
+// AuditConfig holds audit event export configuration
+type AuditConfig struct {
+	// SpoolDir is where events are persisted while a sink is unreachable
+	SpoolDir string `mapstructure:"spool_dir"`
+
+	// Sinks are the external destinations audit events are exported to.
+	// Export is disabled when no sinks are configured.
+	Sinks []AuditSinkConfig `mapstructure:"sinks"`
+}
+
+// AuditSinkConfig holds configuration for a single audit sink
+type AuditSinkConfig struct {
+	// Name uniquely identifies the sink (used for spool files and health reporting)
+	Name string `mapstructure:"name"`
+
+	// Type is the sink type: syslog or http
+	Type string `mapstructure:"type"`
+
+	// Categories limits the sink to these event categories (empty = all)
+	Categories []string `mapstructure:"categories"`
+
+	// RedactFields are event fields always redacted for this sink,
+	// in addition to fields the event itself marks as sensitive
+	RedactFields []string `mapstructure:"redact_fields"`
+
+	// Address is the syslog server address (host:port), syslog only
+	Address string `mapstructure:"address"`
+
+	// TLS enables TLS for the syslog connection, syslog only
+	TLS bool `mapstructure:"tls"`
+
+	// CAFile is an optional PEM bundle used to verify the syslog server, syslog only
+	CAFile string `mapstructure:"ca_file"`
+
+	// AppName is the RFC5424 APP-NAME, syslog only
+	AppName string `mapstructure:"app_name"`
+
+	// URL is the endpoint events are POSTed to, http only
+	URL string `mapstructure:"url"`
+
+	// Headers are extra request headers (e.g., Authorization), http only
+	Headers map[string]string `mapstructure:"headers"`
+
+	// BatchSize is the maximum number of events delivered per request
+	BatchSize int `mapstructure:"batch_size"`
+
+	// FlushIntervalSeconds is the maximum time events wait before delivery
+	FlushIntervalSeconds int `mapstructure:"flush_interval"`
+
+	// TimeoutSeconds is the delivery timeout in seconds
+	TimeoutSeconds int `mapstructure:"timeout"`
+}
+
+// IsEnabled returns true if at least one audit sink is configured
+func (c *AuditConfig) IsEnabled() bool {
+	return len(c.Sinks) > 0
+}
+
+// GetSpoolDir returns the spool directory with default fallback
+func (c *AuditConfig) GetSpoolDir() string {
+	if c.SpoolDir != "" {
+		return c.SpoolDir
+	}
+	return "./data/audit-spool"
+}
+
+// Validate checks that every configured sink is usable
+func (c *AuditConfig) Validate() error {
+	names := make(map[string]bool, len(c.Sinks))
+	for i := range c.Sinks {
+		sink := &c.Sinks[i]
+		if sink.Name == "" {
+			return fmt.Errorf("audit sink #%d: name is required", i+1)
+		}
+		if strings.ContainsAny(sink.Name, `/\ `) {
+			return fmt.Errorf("audit sink %q: name must not contain slashes or spaces", sink.Name)
+		}
+		if names[sink.Name] {
+			return fmt.Errorf("audit sink %q: duplicate name", sink.Name)
+		}
+		names[sink.Name] = true
+
+		switch sink.Type {
+		case AuditSinkSyslog:
+			if sink.Address == "" {
+				return fmt.Errorf("audit sink %q: address is required for syslog sinks", sink.Name)
+			}
+			if sink.CAFile != "" {
+				if _, err := os.Stat(sink.CAFile); err != nil {
+					return fmt.Errorf("audit sink %q: CA file not readable: %w", sink.Name, err)
+				}
+			}
+		case AuditSinkHTTP:
+			u, err := url.Parse(sink.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("audit sink %q: a valid http(s) url is required for http sinks", sink.Name)
+			}
+		default:
+			return fmt.Errorf("audit sink %q: invalid type %q (expected syslog or http)", sink.Name, sink.Type)
+		}
+
+		if sink.BatchSize < 0 || sink.FlushIntervalSeconds < 0 || sink.TimeoutSeconds < 0 {
+			return fmt.Errorf("audit sink %q: batch_size, flush_interval and timeout cannot be negative", sink.Name)
+		}
+	}
+	return nil
+}
+
+// AcceptsCategory returns true if events of the category are exported to the sink
+func (s *AuditSinkConfig) AcceptsCategory(category string) bool {
+	return len(s.Categories) == 0 || slices.Contains(s.Categories, category)
+}
+
+// GetBatchSize returns the batch size with default fallback
+func (s *AuditSinkConfig) GetBatchSize() int {
+	if s.BatchSize <= 0 {
+		return 100
+	}
+	return s.BatchSize
+}
+
+// FlushInterval returns the flush interval as a time.Duration
+func (s *AuditSinkConfig) FlushInterval() time.Duration {
+	if s.FlushIntervalSeconds <= 0 {
+		return 5 * time.Second
+	}
+	return time.Duration(s.FlushIntervalSeconds) * time.Second
+}
+
+// Timeout returns the delivery timeout as a time.Duration
+func (s *AuditSinkConfig) Timeout() time.Duration {
+	if s.TimeoutSeconds <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(s.TimeoutSeconds) * time.Second
+}
+
+// GetAppName returns the syslog APP-NAME with default fallback
+func (s *AuditSinkConfig) GetAppName() string {
+	if s.AppName != "" {
+		return s.AppName
+	}
+	return "stasis"
+}
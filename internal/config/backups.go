@@ -0,0 +1,18 @@
+package config
+
+import "fmt"
+
+// BackupsConfig holds the configuration of repository backups
+type BackupsConfig struct {
+	// Directory is the storage path backups are written under, relative to
+	// the default storage backend
+	Directory string `mapstructure:"directory"`
+}
+
+// Validate checks the backup configuration
+func (c *BackupsConfig) Validate() error {
+	if c.Directory == "" {
+		return fmt.Errorf("backups.directory is required")
+	}
+	return nil
+}
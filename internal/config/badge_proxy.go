@@ -0,0 +1,79 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// BadgeProxyConfig holds configuration for the third-party badge proxy
+type BadgeProxyConfig struct {
+	// Enabled determines if the badge proxy endpoint is active
+	Enabled bool `mapstructure:"enabled"`
+
+	// AllowedHosts are the badge hosts the proxy may fetch from (e.g., img.shields.io)
+	AllowedHosts []string `mapstructure:"allowed_hosts"`
+
+	// MinTTLSeconds is the minimum time a badge stays cached, regardless of upstream headers
+	MinTTLSeconds int `mapstructure:"min_ttl"`
+
+	// MaxTTLSeconds caps the cache lifetime advertised by upstream
+	MaxTTLSeconds int `mapstructure:"max_ttl"`
+
+	// MaxSizeBytes is the maximum size of an upstream badge
+	MaxSizeBytes int64 `mapstructure:"max_size"`
+
+	// TimeoutSeconds is the timeout for upstream fetches in seconds
+	TimeoutSeconds int `mapstructure:"timeout"`
+}
+
+// DefaultBadgeProxyConfig returns default badge proxy configuration
+func DefaultBadgeProxyConfig() BadgeProxyConfig {
+	return BadgeProxyConfig{
+		Enabled:        false,
+		AllowedHosts:   []string{"img.shields.io"},
+		MinTTLSeconds:  300,
+		MaxTTLSeconds:  86400,
+		MaxSizeBytes:   64 * 1024,
+		TimeoutSeconds: 5,
+	}
+}
+
+// IsAllowedHost returns true if badges may be fetched from the host
+func (c *BadgeProxyConfig) IsAllowedHost(host string) bool {
+	return slices.ContainsFunc(c.AllowedHosts, func(allowed string) bool {
+		return strings.EqualFold(allowed, host)
+	})
+}
+
+// MinTTL returns the minimum cache lifetime as a time.Duration
+func (c *BadgeProxyConfig) MinTTL() time.Duration {
+	if c.MinTTLSeconds <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(c.MinTTLSeconds) * time.Second
+}
+
+// MaxTTL returns the maximum cache lifetime as a time.Duration
+func (c *BadgeProxyConfig) MaxTTL() time.Duration {
+	if c.MaxTTLSeconds <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(c.MaxTTLSeconds) * time.Second
+}
+
+// GetMaxSize returns the maximum badge size with default fallback
+func (c *BadgeProxyConfig) GetMaxSize() int64 {
+	if c.MaxSizeBytes <= 0 {
+		return 64 * 1024
+	}
+	return c.MaxSizeBytes
+}
+
+// Timeout returns the upstream fetch timeout as a time.Duration
+func (c *BadgeProxyConfig) Timeout() time.Duration {
+	if c.TimeoutSeconds <= 0 {
+		return 5 * time.Second
+	}
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
@@ -1,10 +1,6 @@
 package config
 
-import (
-	"fmt"
-	"strings"
-	"time"
-)
+import "time"
 
 // CIConfig holds CI/CD runner integration configuration
 type CIConfig struct {
@@ -20,7 +16,9 @@ type CIConfig struct {
 	// If empty, defaults to the main server's hosted URL
 	GitServerURL string `mapstructure:"git_server_url"`
 
-	// APIKey is the API key for authenticating with the CI server
+	// APIKey is the API key for authenticating with the CI server. The
+	// runner may also send it in X-API-Key to report any job, instead of the
+	// callback token of the job; empty accepts callback tokens only.
 	// Should be set via environment variable STASIS_CI_API_KEY in production
 	APIKey string `mapstructure:"api_key"`
 
@@ -39,6 +37,25 @@ type CIConfig struct {
 
 	// RetentionDays is how long to keep job history
 	RetentionDays int `mapstructure:"retention_days"`
+
+	// JobTimeoutMinutes is the longest a job may take from its submission.
+	// The token in the clone URL of a job expires after it.
+	JobTimeoutMinutes int `mapstructure:"job_timeout_minutes"`
+
+	// LogLimitLines and LogLimitMB cap the log lines of a job the runner may
+	// report through the logs callback; further lines are dropped after a
+	// single "log limit exceeded" line. 0 disables a cap.
+	LogLimitLines int `mapstructure:"log_limit_lines"`
+	LogLimitMB    int `mapstructure:"log_limit_mb"`
+
+	// ArtifactsDirectory is the storage path the artifacts uploaded by the
+	// runner are stored under, relative to the default storage backend.
+	// They are kept for RetentionDays.
+	ArtifactsDirectory string `mapstructure:"artifacts_directory"`
+
+	// MaxArtifactSize is the largest artifact the runner may upload in bytes
+	// (0 = unlimited)
+	MaxArtifactSize int64 `mapstructure:"max_artifact_size"`
 }
 
 // DefaultCIConfig returns default CI configuration
@@ -53,6 +70,12 @@ func DefaultCIConfig() CIConfig {
 		WebhookSecret:     "",
 		MaxConcurrentJobs: 5,
 		RetentionDays:     30,
+		JobTimeoutMinutes: 60,
+		LogLimitLines:     1000000,
+		LogLimitMB:        50,
+
+		ArtifactsDirectory: "artifacts",
+		MaxArtifactSize:    1024 * 1024 * 1024,
 	}
 }
 
@@ -69,6 +92,16 @@ func (c *CIConfig) Timeout() time.Duration {
 	return time.Duration(c.TimeoutSeconds) * time.Second
 }
 
+// JobTimeout returns the job timeout as a time.Duration
+func (c *CIConfig) JobTimeout() time.Duration {
+	return time.Duration(c.JobTimeoutMinutes) * time.Minute
+}
+
+// LogLimitBytes returns the cap on the log bytes of a job, 0 if disabled
+func (c *CIConfig) LogLimitBytes() int64 {
+	return int64(c.LogLimitMB) * 1024 * 1024
+}
+
 // GetGitServerURL returns the Git server URL for CI runner to use
 // Falls back to empty string if not configured (caller should use hosted_url)
 func (c *CIConfig) GetGitServerURL() string {
@@ -85,19 +118,3 @@ func (c *CIConfig) GetConfigPath() string {
 	}
 	return ".stasis-ci.yaml"
 }
-
-func (c *CIConfig) GetAPIToken() string {
-	if c.APIKey != "" {
-		return c.APIKey
-	}
-	return ""
-}
-
-func (c *CIConfig) GetGitServerURLWithAPIToken() string {
-	baseURL := c.GetGitServerURL()
-	parts := strings.Split(baseURL, "://")
-	if len(parts) != 2 {
-		return baseURL
-	}
-	return fmt.Sprintf("%s://oauth:%s@%s", parts[0], c.GetAPIToken(), parts[1])
-}
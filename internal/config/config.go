@@ -2,23 +2,44 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/bravo68web/stasis/internal/infrastructure/otel"
 	"github.com/bravo68web/stasis/pkg/logger"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/spf13/viper"
 )
 
 // Config represents the complete application configuration
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Storage  StorageConfig  `mapstructure:"storage"`
-	SSH      SSHConfig      `mapstructure:"ssh"`
-	OIDC     OIDCConfig     `mapstructure:"oidc"`
-	Logging  LoggingConfig  `mapstructure:"logging"`
-	CI       CIConfig       `mapstructure:"ci"`
+	Server      ServerConfig      `mapstructure:"server"`
+	Database    DatabaseConfig    `mapstructure:"database"`
+	Storage     StorageConfig     `mapstructure:"storage"`
+	SSH         SSHConfig         `mapstructure:"ssh"`
+	OIDC        OIDCConfig        `mapstructure:"oidc"`
+	Logging     LoggingConfig     `mapstructure:"logging"`
+	CI          CIConfig          `mapstructure:"ci"`
+	Annotations AnnotationsConfig `mapstructure:"annotations"`
+	BadgeProxy  BadgeProxyConfig  `mapstructure:"badge_proxy"`
+	Audit       AuditConfig       `mapstructure:"audit"`
+	Repos       ReposConfig       `mapstructure:"repos"`
+	Highlight   HighlightConfig   `mapstructure:"highlight"`
+	Tokens      TokensConfig      `mapstructure:"tokens"`
+
+	PushAttempts PushAttemptsConfig `mapstructure:"push_attempts"`
+	Exports      ExportsConfig      `mapstructure:"exports"`
+	Backups      BackupsConfig      `mapstructure:"backups"`
+	Onboarding   OnboardingConfig   `mapstructure:"onboarding"`
+	Signing      SigningConfig      `mapstructure:"signing"`
+	Email        EmailConfig        `mapstructure:"email"`
+	Webhooks     WebhooksConfig     `mapstructure:"webhooks"`
+
+	// references holds the values that referenced a file or environment
+	// variable, by key, before they were resolved
+	references map[string]string
 }
 
 // ServerConfig holds HTTP server configuration
@@ -26,6 +47,38 @@ type ServerConfig struct {
 	Host string `mapstructure:"host"`
 	Port int    `mapstructure:"port"`
 	Mode string `mapstructure:"mode"` // debug, release, test
+
+	// DegradedStartup serves health endpoints while the database is not yet
+	// reachable, instead of listening only once it is
+	DegradedStartup bool `mapstructure:"degraded_startup"`
+
+	// DegradedMode keeps serving anonymous fetches of public repositories
+	// while the database is unreachable
+	DegradedMode DegradedModeConfig `mapstructure:"degraded_mode"`
+
+	// APIVersionDefault is the API version of requests without the
+	// X-Githut-Api-Version header: "latest" or "oldest"
+	APIVersionDefault string `mapstructure:"api_version_default"`
+
+	// SwaggerUI serves a Swagger UI of the OpenAPI spec at /api/docs
+	SwaggerUI bool `mapstructure:"swagger_ui"`
+
+	// TrustedProxies are the IPs and CIDR ranges of the reverse proxies whose
+	// X-Forwarded-For and X-Real-IP headers give the client IP, and whose
+	// X-Forwarded-Proto gives the scheme of the URLs served. Without any, the
+	// client IP is the address of the peer.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+
+	// Metrics serves Prometheus metrics
+	Metrics MetricsConfig `mapstructure:"metrics"`
+
+	// CORS is the cross-origin policy of the REST API
+	CORS CORSConfig `mapstructure:"cors"`
+
+	// SecurityHeaders are set on every response
+	SecurityHeaders SecurityHeadersConfig `mapstructure:"security_headers"`
 }
 
 // DatabaseConfig holds PostgreSQL database configuration
@@ -36,14 +89,39 @@ type DatabaseConfig struct {
 	Password string `mapstructure:"password"`
 	DBName   string `mapstructure:"dbname"`
 	SSLMode  string `mapstructure:"sslmode"`
+
+	StatementTimeoutSeconds int `mapstructure:"statement_timeout"` // Per-statement timeout in seconds (0 = no timeout)
+	ConnectTimeoutSeconds   int `mapstructure:"connect_timeout"`   // How long startup retries the connection and migrations, in seconds
+}
+
+// ConnectTimeout returns how long startup keeps retrying the database, or 0 to try once
+func (d *DatabaseConfig) ConnectTimeout() time.Duration {
+	if d.ConnectTimeoutSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(d.ConnectTimeoutSeconds) * time.Second
+}
+
+// StatementTimeout returns the per-statement timeout as a time.Duration, or 0 for none
+func (d *DatabaseConfig) StatementTimeout() time.Duration {
+	if d.StatementTimeoutSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(d.StatementTimeoutSeconds) * time.Second
 }
 
-// DSN returns the database connection string (libpq format)
+// DSN returns the database connection string (libpq format). The statement
+// timeout is set as a session parameter, so Postgres cancels stuck statements
+// even if the client never notices.
 func (d *DatabaseConfig) DSN() string {
-	return fmt.Sprintf(
+	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		d.Host, d.Port, d.User, d.Password, d.DBName, d.SSLMode,
 	)
+	if timeout := d.StatementTimeout(); timeout > 0 {
+		dsn += fmt.Sprintf(" statement_timeout=%d", timeout.Milliseconds())
+	}
+	return dsn
 }
 
 // URL returns the database connection URL (for tools like Atlas)
@@ -54,34 +132,41 @@ func (d *DatabaseConfig) URL() string {
 	)
 }
 
-// StorageConfig holds storage backend configuration
-type StorageConfig struct {
-	Type           string `mapstructure:"type"` // filesystem, s3
-	BasePath       string `mapstructure:"base_path"`
-	S3Bucket       string `mapstructure:"s3_bucket"`
-	S3Region       string `mapstructure:"s3_region"`
-	S3AccessKey    string `mapstructure:"s3_access_key"`
-	S3SecretKey    string `mapstructure:"s3_secret_key"`
-	S3Endpoint     string `mapstructure:"s3_endpoint"`       // For S3-compatible services
-	S3UsePathStyle bool   `mapstructure:"s3_use_path_style"` // Use path-style addressing (required for MinIO)
-}
-
-// IsS3 returns true if the storage type is S3
-func (s *StorageConfig) IsS3() bool {
-	return strings.ToLower(s.Type) == "s3"
-}
-
-// IsFilesystem returns true if the storage type is filesystem
-func (s *StorageConfig) IsFilesystem() bool {
-	return strings.ToLower(s.Type) == "filesystem" || s.Type == ""
-}
-
 // SSHConfig holds SSH server configuration
 type SSHConfig struct {
 	Enabled     bool   `mapstructure:"enabled"`
 	Host        string `mapstructure:"host"`
 	Port        int    `mapstructure:"port"`
 	HostKeyPath string `mapstructure:"host_key_path"`
+
+	// RSAHostKeyPath is an additional RSA host key for clients without
+	// ed25519 support ("" = none)
+	RSAHostKeyPath string `mapstructure:"rsa_host_key_path"`
+
+	// AutoGenerateHostKey generates missing host keys on startup: ed25519 at
+	// HostKeyPath, RSA 4096 at RSAHostKeyPath
+	AutoGenerateHostKey bool `mapstructure:"auto_generate_host_key"`
+
+	// Session requests beyond running git commands, denied unless enabled
+	AllowPTY             bool     `mapstructure:"allow_pty"`
+	AllowAgentForwarding bool     `mapstructure:"allow_agent_forwarding"`
+	AllowedEnv           []string `mapstructure:"allowed_env"` // Variables clients may set (default: GIT_PROTOCOL)
+
+	// MaxSessionMinutes is the longest a session may last before its git
+	// command is stopped (0 = unlimited)
+	MaxSessionMinutes int `mapstructure:"max_session_duration"`
+
+	// IdleTimeoutSeconds is the time without data sent or received after
+	// which a session is closed and its git command stopped (0 = never)
+	IdleTimeoutSeconds int `mapstructure:"idle_timeout"`
+
+	// MaxSessions is the most sessions open at once; further sessions are
+	// refused and asked to retry (0 = unlimited)
+	MaxSessions int `mapstructure:"max_sessions"`
+
+	// MaxSessionsPerUser is the most sessions a user, or an anonymous client
+	// address, may have open at once (0 = unlimited)
+	MaxSessionsPerUser int `mapstructure:"max_sessions_per_user"`
 }
 
 // Address returns the SSH server address
@@ -89,6 +174,26 @@ func (s *SSHConfig) Address() string {
 	return fmt.Sprintf("%s:%d", s.Host, s.Port)
 }
 
+// MaxSessionDuration returns the longest a session may last, 0 for no limit
+func (s *SSHConfig) MaxSessionDuration() time.Duration {
+	return time.Duration(s.MaxSessionMinutes) * time.Minute
+}
+
+// IdleTimeout returns the time without traffic after which a session is
+// closed, 0 for no limit
+func (s *SSHConfig) IdleTimeout() time.Duration {
+	return time.Duration(s.IdleTimeoutSeconds) * time.Second
+}
+
+// HostKeyPaths returns the paths of the host keys the server loads
+func (s *SSHConfig) HostKeyPaths() []string {
+	paths := []string{s.HostKeyPath}
+	if s.RSAHostKeyPath != "" {
+		paths = append(paths, s.RSAHostKeyPath)
+	}
+	return paths
+}
+
 // OIDCConfig holds OpenID Connect configuration
 type OIDCConfig struct {
 	Enabled      bool     `mapstructure:"enabled"`
@@ -99,6 +204,29 @@ type OIDCConfig struct {
 	FrontendURL  string   `mapstructure:"frontend_url"`  // Frontend URL for redirecting after OIDC callback (e.g., http://localhost:3000)
 	Scopes       []string `mapstructure:"scopes"`        // OIDC scopes (default: openid, profile, email)
 	JWTSecret    string   `mapstructure:"jwt_secret"`    // Secret for signing session JWTs
+
+	AdminMapping OIDCAdminMappingConfig `mapstructure:"admin_mapping"`
+	Sessions     OIDCSessionConfig      `mapstructure:"sessions"`
+}
+
+// OIDCAdminMappingConfig grants the administrator role from a claim of the
+// ID token, so administrators are managed in the identity provider
+type OIDCAdminMappingConfig struct {
+	// Claim is the claim holding the groups or roles of the user, e.g. groups.
+	// A dotted path reaches nested claims, e.g. realm_access.roles.
+	Claim string `mapstructure:"claim"`
+
+	// Values are the claim values granting the administrator role
+	Values []string `mapstructure:"values"`
+
+	// SyncOnLogin sets and unsets the administrator role of existing users on
+	// each login. Users created on their first login get it either way.
+	SyncOnLogin bool `mapstructure:"sync_on_login"`
+}
+
+// Enabled returns true if the administrator role is mapped from a claim
+func (c *OIDCAdminMappingConfig) Enabled() bool {
+	return c.Claim != "" && len(c.Values) > 0
 }
 
 // LoggingConfig holds logging configuration
@@ -257,6 +385,11 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	// Resolve the file:// and ${VAR} references to secrets
+	if err := resolveReferences(&cfg); err != nil {
+		return nil, fmt.Errorf("unresolved configuration reference: %w", err)
+	}
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
@@ -271,6 +404,35 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.host", "0.0.0.0")
 	v.SetDefault("server.port", 8080)
 	v.SetDefault("server.mode", "release")
+	v.SetDefault("server.degraded_startup", false)
+	v.SetDefault("server.degraded_mode.enabled", false)
+	v.SetDefault("server.degraded_mode.refresh_interval", 60)
+	v.SetDefault("server.degraded_mode.probe_interval", 5)
+	v.SetDefault("server.degraded_mode.max_staleness", 3600)
+	v.SetDefault("server.api_version_default", "latest")
+	v.SetDefault("server.swagger_ui", false)
+	v.SetDefault("server.trusted_proxies", []string{})
+	v.SetDefault("server.rate_limit.enabled", true)
+	v.SetDefault("server.rate_limit.api.requests_per_minute", 300)
+	v.SetDefault("server.rate_limit.api.burst", 60)
+	v.SetDefault("server.rate_limit.git.requests_per_minute", 1200)
+	v.SetDefault("server.rate_limit.git.burst", 300)
+	v.SetDefault("server.rate_limit.writes.requests_per_minute", 60)
+	v.SetDefault("server.rate_limit.writes.burst", 20)
+	v.SetDefault("server.metrics.enabled", false)
+	v.SetDefault("server.metrics.path", "/metrics")
+	v.SetDefault("server.cors.allowed_origins", []string{"http://localhost:3000", "http://127.0.0.1:3000"})
+	v.SetDefault("server.cors.allowed_methods", []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS", "HEAD"})
+	v.SetDefault("server.cors.allowed_headers", []string{
+		"Origin", "Content-Length", "Content-Type", "Authorization", "Accept", "Accept-Encoding",
+		"Accept-Language", "Cache-Control", "Cookie", "X-Requested-With", "X-Auth-Token",
+		"X-Githut-Api-Version", "X-Request-ID",
+	})
+	v.SetDefault("server.cors.allow_credentials", true)
+	v.SetDefault("server.cors.max_age", 43200)
+	v.SetDefault("server.security_headers.content_type_options", "nosniff")
+	v.SetDefault("server.security_headers.frame_options", "DENY")
+	v.SetDefault("server.security_headers.referrer_policy", "strict-origin-when-cross-origin")
 
 	// Database defaults
 	v.SetDefault("database.host", "localhost")
@@ -279,16 +441,35 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("database.password", "password")
 	v.SetDefault("database.dbname", "stasis")
 	v.SetDefault("database.sslmode", "disable")
+	v.SetDefault("database.statement_timeout", 30)
+	v.SetDefault("database.connect_timeout", 60)
 
 	// Storage defaults
 	v.SetDefault("storage.type", "filesystem")
 	v.SetDefault("storage.base_path", "./data/repos")
+	v.SetDefault("storage.s3_ca_bundle_path", "")
+	v.SetDefault("storage.s3_insecure_skip_verify", false)
+	v.SetDefault("storage.s3_max_retries", 3)
+	v.SetDefault("storage.s3_retry_max_backoff", 20)
+	v.SetDefault("storage.s3_request_timeout", 60)
+	v.SetDefault("storage.s3_max_idle_conns", 100)
+	v.SetDefault("storage.s3_max_idle_conns_per_host", 10)
+	v.SetDefault("storage.placement.backend", DefaultStorageBackend)
 
 	// SSH defaults
 	v.SetDefault("ssh.enabled", true)
 	v.SetDefault("ssh.host", "0.0.0.0")
 	v.SetDefault("ssh.port", 2222)
 	v.SetDefault("ssh.host_key_path", "./ssh_host_key")
+	v.SetDefault("ssh.rsa_host_key_path", "")
+	v.SetDefault("ssh.auto_generate_host_key", true)
+	v.SetDefault("ssh.allow_pty", false)
+	v.SetDefault("ssh.allow_agent_forwarding", false)
+	v.SetDefault("ssh.allowed_env", []string{"GIT_PROTOCOL"})
+	v.SetDefault("ssh.max_session_duration", 60)
+	v.SetDefault("ssh.idle_timeout", 600)
+	v.SetDefault("ssh.max_sessions", 500)
+	v.SetDefault("ssh.max_sessions_per_user", 20)
 
 	// OIDC defaults
 	v.SetDefault("oidc.enabled", false)
@@ -299,6 +480,13 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("oidc.frontend_url", "http://localhost:3000")
 	v.SetDefault("oidc.scopes", []string{"openid", "profile", "email"})
 	v.SetDefault("oidc.jwt_secret", "change-this-secret-in-production")
+	v.SetDefault("oidc.admin_mapping.claim", "")
+	v.SetDefault("oidc.admin_mapping.values", []string{})
+	v.SetDefault("oidc.admin_mapping.sync_on_login", true)
+	v.SetDefault("oidc.sessions.encryption_key", "")
+	v.SetDefault("oidc.sessions.absolute_lifetime", 168) // 7 days
+	v.SetDefault("oidc.sessions.idle_timeout", 1440)     // 24 hours
+	v.SetDefault("oidc.sessions.token_ttl", 60)
 
 	// Logging defaults
 	v.SetDefault("logging.level", "info")
@@ -330,8 +518,133 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("ci.webhook_secret", "")
 	v.SetDefault("ci.max_concurrent_jobs", 5)
 	v.SetDefault("ci.retention_days", 30)
+	v.SetDefault("ci.job_timeout_minutes", 60)
+	v.SetDefault("ci.log_limit_lines", 1000000)
+	v.SetDefault("ci.log_limit_mb", 50)
+	v.SetDefault("ci.artifacts_directory", "artifacts")
+	v.SetDefault("ci.max_artifact_size", 1024*1024*1024)
+
+	// Annotation defaults
+	v.SetDefault("annotations.listed_keys", []string{})
+	v.SetDefault("annotations.max_value_length", 1024)
+
+	// Badge proxy defaults
+	v.SetDefault("badge_proxy.enabled", false)
+	v.SetDefault("badge_proxy.allowed_hosts", []string{"img.shields.io"})
+	v.SetDefault("badge_proxy.min_ttl", 300)
+	v.SetDefault("badge_proxy.max_ttl", 86400)
+	v.SetDefault("badge_proxy.max_size", 64*1024)
+	v.SetDefault("badge_proxy.timeout", 5)
+
+	// Audit export defaults
+	v.SetDefault("audit.spool_dir", "./data/audit-spool")
+
+	// Repository defaults
+	v.SetDefault("repos.create_on_push", false)
+	v.SetDefault("repos.max_repos_per_user", 0)
+	v.SetDefault("repos.web_url", "http://localhost:3000")
+	v.SetDefault("repos.max_push_size", 0)
+	v.SetDefault("repos.max_file_size", 0)
+	v.SetDefault("repos.large_file_warning_size", 10*1024*1024)
+	v.SetDefault("repos.max_raw_file_size", 50*1024*1024)
+	v.SetDefault("repos.max_inline_file_size", 1024*1024)
+	v.SetDefault("repos.max_lfs_object_size", 2*1024*1024*1024)
+	v.SetDefault("repos.max_branches", 5000)
+	v.SetDefault("repos.max_tags", 10000)
+	v.SetDefault("repos.repo_quota", 0)
+	v.SetDefault("repos.user_quota", 0)
+	v.SetDefault("repos.bulk_task_retention_days", 7)
+	v.SetDefault("repos.stale_lock_minutes", 60)
+	v.SetDefault("repos.rename_redirect_days", 90)
+	v.SetDefault("repos.gc_interval_minutes", 0)
+	v.SetDefault("repos.gc_strategy", "auto")
+	v.SetDefault("repos.gc_loose_objects", 6700)
+	v.SetDefault("repos.gc_packs", 50)
+	v.SetDefault("repos.default_branch", "main")
+	v.SetDefault("repos.max_import_archive_size", 512*1024*1024)
+	v.SetDefault("repos.max_import_size", 2*1024*1024*1024)
+	v.SetDefault("repos.max_import_files", 100000)
+	v.SetDefault("repos.max_concurrent_imports", 2)
+	v.SetDefault("repos.import_allow_private_addresses", false)
+
+	// Syntax highlighting defaults
+	v.SetDefault("highlight.max_size", 1024*1024)
+	v.SetDefault("highlight.cache_entries", 512)
+
+	// Personal access token defaults
+	v.SetDefault("tokens.anomaly_detection.enabled", false)
+	v.SetDefault("tokens.anomaly_detection.geoip_ranges_file", "")
+
+	// Push attempt record defaults
+	v.SetDefault("push_attempts.enabled", true)
+	v.SetDefault("push_attempts.retention_days", 30)
+	v.SetDefault("push_attempts.max_output_size", 64*1024)
+
+	// Webhook defaults
+	v.SetDefault("webhooks.timeout", 10)
+	v.SetDefault("webhooks.max_attempts", 5)
+	v.SetDefault("webhooks.retention_days", 30)
+	v.SetDefault("webhooks.allow_private_addresses", false)
+
+	// User data export defaults
+	v.SetDefault("exports.retention_days", 7)
+	v.SetDefault("exports.directory", "exports")
+	v.SetDefault("exports.signing_secret", "")
+
+	// Repository backup defaults
+	v.SetDefault("backups.directory", "backups")
+
+	// Onboarding defaults
+	v.SetDefault("onboarding.enabled", false)
+	v.SetDefault("onboarding.repo_name", "playground")
+	v.SetDefault("onboarding.description", "A private place to try things out")
+	v.SetDefault("onboarding.readme", defaultOnboardingReadme)
+	v.SetDefault("onboarding.ci_config", defaultOnboardingCIConfig)
+
+	// Commit signing defaults
+	v.SetDefault("signing.format", "ssh")
+	v.SetDefault("signing.key_path", "")
+	v.SetDefault("signing.passphrase", "")
+	v.SetDefault("signing.committer_name", "Stasis")
+	v.SetDefault("signing.committer_email", "noreply@stasis.local")
+
+	// Email defaults
+	v.SetDefault("email.enabled", false)
+	v.SetDefault("email.transport", EmailTransportSMTP)
+	v.SetDefault("email.directory", "./data/emails")
+	v.SetDefault("email.from", "Stasis <noreply@stasis.local>")
+	v.SetDefault("email.host", "localhost")
+	v.SetDefault("email.port", 587)
+	v.SetDefault("email.tls_mode", EmailTLSStartTLS)
+	v.SetDefault("email.username", "")
+	v.SetDefault("email.password", "")
+	v.SetDefault("email.queue_size", 256)
+	v.SetDefault("email.max_attempts", 5)
+	v.SetDefault("email.retry_backoff_seconds", 30)
+	v.SetDefault("email.dead_letter_path", "./data/email-dead-letters.jsonl")
 }
 
+// defaultOnboardingReadme is the README of onboarding repositories
+const defaultOnboardingReadme = `# {{repository}}
+
+Welcome, {{username}}! This private repository is yours to experiment with.
+
+1. Add an SSH key to your account, or create a personal access token.
+2. Clone the repository and push a commit.
+3. Watch the sample CI pipeline run on every push.
+
+Delete it whenever you like.
+`
+
+// defaultOnboardingCIConfig is the sample pipeline of onboarding repositories
+const defaultOnboardingCIConfig = `# Stages run in order; each one runs a job named after itself unless it
+# lists its jobs
+stages:
+  - build
+  - name: test
+    jobs: [lint, unit]
+`
+
 // overrideFromEnv handles special environment variable overrides
 func overrideFromEnv(v *viper.Viper) {
 	// Database password from env
@@ -357,6 +670,9 @@ func overrideFromEnv(v *viper.Viper) {
 	if oidcJWTSecret := os.Getenv("STASIS_OIDC_JWT_SECRET"); oidcJWTSecret != "" {
 		v.Set("oidc.jwt_secret", oidcJWTSecret)
 	}
+	if oidcSessionKey := os.Getenv("STASIS_OIDC_SESSION_KEY"); oidcSessionKey != "" {
+		v.Set("oidc.sessions.encryption_key", oidcSessionKey)
+	}
 	if oidcFrontendURL := os.Getenv("STASIS_OIDC_FRONTEND_URL"); oidcFrontendURL != "" {
 		v.Set("oidc.frontend_url", oidcFrontendURL)
 	}
@@ -368,6 +684,33 @@ func overrideFromEnv(v *viper.Viper) {
 	if ciWebhookSecret := os.Getenv("STASIS_CI_WEBHOOK_SECRET"); ciWebhookSecret != "" {
 		v.Set("ci.webhook_secret", ciWebhookSecret)
 	}
+
+	// CORS origins from env, separated by commas or spaces
+	if corsOrigins := os.Getenv("STASIS_CORS_ALLOWED_ORIGINS"); corsOrigins != "" {
+		v.Set("server.cors.allowed_origins", strings.FieldsFunc(corsOrigins, func(r rune) bool {
+			return r == ',' || r == ' '
+		}))
+	}
+
+	// Metrics endpoint password from env
+	if metricsPass := os.Getenv("STASIS_METRICS_PASSWORD"); metricsPass != "" {
+		v.Set("server.metrics.password", metricsPass)
+	}
+
+	// Export download link signing secret from env
+	if exportsSecret := os.Getenv("STASIS_EXPORTS_SIGNING_SECRET"); exportsSecret != "" {
+		v.Set("exports.signing_secret", exportsSecret)
+	}
+
+	// Commit signing key passphrase from env
+	if signingPassphrase := os.Getenv("STASIS_SIGNING_PASSPHRASE"); signingPassphrase != "" {
+		v.Set("signing.passphrase", signingPassphrase)
+	}
+
+	// SMTP password from env
+	if emailPass := os.Getenv("STASIS_EMAIL_PASSWORD"); emailPass != "" {
+		v.Set("email.password", emailPass)
+	}
 }
 
 // Validate checks if the configuration is valid
@@ -376,6 +719,26 @@ func (c *Config) Validate() error {
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
+	if c.Server.APIVersionDefault != "latest" && c.Server.APIVersionDefault != "oldest" {
+		return fmt.Errorf("server.api_version_default must be latest or oldest, got %q", c.Server.APIVersionDefault)
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("server.trusted_proxies: %q is not an IP address or CIDR range", proxy)
+		}
+	}
+	if err := c.Server.RateLimit.Validate(); err != nil {
+		return err
+	}
+	if err := c.Server.Metrics.Validate(); err != nil {
+		return err
+	}
+	if err := c.Server.CORS.Validate(); err != nil {
+		return err
+	}
+	if err := c.Server.DegradedMode.Validate(); err != nil {
+		return err
+	}
 
 	// Validate database config
 	if c.Database.Host == "" {
@@ -384,21 +747,13 @@ func (c *Config) Validate() error {
 	if c.Database.DBName == "" {
 		return fmt.Errorf("database name is required")
 	}
+	if c.Database.StatementTimeoutSeconds < 0 {
+		return fmt.Errorf("database statement timeout cannot be negative: %d", c.Database.StatementTimeoutSeconds)
+	}
 
 	// Validate storage config
-	if c.Storage.IsS3() {
-		if c.Storage.S3Bucket == "" {
-			return fmt.Errorf("S3 bucket is required when using S3 storage")
-		}
-		if c.Storage.S3Region == "" {
-			return fmt.Errorf("S3 region is required when using S3 storage")
-		}
-	} else if c.Storage.IsFilesystem() {
-		if c.Storage.BasePath == "" {
-			return fmt.Errorf("storage base path is required for filesystem storage")
-		}
-	} else {
-		return fmt.Errorf("invalid storage type: %s", c.Storage.Type)
+	if err := c.Storage.Validate(); err != nil {
+		return err
 	}
 
 	// Validate SSH config if enabled
@@ -406,6 +761,12 @@ func (c *Config) Validate() error {
 		if c.SSH.Port <= 0 || c.SSH.Port > 65535 {
 			return fmt.Errorf("invalid SSH port: %d", c.SSH.Port)
 		}
+		if c.SSH.MaxSessionMinutes < 0 || c.SSH.IdleTimeoutSeconds < 0 {
+			return fmt.Errorf("ssh.max_session_duration and ssh.idle_timeout must not be negative")
+		}
+		if c.SSH.MaxSessions < 0 || c.SSH.MaxSessionsPerUser < 0 {
+			return fmt.Errorf("ssh.max_sessions and ssh.max_sessions_per_user must not be negative")
+		}
 	}
 
 	// Validate OIDC config if enabled
@@ -422,6 +783,121 @@ func (c *Config) Validate() error {
 		if c.OIDC.JWTSecret == "" {
 			return fmt.Errorf("OIDC JWT secret is required when OIDC is enabled")
 		}
+		// A half-configured mapping would silently grant no one the role
+		if (c.OIDC.AdminMapping.Claim == "") != (len(c.OIDC.AdminMapping.Values) == 0) {
+			return fmt.Errorf("oidc.admin_mapping.claim and oidc.admin_mapping.values must be set together")
+		}
+		if err := c.OIDC.Sessions.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Validate CI config
+	if c.CI.Enabled && c.CI.JobTimeoutMinutes <= 0 {
+		return fmt.Errorf("ci.job_timeout_minutes must be positive when CI is enabled")
+	}
+	if c.CI.LogLimitLines < 0 || c.CI.LogLimitMB < 0 {
+		return fmt.Errorf("ci.log_limit_lines and ci.log_limit_mb must not be negative")
+	}
+	if c.CI.Enabled && c.CI.ArtifactsDirectory == "" {
+		return fmt.Errorf("ci.artifacts_directory is required when CI is enabled")
+	}
+	if c.CI.MaxArtifactSize < 0 {
+		return fmt.Errorf("ci.max_artifact_size must not be negative")
+	}
+
+	// Validate repository config
+	if c.Repos.BulkTaskRetentionDays <= 0 {
+		return fmt.Errorf("repos.bulk_task_retention_days must be positive")
+	}
+	if c.Repos.RenameRedirectDays < 0 {
+		return fmt.Errorf("repos.rename_redirect_days must not be negative")
+	}
+	// Git LFS pointers are smaller than 1 KiB and must never draw the warning
+	if c.Repos.LargeFileWarningSize != 0 && c.Repos.LargeFileWarningSize < 1024 {
+		return fmt.Errorf("repos.large_file_warning_size must be 0 or at least 1024 bytes")
+	}
+	if c.Repos.MaxRawFileSize < 0 {
+		return fmt.Errorf("repos.max_raw_file_size must not be negative")
+	}
+	if c.Repos.MaxInlineFileSize < 0 {
+		return fmt.Errorf("repos.max_inline_file_size must not be negative")
+	}
+	if c.Repos.MaxLFSObjectSize < 0 {
+		return fmt.Errorf("repos.max_lfs_object_size must not be negative")
+	}
+	if c.Repos.StaleLockMinutes < 0 {
+		return fmt.Errorf("repos.stale_lock_minutes must not be negative")
+	}
+	if c.Repos.MaxReposPerUser < 0 {
+		return fmt.Errorf("repos.max_repos_per_user must not be negative")
+	}
+	if c.Repos.GCIntervalMinutes < 0 {
+		return fmt.Errorf("repos.gc_interval_minutes must not be negative")
+	}
+	if c.Repos.GCStrategy != "auto" && c.Repos.GCStrategy != "repack" {
+		return fmt.Errorf("repos.gc_strategy must be auto or repack")
+	}
+	if c.Repos.GCLooseObjects <= 0 || c.Repos.GCPacks <= 0 {
+		return fmt.Errorf("repos.gc_loose_objects and repos.gc_packs must be positive")
+	}
+	if c.Repos.DefaultBranch == "" || plumbing.NewBranchReferenceName(c.Repos.DefaultBranch).Validate() != nil {
+		return fmt.Errorf("repos.default_branch must be a valid branch name")
+	}
+	if c.Repos.MaxImportArchiveSize < 0 || c.Repos.MaxImportSize < 0 || c.Repos.MaxImportFiles < 0 {
+		return fmt.Errorf("repos.max_import_archive_size, repos.max_import_size and repos.max_import_files must not be negative")
+	}
+	if c.Repos.MaxConcurrentImports <= 0 {
+		return fmt.Errorf("repos.max_concurrent_imports must be positive")
+	}
+
+	// Validate annotation config
+	if c.Annotations.MaxValueLength > MaxAnnotationValueLength {
+		return fmt.Errorf("annotation max value length must be at most %d bytes", MaxAnnotationValueLength)
+	}
+
+	// Validate badge proxy config if enabled
+	if c.BadgeProxy.Enabled && len(c.BadgeProxy.AllowedHosts) == 0 {
+		return fmt.Errorf("badge proxy allowed hosts are required when the badge proxy is enabled")
+	}
+
+	// Validate audit sinks
+	if err := c.Audit.Validate(); err != nil {
+		return err
+	}
+
+	// Validate token anomaly detection
+	if err := c.Tokens.AnomalyDetection.Validate(); err != nil {
+		return err
+	}
+
+	// Validate push attempt record
+	if err := c.PushAttempts.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Exports.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Backups.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Onboarding.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Signing.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Email.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Webhooks.Validate(); err != nil {
+		return err
 	}
 
 	return nil
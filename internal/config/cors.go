@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CORSConfig holds the CORS policy of the REST API (/api). The git smart HTTP
+// endpoints are not covered: git clients do not need it.
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to call the API: "*" for any
+	// origin, or scheme://host[:port] with an optional wildcard, as in
+	// https://*.example.com. The OIDC frontend URL is always allowed.
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+
+	// AllowedMethods are the methods preflight requests may ask for
+	AllowedMethods []string `mapstructure:"allowed_methods"`
+
+	// AllowedHeaders are the request headers preflight requests may ask for
+	AllowedHeaders []string `mapstructure:"allowed_headers"`
+
+	// AllowCredentials lets browsers send cookies and authorization headers
+	AllowCredentials bool `mapstructure:"allow_credentials"`
+
+	// MaxAgeSeconds is how long browsers may cache a preflight response
+	MaxAgeSeconds int `mapstructure:"max_age"`
+}
+
+// AllowsAllOrigins returns true if any origin may call the API
+func (c *CORSConfig) AllowsAllOrigins() bool {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the CORS configuration
+func (c *CORSConfig) Validate() error {
+	for _, origin := range c.AllowedOrigins {
+		if origin != "*" && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("server.cors.allowed_origins: %q must be * or start with http:// or https://", origin)
+		}
+	}
+	if c.AllowsAllOrigins() && c.AllowCredentials {
+		return fmt.Errorf("server.cors.allowed_origins cannot be * when server.cors.allow_credentials is set, list the origins instead")
+	}
+	if c.MaxAgeSeconds < 0 {
+		return fmt.Errorf("server.cors.max_age must not be negative, got %d", c.MaxAgeSeconds)
+	}
+	return nil
+}
+
+// SecurityHeadersConfig holds the security headers set on every response. An
+// empty value leaves its header out.
+type SecurityHeadersConfig struct {
+	// ContentTypeOptions is the X-Content-Type-Options header
+	ContentTypeOptions string `mapstructure:"content_type_options"`
+
+	// FrameOptions is the X-Frame-Options header
+	FrameOptions string `mapstructure:"frame_options"`
+
+	// ReferrerPolicy is the Referrer-Policy header
+	ReferrerPolicy string `mapstructure:"referrer_policy"`
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DegradedModeConfig holds the configuration of degraded mode, which keeps
+// anonymous fetches of public repositories working while the database is
+// unreachable, from a snapshot of their metadata
+type DegradedModeConfig struct {
+	// Enabled turns degraded mode on
+	Enabled bool `mapstructure:"enabled"`
+
+	// RefreshIntervalSeconds is the time between refreshes of the snapshot
+	// while the database is reachable
+	RefreshIntervalSeconds int `mapstructure:"refresh_interval"`
+
+	// ProbeIntervalSeconds is the time between checks of the database
+	ProbeIntervalSeconds int `mapstructure:"probe_interval"`
+
+	// MaxStalenessSeconds is the age past which the snapshot is no longer
+	// served from: repositories created, deleted or made private since could
+	// be served wrongly
+	MaxStalenessSeconds int `mapstructure:"max_staleness"`
+}
+
+// RefreshInterval returns the time between refreshes of the snapshot
+func (c *DegradedModeConfig) RefreshInterval() time.Duration {
+	return time.Duration(c.RefreshIntervalSeconds) * time.Second
+}
+
+// ProbeInterval returns the time between checks of the database
+func (c *DegradedModeConfig) ProbeInterval() time.Duration {
+	return time.Duration(c.ProbeIntervalSeconds) * time.Second
+}
+
+// MaxStaleness returns the age past which the snapshot is not served from
+func (c *DegradedModeConfig) MaxStaleness() time.Duration {
+	return time.Duration(c.MaxStalenessSeconds) * time.Second
+}
+
+// Validate checks the degraded mode configuration
+func (c *DegradedModeConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.RefreshIntervalSeconds <= 0 {
+		return fmt.Errorf("server.degraded_mode.refresh_interval must be positive, got %d", c.RefreshIntervalSeconds)
+	}
+	if c.ProbeIntervalSeconds <= 0 {
+		return fmt.Errorf("server.degraded_mode.probe_interval must be positive, got %d", c.ProbeIntervalSeconds)
+	}
+	if c.MaxStalenessSeconds < c.RefreshIntervalSeconds {
+		return fmt.Errorf("server.degraded_mode.max_staleness must be at least refresh_interval (%d), got %d", c.RefreshIntervalSeconds, c.MaxStalenessSeconds)
+	}
+	return nil
+}
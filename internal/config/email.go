@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"time"
+)
+
+// Email transports
+const (
+	// EmailTransportSMTP sends emails through an SMTP server
+	EmailTransportSMTP = "smtp"
+
+	// EmailTransportDirectory writes emails to files in a directory instead
+	// of sending them, for tests and CI
+	EmailTransportDirectory = "directory"
+)
+
+// SMTP TLS modes
+const (
+	// EmailTLSNone connects without TLS
+	EmailTLSNone = "none"
+
+	// EmailTLSStartTLS upgrades the connection with STARTTLS, usually on port 587
+	EmailTLSStartTLS = "starttls"
+
+	// EmailTLSImplicit connects over TLS from the start, usually on port 465
+	EmailTLSImplicit = "tls"
+)
+
+// EmailConfig holds the configuration of outgoing email
+type EmailConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Transport is smtp or directory
+	Transport string `mapstructure:"transport"`
+
+	// Directory receives one .eml file per email with the directory transport
+	Directory string `mapstructure:"directory"`
+
+	// From is the sender address, e.g. "Stasis <noreply@example.com>"
+	From string `mapstructure:"from"`
+
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	TLSMode  string `mapstructure:"tls_mode"` // none, starttls, tls
+	Username string `mapstructure:"username"` // Empty to send without authentication
+	Password string `mapstructure:"password"`
+
+	// QueueSize is the number of emails waiting to be sent; emails beyond it
+	// are dead-lettered
+	QueueSize int `mapstructure:"queue_size"`
+
+	// MaxAttempts is how often an email is tried before it is dead-lettered
+	MaxAttempts int `mapstructure:"max_attempts"`
+
+	// RetryBackoffSeconds is the wait before the first retry, doubled for
+	// each further retry
+	RetryBackoffSeconds int `mapstructure:"retry_backoff_seconds"`
+
+	// DeadLetterPath is the file emails that could not be sent are appended
+	// to, as JSON lines
+	DeadLetterPath string `mapstructure:"dead_letter_path"`
+}
+
+// Validate checks the email configuration
+func (c *EmailConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("email.from must be an email address: %w", err)
+	}
+	switch c.Transport {
+	case EmailTransportSMTP:
+		if c.Host == "" {
+			return fmt.Errorf("email.host is required with the smtp transport")
+		}
+		if c.Port <= 0 || c.Port > 65535 {
+			return fmt.Errorf("invalid email port: %d", c.Port)
+		}
+		if c.TLSMode != EmailTLSNone && c.TLSMode != EmailTLSStartTLS && c.TLSMode != EmailTLSImplicit {
+			return fmt.Errorf("email.tls_mode must be none, starttls or tls, got %q", c.TLSMode)
+		}
+	case EmailTransportDirectory:
+		if c.Directory == "" {
+			return fmt.Errorf("email.directory is required with the directory transport")
+		}
+	default:
+		return fmt.Errorf("email.transport must be smtp or directory, got %q", c.Transport)
+	}
+	if c.QueueSize <= 0 {
+		return fmt.Errorf("email.queue_size must be positive")
+	}
+	if c.MaxAttempts <= 0 {
+		return fmt.Errorf("email.max_attempts must be positive")
+	}
+	if c.RetryBackoffSeconds <= 0 {
+		return fmt.Errorf("email.retry_backoff_seconds must be positive")
+	}
+	if c.DeadLetterPath == "" {
+		return fmt.Errorf("email.dead_letter_path is required")
+	}
+	return nil
+}
+
+// RetryBackoff returns the wait before the first retry of an email
+func (c *EmailConfig) RetryBackoff() time.Duration {
+	return time.Duration(c.RetryBackoffSeconds) * time.Second
+}
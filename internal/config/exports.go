@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ExportsConfig holds configuration for user data exports
+type ExportsConfig struct {
+	// RetentionDays is how long a finished export can be downloaded before
+	// it is deleted
+	RetentionDays int `mapstructure:"retention_days"`
+
+	// Directory is the storage path exports are written under, relative to
+	// the default storage backend
+	Directory string `mapstructure:"directory"`
+
+	// SigningSecret signs download links. If empty a random secret is used,
+	// so links stop working when the server restarts.
+	SigningSecret string `mapstructure:"signing_secret"`
+}
+
+// Validate checks the export configuration
+func (c *ExportsConfig) Validate() error {
+	if c.RetentionDays <= 0 {
+		return fmt.Errorf("exports.retention_days must be positive")
+	}
+	if c.Directory == "" {
+		return fmt.Errorf("exports.directory is required")
+	}
+	return nil
+}
+
+// Retention returns how long finished exports are kept
+func (c *ExportsConfig) Retention() time.Duration {
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
@@ -0,0 +1,35 @@
+package config
+
+// HighlightConfig holds configuration for server-side syntax highlighting
+type HighlightConfig struct {
+	// MaxSizeBytes is the largest file that is highlighted; larger files are
+	// returned as plain text only
+	MaxSizeBytes int64 `mapstructure:"max_size"`
+
+	// CacheEntries is the number of highlighted blobs kept in memory
+	CacheEntries int `mapstructure:"cache_entries"`
+}
+
+// DefaultHighlightConfig returns default highlighting configuration
+func DefaultHighlightConfig() HighlightConfig {
+	return HighlightConfig{
+		MaxSizeBytes: 1024 * 1024,
+		CacheEntries: 512,
+	}
+}
+
+// GetMaxSizeBytes returns the highlighting size cap, falling back to the default
+func (c *HighlightConfig) GetMaxSizeBytes() int64 {
+	if c.MaxSizeBytes <= 0 {
+		return DefaultHighlightConfig().MaxSizeBytes
+	}
+	return c.MaxSizeBytes
+}
+
+// GetCacheEntries returns the cache size, falling back to the default
+func (c *HighlightConfig) GetCacheEntries() int {
+	if c.CacheEntries <= 0 {
+		return DefaultHighlightConfig().CacheEntries
+	}
+	return c.CacheEntries
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MetricsConfig holds the configuration of the Prometheus metrics endpoint
+type MetricsConfig struct {
+	// Enabled serves the metrics and records the HTTP request metrics
+	Enabled bool `mapstructure:"enabled"`
+
+	// Path is the route the metrics are served on
+	Path string `mapstructure:"path"`
+
+	// Username and Password require basic auth to read the metrics, if set
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// RequiresAuth returns true if reading the metrics requires basic auth
+func (c *MetricsConfig) RequiresAuth() bool {
+	return c.Username != ""
+}
+
+// Validate checks the metrics configuration
+func (c *MetricsConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if !strings.HasPrefix(c.Path, "/") || strings.ContainsAny(c.Path, ":*") {
+		return fmt.Errorf("server.metrics.path must be a static path starting with /, got %q", c.Path)
+	}
+	if (c.Username == "") != (c.Password == "") {
+		return fmt.Errorf("server.metrics.username and server.metrics.password must be set together")
+	}
+	return nil
+}
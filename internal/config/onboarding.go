@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// onboardingRepoName matches the names repositories can be created with
+var onboardingRepoName = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+
+// OnboardingConfig holds configuration for the onboarding of new users
+type OnboardingConfig struct {
+	// Enabled determines if users get a playground repository on their
+	// first sign-in
+	Enabled bool `mapstructure:"enabled"`
+
+	// RepoName is the name of the private repository created for each user
+	RepoName string `mapstructure:"repo_name"`
+
+	// Description is the description of the created repository
+	Description string `mapstructure:"description"`
+
+	// Readme is the README.md committed to the repository. {{username}} and
+	// {{repository}} are replaced with the user and repository names.
+	Readme string `mapstructure:"readme"`
+
+	// CIConfig is the sample pipeline committed at ci.config_path; empty
+	// commits none
+	CIConfig string `mapstructure:"ci_config"`
+}
+
+// Validate checks the onboarding configuration
+func (c *OnboardingConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if !onboardingRepoName.MatchString(c.RepoName) || c.RepoName == "." || c.RepoName == ".." {
+		return fmt.Errorf("onboarding.repo_name %q is not a valid repository name", c.RepoName)
+	}
+	if c.Readme == "" {
+		return fmt.Errorf("onboarding.readme is required")
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// PushAttemptsConfig holds configuration for the record of push attempts
+type PushAttemptsConfig struct {
+	// Enabled determines if push attempts are recorded
+	Enabled bool `mapstructure:"enabled"`
+
+	// RetentionDays is how long push attempts are kept (0 = forever)
+	RetentionDays int `mapstructure:"retention_days"`
+
+	// MaxOutputSize is the largest output stored per push in bytes; longer
+	// output keeps its beginning and end
+	MaxOutputSize int `mapstructure:"max_output_size"`
+}
+
+// Validate checks the push attempt configuration
+func (c *PushAttemptsConfig) Validate() error {
+	if c.RetentionDays < 0 {
+		return fmt.Errorf("push_attempts.retention_days must not be negative")
+	}
+	if c.MaxOutputSize < 0 {
+		return fmt.Errorf("push_attempts.max_output_size must not be negative")
+	}
+	return nil
+}
+
+// Retention returns how long push attempts are kept, or 0 to keep them forever
+func (c *PushAttemptsConfig) Retention() time.Duration {
+	if c.RetentionDays <= 0 {
+		return 0
+	}
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
+
+// GetMaxOutputSize returns the maximum stored output size with default fallback
+func (c *PushAttemptsConfig) GetMaxOutputSize() int {
+	if c.MaxOutputSize <= 0 {
+		return 64 * 1024
+	}
+	return c.MaxOutputSize
+}
@@ -0,0 +1,55 @@
+package config
+
+import "fmt"
+
+// RateLimitConfig holds configuration for HTTP rate limiting. Each client (the
+// authenticated user, or the client IP for anonymous requests) gets a token
+// bucket per route group.
+type RateLimitConfig struct {
+	// Enabled determines if requests are rate limited
+	Enabled bool `mapstructure:"enabled"`
+
+	// API limits the REST API (/api/v1)
+	API RateLimitRule `mapstructure:"api"`
+
+	// Git limits the smart and dumb HTTP git transport (clone, fetch, push)
+	Git RateLimitRule `mapstructure:"git"`
+
+	// Writes limits API calls that create git objects (branches, tags) per
+	// user and repository, on top of the API budget
+	Writes RateLimitRule `mapstructure:"writes"`
+}
+
+// RateLimitRule is the budget of a client for a route group
+type RateLimitRule struct {
+	// RequestsPerMinute is the sustained request rate
+	RequestsPerMinute int `mapstructure:"requests_per_minute"`
+
+	// Burst is the number of requests allowed at once after a quiet period
+	Burst int `mapstructure:"burst"`
+}
+
+// Validate checks the rate limit configuration
+func (c *RateLimitConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if err := c.API.validate("api"); err != nil {
+		return err
+	}
+	if err := c.Git.validate("git"); err != nil {
+		return err
+	}
+	return c.Writes.validate("writes")
+}
+
+// validate checks a rate limit rule
+func (r *RateLimitRule) validate(name string) error {
+	if r.RequestsPerMinute <= 0 {
+		return fmt.Errorf("server.rate_limit.%s.requests_per_minute must be positive", name)
+	}
+	if r.Burst <= 0 {
+		return fmt.Errorf("server.rate_limit.%s.burst must be positive", name)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package config
+
+import "time"
+
+// ReposConfig holds repository behaviour configuration
+type ReposConfig struct {
+	// CreateOnPush creates a missing repository when an authenticated user
+	// pushes into their own namespace. New repositories are private.
+	CreateOnPush bool `mapstructure:"create_on_push"`
+
+	// MaxReposPerUser is the most repositories a user may own; creating,
+	// importing or forking another one is refused (0 = unlimited)
+	MaxReposPerUser int `mapstructure:"max_repos_per_user"`
+
+	// WebURL is the base URL of the web interface, linked to from the
+	// messages git clients print, e.g. when a push created a repository
+	// (empty = no links)
+	WebURL string `mapstructure:"web_url"`
+
+	// MaxPushSize is the largest pack a single push may send in bytes (0 = unlimited)
+	MaxPushSize int64 `mapstructure:"max_push_size"`
+
+	// MaxFileSize is the largest blob a push may add in bytes (0 = unlimited)
+	MaxFileSize int64 `mapstructure:"max_file_size"`
+
+	// LargeFileWarningSize is the size in bytes above which a file added by a
+	// push draws a warning suggesting Git LFS (0 = never). Pushes are not refused.
+	LargeFileWarningSize int64 `mapstructure:"large_file_warning_size"`
+
+	// MaxRawFileSize is the largest file the raw file endpoint serves in bytes
+	// unless the request asks for large files (0 = unlimited)
+	MaxRawFileSize int64 `mapstructure:"max_raw_file_size"`
+
+	// MaxInlineFileSize is the largest file whose content the file content API
+	// returns in its JSON response in bytes; larger files are returned without
+	// content, pointing at the raw file endpoint (0 = unlimited)
+	MaxInlineFileSize int64 `mapstructure:"max_inline_file_size"`
+
+	// MaxLFSObjectSize is the largest Git LFS object that may be uploaded in
+	// bytes (0 = unlimited)
+	MaxLFSObjectSize int64 `mapstructure:"max_lfs_object_size"`
+
+	// MaxBranches is the most branches the API creates in a repository (0 = unlimited)
+	MaxBranches int `mapstructure:"max_branches"`
+
+	// MaxTags is the most tags the API creates in a repository (0 = unlimited)
+	MaxTags int `mapstructure:"max_tags"`
+
+	// RepoQuota is the most disk space a repository may use in bytes before
+	// pushes are refused (0 = unlimited). Administrators can override it per repository.
+	RepoQuota int64 `mapstructure:"repo_quota"`
+
+	// UserQuota is the most disk space the repositories of a user may use
+	// together in bytes before pushes are refused (0 = unlimited)
+	UserQuota int64 `mapstructure:"user_quota"`
+
+	// BulkTaskRetentionDays is how long the results of a finished bulk
+	// operation can be looked up before they are deleted
+	BulkTaskRetentionDays int `mapstructure:"bulk_task_retention_days"`
+
+	// StaleLockMinutes is how old a lock or temporary file left in a
+	// repository by a git operation must be before it is removed as left by
+	// a killed process (0 = never remove them)
+	StaleLockMinutes int `mapstructure:"stale_lock_minutes"`
+
+	// RenameRedirectDays is how long the former name of a renamed repository
+	// keeps working: git clients are served and API callers redirected to
+	// the new name (0 = until a repository takes the name)
+	RenameRedirectDays int `mapstructure:"rename_redirect_days"`
+
+	// GCIntervalMinutes is the time between scheduled garbage collections
+	// of repositories (0 = never collect on a schedule)
+	GCIntervalMinutes int `mapstructure:"gc_interval_minutes"`
+
+	// GCStrategy is how a repository is collected: "auto" runs git gc --auto,
+	// "repack" packs all objects into a single pack, keeping unreachable ones
+	GCStrategy string `mapstructure:"gc_strategy"`
+
+	// GCLooseObjects is the number of loose objects above which a scheduled
+	// garbage collection picks a repository, like gc.auto of git
+	GCLooseObjects int `mapstructure:"gc_loose_objects"`
+
+	// GCPacks is the number of packs above which a scheduled garbage
+	// collection picks a repository, like gc.autoPackLimit of git
+	GCPacks int `mapstructure:"gc_packs"`
+
+	// DefaultBranch is the initial branch of new repositories, like
+	// init.defaultBranch of git. Owners can change it per repository.
+	DefaultBranch string `mapstructure:"default_branch"`
+
+	// MaxImportArchiveSize is the largest archive an import may upload in
+	// bytes (0 = unlimited)
+	MaxImportArchiveSize int64 `mapstructure:"max_import_archive_size"`
+
+	// MaxImportSize is the most bytes the files of an imported archive may
+	// take once unpacked (0 = unlimited)
+	MaxImportSize int64 `mapstructure:"max_import_size"`
+
+	// MaxImportFiles is the most files an imported archive may hold (0 = unlimited)
+	MaxImportFiles int `mapstructure:"max_import_files"`
+
+	// MaxConcurrentImports is the most imports run at a time; others wait
+	// in the pending status
+	MaxConcurrentImports int `mapstructure:"max_concurrent_imports"`
+
+	// ImportAllowPrivateAddresses allows imports and mirrors to clone and
+	// fetch from loopback, private and link-local addresses, such as git
+	// servers next to this one, and over git and ssh
+	ImportAllowPrivateAddresses bool `mapstructure:"import_allow_private_addresses"`
+}
+
+// BulkTaskRetention returns how long finished bulk operations are kept
+func (c *ReposConfig) BulkTaskRetention() time.Duration {
+	return time.Duration(c.BulkTaskRetentionDays) * 24 * time.Hour
+}
+
+// StaleLockAge returns how old leftover lock and temporary files must be
+// before they are removed
+func (c *ReposConfig) StaleLockAge() time.Duration {
+	return time.Duration(c.StaleLockMinutes) * time.Minute
+}
+
+// RenameRedirectPeriod returns how long the former names of renamed
+// repositories are redirected, 0 for no limit
+func (c *ReposConfig) RenameRedirectPeriod() time.Duration {
+	return time.Duration(c.RenameRedirectDays) * 24 * time.Hour
+}
+
+// GCInterval returns the time between scheduled garbage collections, 0 if
+// repositories are not collected on a schedule
+func (c *ReposConfig) GCInterval() time.Duration {
+	return time.Duration(c.GCIntervalMinutes) * time.Minute
+}
+
+// DefaultReposConfig returns default repository configuration
+func DefaultReposConfig() ReposConfig {
+	return ReposConfig{
+		CreateOnPush:      false,
+		MaxPushSize:       0,
+		MaxFileSize:       0,
+		MaxRawFileSize:    50 * 1024 * 1024,
+		MaxInlineFileSize: 1024 * 1024,
+		MaxLFSObjectSize:  2 * 1024 * 1024 * 1024,
+		MaxBranches:       5000,
+		MaxTags:           10000,
+		RepoQuota:         0,
+		UserQuota:         0,
+
+		LargeFileWarningSize:  10 * 1024 * 1024,
+		BulkTaskRetentionDays: 7,
+		StaleLockMinutes:      60,
+		RenameRedirectDays:    90,
+		WebURL:                "http://localhost:3000",
+		GCStrategy:            "auto",
+		GCLooseObjects:        6700,
+		GCPacks:               50,
+		DefaultBranch:         "main",
+		MaxImportArchiveSize:  512 * 1024 * 1024,
+		MaxImportSize:         2 * 1024 * 1024 * 1024,
+		MaxImportFiles:        100000,
+		MaxConcurrentImports:  2,
+	}
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// fileReferencePrefix starts a value read from a file, as in
+// file:///run/secrets/db_password
+const fileReferencePrefix = "file://"
+
+// envReference matches a reference to an environment variable, ${NAME}
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// resolveReferences replaces the references to secrets in the string values
+// of cfg, anywhere in the configuration:
+//   - a value of the form file:///path is replaced by the content of the
+//     file, without surrounding whitespace
+//   - ${NAME} is replaced by the value of the environment variable NAME, and
+//     may appear inside a larger value
+//
+// The references are kept in cfg.references, by key, so the configuration
+// can be shown without the resolved values. Errors name the key and the
+// reference, never a resolved value.
+func resolveReferences(cfg *Config) error {
+	cfg.references = make(map[string]string)
+	return resolveValue(reflect.ValueOf(cfg).Elem(), "", cfg.references)
+}
+
+// resolveValue resolves the references in v, the value at key
+func resolveValue(v reflect.Value, key string, references map[string]string) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return resolveValue(v.Elem(), key, references)
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+			if name == "-" {
+				continue
+			}
+			fieldKey := key
+			if name != "" {
+				fieldKey = joinKey(key, name)
+			}
+			if err := resolveValue(v.Field(i), fieldKey, references); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := resolveValue(v.Index(i), fmt.Sprintf("%s[%d]", key, i), references); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			entryKey := joinKey(key, iter.Key().String())
+			resolved, changed, err := resolveString(iter.Value().String(), entryKey)
+			if err != nil {
+				return err
+			}
+			if changed {
+				references[entryKey] = iter.Value().String()
+				v.SetMapIndex(iter.Key(), reflect.ValueOf(resolved).Convert(v.Type().Elem()))
+			}
+		}
+
+	case reflect.String:
+		resolved, changed, err := resolveString(v.String(), key)
+		if err != nil {
+			return err
+		}
+		if changed {
+			references[key] = v.String()
+			v.SetString(resolved)
+		}
+	}
+	return nil
+}
+
+// resolveString resolves the references in value, the value at key. changed
+// is false if value holds no reference.
+func resolveString(value, key string) (resolved string, changed bool, err error) {
+	if path, ok := strings.CutPrefix(value, fileReferencePrefix); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("%s: cannot read %s: %w", key, value, err)
+		}
+		content := strings.TrimSpace(string(data))
+		if content == "" {
+			return "", false, fmt.Errorf("%s: %s is empty", key, value)
+		}
+		return content, true, nil
+	}
+
+	if !envReference.MatchString(value) {
+		return value, false, nil
+	}
+	var missing string
+	resolved = envReference.ReplaceAllStringFunc(value, func(reference string) string {
+		name := envReference.FindStringSubmatch(reference)[1]
+		content, ok := os.LookupEnv(name)
+		if !ok && missing == "" {
+			missing = reference
+		}
+		return content
+	})
+	if missing != "" {
+		return "", false, fmt.Errorf("%s: environment variable %s is not set", key, missing)
+	}
+	return resolved, true, nil
+}
+
+// joinKey returns the key of name under parent
+func joinKey(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// References returns the values of the configuration that referenced a file
+// or environment variable, by key (e.g. database.password or
+// storage.backends[1].s3_secret_key), as written in the configuration. Show
+// these instead of the resolved values wherever the configuration is
+// displayed.
+func (c *Config) References() map[string]string {
+	references := make(map[string]string, len(c.references))
+	for key, reference := range c.references {
+		references[key] = reference
+	}
+	return references
+}
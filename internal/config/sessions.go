@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// OIDCSessionConfig holds the configuration of the server-side sessions of
+// OIDC logins. A session outlives its session tokens: the frontend refreshes
+// them until the session reaches its absolute lifetime or is left idle.
+type OIDCSessionConfig struct {
+	// EncryptionKey encrypts the refresh and ID tokens of the identity
+	// provider stored with sessions. Any string; changing it ends the
+	// sessions refreshed through the identity provider.
+	EncryptionKey string `mapstructure:"encryption_key"`
+
+	// AbsoluteLifetimeHours is the time after login at which a session ends,
+	// however active it is
+	AbsoluteLifetimeHours int `mapstructure:"absolute_lifetime"`
+
+	// IdleTimeoutMinutes is the time without requests or refreshes after
+	// which a session ends
+	IdleTimeoutMinutes int `mapstructure:"idle_timeout"`
+
+	// TokenTTLMinutes is the lifetime of a session token; the frontend
+	// refreshes it before or once it expired
+	TokenTTLMinutes int `mapstructure:"token_ttl"`
+}
+
+// AbsoluteLifetime returns the time after login at which a session ends
+func (c *OIDCSessionConfig) AbsoluteLifetime() time.Duration {
+	return time.Duration(c.AbsoluteLifetimeHours) * time.Hour
+}
+
+// IdleTimeout returns the time without use after which a session ends
+func (c *OIDCSessionConfig) IdleTimeout() time.Duration {
+	return time.Duration(c.IdleTimeoutMinutes) * time.Minute
+}
+
+// TokenTTL returns the lifetime of a session token
+func (c *OIDCSessionConfig) TokenTTL() time.Duration {
+	return time.Duration(c.TokenTTLMinutes) * time.Minute
+}
+
+// Validate checks the session configuration
+func (c *OIDCSessionConfig) Validate() error {
+	if c.EncryptionKey == "" {
+		return fmt.Errorf("oidc.sessions.encryption_key is required when OIDC is enabled")
+	}
+	if c.AbsoluteLifetimeHours <= 0 {
+		return fmt.Errorf("oidc.sessions.absolute_lifetime must be positive, got %d", c.AbsoluteLifetimeHours)
+	}
+	if c.IdleTimeoutMinutes <= 0 {
+		return fmt.Errorf("oidc.sessions.idle_timeout must be positive, got %d", c.IdleTimeoutMinutes)
+	}
+	if c.TokenTTLMinutes <= 0 {
+		return fmt.Errorf("oidc.sessions.token_ttl must be positive, got %d", c.TokenTTLMinutes)
+	}
+	if c.TokenTTL() > c.IdleTimeout() {
+		return fmt.Errorf("oidc.sessions.token_ttl (%d minutes) must not exceed idle_timeout (%d minutes)", c.TokenTTLMinutes, c.IdleTimeoutMinutes)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+)
+
+// SigningConfig holds the instance key the server signs the commits it
+// creates with, such as pull request merges and the initial commits of
+// onboarding repositories
+type SigningConfig struct {
+	// Format is the format of the key: gpg for an ASCII-armored OpenPGP
+	// private key, ssh for an OpenSSH private key
+	Format string `mapstructure:"format"`
+
+	// KeyPath is the path of the private key. Empty disables signing: the
+	// commits are created unsigned, committed by their author.
+	KeyPath string `mapstructure:"key_path"`
+
+	// Passphrase decrypts the private key, if it is encrypted
+	Passphrase string `mapstructure:"passphrase"`
+
+	// CommitterName and CommitterEmail are the identity signed commits are
+	// committed as; their author remains the user who made them
+	CommitterName  string `mapstructure:"committer_name"`
+	CommitterEmail string `mapstructure:"committer_email"`
+}
+
+// Enabled returns true if the server signs the commits it creates
+func (c *SigningConfig) Enabled() bool {
+	return c.KeyPath != ""
+}
+
+// Validate checks the signing configuration. The key itself is loaded, and
+// checked, when the server starts.
+func (c *SigningConfig) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.Format != "gpg" && c.Format != "ssh" {
+		return fmt.Errorf("signing.format must be gpg or ssh, got %q", c.Format)
+	}
+	if c.CommitterName == "" {
+		return fmt.Errorf("signing.committer_name is required")
+	}
+	if _, err := mail.ParseAddress(c.CommitterEmail); err != nil {
+		return fmt.Errorf("signing.committer_email %q is not a valid email address", c.CommitterEmail)
+	}
+	return nil
+}
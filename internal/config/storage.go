@@ -0,0 +1,212 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultStorageBackend is the name of the backend configured by the top-level
+// storage settings. Repositories created before multiple backends were
+// configured live on it.
+const DefaultStorageBackend = "default"
+
+// storageBackendNamePattern matches valid storage backend names
+var storageBackendNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// StorageConfig holds storage backend configuration. The top-level settings
+// configure the DefaultStorageBackend; Backends adds more named backends.
+type StorageConfig struct {
+	StorageBackendConfig `mapstructure:",squash"`
+
+	Backends  []StorageBackendConfig `mapstructure:"backends"`  // Additional named backends
+	Placement StoragePlacementConfig `mapstructure:"placement"` // Which backend new repositories are created on
+}
+
+// StorageBackendConfig holds the configuration of a single storage backend
+type StorageBackendConfig struct {
+	Name           string `mapstructure:"name"` // Unique name; ignored for the top-level backend, which is DefaultStorageBackend
+	Type           string `mapstructure:"type"` // filesystem, s3
+	BasePath       string `mapstructure:"base_path"`
+	S3Bucket       string `mapstructure:"s3_bucket"`
+	S3Region       string `mapstructure:"s3_region"`
+	S3AccessKey    string `mapstructure:"s3_access_key"`
+	S3SecretKey    string `mapstructure:"s3_secret_key"`
+	S3Endpoint     string `mapstructure:"s3_endpoint"`       // For S3-compatible services
+	S3UsePathStyle bool   `mapstructure:"s3_use_path_style"` // Use path-style addressing (required for MinIO)
+
+	// S3 client transport settings
+	S3CABundlePath        string `mapstructure:"s3_ca_bundle_path"`          // PEM bundle trusted in addition to the system roots
+	S3InsecureSkipVerify  bool   `mapstructure:"s3_insecure_skip_verify"`    // Disable TLS verification (testing only)
+	S3MaxRetries          int    `mapstructure:"s3_max_retries"`             // Retries after the first attempt
+	S3RetryMaxBackoff     int    `mapstructure:"s3_retry_max_backoff"`       // Maximum backoff between retries in seconds
+	S3RequestTimeout      int    `mapstructure:"s3_request_timeout"`         // Wait for the response headers of an attempt in seconds (0 = no timeout)
+	S3MaxIdleConns        int    `mapstructure:"s3_max_idle_conns"`          // Idle connection pool size
+	S3MaxIdleConnsPerHost int    `mapstructure:"s3_max_idle_conns_per_host"` // Idle connections kept per host
+}
+
+// StoragePlacementConfig decides which backend new repositories are created on.
+// Rules are evaluated in order and the first matching rule wins; repositories
+// no rule matches go to the RoundRobin backends in turn if set, otherwise to Backend.
+type StoragePlacementConfig struct {
+	Backend    string                 `mapstructure:"backend"`     // Backend for repositories no rule matches (default: DefaultStorageBackend)
+	RoundRobin []string               `mapstructure:"round_robin"` // Backends repositories no rule matches are spread across
+	Rules      []StoragePlacementRule `mapstructure:"rules"`
+}
+
+// StoragePlacementRule places repositories matching all of its conditions on a
+// backend. A rule without conditions matches every repository.
+type StoragePlacementRule struct {
+	Backend   string   `mapstructure:"backend"`
+	Owners    []string `mapstructure:"owners"`      // Usernames of repository owners
+	MinSizeMB int64    `mapstructure:"min_size_mb"` // Size class lower bound; new repositories are empty, forks have the size of their source
+	MaxSizeMB int64    `mapstructure:"max_size_mb"` // Size class upper bound (0 = unbounded)
+}
+
+// IsS3 returns true if the storage type is S3
+func (s *StorageBackendConfig) IsS3() bool {
+	return strings.ToLower(s.Type) == "s3"
+}
+
+// IsFilesystem returns true if the storage type is filesystem
+func (s *StorageBackendConfig) IsFilesystem() bool {
+	return strings.ToLower(s.Type) == "filesystem" || s.Type == ""
+}
+
+// AllBackends returns the configuration of every backend, the default backend
+// first, with their names set
+func (c *StorageConfig) AllBackends() []StorageBackendConfig {
+	def := c.StorageBackendConfig
+	def.Name = DefaultStorageBackend
+	return append([]StorageBackendConfig{def}, c.Backends...)
+}
+
+// PlacementBackend returns the backend for repositories no placement rule matches
+func (c *StoragePlacementConfig) PlacementBackend() string {
+	if c.Backend == "" {
+		return DefaultStorageBackend
+	}
+	return c.Backend
+}
+
+// Matches returns true if a repository of owner with a size in bytes matches the rule
+func (r *StoragePlacementRule) Matches(owner string, size int64) bool {
+	if len(r.Owners) > 0 && !slices.Contains(r.Owners, owner) {
+		return false
+	}
+	if r.MinSizeMB > 0 && size < r.MinSizeMB*1024*1024 {
+		return false
+	}
+	if r.MaxSizeMB > 0 && size >= r.MaxSizeMB*1024*1024 {
+		return false
+	}
+	return true
+}
+
+// Validate checks the storage backends and placement rules
+func (c *StorageConfig) Validate() error {
+	backends := c.AllBackends()
+	names := make(map[string]bool, len(backends))
+	for i := range backends {
+		backend := &backends[i]
+		if i > 0 {
+			if !storageBackendNamePattern.MatchString(backend.Name) {
+				return fmt.Errorf("storage backend #%d: name must be lowercase letters, digits, '-' or '_'", i)
+			}
+			if backend.Name == DefaultStorageBackend {
+				return fmt.Errorf("storage backend %q: name is reserved for the top-level storage settings", backend.Name)
+			}
+			if names[backend.Name] {
+				return fmt.Errorf("storage backend %q: duplicate name", backend.Name)
+			}
+			if backend.BasePath == "" {
+				// S3 backends keep their local cache there, which must be distinct too
+				return fmt.Errorf("storage backend %q: base_path is required", backend.Name)
+			}
+		}
+		names[backend.Name] = true
+
+		if err := backend.validate(); err != nil {
+			if i == 0 {
+				return err
+			}
+			return fmt.Errorf("storage backend %q: %w", backend.Name, err)
+		}
+	}
+
+	// Repository paths are <base_path>/<owner>/<repo>.git, so base paths must
+	// not overlap or a user named like a directory could shadow another backend
+	for i := range backends {
+		for j := i + 1; j < len(backends); j++ {
+			if pathsOverlap(backends[i].BasePath, backends[j].BasePath) {
+				return fmt.Errorf("storage backends %q and %q: base paths must not overlap", backends[i].Name, backends[j].Name)
+			}
+		}
+	}
+
+	if !names[c.Placement.PlacementBackend()] {
+		return fmt.Errorf("storage placement: unknown backend %q", c.Placement.Backend)
+	}
+	for _, name := range c.Placement.RoundRobin {
+		if !names[name] {
+			return fmt.Errorf("storage placement: unknown round-robin backend %q", name)
+		}
+	}
+	for i, rule := range c.Placement.Rules {
+		if !names[rule.Backend] {
+			return fmt.Errorf("storage placement rule #%d: unknown backend %q", i+1, rule.Backend)
+		}
+		if rule.MinSizeMB < 0 || rule.MaxSizeMB < 0 {
+			return fmt.Errorf("storage placement rule #%d: sizes cannot be negative", i+1)
+		}
+		if rule.MaxSizeMB > 0 && rule.MaxSizeMB <= rule.MinSizeMB {
+			return fmt.Errorf("storage placement rule #%d: max_size_mb must be greater than min_size_mb", i+1)
+		}
+	}
+	return nil
+}
+
+// validate checks the settings of a single backend
+func (s *StorageBackendConfig) validate() error {
+	if s.IsS3() {
+		if s.S3Bucket == "" {
+			return fmt.Errorf("S3 bucket is required when using S3 storage")
+		}
+		if s.S3Region == "" {
+			return fmt.Errorf("S3 region is required when using S3 storage")
+		}
+		if s.S3MaxRetries < 0 {
+			return fmt.Errorf("S3 max retries cannot be negative: %d", s.S3MaxRetries)
+		}
+		if s.S3RetryMaxBackoff < 0 || s.S3RequestTimeout < 0 {
+			return fmt.Errorf("S3 retry backoff and request timeout cannot be negative")
+		}
+		if s.S3CABundlePath != "" {
+			if _, err := os.Stat(s.S3CABundlePath); err != nil {
+				return fmt.Errorf("S3 CA bundle not readable: %w", err)
+			}
+		}
+	} else if s.IsFilesystem() {
+		if s.BasePath == "" {
+			return fmt.Errorf("storage base path is required for filesystem storage")
+		}
+	} else {
+		return fmt.Errorf("invalid storage type: %s", s.Type)
+	}
+	return nil
+}
+
+// pathsOverlap returns true if two directories are equal or one contains the other
+func pathsOverlap(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	relAB, errAB := filepath.Rel(absA, absB)
+	relBA, errBA := filepath.Rel(absB, absA)
+	return (errAB == nil && !strings.HasPrefix(relAB, "..")) || (errBA == nil && !strings.HasPrefix(relBA, ".."))
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// TokensConfig holds personal access token configuration
+type TokensConfig struct {
+	// AnomalyDetection notifies token owners of uses from new origins
+	AnomalyDetection TokenAnomalyConfig `mapstructure:"anomaly_detection"`
+}
+
+// TokenAnomalyConfig holds configuration for token origin anomaly detection.
+// The origin of a use is the country and autonomous system of the client IP,
+// resolved with a GeoIP database.
+type TokenAnomalyConfig struct {
+	// Enabled determines if token uses are checked against their known origins
+	Enabled bool `mapstructure:"enabled"`
+
+	// GeoIPRangesFile is a CSV file of network,country,asn lines used to
+	// resolve client IPs, e.g. converted from a GeoLite2 or ip2asn export
+	GeoIPRangesFile string `mapstructure:"geoip_ranges_file"`
+}
+
+// Validate checks the anomaly detection configuration
+func (c *TokenAnomalyConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.GeoIPRangesFile == "" {
+		return fmt.Errorf("token anomaly detection requires a GeoIP ranges file")
+	}
+	if _, err := os.Stat(c.GeoIPRangesFile); err != nil {
+		return fmt.Errorf("GeoIP ranges file not readable: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// WebhooksConfig holds configuration for the delivery of repository webhooks
+type WebhooksConfig struct {
+	// TimeoutSeconds is the timeout of a delivery attempt in seconds
+	TimeoutSeconds int `mapstructure:"timeout"`
+
+	// MaxAttempts is how often a delivery is attempted before giving up
+	MaxAttempts int `mapstructure:"max_attempts"`
+
+	// RetentionDays is how long deliveries are kept (0 = forever)
+	RetentionDays int `mapstructure:"retention_days"`
+
+	// AllowPrivateAddresses allows delivering to loopback, private and
+	// link-local addresses, such as services next to the server
+	AllowPrivateAddresses bool `mapstructure:"allow_private_addresses"`
+}
+
+// Validate checks the webhook configuration
+func (c *WebhooksConfig) Validate() error {
+	if c.TimeoutSeconds < 0 {
+		return fmt.Errorf("webhooks.timeout must not be negative")
+	}
+	if c.MaxAttempts < 0 {
+		return fmt.Errorf("webhooks.max_attempts must not be negative")
+	}
+	if c.RetentionDays < 0 {
+		return fmt.Errorf("webhooks.retention_days must not be negative")
+	}
+	return nil
+}
+
+// Timeout returns the timeout of a delivery attempt with default fallback
+func (c *WebhooksConfig) Timeout() time.Duration {
+	if c.TimeoutSeconds <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// GetMaxAttempts returns the attempts per delivery with default fallback
+func (c *WebhooksConfig) GetMaxAttempts() int {
+	if c.MaxAttempts <= 0 {
+		return 5
+	}
+	return c.MaxAttempts
+}
+
+// Retention returns how long deliveries are kept, or 0 to keep them forever
+func (c *WebhooksConfig) Retention() time.Duration {
+	if c.RetentionDays <= 0 {
+		return 0
+	}
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
@@ -0,0 +1,77 @@
+package events
+
+import (
+	"context"
+	"time"
+)
+
+// Publisher publishes domain events
+// Implementations must not block the caller on delivery
+type Publisher interface {
+	Publish(event Event)
+}
+
+// Subscriber registers consumers of domain events
+type Subscriber interface {
+	// Subscribe registers a handler for the events matching opts and returns
+	// a function removing it. name identifies the consumer in logs.
+	Subscribe(name string, handler Handler, opts SubscribeOptions) (unsubscribe func())
+}
+
+// Bus publishes events to their subscribers
+type Bus interface {
+	Publisher
+	Subscriber
+}
+
+// Handler consumes an event. A returned error counts as a failed delivery,
+// which asynchronous subscriptions may retry.
+type Handler func(ctx context.Context, env Envelope) error
+
+// Delivery selects how a subscriber receives events
+type Delivery int
+
+const (
+	// DeliverAsync queues events for a goroutine of the subscriber
+	DeliverAsync Delivery = iota
+
+	// DeliverSync calls the handler from Publish, in publishing order.
+	// Synchronous handlers must be fast and must never block, e.g. forward
+	// to a buffered channel without waiting. They are not retried.
+	DeliverSync
+)
+
+// OverflowPolicy selects which event is dropped when the queue of an
+// asynchronous subscriber is full
+type OverflowPolicy int
+
+const (
+	// DropNewest drops the event being published
+	DropNewest OverflowPolicy = iota
+
+	// DropOldest drops the oldest queued event to make room
+	DropOldest
+)
+
+// SubscribeOptions configures a subscription
+type SubscribeOptions struct {
+	// Types are the event types to receive (empty = all)
+	Types []string
+
+	// Delivery selects synchronous or asynchronous (default) delivery
+	Delivery Delivery
+
+	// QueueSize is the number of events buffered for an asynchronous subscriber
+	QueueSize int
+
+	// Overflow selects what is dropped when the queue is full
+	Overflow OverflowPolicy
+
+	// MaxRetries is how often a failed asynchronous delivery is retried
+	// before the event is dropped
+	MaxRetries int
+
+	// RetryBackoff is the wait before the first retry, doubled for each
+	// further retry
+	RetryBackoff time.Duration
+}
@@ -0,0 +1,301 @@
+// Package events defines the domain events published on the internal event bus.
+// Producers publish what happened; consumers (webhooks, feeds, notifications,
+// cache invalidation) subscribe to the types they care about.
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types
+const (
+	TypeRepositoryCreated     = "repository.created"
+	TypeRepositoryDeleted     = "repository.deleted"
+	TypeRepositoryPushed      = "repository.pushed"
+	TypeRepositoryTransferred = "repository.transferred"
+	TypeCollaboratorAdded     = "repository.collaborator_added"
+	TypeUserCreated           = "user.created"
+	TypeUserDeleted           = "user.deleted"
+	TypeUserSignedIn          = "user.signed_in"
+	TypeUserExportReady       = "user.export_ready"
+	TypeUserOnboarded         = "user.onboarded"
+	TypeCIJobStatus           = "ci.job_status"
+	TypeCIJobLogs             = "ci.job_logs"
+	TypeIssueOpened           = "issue.opened"
+	TypeIssueCommented        = "issue.commented"
+	TypePullRequestOpened     = "pull_request.opened"
+	TypePullRequestClosed     = "pull_request.closed"
+	TypeWebhookDelivery       = "webhook.delivery"
+)
+
+// Event is a domain event
+type Event interface {
+	// EventType returns the type of the event, e.g. repository.created
+	EventType() string
+}
+
+// Envelope is an event as delivered to subscribers
+type Envelope struct {
+	ID         uuid.UUID
+	Type       string
+	OccurredAt time.Time
+	Event      Event
+}
+
+// RepositoryCreated is published when a repository is created, imported,
+// forked or restored from a backup
+type RepositoryCreated struct {
+	RepositoryID uuid.UUID
+	OwnerID      uuid.UUID
+	Owner        string
+	Name         string
+	IsPrivate    bool
+	Source       string // create, push, import, fork, restore
+}
+
+// EventType implements Event
+func (RepositoryCreated) EventType() string { return TypeRepositoryCreated }
+
+// RepositoryDeleted is published when a repository is deleted
+type RepositoryDeleted struct {
+	RepositoryID uuid.UUID
+	OwnerID      uuid.UUID
+	Owner        string
+	Name         string
+}
+
+// EventType implements Event
+func (RepositoryDeleted) EventType() string { return TypeRepositoryDeleted }
+
+// RepositoryTransferred is published when a repository moves to another owner
+type RepositoryTransferred struct {
+	RepositoryID    uuid.UUID
+	Name            string
+	PreviousOwnerID uuid.UUID
+	PreviousOwner   string
+	OwnerID         uuid.UUID
+	Owner           string
+}
+
+// EventType implements Event
+func (RepositoryTransferred) EventType() string { return TypeRepositoryTransferred }
+
+// CollaboratorAdded is published when a user is given access to a repository
+// they did not collaborate on. Permission changes of existing collaborators
+// are not published.
+type CollaboratorAdded struct {
+	RepositoryID uuid.UUID
+	Owner        string
+	Name         string
+	UserID       uuid.UUID
+	Username     string
+	Permission   string // read, write, admin
+}
+
+// EventType implements Event
+func (CollaboratorAdded) EventType() string { return TypeCollaboratorAdded }
+
+// RefChange is a ref updated by a push
+type RefChange struct {
+	RefName string
+	OldHash string // Zero hash for created refs
+	NewHash string // Zero hash for deleted refs
+}
+
+// RefRejected is a ref update of a push that was refused
+type RefRejected struct {
+	RefName string
+	OldHash string
+	NewHash string
+	Reason  string // As reported to the client, e.g. "non-fast-forward"
+}
+
+// LargeFile is a file above the large file warning size that a push added to
+// git instead of storing it with Git LFS
+type LargeFile struct {
+	Path string
+	Hash string
+	Size int64
+}
+
+// RepositoryPushed is published when a push updated refs of a repository
+type RepositoryPushed struct {
+	RepositoryID uuid.UUID
+	Owner        string
+	Name         string
+	PusherID     *uuid.UUID // nil for anonymous pushes
+	Pusher       string
+	Transport    string // http, ssh
+	RemoteIP     string
+	Refs         []RefChange
+	Rejected     []RefRejected // Ref updates of the same push that were refused
+	LargeFiles   []LargeFile   // Large files added without Git LFS
+}
+
+// EventType implements Event
+func (RepositoryPushed) EventType() string { return TypeRepositoryPushed }
+
+// UserCreated is published when a user account is created
+type UserCreated struct {
+	UserID   uuid.UUID
+	Username string
+	Source   string // api, oidc
+}
+
+// EventType implements Event
+func (UserCreated) EventType() string { return TypeUserCreated }
+
+// UserDeleted is published when a user account is deleted
+type UserDeleted struct {
+	UserID   uuid.UUID
+	Username string
+}
+
+// EventType implements Event
+func (UserDeleted) EventType() string { return TypeUserDeleted }
+
+// UserSignedIn is published when a user signs in interactively
+type UserSignedIn struct {
+	UserID   uuid.UUID
+	Username string
+	Method   string // oidc
+}
+
+// EventType implements Event
+func (UserSignedIn) EventType() string { return TypeUserSignedIn }
+
+// UserExportReady is published when a data export of a user can be downloaded.
+// Notification consumers deliver DownloadURL to the user.
+type UserExportReady struct {
+	ExportID    uuid.UUID
+	UserID      uuid.UUID
+	Username    string
+	Email       string
+	DownloadURL string // Signed path, valid until ExpiresAt
+	ExpiresAt   time.Time
+}
+
+// EventType implements Event
+func (UserExportReady) EventType() string { return TypeUserExportReady }
+
+// UserOnboarded is published once per user, when their playground repository
+// is ready. Notification consumers welcome the user and, while SSHKeyCount is
+// zero, remind them to add an SSH key.
+type UserOnboarded struct {
+	UserID       uuid.UUID
+	Username     string
+	Email        string
+	RepositoryID uuid.UUID
+	Repository   string // Name of the playground repository
+	SSHKeyCount  int
+}
+
+// EventType implements Event
+func (UserOnboarded) EventType() string { return TypeUserOnboarded }
+
+// CIJobStatus is published when the status of a CI job changes. What the
+// job runs on is zero for jobs submitted before it was recorded.
+type CIJobStatus struct {
+	JobID        uuid.UUID
+	Status       string
+	Error        string // Reported by the runner with the status, if any
+	StartedAt    *time.Time
+	FinishedAt   *time.Time
+	RunID        uuid.UUID
+	RepositoryID uuid.UUID
+	Stage        string // Empty outside staged runs
+	JobName      string // Empty outside staged runs
+	CommitSHA    string
+	RefName      string
+	RefType      string // branch or tag
+	TriggeredBy  string // Username of the user who triggered the job
+}
+
+// EventType implements Event
+func (CIJobStatus) EventType() string { return TypeCIJobStatus }
+
+// CIJobLogs is published for each batch of log lines a CI job reports
+type CIJobLogs struct {
+	JobID uuid.UUID
+	Lines []CIJobLogLine
+}
+
+// CIJobLogLine is a log line of a CI job
+type CIJobLogLine struct {
+	Timestamp time.Time
+	Level     string
+	StepName  *string
+	Message   string
+	Sequence  uint64
+}
+
+// EventType implements Event
+func (CIJobLogs) EventType() string { return TypeCIJobLogs }
+
+// IssueOpened is published when an issue is opened
+type IssueOpened struct {
+	IssueID      uuid.UUID
+	RepositoryID uuid.UUID
+	Number       int
+	AuthorID     uuid.UUID
+	Author       string
+	Title        string
+	Body         string // Raw Markdown, scanned for @mentions
+}
+
+// EventType implements Event
+func (IssueOpened) EventType() string { return TypeIssueOpened }
+
+// IssueCommented is published when a comment is added to an issue
+type IssueCommented struct {
+	CommentID    uuid.UUID
+	IssueID      uuid.UUID
+	RepositoryID uuid.UUID
+	Number       int // Number of the issue
+	AuthorID     uuid.UUID
+	Author       string
+	Body         string // Raw Markdown, scanned for @mentions
+}
+
+// EventType implements Event
+func (IssueCommented) EventType() string { return TypeIssueCommented }
+
+// PullRequestOpened is published when a pull request is opened
+type PullRequestOpened struct {
+	PullRequestID uuid.UUID
+	RepositoryID  uuid.UUID
+	Number        int
+	AuthorID      uuid.UUID
+	Author        string
+	Title         string
+	Body          string // Raw Markdown, scanned for @mentions
+}
+
+// EventType implements Event
+func (PullRequestOpened) EventType() string { return TypePullRequestOpened }
+
+// PullRequestClosed is published when a pull request is merged or closed
+// without merging
+type PullRequestClosed struct {
+	PullRequestID uuid.UUID
+	RepositoryID  uuid.UUID
+	Number        int
+	AuthorID      *uuid.UUID // nil once the author's account is deleted
+	ActorID       uuid.UUID  // User who merged or closed it
+	Actor         string
+	Merged        bool
+}
+
+// EventType implements Event
+func (PullRequestClosed) EventType() string { return TypePullRequestClosed }
+
+// WebhookDelivery is published when an event is due to be sent to a
+// webhook; the delivery holds the payload
+type WebhookDelivery struct {
+	DeliveryID uuid.UUID
+}
+
+// EventType implements Event
+func (WebhookDelivery) EventType() string { return TypeWebhookDelivery }
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit event actions
+const (
+	AuditActionRepoCreate         = "repo.create"
+	AuditActionRepoImport         = "repo.import"
+	AuditActionRepoDelete         = "repo.delete"
+	AuditActionRepoTransfer       = "repo.transfer"
+	AuditActionRepoRename         = "repo.rename"
+	AuditActionRepoVisibility     = "repo.visibility"
+	AuditActionRepoFork           = "repo.fork"
+	AuditActionBranchCreate       = "branch.create"
+	AuditActionBranchDelete       = "branch.delete"
+	AuditActionTagCreate          = "tag.create"
+	AuditActionTagDelete          = "tag.delete"
+	AuditActionCollaboratorAdd    = "collaborator.add"
+	AuditActionCollaboratorRemove = "collaborator.remove"
+	AuditActionTokenCreate        = "token.create"
+	AuditActionTokenDelete        = "token.delete"
+	AuditActionSSHKeyAdd          = "ssh_key.add"
+	AuditActionSSHKeyDelete       = "ssh_key.delete"
+	AuditActionGPGKeyAdd          = "gpg_key.add"
+	AuditActionGPGKeyDelete       = "gpg_key.delete"
+	AuditActionFeedTokenCreate    = "feed_token.create"
+	AuditActionFeedTokenDelete    = "feed_token.delete"
+	AuditActionGitPush            = "git.push"
+	AuditActionUserCreate         = "user.create"
+	AuditActionUserUpdate         = "user.update"
+	AuditActionUserDelete         = "user.delete"
+)
+
+// AuditEvent records a significant action of a user for the activity feed of
+// a repository and the audit trail of the server. Events are kept when their
+// actor or repository is deleted.
+type AuditEvent struct {
+	ID           uuid.UUID      `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ActorID      *uuid.UUID     `json:"actor_id,omitempty" gorm:"type:uuid;index"` // nil for anonymous actions
+	Actor        string         `json:"actor" gorm:"size:255;not null"`            // Username at the time, or "anonymous"
+	RepositoryID *uuid.UUID     `json:"repository_id,omitempty" gorm:"type:uuid;index:idx_audit_events_repo_time"`
+	Action       string         `json:"action" gorm:"size:64;not null;index"`
+	Metadata     map[string]any `json:"metadata,omitempty" gorm:"type:jsonb;serializer:json"`
+	IP           string         `json:"ip,omitempty" gorm:"size:64"`
+	CreatedAt    time.Time      `json:"created_at" gorm:"index:idx_audit_events_repo_time;index"`
+}
+
+// TableName specifies the table name for AuditEvent
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
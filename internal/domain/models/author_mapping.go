@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthorMapping maps a commit author email to a user for a repository. It
+// supplements the repository's .mailmap where rewriting that file is not possible.
+type AuthorMapping struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	RepositoryID uuid.UUID  `json:"repository_id" gorm:"type:uuid;not null;uniqueIndex:idx_author_mappings_repo_email"`
+	Repository   Repository `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	Email        string     `json:"email" gorm:"not null;size:255;uniqueIndex:idx_author_mappings_repo_email"` // Lowercased commit email
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	User         User       `json:"user,omitzero" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for AuthorMapping
+func (AuthorMapping) TableName() string {
+	return "author_mappings"
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BranchProtection guards the branches of a repository matching a glob
+// pattern (e.g. main, release/*) against force pushes, deletion or pushes by
+// anyone but repository administrators
+type BranchProtection struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	RepositoryID   uuid.UUID  `json:"repository_id" gorm:"type:uuid;not null;uniqueIndex:idx_branch_protections_repo_pattern"`
+	Repository     Repository `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	Pattern        string     `json:"pattern" gorm:"size:255;not null;uniqueIndex:idx_branch_protections_repo_pattern"` // Branch name glob without refs/heads/
+	BlockForcePush bool       `json:"block_force_push" gorm:"not null"`
+	BlockDeletion  bool       `json:"block_deletion" gorm:"not null"`
+	RequireAdmin   bool       `json:"require_admin" gorm:"not null"` // Only repository administrators may push
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for BranchProtection
+func (BranchProtection) TableName() string {
+	return "branch_protections"
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CIArtifact is an artifact of a CI job the runner uploaded to the server,
+// so it outlives the workspace of the runner. The file lives in the default
+// storage backend at StoragePath.
+type CIArtifact struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	JobID        uuid.UUID  `json:"job_id" gorm:"type:uuid;not null;uniqueIndex:idx_ci_artifacts_job_name"`
+	RepositoryID uuid.UUID  `json:"repository_id" gorm:"type:uuid;not null;index"`
+	Repository   Repository `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	Name         string     `json:"name" gorm:"not null;size:255;uniqueIndex:idx_ci_artifacts_job_name"`
+	Size         int64      `json:"size" gorm:"not null"`
+	Checksum     string     `json:"checksum" gorm:"not null;size:64"` // SHA-256, hex
+	ContentType  string     `json:"content_type" gorm:"not null;size:255"`
+	StoragePath  string     `json:"-" gorm:"not null"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+// TableName specifies the table name for CIArtifact
+func (CIArtifact) TableName() string {
+	return "ci_artifacts"
+}
@@ -17,3 +17,40 @@ const (
 	CIRefTypeBranch CIRefType = "branch"
 	CIRefTypeTag    CIRefType = "tag"
 )
+
+// CI job statuses tracked for jobs of staged pipelines. Once a job is submitted
+// its status mirrors the CI runner (queued, running, success, failed, ...).
+const (
+	CIJobStatusBlocked   = "blocked"   // Waiting for the stages it needs to succeed
+	CIJobStatusPending   = "pending"   // Dependencies succeeded, being submitted to the runner
+	CIJobStatusQueued    = "queued"    // Submitted to the runner
+	CIJobStatusSuccess   = "success"   // Finished successfully
+	CIJobStatusFailed    = "failed"    // Finished unsuccessfully
+	CIJobStatusSkipped   = "skipped"   // Not run because a stage it needs did not succeed
+	CIJobStatusCancelled = "cancelled" // Cancelled before or while running
+	CIJobStatusRunning   = "running"   // Running on the runner
+	CIJobStatusTimedOut  = "timed_out" // Stopped by the runner after exceeding its timeout
+	CIJobStatusError     = "error"     // The runner failed to run the job
+)
+
+// CIJobFinishedStatuses are the statuses a job ends with
+var CIJobFinishedStatuses = []string{
+	CIJobStatusSuccess,
+	CIJobStatusFailed,
+	CIJobStatusSkipped,
+	CIJobStatusCancelled,
+	CIJobStatusTimedOut,
+	CIJobStatusError,
+}
+
+// CIRunnerJobStatuses are the statuses a job can have on the CI runner
+var CIRunnerJobStatuses = []string{
+	CIJobStatusPending,
+	CIJobStatusQueued,
+	CIJobStatusRunning,
+	CIJobStatusSuccess,
+	CIJobStatusFailed,
+	CIJobStatusCancelled,
+	CIJobStatusTimedOut,
+	CIJobStatusError,
+}
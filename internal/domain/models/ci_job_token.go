@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CIJobTokenPrefix starts every CI job token, telling them apart from
+// personal access tokens (Sx...)
+const CIJobTokenPrefix = "Sj"
+
+// CICallbackTokenPrefix starts every CI callback token
+const CICallbackTokenPrefix = "Sc"
+
+// CIJobToken is the credential in the clone URL of a CI job. It only allows
+// fetching the repository of the job over HTTP, expires after the job timeout
+// and is revoked once the job finishes. The bytes fetched with it are
+// attributed to the job.
+//
+// The record also holds the callback token of the job, which the runner
+// presents when it reports the logs and status of the job, what the job was
+// triggered for and the last status published for it. It is the only record
+// of jobs outside staged runs.
+type CIJobToken struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	JobID         uuid.UUID  `json:"job_id" gorm:"type:uuid;not null;uniqueIndex"`
+	RunID         uuid.UUID  `json:"run_id" gorm:"type:uuid"`
+	RepositoryID  uuid.UUID  `json:"repository_id" gorm:"type:uuid;not null;index"`
+	Repository    Repository `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	Stage         string     `json:"stage" gorm:"not null;default:'';size:100"`    // Empty outside staged runs
+	JobName       string     `json:"job_name" gorm:"not null;default:'';size:100"` // Empty outside staged runs
+	CommitSHA     string     `json:"commit_sha" gorm:"not null;default:'';size:64"`
+	RefName       string     `json:"ref_name" gorm:"not null;default:'';size:255"`
+	RefType       CIRefType  `json:"ref_type" gorm:"not null;default:'';size:20"`
+	TriggerActor  string     `json:"trigger_actor" gorm:"not null;default:'';size:255"` // Username of the user who triggered the job
+	Status        string     `json:"status" gorm:"not null;default:'';size:20"`         // Last status published, empty before the first
+	Token         string     `json:"-" gorm:"not null;uniqueIndex;size:64"`             // Hashed token
+	CallbackToken string     `json:"-" gorm:"uniqueIndex;size:64"`                      // Hashed callback token
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	TransferBytes int64      `json:"transfer_bytes" gorm:"not null;default:0"` // Bytes sent to fetches with the token
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`                   // When a fetch with the token last completed
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+// TableName specifies the table name for CIJobToken
+func (CIJobToken) TableName() string {
+	return "ci_job_tokens"
+}
+
+// IsValid returns true if the token may still be used at a time
+func (t *CIJobToken) IsValid(at time.Time) bool {
+	return t.RevokedAt == nil && at.Before(t.ExpiresAt)
+}
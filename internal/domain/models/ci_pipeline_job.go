@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CIPipelineJob tracks a job of a staged CI run. All jobs of a run share its
+// RunID; jobs of later stages are held back as blocked until every job of the
+// stages they need has succeeded, and are then submitted to the CI runner.
+type CIPipelineJob struct {
+	ID           uuid.UUID         `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	RunID        uuid.UUID         `json:"run_id" gorm:"type:uuid;not null;index"`
+	RepositoryID uuid.UUID         `json:"repository_id" gorm:"type:uuid;not null;index"`
+	Repository   Repository        `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	Stage        string            `json:"stage" gorm:"not null;size:100"`
+	StageIndex   int               `json:"stage_index" gorm:"not null"`
+	Name         string            `json:"name" gorm:"not null;size:100"`
+	Needs        []string          `json:"needs" gorm:"type:jsonb;serializer:json"` // Stages that must succeed first
+	Status       string            `json:"status" gorm:"not null;size:20;index"`
+	Error        *string           `json:"error,omitempty" gorm:"type:text"`
+	CommitSHA    string            `json:"commit_sha" gorm:"not null;size:64"`
+	RefName      string            `json:"ref_name" gorm:"not null;size:255"`
+	RefType      CIRefType         `json:"ref_type" gorm:"not null;size:20"`
+	TriggerType  CITriggerType     `json:"trigger_type" gorm:"not null;size:20"`
+	TriggerActor string            `json:"trigger_actor" gorm:"not null;size:255"`
+	Metadata     map[string]string `json:"metadata" gorm:"type:jsonb;serializer:json"`
+	CreatedAt    time.Time         `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for CIPipelineJob
+func (CIPipelineJob) TableName() string {
+	return "ci_pipeline_jobs"
+}
+
+// IsFinished returns true if the job reached a terminal status
+func (j *CIPipelineJob) IsFinished() bool {
+	switch j.Status {
+	case CIJobStatusSuccess, CIJobStatusSkipped, CIJobStatusCancelled, CIJobStatusFailed, "timed_out", "error":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Commit status states
+const (
+	// CommitStatePending is a check that has not finished
+	CommitStatePending = "pending"
+
+	// CommitStateSuccess is a check that passed
+	CommitStateSuccess = "success"
+
+	// CommitStateFailure is a check that failed
+	CommitStateFailure = "failure"
+
+	// CommitStateError is a check that could not run to completion
+	CommitStateError = "error"
+)
+
+// IsValidCommitState returns true for the states a commit status can have
+func IsValidCommitState(state string) bool {
+	switch state {
+	case CommitStatePending, CommitStateSuccess, CommitStateFailure, CommitStateError:
+		return true
+	default:
+		return false
+	}
+}
+
+// CommitStatus is the outcome of a check on a commit, reported by CI or an
+// external tool. Statuses are never updated: a new one is added for the same
+// context, and the latest of each context is the current one.
+type CommitStatus struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	RepositoryID uuid.UUID  `json:"repository_id" gorm:"type:uuid;not null;index:idx_commit_statuses_repo_sha,priority:1"`
+	Repository   Repository `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	SHA          string     `json:"sha" gorm:"size:64;not null;index:idx_commit_statuses_repo_sha,priority:2"`
+	State        string     `json:"state" gorm:"size:16;not null"`
+	Context      string     `json:"context" gorm:"size:255;not null;index:idx_commit_statuses_repo_sha,priority:3"` // Check name, e.g. ci/build
+	Description  string     `json:"description,omitempty" gorm:"size:255"`
+	TargetURL    string     `json:"target_url,omitempty" gorm:"size:2048"`
+	CreatorID    *uuid.UUID `json:"creator_id,omitempty" gorm:"type:uuid"`
+	Creator      string     `json:"creator" gorm:"size:255;not null"` // Username, or "ci"
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime;index:idx_commit_statuses_repo_sha,priority:4"`
+}
+
+// TableName specifies the table name for CommitStatus
+func (CommitStatus) TableName() string {
+	return "commit_statuses"
+}
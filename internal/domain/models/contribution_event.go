@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Contribution kinds
+const (
+	// ContributionKindCommit is a commit authored on the default branch of a repository
+	ContributionKindCommit = "commit"
+)
+
+// ContributionEvent records a single contribution credited to a user.
+// Events are deduplicated per repository by kind and ref (e.g., commit hash).
+type ContributionEvent struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index:idx_contribution_events_user_time"`
+	User         User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	RepositoryID uuid.UUID  `json:"repository_id" gorm:"type:uuid;not null;uniqueIndex:idx_contribution_events_repo_kind_ref"`
+	Repository   Repository `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	Kind         string     `json:"kind" gorm:"not null;size:32;uniqueIndex:idx_contribution_events_repo_kind_ref"`
+	Ref          string     `json:"ref" gorm:"not null;size:64;uniqueIndex:idx_contribution_events_repo_kind_ref"`
+	OccurredAt   time.Time  `json:"occurred_at" gorm:"not null;index:idx_contribution_events_user_time"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for ContributionEvent
+func (ContributionEvent) TableName() string {
+	return "contribution_events"
+}
+
+// ContributionDay is the number of contributions of a user on a single day (UTC)
+type ContributionDay struct {
+	Date  string // YYYY-MM-DD
+	Count int64
+}
@@ -0,0 +1,15 @@
+// Package models defines the persisted entities of the server.
+//
+// Deletion policy: every table is hard-deleted. No model embeds gorm.Model
+// or has a DeletedAt column, so GORM never filters rows implicitly and raw
+// queries, counts and quotas see the same rows as the repositories. Rows that
+// only make sense with their parent go with it through ON DELETE CASCADE
+// foreign keys (repository settings, statuses, pull requests, user exports,
+// GPG keys); references that should outlive their target are SET NULL (pull
+// request, issue and annotation authors, forks) or keep a copied name (audit
+// events). The only references to users that restrict their deletion are
+// repository owners and SSH keys, which the user deletion removes in its own
+// transaction. Models added later follow the same rule; keeping deleted rows
+// needs an explicit design rather than a DeletedAt field. The tests of this
+// package and of the database package enforce it.
+package models
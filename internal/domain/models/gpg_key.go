@@ -0,0 +1,36 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GPGKey is an OpenPGP public key of a user, used to verify the signatures of
+// commits authored with one of its emails
+type GPGKey struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	User        User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	KeyID       string     `json:"key_id" gorm:"size:16;not null"`                    // Long key ID of the primary key, upper-case hex
+	Fingerprint string     `json:"fingerprint" gorm:"uniqueIndex;not null;size:64"`   // Fingerprint of the primary key, upper-case hex
+	SubkeyIDs   []string   `json:"subkey_ids" gorm:"type:jsonb;serializer:json"`      // Long key IDs of the subkeys
+	Emails      []string   `json:"emails" gorm:"type:jsonb;serializer:json;not null"` // Emails of the user IDs of the key
+	PublicKey   string     `json:"-" gorm:"not null;type:text"`                       // ASCII-armored public key
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for the GPGKey model
+func (GPGKey) TableName() string {
+	return "gpg_keys"
+}
+
+// HasEmail returns true if one of the user IDs of the key has the email
+func (k *GPGKey) HasEmail(email string) bool {
+	return slices.ContainsFunc(k.Emails, func(e string) bool {
+		return strings.EqualFold(e, email)
+	})
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// No model is soft-deleted: none embeds gorm.Model or has a DeletedAt field
+// or a deleted_at column (see the package documentation)
+func TestModelsAreHardDeleted(t *testing.T) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(info fs.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	structs := 0
+	for _, pkg := range pkgs {
+		ast.Inspect(pkg, func(n ast.Node) bool {
+			spec, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				return true
+			}
+			structs++
+			for _, field := range st.Fields.List {
+				typ := typeName(field.Type)
+				var tag reflect.StructTag
+				if field.Tag != nil {
+					tag = reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+				}
+				switch {
+				case typ == "gorm.Model":
+					t.Errorf("%s: %s embeds gorm.Model", fset.Position(field.Pos()), spec.Name)
+				case typ == "gorm.DeletedAt":
+					t.Errorf("%s: %s has a gorm.DeletedAt field", fset.Position(field.Pos()), spec.Name)
+				case strings.Contains(tag.Get("gorm"), "column:deleted_at"):
+					t.Errorf("%s: %s has a deleted_at column", fset.Position(field.Pos()), spec.Name)
+				}
+				for _, name := range field.Names {
+					if name.Name == "DeletedAt" {
+						t.Errorf("%s: %s has a DeletedAt field", fset.Position(field.Pos()), spec.Name)
+					}
+				}
+			}
+			return true
+		})
+	}
+	if structs == 0 {
+		t.Fatal("no model found")
+	}
+}
+
+// typeName returns the name of a field type as written, without pointers
+func typeName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return typeName(e.X)
+	case *ast.SelectorExpr:
+		if pkg, ok := e.X.(*ast.Ident); ok {
+			return pkg.Name + "." + e.Sel.Name
+		}
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
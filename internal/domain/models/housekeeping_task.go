@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Housekeeping run outcomes
+const (
+	// HousekeepingSucceeded is a run that returned without error
+	HousekeepingSucceeded = "succeeded"
+
+	// HousekeepingFailed is a run that returned an error or panicked
+	HousekeepingFailed = "failed"
+)
+
+// HousekeepingTask is the schedule and last run of a housekeeping task,
+// shared by all replicas so a task runs once per interval across them
+type HousekeepingTask struct {
+	Name           string     `json:"name" gorm:"size:64;primaryKey"`
+	NextRunAt      time.Time  `json:"next_run_at" gorm:"not null"`
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms" gorm:"not null;default:0"`
+	LastOutcome    string     `json:"last_outcome,omitempty" gorm:"size:16"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastRunBy      string     `json:"last_run_by,omitempty" gorm:"size:255"` // Host name of the replica
+	RunCount       int64      `json:"run_count" gorm:"not null;default:0"`
+	FailureCount   int64      `json:"failure_count" gorm:"not null;default:0"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for HousekeepingTask
+func (HousekeepingTask) TableName() string {
+	return "housekeeping_tasks"
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Issue states
+const (
+	// IssueOpen is an issue still to be dealt with
+	IssueOpen = "open"
+
+	// IssueClosed is an issue that was resolved or dismissed
+	IssueClosed = "closed"
+)
+
+// Issue reports a problem or request against a repository. Numbers are
+// sequential per repository. Bodies are stored as the raw Markdown sent by
+// the client, which is left to render them.
+type Issue struct {
+	ID           uuid.UUID      `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	RepositoryID uuid.UUID      `json:"repository_id" gorm:"type:uuid;not null;uniqueIndex:idx_issues_repo_number"`
+	Repository   Repository     `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	Number       int            `json:"number" gorm:"not null;uniqueIndex:idx_issues_repo_number"`
+	Title        string         `json:"title" gorm:"size:255;not null"`
+	Body         string         `json:"body" gorm:"type:text"`
+	AuthorID     *uuid.UUID     `json:"author_id,omitempty" gorm:"type:uuid;index"` // nil once the author's account is deleted
+	Author       *User          `json:"author,omitempty" gorm:"foreignKey:AuthorID;constraint:OnDelete:SET NULL"`
+	State        string         `json:"state" gorm:"size:16;not null;index"`
+	Labels       pq.StringArray `json:"labels" gorm:"type:text[];not null;default:'{}'"`
+	ClosedAt     *time.Time     `json:"closed_at,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// TableName specifies the table name for Issue
+func (Issue) TableName() string {
+	return "issues"
+}
+
+// IssueComment is a comment on an issue, with a raw Markdown body
+type IssueComment struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	IssueID   uuid.UUID  `json:"issue_id" gorm:"type:uuid;not null;index"`
+	Issue     Issue      `json:"-" gorm:"foreignKey:IssueID;constraint:OnDelete:CASCADE"`
+	AuthorID  *uuid.UUID `json:"author_id,omitempty" gorm:"type:uuid;index"` // nil once the author's account is deleted
+	Author    *User      `json:"author,omitempty" gorm:"foreignKey:AuthorID;constraint:OnDelete:SET NULL"`
+	Body      string     `json:"body" gorm:"type:text;not null"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for IssueComment
+func (IssueComment) TableName() string {
+	return "issue_comments"
+}
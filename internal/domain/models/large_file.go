@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LargeFileAddition records a file above the large file warning size that a
+// push added to git instead of storing it with Git LFS, so the repository
+// can report how often it happens
+type LargeFileAddition struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	RepositoryID uuid.UUID  `json:"repository_id" gorm:"type:uuid;not null;index:idx_large_file_additions_repo_time"`
+	Repository   Repository `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	PusherID     *uuid.UUID `json:"pusher_id,omitempty" gorm:"type:uuid"` // nil for anonymous pushes
+	Path         string     `json:"path" gorm:"not null"`
+	BlobHash     string     `json:"blob_hash" gorm:"size:64;not null"`
+	Size         int64      `json:"size" gorm:"not null"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime;index:idx_large_file_additions_repo_time"`
+}
+
+// TableName specifies the table name for LargeFileAddition
+func (LargeFileAddition) TableName() string {
+	return "large_file_additions"
+}
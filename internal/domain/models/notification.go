@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification types
+const (
+	// NotificationMention tells a user they were @mentioned in an issue, a
+	// comment on one, or a pull request
+	NotificationMention = "mention"
+
+	// NotificationCIJobFailed tells the user who triggered a CI job that it failed
+	NotificationCIJobFailed = "ci_job_failed"
+
+	// NotificationPullRequestMerged tells the author of a pull request that someone merged it
+	NotificationPullRequestMerged = "pull_request_merged"
+
+	// NotificationPullRequestClosed tells the author of a pull request that someone closed it
+	NotificationPullRequestClosed = "pull_request_closed"
+)
+
+// Notification tells a user about something that concerns them. The subject
+// is referenced by the repository and, depending on the type, the issue,
+// pull request or CI job it is about.
+type Notification struct {
+	ID            uuid.UUID    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID        uuid.UUID    `json:"user_id" gorm:"type:uuid;not null;index:idx_notifications_user_read"`
+	User          User         `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Type          string       `json:"type" gorm:"size:32;not null"`
+	RepositoryID  *uuid.UUID   `json:"repository_id,omitempty" gorm:"type:uuid;index"`
+	Repository    *Repository  `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	IssueID       *uuid.UUID   `json:"issue_id,omitempty" gorm:"type:uuid"`
+	Issue         *Issue       `json:"-" gorm:"foreignKey:IssueID;constraint:OnDelete:CASCADE"`
+	PullRequestID *uuid.UUID   `json:"pull_request_id,omitempty" gorm:"type:uuid"`
+	PullRequest   *PullRequest `json:"-" gorm:"foreignKey:PullRequestID;constraint:OnDelete:CASCADE"`
+	CIJobID       *uuid.UUID   `json:"ci_job_id,omitempty" gorm:"type:uuid"` // Runner job ID, not a foreign key
+	Number        int          `json:"number,omitempty"`                     // Number of the issue or pull request
+	ActorID       *uuid.UUID   `json:"actor_id,omitempty" gorm:"type:uuid"`  // User who caused it, if any
+	Actor         *User        `json:"actor,omitempty" gorm:"foreignKey:ActorID;constraint:OnDelete:SET NULL"`
+	Title         string       `json:"title" gorm:"size:255;not null"` // One-line summary
+	Read          bool         `json:"read" gorm:"not null;default:false;index:idx_notifications_user_read"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// TableName specifies the table name for Notification
+func (Notification) TableName() string {
+	return "notifications"
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Email notification categories users can opt out of
+const (
+	// EmailCategoryCIFailures is CI jobs failing on the default branch of a repository
+	EmailCategoryCIFailures = "ci_failures"
+
+	// EmailCategoryCollaborators is being added as a collaborator of a repository
+	EmailCategoryCollaborators = "collaborators"
+
+	// EmailCategoryTransfers is repositories transferred to the user
+	EmailCategoryTransfers = "transfers"
+)
+
+// NotificationPreference records which email notifications a user receives.
+// Users without a record receive all of them.
+type NotificationPreference struct {
+	UserID             uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey"`
+	User               User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	EmailCIFailures    bool      `json:"email_ci_failures" gorm:"not null"`
+	EmailCollaborators bool      `json:"email_collaborators" gorm:"not null"`
+	EmailTransfers     bool      `json:"email_transfers" gorm:"not null"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for NotificationPreference
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+// DefaultNotificationPreference returns the preferences of a user without a
+// record: every email enabled
+func DefaultNotificationPreference(userID uuid.UUID) *NotificationPreference {
+	return &NotificationPreference{
+		UserID:             userID,
+		EmailCIFailures:    true,
+		EmailCollaborators: true,
+		EmailTransfers:     true,
+	}
+}
+
+// Allows returns true if the user receives emails of a category
+func (p *NotificationPreference) Allows(category string) bool {
+	switch category {
+	case EmailCategoryCIFailures:
+		return p.EmailCIFailures
+	case EmailCategoryCollaborators:
+		return p.EmailCollaborators
+	case EmailCategoryTransfers:
+		return p.EmailTransfers
+	default:
+		return true
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+// PinnedLink is a quick link on the repository page, to a file or directory
+// of the default branch or to an external URL
+type PinnedLink struct {
+	Title  string `json:"title"`
+	Path   string `json:"path,omitempty"`   // Path within the repository, for in-repository links
+	URL    string `json:"url,omitempty"`    // Absolute http(s) URL, for external links
+	Broken bool   `json:"broken,omitempty"` // Path no longer exists at the tip of the default branch
+}
+
+// IsInRepository returns true if the link points into the repository
+func (l PinnedLink) IsInRepository() bool {
+	return l.Path != ""
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Pull request states
+const (
+	// PullRequestOpen is a pull request waiting to be merged or closed
+	PullRequestOpen = "open"
+
+	// PullRequestClosed is a pull request closed without merging
+	PullRequestClosed = "closed"
+
+	// PullRequestMerged is a pull request whose source branch was merged into its target
+	PullRequestMerged = "merged"
+)
+
+// PullRequest proposes merging a branch of a repository into another.
+// Numbers are sequential per repository, so they identify a pull request in
+// URLs the way issue numbers do.
+type PullRequest struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	RepositoryID    uuid.UUID  `json:"repository_id" gorm:"type:uuid;not null;uniqueIndex:idx_pull_requests_repo_number"`
+	Repository      Repository `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	Number          int        `json:"number" gorm:"not null;uniqueIndex:idx_pull_requests_repo_number"`
+	Title           string     `json:"title" gorm:"size:255;not null"`
+	Body            string     `json:"body" gorm:"type:text"`
+	SourceBranch    string     `json:"source_branch" gorm:"size:255;not null"`
+	TargetBranch    string     `json:"target_branch" gorm:"size:255;not null"`
+	AuthorID        *uuid.UUID `json:"author_id,omitempty" gorm:"type:uuid;index"` // nil once the author's account is deleted
+	Author          *User      `json:"author,omitempty" gorm:"foreignKey:AuthorID;constraint:OnDelete:SET NULL"`
+	State           string     `json:"state" gorm:"size:16;not null;index"`
+	MergedCommitSHA string     `json:"merged_commit_sha,omitempty" gorm:"size:64"`
+	MergedByID      *uuid.UUID `json:"merged_by_id,omitempty" gorm:"type:uuid"`
+	MergedBy        *User      `json:"merged_by,omitempty" gorm:"foreignKey:MergedByID;constraint:OnDelete:SET NULL"`
+	MergedAt        *time.Time `json:"merged_at,omitempty"`
+	ClosedAt        *time.Time `json:"closed_at,omitempty"` // Set when closed or merged
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for PullRequest
+func (PullRequest) TableName() string {
+	return "pull_requests"
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Push attempt outcomes
+const (
+	// PushOutcomeAccepted is a push whose ref updates were all applied
+	PushOutcomeAccepted = "accepted"
+
+	// PushOutcomeRejected is a push with at least one refused ref update
+	PushOutcomeRejected = "rejected"
+)
+
+// PushAttempt records a push to a repository with what its user saw: the
+// refs attempted, their status and the output of server-side checks, so
+// rejected pushes can be investigated after the terminal output is gone
+type PushAttempt struct {
+	ID              uuid.UUID   `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	RepositoryID    uuid.UUID   `json:"repository_id" gorm:"type:uuid;not null;index:idx_push_attempts_repo_time"`
+	Repository      Repository  `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	PusherID        *uuid.UUID  `json:"pusher_id,omitempty" gorm:"type:uuid"`
+	Pusher          string      `json:"pusher" gorm:"size:255;not null"` // Username, or "anonymous"
+	Transport       string      `json:"transport" gorm:"size:16;not null"`
+	Outcome         string      `json:"outcome" gorm:"size:16;not null;index"`
+	Refs            []PushedRef `json:"refs" gorm:"type:jsonb;serializer:json"`
+	Error           string      `json:"error,omitempty"`                       // Server-side failure, if the push did not complete
+	Output          string      `json:"output"`                                // Redacted messages sent to the client
+	OutputTruncated bool        `json:"output_truncated" gorm:"default:false"` // Output was cut at the configured limit
+	StartedAt       time.Time   `json:"started_at" gorm:"not null;index:idx_push_attempts_repo_time"`
+	FinishedAt      time.Time   `json:"finished_at" gorm:"not null"`
+	CreatedAt       time.Time   `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+// TableName specifies the table name for PushAttempt
+func (PushAttempt) TableName() string {
+	return "push_attempts"
+}
+
+// PushedRef is a ref update requested by a push and its status
+type PushedRef struct {
+	RefName string `json:"ref_name"`
+	OldHash string `json:"old_hash"`
+	NewHash string `json:"new_hash"`
+	Status  string `json:"status"` // ok, rejected
+	Reason  string `json:"reason,omitempty"`
+}
@@ -0,0 +1,21 @@
+package models
+
+// PushPolicy is the set of rules every push to a repository must follow, on
+// top of the instance-wide push limits. A zero rule is not enforced.
+type PushPolicy struct {
+	MaxFileSize          int64    `json:"max_file_size,omitempty"`          // Largest file a pushed commit may add or change, in bytes
+	BlockedPaths         []string `json:"blocked_paths,omitempty"`          // Globs of paths pushed commits may not add or change
+	CommitMessagePattern string   `json:"commit_message_pattern,omitempty"` // Regular expression the message of every pushed commit must match
+	DenyNonFastForward   bool     `json:"deny_non_fast_forward,omitempty"`  // Branches may not be rewritten by force pushes
+}
+
+// IsZero returns true if the policy has no rule
+func (p *PushPolicy) IsZero() bool {
+	return p == nil || (p.MaxFileSize == 0 && len(p.BlockedPaths) == 0 && p.CommitMessagePattern == "" && !p.DenyNonFastForward)
+}
+
+// InspectsCommits returns true if the policy has rules on the content of the
+// pushed commits, which can only be checked once the pushed objects are received
+func (p *PushPolicy) InspectsCommits() bool {
+	return p != nil && (p.MaxFileSize > 0 || len(p.BlockedPaths) > 0 || p.CommitMessagePattern != "")
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RepoAnnotation represents a freeform operational key/value note attached to a repository
+type RepoAnnotation struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	RepositoryID uuid.UUID  `json:"repository_id" gorm:"type:uuid;not null;uniqueIndex:idx_repo_annotations_repo_key"`
+	Repository   Repository `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	Key          string     `json:"key" gorm:"not null;size:64;uniqueIndex:idx_repo_annotations_repo_key;index:idx_repo_annotations_key_value"`
+	Value        string     `json:"value" gorm:"not null;type:text;index:idx_repo_annotations_key_value"`
+	AuthorID     *uuid.UUID `json:"author_id,omitempty" gorm:"type:uuid;index"` // nil once the author's account is deleted
+	Author       *User      `json:"author,omitempty" gorm:"foreignKey:AuthorID;constraint:OnDelete:SET NULL"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for RepoAnnotation
+func (RepoAnnotation) TableName() string {
+	return "repo_annotations"
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Repository bulk operations
+const (
+	// RepoBulkSetVisibility makes the repositories public or private
+	RepoBulkSetVisibility = "set_visibility"
+
+	// RepoBulkTransfer moves the repositories to another owner
+	RepoBulkTransfer = "transfer"
+
+	// RepoBulkDelete deletes the repositories
+	RepoBulkDelete = "delete"
+)
+
+// Repository bulk task statuses
+const (
+	// RepoBulkTaskPending is a task waiting for the bulk worker
+	RepoBulkTaskPending = "pending"
+
+	// RepoBulkTaskRunning is a task whose repositories are being processed
+	RepoBulkTaskRunning = "running"
+
+	// RepoBulkTaskCompleted is a task every repository of which was processed,
+	// successfully or not
+	RepoBulkTaskCompleted = "completed"
+)
+
+// Outcomes of a repository in a bulk task
+const (
+	RepoBulkResultSucceeded = "succeeded"
+	RepoBulkResultFailed    = "failed"
+)
+
+// RepoBulkTarget is a repository a bulk task applies to
+type RepoBulkTarget struct {
+	ID       uuid.UUID `json:"id"`
+	FullName string    `json:"full_name"` // owner/name when the task was requested
+}
+
+// RepoBulkResult is the outcome of a bulk task for one repository
+type RepoBulkResult struct {
+	RepositoryID *uuid.UUID `json:"repository_id,omitempty"` // nil if the repository was not found
+	FullName     string     `json:"full_name"`
+	Outcome      string     `json:"outcome"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// RepoBulkTask is an administrative operation applied to many repositories
+// in the background, one at a time, recording the outcome for each. A failure
+// on one repository does not stop the others. Finished tasks are kept until
+// they expire.
+type RepoBulkTask struct {
+	ID            uuid.UUID        `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Operation     string           `json:"operation" gorm:"size:32;not null"`
+	IsPrivate     *bool            `json:"is_private,omitempty"`                    // Visibility set by set_visibility
+	NewOwnerID    *uuid.UUID       `json:"new_owner_id,omitempty" gorm:"type:uuid"` // Owner the repositories are transferred to
+	Targets       []RepoBulkTarget `json:"targets" gorm:"type:jsonb;serializer:json;not null"`
+	Results       []RepoBulkResult `json:"results" gorm:"type:jsonb;serializer:json;not null"`
+	Status        string           `json:"status" gorm:"size:16;not null;index"`
+	RequestedByID *uuid.UUID       `json:"requested_by_id,omitempty" gorm:"type:uuid"`
+	RequestedBy   string           `json:"requested_by" gorm:"size:255;not null"` // Username at the time
+	IP            string           `json:"ip,omitempty" gorm:"size:64"`
+	CreatedAt     time.Time        `json:"created_at"`
+	CompletedAt   *time.Time       `json:"completed_at,omitempty"`
+	ExpiresAt     *time.Time       `json:"expires_at,omitempty" gorm:"index"`
+}
+
+// TableName specifies the table name for RepoBulkTask
+func (RepoBulkTask) TableName() string {
+	return "repo_bulk_tasks"
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Repository import sources
+const (
+	// RepoImportSourceURL clones all refs of a remote repository
+	RepoImportSourceURL = "url"
+
+	// RepoImportSourceArchive commits the files of an uploaded archive
+	RepoImportSourceArchive = "archive"
+)
+
+// Repository import statuses
+const (
+	// RepoImportPending is an import waiting for a free import slot
+	RepoImportPending = "pending"
+
+	// RepoImportRunning is an import being cloned or unpacked
+	RepoImportRunning = "running"
+
+	// RepoImportCompleted is an import whose repository was created
+	RepoImportCompleted = "completed"
+
+	// RepoImportFailed is an import that left no repository behind
+	RepoImportFailed = "failed"
+)
+
+// RepoImport is the creation of a repository from a remote URL or an uploaded
+// archive, run in the background. Credentials for the remote are never
+// stored here.
+type RepoImport struct {
+	ID             uuid.UUID   `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OwnerID        uuid.UUID   `json:"owner_id" gorm:"type:uuid;not null;index:idx_repo_imports_owner_time"`
+	Owner          User        `json:"-" gorm:"foreignKey:OwnerID;constraint:OnDelete:CASCADE"`
+	RepositoryID   *uuid.UUID  `json:"repository_id,omitempty" gorm:"type:uuid;index"` // Set once the import completed
+	Repository     *Repository `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:SET NULL"`
+	Name           string      `json:"name" gorm:"size:100;not null"`
+	Description    string      `json:"description"`
+	IsPrivate      bool        `json:"is_private" gorm:"not null"`
+	Source         string      `json:"source" gorm:"size:16;not null"`
+	CloneURL       string      `json:"clone_url,omitempty"`                    // Remote of url imports
+	Mirror         bool        `json:"mirror" gorm:"not null"`                 // The repository keeps syncing from the remote
+	ArchiveName    string      `json:"archive_name,omitempty" gorm:"size:255"` // File name of the uploaded archive
+	ArchivePath    string      `json:"-" gorm:"size:512"`                      // Uploaded archive waiting to be unpacked
+	GitPath        string      `json:"-" gorm:"size:512"`                      // Where the repository is being created
+	StorageBackend string      `json:"-" gorm:"size:64"`                       // Backend GitPath is on
+	Status         string      `json:"status" gorm:"size:16;not null;index"`
+	Error          string      `json:"error,omitempty"`             // Why the import failed
+	IP             string      `json:"ip,omitempty" gorm:"size:64"` // Address the import was requested from
+	CreatedAt      time.Time   `json:"created_at" gorm:"index:idx_repo_imports_owner_time"`
+	StartedAt      *time.Time  `json:"started_at,omitempty"`
+	CompletedAt    *time.Time  `json:"completed_at,omitempty"`
+}
+
+// TableName specifies the table name for RepoImport
+func (RepoImport) TableName() string {
+	return "repo_imports"
+}
+
+// Finished returns true once the import completed or failed
+func (i *RepoImport) Finished() bool {
+	return i.Status == RepoImportCompleted || i.Status == RepoImportFailed
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RepoLanguage is the bytes of source code of a language in the default
+// branch of a repository, as of CommitHash
+type RepoLanguage struct {
+	RepositoryID uuid.UUID  `json:"repository_id" gorm:"type:uuid;primaryKey"`
+	Repository   Repository `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	Language     string     `json:"language" gorm:"size:64;primaryKey"`
+	Bytes        int64      `json:"bytes" gorm:"not null"`
+	CommitHash   string     `json:"commit_hash" gorm:"size:40;not null"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for RepoLanguage
+func (RepoLanguage) TableName() string {
+	return "repo_languages"
+}
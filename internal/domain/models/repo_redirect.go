@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RepoRedirect keeps a former name of a renamed repository working: requests
+// for OldOwner/OldName are served by, or redirected to, the repository until
+// the grace period of repos.rename_redirect_days ends or the name is taken
+type RepoRedirect struct {
+	OldOwner     string     `json:"old_owner" gorm:"size:255;primaryKey"`
+	OldName      string     `json:"old_name" gorm:"size:100;primaryKey"`
+	RepositoryID uuid.UUID  `json:"repository_id" gorm:"type:uuid;not null;index"`
+	Repository   Repository `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"not null;index"`
+}
+
+// TableName specifies the table name for RepoRedirect
+func (RepoRedirect) TableName() string {
+	return "repo_redirects"
+}
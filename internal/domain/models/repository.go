@@ -4,13 +4,14 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/robfig/cron/v3"
 )
 
 // Repository represents a Git repository in the system
 type Repository struct {
 	ID            uuid.UUID `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	Name          string    `json:"name" gorm:"not null" `
+	Name          string    `json:"name" gorm:"not null;index:idx_repositories_lower_name,expression:lower(name)" `
 	OwnerID       uuid.UUID `json:"owner_id" gorm:"not null" `
 	Owner         User      `json:"owner,omitzero" gorm:"foreignKey:OwnerID" `
 	IsPrivate     bool      `json:"is_private" gorm:"default:false" `
@@ -18,6 +19,12 @@ type Repository struct {
 	DefaultBranch string    `json:"default_branch" gorm:"default:'main'" `
 	GitPath       string    `json:"git_path" gorm:"uniqueIndex;not null" ` // Storage path
 
+	StorageBackend string `json:"storage_backend" gorm:"size:64;not null;default:'default';index"` // Name of the storage backend the repository lives on
+
+	// Fork network
+	ForkedFromID *uuid.UUID  `json:"forked_from_id,omitempty" gorm:"type:uuid;index"`               // Repository this one was forked from
+	ForkedFrom   *Repository `json:"-" gorm:"foreignKey:ForkedFromID;constraint:OnDelete:SET NULL"` // Parent repository, if loaded
+
 	// Mirror configuration
 	MirrorEnabled      bool       `json:"mirror_enabled" gorm:"default:false"`         // Enable/disable mirror sync
 	MirrorDirection    string     `json:"mirror_direction,omitempty"`                  // "upstream", "downstream", "both"
@@ -33,6 +40,42 @@ type Repository struct {
 	SyncStatus         string     `json:"sync_status,omitempty" gorm:"default:'idle'"` // "idle", "syncing", "success", "failed"
 	SyncError          string     `json:"sync_error,omitempty"`                        // Last sync error message
 
+	// Tag protection
+	ProtectedTagPatterns  []string `json:"protected_tag_patterns,omitempty" gorm:"type:jsonb;serializer:json"`  // Glob patterns of tags that cannot be moved or deleted
+	ProtectedTagOverrides []string `json:"protected_tag_overrides,omitempty" gorm:"type:jsonb;serializer:json"` // Usernames or roles ("role:admin", "role:owner") allowed to bypass tag protection
+
+	// Push policy
+	PushPolicy *PushPolicy `json:"push_policy,omitempty" gorm:"type:jsonb;serializer:json"` // Rules pushes must follow, nil without any
+
+	// Upload-pack settings
+	UploadPack UploadPackSettings `json:"upload_pack" gorm:"embedded;embeddedPrefix:upload_pack_"` // Fetches clients may make, see UploadPackSettings
+
+	// Pinned links
+	PinnedLinks []PinnedLink `json:"pinned_links,omitempty" gorm:"type:jsonb;serializer:json"` // Quick links shown on the repository page, in display order
+
+	// Topics
+	Topics pq.StringArray `json:"topics" gorm:"type:text[];not null;default:'{}';index:idx_repositories_topics,type:gin"` // Normalized topics, see service.TopicService
+
+	// License
+	License           string     `json:"license,omitempty" gorm:"size:64;index"`    // Effective SPDX identifier: the override if set, otherwise the detected license
+	DetectedLicense   string     `json:"detected_license,omitempty" gorm:"size:64"` // SPDX identifier detected from the license file of the default branch
+	LicenseOverride   string     `json:"license_override,omitempty" gorm:"size:64"` // SPDX identifier set by an owner, or LicenseOverrideNone
+	LicenseDetectedAt *time.Time `json:"-"`                                         // Last time the license file was inspected
+
+	ContributionsIndexedAt *time.Time `json:"-"` // Last time default branch commits were recorded as contributions
+
+	AuthorMappingsVersion int64 `json:"-" gorm:"not null;default:0"` // Incremented whenever the author mappings change
+
+	// Size quota
+	SizeBytes  int64  `json:"size_bytes" gorm:"not null;default:0"` // Disk usage measured after the last push
+	QuotaBytes *int64 `json:"quota_bytes,omitempty"`                // Size limit set by an administrator, overriding the configured one (0 = unlimited)
+
+	LargeFileHintsDisabled bool `json:"large_file_hints_disabled" gorm:"not null;default:false"` // Pushes get no warnings about large files not stored with Git LFS
+
+	// Garbage collection
+	LastGCAt         *time.Time `json:"last_gc_at,omitempty"`                          // End of the last garbage collection
+	LastGCDurationMS int64      `json:"last_gc_duration_ms" gorm:"not null;default:0"` // Duration of the last garbage collection
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -126,3 +169,37 @@ func (r *Repository) GetNextSyncTime() *time.Time {
 
 	return nil
 }
+
+// LicenseOverrideNone is the license override stating that a repository is not
+// licensed, e.g. when a detected license file belongs to vendored code
+const LicenseOverrideNone = "none"
+
+// EffectiveLicense returns the license override if set, otherwise the detected license
+func (r *Repository) EffectiveLicense() string {
+	switch r.LicenseOverride {
+	case "":
+		return r.DetectedLicense
+	case LicenseOverrideNone:
+		return ""
+	default:
+		return r.LicenseOverride
+	}
+}
+
+// LicenseCount is the number of repositories with an effective license ("" for none)
+type LicenseCount struct {
+	License string
+	Count   int64
+}
+
+// TopicCount is the number of public repositories tagged with a topic
+type TopicCount struct {
+	Topic string
+	Count int64
+}
+
+// StorageBackendCount is the number of repositories living on a storage backend
+type StorageBackendCount struct {
+	StorageBackend string
+	Count          int64
+}
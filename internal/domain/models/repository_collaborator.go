@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RepoPermission is a level of access to a repository. Each level includes the
+// ones below it: admin > write > read.
+type RepoPermission string
+
+const (
+	RepoPermissionNone  RepoPermission = ""      // No access
+	RepoPermissionRead  RepoPermission = "read"  // Clone, fetch and browse
+	RepoPermissionWrite RepoPermission = "write" // Push, manage branches and tags, run CI
+	RepoPermissionAdmin RepoPermission = "admin" // Manage settings and collaborators
+)
+
+// IsValid returns true for the permissions that can be granted to a collaborator
+func (p RepoPermission) IsValid() bool {
+	return p == RepoPermissionRead || p == RepoPermissionWrite || p == RepoPermissionAdmin
+}
+
+// Allows returns true if p includes the required permission
+func (p RepoPermission) Allows(required RepoPermission) bool {
+	return p.level() >= required.level()
+}
+
+// level orders permissions from none (0) to admin (3)
+func (p RepoPermission) level() int {
+	switch p {
+	case RepoPermissionRead:
+		return 1
+	case RepoPermissionWrite:
+		return 2
+	case RepoPermissionAdmin:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// RepositoryCollaborator grants a user other than the owner access to a repository
+type RepositoryCollaborator struct {
+	ID           uuid.UUID      `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	RepositoryID uuid.UUID      `json:"repository_id" gorm:"type:uuid;not null;uniqueIndex:idx_repository_collaborators_repo_user"`
+	Repository   Repository     `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	UserID       uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_repository_collaborators_repo_user;index"`
+	User         User           `json:"user,omitzero" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Permission   RepoPermission `json:"permission" gorm:"not null;size:16"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// TableName specifies the table name for RepositoryCollaborator
+func (RepositoryCollaborator) TableName() string {
+	return "repository_collaborators"
+}
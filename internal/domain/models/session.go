@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is the server-side state of an OIDC login. Session tokens carry
+// its ID, so they stop working once it is revoked, expires or is left idle,
+// and can be refreshed until then.
+type Session struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	User         User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	RefreshToken string     `json:"-" gorm:"type:text"` // Encrypted refresh token of the identity provider, if it issued one
+	IDToken      string     `json:"-" gorm:"type:text"` // Encrypted ID token, the id_token_hint of the logout URL
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null;index"`
+	LastUsedAt   time.Time  `json:"last_used_at" gorm:"not null;index"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for Session
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// IsActive returns true if the session may still be used at a time
+func (s *Session) IsActive(at time.Time, idleTimeout time.Duration) bool {
+	return s.RevokedAt == nil && at.Before(s.ExpiresAt) && at.Before(s.LastUsedAt.Add(idleTimeout))
+}
@@ -1,26 +1,112 @@
 package models
 
 import (
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
 
-// User represents a user in the git server system
+// Token scopes. A token may only do what its scopes allow. A token without
+// scopes, as created before scopes were enforced, may read and write
+// repositories but never administer them or the site.
+const (
+	TokenScopeRepoRead  = "repo:read"  // Clone, fetch and browse repositories
+	TokenScopeRepoWrite = "repo:write" // Also push, manage branches and tags, create repositories
+	TokenScopeRepoAdmin = "repo:admin" // Also manage repository settings and collaborators
+	TokenScopeAdmin     = "admin"      // Everything, including account settings, keys, tokens and site administration
+)
+
+// IsValidTokenScope returns true for the scopes a token can be created with
+func IsValidTokenScope(scope string) bool {
+	switch scope {
+	case TokenScopeRepoRead, TokenScopeRepoWrite, TokenScopeRepoAdmin, TokenScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// Token is a personal access token of a user
 type Token struct {
 	ID        uuid.UUID      `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
 	Name      string         `json:"name" gorm:"not null;size:255"`
 	UserID    uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;index"`
 	Token     string         `json:"-" gorm:"not null;type:text"` // Hashed token
-	Scope     pq.StringArray `json:"scope" gorm:"type:text[]"`    // e.g., "owner/repo", "owner2/repo2"
+	Scope     pq.StringArray `json:"scope" gorm:"type:text[]"`    // TokenScope* values, empty for repo:read and repo:write
 	ExpiresAt *time.Time     `json:"expires_at,omitempty"`
 	LastUsed  *time.Time     `json:"last_used,omitempty"`
-	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Usage tracking, written at most once per minute per token
+	LastUsedIP    string `json:"last_used_ip,omitempty" gorm:"size:64"`
+	LastUserAgent string `json:"last_user_agent,omitempty" gorm:"size:512"`
+	UsageCount    int64  `json:"usage_count" gorm:"not null;default:0"` // Coarse count of authenticated requests
+
+	// KnownOrigins are the "country/ASN" origins the token was used from,
+	// recorded while token anomaly detection is enabled
+	KnownOrigins []string `json:"-" gorm:"type:jsonb;serializer:json"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
 // TableName returns the table name for the PAT model
 func (Token) TableName() string {
 	return "tokens"
 }
+
+// legacyTokenScopes are the scopes of a token without scopes
+var legacyTokenScopes = []string{TokenScopeRepoRead, TokenScopeRepoWrite}
+
+// scopes returns the scopes the token was granted
+func (t *Token) scopes() []string {
+	if len(t.Scope) == 0 {
+		return legacyTokenScopes
+	}
+	return t.Scope
+}
+
+// AllowsAdmin returns true if the token may be used for site administration,
+// which needs the admin scope
+func (t *Token) AllowsAdmin() bool {
+	return slices.Contains(t.Scope, TokenScopeAdmin)
+}
+
+// HasScope returns true if the token was granted scope. Tokens with the admin
+// scope have every scope.
+func (t *Token) HasScope(scope string) bool {
+	return t.AllowsAdmin() || slices.Contains(t.scopes(), scope)
+}
+
+// RepoPermission returns the highest repository permission the token may use
+func (t *Token) RepoPermission() RepoPermission {
+	if t.AllowsAdmin() {
+		return RepoPermissionAdmin
+	}
+
+	permission := RepoPermissionNone
+	for _, scope := range t.scopes() {
+		var granted RepoPermission
+		switch scope {
+		case TokenScopeRepoRead:
+			granted = RepoPermissionRead
+		case TokenScopeRepoWrite:
+			granted = RepoPermissionWrite
+		case TokenScopeRepoAdmin:
+			granted = RepoPermissionAdmin
+		}
+		if granted.Allows(permission) {
+			permission = granted
+		}
+	}
+	return permission
+}
+
+// Includes returns true if the token allows everything other allows, so a
+// request authenticated with it may create other without gaining access
+func (t *Token) Includes(other *Token) bool {
+	if t.AllowsAdmin() {
+		return true
+	}
+	return !other.AllowsAdmin() && t.RepoPermission().Allows(other.RepoPermission())
+}
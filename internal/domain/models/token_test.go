@@ -0,0 +1,69 @@
+package models
+
+import "testing"
+
+func TestTokenScopes(t *testing.T) {
+	tests := []struct {
+		name       string
+		scope      []string
+		admin      bool
+		permission RepoPermission
+		hasScope   map[string]bool
+	}{
+		{
+			// Tokens from before scopes were enforced read and write
+			// repositories, and never administer the site
+			name:       "no scopes",
+			scope:      nil,
+			permission: RepoPermissionWrite,
+			hasScope:   map[string]bool{TokenScopeRepoRead: true, TokenScopeRepoWrite: true, TokenScopeRepoAdmin: false, TokenScopeAdmin: false},
+		},
+		{
+			name:       "empty scopes",
+			scope:      []string{},
+			permission: RepoPermissionWrite,
+			hasScope:   map[string]bool{TokenScopeRepoWrite: true, TokenScopeAdmin: false},
+		},
+		{
+			name:       "repo:read",
+			scope:      []string{TokenScopeRepoRead},
+			permission: RepoPermissionRead,
+			hasScope:   map[string]bool{TokenScopeRepoRead: true, TokenScopeRepoWrite: false, TokenScopeAdmin: false},
+		},
+		{
+			name:       "admin",
+			scope:      []string{TokenScopeAdmin},
+			admin:      true,
+			permission: RepoPermissionAdmin,
+			hasScope:   map[string]bool{TokenScopeRepoRead: true, TokenScopeRepoAdmin: true, TokenScopeAdmin: true},
+		},
+		{
+			// Scopes that once named repositories grant nothing
+			name:       "legacy repository scopes",
+			scope:      []string{"alice/app"},
+			permission: RepoPermissionNone,
+			hasScope:   map[string]bool{TokenScopeRepoRead: false, TokenScopeAdmin: false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &Token{Scope: tt.scope}
+			if got := token.AllowsAdmin(); got != tt.admin {
+				t.Errorf("AllowsAdmin() = %v, want %v", got, tt.admin)
+			}
+			if got := token.RepoPermission(); got != tt.permission {
+				t.Errorf("RepoPermission() = %q, want %q", got, tt.permission)
+			}
+			for scope, want := range tt.hasScope {
+				if got := token.HasScope(scope); got != want {
+					t.Errorf("HasScope(%q) = %v, want %v", scope, got, want)
+				}
+			}
+		})
+	}
+
+	// A token without scopes cannot mint an admin token
+	if (&Token{}).Includes(&Token{Scope: []string{TokenScopeAdmin}}) {
+		t.Error("token without scopes includes an admin token")
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+// UploadPackSettings are the git upload-pack options of a repository, deciding
+// which fetches its clients may make. They are written to the git config of
+// the repository and passed to every upload-pack the transports run.
+type UploadPackSettings struct {
+	AllowFilter              bool `json:"allow_filter" gorm:"not null;default:true"`                 // Partial clones and fetches (--filter=blob:none, ...)
+	AllowReachableSHA1InWant bool `json:"allow_reachable_sha1_in_want" gorm:"not null;default:true"` // Fetches of objects by ID if a ref reaches them, e.g. the blobs a partial clone left out
+	AllowAnySHA1InWant       bool `json:"allow_any_sha1_in_want" gorm:"not null;default:false"`      // Fetches of any object by ID, even one no ref reaches; implies AllowReachableSHA1InWant
+}
+
+// DefaultUploadPackSettings returns the settings of new repositories: partial
+// clones are allowed and objects no ref reaches stay out of reach
+func DefaultUploadPackSettings() UploadPackSettings {
+	return UploadPackSettings{
+		AllowFilter:              true,
+		AllowReachableSHA1InWant: true,
+	}
+}
+
+// AllowsReachableSHA1InWant returns true if clients may fetch objects a ref
+// reaches by ID, which AllowAnySHA1InWant implies
+func (s UploadPackSettings) AllowsReachableSHA1InWant() bool {
+	return s.AllowReachableSHA1InWant || s.AllowAnySHA1InWant
+}
@@ -12,10 +12,43 @@ type User struct {
 	Username    string    `json:"username" gorm:"uniqueIndex;not null;size:255"`
 	Email       string    `json:"email" gorm:"uniqueIndex;not null;size:255"`
 	OIDCSubject string    `json:"-" gorm:"column:oidc_subject;uniqueIndex:idx_oidc_subject_issuer;size:255"` // OIDC subject (sub claim)
-	OIDCIssuer  string    `json:"-" gorm:"column:oidc_issuer;uniqueIndex:idx_oidc_subject_issuer;size:255"` // OIDC issuer URL
+	OIDCIssuer  string    `json:"-" gorm:"column:oidc_issuer;uniqueIndex:idx_oidc_subject_issuer;size:255"`  // OIDC issuer URL
 	IsAdmin     bool      `json:"is_admin" gorm:"default:false"`
 	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// ShowPrivateContributions exposes anonymous contribution counts from
+	// private repositories on the user's public contribution calendar
+	ShowPrivateContributions bool `json:"show_private_contributions" gorm:"default:false"`
+
+	// Locale is the preferred language of API messages; empty follows the
+	// Accept-Language header of each request
+	Locale string `json:"locale,omitempty" gorm:"size:16"`
+
+	// FeedKey signs the feed token of the user, which lets feed readers fetch
+	// the feeds of private repositories; empty if the user has no token.
+	// Replacing it revokes the token.
+	FeedKey string `json:"-" gorm:"size:64"`
+
+	// OnboardedAt is when the user was onboarded or skipped onboarding; nil
+	// until then. Onboarding never runs again once it is set.
+	OnboardedAt *time.Time `json:"onboarded_at,omitempty"`
+
+	// Token is the personal access token the current request authenticated
+	// with, nil otherwise. Its scopes limit what the request may do.
+	Token *Token `json:"-" gorm:"-"`
+}
+
+// TokenAllows returns true if the credentials of the current request allow
+// the permission: always for sessions, per its scopes for a token
+func (u *User) TokenAllows(required RepoPermission) bool {
+	return u.Token == nil || u.Token.RepoPermission().Allows(required)
+}
+
+// TokenHasScope returns true if the credentials of the current request have
+// the scope: always for sessions, per its scopes for a token
+func (u *User) TokenHasScope(scope string) bool {
+	return u.Token == nil || u.Token.HasScope(scope)
 }
 
 // TableName returns the table name for the User model
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User export statuses
+const (
+	// UserExportPending is an export waiting for the export worker
+	UserExportPending = "pending"
+
+	// UserExportRunning is an export being assembled
+	UserExportRunning = "running"
+
+	// UserExportReady is an export that can be downloaded until it expires
+	UserExportReady = "ready"
+
+	// UserExportFailed is an export that could not be assembled
+	UserExportFailed = "failed"
+)
+
+// UserExport is an archive of the data of a user, assembled in the background
+// and kept for download until it expires
+type UserExport struct {
+	ID                  uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID              uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index:idx_user_exports_user_time"`
+	User                User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	RequestedByID       *uuid.UUID `json:"requested_by_id,omitempty" gorm:"type:uuid"` // Administrator who requested the export of another user
+	Status              string     `json:"status" gorm:"size:16;not null;index"`
+	IncludeRepositories bool       `json:"include_repositories" gorm:"not null"` // Git bundles of the owned repositories are included
+	StoragePath         string     `json:"-" gorm:"size:512"`
+	Size                int64      `json:"size"`
+	Error               string     `json:"error,omitempty"`
+	CreatedAt           time.Time  `json:"created_at" gorm:"index:idx_user_exports_user_time"`
+	CompletedAt         *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt           *time.Time `json:"expires_at,omitempty" gorm:"index"`
+}
+
+// TableName specifies the table name for UserExport
+func (UserExport) TableName() string {
+	return "user_exports"
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"path"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook event types sent about CI jobs
+const (
+	WebhookEventCIJobQueued    = "ci_job.queued"
+	WebhookEventCIJobStarted   = "ci_job.started"
+	WebhookEventCIJobSucceeded = "ci_job.succeeded"
+	WebhookEventCIJobFailed    = "ci_job.failed"
+	WebhookEventCIJobCancelled = "ci_job.cancelled"
+)
+
+// WebhookEvents are the event types a webhook can subscribe to
+var WebhookEvents = []string{
+	WebhookEventCIJobQueued,
+	WebhookEventCIJobStarted,
+	WebhookEventCIJobSucceeded,
+	WebhookEventCIJobFailed,
+	WebhookEventCIJobCancelled,
+}
+
+// WebhookEventForCIJobStatus returns the webhook event type of a CI job
+// status, or false for statuses no event is sent for
+func WebhookEventForCIJobStatus(status string) (string, bool) {
+	switch status {
+	case CIJobStatusQueued:
+		return WebhookEventCIJobQueued, true
+	case CIJobStatusRunning:
+		return WebhookEventCIJobStarted, true
+	case CIJobStatusSuccess:
+		return WebhookEventCIJobSucceeded, true
+	case CIJobStatusFailed, CIJobStatusTimedOut, CIJobStatusError:
+		return WebhookEventCIJobFailed, true
+	case CIJobStatusCancelled:
+		return WebhookEventCIJobCancelled, true
+	}
+	return "", false
+}
+
+// Webhook posts the events of a repository it subscribes to to a URL, signed
+// with its secret
+type Webhook struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	RepositoryID uuid.UUID  `json:"repository_id" gorm:"type:uuid;not null;index"`
+	Repository   Repository `json:"-" gorm:"foreignKey:RepositoryID;constraint:OnDelete:CASCADE"`
+	URL          string     `json:"url" gorm:"size:2048;not null"`
+	Secret       string     `json:"-" gorm:"size:255;not null"`
+	Events       []string   `json:"events" gorm:"type:jsonb;serializer:json;not null"`
+	RefPattern   string     `json:"ref_pattern" gorm:"size:255;not null;default:''"` // Ref name glob without refs/heads/ or refs/tags/, empty for every ref
+	Active       bool       `json:"active" gorm:"not null"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for Webhook
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Wants returns true if the webhook is sent an event about a ref
+func (h *Webhook) Wants(event, refName string) bool {
+	if !h.Active || !slices.Contains(h.Events, event) {
+		return false
+	}
+	if h.RefPattern == "" {
+		return true
+	}
+	ok, _ := path.Match(h.RefPattern, refName)
+	return ok
+}
+
+// WebhookDelivery is an event sent to a webhook, with the outcome of its
+// last attempt. Its ID is derived from the event and the webhook, so an event
+// is delivered to each webhook once.
+type WebhookDelivery struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	WebhookID     uuid.UUID  `json:"webhook_id" gorm:"type:uuid;not null;index"`
+	Webhook       Webhook    `json:"-" gorm:"foreignKey:WebhookID;constraint:OnDelete:CASCADE"`
+	Event         string     `json:"event" gorm:"size:50;not null"`
+	Payload       string     `json:"payload" gorm:"type:text;not null"` // The body posted, as signed
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	StatusCode    *int       `json:"status_code,omitempty"` // Of the last attempt, if it got a response
+	Error         string     `json:"error,omitempty" gorm:"type:text;not null;default:''"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name for WebhookDelivery
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
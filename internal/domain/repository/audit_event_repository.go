@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// AuditEventFilter narrows audit event listings. Zero fields do not filter.
+type AuditEventFilter struct {
+	RepositoryID *uuid.UUID
+	ActorID      *uuid.UUID
+	Action       string // An action (repo.delete) or a category of actions (repo)
+}
+
+// AuditEventRepository defines the interface for audit event data access
+type AuditEventRepository interface {
+	// Create stores an audit event
+	Create(ctx context.Context, event *models.AuditEvent) error
+
+	// List returns a page of the events matching a filter, most recent first,
+	// and the total number of matching events
+	List(ctx context.Context, filter AuditEventFilter, limit, offset int) ([]*models.AuditEvent, int64, error)
+}
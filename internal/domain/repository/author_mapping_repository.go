@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// AuthorMappingRepository defines the interface for server-side author mapping data access.
+// Every change increments the repository's AuthorMappingsVersion.
+type AuthorMappingRepository interface {
+	// ListByRepository returns the author mappings of a repository with their users
+	ListByRepository(ctx context.Context, repoID uuid.UUID) ([]*models.AuthorMapping, error)
+
+	// Upsert creates the mapping of an email or points it at a different user
+	Upsert(ctx context.Context, mapping *models.AuthorMapping) error
+
+	// Delete removes a mapping of a repository
+	Delete(ctx context.Context, repoID, id uuid.UUID) error
+}
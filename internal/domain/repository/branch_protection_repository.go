@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// BranchProtectionRepository defines the interface for branch protection data access
+type BranchProtectionRepository interface {
+	// ListByRepository returns the branch protections of a repository, oldest first
+	ListByRepository(ctx context.Context, repoID uuid.UUID) ([]*models.BranchProtection, error)
+
+	// FindByID returns a branch protection of a repository
+	FindByID(ctx context.Context, repoID, id uuid.UUID) (*models.BranchProtection, error)
+
+	// Create stores a new branch protection
+	Create(ctx context.Context, protection *models.BranchProtection) error
+
+	// Update saves the changes to a branch protection
+	Update(ctx context.Context, protection *models.BranchProtection) error
+
+	// Delete removes a branch protection of a repository
+	Delete(ctx context.Context, repoID, id uuid.UUID) error
+}
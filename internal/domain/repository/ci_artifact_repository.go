@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// CIArtifactRepository defines the interface for stored CI artifact data access
+type CIArtifactRepository interface {
+	// Save stores an artifact, replacing the artifact of the same job with
+	// the same name
+	Save(ctx context.Context, artifact *models.CIArtifact) error
+
+	// FindByJobAndName retrieves an artifact of a job by its name
+	FindByJobAndName(ctx context.Context, jobID uuid.UUID, name string) (*models.CIArtifact, error)
+
+	// ListCreatedBefore returns up to limit artifacts stored before a time,
+	// oldest first
+	ListCreatedBefore(ctx context.Context, before time.Time, limit int) ([]*models.CIArtifact, error)
+
+	// ListOrphaned returns up to limit artifacts of jobs without a token
+	// record, that is of jobs that no longer exist, oldest first
+	ListOrphaned(ctx context.Context, limit int) ([]*models.CIArtifact, error)
+
+	// Delete deletes an artifact
+	Delete(ctx context.Context, id uuid.UUID) error
+}
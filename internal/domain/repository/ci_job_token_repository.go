@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// CIJobTokenRepository defines the interface for CI job token data access
+type CIJobTokenRepository interface {
+	// Create stores a new CI job token
+	Create(ctx context.Context, token *models.CIJobToken) error
+
+	// FindByHashedToken retrieves a CI job token by its hashed value
+	FindByHashedToken(ctx context.Context, hashedToken string) (*models.CIJobToken, error)
+
+	// FindByHashedCallbackToken retrieves a CI job token by the hashed value
+	// of its callback token
+	FindByHashedCallbackToken(ctx context.Context, hashedToken string) (*models.CIJobToken, error)
+
+	// FindByJobID retrieves the token of a CI job
+	FindByJobID(ctx context.Context, jobID uuid.UUID) (*models.CIJobToken, error)
+
+	// AdvanceStatus records status as the status of a job unless the job
+	// already has it or has finished, in a single conditional update, and
+	// returns the updated token. It returns nil if the status was not
+	// recorded, and a not found error if the job has no token.
+	AdvanceStatus(ctx context.Context, jobID uuid.UUID, status string) (*models.CIJobToken, error)
+
+	// Revoke revokes the token of a CI job unless it is revoked already and
+	// returns false if there was nothing to revoke
+	Revoke(ctx context.Context, jobID uuid.UUID, at time.Time) (bool, error)
+
+	// AddTransfer adds bytes sent with a token to its transfer count and
+	// records the use
+	AddTransfer(ctx context.Context, id uuid.UUID, bytes int64, at time.Time) error
+
+	// DeleteBefore deletes the tokens created before a time and returns how many were deleted
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
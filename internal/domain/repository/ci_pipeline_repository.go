@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// CIPipelineRepository defines the interface for staged CI run data access
+type CIPipelineRepository interface {
+	// CreateBatch stores the jobs of a new staged run
+	CreateBatch(ctx context.Context, jobs []*models.CIPipelineJob) error
+
+	// FindByID finds a pipeline job by its ID (the runner job ID once submitted)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.CIPipelineJob, error)
+
+	// FindByRunID returns all jobs of a run ordered by stage
+	FindByRunID(ctx context.Context, runID uuid.UUID) ([]*models.CIPipelineJob, error)
+
+	// UpdateStatus sets the status and error of a job
+	UpdateStatus(ctx context.Context, id uuid.UUID, status string, jobErr *string) error
+
+	// TransitionStatus changes the status of a job only if it currently has the
+	// from status. It returns false if another caller changed the job first.
+	TransitionStatus(ctx context.Context, id uuid.UUID, from, to string) (bool, error)
+
+	// DeleteRunsBefore deletes the jobs of the runs none of whose jobs was
+	// updated since a time, whole runs at a time, and returns how many jobs
+	// were deleted
+	DeleteRunsBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// CollaboratorRepository defines the interface for repository collaborator data access
+type CollaboratorRepository interface {
+	// ListByRepository returns the collaborators of a repository with their users
+	ListByRepository(ctx context.Context, repoID uuid.UUID) ([]*models.RepositoryCollaborator, error)
+
+	// FindByRepositoryAndUser returns the collaborator entry of a user on a repository
+	FindByRepositoryAndUser(ctx context.Context, repoID, userID uuid.UUID) (*models.RepositoryCollaborator, error)
+
+	// Upsert adds a collaborator or changes the permission of an existing one
+	Upsert(ctx context.Context, collaborator *models.RepositoryCollaborator) error
+
+	// Delete removes a user from the collaborators of a repository
+	Delete(ctx context.Context, repoID, userID uuid.UUID) error
+}
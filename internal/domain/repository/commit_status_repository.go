@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// CommitStatusRepository defines the interface for commit status data access
+type CommitStatusRepository interface {
+	// Create stores a new status
+	Create(ctx context.Context, status *models.CommitStatus) error
+
+	// ListBySHA lists every status of a commit, most recent first
+	ListBySHA(ctx context.Context, repoID uuid.UUID, sha string, limit, offset int) ([]*models.CommitStatus, error)
+
+	// LatestBySHA returns the most recent status of each context of a
+	// commit, by context
+	LatestBySHA(ctx context.Context, repoID uuid.UUID, sha string) ([]*models.CommitStatus, error)
+}
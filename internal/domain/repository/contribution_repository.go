@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// ContributionRepository defines the interface for contribution event data access
+type ContributionRepository interface {
+	// CreateBatch stores contribution events, skipping events already recorded
+	CreateBatch(ctx context.Context, events []*models.ContributionEvent) error
+
+	// FindExistingRefs returns which of the given refs are already recorded for a repository and kind
+	FindExistingRefs(ctx context.Context, repoID uuid.UUID, kind string, refs []string) (map[string]bool, error)
+
+	// DailyCounts returns per-day contribution counts of a user within [from, to).
+	// Contributions to private repositories are only counted if includePrivate is set.
+	DailyCounts(ctx context.Context, userID uuid.UUID, from, to time.Time, includePrivate bool) ([]models.ContributionDay, error)
+
+	// ListByUser returns a page of the contribution events of a user, oldest first
+	ListByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.ContributionEvent, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// GPGKeyRepository defines the interface for GPG key data access operations
+type GPGKeyRepository interface {
+	// Create creates a new GPG key in the database
+	Create(ctx context.Context, key *models.GPGKey) error
+
+	// FindByID retrieves a GPG key by its ID
+	FindByID(ctx context.Context, id uuid.UUID) (*models.GPGKey, error)
+
+	// FindByUserID retrieves all GPG keys for a user
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]*models.GPGKey, error)
+
+	// Delete removes a GPG key from the database by its ID
+	Delete(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+)
+
+// HousekeepingTaskRepository defines the interface for housekeeping task access
+type HousekeepingTaskRepository interface {
+	// Register stores a task due at a time, unless it is already stored
+	Register(ctx context.Context, name string, nextRunAt time.Time) error
+
+	// FindByName returns a task
+	FindByName(ctx context.Context, name string) (*models.HousekeepingTask, error)
+
+	// List returns all the stored tasks, by name
+	List(ctx context.Context) ([]*models.HousekeepingTask, error)
+
+	// Update saves the schedule and last run of a task
+	Update(ctx context.Context, task *models.HousekeepingTask) error
+
+	// Reschedule sets when a task is next due
+	Reschedule(ctx context.Context, name string, nextRunAt time.Time) error
+
+	// WithLock calls fn while holding a lock on the task shared by all
+	// replicas. It returns false without calling fn if another holds it.
+	WithLock(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error)
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// IssueFilter selects the issues of a repository to list
+type IssueFilter struct {
+	State  string   // Only issues in this state, "" for all
+	Labels []string // Only issues carrying all of these labels
+}
+
+// IssueRepository defines the interface for issue and issue comment data access
+type IssueRepository interface {
+	// Create stores a new issue, giving it the next number of its repository
+	Create(ctx context.Context, issue *models.Issue) error
+
+	// FindByNumber returns an issue of a repository with its author
+	FindByNumber(ctx context.Context, repoID uuid.UUID, number int) (*models.Issue, error)
+
+	// ListByRepository returns a page of the issues of a repository matching
+	// filter, newest first, with the total number matching
+	ListByRepository(ctx context.Context, repoID uuid.UUID, filter IssueFilter, limit, offset int) ([]*models.Issue, int64, error)
+
+	// Update saves the title, body, labels and state of an issue
+	Update(ctx context.Context, issue *models.Issue) error
+
+	// CreateComment stores a new comment on an issue
+	CreateComment(ctx context.Context, comment *models.IssueComment) error
+
+	// ListComments returns a page of the comments on an issue, oldest first,
+	// with the total number
+	ListComments(ctx context.Context, issueID uuid.UUID, limit, offset int) ([]*models.IssueComment, int64, error)
+}
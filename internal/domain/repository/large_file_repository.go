@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// LargeFileRepository defines the interface for large file addition data access
+type LargeFileRepository interface {
+	// CreateBatch stores the large files added by a push
+	CreateBatch(ctx context.Context, additions []*models.LargeFileAddition) error
+
+	// CountSince counts the large files added to a repository since a time
+	CountSince(ctx context.Context, repoID uuid.UUID, since time.Time) (int64, error)
+
+	// DeleteBefore deletes large file additions recorded before a time and returns how many were deleted
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
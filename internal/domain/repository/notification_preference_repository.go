@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// NotificationPreferenceRepository defines the interface for notification preference data access
+type NotificationPreferenceRepository interface {
+	// FindByUser returns the preferences of a user, or a not found error if
+	// the user never changed them
+	FindByUser(ctx context.Context, userID uuid.UUID) (*models.NotificationPreference, error)
+
+	// Upsert stores the preferences of a user
+	Upsert(ctx context.Context, preference *models.NotificationPreference) error
+}
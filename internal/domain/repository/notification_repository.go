@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// NotificationRepository defines the interface for notification data access
+type NotificationRepository interface {
+	// CreateBatch stores new notifications
+	CreateBatch(ctx context.Context, notifications []*models.Notification) error
+
+	// ListByUser returns a page of the notifications of a user, newest first,
+	// optionally only the unread ones, with the total number matching
+	ListByUser(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit, offset int) ([]*models.Notification, int64, error)
+
+	// CountUnread returns the number of unread notifications of a user
+	CountUnread(ctx context.Context, userID uuid.UUID) (int64, error)
+
+	// MarkRead marks a notification of a user as read. It returns a not
+	// found error if the user has no such notification.
+	MarkRead(ctx context.Context, userID, id uuid.UUID) error
+
+	// MarkAllRead marks every notification of a user as read and returns how
+	// many were unread
+	MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error)
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// PullRequestRepository defines the interface for pull request data access
+type PullRequestRepository interface {
+	// Create stores a new pull request, giving it the next number of its repository
+	Create(ctx context.Context, pr *models.PullRequest) error
+
+	// FindByNumber returns a pull request of a repository with its author and merger
+	FindByNumber(ctx context.Context, repoID uuid.UUID, number int) (*models.PullRequest, error)
+
+	// FindOpenByBranches returns the open pull request of a repository from
+	// source into target, or a not found error if there is none
+	FindOpenByBranches(ctx context.Context, repoID uuid.UUID, source, target string) (*models.PullRequest, error)
+
+	// ListByRepository returns a page of the pull requests of a repository,
+	// newest first, optionally only those in a state ("" for all), with the
+	// total number matching
+	ListByRepository(ctx context.Context, repoID uuid.UUID, state string, limit, offset int) ([]*models.PullRequest, int64, error)
+
+	// ListByAuthor returns a page of the pull requests opened by a user, oldest first
+	ListByAuthor(ctx context.Context, authorID uuid.UUID, limit, offset int) ([]*models.PullRequest, error)
+
+	// UpdateState saves the state and merge or close details of a pull request
+	// if it is still open. It returns a conflict error if it is not.
+	UpdateState(ctx context.Context, pr *models.PullRequest) error
+}
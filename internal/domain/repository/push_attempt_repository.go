@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// PushAttemptRepository defines the interface for push attempt data access
+type PushAttemptRepository interface {
+	// Create stores a push attempt
+	Create(ctx context.Context, attempt *models.PushAttempt) error
+
+	// ListByRepository lists the push attempts of a repository, most recent
+	// first, optionally only those with an outcome ("" for all)
+	ListByRepository(ctx context.Context, repoID uuid.UUID, outcome string, limit, offset int) ([]*models.PushAttempt, error)
+
+	// ListByPusher lists the push attempts of a user, oldest first
+	ListByPusher(ctx context.Context, pusherID uuid.UUID, limit, offset int) ([]*models.PushAttempt, error)
+
+	// DeleteBefore deletes push attempts recorded before a time and returns how many were deleted
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
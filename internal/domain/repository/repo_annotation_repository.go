@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// RepoAnnotationRepository defines the interface for repository annotation data access
+type RepoAnnotationRepository interface {
+	// Create creates a new annotation
+	Create(ctx context.Context, annotation *models.RepoAnnotation) error
+
+	// FindByRepoAndKey finds an annotation by repository ID and key
+	FindByRepoAndKey(ctx context.Context, repoID uuid.UUID, key string) (*models.RepoAnnotation, error)
+
+	// ListByRepository lists all annotations of a repository ordered by key
+	ListByRepository(ctx context.Context, repoID uuid.UUID) ([]*models.RepoAnnotation, error)
+
+	// ListByRepositoriesAndKeys lists annotations with the given keys for a set of repositories
+	ListByRepositoriesAndKeys(ctx context.Context, repoIDs []uuid.UUID, keys []string) ([]*models.RepoAnnotation, error)
+
+	// Update updates an annotation
+	Update(ctx context.Context, annotation *models.RepoAnnotation) error
+
+	// Delete deletes an annotation by repository ID and key
+	Delete(ctx context.Context, repoID uuid.UUID, key string) error
+}
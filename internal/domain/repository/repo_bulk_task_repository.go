@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// RepoBulkTaskRepository defines the interface for repository bulk task access
+type RepoBulkTaskRepository interface {
+	// Create stores a new task
+	Create(ctx context.Context, task *models.RepoBulkTask) error
+
+	// FindByID returns a task
+	FindByID(ctx context.Context, id uuid.UUID) (*models.RepoBulkTask, error)
+
+	// ListByStatus returns the tasks in any of the statuses, oldest first
+	ListByStatus(ctx context.Context, statuses ...string) ([]*models.RepoBulkTask, error)
+
+	// Update saves the status and results of a task
+	Update(ctx context.Context, task *models.RepoBulkTask) error
+
+	// DeleteExpired removes the tasks that expired before a time and returns
+	// how many were removed
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// RepoImportRepository defines the interface for repository import data access
+type RepoImportRepository interface {
+	// Create stores a new import
+	Create(ctx context.Context, imp *models.RepoImport) error
+
+	// FindByID returns an import
+	FindByID(ctx context.Context, id uuid.UUID) (*models.RepoImport, error)
+
+	// ListByOwner returns a page of the imports of a user, newest first, with
+	// the total number
+	ListByOwner(ctx context.Context, ownerID uuid.UUID, limit, offset int) ([]*models.RepoImport, int64, error)
+
+	// ListActive returns the pending and running imports of a user
+	ListActive(ctx context.Context, ownerID uuid.UUID) ([]*models.RepoImport, error)
+
+	// ListByStatus returns the imports in any of the statuses, oldest first
+	ListByStatus(ctx context.Context, statuses ...string) ([]*models.RepoImport, error)
+
+	// Update saves the progress and result of an import
+	Update(ctx context.Context, imp *models.RepoImport) error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// RepoLanguageRepository defines the interface for the stored language
+// breakdown of repositories
+type RepoLanguageRepository interface {
+	// Replace replaces the language breakdown of a repository with the one of
+	// a commit
+	Replace(ctx context.Context, repoID uuid.UUID, commitHash string, languages []models.RepoLanguage) error
+
+	// ListByRepository lists the languages of a repository, most bytes first
+	ListByRepository(ctx context.Context, repoID uuid.UUID) ([]models.RepoLanguage, error)
+
+	// PrimaryLanguages returns the language with the most bytes of each of a
+	// set of repositories; repositories without a breakdown are left out
+	PrimaryLanguages(ctx context.Context, repoIDs []uuid.UUID) (map[uuid.UUID]string, error)
+}
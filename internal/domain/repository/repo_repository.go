@@ -2,11 +2,20 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/bravo68web/stasis/internal/domain/models"
 	"github.com/google/uuid"
 )
 
+// RepoFilter narrows repository listings. Zero fields do not filter.
+type RepoFilter struct {
+	AnnotationKey   string  // Only repositories carrying this annotation key...
+	AnnotationValue string  // ...with this value
+	License         *string // Only repositories with this effective license; "" for unlicensed ones
+	Topic           string  // Only repositories tagged with this topic
+}
+
 // RepoRepository defines the interface for repository data access
 type RepoRepository interface {
 	// Create creates a new repository
@@ -27,6 +36,11 @@ type RepoRepository interface {
 	// ListPublic lists public repositories with pagination
 	ListPublic(ctx context.Context, limit, offset int) ([]*models.Repository, error)
 
+	// ListPublicLocations lists every public repository with only what serving
+	// its git data needs: ID, name, owner username, default branch and storage
+	// location
+	ListPublicLocations(ctx context.Context) ([]*models.Repository, error)
+
 	// ListAll lists all repositories with pagination (for admin use)
 	ListAll(ctx context.Context, limit, offset int) ([]*models.Repository, error)
 
@@ -44,4 +58,89 @@ type RepoRepository interface {
 
 	// FindAllMirrors finds all mirror repositories
 	FindAllMirrors(ctx context.Context) ([]*models.Repository, error)
+
+	// FindByOwnerFiltered finds all repositories owned by a user matching the filter
+	FindByOwnerFiltered(ctx context.Context, ownerID uuid.UUID, filter RepoFilter) ([]*models.Repository, error)
+
+	// ListPublicFiltered lists public repositories matching the filter with pagination
+	ListPublicFiltered(ctx context.Context, filter RepoFilter, limit, offset int) ([]*models.Repository, error)
+
+	// Search finds repositories whose name or description contains query and
+	// matching the filter, most recently updated first; private ones only if
+	// viewer (nil for anonymous) owns or collaborates on them, or is a site
+	// administrator
+	Search(ctx context.Context, query string, filter RepoFilter, viewer *models.User, limit, offset int) ([]*models.Repository, error)
+
+	// UpdateLicense stores the detected license, override and effective license of a repository
+	UpdateLicense(ctx context.Context, repo *models.Repository) error
+
+	// FindLicenseUndetected finds repositories whose license file was never inspected
+	FindLicenseUndetected(ctx context.Context, limit int) ([]*models.Repository, error)
+
+	// CountByLicense returns the number of repositories per effective license
+	CountByLicense(ctx context.Context) ([]models.LicenseCount, error)
+
+	// UpdateStorage records that a repository moved to a storage backend and path
+	UpdateStorage(ctx context.Context, id uuid.UUID, backend, gitPath string) error
+
+	// CountByStorageBackend returns the number of repositories per storage backend
+	CountByStorageBackend(ctx context.Context) ([]models.StorageBackendCount, error)
+
+	// UpdateProtectedTags replaces the tag protection settings of a repository
+	UpdateProtectedTags(ctx context.Context, id uuid.UUID, patterns, overrides []string) error
+
+	// UpdatePinnedLinks replaces the pinned links of a repository
+	UpdatePinnedLinks(ctx context.Context, id uuid.UUID, links []models.PinnedLink) error
+
+	// Rename changes the name and git path of a repository and records the
+	// redirect from its former name, in a single transaction. Redirects from
+	// the new name are dropped.
+	Rename(ctx context.Context, repo *models.Repository, redirect *models.RepoRedirect) error
+
+	// FindByRedirect finds the repository a former owner username and name
+	// redirects to, if the redirect was created after since and no repository
+	// took the name since
+	FindByRedirect(ctx context.Context, username, name string, since time.Time) (*models.Repository, error)
+
+	// DeleteRedirectsCreatedBefore deletes the redirects created before a time
+	// and returns how many were deleted
+	DeleteRedirectsCreatedBefore(ctx context.Context, before time.Time) (int64, error)
+
+	// UpdatePushPolicy replaces the push policy of a repository; nil removes it
+	UpdatePushPolicy(ctx context.Context, id uuid.UUID, policy *models.PushPolicy) error
+
+	// UpdateUploadPackSettings replaces the upload-pack settings of a repository
+	UpdateUploadPackSettings(ctx context.Context, id uuid.UUID, settings models.UploadPackSettings) error
+
+	// UpdateTopics replaces the topics of a repository
+	UpdateTopics(ctx context.Context, id uuid.UUID, topics []string) error
+
+	// CountByTopic returns the number of public repositories per topic, most
+	// used first, for at most limit topics
+	CountByTopic(ctx context.Context, limit int) ([]models.TopicCount, error)
+
+	// FindContributionsUnindexed finds repositories whose commits were never recorded as contributions
+	FindContributionsUnindexed(ctx context.Context, limit int) ([]*models.Repository, error)
+
+	// MarkContributionsIndexed records that the commits of a repository were recorded as contributions
+	MarkContributionsIndexed(ctx context.Context, id uuid.UUID) error
+
+	// UpdateSize stores the measured disk usage of a repository
+	UpdateSize(ctx context.Context, id uuid.UUID, sizeBytes int64) error
+
+	// UpdateGC records the end and duration of a garbage collection of a repository
+	UpdateGC(ctx context.Context, id uuid.UUID, at time.Time, duration time.Duration) error
+
+	// UpdateQuota sets the size limit override of a repository (nil to remove it)
+	UpdateQuota(ctx context.Context, id uuid.UUID, quotaBytes *int64) error
+
+	// SumSizeByOwner returns the recorded disk usage of all repositories owned by a user
+	SumSizeByOwner(ctx context.Context, ownerID uuid.UUID) (int64, error)
+
+	// FindForks finds the direct forks of a repository, oldest first; private
+	// ones only if owned by viewerID (nil for anonymous)
+	FindForks(ctx context.Context, parentID uuid.UUID, viewerID *uuid.UUID, limit, offset int) ([]*models.Repository, error)
+
+	// CountForks returns the number of direct forks of a repository
+	CountForks(ctx context.Context, parentID uuid.UUID) (int64, error)
 }
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// SessionRepository defines the interface for OIDC session data access
+type SessionRepository interface {
+	// Create stores a new session
+	Create(ctx context.Context, session *models.Session) error
+
+	// FindByID retrieves a session by its ID
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Session, error)
+
+	// Touch records a use of a session
+	Touch(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	// UpdateTokens replaces the encrypted identity provider tokens of a
+	// session after a refresh and records the use
+	UpdateTokens(ctx context.Context, id uuid.UUID, refreshToken, idToken string, at time.Time) error
+
+	// Revoke revokes a session unless it is revoked already and returns
+	// false if there was nothing to revoke
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+
+	// DeleteInactive deletes the sessions revoked, expired at a time or unused
+	// since idleSince and returns how many were deleted
+	DeleteInactive(ctx context.Context, at, idleSince time.Time) (int64, error)
+}
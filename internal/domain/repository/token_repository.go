@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/bravo68web/stasis/internal/domain/models"
 	"github.com/google/uuid"
@@ -30,6 +31,12 @@ type TokenRepository interface {
 	// UpdateLastUsed updates the last_used timestamp for a token
 	UpdateLastUsed(ctx context.Context, id uuid.UUID) error
 
+	// RecordUsage stores the last use of a token and adds uses to its usage count
+	RecordUsage(ctx context.Context, id uuid.UUID, usedAt time.Time, ip, userAgent string, uses int64) error
+
+	// AddKnownOrigin adds a "country/ASN" origin to the known origins of a token
+	AddKnownOrigin(ctx context.Context, id uuid.UUID, origin string) error
+
 	// CountByUserID returns the number of tokens for a user
 	CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
 }
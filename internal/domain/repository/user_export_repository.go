@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// UserExportRepository defines the interface for user data export access
+type UserExportRepository interface {
+	// Create stores a new export
+	Create(ctx context.Context, export *models.UserExport) error
+
+	// FindByID returns an export
+	FindByID(ctx context.Context, id uuid.UUID) (*models.UserExport, error)
+
+	// ListByUser returns the exports of a user, newest first
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.UserExport, error)
+
+	// CountSelfRequestedSince counts the exports a user requested for
+	// themselves since a time, except failed ones
+	CountSelfRequestedSince(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error)
+
+	// ListByStatus returns the exports in any of the statuses, oldest first
+	ListByStatus(ctx context.Context, statuses ...string) ([]*models.UserExport, error)
+
+	// Update saves the status and result of an export
+	Update(ctx context.Context, export *models.UserExport) error
+
+	// ListExpired returns up to limit exports that expired before a time
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]*models.UserExport, error)
+
+	// Delete removes an export
+	Delete(ctx context.Context, id uuid.UUID) error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/bravo68web/stasis/internal/domain/models"
 	"github.com/google/uuid"
@@ -24,8 +25,9 @@ type UserRepository interface {
 	// Update updates an existing user's information
 	Update(ctx context.Context, user *models.User) error
 
-	// Delete removes a user from the database by their ID
-	Delete(ctx context.Context, id uuid.UUID) error
+	// Delete removes a user from the database by their ID, along with the
+	// repositories repoIDs they own, in a single transaction
+	Delete(ctx context.Context, id uuid.UUID, repoIDs []uuid.UUID) error
 
 	// List retrieves all users with pagination
 	List(ctx context.Context, limit, offset int) ([]*models.User, error)
@@ -33,6 +35,16 @@ type UserRepository interface {
 	// Count returns the total number of users
 	Count(ctx context.Context) (int64, error)
 
+	// Search finds users whose username or email contains query, case
+	// insensitively, with pagination
+	Search(ctx context.Context, query string, limit, offset int) ([]*models.User, error)
+
+	// CountSearch returns the number of users Search finds for query
+	CountSearch(ctx context.Context, query string) (int64, error)
+
+	// CountAdmins returns the number of site administrators
+	CountAdmins(ctx context.Context) (int64, error)
+
 	// ExistsByUsername checks if a user with the given username exists
 	ExistsByUsername(ctx context.Context, username string) (bool, error)
 
@@ -41,4 +53,8 @@ type UserRepository interface {
 
 	// FindByOIDCSubject retrieves a user by their OIDC subject and issuer
 	FindByOIDCSubject(ctx context.Context, subject, issuer string) (*models.User, error)
+
+	// MarkOnboarded sets when a user was onboarded unless it is already set.
+	// It returns false if the user was already onboarded.
+	MarkOnboarded(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
 }
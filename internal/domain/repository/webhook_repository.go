@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/google/uuid"
+)
+
+// WebhookRepository defines the interface for webhook and webhook delivery data access
+type WebhookRepository interface {
+	// ListByRepository returns the webhooks of a repository, oldest first
+	ListByRepository(ctx context.Context, repoID uuid.UUID) ([]*models.Webhook, error)
+
+	// FindByID returns a webhook of a repository
+	FindByID(ctx context.Context, repoID, id uuid.UUID) (*models.Webhook, error)
+
+	// Create stores a new webhook
+	Create(ctx context.Context, hook *models.Webhook) error
+
+	// Update saves the changes to a webhook
+	Update(ctx context.Context, hook *models.Webhook) error
+
+	// Delete removes a webhook of a repository with its deliveries
+	Delete(ctx context.Context, repoID, id uuid.UUID) error
+
+	// CreateDelivery stores a new delivery and returns true, or returns false
+	// if a delivery with its ID already exists
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error)
+
+	// FindDelivery returns a delivery with its webhook
+	FindDelivery(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error)
+
+	// UpdateDelivery saves the outcome of the last attempt of a delivery
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+
+	// ListDeliveries lists the deliveries of a webhook, most recent first
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, limit, offset int) ([]*models.WebhookDelivery, error)
+
+	// DeleteDeliveriesBefore deletes deliveries created before a time and
+	// returns how many were deleted
+	DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int64, error)
+}
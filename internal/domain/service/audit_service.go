@@ -0,0 +1,22 @@
+package service
+
+// AuditEntry describes a security-relevant action for the audit trail
+type AuditEntry struct {
+	Category string // e.g., auth, repository, admin
+	Action   string // e.g., repository.delete
+	ActorID  string
+	Actor    string
+	Resource string
+	Outcome  string // success, failure
+	Fields   map[string]string
+
+	// Sensitive lists keys of Fields that must be redacted before export
+	Sensitive []string
+}
+
+// AuditRecorder records audit entries
+// Implementations must not block the caller on delivery
+type AuditRecorder interface {
+	// Record queues an audit entry for export
+	Record(entry AuditEntry)
+}
@@ -12,7 +12,8 @@ type AuthService interface {
 
 	// AuthenticateToken authenticates a user using an access token (PAT)
 	// Returns the authenticated user or an error if the token is invalid or expired
-	AuthenticateToken(ctx context.Context, token string) (*models.User, error)
+	// The origin of the request is recorded as the last use of the token
+	AuthenticateToken(ctx context.Context, token string, origin RequestOrigin) (*models.User, error)
 
 	// AuthenticateSSH authenticates a user using their SSH public key
 	// Returns the authenticated user or an error if the key is not recognized
@@ -21,4 +22,14 @@ type AuthService interface {
 	// AuthenticateSession authenticates a user using a session JWT (from OIDC login)
 	// Returns the authenticated user or an error if the session is invalid or expired
 	AuthenticateSession(ctx context.Context, sessionToken string) (*models.User, error)
+
+	// AuthenticateCIJobToken validates the token in the clone URL of a CI job
+	// Returns the token or an error if it is unknown, expired or revoked
+	AuthenticateCIJobToken(ctx context.Context, token string) (*models.CIJobToken, error)
+}
+
+// RequestOrigin describes the client a credential was presented by
+type RequestOrigin struct {
+	IP        string
+	UserAgent string
 }
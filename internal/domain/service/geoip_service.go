@@ -0,0 +1,25 @@
+package service
+
+import (
+	"net/netip"
+	"strconv"
+)
+
+// GeoLocation is the coarse origin of an IP address
+type GeoLocation struct {
+	Country string // ISO 3166-1 alpha-2 country code
+	ASN     uint32 // Autonomous system number (0 if unknown)
+}
+
+// Origin returns the location as a stable "country/ASN" key, e.g. "DE/AS3320"
+func (l GeoLocation) Origin() string {
+	return l.Country + "/AS" + strconv.FormatUint(uint64(l.ASN), 10)
+}
+
+// GeoIPLookup resolves the location of IP addresses.
+// This abstraction allows for different GeoIP databases.
+type GeoIPLookup interface {
+	// Lookup returns the location of an address, or false if it is unknown
+	// (e.g. private and loopback addresses)
+	Lookup(addr netip.Addr) (GeoLocation, bool)
+}
@@ -1,9 +1,15 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"io"
+	"strings"
+	"syscall"
 	"time"
+	"unicode/utf8"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
 )
 
 // Ref represents a Git reference (branch or tag)
@@ -19,7 +25,8 @@ type Tag struct {
 	Hash    string
 	Message string // For annotated tags
 	Tagger  string
-	IsLight bool // True if it's a lightweight tag
+	Date    time.Time // Tagger date, or the committer date of a lightweight tag
+	IsLight bool      // True if it's a lightweight tag
 }
 
 // Branch represents a Git branch
@@ -44,6 +51,48 @@ type Commit struct {
 	ParentHashes   []string
 }
 
+// Commit signature verification reasons
+const (
+	// SignatureUnsigned is a commit without a signature
+	SignatureUnsigned = "unsigned"
+
+	// SignatureUnknownKey is a commit signed by none of the keys it was checked
+	// against, or in an unsupported format
+	SignatureUnknownKey = "unknown_key"
+
+	// SignatureBad is a commit whose signature does not match its content
+	SignatureBad = "bad_signature"
+
+	// SignatureValid is a commit signed by one of the keys it was checked against
+	SignatureValid = "valid"
+)
+
+// Commit signature formats
+const (
+	SignatureFormatGPG = "gpg"
+	SignatureFormatSSH = "ssh"
+)
+
+// SignatureCheck asks for the signature of a commit to be checked against
+// the public keys of its author
+type SignatureCheck struct {
+	CommitHash string
+	GPGKeys    []string // ASCII-armored OpenPGP public keys
+	SSHKeys    []string // SSH public keys in authorized_keys format
+}
+
+// SignatureVerification is the outcome of a SignatureCheck
+type SignatureVerification struct {
+	Reason string // One of the Signature* reasons
+	Format string // SignatureFormat* of the signature, empty if unsigned or unsupported
+	KeyID  string // Long key ID (GPG) or SHA256 fingerprint (SSH) of the signing key, if known
+}
+
+// Signed returns true if the commit carries a signature, valid or not
+func (v *SignatureVerification) Signed() bool {
+	return v.Reason != SignatureUnsigned
+}
+
 // TreeEntry represents an entry in a Git tree (file or directory)
 type TreeEntry struct {
 	Name string // File or directory name
@@ -56,13 +105,82 @@ type TreeEntry struct {
 
 // FileContent represents the content of a file in a Git repository
 type FileContent struct {
-	Path     string
-	Name     string
-	Size     int64
-	Hash     string
-	Content  []byte
-	IsBinary bool
-	Encoding string // "utf-8", "base64" for binary files
+	Path      string
+	Name      string
+	Size      int64
+	Hash      string
+	Content   []byte
+	IsBinary  bool
+	Encoding  string // "utf-8", "base64" for binary files
+	Truncated bool   // Content was left out, the file is larger than the inline size cap
+}
+
+// binarySniffLength is how much of the start of a file is searched for NUL bytes
+const binarySniffLength = 8000
+
+// IsBinaryContent reports whether content is binary: it has a NUL byte near
+// its start, as git itself checks, or it is not valid UTF-8, so it cannot be
+// returned as text without corrupting it. partial is set when content is only
+// the start of the file, so a character cut at the end is not held against it.
+func IsBinaryContent(content []byte, partial bool) bool {
+	if bytes.IndexByte(content[:min(len(content), binarySniffLength)], 0) >= 0 {
+		return true
+	}
+	if partial {
+		// Drop the bytes of a character cut at the end, at most UTFMax-1
+		for cut := 0; cut < utf8.UTFMax && cut < len(content); cut++ {
+			if !utf8.RuneStart(content[len(content)-1-cut]) {
+				continue
+			}
+			if !utf8.FullRune(content[len(content)-1-cut:]) {
+				content = content[:len(content)-1-cut]
+			}
+			break
+		}
+	}
+	return !utf8.Valid(content)
+}
+
+// FileBlob is a file at a given ref whose content is streamed instead of read
+// into memory. Content seeks, so it can serve HTTP range requests; close it
+// when done.
+type FileBlob struct {
+	Path    string
+	Name    string
+	Size    int64
+	Hash    string
+	Content io.ReadSeekCloser
+}
+
+// ContributorStats is the activity of an author, by email, in a history
+type ContributorStats struct {
+	Name        string // Name of the most recent commit of the author
+	Email       string // Lowercased
+	Commits     int
+	Additions   int64
+	Deletions   int64
+	FirstCommit time.Time
+	LastCommit  time.Time
+}
+
+// WeeklyCommits is the number of commits authored in a week
+type WeeklyCommits struct {
+	Week    time.Time // Start of the week, Sunday 00:00 UTC
+	Commits int
+}
+
+// ContributorActivity is the contributor statistics of the history of a commit
+type ContributorActivity struct {
+	Tip          string             // Commit the history was read from
+	Contributors []ContributorStats // Most commits first
+	Weeks        []WeeklyCommits    // Commits of all authors in the last 52 weeks, oldest first
+}
+
+// LanguageBreakdown is the bytes of source code per language in the tree of
+// a commit, see package language
+type LanguageBreakdown struct {
+	Tip   string           // Commit the tree was read from
+	Bytes map[string]int64 // By language
 }
 
 // BlameLine represents a single line in a blame output
@@ -78,13 +196,49 @@ type BlameLine struct {
 // DiffResult represents the diff output for a commit
 type DiffResult struct {
 	CommitHash   string
+	MergeBase    string // Set for merge base (three-dot) comparisons
 	Content      string
+	Truncated    bool // True if Content was cut at the diff size limit
 	FilesChanged int
 	Additions    int
 	Deletions    int
 	Files        []DiffFile
 }
 
+// Comparison is the result of comparing two revisions of a repository
+type Comparison struct {
+	Base         string      // Resolved commit hash of base
+	Head         string      // Resolved commit hash of head
+	Commits      []Commit    // Commits of head missing from base, newest first
+	TotalCommits int         // Number of such commits, which may exceed len(Commits)
+	Diff         *DiffResult // Changes of head since the merge base
+}
+
+// Signature identifies the author of a commit created by the server
+type Signature struct {
+	Name  string
+	Email string
+}
+
+// CommitSigner signs the commits created by the server with the instance key
+type CommitSigner interface {
+	// Committer returns the identity signed commits are committed as
+	Committer() Signature
+
+	// Format returns the SignatureFormat* of the key
+	Format() string
+
+	// PublicKey returns the public key: ASCII-armored for GPG, in
+	// authorized_keys format for SSH
+	PublicKey() string
+
+	// KeyID returns the long key ID (GPG) or SHA256 fingerprint (SSH) of the key
+	KeyID() string
+
+	// Sign returns the armored signature of the payload of a commit object
+	Sign(payload []byte) (string, error)
+}
+
 // DiffFile represents a single file's diff information
 type DiffFile struct {
 	OldPath   string
@@ -95,17 +249,231 @@ type DiffFile struct {
 	Patch     string
 }
 
+// RefCommand represents a single ref update requested by a push
+type RefCommand struct {
+	OldHash string
+	NewHash string
+	RefName string
+}
+
+// IsCreate returns true if the command creates a new ref
+func (c RefCommand) IsCreate() bool {
+	return isZeroHash(c.OldHash)
+}
+
+// IsDelete returns true if the command deletes an existing ref
+func (c RefCommand) IsDelete() bool {
+	return isZeroHash(c.NewHash)
+}
+
+// RefUpdate is a ref update applied by a push
+type RefUpdate struct {
+	RefCommand
+	CommitHash string // NewHash peeled to the commit an annotated tag points to; empty for deletions
+}
+
+// RefRejection describes why a ref update of a push was refused
+type RefRejection struct {
+	RefName string
+	Reason  string
+
+	// UnlessFastForward refuses the update only if it is not a fast-forward.
+	// This can only be decided once the pushed commits have been received, so
+	// such rejections do not stop the push from being read.
+	UnlessFastForward bool
+}
+
+// RefStatus is the status of a ref update as reported to the pushing client
+type RefStatus struct {
+	RefName string // The ref of the command, as the client sent it
+	OK      bool
+	Reason  string // Why the update was refused, if not OK
+
+	// Set with report-status-v2 when a hook (proc-receive) updated another
+	// ref or other objects than the command asked for
+	UpdatedRef   string
+	OldHash      string
+	NewHash      string
+	ForcedUpdate bool
+}
+
+// PushResult describes a push as the client saw it
+type PushResult struct {
+	Commands        []RefCommand // Ref updates the client requested
+	Updates         []RefUpdate  // Ref updates git applied
+	Statuses        []RefStatus  // Per-ref status reported to the client, in the order git reported them
+	UnpackError     string       // Why git could not unpack the pushed objects, "" if it could
+	Atomic          bool         // The client asked for all refs to be updated or none
+	Output          string       // Messages sent to the client, e.g. hook output and rejections
+	OutputTruncated bool         // Output was cut at the capture limit
+	LargeFiles      []LargeFile  // Files above the large file warning size the push added
+}
+
+// Accepted returns true if every requested ref update was applied
+func (r *PushResult) Accepted() bool {
+	return len(r.Commands) > 0 && len(r.Updates) == len(r.Commands)
+}
+
+// Status returns the status reported for the command updating a ref. A
+// rejected status wins over an accepted one, should a hook have reported the
+// same ref more than once.
+func (r *PushResult) Status(refName string) (RefStatus, bool) {
+	var found RefStatus
+	ok := false
+	for _, status := range r.Statuses {
+		if status.RefName != refName {
+			continue
+		}
+		if !ok || !status.OK {
+			found, ok = status, true
+		}
+	}
+	return found, ok
+}
+
+// RejectedCommands returns the commands of the push git did not apply, with
+// the reason reported for each
+func (r *PushResult) RejectedCommands() []RejectedCommand {
+	applied := make(map[string]bool, len(r.Updates))
+	for _, update := range r.Updates {
+		applied[update.RefName] = true
+	}
+
+	var rejected []RejectedCommand
+	for _, cmd := range r.Commands {
+		if applied[cmd.RefName] {
+			continue
+		}
+		rejection := RejectedCommand{RefCommand: cmd}
+		if status, ok := r.Status(cmd.RefName); ok && !status.OK {
+			rejection.Reason = status.Reason
+		} else if r.UnpackError != "" {
+			rejection.Reason = "unpack failed: " + r.UnpackError
+		}
+		rejected = append(rejected, rejection)
+	}
+	return rejected
+}
+
+// RejectedCommand is a ref update of a push that was not applied
+type RejectedCommand struct {
+	RefCommand
+	Reason string // As reported to the client, "" if git reported nothing
+}
+
+// LargeFile is a file a push added to git above the large file warning size,
+// instead of storing it with Git LFS
+type LargeFile struct {
+	Path string // Path of the file in the pushed commits
+	Hash string // Blob hash
+	Size int64  // Size in bytes
+}
+
+// Push policy rules, as named in violations
+const (
+	PushPolicyRuleMaxFileSize    = "max_file_size"
+	PushPolicyRuleBlockedPath    = "blocked_path"
+	PushPolicyRuleCommitMessage  = "commit_message_pattern"
+	PushPolicyRuleNonFastForward = "deny_non_fast_forward"
+)
+
+// PushPolicyViolation is a pushed commit breaking a rule of a push policy
+type PushPolicyViolation struct {
+	RefName string
+	Commit  string
+	Rule    string // One of the PushPolicyRule constants
+	Path    string // File breaking the rule, for file rules
+	Message string // Human readable explanation, shown to the pusher
+}
+
+// StaleFile is a lock or temporary file left in a repository by a git
+// operation that was killed midway
+type StaleFile struct {
+	Path    string    // Path relative to the repository
+	Size    int64     // Size in bytes, of all files within for a directory
+	ModTime time.Time // Last modification, of any file within for a directory
+}
+
+// ObjectCounts describes how the objects of a repository are stored, as
+// reported by git count-objects
+type ObjectCounts struct {
+	LooseObjects  int64 // Objects stored one file each
+	LooseSize     int64 // Disk space of the loose objects in bytes
+	PackedObjects int64 // Objects stored in packs
+	Packs         int64 // Number of packs
+	PackSize      int64 // Disk space of the packs in bytes
+	Garbage       int64 // Files in the object directory that are neither objects nor packs
+}
+
+// GCOptions tunes a garbage collection of a repository
+type GCOptions struct {
+	// Auto only collects if the repository has more loose objects or packs
+	// than the limits below (git gc --auto)
+	Auto             bool
+	LooseObjectLimit int // gc.auto, git's default without it
+	PackLimit        int // gc.autoPackLimit, git's default without it
+}
+
+// RefAdvertiser produces the ref advertisement git-upload-pack sends fetching
+// clients in protocol v0, without the smart HTTP service header. A snapshot
+// of it is served while a repository is being collected.
+type RefAdvertiser interface {
+	AdvertiseRefs(ctx context.Context, repoPath string, settings models.UploadPackSettings) ([]byte, error)
+}
+
+// RefCommandCheck inspects the ref updates of a push before they are applied.
+// Any returned rejection refuses the whole push.
+type RefCommandCheck func(commands []RefCommand) []RefRejection
+
+// CombineRefChecks returns a check running every non-nil check and returning
+// all their rejections, or nil if there is nothing to check
+func CombineRefChecks(checks ...RefCommandCheck) RefCommandCheck {
+	var active []RefCommandCheck
+	for _, check := range checks {
+		if check != nil {
+			active = append(active, check)
+		}
+	}
+
+	switch len(active) {
+	case 0:
+		return nil
+	case 1:
+		return active[0]
+	}
+
+	return func(commands []RefCommand) []RefRejection {
+		var rejections []RefRejection
+		for _, check := range active {
+			rejections = append(rejections, check(commands)...)
+		}
+		return rejections
+	}
+}
+
+// isZeroHash returns true for the all-zero SHA-1 or SHA-256 object name
+func isZeroHash(hash string) bool {
+	return hash != "" && strings.Trim(hash, "0") == ""
+}
+
+// DialControl vets the address of each connection opened to a remote
+// repository, after name resolution and on every redirect, like
+// net.Dialer.Control. An error refuses the connection.
+type DialControl func(network, address string, c syscall.RawConn) error
+
 // GitService defines the interface for Git repository operations
 type GitService interface {
 	// Repository operations
 	// InitRepository initializes a new Git repository at the specified path
+	// with HEAD on initialBranch (git's default if empty)
 	// If bare is true, creates a bare repository (no working directory)
-	InitRepository(ctx context.Context, repoPath string, bare bool) error
+	InitRepository(ctx context.Context, repoPath string, bare bool, initialBranch string) error
 
 	// CloneRepository clones a repository from source to destination
 	// username and password are optional and used for authentication
 	// If mirror is true, creates a mirror clone (bare repository with all refs)
-	CloneRepository(ctx context.Context, source, dest, username, password string, mirror bool) error
+	// control, if not nil, vets every address an http(s) clone connects to
+	CloneRepository(ctx context.Context, source, dest, username, password string, mirror bool, control DialControl) error
 
 	// ConfigureMirror configures a repository as a mirror of the source
 	// This sets up the repository to fetch all refs from the source
@@ -113,7 +481,8 @@ type GitService interface {
 
 	// FetchMirror fetches updates from the mirror source repository
 	// This performs a mirror fetch to sync all refs from the source
-	FetchMirror(ctx context.Context, repoPath, sourceURL string) error
+	// control, if not nil, vets every address an http(s) fetch connects to
+	FetchMirror(ctx context.Context, repoPath, sourceURL string, control DialControl) error
 
 	// PushMirror pushes updates to a downstream mirror repository
 	// This performs a mirror push to sync all refs to the destination
@@ -132,6 +501,10 @@ type GitService interface {
 	// GetHEADRef returns the current HEAD reference
 	GetHEADRef(ctx context.Context, repoPath string) (string, error)
 
+	// IsEmpty returns true if the repository has no branches or tags yet,
+	// e.g. before its first push
+	IsEmpty(ctx context.Context, repoPath string) (bool, error)
+
 	// Branch operations
 	// CreateBranch creates a new branch pointing to the specified commit
 	CreateBranch(ctx context.Context, repoPath, branchName, commitHash string) error
@@ -190,14 +563,25 @@ type GitService interface {
 	// BranchExists checks if a branch exists in the repository
 	BranchExists(ctx context.Context, repoPath, branchName string) (bool, error)
 
+	// SetConfig sets section.key to value in the git config of the repository
+	SetConfig(ctx context.Context, repoPath, section, key, value string) error
+
 	// Commit operations
 	// GetCommits returns a list of commits for a given ref (branch/tag/commit hash)
 	// If ref is empty, uses the default branch
 	GetCommits(ctx context.Context, repoPath, ref string, limit, offset int) ([]Commit, error)
 
+	// CountCommits returns the number of commits reachable from a ref
+	// If ref is empty, uses the default branch
+	CountCommits(ctx context.Context, repoPath, ref string) (int, error)
+
 	// GetCommit returns a single commit by hash
 	GetCommit(ctx context.Context, repoPath, commitHash string) (*Commit, error)
 
+	// VerifyCommitSignatures reads the signature of each commit from its raw
+	// object and checks it against the keys of the check, in order
+	VerifyCommitSignatures(ctx context.Context, repoPath string, checks []SignatureCheck) ([]SignatureVerification, error)
+
 	// Tree operations
 	// GetTree returns the tree entries for a given ref and path
 	// If path is empty, returns the root tree
@@ -207,6 +591,28 @@ type GitService interface {
 	// GetFileContent returns the content of a file at a given ref and path
 	GetFileContent(ctx context.Context, repoPath, ref, filePath string) (*FileContent, error)
 
+	// OpenFile opens the content of a file at a given ref and path for
+	// streaming. The caller must close FileBlob.Content.
+	OpenFile(ctx context.Context, repoPath, ref, filePath string) (*FileBlob, error)
+
+	// GetContributorStats returns the commits, additions and deletions per
+	// author of the non-merge commits reachable from ref, authored within
+	// since and until if set (zero for no bound), with the weekly commit
+	// counts of the last 52 weeks. If ref is empty, uses the default branch.
+	GetContributorStats(ctx context.Context, repoPath, ref string, since, until time.Time) (*ContributorActivity, error)
+
+	// GetLanguageBytes sums the size of the files of each language in the
+	// tree at ref, leaving out vendored directories and binary files. If ref
+	// is empty, uses the default branch.
+	GetLanguageBytes(ctx context.Context, repoPath, ref string) (*LanguageBreakdown, error)
+
+	// CheckPushPolicy evaluates policy against a push of head to refName
+	// over base, as if base were the current tip of the ref: the commits
+	// reachable from head but not from base are inspected. If base is empty,
+	// the ref is treated as new and every commit reachable from head is.
+	// At most limit violations are returned.
+	CheckPushPolicy(ctx context.Context, repoPath string, policy *models.PushPolicy, refName, base, head string, limit int) ([]PushPolicyViolation, error)
+
 	// Blame operations
 	// GetBlame returns blame information for a file at a given ref
 	GetBlame(ctx context.Context, repoPath, ref, filePath string) ([]BlameLine, error)
@@ -217,4 +623,70 @@ type GitService interface {
 
 	// Compare diff between two commits
 	GetCompareDiff(ctx context.Context, repoPath, from, to string) (*DiffResult, error)
+
+	// GetForkCompareDiff returns the changes of head, a revision of the repository
+	// at forkPath, since its merge base with base, a revision of repoPath.
+	// It returns an error wrapping errors.ErrNoMergeBase if they share no history.
+	GetForkCompareDiff(ctx context.Context, repoPath, base, forkPath, head string) (*DiffResult, error)
+
+	// CompareRefs compares head with base, both revisions of the repository:
+	// the commits of head missing from base and the changes of head since their
+	// merge base. It returns a not found error if either revision does not
+	// resolve and an error wrapping errors.ErrNoMergeBase if they share no history.
+	CompareRefs(ctx context.Context, repoPath, base, head string) (*Comparison, error)
+
+	// GetChangedPaths returns the paths of the files changed between two commits
+	GetChangedPaths(ctx context.Context, repoPath, from, to string) ([]string, error)
+
+	// Merge operations
+	// CreateMergeCommit creates a commit merging head into base, both commit
+	// hashes, without updating any ref. It returns a conflict error wrapping
+	// errors.ErrMergeConflict with the conflicting paths in its "conflicts"
+	// detail, errors.ErrNothingToMerge if head is already part of base, or
+	// errors.ErrNoMergeBase if they share no history. With a commit signer,
+	// the commit is signed and committed as its identity, and a failure to sign
+	// is an error wrapping errors.ErrCommitSigning.
+	CreateMergeCommit(ctx context.Context, repoPath, base, head, message string, author Signature) (string, error)
+
+	// UpdateRef points refName at newHash if it still points at oldHash.
+	// It returns an error wrapping errors.ErrRefChanged otherwise.
+	UpdateRef(ctx context.Context, repoPath, refName, newHash, oldHash string) error
+
+	// CreateInitialCommit commits files, keyed by their path, as the root
+	// commit of a new branch and returns its hash. It returns a conflict error
+	// wrapping errors.ErrBranchExists if the branch already exists. It is
+	// signed like merge commits.
+	CreateInitialCommit(ctx context.Context, repoPath, branch string, files map[string][]byte, message string, author Signature) (string, error)
+
+	// CommitDirectory commits the files under dir, with their modes, as the
+	// root commit of a new branch and returns its hash, like
+	// CreateInitialCommit. Ignore rules found in dir do not apply.
+	CommitDirectory(ctx context.Context, repoPath, branch, dir, message string, author Signature) (string, error)
+
+	// CreateBundle writes a git bundle of all the refs of the repository to w.
+	// The repository must have at least one ref.
+	CreateBundle(ctx context.Context, repoPath string, w io.Writer) error
+
+	// RestoreBundle creates a bare repository at repoPath holding the refs and
+	// objects of the git bundle at bundlePath, a local file. HEAD is left to
+	// the caller.
+	RestoreBundle(ctx context.Context, repoPath, bundlePath string) error
+
+	// Maintenance operations
+	// RemoveStaleFiles removes the lock files and temporary packs and object
+	// directories of the repository last modified before olderThan, which
+	// block later operations if left by a killed git process, and returns them
+	RemoveStaleFiles(ctx context.Context, repoPath string, olderThan time.Time) ([]StaleFile, error)
+
+	// CountObjects returns how the objects of the repository are stored
+	CountObjects(ctx context.Context, repoPath string) (*ObjectCounts, error)
+
+	// GarbageCollect runs git gc on the repository: packs loose objects,
+	// merges packs and prunes unreachable objects past git's grace period
+	GarbageCollect(ctx context.Context, repoPath string, opts GCOptions) error
+
+	// Repack packs all objects of the repository into a single pack, keeping
+	// unreachable ones, and removes the packs and loose objects it made
+	// redundant
+	Repack(ctx context.Context, repoPath string) error
 }
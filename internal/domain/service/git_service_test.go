@@ -0,0 +1,35 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestIsBinaryContent(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	tests := []struct {
+		name    string
+		content []byte
+		partial bool
+		want    bool
+	}{
+		{name: "empty", content: nil},
+		{name: "text", content: []byte("package main\n")},
+		{name: "utf-8 text", content: []byte("naïve café — 日本語\n")},
+		{name: "png", content: png, want: true},
+		{name: "nul byte", content: []byte("text\x00more text"), want: true},
+		{name: "nul byte past the sniffed start", content: append(bytes.Repeat([]byte("a"), 8000), 0), want: false},
+		{name: "latin-1 text", content: []byte("caf\xe9\n"), want: true},
+		{name: "truncated character", content: []byte("日本")[:5], want: true},
+		{name: "start of a file cut in a character", content: []byte("日本")[:5], partial: true},
+		{name: "start of a file cut in a 4-byte character", content: []byte("a😀")[:4], partial: true},
+		{name: "start of a file with invalid utf-8", content: []byte("caf\xe9 au lait"), partial: true, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBinaryContent(tt.content, tt.partial); got != tt.want {
+				t.Errorf("IsBinaryContent(%q, %v) = %v, want %v", tt.content, tt.partial, got, tt.want)
+			}
+		})
+	}
+}
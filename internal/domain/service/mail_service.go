@@ -0,0 +1,19 @@
+package service
+
+// Email is an outgoing email with a plain text and an HTML body
+type Email struct {
+	To       string // Address of the recipient
+	Subject  string
+	Text     string
+	HTML     string
+	Category string // Notification category, kept in logs and dead letters
+}
+
+// Mailer sends emails in the background.
+// This abstraction allows for different transports (SMTP, files for tests).
+type Mailer interface {
+	// Enqueue queues an email and returns without waiting for it to be sent.
+	// It returns false if the email was not queued, e.g. because the queue
+	// is full; such emails are dead-lettered.
+	Enqueue(email Email) bool
+}
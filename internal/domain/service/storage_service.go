@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"io"
 	"io/fs"
 	"path/filepath"
@@ -73,6 +74,10 @@ type StorageService interface {
 	// Walk walks the file tree rooted at root, calling fn for each file or directory
 	Walk(root string, fn filepath.WalkFunc) error
 
+	// ImportDirectory copies a local directory tree, such as a repository on
+	// another backend, into the storage at path
+	ImportDirectory(localPath, path string) error
+
 	// Symlink operations (useful for git)
 
 	// CreateSymlink creates a symbolic link
@@ -99,3 +104,23 @@ type StorageService interface {
 	// For S3 storage, this uploads local files to S3
 	SyncToRemote(localPath string) error
 }
+
+// StorageBackends holds the named storage backends repositories live on and
+// decides which backend new repositories are created on
+type StorageBackends interface {
+	// Backend returns the backend with the given name
+	Backend(name string) (StorageService, bool)
+
+	// Names returns the names of all backends, sorted
+	Names() []string
+
+	// Type returns the storage type of a backend (filesystem, s3)
+	Type(name string) string
+
+	// Place returns the backend a repository of owner with a size in bytes
+	// should live on according to the placement rules
+	Place(owner string, size int64) string
+
+	// Check verifies every backend is reachable and writable
+	Check(ctx context.Context) error
+}
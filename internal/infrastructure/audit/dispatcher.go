@@ -0,0 +1,350 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+	domainservice "github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// queueSize is the number of events buffered in memory per sink before
+// overflowing to the spool
+const queueSize = 1024
+
+// sink delivers a batch of audit events to an external system
+type sink interface {
+	Send(ctx context.Context, events []Event) error
+	Close() error
+}
+
+// SinkHealth reports the delivery state of a sink
+type SinkHealth struct {
+	Name        string
+	Type        string
+	Backlog     int // Events queued in memory plus spooled on disk
+	Spooled     int
+	LastSuccess *time.Time
+	LastError   string
+	LastErrorAt *time.Time
+}
+
+// Dispatcher fans audit events out to the configured sinks.
+// A nil Dispatcher or one without sinks accepts and discards events.
+type Dispatcher struct {
+	workers []*sinkWorker
+	log     *logger.Logger
+}
+
+// NewDispatcher creates sink workers for every configured sink.
+// It returns nil when no sinks are configured.
+func NewDispatcher(cfg *config.AuditConfig) (*Dispatcher, error) {
+	if !cfg.IsEnabled() {
+		return nil, nil
+	}
+
+	d := &Dispatcher{
+		log: logger.Get().WithFields(logger.Component("audit")),
+	}
+
+	for i := range cfg.Sinks {
+		sinkCfg := &cfg.Sinks[i]
+
+		var s sink
+		switch sinkCfg.Type {
+		case config.AuditSinkSyslog:
+			syslog, err := newSyslogSink(sinkCfg)
+			if err != nil {
+				return nil, fmt.Errorf("audit sink %q: %w", sinkCfg.Name, err)
+			}
+			s = syslog
+		case config.AuditSinkHTTP:
+			s = newHTTPSink(sinkCfg)
+		default:
+			return nil, fmt.Errorf("audit sink %q: invalid type %q", sinkCfg.Name, sinkCfg.Type)
+		}
+
+		sp, err := newSpool(cfg.GetSpoolDir(), sinkCfg.Name)
+		if err != nil {
+			return nil, fmt.Errorf("audit sink %q: %w", sinkCfg.Name, err)
+		}
+
+		d.workers = append(d.workers, &sinkWorker{
+			cfg:   sinkCfg,
+			sink:  s,
+			spool: sp,
+			queue: make(chan Event, queueSize),
+			stop:  make(chan struct{}),
+			log:   d.log.WithFields(logger.String("sink", sinkCfg.Name)),
+		})
+	}
+
+	return d, nil
+}
+
+// Start starts delivery for all sinks
+func (d *Dispatcher) Start() {
+	if d == nil {
+		return
+	}
+	for _, w := range d.workers {
+		w.wg.Add(1)
+		go w.run()
+	}
+	d.log.Info("Audit export started", logger.Int("sinks", len(d.workers)))
+}
+
+// Stop flushes pending events (spooling what cannot be delivered) and stops all sinks
+func (d *Dispatcher) Stop() {
+	if d == nil {
+		return
+	}
+	for _, w := range d.workers {
+		close(w.stop)
+	}
+	for _, w := range d.workers {
+		w.wg.Wait()
+		if err := w.sink.Close(); err != nil {
+			w.log.Warn("Failed to close audit sink", logger.Error(err))
+		}
+	}
+	d.log.Info("Audit export stopped")
+}
+
+// Publish queues an event for every sink accepting its category. Events are
+// redacted per sink before they are queued or spooled. Publish never blocks on
+// a sink: when a queue is full the event is spooled to disk instead.
+func (d *Dispatcher) Publish(e Event) {
+	if d == nil || len(d.workers) == 0 {
+		return
+	}
+
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now().UTC()
+	}
+
+	for _, w := range d.workers {
+		if !w.cfg.AcceptsCategory(e.Category) {
+			continue
+		}
+
+		redacted := e.redacted(w.cfg.RedactFields)
+		select {
+		case w.queue <- redacted:
+		default:
+			if err := w.spool.Append([]Event{redacted}); err != nil {
+				w.log.Error("Audit queue full and spool write failed, event lost",
+					logger.Error(err),
+					logger.String("event_id", e.ID.String()),
+				)
+			}
+		}
+	}
+}
+
+// Health returns the delivery state of every sink
+func (d *Dispatcher) Health() []SinkHealth {
+	if d == nil {
+		return []SinkHealth{}
+	}
+
+	health := make([]SinkHealth, 0, len(d.workers))
+	for _, w := range d.workers {
+		health = append(health, w.health())
+	}
+	return health
+}
+
+// sinkWorker batches and delivers events to a single sink
+type sinkWorker struct {
+	cfg   *config.AuditSinkConfig
+	sink  sink
+	spool *spool
+	queue chan Event
+	stop  chan struct{}
+	wg    sync.WaitGroup
+	log   *logger.Logger
+
+	mu          sync.Mutex
+	pending     int
+	lastSuccess time.Time
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// run collects events into batches and delivers them until stopped
+func (w *sinkWorker) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.cfg.FlushInterval())
+	defer ticker.Stop()
+
+	batchSize := w.cfg.GetBatchSize()
+	batch := make([]Event, 0, batchSize)
+
+	for {
+		select {
+		case e := <-w.queue:
+			batch = append(batch, e)
+			w.setPending(len(batch))
+			if len(batch) >= batchSize {
+				w.flush(batch)
+				batch = batch[:0]
+				w.setPending(0)
+			}
+		case <-ticker.C:
+			w.flush(batch)
+			batch = batch[:0]
+			w.setPending(0)
+		case <-w.stop:
+		drain:
+			for {
+				select {
+				case e := <-w.queue:
+					batch = append(batch, e)
+				default:
+					break drain
+				}
+			}
+			w.flush(batch)
+			w.setPending(0)
+			return
+		}
+	}
+}
+
+// flush replays the spool, then delivers the batch. Anything that cannot be
+// delivered is spooled so it is retried on the next flush.
+func (w *sinkWorker) flush(batch []Event) {
+	if w.spool.Len() > 0 && !w.replay() {
+		w.spoolEvents(batch)
+		return
+	}
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := w.send(batch); err != nil {
+		w.spoolEvents(batch)
+	}
+}
+
+// replay delivers spooled events in order and reports whether the spool was drained
+func (w *sinkWorker) replay() bool {
+	events, err := w.spool.Load()
+	if err != nil {
+		w.log.Error("Failed to load audit spool", logger.Error(err))
+		return false
+	}
+
+	batchSize := w.cfg.GetBatchSize()
+	delivered := 0
+	for delivered < len(events) {
+		end := min(delivered+batchSize, len(events))
+		if err := w.send(events[delivered:end]); err != nil {
+			break
+		}
+		delivered = end
+	}
+
+	if delivered > 0 {
+		if err := w.spool.Drop(delivered); err != nil {
+			w.log.Error("Failed to trim audit spool", logger.Error(err))
+			return false
+		}
+		w.log.Info("Replayed spooled audit events", logger.Int("count", delivered))
+	}
+
+	return w.spool.Len() == 0
+}
+
+// send delivers a batch and records the outcome for health reporting
+func (w *sinkWorker) send(events []Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), w.cfg.Timeout())
+	defer cancel()
+
+	err := w.sink.Send(ctx, events)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		w.lastError = err.Error()
+		w.lastErrorAt = time.Now().UTC()
+		w.log.Warn("Failed to deliver audit events",
+			logger.Error(err),
+			logger.Int("count", len(events)),
+		)
+		return err
+	}
+	w.lastSuccess = time.Now().UTC()
+	return nil
+}
+
+// spoolEvents persists undelivered events
+func (w *sinkWorker) spoolEvents(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	if err := w.spool.Append(events); err != nil {
+		w.log.Error("Failed to spool audit events, events lost",
+			logger.Error(err),
+			logger.Int("count", len(events)),
+		)
+	}
+}
+
+// setPending records the number of events batched but not yet delivered
+func (w *sinkWorker) setPending(n int) {
+	w.mu.Lock()
+	w.pending = n
+	w.mu.Unlock()
+}
+
+// health returns the current delivery state of the sink
+func (w *sinkWorker) health() SinkHealth {
+	spooled := w.spool.Len()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	h := SinkHealth{
+		Name:      w.cfg.Name,
+		Type:      w.cfg.Type,
+		Backlog:   len(w.queue) + w.pending + spooled,
+		Spooled:   spooled,
+		LastError: w.lastError,
+	}
+	if !w.lastSuccess.IsZero() {
+		t := w.lastSuccess
+		h.LastSuccess = &t
+	}
+	if !w.lastErrorAt.IsZero() {
+		t := w.lastErrorAt
+		h.LastErrorAt = &t
+	}
+	return h
+}
+
+// Record publishes an audit entry from the application layer
+func (d *Dispatcher) Record(entry domainservice.AuditEntry) {
+	d.Publish(Event{
+		Category:  entry.Category,
+		Action:    entry.Action,
+		ActorID:   entry.ActorID,
+		Actor:     entry.Actor,
+		Resource:  entry.Resource,
+		Outcome:   entry.Outcome,
+		Fields:    entry.Fields,
+		Sensitive: entry.Sensitive,
+	})
+}
+
+var _ domainservice.AuditRecorder = (*Dispatcher)(nil)
@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// redactedValue replaces the value of redacted fields
+const redactedValue = "[REDACTED]"
+
+// Event is a single audit event exported to sinks
+type Event struct {
+	ID        uuid.UUID         `json:"id"`
+	Timestamp time.Time         `json:"timestamp"`
+	Category  string            `json:"category"` // e.g., auth, repository, admin
+	Action    string            `json:"action"`   // e.g., repository.delete
+	ActorID   string            `json:"actor_id,omitempty"`
+	Actor     string            `json:"actor,omitempty"`
+	Resource  string            `json:"resource,omitempty"`
+	Outcome   string            `json:"outcome,omitempty"` // success, failure
+	Fields    map[string]string `json:"fields,omitempty"`
+
+	// Sensitive lists keys of Fields that must be redacted before export
+	Sensitive []string `json:"-"`
+}
+
+// redacted returns a copy of the event with sensitive and extra fields redacted
+func (e Event) redacted(extra []string) Event {
+	if len(e.Fields) == 0 || (len(e.Sensitive) == 0 && len(extra) == 0) {
+		return e
+	}
+
+	fields := make(map[string]string, len(e.Fields))
+	for k, v := range e.Fields {
+		if slices.Contains(e.Sensitive, k) || slices.Contains(extra, k) {
+			v = redactedValue
+		}
+		fields[k] = v
+	}
+	e.Fields = fields
+	e.Sensitive = nil
+	return e
+}
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/bravo68web/stasis/internal/config"
+)
+
+// httpSink delivers batches of events as a JSON array in a single POST
+type httpSink struct {
+	cfg    *config.AuditSinkConfig
+	client *http.Client
+}
+
+// newHTTPSink creates an HTTP sink from configuration
+func newHTTPSink(cfg *config.AuditSinkConfig) *httpSink {
+	return &httpSink{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout()},
+	}
+}
+
+// Send posts a batch of events; any non-2xx response is a failure
+func (s *httpSink) Send(ctx context.Context, events []Event) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit events: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create audit request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post audit events: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("audit sink returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close releases idle connections
+func (s *httpSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// spool persists undelivered events for a sink as JSON lines on disk
+type spool struct {
+	path  string
+	mu    sync.Mutex
+	count int
+}
+
+// newSpool opens the spool file for a sink, counting events left from a previous run
+func newSpool(dir, name string) (*spool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit spool directory: %w", err)
+	}
+
+	s := &spool{path: filepath.Join(dir, name+".jsonl")}
+	events, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	s.count = len(events)
+	return s, nil
+}
+
+// Len returns the number of spooled events
+func (s *spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+// Append persists events at the end of the spool
+func (s *spool) Append(events []Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit spool: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("failed to write audit spool: %w", err)
+		}
+		s.count++
+	}
+	return f.Sync()
+}
+
+// Load returns all spooled events in order
+func (s *spool) Load() ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+// Drop atomically removes the first n events, keeping anything appended since they were loaded
+func (s *spool) Drop(n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events, err := s.read()
+	if err != nil {
+		return err
+	}
+	remaining := events[min(n, len(events)):]
+
+	if len(remaining) == 0 {
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to clear audit spool: %w", err)
+		}
+		s.count = 0
+		return nil
+	}
+
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to rewrite audit spool: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	for _, e := range remaining {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to rewrite audit spool: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to rewrite audit spool: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to rewrite audit spool: %w", err)
+	}
+	s.count = len(remaining)
+	return nil
+}
+
+// read decodes the spool file; callers must hold the lock
+func (s *spool) read() ([]Event, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit spool: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// Skip a torn trailing write rather than blocking the whole spool
+			continue
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit spool: %w", err)
+	}
+	return events, nil
+}
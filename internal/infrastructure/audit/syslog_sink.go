@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/config"
+)
+
+// syslogPriority is facility 13 (log audit) at severity 6 (informational)
+const syslogPriority = 13*8 + 6
+
+// syslogSink delivers events as RFC5424 messages over TCP, optionally TLS,
+// using octet-counting framing (RFC6587)
+type syslogSink struct {
+	cfg       *config.AuditSinkConfig
+	tlsConfig *tls.Config
+	hostname  string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// newSyslogSink creates a syslog sink from configuration
+func newSyslogSink(cfg *config.AuditSinkConfig) (*syslogSink, error) {
+	s := &syslogSink{cfg: cfg}
+
+	if cfg.TLS {
+		host, _, err := net.SplitHostPort(cfg.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid syslog address %q: %w", cfg.Address, err)
+		}
+		s.tlsConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+
+		if cfg.CAFile != "" {
+			pem, err := os.ReadFile(cfg.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read syslog CA file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("syslog CA file %s contains no valid PEM certificates", cfg.CAFile)
+			}
+			s.tlsConfig.RootCAs = pool
+		}
+	}
+
+	s.hostname, _ = os.Hostname()
+	if s.hostname == "" {
+		s.hostname = "-"
+	}
+
+	return s, nil
+}
+
+// Send writes events to the syslog server, reconnecting if needed
+func (s *syslogSink) Send(ctx context.Context, events []Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.conn.SetWriteDeadline(deadline)
+	}
+
+	for _, e := range events {
+		msg, err := s.format(e)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(s.conn, "%d %s", len(msg), msg); err != nil {
+			s.conn.Close()
+			s.conn = nil
+			return fmt.Errorf("failed to write to syslog: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Close closes the syslog connection
+func (s *syslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// dial opens a TCP or TLS connection to the syslog server
+func (s *syslogSink) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: s.cfg.Timeout(), KeepAlive: 30 * time.Second}
+
+	if s.tlsConfig != nil {
+		conn, err := (&tls.Dialer{NetDialer: dialer, Config: s.tlsConfig}).DialContext(ctx, "tcp", s.cfg.Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to syslog over TLS: %w", err)
+		}
+		return conn, nil
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", s.cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return conn, nil
+}
+
+// format renders an event as an RFC5424 message with the JSON event as MSG
+func (s *syslogSink) format(e Event) (string, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit event: %w", err)
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s [stasis@32473 eventId=\"%s\"] %s",
+		syslogPriority,
+		e.Timestamp.UTC().Format(time.RFC3339Nano),
+		s.hostname,
+		syslogToken(s.cfg.GetAppName()),
+		os.Getpid(),
+		syslogToken(e.Category),
+		e.ID.String(),
+		body,
+	), nil
+}
+
+// syslogToken makes a header field safe for RFC5424 (printable ASCII, no spaces)
+func syslogToken(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= 32 || r > 126 {
+			return '_'
+		}
+		return r
+	}, v)
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"io/fs"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// userReference matches a foreign key to users in a migration, with the name
+// of its constraint and its ON DELETE action
+var userReference = regexp.MustCompile(`CONSTRAINT "(\w+)" FOREIGN KEY \("\w+"\) REFERENCES "users" \("id"\)(?: ON UPDATE [A-Z ]+?)? ON DELETE (CASCADE|SET NULL|SET DEFAULT|RESTRICT|NO ACTION)`)
+
+// restrictingUserReferences are the foreign keys to users that block the
+// deletion of a user, which deletes their rows in its own transaction
+var restrictingUserReferences = []string{"fk_repositories_owner", "fk_ssh_keys_user"}
+
+// The migrations follow the hard-delete policy of the models: no table has a
+// deleted_at column, and rows referencing a user either go with them or
+// outlive them, unless the user deletion removes them itself
+func TestMigrationsHardDelete(t *testing.T) {
+	files, err := fs.Glob(migrationsFS, "migrations/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no migration found")
+	}
+	slices.Sort(files)
+
+	// The action of each constraint, as left by the latest migration
+	// defining it
+	onDelete := map[string]string{}
+	for _, file := range files {
+		data, err := fs.ReadFile(migrationsFS, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sql := string(data)
+		if strings.Contains(strings.ToLower(sql), "deleted_at") {
+			t.Errorf("%s: adds a deleted_at column", file)
+		}
+		for _, m := range userReference.FindAllStringSubmatch(sql, -1) {
+			onDelete[m[1]] = m[2]
+		}
+	}
+
+	if len(onDelete) == 0 {
+		t.Fatal("no foreign key to users found")
+	}
+	for constraint, action := range onDelete {
+		if action != "CASCADE" && action != "SET NULL" && !slices.Contains(restrictingUserReferences, constraint) {
+			t.Errorf("%s is ON DELETE %s, want CASCADE or SET NULL so users can be deleted", constraint, action)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+-- Create "repo_annotations" table
+CREATE TABLE "repo_annotations" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "repository_id" uuid NOT NULL,
+  "key" character varying(64) NOT NULL,
+  "value" text NOT NULL,
+  "author_id" uuid NOT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_repo_annotations_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "fk_repo_annotations_author" FOREIGN KEY ("author_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION
+);
+-- Create index "idx_repo_annotations_repo_key" to table: "repo_annotations"
+CREATE UNIQUE INDEX "idx_repo_annotations_repo_key" ON "repo_annotations" ("repository_id", "key");
+-- Create index "idx_repo_annotations_key_value" to table: "repo_annotations"
+CREATE INDEX "idx_repo_annotations_key_value" ON "repo_annotations" ("key", "value");
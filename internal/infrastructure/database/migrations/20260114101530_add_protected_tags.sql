@@ -0,0 +1,2 @@
+-- Modify "repositories" table
+ALTER TABLE "repositories" ADD COLUMN "protected_tag_patterns" jsonb NULL, ADD COLUMN "protected_tag_overrides" jsonb NULL;
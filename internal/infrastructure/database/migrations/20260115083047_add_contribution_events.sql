@@ -0,0 +1,21 @@
+-- Modify "repositories" table
+ALTER TABLE "repositories" ADD COLUMN "contributions_indexed_at" timestamptz NULL;
+-- Modify "users" table
+ALTER TABLE "users" ADD COLUMN "show_private_contributions" boolean NULL DEFAULT false;
+-- Create "contribution_events" table
+CREATE TABLE "contribution_events" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "user_id" uuid NOT NULL,
+  "repository_id" uuid NOT NULL,
+  "kind" character varying(32) NOT NULL,
+  "ref" character varying(64) NOT NULL,
+  "occurred_at" timestamptz NOT NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_contribution_events_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "fk_contribution_events_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_contribution_events_repo_kind_ref" to table: "contribution_events"
+CREATE UNIQUE INDEX "idx_contribution_events_repo_kind_ref" ON "contribution_events" ("repository_id", "kind", "ref");
+-- Create index "idx_contribution_events_user_time" to table: "contribution_events"
+CREATE INDEX "idx_contribution_events_user_time" ON "contribution_events" ("user_id", "occurred_at");
@@ -0,0 +1,28 @@
+-- Create "ci_pipeline_jobs" table
+CREATE TABLE "ci_pipeline_jobs" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "run_id" uuid NOT NULL,
+  "repository_id" uuid NOT NULL,
+  "stage" character varying(100) NOT NULL,
+  "stage_index" bigint NOT NULL,
+  "name" character varying(100) NOT NULL,
+  "needs" jsonb NULL,
+  "status" character varying(20) NOT NULL,
+  "error" text NULL,
+  "commit_sha" character varying(64) NOT NULL,
+  "ref_name" character varying(255) NOT NULL,
+  "ref_type" character varying(20) NOT NULL,
+  "trigger_type" character varying(20) NOT NULL,
+  "trigger_actor" character varying(255) NOT NULL,
+  "metadata" jsonb NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_ci_pipeline_jobs_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_ci_pipeline_jobs_repository_id" to table: "ci_pipeline_jobs"
+CREATE INDEX "idx_ci_pipeline_jobs_repository_id" ON "ci_pipeline_jobs" ("repository_id");
+-- Create index "idx_ci_pipeline_jobs_run_id" to table: "ci_pipeline_jobs"
+CREATE INDEX "idx_ci_pipeline_jobs_run_id" ON "ci_pipeline_jobs" ("run_id");
+-- Create index "idx_ci_pipeline_jobs_status" to table: "ci_pipeline_jobs"
+CREATE INDEX "idx_ci_pipeline_jobs_status" ON "ci_pipeline_jobs" ("status");
@@ -0,0 +1,18 @@
+-- Modify "repositories" table
+ALTER TABLE "repositories" ADD COLUMN "author_mappings_version" bigint NOT NULL DEFAULT 0;
+-- Create "author_mappings" table
+CREATE TABLE "author_mappings" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "repository_id" uuid NOT NULL,
+  "email" character varying(255) NOT NULL,
+  "user_id" uuid NOT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_author_mappings_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "fk_author_mappings_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_author_mappings_repo_email" to table: "author_mappings"
+CREATE UNIQUE INDEX "idx_author_mappings_repo_email" ON "author_mappings" ("repository_id", "email");
+-- Create index "idx_author_mappings_user_id" to table: "author_mappings"
+CREATE INDEX "idx_author_mappings_user_id" ON "author_mappings" ("user_id");
@@ -0,0 +1,16 @@
+-- Create "repository_collaborators" table
+CREATE TABLE "repository_collaborators" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "repository_id" uuid NOT NULL,
+  "user_id" uuid NOT NULL,
+  "permission" character varying(16) NOT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_repository_collaborators_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "fk_repository_collaborators_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_repository_collaborators_repo_user" to table: "repository_collaborators"
+CREATE UNIQUE INDEX "idx_repository_collaborators_repo_user" ON "repository_collaborators" ("repository_id", "user_id");
+-- Create index "idx_repository_collaborators_user_id" to table: "repository_collaborators"
+CREATE INDEX "idx_repository_collaborators_user_id" ON "repository_collaborators" ("user_id");
@@ -0,0 +1,4 @@
+-- Modify "repositories" table
+ALTER TABLE "repositories" ADD COLUMN "forked_from_id" uuid NULL, ADD CONSTRAINT "fk_repositories_forked_from" FOREIGN KEY ("forked_from_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE SET NULL;
+-- Create index "idx_repositories_forked_from_id" to table: "repositories"
+CREATE INDEX "idx_repositories_forked_from_id" ON "repositories" ("forked_from_id");
@@ -0,0 +1,4 @@
+-- Modify "repositories" table
+ALTER TABLE "repositories" ADD COLUMN "license" character varying(64) NULL, ADD COLUMN "detected_license" character varying(64) NULL, ADD COLUMN "license_override" character varying(64) NULL, ADD COLUMN "license_detected_at" timestamptz NULL;
+-- Create index "idx_repositories_license" to table: "repositories"
+CREATE INDEX "idx_repositories_license" ON "repositories" ("license");
@@ -0,0 +1,4 @@
+-- Modify "repositories" table
+ALTER TABLE "repositories" ADD COLUMN "storage_backend" character varying(64) NOT NULL DEFAULT 'default';
+-- Create index "idx_repositories_storage_backend" to table: "repositories"
+CREATE INDEX "idx_repositories_storage_backend" ON "repositories" ("storage_backend");
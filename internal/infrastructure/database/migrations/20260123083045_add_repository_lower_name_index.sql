@@ -0,0 +1,2 @@
+-- Create index "idx_repositories_lower_name" to table: "repositories"
+CREATE INDEX "idx_repositories_lower_name" ON "repositories" ((lower(name)));
@@ -0,0 +1,2 @@
+-- Modify "tokens" table
+ALTER TABLE "tokens" ADD COLUMN "last_used_ip" character varying(64) NULL, ADD COLUMN "last_user_agent" character varying(512) NULL, ADD COLUMN "usage_count" bigint NOT NULL DEFAULT 0, ADD COLUMN "known_origins" jsonb NULL;
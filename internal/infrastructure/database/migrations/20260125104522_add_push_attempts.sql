@@ -0,0 +1,24 @@
+-- Create "push_attempts" table
+CREATE TABLE "push_attempts" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "repository_id" uuid NOT NULL,
+  "pusher_id" uuid NULL,
+  "pusher" character varying(255) NOT NULL,
+  "transport" character varying(16) NOT NULL,
+  "outcome" character varying(16) NOT NULL,
+  "refs" jsonb NULL,
+  "error" text NULL,
+  "output" text NULL,
+  "output_truncated" boolean NULL DEFAULT false,
+  "started_at" timestamptz NOT NULL,
+  "finished_at" timestamptz NOT NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_push_attempts_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_push_attempts_created_at" to table: "push_attempts"
+CREATE INDEX "idx_push_attempts_created_at" ON "push_attempts" ("created_at");
+-- Create index "idx_push_attempts_outcome" to table: "push_attempts"
+CREATE INDEX "idx_push_attempts_outcome" ON "push_attempts" ("outcome");
+-- Create index "idx_push_attempts_repo_time" to table: "push_attempts"
+CREATE INDEX "idx_push_attempts_repo_time" ON "push_attempts" ("repository_id", "started_at");
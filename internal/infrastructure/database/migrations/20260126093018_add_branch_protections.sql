@@ -0,0 +1,15 @@
+-- Create "branch_protections" table
+CREATE TABLE "branch_protections" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "repository_id" uuid NOT NULL,
+  "pattern" character varying(255) NOT NULL,
+  "block_force_push" boolean NOT NULL,
+  "block_deletion" boolean NOT NULL,
+  "require_admin" boolean NOT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_branch_protections_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_branch_protections_repo_pattern" to table: "branch_protections"
+CREATE UNIQUE INDEX "idx_branch_protections_repo_pattern" ON "branch_protections" ("repository_id", "pattern");
@@ -0,0 +1,28 @@
+-- Create "pull_requests" table
+CREATE TABLE "pull_requests" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "repository_id" uuid NOT NULL,
+  "number" bigint NOT NULL,
+  "title" character varying(255) NOT NULL,
+  "body" text NULL,
+  "source_branch" character varying(255) NOT NULL,
+  "target_branch" character varying(255) NOT NULL,
+  "author_id" uuid NULL,
+  "state" character varying(16) NOT NULL,
+  "merged_commit_sha" character varying(64) NULL,
+  "merged_by_id" uuid NULL,
+  "merged_at" timestamptz NULL,
+  "closed_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_pull_requests_author" FOREIGN KEY ("author_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE SET NULL,
+  CONSTRAINT "fk_pull_requests_merged_by" FOREIGN KEY ("merged_by_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE SET NULL,
+  CONSTRAINT "fk_pull_requests_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_pull_requests_author_id" to table: "pull_requests"
+CREATE INDEX "idx_pull_requests_author_id" ON "pull_requests" ("author_id");
+-- Create index "idx_pull_requests_repo_number" to table: "pull_requests"
+CREATE UNIQUE INDEX "idx_pull_requests_repo_number" ON "pull_requests" ("repository_id", "number");
+-- Create index "idx_pull_requests_state" to table: "pull_requests"
+CREATE INDEX "idx_pull_requests_state" ON "pull_requests" ("state");
@@ -0,0 +1,22 @@
+-- Create "user_exports" table
+CREATE TABLE "user_exports" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "user_id" uuid NOT NULL,
+  "requested_by_id" uuid NULL,
+  "status" character varying(16) NOT NULL,
+  "include_repositories" boolean NOT NULL,
+  "storage_path" character varying(512) NULL,
+  "size" bigint NULL,
+  "error" text NULL,
+  "created_at" timestamptz NULL,
+  "completed_at" timestamptz NULL,
+  "expires_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_user_exports_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_user_exports_expires_at" to table: "user_exports"
+CREATE INDEX "idx_user_exports_expires_at" ON "user_exports" ("expires_at");
+-- Create index "idx_user_exports_status" to table: "user_exports"
+CREATE INDEX "idx_user_exports_status" ON "user_exports" ("status");
+-- Create index "idx_user_exports_user_time" to table: "user_exports"
+CREATE INDEX "idx_user_exports_user_time" ON "user_exports" ("user_id", "created_at");
@@ -0,0 +1,20 @@
+-- Create "audit_events" table
+CREATE TABLE "audit_events" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "actor_id" uuid NULL,
+  "actor" character varying(255) NOT NULL,
+  "repository_id" uuid NULL,
+  "action" character varying(64) NOT NULL,
+  "metadata" jsonb NULL,
+  "ip" character varying(64) NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_audit_events_action" to table: "audit_events"
+CREATE INDEX "idx_audit_events_action" ON "audit_events" ("action");
+-- Create index "idx_audit_events_actor_id" to table: "audit_events"
+CREATE INDEX "idx_audit_events_actor_id" ON "audit_events" ("actor_id");
+-- Create index "idx_audit_events_created_at" to table: "audit_events"
+CREATE INDEX "idx_audit_events_created_at" ON "audit_events" ("created_at");
+-- Create index "idx_audit_events_repo_time" to table: "audit_events"
+CREATE INDEX "idx_audit_events_repo_time" ON "audit_events" ("repository_id", "created_at");
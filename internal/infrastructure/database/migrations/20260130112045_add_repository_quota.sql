@@ -0,0 +1,2 @@
+-- Modify "repositories" table
+ALTER TABLE "repositories" ADD COLUMN "size_bytes" bigint NOT NULL DEFAULT 0, ADD COLUMN "quota_bytes" bigint NULL;
@@ -0,0 +1,2 @@
+-- Modify "repositories" table
+ALTER TABLE "repositories" ADD COLUMN "pinned_links" jsonb NULL;
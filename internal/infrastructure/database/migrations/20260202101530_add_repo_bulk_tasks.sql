@@ -0,0 +1,21 @@
+-- Create "repo_bulk_tasks" table
+CREATE TABLE "repo_bulk_tasks" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "operation" character varying(32) NOT NULL,
+  "is_private" boolean NULL,
+  "new_owner_id" uuid NULL,
+  "targets" jsonb NOT NULL,
+  "results" jsonb NOT NULL,
+  "status" character varying(16) NOT NULL,
+  "requested_by_id" uuid NULL,
+  "requested_by" character varying(255) NOT NULL,
+  "ip" character varying(64) NULL,
+  "created_at" timestamptz NULL,
+  "completed_at" timestamptz NULL,
+  "expires_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_repo_bulk_tasks_expires_at" to table: "repo_bulk_tasks"
+CREATE INDEX "idx_repo_bulk_tasks_expires_at" ON "repo_bulk_tasks" ("expires_at");
+-- Create index "idx_repo_bulk_tasks_status" to table: "repo_bulk_tasks"
+CREATE INDEX "idx_repo_bulk_tasks_status" ON "repo_bulk_tasks" ("status");
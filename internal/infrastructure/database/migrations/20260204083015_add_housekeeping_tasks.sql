@@ -0,0 +1,14 @@
+-- Create "housekeeping_tasks" table
+CREATE TABLE "housekeeping_tasks" (
+  "name" character varying(64) NOT NULL,
+  "next_run_at" timestamptz NOT NULL,
+  "last_started_at" timestamptz NULL,
+  "last_duration_ms" bigint NOT NULL DEFAULT 0,
+  "last_outcome" character varying(16) NULL,
+  "last_error" text NULL,
+  "last_run_by" character varying(255) NULL,
+  "run_count" bigint NOT NULL DEFAULT 0,
+  "failure_count" bigint NOT NULL DEFAULT 0,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("name")
+);
@@ -0,0 +1,17 @@
+-- Create "commit_statuses" table
+CREATE TABLE "commit_statuses" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "repository_id" uuid NOT NULL,
+  "sha" character varying(64) NOT NULL,
+  "state" character varying(16) NOT NULL,
+  "context" character varying(255) NOT NULL,
+  "description" character varying(255) NULL,
+  "target_url" character varying(2048) NULL,
+  "creator_id" uuid NULL,
+  "creator" character varying(255) NOT NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_commit_statuses_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_commit_statuses_repo_sha" to table: "commit_statuses"
+CREATE INDEX "idx_commit_statuses_repo_sha" ON "commit_statuses" ("repository_id", "sha", "context", "created_at");
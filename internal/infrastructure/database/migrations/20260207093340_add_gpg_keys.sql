@@ -0,0 +1,18 @@
+-- Create "gpg_keys" table
+CREATE TABLE "gpg_keys" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "user_id" uuid NOT NULL,
+  "key_id" character varying(16) NOT NULL,
+  "fingerprint" character varying(64) NOT NULL,
+  "subkey_ids" jsonb NULL,
+  "emails" jsonb NOT NULL,
+  "public_key" text NOT NULL,
+  "expires_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_gpg_keys_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_gpg_keys_fingerprint" to table: "gpg_keys"
+CREATE UNIQUE INDEX "idx_gpg_keys_fingerprint" ON "gpg_keys" ("fingerprint");
+-- Create index "idx_gpg_keys_user_id" to table: "gpg_keys"
+CREATE INDEX "idx_gpg_keys_user_id" ON "gpg_keys" ("user_id");
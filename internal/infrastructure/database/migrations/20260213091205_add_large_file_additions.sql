@@ -0,0 +1,16 @@
+-- Modify "repositories" table
+ALTER TABLE "repositories" ADD COLUMN "large_file_hints_disabled" boolean NOT NULL DEFAULT false;
+-- Create "large_file_additions" table
+CREATE TABLE "large_file_additions" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "repository_id" uuid NOT NULL,
+  "pusher_id" uuid NULL,
+  "path" text NOT NULL,
+  "blob_hash" character varying(64) NOT NULL,
+  "size" bigint NOT NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_large_file_additions_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_large_file_additions_repo_time" to table: "large_file_additions"
+CREATE INDEX "idx_large_file_additions_repo_time" ON "large_file_additions" ("repository_id", "created_at");
@@ -0,0 +1,22 @@
+-- Create "ci_job_tokens" table
+CREATE TABLE "ci_job_tokens" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "job_id" uuid NOT NULL,
+  "repository_id" uuid NOT NULL,
+  "token" character varying(64) NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz NULL,
+  "transfer_bytes" bigint NOT NULL DEFAULT 0,
+  "last_used_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_ci_job_tokens_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_ci_job_tokens_job_id" to table: "ci_job_tokens"
+CREATE UNIQUE INDEX "idx_ci_job_tokens_job_id" ON "ci_job_tokens" ("job_id");
+-- Create index "idx_ci_job_tokens_token" to table: "ci_job_tokens"
+CREATE UNIQUE INDEX "idx_ci_job_tokens_token" ON "ci_job_tokens" ("token");
+-- Create index "idx_ci_job_tokens_repository_id" to table: "ci_job_tokens"
+CREATE INDEX "idx_ci_job_tokens_repository_id" ON "ci_job_tokens" ("repository_id");
+-- Create index "idx_ci_job_tokens_created_at" to table: "ci_job_tokens"
+CREATE INDEX "idx_ci_job_tokens_created_at" ON "ci_job_tokens" ("created_at");
@@ -0,0 +1,19 @@
+-- Create "sessions" table
+CREATE TABLE "sessions" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "user_id" uuid NOT NULL,
+  "refresh_token" text NULL,
+  "id_token" text NULL,
+  "expires_at" timestamptz NOT NULL,
+  "last_used_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_sessions_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_sessions_user_id" to table: "sessions"
+CREATE INDEX "idx_sessions_user_id" ON "sessions" ("user_id");
+-- Create index "idx_sessions_expires_at" to table: "sessions"
+CREATE INDEX "idx_sessions_expires_at" ON "sessions" ("expires_at");
+-- Create index "idx_sessions_last_used_at" to table: "sessions"
+CREATE INDEX "idx_sessions_last_used_at" ON "sessions" ("last_used_at");
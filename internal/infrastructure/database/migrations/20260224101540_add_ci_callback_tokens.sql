@@ -0,0 +1,4 @@
+-- Modify "ci_job_tokens" table
+ALTER TABLE "ci_job_tokens" ADD COLUMN "callback_token" character varying(64) NULL;
+-- Create index "idx_ci_job_tokens_callback_token" to table: "ci_job_tokens"
+CREATE UNIQUE INDEX "idx_ci_job_tokens_callback_token" ON "ci_job_tokens" ("callback_token");
@@ -0,0 +1,20 @@
+-- Create "ci_artifacts" table
+CREATE TABLE "ci_artifacts" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "job_id" uuid NOT NULL,
+  "repository_id" uuid NOT NULL,
+  "name" character varying(255) NOT NULL,
+  "size" bigint NOT NULL,
+  "checksum" character varying(64) NOT NULL,
+  "content_type" character varying(255) NOT NULL,
+  "storage_path" text NOT NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_ci_artifacts_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_ci_artifacts_job_name" to table: "ci_artifacts"
+CREATE UNIQUE INDEX "idx_ci_artifacts_job_name" ON "ci_artifacts" ("job_id", "name");
+-- Create index "idx_ci_artifacts_repository_id" to table: "ci_artifacts"
+CREATE INDEX "idx_ci_artifacts_repository_id" ON "ci_artifacts" ("repository_id");
+-- Create index "idx_ci_artifacts_created_at" to table: "ci_artifacts"
+CREATE INDEX "idx_ci_artifacts_created_at" ON "ci_artifacts" ("created_at");
@@ -0,0 +1,4 @@
+-- Modify "repositories" table
+ALTER TABLE "repositories" ADD COLUMN "topics" text[] NOT NULL DEFAULT '{}';
+-- Create index "idx_repositories_topics" to table: "repositories"
+CREATE INDEX "idx_repositories_topics" ON "repositories" USING gin ("topics");
@@ -0,0 +1,10 @@
+-- Create "repo_languages" table
+CREATE TABLE "repo_languages" (
+  "repository_id" uuid NOT NULL,
+  "language" character varying(64) NOT NULL,
+  "bytes" bigint NOT NULL,
+  "commit_hash" character varying(40) NOT NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("repository_id", "language"),
+  CONSTRAINT "fk_repo_languages_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
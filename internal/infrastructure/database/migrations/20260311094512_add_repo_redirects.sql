@@ -0,0 +1,13 @@
+-- Create "repo_redirects" table
+CREATE TABLE "repo_redirects" (
+  "old_owner" character varying(255) NOT NULL,
+  "old_name" character varying(100) NOT NULL,
+  "repository_id" uuid NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("old_owner", "old_name"),
+  CONSTRAINT "fk_repo_redirects_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_repo_redirects_repository_id" to table: "repo_redirects"
+CREATE INDEX "idx_repo_redirects_repository_id" ON "repo_redirects" ("repository_id");
+-- Create index "idx_repo_redirects_created_at" to table: "repo_redirects"
+CREATE INDEX "idx_repo_redirects_created_at" ON "repo_redirects" ("created_at");
@@ -0,0 +1,2 @@
+-- Modify "repositories" table
+ALTER TABLE "repositories" ADD COLUMN "upload_pack_allow_filter" boolean NOT NULL DEFAULT true, ADD COLUMN "upload_pack_allow_reachable_sha1_in_want" boolean NOT NULL DEFAULT true, ADD COLUMN "upload_pack_allow_any_sha1_in_want" boolean NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+-- Modify "repositories" table
+ALTER TABLE "repositories" ADD COLUMN "last_gc_at" timestamptz NULL, ADD COLUMN "last_gc_duration_ms" bigint NOT NULL DEFAULT 0;
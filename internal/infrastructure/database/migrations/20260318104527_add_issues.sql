@@ -0,0 +1,39 @@
+-- Create "issues" table
+CREATE TABLE "issues" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "repository_id" uuid NOT NULL,
+  "number" bigint NOT NULL,
+  "title" character varying(255) NOT NULL,
+  "body" text NULL,
+  "author_id" uuid NULL,
+  "state" character varying(16) NOT NULL,
+  "labels" text[] NOT NULL DEFAULT '{}',
+  "closed_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_issues_author" FOREIGN KEY ("author_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE SET NULL,
+  CONSTRAINT "fk_issues_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_issues_author_id" to table: "issues"
+CREATE INDEX "idx_issues_author_id" ON "issues" ("author_id");
+-- Create index "idx_issues_repo_number" to table: "issues"
+CREATE UNIQUE INDEX "idx_issues_repo_number" ON "issues" ("repository_id", "number");
+-- Create index "idx_issues_state" to table: "issues"
+CREATE INDEX "idx_issues_state" ON "issues" ("state");
+-- Create "issue_comments" table
+CREATE TABLE "issue_comments" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "issue_id" uuid NOT NULL,
+  "author_id" uuid NULL,
+  "body" text NOT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_issue_comments_author" FOREIGN KEY ("author_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE SET NULL,
+  CONSTRAINT "fk_issue_comments_issue" FOREIGN KEY ("issue_id") REFERENCES "issues" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_issue_comments_author_id" to table: "issue_comments"
+CREATE INDEX "idx_issue_comments_author_id" ON "issue_comments" ("author_id");
+-- Create index "idx_issue_comments_issue_id" to table: "issue_comments"
+CREATE INDEX "idx_issue_comments_issue_id" ON "issue_comments" ("issue_id");
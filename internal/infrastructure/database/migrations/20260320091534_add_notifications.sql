@@ -0,0 +1,25 @@
+-- Create "notifications" table
+CREATE TABLE "notifications" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "user_id" uuid NOT NULL,
+  "type" character varying(32) NOT NULL,
+  "repository_id" uuid NULL,
+  "issue_id" uuid NULL,
+  "pull_request_id" uuid NULL,
+  "ci_job_id" uuid NULL,
+  "number" bigint NULL,
+  "actor_id" uuid NULL,
+  "title" character varying(255) NOT NULL,
+  "read" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_notifications_actor" FOREIGN KEY ("actor_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE SET NULL,
+  CONSTRAINT "fk_notifications_issue" FOREIGN KEY ("issue_id") REFERENCES "issues" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "fk_notifications_pull_request" FOREIGN KEY ("pull_request_id") REFERENCES "pull_requests" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "fk_notifications_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "fk_notifications_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_notifications_repository_id" to table: "notifications"
+CREATE INDEX "idx_notifications_repository_id" ON "notifications" ("repository_id");
+-- Create index "idx_notifications_user_read" to table: "notifications"
+CREATE INDEX "idx_notifications_user_read" ON "notifications" ("user_id", "read");
@@ -0,0 +1,10 @@
+-- Create "notification_preferences" table
+CREATE TABLE "notification_preferences" (
+  "user_id" uuid NOT NULL,
+  "email_ci_failures" boolean NOT NULL,
+  "email_collaborators" boolean NOT NULL,
+  "email_transfers" boolean NOT NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("user_id"),
+  CONSTRAINT "fk_notification_preferences_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
@@ -0,0 +1,31 @@
+-- Create "repo_imports" table
+CREATE TABLE "repo_imports" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "owner_id" uuid NOT NULL,
+  "repository_id" uuid NULL,
+  "name" character varying(100) NOT NULL,
+  "description" text NULL,
+  "is_private" boolean NOT NULL,
+  "source" character varying(16) NOT NULL,
+  "clone_url" text NULL,
+  "mirror" boolean NOT NULL,
+  "archive_name" character varying(255) NULL,
+  "archive_path" character varying(512) NULL,
+  "git_path" character varying(512) NULL,
+  "storage_backend" character varying(64) NULL,
+  "status" character varying(16) NOT NULL,
+  "error" text NULL,
+  "ip" character varying(64) NULL,
+  "created_at" timestamptz NULL,
+  "started_at" timestamptz NULL,
+  "completed_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_repo_imports_owner" FOREIGN KEY ("owner_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE CASCADE,
+  CONSTRAINT "fk_repo_imports_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE SET NULL
+);
+-- Create index "idx_repo_imports_owner_time" to table: "repo_imports"
+CREATE INDEX "idx_repo_imports_owner_time" ON "repo_imports" ("owner_id", "created_at");
+-- Create index "idx_repo_imports_repository_id" to table: "repo_imports"
+CREATE INDEX "idx_repo_imports_repository_id" ON "repo_imports" ("repository_id");
+-- Create index "idx_repo_imports_status" to table: "repo_imports"
+CREATE INDEX "idx_repo_imports_status" ON "repo_imports" ("status");
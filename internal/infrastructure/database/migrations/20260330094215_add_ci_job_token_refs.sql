@@ -0,0 +1,2 @@
+-- Modify "ci_job_tokens" table
+ALTER TABLE "ci_job_tokens" ADD COLUMN "stage" character varying(100) NOT NULL DEFAULT '', ADD COLUMN "job_name" character varying(100) NOT NULL DEFAULT '', ADD COLUMN "commit_sha" character varying(64) NOT NULL DEFAULT '', ADD COLUMN "ref_name" character varying(255) NOT NULL DEFAULT '', ADD COLUMN "ref_type" character varying(20) NOT NULL DEFAULT '', ADD COLUMN "trigger_actor" character varying(255) NOT NULL DEFAULT '';
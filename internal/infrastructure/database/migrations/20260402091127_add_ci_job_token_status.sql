@@ -0,0 +1,2 @@
+-- Modify "ci_job_tokens" table
+ALTER TABLE "ci_job_tokens" ADD COLUMN "run_id" uuid NULL, ADD COLUMN "status" character varying(20) NOT NULL DEFAULT '';
@@ -0,0 +1,35 @@
+-- Create "webhooks" table
+CREATE TABLE "webhooks" (
+  "id" uuid NOT NULL DEFAULT gen_random_uuid(),
+  "repository_id" uuid NOT NULL,
+  "url" character varying(2048) NOT NULL,
+  "secret" character varying(255) NOT NULL,
+  "events" jsonb NOT NULL,
+  "ref_pattern" character varying(255) NOT NULL DEFAULT '',
+  "active" boolean NOT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_webhooks_repository" FOREIGN KEY ("repository_id") REFERENCES "repositories" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_webhooks_repository_id" to table: "webhooks"
+CREATE INDEX "idx_webhooks_repository_id" ON "webhooks" ("repository_id");
+-- Create "webhook_deliveries" table
+CREATE TABLE "webhook_deliveries" (
+  "id" uuid NOT NULL,
+  "webhook_id" uuid NOT NULL,
+  "event" character varying(50) NOT NULL,
+  "payload" text NOT NULL,
+  "attempts" bigint NOT NULL DEFAULT 0,
+  "status_code" bigint NULL,
+  "error" text NOT NULL DEFAULT '',
+  "delivered_at" timestamptz NULL,
+  "last_attempt_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_webhook_deliveries_webhook" FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_webhook_deliveries_created_at" to table: "webhook_deliveries"
+CREATE INDEX "idx_webhook_deliveries_created_at" ON "webhook_deliveries" ("created_at");
+-- Create index "idx_webhook_deliveries_webhook_id" to table: "webhook_deliveries"
+CREATE INDEX "idx_webhook_deliveries_webhook_id" ON "webhook_deliveries" ("webhook_id");
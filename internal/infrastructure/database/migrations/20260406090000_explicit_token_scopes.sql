@@ -0,0 +1,3 @@
+-- Give tokens without scopes, which had full access, the repository scopes
+-- they keep: site administration now needs the admin scope
+UPDATE "tokens" SET "scope" = ARRAY['repo:read', 'repo:write'] WHERE "scope" IS NULL OR cardinality("scope") = 0;
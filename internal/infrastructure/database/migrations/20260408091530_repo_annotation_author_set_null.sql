@@ -0,0 +1,4 @@
+-- Modify "repo_annotations" table
+ALTER TABLE "repo_annotations" DROP CONSTRAINT "fk_repo_annotations_author", ALTER COLUMN "author_id" DROP NOT NULL, ADD CONSTRAINT "fk_repo_annotations_author" FOREIGN KEY ("author_id") REFERENCES "users" ("id") ON UPDATE NO ACTION ON DELETE SET NULL;
+-- Create index "idx_repo_annotations_author_id" to table: "repo_annotations"
+CREATE INDEX "idx_repo_annotations_author_id" ON "repo_annotations" ("author_id");
@@ -65,6 +65,18 @@ func NewDatabase(cfg *config.DatabaseConfig) (*Database, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if timeout := cfg.StatementTimeout(); timeout > 0 {
+		if err := db.Use(&timeoutPlugin{timeout: timeout}); err != nil {
+			log.Error("Failed to register statement timeout",
+				logger.Error(err),
+			)
+			return nil, fmt.Errorf("failed to register statement timeout: %w", err)
+		}
+		log.Debug("Statement timeout configured",
+			logger.Duration("statement_timeout", timeout),
+		)
+	}
+
 	log.Debug("Database connection established, configuring connection pool...")
 
 	// Get underlying SQL DB and configure connection pool
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// Backoff between attempts of Retry
+const (
+	initialRetryDelay = time.Second
+	maxRetryDelay     = 30 * time.Second
+)
+
+// Retry calls fn until it succeeds or ctx is done, doubling the delay between
+// attempts up to maxRetryDelay. fn is always called at least once; once ctx is
+// done the last error of fn is returned. what names the operation in the logs.
+func Retry(ctx context.Context, what string, fn func(ctx context.Context) error) error {
+	log := logger.Get().WithFields(logger.Component("database"))
+
+	delay := initialRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			if attempt > 1 {
+				log.Info("Database operation succeeded after retrying",
+					logger.String("operation", what),
+					logger.Int("attempts", attempt),
+				)
+			}
+			return nil
+		}
+
+		deadline, hasDeadline := ctx.Deadline()
+		if ctx.Err() != nil || (hasDeadline && time.Until(deadline) < delay) {
+			return fmt.Errorf("%s failed after %d attempts: %w", what, attempt, err)
+		}
+
+		log.Warn("Database operation failed, retrying",
+			logger.String("operation", what),
+			logger.Int("attempt", attempt),
+			logger.Duration("retry_in", delay),
+			logger.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s failed after %d attempts: %w", what, attempt, err)
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
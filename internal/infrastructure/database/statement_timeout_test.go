@@ -0,0 +1,84 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/testutil"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// A stuck statement fails once the statement timeout or the deadline of its
+// context expires, whichever comes first, with an error the handlers answer
+// with 504. A statement whose client went away is not a timeout.
+func TestStatementTimeout(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Database.StatementTimeoutSeconds = 1
+	db, drop, err := testutil.NewDatabase(context.Background(), cfg.Database)
+	if errors.Is(err, testutil.ErrNoDatabase) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer drop()
+
+	tests := []struct {
+		name        string
+		ctx         func() (context.Context, context.CancelFunc)
+		wantTimeout bool
+	}{
+		{
+			name:        "statement timeout",
+			ctx:         func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+			wantTimeout: true,
+		},
+		{
+			name: "context deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 200*time.Millisecond)
+			},
+			wantTimeout: true,
+		},
+		{
+			name: "context canceled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(200*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			wantTimeout: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			started := time.Now()
+			err := db.DB().WithContext(ctx).Exec("SELECT pg_sleep(30)").Error
+			if elapsed := time.Since(started); elapsed > 5*time.Second {
+				t.Errorf("statement failed after %v, want within the 1s timeout", elapsed)
+			}
+			if err == nil {
+				t.Fatal("pg_sleep(30) succeeded, want it stopped")
+			}
+			if got := errors.Is(err, apperrors.ErrTimeout); got != tt.wantTimeout {
+				t.Fatalf("errors.Is(%v, ErrTimeout) = %v, want %v", err, got, tt.wantTimeout)
+			}
+			if !tt.wantTimeout {
+				return
+			}
+			if status := apperrors.DatabaseError("query", err).HTTPStatus(); status != http.StatusGatewayTimeout {
+				t.Errorf("DatabaseError status = %d, want %d", status, http.StatusGatewayTimeout)
+			}
+		})
+	}
+}
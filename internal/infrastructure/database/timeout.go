@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// queryCanceled is the SQLSTATE Postgres reports for statements canceled by
+// statement_timeout (or a cancel request sent when a context expires)
+const queryCanceled = "57014"
+
+// timeoutCancelKey stores the cancel function of a statement's deadline
+const timeoutCancelKey = "stasis:timeout_cancel"
+
+// timeoutPlugin derives a deadline for every statement from its context,
+// bounded by the statement timeout, and marks errors caused by an expired
+// deadline with apperrors.ErrTimeout so callers can tell them from failures
+type timeoutPlugin struct {
+	timeout time.Duration
+}
+
+// Name implements gorm.Plugin
+func (p *timeoutPlugin) Name() string {
+	return "stasis:timeout"
+}
+
+// Initialize implements gorm.Plugin. Row callbacks are left out: the rows they
+// return are read after the callback chain, when the deadline would already be
+// canceled. Postgres still bounds them through statement_timeout.
+func (p *timeoutPlugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	err := errors.Join(
+		callbacks.Create().Before("*").Register("stasis:timeout_begin", p.begin),
+		callbacks.Create().After("*").Register("stasis:timeout_end", p.end),
+		callbacks.Query().Before("*").Register("stasis:timeout_begin", p.begin),
+		callbacks.Query().After("*").Register("stasis:timeout_end", p.end),
+		callbacks.Update().Before("*").Register("stasis:timeout_begin", p.begin),
+		callbacks.Update().After("*").Register("stasis:timeout_end", p.end),
+		callbacks.Delete().Before("*").Register("stasis:timeout_begin", p.begin),
+		callbacks.Delete().After("*").Register("stasis:timeout_end", p.end),
+		callbacks.Raw().Before("*").Register("stasis:timeout_begin", p.begin),
+		callbacks.Raw().After("*").Register("stasis:timeout_end", p.end),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register timeout callbacks: %w", err)
+	}
+	return nil
+}
+
+// begin replaces the statement context with one that expires after the
+// statement timeout, unless the context already expires sooner
+func (p *timeoutPlugin) begin(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= p.timeout {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	db.Statement.Context = ctx
+	db.InstanceSet(timeoutCancelKey, cancel)
+}
+
+// end releases the deadline and marks timeout errors
+func (p *timeoutPlugin) end(db *gorm.DB) {
+	if cancel, ok := db.InstanceGet(timeoutCancelKey); ok {
+		cancel.(context.CancelFunc)()
+	}
+
+	if db.Error != nil && isTimeout(db.Error) && !errors.Is(db.Error, apperrors.ErrTimeout) {
+		db.Error = fmt.Errorf("%w: %w", apperrors.ErrTimeout, db.Error)
+	}
+}
+
+// isTimeout returns true for errors caused by an expired deadline or statement timeout
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if errors.Is(err, context.Canceled) {
+		// The client went away; the statement did not time out
+		return false
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == queryCanceled
+}
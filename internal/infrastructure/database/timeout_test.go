@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "statement timeout", err: &pgconn.PgError{Code: queryCanceled}, want: true},
+		{name: "wrapped statement timeout", err: fmt.Errorf("query: %w", &pgconn.PgError{Code: queryCanceled}), want: true},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: true},
+		{name: "wrapped deadline exceeded", err: fmt.Errorf("query: %w", context.DeadlineExceeded), want: true},
+		// The client went away; the statement did not time out
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "canceled statement", err: errors.Join(context.Canceled, &pgconn.PgError{Code: queryCanceled}), want: false},
+		{name: "other Postgres error", err: &pgconn.PgError{Code: "23505"}, want: false},
+		{name: "other error", err: errors.New("connection refused"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTimeout(tt.err); got != tt.want {
+				t.Errorf("isTimeout(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+// Package eventbus delivers domain events in-process from producers to
+// subscribers, without either knowing about the other.
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/events"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// defaultQueueSize is the queue size of asynchronous subscribers that set none
+	defaultQueueSize = 256
+
+	// defaultRetryBackoff is the first retry wait of subscribers that set none
+	defaultRetryBackoff = time.Second
+
+	// maxRetryBackoff bounds the wait between retries
+	maxRetryBackoff = time.Minute
+)
+
+// TypeMetrics counts the events of a type. Consumed and Dropped count
+// deliveries, so an event with two subscribers is consumed twice.
+type TypeMetrics struct {
+	Type      string
+	Published uint64
+	Consumed  uint64 // Deliveries the handler accepted
+	Dropped   uint64 // Deliveries lost to a full queue or failed after all retries
+}
+
+// Bus is an in-memory event bus. Publish never blocks on asynchronous
+// subscribers: each has its own queue and goroutine.
+type Bus struct {
+	subs    atomic.Pointer[[]*subscription] // Replaced on (un)subscribe, never modified
+	subsMu  sync.Mutex                      // Serializes replacements of subs
+	metrics sync.Map                        // string -> *typeCounters
+	log     *logger.Logger
+}
+
+// typeCounters holds the counters of an event type
+type typeCounters struct {
+	published atomic.Uint64
+	consumed  atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// New creates an event bus without subscribers
+func New() *Bus {
+	b := &Bus{log: logger.Get().WithFields(logger.Component("event-bus"))}
+	b.subs.Store(&[]*subscription{})
+	return b
+}
+
+// Publish implements events.Publisher
+func (b *Bus) Publish(event events.Event) {
+	env := events.Envelope{
+		ID:         uuid.New(),
+		Type:       event.EventType(),
+		OccurredAt: time.Now().UTC(),
+		Event:      event,
+	}
+	b.counters(env.Type).published.Add(1)
+
+	for _, sub := range *b.subs.Load() {
+		if sub.accepts(env.Type) {
+			sub.deliver(env)
+		}
+	}
+}
+
+// Subscribe implements events.Subscriber
+func (b *Bus) Subscribe(name string, handler events.Handler, opts events.SubscribeOptions) func() {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := &subscription{
+		name:    name,
+		types:   opts.Types,
+		handler: handler,
+		opts:    opts,
+		bus:     b,
+		ctx:     ctx,
+		cancel:  cancel,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		log:     b.log.WithFields(logger.String("subscriber", name)),
+	}
+	if opts.Delivery == events.DeliverAsync {
+		sub.queue = make(chan events.Envelope, opts.QueueSize)
+		go sub.run()
+	} else {
+		close(sub.done)
+	}
+
+	b.subsMu.Lock()
+	subs := append(slices.Clone(*b.subs.Load()), sub)
+	b.subs.Store(&subs)
+	b.subsMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { b.unsubscribe(sub) })
+	}
+}
+
+// unsubscribe removes a subscription, delivering its queued events first
+func (b *Bus) unsubscribe(sub *subscription) {
+	b.subsMu.Lock()
+	subs := slices.DeleteFunc(slices.Clone(*b.subs.Load()), func(s *subscription) bool { return s == sub })
+	b.subs.Store(&subs)
+	b.subsMu.Unlock()
+
+	close(sub.stop)
+	<-sub.done
+	sub.cancel()
+}
+
+// Close removes all subscriptions, delivering their queued events first
+func (b *Bus) Close() {
+	for _, sub := range *b.subs.Load() {
+		b.unsubscribe(sub)
+	}
+}
+
+// Metrics returns the counters of every event type published or delivered so far
+func (b *Bus) Metrics() []TypeMetrics {
+	var metrics []TypeMetrics
+	b.metrics.Range(func(key, value any) bool {
+		c := value.(*typeCounters)
+		metrics = append(metrics, TypeMetrics{
+			Type:      key.(string),
+			Published: c.published.Load(),
+			Consumed:  c.consumed.Load(),
+			Dropped:   c.dropped.Load(),
+		})
+		return true
+	})
+	slices.SortFunc(metrics, func(a, b TypeMetrics) int { return strings.Compare(a.Type, b.Type) })
+	return metrics
+}
+
+// counters returns the counters of an event type
+func (b *Bus) counters(eventType string) *typeCounters {
+	if c, ok := b.metrics.Load(eventType); ok {
+		return c.(*typeCounters)
+	}
+	c, _ := b.metrics.LoadOrStore(eventType, &typeCounters{})
+	return c.(*typeCounters)
+}
+
+// subscription is a registered handler and, for asynchronous delivery, its queue
+type subscription struct {
+	name    string
+	types   []string
+	handler events.Handler
+	opts    events.SubscribeOptions
+	bus     *Bus
+	ctx     context.Context
+	cancel  context.CancelFunc
+	queue   chan events.Envelope
+	stop    chan struct{}
+	done    chan struct{}
+	log     *logger.Logger
+}
+
+// accepts returns true if the subscription receives events of a type
+func (s *subscription) accepts(eventType string) bool {
+	return len(s.types) == 0 || slices.Contains(s.types, eventType)
+}
+
+// deliver hands an event to the subscription without blocking on an
+// asynchronous handler
+func (s *subscription) deliver(env events.Envelope) {
+	if s.opts.Delivery == events.DeliverSync {
+		if err := s.call(env); err != nil {
+			s.failed(env, err)
+			return
+		}
+		s.bus.counters(env.Type).consumed.Add(1)
+		return
+	}
+
+	select {
+	case s.queue <- env:
+		return
+	default:
+	}
+
+	if s.opts.Overflow == events.DropOldest {
+		select {
+		case oldest := <-s.queue:
+			s.overflowed(oldest)
+		default:
+		}
+		select {
+		case s.queue <- env:
+			return
+		default:
+		}
+	}
+	s.overflowed(env)
+}
+
+// run delivers queued events until the subscription is removed, then
+// delivers what is left in the queue once, without retries
+func (s *subscription) run() {
+	defer close(s.done)
+
+	for {
+		select {
+		case env := <-s.queue:
+			s.process(env)
+		case <-s.stop:
+			for {
+				select {
+				case env := <-s.queue:
+					if err := s.call(env); err != nil {
+						s.failed(env, err)
+					} else {
+						s.bus.counters(env.Type).consumed.Add(1)
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// process delivers an event, retrying failed deliveries with backoff
+func (s *subscription) process(env events.Envelope) {
+	backoff := s.opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := s.call(env)
+		if err == nil {
+			s.bus.counters(env.Type).consumed.Add(1)
+			return
+		}
+		if attempt >= s.opts.MaxRetries {
+			s.failed(env, err)
+			return
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-s.stop:
+			s.failed(env, err)
+			return
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// call runs the handler, turning panics into errors
+func (s *subscription) call(env events.Envelope) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return s.handler(s.ctx, env)
+}
+
+// failed records a delivery that was given up
+func (s *subscription) failed(env events.Envelope, err error) {
+	s.bus.counters(env.Type).dropped.Add(1)
+	s.log.Warn("Event delivery failed, event dropped",
+		logger.Error(err),
+		logger.String("event_type", env.Type),
+		logger.String("event_id", env.ID.String()),
+	)
+}
+
+// overflowed records an event dropped because the queue was full
+func (s *subscription) overflowed(env events.Envelope) {
+	s.bus.counters(env.Type).dropped.Add(1)
+	s.log.Warn("Event queue full, event dropped",
+		logger.String("event_type", env.Type),
+		logger.String("event_id", env.ID.String()),
+	)
+}
+
+// Verify interface compliance at compile time
+var _ events.Bus = (*Bus)(nil)
@@ -0,0 +1,131 @@
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+)
+
+// ipv4BitsOffset is added to the prefix length of IPv4 networks so they are
+// indexed apart from IPv6 networks (lengths 0-128)
+const ipv4BitsOffset = 256
+
+// RangeDatabase resolves addresses from a list of networks. Overlapping
+// networks are allowed; the most specific one wins.
+type RangeDatabase struct {
+	// networks maps prefix lengths to the networks of that length
+	networks map[int]map[netip.Prefix]service.GeoLocation
+	// bits holds the prefix lengths present, longest first
+	bits []int
+}
+
+// LoadRangeFile loads a CSV file of network,country,asn lines. The ASN may be
+// empty or prefixed with "AS"; blank lines and lines starting with # are skipped.
+func LoadRangeFile(path string) (*RangeDatabase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP ranges file: %w", err)
+	}
+	defer f.Close()
+
+	db, err := ParseRanges(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return db, nil
+}
+
+// ParseRanges parses network,country,asn lines as read by LoadRangeFile
+func ParseRanges(r io.Reader) (*RangeDatabase, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	db := &RangeDatabase{networks: make(map[int]map[netip.Prefix]service.GeoLocation)}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: expected network,country[,asn]", line)
+		}
+
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		prefix = prefix.Masked()
+
+		location := service.GeoLocation{Country: strings.ToUpper(strings.TrimSpace(record[1]))}
+		if len(record) > 2 {
+			asn := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(record[2])), "AS")
+			if asn != "" {
+				n, err := strconv.ParseUint(asn, 10, 32)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid ASN %q", line, record[2])
+				}
+				location.ASN = uint32(n)
+			}
+		}
+
+		bits := prefix.Bits()
+		if prefix.Addr().Is4() {
+			// Keep IPv4 and IPv6 networks of the same length apart
+			bits += ipv4BitsOffset
+		}
+		if db.networks[bits] == nil {
+			db.networks[bits] = make(map[netip.Prefix]service.GeoLocation)
+			db.bits = append(db.bits, bits)
+		}
+		db.networks[bits][prefix] = location
+	}
+
+	slices.SortFunc(db.bits, func(a, b int) int { return b - a })
+	return db, nil
+}
+
+// Lookup implements service.GeoIPLookup
+func (db *RangeDatabase) Lookup(addr netip.Addr) (service.GeoLocation, bool) {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return service.GeoLocation{}, false
+	}
+
+	for _, bits := range db.bits {
+		length := bits
+		if addr.Is4() {
+			if bits < ipv4BitsOffset {
+				continue
+			}
+			length -= ipv4BitsOffset
+		} else if bits >= ipv4BitsOffset {
+			continue
+		}
+
+		prefix, err := addr.Prefix(length)
+		if err != nil {
+			continue
+		}
+		if location, ok := db.networks[bits][prefix]; ok {
+			return location, true
+		}
+	}
+	return service.GeoLocation{}, false
+}
+
+// Verify interface compliance at compile time
+var _ service.GeoIPLookup = (*RangeDatabase)(nil)
@@ -0,0 +1,91 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// blobReader streams the content of a blob and seeks within it. Git objects
+// are compressed, so seeking backwards reopens the blob and seeking forwards
+// reads and discards the content up to the new offset, on the next Read.
+type blobReader struct {
+	blob   *object.Blob
+	offset int64         // Offset of the next Read
+	reader io.ReadCloser // Open reader, nil until the first Read
+	pos    int64         // Offset of reader
+}
+
+// newBlobReader returns a blobReader positioned at the start of blob
+func newBlobReader(blob *object.Blob) *blobReader {
+	return &blobReader{blob: blob}
+}
+
+// Read reads from the current offset
+func (r *blobReader) Read(p []byte) (int, error) {
+	if r.offset >= r.blob.Size {
+		return 0, io.EOF
+	}
+
+	if r.reader == nil || r.pos > r.offset {
+		if err := r.reopen(); err != nil {
+			return 0, err
+		}
+	}
+	if r.pos < r.offset {
+		skipped, err := io.CopyN(io.Discard, r.reader, r.offset-r.pos)
+		r.pos += skipped
+		if err != nil {
+			return 0, fmt.Errorf("failed to seek blob: %w", err)
+		}
+	}
+
+	n, err := r.reader.Read(p)
+	r.pos += int64(n)
+	r.offset = r.pos
+	return n, err
+}
+
+// Seek sets the offset of the next Read
+func (r *blobReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.blob.Size
+	default:
+		return 0, errors.New("blob seek: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("blob seek: negative position")
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+// Close closes the open reader, if any
+func (r *blobReader) Close() error {
+	if r.reader == nil {
+		return nil
+	}
+	err := r.reader.Close()
+	r.reader = nil
+	return err
+}
+
+// reopen opens the blob again from its start
+func (r *blobReader) reopen() error {
+	if err := r.Close(); err != nil {
+		return err
+	}
+	reader, err := r.blob.Reader()
+	if err != nil {
+		return fmt.Errorf("failed to read blob: %w", err)
+	}
+	r.reader = reader
+	r.pos = 0
+	return nil
+}
@@ -0,0 +1,192 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// maxCachedHistoryHashes bounds the commit hashes held by the history cache,
+// about 40 MiB. A history longer than this is never cached.
+const maxCachedHistoryHashes = 1 << 21
+
+// historyCache is an LRU cache of the ordered commit history reachable from
+// a commit of a repository. Entries are keyed by the tip commit rather than
+// the ref, so a moved ref never sees a stale history and refs pointing at the
+// same commit share it. An entry holds either the full list of hashes, which
+// makes any page of the history a slice, or only the number of commits.
+type historyCache struct {
+	mu      sync.Mutex
+	limit   int // Hashes the cache may hold
+	size    int // Hashes held by all entries
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// historyCacheEntry is the history of a single tip commit
+type historyCacheEntry struct {
+	key    string
+	hashes []plumbing.Hash // nil if only counted
+	count  int
+}
+
+// newHistoryCache creates an empty history cache holding up to limit hashes
+func newHistoryCache(limit int) *historyCache {
+	return &historyCache{
+		limit:   limit,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// historyKey returns the cache key of the history of tip in a repository
+func historyKey(repoPath string, tip plumbing.Hash) string {
+	return repoPath + "\x00" + tip.String()
+}
+
+// get returns the cached history of a key, hashes being nil if only its
+// length is known, and marks it as recently used
+func (c *historyCache) get(key string) (hashes []plumbing.Hash, count int, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, 0, false
+	}
+	c.order.MoveToFront(el)
+	entry := el.Value.(*historyCacheEntry)
+	return entry.hashes, entry.count, true
+}
+
+// put stores a history, with hashes nil to store only its length, and evicts
+// the least recently used entries until the cache fits its bound again
+func (c *historyCache) put(key string, hashes []plumbing.Hash, count int) {
+	if len(hashes) > c.limit {
+		hashes = nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*historyCacheEntry)
+		c.order.MoveToFront(el)
+		if entry.hashes != nil || hashes == nil {
+			return
+		}
+		c.size += len(hashes)
+		entry.hashes = hashes
+	} else {
+		c.entries[key] = c.order.PushFront(&historyCacheEntry{key: key, hashes: hashes, count: count})
+		c.size += len(hashes) + 1
+	}
+
+	for c.size > c.limit {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*historyCacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.size -= len(entry.hashes) + 1
+	}
+}
+
+// history returns the hashes of count commits reachable from tip, skipping
+// the first skip, newest first. count <= 0 returns all remaining commits.
+// The first page is read directly; deeper pages walk the history once and
+// cache it, so following pages are slices of the cached list.
+func (g *GitOperations) history(ctx context.Context, repoPath string, tip plumbing.Hash, skip, count int) ([]plumbing.Hash, error) {
+	key := historyKey(repoPath, tip)
+	hashes, _, ok := g.histories.get(key)
+	if !ok || hashes == nil {
+		if skip == 0 && count > 0 {
+			return revList(ctx, repoPath, tip, "--max-count="+strconv.Itoa(count))
+		}
+
+		var err error
+		hashes, err = revList(ctx, repoPath, tip)
+		if err != nil {
+			return nil, err
+		}
+		g.histories.put(key, hashes, len(hashes))
+	}
+
+	if skip >= len(hashes) {
+		return nil, nil
+	}
+	hashes = hashes[skip:]
+	if count > 0 && count < len(hashes) {
+		hashes = hashes[:count]
+	}
+	return hashes, nil
+}
+
+// CountCommits returns the number of commits reachable from a ref
+func (g *GitOperations) CountCommits(ctx context.Context, repoPath, ref string) (int, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open repository: %w", err)
+	}
+	tip, err := g.resolveRef(repo, ref)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve ref '%s': %w", ref, err)
+	}
+
+	key := historyKey(repoPath, tip)
+	if _, count, ok := g.histories.get(key); ok {
+		return count, nil
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-list", "--count", tip.String())
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return 0, fmt.Errorf("failed to count commits: %w (stderr: %s)", err, stderr.String())
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(stdout.String()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse commit count: %w", err)
+	}
+
+	g.histories.put(key, nil, count)
+	return count, nil
+}
+
+// revList lists the commits reachable from tip, newest first, in the order
+// of git log
+func revList(ctx context.Context, repoPath string, tip plumbing.Hash, args ...string) ([]plumbing.Hash, error) {
+	cmd := exec.CommandContext(ctx, "git", append(append([]string{"-C", repoPath, "rev-list"}, args...), tip.String())...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	var hashes []plumbing.Hash
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		hashes = append(hashes, plumbing.NewHash(scanner.Text()))
+	}
+	if err := scanner.Err(); err != nil {
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("failed to read commit list: %w", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w (stderr: %s)", err, stderr.String())
+	}
+	return hashes, nil
+}
@@ -0,0 +1,247 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// linearRepo returns a bare repository whose main branch has n commits,
+// each changing one file
+func linearRepo(tb testing.TB, n int) string {
+	tb.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		tb.Skip("git is not installed")
+	}
+	repoPath := filepath.Join(tb.TempDir(), "history.git")
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", "--initial-branch=main", repoPath).CombinedOutput(); err != nil {
+		tb.Fatalf("git init: %v\n%s", err, out)
+	}
+	appendCommits(tb, repoPath, 0, n)
+	return repoPath
+}
+
+// appendCommits adds n commits to main of a repository that has from
+func appendCommits(tb testing.TB, repoPath string, from, n int) {
+	tb.Helper()
+	var stream strings.Builder
+	for i := from + 1; i <= from+n; i++ {
+		msg := fmt.Sprintf("Commit %d\n", i)
+		content := fmt.Sprintf("revision %d\n", i)
+		fmt.Fprintf(&stream, "commit refs/heads/main\ncommitter Test <test@example.com> %d +0000\ndata %d\n%s", 1700000000+i, len(msg), msg)
+		if i == from+1 && from > 0 {
+			stream.WriteString("from refs/heads/main^0\n")
+		}
+		fmt.Fprintf(&stream, "M 644 inline file.txt\ndata %d\n%s\n", len(content), content)
+	}
+
+	cmd := exec.Command("git", "-C", repoPath, "fast-import", "--quiet")
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL=/dev/null")
+	cmd.Stdin = strings.NewReader(stream.String())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		tb.Fatalf("git fast-import: %v\n%s", err, out)
+	}
+}
+
+// tipOf returns the commit main points at
+func tipOf(tb testing.TB, repoPath string) plumbing.Hash {
+	tb.Helper()
+	out, err := exec.Command("git", "-C", repoPath, "rev-parse", "main").Output()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return plumbing.NewHash(strings.TrimSpace(string(out)))
+}
+
+func TestHistoryCache(t *testing.T) {
+	hashes := func(n int) []plumbing.Hash { return make([]plumbing.Hash, n) }
+	c := newHistoryCache(10)
+
+	c.put("a", hashes(4), 4)
+	c.put("b", nil, 7)
+	if got, count, ok := c.get("b"); !ok || got != nil || count != 7 {
+		t.Errorf("counted entry = %d hashes, %d, %v, want only the count 7", len(got), count, ok)
+	}
+
+	// A counted entry gains its hashes once they are read
+	c.put("b", hashes(3), 3)
+	if got, _, ok := c.get("b"); !ok || len(got) != 3 {
+		t.Errorf("entry after storing its hashes = %d hashes, %v, want 3", len(got), ok)
+	}
+
+	// a is the least recently used, and goes first once the cache is full
+	c.put("c", hashes(2), 2)
+	if _, _, ok := c.get("a"); ok {
+		t.Error("least recently used entry kept past the limit")
+	}
+	for _, key := range []string{"b", "c"} {
+		if _, _, ok := c.get(key); !ok {
+			t.Errorf("entry %s evicted, want only the least recently used one", key)
+		}
+	}
+	if c.size > c.limit {
+		t.Errorf("cache holds %d hashes, more than its limit %d", c.size, c.limit)
+	}
+
+	// A history longer than the cache is only counted
+	c.put("d", hashes(11), 11)
+	if got, count, ok := c.get("d"); !ok || got != nil || count != 11 {
+		t.Errorf("oversized entry = %d hashes, %d, %v, want only the count 11", len(got), count, ok)
+	}
+}
+
+func TestGetCommitsPaging(t *testing.T) {
+	repoPath := linearRepo(t, 120)
+	g := NewGitOperations(nil, nil).(*GitOperations)
+	ctx := context.Background()
+	tip := tipOf(t, repoPath)
+
+	// The first page is read without caching the history
+	first, err := g.GetCommits(ctx, repoPath, "main", 25, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 25 || first[0].Hash != tip.String() {
+		t.Fatalf("first page = %d commits from %s, want 25 from %s", len(first), first[0].Hash, tip)
+	}
+	if _, _, ok := g.histories.get(historyKey(repoPath, tip)); ok {
+		t.Error("first page cached the history")
+	}
+
+	// Deeper pages are slices of the cached history, in git log order
+	seen := make(map[string]int)
+	for offset := 0; ; offset += 25 {
+		page, err := g.GetCommits(ctx, repoPath, "main", 25, offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, commit := range page {
+			want := fmt.Sprintf("Commit %d", 120-offset-i)
+			if strings.TrimSpace(commit.Message) != want {
+				t.Fatalf("commit %d is %q, want %q", offset+i, strings.TrimSpace(commit.Message), want)
+			}
+			seen[commit.Hash]++
+		}
+		if len(page) < 25 {
+			if offset != 100 || len(page) != 20 {
+				t.Errorf("last page at offset %d has %d commits, want 20 at offset 100", offset, len(page))
+			}
+			break
+		}
+	}
+	if len(seen) != 120 {
+		t.Errorf("pages listed %d distinct commits, want 120", len(seen))
+	}
+	if hashes, count, ok := g.histories.get(historyKey(repoPath, tip)); !ok || len(hashes) != 120 || count != 120 {
+		t.Errorf("cached history = %d hashes, %d, %v, want 120", len(hashes), count, ok)
+	}
+	if page, err := g.GetCommits(ctx, repoPath, "main", 25, 500); err != nil || len(page) != 0 {
+		t.Errorf("page past the end = %d commits, %v, want none", len(page), err)
+	}
+
+	// A moved ref is a new tip, so its history is never the stale one
+	appendCommits(t, repoPath, 120, 5)
+	moved := tipOf(t, repoPath)
+	page, err := g.GetCommits(ctx, repoPath, "main", 10, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(page[0].Message); got != "Commit 100" {
+		t.Errorf("page after moving the ref starts at %q, want \"Commit 100\"", got)
+	}
+	count, err := g.CountCommits(ctx, repoPath, "main")
+	if err != nil || count != 125 {
+		t.Errorf("CountCommits after moving the ref = %d, %v, want 125", count, err)
+	}
+	if old, err := g.CountCommits(ctx, repoPath, tip.String()); err != nil || old != 120 {
+		t.Errorf("CountCommits of the old tip = %d, %v, want 120", old, err)
+	}
+	if _, _, ok := g.histories.get(historyKey(repoPath, moved)); !ok {
+		t.Error("history of the moved ref not cached")
+	}
+}
+
+// logPage lists a page of history by walking it with go-git from the tip,
+// as commit listing did before the history was cached
+func logPage(repoPath string, tip plumbing.Hash, limit, offset int) ([]plumbing.Hash, error) {
+	repo, err := gogit.PlainOpen(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := repo.Log(&gogit.LogOptions{From: tip})
+	if err != nil {
+		return nil, err
+	}
+	var page []plumbing.Hash
+	i := 0
+	err = iter.ForEach(func(c *object.Commit) error {
+		if i++; i <= offset {
+			return nil
+		}
+		page = append(page, c.Hash)
+		if len(page) == limit {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, storer.ErrStop) {
+		return nil, err
+	}
+	return page, nil
+}
+
+// BenchmarkGetCommits lists pages of a 50k commit history: the first page,
+// page 50 and a page near the end, cached and not, against a walk of the
+// history for every page
+func BenchmarkGetCommits(b *testing.B) {
+	const commits, perPage = 50_000, 30
+	repoPath := linearRepo(b, commits)
+	tip := tipOf(b, repoPath)
+	ctx := context.Background()
+
+	pages := []struct {
+		name   string
+		offset int
+	}{
+		{"first", 0},
+		{"page50", 49 * perPage},
+		{"last", commits - perPage},
+	}
+	for _, page := range pages {
+		b.Run(page.name+"/cached", func(b *testing.B) {
+			g := NewGitOperations(nil, nil)
+			if _, err := g.GetCommits(ctx, repoPath, "main", perPage, page.offset); err != nil {
+				b.Fatal(err)
+			}
+			for b.Loop() {
+				if _, err := g.GetCommits(ctx, repoPath, "main", perPage, page.offset); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(page.name+"/uncached", func(b *testing.B) {
+			for b.Loop() {
+				if _, err := NewGitOperations(nil, nil).GetCommits(ctx, repoPath, "main", perPage, page.offset); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(page.name+"/walk", func(b *testing.B) {
+			for b.Loop() {
+				if _, err := logPage(repoPath, tip, perPage, page.offset); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+)
+
+const (
+	// pgpSignatureHeader starts an ASCII-armored OpenPGP signature
+	pgpSignatureHeader = "-----BEGIN PGP SIGNATURE-----"
+
+	// sshSignatureHeader starts an SSH signature (git gpg.format=ssh)
+	sshSignatureHeader = "-----BEGIN SSH SIGNATURE-----"
+
+	// sshSignatureFooter ends an SSH signature
+	sshSignatureFooter = "-----END SSH SIGNATURE-----"
+
+	// sshSignatureMagic starts an SSH signature blob and its signed data
+	sshSignatureMagic = "SSHSIG"
+
+	// sshSignatureNamespace is the namespace git signs commits in
+	sshSignatureNamespace = "git"
+)
+
+// VerifyCommitSignatures reads the signature of each commit from its raw
+// object and checks it against the keys of the check, in order
+func (g *GitOperations) VerifyCommitSignatures(ctx context.Context, repoPath string, checks []service.SignatureCheck) ([]service.SignatureVerification, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	verifications := make([]service.SignatureVerification, len(checks))
+	for i, check := range checks {
+		if err := ctx.Err(); err != nil {
+			return nil, apperror.Timeout("verify commit signatures", err)
+		}
+
+		c, err := repo.CommitObject(plumbing.NewHash(check.CommitHash))
+		if err != nil {
+			if errors.Is(err, plumbing.ErrObjectNotFound) {
+				return nil, apperror.NotFound("commit", apperror.ErrNotFound)
+			}
+			return nil, fmt.Errorf("failed to get commit: %w", err)
+		}
+		verifications[i], err = verifyCommitSignature(c, check)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return verifications, nil
+}
+
+// verifyCommitSignature checks the signature of a commit against the keys of
+// a check
+func verifyCommitSignature(c *object.Commit, check service.SignatureCheck) (service.SignatureVerification, error) {
+	signature := strings.TrimSpace(c.PGPSignature)
+	if signature == "" {
+		return service.SignatureVerification{Reason: service.SignatureUnsigned}, nil
+	}
+
+	payload, err := commitPayload(c)
+	if err != nil {
+		return service.SignatureVerification{}, err
+	}
+
+	switch {
+	case strings.HasPrefix(signature, pgpSignatureHeader):
+		return verifyPGPSignature(signature, payload, check.GPGKeys, c.Committer.When), nil
+	case strings.HasPrefix(signature, sshSignatureHeader):
+		return verifySSHSignature(signature, payload, check.SSHKeys), nil
+	default:
+		// X.509 (gpgsm) and other formats are not supported
+		return service.SignatureVerification{Reason: service.SignatureUnknownKey}, nil
+	}
+}
+
+// verifyPGPSignature checks an armored OpenPGP signature of payload against
+// armored public keys, at the time the commit was made so keys that expired
+// since still verify it
+func verifyPGPSignature(signature string, payload []byte, keys []string, signedAt time.Time) service.SignatureVerification {
+	verification := service.SignatureVerification{
+		Reason: service.SignatureBad,
+		Format: service.SignatureFormatGPG,
+	}
+
+	block, err := armor.Decode(strings.NewReader(signature))
+	if err != nil {
+		return verification
+	}
+	sigPacket, err := packet.Read(block.Body)
+	if err != nil {
+		return verification
+	}
+	sig, ok := sigPacket.(*packet.Signature)
+	if !ok {
+		return verification
+	}
+	if sig.IssuerKeyId != nil {
+		verification.KeyID = fmt.Sprintf("%016X", *sig.IssuerKeyId)
+	}
+
+	var keyring openpgp.EntityList
+	for _, key := range keys {
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+		if err != nil {
+			continue
+		}
+		keyring = append(keyring, entities...)
+	}
+
+	config := &packet.Config{Time: func() time.Time { return signedAt }}
+	_, err = openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(payload), strings.NewReader(signature), config)
+	switch {
+	case err == nil:
+		verification.Reason = service.SignatureValid
+	case errors.Is(err, pgperrors.ErrUnknownIssuer):
+		verification.Reason = service.SignatureUnknownKey
+	}
+	return verification
+}
+
+// sshSignature is the blob of an SSH signature, after its magic preamble
+// (PROTOCOL.sshsig in OpenSSH)
+type sshSignature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData is the data an SSH signature signs, after its magic preamble
+type sshSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+// verifySSHSignature checks an armored SSH signature of payload against
+// public keys in authorized_keys format
+func verifySSHSignature(signature string, payload []byte, keys []string) service.SignatureVerification {
+	verification := service.SignatureVerification{
+		Reason: service.SignatureBad,
+		Format: service.SignatureFormatSSH,
+	}
+
+	body := strings.TrimSuffix(strings.TrimPrefix(signature, sshSignatureHeader), sshSignatureFooter)
+	blob, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil || !bytes.HasPrefix(blob, []byte(sshSignatureMagic)) {
+		return verification
+	}
+	var sig sshSignature
+	if err := ssh.Unmarshal(blob[len(sshSignatureMagic):], &sig); err != nil || sig.Version != 1 {
+		return verification
+	}
+	signer, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return verification
+	}
+	verification.KeyID = ssh.FingerprintSHA256(signer)
+
+	known := false
+	for _, key := range keys {
+		parsed, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+		if err == nil && bytes.Equal(parsed.Marshal(), signer.Marshal()) {
+			known = true
+			break
+		}
+	}
+	if !known {
+		verification.Reason = service.SignatureUnknownKey
+		return verification
+	}
+
+	// A signature for another purpose, such as a file, must not pass as a
+	// commit signature
+	if sig.Namespace != sshSignatureNamespace {
+		return verification
+	}
+	var h hash.Hash
+	switch sig.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return verification
+	}
+	h.Write(payload)
+
+	var sshSig ssh.Signature
+	if err := ssh.Unmarshal(sig.Signature, &sshSig); err != nil {
+		return verification
+	}
+	signed := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignedData{
+		Namespace:     sig.Namespace,
+		Reserved:      sig.Reserved,
+		HashAlgorithm: sig.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	})...)
+	if signer.Verify(signed, &sshSig) == nil {
+		verification.Reason = service.SignatureValid
+	}
+	return verification
+}
@@ -0,0 +1,198 @@
+package git_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/internal/infrastructure/git"
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+// signer signs the payload of a commit, returning its gpgsig header
+type signer func(t *testing.T, payload []byte) string
+
+// writeSignedCommit writes a commit of tree with message, signed by sign
+// over signedMessage, and returns its hash. An empty signedMessage signs the
+// commit itself; another one gives a signature that does not match it.
+func writeSignedCommit(t *testing.T, b *testutil.RepoBuilder, tree plumbing.Hash, message, signedMessage string, sign signer) string {
+	t.Helper()
+	// Keys are valid from now on, and checked at the time of the commit
+	when := time.Now().Add(time.Minute).Truncate(time.Second)
+	sig := object.Signature{Name: "Alice", Email: "alice@example.com", When: when}
+	commit := &object.Commit{Author: sig, Committer: sig, Message: message, TreeHash: tree}
+
+	if sign != nil {
+		signed := *commit
+		if signedMessage != "" {
+			signed.Message = signedMessage
+		}
+		payload := &plumbing.MemoryObject{}
+		if err := signed.EncodeWithoutSignature(payload); err != nil {
+			t.Fatal(err)
+		}
+		r, _ := payload.Reader()
+		data, _ := io.ReadAll(r)
+		commit.PGPSignature = sign(t, data)
+	}
+
+	obj := b.Repository().Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := b.Repository().Storer.SetEncodedObject(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash.String()
+}
+
+// gpgKey returns a new OpenPGP key for alice@example.com, its armored public
+// key and a signer signing with it as git does
+func gpgKey(t *testing.T) (string, signer) {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Alice", "", "alice@example.com", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var public bytes.Buffer
+	w, err := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	return public.String(), func(t *testing.T, payload []byte) string {
+		var sig bytes.Buffer
+		if err := openpgp.ArmoredDetachSign(&sig, entity, bytes.NewReader(payload), nil); err != nil {
+			t.Fatal(err)
+		}
+		return sig.String()
+	}
+}
+
+// sshKey returns a new SSH key in authorized_keys format and a signer
+// signing in a namespace with it as git does, through ssh-keygen
+func sshKey(t *testing.T) (string, func(namespace string) signer) {
+	t.Helper()
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not found")
+	}
+	key := filepath.Join(t.TempDir(), "id_ed25519")
+	if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "alice@example.com", "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen: %v: %s", err, out)
+	}
+	public, err := os.ReadFile(key + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(public), func(namespace string) signer {
+		return func(t *testing.T, payload []byte) string {
+			cmd := exec.Command("ssh-keygen", "-Y", "sign", "-f", key, "-n", namespace)
+			cmd.Stdin = bytes.NewReader(payload)
+			out, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("ssh-keygen -Y sign: %v", err)
+			}
+			return string(out)
+		}
+	}
+}
+
+func TestVerifyCommitSignatures(t *testing.T) {
+	b := testutil.TempRepo(t)
+	tip := b.Commit("main", "Initial commit", testutil.File("README.md", "# demo\n"))
+	c, err := b.Repository().CommitObject(tip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := c.TreeHash
+
+	gpgPublic, gpgSign := gpgKey(t)
+	otherGPGPublic, otherGPGSign := gpgKey(t)
+	sshPublic, sshSign := sshKey(t)
+	otherSSHPublic, otherSSHSign := sshKey(t)
+	keys := service.SignatureCheck{GPGKeys: []string{otherGPGPublic, gpgPublic}, SSHKeys: []string{otherSSHPublic, sshPublic}}
+
+	tests := []struct {
+		name          string
+		signedMessage string
+		sign          signer
+		gpgKeys       []string
+		sshKeys       []string
+		want          service.SignatureVerification
+	}{
+		{name: "unsigned", want: service.SignatureVerification{Reason: service.SignatureUnsigned}},
+		{name: "gpg valid", sign: gpgSign, want: service.SignatureVerification{Reason: service.SignatureValid, Format: service.SignatureFormatGPG}},
+		{name: "gpg invalid", signedMessage: "Other\n", sign: gpgSign, want: service.SignatureVerification{Reason: service.SignatureBad, Format: service.SignatureFormatGPG}},
+		{name: "gpg unknown key", sign: otherGPGSign, gpgKeys: []string{gpgPublic}, want: service.SignatureVerification{Reason: service.SignatureUnknownKey, Format: service.SignatureFormatGPG}},
+		{name: "gpg without keys", sign: gpgSign, gpgKeys: []string{}, want: service.SignatureVerification{Reason: service.SignatureUnknownKey, Format: service.SignatureFormatGPG}},
+		{name: "ssh valid", sign: sshSign("git"), want: service.SignatureVerification{Reason: service.SignatureValid, Format: service.SignatureFormatSSH}},
+		{name: "ssh invalid", signedMessage: "Other\n", sign: sshSign("git"), want: service.SignatureVerification{Reason: service.SignatureBad, Format: service.SignatureFormatSSH}},
+		{name: "ssh unknown key", sign: otherSSHSign("git"), sshKeys: []string{sshPublic}, want: service.SignatureVerification{Reason: service.SignatureUnknownKey, Format: service.SignatureFormatSSH}},
+		{name: "ssh signature of a file", sign: sshSign("file"), want: service.SignatureVerification{Reason: service.SignatureBad, Format: service.SignatureFormatSSH}},
+		{
+			name: "malformed",
+			sign: func(*testing.T, []byte) string {
+				return "-----BEGIN PGP SIGNATURE-----\n\nbm90IGEgc2lnbmF0dXJl\n-----END PGP SIGNATURE-----\n"
+			},
+			want: service.SignatureVerification{Reason: service.SignatureBad, Format: service.SignatureFormatGPG},
+		},
+		{
+			name: "unsupported format",
+			sign: func(*testing.T, []byte) string {
+				return "-----BEGIN SIGNED MESSAGE-----\nMIAGCSqGSIb3DQEHAqCAMIACAQEx\n-----END SIGNED MESSAGE-----\n"
+			},
+			want: service.SignatureVerification{Reason: service.SignatureUnknownKey},
+		},
+	}
+
+	var checks []service.SignatureCheck
+	for _, tt := range tests {
+		check := keys
+		if tt.gpgKeys != nil {
+			check.GPGKeys = tt.gpgKeys
+		}
+		if tt.sshKeys != nil {
+			check.SSHKeys = tt.sshKeys
+		}
+		check.CommitHash = writeSignedCommit(t, b, tree, tt.name+"\n", tt.signedMessage, tt.sign)
+		checks = append(checks, check)
+	}
+
+	verifications, err := git.NewGitOperations(nil, nil).VerifyCommitSignatures(context.Background(), b.Path(), checks)
+	if err != nil {
+		t.Fatalf("VerifyCommitSignatures: %v", err)
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := verifications[i]
+			if got.Reason != tt.want.Reason || got.Format != tt.want.Format {
+				t.Errorf("verification = %+v, want reason %q in format %q", got, tt.want.Reason, tt.want.Format)
+			}
+			switch {
+			case tt.want.Reason == service.SignatureValid && tt.want.Format == service.SignatureFormatSSH && !strings.HasPrefix(got.KeyID, "SHA256:"):
+				t.Errorf("key ID = %q, want the SHA256 fingerprint of the key", got.KeyID)
+			case tt.want.Reason == service.SignatureValid && tt.want.Format == service.SignatureFormatGPG && len(got.KeyID) != 16:
+				t.Errorf("key ID = %q, want the long key ID", got.KeyID)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package git
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// sshSignatureLineLength is the length of the base64 lines of an armored SSH
+// signature, as ssh-keygen writes them
+const sshSignatureLineLength = 70
+
+// CommitSigner signs commits with the instance key of the server, in the
+// format git writes them (gpg.format openpgp or ssh), so git and the
+// verification of VerifyCommitSignatures accept them
+type CommitSigner struct {
+	format    string
+	pgp       *openpgp.Entity // Set for GPG keys
+	ssh       ssh.Signer      // Set for SSH keys
+	committer service.Signature
+	publicKey string
+	keyID     string
+}
+
+// NewCommitSigner loads the private key at keyPath, in format gpg or ssh, and
+// decrypts it with passphrase if it is encrypted. Commits it signs are
+// committed as committer.
+func NewCommitSigner(format, keyPath, passphrase string, committer service.Signature) (*CommitSigner, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	s := &CommitSigner{format: format, committer: committer}
+	switch format {
+	case service.SignatureFormatGPG:
+		err = s.loadPGPKey(data, passphrase)
+	case service.SignatureFormatSSH:
+		err = s.loadSSHKey(data, passphrase)
+	default:
+		err = fmt.Errorf("unsupported signing key format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadPGPKey loads an ASCII-armored OpenPGP private key
+func (s *CommitSigner) loadPGPKey(data []byte, passphrase string) error {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse GPG signing key: %w", err)
+	}
+	if len(entities) != 1 {
+		return fmt.Errorf("the GPG signing key file must hold exactly one key, found %d", len(entities))
+	}
+	entity := entities[0]
+	if entity.PrivateKey == nil {
+		return errors.New("the GPG signing key file holds a public key, a private key is required")
+	}
+	if entity.PrivateKey.Encrypted {
+		if passphrase == "" {
+			return errors.New("the GPG signing key is encrypted, a passphrase is required")
+		}
+		if err := entity.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+			return fmt.Errorf("failed to decrypt GPG signing key: %w", err)
+		}
+	}
+	if _, ok := entity.SigningKey(time.Now()); !ok {
+		return errors.New("the GPG signing key has no valid key for signing")
+	}
+
+	var public bytes.Buffer
+	w, err := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return fmt.Errorf("failed to encode GPG public key: %w", err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		return fmt.Errorf("failed to encode GPG public key: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to encode GPG public key: %w", err)
+	}
+
+	s.pgp = entity
+	s.publicKey = strings.TrimRight(public.String(), "\n") + "\n"
+	s.keyID = fmt.Sprintf("%016X", entity.PrimaryKey.KeyId)
+	return nil
+}
+
+// loadSSHKey loads an OpenSSH private key
+func (s *CommitSigner) loadSSHKey(data []byte, passphrase string) error {
+	signer, err := ssh.ParsePrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		if passphrase == "" {
+			return errors.New("the SSH signing key is encrypted, a passphrase is required")
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse SSH signing key: %w", err)
+	}
+
+	s.ssh = signer
+	s.publicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	s.keyID = ssh.FingerprintSHA256(signer.PublicKey())
+	return nil
+}
+
+// Committer returns the identity signed commits are committed as
+func (s *CommitSigner) Committer() service.Signature {
+	return s.committer
+}
+
+// Format returns the service.SignatureFormat* of the key
+func (s *CommitSigner) Format() string {
+	return s.format
+}
+
+// PublicKey returns the public key, ASCII-armored for GPG and in
+// authorized_keys format for SSH
+func (s *CommitSigner) PublicKey() string {
+	return s.publicKey
+}
+
+// KeyID returns the long key ID (GPG) or SHA256 fingerprint (SSH) of the key
+func (s *CommitSigner) KeyID() string {
+	return s.keyID
+}
+
+// Sign returns the armored signature of the payload of a commit object
+func (s *CommitSigner) Sign(payload []byte) (string, error) {
+	if s.pgp != nil {
+		var signature bytes.Buffer
+		if err := openpgp.ArmoredDetachSign(&signature, s.pgp, bytes.NewReader(payload), nil); err != nil {
+			return "", err
+		}
+		return strings.TrimRight(signature.String(), "\n") + "\n", nil
+	}
+	return s.signSSH(payload)
+}
+
+// signSSH returns the armored SSH signature of payload in the git namespace
+// (PROTOCOL.sshsig in OpenSSH)
+func (s *CommitSigner) signSSH(payload []byte) (string, error) {
+	hash := sha512.Sum512(payload)
+	signed := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignedData{
+		Namespace:     sshSignatureNamespace,
+		HashAlgorithm: "sha512",
+		Hash:          hash[:],
+	})...)
+
+	var sig *ssh.Signature
+	var err error
+	if algorithmSigner, ok := s.ssh.(ssh.AlgorithmSigner); ok && s.ssh.PublicKey().Type() == ssh.KeyAlgoRSA {
+		// ssh-keygen refuses SHA-1 RSA signatures
+		sig, err = algorithmSigner.SignWithAlgorithm(rand.Reader, signed, ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = s.ssh.Sign(rand.Reader, signed)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	blob := append([]byte(sshSignatureMagic), ssh.Marshal(sshSignature{
+		Version:       1,
+		PublicKey:     s.ssh.PublicKey().Marshal(),
+		Namespace:     sshSignatureNamespace,
+		HashAlgorithm: "sha512",
+		Signature:     ssh.Marshal(sig),
+	})...)
+	encoded := base64.StdEncoding.EncodeToString(blob)
+
+	var armored strings.Builder
+	armored.WriteString(sshSignatureHeader + "\n")
+	for len(encoded) > sshSignatureLineLength {
+		armored.WriteString(encoded[:sshSignatureLineLength] + "\n")
+		encoded = encoded[sshSignatureLineLength:]
+	}
+	armored.WriteString(encoded + "\n")
+	armored.WriteString(sshSignatureFooter + "\n")
+	return armored.String(), nil
+}
+
+// committerOf returns the identity a commit of author is committed as: the
+// identity of the signer if commits are signed, author otherwise
+func (g *GitOperations) committerOf(author service.Signature) service.Signature {
+	if g.signer == nil {
+		return author
+	}
+	return g.signer.Committer()
+}
+
+// signCommit signs a commit created by git commit-tree, if commits are
+// signed, and returns the hash of the signed commit. The unsigned commit is
+// left for git gc.
+func (g *GitOperations) signCommit(repoPath, hash string) (string, error) {
+	if g.signer == nil {
+		return hash, nil
+	}
+
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+	c, err := repo.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		return "", fmt.Errorf("failed to get commit: %w", err)
+	}
+
+	payload, err := commitPayload(c)
+	if err != nil {
+		return "", err
+	}
+	signature, err := g.signer.Sign(payload)
+	if err != nil {
+		return "", apperror.InternalError(
+			"the commit could not be signed with the instance signing key, check the signing configuration",
+			fmt.Errorf("%w: %v", apperror.ErrCommitSigning, err),
+		)
+	}
+
+	c.PGPSignature = signature
+	obj := repo.Storer.NewEncodedObject()
+	if err := c.Encode(obj); err != nil {
+		return "", fmt.Errorf("failed to encode signed commit: %w", err)
+	}
+	signed, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return "", fmt.Errorf("failed to write signed commit: %w", err)
+	}
+	return signed.String(), nil
+}
+
+// commitPayload returns the content of a commit object without its gpgsig
+// header, which is what its signature covers
+func commitPayload(c *object.Commit) ([]byte, error) {
+	encoded := &plumbing.MemoryObject{}
+	if err := c.EncodeWithoutSignature(encoded); err != nil {
+		return nil, fmt.Errorf("failed to encode commit: %w", err)
+	}
+	reader, err := encoded.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode commit: %w", err)
+	}
+	defer reader.Close()
+	var payload bytes.Buffer
+	if _, err := payload.ReadFrom(reader); err != nil {
+		return nil, fmt.Errorf("failed to encode commit: %w", err)
+	}
+	return payload.Bytes(), nil
+}
@@ -0,0 +1,280 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+)
+
+const (
+	// maxCachedContributions bounds the commits held by the contribution
+	// cache, about 100 MiB. A history longer than this is never cached.
+	maxCachedContributions = 1 << 20
+
+	// contributorActivityWeeks is the number of weeks of weekly commit counts
+	contributorActivityWeeks = 52
+)
+
+// contribution is the author and line changes of a commit
+type contribution struct {
+	name      string
+	email     string
+	when      time.Time
+	additions int64
+	deletions int64
+}
+
+// contributionCache is an LRU cache of the contributions of the history of a
+// commit of a repository. Like historyCache, entries are keyed by the tip
+// commit, so a moved ref never sees stale statistics. Windows and weekly
+// counts are computed from the cached contributions on every request.
+type contributionCache struct {
+	mu      sync.Mutex
+	size    int // Contributions held by all entries
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// contributionCacheEntry is the contributions of a single tip commit
+type contributionCacheEntry struct {
+	key           string
+	contributions []contribution
+}
+
+// newContributionCache creates an empty contribution cache
+func newContributionCache() *contributionCache {
+	return &contributionCache{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the cached contributions of a key and marks them as recently used
+func (c *contributionCache) get(key string) ([]contribution, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*contributionCacheEntry).contributions, true
+}
+
+// put stores the contributions of a key and evicts the least recently used
+// entries until the cache fits its bound again
+func (c *contributionCache) put(key string, contributions []contribution) {
+	if len(contributions) > maxCachedContributions {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&contributionCacheEntry{key: key, contributions: contributions})
+	c.size += len(contributions) + 1
+
+	for c.size > maxCachedContributions {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*contributionCacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.size -= len(entry.contributions) + 1
+	}
+}
+
+// GetContributorStats returns the commits, additions and deletions per author
+// of the non-merge commits reachable from ref, authored within since and
+// until if set, with the weekly commit counts of the last 52 weeks. The
+// history is read once per tip commit and cached.
+func (g *GitOperations) GetContributorStats(ctx context.Context, repoPath, ref string, since, until time.Time) (*service.ContributorActivity, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	tip, err := g.resolveRef(repo, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve ref '%s': %w", ref, err)
+	}
+
+	key := historyKey(repoPath, tip)
+	contributions, ok := g.contributions.get(key)
+	if !ok {
+		contributions, err = readContributions(ctx, repoPath, tip)
+		if err != nil {
+			return nil, err
+		}
+		g.contributions.put(key, contributions)
+	}
+
+	return &service.ContributorActivity{
+		Tip:          tip.String(),
+		Contributors: aggregateContributors(contributions, since, until),
+		Weeks:        weeklyCommits(contributions, time.Now()),
+	}, nil
+}
+
+// readContributions reads the author and line changes of the non-merge
+// commits reachable from tip, newest first. Names and emails follow .mailmap.
+func readContributions(ctx context.Context, repoPath string, tip plumbing.Hash) ([]contribution, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "log", "--no-merges", "--numstat",
+		"--format=%x00%aN%x00%aE%x00%at", tip.String())
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	contributions, parseErr := parseContributions(stdout)
+	if parseErr != nil {
+		_ = cmd.Wait()
+		return nil, parseErr
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w (stderr: %s)", err, stderr.String())
+	}
+	return contributions, nil
+}
+
+// parseContributions parses the output of git log --numstat with a header
+// line of "\x00name\x00email\x00timestamp" per commit. Binary files, counted
+// as "-", change no lines.
+func parseContributions(output io.Reader) ([]contribution, error) {
+	var contributions []contribution
+	strs := make(map[string]string) // Shares the name and email strings of an author
+	intern := func(s string) string {
+		if interned, ok := strs[s]; ok {
+			return interned
+		}
+		strs[s] = s
+		return s
+	}
+
+	scanner := bufio.NewScanner(output)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header, ok := strings.CutPrefix(line, "\x00"); ok {
+			fields := strings.Split(header, "\x00")
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("failed to parse commit header %q", line)
+			}
+			timestamp, err := strconv.ParseInt(fields[2], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse commit time %q: %w", fields[2], err)
+			}
+			contributions = append(contributions, contribution{
+				name:  intern(fields[0]),
+				email: intern(strings.ToLower(fields[1])),
+				when:  time.Unix(timestamp, 0).UTC(),
+			})
+			continue
+		}
+
+		if line == "" || len(contributions) == 0 {
+			continue
+		}
+		added, rest, ok := strings.Cut(line, "\t")
+		deleted, _, ok2 := strings.Cut(rest, "\t")
+		if !ok || !ok2 {
+			continue
+		}
+		current := &contributions[len(contributions)-1]
+		if n, err := strconv.ParseInt(added, 10, 64); err == nil {
+			current.additions += n
+		}
+		if n, err := strconv.ParseInt(deleted, 10, 64); err == nil {
+			current.deletions += n
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return contributions, nil
+}
+
+// aggregateContributors sums the contributions authored within since and
+// until (zero for no bound) per email, most commits first, then by email
+func aggregateContributors(contributions []contribution, since, until time.Time) []service.ContributorStats {
+	byEmail := make(map[string]*service.ContributorStats)
+	var order []*service.ContributorStats
+	for _, c := range contributions {
+		if (!since.IsZero() && c.when.Before(since)) || (!until.IsZero() && c.when.After(until)) {
+			continue
+		}
+
+		stats, ok := byEmail[c.email]
+		if !ok {
+			// Contributions are newest first: the first one names the author
+			stats = &service.ContributorStats{Name: c.name, Email: c.email, FirstCommit: c.when, LastCommit: c.when}
+			byEmail[c.email] = stats
+			order = append(order, stats)
+		}
+		stats.Commits++
+		stats.Additions += c.additions
+		stats.Deletions += c.deletions
+		if c.when.Before(stats.FirstCommit) {
+			stats.FirstCommit = c.when
+		}
+		if c.when.After(stats.LastCommit) {
+			stats.LastCommit = c.when
+		}
+	}
+
+	contributors := make([]service.ContributorStats, len(order))
+	for i, stats := range order {
+		contributors[i] = *stats
+	}
+	slices.SortFunc(contributors, func(a, b service.ContributorStats) int {
+		return cmp.Or(cmp.Compare(b.Commits, a.Commits), cmp.Compare(a.Email, b.Email))
+	})
+	return contributors
+}
+
+// weeklyCommits counts the contributions of each of the 52 weeks up to the
+// week of now, oldest first. Weeks start on Sunday, in UTC.
+func weeklyCommits(contributions []contribution, now time.Time) []service.WeeklyCommits {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	current := today.AddDate(0, 0, -int(today.Weekday()))
+	first := current.AddDate(0, 0, -7*(contributorActivityWeeks-1))
+
+	weeks := make([]service.WeeklyCommits, contributorActivityWeeks)
+	for i := range weeks {
+		weeks[i].Week = first.AddDate(0, 0, 7*i)
+	}
+	for _, c := range contributions {
+		if c.when.Before(first) {
+			continue
+		}
+		i := int(c.when.Sub(first) / (7 * 24 * time.Hour))
+		if i < len(weeks) {
+			weeks[i].Commits++
+		}
+	}
+	return weeks
+}
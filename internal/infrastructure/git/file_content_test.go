@@ -0,0 +1,56 @@
+package git_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/infrastructure/git"
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+func TestTreeAndFileSizes(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	large := bytes.Repeat([]byte("0123456789abcdef"), 1<<20) // 16 MiB
+	b := testutil.TempRepo(t)
+	b.Commit("main", "Initial commit",
+		testutil.File("empty.txt", ""),
+		testutil.File("docs/guide.md", "# Guide\n"),
+		testutil.BinaryFile("logo.png", png),
+		testutil.BinaryFile("data.bin", large),
+	)
+	ops := git.NewGitOperations(nil, nil)
+	ctx := context.Background()
+
+	entries, err := ops.GetTree(ctx, b.Path(), "main", "")
+	if err != nil {
+		t.Fatalf("GetTree: %v", err)
+	}
+	sizes := map[string]int64{}
+	for _, entry := range entries {
+		sizes[entry.Path] = entry.Size
+	}
+	want := map[string]int64{"empty.txt": 0, "docs": 0, "logo.png": int64(len(png)), "data.bin": int64(len(large))}
+	for path, size := range want {
+		if got, ok := sizes[path]; !ok || got != size {
+			t.Errorf("tree entry %s has size %d (listed %v), want %d", path, got, ok, size)
+		}
+	}
+
+	blob, err := ops.OpenFile(ctx, b.Path(), "main", "data.bin")
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	defer blob.Content.Close()
+	if blob.Size != int64(len(large)) {
+		t.Errorf("blob size = %d, want %d", blob.Size, len(large))
+	}
+	start := make([]byte, 8000)
+	if _, err := io.ReadFull(blob.Content, start); err != nil {
+		t.Fatalf("read the start of the blob: %v", err)
+	}
+	if !bytes.Equal(start, large[:8000]) {
+		t.Error("the start of the blob does not match the file")
+	}
+}
@@ -0,0 +1,98 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+)
+
+// CountObjects returns how the objects of a repository are stored
+func (g *GitOperations) CountObjects(ctx context.Context, repoPath string) (*service.ObjectCounts, error) {
+	// Sizes are reported in KiB
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "count-objects", "-v")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count objects: %w (stderr: %s)", err, stderr.String())
+	}
+
+	counts := &service.ObjectCounts{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ": ")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "count":
+			counts.LooseObjects = n
+		case "size":
+			counts.LooseSize = n * 1024
+		case "in-pack":
+			counts.PackedObjects = n
+		case "packs":
+			counts.Packs = n
+		case "size-pack":
+			counts.PackSize = n * 1024
+		case "garbage":
+			counts.Garbage = n
+		}
+	}
+	return counts, nil
+}
+
+// GarbageCollect runs git gc on a repository
+func (g *GitOperations) GarbageCollect(ctx context.Context, repoPath string, opts service.GCOptions) error {
+	args := []string{"-C", repoPath}
+	if opts.LooseObjectLimit > 0 {
+		args = append(args, "-c", "gc.auto="+strconv.Itoa(opts.LooseObjectLimit))
+	}
+	if opts.PackLimit > 0 {
+		args = append(args, "-c", "gc.autoPackLimit="+strconv.Itoa(opts.PackLimit))
+	}
+	// Collect in the foreground: the caller times the run and must know
+	// when it is over
+	args = append(args, "-c", "gc.autoDetach=false", "gc", "--quiet")
+	if opts.Auto {
+		args = append(args, "--auto")
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to collect garbage: %w (stderr: %s)", err, stderr.String())
+	}
+	return nil
+}
+
+// Repack packs all objects of a repository into a single pack. Unreachable
+// objects are kept rather than dropped, the packed ones in the new pack: a
+// fetch in flight may still read them, and the objects of a push that has not
+// updated its refs yet are unreachable. git gc prunes them later, past its
+// grace period.
+func (g *GitOperations) Repack(ctx context.Context, repoPath string) error {
+	for _, args := range [][]string{
+		{"-C", repoPath, "repack", "-a", "-d", "--keep-unreachable", "-q"},
+		{"-C", repoPath, "prune-packed", "-q"},
+	} {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to repack: %w (stderr: %s)", err, stderr.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package git_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/infrastructure/git"
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+func TestRepackKeepsUnreachableObjects(t *testing.T) {
+	b := testutil.TempRepo(t)
+	b.Commit("main", "Initial commit", testutil.File("README.md", "# demo\n"))
+	scratch := b.Commit("scratch", "Scratch", testutil.File("scratch.txt", "not referenced\n"))
+
+	ops := git.NewGitOperations(nil, nil)
+	ctx := context.Background()
+	if err := ops.Repack(ctx, b.Path()); err != nil {
+		t.Fatalf("Repack: %v", err)
+	}
+	// A packed commit no ref points at, like the pack of a push that has not
+	// updated its refs yet
+	if err := b.Repository().Storer.RemoveReference(plumbing.NewBranchReferenceName("scratch")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ops.Repack(ctx, b.Path()); err != nil {
+		t.Fatalf("Repack: %v", err)
+	}
+
+	counts, err := ops.CountObjects(ctx, b.Path())
+	if err != nil {
+		t.Fatalf("CountObjects: %v", err)
+	}
+	if counts.LooseObjects != 0 || counts.Packs != 1 {
+		t.Errorf("repacked into %d loose objects and %d packs, want 0 and 1", counts.LooseObjects, counts.Packs)
+	}
+
+	repo, err := gogit.PlainOpen(b.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CommitObject(scratch); err != nil {
+		t.Errorf("unreachable commit %s was dropped: %v", scratch, err)
+	}
+}
+
+func TestInfoRefsServesSnapshot(t *testing.T) {
+	b := testutil.TempRepo(t)
+	b.Commit("main", "Initial commit", testutil.File("README.md", "# demo\n"))
+	b.AnnotatedTag("v1.0.0", b.Commit("main", "Second commit", testutil.File("README.md", "# demo\n\nmore\n")), "Release")
+
+	protocol, err := git.NewGitProtocol(git.ReceiveLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	settings := models.DefaultUploadPackSettings()
+	infoRefs := func(snapshot []byte) []byte {
+		t.Helper()
+		var out bytes.Buffer
+		err := protocol.WriteInfoRefs(ctx, git.InfoRefsRequest{
+			RepoPath:   b.Path(),
+			Service:    git.ServiceUploadPack,
+			UploadPack: settings,
+			Snapshot:   snapshot,
+		}, &out)
+		if err != nil {
+			t.Fatalf("WriteInfoRefs: %v", err)
+		}
+		return out.Bytes()
+	}
+
+	snapshot, err := protocol.AdvertiseRefs(ctx, b.Path(), settings)
+	if err != nil {
+		t.Fatalf("AdvertiseRefs: %v", err)
+	}
+	live := infoRefs(nil)
+	if got := infoRefs(snapshot); !bytes.Equal(got, live) {
+		t.Errorf("info/refs from the snapshot:\n%s\nwant, as git advertises:\n%s", got, live)
+	}
+
+	// The snapshot outlives the refs it was taken from
+	b.Commit("main", "Third commit", testutil.File("README.md", "# demo\n"))
+	if got := infoRefs(snapshot); !bytes.Equal(got, live) {
+		t.Errorf("info/refs from the snapshot after a commit:\n%s\nwant:\n%s", got, live)
+	}
+}
@@ -6,16 +6,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
-	"unicode/utf8"
 
 	"github.com/bravo68web/stasis/internal/domain/service"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
 	"github.com/bravo68web/stasis/pkg/logger"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
@@ -23,33 +24,53 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
+const (
+	// maxCompareCommits bounds the commits listed by a comparison
+	maxCompareCommits = 250
+
+	// maxCompareDiffSize bounds the diff content returned by a comparison
+	maxCompareDiffSize = 5 * 1024 * 1024
+)
+
 // GitOperations implements the GitService interface using go-git library
 type GitOperations struct {
-	storage service.StorageService
-	log     *logger.Logger
+	storage       service.StorageService
+	signer        service.CommitSigner // nil if the commits created are not signed
+	histories     *historyCache
+	contributions *contributionCache
+	languages     *languageCache
+	log           *logger.Logger
 }
 
-// NewGitOperations creates a new GitOperations instance
-func NewGitOperations(storage service.StorageService) service.GitService {
+// NewGitOperations creates a new GitOperations instance signing the commits
+// it creates with signer, if not nil
+func NewGitOperations(storage service.StorageService, signer service.CommitSigner) service.GitService {
 	return &GitOperations{
-		storage: storage,
-		log:     logger.Get().WithFields(logger.Component("git-operations")),
+		storage:       storage,
+		signer:        signer,
+		histories:     newHistoryCache(maxCachedHistoryHashes),
+		contributions: newContributionCache(),
+		languages:     newLanguageCache(),
+		log:           logger.Get().WithFields(logger.Component("git-operations")),
 	}
 }
 
 // InitRepository initializes a new Git repository at the specified path
-func (g *GitOperations) InitRepository(ctx context.Context, repoPath string, bare bool) error {
-	g.log.Info("Initializing git repository",
+// with HEAD on initialBranch, or git's default if empty
+func (g *GitOperations) InitRepository(ctx context.Context, repoPath string, bare bool, initialBranch string) error {
+	g.log.WithContext(ctx).Info("Initializing git repository",
 		logger.String("repo_path", repoPath),
 		logger.Bool("bare", bare),
+		logger.String("initial_branch", initialBranch),
 	)
 
 	// Ensure the directory exists
 	if err := os.MkdirAll(repoPath, 0755); err != nil {
-		g.log.Error("Failed to create repository directory",
+		g.log.WithContext(ctx).Error("Failed to create repository directory",
 			logger.Error(err),
 			logger.String("repo_path", repoPath),
 		)
@@ -57,9 +78,14 @@ func (g *GitOperations) InitRepository(ctx context.Context, repoPath string, bar
 	}
 
 	// Initialize the repository
-	_, err := git.PlainInit(repoPath, bare)
+	var opts git.PlainInitOptions
+	opts.Bare = bare
+	if initialBranch != "" {
+		opts.InitOptions.DefaultBranch = plumbing.NewBranchReferenceName(initialBranch)
+	}
+	_, err := git.PlainInitWithOptions(repoPath, &opts)
 	if err != nil {
-		g.log.Error("Failed to initialize git repository",
+		g.log.WithContext(ctx).Error("Failed to initialize git repository",
 			logger.Error(err),
 			logger.String("repo_path", repoPath),
 		)
@@ -69,24 +95,25 @@ func (g *GitOperations) InitRepository(ctx context.Context, repoPath string, bar
 	// For bare repos, update server info to support dumb HTTP protocol
 	if bare {
 		if err := g.UpdateServerInfo(ctx, repoPath); err != nil {
-			g.log.Warn("Failed to update server info after init",
+			g.log.WithContext(ctx).Warn("Failed to update server info after init",
 				logger.Error(err),
 				logger.String("repo_path", repoPath),
 			)
 		}
 	}
 
-	g.log.Info("Git repository initialized successfully",
+	g.log.WithContext(ctx).Info("Git repository initialized successfully",
 		logger.String("repo_path", repoPath),
 	)
 
 	return nil
 }
 
-// CloneRepository clones a repository from source to destination
-func (g *GitOperations) CloneRepository(ctx context.Context, source, dest, username, password string, mirror bool) error {
-	g.log.Info("Cloning git repository",
-		logger.String("source", source),
+// CloneRepository clones a repository from source to destination, vetting
+// the addresses an http(s) clone connects to with control if not nil
+func (g *GitOperations) CloneRepository(ctx context.Context, source, dest, username, password string, mirror bool, control service.DialControl) error {
+	g.log.WithContext(ctx).Info("Cloning git repository",
+		logger.String("source", logger.Redact(source)),
 		logger.String("dest", dest),
 		logger.Bool("mirror", mirror),
 	)
@@ -105,18 +132,20 @@ func (g *GitOperations) CloneRepository(ctx context.Context, source, dest, usern
 		}
 	}
 
-	_, err := git.PlainClone(dest, mirror, cloneOptions)
+	ctx, release := withRemoteDialControl(ctx, control)
+	defer release()
+	_, err := git.PlainCloneContext(ctx, dest, mirror, cloneOptions)
 	if err != nil {
-		g.log.Error("Failed to clone repository",
+		g.log.WithContext(ctx).Error("Failed to clone repository",
 			logger.Error(err),
-			logger.String("source", source),
+			logger.String("source", logger.Redact(source)),
 			logger.String("dest", dest),
 		)
 		return fmt.Errorf("failed to clone repository: %w", err)
 	}
 
-	g.log.Info("Repository cloned successfully",
-		logger.String("source", source),
+	g.log.WithContext(ctx).Info("Repository cloned successfully",
+		logger.String("source", logger.Redact(source)),
 		logger.String("dest", dest),
 		logger.Bool("mirror", mirror),
 	)
@@ -126,14 +155,14 @@ func (g *GitOperations) CloneRepository(ctx context.Context, source, dest, usern
 
 // ConfigureMirror configures a repository as a mirror of the source
 func (g *GitOperations) ConfigureMirror(ctx context.Context, repoPath, sourceURL string) error {
-	g.log.Info("Configuring repository as mirror",
+	g.log.WithContext(ctx).Info("Configuring repository as mirror",
 		logger.String("repo_path", repoPath),
-		logger.String("source_url", sourceURL),
+		logger.String("source_url", logger.Redact(sourceURL)),
 	)
 
 	repo, err := git.PlainOpen(repoPath)
 	if err != nil {
-		g.log.Error("Failed to open repository",
+		g.log.WithContext(ctx).Error("Failed to open repository",
 			logger.Error(err),
 			logger.String("repo_path", repoPath),
 		)
@@ -143,7 +172,7 @@ func (g *GitOperations) ConfigureMirror(ctx context.Context, repoPath, sourceURL
 	// Set remote configuration for mirroring
 	config, err := repo.Config()
 	if err != nil {
-		g.log.Error("Failed to get repository config",
+		g.log.WithContext(ctx).Error("Failed to get repository config",
 			logger.Error(err),
 			logger.String("repo_path", repoPath),
 		)
@@ -163,31 +192,32 @@ func (g *GitOperations) ConfigureMirror(ctx context.Context, repoPath, sourceURL
 	}
 
 	if err := repo.SetConfig(config); err != nil {
-		g.log.Error("Failed to set repository config",
+		g.log.WithContext(ctx).Error("Failed to set repository config",
 			logger.Error(err),
 			logger.String("repo_path", repoPath),
 		)
 		return fmt.Errorf("failed to set repository config: %w", err)
 	}
 
-	g.log.Info("Repository configured as mirror successfully",
+	g.log.WithContext(ctx).Info("Repository configured as mirror successfully",
 		logger.String("repo_path", repoPath),
-		logger.String("source_url", sourceURL),
+		logger.String("source_url", logger.Redact(sourceURL)),
 	)
 
 	return nil
 }
 
-// FetchMirror fetches updates from the mirror source repository
-func (g *GitOperations) FetchMirror(ctx context.Context, repoPath, sourceURL string) error {
-	g.log.Info("Fetching mirror updates",
+// FetchMirror fetches updates from the mirror source repository, vetting
+// the addresses an http(s) fetch connects to with control if not nil
+func (g *GitOperations) FetchMirror(ctx context.Context, repoPath, sourceURL string, control service.DialControl) error {
+	g.log.WithContext(ctx).Info("Fetching mirror updates",
 		logger.String("repo_path", repoPath),
-		logger.String("source_url", sourceURL),
+		logger.String("source_url", logger.Redact(sourceURL)),
 	)
 
 	repo, err := git.PlainOpen(repoPath)
 	if err != nil {
-		g.log.Error("Failed to open repository",
+		g.log.WithContext(ctx).Error("Failed to open repository",
 			logger.Error(err),
 			logger.String("repo_path", repoPath),
 		)
@@ -197,7 +227,7 @@ func (g *GitOperations) FetchMirror(ctx context.Context, repoPath, sourceURL str
 	// Get the remote
 	remote, err := repo.Remote("origin")
 	if err != nil {
-		g.log.Error("Failed to get origin remote",
+		g.log.WithContext(ctx).Error("Failed to get origin remote",
 			logger.Error(err),
 			logger.String("repo_path", repoPath),
 		)
@@ -205,7 +235,9 @@ func (g *GitOperations) FetchMirror(ctx context.Context, repoPath, sourceURL str
 	}
 
 	// Fetch with mirror option
-	err = remote.Fetch(&git.FetchOptions{
+	ctx, release := withRemoteDialControl(ctx, control)
+	defer release()
+	err = remote.FetchContext(ctx, &git.FetchOptions{
 		RemoteName: "origin",
 		RefSpecs:   []gitconfig.RefSpec{"+refs/*:refs/*"},
 		Force:      true,
@@ -213,22 +245,22 @@ func (g *GitOperations) FetchMirror(ctx context.Context, repoPath, sourceURL str
 	})
 
 	if err != nil && err != git.NoErrAlreadyUpToDate {
-		g.log.Error("Failed to fetch mirror updates",
+		g.log.WithContext(ctx).Error("Failed to fetch mirror updates",
 			logger.Error(err),
 			logger.String("repo_path", repoPath),
-			logger.String("source_url", sourceURL),
+			logger.String("source_url", logger.Redact(sourceURL)),
 		)
 		return fmt.Errorf("failed to fetch mirror updates: %w", err)
 	}
 
 	if err == git.NoErrAlreadyUpToDate {
-		g.log.Info("Mirror already up to date",
+		g.log.WithContext(ctx).Info("Mirror already up to date",
 			logger.String("repo_path", repoPath),
 		)
 	} else {
-		g.log.Info("Mirror fetched successfully",
+		g.log.WithContext(ctx).Info("Mirror fetched successfully",
 			logger.String("repo_path", repoPath),
-			logger.String("source_url", sourceURL),
+			logger.String("source_url", logger.Redact(sourceURL)),
 		)
 	}
 
@@ -237,14 +269,14 @@ func (g *GitOperations) FetchMirror(ctx context.Context, repoPath, sourceURL str
 
 // PushMirror pushes updates to a downstream mirror repository
 func (g *GitOperations) PushMirror(ctx context.Context, repoPath, destURL, username, password string) error {
-	g.log.Info("Pushing to downstream mirror",
+	g.log.WithContext(ctx).Info("Pushing to downstream mirror",
 		logger.String("repo_path", repoPath),
-		logger.String("dest_url", destURL),
+		logger.String("dest_url", logger.Redact(destURL)),
 	)
 
 	repo, err := git.PlainOpen(repoPath)
 	if err != nil {
-		g.log.Error("Failed to open repository",
+		g.log.WithContext(ctx).Error("Failed to open repository",
 			logger.Error(err),
 			logger.String("repo_path", repoPath),
 		)
@@ -256,7 +288,7 @@ func (g *GitOperations) PushMirror(ctx context.Context, repoPath, destURL, usern
 	remote, err := repo.Remote(remoteName)
 	if err != nil {
 		// Remote doesn't exist, create it
-		g.log.Debug("Creating downstream remote",
+		g.log.WithContext(ctx).Debug("Creating downstream remote",
 			logger.String("repo_path", repoPath),
 			logger.String("remote_name", remoteName),
 		)
@@ -265,7 +297,7 @@ func (g *GitOperations) PushMirror(ctx context.Context, repoPath, destURL, usern
 			URLs: []string{destURL},
 		})
 		if err != nil {
-			g.log.Error("Failed to create downstream remote",
+			g.log.WithContext(ctx).Error("Failed to create downstream remote",
 				logger.Error(err),
 				logger.String("repo_path", repoPath),
 			)
@@ -291,22 +323,22 @@ func (g *GitOperations) PushMirror(ctx context.Context, repoPath, destURL, usern
 
 	err = remote.Push(pushOptions)
 	if err != nil && err != git.NoErrAlreadyUpToDate {
-		g.log.Error("Failed to push to downstream mirror",
+		g.log.WithContext(ctx).Error("Failed to push to downstream mirror",
 			logger.Error(err),
 			logger.String("repo_path", repoPath),
-			logger.String("dest_url", destURL),
+			logger.String("dest_url", logger.Redact(destURL)),
 		)
 		return fmt.Errorf("failed to push to downstream mirror: %w", err)
 	}
 
 	if err == git.NoErrAlreadyUpToDate {
-		g.log.Info("Downstream mirror already up to date",
+		g.log.WithContext(ctx).Info("Downstream mirror already up to date",
 			logger.String("repo_path", repoPath),
 		)
 	} else {
-		g.log.Info("Pushed to downstream mirror successfully",
+		g.log.WithContext(ctx).Info("Pushed to downstream mirror successfully",
 			logger.String("repo_path", repoPath),
-			logger.String("dest_url", destURL),
+			logger.String("dest_url", logger.Redact(destURL)),
 		)
 	}
 
@@ -315,19 +347,19 @@ func (g *GitOperations) PushMirror(ctx context.Context, repoPath, destURL, usern
 
 // DeleteRepository removes a repository from the storage
 func (g *GitOperations) DeleteRepository(ctx context.Context, repoPath string) error {
-	g.log.Info("Deleting git repository",
+	g.log.WithContext(ctx).Info("Deleting git repository",
 		logger.String("repo_path", repoPath),
 	)
 
 	if err := os.RemoveAll(repoPath); err != nil {
-		g.log.Error("Failed to delete repository",
+		g.log.WithContext(ctx).Error("Failed to delete repository",
 			logger.Error(err),
 			logger.String("repo_path", repoPath),
 		)
 		return fmt.Errorf("failed to delete repository: %w", err)
 	}
 
-	g.log.Info("Repository deleted successfully",
+	g.log.WithContext(ctx).Info("Repository deleted successfully",
 		logger.String("repo_path", repoPath),
 	)
 
@@ -394,6 +426,33 @@ func (g *GitOperations) GetHEADRef(ctx context.Context, repoPath string) (string
 	return head.Hash().String(), nil
 }
 
+// IsEmpty returns true if the repository has no branches or tags
+func (g *GitOperations) IsEmpty(ctx context.Context, repoPath string) (bool, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	refs, err := repo.References()
+	if err != nil {
+		return false, fmt.Errorf("failed to list references: %w", err)
+	}
+	defer refs.Close()
+
+	empty := true
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference && (ref.Name().IsBranch() || ref.Name().IsTag()) {
+			empty = false
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to iterate references: %w", err)
+	}
+	return empty, nil
+}
+
 // CreateBranch creates a new branch pointing to the specified commit
 func (g *GitOperations) CreateBranch(ctx context.Context, repoPath, branchName, commitHash string) error {
 	repo, err := git.PlainOpen(repoPath)
@@ -464,11 +523,13 @@ func (g *GitOperations) ListBranches(ctx context.Context, repoPath string) ([]se
 	}
 
 	err = refIter.ForEach(func(ref *plumbing.Reference) error {
+		// A branch that cannot be counted is listed without its count
+		commitCount, _ := g.CountCommits(ctx, repoPath, ref.Name().Short())
 		branches = append(branches, service.Branch{
 			Name:        ref.Name().Short(),
 			Hash:        ref.Hash().String(),
 			IsHead:      ref.Name().Short() == headName,
-			CommitCount: g.CountCommits(ctx, repoPath, ref.Name().Short()),
+			CommitCount: commitCount,
 		})
 		return nil
 	})
@@ -479,29 +540,6 @@ func (g *GitOperations) ListBranches(ctx context.Context, repoPath string) ([]se
 	return branches, nil
 }
 
-func (g *GitOperations) CountCommits(ctx context.Context, repoPath string, branchName string) int {
-	repo, err := git.PlainOpen(repoPath)
-	if err != nil {
-		return 0
-	}
-
-	commits, err := repo.CommitObjects()
-	if err != nil {
-		return 0
-	}
-
-	var commitCount int
-	err = commits.ForEach(func(commit *object.Commit) error {
-		commitCount++
-		return nil
-	})
-	if err != nil {
-		return 0
-	}
-
-	return int(commitCount)
-}
-
 // GetBranch returns information about a specific branch
 func (g *GitOperations) GetBranch(ctx context.Context, repoPath, branchName string) (*service.Branch, error) {
 	repo, err := git.PlainOpen(repoPath)
@@ -604,7 +642,10 @@ func (g *GitOperations) ListTags(ctx context.Context, repoPath string) ([]servic
 		if err == nil {
 			tag.Message = tagObj.Message
 			tag.Tagger = tagObj.Tagger.String()
+			tag.Date = tagObj.Tagger.When
 			tag.IsLight = false
+		} else if commit, err := repo.CommitObject(ref.Hash()); err == nil {
+			tag.Date = commit.Committer.When
 		}
 
 		tags = append(tags, tag)
@@ -644,7 +685,10 @@ func (g *GitOperations) GetTag(ctx context.Context, repoPath, tagName string) (*
 	if err == nil {
 		tag.Message = tagObj.Message
 		tag.Tagger = tagObj.Tagger.String()
+		tag.Date = tagObj.Tagger.When
 		tag.IsLight = false
+	} else if commit, err := repo.CommitObject(ref.Hash()); err == nil {
+		tag.Date = commit.Committer.When
 	}
 
 	return tag, nil
@@ -703,8 +747,9 @@ func (g *GitOperations) GetInfoRefs(ctx context.Context, repoPath, serviceName s
 	buf.WriteString(pktHeader)
 	buf.WriteString("0000") // Flush packet
 
-	// Get refs using git command
-	cmd := exec.CommandContext(ctx, "git", serviceName, "--stateless-rpc", "--advertise-refs", repoPath)
+	// Get refs using git command; git has no "git-" prefixed subcommands, and
+	// the upload-pack settings come from the git config of the repository
+	cmd := exec.CommandContext(ctx, "git", strings.TrimPrefix(service, "git-"), "--stateless-rpc", "--advertise-refs", repoPath)
 	cmd.Stdout = &buf
 	cmd.Stderr = os.Stderr
 	cmd.Dir = repoPath
@@ -869,7 +914,7 @@ func (g *GitOperations) GetHEADBranch(ctx context.Context, repoPath string) (str
 
 // SetHEADBranch sets the default branch (HEAD) for a bare repository
 func (g *GitOperations) SetHEADBranch(ctx context.Context, repoPath, branchName string) error {
-	g.log.Debug("Setting default branch",
+	g.log.WithContext(ctx).Debug("Setting default branch",
 		logger.String("repo_path", repoPath),
 		logger.String("branch", branchName),
 	)
@@ -896,7 +941,7 @@ func (g *GitOperations) SetHEADBranch(ctx context.Context, repoPath, branchName
 		return fmt.Errorf("failed to set HEAD: %w", err)
 	}
 
-	g.log.Info("Default branch set successfully",
+	g.log.WithContext(ctx).Info("Default branch set successfully",
 		logger.String("repo_path", repoPath),
 		logger.String("branch", branchName),
 	)
@@ -936,7 +981,7 @@ func GetPackPath(repoPath string) string {
 	return filepath.Join(repoPath, "objects", "pack")
 }
 
-// GetCommits returns a list of commits for a given ref
+// GetCommits returns a list of commits for a given ref, newest first
 func (g *GitOperations) GetCommits(ctx context.Context, repoPath, ref string, limit, offset int) ([]service.Commit, error) {
 	repo, err := git.PlainOpen(repoPath)
 	if err != nil {
@@ -949,57 +994,18 @@ func (g *GitOperations) GetCommits(ctx context.Context, repoPath, ref string, li
 		return nil, fmt.Errorf("failed to resolve ref '%s': %w", ref, err)
 	}
 
-	// Get the commit iterator starting from the resolved hash
-	commitIter, err := repo.Log(&git.LogOptions{
-		From:  hash,
-		Order: git.LogOrderCommitterTime,
-	})
+	hashes, err := g.history(ctx, repoPath, hash, offset, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get commit log: %w", err)
+		return nil, err
 	}
-	defer commitIter.Close()
-
-	commits := []service.Commit{}
-	count := 0
-	skipped := 0
-
-	err = commitIter.ForEach(func(c *object.Commit) error {
-		// Skip commits until we reach the offset
-		if skipped < offset {
-			skipped++
-			return nil
-		}
-
-		// Stop if we've reached the limit
-		if limit > 0 && count >= limit {
-			return fmt.Errorf("limit reached")
-		}
 
-		parentHashes := make([]string, len(c.ParentHashes))
-		for i, ph := range c.ParentHashes {
-			parentHashes[i] = ph.String()
+	commits := make([]service.Commit, 0, len(hashes))
+	for _, h := range hashes {
+		c, err := repo.CommitObject(h)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get commit %s: %w", h, err)
 		}
-
-		commits = append(commits, service.Commit{
-			Hash:           c.Hash.String(),
-			ShortHash:      c.Hash.String()[:7],
-			Message:        c.Message,
-			Author:         c.Author.Name,
-			AuthorEmail:    c.Author.Email,
-			AuthorDate:     c.Author.When,
-			Committer:      c.Committer.Name,
-			CommitterEmail: c.Committer.Email,
-			CommitterDate:  c.Committer.When,
-			ParentHashes:   parentHashes,
-		})
-
-		count++
-		return nil
-	})
-
-	// Ignore "limit reached" error
-	if err != nil && err.Error() != "limit reached" {
-		return nil, fmt.Errorf("failed to iterate commits: %w", err)
+		commits = append(commits, commitFromObject(c))
 	}
 
 	return commits, nil
@@ -1021,12 +1027,18 @@ func (g *GitOperations) GetCommit(ctx context.Context, repoPath, commitHash stri
 		return nil, fmt.Errorf("failed to get commit: %w", err)
 	}
 
+	commit := commitFromObject(c)
+	return &commit, nil
+}
+
+// commitFromObject converts a go-git commit
+func commitFromObject(c *object.Commit) service.Commit {
 	parentHashes := make([]string, len(c.ParentHashes))
 	for i, ph := range c.ParentHashes {
 		parentHashes[i] = ph.String()
 	}
 
-	return &service.Commit{
+	return service.Commit{
 		Hash:           c.Hash.String(),
 		ShortHash:      c.Hash.String()[:7],
 		Message:        c.Message,
@@ -1037,7 +1049,7 @@ func (g *GitOperations) GetCommit(ctx context.Context, repoPath, commitHash stri
 		CommitterEmail: c.Committer.Email,
 		CommitterDate:  c.Committer.When,
 		ParentHashes:   parentHashes,
-	}, nil
+	}
 }
 
 // GetTree returns the tree entries for a given ref and path
@@ -1150,7 +1162,7 @@ func (g *GitOperations) GetFileContent(ctx context.Context, repoPath, ref, fileP
 	}
 
 	// Check if the file is binary
-	isBinary := isBinaryContent(content)
+	isBinary := service.IsBinaryContent(content, false)
 	encoding := "utf-8"
 	if isBinary {
 		encoding = "base64"
@@ -1170,6 +1182,38 @@ func (g *GitOperations) GetFileContent(ctx context.Context, repoPath, ref, fileP
 	}, nil
 }
 
+// OpenFile opens the content of a file at a given ref and path for streaming
+func (g *GitOperations) OpenFile(ctx context.Context, repoPath, ref, filePath string) (*service.FileBlob, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	hash, err := g.resolveRef(repo, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve ref '%s': %w", ref, err)
+	}
+
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit: %w", err)
+	}
+
+	filePath = strings.TrimPrefix(filePath, "/")
+	file, err := commit.File(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file '%s': %w", filePath, err)
+	}
+
+	return &service.FileBlob{
+		Path:    filePath,
+		Name:    filepath.Base(filePath),
+		Size:    file.Size,
+		Hash:    file.Hash.String(),
+		Content: newBlobReader(&file.Blob),
+	}, nil
+}
+
 // resolveRef resolves a ref string to a commit hash
 // It handles branch names, tag names, and commit hashes
 func (g *GitOperations) resolveRef(repo *git.Repository, ref string) (plumbing.Hash, error) {
@@ -1220,35 +1264,6 @@ func (g *GitOperations) resolveRef(repo *git.Repository, ref string) (plumbing.H
 	return plumbing.ZeroHash, fmt.Errorf("unable to resolve ref: %s", ref)
 }
 
-// isBinaryContent checks if the content appears to be binary
-func isBinaryContent(content []byte) bool {
-	// Check for null bytes (common in binary files)
-	if bytes.Contains(content[:min(len(content), 8000)], []byte{0}) {
-		return true
-	}
-
-	// Use http.DetectContentType to check MIME type
-	contentType := http.DetectContentType(content)
-	if strings.HasPrefix(contentType, "text/") || contentType == "application/json" || contentType == "application/xml" {
-		return false
-	}
-
-	// Check if content is valid UTF-8
-	if !utf8.Valid(content) {
-		return true
-	}
-
-	return false
-}
-
-// min returns the smaller of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
 // GetBlame returns blame information for a file at a given ref
 func (g *GitOperations) GetBlame(ctx context.Context, repoPath, ref, filePath string) ([]service.BlameLine, error) {
 	repo, err := git.PlainOpen(repoPath)
@@ -1394,60 +1409,436 @@ func (g *GitOperations) GetDiff(ctx context.Context, repoPath, commitHash string
 
 // GetCompareDiff returns the diff between two commits
 func (g *GitOperations) GetCompareDiff(ctx context.Context, repoPath, from, to string) (*service.DiffResult, error) {
-	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "diff", "--format=", "-p", from+".."+to)
+	result, err := g.compareDiff(ctx, repoPath, from, to, nil)
+	if err != nil {
+		return nil, err
+	}
+	result.CommitHash = from + ".." + to
+	return result, nil
+}
+
+// GetForkCompareDiff returns the changes of head in the fork since its merge
+// base with base. The fork's objects are read through GIT_ALTERNATE_OBJECT_DIRECTORIES,
+// so nothing is fetched into or written to the base repository.
+func (g *GitOperations) GetForkCompareDiff(ctx context.Context, repoPath, base, forkPath, head string) (*service.DiffResult, error) {
+	baseHash, err := g.revParseCommit(ctx, repoPath, base)
+	if err != nil {
+		return nil, err
+	}
+	headHash, err := g.revParseCommit(ctx, forkPath, head)
+	if err != nil {
+		return nil, err
+	}
+
+	env := gitEnv("GIT_ALTERNATE_OBJECT_DIRECTORIES=" + filepath.Join(forkPath, "objects"))
+
+	mergeBase, err := g.mergeBase(ctx, repoPath, baseHash, headHash, env)
+	if err != nil {
+		return nil, fmt.Errorf("%s and %s: %w", base, head, err)
+	}
+
+	result, err := g.compareDiff(ctx, repoPath, mergeBase, headHash, env)
+	if err != nil {
+		return nil, err
+	}
+	result.CommitHash = base + "..." + head
+	result.MergeBase = mergeBase
+	return result, nil
+}
+
+// CompareRefs compares head with base, both revisions of the repository
+func (g *GitOperations) CompareRefs(ctx context.Context, repoPath, base, head string) (*service.Comparison, error) {
+	baseHash, err := g.revParseCommit(ctx, repoPath, base)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, apperror.NotFound("revision "+base, err)
+	}
+	headHash, err := g.revParseCommit(ctx, repoPath, head)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, apperror.NotFound("revision "+head, err)
+	}
+
+	mergeBase, err := g.mergeBase(ctx, repoPath, baseHash, headHash, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s and %s: %w", base, head, err)
+	}
+
+	commits, total, err := g.commitsBetween(ctx, repoPath, baseHash, headHash)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := g.compareDiff(ctx, repoPath, mergeBase, headHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	diff.CommitHash = base + "..." + head
+	diff.MergeBase = mergeBase
+
+	return &service.Comparison{
+		Base:         baseHash,
+		Head:         headHash,
+		Commits:      commits,
+		TotalCommits: total,
+		Diff:         diff,
+	}, nil
+}
+
+// mergeBase returns the best common ancestor of two commits, running git with
+// env if set. It returns errors.ErrNoMergeBase if they share no history.
+func (g *GitOperations) mergeBase(ctx context.Context, repoPath, a, b string, env []string) (string, error) {
+	// merge-base exits with 1 and no output when the commits share no history
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "merge-base", a, b)
+	cmd.Env = env
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("failed to get compare diff %s..%s: %w (stderr: %s)", from, to, err, stderr.String())
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && stderr.Len() == 0 {
+			return "", apperror.ErrNoMergeBase
+		}
+		return "", fmt.Errorf("failed to find merge base: %w (stderr: %s)", err, stderr.String())
 	}
-	content := stdout.String()
+	return strings.TrimSpace(stdout.String()), nil
+}
 
-	statsCmd := exec.CommandContext(ctx, "git", "-C", repoPath, "diff", "--format=", "--stat", "--numstat", from+".."+to)
-	var statsStdout, statsStderr bytes.Buffer
-	statsCmd.Stdout = &statsStdout
-	statsCmd.Stderr = &statsStderr
+// commitsBetween lists the commits reachable from head but not from base,
+// newest first and at most maxCompareCommits, with their total number
+func (g *GitOperations) commitsBetween(ctx context.Context, repoPath, base, head string) ([]service.Commit, int, error) {
+	rng := base + ".." + head
 
-	var filesChanged, additions, deletions int
-	var files []service.DiffFile
+	countCmd := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-list", "--count", rng)
+	var countOut, countErr bytes.Buffer
+	countCmd.Stdout = &countOut
+	countCmd.Stderr = &countErr
+	if err := countCmd.Run(); err != nil {
+		return nil, 0, fmt.Errorf("failed to count commits %s: %w (stderr: %s)", rng, err, countErr.String())
+	}
+	total, err := strconv.Atoi(strings.TrimSpace(countOut.String()))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count commits %s: %w", rng, err)
+	}
 
-	if err := statsCmd.Run(); err == nil {
-		lines := strings.Split(statsStdout.String(), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
-			parts := strings.Split(line, "\t")
-			if len(parts) >= 3 {
-				add, _ := strconv.Atoi(parts[0])
-				del, _ := strconv.Atoi(parts[1])
-				filePath := parts[2]
-				additions += add
-				deletions += del
-				filesChanged++
-				status := "modified"
-				oldPath := filePath
-				newPath := filePath
-				if strings.Contains(filePath, " => ") {
-					status = "renamed"
-					renameParts := strings.Split(filePath, " => ")
-					if len(renameParts) == 2 {
-						oldPath = strings.TrimSpace(renameParts[0])
-						newPath = strings.TrimSpace(renameParts[1])
-					}
+	// One NUL-separated field per placeholder, commits are NUL-terminated
+	const fields = 9
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "log", "-z",
+		"--max-count="+strconv.Itoa(maxCompareCommits),
+		"--format=%H%x00%P%x00%an%x00%ae%x00%aI%x00%cn%x00%ce%x00%cI%x00%B",
+		rng)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list commits %s: %w (stderr: %s)", rng, err, stderr.String())
+	}
+
+	tokens := strings.Split(stdout.String(), "\x00")
+	commits := make([]service.Commit, 0, len(tokens)/fields)
+	for i := 0; i+fields <= len(tokens); i += fields {
+		f := tokens[i : i+fields]
+		authorDate, _ := time.Parse(time.RFC3339, f[4])
+		committerDate, _ := time.Parse(time.RFC3339, f[7])
+		commits = append(commits, service.Commit{
+			Hash:           f[0],
+			ShortHash:      f[0][:min(7, len(f[0]))],
+			Message:        f[8],
+			Author:         f[2],
+			AuthorEmail:    f[3],
+			AuthorDate:     authorDate,
+			Committer:      f[5],
+			CommitterEmail: f[6],
+			CommitterDate:  committerDate,
+			ParentHashes:   strings.Fields(f[1]),
+		})
+	}
+	return commits, total, nil
+}
+
+// GetChangedPaths returns the paths of the files changed between two commits
+func (g *GitOperations) GetChangedPaths(ctx context.Context, repoPath, from, to string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "diff", "--name-only", "-z", "--no-renames", from, to, "--")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to list changed paths %s..%s: %w (stderr: %s)", from, to, err, stderr.String())
+	}
+
+	var paths []string
+	for _, path := range strings.Split(stdout.String(), "\x00") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// CreateMergeCommit creates a commit merging head into base without updating any ref
+func (g *GitOperations) CreateMergeCommit(ctx context.Context, repoPath, base, head, message string, author service.Signature) (string, error) {
+	mergeBase, err := g.mergeBase(ctx, repoPath, base, head, nil)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNoMergeBase) {
+			return "", apperror.Conflict("no common history", err)
+		}
+		return "", err
+	}
+	if mergeBase == head {
+		return "", apperror.Conflict("nothing to merge", apperror.ErrNothingToMerge)
+	}
+
+	// merge-tree exits with 1 on conflicts, listing the conflicting paths after the tree
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "merge-tree", "--write-tree", "--name-only", "--no-messages", "-z", base, head)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	fields := strings.Split(stdout.String(), "\x00")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			conflicts := []string{}
+			for _, path := range fields[1:] {
+				if path != "" && !slices.Contains(conflicts, path) {
+					conflicts = append(conflicts, path)
 				}
-				files = append(files, service.DiffFile{
-					OldPath:   oldPath,
-					NewPath:   newPath,
-					Status:    status,
-					Additions: add,
-					Deletions: del,
-				})
 			}
+			return "", apperror.Conflict("merge conflict", apperror.ErrMergeConflict).
+				WithDetails(map[string]interface{}{"conflicts": conflicts})
+		}
+		return "", fmt.Errorf("failed to merge %s into %s: %w (stderr: %s)", head, base, err, stderr.String())
+	}
+
+	committer := g.committerOf(author)
+	commitCmd := exec.CommandContext(ctx, "git", "-C", repoPath, "commit-tree", fields[0], "-p", base, "-p", head, "-F", "-")
+	commitCmd.Env = gitEnv(
+		"GIT_AUTHOR_NAME="+author.Name,
+		"GIT_AUTHOR_EMAIL="+author.Email,
+		"GIT_COMMITTER_NAME="+committer.Name,
+		"GIT_COMMITTER_EMAIL="+committer.Email,
+	)
+	commitCmd.Stdin = strings.NewReader(message)
+	stdout.Reset()
+	stderr.Reset()
+	commitCmd.Stdout = &stdout
+	commitCmd.Stderr = &stderr
+	if err := commitCmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to create merge commit: %w (stderr: %s)", err, stderr.String())
+	}
+	return g.signCommit(repoPath, strings.TrimSpace(stdout.String()))
+}
+
+// UpdateRef points refName at newHash if it still points at oldHash
+func (g *GitOperations) UpdateRef(ctx context.Context, repoPath, refName, newHash, oldHash string) error {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "update-ref", refName, newHash, oldHash)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if current, revErr := g.revParseCommit(ctx, repoPath, refName); revErr == nil && current != oldHash {
+			return fmt.Errorf("%s: %w", refName, apperror.ErrRefChanged)
+		}
+		return fmt.Errorf("failed to update %s: %w (stderr: %s)", refName, err, stderr.String())
+	}
+	return nil
+}
+
+// CreateInitialCommit commits files as the root commit of a new branch,
+// using a temporary index so the repository needs no work tree
+func (g *GitOperations) CreateInitialCommit(ctx context.Context, repoPath, branch string, files map[string][]byte, message string, author service.Signature) (string, error) {
+	indexDir, err := os.MkdirTemp("", "stasis-index-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	defer os.RemoveAll(indexDir)
+	env := gitEnv("GIT_INDEX_FILE=" + filepath.Join(indexDir, "index"))
+
+	run := func(stdin []byte, args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repoPath}, args...)...)
+		cmd.Env = env
+		var stdout, stderr bytes.Buffer
+		cmd.Stdin = bytes.NewReader(stdin)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("git %s failed: %w (stderr: %s)", args[0], err, stderr.String())
+		}
+		return strings.TrimSpace(stdout.String()), nil
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		blob, err := run(files[path], "hash-object", "-w", "--stdin")
+		if err != nil {
+			return "", err
+		}
+		if _, err := run(nil, "update-index", "--add", "--cacheinfo", "100644,"+blob+","+path); err != nil {
+			return "", err
+		}
+	}
+	tree, err := run(nil, "write-tree")
+	if err != nil {
+		return "", err
+	}
+	return g.commitRoot(ctx, repoPath, branch, tree, message, author)
+}
+
+// CommitDirectory commits the files under dir as the root commit of a new
+// branch, keeping their executable bits and symbolic links. Ignore rules
+// found in dir do not apply. A temporary index is used so the repository
+// needs no work tree.
+func (g *GitOperations) CommitDirectory(ctx context.Context, repoPath, branch, dir, message string, author service.Signature) (string, error) {
+	indexDir, err := os.MkdirTemp("", "stasis-index-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	defer os.RemoveAll(indexDir)
+
+	var tree string
+	for _, args := range [][]string{
+		{"add", "--all", "--force", "--", "."},
+		{"write-tree"},
+	} {
+		cmd := exec.CommandContext(ctx, "git", append([]string{"--git-dir", repoPath, "--work-tree", dir}, args...)...)
+		cmd.Dir = dir
+		cmd.Env = gitEnv("GIT_INDEX_FILE=" + filepath.Join(indexDir, "index"))
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("git %s failed: %w (stderr: %s)", args[0], err, stderr.String())
+		}
+		tree = strings.TrimSpace(stdout.String())
+	}
+	return g.commitRoot(ctx, repoPath, branch, tree, message, author)
+}
+
+// commitRoot commits tree as the root commit of a new branch, signed like
+// merge commits, and returns its hash
+func (g *GitOperations) commitRoot(ctx context.Context, repoPath, branch, tree, message string, author service.Signature) (string, error) {
+	committer := g.committerOf(author)
+	env := gitEnv(
+		"GIT_AUTHOR_NAME="+author.Name,
+		"GIT_AUTHOR_EMAIL="+author.Email,
+		"GIT_COMMITTER_NAME="+committer.Name,
+		"GIT_COMMITTER_EMAIL="+committer.Email,
+	)
+
+	run := func(stdin []byte, args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repoPath}, args...)...)
+		cmd.Env = env
+		var stdout, stderr bytes.Buffer
+		cmd.Stdin = bytes.NewReader(stdin)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("git %s failed: %w (stderr: %s)", args[0], err, stderr.String())
+		}
+		return strings.TrimSpace(stdout.String()), nil
+	}
+
+	commit, err := run([]byte(message), "commit-tree", tree, "-F", "-")
+	if err != nil {
+		return "", err
+	}
+	if commit, err = g.signCommit(repoPath, commit); err != nil {
+		return "", err
+	}
+
+	// An empty old value makes update-ref fail if the branch exists
+	refName := "refs/heads/" + branch
+	if _, err := run(nil, "update-ref", refName, commit, ""); err != nil {
+		if _, revErr := g.revParseCommit(ctx, repoPath, refName); revErr == nil {
+			return "", apperror.Conflict("branch already exists", apperror.ErrBranchExists)
+		}
+		return "", err
+	}
+	return commit, nil
+}
+
+// CreateBundle writes a git bundle of all the refs of the repository to w
+func (g *GitOperations) CreateBundle(ctx context.Context, repoPath string, w io.Writer) error {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "bundle", "create", "--quiet", "-", "--all")
+	var stderr bytes.Buffer
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to create bundle: %w (stderr: %s)", err, stderr.String())
+	}
+	return nil
+}
+
+// RestoreBundle creates a bare repository at repoPath holding the refs and
+// objects of the git bundle at bundlePath
+func (g *GitOperations) RestoreBundle(ctx context.Context, repoPath, bundlePath string) error {
+	if err := os.MkdirAll(repoPath, 0755); err != nil {
+		return fmt.Errorf("failed to create repository directory: %w", err)
+	}
+
+	for _, args := range [][]string{
+		{"init", "--quiet", "--bare", repoPath},
+		// The bundle is checked as it is fetched: objects must be complete and valid
+		{"-C", repoPath, "fetch", "--quiet", "--no-write-fetch-head", bundlePath, "+refs/*:refs/*"},
+	} {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to restore bundle: %w (stderr: %s)", err, stderr.String())
 		}
 	}
 
+	if err := g.UpdateServerInfo(ctx, repoPath); err != nil {
+		g.log.WithContext(ctx).Warn("Failed to update server info after restoring bundle",
+			logger.Error(err),
+			logger.String("repo_path", repoPath),
+		)
+	}
+	return nil
+}
+
+// revParseCommit resolves a revision to a commit hash
+func (g *GitOperations) revParseCommit(ctx context.Context, repoPath, rev string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-parse", "--verify", "--end-of-options", rev+"^{commit}")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w (stderr: %s)", rev, err, stderr.String())
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// compareDiff returns the diff between two commits, running git with env if
+// set. Content is cut at a line boundary past maxCompareDiffSize bytes.
+func (g *GitOperations) compareDiff(ctx context.Context, repoPath, from, to string, env []string) (*service.DiffResult, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "diff", "--format=", "-p", "-M", from+".."+to)
+	cmd.Env = env
+	stdout := &cappedBuffer{limit: maxCompareDiffSize}
+	var stderr bytes.Buffer
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to get compare diff %s..%s: %w (stderr: %s)", from, to, err, stderr.String())
+	}
+	content := stdout.String()
+	if stdout.truncated {
+		content = content[:strings.LastIndexByte(content, '\n')+1]
+	}
+
+	var filesChanged, additions, deletions int
+	files, err := g.diffFiles(ctx, repoPath, from, to, env)
+	if err == nil {
+		for _, f := range files {
+			additions += f.Additions
+			deletions += f.Deletions
+		}
+		filesChanged = len(files)
+	}
+
 	if filesChanged == 0 && content != "" {
 		diffLines := strings.Split(content, "\n")
 		for _, line := range diffLines {
@@ -1466,8 +1857,8 @@ func (g *GitOperations) GetCompareDiff(ctx context.Context, repoPath, from, to s
 	}
 
 	return &service.DiffResult{
-		CommitHash:   from + ".." + to,
 		Content:      content,
+		Truncated:    stdout.truncated,
 		FilesChanged: filesChanged,
 		Additions:    additions,
 		Deletions:    deletions,
@@ -1475,6 +1866,89 @@ func (g *GitOperations) GetCompareDiff(ctx context.Context, repoPath, from, to s
 	}, nil
 }
 
+// diffFiles returns the files changed between two commits with their status
+// and line counts, running git with env if set. Binary files count no lines.
+func (g *GitOperations) diffFiles(ctx context.Context, repoPath, from, to string, env []string) ([]service.DiffFile, error) {
+	run := func(format string) ([]string, error) {
+		cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "diff", format, "-z", "-M", from+".."+to)
+		cmd.Env = env
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("failed to get changed files %s..%s: %w (stderr: %s)", from, to, err, stderr.String())
+		}
+		return strings.Split(stdout.String(), "\x00"), nil
+	}
+
+	// --name-status: <status> NUL <path> NUL, or <status> NUL <old> NUL <new> NUL
+	// for renames and copies
+	names, err := run("--name-status")
+	if err != nil {
+		return nil, err
+	}
+	var files []service.DiffFile
+	for i := 0; i+1 < len(names) && names[i] != ""; {
+		file := service.DiffFile{OldPath: names[i+1], NewPath: names[i+1]}
+		switch names[i][0] {
+		case 'A':
+			file.Status = "added"
+		case 'D':
+			file.Status = "deleted"
+		case 'R', 'C':
+			if i+2 >= len(names) {
+				return nil, fmt.Errorf("malformed name-status output for %s..%s", from, to)
+			}
+			file.Status = "renamed"
+			file.NewPath = names[i+2]
+			i++
+		default:
+			file.Status = "modified"
+		}
+		files = append(files, file)
+		i += 2
+	}
+
+	// --numstat: <added> TAB <deleted> TAB <path> NUL, or <added> TAB <deleted>
+	// TAB NUL <old> NUL <new> NUL for renames and copies, in the same order
+	stats, err := run("--numstat")
+	if err != nil {
+		return nil, err
+	}
+	for i, n := 0, 0; i < len(stats) && n < len(files); n++ {
+		parts := strings.SplitN(stats[i], "\t", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("malformed numstat output for %s..%s", from, to)
+		}
+		files[n].Additions, _ = strconv.Atoi(parts[0])
+		files[n].Deletions, _ = strconv.Atoi(parts[1])
+		if parts[2] == "" {
+			i += 3
+		} else {
+			i++
+		}
+	}
+	return files, nil
+}
+
+// cappedBuffer keeps the first limit bytes written to it and discards the
+// rest, so a writer never fails on it
+type cappedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write implements io.Writer
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.Buffer.Write(p[:room])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
 // parseFilePatchesFromDiff extracts individual file patches from the full diff content
 func (g *GitOperations) parseFilePatchesFromDiff(content string, files []service.DiffFile) []service.DiffFile {
 	// Split content by "diff --git" to get individual file diffs
@@ -1488,20 +1962,27 @@ func (g *GitOperations) parseFilePatchesFromDiff(content string, files []service
 		// Re-add the prefix that was removed by split
 		patch := "diff --git " + part
 
-		// Find which file this patch belongs to
-		for j := range files {
-			// Check if this patch is for this file
-			if strings.Contains(patch, files[j].NewPath) || strings.Contains(patch, files[j].OldPath) {
-				files[j].Patch = strings.TrimSpace(patch)
-
-				// Detect added/deleted files from the patch
-				if strings.Contains(patch, "new file mode") {
-					files[j].Status = "added"
-				} else if strings.Contains(patch, "deleted file mode") {
-					files[j].Status = "deleted"
-				}
-				break
-			}
+		// Find which file this patch belongs to: the one named by its header,
+		// else the first file without a patch whose path it mentions
+		j := slices.IndexFunc(files, func(f service.DiffFile) bool {
+			return strings.HasPrefix(patch, "diff --git a/"+f.OldPath+" b/"+f.NewPath+"\n")
+		})
+		if j < 0 {
+			j = slices.IndexFunc(files, func(f service.DiffFile) bool {
+				return f.Patch == "" && (strings.Contains(patch, f.NewPath) || strings.Contains(patch, f.OldPath))
+			})
+		}
+		if j < 0 {
+			continue
+		}
+		files[j].Patch = strings.TrimSpace(patch)
+
+		// Detect added/deleted files from the extended header of the patch
+		header, _, _ := strings.Cut(patch, "\n@@")
+		if strings.Contains(header, "\nnew file mode") {
+			files[j].Status = "added"
+		} else if strings.Contains(header, "\ndeleted file mode") {
+			files[j].Status = "deleted"
 		}
 	}
 
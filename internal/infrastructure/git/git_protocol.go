@@ -1,20 +1,76 @@
 package git
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/pkg/logger"
+	"github.com/bravo68web/stasis/pkg/metrics"
 )
 
+// gitWaitDelay bounds how long a git service killed on cancellation may take
+// to release its input and output, so a client that stopped reading or
+// sending cannot hold the request open
+const gitWaitDelay = 5 * time.Second
+
+// slowGitServiceThreshold is the duration past which a git service run is
+// logged, with its request ID, to find the requests behind slow fetches
+const slowGitServiceThreshold = 30 * time.Second
+
+// requestIDEnv passes the request ID of a git subprocess, from the
+// X-Request-ID of the HTTP request or the ID of the SSH session, to it and to
+// the hooks it runs
+const requestIDEnv = "STASIS_REQUEST_ID"
+
+// RepositoryNotFoundMessage is shown to git clients, over HTTP and SSH, for a
+// repository that does not exist or that they cannot read. Both cases read
+// the same so the message does not reveal which repositories exist.
+const RepositoryNotFoundMessage = "Repository not found. Check the URL or your access rights."
+
+// CreatedOnPushMessage is shown to git clients, over HTTP and SSH, whose push
+// created the repository, with where to find it. webURL may be empty.
+func CreatedOnPushMessage(owner, repoName, webURL, cloneURL string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nCreated private repository %s/%s\n", owner, repoName)
+	if webURL != "" {
+		fmt.Fprintf(&b, "  Web:   %s\n", webURL)
+	}
+	fmt.Fprintf(&b, "  Clone: %s\n\n", cloneURL)
+	return b.String()
+}
+
 // GitProtocol handles Git smart HTTP protocol operations
-type GitProtocol struct{}
+type GitProtocol struct {
+	limits    ReceiveLimits
+	hooksPath string // directory of the pre-receive hook
+	log       *logger.Logger
+}
+
+// NewGitProtocol creates a new GitProtocol instance enforcing the given push limits
+func NewGitProtocol(limits ReceiveLimits) (*GitProtocol, error) {
+	hooksPath, err := installHooks()
+	if err != nil {
+		return nil, err
+	}
 
-// NewGitProtocol creates a new GitProtocol instance
-func NewGitProtocol() *GitProtocol {
-	return &GitProtocol{}
+	return &GitProtocol{
+		limits:    limits,
+		hooksPath: hooksPath,
+		log:       logger.Get().WithFields(logger.Component("git-protocol")),
+	}, nil
 }
 
 // ServiceType represents the type of Git service
@@ -26,18 +82,23 @@ const (
 
 	// ServiceReceivePack is the service for git push operations
 	ServiceReceivePack ServiceType = "git-receive-pack"
+
+	// ServiceUploadArchive is the service for git archive --remote, over SSH
+	// only: git clients do not support it over HTTP
+	ServiceUploadArchive ServiceType = "git-upload-archive"
 )
 
 // InfoRefsRequest represents a request for info/refs
 type InfoRefsRequest struct {
-	RepoPath string
-	Service  ServiceType
-}
+	RepoPath    string
+	Service     ServiceType
+	GitProtocol string // Git-Protocol header of the request (e.g. version=2), or ""
+
+	UploadPack models.UploadPackSettings // Upload-pack settings of the repository, deciding the capabilities advertised
 
-// InfoRefsResponse represents the response for info/refs
-type InfoRefsResponse struct {
-	ContentType string
-	Body        []byte
+	// Snapshot, if not nil, is an upload-pack advertisement from AdvertiseRefs
+	// served to v0 and v1 clients instead of asking git
+	Snapshot []byte
 }
 
 // PackRequest represents a request for upload-pack or receive-pack
@@ -70,68 +131,219 @@ func NormalizeServiceName(service string) ServiceType {
 	return ServiceType(service)
 }
 
-// GetInfoRefs returns the info/refs response for smart HTTP protocol
-func (p *GitProtocol) GetInfoRefs(ctx context.Context, req InfoRefsRequest) (*InfoRefsResponse, error) {
-	var buf bytes.Buffer
+// WriteInfoRefs streams the info/refs response for smart HTTP protocol to
+// output as git produces it; its content type is AdvertisementContentType.
+// Nothing is written if git fails before advertising any ref.
+func (p *GitProtocol) WriteInfoRefs(ctx context.Context, req InfoRefsRequest, output io.Writer) error {
+	// git has no protocol v2 for pushes, receive-pack always answers in v0
+	if req.Service == ServiceUploadPack && IsProtocolV2(req.GitProtocol) {
+		// A v2 capability advertisement has no service header
+		return p.advertiseRefs(ctx, req.RepoPath, req.Service, req.UploadPack, output, gitProtocolEnv(req.GitProtocol)...)
+	}
 
-	// Write pkt-line header for service advertisement
+	// The pkt-line header for service advertisement goes out with the first
+	// refs. As git http-backend does, the Git-Protocol of the request reaches
+	// git, which answers version=1 with a "version 1" line.
 	header := fmt.Sprintf("# service=%s\n", req.Service)
-	pktHeader := EncodePktLine(header)
-	buf.WriteString(pktHeader)
-	buf.WriteString("0000") // Flush packet
+	if req.Service == ServiceUploadPack && req.Snapshot != nil {
+		// A snapshot is a v0 advertisement, which v1 clients accept too
+		_, err := io.WriteString(output, EncodePktLine(header)+FlushPacket()+string(req.Snapshot))
+		return err
+	}
+	return p.advertiseRefs(ctx, req.RepoPath, req.Service, req.UploadPack, &prefixWriter{
+		w:      output,
+		prefix: []byte(EncodePktLine(header) + FlushPacket()),
+	}, gitProtocolEnv(req.GitProtocol)...)
+}
+
+// AdvertiseRefs returns the protocol v0 ref advertisement of git-upload-pack
+// for a repository, without the smart HTTP service header
+func (p *GitProtocol) AdvertiseRefs(ctx context.Context, repoPath string, settings models.UploadPackSettings) ([]byte, error) {
+	var out bytes.Buffer
+	if err := p.advertiseRefs(ctx, repoPath, ServiceUploadPack, settings, &out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
 
-	// Get refs using git command
-	// Remove "git-" prefix from service name (e.g., "git-receive-pack" -> "receive-pack")
-	serviceName := strings.TrimPrefix(string(req.Service), "git-")
-	cmd := exec.CommandContext(ctx, "git", serviceName, "--stateless-rpc", "--advertise-refs", req.RepoPath)
-	cmd.Dir = req.RepoPath
+// IsProtocolV2 reports whether a GIT_PROTOCOL value asks for protocol v2
+func IsProtocolV2(gitProtocol string) bool {
+	for _, param := range strings.Split(gitProtocol, ":") {
+		if param == "version=2" {
+			return true
+		}
+	}
+	return false
+}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+// gitProtocolEnv returns the environment passing the GIT_PROTOCOL a client
+// sent to git, or nothing if it sent none or one with control characters
+func gitProtocolEnv(gitProtocol string) []string {
+	if gitProtocol == "" || strings.ContainsFunc(gitProtocol, func(r rune) bool { return r < ' ' || r == 0x7f }) {
+		return nil
+	}
+	return []string{"GIT_PROTOCOL=" + gitProtocol}
+}
 
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("failed to advertise refs: %w (stderr: %s, repoPath: %s)", err, stderr.String(), req.RepoPath)
+// prefixWriter writes prefix to w before the first write
+type prefixWriter struct {
+	w      io.Writer
+	prefix []byte
+}
+
+// Write implements io.Writer
+func (pw *prefixWriter) Write(p []byte) (int, error) {
+	if pw.prefix != nil {
+		if _, err := pw.w.Write(pw.prefix); err != nil {
+			return 0, err
+		}
+		pw.prefix = nil
 	}
+	return pw.w.Write(p)
+}
 
-	buf.Write(stdout.Bytes())
+// HandleUploadPack handles git-upload-pack for fetch/clone operations.
+// gitProtocol is the Git-Protocol header of the request (e.g. version=2), or "";
+// settings are the upload-pack settings of the repository.
+func (p *GitProtocol) HandleUploadPack(ctx context.Context, repoPath string, input io.Reader, output io.Writer, gitProtocol string, settings models.UploadPackSettings) error {
+	op := metrics.StartGitOperation(string(ServiceUploadPack), metrics.TransportHTTP, repositoryLabel(repoPath), input, output)
+	err := p.runGitService(ctx, repoPath, ServiceUploadPack, uploadPackArgs(settings), op.Input, op.Output, true, gitProtocolEnv(gitProtocol)...)
+	op.Done(err)
+	return err
+}
 
-	return &InfoRefsResponse{
-		ContentType: AdvertisementContentType(req.Service),
-		Body:        buf.Bytes(),
-	}, nil
+// HandleReceivePack handles git-receive-pack for push operations and returns
+// what the push requested, applied and reported to the client.
+// If check is set, ref updates are vetted before git runs and a refused push
+// returns ErrPushRejected after the refusal has been reported to the client.
+// The result is returned with errors too, unless the request was unreadable.
+func (p *GitProtocol) HandleReceivePack(ctx context.Context, repoPath string, input io.Reader, output io.Writer, check service.RefCommandCheck, opts ReceiveOptions) (*service.PushResult, error) {
+	op := metrics.StartGitOperation(string(ServiceReceivePack), metrics.TransportHTTP, repositoryLabel(repoPath), input, output)
+	result, err := p.handleReceivePack(ctx, repoPath, op.Input, op.Output, check, opts)
+	op.Done(receivePackFailure(err))
+	return result, err
+}
+
+// HandleUploadPackSSH handles git-upload-pack for SSH transport (stateful).
+// gitProtocol is the GIT_PROTOCOL the client sent (e.g. version=2), or "";
+// settings are the upload-pack settings of the repository.
+func (p *GitProtocol) HandleUploadPackSSH(ctx context.Context, repoPath string, input io.Reader, output io.Writer, gitProtocol string, settings models.UploadPackSettings) error {
+	op := metrics.StartGitOperation(string(ServiceUploadPack), metrics.TransportSSH, repositoryLabel(repoPath), input, output)
+	err := p.runGitService(ctx, repoPath, ServiceUploadPack, uploadPackArgs(settings), op.Input, op.Output, false, gitProtocolEnv(gitProtocol)...)
+	op.Done(err)
+	return err
 }
 
-// HandleUploadPack handles git-upload-pack for fetch/clone operations
-func (p *GitProtocol) HandleUploadPack(ctx context.Context, repoPath string, input io.Reader, output io.Writer) error {
-	return p.runGitService(ctx, repoPath, ServiceUploadPack, input, output, true)
+// HandleUploadArchiveSSH handles git-upload-archive for SSH transport, for
+// git archive --remote. As git does by default, only the archives of refs,
+// and of trees and commits named by path from them, can be requested.
+func (p *GitProtocol) HandleUploadArchiveSSH(ctx context.Context, repoPath string, input io.Reader, output io.Writer) error {
+	op := metrics.StartGitOperation(string(ServiceUploadArchive), metrics.TransportSSH, repositoryLabel(repoPath), input, output)
+	err := p.runGitService(ctx, repoPath, ServiceUploadArchive, nil, op.Input, op.Output, false)
+	op.Done(err)
+	return err
 }
 
-// HandleReceivePack handles git-receive-pack for push operations
-func (p *GitProtocol) HandleReceivePack(ctx context.Context, repoPath string, input io.Reader, output io.Writer) error {
-	err := p.runGitService(ctx, repoPath, ServiceReceivePack, input, output, true)
-	if err != nil {
-		return err
-	}
+// HandleReceivePackSSH handles git-receive-pack for SSH transport.
+// It returns the result of the push and treats check as HandleReceivePack does.
+func (p *GitProtocol) HandleReceivePackSSH(ctx context.Context, repoPath string, input io.Reader, output io.Writer, check service.RefCommandCheck, opts ReceiveOptions) (*service.PushResult, error) {
+	op := metrics.StartGitOperation(string(ServiceReceivePack), metrics.TransportSSH, repositoryLabel(repoPath), input, output)
 
-	// Update server info after receiving push
-	if err := p.updateServerInfo(ctx, repoPath); err != nil {
-		// TODO: log error but do not fail the push
+	// The refs have to be advertised before the client sends its commands, so
+	// after reading them git runs stateless on the rest of the request like over HTTP
+	if err := p.advertiseRefs(ctx, repoPath, ServiceReceivePack, models.UploadPackSettings{}, op.Output); err != nil {
+		op.Done(err)
+		return nil, err
 	}
 
-	return nil
+	result, err := p.handleReceivePack(ctx, repoPath, op.Input, op.Output, check, opts)
+	op.Done(receivePackFailure(err))
+	return result, err
 }
 
-// HandleUploadPackSSH handles git-upload-pack for SSH transport (stateful)
-func (p *GitProtocol) HandleUploadPackSSH(ctx context.Context, repoPath string, input io.Reader, output io.Writer) error {
-	return p.runGitService(ctx, repoPath, ServiceUploadPack, input, output, false)
+// receivePackFailure returns the error of a receive-pack operation as the
+// metrics record it: a push refused by a ref check completed normally
+func receivePackFailure(err error) error {
+	if errors.Is(err, ErrPushRejected) {
+		return nil
+	}
+	return err
 }
 
-// HandleReceivePackSSH handles git-receive-pack for SSH transport (stateful)
-func (p *GitProtocol) HandleReceivePackSSH(ctx context.Context, repoPath string, input io.Reader, output io.Writer) error {
-	err := p.runGitService(ctx, repoPath, ServiceReceivePack, input, output, false)
+// repositoryLabel returns the owner/name of the repository at repoPath, which
+// ends in <owner>/<name>.git on every storage backend
+func repositoryLabel(repoPath string) string {
+	dir, base := filepath.Split(filepath.Clean(repoPath))
+	return filepath.Base(dir) + "/" + strings.TrimSuffix(base, ".git")
+}
+
+// handleReceivePack vets the ref updates of a push, runs git-receive-pack
+// statelessly, refreshes the server info and returns the result of the push
+func (p *GitProtocol) handleReceivePack(ctx context.Context, repoPath string, input io.Reader, output io.Writer, check service.RefCommandCheck, opts ReceiveOptions) (*service.PushResult, error) {
+	out := newPushOutput(output)
+	result := &service.PushResult{}
+	defer func() {
+		result.Statuses = out.status.statuses
+		result.UnpackError = out.status.unpackError
+		result.Output = out.Output()
+		result.OutputTruncated = out.truncated
+	}()
+
+	input, commands, caps, unlessFastForward, err := p.checkRefCommands(input, out, check)
+	result.Commands = commands
+	result.Atomic = caps.Has("atomic")
 	if err != nil {
-		return err
+		if errors.Is(err, ErrPushRejected) {
+			return result, err
+		}
+		return nil, err
+	}
+
+	// Commits breaking the push policy are refused before git sees them
+	if opts.PushPolicy.InspectsCommits() {
+		var cleanup func()
+		input, cleanup, err = p.inspectPushedCommits(ctx, repoPath, input, out, caps, commands, opts.PushPolicy)
+		if err != nil {
+			return result, err
+		}
+		defer cleanup()
+	}
+
+	// git would refuse to update a ref whose lock a killed push left behind.
+	// The pushed pack cannot be sent to git twice, so stale locks are removed
+	// before the refs are handed to git rather than after it failed.
+	if opts.Exclusive && p.limits.StaleLockAge > 0 {
+		p.removeStaleRefLocks(repoPath, commands, time.Now().Add(-p.limits.StaleLockAge))
+	}
+
+	// Whether an update is a fast-forward is decided by the pre-receive hook,
+	// once the pushed commits can be read
+	unlessFastForward = append(unlessFastForward, policyFastForwardOnly(opts.PushPolicy, commands)...)
+	env := fastForwardOnlyHookEnv(unlessFastForward)
+
+	// Large files are found by the pre-receive hook while it checks file sizes
+	largeFileEnv, report, err := p.largeFileHookEnv(opts)
+	if err != nil {
+		return result, err
+	}
+	if report != "" {
+		defer os.Remove(report)
+		env = append(env, largeFileEnv...)
+	}
+
+	if err := p.runGitService(ctx, repoPath, ServiceReceivePack, nil, input, out, true, env...); err != nil {
+		return result, err
+	}
+
+	if report != "" {
+		largeFiles, err := readLargeFileReport(report)
+		if err != nil {
+			p.log.WithContext(ctx).Warn("Failed to read large files added by push",
+				logger.Error(err),
+				logger.String("repo_path", repoPath),
+			)
+		}
+		result.LargeFiles = largeFiles
 	}
 
 	// Update server info after receiving push
@@ -139,15 +351,62 @@ func (p *GitProtocol) HandleReceivePackSSH(ctx context.Context, repoPath string,
 		// TODO: log error but do not fail the push
 	}
 
+	// The push succeeded either way; without the updates nothing reacts to it
+	updates, err := p.appliedRefUpdates(ctx, repoPath, commands)
+	if err != nil {
+		p.log.WithContext(ctx).Warn("Failed to inspect pushed refs",
+			logger.Error(err),
+			logger.String("repo_path", repoPath),
+		)
+	}
+	result.Updates = updates
+	return result, nil
+}
+
+// advertiseRefs writes the ref advertisement of a service without the smart
+// HTTP header, or the capability advertisement if extraEnv asks for protocol v2.
+// settings are the upload-pack settings of the repository, for upload-pack.
+func (p *GitProtocol) advertiseRefs(ctx context.Context, repoPath string, service ServiceType, settings models.UploadPackSettings, output io.Writer, extraEnv ...string) error {
+	// Remove "git-" prefix from service name (e.g., "git-receive-pack" -> "receive-pack")
+	serviceName := strings.TrimPrefix(string(service), "git-")
+
+	var args []string
+	if service == ServiceUploadPack {
+		args = uploadPackArgs(settings)
+	}
+	args = append(args, serviceName, "--stateless-rpc", "--advertise-refs", repoPath)
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoPath
+	cmd.Env = append(gitEnv(extraEnv...), requestEnv(ctx)...)
+	cmd.Stdout = output
+	cmd.WaitDelay = gitWaitDelay
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to advertise refs: %w (stderr: %s, repoPath: %s)", err, stderr.String(), repoPath)
+	}
 	return nil
 }
 
-// runGitService executes a git service command
-func (p *GitProtocol) runGitService(ctx context.Context, repoPath string, service ServiceType, input io.Reader, output io.Writer, stateless bool) error {
+// runGitService executes a git service command with git options (-c ...) and
+// extra environment variables
+func (p *GitProtocol) runGitService(ctx context.Context, repoPath string, service ServiceType, args []string, input io.Reader, output io.Writer, stateless bool, extraEnv ...string) error {
 	// Remove "git-" prefix from service name (e.g., "git-receive-pack" -> "receive-pack")
 	serviceName := strings.TrimPrefix(string(service), "git-")
 
-	args := []string{serviceName}
+	var env []string
+	if service == ServiceReceivePack {
+		// Limits are checked by git while the pushed objects are quarantined
+		receiveArgs, receiveEnv := p.receivePackArgs()
+		args = append(slices.Clip(args), receiveArgs...)
+		env = receiveEnv
+	}
+	env = append(env, extraEnv...)
+
+	args = append(args, serviceName)
 	if stateless {
 		args = append(args, "--stateless-rpc")
 	}
@@ -155,23 +414,71 @@ func (p *GitProtocol) runGitService(ctx context.Context, repoPath string, servic
 
 	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = repoPath
+	cmd.Env = append(gitEnv(env...), requestEnv(ctx)...)
 	cmd.Stdin = input
 	cmd.Stdout = output
+	// Killed with ctx, git may leave the copy of a stalled request body behind
+	cmd.WaitDelay = gitWaitDelay
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
+	start := time.Now()
+	err := cmd.Run()
+	duration := time.Since(start)
+	if err != nil {
+		// A client going away kills git, which is not worth a warning
+		if ctx.Err() == nil {
+			p.log.WithContext(ctx).Warn("Git service failed",
+				logger.Error(err),
+				logger.String("service", string(service)),
+				logger.String("repo_path", repoPath),
+				logger.Duration("duration", duration),
+				logger.String("stderr", strings.TrimSpace(stderr.String())),
+			)
+		}
 		return fmt.Errorf("%s failed: %w", service, err)
 	}
+	if duration > slowGitServiceThreshold {
+		p.log.WithContext(ctx).Info("Slow git service",
+			logger.String("service", string(service)),
+			logger.String("repo_path", repoPath),
+			logger.Duration("duration", duration),
+		)
+	}
 
 	return nil
 }
 
+// requestEnv returns the environment passing the request ID of ctx, if any,
+// to a git subprocess
+func requestEnv(ctx context.Context) []string {
+	if requestID := logger.RequestIDFromContext(ctx); requestID != "" {
+		return []string{requestIDEnv + "=" + requestID}
+	}
+	return nil
+}
+
+// uploadPackArgs returns the git options of upload-pack applying the
+// settings of a repository: whether clients may ask for partial clones (e.g.
+// --filter=blob:none) and fetch objects by ID, like the objects a partial
+// clone left out. The options override the git config of the repository, so
+// the settings hold even where the config was never written.
+func uploadPackArgs(settings models.UploadPackSettings) []string {
+	// git clears the reachable bit along with uploadpack.allowAnySHA1InWant,
+	// which must come first
+	return []string{
+		"-c", "uploadpack.allowAnySHA1InWant=" + strconv.FormatBool(settings.AllowAnySHA1InWant),
+		"-c", "uploadpack.allowReachableSHA1InWant=" + strconv.FormatBool(settings.AllowsReachableSHA1InWant()),
+		"-c", "uploadpack.allowFilter=" + strconv.FormatBool(settings.AllowFilter),
+	}
+}
+
 // updateServerInfo updates auxiliary info file (for dumb HTTP protocol)
 func (p *GitProtocol) updateServerInfo(ctx context.Context, repoPath string) error {
 	cmd := exec.CommandContext(ctx, "git", "update-server-info")
 	cmd.Dir = repoPath
+	cmd.Env = append(gitEnv(), requestEnv(ctx)...)
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
@@ -360,8 +667,35 @@ func ParseCapabilities(s string) *Capabilities {
 	return caps
 }
 
-// DefaultCapabilities returns default server capabilities
-func DefaultCapabilities() *Capabilities {
+// PeekCommandCapabilities returns the capabilities sent with the first command
+// of a receive-pack request without consuming any input. It returns empty
+// capabilities if the request does not start with a command pkt-line.
+func PeekCommandCapabilities(r *bufio.Reader) *Capabilities {
+	lenBuf, err := r.Peek(4)
+	if err != nil {
+		return NewCapabilities()
+	}
+
+	var length int
+	if _, err := fmt.Sscanf(string(lenBuf), "%04x", &length); err != nil || length <= 4 {
+		return NewCapabilities()
+	}
+
+	line, err := r.Peek(length)
+	if err != nil {
+		return NewCapabilities()
+	}
+
+	_, caps, found := strings.Cut(string(line[4:]), "\x00")
+	if !found {
+		return NewCapabilities()
+	}
+	return ParseCapabilities(caps)
+}
+
+// DefaultCapabilities returns default server capabilities, with HEAD
+// pointing to the default branch of the repository if it has one
+func DefaultCapabilities(defaultBranch string) *Capabilities {
 	caps := NewCapabilities()
 	caps.Add("multi_ack", "")
 	caps.Add("thin-pack", "")
@@ -373,7 +707,9 @@ func DefaultCapabilities() *Capabilities {
 	caps.Add("include-tag", "")
 	caps.Add("multi_ack_detailed", "")
 	caps.Add("no-done", "")
-	caps.Add("symref", "HEAD:refs/heads/main")
+	if defaultBranch != "" {
+		caps.Add("symref", "HEAD:refs/heads/"+defaultBranch)
+	}
 	caps.Add("agent", "git-server/1.0")
 	return caps
 }
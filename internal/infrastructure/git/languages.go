@@ -0,0 +1,214 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/pkg/language"
+)
+
+const (
+	// maxCachedLanguageBreakdowns bounds the trees whose language breakdown is cached
+	maxCachedLanguageBreakdowns = 1000
+
+	// languageSniffLength is how much of the start of a file is searched for
+	// NUL bytes, as git does, to leave binary files out
+	languageSniffLength = 8000
+)
+
+// languageCache is an LRU cache of the language breakdown of the tree of a
+// commit of a repository, keyed by the commit like historyCache
+type languageCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// languageCacheEntry is the language breakdown of a single commit
+type languageCacheEntry struct {
+	key   string
+	bytes map[string]int64
+}
+
+// newLanguageCache creates an empty language cache
+func newLanguageCache() *languageCache {
+	return &languageCache{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the cached breakdown of a key and marks it as recently used
+func (c *languageCache) get(key string) (map[string]int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*languageCacheEntry).bytes, true
+}
+
+// put stores the breakdown of a key, evicting the least recently used entry
+// when the cache is full
+func (c *languageCache) put(key string, bytes map[string]int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&languageCacheEntry{key: key, bytes: bytes})
+	if c.order.Len() > maxCachedLanguageBreakdowns {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*languageCacheEntry).key)
+	}
+}
+
+// GetLanguageBytes sums the size of the files of each language in the tree at
+// ref, leaving out vendored directories and binary files. Breakdowns are
+// cached per commit.
+func (g *GitOperations) GetLanguageBytes(ctx context.Context, repoPath, ref string) (*service.LanguageBreakdown, error) {
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	tip, err := g.resolveRef(repo, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve ref '%s': %w", ref, err)
+	}
+
+	key := historyKey(repoPath, tip)
+	if cached, ok := g.languages.get(key); ok {
+		return &service.LanguageBreakdown{Tip: tip.String(), Bytes: cached}, nil
+	}
+
+	files, err := listTreeFiles(ctx, repoPath, tip)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := make(map[string]int64)
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if language.IsVendored(file.path) {
+			continue
+		}
+		lang, ok := language.Detect(file.path)
+		if !ok {
+			continue
+		}
+		binary, err := isBinaryBlob(repo, file.hash)
+		if err != nil {
+			return nil, err
+		}
+		if !binary {
+			breakdown[lang] += file.size
+		}
+	}
+
+	g.languages.put(key, breakdown)
+	return &service.LanguageBreakdown{Tip: tip.String(), Bytes: breakdown}, nil
+}
+
+// treeFile is a regular file of the full tree of a commit
+type treeFile struct {
+	path string
+	hash plumbing.Hash
+	size int64
+}
+
+// listTreeFiles lists the regular files of the tree of a commit, recursively,
+// with their sizes. Symbolic links and submodules are left out.
+func listTreeFiles(ctx context.Context, repoPath string, tip plumbing.Hash) ([]treeFile, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "ls-tree", "-r", "-l", "-z", tip.String())
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tree: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to list tree: %w", err)
+	}
+
+	files, parseErr := parseTreeFiles(stdout)
+	if parseErr != nil {
+		_ = cmd.Wait()
+		return nil, parseErr
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("failed to list tree: %w (stderr: %s)", err, stderr.String())
+	}
+	return files, nil
+}
+
+// parseTreeFiles parses the output of git ls-tree -r -l -z, entries of the
+// form "<mode> <type> <hash> <size>\t<path>\x00"
+func parseTreeFiles(output io.Reader) ([]treeFile, error) {
+	var files []treeFile
+	reader := bufio.NewReader(output)
+	for {
+		entry, err := reader.ReadString(0)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tree: %w", err)
+		}
+
+		info, filePath, ok := strings.Cut(strings.TrimSuffix(entry, "\x00"), "\t")
+		fields := strings.Fields(info)
+		if !ok || len(fields) != 4 {
+			return nil, fmt.Errorf("failed to parse tree entry %q", entry)
+		}
+		// Regular and executable files only
+		if fields[1] != "blob" || (fields[0] != "100644" && fields[0] != "100755") {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse size of %q: %w", filePath, err)
+		}
+		files = append(files, treeFile{path: filePath, hash: plumbing.NewHash(fields[2]), size: size})
+	}
+	return files, nil
+}
+
+// isBinaryBlob returns true if a NUL byte appears near the start of a blob
+func isBinaryBlob(repo *git.Repository, hash plumbing.Hash) (bool, error) {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return false, fmt.Errorf("failed to get blob %s: %w", hash, err)
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return false, fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+	defer reader.Close()
+
+	head := make([]byte, languageSniffLength)
+	n, err := io.ReadFull(reader, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false, fmt.Errorf("failed to read blob %s: %w", hash, err)
+	}
+	return bytes.IndexByte(head[:n], 0) >= 0, nil
+}
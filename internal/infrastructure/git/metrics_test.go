@@ -0,0 +1,78 @@
+package git_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/infrastructure/git"
+	"github.com/bravo68web/stasis/internal/testutil"
+	"github.com/bravo68web/stasis/pkg/metrics"
+)
+
+// metricValue returns the value of a series of the served metrics, 0 if it
+// has not been recorded
+func metricValue(t *testing.T, series string) float64 {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), series+" "); ok {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return v
+		}
+	}
+	return 0
+}
+
+// A clone is counted and timed by service, transport and repository, with
+// the bytes it received and sent
+func TestUploadPackMetrics(t *testing.T) {
+	b := testutil.TempRepo(t)
+	tip := b.Commit("main", "Initial commit", testutil.File("README.md", "# demo\n"))
+	protocol, err := git.NewGitProtocol(git.ReceiveLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The repository is labelled <owner>/<name> from the last elements of its path
+	repo := filepath.Base(filepath.Dir(b.Path())) + "/" + strings.TrimSuffix(filepath.Base(b.Path()), ".git")
+	labels := `{repository="` + repo + `",service="git-upload-pack",transport="http"}`
+	operations := `stasis_git_operations_total{repository="` + repo + `",result="success",service="git-upload-pack",transport="http"}`
+	bytesIn := `stasis_git_transfer_bytes_total{direction="in",service="git-upload-pack",transport="http"}`
+	bytesOut := `stasis_git_transfer_bytes_total{direction="out",service="git-upload-pack",transport="http"}`
+	inBefore, outBefore := metricValue(t, bytesIn), metricValue(t, bytesOut)
+
+	request := git.EncodePktLine("want "+tip.String()+" ofs-delta\n") + git.FlushPacket() + git.EncodePktLine("done\n")
+	var output bytes.Buffer
+	if err := protocol.HandleUploadPack(context.Background(), b.Path(), strings.NewReader(request), &output, "", models.UploadPackSettings{}); err != nil {
+		t.Fatalf("HandleUploadPack: %v", err)
+	}
+	if !bytes.Contains(output.Bytes(), []byte("PACK")) {
+		t.Fatalf("upload-pack sent no pack: %q", output.String())
+	}
+
+	if got := metricValue(t, operations); got != 1 {
+		t.Errorf("%s = %v, want 1", operations, got)
+	}
+	if got := metricValue(t, "stasis_git_operation_duration_seconds_count"+labels); got != 1 {
+		t.Errorf("timed %v operations, want 1", got)
+	}
+	if got := metricValue(t, bytesIn) - inBefore; got != float64(len(request)) {
+		t.Errorf("counted %v bytes received, want %d", got, len(request))
+	}
+	if got := metricValue(t, bytesOut) - outBefore; got != float64(output.Len()) {
+		t.Errorf("counted %v bytes sent, want %d", got, output.Len())
+	}
+}
@@ -0,0 +1,124 @@
+package git
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxPushOutputCapture bounds the push output kept in memory for the record of a push
+const maxPushOutputCapture = 1024 * 1024
+
+// pushOutput passes the response of git-receive-pack to the client and keeps
+// what the client shows its user: side-band messages (hook output, remote
+// errors) and the per-ref report-status
+type pushOutput struct {
+	w         io.Writer
+	reader    pktLineReader
+	report    pktLineReader // report-status nested in side-band channel 1
+	messages  bytes.Buffer
+	truncated bool
+	status    reportStatus
+}
+
+// pktLineReader incrementally splits a stream into pkt-line payloads
+type pktLineReader struct {
+	buf    []byte
+	broken bool // the stream is not (or no longer) pkt-line framed
+}
+
+// newPushOutput creates a pushOutput writing to w
+func newPushOutput(w io.Writer) *pushOutput {
+	return &pushOutput{w: w}
+}
+
+// Write implements io.Writer
+func (o *pushOutput) Write(p []byte) (int, error) {
+	n, err := o.w.Write(p)
+	o.reader.feed(p[:n], o.handlePacket)
+	return n, err
+}
+
+// handlePacket handles a packet of the response. Side-band channel 1 carries
+// the report-status as nested pkt-lines, 2 progress and 3 fatal errors.
+func (o *pushOutput) handlePacket(payload []byte) {
+	if len(payload) == 0 {
+		return
+	}
+	switch SideBandChannel(payload[0]) {
+	case SideBandData:
+		o.report.feed(payload[1:], o.handleReportLine)
+	case SideBandProgress, SideBandError:
+		o.appendMessage(payload[1:])
+	default:
+		o.handleReportLine(payload)
+	}
+}
+
+// handleReportLine records a report-status line, and shows a failed unpack
+// in the output as git does
+func (o *pushOutput) handleReportLine(line []byte) {
+	unpacked := o.status.unpackError == ""
+	o.status.line(line)
+	if unpacked && o.status.unpackError != "" {
+		o.appendMessage([]byte("unpack failed: " + o.status.unpackError + "\n"))
+	}
+}
+
+// appendMessage keeps a message up to the capture limit
+func (o *pushOutput) appendMessage(msg []byte) {
+	if o.truncated {
+		return
+	}
+	if room := maxPushOutputCapture - o.messages.Len(); len(msg) > room {
+		msg = msg[:room]
+		o.truncated = true
+	}
+	o.messages.Write(msg)
+}
+
+// Output returns the captured messages with progress updates collapsed to
+// their final state, as a terminal would show them
+func (o *pushOutput) Output() string {
+	lines := strings.Split(o.messages.String(), "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if idx := strings.LastIndexByte(line, '\r'); idx >= 0 {
+			line = line[idx+1:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// feed splits data into packets, calling handle with the payload of each
+// complete data packet. Special packets (flush, delimiter) are skipped.
+func (r *pktLineReader) feed(data []byte, handle func(payload []byte)) {
+	if r.broken {
+		return
+	}
+	r.buf = append(r.buf, data...)
+
+	for len(r.buf) >= 4 {
+		length, err := strconv.ParseUint(string(r.buf[:4]), 16, 16)
+		if err != nil || (length > 2 && length < 4) {
+			r.broken = true
+			r.buf = nil
+			return
+		}
+		if length < 4 {
+			r.buf = r.buf[4:]
+			continue
+		}
+		if len(r.buf) < int(length) {
+			return
+		}
+		handle(r.buf[4:length])
+		r.buf = r.buf[length:]
+	}
+
+	if len(r.buf) == 0 {
+		r.buf = nil
+	}
+}
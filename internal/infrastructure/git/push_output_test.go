@@ -0,0 +1,122 @@
+package git
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+)
+
+// report encodes the lines of a report-status response
+func report(lines ...string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(EncodePktLine(line))
+	}
+	return b.String() + "0000"
+}
+
+// sideBand encodes a packet of a side-band channel
+func sideBand(channel SideBandChannel, payload string) string {
+	return EncodePktLine(string(rune(channel)) + payload)
+}
+
+func TestPushOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   string
+		wantOutput string
+		wantUnpack string
+		want       []service.RefStatus
+	}{
+		{
+			name: "side-band",
+			response: sideBand(SideBandProgress, "Resolving deltas:  50% (1/2)\r") +
+				sideBand(SideBandProgress, "Resolving deltas: 100% (2/2), done.\n") +
+				sideBand(SideBandProgress, "hook: build queued\n") +
+				sideBand(SideBandData, report("unpack ok\n", "ok refs/heads/main\n", "ng refs/tags/v1 exists\n")) +
+				"0000",
+			wantOutput: "Resolving deltas: 100% (2/2), done.\nhook: build queued\n",
+			want: []service.RefStatus{
+				{RefName: "refs/heads/main", OK: true},
+				{RefName: "refs/tags/v1", Reason: "exists"},
+			},
+		},
+		{
+			name:     "without side-band",
+			response: report("unpack ok\n", "ok refs/heads/main\n"),
+			want:     []service.RefStatus{{RefName: "refs/heads/main", OK: true}},
+		},
+		{
+			name:       "unpack failure",
+			response:   sideBand(SideBandData, report("unpack index-pack failed\n", "ng refs/heads/main unpacker error\n")) + "0000",
+			wantOutput: "unpack failed: index-pack failed\n",
+			wantUnpack: "index-pack failed",
+			want:       []service.RefStatus{{RefName: "refs/heads/main", Reason: "unpacker error"}},
+		},
+		{
+			name:       "fatal error",
+			response:   sideBand(SideBandError, "fatal: the remote end hung up\n") + "0000",
+			wantOutput: "fatal: the remote end hung up\n",
+		},
+		{
+			name: "report split across packets",
+			response: sideBand(SideBandData, "000eunpack ok\n00") +
+				sideBand(SideBandData, "17ok refs/heads/main\n0000") +
+				"0000",
+			want: []service.RefStatus{{RefName: "refs/heads/main", OK: true}},
+		},
+		{
+			name:       "not pkt-line framed",
+			response:   "garbage\n" + sideBand(SideBandProgress, "ignored\n"),
+			wantOutput: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Whole, and a byte at a time as a slow connection delivers it
+			for _, chunk := range []int{len(tt.response), 1} {
+				var client bytes.Buffer
+				out := newPushOutput(&client)
+				for data := tt.response; len(data) > 0; {
+					n := min(chunk, len(data))
+					if _, err := out.Write([]byte(data[:n])); err != nil {
+						t.Fatal(err)
+					}
+					data = data[n:]
+				}
+
+				if client.String() != tt.response {
+					t.Errorf("chunks of %d: passed %q to the client, want the response unchanged", chunk, client.String())
+				}
+				if got := out.Output(); got != tt.wantOutput {
+					t.Errorf("chunks of %d: output = %q, want %q", chunk, got, tt.wantOutput)
+				}
+				if out.status.unpackError != tt.wantUnpack {
+					t.Errorf("chunks of %d: unpack error = %q, want %q", chunk, out.status.unpackError, tt.wantUnpack)
+				}
+				if !reflect.DeepEqual(out.status.statuses, tt.want) {
+					t.Errorf("chunks of %d: statuses = %+v, want %+v", chunk, out.status.statuses, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPushOutputTruncated(t *testing.T) {
+	out := newPushOutput(&bytes.Buffer{})
+	line := strings.Repeat("x", 60000) + "\n"
+	for range maxPushOutputCapture/len(line) + 2 {
+		if _, err := out.Write([]byte(sideBand(SideBandProgress, line))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(out.Output()); got != maxPushOutputCapture {
+		t.Errorf("kept %d bytes of output, want %d", got, maxPushOutputCapture)
+	}
+	if !out.truncated {
+		t.Error("output not marked truncated")
+	}
+}
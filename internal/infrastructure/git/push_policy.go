@@ -0,0 +1,531 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// maxPushPolicyViolations bounds the violations reported to a pusher
+const maxPushPolicyViolations = 50
+
+// pushPolicyChecker evaluates the rules of a push policy on the commits of a
+// push. env is the environment of the git commands it runs, giving them
+// access to the pushed objects while they are quarantined.
+type pushPolicyChecker struct {
+	repoPath string
+	env      []string
+	policy   *models.PushPolicy
+	message  *regexp.Regexp
+	limit    int
+}
+
+// newPushPolicyChecker creates a checker returning at most limit violations
+func newPushPolicyChecker(repoPath string, env []string, policy *models.PushPolicy, limit int) (*pushPolicyChecker, error) {
+	c := &pushPolicyChecker{repoPath: repoPath, env: env, policy: policy, limit: limit}
+	if policy.CommitMessagePattern != "" {
+		message, err := regexp.Compile(policy.CommitMessagePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit message pattern: %w", err)
+		}
+		c.message = message
+	}
+	return c, nil
+}
+
+// policyFile is a file added or changed by a commit
+type policyFile struct {
+	commit string
+	path   string
+	blob   string
+}
+
+// check inspects the commits listed by git rev-list revs, oldest first, for
+// a push to refName
+func (c *pushPolicyChecker) check(ctx context.Context, refName string, revs []string) ([]service.PushPolicyViolation, error) {
+	if !c.policy.InspectsCommits() {
+		return nil, nil
+	}
+
+	commits, messages, err := c.listCommits(ctx, revs)
+	if err != nil || len(commits) == 0 {
+		return nil, err
+	}
+
+	var violations []service.PushPolicyViolation
+	add := func(v service.PushPolicyViolation) bool {
+		v.RefName = refName
+		violations = append(violations, v)
+		return len(violations) < c.limit
+	}
+
+	if c.message != nil {
+		for _, commit := range commits {
+			if c.message.MatchString(messages[commit]) {
+				continue
+			}
+			if !add(service.PushPolicyViolation{
+				Commit:  commit,
+				Rule:    service.PushPolicyRuleCommitMessage,
+				Message: fmt.Sprintf("commit message does not match %q", c.policy.CommitMessagePattern),
+			}) {
+				return violations, nil
+			}
+		}
+	}
+
+	if c.policy.MaxFileSize <= 0 && len(c.policy.BlockedPaths) == 0 {
+		return violations, nil
+	}
+	files, err := c.changedFiles(ctx, commits)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		pattern, blocked := matchBlockedPath(c.policy.BlockedPaths, file.path)
+		if !blocked {
+			continue
+		}
+		if !add(service.PushPolicyViolation{
+			Commit:  file.commit,
+			Rule:    service.PushPolicyRuleBlockedPath,
+			Path:    file.path,
+			Message: fmt.Sprintf("%s matches blocked path %q", file.path, pattern),
+		}) {
+			return violations, nil
+		}
+	}
+
+	if c.policy.MaxFileSize > 0 {
+		sizes, err := c.blobSizes(ctx, files)
+		if err != nil {
+			return nil, err
+		}
+		// The same content at the same path is reported once
+		reported := make(map[string]bool)
+		for _, file := range files {
+			size, ok := sizes[file.blob]
+			if !ok || size <= c.policy.MaxFileSize || reported[file.blob+" "+file.path] {
+				continue
+			}
+			reported[file.blob+" "+file.path] = true
+			if !add(service.PushPolicyViolation{
+				Commit:  file.commit,
+				Rule:    service.PushPolicyRuleMaxFileSize,
+				Path:    file.path,
+				Message: fmt.Sprintf("%s is %d bytes, larger than the %d byte limit", file.path, size, c.policy.MaxFileSize),
+			}) {
+				return violations, nil
+			}
+		}
+	}
+
+	return violations, nil
+}
+
+// listCommits lists the commits of revs, oldest first, with their messages
+// if the policy checks them
+func (c *pushPolicyChecker) listCommits(ctx context.Context, revs []string) ([]string, map[string]string, error) {
+	format := "--format=%x1e%H"
+	if c.message != nil {
+		format = "--format=%x1e%H%x00%B"
+	}
+	args := append([]string{"log", "--reverse", format}, revs...)
+
+	var stdout bytes.Buffer
+	if err := c.run(ctx, args, nil, &stdout); err != nil {
+		return nil, nil, fmt.Errorf("failed to list pushed commits: %w", err)
+	}
+
+	var commits []string
+	messages := make(map[string]string)
+	for _, record := range strings.Split(stdout.String(), "\x1e") {
+		commit, message, _ := strings.Cut(strings.TrimSpace(record), "\x00")
+		if commit == "" {
+			continue
+		}
+		commits = append(commits, commit)
+		messages[commit] = strings.TrimRight(message, "\n")
+	}
+	return commits, messages, nil
+}
+
+// changedFiles lists the files each commit added or changed compared to its
+// first parent, in the order of commits. Deleted files and the changes of
+// merges, made in the merged commits, are left out.
+func (c *pushPolicyChecker) changedFiles(ctx context.Context, commits []string) ([]policyFile, error) {
+	var stdout bytes.Buffer
+	stdin := strings.NewReader(strings.Join(commits, "\n") + "\n")
+	args := []string{"diff-tree", "--stdin", "-r", "--root", "--no-renames", "-z"}
+	if err := c.run(ctx, args, stdin, &stdout); err != nil {
+		return nil, fmt.Errorf("failed to list pushed files: %w", err)
+	}
+
+	// Commits are followed by ":<old mode> <new mode> <old hash> <new hash>
+	// <status>" and path pairs, all NUL terminated
+	var files []policyFile
+	var commit string
+	tokens := strings.Split(stdout.String(), "\x00")
+	for i := 0; i < len(tokens); i++ {
+		token := strings.TrimSpace(tokens[i])
+		if !strings.HasPrefix(token, ":") {
+			if token != "" {
+				commit = token
+			}
+			continue
+		}
+		if i+1 >= len(tokens) {
+			break
+		}
+		i++
+		fields := strings.Fields(token)
+		if len(fields) != 5 || fields[4] == "D" {
+			continue
+		}
+		blob := fields[3]
+		if fields[1] == "160000" {
+			// Submodules have no content in the repository
+			blob = ""
+		}
+		files = append(files, policyFile{commit: commit, path: tokens[i], blob: blob})
+	}
+	return files, nil
+}
+
+// blobSizes returns the sizes of the blobs of files
+func (c *pushPolicyChecker) blobSizes(ctx context.Context, files []policyFile) (map[string]int64, error) {
+	var stdin strings.Builder
+	seen := make(map[string]bool)
+	for _, file := range files {
+		if file.blob != "" && !seen[file.blob] {
+			seen[file.blob] = true
+			stdin.WriteString(file.blob + "\n")
+		}
+	}
+	if len(seen) == 0 {
+		return nil, nil
+	}
+
+	var stdout bytes.Buffer
+	args := []string{"cat-file", "--batch-check=%(objectname) %(objectsize)"}
+	if err := c.run(ctx, args, strings.NewReader(stdin.String()), &stdout); err != nil {
+		return nil, fmt.Errorf("failed to read pushed file sizes: %w", err)
+	}
+
+	sizes := make(map[string]int64, len(seen))
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		hash, size, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseInt(size, 10, 64); err == nil {
+			sizes[hash] = n
+		}
+	}
+	return sizes, nil
+}
+
+// run runs a git command in the repository with the environment of the checker
+func (c *pushPolicyChecker) run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = c.repoPath
+	cmd.Env = gitEnv(c.env...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// matchBlockedPath returns the first pattern blocking a path. Patterns without
+// a slash match any file or directory name (*.pem, node_modules); patterns
+// with one match from the root, the path or a directory containing it
+// (config/secrets, build/*.zip).
+func matchBlockedPath(patterns []string, filePath string) (string, bool) {
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "/") {
+			for p := filePath; p != "." && p != "/"; p = path.Dir(p) {
+				if ok, _ := path.Match(pattern, p); ok {
+					return pattern, true
+				}
+			}
+			continue
+		}
+		for _, name := range strings.Split(filePath, "/") {
+			if ok, _ := path.Match(pattern, name); ok {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
+
+// CheckPushPolicy evaluates policy against a push of head to refName over
+// base, see service.GitService
+func (g *GitOperations) CheckPushPolicy(ctx context.Context, repoPath string, policy *models.PushPolicy, refName, base, head string, limit int) ([]service.PushPolicyViolation, error) {
+	checker, err := newPushPolicyChecker(repoPath, nil, policy, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	headHash, err := resolveCommit(ctx, repoPath, head)
+	if err != nil {
+		return nil, err
+	}
+	revs := []string{headHash}
+
+	var violations []service.PushPolicyViolation
+	if base != "" {
+		baseHash, err := resolveCommit(ctx, repoPath, base)
+		if err != nil {
+			return nil, err
+		}
+		revs = append(revs, "^"+baseHash)
+
+		// Rewrites only matter for branches
+		if policy.DenyNonFastForward && (refName == "" || strings.HasPrefix(refName, "refs/heads/")) {
+			err := checker.run(ctx, []string{"merge-base", "--is-ancestor", baseHash, headHash}, nil, io.Discard)
+			var exitErr *exec.ExitError
+			switch {
+			case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+				violations = append(violations, service.PushPolicyViolation{
+					RefName: refName,
+					Commit:  headHash,
+					Rule:    service.PushPolicyRuleNonFastForward,
+					Message: "the update is not a fast-forward",
+				})
+			case err != nil:
+				return nil, fmt.Errorf("failed to compare %s and %s: %w", base, head, err)
+			}
+		}
+	}
+
+	checker.limit = limit - len(violations)
+	if checker.limit <= 0 {
+		return violations, nil
+	}
+	found, err := checker.check(ctx, refName, revs)
+	if err != nil {
+		return nil, err
+	}
+	return append(violations, found...), nil
+}
+
+// resolveCommit returns the commit a revision names
+func resolveCommit(ctx context.Context, repoPath, rev string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", "--end-of-options", rev+"^{commit}")
+	cmd.Dir = repoPath
+	cmd.Env = gitEnv()
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("revision %q not found", rev)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// inspectPushedCommits receives the pack of a push, input replaying the
+// request from its commands, into a temporary file and checks the commits it adds against policy before git runs. It returns a
+// reader replaying the rest of the request for git and a function removing
+// the file once git is done, or ErrPushRejected once violations have been
+// reported to the client.
+func (p *GitProtocol) inspectPushedCommits(ctx context.Context, repoPath string, input io.Reader, output io.Writer, caps *Capabilities, commands []service.RefCommand, policy *models.PushPolicy) (io.Reader, func(), error) {
+	// Clients send no pack when they only delete refs, and may then keep the
+	// connection open, so the request is only read to its end with a pack
+	var updates []service.RefCommand
+	for _, cmd := range commands {
+		if !cmd.IsDelete() {
+			updates = append(updates, cmd)
+		}
+	}
+	if len(updates) == 0 {
+		return input, func() {}, nil
+	}
+
+	// The commands, and push options if any, come before the pack and are
+	// replayed to git as read
+	var header bytes.Buffer
+	tee := io.TeeReader(input, &header)
+	sections := 1
+	if caps.Has("push-options") {
+		sections++
+	}
+	for range sections {
+		for {
+			line, err := DecodePktLine(tee)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read push commands: %w", err)
+			}
+			if line == "" {
+				break
+			}
+		}
+	}
+
+	pack, err := os.CreateTemp("", "stasis-push-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create push buffer: %w", err)
+	}
+	cleanup := func() {
+		pack.Close()
+		os.Remove(pack.Name())
+	}
+
+	// Larger pushes are not inspected, git refuses them once replayed
+	src := input
+	if p.limits.MaxPushSize > 0 {
+		src = io.LimitReader(input, p.limits.MaxPushSize+1)
+	}
+	size, err := io.Copy(pack, src)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to receive pack: %w", err)
+	}
+
+	var violations []service.PushPolicyViolation
+	if p.limits.MaxPushSize <= 0 || size <= p.limits.MaxPushSize {
+		violations, err = p.checkPack(ctx, repoPath, pack, updates, policy)
+		if err != nil {
+			// A pack git cannot index either is reported by git as usual
+			p.log.WithContext(ctx).Warn("Failed to check push policy",
+				logger.Error(err),
+				logger.String("repo_path", repoPath),
+			)
+		}
+	}
+
+	if len(violations) > 0 {
+		cleanup()
+		if err := writePolicyViolations(output, caps, commands, violations); err != nil {
+			return nil, nil, fmt.Errorf("failed to report rejected push: %w", err)
+		}
+		drainInput(input)
+		return nil, nil, ErrPushRejected
+	}
+
+	if _, err := pack.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to replay pack: %w", err)
+	}
+	return io.MultiReader(bytes.NewReader(header.Bytes()), pack, input), cleanup, nil
+}
+
+// policyFastForwardOnly returns the branch updates a policy denying
+// non-fast-forward updates refuses unless they turn out to be fast-forwards
+func policyFastForwardOnly(policy *models.PushPolicy, commands []service.RefCommand) []service.RefRejection {
+	if policy == nil || !policy.DenyNonFastForward {
+		return nil
+	}
+
+	var rejections []service.RefRejection
+	for _, cmd := range commands {
+		if !strings.HasPrefix(cmd.RefName, "refs/heads/") || cmd.IsCreate() || cmd.IsDelete() {
+			continue
+		}
+		rejections = append(rejections, service.RefRejection{
+			RefName:           cmd.RefName,
+			Reason:            "push policy denies non-fast-forward updates",
+			UnlessFastForward: true,
+		})
+	}
+	return rejections
+}
+
+// checkPack indexes a pushed pack into a temporary object directory, which
+// the repository is an alternate of, and checks each updated ref against policy
+func (p *GitProtocol) checkPack(ctx context.Context, repoPath string, pack *os.File, updates []service.RefCommand, policy *models.PushPolicy) ([]service.PushPolicyViolation, error) {
+	objects, err := os.MkdirTemp("", "stasis-push-objects-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create quarantine: %w", err)
+	}
+	defer os.RemoveAll(objects)
+	if err := os.Mkdir(filepath.Join(objects, "pack"), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create quarantine: %w", err)
+	}
+	repoObjects, err := filepath.Abs(filepath.Join(repoPath, "objects"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve object directory: %w", err)
+	}
+	env := []string{
+		"GIT_OBJECT_DIRECTORY=" + objects,
+		"GIT_ALTERNATE_OBJECT_DIRECTORIES=" + repoObjects,
+	}
+
+	checker, err := newPushPolicyChecker(repoPath, env, policy, maxPushPolicyViolations)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := pack.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read pack: %w", err)
+	}
+	if err := checker.run(ctx, []string{"index-pack", "--stdin", "--fix-thin"}, pack, io.Discard); err != nil {
+		return nil, fmt.Errorf("failed to index pack: %w", err)
+	}
+
+	var violations []service.PushPolicyViolation
+	for _, cmd := range updates {
+		found, err := checker.check(ctx, cmd.RefName, []string{cmd.NewHash, "--not", "--all"})
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, found...)
+		checker.limit -= len(found)
+		if checker.limit <= 0 {
+			break
+		}
+	}
+	return violations, nil
+}
+
+// writePolicyViolations reports every violation on the side band, then
+// refuses the refs they were found on
+func writePolicyViolations(w io.Writer, caps *Capabilities, commands []service.RefCommand, violations []service.PushPolicyViolation) error {
+	sideBand := caps.Has("side-band-64k") || caps.Has("side-band")
+
+	var rejections []service.RefRejection
+	rejected := make(map[string]bool)
+	for _, v := range violations {
+		if sideBand {
+			if err := WriteSideBandProgress(w, fmt.Sprintf("error: %s: commit %s: %s: %s\n", v.RefName, shortHash(v.Commit), v.Rule, v.Message)); err != nil {
+				return err
+			}
+		}
+		if !rejected[v.RefName] {
+			rejected[v.RefName] = true
+			rejections = append(rejections, service.RefRejection{RefName: v.RefName, Reason: "push policy violated"})
+		}
+	}
+	if sideBand && len(violations) >= maxPushPolicyViolations {
+		if err := WriteSideBandProgress(w, fmt.Sprintf("error: stopped after %d violations\n", maxPushPolicyViolations)); err != nil {
+			return err
+		}
+	}
+	return writeRefRejections(w, caps, commands, rejections)
+}
+
+// shortHash abbreviates a commit hash for messages
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -0,0 +1,275 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/service"
+)
+
+// ReceiveLimits bounds what a single push may add to a repository. Both limits
+// are enforced by git-receive-pack while the pushed objects are still in its
+// quarantine directory, so a rejected push leaves no objects behind.
+type ReceiveLimits struct {
+	// MaxPushSize is the largest pack a push may send in bytes (0 = unlimited)
+	MaxPushSize int64
+
+	// MaxFileSize is the largest blob a push may add in bytes (0 = unlimited)
+	MaxFileSize int64
+
+	// LargeFileWarningSize is the size in bytes above which a blob added by a
+	// push draws a warning suggesting Git LFS (0 = never). The push is not
+	// refused. Git LFS pointers are smaller than 1 KiB, so any blob above a
+	// larger threshold is file content stored in git itself.
+	LargeFileWarningSize int64
+
+	// StaleLockAge is how old a lock on a pushed ref must be before a push
+	// that is the only git operation on the repository removes it as left
+	// by a killed process (0 = never)
+	StaleLockAge time.Duration
+}
+
+// ReceiveOptions adjusts the checks of a push to the repository it goes to
+type ReceiveOptions struct {
+	// SkipLargeFileHints disables the warnings about large files not stored
+	// with Git LFS, for repositories that keep large files in git on purpose
+	SkipLargeFileHints bool
+
+	// Exclusive is set if no other git operation of this process is in
+	// flight on the repository, so old locks on the pushed refs can only
+	// have been left by killed processes
+	Exclusive bool
+
+	// PushPolicy is the policy of the repository, if any. Its rules on the
+	// content of commits are checked on the pushed pack before git runs;
+	// DenyNonFastForward is enforced by the pre-receive hook.
+	PushPolicy *models.PushPolicy
+}
+
+const (
+	// maxFileSizeEnv passes the file size limit to the pre-receive hook
+	maxFileSizeEnv = "STASIS_MAX_FILE_SIZE"
+
+	// fastForwardOnlyEnv passes the refs that may only be fast-forwarded to the
+	// pre-receive hook, one "<ref> <reason>" line per ref
+	fastForwardOnlyEnv = "STASIS_FAST_FORWARD_ONLY"
+
+	// largeFileWarningEnv passes the large file warning size to the
+	// pre-receive hook
+	largeFileWarningEnv = "STASIS_LARGE_FILE_WARNING"
+
+	// largeFileReportEnv passes the file the pre-receive hook lists the large
+	// files it warned about in, one "<hash> <size> <path>" line per file
+	largeFileReportEnv = "STASIS_LARGE_FILE_REPORT"
+)
+
+// preReceiveHook rejects pushes adding blobs above the size limit and
+// non-fast-forward updates of fast-forward-only refs, and warns about blobs
+// above the large file warning size. Both sizes are checked in the same pass
+// over the new objects. git runs it with GIT_QUARANTINE_PATH set: new objects
+// are only readable through the quarantine and are discarded if the hook fails.
+const preReceiveHook = `#!/bin/sh
+# Installed by stasis. Rejects pushes that add files larger than $` + maxFileSizeEnv + ` bytes
+# and non-fast-forward updates of the refs listed in $` + fastForwardOnlyEnv + `.
+# Warns about files larger than $` + largeFileWarningEnv + ` bytes.
+rejected=0
+
+new=
+while read -r old_hash new_hash ref_name; do
+	case "$new_hash" in
+	*[!0]*) new="$new $new_hash" ;;
+	*) continue ;;
+	esac
+	case "$old_hash" in
+	*[!0]*) ;;
+	*) continue ;;
+	esac
+	[ -n "$` + fastForwardOnlyEnv + `" ] || continue
+
+	reason=$(printf '%s\n' "$` + fastForwardOnlyEnv + `" | awk -v ref="$ref_name" '$1 == ref { sub(/^[^ ]+ /, ""); print; exit }')
+	[ -n "$reason" ] || continue
+	if ! git merge-base --is-ancestor "$old_hash" "$new_hash" 2>/dev/null; then
+		printf 'error: %s: %s\n' "$ref_name" "$reason"
+		rejected=1
+	fi
+done
+
+if { [ -n "$` + maxFileSizeEnv + `" ] || [ -n "$` + largeFileWarningEnv + `" ]; } && [ -n "$new" ]; then
+	git rev-list --objects $new --not --all |
+		git cat-file --batch-check='%(objecttype) %(objectname) %(objectsize) %(rest)' |
+		awk -v max="$` + maxFileSizeEnv + `" -v warn="$` + largeFileWarningEnv + `" -v report="$` + largeFileReportEnv + `" '
+			$1 != "blob" { next }
+			{
+				size = $3
+				path = $0
+				sub(/^[^ ]+ [^ ]+ [^ ]+ ?/, "", path)
+				if (path == "") path = $2
+			}
+			max != "" && size + 0 > max + 0 {
+				printf "error: %s is %s bytes, larger than the %s byte file size limit\n", path, size, max
+				rejected = 1
+				next
+			}
+			warn != "" && size + 0 > warn + 0 {
+				printf "warning: %s is %s bytes and not stored with Git LFS\n", path, size
+				if (report != "") printf "%s %s %s\n", $2, size, path > report
+				warned = 1
+			}
+			END {
+				if (warned) {
+					print "hint: Large files in git slow down every clone and fetch of the repository."
+					print "hint: Consider storing them with Git LFS: git lfs track \"<pattern>\""
+					print "hint: Files already committed can be moved with: git lfs migrate import --include=\"<pattern>\""
+				}
+				exit rejected
+			}
+		' || rejected=1
+fi
+
+exit $rejected
+`
+
+// quarantineBreakingEnv lists variables that, if inherited from the server's
+// environment, would make git-receive-pack write objects outside its quarantine
+// or into another repository entirely
+var quarantineBreakingEnv = []string{
+	"GIT_DIR",
+	"GIT_WORK_TREE",
+	"GIT_INDEX_FILE",
+	"GIT_OBJECT_DIRECTORY",
+	"GIT_ALTERNATE_OBJECT_DIRECTORIES",
+	"GIT_QUARANTINE_PATH",
+	"GIT_CONFIG_PARAMETERS",
+	"GIT_CONFIG_COUNT",
+	maxFileSizeEnv,
+	fastForwardOnlyEnv,
+	largeFileWarningEnv,
+	largeFileReportEnv,
+}
+
+// installHooks writes the pre-receive hook to a new directory and returns it
+func installHooks() (string, error) {
+	dir, err := os.MkdirTemp("", "stasis-hooks-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "pre-receive"), []byte(preReceiveHook), 0o755); err != nil {
+		return "", fmt.Errorf("failed to write pre-receive hook: %w", err)
+	}
+
+	return dir, nil
+}
+
+// gitEnv returns the server environment without variables that redirect git's
+// repository or object store, plus the given extra variables
+func gitEnv(extra ...string) []string {
+	env := make([]string, 0, len(os.Environ())+len(extra))
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if isQuarantineBreakingEnv(name) {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return append(env, extra...)
+}
+
+// isQuarantineBreakingEnv reports whether an environment variable must not reach git
+func isQuarantineBreakingEnv(name string) bool {
+	if strings.HasPrefix(name, "GIT_CONFIG_KEY_") || strings.HasPrefix(name, "GIT_CONFIG_VALUE_") {
+		return true
+	}
+	for _, v := range quarantineBreakingEnv {
+		if name == v {
+			return true
+		}
+	}
+	return false
+}
+
+// receivePackArgs returns the git options and environment enforcing the push limits
+func (p *GitProtocol) receivePackArgs() (args []string, env []string) {
+	// index-pack aborts once the pack exceeds the limit; the quarantine is removed with it
+	if p.limits.MaxPushSize > 0 {
+		args = append(args, "-c", "receive.maxInputSize="+strconv.FormatInt(p.limits.MaxPushSize, 10))
+	}
+	args = append(args, "-c", "core.hooksPath="+p.hooksPath)
+	if p.limits.MaxFileSize > 0 {
+		env = append(env, maxFileSizeEnv+"="+strconv.FormatInt(p.limits.MaxFileSize, 10))
+	}
+	return args, env
+}
+
+// fastForwardOnlyHookEnv returns the environment making the pre-receive hook
+// enforce rejections that only apply to non-fast-forward updates
+func fastForwardOnlyHookEnv(rejections []service.RefRejection) []string {
+	if len(rejections) == 0 {
+		return nil
+	}
+
+	lines := make([]string, 0, len(rejections))
+	for _, rejection := range rejections {
+		// The hook reads one ref per line
+		reason := strings.Join(strings.Fields(rejection.Reason), " ")
+		lines = append(lines, rejection.RefName+" "+reason)
+	}
+	return []string{fastForwardOnlyEnv + "=" + strings.Join(lines, "\n")}
+}
+
+// largeFileHookEnv returns the environment making the pre-receive hook warn
+// about large files, and the file it lists them in, which the caller removes.
+// It returns nothing if the push gets no warnings.
+func (p *GitProtocol) largeFileHookEnv(opts ReceiveOptions) (env []string, report string, err error) {
+	if p.limits.LargeFileWarningSize <= 0 || opts.SkipLargeFileHints {
+		return nil, "", nil
+	}
+
+	f, err := os.CreateTemp("", "stasis-large-files-")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create large file report: %w", err)
+	}
+	report = f.Name()
+	if err := f.Close(); err != nil {
+		os.Remove(report)
+		return nil, "", fmt.Errorf("failed to create large file report: %w", err)
+	}
+
+	return []string{
+		largeFileWarningEnv + "=" + strconv.FormatInt(p.limits.LargeFileWarningSize, 10),
+		largeFileReportEnv + "=" + report,
+	}, report, nil
+}
+
+// readLargeFileReport reads the large files listed by the pre-receive hook
+func readLargeFileReport(report string) ([]service.LargeFile, error) {
+	f, err := os.Open(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open large file report: %w", err)
+	}
+	defer f.Close()
+
+	var files []service.LargeFile
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		files = append(files, service.LargeFile{Path: fields[2], Hash: fields[0], Size: size})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read large file report: %w", err)
+	}
+	return files, nil
+}
@@ -0,0 +1,121 @@
+package git_test
+
+import (
+	"bytes"
+	"context"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/infrastructure/git"
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+// runGit runs git in dir, without the GIT_ variables of the test's
+// environment, and returns its standard output
+func runGit(t *testing.T, dir string, stdin []byte, args ...string) []byte {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "GIT_") {
+			cmd.Env = append(cmd.Env, kv)
+		}
+	}
+	cmd.Env = append(cmd.Env, "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL=/dev/null")
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return out
+}
+
+// pushRequest returns a receive-pack request updating main of the repository
+// at repoPath from its tip to a new commit adding size random bytes
+func pushRequest(t *testing.T, repoPath string, size int) []byte {
+	t.Helper()
+	work := filepath.Join(t.TempDir(), "work")
+	runGit(t, "", nil, "clone", "--quiet", repoPath, work)
+	data := make([]byte, size)
+	rng := rand.NewChaCha8([32]byte{})
+	_, _ = rng.Read(data)
+	if err := os.WriteFile(filepath.Join(work, "large.bin"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, work, nil, "add", "large.bin")
+	runGit(t, work, nil, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "Add large file")
+
+	old := strings.TrimSpace(string(runGit(t, work, nil, "rev-parse", "origin/main")))
+	tip := strings.TrimSpace(string(runGit(t, work, nil, "rev-parse", "HEAD")))
+	pack := runGit(t, work, []byte(tip+"\n^"+old+"\n"), "pack-objects", "--stdout", "--revs", "--quiet")
+	return append([]byte(git.EncodePktLine(old+" "+tip+" refs/heads/main\x00report-status\n")+git.FlushPacket()), pack...)
+}
+
+// A push refused by the receive limits leaves nothing in the object store:
+// git checks them while the pushed objects are in its quarantine directory
+func TestRejectedPushLeavesNoObjects(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	// Inherited from the server, these would move the pushed objects out of
+	// the quarantine; git must be given its own
+	t.Setenv("GIT_OBJECT_DIRECTORY", t.TempDir())
+	t.Setenv("GIT_QUARANTINE_PATH", t.TempDir())
+
+	const size = 1 << 20
+	tests := []struct {
+		name       string
+		limits     git.ReceiveLimits
+		wantPushed bool
+	}{
+		{name: "within the limits", limits: git.ReceiveLimits{MaxPushSize: 4 * size, MaxFileSize: 2 * size}, wantPushed: true},
+		{name: "over the push size limit", limits: git.ReceiveLimits{MaxPushSize: size / 2}},
+		{name: "over the file size limit", limits: git.ReceiveLimits{MaxFileSize: size / 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := testutil.TempRepo(t)
+			old := b.Commit("main", "Initial commit", testutil.File("README.md", "# demo\n"))
+			request := pushRequest(t, b.Path(), size)
+
+			protocol, err := git.NewGitProtocol(tt.limits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := runGit(t, b.Path(), nil, "count-objects", "-v")
+			var output bytes.Buffer
+			_, err = protocol.HandleReceivePack(context.Background(), b.Path(), bytes.NewReader(request), &output, nil, git.ReceiveOptions{})
+			after := runGit(t, b.Path(), nil, "count-objects", "-v")
+
+			tip := strings.TrimSpace(string(runGit(t, b.Path(), nil, "rev-parse", "main")))
+			if tt.wantPushed {
+				if err != nil {
+					t.Fatalf("HandleReceivePack: %v\n%s", err, output.String())
+				}
+				if tip == old.String() || bytes.Equal(before, after) {
+					t.Fatalf("push within the limits was not applied:\n%s", output.String())
+				}
+				return
+			}
+			if tip != old.String() {
+				t.Errorf("main moved to %s, want it left at %s", tip, old)
+			}
+			if !bytes.Equal(before, after) {
+				t.Errorf("object store changed by a rejected push\nbefore:\n%s\nafter:\n%s", before, after)
+			}
+			incoming, err := filepath.Glob(filepath.Join(b.Path(), "objects", "tmp_objdir-incoming-*"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(incoming) != 0 {
+				t.Errorf("quarantine directories left behind: %v", incoming)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+)
+
+// ErrPushRejected is returned when a push is refused before git-receive-pack runs
+var ErrPushRejected = errors.New("push rejected")
+
+const (
+	// maxRefCommandBytes bounds the command list read ahead of git-receive-pack
+	maxRefCommandBytes = 8 * 1024 * 1024
+
+	// rejectedDrainTimeout bounds how long the rest of a rejected push is
+	// discarded while waiting for the client to read the report
+	rejectedDrainTimeout = 30 * time.Second
+)
+
+// checkRefCommands reads the command list of a push and runs check, if set,
+// against it. If the push is accepted it returns a reader replaying the complete
+// request for git, the requested commands, the capabilities the client asked
+// for and the rejections that only apply to updates that turn out not to be
+// fast-forwards; otherwise it reports the refused refs to the client and
+// returns the commands and capabilities with ErrPushRejected.
+func (p *GitProtocol) checkRefCommands(input io.Reader, output io.Writer, check service.RefCommandCheck) (io.Reader, []service.RefCommand, *Capabilities, []service.RefRejection, error) {
+	commands, caps, raw, err := readRefCommands(input)
+	if len(raw) == 0 && errors.Is(err, io.EOF) {
+		// The client hung up after the advertisement without pushing
+		return bytes.NewReader(nil), nil, caps, nil, nil
+	}
+
+	replay := io.MultiReader(bytes.NewReader(raw), input)
+	if err != nil || len(commands) == 0 {
+		// Let git report malformed requests the way it always does
+		return replay, nil, caps, nil, nil
+	}
+
+	if check == nil {
+		return replay, commands, caps, nil, nil
+	}
+
+	var rejections, unlessFastForward []service.RefRejection
+	for _, rejection := range check(commands) {
+		if rejection.UnlessFastForward {
+			unlessFastForward = append(unlessFastForward, rejection)
+		} else {
+			rejections = append(rejections, rejection)
+		}
+	}
+	if len(rejections) == 0 {
+		return replay, commands, caps, unlessFastForward, nil
+	}
+
+	if err := writeRefRejections(output, caps, commands, rejections); err != nil {
+		return nil, nil, caps, nil, fmt.Errorf("failed to report rejected push: %w", err)
+	}
+	drainInput(input)
+
+	return nil, commands, caps, nil, ErrPushRejected
+}
+
+// appliedRefUpdates returns the commands git-receive-pack actually applied,
+// since it may refuse some refs of an accepted push (e.g. non-fast-forwards)
+func (p *GitProtocol) appliedRefUpdates(ctx context.Context, repoPath string, commands []service.RefCommand) ([]service.RefUpdate, error) {
+	if len(commands) == 0 {
+		return nil, nil
+	}
+
+	args := []string{"for-each-ref", "--format=%(objectname) %(*objectname) %(refname)", "--"}
+	for _, cmd := range commands {
+		args = append(args, cmd.RefName)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoPath
+	cmd.Env = gitEnv()
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to list pushed refs: %w (stderr: %s)", err, stderr.String())
+	}
+
+	type refTip struct{ object, peeled string }
+	tips := make(map[string]refTip)
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		tips[fields[2]] = refTip{object: fields[0], peeled: fields[1]}
+	}
+
+	var updates []service.RefUpdate
+	for _, cmd := range commands {
+		tip, exists := tips[cmd.RefName]
+		switch {
+		case cmd.IsDelete():
+			if !exists {
+				updates = append(updates, service.RefUpdate{RefCommand: cmd})
+			}
+		case exists && tip.object == cmd.NewHash:
+			commit := tip.object
+			if tip.peeled != "" {
+				commit = tip.peeled
+			}
+			updates = append(updates, service.RefUpdate{RefCommand: cmd, CommitHash: commit})
+		}
+	}
+	return updates, nil
+}
+
+// readRefCommands reads receive-pack commands up to the flush packet. It returns
+// the parsed commands, the capabilities of the first command and the raw bytes
+// consumed so they can be replayed to git.
+func readRefCommands(r io.Reader) ([]service.RefCommand, *Capabilities, []byte, error) {
+	var raw bytes.Buffer
+	tee := io.TeeReader(r, &raw)
+
+	caps := NewCapabilities()
+	var commands []service.RefCommand
+	first := true
+
+	for {
+		line, err := DecodePktLine(tee)
+		if err != nil {
+			return nil, caps, raw.Bytes(), err
+		}
+		if line == "" {
+			break
+		}
+		if raw.Len() > maxRefCommandBytes {
+			return nil, caps, raw.Bytes(), fmt.Errorf("ref command list exceeds %d bytes", maxRefCommandBytes)
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		if first {
+			if cmd, c, found := strings.Cut(line, "\x00"); found {
+				line = cmd
+				caps = ParseCapabilities(c)
+			}
+			first = false
+		}
+
+		// Skip shallow lines and push certificate headers
+		fields := strings.Fields(line)
+		if len(fields) != 3 || !isObjectName(fields[0]) || !isObjectName(fields[1]) {
+			continue
+		}
+
+		commands = append(commands, service.RefCommand{
+			OldHash: fields[0],
+			NewHash: fields[1],
+			RefName: fields[2],
+		})
+	}
+
+	return commands, caps, raw.Bytes(), nil
+}
+
+// writeRefRejections answers a refused push. Reasons are sent as remote error
+// messages and, when the client asked for it, as a report-status refusing every
+// ref so the push fails atomically.
+func writeRefRejections(w io.Writer, caps *Capabilities, commands []service.RefCommand, rejections []service.RefRejection) error {
+	sideBand := caps.Has("side-band-64k") || caps.Has("side-band")
+
+	reasons := make(map[string]string, len(rejections))
+	for _, r := range rejections {
+		reasons[r.RefName] = r.Reason
+		if sideBand {
+			if err := WriteSideBandProgress(w, fmt.Sprintf("error: %s: %s\n", r.RefName, r.Reason)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if caps.Has("report-status") || caps.Has("report-status-v2") {
+		var report strings.Builder
+		report.WriteString(EncodePktLine("unpack ok\n"))
+		for _, cmd := range commands {
+			reason, ok := reasons[cmd.RefName]
+			if !ok {
+				reason = "push declined due to other rejected refs"
+			}
+			report.WriteString(EncodePktLine(fmt.Sprintf("ng %s %s\n", cmd.RefName, reason)))
+		}
+		report.WriteString(FlushPacket())
+
+		if err := writeReport(w, report.String(), sideBand, caps.Has("side-band-64k")); err != nil {
+			return err
+		}
+	}
+
+	if sideBand {
+		_, err := io.WriteString(w, FlushPacket())
+		return err
+	}
+	return nil
+}
+
+// writeReport writes report-status data, wrapped in side-band packets when negotiated
+func writeReport(w io.Writer, report string, sideBand, large bool) error {
+	if !sideBand {
+		_, err := io.WriteString(w, report)
+		return err
+	}
+
+	chunkSize := 1000 - 5
+	if large {
+		chunkSize = 65520 - 5
+	}
+	for len(report) > 0 {
+		n := min(chunkSize, len(report))
+		if err := WriteSideBand(w, SideBandData, []byte(report[:n])); err != nil {
+			return err
+		}
+		report = report[n:]
+	}
+	return nil
+}
+
+// drainInput discards the rest of a rejected push (usually the pack) so the
+// client can finish sending and read the report
+func drainInput(r io.Reader) {
+	done := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, r)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(rejectedDrainTimeout):
+	}
+}
+
+// isObjectName returns true for a full SHA-1 or SHA-256 hex object name
+func isObjectName(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
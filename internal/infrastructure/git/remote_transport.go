@@ -0,0 +1,61 @@
+package git
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+)
+
+// go-git keeps one client per protocol for the whole process, so the http
+// and https clients dispatch each request to the transport the context of the
+// clone or fetch carries
+func init() {
+	remote := githttp.NewClient(&http.Client{Transport: remoteRoundTripper{}})
+	client.InstallProtocol("http", remote)
+	client.InstallProtocol("https", remote)
+}
+
+// remoteTransportKey is the context key of the transport of a clone or fetch
+type remoteTransportKey struct{}
+
+// remoteRoundTripper sends a request through the transport its context
+// carries, or http.DefaultTransport
+type remoteRoundTripper struct{}
+
+func (remoteRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if tr, ok := req.Context().Value(remoteTransportKey{}).(*http.Transport); ok {
+		return tr.RoundTrip(req)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// withRemoteDialControl returns a context under which go-git connects to
+// http(s) remotes only where control allows, and a function releasing the
+// connections. The transport has its own connection pool and no proxy, so
+// every connection, including those of redirects, is checked after name
+// resolution. A nil control returns ctx unchanged.
+func withRemoteDialControl(ctx context.Context, control service.DialControl) (context.Context, func()) {
+	if control == nil {
+		return ctx, func() {}
+	}
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   control,
+	}
+	tr := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	return context.WithValue(ctx, remoteTransportKey{}, tr), tr.CloseIdleConnections
+}
@@ -0,0 +1,134 @@
+package git_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/internal/infrastructure/git"
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+// countingHandler counts the requests it passes on to h
+type countingHandler struct {
+	h    http.Handler
+	hits atomic.Int64
+}
+
+func (c *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.hits.Add(1)
+	c.h.ServeHTTP(w, r)
+}
+
+// allowOnly returns a dial control refusing every address but addr
+func allowOnly(addr string) service.DialControl {
+	return func(_, address string, _ syscall.RawConn) error {
+		if address != addr {
+			return errors.New("address " + address + " refused")
+		}
+		return nil
+	}
+}
+
+func TestRemoteDialControl(t *testing.T) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+	b := testutil.TempRepo(t)
+	b.Commit("main", "Initial commit", testutil.File("README.md", "# demo\n"))
+
+	upstream := &countingHandler{h: &cgi.Handler{
+		Path: gitPath,
+		Args: []string{"http-backend"},
+		Env:  []string{"GIT_PROJECT_ROOT=" + filepath.Dir(b.Path()), "GIT_HTTP_EXPORT_ALL=1"},
+	}}
+	backend := httptest.NewServer(upstream)
+	defer backend.Close()
+	// The redirecting server stands for a public host answering with a
+	// redirect to an internal one
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, backend.URL+r.URL.RequestURI(), http.StatusFound)
+	}))
+	defer redirector.Close()
+
+	repoName := "/" + filepath.Base(b.Path())
+	backendAddr := backend.Listener.Addr().String()
+	_, port, _ := net.SplitHostPort(backendAddr)
+	ops := git.NewGitOperations(nil, nil)
+
+	tests := []struct {
+		name    string
+		url     string
+		control service.DialControl
+		wantErr bool
+	}{
+		{name: "no control", url: redirector.URL + repoName, control: nil},
+		{name: "allowed address", url: backend.URL + repoName, control: allowOnly(backendAddr)},
+		{
+			name:    "redirect to a refused address",
+			url:     redirector.URL + repoName,
+			control: allowOnly(redirector.Listener.Addr().String()),
+			wantErr: true,
+		},
+		{
+			// The name is resolved again when connecting, whatever it
+			// resolved to before
+			name:    "name resolving to a refused address",
+			url:     "http://localhost:" + port + repoName,
+			control: allowOnly("203.0.113.7:" + port),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := upstream.hits.Load()
+			dest := filepath.Join(t.TempDir(), "clone.git")
+			err := ops.CloneRepository(context.Background(), tt.url, dest, "", "", true, tt.control)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("CloneRepository succeeded, want the connection refused")
+				}
+				if hits := upstream.hits.Load() - before; hits != 0 {
+					t.Errorf("refused clone made %d requests to the upstream, want none", hits)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CloneRepository: %v", err)
+			}
+		})
+	}
+
+	t.Run("mirror fetch", func(t *testing.T) {
+		dest := filepath.Join(t.TempDir(), "mirror.git")
+		ctx := context.Background()
+		if err := ops.CloneRepository(ctx, backend.URL+repoName, dest, "", "", true, nil); err != nil {
+			t.Fatalf("CloneRepository: %v", err)
+		}
+		if err := ops.ConfigureMirror(ctx, dest, redirector.URL+repoName); err != nil {
+			t.Fatalf("ConfigureMirror: %v", err)
+		}
+		b.Commit("main", "Second commit", testutil.File("README.md", "# demo\n\nmore\n"))
+
+		before := upstream.hits.Load()
+		if err := ops.FetchMirror(ctx, dest, redirector.URL+repoName, allowOnly(redirector.Listener.Addr().String())); err == nil {
+			t.Fatal("FetchMirror succeeded, want the redirect refused")
+		}
+		if hits := upstream.hits.Load() - before; hits != 0 {
+			t.Errorf("refused fetch made %d requests to the upstream, want none", hits)
+		}
+		if err := ops.FetchMirror(ctx, dest, redirector.URL+repoName, nil); err != nil {
+			t.Fatalf("FetchMirror: %v", err)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package git
+
+import (
+	"strings"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+)
+
+// reportStatus parses the report-status and report-status-v2 sections of a
+// receive-pack response, line by line:
+//
+//	unpack ok | unpack <error>
+//	ok <ref> | ng <ref> <reason>
+//	option refname <ref>         (v2, after an ok line)
+//	option old-oid <oid>         (v2)
+//	option new-oid <oid>         (v2)
+//	option forced-update         (v2)
+//
+// git reports refs in the order of the commands, but a proc-receive hook may
+// report one command several times or in any order, so the result is meant
+// to be looked up by ref (service.PushResult.Status) rather than by position.
+type reportStatus struct {
+	unpackError string
+	statuses    []service.RefStatus
+}
+
+// line parses a report line, without its pkt-line header. Anything else,
+// such as the flush ending the report, is ignored.
+func (r *reportStatus) line(line []byte) {
+	text := strings.TrimSuffix(string(line), "\n")
+	switch {
+	case strings.HasPrefix(text, "unpack "):
+		if status := strings.TrimPrefix(text, "unpack "); status != "ok" {
+			r.unpackError = status
+		}
+	case strings.HasPrefix(text, "ok "):
+		r.statuses = append(r.statuses, service.RefStatus{RefName: strings.TrimPrefix(text, "ok "), OK: true})
+	case strings.HasPrefix(text, "ng "):
+		refName, reason, _ := strings.Cut(strings.TrimPrefix(text, "ng "), " ")
+		r.statuses = append(r.statuses, service.RefStatus{RefName: refName, Reason: reason})
+	case strings.HasPrefix(text, "option "):
+		r.option(strings.TrimPrefix(text, "option "))
+	}
+}
+
+// option applies a report-status-v2 option line to the status it follows
+func (r *reportStatus) option(option string) {
+	if len(r.statuses) == 0 {
+		return
+	}
+	status := &r.statuses[len(r.statuses)-1]
+	key, value, _ := strings.Cut(option, " ")
+	switch key {
+	case "refname":
+		status.UpdatedRef = value
+	case "old-oid":
+		status.OldHash = value
+	case "new-oid":
+		status.NewHash = value
+	case "forced-update":
+		status.ForcedUpdate = true
+	}
+}
@@ -0,0 +1,155 @@
+package git
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+)
+
+func TestReportStatusLines(t *testing.T) {
+	tests := []struct {
+		name       string
+		lines      []string
+		wantUnpack string
+		want       []service.RefStatus
+	}{
+		{
+			name:  "ok and ng",
+			lines: []string{"unpack ok\n", "ok refs/heads/main\n", "ng refs/heads/dev non-fast-forward\n"},
+			want: []service.RefStatus{
+				{RefName: "refs/heads/main", OK: true},
+				{RefName: "refs/heads/dev", Reason: "non-fast-forward"},
+			},
+		},
+		{
+			name:  "reason with spaces",
+			lines: []string{"unpack ok\n", "ng refs/heads/main pre-receive hook declined\n"},
+			want:  []service.RefStatus{{RefName: "refs/heads/main", Reason: "pre-receive hook declined"}},
+		},
+		{
+			name:       "unpack error",
+			lines:      []string{"unpack index-pack abnormal exit\n", "ng refs/heads/main unpacker error\n"},
+			wantUnpack: "index-pack abnormal exit",
+			want:       []service.RefStatus{{RefName: "refs/heads/main", Reason: "unpacker error"}},
+		},
+		{
+			name: "v2 options",
+			lines: []string{
+				"unpack ok\n",
+				"ok refs/for/main\n",
+				"option refname refs/changes/01/1/1\n",
+				"option old-oid 0000000000000000000000000000000000000000\n",
+				"option new-oid 1111111111111111111111111111111111111111\n",
+				"option forced-update\n",
+				"ok refs/heads/dev\n",
+			},
+			want: []service.RefStatus{
+				{
+					RefName:      "refs/for/main",
+					OK:           true,
+					UpdatedRef:   "refs/changes/01/1/1",
+					OldHash:      "0000000000000000000000000000000000000000",
+					NewHash:      "1111111111111111111111111111111111111111",
+					ForcedUpdate: true,
+				},
+				{RefName: "refs/heads/dev", OK: true},
+			},
+		},
+		{
+			name:  "option before any status",
+			lines: []string{"unpack ok\n", "option refname refs/heads/x\n", "ok refs/heads/main"},
+			want:  []service.RefStatus{{RefName: "refs/heads/main", OK: true}},
+		},
+		{
+			name:  "other lines",
+			lines: []string{"", "shallow 1111111111111111111111111111111111111111\n", "unpack ok\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r reportStatus
+			for _, line := range tt.lines {
+				r.line([]byte(line))
+			}
+			if r.unpackError != tt.wantUnpack {
+				t.Errorf("unpack error = %q, want %q", r.unpackError, tt.wantUnpack)
+			}
+			if !reflect.DeepEqual(r.statuses, tt.want) {
+				t.Errorf("statuses = %+v, want %+v", r.statuses, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteRefRejections(t *testing.T) {
+	commands := []service.RefCommand{
+		{OldHash: strings.Repeat("0", 40), NewHash: strings.Repeat("1", 40), RefName: "refs/heads/main"},
+		{OldHash: strings.Repeat("0", 40), NewHash: strings.Repeat("2", 40), RefName: "refs/heads/dev"},
+	}
+	rejections := []service.RefRejection{{RefName: "refs/heads/main", Reason: "protected branch"}}
+
+	tests := []struct {
+		name string
+		caps string
+		want string
+	}{
+		{
+			name: "report-status",
+			caps: "report-status",
+			want: "000eunpack ok\n" +
+				"0028ng refs/heads/main protected branch\n" +
+				"003fng refs/heads/dev push declined due to other rejected refs\n" +
+				"0000",
+		},
+		{
+			name: "report-status in side-band-64k",
+			caps: "report-status side-band-64k",
+			want: "002e\x02error: refs/heads/main: protected branch\n" +
+				"007e\x01" +
+				"000eunpack ok\n" +
+				"0028ng refs/heads/main protected branch\n" +
+				"003fng refs/heads/dev push declined due to other rejected refs\n" +
+				"0000" +
+				"0000",
+		},
+		{
+			name: "side-band without report-status",
+			caps: "side-band",
+			want: "002e\x02error: refs/heads/main: protected branch\n" + "0000",
+		},
+		{
+			name: "neither",
+			caps: "",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeRefRejections(&buf, ParseCapabilities(tt.caps), commands, rejections); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("wrote %q, want %q", got, tt.want)
+			}
+
+			// The client reads back what was written
+			out := newPushOutput(&bytes.Buffer{})
+			if _, err := out.Write(buf.Bytes()); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(tt.caps, "report-status") {
+				want := []service.RefStatus{
+					{RefName: "refs/heads/main", Reason: "protected branch"},
+					{RefName: "refs/heads/dev", Reason: "push declined due to other rejected refs"},
+				}
+				if !reflect.DeepEqual(out.status.statuses, want) {
+					t.Errorf("read back %+v, want %+v", out.status.statuses, want)
+				}
+			}
+		})
+	}
+}
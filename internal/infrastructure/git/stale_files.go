@@ -0,0 +1,222 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// Lock and temporary files are named by git itself: <file>.lock for locked
+// refs and config, tmp_* and .tmp-* in objects/pack while a pack is written,
+// tmp_obj_* for loose objects and tmp_objdir-* for the quarantine of a push.
+const (
+	lockSuffix         = ".lock"
+	tmpObjectPrefix    = "tmp_obj_"
+	tmpObjectDirPrefix = "tmp_objdir-"
+)
+
+// RemoveStaleFiles removes the lock files and temporary packs and object
+// directories of a repository last modified before olderThan, which block
+// later operations if left by a killed git process, and returns them
+func (g *GitOperations) RemoveStaleFiles(ctx context.Context, repoPath string, olderThan time.Time) ([]service.StaleFile, error) {
+	stale, err := findStaleFiles(repoPath, olderThan)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := make([]service.StaleFile, 0, len(stale))
+	for _, file := range stale {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+		if err := os.RemoveAll(filepath.Join(repoPath, file.Path)); err != nil {
+			return removed, fmt.Errorf("failed to remove stale file %s: %w", file.Path, err)
+		}
+		removed = append(removed, file)
+	}
+	return removed, nil
+}
+
+// findStaleFiles lists the lock and temporary files of a repository last
+// modified before olderThan
+func findStaleFiles(repoPath string, olderThan time.Time) ([]service.StaleFile, error) {
+	var stale []service.StaleFile
+	add := func(path string, info fs.FileInfo) {
+		if info.ModTime().Before(olderThan) {
+			stale = append(stale, service.StaleFile{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+		}
+	}
+
+	// HEAD.lock, packed-refs.lock, config.lock and the like
+	if err := scanDir(repoPath, "", func(path string, entry fs.DirEntry, info fs.FileInfo) {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), lockSuffix) {
+			add(path, info)
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	// Locks of loose refs and their reflogs
+	for _, dir := range []string{"refs", "logs"} {
+		err := filepath.WalkDir(filepath.Join(repoPath, dir), func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), lockSuffix) {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return ignoreNotExist(err)
+			}
+			rel, _ := filepath.Rel(repoPath, path)
+			add(rel, info)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s for stale locks: %w", dir, err)
+		}
+	}
+
+	// Packs and indexes being written, and pack locks
+	if err := scanDir(repoPath, filepath.Join("objects", "pack"), func(path string, entry fs.DirEntry, info fs.FileInfo) {
+		name := entry.Name()
+		if strings.HasPrefix(name, "tmp_") || strings.HasPrefix(name, ".tmp-") || strings.HasSuffix(name, lockSuffix) {
+			add(path, info)
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	// Quarantines of pushes and loose objects being written
+	var fanout []string
+	if err := scanDir(repoPath, "objects", func(path string, entry fs.DirEntry, info fs.FileInfo) {
+		name := entry.Name()
+		switch {
+		case entry.IsDir() && strings.HasPrefix(name, tmpObjectDirPrefix):
+			// A quarantine is in use as long as any object in it is written to
+			size, modTime, err := treeUsage(filepath.Join(repoPath, path))
+			if err == nil && modTime.Before(olderThan) {
+				stale = append(stale, service.StaleFile{Path: path, Size: size, ModTime: modTime})
+			}
+		case entry.IsDir() && len(name) == 2:
+			fanout = append(fanout, path)
+		case !entry.IsDir() && strings.HasPrefix(name, tmpObjectPrefix):
+			add(path, info)
+		}
+	}); err != nil {
+		return nil, err
+	}
+	for _, dir := range fanout {
+		if err := scanDir(repoPath, dir, func(path string, entry fs.DirEntry, info fs.FileInfo) {
+			if !entry.IsDir() && strings.HasPrefix(entry.Name(), tmpObjectPrefix) {
+				add(path, info)
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return stale, nil
+}
+
+// scanDir calls fn for each entry of a directory of a repository, with its
+// path relative to the repository. A missing directory has no entries.
+func scanDir(repoPath, dir string, fn func(path string, entry fs.DirEntry, info fs.FileInfo)) error {
+	entries, err := os.ReadDir(filepath.Join(repoPath, dir))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to scan %s for stale files: %w", filepath.Join(repoPath, dir), err)
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			// Removed since it was listed
+			continue
+		}
+		fn(filepath.Join(dir, entry.Name()), entry, info)
+	}
+	return nil
+}
+
+// treeUsage returns the total size of the files in a directory and the
+// latest modification of the directory or any entry in it
+func treeUsage(root string) (size int64, modTime time.Time, err error) {
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return ignoreNotExist(err)
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return ignoreNotExist(err)
+		}
+		if !entry.IsDir() {
+			size += info.Size()
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+		return nil
+	})
+	return size, modTime, err
+}
+
+// ignoreNotExist returns nil for errors about files removed while scanning
+func ignoreNotExist(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// removeStaleRefLocks removes the locks of the refs a push updates, and of
+// the packed refs, last modified before olderThan. git takes these locks to
+// update the refs, so a lock left by a killed process fails the push with
+// "cannot lock ref".
+func (p *GitProtocol) removeStaleRefLocks(repoPath string, commands []service.RefCommand, olderThan time.Time) []string {
+	locks := []string{"packed-refs" + lockSuffix}
+	for _, cmd := range commands {
+		// Ref names come from the client; git refuses malformed ones later
+		if !strings.HasPrefix(cmd.RefName, "refs/") || !filepath.IsLocal(cmd.RefName) {
+			continue
+		}
+		locks = append(locks, cmd.RefName+lockSuffix, "logs/"+cmd.RefName+lockSuffix)
+	}
+
+	var removed []string
+	for _, lock := range locks {
+		path := filepath.Join(repoPath, filepath.FromSlash(lock))
+		info, err := os.Lstat(path)
+		if err != nil || info.IsDir() || !info.ModTime().Before(olderThan) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			p.log.Warn("Failed to remove stale ref lock",
+				logger.Error(err),
+				logger.String("repo_path", repoPath),
+				logger.String("lock", lock),
+			)
+			continue
+		}
+		p.log.Warn("Removed stale ref lock left by an interrupted git operation",
+			logger.String("repo_path", repoPath),
+			logger.String("lock", lock),
+			logger.Time("modified_at", info.ModTime()),
+		)
+		removed = append(removed, lock)
+	}
+	return removed
+}
@@ -0,0 +1,200 @@
+package git_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/bravo68web/stasis/internal/infrastructure/git"
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+// touch creates a file of a repository last modified age ago
+func touch(t *testing.T, repoPath, file string, age time.Duration) {
+	t.Helper()
+	path := filepath.Join(repoPath, filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("left by a killed git process"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-age)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// exists reports whether a file of a repository exists
+func exists(t *testing.T, repoPath, file string) bool {
+	t.Helper()
+	_, err := os.Lstat(filepath.Join(repoPath, filepath.FromSlash(file)))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
+
+func TestRemoveStaleFiles(t *testing.T) {
+	b := testutil.TempRepo(t)
+	b.Commit("main", "Initial commit", testutil.File("README.md", "# demo\n"))
+	repoPath := b.Path()
+
+	stale := []string{
+		"HEAD.lock",
+		"config.lock",
+		"packed-refs.lock",
+		"refs/heads/main.lock",
+		"refs/heads/feature/login.lock",
+		"refs/tags/v1.0.lock",
+		"logs/refs/heads/main.lock",
+		"objects/pack/tmp_pack_a1b2c3",
+		"objects/pack/tmp_idx_a1b2c3",
+		"objects/pack/.tmp-4242-pack-0123.pack",
+		"objects/pack/pack-0123.keep.lock",
+		"objects/tmp_obj_x1y2z3",
+		"objects/ab/tmp_obj_x1y2z3",
+	}
+	kept := []string{
+		// Recent, so possibly in use
+		"refs/heads/fresh.lock",
+		"objects/pack/tmp_pack_d4e5f6",
+		"objects/cd/tmp_obj_d4e5f6",
+		// Old, but not lock or temporary files
+		"refs/heads/old",
+		"objects/pack/pack-0123.keep",
+		"objects/ab/cdef0123456789abcdef0123456789abcdef01",
+		"hooks/pre-receive.lock.sample",
+	}
+	for _, file := range stale {
+		touch(t, repoPath, file, 2*time.Hour)
+	}
+	for _, file := range kept[:3] {
+		touch(t, repoPath, file, time.Minute)
+	}
+	for _, file := range kept[3:] {
+		touch(t, repoPath, file, 2*time.Hour)
+	}
+
+	// Quarantines count as recent while any object in them is
+	touch(t, repoPath, "objects/tmp_objdir-incoming-old/pack/tmp_pack_1", 2*time.Hour)
+	touch(t, repoPath, "objects/tmp_objdir-incoming-old/12/3456", 3*time.Hour)
+	for _, dir := range []string{"objects/tmp_objdir-incoming-old/pack", "objects/tmp_objdir-incoming-old/12", "objects/tmp_objdir-incoming-old"} {
+		modTime := time.Now().Add(-2 * time.Hour)
+		if err := os.Chtimes(filepath.Join(repoPath, filepath.FromSlash(dir)), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	touch(t, repoPath, "objects/tmp_objdir-incoming-busy/pack/tmp_pack_2", 2*time.Hour)
+	touch(t, repoPath, "objects/tmp_objdir-incoming-busy/78/9abc", time.Minute)
+	stale = append(stale, "objects/tmp_objdir-incoming-old")
+	kept = append(kept, "objects/tmp_objdir-incoming-busy/pack/tmp_pack_2", "objects/tmp_objdir-incoming-busy/78/9abc")
+
+	removed, err := git.NewGitOperations(nil, nil).RemoveStaleFiles(context.Background(), repoPath, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("RemoveStaleFiles: %v", err)
+	}
+
+	var paths []string
+	for _, file := range removed {
+		paths = append(paths, filepath.ToSlash(file.Path))
+		if file.ModTime.After(time.Now().Add(-time.Hour)) {
+			t.Errorf("removed %s modified at %s", file.Path, file.ModTime)
+		}
+	}
+	slices.Sort(paths)
+	slices.Sort(stale)
+	if !slices.Equal(paths, stale) {
+		t.Errorf("removed %q, want %q", paths, stale)
+	}
+	for _, file := range stale {
+		if exists(t, repoPath, file) {
+			t.Errorf("%s was not removed", file)
+		}
+	}
+	for _, file := range kept {
+		if !exists(t, repoPath, file) {
+			t.Errorf("%s was removed", file)
+		}
+	}
+	if !exists(t, repoPath, "refs/heads") || !exists(t, repoPath, "objects/pack") {
+		t.Error("removed a directory of the repository")
+	}
+
+	// Nothing is left to remove
+	removed, err = git.NewGitOperations(nil, nil).RemoveStaleFiles(context.Background(), repoPath, time.Now().Add(-time.Hour))
+	if err != nil || len(removed) != 0 {
+		t.Errorf("second RemoveStaleFiles = %v, %v, want nothing removed", removed, err)
+	}
+}
+
+// emptyPack returns a pack without objects, as sent by pushes of refs to
+// commits the repository already has
+func emptyPack() []byte {
+	pack := []byte("PACK")
+	pack = binary.BigEndian.AppendUint32(pack, 2)
+	pack = binary.BigEndian.AppendUint32(pack, 0)
+	sum := sha1.Sum(pack)
+	return append(pack, sum[:]...)
+}
+
+// A push removes an old lock left on a ref it updates, if it is the only
+// git operation on the repository
+func TestReceivePackRemovesStaleRefLock(t *testing.T) {
+	tests := []struct {
+		name         string
+		staleLockAge time.Duration
+		lockAge      time.Duration
+		exclusive    bool
+		wantPushed   bool
+	}{
+		{name: "old lock", staleLockAge: time.Hour, lockAge: 2 * time.Hour, exclusive: true, wantPushed: true},
+		{name: "recent lock", staleLockAge: time.Hour, lockAge: time.Minute, exclusive: true},
+		{name: "other operations in flight", staleLockAge: time.Hour, lockAge: 2 * time.Hour},
+		{name: "removal disabled", lockAge: 2 * time.Hour, exclusive: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := testutil.TempRepo(t)
+			tip := b.Commit("main", "Initial commit", testutil.File("README.md", "# demo\n"))
+			touch(t, b.Path(), "refs/heads/feature.lock", tt.lockAge)
+
+			protocol, err := git.NewGitProtocol(git.ReceiveLimits{StaleLockAge: tt.staleLockAge})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var request bytes.Buffer
+			request.WriteString(git.EncodePktLine(plumbing.ZeroHash.String() + " " + tip.String() + " refs/heads/feature\x00report-status\n"))
+			request.WriteString(git.FlushPacket())
+			request.Write(emptyPack())
+
+			var output bytes.Buffer
+			result, err := protocol.HandleReceivePack(context.Background(), b.Path(), &request, &output, nil, git.ReceiveOptions{Exclusive: tt.exclusive})
+			if err != nil && !errors.Is(err, git.ErrPushRejected) {
+				t.Fatalf("HandleReceivePack: %v", err)
+			}
+
+			ref, refErr := b.Repository().Reference(plumbing.NewBranchReferenceName("feature"), false)
+			pushed := refErr == nil && ref.Hash() == tip
+			if len(result.Statuses) != 1 || result.Statuses[0].OK != pushed {
+				t.Errorf("reported %+v, but the ref was pushed: %v", result.Statuses, pushed)
+			}
+			if pushed != tt.wantPushed {
+				t.Fatalf("pushed %v, want %v\n%s", pushed, tt.wantPushed, output.String())
+			}
+			if lock := exists(t, b.Path(), "refs/heads/feature.lock"); lock == tt.wantPushed {
+				t.Errorf("lock exists after the push: %v", lock)
+			}
+		})
+	}
+}
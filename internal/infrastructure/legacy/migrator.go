@@ -0,0 +1,665 @@
+// Package legacy imports a server running the legacy githut schema of SPEC.md
+// (users, ssh_keys, repos, repo_members, tokens and audit_logs, with the
+// repositories under data/repos/{owner}/{repo}.git) into the models and
+// storage of this server.
+package legacy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// auditBatchSize is the number of audit logs read and written at once
+const auditBatchSize = 500
+
+// LegacyIDKey is the metadata key of a migrated audit event holding the ID of
+// its legacy audit log, which makes the migration idempotent
+const LegacyIDKey = "legacy_audit_log_id"
+
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errors.New("legacy: dry run")
+
+// Open connects to the legacy database at dsn
+func Open(dsn string) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the legacy database: %w", err)
+	}
+	return db, nil
+}
+
+// Migrator copies the rows of a legacy database into the database of this
+// server, and the repositories of the legacy server into its storage.
+//
+// Users keep their username and email and get a new UUID; the legacy admin
+// role makes site administrators, the other global roles have no equivalent.
+// Users sign in with OIDC, which links them by email. Disabled users are not
+// migrated, nor their keys, repositories and memberships. SSH keys get the
+// SHA256 fingerprint this server uses. Repositories are copied to the backend
+// the placement rules pick, in the layout of that backend; internal ones
+// become private. Memberships become collaborators (maintainer: admin,
+// developer: write, reader: read). Legacy access tokens are not migrated and
+// their users are listed for rotation.
+//
+// Every row is written in one transaction, and rows migrated by an earlier
+// run are recognized, so a failed run can be run again.
+type Migrator struct {
+	legacy    *gorm.DB
+	target    *gorm.DB
+	storage   service.StorageBackends
+	reposRoot string
+	log       *logger.Logger
+}
+
+// NewMigrator creates a Migrator from the legacy database to target, copying
+// the repositories under reposRoot to the backends of storage
+func NewMigrator(legacy, target *gorm.DB, storage service.StorageBackends, reposRoot string) *Migrator {
+	return &Migrator{
+		legacy:    legacy,
+		target:    target,
+		storage:   storage,
+		reposRoot: reposRoot,
+		log:       logger.Get().WithFields(logger.Component("legacy-migration")),
+	}
+}
+
+// Legacy rows, as read from the tables of SPEC.md
+type (
+	legacyUser struct {
+		ID        int64
+		Username  string
+		Email     string
+		Role      string
+		Disabled  bool
+		CreatedAt time.Time
+	}
+	legacySSHKey struct {
+		ID        int64
+		UserID    *int64
+		Name      string
+		Pubkey    string
+		CreatedAt time.Time
+	}
+	legacyRepo struct {
+		ID            int64
+		OwnerID       *int64
+		Name          string
+		Visibility    string
+		DefaultBranch string
+		Archived      bool
+		Deleted       bool
+		CreatedAt     time.Time
+	}
+	legacyMember struct {
+		RepoID int64
+		UserID int64
+		Role   string
+	}
+	legacyAuditLog struct {
+		ID      int64
+		ActorID *int64
+		Action  string
+		RepoID  *int64
+		IP      string
+		Ts      time.Time
+		Meta    string
+	}
+)
+
+// memberPermissions maps the roles of repo_members to collaborator permissions
+var memberPermissions = map[string]models.RepoPermission{
+	"maintainer": models.RepoPermissionAdmin,
+	"developer":  models.RepoPermissionWrite,
+	"reader":     models.RepoPermissionRead,
+}
+
+// copiedRepository is a repository copied to storage by a run, removed again
+// if the run fails
+type copiedRepository struct {
+	backend service.StorageService
+	path    string
+}
+
+// run is the state of one migration
+type run struct {
+	*Migrator
+	ctx    context.Context
+	tx     *gorm.DB
+	dryRun bool
+	report *Report
+
+	usernames map[int64]string    // Legacy user ID to username
+	users     map[int64]uuid.UUID // Legacy user ID to migrated user
+	owners    map[int64]int64     // Legacy repository ID to legacy owner ID
+	repos     map[int64]uuid.UUID // Legacy repository ID to migrated repository
+	copies    []copiedRepository
+}
+
+// Run migrates the legacy server and returns the reconciliation report. A dry
+// run writes nothing, neither rows nor repositories, and reports what a run
+// would do.
+func (m *Migrator) Run(ctx context.Context, dryRun bool) (*Report, error) {
+	r := &run{
+		Migrator:  m,
+		ctx:       ctx,
+		dryRun:    dryRun,
+		report:    &Report{DryRun: dryRun},
+		usernames: make(map[int64]string),
+		users:     make(map[int64]uuid.UUID),
+		owners:    make(map[int64]int64),
+		repos:     make(map[int64]uuid.UUID),
+	}
+
+	m.log.Info("Migrating legacy server",
+		logger.String("repos_root", m.reposRoot),
+		logger.Bool("dry_run", dryRun),
+	)
+
+	err := m.target.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		r.tx = tx
+		for _, step := range []func() error{r.migrateUsers, r.migrateSSHKeys, r.migrateRepositories, r.migrateMembers, r.migrateAuditLogs, r.listTokens} {
+			if err := step(); err != nil {
+				return err
+			}
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		r.removeCopies()
+		m.log.Error("Legacy migration failed, nothing was migrated",
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
+	m.log.Info("Legacy migration complete",
+		logger.Int("users", r.report.Users.Migrated),
+		logger.Int("repositories", r.report.Repositories.Migrated),
+		logger.Int("issues", len(r.report.Issues)),
+		logger.Bool("dry_run", dryRun),
+	)
+	return r.report, nil
+}
+
+// issue records a legacy row that was not migrated as it was
+func (r *run) issue(table string, id int64, subject, reason string, skipped bool) {
+	r.report.Issues = append(r.report.Issues, Issue{Table: table, LegacyID: id, Subject: subject, Reason: reason, Skipped: skipped})
+}
+
+// username returns the legacy username of a user for the report
+func (r *run) username(id int64) string {
+	if name, ok := r.usernames[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("legacy user %d", id)
+}
+
+func (r *run) migrateUsers() error {
+	var rows []legacyUser
+	err := r.legacy.WithContext(r.ctx).Raw(`SELECT id, username, email, role, COALESCE(disabled, false) AS disabled,
+		COALESCE(created_at, now()) AS created_at FROM users ORDER BY id`).Scan(&rows).Error
+	if err != nil {
+		return fmt.Errorf("failed to read legacy users: %w", err)
+	}
+
+	count := &r.report.Users
+	for _, row := range rows {
+		count.Legacy++
+		r.usernames[row.ID] = row.Username
+		if row.Disabled {
+			count.Skipped++
+			r.issue("users", row.ID, row.Username, "disabled in the legacy server, which this server cannot express", true)
+			continue
+		}
+
+		var existing models.User
+		if err := r.tx.Where("username = ?", row.Username).Limit(1).Find(&existing).Error; err != nil {
+			return fmt.Errorf("failed to look up user %s: %w", row.Username, err)
+		}
+		if existing.ID != uuid.Nil {
+			r.users[row.ID] = existing.ID
+			count.Existing++
+			continue
+		}
+
+		email := strings.ToLower(row.Email)
+		var taken int64
+		if err := r.tx.Model(&models.User{}).Where("lower(email) = ?", email).Count(&taken).Error; err != nil {
+			return fmt.Errorf("failed to look up email of %s: %w", row.Username, err)
+		}
+		if taken > 0 {
+			count.Skipped++
+			r.issue("users", row.ID, row.Username, "email "+email+" belongs to another user", true)
+			continue
+		}
+
+		user := &models.User{
+			ID:        uuid.New(),
+			Username:  row.Username,
+			Email:     email,
+			IsAdmin:   row.Role == "admin",
+			CreatedAt: row.CreatedAt,
+		}
+		if err := r.tx.Create(user).Error; err != nil {
+			return fmt.Errorf("failed to create user %s: %w", row.Username, err)
+		}
+		r.users[row.ID] = user.ID
+		count.Migrated++
+	}
+	return nil
+}
+
+func (r *run) migrateSSHKeys() error {
+	var rows []legacySSHKey
+	err := r.legacy.WithContext(r.ctx).Raw(`SELECT id, user_id, name, pubkey,
+		COALESCE(created_at, now()) AS created_at FROM ssh_keys ORDER BY id`).Scan(&rows).Error
+	if err != nil {
+		return fmt.Errorf("failed to read legacy ssh keys: %w", err)
+	}
+
+	count := &r.report.SSHKeys
+	for _, row := range rows {
+		count.Legacy++
+		var userID uuid.UUID
+		if row.UserID != nil {
+			userID = r.users[*row.UserID]
+		}
+		if userID == uuid.Nil {
+			count.Skipped++
+			r.issue("ssh_keys", row.ID, row.Name, "its user is not migrated", true)
+			continue
+		}
+
+		parsed, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(row.Pubkey))
+		if err != nil {
+			count.Skipped++
+			r.issue("ssh_keys", row.ID, row.Name, "invalid public key", true)
+			continue
+		}
+		fingerprint := ssh.FingerprintSHA256(parsed)
+
+		var existing models.SSHKey
+		if err := r.tx.Where("fingerprint = ?", fingerprint).Limit(1).Find(&existing).Error; err != nil {
+			return fmt.Errorf("failed to look up ssh key %s: %w", fingerprint, err)
+		}
+		if existing.ID != uuid.Nil {
+			if existing.UserID == userID {
+				count.Existing++
+			} else {
+				count.Skipped++
+				r.issue("ssh_keys", row.ID, row.Name, "the key belongs to another user", true)
+			}
+			continue
+		}
+
+		title := row.Name
+		if title == "" {
+			title = comment
+		}
+		if title == "" {
+			title = parsed.Type() + " key"
+		}
+		key := &models.SSHKey{
+			ID:          uuid.New(),
+			UserID:      userID,
+			Title:       title,
+			PublicKey:   strings.TrimSpace(row.Pubkey),
+			Fingerprint: fingerprint,
+			KeyType:     parsed.Type(),
+			CreatedAt:   row.CreatedAt,
+		}
+		if err := r.tx.Create(key).Error; err != nil {
+			return fmt.Errorf("failed to create ssh key %s: %w", fingerprint, err)
+		}
+		count.Migrated++
+	}
+	return nil
+}
+
+func (r *run) migrateRepositories() error {
+	var rows []legacyRepo
+	err := r.legacy.WithContext(r.ctx).Raw(`SELECT id, owner_id, name, visibility, COALESCE(default_branch, '') AS default_branch,
+		COALESCE(archived, false) AS archived, deleted_at IS NOT NULL AS deleted,
+		COALESCE(created_at, now()) AS created_at FROM repos ORDER BY id`).Scan(&rows).Error
+	if err != nil {
+		return fmt.Errorf("failed to read legacy repositories: %w", err)
+	}
+
+	count := &r.report.Repositories
+	for _, row := range rows {
+		count.Legacy++
+		var ownerID uuid.UUID
+		owner := "unknown owner"
+		if row.OwnerID != nil {
+			r.owners[row.ID] = *row.OwnerID
+			ownerID = r.users[*row.OwnerID]
+			owner = r.username(*row.OwnerID)
+		}
+		subject := owner + "/" + row.Name
+		if row.Deleted {
+			count.Skipped++
+			r.issue("repos", row.ID, subject, "deleted in the legacy server", true)
+			continue
+		}
+		if ownerID == uuid.Nil {
+			count.Skipped++
+			r.issue("repos", row.ID, subject, "its owner is not migrated", true)
+			continue
+		}
+
+		var existing models.Repository
+		if err := r.tx.Where("owner_id = ? AND lower(name) = lower(?)", ownerID, row.Name).Limit(1).Find(&existing).Error; err != nil {
+			return fmt.Errorf("failed to look up repository %s: %w", subject, err)
+		}
+		if existing.ID != uuid.Nil {
+			r.repos[row.ID] = existing.ID
+			count.Existing++
+			continue
+		}
+
+		source := filepath.Join(r.reposRoot, owner, row.Name+".git")
+		size, err := gitDirectorySize(source)
+		if err != nil {
+			count.Skipped++
+			r.issue("repos", row.ID, subject, err.Error(), true)
+			continue
+		}
+
+		backendName := r.storage.Place(owner, size)
+		backend, ok := r.storage.Backend(backendName)
+		if !ok {
+			return fmt.Errorf("storage placement picked unknown backend %q for %s", backendName, subject)
+		}
+		gitPath := backend.GetRepoPath(owner, row.Name)
+		if !samePath(source, gitPath) {
+			exists, err := backend.Exists(gitPath)
+			if err != nil {
+				return fmt.Errorf("failed to check storage of %s: %w", subject, err)
+			}
+			if exists {
+				count.Skipped++
+				r.issue("repos", row.ID, subject, "storage path "+gitPath+" is already in use", true)
+				continue
+			}
+			if !r.dryRun {
+				if err := backend.ImportDirectory(source, gitPath); err != nil {
+					_ = backend.DeleteDirectory(gitPath)
+					return fmt.Errorf("failed to copy repository %s: %w", subject, err)
+				}
+				r.copies = append(r.copies, copiedRepository{backend: backend, path: gitPath})
+			}
+		}
+
+		private := true
+		switch row.Visibility {
+		case "public":
+			private = false
+		case "private":
+		case "internal":
+			r.issue("repos", row.ID, subject, "internal visibility does not exist here, made private", false)
+		default:
+			r.issue("repos", row.ID, subject, fmt.Sprintf("unknown visibility %q, made private", row.Visibility), false)
+		}
+		if row.Archived {
+			r.issue("repos", row.ID, subject, "archived in the legacy server, which this server cannot express", false)
+		}
+		branch := row.DefaultBranch
+		if branch == "" {
+			branch = "main"
+		}
+
+		repo := &models.Repository{
+			ID:             uuid.New(),
+			Name:           row.Name,
+			OwnerID:        ownerID,
+			IsPrivate:      private,
+			DefaultBranch:  branch,
+			GitPath:        gitPath,
+			StorageBackend: backendName,
+			SizeBytes:      size,
+			CreatedAt:      row.CreatedAt,
+		}
+		if err := r.tx.Create(repo).Error; err != nil {
+			return fmt.Errorf("failed to create repository %s: %w", subject, err)
+		}
+		r.repos[row.ID] = repo.ID
+		count.Migrated++
+	}
+	return nil
+}
+
+func (r *run) migrateMembers() error {
+	var rows []legacyMember
+	err := r.legacy.WithContext(r.ctx).Raw(`SELECT repo_id, user_id, role FROM repo_members ORDER BY repo_id, user_id`).Scan(&rows).Error
+	if err != nil {
+		return fmt.Errorf("failed to read legacy repository members: %w", err)
+	}
+
+	count := &r.report.Collaborators
+	for _, row := range rows {
+		count.Legacy++
+		subject := r.username(row.UserID)
+		repoID, userID := r.repos[row.RepoID], r.users[row.UserID]
+		permission, known := memberPermissions[row.Role]
+		switch {
+		case repoID == uuid.Nil || userID == uuid.Nil:
+			count.Skipped++
+			r.issue("repo_members", row.RepoID, subject, "its repository or user is not migrated", true)
+			continue
+		case r.owners[row.RepoID] == row.UserID:
+			count.Skipped++
+			r.issue("repo_members", row.RepoID, subject, "the owner of the repository", true)
+			continue
+		case !known:
+			count.Skipped++
+			r.issue("repo_members", row.RepoID, subject, fmt.Sprintf("unknown role %q", row.Role), true)
+			continue
+		}
+
+		var existing int64
+		if err := r.tx.Model(&models.RepositoryCollaborator{}).
+			Where("repository_id = ? AND user_id = ?", repoID, userID).Count(&existing).Error; err != nil {
+			return fmt.Errorf("failed to look up collaborator %s: %w", subject, err)
+		}
+		if existing > 0 {
+			count.Existing++
+			continue
+		}
+
+		collaborator := &models.RepositoryCollaborator{
+			RepositoryID: repoID,
+			UserID:       userID,
+			Permission:   permission,
+		}
+		if err := r.tx.Create(collaborator).Error; err != nil {
+			return fmt.Errorf("failed to create collaborator %s: %w", subject, err)
+		}
+		count.Migrated++
+	}
+	return nil
+}
+
+func (r *run) migrateAuditLogs() error {
+	var migrated []int64
+	err := r.tx.Raw(`SELECT (metadata->>?)::bigint FROM audit_events WHERE metadata->>? IS NOT NULL`, LegacyIDKey, LegacyIDKey).
+		Scan(&migrated).Error
+	if err != nil {
+		return fmt.Errorf("failed to read migrated audit events: %w", err)
+	}
+	slices.Sort(migrated)
+
+	count := &r.report.AuditEvents
+	var after int64
+	for {
+		var rows []legacyAuditLog
+		err := r.legacy.WithContext(r.ctx).Raw(`SELECT id, actor_id, action, repo_id, COALESCE(host(ip), '') AS ip,
+			COALESCE(ts, now()) AS ts, COALESCE(meta::text, '') AS meta
+			FROM audit_logs WHERE id > ? ORDER BY id LIMIT ?`, after, auditBatchSize).Scan(&rows).Error
+		if err != nil {
+			return fmt.Errorf("failed to read legacy audit logs: %w", err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		after = rows[len(rows)-1].ID
+
+		events := make([]*models.AuditEvent, 0, len(rows))
+		for _, row := range rows {
+			count.Legacy++
+			if _, found := slices.BinarySearch(migrated, row.ID); found {
+				count.Existing++
+				continue
+			}
+			events = append(events, r.auditEvent(row))
+		}
+		if len(events) == 0 {
+			continue
+		}
+		if err := r.tx.Create(&events).Error; err != nil {
+			return fmt.Errorf("failed to create audit events: %w", err)
+		}
+		count.Migrated += len(events)
+	}
+}
+
+// auditEvent returns the audit event of a legacy audit log. Its metadata keeps
+// the legacy metadata, and the legacy repository if it was not migrated.
+func (r *run) auditEvent(row legacyAuditLog) *models.AuditEvent {
+	metadata := map[string]any{}
+	if row.Meta != "" {
+		var meta any
+		if err := json.Unmarshal([]byte(row.Meta), &meta); err == nil {
+			if object, ok := meta.(map[string]any); ok {
+				metadata = object
+			} else if meta != nil {
+				metadata["legacy_meta"] = meta
+			}
+		}
+	}
+	metadata[LegacyIDKey] = row.ID
+
+	event := &models.AuditEvent{
+		Actor:     "anonymous",
+		Action:    row.Action,
+		Metadata:  metadata,
+		IP:        row.IP,
+		CreatedAt: row.Ts,
+	}
+	if len(event.Action) > 64 {
+		event.Action = event.Action[:64]
+	}
+	if row.ActorID != nil {
+		event.Actor = r.username(*row.ActorID)
+		if id, ok := r.users[*row.ActorID]; ok {
+			event.ActorID = &id
+		}
+	}
+	if row.RepoID != nil {
+		if id, ok := r.repos[*row.RepoID]; ok {
+			event.RepositoryID = &id
+		} else {
+			metadata["legacy_repo_id"] = *row.RepoID
+		}
+	}
+	return event
+}
+
+// listTokens lists the migrated users who had legacy access tokens. The
+// tokens table is optional, as SPEC.md does not describe it; only its
+// user_id column is read.
+func (r *run) listTokens() error {
+	var exists bool
+	if err := r.legacy.WithContext(r.ctx).Raw(`SELECT to_regclass('tokens') IS NOT NULL`).Row().Scan(&exists); err != nil {
+		return fmt.Errorf("failed to look up legacy tokens: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+
+	var rows []struct {
+		UserID int64
+		Tokens int
+	}
+	err := r.legacy.WithContext(r.ctx).Raw(`SELECT user_id, count(*) AS tokens FROM tokens
+		WHERE user_id IS NOT NULL GROUP BY user_id ORDER BY user_id`).Scan(&rows).Error
+	if err != nil {
+		return fmt.Errorf("failed to read legacy tokens: %w", err)
+	}
+	for _, row := range rows {
+		if _, ok := r.users[row.UserID]; ok {
+			r.report.TokenRotations = append(r.report.TokenRotations, TokenRotation{Username: r.username(row.UserID), Tokens: row.Tokens})
+		}
+	}
+	return nil
+}
+
+// removeCopies deletes the repositories a failed run copied to storage
+func (r *run) removeCopies() {
+	for _, copied := range r.copies {
+		if err := copied.backend.DeleteDirectory(copied.path); err != nil {
+			r.log.Error("Failed to remove repository copied by the failed migration - manual cleanup may be required",
+				logger.Error(err),
+				logger.String("git_path", copied.path),
+			)
+		}
+	}
+}
+
+// gitDirectorySize returns the size of the bare repository at path, or an
+// error if there is none
+func gitDirectorySize(path string) (int64, error) {
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(path, name)); err != nil {
+			return 0, fmt.Errorf("no git repository at %s", path)
+		}
+	}
+
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read git repository at %s: %w", path, err)
+	}
+	return size, nil
+}
+
+// samePath returns true if the legacy repository already lives where the
+// storage backend keeps it, so it is adopted rather than copied
+func samePath(source, gitPath string) bool {
+	a, errA := filepath.Abs(source)
+	b, errB := filepath.Abs(gitPath)
+	return errA == nil && errB == nil && a == b
+}
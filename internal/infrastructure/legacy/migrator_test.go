@@ -0,0 +1,329 @@
+package legacy_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/infrastructure/legacy"
+	"github.com/bravo68web/stasis/internal/infrastructure/storage"
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+// legacySchema is the schema of SPEC.md, with a tokens table
+const legacySchema = `
+CREATE TABLE users (
+	id BIGSERIAL PRIMARY KEY,
+	username TEXT UNIQUE NOT NULL,
+	email TEXT UNIQUE NOT NULL,
+	role TEXT NOT NULL,
+	disabled BOOLEAN DEFAULT FALSE,
+	created_at TIMESTAMPTZ DEFAULT now()
+);
+CREATE TABLE ssh_keys (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT REFERENCES users(id),
+	name TEXT NOT NULL,
+	pubkey TEXT NOT NULL,
+	fingerprint TEXT UNIQUE,
+	created_at TIMESTAMPTZ DEFAULT now()
+);
+CREATE TABLE repos (
+	id BIGSERIAL PRIMARY KEY,
+	owner_id BIGINT REFERENCES users(id),
+	name TEXT NOT NULL,
+	visibility TEXT NOT NULL,
+	default_branch TEXT,
+	archived BOOLEAN DEFAULT FALSE,
+	created_at TIMESTAMPTZ DEFAULT now(),
+	deleted_at TIMESTAMPTZ,
+	UNIQUE(owner_id, name)
+);
+CREATE TABLE repo_members (
+	repo_id BIGINT REFERENCES repos(id),
+	user_id BIGINT REFERENCES users(id),
+	role TEXT NOT NULL,
+	PRIMARY KEY (repo_id, user_id)
+);
+CREATE TABLE audit_logs (
+	id BIGSERIAL PRIMARY KEY,
+	actor_id BIGINT REFERENCES users(id),
+	action TEXT NOT NULL,
+	repo_id BIGINT REFERENCES repos(id),
+	ip INET,
+	ts TIMESTAMPTZ DEFAULT now(),
+	meta JSONB
+);
+CREATE TABLE tokens (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT REFERENCES users(id),
+	token_hash TEXT NOT NULL
+);
+`
+
+// authorizedKey returns a new public key in the authorized_keys format
+func authorizedKey(t *testing.T) string {
+	t.Helper()
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+}
+
+// createBareRepository lays out an empty bare repository at path
+func createBareRepository(t *testing.T, path string) {
+	t.Helper()
+	for _, dir := range []string{"objects", "refs/heads"} {
+		if err := os.MkdirAll(filepath.Join(path, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(path, "HEAD"), []byte("ref: refs/heads/main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// seedLegacy creates the legacy schema and its rows, and the repositories of
+// the legacy server under reposRoot. It returns the public key of alice.
+func seedLegacy(t *testing.T, db *gorm.DB, reposRoot string) string {
+	t.Helper()
+	aliceKey := authorizedKey(t)
+	type statement struct {
+		sql  string
+		args []any
+	}
+	// One statement at a time, as the connection prepares them
+	var statements []statement
+	for _, table := range strings.Split(legacySchema, ";") {
+		if strings.TrimSpace(table) != "" {
+			statements = append(statements, statement{sql: table})
+		}
+	}
+	statements = append(statements, []statement{
+		{sql: `INSERT INTO users (id, username, email, role, disabled) VALUES
+			(1, 'alice', 'alice@example.com', 'admin', false),
+			(2, 'bob', 'bob@example.com', 'developer', false),
+			(3, 'carol', 'carol@example.com', 'maintainer', true),
+			(4, 'alice2', 'Alice@example.com', 'reader', false)`},
+		{sql: `INSERT INTO ssh_keys (user_id, name, pubkey, fingerprint) VALUES
+			(1, 'laptop', ?, 'legacy-1'), (2, 'broken', 'not a key', 'legacy-2'), (3, 'desktop', ?, 'legacy-3')`,
+			args: []any{aliceKey, authorizedKey(t)}},
+		{sql: `INSERT INTO repos (id, owner_id, name, visibility, default_branch, archived, deleted_at) VALUES
+			(1, 1, 'app', 'public', 'trunk', false, NULL),
+			(2, 1, 'secret', 'internal', NULL, true, NULL),
+			(3, 2, 'gone', 'public', 'main', false, now()),
+			(4, 2, 'missing', 'private', 'main', false, NULL),
+			(5, 3, 'old', 'public', 'main', false, NULL)`},
+		{sql: `INSERT INTO repo_members (repo_id, user_id, role) VALUES
+			(1, 2, 'developer'), (1, 1, 'maintainer'), (2, 2, 'wizard')`},
+		{sql: `INSERT INTO audit_logs (actor_id, action, repo_id, ip, meta) VALUES
+			(1, 'push', 1, '10.0.0.1', '{"ref": "refs/heads/trunk"}'),
+			(NULL, 'clone', 4, NULL, NULL)`},
+		{sql: `INSERT INTO tokens (user_id, token_hash) VALUES (1, 'a'), (1, 'b'), (3, 'c')`},
+	}...)
+	for _, stmt := range statements {
+		if err := db.Exec(stmt.sql, stmt.args...).Error; err != nil {
+			t.Fatalf("seed legacy database: %v", err)
+		}
+	}
+
+	for _, repo := range []string{"alice/app.git", "alice/secret.git", "carol/old.git"} {
+		createBareRepository(t, filepath.Join(reposRoot, repo))
+	}
+	return aliceKey
+}
+
+func TestMigrateLegacy(t *testing.T) {
+	ctx := context.Background()
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, dropTarget, err := testutil.NewDatabase(ctx, cfg.Database)
+	if errors.Is(err, testutil.ErrNoDatabase) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dropTarget()
+	legacyDB, dropLegacy, err := testutil.NewEmptyDatabase(ctx, cfg.Database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dropLegacy()
+
+	reposRoot := t.TempDir()
+	aliceKey := seedLegacy(t, legacyDB.DB(), reposRoot)
+	storageRoot := t.TempDir()
+	backends, err := storage.NewRegistry(&config.StorageConfig{
+		StorageBackendConfig: config.StorageBackendConfig{Type: "filesystem", BasePath: storageRoot},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator := legacy.NewMigrator(legacyDB.DB(), target.DB(), backends, reposRoot)
+
+	wantCounts := func(t *testing.T, report *legacy.Report, again bool) {
+		t.Helper()
+		want := map[string][2]legacy.Count{
+			// Migrated by a first run, then found by the next ones
+			"users":        {{Legacy: 4, Migrated: 2, Skipped: 2}, {Legacy: 4, Existing: 2, Skipped: 2}},
+			"ssh_keys":     {{Legacy: 3, Migrated: 1, Skipped: 2}, {Legacy: 3, Existing: 1, Skipped: 2}},
+			"repos":        {{Legacy: 5, Migrated: 2, Skipped: 3}, {Legacy: 5, Existing: 2, Skipped: 3}},
+			"repo_members": {{Legacy: 3, Migrated: 1, Skipped: 2}, {Legacy: 3, Existing: 1, Skipped: 2}},
+			"audit_logs":   {{Legacy: 2, Migrated: 2}, {Legacy: 2, Existing: 2}},
+		}
+		got := map[string]legacy.Count{
+			"users":        report.Users,
+			"ssh_keys":     report.SSHKeys,
+			"repos":        report.Repositories,
+			"repo_members": report.Collaborators,
+			"audit_logs":   report.AuditEvents,
+		}
+		run := 0
+		if again {
+			run = 1
+		}
+		for table, counts := range want {
+			if got[table] != counts[run] {
+				t.Errorf("%s: counts = %+v, want %+v", table, got[table], counts[run])
+			}
+		}
+		if !report.Reconciled() {
+			t.Error("report does not reconcile")
+		}
+		if len(report.TokenRotations) != 1 || report.TokenRotations[0] != (legacy.TokenRotation{Username: "alice", Tokens: 2}) {
+			t.Errorf("token rotations = %+v, want the 2 tokens of alice", report.TokenRotations)
+		}
+	}
+
+	// A dry run reports the migration and writes nothing
+	report, err := migrator.Run(ctx, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	wantCounts(t, report, false)
+	var users int64
+	if err := target.DB().Model(&models.User{}).Count(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(storageRoot); users != 0 || len(entries) != 0 {
+		t.Fatalf("dry run left %d users and %d storage entries, want none", users, len(entries))
+	}
+
+	report, err = migrator.Run(ctx, false)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	wantCounts(t, report, false)
+	var text strings.Builder
+	if _, err := report.WriteTo(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"(carol): disabled in the legacy server",
+		"(alice2): email alice@example.com belongs to another user [skipped]",
+		"(alice/secret): internal visibility does not exist here, made private [migrated]",
+		"(bob/gone): deleted in the legacy server [skipped]",
+		"no git repository at " + filepath.Join(reposRoot, "bob", "missing.git"),
+		"alice: 2 tokens",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("report misses %q:\n%s", want, text.String())
+		}
+	}
+
+	db := target.DB()
+	var alice, bob models.User
+	if err := db.Where("username = ?", "alice").First(&alice).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Where("username = ?", "bob").First(&bob).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !alice.IsAdmin || bob.IsAdmin {
+		t.Errorf("alice admin = %v, bob admin = %v, want only alice", alice.IsAdmin, bob.IsAdmin)
+	}
+
+	var key models.SSHKey
+	if err := db.Where("user_id = ?", alice.ID).First(&key).Error; err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, _, _, _ := ssh.ParseAuthorizedKey([]byte(aliceKey))
+	if key.Title != "laptop" || key.Fingerprint != ssh.FingerprintSHA256(parsed) || key.KeyType != ssh.KeyAlgoED25519 {
+		t.Errorf("ssh key of alice = %+v, want laptop with its SHA256 fingerprint", key)
+	}
+
+	var app, secret models.Repository
+	if err := db.Where("owner_id = ? AND name = ?", alice.ID, "app").First(&app).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Where("owner_id = ? AND name = ?", alice.ID, "secret").First(&secret).Error; err != nil {
+		t.Fatal(err)
+	}
+	if app.IsPrivate || app.DefaultBranch != "trunk" || !secret.IsPrivate || secret.DefaultBranch != "main" {
+		t.Errorf("app private = %v on %s, secret private = %v on %s, want public app on trunk, private secret on main",
+			app.IsPrivate, app.DefaultBranch, secret.IsPrivate, secret.DefaultBranch)
+	}
+	if want := filepath.Join(storageRoot, "alice", "app.git"); app.GitPath != want || app.StorageBackend != config.DefaultStorageBackend {
+		t.Errorf("app lives at %s on %s, want %s on the default backend", app.GitPath, app.StorageBackend, want)
+	}
+	if _, err := os.Stat(filepath.Join(app.GitPath, "HEAD")); err != nil {
+		t.Errorf("app was not copied to storage: %v", err)
+	}
+
+	var collaborator models.RepositoryCollaborator
+	if err := db.Where("repository_id = ?", app.ID).First(&collaborator).Error; err != nil {
+		t.Fatal(err)
+	}
+	if collaborator.UserID != bob.ID || collaborator.Permission != models.RepoPermissionWrite {
+		t.Errorf("collaborator of app = %+v, want bob with write", collaborator)
+	}
+
+	var events []models.AuditEvent
+	if err := db.Order("created_at, actor").Find(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("migrated %d audit events, want 2", len(events))
+	}
+	for _, event := range events {
+		switch event.Action {
+		case "push":
+			if event.Actor != "alice" || event.ActorID == nil || *event.ActorID != alice.ID ||
+				event.RepositoryID == nil || *event.RepositoryID != app.ID ||
+				event.IP != "10.0.0.1" || event.Metadata["ref"] != "refs/heads/trunk" {
+				t.Errorf("push event = %+v, want alice pushing to app from 10.0.0.1", event)
+			}
+		case "clone":
+			if event.Actor != "anonymous" || event.ActorID != nil || event.RepositoryID != nil || event.Metadata["legacy_repo_id"] != float64(4) {
+				t.Errorf("clone event = %+v, want an anonymous clone of legacy repository 4", event)
+			}
+		default:
+			t.Errorf("unexpected audit event %+v", event)
+		}
+	}
+
+	// Running again finds everything migrated
+	report, err = migrator.Run(ctx, false)
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	wantCounts(t, report, true)
+}
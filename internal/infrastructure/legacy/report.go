@@ -0,0 +1,110 @@
+package legacy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Count reconciles the rows of a legacy table with the migrated ones: every
+// legacy row is either migrated, found from an earlier run, or skipped
+type Count struct {
+	Legacy   int // Rows of the legacy table
+	Migrated int // Rows created by this run
+	Existing int // Rows created by an earlier run
+	Skipped  int // Rows not migrated, each with an Issue
+}
+
+// Issue is a legacy row that was not migrated, or not migrated as it was
+type Issue struct {
+	Table    string // Legacy table of the row
+	LegacyID int64  // ID of the row; the repository for repo_members
+	Subject  string // Username, owner/name or title of the row
+	Reason   string
+	Skipped  bool // The row was not migrated
+}
+
+// TokenRotation is a user whose legacy access tokens must be replaced
+type TokenRotation struct {
+	Username string
+	Tokens   int
+}
+
+// Report is the outcome of a legacy migration
+type Report struct {
+	DryRun bool // Nothing was written
+
+	Users         Count
+	SSHKeys       Count
+	Repositories  Count
+	Collaborators Count
+	AuditEvents   Count
+
+	Issues []Issue
+
+	// TokenRotations lists the users who had legacy access tokens. Tokens
+	// are not migrated, their hashes are not compatible: the users have to
+	// create new ones.
+	TokenRotations []TokenRotation
+}
+
+// Reconciled returns true if every legacy row is accounted for
+func (r *Report) Reconciled() bool {
+	for _, c := range []Count{r.Users, r.SSHKeys, r.Repositories, r.Collaborators, r.AuditEvents} {
+		if c.Legacy != c.Migrated+c.Existing+c.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTo writes the report as text
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	if r.DryRun {
+		buf.WriteString("Legacy migration report (dry run, nothing was written)\n\n")
+	} else {
+		buf.WriteString("Legacy migration report\n\n")
+	}
+
+	tw := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "legacy table\tlegacy\tmigrated\texisting\tskipped\t")
+	for _, row := range []struct {
+		table string
+		count Count
+	}{
+		{"users", r.Users},
+		{"ssh_keys", r.SSHKeys},
+		{"repos", r.Repositories},
+		{"repo_members", r.Collaborators},
+		{"audit_logs", r.AuditEvents},
+	} {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t\n", row.table, row.count.Legacy, row.count.Migrated, row.count.Existing, row.count.Skipped)
+	}
+	_ = tw.Flush()
+	if !r.Reconciled() {
+		buf.WriteString("\nWARNING: the counts do not reconcile\n")
+	}
+
+	if len(r.Issues) > 0 {
+		buf.WriteString("\nIssues:\n")
+		for _, issue := range r.Issues {
+			outcome := "migrated"
+			if issue.Skipped {
+				outcome = "skipped"
+			}
+			fmt.Fprintf(&buf, "  %s %d (%s): %s [%s]\n", issue.Table, issue.LegacyID, issue.Subject, issue.Reason, outcome)
+		}
+	}
+
+	if len(r.TokenRotations) > 0 {
+		buf.WriteString("\nLegacy access tokens are not migrated; these users must create new ones:\n")
+		for _, rotation := range r.TokenRotations {
+			fmt.Fprintf(&buf, "  %s: %d tokens\n", rotation.Username, rotation.Tokens)
+		}
+	}
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
@@ -0,0 +1,223 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/config"
+	domainservice "github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// maxRetryBackoff bounds the wait between retries of an email
+const maxRetryBackoff = 30 * time.Minute
+
+// Queue sends emails one at a time from an in-memory queue, retrying failed
+// sends with exponential backoff. Emails that cannot be queued or sent are
+// appended to the dead letter file. A nil Queue discards emails.
+type Queue struct {
+	cfg       *config.EmailConfig
+	from      *mail.Address
+	transport transport
+	queue     chan domainservice.Email
+	stop      chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+	deadMu    sync.Mutex
+	log       *logger.Logger
+}
+
+// deadLetter is an email given up on, as written to the dead letter file
+type deadLetter struct {
+	Time     time.Time `json:"time"`
+	To       string    `json:"to"`
+	Subject  string    `json:"subject"`
+	Category string    `json:"category"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+	Text     string    `json:"text"`
+}
+
+// NewQueue creates the email queue configured by cfg.
+// It returns nil when email is disabled.
+func NewQueue(cfg *config.EmailConfig) (*Queue, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email sender: %w", err)
+	}
+	t, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.DeadLetterPath), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create email dead letter directory: %w", err)
+	}
+
+	return &Queue{
+		cfg:       cfg,
+		from:      from,
+		transport: t,
+		queue:     make(chan domainservice.Email, cfg.QueueSize),
+		stop:      make(chan struct{}),
+		log:       logger.Get().WithFields(logger.Component("email")),
+	}, nil
+}
+
+// Start starts sending queued emails
+func (q *Queue) Start() {
+	if q == nil {
+		return
+	}
+	q.wg.Add(1)
+	go q.run()
+	q.log.Info("Email delivery started", logger.String("transport", q.cfg.Transport))
+}
+
+// Stop sends the emails still queued once, dead-lettering those that fail,
+// and stops the queue
+func (q *Queue) Stop() {
+	if q == nil {
+		return
+	}
+	q.stopOnce.Do(func() {
+		close(q.stop)
+		q.wg.Wait()
+		q.log.Info("Email delivery stopped")
+	})
+}
+
+// Enqueue implements service.Mailer
+func (q *Queue) Enqueue(email domainservice.Email) bool {
+	if q == nil {
+		return false
+	}
+	select {
+	case q.queue <- email:
+		return true
+	default:
+		q.deadLetter(email, 0, fmt.Errorf("email queue full"))
+		return false
+	}
+}
+
+// run sends queued emails until the queue is stopped, then sends what is
+// left once, without retries
+func (q *Queue) run() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case email := <-q.queue:
+			q.process(email)
+		case <-q.stop:
+			for {
+				select {
+				case email := <-q.queue:
+					if err := q.send(email); err != nil {
+						q.deadLetter(email, 1, err)
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// process sends an email, retrying with backoff until it is sent or
+// max_attempts is reached
+func (q *Queue) process(email domainservice.Email) {
+	backoff := q.cfg.RetryBackoff()
+	for attempt := 1; ; attempt++ {
+		err := q.send(email)
+		if err == nil {
+			return
+		}
+		if attempt >= q.cfg.MaxAttempts {
+			q.deadLetter(email, attempt, err)
+			return
+		}
+
+		q.log.Warn("Failed to send email, retrying",
+			logger.Error(err),
+			logger.String("category", email.Category),
+			logger.Int("attempt", attempt),
+			logger.Duration("backoff", backoff),
+		)
+		select {
+		case <-time.After(backoff):
+		case <-q.stop:
+			q.deadLetter(email, attempt, err)
+			return
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// send renders and delivers an email
+func (q *Queue) send(email domainservice.Email) error {
+	to, err := mail.ParseAddress(email.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+	email.To = to.String()
+
+	msg, err := buildMessage(q.from, email, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := q.transport.Send(context.Background(), q.from.Address, to.Address, msg); err != nil {
+		return err
+	}
+
+	q.log.Debug("Email sent",
+		logger.String("category", email.Category),
+		logger.String("subject", email.Subject),
+	)
+	return nil
+}
+
+// deadLetter appends an email given up on to the dead letter file
+func (q *Queue) deadLetter(email domainservice.Email, attempts int, cause error) {
+	q.log.Error("Email dead-lettered",
+		logger.Error(cause),
+		logger.String("category", email.Category),
+		logger.Int("attempts", attempts),
+	)
+
+	q.deadMu.Lock()
+	defer q.deadMu.Unlock()
+
+	f, err := os.OpenFile(q.cfg.DeadLetterPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		q.log.Error("Failed to open email dead letter file", logger.Error(err))
+		return
+	}
+	defer f.Close()
+
+	err = json.NewEncoder(f).Encode(deadLetter{
+		Time:     time.Now().UTC(),
+		To:       email.To,
+		Subject:  email.Subject,
+		Category: email.Category,
+		Attempts: attempts,
+		Error:    cause.Error(),
+		Text:     email.Text,
+	})
+	if err != nil {
+		q.log.Error("Failed to write email dead letter", logger.Error(err))
+	}
+}
+
+// Verify interface compliance at compile time
+var _ domainservice.Mailer = (*Queue)(nil)
@@ -0,0 +1,136 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/config"
+)
+
+// smtpTimeout bounds a whole SMTP conversation
+const smtpTimeout = time.Minute
+
+// transport delivers a rendered message
+type transport interface {
+	Send(ctx context.Context, from, to string, msg []byte) error
+}
+
+// newTransport returns the transport configured by cfg
+func newTransport(cfg *config.EmailConfig) (transport, error) {
+	switch cfg.Transport {
+	case config.EmailTransportSMTP:
+		return &smtpTransport{cfg: cfg}, nil
+	case config.EmailTransportDirectory:
+		if err := os.MkdirAll(cfg.Directory, 0750); err != nil {
+			return nil, fmt.Errorf("failed to create email directory: %w", err)
+		}
+		return &directoryTransport{dir: cfg.Directory}, nil
+	default:
+		return nil, fmt.Errorf("invalid email transport %q", cfg.Transport)
+	}
+}
+
+// smtpTransport sends messages through an SMTP server
+type smtpTransport struct {
+	cfg *config.EmailConfig
+}
+
+// Send implements transport
+func (t *smtpTransport) Send(ctx context.Context, from, to string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(t.cfg.Host, strconv.Itoa(t.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: t.cfg.Host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{}
+	if t.cfg.TLSMode == config.EmailTLSImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, t.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if t.cfg.TLSMode == config.EmailTLSStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if t.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", t.cfg.Username, t.cfg.Password, t.cfg.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("SMTP server refused sender: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("SMTP server refused recipient: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP server refused data: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected message: %w", err)
+	}
+	return client.Quit()
+}
+
+// directoryTransport writes each message to a .eml file in a directory
+// instead of sending it
+type directoryTransport struct {
+	dir string
+}
+
+// Send implements transport
+func (t *directoryTransport) Send(_ context.Context, _, _ string, msg []byte) error {
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000Z"), uuid.NewString())
+	tmp, err := os.CreateTemp(t.dir, ".email-*")
+	if err != nil {
+		return fmt.Errorf("failed to create email file: %w", err)
+	}
+	if _, err := tmp.Write(msg); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write email file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write email file: %w", err)
+	}
+	// Rename so readers never see a partial email
+	if err := os.Rename(tmp.Name(), filepath.Join(t.dir, name)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write email file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// AuditEventRepoImpl implements the AuditEventRepository interface using GORM
+type AuditEventRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewAuditEventRepository creates a new AuditEventRepoImpl instance
+func NewAuditEventRepository(db *gorm.DB) repository.AuditEventRepository {
+	return &AuditEventRepoImpl{db: db}
+}
+
+// Create stores an audit event
+func (r *AuditEventRepoImpl) Create(ctx context.Context, event *models.AuditEvent) error {
+	if err := r.db.WithContext(ctx).Create(event).Error; err != nil {
+		return apperror.DatabaseError("create audit event", err)
+	}
+	return nil
+}
+
+// List returns a page of the events matching a filter, most recent first,
+// and the total number of matching events
+func (r *AuditEventRepoImpl) List(ctx context.Context, filter repository.AuditEventFilter, limit, offset int) ([]*models.AuditEvent, int64, error) {
+	scope := func(db *gorm.DB) *gorm.DB {
+		if filter.RepositoryID != nil {
+			db = db.Where("repository_id = ?", *filter.RepositoryID)
+		}
+		if filter.ActorID != nil {
+			db = db.Where("actor_id = ?", *filter.ActorID)
+		}
+		if filter.Action != "" {
+			if strings.Contains(filter.Action, ".") {
+				db = db.Where("action = ?", filter.Action)
+			} else {
+				db = db.Where("action LIKE ?", filter.Action+".%")
+			}
+		}
+		return db
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.AuditEvent{}).Scopes(scope).Count(&total).Error; err != nil {
+		return nil, 0, apperror.DatabaseError("count audit events", err)
+	}
+
+	var auditEvents []*models.AuditEvent
+	err := r.db.WithContext(ctx).
+		Scopes(scope).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&auditEvents).Error
+	if err != nil {
+		return nil, 0, apperror.DatabaseError("list audit events", err)
+	}
+	return auditEvents, total, nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.AuditEventRepository = (*AuditEventRepoImpl)(nil)
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// AuthorMappingRepoImpl implements the AuthorMappingRepository interface using GORM
+type AuthorMappingRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewAuthorMappingRepository creates a new AuthorMappingRepoImpl instance
+func NewAuthorMappingRepository(db *gorm.DB) repository.AuthorMappingRepository {
+	return &AuthorMappingRepoImpl{db: db}
+}
+
+// ListByRepository returns the author mappings of a repository with their users
+func (r *AuthorMappingRepoImpl) ListByRepository(ctx context.Context, repoID uuid.UUID) ([]*models.AuthorMapping, error) {
+	var mappings []*models.AuthorMapping
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("repository_id = ?", repoID).
+		Order("email ASC").
+		Find(&mappings).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list author mappings", err)
+	}
+	return mappings, nil
+}
+
+// Upsert creates the mapping of an email or points it at a different user
+func (r *AuthorMappingRepoImpl) Upsert(ctx context.Context, mapping *models.AuthorMapping) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "repository_id"}, {Name: "email"}},
+			DoUpdates: clause.AssignmentColumns([]string{"user_id", "updated_at"}),
+		}).Create(mapping).Error
+		if err != nil {
+			return err
+		}
+		return bumpAuthorMappingsVersion(tx, mapping.RepositoryID)
+	})
+	if err != nil {
+		return apperror.DatabaseError("save author mapping", err)
+	}
+	return nil
+}
+
+// Delete removes a mapping of a repository
+func (r *AuthorMappingRepoImpl) Delete(ctx context.Context, repoID, id uuid.UUID) error {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND repository_id = ?", id, repoID).Delete(&models.AuthorMapping{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		if deleted == 0 {
+			return nil
+		}
+		return bumpAuthorMappingsVersion(tx, repoID)
+	})
+	if err != nil {
+		return apperror.DatabaseError("delete author mapping", err)
+	}
+	if deleted == 0 {
+		return apperror.NotFound("author mapping", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// bumpAuthorMappingsVersion invalidates cached author resolution of a repository
+func bumpAuthorMappingsVersion(tx *gorm.DB, repoID uuid.UUID) error {
+	return tx.Model(&models.Repository{}).
+		Where("id = ?", repoID).
+		UpdateColumn("author_mappings_version", gorm.Expr("author_mappings_version + 1")).Error
+}
+
+// Verify interface compliance at compile time
+var _ repository.AuthorMappingRepository = (*AuthorMappingRepoImpl)(nil)
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// BranchProtectionRepoImpl implements the BranchProtectionRepository interface using GORM
+type BranchProtectionRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewBranchProtectionRepository creates a new BranchProtectionRepoImpl instance
+func NewBranchProtectionRepository(db *gorm.DB) repository.BranchProtectionRepository {
+	return &BranchProtectionRepoImpl{db: db}
+}
+
+// ListByRepository returns the branch protections of a repository, oldest first
+func (r *BranchProtectionRepoImpl) ListByRepository(ctx context.Context, repoID uuid.UUID) ([]*models.BranchProtection, error) {
+	var protections []*models.BranchProtection
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ?", repoID).
+		Order("created_at ASC").
+		Find(&protections).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list branch protections", err)
+	}
+	return protections, nil
+}
+
+// FindByID returns a branch protection of a repository
+func (r *BranchProtectionRepoImpl) FindByID(ctx context.Context, repoID, id uuid.UUID) (*models.BranchProtection, error) {
+	var protection models.BranchProtection
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ? AND id = ?", repoID, id).
+		First(&protection).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("branch protection", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find branch protection", err)
+	}
+	return &protection, nil
+}
+
+// Create stores a new branch protection
+func (r *BranchProtectionRepoImpl) Create(ctx context.Context, protection *models.BranchProtection) error {
+	if err := r.db.WithContext(ctx).Create(protection).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return apperror.Conflict("branch protection already exists", apperror.ErrBranchProtectionExists)
+		}
+		return apperror.DatabaseError("create branch protection", err)
+	}
+	return nil
+}
+
+// Update saves the changes to a branch protection
+func (r *BranchProtectionRepoImpl) Update(ctx context.Context, protection *models.BranchProtection) error {
+	result := r.db.WithContext(ctx).
+		Model(protection).
+		Select("pattern", "block_force_push", "block_deletion", "require_admin", "updated_at").
+		Updates(protection)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return apperror.Conflict("branch protection already exists", apperror.ErrBranchProtectionExists)
+		}
+		return apperror.DatabaseError("update branch protection", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("branch protection", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// Delete removes a branch protection of a repository
+func (r *BranchProtectionRepoImpl) Delete(ctx context.Context, repoID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Where("repository_id = ? AND id = ?", repoID, id).
+		Delete(&models.BranchProtection{})
+	if result.Error != nil {
+		return apperror.DatabaseError("delete branch protection", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("branch protection", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.BranchProtectionRepository = (*BranchProtectionRepoImpl)(nil)
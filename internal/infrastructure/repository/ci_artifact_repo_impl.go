@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// CIArtifactRepoImpl implements the CIArtifactRepository interface using GORM
+type CIArtifactRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewCIArtifactRepository creates a new CIArtifactRepoImpl instance
+func NewCIArtifactRepository(db *gorm.DB) repository.CIArtifactRepository {
+	return &CIArtifactRepoImpl{db: db}
+}
+
+// Save stores an artifact, replacing the artifact of the same job with the
+// same name
+func (r *CIArtifactRepoImpl) Save(ctx context.Context, artifact *models.CIArtifact) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "job_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"size", "checksum", "content_type", "storage_path", "created_at"}),
+	}).Create(artifact).Error
+	if err != nil {
+		return apperror.DatabaseError("save ci artifact", err)
+	}
+	return nil
+}
+
+// FindByJobAndName retrieves an artifact of a job by its name
+func (r *CIArtifactRepoImpl) FindByJobAndName(ctx context.Context, jobID uuid.UUID, name string) (*models.CIArtifact, error) {
+	var artifact models.CIArtifact
+	if err := r.db.WithContext(ctx).Where("job_id = ? AND name = ?", jobID, name).First(&artifact).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("ci artifact", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find ci artifact", err)
+	}
+	return &artifact, nil
+}
+
+// ListCreatedBefore returns up to limit artifacts stored before a time, oldest first
+func (r *CIArtifactRepoImpl) ListCreatedBefore(ctx context.Context, before time.Time, limit int) ([]*models.CIArtifact, error) {
+	var artifacts []*models.CIArtifact
+	err := r.db.WithContext(ctx).
+		Where("created_at < ?", before).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&artifacts).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list old ci artifacts", err)
+	}
+	return artifacts, nil
+}
+
+// ListOrphaned returns up to limit artifacts of jobs without a token record,
+// oldest first
+func (r *CIArtifactRepoImpl) ListOrphaned(ctx context.Context, limit int) ([]*models.CIArtifact, error) {
+	jobs := r.db.Model(&models.CIJobToken{}).Select("1").Where("ci_job_tokens.job_id = ci_artifacts.job_id")
+	var artifacts []*models.CIArtifact
+	err := r.db.WithContext(ctx).
+		Where("NOT EXISTS (?)", jobs).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&artifacts).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list orphaned ci artifacts", err)
+	}
+	return artifacts, nil
+}
+
+// Delete deletes an artifact
+func (r *CIArtifactRepoImpl) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Delete(&models.CIArtifact{}, "id = ?", id).Error; err != nil {
+		return apperror.DatabaseError("delete ci artifact", err)
+	}
+	return nil
+}
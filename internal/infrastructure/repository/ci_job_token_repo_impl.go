@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// CIJobTokenRepoImpl implements the CIJobTokenRepository interface using GORM
+type CIJobTokenRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewCIJobTokenRepository creates a new CIJobTokenRepoImpl instance
+func NewCIJobTokenRepository(db *gorm.DB) repository.CIJobTokenRepository {
+	return &CIJobTokenRepoImpl{db: db}
+}
+
+// Create stores a new CI job token
+func (r *CIJobTokenRepoImpl) Create(ctx context.Context, token *models.CIJobToken) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return apperror.DatabaseError("create ci job token", err)
+	}
+	return nil
+}
+
+// FindByHashedToken retrieves a CI job token by its hashed value
+func (r *CIJobTokenRepoImpl) FindByHashedToken(ctx context.Context, hashedToken string) (*models.CIJobToken, error) {
+	var token models.CIJobToken
+	if err := r.db.WithContext(ctx).Where("token = ?", hashedToken).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("ci job token", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find ci job token by hash", err)
+	}
+	return &token, nil
+}
+
+// FindByHashedCallbackToken retrieves a CI job token by the hashed value of
+// its callback token
+func (r *CIJobTokenRepoImpl) FindByHashedCallbackToken(ctx context.Context, hashedToken string) (*models.CIJobToken, error) {
+	var token models.CIJobToken
+	if err := r.db.WithContext(ctx).Where("callback_token = ?", hashedToken).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("ci job token", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find ci job token by callback token", err)
+	}
+	return &token, nil
+}
+
+// FindByJobID retrieves the token of a CI job
+func (r *CIJobTokenRepoImpl) FindByJobID(ctx context.Context, jobID uuid.UUID) (*models.CIJobToken, error) {
+	var token models.CIJobToken
+	if err := r.db.WithContext(ctx).Where("job_id = ?", jobID).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("ci job token", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find ci job token by job id", err)
+	}
+	return &token, nil
+}
+
+// AdvanceStatus records status as the status of a job unless the job already
+// has it or has finished. Concurrent reports of the same status update the
+// row once, so only one of them gets the token back.
+func (r *CIJobTokenRepoImpl) AdvanceStatus(ctx context.Context, jobID uuid.UUID, status string) (*models.CIJobToken, error) {
+	var token models.CIJobToken
+	result := r.db.WithContext(ctx).
+		Model(&token).
+		Clauses(clause.Returning{}).
+		Where("job_id = ? AND status <> ? AND status NOT IN ?", jobID, status, models.CIJobFinishedStatuses).
+		Update("status", status)
+	if result.Error != nil {
+		return nil, apperror.DatabaseError("advance ci job status", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return &token, nil
+	}
+
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.CIJobToken{}).Where("job_id = ?", jobID).Count(&count).Error; err != nil {
+		return nil, apperror.DatabaseError("find ci job token by job id", err)
+	}
+	if count == 0 {
+		return nil, apperror.NotFound("ci job token", apperror.ErrNotFound)
+	}
+	return nil, nil
+}
+
+// Revoke revokes the token of a CI job unless it is revoked already
+func (r *CIJobTokenRepoImpl) Revoke(ctx context.Context, jobID uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.CIJobToken{}).
+		Where("job_id = ? AND revoked_at IS NULL", jobID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return false, apperror.DatabaseError("revoke ci job token", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// AddTransfer adds bytes sent with a token to its transfer count and records the use
+func (r *CIJobTokenRepoImpl) AddTransfer(ctx context.Context, id uuid.UUID, bytes int64, at time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.CIJobToken{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"transfer_bytes": gorm.Expr("transfer_bytes + ?", bytes),
+			"last_used_at":   at,
+		}).Error
+	if err != nil {
+		return apperror.DatabaseError("add ci job token transfer", err)
+	}
+	return nil
+}
+
+// DeleteBefore deletes the tokens created before a time and returns how many were deleted
+func (r *CIJobTokenRepoImpl) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&models.CIJobToken{})
+	if result.Error != nil {
+		return 0, apperror.DatabaseError("delete ci job tokens", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.CIJobTokenRepository = (*CIJobTokenRepoImpl)(nil)
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// CIPipelineRepoImpl implements the CIPipelineRepository interface using GORM
+type CIPipelineRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewCIPipelineRepository creates a new CIPipelineRepoImpl instance
+func NewCIPipelineRepository(db *gorm.DB) repository.CIPipelineRepository {
+	return &CIPipelineRepoImpl{db: db}
+}
+
+// CreateBatch stores the jobs of a new staged run
+func (r *CIPipelineRepoImpl) CreateBatch(ctx context.Context, jobs []*models.CIPipelineJob) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Create(jobs).Error; err != nil {
+		return apperror.DatabaseError("create pipeline jobs", err)
+	}
+	return nil
+}
+
+// FindByID finds a pipeline job by its ID
+func (r *CIPipelineRepoImpl) FindByID(ctx context.Context, id uuid.UUID) (*models.CIPipelineJob, error) {
+	var job models.CIPipelineJob
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("pipeline job", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find pipeline job", err)
+	}
+	return &job, nil
+}
+
+// FindByRunID returns all jobs of a run ordered by stage
+func (r *CIPipelineRepoImpl) FindByRunID(ctx context.Context, runID uuid.UUID) ([]*models.CIPipelineJob, error) {
+	var jobs []*models.CIPipelineJob
+	err := r.db.WithContext(ctx).
+		Where("run_id = ?", runID).
+		Order("stage_index ASC, name ASC").
+		Find(&jobs).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("find pipeline jobs", err)
+	}
+	return jobs, nil
+}
+
+// UpdateStatus sets the status and error of a job
+func (r *CIPipelineRepoImpl) UpdateStatus(ctx context.Context, id uuid.UUID, status string, jobErr *string) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.CIPipelineJob{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status": status,
+			"error":  jobErr,
+		})
+	if result.Error != nil {
+		return apperror.DatabaseError("update pipeline job status", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("pipeline job", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// TransitionStatus changes the status of a job only if it currently has the from status
+func (r *CIPipelineRepoImpl) TransitionStatus(ctx context.Context, id uuid.UUID, from, to string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.CIPipelineJob{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	if result.Error != nil {
+		return false, apperror.DatabaseError("update pipeline job status", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteRunsBefore deletes the jobs of the runs none of whose jobs was updated
+// since a time
+func (r *CIPipelineRepoImpl) DeleteRunsBefore(ctx context.Context, before time.Time) (int64, error) {
+	stale := r.db.Model(&models.CIPipelineJob{}).
+		Select("run_id").
+		Group("run_id").
+		Having("MAX(updated_at) < ?", before)
+	result := r.db.WithContext(ctx).Where("run_id IN (?)", stale).Delete(&models.CIPipelineJob{})
+	if result.Error != nil {
+		return 0, apperror.DatabaseError("delete old pipeline runs", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.CIPipelineRepository = (*CIPipelineRepoImpl)(nil)
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// CollaboratorRepoImpl implements the CollaboratorRepository interface using GORM
+type CollaboratorRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewCollaboratorRepository creates a new CollaboratorRepoImpl instance
+func NewCollaboratorRepository(db *gorm.DB) repository.CollaboratorRepository {
+	return &CollaboratorRepoImpl{db: db}
+}
+
+// ListByRepository returns the collaborators of a repository with their users
+func (r *CollaboratorRepoImpl) ListByRepository(ctx context.Context, repoID uuid.UUID) ([]*models.RepositoryCollaborator, error) {
+	var collaborators []*models.RepositoryCollaborator
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("repository_id = ?", repoID).
+		Order("created_at ASC").
+		Find(&collaborators).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list collaborators", err)
+	}
+	return collaborators, nil
+}
+
+// FindByRepositoryAndUser returns the collaborator entry of a user on a repository
+func (r *CollaboratorRepoImpl) FindByRepositoryAndUser(ctx context.Context, repoID, userID uuid.UUID) (*models.RepositoryCollaborator, error) {
+	var collaborator models.RepositoryCollaborator
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ? AND user_id = ?", repoID, userID).
+		First(&collaborator).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("collaborator", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find collaborator", err)
+	}
+	return &collaborator, nil
+}
+
+// Upsert adds a collaborator or changes the permission of an existing one
+func (r *CollaboratorRepoImpl) Upsert(ctx context.Context, collaborator *models.RepositoryCollaborator) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "repository_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"permission", "updated_at"}),
+	}).Create(collaborator).Error
+	if err != nil {
+		return apperror.DatabaseError("save collaborator", err)
+	}
+	return nil
+}
+
+// Delete removes a user from the collaborators of a repository
+func (r *CollaboratorRepoImpl) Delete(ctx context.Context, repoID, userID uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Where("repository_id = ? AND user_id = ?", repoID, userID).
+		Delete(&models.RepositoryCollaborator{})
+	if result.Error != nil {
+		return apperror.DatabaseError("delete collaborator", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("collaborator", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.CollaboratorRepository = (*CollaboratorRepoImpl)(nil)
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// CommitStatusRepoImpl implements the CommitStatusRepository interface using GORM
+type CommitStatusRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewCommitStatusRepository creates a new CommitStatusRepoImpl instance
+func NewCommitStatusRepository(db *gorm.DB) repository.CommitStatusRepository {
+	return &CommitStatusRepoImpl{db: db}
+}
+
+// Create stores a new status
+func (r *CommitStatusRepoImpl) Create(ctx context.Context, status *models.CommitStatus) error {
+	if err := r.db.WithContext(ctx).Create(status).Error; err != nil {
+		return apperror.DatabaseError("create commit status", err)
+	}
+	return nil
+}
+
+// ListBySHA lists every status of a commit, most recent first
+func (r *CommitStatusRepoImpl) ListBySHA(ctx context.Context, repoID uuid.UUID, sha string, limit, offset int) ([]*models.CommitStatus, error) {
+	var statuses []*models.CommitStatus
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ? AND sha = ?", repoID, sha).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&statuses).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list commit statuses", err)
+	}
+	return statuses, nil
+}
+
+// LatestBySHA returns the most recent status of each context of a commit,
+// by context
+func (r *CommitStatusRepoImpl) LatestBySHA(ctx context.Context, repoID uuid.UUID, sha string) ([]*models.CommitStatus, error) {
+	var statuses []*models.CommitStatus
+	err := r.db.WithContext(ctx).
+		Select("DISTINCT ON (context) *").
+		Where("repository_id = ? AND sha = ?", repoID, sha).
+		Order("context, created_at DESC, id DESC").
+		Find(&statuses).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list latest commit statuses", err)
+	}
+	return statuses, nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.CommitStatusRepository = (*CommitStatusRepoImpl)(nil)
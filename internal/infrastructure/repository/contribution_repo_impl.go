@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// ContributionRepoImpl implements the ContributionRepository interface using GORM
+type ContributionRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewContributionRepository creates a new ContributionRepoImpl instance
+func NewContributionRepository(db *gorm.DB) repository.ContributionRepository {
+	return &ContributionRepoImpl{db: db}
+}
+
+// CreateBatch stores contribution events, skipping events already recorded
+func (r *ContributionRepoImpl) CreateBatch(ctx context.Context, events []*models.ContributionEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(events, 500).Error
+	if err != nil {
+		return apperror.DatabaseError("create contribution events", err)
+	}
+	return nil
+}
+
+// FindExistingRefs returns which of the given refs are already recorded for a repository and kind
+func (r *ContributionRepoImpl) FindExistingRefs(ctx context.Context, repoID uuid.UUID, kind string, refs []string) (map[string]bool, error) {
+	existing := make(map[string]bool, len(refs))
+	if len(refs) == 0 {
+		return existing, nil
+	}
+
+	var found []string
+	err := r.db.WithContext(ctx).
+		Model(&models.ContributionEvent{}).
+		Where("repository_id = ? AND kind = ? AND ref IN ?", repoID, kind, refs).
+		Pluck("ref", &found).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("find contribution events", err)
+	}
+
+	for _, ref := range found {
+		existing[ref] = true
+	}
+	return existing, nil
+}
+
+// DailyCounts returns per-day contribution counts of a user within [from, to)
+func (r *ContributionRepoImpl) DailyCounts(ctx context.Context, userID uuid.UUID, from, to time.Time, includePrivate bool) ([]models.ContributionDay, error) {
+	db := r.db.WithContext(ctx).
+		Table("contribution_events AS e").
+		Select("to_char(e.occurred_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS date, COUNT(*) AS count").
+		Joins("JOIN repositories r ON r.id = e.repository_id").
+		Where("e.user_id = ? AND e.occurred_at >= ? AND e.occurred_at < ?", userID, from, to)
+
+	if !includePrivate {
+		db = db.Where("r.is_private = ?", false)
+	}
+
+	var days []models.ContributionDay
+	err := db.Group("date").Order("date ASC").Scan(&days).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("count contributions", err)
+	}
+	return days, nil
+}
+
+// ListByUser returns a page of the contribution events of a user, oldest first
+func (r *ContributionRepoImpl) ListByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.ContributionEvent, error) {
+	var events []*models.ContributionEvent
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("occurred_at ASC, id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&events).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list contribution events", err)
+	}
+	return events, nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.ContributionRepository = (*ContributionRepoImpl)(nil)
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// GPGKeyRepoImpl implements the GPGKeyRepository interface using GORM
+type GPGKeyRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewGPGKeyRepository creates a new GPGKeyRepoImpl instance
+func NewGPGKeyRepository(db *gorm.DB) repository.GPGKeyRepository {
+	return &GPGKeyRepoImpl{db: db}
+}
+
+// Create creates a new GPG key in the database
+func (r *GPGKeyRepoImpl) Create(ctx context.Context, key *models.GPGKey) error {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return apperror.Conflict("gpg key already exists", apperror.ErrGPGKeyExists)
+		}
+		return apperror.DatabaseError("create gpg key", err)
+	}
+	return nil
+}
+
+// FindByID retrieves a GPG key by its ID
+func (r *GPGKeyRepoImpl) FindByID(ctx context.Context, id uuid.UUID) (*models.GPGKey, error) {
+	var key models.GPGKey
+	if err := r.db.WithContext(ctx).First(&key, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("gpg key", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find gpg key by id", err)
+	}
+	return &key, nil
+}
+
+// FindByUserID retrieves all GPG keys for a user
+func (r *GPGKeyRepoImpl) FindByUserID(ctx context.Context, userID uuid.UUID) ([]*models.GPGKey, error) {
+	var keys []*models.GPGKey
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, apperror.DatabaseError("find gpg keys by user id", err)
+	}
+	return keys, nil
+}
+
+// Delete removes a GPG key from the database by its ID
+func (r *GPGKeyRepoImpl) Delete(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Delete(&models.GPGKey{}, id)
+	if result.Error != nil {
+		return apperror.DatabaseError("delete gpg key", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("gpg key", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+var _ repository.GPGKeyRepository = (*GPGKeyRepoImpl)(nil)
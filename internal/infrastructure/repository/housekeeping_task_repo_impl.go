@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// housekeepingLockClass is the first key of the advisory locks on
+// housekeeping tasks, the second being the hash of the task name, so they
+// cannot collide with advisory locks taken for anything else
+const housekeepingLockClass = 0x686b // "hk"
+
+// HousekeepingTaskRepoImpl implements the HousekeepingTaskRepository interface using GORM
+type HousekeepingTaskRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewHousekeepingTaskRepository creates a new HousekeepingTaskRepoImpl instance
+func NewHousekeepingTaskRepository(db *gorm.DB) repository.HousekeepingTaskRepository {
+	return &HousekeepingTaskRepoImpl{db: db}
+}
+
+// Register stores a task due at a time, unless it is already stored
+func (r *HousekeepingTaskRepoImpl) Register(ctx context.Context, name string, nextRunAt time.Time) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.HousekeepingTask{Name: name, NextRunAt: nextRunAt}).Error
+	if err != nil {
+		return apperror.DatabaseError("register housekeeping task", err)
+	}
+	return nil
+}
+
+// FindByName returns a task
+func (r *HousekeepingTaskRepoImpl) FindByName(ctx context.Context, name string) (*models.HousekeepingTask, error) {
+	var task models.HousekeepingTask
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("housekeeping task", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find housekeeping task", err)
+	}
+	return &task, nil
+}
+
+// List returns all the stored tasks, by name
+func (r *HousekeepingTaskRepoImpl) List(ctx context.Context) ([]*models.HousekeepingTask, error) {
+	var tasks []*models.HousekeepingTask
+	if err := r.db.WithContext(ctx).Order("name ASC").Find(&tasks).Error; err != nil {
+		return nil, apperror.DatabaseError("list housekeeping tasks", err)
+	}
+	return tasks, nil
+}
+
+// Update saves the schedule and last run of a task
+func (r *HousekeepingTaskRepoImpl) Update(ctx context.Context, task *models.HousekeepingTask) error {
+	result := r.db.WithContext(ctx).
+		Model(task).
+		Select("next_run_at", "last_started_at", "last_duration_ms", "last_outcome", "last_error",
+			"last_run_by", "run_count", "failure_count").
+		Updates(task)
+	if result.Error != nil {
+		return apperror.DatabaseError("update housekeeping task", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("housekeeping task", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// Reschedule sets when a task is next due
+func (r *HousekeepingTaskRepoImpl) Reschedule(ctx context.Context, name string, nextRunAt time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.HousekeepingTask{}).
+		Where("name = ?", name).
+		Update("next_run_at", nextRunAt)
+	if result.Error != nil {
+		return apperror.DatabaseError("reschedule housekeeping task", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("housekeeping task", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// WithLock calls fn while holding a transaction-level advisory lock on the
+// task, which Postgres releases when the transaction ends, even if the
+// replica holding it dies. fn does not run in the transaction.
+func (r *HousekeepingTaskRepoImpl) WithLock(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error) {
+	var acquired bool
+	var fnErr error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?, hashtext(?))", housekeepingLockClass, name).
+			Scan(&acquired).Error; err != nil {
+			return apperror.DatabaseError("lock housekeeping task", err)
+		}
+		if acquired {
+			fnErr = fn(ctx)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return acquired, fnErr
+}
+
+// Verify interface compliance at compile time
+var _ repository.HousekeepingTaskRepository = (*HousekeepingTaskRepoImpl)(nil)
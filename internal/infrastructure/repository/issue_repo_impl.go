@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// IssueRepoImpl implements the IssueRepository interface using GORM
+type IssueRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewIssueRepository creates a new IssueRepoImpl instance
+func NewIssueRepository(db *gorm.DB) repository.IssueRepository {
+	return &IssueRepoImpl{db: db}
+}
+
+// Create stores a new issue, giving it the next number of its repository
+func (r *IssueRepoImpl) Create(ctx context.Context, issue *models.Issue) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the repository so concurrent creations get distinct numbers
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", issue.RepositoryID).
+			Take(&models.Repository{}).Error
+		if err != nil {
+			return err
+		}
+
+		var last int
+		err = tx.Model(&models.Issue{}).
+			Where("repository_id = ?", issue.RepositoryID).
+			Select("COALESCE(MAX(number), 0)").
+			Scan(&last).Error
+		if err != nil {
+			return err
+		}
+
+		issue.Number = last + 1
+		return tx.Create(issue).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.NotFound("repository", apperror.ErrNotFound)
+		}
+		return apperror.DatabaseError("create issue", err)
+	}
+	return nil
+}
+
+// FindByNumber returns an issue of a repository with its author
+func (r *IssueRepoImpl) FindByNumber(ctx context.Context, repoID uuid.UUID, number int) (*models.Issue, error) {
+	var issue models.Issue
+	err := r.db.WithContext(ctx).
+		Preload("Author").
+		Where("repository_id = ? AND number = ?", repoID, number).
+		First(&issue).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("issue", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find issue", err)
+	}
+	return &issue, nil
+}
+
+// ListByRepository returns a page of the issues of a repository matching filter, newest first
+func (r *IssueRepoImpl) ListByRepository(ctx context.Context, repoID uuid.UUID, filter repository.IssueFilter, limit, offset int) ([]*models.Issue, int64, error) {
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("repository_id = ?", repoID)
+		if filter.State != "" {
+			db = db.Where("state = ?", filter.State)
+		}
+		if len(filter.Labels) > 0 {
+			db = db.Where("labels @> ?", pq.StringArray(filter.Labels))
+		}
+		return db
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.Issue{}).Scopes(scope).Count(&total).Error; err != nil {
+		return nil, 0, apperror.DatabaseError("count issues", err)
+	}
+
+	var issues []*models.Issue
+	err := r.db.WithContext(ctx).
+		Scopes(scope).
+		Preload("Author").
+		Order("number DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&issues).Error
+	if err != nil {
+		return nil, 0, apperror.DatabaseError("list issues", err)
+	}
+	return issues, total, nil
+}
+
+// Update saves the title, body, labels and state of an issue
+func (r *IssueRepoImpl) Update(ctx context.Context, issue *models.Issue) error {
+	result := r.db.WithContext(ctx).
+		Model(issue).
+		Select("title", "body", "labels", "state", "closed_at", "updated_at").
+		Updates(issue)
+	if result.Error != nil {
+		return apperror.DatabaseError("update issue", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("issue", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// CreateComment stores a new comment on an issue
+func (r *IssueRepoImpl) CreateComment(ctx context.Context, comment *models.IssueComment) error {
+	if err := r.db.WithContext(ctx).Create(comment).Error; err != nil {
+		return apperror.DatabaseError("create issue comment", err)
+	}
+	return nil
+}
+
+// ListComments returns a page of the comments on an issue, oldest first
+func (r *IssueRepoImpl) ListComments(ctx context.Context, issueID uuid.UUID, limit, offset int) ([]*models.IssueComment, int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).
+		Model(&models.IssueComment{}).
+		Where("issue_id = ?", issueID).
+		Count(&total).Error
+	if err != nil {
+		return nil, 0, apperror.DatabaseError("count issue comments", err)
+	}
+
+	var comments []*models.IssueComment
+	err = r.db.WithContext(ctx).
+		Preload("Author").
+		Where("issue_id = ?", issueID).
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&comments).Error
+	if err != nil {
+		return nil, 0, apperror.DatabaseError("list issue comments", err)
+	}
+	return comments, total, nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.IssueRepository = (*IssueRepoImpl)(nil)
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// LargeFileRepoImpl implements the LargeFileRepository interface using GORM
+type LargeFileRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewLargeFileRepository creates a new LargeFileRepoImpl instance
+func NewLargeFileRepository(db *gorm.DB) repository.LargeFileRepository {
+	return &LargeFileRepoImpl{db: db}
+}
+
+// CreateBatch stores the large files added by a push
+func (r *LargeFileRepoImpl) CreateBatch(ctx context.Context, additions []*models.LargeFileAddition) error {
+	if len(additions) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Create(additions).Error; err != nil {
+		return apperror.DatabaseError("create large file additions", err)
+	}
+	return nil
+}
+
+// CountSince counts the large files added to a repository since a time
+func (r *LargeFileRepoImpl) CountSince(ctx context.Context, repoID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.LargeFileAddition{}).
+		Where("repository_id = ? AND created_at >= ?", repoID, since).
+		Count(&count).Error
+	if err != nil {
+		return 0, apperror.DatabaseError("count large file additions", err)
+	}
+	return count, nil
+}
+
+// DeleteBefore deletes large file additions recorded before a time and returns how many were deleted
+func (r *LargeFileRepoImpl) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&models.LargeFileAddition{})
+	if result.Error != nil {
+		return 0, apperror.DatabaseError("delete large file additions", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.LargeFileRepository = (*LargeFileRepoImpl)(nil)
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// NotificationPreferenceRepoImpl implements the NotificationPreferenceRepository interface using GORM
+type NotificationPreferenceRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewNotificationPreferenceRepository creates a new NotificationPreferenceRepoImpl instance
+func NewNotificationPreferenceRepository(db *gorm.DB) repository.NotificationPreferenceRepository {
+	return &NotificationPreferenceRepoImpl{db: db}
+}
+
+// FindByUser returns the preferences of a user
+func (r *NotificationPreferenceRepoImpl) FindByUser(ctx context.Context, userID uuid.UUID) (*models.NotificationPreference, error) {
+	var preference models.NotificationPreference
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&preference).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("notification preferences", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find notification preferences", err)
+	}
+	return &preference, nil
+}
+
+// Upsert stores the preferences of a user
+func (r *NotificationPreferenceRepoImpl) Upsert(ctx context.Context, preference *models.NotificationPreference) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"email_ci_failures", "email_collaborators", "email_transfers", "updated_at"}),
+	}).Create(preference).Error
+	if err != nil {
+		return apperror.DatabaseError("save notification preferences", err)
+	}
+	return nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.NotificationPreferenceRepository = (*NotificationPreferenceRepoImpl)(nil)
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// NotificationRepoImpl implements the NotificationRepository interface using GORM
+type NotificationRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new NotificationRepoImpl instance
+func NewNotificationRepository(db *gorm.DB) repository.NotificationRepository {
+	return &NotificationRepoImpl{db: db}
+}
+
+// CreateBatch stores new notifications
+func (r *NotificationRepoImpl) CreateBatch(ctx context.Context, notifications []*models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Create(notifications).Error; err != nil {
+		return apperror.DatabaseError("create notifications", err)
+	}
+	return nil
+}
+
+// ListByUser returns a page of the notifications of a user, newest first
+func (r *NotificationRepoImpl) ListByUser(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit, offset int) ([]*models.Notification, int64, error) {
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("user_id = ?", userID)
+		if unreadOnly {
+			db = db.Where("read = ?", false)
+		}
+		return db
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.Notification{}).Scopes(scope).Count(&total).Error; err != nil {
+		return nil, 0, apperror.DatabaseError("count notifications", err)
+	}
+
+	var notifications []*models.Notification
+	err := r.db.WithContext(ctx).
+		Scopes(scope).
+		Preload("Repository.Owner").
+		Preload("Actor").
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&notifications).Error
+	if err != nil {
+		return nil, 0, apperror.DatabaseError("list notifications", err)
+	}
+	return notifications, total, nil
+}
+
+// CountUnread returns the number of unread notifications of a user
+func (r *NotificationRepoImpl) CountUnread(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.Notification{}).
+		Where("user_id = ? AND read = ?", userID, false).
+		Count(&count).Error
+	if err != nil {
+		return 0, apperror.DatabaseError("count unread notifications", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks a notification of a user as read
+func (r *NotificationRepoImpl) MarkRead(ctx context.Context, userID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read", true)
+	if result.Error != nil {
+		return apperror.DatabaseError("mark notification read", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("notification", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// MarkAllRead marks every notification of a user as read
+func (r *NotificationRepoImpl) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Notification{}).
+		Where("user_id = ? AND read = ?", userID, false).
+		Update("read", true)
+	if result.Error != nil {
+		return 0, apperror.DatabaseError("mark notifications read", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.NotificationRepository = (*NotificationRepoImpl)(nil)
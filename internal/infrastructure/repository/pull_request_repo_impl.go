@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// PullRequestRepoImpl implements the PullRequestRepository interface using GORM
+type PullRequestRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewPullRequestRepository creates a new PullRequestRepoImpl instance
+func NewPullRequestRepository(db *gorm.DB) repository.PullRequestRepository {
+	return &PullRequestRepoImpl{db: db}
+}
+
+// Create stores a new pull request, giving it the next number of its repository
+func (r *PullRequestRepoImpl) Create(ctx context.Context, pr *models.PullRequest) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the repository so concurrent creations get distinct numbers
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", pr.RepositoryID).
+			Take(&models.Repository{}).Error
+		if err != nil {
+			return err
+		}
+
+		var last int
+		err = tx.Model(&models.PullRequest{}).
+			Where("repository_id = ?", pr.RepositoryID).
+			Select("COALESCE(MAX(number), 0)").
+			Scan(&last).Error
+		if err != nil {
+			return err
+		}
+
+		pr.Number = last + 1
+		return tx.Create(pr).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.NotFound("repository", apperror.ErrNotFound)
+		}
+		return apperror.DatabaseError("create pull request", err)
+	}
+	return nil
+}
+
+// FindByNumber returns a pull request of a repository with its author and merger
+func (r *PullRequestRepoImpl) FindByNumber(ctx context.Context, repoID uuid.UUID, number int) (*models.PullRequest, error) {
+	var pr models.PullRequest
+	err := r.db.WithContext(ctx).
+		Preload("Author").
+		Preload("MergedBy").
+		Where("repository_id = ? AND number = ?", repoID, number).
+		First(&pr).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("pull request", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find pull request", err)
+	}
+	return &pr, nil
+}
+
+// FindOpenByBranches returns the open pull request of a repository from source into target
+func (r *PullRequestRepoImpl) FindOpenByBranches(ctx context.Context, repoID uuid.UUID, source, target string) (*models.PullRequest, error) {
+	var pr models.PullRequest
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ? AND source_branch = ? AND target_branch = ? AND state = ?",
+			repoID, source, target, models.PullRequestOpen).
+		First(&pr).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("pull request", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find pull request", err)
+	}
+	return &pr, nil
+}
+
+// ListByRepository returns a page of the pull requests of a repository, newest first
+func (r *PullRequestRepoImpl) ListByRepository(ctx context.Context, repoID uuid.UUID, state string, limit, offset int) ([]*models.PullRequest, int64, error) {
+	filter := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("repository_id = ?", repoID)
+		if state != "" {
+			db = db.Where("state = ?", state)
+		}
+		return db
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.PullRequest{}).Scopes(filter).Count(&total).Error; err != nil {
+		return nil, 0, apperror.DatabaseError("count pull requests", err)
+	}
+
+	var prs []*models.PullRequest
+	err := r.db.WithContext(ctx).
+		Scopes(filter).
+		Preload("Author").
+		Preload("MergedBy").
+		Order("number DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&prs).Error
+	if err != nil {
+		return nil, 0, apperror.DatabaseError("list pull requests", err)
+	}
+	return prs, total, nil
+}
+
+// ListByAuthor returns a page of the pull requests opened by a user, oldest first
+func (r *PullRequestRepoImpl) ListByAuthor(ctx context.Context, authorID uuid.UUID, limit, offset int) ([]*models.PullRequest, error) {
+	var prs []*models.PullRequest
+	err := r.db.WithContext(ctx).
+		Where("author_id = ?", authorID).
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&prs).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list pull requests", err)
+	}
+	return prs, nil
+}
+
+// UpdateState saves the state and merge or close details of a pull request if it is still open
+func (r *PullRequestRepoImpl) UpdateState(ctx context.Context, pr *models.PullRequest) error {
+	result := r.db.WithContext(ctx).
+		Model(pr).
+		Where("state = ?", models.PullRequestOpen).
+		Select("state", "merged_commit_sha", "merged_by_id", "merged_at", "closed_at", "updated_at").
+		Updates(pr)
+	if result.Error != nil {
+		return apperror.DatabaseError("update pull request", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.Conflict("pull request is not open", apperror.ErrPullRequestNotOpen)
+	}
+	return nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.PullRequestRepository = (*PullRequestRepoImpl)(nil)
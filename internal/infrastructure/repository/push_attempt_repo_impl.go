@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// PushAttemptRepoImpl implements the PushAttemptRepository interface using GORM
+type PushAttemptRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewPushAttemptRepository creates a new PushAttemptRepoImpl instance
+func NewPushAttemptRepository(db *gorm.DB) repository.PushAttemptRepository {
+	return &PushAttemptRepoImpl{db: db}
+}
+
+// Create stores a push attempt
+func (r *PushAttemptRepoImpl) Create(ctx context.Context, attempt *models.PushAttempt) error {
+	if err := r.db.WithContext(ctx).Create(attempt).Error; err != nil {
+		return apperror.DatabaseError("create push attempt", err)
+	}
+	return nil
+}
+
+// ListByRepository lists the push attempts of a repository, most recent
+// first, optionally only those with an outcome ("" for all)
+func (r *PushAttemptRepoImpl) ListByRepository(ctx context.Context, repoID uuid.UUID, outcome string, limit, offset int) ([]*models.PushAttempt, error) {
+	var attempts []*models.PushAttempt
+	db := r.db.WithContext(ctx).Where("repository_id = ?", repoID)
+	if outcome != "" {
+		db = db.Where("outcome = ?", outcome)
+	}
+	err := db.Order("started_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&attempts).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list push attempts", err)
+	}
+	return attempts, nil
+}
+
+// ListByPusher lists the push attempts of a user, oldest first
+func (r *PushAttemptRepoImpl) ListByPusher(ctx context.Context, pusherID uuid.UUID, limit, offset int) ([]*models.PushAttempt, error) {
+	var attempts []*models.PushAttempt
+	err := r.db.WithContext(ctx).
+		Where("pusher_id = ?", pusherID).
+		Order("started_at ASC, id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&attempts).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list push attempts", err)
+	}
+	return attempts, nil
+}
+
+// DeleteBefore deletes push attempts recorded before a time and returns how many were deleted
+func (r *PushAttemptRepoImpl) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&models.PushAttempt{})
+	if result.Error != nil {
+		return 0, apperror.DatabaseError("delete push attempts", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.PushAttemptRepository = (*PushAttemptRepoImpl)(nil)
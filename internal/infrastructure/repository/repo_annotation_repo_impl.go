@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// RepoAnnotationRepoImpl implements the RepoAnnotationRepository interface using GORM
+type RepoAnnotationRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewRepoAnnotationRepository creates a new RepoAnnotationRepoImpl instance
+func NewRepoAnnotationRepository(db *gorm.DB) repository.RepoAnnotationRepository {
+	return &RepoAnnotationRepoImpl{db: db}
+}
+
+// Create creates a new annotation in the database
+func (r *RepoAnnotationRepoImpl) Create(ctx context.Context, annotation *models.RepoAnnotation) error {
+	if err := r.db.WithContext(ctx).Create(annotation).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return apperror.Conflict("annotation already exists", apperror.ErrAnnotationExists)
+		}
+		return apperror.DatabaseError("create annotation", err)
+	}
+	return nil
+}
+
+// FindByRepoAndKey retrieves an annotation by repository ID and key
+func (r *RepoAnnotationRepoImpl) FindByRepoAndKey(ctx context.Context, repoID uuid.UUID, key string) (*models.RepoAnnotation, error) {
+	var annotation models.RepoAnnotation
+	err := r.db.WithContext(ctx).
+		Preload("Author").
+		Where("repository_id = ? AND key = ?", repoID, key).
+		First(&annotation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("annotation", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find annotation", err)
+	}
+	return &annotation, nil
+}
+
+// ListByRepository lists all annotations of a repository ordered by key
+func (r *RepoAnnotationRepoImpl) ListByRepository(ctx context.Context, repoID uuid.UUID) ([]*models.RepoAnnotation, error) {
+	var annotations []*models.RepoAnnotation
+	err := r.db.WithContext(ctx).
+		Preload("Author").
+		Where("repository_id = ?", repoID).
+		Order("key ASC").
+		Find(&annotations).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list annotations", err)
+	}
+	return annotations, nil
+}
+
+// ListByRepositoriesAndKeys lists annotations with the given keys for a set of repositories
+func (r *RepoAnnotationRepoImpl) ListByRepositoriesAndKeys(ctx context.Context, repoIDs []uuid.UUID, keys []string) ([]*models.RepoAnnotation, error) {
+	var annotations []*models.RepoAnnotation
+	if len(repoIDs) == 0 || len(keys) == 0 {
+		return annotations, nil
+	}
+
+	err := r.db.WithContext(ctx).
+		Where("repository_id IN ? AND key IN ?", repoIDs, keys).
+		Find(&annotations).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list annotations", err)
+	}
+	return annotations, nil
+}
+
+// Update updates an annotation
+func (r *RepoAnnotationRepoImpl) Update(ctx context.Context, annotation *models.RepoAnnotation) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.RepoAnnotation{}).
+		Where("id = ?", annotation.ID).
+		Updates(map[string]interface{}{
+			"value":     annotation.Value,
+			"author_id": annotation.AuthorID,
+		})
+	if result.Error != nil {
+		return apperror.DatabaseError("update annotation", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("annotation", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// Delete deletes an annotation by repository ID and key
+func (r *RepoAnnotationRepoImpl) Delete(ctx context.Context, repoID uuid.UUID, key string) error {
+	result := r.db.WithContext(ctx).
+		Where("repository_id = ? AND key = ?", repoID, key).
+		Delete(&models.RepoAnnotation{})
+	if result.Error != nil {
+		return apperror.DatabaseError("delete annotation", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("annotation", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.RepoAnnotationRepository = (*RepoAnnotationRepoImpl)(nil)
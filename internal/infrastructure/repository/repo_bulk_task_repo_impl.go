@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// RepoBulkTaskRepoImpl implements the RepoBulkTaskRepository interface using GORM
+type RepoBulkTaskRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewRepoBulkTaskRepository creates a new RepoBulkTaskRepoImpl instance
+func NewRepoBulkTaskRepository(db *gorm.DB) repository.RepoBulkTaskRepository {
+	return &RepoBulkTaskRepoImpl{db: db}
+}
+
+// Create stores a new task
+func (r *RepoBulkTaskRepoImpl) Create(ctx context.Context, task *models.RepoBulkTask) error {
+	if err := r.db.WithContext(ctx).Create(task).Error; err != nil {
+		return apperror.DatabaseError("create repository bulk task", err)
+	}
+	return nil
+}
+
+// FindByID returns a task
+func (r *RepoBulkTaskRepoImpl) FindByID(ctx context.Context, id uuid.UUID) (*models.RepoBulkTask, error) {
+	var task models.RepoBulkTask
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("task", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find repository bulk task", err)
+	}
+	return &task, nil
+}
+
+// ListByStatus returns the tasks in any of the statuses, oldest first
+func (r *RepoBulkTaskRepoImpl) ListByStatus(ctx context.Context, statuses ...string) ([]*models.RepoBulkTask, error) {
+	var tasks []*models.RepoBulkTask
+	err := r.db.WithContext(ctx).
+		Where("status IN ?", statuses).
+		Order("created_at ASC").
+		Find(&tasks).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list repository bulk tasks", err)
+	}
+	return tasks, nil
+}
+
+// Update saves the status and results of a task
+func (r *RepoBulkTaskRepoImpl) Update(ctx context.Context, task *models.RepoBulkTask) error {
+	result := r.db.WithContext(ctx).
+		Model(task).
+		Select("status", "results", "completed_at", "expires_at").
+		Updates(task)
+	if result.Error != nil {
+		return apperror.DatabaseError("update repository bulk task", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("task", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteExpired removes the tasks that expired before a time and returns how
+// many were removed
+func (r *RepoBulkTaskRepoImpl) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.RepoBulkTask{})
+	if result.Error != nil {
+		return 0, apperror.DatabaseError("delete repository bulk tasks", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.RepoBulkTaskRepository = (*RepoBulkTaskRepoImpl)(nil)
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// RepoImportRepoImpl implements the RepoImportRepository interface using GORM
+type RepoImportRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewRepoImportRepository creates a new RepoImportRepoImpl instance
+func NewRepoImportRepository(db *gorm.DB) repository.RepoImportRepository {
+	return &RepoImportRepoImpl{db: db}
+}
+
+// Create stores a new import
+func (r *RepoImportRepoImpl) Create(ctx context.Context, imp *models.RepoImport) error {
+	if err := r.db.WithContext(ctx).Create(imp).Error; err != nil {
+		return apperror.DatabaseError("create repository import", err)
+	}
+	return nil
+}
+
+// FindByID returns an import
+func (r *RepoImportRepoImpl) FindByID(ctx context.Context, id uuid.UUID) (*models.RepoImport, error) {
+	var imp models.RepoImport
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&imp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("import", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find repository import", err)
+	}
+	return &imp, nil
+}
+
+// ListByOwner returns a page of the imports of a user, newest first, with the
+// total number
+func (r *RepoImportRepoImpl) ListByOwner(ctx context.Context, ownerID uuid.UUID, limit, offset int) ([]*models.RepoImport, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.RepoImport{}).Where("owner_id = ?", ownerID).Count(&total).Error; err != nil {
+		return nil, 0, apperror.DatabaseError("count repository imports", err)
+	}
+
+	var imports []*models.RepoImport
+	err := r.db.WithContext(ctx).
+		Where("owner_id = ?", ownerID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&imports).Error
+	if err != nil {
+		return nil, 0, apperror.DatabaseError("list repository imports", err)
+	}
+	return imports, total, nil
+}
+
+// ListActive returns the pending and running imports of a user
+func (r *RepoImportRepoImpl) ListActive(ctx context.Context, ownerID uuid.UUID) ([]*models.RepoImport, error) {
+	var imports []*models.RepoImport
+	err := r.db.WithContext(ctx).
+		Where("owner_id = ? AND status IN ?", ownerID, []string{models.RepoImportPending, models.RepoImportRunning}).
+		Order("created_at ASC").
+		Find(&imports).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list active repository imports", err)
+	}
+	return imports, nil
+}
+
+// ListByStatus returns the imports in any of the statuses, oldest first
+func (r *RepoImportRepoImpl) ListByStatus(ctx context.Context, statuses ...string) ([]*models.RepoImport, error) {
+	var imports []*models.RepoImport
+	err := r.db.WithContext(ctx).
+		Where("status IN ?", statuses).
+		Order("created_at ASC").
+		Find(&imports).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list repository imports", err)
+	}
+	return imports, nil
+}
+
+// Update saves the progress and result of an import
+func (r *RepoImportRepoImpl) Update(ctx context.Context, imp *models.RepoImport) error {
+	result := r.db.WithContext(ctx).
+		Model(imp).
+		Select("repository_id", "archive_path", "git_path", "storage_backend", "status", "error", "started_at", "completed_at").
+		Updates(imp)
+	if result.Error != nil {
+		return apperror.DatabaseError("update repository import", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("import", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.RepoImportRepository = (*RepoImportRepoImpl)(nil)
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// RepoLanguageRepoImpl implements the RepoLanguageRepository interface using GORM
+type RepoLanguageRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewRepoLanguageRepository creates a new RepoLanguageRepoImpl instance
+func NewRepoLanguageRepository(db *gorm.DB) repository.RepoLanguageRepository {
+	return &RepoLanguageRepoImpl{db: db}
+}
+
+// Replace replaces the language breakdown of a repository with the one of a
+// commit, in a single transaction
+func (r *RepoLanguageRepoImpl) Replace(ctx context.Context, repoID uuid.UUID, commitHash string, languages []models.RepoLanguage) error {
+	for i := range languages {
+		languages[i].RepositoryID = repoID
+		languages[i].CommitHash = commitHash
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repository_id = ?", repoID).Delete(&models.RepoLanguage{}).Error; err != nil {
+			return err
+		}
+		if len(languages) == 0 {
+			return nil
+		}
+		return tx.Create(&languages).Error
+	})
+	if err != nil {
+		return apperror.DatabaseError("save repository languages", err)
+	}
+	return nil
+}
+
+// ListByRepository lists the languages of a repository, most bytes first
+func (r *RepoLanguageRepoImpl) ListByRepository(ctx context.Context, repoID uuid.UUID) ([]models.RepoLanguage, error) {
+	var languages []models.RepoLanguage
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ?", repoID).
+		Order("bytes DESC, language ASC").
+		Find(&languages).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list repository languages", err)
+	}
+	return languages, nil
+}
+
+// PrimaryLanguages returns the language with the most bytes of each of a set
+// of repositories
+func (r *RepoLanguageRepoImpl) PrimaryLanguages(ctx context.Context, repoIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	primary := make(map[uuid.UUID]string, len(repoIDs))
+	if len(repoIDs) == 0 {
+		return primary, nil
+	}
+
+	var rows []models.RepoLanguage
+	err := r.db.WithContext(ctx).
+		Select("DISTINCT ON (repository_id) repository_id, language").
+		Where("repository_id IN ?", repoIDs).
+		Order("repository_id, bytes DESC, language ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list primary languages", err)
+	}
+	for _, row := range rows {
+		primary[row.RepositoryID] = row.Language
+	}
+	return primary, nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.RepoLanguageRepository = (*RepoLanguageRepoImpl)(nil)
@@ -3,16 +3,22 @@ package repository
 import (
 	"context"
 	"errors"
-	"fmt"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/bravo68web/stasis/internal/domain/models"
 	"github.com/bravo68web/stasis/internal/domain/repository"
 	apperror "github.com/bravo68web/stasis/pkg/errors"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// likeEscaper escapes the LIKE wildcards of user input so it matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // RepoRepoImpl implements the RepoRepository interface using GORM
 type RepoRepoImpl struct {
 	db *gorm.DB
@@ -68,6 +74,7 @@ func (r *RepoRepoImpl) FindByOwnerUsernameAndName(ctx context.Context, username,
 	var repo models.Repository
 	err := r.db.WithContext(ctx).
 		Preload("Owner").
+		Preload("ForkedFrom.Owner").
 		Joins("JOIN users ON users.id = repositories.owner_id").
 		Where("users.username = ? AND repositories.name = ?", username, name).
 		First(&repo).Error
@@ -110,6 +117,24 @@ func (r *RepoRepoImpl) ListPublic(ctx context.Context, limit, offset int) ([]*mo
 	return repos, nil
 }
 
+// ListPublicLocations lists every public repository with only what serving
+// its git data needs: ID, name, owner username, default branch and storage
+// location
+func (r *RepoRepoImpl) ListPublicLocations(ctx context.Context) ([]*models.Repository, error) {
+	var repos []*models.Repository
+	err := r.db.WithContext(ctx).
+		Select("id", "name", "owner_id", "default_branch", "git_path", "storage_backend").
+		Preload("Owner", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "username")
+		}).
+		Where("is_private = ?", false).
+		Find(&repos).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list", err)
+	}
+	return repos, nil
+}
+
 // ListAll lists all repositories with pagination (for admin use)
 func (r *RepoRepoImpl) ListAll(ctx context.Context, limit, offset int) ([]*models.Repository, error) {
 	var repos []*models.Repository
@@ -194,20 +219,30 @@ func (r *RepoRepoImpl) FindByGitPath(ctx context.Context, gitPath string) (*mode
 	return &repo, nil
 }
 
-// Search searches repositories by name or description
-func (r *RepoRepoImpl) Search(ctx context.Context, query string, limit, offset int, includePrivate bool) ([]*models.Repository, error) {
+// Search finds repositories whose name or description contains query, case
+// insensitively, and matching the filter, most recently updated first.
+// Private repositories are only included if viewer owns them or is one of
+// their collaborators, or all of them if viewer is a site administrator.
+func (r *RepoRepoImpl) Search(ctx context.Context, query string, filter repository.RepoFilter, viewer *models.User, limit, offset int) ([]*models.Repository, error) {
 	var repos []*models.Repository
-	searchPattern := fmt.Sprintf("%%%s%%", query)
+	searchPattern := "%" + likeEscaper.Replace(query) + "%"
 
-	db := r.db.WithContext(ctx).
+	db := applyRepoFilter(r.db.WithContext(ctx), filter).
 		Preload("Owner").
-		Where("name ILIKE ? OR description ILIKE ?", searchPattern, searchPattern)
+		Where("(repositories.name ILIKE ? OR repositories.description ILIKE ?)", searchPattern, searchPattern)
 
-	if !includePrivate {
-		db = db.Where("is_private = ?", false)
+	// Site administrators see every repository
+	switch {
+	case viewer == nil:
+		db = db.Where("repositories.is_private = ?", false)
+	case !viewer.IsAdmin:
+		db = db.Where(`(repositories.is_private = ? OR repositories.owner_id = ? OR EXISTS (
+			SELECT 1 FROM repository_collaborators
+			WHERE repository_collaborators.repository_id = repositories.id AND repository_collaborators.user_id = ?
+		))`, false, viewer.ID, viewer.ID)
 	}
 
-	err := db.Order("created_at DESC").
+	err := db.Order("repositories.updated_at DESC").
 		Limit(limit).
 		Offset(offset).
 		Find(&repos).Error
@@ -247,6 +282,207 @@ func (r *RepoRepoImpl) UpdateDescription(ctx context.Context, id uuid.UUID, desc
 	return nil
 }
 
+// UpdateProtectedTags replaces the tag protection settings of a repository
+func (r *RepoRepoImpl) UpdateProtectedTags(ctx context.Context, id uuid.UUID, patterns, overrides []string) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Repository{ID: id}).
+		Select("protected_tag_patterns", "protected_tag_overrides").
+		Updates(&models.Repository{
+			ProtectedTagPatterns:  patterns,
+			ProtectedTagOverrides: overrides,
+		})
+	if result.Error != nil {
+		return apperror.DatabaseError("update", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("repository", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// UpdatePushPolicy replaces the push policy of a repository; nil removes it
+func (r *RepoRepoImpl) UpdatePushPolicy(ctx context.Context, id uuid.UUID, policy *models.PushPolicy) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Repository{ID: id}).
+		Select("push_policy").
+		Updates(&models.Repository{PushPolicy: policy})
+	if result.Error != nil {
+		return apperror.DatabaseError("update", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("repository", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// UpdateUploadPackSettings replaces the upload-pack settings of a repository
+func (r *RepoRepoImpl) UpdateUploadPackSettings(ctx context.Context, id uuid.UUID, settings models.UploadPackSettings) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Repository{ID: id}).
+		Select("upload_pack_allow_filter", "upload_pack_allow_reachable_sha1_in_want", "upload_pack_allow_any_sha1_in_want").
+		Updates(&models.Repository{UploadPack: settings})
+	if result.Error != nil {
+		return apperror.DatabaseError("update", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("repository", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// Rename changes the name and git path of a repository, drops the redirects
+// from its new name and records the redirect from its former one, in a single
+// transaction
+func (r *RepoRepoImpl) Rename(ctx context.Context, repo *models.Repository, redirect *models.RepoRedirect) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Repository{ID: repo.ID}).
+			Select("name", "git_path").
+			Updates(&models.Repository{Name: repo.Name, GitPath: repo.GitPath})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		err := tx.Where("old_owner = ? AND old_name = ?", redirect.OldOwner, repo.Name).
+			Delete(&models.RepoRedirect{}).Error
+		if err != nil {
+			return err
+		}
+
+		redirect.RepositoryID = repo.ID
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "old_owner"}, {Name: "old_name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"repository_id", "created_at"}),
+		}).Create(redirect).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperror.NotFound("repository", apperror.ErrNotFound)
+		}
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return apperror.Conflict("repository name already exists", apperror.ErrRepositoryExists)
+		}
+		return apperror.DatabaseError("rename", err)
+	}
+	return nil
+}
+
+// FindByRedirect finds the repository a former owner username and name
+// redirects to. Redirects created before since, or whose name a repository
+// took since, are ignored.
+func (r *RepoRepoImpl) FindByRedirect(ctx context.Context, username, name string, since time.Time) (*models.Repository, error) {
+	redirect := r.db.Model(&models.RepoRedirect{}).
+		Select("repo_redirects.repository_id").
+		Where("repo_redirects.old_owner = ? AND repo_redirects.old_name = ? AND repo_redirects.created_at >= ?", username, name, since).
+		Where(`NOT EXISTS (SELECT 1 FROM repositories taken JOIN users ON users.id = taken.owner_id
+			WHERE users.username = repo_redirects.old_owner AND taken.name = repo_redirects.old_name)`)
+
+	var repo models.Repository
+	err := r.db.WithContext(ctx).
+		Preload("Owner").
+		Preload("ForkedFrom.Owner").
+		Where("repositories.id = (?)", redirect).
+		First(&repo).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("repository", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find", err)
+	}
+	return &repo, nil
+}
+
+// DeleteRedirectsCreatedBefore deletes the redirects created before a time
+func (r *RepoRepoImpl) DeleteRedirectsCreatedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("created_at < ?", before).
+		Delete(&models.RepoRedirect{})
+	if result.Error != nil {
+		return 0, apperror.DatabaseError("delete repository redirects", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// FindContributionsUnindexed finds repositories whose commits were never recorded as contributions
+func (r *RepoRepoImpl) FindContributionsUnindexed(ctx context.Context, limit int) ([]*models.Repository, error) {
+	var repos []*models.Repository
+	err := r.db.WithContext(ctx).
+		Preload("Owner").
+		Where("contributions_indexed_at IS NULL").
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&repos).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("find", err)
+	}
+	return repos, nil
+}
+
+// UpdatePinnedLinks replaces the pinned links of a repository
+func (r *RepoRepoImpl) UpdatePinnedLinks(ctx context.Context, id uuid.UUID, links []models.PinnedLink) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Repository{ID: id}).
+		Select("pinned_links").
+		Updates(&models.Repository{PinnedLinks: links})
+	if result.Error != nil {
+		return apperror.DatabaseError("update", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("repository", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// UpdateTopics replaces the topics of a repository
+func (r *RepoRepoImpl) UpdateTopics(ctx context.Context, id uuid.UUID, topics []string) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Repository{}).
+		Where("id = ?", id).
+		Update("topics", pq.StringArray(topics))
+	if result.Error != nil {
+		return apperror.DatabaseError("update", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("repository", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// CountByTopic returns the number of public repositories per topic, most
+// used first, for at most limit topics
+func (r *RepoRepoImpl) CountByTopic(ctx context.Context, limit int) ([]models.TopicCount, error) {
+	var counts []models.TopicCount
+	err := r.db.WithContext(ctx).
+		Table("repositories, unnest(repositories.topics) AS topic").
+		Select("topic, COUNT(*) AS count").
+		Where("repositories.is_private = ?", false).
+		Group("topic").
+		Order("count DESC, topic ASC").
+		Limit(limit).
+		Scan(&counts).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("count", err)
+	}
+	return counts, nil
+}
+
+// MarkContributionsIndexed records that the commits of a repository were recorded as contributions
+func (r *RepoRepoImpl) MarkContributionsIndexed(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Repository{}).
+		Where("id = ?", id).
+		Update("contributions_indexed_at", time.Now())
+	if result.Error != nil {
+		return apperror.DatabaseError("update", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("repository", apperror.ErrNotFound)
+	}
+	return nil
+}
+
 // FindAllMirrors finds all mirror repositories
 func (r *RepoRepoImpl) FindAllMirrors(ctx context.Context) ([]*models.Repository, error) {
 	var repos []*models.Repository
@@ -260,3 +496,236 @@ func (r *RepoRepoImpl) FindAllMirrors(ctx context.Context) ([]*models.Repository
 	}
 	return repos, nil
 }
+
+// FindByOwnerFiltered finds all repositories owned by a user matching the filter
+func (r *RepoRepoImpl) FindByOwnerFiltered(ctx context.Context, ownerID uuid.UUID, filter repository.RepoFilter) ([]*models.Repository, error) {
+	var repos []*models.Repository
+	err := applyRepoFilter(r.db.WithContext(ctx), filter).
+		Preload("Owner").
+		Where("repositories.owner_id = ?", ownerID).
+		Order("repositories.created_at DESC").
+		Find(&repos).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("find", err)
+	}
+	return repos, nil
+}
+
+// ListPublicFiltered lists public repositories matching the filter with pagination
+func (r *RepoRepoImpl) ListPublicFiltered(ctx context.Context, filter repository.RepoFilter, limit, offset int) ([]*models.Repository, error) {
+	var repos []*models.Repository
+	err := applyRepoFilter(r.db.WithContext(ctx), filter).
+		Preload("Owner").
+		Where("repositories.is_private = ?", false).
+		Order("repositories.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&repos).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list", err)
+	}
+	return repos, nil
+}
+
+// applyRepoFilter adds the conditions of a repository filter to a query
+func applyRepoFilter(db *gorm.DB, filter repository.RepoFilter) *gorm.DB {
+	if filter.AnnotationKey != "" {
+		db = db.Joins("JOIN repo_annotations ON repo_annotations.repository_id = repositories.id").
+			Where("repo_annotations.key = ? AND repo_annotations.value = ?", filter.AnnotationKey, filter.AnnotationValue)
+	}
+	if filter.License != nil {
+		if *filter.License == "" {
+			db = db.Where("(repositories.license IS NULL OR repositories.license = '')")
+		} else {
+			db = db.Where("repositories.license = ?", *filter.License)
+		}
+	}
+	if filter.Topic != "" {
+		db = db.Where("repositories.topics @> ?", pq.StringArray{filter.Topic})
+	}
+	return db
+}
+
+// UpdateLicense stores the detected license, override and effective license of a repository
+func (r *RepoRepoImpl) UpdateLicense(ctx context.Context, repo *models.Repository) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Repository{}).
+		Where("id = ?", repo.ID).
+		Updates(map[string]any{
+			"license":             repo.License,
+			"detected_license":    repo.DetectedLicense,
+			"license_override":    repo.LicenseOverride,
+			"license_detected_at": repo.LicenseDetectedAt,
+		})
+	if result.Error != nil {
+		return apperror.DatabaseError("update", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("repository", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// FindLicenseUndetected finds repositories whose license file was never inspected
+func (r *RepoRepoImpl) FindLicenseUndetected(ctx context.Context, limit int) ([]*models.Repository, error) {
+	var repos []*models.Repository
+	err := r.db.WithContext(ctx).
+		Where("license_detected_at IS NULL").
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&repos).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("find", err)
+	}
+	return repos, nil
+}
+
+// CountByLicense returns the number of repositories per effective license
+func (r *RepoRepoImpl) CountByLicense(ctx context.Context) ([]models.LicenseCount, error) {
+	var counts []models.LicenseCount
+	err := r.db.WithContext(ctx).
+		Model(&models.Repository{}).
+		Select("COALESCE(license, '') AS license, COUNT(*) AS count").
+		Group("COALESCE(license, '')").
+		Order("count DESC, license ASC").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("count", err)
+	}
+	return counts, nil
+}
+
+// UpdateStorage records that a repository moved to a storage backend and path
+func (r *RepoRepoImpl) UpdateStorage(ctx context.Context, id uuid.UUID, backend, gitPath string) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Repository{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"storage_backend": backend,
+			"git_path":        gitPath,
+		})
+	if result.Error != nil {
+		return apperror.DatabaseError("update", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("repository", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// CountByStorageBackend returns the number of repositories per storage backend
+func (r *RepoRepoImpl) CountByStorageBackend(ctx context.Context) ([]models.StorageBackendCount, error) {
+	var counts []models.StorageBackendCount
+	err := r.db.WithContext(ctx).
+		Model(&models.Repository{}).
+		Select("storage_backend, COUNT(*) AS count").
+		Group("storage_backend").
+		Order("storage_backend ASC").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("count", err)
+	}
+	return counts, nil
+}
+
+// UpdateSize stores the measured disk usage of a repository. The update time
+// is left alone: a new size is not a change of the repository settings.
+func (r *RepoRepoImpl) UpdateSize(ctx context.Context, id uuid.UUID, sizeBytes int64) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Repository{}).
+		Where("id = ?", id).
+		UpdateColumn("size_bytes", sizeBytes)
+	if result.Error != nil {
+		return apperror.DatabaseError("update", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("repository", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// UpdateGC records the end and duration of a garbage collection of a
+// repository. Like UpdateSize it leaves the update time alone.
+func (r *RepoRepoImpl) UpdateGC(ctx context.Context, id uuid.UUID, at time.Time, duration time.Duration) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Repository{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]any{
+			"last_gc_at":          at,
+			"last_gc_duration_ms": duration.Milliseconds(),
+		})
+	if result.Error != nil {
+		return apperror.DatabaseError("update", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("repository", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// UpdateQuota sets the size limit override of a repository (nil to remove it)
+func (r *RepoRepoImpl) UpdateQuota(ctx context.Context, id uuid.UUID, quotaBytes *int64) error {
+	result := r.db.WithContext(ctx).
+		Model(&models.Repository{}).
+		Where("id = ?", id).
+		Update("quota_bytes", quotaBytes)
+	if result.Error != nil {
+		return apperror.DatabaseError("update", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("repository", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// SumSizeByOwner returns the recorded disk usage of all repositories owned by a user
+func (r *RepoRepoImpl) SumSizeByOwner(ctx context.Context, ownerID uuid.UUID) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).
+		Model(&models.Repository{}).
+		Where("owner_id = ?", ownerID).
+		Select("COALESCE(SUM(size_bytes), 0)").
+		Scan(&total).Error
+	if err != nil {
+		return 0, apperror.DatabaseError("sum", err)
+	}
+	return total, nil
+}
+
+// FindForks finds the direct forks of a repository visible to a viewer,
+// oldest first
+func (r *RepoRepoImpl) FindForks(ctx context.Context, parentID uuid.UUID, viewerID *uuid.UUID, limit, offset int) ([]*models.Repository, error) {
+	var repos []*models.Repository
+
+	db := r.db.WithContext(ctx).
+		Preload("Owner").
+		Where("forked_from_id = ?", parentID)
+
+	if viewerID != nil {
+		db = db.Where("is_private = ? OR owner_id = ?", false, *viewerID)
+	} else {
+		db = db.Where("is_private = ?", false)
+	}
+
+	err := db.Order("created_at ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&repos).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("find forks", err)
+	}
+	return repos, nil
+}
+
+// CountForks returns the number of direct forks of a repository
+func (r *RepoRepoImpl) CountForks(ctx context.Context, parentID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.Repository{}).
+		Where("forked_from_id = ?", parentID).
+		Count(&count).Error
+	if err != nil {
+		return 0, apperror.DatabaseError("count forks", err)
+	}
+	return count, nil
+}
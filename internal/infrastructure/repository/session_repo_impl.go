@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// SessionRepoImpl implements the SessionRepository interface using GORM
+type SessionRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository creates a new SessionRepoImpl instance
+func NewSessionRepository(db *gorm.DB) repository.SessionRepository {
+	return &SessionRepoImpl{db: db}
+}
+
+// Create stores a new session
+func (r *SessionRepoImpl) Create(ctx context.Context, session *models.Session) error {
+	if err := r.db.WithContext(ctx).Create(session).Error; err != nil {
+		return apperror.DatabaseError("create session", err)
+	}
+	return nil
+}
+
+// FindByID retrieves a session by its ID
+func (r *SessionRepoImpl) FindByID(ctx context.Context, id uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("session", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find session by id", err)
+	}
+	return &session, nil
+}
+
+// Touch records a use of a session
+func (r *SessionRepoImpl) Touch(ctx context.Context, id uuid.UUID, at time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+	if err != nil {
+		return apperror.DatabaseError("touch session", err)
+	}
+	return nil
+}
+
+// UpdateTokens replaces the encrypted identity provider tokens of a session
+// and records the use
+func (r *SessionRepoImpl) UpdateTokens(ctx context.Context, id uuid.UUID, refreshToken, idToken string, at time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"refresh_token": refreshToken,
+			"id_token":      idToken,
+			"last_used_at":  at,
+		}).Error
+	if err != nil {
+		return apperror.DatabaseError("update session tokens", err)
+	}
+	return nil
+}
+
+// Revoke revokes a session unless it is revoked already
+func (r *SessionRepoImpl) Revoke(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return false, apperror.DatabaseError("revoke session", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteInactive deletes the sessions revoked, expired or idle and returns how
+// many were deleted
+func (r *SessionRepoImpl) DeleteInactive(ctx context.Context, at, idleSince time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("revoked_at IS NOT NULL OR expires_at < ? OR last_used_at < ?", at, idleSince).
+		Delete(&models.Session{})
+	if result.Error != nil {
+		return 0, apperror.DatabaseError("delete inactive sessions", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.SessionRepository = (*SessionRepoImpl)(nil)
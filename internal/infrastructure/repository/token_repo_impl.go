@@ -95,6 +95,31 @@ func (r *TokenRepoImpl) UpdateLastUsed(ctx context.Context, id uuid.UUID) error
 	return nil
 }
 
+// RecordUsage stores the last use of a token and adds uses to its usage count
+func (r *TokenRepoImpl) RecordUsage(ctx context.Context, id uuid.UUID, usedAt time.Time, ip, userAgent string, uses int64) error {
+	result := r.db.WithContext(ctx).Model(&models.Token{}).Where("id = ?", id).UpdateColumns(map[string]any{
+		"last_used":       usedAt,
+		"last_used_ip":    ip,
+		"last_user_agent": userAgent,
+		"usage_count":     gorm.Expr("usage_count + ?", uses),
+	})
+	if result.Error != nil {
+		return apperror.DatabaseError("record token usage", result.Error)
+	}
+	return nil
+}
+
+// AddKnownOrigin adds a "country/ASN" origin to the known origins of a token
+func (r *TokenRepoImpl) AddKnownOrigin(ctx context.Context, id uuid.UUID, origin string) error {
+	result := r.db.WithContext(ctx).Model(&models.Token{}).
+		Where("id = ? AND NOT COALESCE(known_origins, '[]'::jsonb) @> to_jsonb(?::text)", id, origin).
+		UpdateColumn("known_origins", gorm.Expr("COALESCE(known_origins, '[]'::jsonb) || to_jsonb(?::text)", origin))
+	if result.Error != nil {
+		return apperror.DatabaseError("add token origin", result.Error)
+	}
+	return nil
+}
+
 // CountByUserID returns the number of tokens for a user
 func (r *TokenRepoImpl) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
 	var count int64
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// UserExportRepoImpl implements the UserExportRepository interface using GORM
+type UserExportRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewUserExportRepository creates a new UserExportRepoImpl instance
+func NewUserExportRepository(db *gorm.DB) repository.UserExportRepository {
+	return &UserExportRepoImpl{db: db}
+}
+
+// Create stores a new export
+func (r *UserExportRepoImpl) Create(ctx context.Context, export *models.UserExport) error {
+	if err := r.db.WithContext(ctx).Create(export).Error; err != nil {
+		return apperror.DatabaseError("create user export", err)
+	}
+	return nil
+}
+
+// FindByID returns an export
+func (r *UserExportRepoImpl) FindByID(ctx context.Context, id uuid.UUID) (*models.UserExport, error) {
+	var export models.UserExport
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&export).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("export", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find user export", err)
+	}
+	return &export, nil
+}
+
+// ListByUser returns the exports of a user, newest first
+func (r *UserExportRepoImpl) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.UserExport, error) {
+	var exports []*models.UserExport
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&exports).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list user exports", err)
+	}
+	return exports, nil
+}
+
+// CountSelfRequestedSince counts the exports a user requested for themselves
+// since a time, except failed ones
+func (r *UserExportRepoImpl) CountSelfRequestedSince(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.UserExport{}).
+		Where("user_id = ? AND requested_by_id IS NULL AND created_at >= ? AND status <> ?", userID, since, models.UserExportFailed).
+		Count(&count).Error
+	if err != nil {
+		return 0, apperror.DatabaseError("count user exports", err)
+	}
+	return count, nil
+}
+
+// ListByStatus returns the exports in any of the statuses, oldest first
+func (r *UserExportRepoImpl) ListByStatus(ctx context.Context, statuses ...string) ([]*models.UserExport, error) {
+	var exports []*models.UserExport
+	err := r.db.WithContext(ctx).
+		Where("status IN ?", statuses).
+		Order("created_at ASC").
+		Find(&exports).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list user exports", err)
+	}
+	return exports, nil
+}
+
+// Update saves the status and result of an export
+func (r *UserExportRepoImpl) Update(ctx context.Context, export *models.UserExport) error {
+	result := r.db.WithContext(ctx).
+		Model(export).
+		Select("status", "storage_path", "size", "error", "completed_at", "expires_at").
+		Updates(export)
+	if result.Error != nil {
+		return apperror.DatabaseError("update user export", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("export", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// ListExpired returns up to limit exports that expired before a time
+func (r *UserExportRepoImpl) ListExpired(ctx context.Context, before time.Time, limit int) ([]*models.UserExport, error) {
+	var exports []*models.UserExport
+	err := r.db.WithContext(ctx).
+		Where("expires_at < ?", before).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&exports).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list expired user exports", err)
+	}
+	return exports, nil
+}
+
+// Delete removes an export
+func (r *UserExportRepoImpl) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.UserExport{}).Error; err != nil {
+		return apperror.DatabaseError("delete user export", err)
+	}
+	return nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.UserExportRepository = (*UserExportRepoImpl)(nil)
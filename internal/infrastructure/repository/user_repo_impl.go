@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 
 	"github.com/bravo68web/stasis/internal/domain/models"
@@ -12,6 +14,9 @@ import (
 	"github.com/google/uuid"
 )
 
+// foreignKeyViolation is the SQLSTATE of a violated foreign key constraint
+const foreignKeyViolation = "23503"
+
 // UserRepoImpl implements the UserRepository interface using GORM
 type UserRepoImpl struct {
 	db *gorm.DB
@@ -84,14 +89,45 @@ func (r *UserRepoImpl) Update(ctx context.Context, user *models.User) error {
 	return nil
 }
 
-// Delete removes a user from the database by their ID
-func (r *UserRepoImpl) Delete(ctx context.Context, id uuid.UUID) error {
-	result := r.db.WithContext(ctx).Delete(&models.User{}, id)
-	if result.Error != nil {
-		return apperror.DatabaseError("delete user", result.Error)
-	}
-	if result.RowsAffected == 0 {
-		return apperror.NotFound("user", apperror.ErrNotFound)
+// Delete removes a user from the database by their ID, along with their
+// access tokens, SSH keys and the repositories repoIDs, in a single
+// transaction. It fails with a conflict, deleting nothing, while other
+// records, such as other repositories, still reference the user.
+func (r *UserRepoImpl) Delete(ctx context.Context, id uuid.UUID, repoIDs []uuid.UUID) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(repoIDs) > 0 {
+			result := tx.Where("id IN ? AND owner_id = ?", repoIDs, id).Delete(&models.Repository{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != int64(len(repoIDs)) {
+				return apperror.Conflict("the repositories of the user changed, try again", apperror.ErrInvalidInput)
+			}
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.Token{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.SSHKey{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.User{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperror.NotFound("user", apperror.ErrNotFound)
+		}
+		return nil
+	})
+	if err != nil {
+		if apperror.IsNotFound(err) || apperror.IsConflict(err) {
+			return err
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return apperror.Conflict("user is still referenced by "+pgErr.TableName, apperror.ErrInvalidInput)
+		}
+		return apperror.DatabaseError("delete user", err)
 	}
 	return nil
 }
@@ -123,6 +159,51 @@ func (r *UserRepoImpl) Count(ctx context.Context) (int64, error) {
 	return count, nil
 }
 
+// Search finds users whose username or email contains query, case
+// insensitively, with pagination
+func (r *UserRepoImpl) Search(ctx context.Context, query string, limit, offset int) ([]*models.User, error) {
+	var users []*models.User
+	db := r.searchQuery(ctx, query).Order("username ASC")
+
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+	if offset > 0 {
+		db = db.Offset(offset)
+	}
+
+	if err := db.Find(&users).Error; err != nil {
+		return nil, apperror.DatabaseError("search users", err)
+	}
+	return users, nil
+}
+
+// CountSearch returns the number of users Search finds for query
+func (r *UserRepoImpl) CountSearch(ctx context.Context, query string) (int64, error) {
+	var count int64
+	if err := r.searchQuery(ctx, query).Count(&count).Error; err != nil {
+		return 0, apperror.DatabaseError("count users", err)
+	}
+	return count, nil
+}
+
+// CountAdmins returns the number of site administrators
+func (r *UserRepoImpl) CountAdmins(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Where("is_admin = ?", true).Count(&count).Error; err != nil {
+		return 0, apperror.DatabaseError("count admins", err)
+	}
+	return count, nil
+}
+
+// searchQuery selects the users whose username or email contains query
+func (r *UserRepoImpl) searchQuery(ctx context.Context, query string) *gorm.DB {
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	return r.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("username ILIKE ? OR email ILIKE ?", pattern, pattern)
+}
+
 // ExistsByUsername checks if a user with the given username exists
 func (r *UserRepoImpl) ExistsByUsername(ctx context.Context, username string) (bool, error) {
 	var count int64
@@ -152,3 +233,14 @@ func (r *UserRepoImpl) FindByOIDCSubject(ctx context.Context, subject, issuer st
 	}
 	return &user, nil
 }
+
+// MarkOnboarded sets when a user was onboarded unless it is already set
+func (r *UserRepoImpl) MarkOnboarded(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND onboarded_at IS NULL", id).
+		Update("onboarded_at", at)
+	if result.Error != nil {
+		return false, apperror.DatabaseError("mark user onboarded", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
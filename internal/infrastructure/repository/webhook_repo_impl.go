@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	apperror "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// WebhookRepoImpl implements the WebhookRepository interface using GORM
+type WebhookRepoImpl struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new WebhookRepoImpl instance
+func NewWebhookRepository(db *gorm.DB) repository.WebhookRepository {
+	return &WebhookRepoImpl{db: db}
+}
+
+// ListByRepository returns the webhooks of a repository, oldest first
+func (r *WebhookRepoImpl) ListByRepository(ctx context.Context, repoID uuid.UUID) ([]*models.Webhook, error) {
+	var hooks []*models.Webhook
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ?", repoID).
+		Order("created_at ASC").
+		Find(&hooks).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list webhooks", err)
+	}
+	return hooks, nil
+}
+
+// FindByID returns a webhook of a repository
+func (r *WebhookRepoImpl) FindByID(ctx context.Context, repoID, id uuid.UUID) (*models.Webhook, error) {
+	var hook models.Webhook
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ? AND id = ?", repoID, id).
+		First(&hook).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("webhook", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find webhook", err)
+	}
+	return &hook, nil
+}
+
+// Create stores a new webhook
+func (r *WebhookRepoImpl) Create(ctx context.Context, hook *models.Webhook) error {
+	if err := r.db.WithContext(ctx).Create(hook).Error; err != nil {
+		return apperror.DatabaseError("create webhook", err)
+	}
+	return nil
+}
+
+// Update saves the changes to a webhook
+func (r *WebhookRepoImpl) Update(ctx context.Context, hook *models.Webhook) error {
+	result := r.db.WithContext(ctx).
+		Model(hook).
+		Select("url", "secret", "events", "ref_pattern", "active", "updated_at").
+		Updates(hook)
+	if result.Error != nil {
+		return apperror.DatabaseError("update webhook", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("webhook", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// Delete removes a webhook of a repository with its deliveries
+func (r *WebhookRepoImpl) Delete(ctx context.Context, repoID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).
+		Where("repository_id = ? AND id = ?", repoID, id).
+		Delete(&models.Webhook{})
+	if result.Error != nil {
+		return apperror.DatabaseError("delete webhook", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("webhook", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// CreateDelivery stores a new delivery and returns true, or returns false if
+// a delivery with its ID already exists
+func (r *WebhookRepoImpl) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(delivery)
+	if result.Error != nil {
+		return false, apperror.DatabaseError("create webhook delivery", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindDelivery returns a delivery with its webhook
+func (r *WebhookRepoImpl) FindDelivery(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Preload("Webhook").
+		Where("id = ?", id).
+		First(&delivery).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.NotFound("webhook delivery", apperror.ErrNotFound)
+		}
+		return nil, apperror.DatabaseError("find webhook delivery", err)
+	}
+	return &delivery, nil
+}
+
+// UpdateDelivery saves the outcome of the last attempt of a delivery
+func (r *WebhookRepoImpl) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	result := r.db.WithContext(ctx).
+		Model(delivery).
+		Select("attempts", "status_code", "error", "delivered_at", "last_attempt_at").
+		Updates(delivery)
+	if result.Error != nil {
+		return apperror.DatabaseError("update webhook delivery", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperror.NotFound("webhook delivery", apperror.ErrNotFound)
+	}
+	return nil
+}
+
+// ListDeliveries lists the deliveries of a webhook, most recent first
+func (r *WebhookRepoImpl) ListDeliveries(ctx context.Context, webhookID uuid.UUID, limit, offset int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("webhook_id = ?", webhookID).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, apperror.DatabaseError("list webhook deliveries", err)
+	}
+	return deliveries, nil
+}
+
+// DeleteDeliveriesBefore deletes deliveries created before a time and
+// returns how many were deleted
+func (r *WebhookRepoImpl) DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", before).Delete(&models.WebhookDelivery{})
+	if result.Error != nil {
+		return 0, apperror.DatabaseError("delete webhook deliveries", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Verify interface compliance at compile time
+var _ repository.WebhookRepository = (*WebhookRepoImpl)(nil)
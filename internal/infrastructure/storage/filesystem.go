@@ -293,6 +293,11 @@ func (s *FilesystemStorage) Walk(root string, fn filepath.WalkFunc) error {
 	return filepath.Walk(fullPath, fn)
 }
 
+// ImportDirectory copies a local directory tree into the storage at path
+func (s *FilesystemStorage) ImportDirectory(localPath, path string) error {
+	return copyLocalTree(localPath, s.resolvePath(path))
+}
+
 // CreateSymlink creates a symbolic link
 func (s *FilesystemStorage) CreateSymlink(target, link string) error {
 	linkPath := s.resolvePath(link)
@@ -373,6 +378,70 @@ func (s *FilesystemStorage) resolvePath(path string) string {
 	return filepath.Join(s.basePath, path)
 }
 
+// copyLocalTree copies a directory tree, keeping file modes and symlinks.
+// The destination must not exist yet.
+func copyLocalTree(src, dst string) error {
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("destination already exists: %s", dst)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to check destination: %w", err)
+	}
+
+	return filepath.Walk(src, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			if err := os.MkdirAll(target, info.Mode().Perm()); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("failed to read symlink: %w", err)
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return fmt.Errorf("failed to create symlink: %w", err)
+			}
+		case info.Mode().IsRegular():
+			if err := copyLocalFile(path, target, info.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// copyLocalFile copies a regular file and syncs it to disk
+func copyLocalFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open source file: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy file content: %w", err)
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to sync file: %w", err)
+	}
+	return out.Close()
+}
+
 // SyncToRemote is a no-op for filesystem storage
 // since files are already on the local filesystem
 func (s *FilesystemStorage) SyncToRemote(localPath string) error {
@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"io"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/pkg/metrics"
+)
+
+// InstrumentedStorage records the latency of the operations of a storage
+// backend in the storage metrics. Operations returning a stream (OpenFile,
+// CreateFile, AppendFile) are timed until the stream is returned.
+type InstrumentedStorage struct {
+	backend service.StorageService
+	name    string
+}
+
+// NewInstrumentedStorage wraps the backend called name
+func NewInstrumentedStorage(backend service.StorageService, name string) *InstrumentedStorage {
+	return &InstrumentedStorage{backend: backend, name: name}
+}
+
+// observe records an operation started at start
+func (s *InstrumentedStorage) observe(operation string, start time.Time, err error) {
+	metrics.ObserveStorageOperation(s.name, operation, start, err)
+}
+
+// GetRepoPath returns the full path for a repository given owner and repo name
+func (s *InstrumentedStorage) GetRepoPath(owner, repoName string) string {
+	return s.backend.GetRepoPath(owner, repoName)
+}
+
+// GetBasePath returns the base storage path
+func (s *InstrumentedStorage) GetBasePath() string {
+	return s.backend.GetBasePath()
+}
+
+// Exists checks if a path exists in the storage
+func (s *InstrumentedStorage) Exists(path string) (bool, error) {
+	start := time.Now()
+	exists, err := s.backend.Exists(path)
+	s.observe("exists", start, err)
+	return exists, err
+}
+
+// IsDir checks if the path is a directory
+func (s *InstrumentedStorage) IsDir(path string) (bool, error) {
+	start := time.Now()
+	isDir, err := s.backend.IsDir(path)
+	s.observe("is_dir", start, err)
+	return isDir, err
+}
+
+// CreateDirectory creates a directory and all parent directories
+func (s *InstrumentedStorage) CreateDirectory(path string) error {
+	start := time.Now()
+	err := s.backend.CreateDirectory(path)
+	s.observe("create_directory", start, err)
+	return err
+}
+
+// DeleteDirectory removes a directory and all its contents
+func (s *InstrumentedStorage) DeleteDirectory(path string) error {
+	start := time.Now()
+	err := s.backend.DeleteDirectory(path)
+	s.observe("delete_directory", start, err)
+	return err
+}
+
+// ReadFile reads the entire file content
+func (s *InstrumentedStorage) ReadFile(path string) ([]byte, error) {
+	start := time.Now()
+	data, err := s.backend.ReadFile(path)
+	s.observe("read_file", start, err)
+	return data, err
+}
+
+// WriteFile writes data to a file, creating it if it doesn't exist
+func (s *InstrumentedStorage) WriteFile(path string, data []byte) error {
+	start := time.Now()
+	err := s.backend.WriteFile(path, data)
+	s.observe("write_file", start, err)
+	return err
+}
+
+// OpenFile opens a file for reading
+func (s *InstrumentedStorage) OpenFile(path string) (io.ReadCloser, error) {
+	start := time.Now()
+	file, err := s.backend.OpenFile(path)
+	s.observe("open_file", start, err)
+	return file, err
+}
+
+// CreateFile creates or truncates a file for writing
+func (s *InstrumentedStorage) CreateFile(path string) (io.WriteCloser, error) {
+	start := time.Now()
+	file, err := s.backend.CreateFile(path)
+	s.observe("create_file", start, err)
+	return file, err
+}
+
+// AppendFile opens a file for appending
+func (s *InstrumentedStorage) AppendFile(path string) (io.WriteCloser, error) {
+	start := time.Now()
+	file, err := s.backend.AppendFile(path)
+	s.observe("append_file", start, err)
+	return file, err
+}
+
+// DeleteFile removes a file
+func (s *InstrumentedStorage) DeleteFile(path string) error {
+	start := time.Now()
+	err := s.backend.DeleteFile(path)
+	s.observe("delete_file", start, err)
+	return err
+}
+
+// CopyFile copies a file from source to destination
+func (s *InstrumentedStorage) CopyFile(src, dst string) error {
+	start := time.Now()
+	err := s.backend.CopyFile(src, dst)
+	s.observe("copy_file", start, err)
+	return err
+}
+
+// MoveFile moves/renames a file
+func (s *InstrumentedStorage) MoveFile(src, dst string) error {
+	start := time.Now()
+	err := s.backend.MoveFile(src, dst)
+	s.observe("move_file", start, err)
+	return err
+}
+
+// Stat returns file info for the given path
+func (s *InstrumentedStorage) Stat(path string) (fs.FileInfo, error) {
+	start := time.Now()
+	info, err := s.backend.Stat(path)
+	s.observe("stat", start, err)
+	return info, err
+}
+
+// ListFiles returns a list of file paths in the given directory
+func (s *InstrumentedStorage) ListFiles(path string) ([]string, error) {
+	start := time.Now()
+	files, err := s.backend.ListFiles(path)
+	s.observe("list_files", start, err)
+	return files, err
+}
+
+// ReadDir reads a directory and returns directory entries
+func (s *InstrumentedStorage) ReadDir(path string) ([]fs.DirEntry, error) {
+	start := time.Now()
+	entries, err := s.backend.ReadDir(path)
+	s.observe("read_dir", start, err)
+	return entries, err
+}
+
+// Walk walks the file tree rooted at root, calling fn for each file or
+// directory; the time spent in fn is included
+func (s *InstrumentedStorage) Walk(root string, fn filepath.WalkFunc) error {
+	start := time.Now()
+	err := s.backend.Walk(root, fn)
+	s.observe("walk", start, err)
+	return err
+}
+
+// ImportDirectory copies a local directory tree into the storage at path
+func (s *InstrumentedStorage) ImportDirectory(localPath, path string) error {
+	start := time.Now()
+	err := s.backend.ImportDirectory(localPath, path)
+	s.observe("import_directory", start, err)
+	return err
+}
+
+// CreateSymlink creates a symbolic link
+func (s *InstrumentedStorage) CreateSymlink(target, link string) error {
+	start := time.Now()
+	err := s.backend.CreateSymlink(target, link)
+	s.observe("create_symlink", start, err)
+	return err
+}
+
+// ReadSymlink reads the target of a symbolic link
+func (s *InstrumentedStorage) ReadSymlink(path string) (string, error) {
+	start := time.Now()
+	target, err := s.backend.ReadSymlink(path)
+	s.observe("read_symlink", start, err)
+	return target, err
+}
+
+// Chmod changes the permissions of a file
+func (s *InstrumentedStorage) Chmod(path string, mode fs.FileMode) error {
+	start := time.Now()
+	err := s.backend.Chmod(path, mode)
+	s.observe("chmod", start, err)
+	return err
+}
+
+// Size returns the size of a file in bytes
+func (s *InstrumentedStorage) Size(path string) (int64, error) {
+	start := time.Now()
+	size, err := s.backend.Size(path)
+	s.observe("size", start, err)
+	return size, err
+}
+
+// GetDiskUsage returns the total size of a directory in bytes
+func (s *InstrumentedStorage) GetDiskUsage(path string) (int64, error) {
+	start := time.Now()
+	usage, err := s.backend.GetDiskUsage(path)
+	s.observe("get_disk_usage", start, err)
+	return usage, err
+}
+
+// SyncToRemote syncs a local path to remote storage
+func (s *InstrumentedStorage) SyncToRemote(localPath string) error {
+	start := time.Now()
+	err := s.backend.SyncToRemote(localPath)
+	s.observe("sync_to_remote", start, err)
+	return err
+}
+
+// Verify interface compliance at compile time
+var _ service.StorageService = (*InstrumentedStorage)(nil)
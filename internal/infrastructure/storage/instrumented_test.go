@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bravo68web/stasis/pkg/metrics"
+)
+
+// storageOperations returns the operations of a backend the served metrics
+// timed, by operation and result
+func storageOperations(t *testing.T, backend, operation, result string) float64 {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	series := `stasis_storage_operation_duration_seconds_count{backend="` + backend + `",operation="` + operation + `",result="` + result + `"} `
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), series); ok {
+			count, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return count
+		}
+	}
+	return 0
+}
+
+func TestInstrumentedStorage(t *testing.T) {
+	backend, err := NewFilesystemStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewInstrumentedStorage(backend, "instrumented-test")
+
+	if err := s.WriteFile("alice/notes.txt", []byte("notes")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := s.ReadFile("alice/notes.txt"); err != nil || string(data) != "notes" {
+		t.Fatalf("ReadFile = %q, %v", data, err)
+	}
+	if _, err := s.ReadFile("alice/missing.txt"); err == nil {
+		t.Fatal("ReadFile of a missing file succeeded")
+	}
+
+	tests := []struct {
+		operation string
+		result    string
+		want      float64
+	}{
+		{operation: "write_file", result: "success", want: 1},
+		{operation: "read_file", result: "success", want: 1},
+		{operation: "read_file", result: "error", want: 1},
+		{operation: "write_file", result: "error", want: 0},
+	}
+	for _, tt := range tests {
+		if got := storageOperations(t, "instrumented-test", tt.operation, tt.result); got != tt.want {
+			t.Errorf("timed %v %s operations with result %s, want %v", got, tt.operation, tt.result, tt.want)
+		}
+	}
+}
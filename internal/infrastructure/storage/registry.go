@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// healthCheckFile is written to and removed from every backend by Check
+const healthCheckFile = ".stasis-healthcheck"
+
+// Registry holds the configured storage backends by name and places new
+// repositories on them according to the placement rules
+type Registry struct {
+	backends  map[string]service.StorageService
+	types     map[string]StorageType
+	placement config.StoragePlacementConfig
+	next      atomic.Uint64 // Position in the round-robin backends
+	log       *logger.Logger
+}
+
+// NewRegistry creates every configured storage backend
+func NewRegistry(cfg *config.StorageConfig) (*Registry, error) {
+	r := &Registry{
+		backends:  make(map[string]service.StorageService),
+		types:     make(map[string]StorageType),
+		placement: cfg.Placement,
+		log:       logger.Get().WithFields(logger.Component("storage-registry")),
+	}
+
+	for _, backendCfg := range cfg.AllBackends() {
+		backend, err := NewFactory(&backendCfg).Create()
+		if err != nil {
+			return nil, fmt.Errorf("storage backend %q: %w", backendCfg.Name, err)
+		}
+		r.backends[backendCfg.Name] = NewInstrumentedStorage(backend, backendCfg.Name)
+		r.types[backendCfg.Name] = GetStorageType(&backendCfg)
+	}
+
+	r.log.Info("Storage backends initialized",
+		logger.Int("count", len(r.backends)),
+		logger.String("placement_backend", cfg.Placement.PlacementBackend()),
+		logger.Int("placement_rules", len(cfg.Placement.Rules)),
+	)
+	return r, nil
+}
+
+// Backend returns the backend with the given name
+func (r *Registry) Backend(name string) (service.StorageService, bool) {
+	backend, ok := r.backends[name]
+	return backend, ok
+}
+
+// Names returns the names of all backends, sorted
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.backends))
+	for name := range r.backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Type returns the storage type of a backend
+func (r *Registry) Type(name string) string {
+	return string(r.types[name])
+}
+
+// Place returns the backend of the first placement rule matching a repository
+// of owner with a size in bytes. Repositories no rule matches go to the
+// round-robin backends in turn if configured, otherwise to the placement backend.
+func (r *Registry) Place(owner string, size int64) string {
+	for _, rule := range r.placement.Rules {
+		if rule.Matches(owner, size) {
+			return rule.Backend
+		}
+	}
+	if n := uint64(len(r.placement.RoundRobin)); n > 0 {
+		return r.placement.RoundRobin[(r.next.Add(1)-1)%n]
+	}
+	return r.placement.PlacementBackend()
+}
+
+// Check verifies every backend is reachable and writable by writing and
+// removing a small file
+func (r *Registry) Check(ctx context.Context) error {
+	for _, name := range r.Names() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		backend := r.backends[name]
+		stamp := []byte(time.Now().UTC().Format(time.RFC3339))
+		if err := backend.WriteFile(healthCheckFile, stamp); err != nil {
+			return fmt.Errorf("storage backend %q is not writable: %w", name, err)
+		}
+		if err := backend.DeleteFile(healthCheckFile); err != nil {
+			return fmt.Errorf("storage backend %q: failed to remove health check file: %w", name, err)
+		}
+
+		r.log.Debug("Storage backend is reachable",
+			logger.String("backend", name),
+			logger.String("type", r.Type(name)),
+		)
+	}
+	return nil
+}
+
+// Verify interface compliance at compile time
+var _ service.StorageBackends = (*Registry)(nil)
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"net/url"
 	"os"
+	pathpkg "path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -20,6 +22,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/pkg/logger"
 )
 
 // S3Storage implements the StorageService interface using AWS S3
@@ -41,13 +44,31 @@ type S3Config struct {
 	UsePathStyle bool   // Optional: use path-style addressing
 	Prefix       string // Base prefix for all objects
 	LocalCache   string // Local cache directory
+
+	// Transport settings
+	CABundlePath        string        // Optional: PEM bundle trusted in addition to the system roots
+	InsecureSkipVerify  bool          // Optional: disable TLS certificate verification
+	MaxRetries          int           // Retries after the first attempt
+	RetryMaxBackoff     time.Duration // Maximum backoff between retries (0 = SDK default)
+	RequestTimeout      time.Duration // Wait for the response headers of an attempt (0 = no timeout)
+	MaxIdleConns        int           // Idle connection pool size (0 = SDK default)
+	MaxIdleConnsPerHost int           // Idle connections kept per host (0 = SDK default)
 }
 
 // NewS3Storage creates a new S3 storage instance
 func NewS3Storage(ctx context.Context, cfg S3Config) (*S3Storage, error) {
+	log := logger.Get().WithFields(logger.Component("s3-storage"))
+
+	httpClient, err := newS3HTTPClient(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build AWS config options
 	var configOpts []func(*config.LoadOptions) error
 	configOpts = append(configOpts, config.WithRegion(cfg.Region))
+	configOpts = append(configOpts, config.WithHTTPClient(httpClient))
+	configOpts = append(configOpts, config.WithRetryer(newS3Retryer(cfg)))
 
 	// Add credentials if provided
 	if cfg.AccessKey != "" && cfg.SecretKey != "" {
@@ -66,6 +87,7 @@ func NewS3Storage(ctx context.Context, cfg S3Config) (*S3Storage, error) {
 	var s3Opts []func(*s3.Options)
 	s3Opts = append(s3Opts, func(o *s3.Options) {
 		o.UsePathStyle = cfg.UsePathStyle
+		o.APIOptions = append(o.APIOptions, recordS3Metrics)
 	})
 
 	// Add custom endpoint if provided
@@ -109,6 +131,9 @@ func NewS3Storage(ctx context.Context, cfg S3Config) (*S3Storage, error) {
 
 	// Verify bucket exists
 	if err := storage.verifyBucket(ctx); err != nil {
+		if isTLSVerificationError(err) {
+			return nil, fmt.Errorf("S3 endpoint %s failed TLS certificate verification; set storage.s3_ca_bundle_path to the CA that signed it: %w", cfg.Endpoint, err)
+		}
 		return nil, fmt.Errorf("failed to verify S3 bucket: %w", err)
 	}
 
@@ -134,19 +159,51 @@ func (s *S3Storage) GetBasePath() string {
 	return s.localCache
 }
 
-// fullKey returns the full S3 key for a path
-func (s *S3Storage) fullKey(path string) string {
-	// If path already has prefix, don't add it again
-	if strings.HasPrefix(path, s.prefix) {
-		return path
+// objectKey returns the S3 key of a storage path. All keys are built here:
+// paths inside the local cache (such as repository git paths) are taken
+// relative to it, and paths are cleaned with slash semantics, so "a/b",
+// "/a/b/", "a//b" and the cache path of a/b name the same object. Keys are not
+// escaped; the SDK escapes them in requests, except for copy sources.
+func (s *S3Storage) objectKey(p string) string {
+	if filepath.IsAbs(p) {
+		if rel, err := filepath.Rel(s.localCache, p); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			p = rel
+		}
+	}
+	return s.prefix + strings.Trim(pathpkg.Clean("/"+filepath.ToSlash(p)), "/")
+}
+
+// dirKey returns the key prefix of the objects inside a storage path. A
+// directory created with CreateDirectory has a zero-byte marker object at
+// exactly this key.
+func (s *S3Storage) dirKey(p string) string {
+	key := s.objectKey(p)
+	if key == "" || strings.HasSuffix(key, "/") {
+		return key
+	}
+	return key + "/"
+}
+
+// keyName returns the last element of a key, ignoring the trailing slash of
+// directory markers
+func keyName(key string) string {
+	return pathpkg.Base(strings.TrimSuffix(key, "/"))
+}
+
+// copySource returns the URL-encoded CopySource of an object. S3 decodes it
+// like a query value, so "+" is escaped as well as spaces.
+func (s *S3Storage) copySource(key string) string {
+	segments := strings.Split(s.bucket+"/"+key, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "+", "%2B")
 	}
-	return s.prefix + path
+	return strings.Join(segments, "/")
 }
 
 // Exists checks if an object or prefix exists in S3
 func (s *S3Storage) Exists(path string) (bool, error) {
 	ctx := context.Background()
-	key := s.fullKey(path)
+	key := s.objectKey(path)
 
 	// First try as object
 	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
@@ -173,11 +230,7 @@ func (s *S3Storage) Exists(path string) (bool, error) {
 // IsDir checks if the path is a "directory" (prefix with objects beneath it)
 func (s *S3Storage) IsDir(path string) (bool, error) {
 	ctx := context.Background()
-	key := s.fullKey(path)
-
-	if !strings.HasSuffix(key, "/") {
-		key += "/"
-	}
+	key := s.dirKey(path)
 
 	result, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
 		Bucket:  aws.String(s.bucket),
@@ -194,11 +247,7 @@ func (s *S3Storage) IsDir(path string) (bool, error) {
 // CreateDirectory creates a "directory" in S3 (a zero-byte object with trailing /)
 func (s *S3Storage) CreateDirectory(path string) error {
 	ctx := context.Background()
-	key := s.fullKey(path)
-
-	if !strings.HasSuffix(key, "/") {
-		key += "/"
-	}
+	key := s.dirKey(path)
 
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(s.bucket),
@@ -215,11 +264,7 @@ func (s *S3Storage) CreateDirectory(path string) error {
 // DeleteDirectory removes all objects with the given prefix
 func (s *S3Storage) DeleteDirectory(path string) error {
 	ctx := context.Background()
-	key := s.fullKey(path)
-
-	if !strings.HasSuffix(key, "/") {
-		key += "/"
-	}
+	key := s.dirKey(path)
 
 	// List all objects with prefix
 	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
@@ -271,7 +316,7 @@ func (s *S3Storage) DeleteDirectory(path string) error {
 // ReadFile reads the entire content of an object
 func (s *S3Storage) ReadFile(path string) ([]byte, error) {
 	ctx := context.Background()
-	key := s.fullKey(path)
+	key := s.objectKey(path)
 
 	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
@@ -292,7 +337,7 @@ func (s *S3Storage) ReadFile(path string) ([]byte, error) {
 // WriteFile writes data to an object
 func (s *S3Storage) WriteFile(path string, data []byte) error {
 	ctx := context.Background()
-	key := s.fullKey(path)
+	key := s.objectKey(path)
 
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(s.bucket),
@@ -322,7 +367,7 @@ func (r *s3ReadCloser) Close() error {
 // OpenFile opens an object for reading
 func (s *S3Storage) OpenFile(path string) (io.ReadCloser, error) {
 	ctx := context.Background()
-	key := s.fullKey(path)
+	key := s.objectKey(path)
 
 	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
@@ -339,79 +384,53 @@ func (s *S3Storage) OpenFile(path string) (io.ReadCloser, error) {
 	return &s3ReadCloser{body: result.Body}, nil
 }
 
-// s3WriteCloser implements io.WriteCloser for S3 uploads
-type s3WriteCloser struct {
-	storage *S3Storage
-	key     string
-	buf     *bytes.Buffer
-	closed  bool
-	mu      sync.Mutex
+// CreateFile creates a new object for writing. The object is uploaded in
+// parts while it is written and appears when the writer is closed.
+func (s *S3Storage) CreateFile(path string) (io.WriteCloser, error) {
+	return newS3Upload(s, s.objectKey(path)), nil
 }
 
-func (w *s3WriteCloser) Write(p []byte) (n int, err error) {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
-	if w.closed {
-		return 0, errors.New("write to closed file")
-	}
-	return w.buf.Write(p)
-}
+// AppendFile opens an object for appending. The existing content is copied
+// on the server side rather than downloaded, except for objects smaller than
+// a part, and the object is replaced when the writer is closed.
+func (s *S3Storage) AppendFile(path string) (io.WriteCloser, error) {
+	ctx := context.Background()
+	key := s.objectKey(path)
 
-func (w *s3WriteCloser) Close() error {
-	w.mu.Lock()
-	defer w.mu.Unlock()
+	upload := newS3Upload(s, key)
 
-	if w.closed {
-		return nil
-	}
-	w.closed = true
-
-	ctx := context.Background()
-	_, err := w.storage.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(w.storage.bucket),
-		Key:    aws.String(w.key),
-		Body:   bytes.NewReader(w.buf.Bytes()),
+	head, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to upload file: %w", err)
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return upload, nil
+		}
+		return nil, fmt.Errorf("failed to stat file for append: %w", err)
 	}
 
-	return nil
-}
-
-// CreateFile creates a new object for writing
-func (s *S3Storage) CreateFile(path string) (io.WriteCloser, error) {
-	key := s.fullKey(path)
-	return &s3WriteCloser{
-		storage: s,
-		key:     key,
-		buf:     bytes.NewBuffer(nil),
-	}, nil
-}
-
-// AppendFile opens an object for appending (reads existing content first)
-func (s *S3Storage) AppendFile(path string) (io.WriteCloser, error) {
-	key := s.fullKey(path)
-
-	// Read existing content
-	existingData, err := s.ReadFile(path)
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		return nil, fmt.Errorf("failed to read existing file for append: %w", err)
+	size := aws.ToInt64(head.ContentLength)
+	if size < s3MinPartSize {
+		existingData, err := s.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read existing file for append: %w", err)
+		}
+		upload.buf.Write(existingData)
+		return upload, nil
 	}
 
-	buf := bytes.NewBuffer(existingData)
-	return &s3WriteCloser{
-		storage: s,
-		key:     key,
-		buf:     buf,
-	}, nil
+	if err := upload.copyExisting(ctx, size); err != nil {
+		return nil, fmt.Errorf("failed to copy existing file for append: %w", err)
+	}
+	return upload, nil
 }
 
 // DeleteFile removes an object
 func (s *S3Storage) DeleteFile(path string) error {
 	ctx := context.Background()
-	key := s.fullKey(path)
+	key := s.objectKey(path)
 
 	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: aws.String(s.bucket),
@@ -427,14 +446,12 @@ func (s *S3Storage) DeleteFile(path string) error {
 // CopyFile copies an object from source to destination
 func (s *S3Storage) CopyFile(src, dst string) error {
 	ctx := context.Background()
-	srcKey := s.fullKey(src)
-	dstKey := s.fullKey(dst)
-
-	copySource := fmt.Sprintf("%s/%s", s.bucket, srcKey)
+	srcKey := s.objectKey(src)
+	dstKey := s.objectKey(dst)
 
 	_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
 		Bucket:     aws.String(s.bucket),
-		CopySource: aws.String(copySource),
+		CopySource: aws.String(s.copySource(srcKey)),
 		Key:        aws.String(dstKey),
 	})
 	if err != nil {
@@ -475,7 +492,7 @@ func (f *s3FileInfo) Sys() interface{}   { return nil }
 // Stat returns file info for an object
 func (s *S3Storage) Stat(path string) (fs.FileInfo, error) {
 	ctx := context.Background()
-	key := s.fullKey(path)
+	key := s.objectKey(path)
 
 	// Try as object first
 	result, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
@@ -484,7 +501,7 @@ func (s *S3Storage) Stat(path string) (fs.FileInfo, error) {
 	})
 	if err == nil {
 		return &s3FileInfo{
-			name:    filepath.Base(path),
+			name:    keyName(key),
 			size:    aws.ToInt64(result.ContentLength),
 			modTime: aws.ToTime(result.LastModified),
 			isDir:   false,
@@ -498,7 +515,7 @@ func (s *S3Storage) Stat(path string) (fs.FileInfo, error) {
 	}
 	if isDir {
 		return &s3FileInfo{
-			name:    filepath.Base(path),
+			name:    keyName(key),
 			size:    0,
 			modTime: time.Now(),
 			isDir:   true,
@@ -511,11 +528,7 @@ func (s *S3Storage) Stat(path string) (fs.FileInfo, error) {
 // ListFiles returns a list of file paths in a "directory"
 func (s *S3Storage) ListFiles(path string) ([]string, error) {
 	ctx := context.Background()
-	key := s.fullKey(path)
-
-	if !strings.HasSuffix(key, "/") {
-		key += "/"
-	}
+	key := s.dirKey(path)
 
 	var files []string
 
@@ -531,9 +544,10 @@ func (s *S3Storage) ListFiles(path string) ([]string, error) {
 		}
 
 		for _, obj := range page.Contents {
-			// Remove the prefix and base key to get relative path
+			// Remove the prefix and base key to get relative path;
+			// directory markers are not files
 			relPath := strings.TrimPrefix(aws.ToString(obj.Key), key)
-			if relPath != "" {
+			if relPath != "" && !strings.HasSuffix(relPath, "/") {
 				files = append(files, relPath)
 			}
 		}
@@ -553,57 +567,65 @@ func (e *s3DirEntry) IsDir() bool                { return e.info.isDir }
 func (e *s3DirEntry) Type() fs.FileMode          { return e.info.Mode().Type() }
 func (e *s3DirEntry) Info() (fs.FileInfo, error) { return e.info, nil }
 
-// ReadDir reads a "directory" and returns directory entries
+// ReadDir reads a "directory" and returns directory entries. Directory
+// markers are not listed as files; a directory that only has its marker is
+// empty, and one with neither marker nor objects does not exist.
 func (s *S3Storage) ReadDir(path string) ([]fs.DirEntry, error) {
 	ctx := context.Background()
-	key := s.fullKey(path)
+	key := s.dirKey(path)
 
-	if !strings.HasSuffix(key, "/") {
-		key += "/"
-	}
-
-	result, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
 		Bucket:    aws.String(s.bucket),
 		Prefix:    aws.String(key),
 		Delimiter: aws.String("/"),
 	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to read directory: %w", err)
-	}
 
 	var entries []fs.DirEntry
+	found := key == s.prefix // The root always exists
 
-	// Add directories (common prefixes)
-	for _, prefix := range result.CommonPrefixes {
-		name := strings.TrimPrefix(aws.ToString(prefix.Prefix), key)
-		name = strings.TrimSuffix(name, "/")
-		if name != "" {
-			entries = append(entries, &s3DirEntry{
-				info: &s3FileInfo{
-					name:    name,
-					size:    0,
-					modTime: time.Now(),
-					isDir:   true,
-				},
-			})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory: %w", err)
 		}
-	}
 
-	// Add files
-	for _, obj := range result.Contents {
-		name := strings.TrimPrefix(aws.ToString(obj.Key), key)
-		if name != "" && !strings.Contains(name, "/") {
-			entries = append(entries, &s3DirEntry{
-				info: &s3FileInfo{
-					name:    name,
-					size:    aws.ToInt64(obj.Size),
-					modTime: aws.ToTime(obj.LastModified),
-					isDir:   false,
-				},
-			})
+		// Add directories (common prefixes)
+		for _, prefix := range page.CommonPrefixes {
+			found = true
+			name := strings.TrimSuffix(strings.TrimPrefix(aws.ToString(prefix.Prefix), key), "/")
+			if name != "" {
+				entries = append(entries, &s3DirEntry{
+					info: &s3FileInfo{
+						name:    name,
+						size:    0,
+						modTime: time.Now(),
+						isDir:   true,
+					},
+				})
+			}
+		}
+
+		// Add files, skipping the marker of the directory itself
+		for _, obj := range page.Contents {
+			found = true
+			name := strings.TrimPrefix(aws.ToString(obj.Key), key)
+			if name != "" && !strings.Contains(name, "/") {
+				entries = append(entries, &s3DirEntry{
+					info: &s3FileInfo{
+						name:    name,
+						size:    aws.ToInt64(obj.Size),
+						modTime: aws.ToTime(obj.LastModified),
+						isDir:   false,
+					},
+				})
+			}
 		}
 	}
 
+	if !found {
+		return nil, os.ErrNotExist
+	}
+
 	// Sort entries by name
 	sort.Slice(entries, func(i, j int) bool {
 		return entries[i].Name() < entries[j].Name()
@@ -615,11 +637,7 @@ func (s *S3Storage) ReadDir(path string) ([]fs.DirEntry, error) {
 // Walk walks the file tree rooted at root, calling fn for each file or directory
 func (s *S3Storage) Walk(root string, fn filepath.WalkFunc) error {
 	ctx := context.Background()
-	key := s.fullKey(root)
-
-	if !strings.HasSuffix(key, "/") {
-		key += "/"
-	}
+	key := s.dirKey(root)
 
 	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
 		Bucket: aws.String(s.bucket),
@@ -634,10 +652,10 @@ func (s *S3Storage) Walk(root string, fn filepath.WalkFunc) error {
 
 		for _, obj := range page.Contents {
 			objKey := aws.ToString(obj.Key)
-			relPath := strings.TrimPrefix(objKey, s.prefix)
+			relPath := strings.TrimSuffix(strings.TrimPrefix(objKey, s.prefix), "/")
 
 			info := &s3FileInfo{
-				name:    filepath.Base(relPath),
+				name:    keyName(objKey),
 				size:    aws.ToInt64(obj.Size),
 				modTime: aws.ToTime(obj.LastModified),
 				isDir:   strings.HasSuffix(objKey, "/"),
@@ -683,11 +701,7 @@ func (s *S3Storage) Size(path string) (int64, error) {
 // GetDiskUsage returns the total size of all objects under a prefix
 func (s *S3Storage) GetDiskUsage(path string) (int64, error) {
 	ctx := context.Background()
-	key := s.fullKey(path)
-
-	if !strings.HasSuffix(key, "/") {
-		key += "/"
-	}
+	key := s.dirKey(path)
 
 	var totalSize int64
 
@@ -711,6 +725,18 @@ func (s *S3Storage) GetDiskUsage(path string) (int64, error) {
 }
 
 // SyncToRemote syncs a local directory to S3
+// ImportDirectory copies a local directory tree into the local cache at path
+// and uploads it to S3
+func (s *S3Storage) ImportDirectory(localPath, path string) error {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.localCache, path)
+	}
+	if err := copyLocalTree(localPath, path); err != nil {
+		return err
+	}
+	return s.SyncToRemote(path)
+}
+
 // This implements write-through for git operations
 func (s *S3Storage) SyncToRemote(localPath string) error {
 	ctx := context.Background()
@@ -738,9 +764,7 @@ func (s *S3Storage) SyncToRemote(localPath string) error {
 			return fmt.Errorf("failed to get relative file path: %w", err)
 		}
 
-		s3Key := s.prefix + relPath + "/" + fileRelPath
-		// Normalize path separators for S3
-		s3Key = strings.ReplaceAll(s3Key, string(filepath.Separator), "/")
+		s3Key := s.objectKey(filepath.Join(relPath, fileRelPath))
 
 		// Read the local file
 		data, err := os.ReadFile(path)
@@ -0,0 +1,472 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is an in-memory S3 bucket serving path-style requests. Listings
+// return at most pageSize entries per page, so callers must paginate.
+type fakeS3 struct {
+	bucket   string
+	pageSize int
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+// newFakeS3 returns an S3Storage backed by a fake bucket with prefix "repos/"
+func newFakeS3(t *testing.T) (*S3Storage, *fakeS3) {
+	t.Helper()
+	fake := &fakeS3{bucket: "stasis", pageSize: 2, objects: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	s, err := NewS3Storage(context.Background(), S3Config{
+		Bucket:       fake.bucket,
+		Region:       "us-east-1",
+		AccessKey:    "access",
+		SecretKey:    "secret",
+		Endpoint:     server.URL,
+		UsePathStyle: true,
+		Prefix:       "repos",
+		LocalCache:   t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+	return s, fake
+}
+
+// keys returns the keys of the bucket in order
+func (f *fakeS3) keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.objects))
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+type fakeS3Object struct {
+	Key          string
+	Size         int
+	LastModified string
+}
+
+type fakeS3Prefix struct {
+	Prefix string
+}
+
+type fakeS3Listing struct {
+	XMLName               xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name                  string
+	Prefix                string
+	KeyCount              int
+	IsTruncated           bool
+	NextContinuationToken string         `xml:",omitempty"`
+	Contents              []fakeS3Object `xml:"Contents"`
+	CommonPrefixes        []fakeS3Prefix `xml:"CommonPrefixes"`
+}
+
+type fakeS3Delete struct {
+	Objects []struct{ Key string } `xml:"Object"`
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		f.error(w, r, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+	case key == "" && r.Method == http.MethodGet:
+		f.list(w, r.URL.Query())
+	case key == "" && r.Method == http.MethodPost && r.URL.Query().Has("delete"):
+		var req fakeS3Delete
+		if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+			f.error(w, r, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		for _, obj := range req.Objects {
+			delete(f.objects, obj.Key)
+		}
+		io.WriteString(w, `<DeleteResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></DeleteResult>`)
+	case r.Method == http.MethodHead || r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			f.error(w, r, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		checksum := binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(data))
+		w.Header().Set("X-Amz-Checksum-Crc32", base64.StdEncoding.EncodeToString(checksum))
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		// S3 decodes the copy source like a query value
+		source, err := url.QueryUnescape(r.Header.Get("X-Amz-Copy-Source"))
+		if err != nil {
+			f.error(w, r, http.StatusBadRequest, "InvalidArgument")
+			return
+		}
+		data, ok := f.objects[strings.TrimPrefix(source, f.bucket+"/")]
+		if !strings.HasPrefix(source, f.bucket+"/") || !ok {
+			f.error(w, r, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		f.objects[key] = slices.Clone(data)
+		io.WriteString(w, `<CopyObjectResult><LastModified>2026-01-01T00:00:00.000Z</LastModified></CopyObjectResult>`)
+	case r.Method == http.MethodPut:
+		data, err := readS3Body(r)
+		if err != nil {
+			f.error(w, r, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		f.objects[key] = data
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.error(w, r, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// readS3Body reads the body of an upload, decoding the aws-chunked encoding
+// the SDK uses over TLS to send a trailing checksum
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") {
+		return io.ReadAll(r.Body)
+	}
+	var data []byte
+	body := bufio.NewReader(r.Body)
+	for {
+		line, err := body.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			// Trailers follow; the checksum is not verified
+			return data, nil
+		}
+		chunk := make([]byte, size+2)
+		if _, err := io.ReadFull(body, chunk); err != nil {
+			return nil, err
+		}
+		data = append(data, chunk[:size]...)
+	}
+}
+
+// list serves ListObjectsV2, grouping keys by the delimiter
+func (f *fakeS3) list(w http.ResponseWriter, query url.Values) {
+	prefix, delimiter, after := query.Get("prefix"), query.Get("delimiter"), query.Get("continuation-token")
+	pageSize := f.pageSize
+	if maxKeys, err := strconv.Atoi(query.Get("max-keys")); err == nil && maxKeys < pageSize {
+		pageSize = maxKeys
+	}
+
+	// Entries are keys, or the common prefixes grouping them
+	var entries []string
+	grouped := map[string]bool{}
+	for key := range f.objects {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		entry := key
+		if i := strings.Index(key[len(prefix):], delimiter); delimiter != "" && i >= 0 {
+			entry = key[:len(prefix)+i+len(delimiter)]
+			grouped[entry] = true
+		}
+		if entry > after && !slices.Contains(entries, entry) {
+			entries = append(entries, entry)
+		}
+	}
+	slices.Sort(entries)
+
+	result := fakeS3Listing{Name: f.bucket, Prefix: prefix}
+	if len(entries) > pageSize {
+		entries = entries[:pageSize]
+		result.IsTruncated = true
+		result.NextContinuationToken = entries[len(entries)-1]
+	}
+	for _, entry := range entries {
+		if grouped[entry] {
+			result.CommonPrefixes = append(result.CommonPrefixes, fakeS3Prefix{Prefix: entry})
+		} else {
+			result.Contents = append(result.Contents, fakeS3Object{Key: entry, Size: len(f.objects[entry]), LastModified: "2026-01-01T00:00:00.000Z"})
+		}
+	}
+	result.KeyCount = len(entries)
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+// error writes an S3 error response
+func (f *fakeS3) error(w http.ResponseWriter, r *http.Request, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		io.WriteString(w, "<Error><Code>"+code+"</Code></Error>")
+	}
+}
+
+func TestObjectKey(t *testing.T) {
+	s := &S3Storage{prefix: "repos/", localCache: "/var/cache/stasis"}
+	tests := []struct {
+		path string
+		key  string
+		dir  string
+	}{
+		{path: "", key: "repos/", dir: "repos/"},
+		{path: "/", key: "repos/", dir: "repos/"},
+		{path: "alice/repo.git", key: "repos/alice/repo.git", dir: "repos/alice/repo.git/"},
+		{path: "/alice/repo.git/", key: "repos/alice/repo.git", dir: "repos/alice/repo.git/"},
+		{path: "alice//repo.git/./objects/..", key: "repos/alice/repo.git", dir: "repos/alice/repo.git/"},
+		{path: "../../alice", key: "repos/alice", dir: "repos/alice/"},
+		// Paths inside the local cache map to the keys SyncToRemote uploads to
+		{path: "/var/cache/stasis/alice/repo.git", key: "repos/alice/repo.git", dir: "repos/alice/repo.git/"},
+		{path: "/var/cache/stasis", key: "repos/", dir: "repos/"},
+		{path: "/var/cache/stasis-old/alice", key: "repos/var/cache/stasis-old/alice", dir: "repos/var/cache/stasis-old/alice/"},
+		// A path starting with the prefix is still prefixed
+		{path: "repos/alice", key: "repos/repos/alice", dir: "repos/repos/alice/"},
+		{path: "a b/c+d%e&f?g#h/ünïcødé", key: "repos/a b/c+d%e&f?g#h/ünïcødé", dir: "repos/a b/c+d%e&f?g#h/ünïcødé/"},
+	}
+	for _, tt := range tests {
+		if got := s.objectKey(tt.path); got != tt.key {
+			t.Errorf("objectKey(%q) = %q, want %q", tt.path, got, tt.key)
+		}
+		if got := s.dirKey(tt.path); got != tt.dir {
+			t.Errorf("dirKey(%q) = %q, want %q", tt.path, got, tt.dir)
+		}
+	}
+
+	// Without a prefix, the root is the empty key
+	s.prefix = ""
+	if got := s.dirKey("/"); got != "" {
+		t.Errorf("dirKey(/) without a prefix = %q, want \"\"", got)
+	}
+	if got := s.dirKey("a"); got != "a/" {
+		t.Errorf("dirKey(a) without a prefix = %q, want \"a/\"", got)
+	}
+}
+
+func TestCopySource(t *testing.T) {
+	s := &S3Storage{bucket: "stasis"}
+	tests := map[string]string{
+		"repos/alice/README.md":       "stasis/repos/alice/README.md",
+		"repos/a b/c+d.txt":           "stasis/repos/a%20b/c%2Bd.txt",
+		"repos/100%/what?#1&2=3.txt":  "stasis/repos/100%25/what%3F%231&2=3.txt",
+		"repos/ünïcødé":               "stasis/repos/%C3%BCn%C3%AFc%C3%B8d%C3%A9",
+		"repos/alice/repo.git/HEAD":   "stasis/repos/alice/repo.git/HEAD",
+		"repos/dir/":                  "stasis/repos/dir/",
+		"repos/semi;colon,comma.json": "stasis/repos/semi%3Bcolon%2Ccomma.json",
+	}
+	for key, want := range tests {
+		if got := s.copySource(key); got != want {
+			t.Errorf("copySource(%q) = %q, want %q", key, got, want)
+		}
+		if decoded, err := url.QueryUnescape(s.copySource(key)); err != nil || decoded != "stasis/"+key {
+			t.Errorf("copySource(%q) decodes to %q (%v)", key, decoded, err)
+		}
+	}
+}
+
+// Objects with awkward names must round-trip through every operation
+func TestS3StorageAwkwardNames(t *testing.T) {
+	s, fake := newFakeS3(t)
+	names := []string{
+		"plain.txt",
+		"with space.txt",
+		"a+b.txt",
+		"100%.txt",
+		"q&a.txt",
+		"what?.txt",
+		"#hash.txt",
+		"ünïcødé.txt",
+		"dir with space/c++/notes.md",
+	}
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			content := []byte("content of " + name)
+			w, err := s.CreateFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write(content)
+			if err := w.Close(); err != nil {
+				t.Fatalf("CreateFile: %v", err)
+			}
+
+			if exists, err := s.Exists(name); err != nil || !exists {
+				t.Errorf("Exists = %v, %v, want true", exists, err)
+			}
+			info, err := s.Stat(name)
+			if err != nil {
+				t.Fatalf("Stat: %v", err)
+			}
+			if info.Name() != filepath.Base(name) || info.Size() != int64(len(content)) || info.IsDir() {
+				t.Errorf("Stat = %s (%d bytes, dir %v), want %s (%d bytes)", info.Name(), info.Size(), info.IsDir(), filepath.Base(name), len(content))
+			}
+			if data, err := s.ReadFile(name); err != nil || string(data) != string(content) {
+				t.Errorf("ReadFile = %q, %v", data, err)
+			}
+
+			moved := "moved/" + name
+			if err := s.MoveFile(name, moved); err != nil {
+				t.Fatalf("MoveFile: %v", err)
+			}
+			if data, err := s.ReadFile(moved); err != nil || string(data) != string(content) {
+				t.Errorf("ReadFile after move = %q, %v", data, err)
+			}
+			if exists, _ := s.Exists(name); exists {
+				t.Error("source exists after move")
+			}
+
+			if err := s.DeleteFile(moved); err != nil {
+				t.Fatalf("DeleteFile: %v", err)
+			}
+			if exists, _ := s.Exists(moved); exists {
+				t.Error("exists after delete")
+			}
+		})
+	}
+
+	if keys := fake.keys(); len(keys) != 0 {
+		t.Errorf("left %q in the bucket", keys)
+	}
+}
+
+// entryNames returns the names of entries, with a trailing slash for
+// directories
+func entryNames(entries []fs.DirEntry) []string {
+	names := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestS3StorageReadDir(t *testing.T) {
+	s, _ := newFakeS3(t)
+	for _, dir := range []string{"empty", "full", "full/nested"} {
+		if err := s.CreateDirectory(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Spread over several listing pages
+	for _, file := range []string{"full/a.txt", "full/b c.txt", "full/d+e.txt", "full/nested/f.txt", "full/sub/g.txt", "full/z.txt"} {
+		if err := s.WriteFile(file, []byte(file)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "empty", want: []string{}},
+		{path: "empty/", want: []string{}},
+		{path: "full", want: []string{"a.txt", "b c.txt", "d+e.txt", "nested/", "sub/", "z.txt"}},
+		{path: "full/nested", want: []string{"f.txt"}},
+		{path: "/", want: []string{"empty/", "full/"}},
+		{path: filepath.Join(s.GetBasePath(), "full", "sub"), want: []string{"g.txt"}},
+	}
+	for _, tt := range tests {
+		entries, err := s.ReadDir(tt.path)
+		if err != nil {
+			t.Errorf("ReadDir(%q): %v", tt.path, err)
+			continue
+		}
+		if got := entryNames(entries); !slices.Equal(got, tt.want) {
+			t.Errorf("ReadDir(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	if _, err := s.ReadDir("missing"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadDir(missing) = %v, want %v", err, os.ErrNotExist)
+	}
+
+	files, err := s.ListFiles("full")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a.txt", "b c.txt", "d+e.txt", "nested/f.txt", "sub/g.txt", "z.txt"}
+	if !slices.Equal(files, want) {
+		t.Errorf("ListFiles(full) = %q, want %q", files, want)
+	}
+
+	var walked []string
+	err = s.Walk("full", func(path string, info fs.FileInfo, err error) error {
+		if !info.IsDir() {
+			walked = append(walked, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"full/a.txt", "full/b c.txt", "full/d+e.txt", "full/nested/f.txt", "full/sub/g.txt", "full/z.txt"}
+	if !slices.Equal(walked, want) {
+		t.Errorf("Walk(full) visited files %q, want %q", walked, want)
+	}
+}
+
+// Repository git paths are local cache paths and must reach the objects
+// uploaded for them
+func TestS3StorageGitPaths(t *testing.T) {
+	s, fake := newFakeS3(t)
+	for _, file := range []string{"alice/repo.git/HEAD", "alice/repo.git/objects/pack/p.pack", "alice/other.git/HEAD"} {
+		if err := s.WriteFile(file, []byte("0123456789")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitPath := s.GetRepoPath("alice", "repo")
+
+	if size, err := s.GetDiskUsage(gitPath); err != nil || size != 20 {
+		t.Errorf("GetDiskUsage = %d, %v, want 20", size, err)
+	}
+	if err := s.DeleteDirectory(gitPath); err != nil {
+		t.Fatal(err)
+	}
+	if keys := fake.keys(); !slices.Equal(keys, []string{"repos/alice/other.git/HEAD"}) {
+		t.Errorf("bucket has %q after deleting %s", keys, gitPath)
+	}
+}
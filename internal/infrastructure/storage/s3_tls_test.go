@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Environment of the tests against a real S3 server, such as a MinIO
+// container serving TLS with a certificate of its own CA:
+//
+//	STASIS_TEST_S3_ENDPOINT=https://localhost:9000
+//	STASIS_TEST_S3_CA_BUNDLE=/path/to/minio/certs/CAs/ca.crt
+//	STASIS_TEST_S3_ACCESS_KEY=minioadmin STASIS_TEST_S3_SECRET_KEY=minioadmin
+//	STASIS_TEST_S3_BUCKET=stasis-test
+//
+// The tests are skipped when STASIS_TEST_S3_ENDPOINT is not set.
+const (
+	s3EndpointEnv  = "STASIS_TEST_S3_ENDPOINT"
+	s3CABundleEnv  = "STASIS_TEST_S3_CA_BUNDLE"
+	s3AccessKeyEnv = "STASIS_TEST_S3_ACCESS_KEY"
+	s3SecretKeyEnv = "STASIS_TEST_S3_SECRET_KEY"
+	s3BucketEnv    = "STASIS_TEST_S3_BUCKET"
+)
+
+// minioConfig returns the configuration of the test S3 server, with the
+// objects of the test under a prefix of their own, or skips the test
+func minioConfig(t *testing.T) S3Config {
+	t.Helper()
+	endpoint := os.Getenv(s3EndpointEnv)
+	if endpoint == "" {
+		t.Skip("no S3 server, set " + s3EndpointEnv + " to run against a MinIO container")
+	}
+	bucket := os.Getenv(s3BucketEnv)
+	if bucket == "" {
+		bucket = "stasis-test"
+	}
+	return S3Config{
+		Bucket:       bucket,
+		Region:       "us-east-1",
+		AccessKey:    os.Getenv(s3AccessKeyEnv),
+		SecretKey:    os.Getenv(s3SecretKeyEnv),
+		Endpoint:     endpoint,
+		UsePathStyle: true,
+		Prefix:       "test/" + strings.ReplaceAll(t.Name(), "/", "-"),
+		LocalCache:   t.TempDir(),
+		CABundlePath: os.Getenv(s3CABundleEnv),
+	}
+}
+
+// newTLSFakeS3 serves a fake bucket over TLS with a self-signed certificate
+// and returns a configuration for it and the path of a PEM bundle holding
+// the certificate
+func newTLSFakeS3(t *testing.T) (S3Config, string) {
+	t.Helper()
+	fake := &fakeS3{bucket: "stasis", pageSize: 100, objects: map[string][]byte{}}
+	server := httptest.NewTLSServer(fake)
+	t.Cleanup(server.Close)
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return S3Config{
+		Bucket:       fake.bucket,
+		Region:       "us-east-1",
+		AccessKey:    "access",
+		SecretKey:    "secret",
+		Endpoint:     server.URL,
+		UsePathStyle: true,
+		LocalCache:   t.TempDir(),
+	}, bundle
+}
+
+func TestS3TLSVerification(t *testing.T) {
+	cfg, bundle := newTLSFakeS3(t)
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		_, err := NewS3Storage(context.Background(), cfg)
+		if err == nil {
+			t.Fatal("NewS3Storage trusted a self-signed certificate")
+		}
+		if !isTLSVerificationError(err) || !strings.Contains(err.Error(), "s3_ca_bundle_path") {
+			t.Errorf("NewS3Storage: %v, want a certificate verification error naming s3_ca_bundle_path", err)
+		}
+	})
+
+	t.Run("CA bundle", func(t *testing.T) {
+		cfg := cfg
+		cfg.CABundlePath = bundle
+		s, err := NewS3Storage(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("NewS3Storage with the CA bundle: %v", err)
+		}
+		if err := s.WriteFile("alice/notes.txt", []byte("notes")); err != nil {
+			t.Fatal(err)
+		}
+		if data, err := s.ReadFile("alice/notes.txt"); err != nil || string(data) != "notes" {
+			t.Errorf("ReadFile = %q, %v", data, err)
+		}
+	})
+
+	t.Run("verification disabled", func(t *testing.T) {
+		cfg := cfg
+		cfg.InsecureSkipVerify = true
+		if _, err := NewS3Storage(context.Background(), cfg); err != nil {
+			t.Fatalf("NewS3Storage without verification: %v", err)
+		}
+	})
+}
+
+func TestLoadCABundle(t *testing.T) {
+	_, bundle := newTLSFakeS3(t)
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "not.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if pool, err := loadCABundle(bundle); err != nil || pool == nil {
+		t.Errorf("loadCABundle of a certificate: %v", err)
+	}
+	if _, err := loadCABundle(filepath.Join(dir, "missing.pem")); err == nil || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("loadCABundle of a missing file: %v, want not found", err)
+	}
+	if _, err := loadCABundle(notPEM); err == nil || !strings.Contains(err.Error(), "no valid PEM certificates") {
+		t.Errorf("loadCABundle of a file without certificates: %v", err)
+	}
+}
+
+func TestIsTLSVerificationError(t *testing.T) {
+	if isTLSVerificationError(errors.New("connection refused")) {
+		t.Error("a connection error counts as a certificate error")
+	}
+	if isTLSVerificationError(nil) {
+		t.Error("nil counts as a certificate error")
+	}
+}
+
+func TestMinIOTLS(t *testing.T) {
+	cfg := minioConfig(t)
+	if !strings.HasPrefix(cfg.Endpoint, "https://") || cfg.CABundlePath == "" {
+		t.Skip("the MinIO container does not serve TLS with a CA bundle, set " + s3CABundleEnv)
+	}
+
+	s, err := NewS3Storage(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewS3Storage with the CA bundle: %v", err)
+	}
+	if err := s.WriteFile("alice/notes.txt", []byte("notes")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.DeleteFile("alice/notes.txt") })
+	if data, err := s.ReadFile("alice/notes.txt"); err != nil || string(data) != "notes" {
+		t.Errorf("ReadFile = %q, %v", data, err)
+	}
+
+	// Without the bundle the CA of the container is not trusted
+	cfg.CABundlePath = ""
+	if _, err := NewS3Storage(context.Background(), cfg); !isTLSVerificationError(err) {
+		t.Errorf("NewS3Storage without the CA bundle: %v, want a certificate verification error", err)
+	}
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/bravo68web/stasis/pkg/logger"
+	"github.com/bravo68web/stasis/pkg/metrics"
+)
+
+// recordS3Metrics is an API option recording every operation of the S3
+// client in the S3 metrics. It runs in the initialize step so an operation is
+// counted once regardless of how many attempts the retryer makes.
+func recordS3Metrics(stack *middleware.Stack) error {
+	// The stack of an operation is named after it
+	operation := stack.ID()
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("StasisS3Metrics",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			out, metadata, err := next.HandleInitialize(ctx, in)
+
+			retries := 0
+			if results, ok := retry.GetAttemptResults(metadata); ok && len(results.Results) > 1 {
+				retries = len(results.Results) - 1
+			}
+			metrics.ObserveS3Request(operation, retries, err)
+
+			return out, metadata, err
+		},
+	), middleware.Before)
+}
+
+// newS3HTTPClient builds the HTTP client used by the S3 SDK with the configured
+// TLS trust, connection pool and timeout settings
+func newS3HTTPClient(cfg S3Config, log *logger.Logger) (*awshttp.BuildableClient, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.CABundlePath != "" {
+		pool, err := loadCABundle(cfg.CABundlePath)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.InsecureSkipVerify {
+		log.Warn("S3 TLS certificate verification is DISABLED - connections to the object store can be intercepted; never use this in production",
+			logger.String("endpoint", cfg.Endpoint),
+		)
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	client := awshttp.NewBuildableClient().WithTransportOptions(func(tr *http.Transport) {
+		tr.TLSClientConfig = tlsConfig
+		if cfg.MaxIdleConns > 0 {
+			tr.MaxIdleConns = cfg.MaxIdleConns
+		}
+		if cfg.MaxIdleConnsPerHost > 0 {
+			tr.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+		}
+		// Only the wait for the response is bounded, per attempt: a timeout
+		// of the whole client would cut off streams of large objects
+		if cfg.RequestTimeout > 0 {
+			tr.ResponseHeaderTimeout = cfg.RequestTimeout
+		}
+	})
+
+	return client, nil
+}
+
+// newS3Retryer builds the retryer used by the S3 SDK from the configured
+// retry count and backoff
+func newS3Retryer(cfg S3Config) func() aws.Retryer {
+	return func() aws.Retryer {
+		return retry.NewStandard(func(o *retry.StandardOptions) {
+			o.MaxAttempts = cfg.MaxRetries + 1
+			if cfg.RetryMaxBackoff > 0 {
+				o.MaxBackoff = cfg.RetryMaxBackoff
+			}
+		})
+	}
+}
+
+// loadCABundle returns the system cert pool extended with the certificates in a PEM bundle
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read S3 CA bundle %s: %w", path, err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("S3 CA bundle %s contains no valid PEM certificates", path)
+	}
+
+	return pool, nil
+}
+
+// isTLSVerificationError reports whether err was caused by a failed certificate check
+func isTLSVerificationError(err error) bool {
+	var (
+		verifyErr   *tls.CertificateVerificationError
+		unknownErr  x509.UnknownAuthorityError
+		invalidErr  x509.CertificateInvalidError
+		hostnameErr x509.HostnameError
+	)
+	return errors.As(err, &verifyErr) ||
+		errors.As(err, &unknownErr) ||
+		errors.As(err, &invalidErr) ||
+		errors.As(err, &hostnameErr)
+}
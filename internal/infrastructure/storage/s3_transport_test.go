@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bravo68web/stasis/pkg/metrics"
+)
+
+// metricValue returns the value of a series of the served metrics, 0 if it
+// has not been recorded
+func metricValue(t *testing.T, series string) float64 {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), series+" "); ok {
+			count, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return count
+		}
+	}
+	return 0
+}
+
+// failingS3 answers the first failures object reads with 503 SlowDown, then
+// passes requests on to the fake bucket
+type failingS3 struct {
+	*fakeS3
+	failures atomic.Int32
+}
+
+func (f *failingS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && strings.Count(strings.Trim(r.URL.Path, "/"), "/") > 0 && f.failures.Add(-1) >= 0 {
+		f.error(w, r, http.StatusServiceUnavailable, "SlowDown")
+		return
+	}
+	f.fakeS3.ServeHTTP(w, r)
+}
+
+func TestS3Metrics(t *testing.T) {
+	fake := &failingS3{fakeS3: &fakeS3{bucket: "stasis", pageSize: 100, objects: map[string][]byte{}}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	s, err := NewS3Storage(context.Background(), S3Config{
+		Bucket:          fake.bucket,
+		Region:          "us-east-1",
+		AccessKey:       "access",
+		SecretKey:       "secret",
+		Endpoint:        server.URL,
+		UsePathStyle:    true,
+		LocalCache:      t.TempDir(),
+		MaxRetries:      2,
+		RetryMaxBackoff: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+	if err := s.WriteFile("alice/notes.txt", []byte("notes")); err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		succeeded = `stasis_s3_requests_total{operation="GetObject",result="success"}`
+		failed    = `stasis_s3_requests_total{operation="GetObject",result="error"}`
+		retried   = `stasis_s3_retries_total{operation="GetObject"}`
+	)
+	before := map[string]float64{succeeded: metricValue(t, succeeded), failed: metricValue(t, failed), retried: metricValue(t, retried)}
+
+	// Two failed attempts are retried; three exhaust the retries
+	fake.failures.Store(2)
+	if data, err := s.ReadFile("alice/notes.txt"); err != nil || string(data) != "notes" {
+		t.Fatalf("ReadFile after two failed attempts = %q, %v", data, err)
+	}
+	fake.failures.Store(3)
+	if _, err := s.ReadFile("alice/notes.txt"); err == nil {
+		t.Fatal("ReadFile succeeded although every attempt failed")
+	}
+
+	want := map[string]float64{succeeded: 1, failed: 1, retried: 4}
+	for series, delta := range want {
+		if got := metricValue(t, series) - before[series]; got != delta {
+			t.Errorf("%s grew by %v, want %v", series, got, delta)
+		}
+	}
+}
+
+func TestS3RequestTimeoutBoundsOnlyTheResponseHeaders(t *testing.T) {
+	var delayHeaders atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+		if delayHeaders.Load() {
+			time.Sleep(time.Second)
+			return
+		}
+		// The body takes three times the timeout to arrive
+		w.Header().Set("Content-Length", "6")
+		w.Header().Set("X-Amz-Checksum-Crc32", base64.StdEncoding.EncodeToString(binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE([]byte("stream")))))
+		w.WriteHeader(http.StatusOK)
+		for _, b := range []byte("stream") {
+			w.Write([]byte{b})
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(server.Close)
+	s, err := NewS3Storage(context.Background(), S3Config{
+		Bucket:         "stasis",
+		Region:         "us-east-1",
+		AccessKey:      "access",
+		SecretKey:      "secret",
+		Endpoint:       server.URL,
+		UsePathStyle:   true,
+		LocalCache:     t.TempDir(),
+		RequestTimeout: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+
+	if data, err := s.ReadFile("alice/pack"); err != nil || string(data) != "stream" {
+		t.Fatalf("ReadFile of a slow body = %q, %v, want it read in full", data, err)
+	}
+
+	delayHeaders.Store(true)
+	start := time.Now()
+	if _, err := s.ReadFile("alice/pack"); err == nil {
+		t.Fatal("ReadFile succeeded although the response headers never came in time")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("ReadFile took %s to time out", elapsed)
+	}
+}
@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// s3PartSize is the size of the parts written objects are uploaded in,
+	// which bounds the memory a writer holds
+	s3PartSize = 16 << 20
+
+	// s3MinPartSize is the smallest part S3 accepts, except for the last
+	s3MinPartSize = 5 << 20
+
+	// s3MaxCopyPartSize is the largest part S3 copies from an existing object
+	s3MaxCopyPartSize = 5 << 30
+
+	// s3MaxParts is the largest number of parts of an object
+	s3MaxParts = 10000
+)
+
+// s3Upload writes an object through the multipart upload API. Data is
+// buffered until a part is full and then uploaded, so a writer never holds
+// more than a part. Objects smaller than a part are uploaded with a single
+// PutObject on Close. A failed upload is aborted so S3 drops its parts.
+type s3Upload struct {
+	storage *S3Storage
+	key     string
+
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	uploadID string // Empty until the first part is uploaded
+	parts    []types.CompletedPart
+	err      error // First failure; later writes and Close return it
+	closed   bool
+}
+
+// newS3Upload creates a writer replacing the object at key on Close
+func newS3Upload(storage *S3Storage, key string) *s3Upload {
+	return &s3Upload{storage: storage, key: key}
+}
+
+// Write buffers p, uploading every part it fills
+func (w *s3Upload) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, errors.New("write to closed file")
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), s3PartSize-w.buf.Len())
+		w.buf.Write(p[:n])
+		p = p[n:]
+		written += n
+
+		if w.buf.Len() == s3PartSize {
+			if err := w.uploadPart(context.Background(), w.buf.Bytes()); err != nil {
+				w.fail(err)
+				return written, w.err
+			}
+			w.buf.Reset()
+		}
+	}
+	return written, nil
+}
+
+// Close uploads the remaining data and completes the object
+func (w *s3Upload) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	if w.err != nil {
+		return w.err
+	}
+
+	ctx := context.Background()
+	if w.uploadID == "" {
+		_, err := w.storage.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(w.storage.bucket),
+			Key:    aws.String(w.key),
+			Body:   bytes.NewReader(w.buf.Bytes()),
+		})
+		if err != nil {
+			w.err = fmt.Errorf("failed to upload file: %w", err)
+		}
+		return w.err
+	}
+
+	if w.buf.Len() > 0 {
+		if err := w.uploadPart(ctx, w.buf.Bytes()); err != nil {
+			w.fail(err)
+			return w.err
+		}
+		w.buf.Reset()
+	}
+
+	_, err := w.storage.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(w.storage.bucket),
+		Key:             aws.String(w.key),
+		UploadId:        aws.String(w.uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: w.parts},
+	})
+	if err != nil {
+		w.fail(fmt.Errorf("failed to complete upload: %w", err))
+	}
+	return w.err
+}
+
+// start creates the multipart upload on the first part
+func (w *s3Upload) start(ctx context.Context) error {
+	if w.uploadID != "" {
+		return nil
+	}
+	result, err := w.storage.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(w.storage.bucket),
+		Key:    aws.String(w.key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start upload: %w", err)
+	}
+	w.uploadID = aws.ToString(result.UploadId)
+	return nil
+}
+
+// nextPart returns the number of the next part
+func (w *s3Upload) nextPart() (int32, error) {
+	if len(w.parts) >= s3MaxParts {
+		return 0, fmt.Errorf("file exceeds %d parts of %d bytes", s3MaxParts, s3PartSize)
+	}
+	return int32(len(w.parts) + 1), nil
+}
+
+// uploadPart uploads data as the next part
+func (w *s3Upload) uploadPart(ctx context.Context, data []byte) error {
+	if err := w.start(ctx); err != nil {
+		return err
+	}
+	number, err := w.nextPart()
+	if err != nil {
+		return err
+	}
+
+	result, err := w.storage.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:        aws.String(w.storage.bucket),
+		Key:           aws.String(w.key),
+		UploadId:      aws.String(w.uploadID),
+		PartNumber:    aws.Int32(number),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload part %d: %w", number, err)
+	}
+	w.parts = append(w.parts, types.CompletedPart{ETag: result.ETag, PartNumber: aws.Int32(number)})
+	return nil
+}
+
+// copyExisting makes the current content of the object, size bytes of at
+// least a part, the first parts of the upload. Objects over the copy limit
+// are copied in ranges of equal size.
+func (w *s3Upload) copyExisting(ctx context.Context, size int64) error {
+	if err := w.start(ctx); err != nil {
+		return err
+	}
+
+	count := (size + s3MaxCopyPartSize - 1) / s3MaxCopyPartSize
+	partSize := (size + count - 1) / count
+	for offset := int64(0); offset < size; offset += partSize {
+		number, err := w.nextPart()
+		if err != nil {
+			w.fail(err)
+			return err
+		}
+
+		end := min(offset+partSize, size) - 1
+		result, err := w.storage.client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+			Bucket:          aws.String(w.storage.bucket),
+			Key:             aws.String(w.key),
+			UploadId:        aws.String(w.uploadID),
+			PartNumber:      aws.Int32(number),
+			CopySource:      aws.String(w.storage.copySource(w.key)),
+			CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", offset, end)),
+		})
+		if err != nil {
+			w.fail(fmt.Errorf("failed to copy part %d: %w", number, err))
+			return w.err
+		}
+		w.parts = append(w.parts, types.CompletedPart{
+			ETag:       result.CopyPartResult.ETag,
+			PartNumber: aws.Int32(number),
+		})
+	}
+	return nil
+}
+
+// fail records the first failure and aborts the upload, so S3 does not keep
+// its parts
+func (w *s3Upload) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+	if w.uploadID == "" {
+		return
+	}
+	_, abortErr := w.storage.client.AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(w.storage.bucket),
+		Key:      aws.String(w.key),
+		UploadId: aws.String(w.uploadID),
+	})
+	if abortErr != nil {
+		w.err = fmt.Errorf("%w (abort failed: %v)", w.err, abortErr)
+	}
+	w.uploadID = ""
+}
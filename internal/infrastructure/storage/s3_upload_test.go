@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// newMinIOStorage returns a storage on the test S3 server that deletes the
+// given objects when the test ends, or skips the test. The uploads are
+// hundreds of megabytes, so the tests are skipped in short mode too.
+func newMinIOStorage(t *testing.T, cfg S3Config, paths ...string) *S3Storage {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("large uploads are skipped in short mode")
+	}
+	s, err := NewS3Storage(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, path := range paths {
+			s.DeleteFile(path)
+		}
+	})
+	return s
+}
+
+// writeRandom writes size bytes of a random stream seeded by seed to w, in
+// writes of an odd size so parts never line up with them, and returns the
+// SHA-256 of what it wrote
+func writeRandom(t *testing.T, w io.Writer, seed byte, size int64) [32]byte {
+	t.Helper()
+	rng := rand.NewChaCha8([32]byte{seed})
+	h := sha256.New()
+	chunk := make([]byte, 1<<20+7)
+	for written := int64(0); written < size; {
+		n := int(min(int64(len(chunk)), size-written))
+		_, _ = rng.Read(chunk[:n])
+		h.Write(chunk[:n])
+		if _, err := w.Write(chunk[:n]); err != nil {
+			t.Fatalf("write at %d: %v", written, err)
+		}
+		written += int64(n)
+	}
+	return [32]byte(h.Sum(nil))
+}
+
+// checkObject compares the object at path with the SHA-256 and size of its
+// content, and returns the number of parts it was uploaded in, 0 for a
+// single PutObject
+func checkObject(t *testing.T, s *S3Storage, path string, want [32]byte, size int64) int {
+	t.Helper()
+	r, err := s.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	if n != size || [32]byte(h.Sum(nil)) != want {
+		t.Fatalf("%s holds %d bytes that differ from the %d written", path, n, size)
+	}
+
+	// The ETag of a multipart object ends with the number of its parts
+	head, err := s.client.HeadObject(context.Background(), &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(path)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := strings.Trim(aws.ToString(head.ETag), `"`)
+	_, suffix, ok := strings.Cut(etag, "-")
+	if !ok {
+		return 0
+	}
+	parts, err := strconv.Atoi(suffix)
+	if err != nil {
+		t.Fatalf("ETag %s: %v", etag, err)
+	}
+	return parts
+}
+
+// pendingUploads returns the IDs of the multipart uploads of the object at
+// path that were neither completed nor aborted
+func pendingUploads(t *testing.T, s *S3Storage, path string) []string {
+	t.Helper()
+	result, err := s.client.ListMultipartUploads(context.Background(), &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.objectKey(path)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, upload := range result.Uploads {
+		ids = append(ids, aws.ToString(upload.UploadId))
+	}
+	return ids
+}
+
+func TestMinIOMultipartUpload(t *testing.T) {
+	const size = 300<<20 + 12345
+	s := newMinIOStorage(t, minioConfig(t), "alice/large.pack")
+
+	w, err := s.CreateFile("alice/large.pack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := writeRandom(t, w, 1, size)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if parts, want := checkObject(t, s, "alice/large.pack", sum, size), size/s3PartSize+1; parts != want {
+		t.Errorf("object uploaded in %d parts, want %d", parts, want)
+	}
+	if ids := pendingUploads(t, s, "alice/large.pack"); len(ids) != 0 {
+		t.Errorf("uploads left pending: %v", ids)
+	}
+}
+
+// Appending to an object of a part or more copies it on the server as the
+// first part of the new upload
+func TestMinIOMultipartAppend(t *testing.T) {
+	const existing, appended = 40 << 20, 20<<20 + 99
+	s := newMinIOStorage(t, minioConfig(t), "alice/objects.log")
+
+	w, err := s.CreateFile("alice/objects.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := sha256.New()
+	writeRandom(t, io.MultiWriter(w, h), 2, existing)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	w, err = s.AppendFile("alice/objects.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeRandom(t, io.MultiWriter(w, h), 3, appended)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close after append: %v", err)
+	}
+
+	// One copied part, then the appended data in parts of its own
+	parts := checkObject(t, s, "alice/objects.log", [32]byte(h.Sum(nil)), existing+appended)
+	if want := 1 + appended/s3PartSize + 1; parts != want {
+		t.Errorf("appended object in %d parts, want %d", parts, want)
+	}
+	if ids := pendingUploads(t, s, "alice/objects.log"); len(ids) != 0 {
+		t.Errorf("uploads left pending: %v", ids)
+	}
+}
+
+// An upload failing part way through is aborted, leaving neither the object
+// nor its parts on the server
+func TestMinIOMultipartUploadAborted(t *testing.T) {
+	cfg := minioConfig(t)
+	target, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.CABundlePath != "" {
+		pool, err := loadCABundle(cfg.CABundlePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	// The third part is refused on the way to the server. The host the
+	// request was signed for is kept, so the server accepts the others.
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.Out.Host = r.In.Host
+		},
+		Transport: transport,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && r.URL.Query().Get("partNumber") == "3" {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, "<Error><Code>AccessDenied</Code></Error>")
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	cfg.Endpoint = server.URL
+	cfg.CABundlePath = ""
+	s := newMinIOStorage(t, cfg, "alice/broken.pack")
+
+	w, err := s.CreateFile("alice/broken.pack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	upload := w.(*s3Upload)
+	data := bytes.Repeat([]byte{'x'}, s3PartSize)
+	for part := 1; part <= 2; part++ {
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("write of part %d: %v", part, err)
+		}
+	}
+	if ids := pendingUploads(t, s, "alice/broken.pack"); len(ids) != 1 || ids[0] != upload.uploadID {
+		t.Fatalf("pending uploads = %v, want the one of the writer", ids)
+	}
+
+	if _, err := w.Write(data); err == nil || !strings.Contains(err.Error(), "part 3") {
+		t.Fatalf("write of the refused part = %v, want it to fail", err)
+	}
+	if _, err := w.Write([]byte("more")); err == nil {
+		t.Error("write after a failed part succeeded")
+	}
+	if err := w.Close(); err == nil {
+		t.Error("Close of a failed upload succeeded")
+	}
+
+	if ids := pendingUploads(t, s, "alice/broken.pack"); len(ids) != 0 {
+		t.Errorf("failed upload left pending: %v", ids)
+	}
+	_, err = s.client.HeadObject(context.Background(), &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey("alice/broken.pack")),
+	})
+	var notFound *types.NotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("object of a failed upload: %v, want it not found", err)
+	}
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/bravo68web/stasis/internal/config"
 	"github.com/bravo68web/stasis/internal/domain/service"
@@ -22,12 +23,12 @@ const (
 
 // Factory creates storage backends based on configuration
 type Factory struct {
-	config *config.StorageConfig
+	config *config.StorageBackendConfig
 	log    *logger.Logger
 }
 
 // NewFactory creates a new storage factory
-func NewFactory(cfg *config.StorageConfig) *Factory {
+func NewFactory(cfg *config.StorageBackendConfig) *Factory {
 	return &Factory{
 		config: cfg,
 		log:    logger.Get().WithFields(logger.Component("storage-factory")),
@@ -77,6 +78,14 @@ func (f *Factory) Create() (service.StorageService, error) {
 				Endpoint:     f.config.S3Endpoint,
 				UsePathStyle: f.config.S3UsePathStyle || f.config.S3Endpoint != "",
 				LocalCache:   f.config.BasePath, // Local path for git operations
+
+				CABundlePath:        f.config.S3CABundlePath,
+				InsecureSkipVerify:  f.config.S3InsecureSkipVerify,
+				MaxRetries:          f.config.S3MaxRetries,
+				RetryMaxBackoff:     time.Duration(f.config.S3RetryMaxBackoff) * time.Second,
+				RequestTimeout:      time.Duration(f.config.S3RequestTimeout) * time.Second,
+				MaxIdleConns:        f.config.S3MaxIdleConns,
+				MaxIdleConnsPerHost: f.config.S3MaxIdleConnsPerHost,
 			},
 		)
 		if err != nil {
@@ -132,7 +141,7 @@ func (f *Factory) MustCreate() service.StorageService {
 }
 
 // ValidateConfig validates the storage configuration
-func ValidateConfig(cfg *config.StorageConfig) error {
+func ValidateConfig(cfg *config.StorageBackendConfig) error {
 	log := logger.Get().WithFields(logger.Component("storage"))
 	storageType := StorageType(cfg.Type)
 
@@ -175,7 +184,7 @@ func ValidateConfig(cfg *config.StorageConfig) error {
 }
 
 // GetStorageType returns the storage type from configuration
-func GetStorageType(cfg *config.StorageConfig) StorageType {
+func GetStorageType(cfg *config.StorageBackendConfig) StorageType {
 	if cfg.Type == "" {
 		return StorageTypeFilesystem
 	}
@@ -183,11 +192,11 @@ func GetStorageType(cfg *config.StorageConfig) StorageType {
 }
 
 // IsFilesystem checks if the storage type is filesystem
-func IsFilesystem(cfg *config.StorageConfig) bool {
+func IsFilesystem(cfg *config.StorageBackendConfig) bool {
 	return GetStorageType(cfg) == StorageTypeFilesystem
 }
 
 // IsS3 checks if the storage type is S3
-func IsS3(cfg *config.StorageConfig) bool {
+func IsS3(cfg *config.StorageBackendConfig) bool {
 	return GetStorageType(cfg) == StorageTypeS3
 }
@@ -0,0 +1,18 @@
+package injectable
+
+import (
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/infrastructure/audit"
+)
+
+// loadAuditDispatcher creates and starts the audit dispatcher. Sink spool
+// files must only have a single writer, so the process loads it once, with
+// the other dependencies.
+func loadAuditDispatcher(cfg *config.AuditConfig) (*audit.Dispatcher, error) {
+	dispatcher, err := audit.NewDispatcher(cfg)
+	if err != nil {
+		return nil, err
+	}
+	dispatcher.Start()
+	return dispatcher, nil
+}
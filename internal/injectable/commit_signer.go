@@ -0,0 +1,23 @@
+package injectable
+
+import (
+	"github.com/bravo68web/stasis/internal/config"
+	domainservice "github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/internal/infrastructure/git"
+)
+
+// loadCommitSigner loads the instance key the server signs the commits it
+// creates with. It returns nil if signing is disabled.
+func loadCommitSigner(cfg *config.SigningConfig) (domainservice.CommitSigner, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	signer, err := git.NewCommitSigner(cfg.Format, cfg.KeyPath, cfg.Passphrase, domainservice.Signature{
+		Name:  cfg.CommitterName,
+		Email: cfg.CommitterEmail,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
@@ -8,10 +8,12 @@ import (
 	"github.com/bravo68web/stasis/internal/application/service"
 	"github.com/bravo68web/stasis/internal/config"
 	domainservice "github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/internal/infrastructure/audit"
 	"github.com/bravo68web/stasis/internal/infrastructure/database"
+	"github.com/bravo68web/stasis/internal/infrastructure/eventbus"
 	"github.com/bravo68web/stasis/internal/infrastructure/git"
+	"github.com/bravo68web/stasis/internal/infrastructure/mail"
 	"github.com/bravo68web/stasis/internal/infrastructure/repository"
-	"github.com/bravo68web/stasis/internal/infrastructure/storage"
 	"github.com/bravo68web/stasis/pkg/logger"
 )
 
@@ -20,55 +22,150 @@ type Dependencies struct {
 	// Services
 	AuthService       domainservice.AuthService
 	GitService        domainservice.GitService
+	GitProtocol       *git.GitProtocol
+	CommitSigner      domainservice.CommitSigner
 	RepoService       *service.RepoService
 	UserService       *service.UserService
+	UserAdmin         *service.UserAdminService
 	SSHKeyService     *service.SSHKeyService
+	GPGKeys           *service.GPGKeyService
 	TokenService      *service.TokenService
 	OIDCService       *service.OIDCService
 	CIService         *service.CIService
+	CIJobTokens       *service.CIJobTokenService
+	CommitStatuses    *service.CommitStatusService
 	MirrorSyncService *service.MirrorSyncService
 	MirrorCronService *service.MirrorCronService
-	Storage           domainservice.StorageService
+	AnnotationService *service.AnnotationService
+	BadgeProxyService *service.BadgeProxyService
+	TagProtection     *service.TagProtectionService
+	PushPolicies      *service.PushPolicyService
+	UploadPack        *service.UploadPackService
+	Contributions     *service.ContributionService
+	Highlight         *service.HighlightService
+	AuthorMappings    *service.AuthorMappingService
+	CommitSignatures  *service.CommitSignatureService
+	Collaborators     *service.CollaboratorService
+	SSHHostKeys       *service.SSHHostKeyService
+	Licenses          *service.LicenseService
+	PinnedLinks       *service.PinnedLinkService
+	Topics            *service.TopicService
+	Languages         *service.LanguageService
+	AuditDispatcher   *audit.Dispatcher
+	StorageBackends   *service.StorageBackendService
+	PushAttempts      *service.PushAttemptService
+	LargeFiles        *service.LargeFileService
+	Quotas            *service.QuotaService
+	StaleFiles        *service.StaleFileService
+	GarbageCollection *service.GarbageCollectionService
+	Backups           *service.BackupService
+	LFS               *service.LFSService
+	RepoBulk          *service.RepoBulkService
+	RepoImports       *service.RepoImportService
+	BranchProtection  *service.BranchProtectionService
+	Webhooks          *service.WebhookService
+	PullRequests      *service.PullRequestService
+	Issues            *service.IssueService
+	Notifications     *service.NotificationService
+	Emails            *service.EmailService
+	Mailer            *mail.Queue
+	UserExports       *service.UserExportService
+	Housekeeping      *service.HousekeepingService
+	AuditEvents       *service.AuditEventService
+	Feeds             *service.FeedService
+	Onboarding        *service.OnboardingService
+	DegradedMode      *service.DegradedModeService
+	EventBus          *eventbus.Bus
+
+	// stopBackground stops the workers and schedulers started in the background
+	stopBackground context.CancelFunc
 }
 
-func LoadDependencies(cfg *config.Config, db *database.Database) Dependencies {
+// LoadDependencies creates the services of the HTTP and SSH servers and
+// starts their background work. Call it once per process: event bus
+// subscriptions, workers and spool files must not be duplicated. Close stops
+// what it started.
+func LoadDependencies(cfg *config.Config, db *database.Database) *Dependencies {
 	log := logger.Get()
 
 	log.Info("Loading application dependencies...")
 
+	// Background work runs until Close
+	background, stopBackground := context.WithCancel(context.Background())
+
 	// Initialize repositories
 	log.Debug("Initializing repositories...")
 	userRepo := repository.NewUserRepository(db.DB())
 	repoRepo := repository.NewRepoRepository(db.DB())
 	sshKeyRepo := repository.NewSSHKeyRepository(db.DB())
+	gpgKeyRepo := repository.NewGPGKeyRepository(db.DB())
 	tokenRepo := repository.NewTokenRepository(db.DB())
+	annotationRepo := repository.NewRepoAnnotationRepository(db.DB())
+	contributionRepo := repository.NewContributionRepository(db.DB())
+	ciPipelineRepo := repository.NewCIPipelineRepository(db.DB())
+	authorMappingRepo := repository.NewAuthorMappingRepository(db.DB())
+	collaboratorRepo := repository.NewCollaboratorRepository(db.DB())
+	pushAttemptRepo := repository.NewPushAttemptRepository(db.DB())
+	branchProtectionRepo := repository.NewBranchProtectionRepository(db.DB())
+	pullRequestRepo := repository.NewPullRequestRepository(db.DB())
+	issueRepo := repository.NewIssueRepository(db.DB())
+	notificationRepo := repository.NewNotificationRepository(db.DB())
+	notificationPreferenceRepo := repository.NewNotificationPreferenceRepository(db.DB())
+	userExportRepo := repository.NewUserExportRepository(db.DB())
+	repoBulkTaskRepo := repository.NewRepoBulkTaskRepository(db.DB())
+	repoImportRepo := repository.NewRepoImportRepository(db.DB())
+	housekeepingTaskRepo := repository.NewHousekeepingTaskRepository(db.DB())
+	commitStatusRepo := repository.NewCommitStatusRepository(db.DB())
+	auditEventRepo := repository.NewAuditEventRepository(db.DB())
+	largeFileRepo := repository.NewLargeFileRepository(db.DB())
+	ciJobTokenRepo := repository.NewCIJobTokenRepository(db.DB())
+	ciArtifactRepo := repository.NewCIArtifactRepository(db.DB())
+	sessionRepo := repository.NewSessionRepository(db.DB())
+	languageRepo := repository.NewRepoLanguageRepository(db.DB())
+	webhookRepo := repository.NewWebhookRepository(db.DB())
 
 	log.Debug("Repositories initialized",
-		logger.Int("count", 4),
+		logger.Int("count", 16),
 	)
 
-	// Initialize storage
-	log.Debug("Initializing storage service...",
+	// Initialize the event bus shared by all producers and consumers
+	eventBus := eventbus.New()
+
+	// Initialize the audit trail of API actions and pushes
+	auditEventService := service.NewAuditEventService(auditEventRepo, eventBus)
+
+	// Initialize audit export (no-op when no sinks are configured)
+	auditDispatcher, err := loadAuditDispatcher(&cfg.Audit)
+	if err != nil {
+		log.Fatal("Failed to initialize audit sinks",
+			logger.Error(err),
+		)
+	}
+
+	// Initialize storage backends
+	log.Debug("Initializing storage backends...",
 		logger.String("type", cfg.Storage.Type),
+		logger.Int("additional_backends", len(cfg.Storage.Backends)),
 	)
-	storageFactory := storage.NewFactory(&cfg.Storage)
-	storageService, err := storageFactory.Create()
+	storageRegistry, storageBackends, err := loadStorageBackends(&cfg.Storage, repoRepo, auditDispatcher)
 	if err != nil {
-		log.Fatal("Failed to initialize storage service",
+		log.Fatal("Failed to initialize storage backends",
 			logger.Error(err),
 			logger.String("storage_type", cfg.Storage.Type),
 		)
 	}
-	log.Info("Storage service initialized",
+	storageService, _ := storageRegistry.Backend(config.DefaultStorageBackend)
+	log.Info("Storage backends initialized",
 		logger.String("type", cfg.Storage.Type),
 		logger.String("base_path", cfg.Storage.BasePath),
+		logger.Int("backends", len(storageRegistry.Names())),
 	)
 
 	// Initialize OIDC service
 	log.Debug("Initializing OIDC service...",
 		logger.Bool("enabled", cfg.OIDC.Enabled),
 	)
-	oidcService := service.NewOIDCService(&cfg.OIDC, userRepo)
+	oidcService := service.NewOIDCService(&cfg.OIDC, userRepo, sessionRepo, eventBus)
 	if cfg.OIDC.Enabled {
 		if err := oidcService.Initialize(context.Background()); err != nil {
 			log.Warn("Failed to initialize OIDC service - OIDC authentication will be unavailable",
@@ -85,28 +182,149 @@ func LoadDependencies(cfg *config.Config, db *database.Database) Dependencies {
 		log.Info("OIDC service is disabled")
 	}
 
+	// Initialize token usage tracking
+	tokenUsage, err := loadTokenUsageTracker(&cfg.Tokens, tokenRepo, auditDispatcher)
+	if err != nil {
+		log.Fatal("Failed to initialize token anomaly detection",
+			logger.Error(err),
+		)
+	}
+
 	// Initialize services
 	log.Debug("Initializing application services...")
-	authService := service.NewAuthService(userRepo, sshKeyRepo, tokenRepo, oidcService, &cfg.OIDC)
-	gitService := git.NewGitOperations(storageService)
+	authService := service.NewAuthService(userRepo, sshKeyRepo, tokenRepo, ciJobTokenRepo, tokenUsage, oidcService, &cfg.OIDC)
+	commitSigner, err := loadCommitSigner(&cfg.Signing)
+	if err != nil {
+		log.Fatal("Failed to load the commit signing key, check signing.key_path, signing.format and signing.passphrase",
+			logger.Error(err),
+		)
+	}
+	gitService := git.NewGitOperations(storageService, commitSigner)
+	gitProtocol, err := git.NewGitProtocol(git.ReceiveLimits{
+		MaxPushSize: cfg.Repos.MaxPushSize,
+		MaxFileSize: cfg.Repos.MaxFileSize,
+
+		LargeFileWarningSize: cfg.Repos.LargeFileWarningSize,
+		StaleLockAge:         cfg.Repos.StaleLockAge(),
+	})
+	if err != nil {
+		log.Fatal("Failed to initialize git protocol",
+			logger.Error(err),
+		)
+	}
 	repoService := service.NewRepoService(
 		repoRepo,
 		userRepo,
+		collaboratorRepo,
 		gitService,
-		storageService,
+		storageBackends,
+		&cfg.Repos,
+		eventBus,
 	)
-	userService := service.NewUserService(userRepo)
+	userService := service.NewUserService(userRepo, eventBus)
+	userAdminService := service.NewUserAdminService(userService, userRepo, repoRepo, repoService)
 	sshKeyService := service.NewSSHKeyService(sshKeyRepo, userRepo)
+	gpgKeyService := service.NewGPGKeyService(gpgKeyRepo)
 	tokenService := service.NewTokenService(tokenRepo, userRepo)
+	annotationService := service.NewAnnotationService(annotationRepo, &cfg.Annotations)
+	badgeProxyService := service.NewBadgeProxyService(&cfg.BadgeProxy)
+	tagProtectionService := service.NewTagProtectionService(repoRepo, auditDispatcher)
+	pushPolicyService := service.NewPushPolicyService(repoRepo, gitService)
+	uploadPackService := service.NewUploadPackService(repoRepo, gitService)
+	branchProtectionService := service.NewBranchProtectionService(branchProtectionRepo, repoService)
+	webhookService := service.NewWebhookService(webhookRepo, repoRepo, repoService, eventBus, &cfg.Webhooks)
+	pullRequestService := service.NewPullRequestService(pullRequestRepo, branchProtectionService, gitService, eventBus)
+	issueService := service.NewIssueService(issueRepo, eventBus)
+	notificationService := service.NewNotificationService(notificationRepo, userRepo, repoRepo, repoService, eventBus)
+	mailQueue, err := loadMailQueue(&cfg.Email)
+	if err != nil {
+		log.Fatal("Failed to initialize email delivery",
+			logger.Error(err),
+		)
+	}
+	emailService := service.NewEmailService(mailQueue, notificationPreferenceRepo, userRepo, repoRepo, repoService, eventBus, &cfg.Email)
+	contributionService := service.NewContributionService(contributionRepo, repoRepo, userRepo, gitService)
+	go contributionService.Backfill(background)
+	highlightService := service.NewHighlightService(&cfg.Highlight)
+	authorMappingService := service.NewAuthorMappingService(authorMappingRepo, userRepo, gitService)
+	commitSignatureService := service.NewCommitSignatureService(gpgKeyRepo, sshKeyRepo, userRepo, gitService, commitSigner)
+	collaboratorService := service.NewCollaboratorService(collaboratorRepo, userRepo, eventBus)
+	sshHostKeyService := service.NewSSHHostKeyService(&cfg.SSH)
+	licenseService := service.NewLicenseService(repoRepo, gitService)
+	go licenseService.Backfill(background)
+	pinnedLinkService := service.NewPinnedLinkService(repoRepo, gitService)
+	topicService := service.NewTopicService(repoRepo)
+	languageService := service.NewLanguageService(languageRepo, gitService)
+	feedService := service.NewFeedService(gitService, auditEventRepo, userRepo)
+	pushAttemptService := service.NewPushAttemptService(pushAttemptRepo, &cfg.PushAttempts)
+	largeFileService := service.NewLargeFileService(largeFileRepo, eventBus)
+	ciJobTokenService := service.NewCIJobTokenService(ciJobTokenRepo, &cfg.CI, eventBus)
+	ciArtifactService := service.NewCIArtifactService(ciArtifactRepo, ciJobTokenRepo, storageService, &cfg.CI)
+	quotaService := service.NewQuotaService(repoRepo, storageBackends, auditDispatcher, &cfg.Repos)
+	staleFileService := service.NewStaleFileService(repoRepo, storageBackends, gitService, auditDispatcher, &cfg.Repos)
+	gcService := service.NewGarbageCollectionService(repoRepo, storageBackends, gitService, gitProtocol, auditDispatcher, &cfg.Repos)
+	backupService := service.NewBackupService(repoRepo, userRepo, collaboratorRepo, gitService, storageService, storageBackends, auditDispatcher, eventBus, &cfg.Backups)
+	onboardingService := service.NewOnboardingService(
+		repoService,
+		userRepo,
+		repoRepo,
+		sshKeyRepo,
+		gitService,
+		storageBackends,
+		quotaService,
+		eventBus,
+		eventBus,
+		&cfg.Onboarding,
+		cfg.CI.GetConfigPath(),
+	)
+	lfsService := service.NewLFSService(storageBackends, quotaService, &cfg.Repos)
+	repoBulkService := service.NewRepoBulkService(repoBulkTaskRepo, repoRepo, userRepo, repoService, auditEventService, &cfg.Repos)
+	go repoBulkService.RunWorker(background)
+	repoImportService := service.NewRepoImportService(repoImportRepo, repoService, auditEventService, &cfg.Repos)
+	go repoImportService.FailInterrupted(background)
+	var degradedModeService *service.DegradedModeService
+	if cfg.Server.DegradedMode.Enabled {
+		degradedModeService = service.NewDegradedModeService(repoRepo, db.Ping, &cfg.Server.DegradedMode)
+		go degradedModeService.Run(background)
+	}
+	userExportService, err := service.NewUserExportService(
+		userExportRepo,
+		userRepo,
+		sshKeyRepo,
+		tokenRepo,
+		repoRepo,
+		contributionRepo,
+		pullRequestRepo,
+		pushAttemptRepo,
+		auditEventRepo,
+		gitService,
+		storageService,
+		eventBus,
+		&cfg.Exports,
+	)
+	if err != nil {
+		log.Fatal("Failed to initialize user exports",
+			logger.Error(err),
+		)
+	}
+	go userExportService.RunWorker(background)
 
 	// Initialize CI service
-	// CI data (jobs, logs, artifacts) is fetched directly from CI server - no local database storage
+	// CI data (jobs, logs) is fetched directly from CI server - no local database storage.
+	// Artifacts the runner uploads are stored on the server.
 	log.Debug("Initializing CI service...",
 		logger.Bool("enabled", cfg.CI.Enabled),
 	)
+	commitStatusService := service.NewCommitStatusService(commitStatusRepo, gitService)
 	ciService := service.NewCIService(
 		&cfg.CI,
 		repoRepo,
+		ciPipelineRepo,
+		gitService,
+		commitStatusService,
+		ciJobTokenService,
+		ciArtifactService,
+		eventBus,
 	)
 	if cfg.CI.Enabled {
 		log.Info("CI service initialized successfully (fetching from CI server)",
@@ -116,11 +334,35 @@ func LoadDependencies(cfg *config.Config, db *database.Database) Dependencies {
 		log.Info("CI service is disabled")
 	}
 
+	housekeepingService := service.NewHousekeepingService(housekeepingTaskRepo)
+	for _, task := range []*service.HousekeepingTask{
+		pushAttemptService.CleanupTask(),
+		userExportService.CleanupTask(),
+		repoBulkService.CleanupTask(),
+		largeFileService.CleanupTask(),
+		staleFileService.CleanupTask(),
+		gcService.Task(),
+		ciJobTokenService.CleanupTask(),
+		ciArtifactService.CleanupTask(),
+		ciArtifactService.OrphanCleanupTask(),
+		ciService.PipelineCleanupTask(),
+		oidcService.SessionCleanupTask(),
+		repoService.RedirectCleanupTask(),
+		webhookService.CleanupTask(),
+	} {
+		// Disabled tasks are nil
+		if task != nil {
+			housekeepingService.Register(task)
+		}
+	}
+	go housekeepingService.RunScheduler(background)
+
 	// Initialize mirror sync services
 	log.Debug("Initializing mirror sync services...")
 	mirrorSyncService := service.NewMirrorSyncService(
 		repoRepo,
 		gitService,
+		&cfg.Repos,
 	)
 
 	// Initialize mirror cron service (checks per-repository intervals)
@@ -140,25 +382,86 @@ func LoadDependencies(cfg *config.Config, db *database.Database) Dependencies {
 		logger.Bool("user_service", true),
 		logger.Bool("ssh_key_service", true),
 		logger.Bool("token_service", true),
+		logger.Bool("annotation_service", true),
 		logger.Bool("oidc_service", cfg.OIDC.Enabled),
 		logger.Bool("ci_service", cfg.CI.Enabled),
+		logger.Bool("badge_proxy_service", cfg.BadgeProxy.Enabled),
+		logger.Int("audit_sinks", len(cfg.Audit.Sinks)),
 		logger.Bool("mirror_sync_service", true),
 		logger.Bool("mirror_cron_service", true),
 	)
 
 	log.Info("Dependencies loaded successfully")
 
-	return Dependencies{
+	return &Dependencies{
 		AuthService:       authService,
 		GitService:        gitService,
+		GitProtocol:       gitProtocol,
+		CommitSigner:      commitSigner,
 		RepoService:       repoService,
 		UserService:       userService,
+		UserAdmin:         userAdminService,
 		SSHKeyService:     sshKeyService,
+		GPGKeys:           gpgKeyService,
 		TokenService:      tokenService,
 		OIDCService:       oidcService,
 		CIService:         ciService,
+		CIJobTokens:       ciJobTokenService,
+		CommitStatuses:    commitStatusService,
 		MirrorSyncService: mirrorSyncService,
 		MirrorCronService: mirrorCronService,
-		Storage:           storageService,
+		AnnotationService: annotationService,
+		BadgeProxyService: badgeProxyService,
+		TagProtection:     tagProtectionService,
+		PushPolicies:      pushPolicyService,
+		UploadPack:        uploadPackService,
+		Contributions:     contributionService,
+		Highlight:         highlightService,
+		AuthorMappings:    authorMappingService,
+		CommitSignatures:  commitSignatureService,
+		Collaborators:     collaboratorService,
+		SSHHostKeys:       sshHostKeyService,
+		Licenses:          licenseService,
+		PinnedLinks:       pinnedLinkService,
+		Topics:            topicService,
+		Languages:         languageService,
+		AuditDispatcher:   auditDispatcher,
+		StorageBackends:   storageBackends,
+		PushAttempts:      pushAttemptService,
+		LargeFiles:        largeFileService,
+		Quotas:            quotaService,
+		StaleFiles:        staleFileService,
+		GarbageCollection: gcService,
+		Backups:           backupService,
+		LFS:               lfsService,
+		RepoBulk:          repoBulkService,
+		RepoImports:       repoImportService,
+		BranchProtection:  branchProtectionService,
+		Webhooks:          webhookService,
+		PullRequests:      pullRequestService,
+		Issues:            issueService,
+		Notifications:     notificationService,
+		Emails:            emailService,
+		Mailer:            mailQueue,
+		UserExports:       userExportService,
+		Housekeeping:      housekeepingService,
+		AuditEvents:       auditEventService,
+		Feeds:             feedService,
+		Onboarding:        onboardingService,
+		DegradedMode:      degradedModeService,
+		EventBus:          eventBus,
+		stopBackground:    stopBackground,
 	}
 }
+
+// Close stops the background work of the dependencies: workers and
+// schedulers, mirror syncs, event delivery after the queued events are
+// delivered, audit export, spooling what the sinks cannot take, and email
+// after the queued emails are sent
+func (d *Dependencies) Close() {
+	d.stopBackground()
+	d.MirrorCronService.Stop()
+	d.EventBus.Close()
+	d.AuditDispatcher.Stop()
+	d.Mailer.Stop()
+}
@@ -0,0 +1,17 @@
+package injectable
+
+import (
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/infrastructure/mail"
+)
+
+// loadMailQueue creates and starts the email queue.
+// The queue is nil when email is disabled.
+func loadMailQueue(cfg *config.EmailConfig) (*mail.Queue, error) {
+	queue, err := mail.NewQueue(cfg)
+	if err != nil {
+		return nil, err
+	}
+	queue.Start()
+	return queue, nil
+}
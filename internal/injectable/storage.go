@@ -0,0 +1,40 @@
+package injectable
+
+import (
+	"context"
+	"time"
+
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	domainservice "github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/internal/infrastructure/git"
+	"github.com/bravo68web/stasis/internal/infrastructure/storage"
+)
+
+// storageCheckTimeout bounds the startup check of the storage backends
+const storageCheckTimeout = time.Minute
+
+// loadStorageBackends creates the storage backends and checks they are
+// reachable. Storage migrations must see the git operations of the HTTP and
+// SSH servers, which share the backends loaded here.
+func loadStorageBackends(
+	cfg *config.StorageConfig,
+	repoRepo repository.RepoRepository,
+	audit domainservice.AuditRecorder,
+) (*storage.Registry, *service.StorageBackendService, error) {
+	registry, err := storage.NewRegistry(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storageCheckTimeout)
+	defer cancel()
+	if err := registry.Check(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	defaultBackend, _ := registry.Backend(config.DefaultStorageBackend)
+	backends := service.NewStorageBackendService(registry, repoRepo, git.NewGitOperations(defaultBackend, nil), audit)
+	return registry, backends, backends.CheckRepositories(ctx)
+}
@@ -0,0 +1,23 @@
+package injectable
+
+import (
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	domainservice "github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/internal/infrastructure/geoip"
+)
+
+// loadTokenUsageTracker creates the token usage tracker, shared by the HTTP
+// and SSH servers for its write throttling and known origins
+func loadTokenUsageTracker(cfg *config.TokensConfig, tokenRepo repository.TokenRepository, audit domainservice.AuditRecorder) (*service.TokenUsageTracker, error) {
+	var lookup domainservice.GeoIPLookup
+	if cfg.AnomalyDetection.Enabled {
+		ranges, err := geoip.LoadRangeFile(cfg.AnomalyDetection.GeoIPRangesFile)
+		if err != nil {
+			return nil, err
+		}
+		lookup = ranges
+	}
+	return service.NewTokenUsageTracker(tokenRepo, lookup, audit, nil), nil
+}
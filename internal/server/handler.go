@@ -0,0 +1,30 @@
+package server
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// SwapHandler serves HTTP requests with the handler last given to Swap, so
+// the listener can start with the health endpoints alone and take on every
+// route once the dependencies are loaded
+type SwapHandler struct {
+	current atomic.Pointer[http.Handler]
+}
+
+// NewSwapHandler creates a SwapHandler serving h
+func NewSwapHandler(h http.Handler) *SwapHandler {
+	sh := &SwapHandler{}
+	sh.Swap(h)
+	return sh
+}
+
+// Swap serves the following requests with h
+func (sh *SwapHandler) Swap(h http.Handler) {
+	sh.current.Store(&h)
+}
+
+// ServeHTTP implements http.Handler
+func (sh *SwapHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	(*sh.current.Load()).ServeHTTP(w, req)
+}
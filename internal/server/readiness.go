@@ -0,0 +1,66 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// State is a stage of server startup, as reported by /readyz
+type State string
+
+const (
+	// StateStarting: nothing is served yet
+	StateStarting State = "starting"
+	// StateDegraded: only health endpoints are served, the database is not yet
+	// available or a dependency went away
+	StateDegraded State = "degraded"
+	// StateReady: every route is served
+	StateReady State = "ready"
+)
+
+// Readiness tracks the startup state of the server so orchestrators can tell
+// a server still waiting for its database from one ready for traffic
+type Readiness struct {
+	mu     sync.RWMutex
+	state  State
+	reason string
+	log    *logger.Logger
+}
+
+// NewReadiness creates a Readiness in the starting state
+func NewReadiness(log *logger.Logger) *Readiness {
+	return &Readiness{
+		state: StateStarting,
+		log:   log.WithFields(logger.Component("readiness")),
+	}
+}
+
+// Set moves to a state, logging the transition. reason tells why the server
+// is not ready and is cleared once it is.
+func (r *Readiness) Set(state State, reason string) {
+	if state == StateReady {
+		reason = ""
+	}
+
+	r.mu.Lock()
+	from := r.state
+	changed := from != state || r.reason != reason
+	r.state, r.reason = state, reason
+	r.mu.Unlock()
+
+	if changed {
+		r.log.Info("Server state changed",
+			logger.String("from", string(from)),
+			logger.String("to", string(state)),
+			logger.String("reason", reason),
+		)
+	}
+}
+
+// Get returns the current state and why the server is not ready
+func (r *Readiness) Get() (State, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.state, r.reason
+}
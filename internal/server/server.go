@@ -20,9 +20,10 @@ type Server struct {
 	OpenAPIGenerator *openapi.Generator
 
 	Config       *config.Config
-	DB           *database.Database
+	DB           *database.Database // Set by ConnectDatabase
 	Logger       *logger.Logger
 	OTELProvider *otel.Provider
+	Readiness    *Readiness
 }
 
 func New() *Server {
@@ -89,26 +90,12 @@ func New() *Server {
 		logger.String("format", cfg.Logging.Format),
 	)
 
-	// Initialize database connection
-	db, err := database.NewDatabase(&cfg.Database)
-	if err != nil {
-		log.Fatal("Failed to initialize database", logger.Error(err))
-	}
-
-	log.Info("Database connection established",
-		logger.String("host", cfg.Database.Host),
-		logger.Int("port", cfg.Database.Port),
-		logger.String("database", cfg.Database.DBName),
-	)
-
-	// Run auto-migrations in production mode
-	if cfg.IsProduction() {
-		migrator := database.NewMigrator(db)
-		migrator.MustApplyMigrations(context.Background())
-
-		log.Info("Database migrations applied")
-	}
+	return NewFromConfig(cfg, log, otelProvider)
+}
 
+// NewFromConfig creates a server from a loaded configuration and logger, for
+// callers such as tests that build their own. otelProvider may be nil.
+func NewFromConfig(cfg *config.Config, log *logger.Logger, otelProvider *otel.Provider) *Server {
 	// Set Gin mode based on configuration
 	switch cfg.Server.Mode {
 	case "release":
@@ -122,6 +109,12 @@ func New() *Server {
 	// Create Gin engine without default middleware
 	engine := gin.New()
 
+	// Only believe the forwarded client IP of the configured proxies, so
+	// clients cannot pick the IP they are rate limited and audited as
+	if err := engine.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatal("Invalid server.trusted_proxies", logger.Error(err))
+	}
+
 	apiGen := openapi.NewGenerator(engine, openapi.Info{
 		Title:       "Stasis - Git Server API",
 		Version:     "1.0.0",
@@ -136,14 +129,19 @@ func New() *Server {
 		{URL: "http://localhost:8080", Description: "Local development server"},
 	}, []openapi.Tag{
 		{Name: "Health", Description: "Health check endpoints"},
+		{Name: "Meta", Description: "Public server information such as SSH host keys"},
 		{Name: "Authentication", Description: "User authentication and registration"},
 		{Name: "SSH Keys", Description: "SSH key management for Git SSH access"},
 		{Name: "Repositories", Description: "Repository management operations"},
+		{Name: "Annotations", Description: "Repository operational annotations"},
+		{Name: "Collaborators", Description: "Repository collaborators and their permissions"},
 		{Name: "Branches", Description: "Branch management operations"},
 		{Name: "Tags", Description: "Tag management operations"},
 		{Name: "Commits", Description: "Commit history and details"},
 		{Name: "Code", Description: "File tree, content, and blame information"},
 		{Name: "Git Protocol", Description: "Git Smart HTTP protocol endpoints"},
+		{Name: "Badges", Description: "Third-party README badge proxy"},
+		{Name: "Admin", Description: "Server administration"},
 	})
 
 	log.Info("Server initialized",
@@ -156,12 +154,50 @@ func New() *Server {
 		Engine:           engine,
 		OpenAPIGenerator: apiGen,
 		Config:           cfg,
-		DB:               db,
 		Logger:           log,
 		OTELProvider:     otelProvider,
+		Readiness:        NewReadiness(log),
 	}
 }
 
+// ConnectDatabase connects to the database and, in production, applies the
+// migrations. A database that is down or still starting is retried with
+// backoff until ctx is done, so the server can start alongside it.
+func (s *Server) ConnectDatabase(ctx context.Context) error {
+	cfg := &s.Config.Database
+
+	err := database.Retry(ctx, "connect", func(context.Context) error {
+		db, err := database.NewDatabase(cfg)
+		if err != nil {
+			return err
+		}
+		s.DB = db
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Logger.Info("Database connection established",
+		logger.String("host", cfg.Host),
+		logger.Int("port", cfg.Port),
+		logger.String("database", cfg.DBName),
+	)
+
+	// Run auto-migrations in production mode
+	if s.Config.IsProduction() {
+		s.Readiness.Set(StateDegraded, "applying database migrations")
+
+		migrator := database.NewMigrator(s.DB)
+		if err := database.Retry(ctx, "migrate", migrator.ApplyMigrations); err != nil {
+			return err
+		}
+
+		s.Logger.Info("Database migrations applied")
+	}
+	return nil
+}
+
 // Close gracefully shuts down the server and its resources
 func (s *Server) Close() error {
 	if s.Logger != nil {
@@ -177,6 +213,12 @@ func (s *Server) Close() error {
 		}
 	}
 
+	if s.DB != nil {
+		if err := s.DB.Close(); err != nil && s.Logger != nil {
+			s.Logger.Warn("Error closing database", logger.Error(err))
+		}
+	}
+
 	// Close logger
 	if s.Logger != nil {
 		return s.Logger.Close()
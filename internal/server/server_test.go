@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// anonymousAuth authenticates nobody, so requests are keyed by client IP
+type anonymousAuth struct{}
+
+func (anonymousAuth) AuthenticateToken(context.Context, string, service.RequestOrigin) (*models.User, error) {
+	return nil, errors.New("not supported")
+}
+
+func (anonymousAuth) AuthenticateSSH(context.Context, []byte) (*models.User, error) {
+	return nil, errors.New("not supported")
+}
+
+func (anonymousAuth) AuthenticateSession(context.Context, string) (*models.User, error) {
+	return nil, errors.New("not supported")
+}
+
+func (anonymousAuth) AuthenticateCIJobToken(context.Context, string) (*models.CIJobToken, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestRateLimitKeyTrustsOnlyConfiguredProxies(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   string
+		realIP         string
+		want           string
+	}{
+		{name: "no proxy", remoteAddr: "203.0.113.7:40000", want: "ip:203.0.113.7"},
+		{name: "spoofed X-Forwarded-For without trusted proxies", remoteAddr: "203.0.113.7:40000", forwardedFor: "198.51.100.1", want: "ip:203.0.113.7"},
+		{name: "spoofed X-Real-IP without trusted proxies", remoteAddr: "203.0.113.7:40000", realIP: "198.51.100.1", want: "ip:203.0.113.7"},
+		{name: "spoofed X-Forwarded-For from an untrusted peer", trustedProxies: []string{"10.0.0.0/8"}, remoteAddr: "203.0.113.7:40000", forwardedFor: "198.51.100.1", want: "ip:203.0.113.7"},
+		{name: "forwarded by a trusted proxy", trustedProxies: []string{"10.0.0.0/8"}, remoteAddr: "10.1.2.3:40000", forwardedFor: "198.51.100.1", want: "ip:198.51.100.1"},
+		{name: "client prepended a spoofed hop", trustedProxies: []string{"10.0.0.2"}, remoteAddr: "10.0.0.2:40000", forwardedFor: "192.0.2.66, 198.51.100.1", want: "ip:198.51.100.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Server: config.ServerConfig{Mode: "test", TrustedProxies: tt.trustedProxies}}
+			s := NewFromConfig(cfg, logger.Get(), nil)
+			auth := middleware.NewAuthMiddleware(anonymousAuth{})
+			s.GET("/key", func(c *gin.Context) {
+				c.String(http.StatusOK, auth.RateLimitKey(c))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/key", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("RateLimitKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
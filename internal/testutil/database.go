@@ -0,0 +1,89 @@
+package testutil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/infrastructure/database"
+)
+
+// DatabaseEnv enables the tests that need Postgres when set to a non-empty
+// value. The server is the one of the usual database settings
+// (STASIS_DATABASE_HOST, ...); its user must be allowed to create databases.
+const DatabaseEnv = "STASIS_TEST_DATABASE"
+
+// ErrNoDatabase is returned when DatabaseEnv is not set or, for a migrated
+// database, the atlas CLI, which applies the migrations, is not installed.
+// Tests needing the database are skipped.
+var ErrNoDatabase = errors.New("testutil: no test database, set " + DatabaseEnv + " and install the atlas CLI")
+
+// NewDatabase creates an empty database on the server of cfg, applies the
+// migrations to it and connects to it. drop closes the connection and drops
+// the database.
+func NewDatabase(ctx context.Context, cfg config.DatabaseConfig) (db *database.Database, drop func() error, err error) {
+	if _, err := exec.LookPath("atlas"); err != nil {
+		return nil, nil, ErrNoDatabase
+	}
+	return newDatabase(ctx, cfg, true)
+}
+
+// NewEmptyDatabase creates an empty database on the server of cfg, without
+// the migrations, and connects to it. drop closes the connection and drops
+// the database.
+func NewEmptyDatabase(ctx context.Context, cfg config.DatabaseConfig) (db *database.Database, drop func() error, err error) {
+	return newDatabase(ctx, cfg, false)
+}
+
+// newDatabase creates a database with a random name on the server of cfg,
+// applying the migrations to it if migrate is set
+func newDatabase(ctx context.Context, cfg config.DatabaseConfig, migrate bool) (db *database.Database, drop func() error, err error) {
+	if os.Getenv(DatabaseEnv) == "" {
+		return nil, nil, ErrNoDatabase
+	}
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, nil, err
+	}
+	name := "stasis_test_" + hex.EncodeToString(suffix)
+
+	admin, err := database.NewDatabase(&cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("testutil: failed to connect to the database server: %w", err)
+	}
+	err = admin.DB().WithContext(ctx).Exec(fmt.Sprintf(`CREATE DATABASE "%s"`, name)).Error
+	_ = admin.Close()
+	if err != nil {
+		return nil, nil, fmt.Errorf("testutil: failed to create database %s: %w", name, err)
+	}
+
+	drop = func() error {
+		if db != nil {
+			_ = db.Close()
+		}
+		admin, err := database.NewDatabase(&cfg)
+		if err != nil {
+			return err
+		}
+		defer admin.Close()
+		return admin.DB().Exec(fmt.Sprintf(`DROP DATABASE IF EXISTS "%s" WITH (FORCE)`, name)).Error
+	}
+
+	testCfg := cfg
+	testCfg.DBName = name
+	db, err = database.NewDatabase(&testCfg)
+	if err == nil && migrate {
+		err = database.NewMigrator(db).ApplyMigrations(ctx)
+	}
+	if err != nil {
+		_ = drop()
+		return nil, nil, fmt.Errorf("testutil: failed to prepare database %s: %w", name, err)
+	}
+	return db, drop, nil
+}
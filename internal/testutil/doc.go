@@ -0,0 +1,34 @@
+// Package testutil holds the fixtures of integration tests of git features,
+// and tests of git-facing features must use them rather than shelling out to
+// git or skipping coverage.
+//
+// RepoBuilder writes bare repositories with go-git: commits with fixed
+// authors and timestamps, so hashes are the same on every run, branches,
+// merges, annotated and lightweight tags, submodules, binary and large files
+// and renames:
+//
+//	b := testutil.TempRepo(t)
+//	first := b.Commit("main", "Initial commit", testutil.File("README.md", "# demo\n"))
+//	b.Commit("main", "Move readme", testutil.Rename("README.md", "docs/README.md"))
+//	b.AnnotatedTag("v1.0.0", first, "First release")
+//
+// Env runs the HTTP router, and on demand the SSH server, against a database
+// and storage root of its own. A package starts it once from TestMain and
+// seeds its own users and repositories per test:
+//
+//	func TestMain(m *testing.M) { testutil.Main(m) }
+//
+//	func TestListBranches(t *testing.T) {
+//		env := testutil.SharedEnv(t)
+//		owner := env.CreateUser(t, "owner")
+//		repo, b := env.CreateRepository(t, owner, "demo", false)
+//		b.Commit("main", "Initial commit", testutil.File("README.md", "# demo\n"))
+//		// GET env.URL("/api/v1/repos/" + owner.Username + "/" + repo.Name + "/branches")
+//	}
+//
+// Git clients reach a repository at env.CloneURL over HTTP, or at
+// env.SSHCloneURL with the private key env.AddSSHKey registers for a user.
+//
+// Tests using Env are skipped unless STASIS_TEST_DATABASE is set and the atlas
+// CLI is installed; see DatabaseEnv.
+package testutil
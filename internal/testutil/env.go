@@ -0,0 +1,352 @@
+package testutil
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/injectable"
+	"github.com/bravo68web/stasis/internal/server"
+	"github.com/bravo68web/stasis/internal/transport/http/router"
+	sshserver "github.com/bravo68web/stasis/internal/transport/ssh"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// LogLevelEnv sets the level of the server logs during tests, warn by default
+const LogLevelEnv = "STASIS_TEST_LOG_LEVEL"
+
+// Env is a running server for integration tests: the HTTP router on an
+// ephemeral port, optionally the SSH server, a database of its own and a
+// temporary storage root.
+type Env struct {
+	Config *config.Config
+	Server *server.Server
+	Deps   *injectable.Dependencies
+	HTTP   *httptest.Server
+
+	// StorageRoot is the base path of the default storage backend
+	StorageRoot string
+
+	dropDB   func() error
+	sshOnce  sync.Once
+	sshSrv   *sshserver.Server
+	sshAddr  string
+	sshErr   error
+	userSeq  int
+	userLock sync.Mutex
+}
+
+var (
+	sharedOnce sync.Once
+	sharedEnv  *Env
+	sharedErr  error
+)
+
+// Main runs the tests of a package and stops the environment SharedEnv
+// started, if any. Call it from TestMain:
+//
+//	func TestMain(m *testing.M) { testutil.Main(m) }
+func Main(m *testing.M) {
+	code := m.Run()
+	if sharedEnv != nil {
+		if err := sharedEnv.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "testutil: failed to stop environment: %v\n", err)
+		}
+	}
+	os.Exit(code)
+}
+
+// SharedEnv returns the environment of the tests of the package, starting it
+// on first use. The tests are skipped without a test database (see
+// DatabaseEnv). Starting an environment creates and migrates a database, so
+// the tests of a package share one and tell their data apart by creating
+// their own users and repositories.
+func SharedEnv(tb testing.TB) *Env {
+	tb.Helper()
+
+	sharedOnce.Do(func() {
+		sharedEnv, sharedErr = StartEnv(context.Background(), nil)
+	})
+	if errors.Is(sharedErr, ErrNoDatabase) {
+		tb.Skip(sharedErr)
+	}
+	if sharedErr != nil {
+		tb.Fatal(sharedErr)
+	}
+	return sharedEnv
+}
+
+// StartEnv starts an environment with the default configuration, changed by
+// configure if set. Stop it with Close.
+func StartEnv(ctx context.Context, configure func(*config.Config)) (*Env, error) {
+	cfg, err := config.Load("")
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := os.MkdirTemp("", "stasis-test-")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Server.Mode = "test"
+	cfg.Server.RateLimit.Enabled = false
+	cfg.Storage.StorageBackendConfig = config.StorageBackendConfig{Type: "filesystem", BasePath: filepath.Join(root, "repos")}
+	cfg.Storage.Backends = nil
+	cfg.SSH.Enabled = false
+	cfg.SSH.Host = "127.0.0.1"
+	cfg.SSH.HostKeyPath = filepath.Join(root, "ssh_host_key")
+	cfg.SSH.RSAHostKeyPath = ""
+	cfg.SSH.AutoGenerateHostKey = true
+	cfg.CI.Enabled = false
+	if configure != nil {
+		configure(cfg)
+	}
+
+	log, err := testLogger()
+	if err != nil {
+		_ = os.RemoveAll(root)
+		return nil, err
+	}
+
+	db, dropDB, err := NewDatabase(ctx, cfg.Database)
+	if err != nil {
+		_ = os.RemoveAll(root)
+		return nil, err
+	}
+
+	s := server.NewFromConfig(cfg, log, nil)
+	s.DB = db
+	deps := injectable.LoadDependencies(cfg, db)
+	r := router.NewRouter(s, deps)
+	r.RegisterRoutes()
+	s.Readiness.Set(server.StateReady, "")
+
+	env := &Env{
+		Config:      cfg,
+		Server:      s,
+		Deps:        r.Deps,
+		HTTP:        httptest.NewServer(s.Engine),
+		StorageRoot: cfg.Storage.BasePath,
+		dropDB: func() error {
+			err := dropDB()
+			return errors.Join(err, os.RemoveAll(root))
+		},
+	}
+	return env, nil
+}
+
+// Close stops the servers, drops the database and removes the storage root
+func (e *Env) Close() error {
+	e.HTTP.Close()
+
+	var errs []error
+	if e.sshSrv != nil {
+		errs = append(errs, e.sshSrv.Shutdown(context.Background()))
+	}
+	e.Deps.Close()
+	errs = append(errs, e.dropDB())
+	return errors.Join(errs...)
+}
+
+// URL returns the URL of a path on the HTTP server
+func (e *Env) URL(path string) string {
+	return e.HTTP.URL + path
+}
+
+// CloneURL returns the HTTP clone URL of a repository
+func (e *Env) CloneURL(owner, name string) string {
+	return fmt.Sprintf("%s/%s/%s.git", e.HTTP.URL, owner, name)
+}
+
+// SSHAddr starts the SSH server on an ephemeral port on first use and returns
+// its address
+func (e *Env) SSHAddr(tb testing.TB) string {
+	tb.Helper()
+
+	e.sshOnce.Do(func() {
+		e.sshSrv, e.sshErr = sshserver.NewServer(
+			&e.Config.SSH,
+			&e.Config.Storage,
+			e.Deps.AuthService,
+			e.Deps.RepoService,
+			e.Deps.TagProtection,
+			e.Deps.BranchProtection,
+			e.Deps.Contributions,
+			e.Deps.Licenses,
+			e.Deps.PinnedLinks,
+			e.Deps.Languages,
+			e.Deps.CIService,
+			e.Deps.GitService,
+			e.Deps.GitProtocol,
+			e.Deps.StorageBackends,
+			e.Deps.PushAttempts,
+			e.Deps.Quotas,
+			e.Deps.DegradedMode,
+			e.Deps.EventBus,
+		)
+		if e.sshErr != nil {
+			return
+		}
+
+		var l net.Listener
+		l, e.sshErr = net.Listen("tcp", "127.0.0.1:0")
+		if e.sshErr != nil {
+			return
+		}
+		e.sshAddr = l.Addr().String()
+		go func() {
+			if err := e.sshSrv.Serve(l); err != nil && !errors.Is(err, ssh.ErrServerClosed) {
+				logger.Get().Error("Test SSH server stopped", logger.Error(err))
+			}
+		}()
+	})
+	if e.sshErr != nil {
+		tb.Fatalf("testutil: failed to start SSH server: %v", e.sshErr)
+	}
+	return e.sshAddr
+}
+
+// SSHCloneURL starts the SSH server on first use and returns the SSH clone
+// URL of a repository
+func (e *Env) SSHCloneURL(tb testing.TB, owner, name string) string {
+	tb.Helper()
+	return fmt.Sprintf("ssh://git@%s/%s/%s.git", e.SSHAddr(tb), owner, name)
+}
+
+// AddSSHKey registers a new ed25519 key of a user and returns the path of its
+// private key, in the OpenSSH format ssh -i reads
+func (e *Env) AddSSHKey(tb testing.TB, user *models.User) string {
+	tb.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		tb.Fatalf("testutil: failed to generate SSH key: %v", err)
+	}
+	sshPub, err := gossh.NewPublicKey(pub)
+	if err != nil {
+		tb.Fatalf("testutil: failed to encode SSH public key: %v", err)
+	}
+	block, err := gossh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		tb.Fatalf("testutil: failed to encode SSH private key: %v", err)
+	}
+
+	_, err = e.Deps.SSHKeyService.AddSSHKey(context.Background(), service.AddSSHKeyRequest{
+		UserID:    user.ID,
+		Title:     "test",
+		PublicKey: string(gossh.MarshalAuthorizedKey(sshPub)),
+	})
+	if err != nil {
+		tb.Fatalf("testutil: failed to add SSH key of %s: %v", user.Username, err)
+	}
+
+	path := filepath.Join(tb.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		tb.Fatalf("testutil: failed to write SSH private key: %v", err)
+	}
+	return path
+}
+
+// CreateUser creates a user whose name starts with prefix and is unique in
+// the environment
+func (e *Env) CreateUser(tb testing.TB, prefix string) *models.User {
+	tb.Helper()
+	return e.createUser(tb, prefix, false)
+}
+
+// CreateAdmin creates a site administrator whose name starts with prefix
+func (e *Env) CreateAdmin(tb testing.TB, prefix string) *models.User {
+	tb.Helper()
+	return e.createUser(tb, prefix, true)
+}
+
+// CreateToken creates a personal access token of a user and returns it;
+// no scopes give repo:read and repo:write
+func (e *Env) CreateToken(tb testing.TB, user *models.User, scopes ...string) string {
+	tb.Helper()
+
+	resp, err := e.Deps.TokenService.CreateToken(context.Background(), service.CreateTokenRequest{
+		UserID: user.ID,
+		Name:   "test",
+		Scopes: scopes,
+	})
+	if err != nil {
+		tb.Fatalf("testutil: failed to create token of %s: %v", user.Username, err)
+	}
+	return resp.RawToken
+}
+
+// CreateRepository creates the record of a repository of owner on the default
+// storage backend, and returns it with a builder of its empty bare repository
+func (e *Env) CreateRepository(tb testing.TB, owner *models.User, name string, private bool) (*models.Repository, *RepoBuilder) {
+	tb.Helper()
+
+	builder := NewRepoBuilder(tb, filepath.Join(e.StorageRoot, owner.Username, name+".git"))
+	repo := &models.Repository{
+		Name:           name,
+		OwnerID:        owner.ID,
+		Owner:          *owner,
+		IsPrivate:      private,
+		DefaultBranch:  "main",
+		GitPath:        builder.Path(),
+		StorageBackend: config.DefaultStorageBackend,
+	}
+	if err := e.Server.DB.DB().Omit("Owner").Create(repo).Error; err != nil {
+		tb.Fatalf("testutil: failed to create repository %s/%s: %v", owner.Username, name, err)
+	}
+	return repo, builder
+}
+
+// createUser creates a user with a unique name
+func (e *Env) createUser(tb testing.TB, prefix string, admin bool) *models.User {
+	tb.Helper()
+
+	e.userLock.Lock()
+	e.userSeq++
+	username := fmt.Sprintf("%s%d", strings.ToLower(prefix), e.userSeq)
+	e.userLock.Unlock()
+
+	user := &models.User{
+		Username: username,
+		Email:    username + "@example.com",
+		IsAdmin:  admin,
+	}
+	if err := e.Server.DB.DB().Create(user).Error; err != nil {
+		tb.Fatalf("testutil: failed to create user %s: %v", username, err)
+	}
+	return user
+}
+
+// testLogger returns the global logger, set to the level of LogLevelEnv
+func testLogger() (*logger.Logger, error) {
+	level := os.Getenv(LogLevelEnv)
+	if level == "" {
+		level = "warn"
+	}
+
+	cfg := logger.DefaultConfig()
+	cfg.Level = level
+	cfg.Format = "console"
+	log, err := logger.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	logger.SetGlobal(log)
+	return log, nil
+}
@@ -0,0 +1,92 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMain(m *testing.M) { Main(m) }
+
+func TestStartEnvWithoutDatabase(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	t.Setenv(DatabaseEnv, "")
+
+	env, err := StartEnv(context.Background(), nil)
+	if !errors.Is(err, ErrNoDatabase) {
+		t.Fatalf("StartEnv = %v, %v, want ErrNoDatabase", env, err)
+	}
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("StartEnv left %d entries in the temporary directory", len(entries))
+	}
+}
+
+func TestEnv(t *testing.T) {
+	env, err := StartEnv(context.Background(), nil)
+	if errors.Is(err, ErrNoDatabase) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := false
+	t.Cleanup(func() {
+		if !closed {
+			_ = env.Close()
+		}
+	})
+
+	owner := env.CreateUser(t, "Owner")
+	other := env.CreateUser(t, "Owner")
+	if owner.Username == other.Username {
+		t.Errorf("created two users named %s", owner.Username)
+	}
+	repo, b := env.CreateRepository(t, owner, "demo", true)
+	b.Commit("main", "Initial commit", File("README.md", "# demo\n"))
+	if want := filepath.Join(env.StorageRoot, owner.Username, "demo.git"); repo.GitPath != want {
+		t.Errorf("repository at %s, want %s", repo.GitPath, want)
+	}
+
+	get := func(token string) int {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, env.URL("/api/v1/repos/"+owner.Username+"/demo/branches"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "token "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if got := get(env.CreateToken(t, owner)); got != http.StatusOK {
+		t.Errorf("owner listing branches = %d, want %d", got, http.StatusOK)
+	}
+	if got := get(""); got != http.StatusNotFound {
+		t.Errorf("anonymous listing branches of a private repository = %d, want %d", got, http.StatusNotFound)
+	}
+
+	root := filepath.Dir(env.StorageRoot)
+	closed = true
+	if err := env.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Errorf("Close left the storage root %s: %v", root, err)
+	}
+	if _, err := http.Get(env.URL("/healthz")); err == nil {
+		t.Error("the HTTP server still answers after Close")
+	}
+}
@@ -0,0 +1,470 @@
+package testutil
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Epoch is the time of the first commit a RepoBuilder writes; every later
+// commit and tag is one minute after the previous one
+var Epoch = time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// DefaultAuthor is the author and committer of the commits of a RepoBuilder
+// until SetAuthor changes them
+var DefaultAuthor = object.Signature{Name: "Test Author", Email: "author@example.com"}
+
+// RepoBuilder writes the history of a bare repository object by object, so
+// the same calls always produce the same commit hashes. Failures end the test.
+type RepoBuilder struct {
+	tb     testing.TB
+	path   string
+	repo   *git.Repository
+	author object.Signature
+	clock  int
+
+	// trees holds the files of every commit written, by commit hash
+	trees map[plumbing.Hash]fileSet
+}
+
+// NewRepoBuilder initializes a bare repository at path with HEAD on main
+func NewRepoBuilder(tb testing.TB, path string) *RepoBuilder {
+	tb.Helper()
+
+	repo, err := git.PlainInitWithOptions(path, &git.PlainInitOptions{
+		Bare:        true,
+		InitOptions: git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")},
+	})
+	if err != nil {
+		tb.Fatalf("testutil: failed to initialize repository at %s: %v", path, err)
+	}
+
+	return &RepoBuilder{
+		tb:     tb,
+		path:   path,
+		repo:   repo,
+		author: DefaultAuthor,
+		trees:  make(map[plumbing.Hash]fileSet),
+	}
+}
+
+// TempRepo initializes a bare repository in a temporary directory removed
+// when the test ends
+func TempRepo(tb testing.TB) *RepoBuilder {
+	tb.Helper()
+	return NewRepoBuilder(tb, filepath.Join(tb.TempDir(), "repo.git"))
+}
+
+// Path returns the directory of the repository
+func (b *RepoBuilder) Path() string {
+	return b.path
+}
+
+// Repository returns the repository for assertions
+func (b *RepoBuilder) Repository() *git.Repository {
+	return b.repo
+}
+
+// SetAuthor sets the author and committer of the next commits
+func (b *RepoBuilder) SetAuthor(name, email string) {
+	b.author = object.Signature{Name: name, Email: email}
+}
+
+// Commit writes a commit on top of branch applying changes to its files,
+// moves branch to it and returns its hash. A branch that does not exist yet
+// starts with a root commit.
+func (b *RepoBuilder) Commit(branch, message string, changes ...Change) plumbing.Hash {
+	b.tb.Helper()
+
+	var parents []plumbing.Hash
+	files := fileSet{}
+	if tip, ok := b.tip(branch); ok {
+		parents = []plumbing.Hash{tip}
+		files = b.files(tip).clone()
+	}
+
+	for _, change := range changes {
+		if err := change.apply(b, &files); err != nil {
+			b.tb.Fatalf("testutil: commit %q on %s: %v", message, branch, err)
+		}
+	}
+
+	treeHash := b.writeTree(files)
+	sig := b.signature()
+	commit := &object.Commit{
+		Author:       sig,
+		Committer:    sig,
+		Message:      message + "\n",
+		TreeHash:     treeHash,
+		ParentHashes: parents,
+	}
+	hash := b.writeObject(commit)
+	b.trees[hash] = files
+	b.setRef(plumbing.NewBranchReferenceName(branch), hash)
+	return hash
+}
+
+// Merge writes a merge commit of from into branch taking the files of
+// branch, and returns its hash
+func (b *RepoBuilder) Merge(branch, from, message string) plumbing.Hash {
+	b.tb.Helper()
+
+	tip, ok := b.tip(branch)
+	if !ok {
+		b.tb.Fatalf("testutil: merge into %s: branch does not exist", branch)
+	}
+	other, ok := b.tip(from)
+	if !ok {
+		b.tb.Fatalf("testutil: merge of %s: branch does not exist", from)
+	}
+
+	files := b.files(tip)
+	sig := b.signature()
+	commit := &object.Commit{
+		Author:       sig,
+		Committer:    sig,
+		Message:      message + "\n",
+		TreeHash:     b.writeTree(files),
+		ParentHashes: []plumbing.Hash{tip, other},
+	}
+	hash := b.writeObject(commit)
+	b.trees[hash] = files
+	b.setRef(plumbing.NewBranchReferenceName(branch), hash)
+	return hash
+}
+
+// Branch points branch at a commit
+func (b *RepoBuilder) Branch(branch string, at plumbing.Hash) {
+	b.tb.Helper()
+	b.setRef(plumbing.NewBranchReferenceName(branch), at)
+}
+
+// LightweightTag points tag at a commit
+func (b *RepoBuilder) LightweightTag(tag string, at plumbing.Hash) {
+	b.tb.Helper()
+	b.setRef(plumbing.NewTagReferenceName(tag), at)
+}
+
+// AnnotatedTag writes a tag object for a commit, points tag at it and
+// returns its hash
+func (b *RepoBuilder) AnnotatedTag(tag string, at plumbing.Hash, message string) plumbing.Hash {
+	b.tb.Helper()
+
+	hash := b.writeObject(&object.Tag{
+		Name:       tag,
+		Tagger:     b.signature(),
+		Message:    message + "\n",
+		TargetType: plumbing.CommitObject,
+		Target:     at,
+	})
+	b.setRef(plumbing.NewTagReferenceName(tag), hash)
+	return hash
+}
+
+// SetHEAD points HEAD at branch, the default branch of the repository
+func (b *RepoBuilder) SetHEAD(branch string) {
+	b.tb.Helper()
+
+	ref := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(branch))
+	if err := b.repo.Storer.SetReference(ref); err != nil {
+		b.tb.Fatalf("testutil: failed to set HEAD to %s: %v", branch, err)
+	}
+}
+
+// tip returns the commit branch points at
+func (b *RepoBuilder) tip(branch string) (plumbing.Hash, bool) {
+	ref, err := b.repo.Reference(plumbing.NewBranchReferenceName(branch), false)
+	if err != nil {
+		return plumbing.ZeroHash, false
+	}
+	return ref.Hash(), true
+}
+
+// files returns the files of a commit written by the builder
+func (b *RepoBuilder) files(commit plumbing.Hash) fileSet {
+	files, ok := b.trees[commit]
+	if !ok {
+		b.tb.Fatalf("testutil: commit %s was not written by this builder", commit)
+	}
+	return files
+}
+
+// signature returns the author and committer signature of the next commit or
+// tag, advancing the clock by a minute
+func (b *RepoBuilder) signature() object.Signature {
+	sig := b.author
+	sig.When = Epoch.Add(time.Duration(b.clock) * time.Minute)
+	b.clock++
+	return sig
+}
+
+// setRef points a ref at an object
+func (b *RepoBuilder) setRef(name plumbing.ReferenceName, hash plumbing.Hash) {
+	b.tb.Helper()
+	if err := b.repo.Storer.SetReference(plumbing.NewHashReference(name, hash)); err != nil {
+		b.tb.Fatalf("testutil: failed to set %s: %v", name, err)
+	}
+}
+
+// encodable is an object go-git can encode: a commit, tree or tag
+type encodable interface {
+	Encode(plumbing.EncodedObject) error
+}
+
+// writeObject stores an object and returns its hash
+func (b *RepoBuilder) writeObject(obj encodable) plumbing.Hash {
+	b.tb.Helper()
+
+	encoded := b.repo.Storer.NewEncodedObject()
+	if err := obj.Encode(encoded); err != nil {
+		b.tb.Fatalf("testutil: failed to encode object: %v", err)
+	}
+	hash, err := b.repo.Storer.SetEncodedObject(encoded)
+	if err != nil {
+		b.tb.Fatalf("testutil: failed to store object: %v", err)
+	}
+	return hash
+}
+
+// writeBlob stores a blob and returns its hash
+func (b *RepoBuilder) writeBlob(data []byte) plumbing.Hash {
+	b.tb.Helper()
+
+	encoded := b.repo.Storer.NewEncodedObject()
+	encoded.SetType(plumbing.BlobObject)
+	encoded.SetSize(int64(len(data)))
+	w, err := encoded.Writer()
+	if err == nil {
+		_, err = w.Write(data)
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		b.tb.Fatalf("testutil: failed to write blob: %v", err)
+	}
+	hash, err := b.repo.Storer.SetEncodedObject(encoded)
+	if err != nil {
+		b.tb.Fatalf("testutil: failed to store blob: %v", err)
+	}
+	return hash
+}
+
+// writeTree stores the trees of a set of files and returns the hash of the
+// root tree
+func (b *RepoBuilder) writeTree(files fileSet) plumbing.Hash {
+	b.tb.Helper()
+
+	entries := make(map[string]fileEntry, len(files.entries)+1)
+	for p, entry := range files.entries {
+		entries[p] = entry
+	}
+	if len(files.submodules) > 0 {
+		entries[".gitmodules"] = fileEntry{mode: filemode.Regular, hash: b.writeBlob(files.gitmodules())}
+	}
+	return b.writeDir(entries, "")
+}
+
+// writeDir stores the tree of the directory dir ("" for the root) holding
+// entries, keyed by their path from the root
+func (b *RepoBuilder) writeDir(entries map[string]fileEntry, dir string) plumbing.Hash {
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+
+	tree := &object.Tree{}
+	subdirs := map[string]bool{}
+	for p, entry := range entries {
+		rel, ok := strings.CutPrefix(p, prefix)
+		if !ok {
+			continue
+		}
+		if name, _, nested := strings.Cut(rel, "/"); nested {
+			subdirs[name] = true
+		} else {
+			tree.Entries = append(tree.Entries, object.TreeEntry{Name: rel, Mode: entry.mode, Hash: entry.hash})
+		}
+	}
+	for name := range subdirs {
+		hash := b.writeDir(entries, prefix+name)
+		tree.Entries = append(tree.Entries, object.TreeEntry{Name: name, Mode: filemode.Dir, Hash: hash})
+	}
+
+	// git orders entries by name, comparing directories as if they ended in "/"
+	slices.SortFunc(tree.Entries, func(a, c object.TreeEntry) int {
+		return strings.Compare(treeSortKey(a), treeSortKey(c))
+	})
+	return b.writeObject(tree)
+}
+
+// treeSortKey returns the name git sorts a tree entry by
+func treeSortKey(entry object.TreeEntry) string {
+	if entry.Mode == filemode.Dir {
+		return entry.Name + "/"
+	}
+	return entry.Name
+}
+
+// fileEntry is a file of a commit: a blob, or a commit for a submodule
+type fileEntry struct {
+	mode filemode.FileMode
+	hash plumbing.Hash
+}
+
+// fileSet holds the files of a commit by path, and the URL of its submodules
+type fileSet struct {
+	entries    map[string]fileEntry
+	submodules map[string]string
+}
+
+// clone returns a copy of the set to change for a child commit
+func (f fileSet) clone() fileSet {
+	c := fileSet{
+		entries:    make(map[string]fileEntry, len(f.entries)),
+		submodules: make(map[string]string, len(f.submodules)),
+	}
+	for p, entry := range f.entries {
+		c.entries[p] = entry
+	}
+	for p, url := range f.submodules {
+		c.submodules[p] = url
+	}
+	return c
+}
+
+// set adds or replaces a file
+func (f *fileSet) set(p string, entry fileEntry) {
+	if f.entries == nil {
+		f.entries = make(map[string]fileEntry)
+	}
+	f.entries[p] = entry
+}
+
+// gitmodules renders the .gitmodules file of the submodules
+func (f fileSet) gitmodules() []byte {
+	paths := make([]string, 0, len(f.submodules))
+	for p := range f.submodules {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+
+	var sb strings.Builder
+	for _, p := range paths {
+		fmt.Fprintf(&sb, "[submodule %q]\n\tpath = %s\n\turl = %s\n", p, p, f.submodules[p])
+	}
+	return []byte(sb.String())
+}
+
+// Change is a change to the files of a commit written by RepoBuilder.Commit
+type Change struct {
+	apply func(b *RepoBuilder, files *fileSet) error
+}
+
+// File writes a regular file
+func File(p, content string) Change {
+	return BinaryFile(p, []byte(content))
+}
+
+// Executable writes an executable file
+func Executable(p, content string) Change {
+	return Change{apply: func(b *RepoBuilder, files *fileSet) error {
+		if err := checkPath(p); err != nil {
+			return err
+		}
+		files.set(p, fileEntry{mode: filemode.Executable, hash: b.writeBlob([]byte(content))})
+		return nil
+	}}
+}
+
+// BinaryFile writes a file with arbitrary bytes
+func BinaryFile(p string, data []byte) Change {
+	return Change{apply: func(b *RepoBuilder, files *fileSet) error {
+		if err := checkPath(p); err != nil {
+			return err
+		}
+		files.set(p, fileEntry{mode: filemode.Regular, hash: b.writeBlob(data)})
+		return nil
+	}}
+}
+
+// LargeFile writes a file of size bytes that does not compress, the same
+// for the same path and size
+func LargeFile(p string, size int) Change {
+	seed := fnv.New64a()
+	seed.Write([]byte(p))
+	rng := rand.New(rand.NewPCG(seed.Sum64(), uint64(size)))
+
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(rng.Uint32())
+	}
+	return BinaryFile(p, data)
+}
+
+// Delete removes a file or submodule
+func Delete(p string) Change {
+	return Change{apply: func(_ *RepoBuilder, files *fileSet) error {
+		if _, ok := files.entries[p]; !ok {
+			return fmt.Errorf("delete %s: no such file", p)
+		}
+		delete(files.entries, p)
+		delete(files.submodules, p)
+		return nil
+	}}
+}
+
+// Rename moves a file unchanged, so git detects the rename
+func Rename(from, to string) Change {
+	return Change{apply: func(_ *RepoBuilder, files *fileSet) error {
+		entry, ok := files.entries[from]
+		if !ok {
+			return fmt.Errorf("rename %s: no such file", from)
+		}
+		if err := checkPath(to); err != nil {
+			return err
+		}
+		delete(files.entries, from)
+		files.set(to, entry)
+		if url, ok := files.submodules[from]; ok {
+			delete(files.submodules, from)
+			files.submodules[to] = url
+		}
+		return nil
+	}}
+}
+
+// Submodule adds a submodule at p checked out at commit of the repository at
+// url, and lists it in .gitmodules
+func Submodule(p, url string, commit plumbing.Hash) Change {
+	return Change{apply: func(_ *RepoBuilder, files *fileSet) error {
+		if err := checkPath(p); err != nil {
+			return err
+		}
+		files.set(p, fileEntry{mode: filemode.Submodule, hash: commit})
+		if files.submodules == nil {
+			files.submodules = make(map[string]string)
+		}
+		files.submodules[p] = url
+		return nil
+	}}
+}
+
+// checkPath rejects paths git would not store
+func checkPath(p string) error {
+	if p == "" || p == ".gitmodules" || path.Clean(p) != p || strings.HasPrefix(p, "/") || strings.HasPrefix(p, "../") {
+		return fmt.Errorf("invalid path %q", p)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package testutil
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// buildHistory writes the same history with every kind of change into b
+func buildHistory(b *RepoBuilder) {
+	first := b.Commit("main", "Initial commit",
+		File("README.md", "# demo\n"),
+		Executable("bin/run", "#!/bin/sh\n"),
+		BinaryFile("logo.png", []byte{0x89, 'P', 'N', 'G', 0}),
+	)
+	b.AnnotatedTag("v1.0.0", first, "First release")
+	b.Commit("feature", "Add large file", LargeFile("data.bin", 4096))
+	b.SetAuthor("Other Author", "other@example.com")
+	b.Commit("main", "Move readme", Rename("README.md", "docs/README.md"), Delete("logo.png"))
+	merge := b.Merge("main", "feature", "Merge feature")
+	b.LightweightTag("latest", merge)
+	b.Commit("main", "Add submodule", Submodule("vendor/lib", "https://example.com/lib.git", first))
+}
+
+// refs returns the hash of every ref of a repository by name
+func refs(t *testing.T, b *RepoBuilder) map[plumbing.ReferenceName]plumbing.Hash {
+	t.Helper()
+	iter, err := b.Repository().References()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := map[plumbing.ReferenceName]plumbing.Hash{}
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference {
+			hashes[ref.Name()] = ref.Hash()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hashes
+}
+
+func TestRepoBuilderIsDeterministic(t *testing.T) {
+	a, b := TempRepo(t), TempRepo(t)
+	buildHistory(a)
+	buildHistory(b)
+
+	got, want := refs(t, a), refs(t, b)
+	if !maps.Equal(got, want) {
+		t.Fatalf("refs differ between builds:\n%v\n%v", got, want)
+	}
+	names := slices.Sorted(maps.Keys(got))
+	wantNames := []plumbing.ReferenceName{"refs/heads/feature", "refs/heads/main", "refs/tags/latest", "refs/tags/v1.0.0"}
+	if !slices.Equal(names, wantNames) {
+		t.Errorf("refs = %v, want %v", names, wantNames)
+	}
+
+	// Hashes are the same on every run, not only within one: this is the hash
+	// git commit-tree gives for the same tree, author and dates
+	c := TempRepo(t)
+	root := c.Commit("main", "Initial commit", File("README.md", "# demo\n"))
+	if want := "5b2c8769ead3be5afc55a3c5bf6f7bcbb71fe1b2"; root.String() != want {
+		t.Errorf("root commit = %s, want %s", root, want)
+	}
+}
+
+func TestRepoBuilderHistory(t *testing.T) {
+	b := TempRepo(t)
+	buildHistory(b)
+	repo := b.Repository()
+
+	head, err := repo.Reference(plumbing.NewBranchReferenceName("main"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tip, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tip.Author.Name != "Other Author" || !tip.Author.When.Equal(Epoch.Add(5*time.Minute)) {
+		t.Errorf("tip authored by %s at %s, want Other Author at %s", tip.Author.Name, tip.Author.When, Epoch.Add(5*time.Minute))
+	}
+
+	files := map[string]filemode.FileMode{}
+	tree, err := tip.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err != nil {
+			break
+		}
+		if entry.Mode != filemode.Dir {
+			files[name] = entry.Mode
+		}
+	}
+	wantFiles := map[string]filemode.FileMode{
+		".gitmodules":    filemode.Regular,
+		"bin/run":        filemode.Executable,
+		"docs/README.md": filemode.Regular,
+		"vendor/lib":     filemode.Submodule,
+	}
+	if !maps.Equal(files, wantFiles) {
+		t.Errorf("files = %v, want %v", files, wantFiles)
+	}
+
+	gitmodules, err := tree.File(".gitmodules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := gitmodules.Contents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[submodule \"vendor/lib\"]\n\tpath = vendor/lib\n\turl = https://example.com/lib.git\n"; content != want {
+		t.Errorf(".gitmodules = %q, want %q", content, want)
+	}
+
+	merge, err := tip.Parent(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merge.NumParents() != 2 {
+		t.Errorf("merge commit has %d parents, want 2", merge.NumParents())
+	}
+
+	ref, err := repo.Reference(plumbing.NewTagReferenceName("v1.0.0"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, err := repo.TagObject(ref.Hash())
+	if err != nil {
+		t.Fatalf("v1.0.0 is not an annotated tag: %v", err)
+	}
+	if tag.Message != "First release\n" || tag.TargetType != plumbing.CommitObject {
+		t.Errorf("tag = %q on a %s, want %q on a commit", tag.Message, tag.TargetType, "First release\n")
+	}
+}
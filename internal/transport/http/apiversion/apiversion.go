@@ -0,0 +1,103 @@
+// Package apiversion negotiates the version of the REST API a request is
+// answered with, so response shapes can evolve without breaking clients.
+//
+// Versions are dates. Clients pin one with the X-Githut-Api-Version header;
+// requests without it get the configured default, the latest version unless
+// the deployment opts for the oldest. The negotiated version is echoed in the
+// same response header. Handlers whose response differs between versions ask
+// AtLeast whether the request gets the newer shape.
+package apiversion
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header is the request and response header carrying the API version
+const Header = "X-Githut-Api-Version"
+
+// API versions, oldest first. Each one after the first names the change it
+// introduced.
+const (
+	// Initial is the API as it was before versioning
+	Initial = "2025-01-01"
+
+	// CommitPeople nests the author and committer of commits into
+	// {name, email, date} objects instead of flat fields
+	CommitPeople = "2025-06-01"
+)
+
+// Supported lists the supported versions, oldest first
+var Supported = []string{Initial, CommitPeople}
+
+// Default policies for requests without a version
+const (
+	DefaultLatest = "latest"
+	DefaultOldest = "oldest"
+)
+
+// Latest returns the newest supported version
+func Latest() string {
+	return Supported[len(Supported)-1]
+}
+
+// Oldest returns the oldest supported version
+func Oldest() string {
+	return Supported[0]
+}
+
+// Default returns the version of requests without one under a policy
+func Default(policy string) string {
+	if policy == DefaultOldest {
+		return Oldest()
+	}
+	return Latest()
+}
+
+// contextKey is the request context key of the negotiated version
+type contextKey struct{}
+
+// Middleware negotiates the API version of each request. An unsupported
+// version is rejected with 400 and the list of supported versions.
+func Middleware(defaultVersion string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", Header)
+
+		version := c.GetHeader(Header)
+		if version == "" {
+			version = defaultVersion
+		} else if !slices.Contains(Supported, version) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "bad_request",
+				"message": "Unsupported API version",
+				"details": gin.H{
+					"version":   version,
+					"supported": Supported,
+				},
+			})
+			return
+		}
+
+		c.Header(Header, version)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), contextKey{}, version))
+		c.Next()
+	}
+}
+
+// FromContext returns the API version negotiated for a request, or the
+// oldest version outside a negotiated request
+func FromContext(ctx context.Context) string {
+	if version, ok := ctx.Value(contextKey{}).(string); ok {
+		return version
+	}
+	return Oldest()
+}
+
+// AtLeast returns true if the request is answered with version or a newer one
+func AtLeast(c *gin.Context, version string) bool {
+	// Versions are ISO dates, which order as strings
+	return FromContext(c.Request.Context()) >= version
+}
@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// AnnotationHandler handles repository annotation HTTP requests
+type AnnotationHandler struct {
+	repoService       *service.RepoService
+	annotationService *service.AnnotationService
+	log               *logger.Logger
+}
+
+// NewAnnotationHandler creates a new AnnotationHandler instance
+func NewAnnotationHandler(
+	repoService *service.RepoService,
+	annotationService *service.AnnotationService,
+) *AnnotationHandler {
+	return &AnnotationHandler{
+		repoService:       repoService,
+		annotationService: annotationService,
+		log:               logger.Get().WithFields(logger.Component("annotation-handler")),
+	}
+}
+
+// ListAnnotations handles GET /api/v1/repos/:owner/:repo/annotations
+func (h *AnnotationHandler) ListAnnotations(c *gin.Context) {
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return
+	}
+
+	annotations, err := h.annotationService.ListAnnotations(c.Request.Context(), repo)
+	if err != nil {
+		h.log.WithContext(c.Request.Context()).Error("Failed to list annotations",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.AnnotationListFromModels(annotations))
+}
+
+// GetAnnotation handles GET /api/v1/repos/:owner/:repo/annotations/:key
+func (h *AnnotationHandler) GetAnnotation(c *gin.Context) {
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return
+	}
+
+	annotation, err := h.annotationService.GetAnnotation(c.Request.Context(), repo, c.Param("key"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.AnnotationFromModel(annotation))
+}
+
+// CreateAnnotation handles POST /api/v1/repos/:owner/:repo/annotations
+func (h *AnnotationHandler) CreateAnnotation(c *gin.Context) {
+	repo, user, ok := h.getWritableRepository(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateAnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	annotation, err := h.annotationService.CreateAnnotation(c.Request.Context(), repo, user.ID, req.Key, req.Value)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.AnnotationFromModel(annotation))
+}
+
+// UpdateAnnotation handles PUT /api/v1/repos/:owner/:repo/annotations/:key
+func (h *AnnotationHandler) UpdateAnnotation(c *gin.Context) {
+	repo, user, ok := h.getWritableRepository(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateAnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	annotation, err := h.annotationService.UpdateAnnotation(c.Request.Context(), repo, user.ID, c.Param("key"), req.Value)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.AnnotationFromModel(annotation))
+}
+
+// DeleteAnnotation handles DELETE /api/v1/repos/:owner/:repo/annotations/:key
+func (h *AnnotationHandler) DeleteAnnotation(c *gin.Context) {
+	repo, _, ok := h.getWritableRepository(c)
+	if !ok {
+		return
+	}
+
+	if err := h.annotationService.DeleteAnnotation(c.Request.Context(), repo, c.Param("key")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Annotation deleted successfully",
+	})
+}
+
+// getReadableRepository loads the repository from the path and checks read access.
+// It writes the error response and returns false if the request cannot proceed.
+func (h *AnnotationHandler) getReadableRepository(c *gin.Context) (*models.Repository, bool) {
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleRepoError(c, err)
+		return nil, false
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return nil, false
+	}
+
+	return repo, true
+}
+
+// getWritableRepository loads the repository from the path and checks that the
+// authenticated user administers it (owner, site admin or admin collaborator).
+func (h *AnnotationHandler) getWritableRepository(c *gin.Context) (*models.Repository, *models.User, bool) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return nil, nil, false
+	}
+
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionAdmin) {
+		h.log.WithContext(c.Request.Context()).Warn("User attempted to modify annotations without permission",
+			logger.String("user_id", user.ID.String()),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Only repository administrators can modify annotations",
+		})
+		return nil, nil, false
+	}
+
+	return repo, user, true
+}
+
+// handleRepoError handles errors from repository lookups
+func (h *AnnotationHandler) handleRepoError(c *gin.Context, err error) {
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+	h.handleError(c, err)
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *AnnotationHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Annotation not found",
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsConflict(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "conflict",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsForbidden(err) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// AuditEventHandler handles audit event HTTP requests
+type AuditEventHandler struct {
+	repoService *service.RepoService
+	auditEvents *service.AuditEventService
+	log         *logger.Logger
+}
+
+// NewAuditEventHandler creates a new AuditEventHandler instance
+func NewAuditEventHandler(
+	repoService *service.RepoService,
+	auditEvents *service.AuditEventService,
+) *AuditEventHandler {
+	return &AuditEventHandler{
+		repoService: repoService,
+		auditEvents: auditEvents,
+		log:         logger.Get().WithFields(logger.Component("audit-event-handler")),
+	}
+}
+
+// ListRepositoryEvents handles GET /api/v1/repos/:owner/:repo/events.
+// Client IP addresses are only shown to repository administrators.
+func (h *AuditEventHandler) ListRepositoryEvents(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	permission := h.repoService.RepositoryPermission(c.Request.Context(), user, repo)
+	if !permission.Allows(models.RepoPermissionRead) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
+	}
+	filter := repository.AuditEventFilter{
+		RepositoryID: &repo.ID,
+		Action:       c.Query("action"),
+	}
+	auditEvents, total, err := h.auditEvents.ListEvents(c.Request.Context(), filter, page.PerPage, page.Offset)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	showIP := permission.Allows(models.RepoPermissionAdmin)
+	responses := make([]dto.AuditEventResponse, 0, len(auditEvents))
+	for _, event := range auditEvents {
+		resp := dto.AuditEventFromModel(event)
+		if !showIP {
+			resp.IP = ""
+		}
+		responses = append(responses, resp)
+	}
+
+	c.JSON(http.StatusOK, dto.ListAuditEventsResponse{
+		Events:     responses,
+		Page:       page.Page,
+		PerPage:    page.PerPage,
+		Total:      total,
+		Pagination: page.Counted(len(responses), total),
+	})
+}
+
+// ListEvents handles GET /api/v1/admin/events
+func (h *AuditEventHandler) ListEvents(c *gin.Context) {
+	repoID, ok := uuidQuery(c, "repository_id")
+	if !ok {
+		return
+	}
+	actorID, ok := uuidQuery(c, "actor_id")
+	if !ok {
+		return
+	}
+	filter := repository.AuditEventFilter{
+		RepositoryID: repoID,
+		ActorID:      actorID,
+		Action:       c.Query("action"),
+	}
+
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
+	}
+	auditEvents, total, err := h.auditEvents.ListEvents(c.Request.Context(), filter, page.PerPage, page.Offset)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	responses := make([]dto.AuditEventResponse, 0, len(auditEvents))
+	for _, event := range auditEvents {
+		responses = append(responses, dto.AuditEventFromModel(event))
+	}
+
+	c.JSON(http.StatusOK, dto.ListAuditEventsResponse{
+		Events:     responses,
+		Page:       page.Page,
+		PerPage:    page.PerPage,
+		Total:      total,
+		Pagination: page.Counted(len(responses), total),
+	})
+}
+
+// uuidQuery parses an optional UUID query parameter. It writes the error
+// response and returns false if the parameter is not a UUID.
+func uuidQuery(c *gin.Context, param string) (*uuid.UUID, bool) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "repository_id and actor_id must be UUIDs",
+		})
+		return nil, false
+	}
+	return &id, true
+}
+
+// recordAuditEvent records an action of the authenticated user in the
+// background. repo is the repository acted on, or nil.
+func recordAuditEvent(c *gin.Context, auditEvents *service.AuditEventService, action string, repo *models.Repository, metadata map[string]any) {
+	event := &models.AuditEvent{
+		Action:   action,
+		Metadata: metadata,
+		IP:       c.ClientIP(),
+	}
+	if user := middleware.GetUserFromContext(c); user != nil {
+		event.ActorID = &user.ID
+		event.Actor = user.Username
+	}
+	if repo != nil {
+		event.RepositoryID = &repo.ID
+		if event.Metadata == nil {
+			event.Metadata = map[string]any{}
+		}
+		event.Metadata["repository"] = repo.Name
+		if repo.Owner.Username != "" {
+			event.Metadata["repository"] = repo.Owner.Username + "/" + repo.Name
+		}
+	}
+	auditEvents.Record(event)
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *AuditEventHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Audit event request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/infrastructure/audit"
+)
+
+// AuditHandler handles audit export administration HTTP requests
+type AuditHandler struct {
+	dispatcher *audit.Dispatcher
+}
+
+// NewAuditHandler creates a new AuditHandler instance
+func NewAuditHandler(dispatcher *audit.Dispatcher) *AuditHandler {
+	return &AuditHandler{
+		dispatcher: dispatcher,
+	}
+}
+
+// ListSinks handles GET /api/v1/admin/audit/sinks
+func (h *AuditHandler) ListSinks(c *gin.Context) {
+	health := h.dispatcher.Health()
+
+	sinks := make([]dto.AuditSinkHealthResponse, len(health))
+	for i, s := range health {
+		sinks[i] = dto.AuditSinkHealthResponse{
+			Name:        s.Name,
+			Type:        s.Type,
+			Backlog:     s.Backlog,
+			Spooled:     s.Spooled,
+			LastSuccess: s.LastSuccess,
+			LastError:   s.LastError,
+			LastErrorAt: s.LastErrorAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.AuditSinkListResponse{Sinks: sinks})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -53,12 +54,12 @@ func NewAuthHandler(
 // OIDCLogin handles GET /api/v1/auth/oidc/login
 // Initiates the OIDC authentication flow by redirecting to the identity provider
 func (h *AuthHandler) OIDCLogin(c *gin.Context) {
-	h.log.Debug("OIDC login initiated",
+	h.log.WithContext(c.Request.Context()).Debug("OIDC login initiated",
 		logger.ClientIP(c.ClientIP()),
 	)
 
 	if h.oidcService == nil || !h.oidcService.IsEnabled() {
-		h.log.Warn("OIDC login attempted but OIDC is not enabled")
+		h.log.WithContext(c.Request.Context()).Warn("OIDC login attempted but OIDC is not enabled")
 		c.JSON(http.StatusNotImplemented, gin.H{
 			"error":   "not_implemented",
 			"message": "OIDC authentication is not enabled",
@@ -67,7 +68,7 @@ func (h *AuthHandler) OIDCLogin(c *gin.Context) {
 	}
 
 	if !h.oidcService.IsInitialized() {
-		h.log.Warn("OIDC login attempted but OIDC service is not initialized")
+		h.log.WithContext(c.Request.Context()).Warn("OIDC login attempted but OIDC service is not initialized")
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"error":   "service_unavailable",
 			"message": "OIDC service is not initialized",
@@ -78,7 +79,7 @@ func (h *AuthHandler) OIDCLogin(c *gin.Context) {
 	// Generate authorization URL with state
 	authURL, state, err := h.oidcService.GenerateAuthURL()
 	if err != nil {
-		h.log.Error("Failed to generate OIDC authorization URL",
+		h.log.WithContext(c.Request.Context()).Error("Failed to generate OIDC authorization URL",
 			logger.Error(err),
 		)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -99,7 +100,7 @@ func (h *AuthHandler) OIDCLogin(c *gin.Context) {
 		true,  // httpOnly
 	)
 
-	h.log.Info("Redirecting user to OIDC provider",
+	h.log.WithContext(c.Request.Context()).Info("Redirecting user to OIDC provider",
 		logger.ClientIP(c.ClientIP()),
 	)
 
@@ -110,12 +111,12 @@ func (h *AuthHandler) OIDCLogin(c *gin.Context) {
 // OIDCCallback handles GET /api/v1/auth/oidc/callback
 // Processes the callback from the identity provider after user authentication
 func (h *AuthHandler) OIDCCallback(c *gin.Context) {
-	h.log.Debug("OIDC callback received",
+	h.log.WithContext(c.Request.Context()).Debug("OIDC callback received",
 		logger.ClientIP(c.ClientIP()),
 	)
 
 	if h.oidcService == nil || !h.oidcService.IsEnabled() {
-		h.log.Warn("OIDC callback received but OIDC is not enabled")
+		h.log.WithContext(c.Request.Context()).Warn("OIDC callback received but OIDC is not enabled")
 		c.JSON(http.StatusNotImplemented, gin.H{
 			"error":   "not_implemented",
 			"message": "OIDC authentication is not enabled",
@@ -126,7 +127,7 @@ func (h *AuthHandler) OIDCCallback(c *gin.Context) {
 	// Check for errors from the identity provider
 	if errParam := c.Query("error"); errParam != "" {
 		errDesc := c.Query("error_description")
-		h.log.Warn("OIDC provider returned error",
+		h.log.WithContext(c.Request.Context()).Warn("OIDC provider returned error",
 			logger.String("error", errParam),
 			logger.String("description", errDesc),
 		)
@@ -141,7 +142,7 @@ func (h *AuthHandler) OIDCCallback(c *gin.Context) {
 	// Get the authorization code
 	code := c.Query("code")
 	if code == "" {
-		h.log.Warn("OIDC callback missing authorization code")
+		h.log.WithContext(c.Request.Context()).Warn("OIDC callback missing authorization code")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "bad_request",
 			"message": "Missing authorization code",
@@ -153,7 +154,7 @@ func (h *AuthHandler) OIDCCallback(c *gin.Context) {
 	state := c.Query("state")
 	expectedState, err := c.Cookie(oidcStateCookie)
 	if err != nil {
-		h.log.Warn("OIDC callback missing or expired state cookie",
+		h.log.WithContext(c.Request.Context()).Warn("OIDC callback missing or expired state cookie",
 			logger.Error(err),
 		)
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -166,19 +167,19 @@ func (h *AuthHandler) OIDCCallback(c *gin.Context) {
 	// Clear the state cookie
 	c.SetCookie(oidcStateCookie, "", -1, "/", "", false, true)
 
-	h.log.Debug("Processing OIDC callback")
+	h.log.WithContext(c.Request.Context()).Debug("Processing OIDC callback")
 
 	// Handle the callback - this exchanges the code for tokens and creates/updates the user
 	user, sessionToken, err := h.oidcService.HandleCallback(c.Request.Context(), code, state, expectedState)
 	if err != nil {
-		h.log.Error("OIDC callback handling failed",
+		h.log.WithContext(c.Request.Context()).Error("OIDC callback handling failed",
 			logger.Error(err),
 		)
 		h.handleError(c, err)
 		return
 	}
 
-	h.log.Info("OIDC authentication successful",
+	h.log.WithContext(c.Request.Context()).Info("OIDC authentication successful",
 		logger.String("user_id", user.ID.String()),
 		logger.String("username", user.Username),
 		logger.String("email", user.Email),
@@ -198,7 +199,7 @@ func (h *AuthHandler) OIDCCallback(c *gin.Context) {
 		// Encode user info as base64 JSON
 		userJSON, err := json.Marshal(userInfo)
 		if err != nil {
-			h.log.Error("Failed to marshal user info for redirect",
+			h.log.WithContext(c.Request.Context()).Error("Failed to marshal user info for redirect",
 				logger.Error(err),
 			)
 			h.handleError(c, err)
@@ -211,7 +212,7 @@ func (h *AuthHandler) OIDCCallback(c *gin.Context) {
 		redirectURL := fmt.Sprintf("%s/auth/callback#token=%s&user=%s",
 			frontendURL, sessionToken, userBase64)
 
-		h.log.Debug("Redirecting user to frontend",
+		h.log.WithContext(c.Request.Context()).Debug("Redirecting user to frontend",
 			logger.String("frontend_url", frontendURL),
 			logger.String("user_id", user.ID.String()),
 		)
@@ -233,43 +234,80 @@ func (h *AuthHandler) OIDCCallback(c *gin.Context) {
 	})
 }
 
-// OIDCLogout handles POST /api/v1/auth/oidc/logout
-// Logs the user out by invalidating the session
-func (h *AuthHandler) OIDCLogout(c *gin.Context) {
-	h.log.Debug("OIDC logout initiated",
+// Logout handles POST /api/v1/auth/logout and POST /api/v1/auth/oidc/logout
+// Revokes the session of the session token, if any, and returns the logout
+// URL of the identity provider for the frontend to end its session too
+func (h *AuthHandler) Logout(c *gin.Context) {
+	h.log.WithContext(c.Request.Context()).Debug("Logout initiated",
 		logger.ClientIP(c.ClientIP()),
 	)
 
 	// Get the post-logout redirect URI from query parameter
 	postLogoutRedirectURI := c.Query("redirect_uri")
 
-	// If OIDC is enabled and supports logout, get the logout URL
 	var logoutURL string
-	if h.oidcService != nil && h.oidcService.IsEnabled() && h.oidcService.IsInitialized() {
+	if h.oidcService != nil && h.oidcService.IsEnabled() {
 		var err error
-		logoutURL, err = h.oidcService.GetLogoutURL("", postLogoutRedirectURI)
+		logoutURL, err = h.oidcService.EndSession(c.Request.Context(), bearerToken(c), postLogoutRedirectURI)
 		if err != nil {
-			h.log.Warn("Failed to get OIDC logout URL",
+			h.log.WithContext(c.Request.Context()).Error("Failed to end session",
 				logger.Error(err),
 			)
-			// Log error but continue - logout from our side should still work
+			h.handleError(c, err)
+			return
 		}
 	}
 
-	// If we have a provider logout URL, redirect to it
-	if logoutURL != "" {
-		h.log.Info("User logged out with provider logout URL")
-		c.JSON(http.StatusOK, gin.H{
-			"message":    "Logged out successfully",
-			"logout_url": logoutURL,
+	h.log.WithContext(c.Request.Context()).Info("User logged out successfully",
+		logger.Bool("provider_logout", logoutURL != ""),
+	)
+	c.JSON(http.StatusOK, dto.LogoutResponse{
+		Message:   "Logged out successfully",
+		LogoutURL: logoutURL,
+	})
+}
+
+// RefreshSession handles POST /api/v1/auth/refresh
+// Mints a new session token from the session token of the request, expired
+// or not, while its session is active
+func (h *AuthHandler) RefreshSession(c *gin.Context) {
+	if h.oidcService == nil || !h.oidcService.IsEnabled() {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error":   "not_implemented",
+			"message": "OIDC authentication is not enabled",
 		})
 		return
 	}
 
-	h.log.Info("User logged out successfully")
-	// Otherwise just confirm logout
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Logged out successfully",
+	token := bearerToken(c)
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	user, sessionToken, err := h.oidcService.RefreshSession(c.Request.Context(), token)
+	if err != nil {
+		h.log.WithContext(c.Request.Context()).Debug("Session refresh failed",
+			logger.Error(err),
+		)
+		h.handleError(c, err)
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Debug("Session refreshed",
+		logger.String("user_id", user.ID.String()),
+	)
+	c.JSON(http.StatusOK, dto.SessionRefreshResponse{
+		Token: sessionToken,
+		User: dto.UserInfo{
+			ID:       user.ID,
+			Username: user.Username,
+			Email:    user.Email,
+			IsAdmin:  user.IsAdmin,
+		},
 	})
 }
 
@@ -278,7 +316,7 @@ func (h *AuthHandler) OIDCLogout(c *gin.Context) {
 func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
-		h.log.Debug("GetCurrentUser called without authentication")
+		h.log.WithContext(c.Request.Context()).Debug("GetCurrentUser called without authentication")
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error":   "unauthorized",
 			"message": "Authentication required",
@@ -286,7 +324,7 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		return
 	}
 
-	h.log.Debug("Returning current user info",
+	h.log.WithContext(c.Request.Context()).Debug("Returning current user info",
 		logger.String("user_id", user.ID.String()),
 		logger.String("username", user.Username),
 	)
@@ -305,7 +343,7 @@ func (h *AuthHandler) GetOIDCConfig(c *gin.Context) {
 	enabled := h.oidcService != nil && h.oidcService.IsEnabled()
 	initialized := enabled && h.oidcService.IsInitialized()
 
-	h.log.Debug("Returning OIDC config",
+	h.log.WithContext(c.Request.Context()).Debug("Returning OIDC config",
 		logger.Bool("enabled", enabled),
 		logger.Bool("initialized", initialized),
 	)
@@ -316,9 +354,26 @@ func (h *AuthHandler) GetOIDCConfig(c *gin.Context) {
 	})
 }
 
+// bearerToken returns the token of the Authorization header of a request, or ""
+func bearerToken(c *gin.Context) string {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
 // handleError handles errors and returns appropriate HTTP responses
 func (h *AuthHandler) handleError(c *gin.Context, err error) {
 	var appErr *apperrors.AppError
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
 	if ok := apperrors.IsNotFound(err); ok {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
@@ -372,7 +427,8 @@ func (h *AuthHandler) handleError(c *gin.Context, err error) {
 var _ interface {
 	OIDCLogin(c *gin.Context)
 	OIDCCallback(c *gin.Context)
-	OIDCLogout(c *gin.Context)
+	Logout(c *gin.Context)
+	RefreshSession(c *gin.Context)
 	GetCurrentUser(c *gin.Context)
 	GetOIDCConfig(c *gin.Context)
 } = (*AuthHandler)(nil)
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// AuthorMappingHandler handles contributor and author mapping HTTP requests
+type AuthorMappingHandler struct {
+	repoService    *service.RepoService
+	authorMappings *service.AuthorMappingService
+	log            *logger.Logger
+}
+
+// NewAuthorMappingHandler creates a new AuthorMappingHandler instance
+func NewAuthorMappingHandler(
+	repoService *service.RepoService,
+	authorMappings *service.AuthorMappingService,
+) *AuthorMappingHandler {
+	return &AuthorMappingHandler{
+		repoService:    repoService,
+		authorMappings: authorMappings,
+		log:            logger.Get().WithFields(logger.Component("author-mapping-handler")),
+	}
+}
+
+// ListContributors handles GET /api/v1/repos/:owner/:repo/contributors
+func (h *AuthorMappingHandler) ListContributors(c *gin.Context) {
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// Check access
+	user := middleware.GetUserFromContext(c)
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	resp, err := h.authorMappings.ListContributors(c.Request.Context(), repo)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListMappings handles GET /api/v1/repos/:owner/:repo/settings/author-mappings
+func (h *AuthorMappingHandler) ListMappings(c *gin.Context) {
+	repo, _, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	mappings, err := h.authorMappings.ListMappings(c.Request.Context(), repo)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.AuthorMappingListFromModels(mappings))
+}
+
+// SetMapping handles PUT /api/v1/repos/:owner/:repo/settings/author-mappings
+func (h *AuthorMappingHandler) SetMapping(c *gin.Context) {
+	repo, user, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SetAuthorMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	mapping, err := h.authorMappings.SetMapping(c.Request.Context(), repo, req.Email, req.Username)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Info("Author mapping changed",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("user_id", user.ID.String()),
+	)
+
+	c.JSON(http.StatusOK, dto.AuthorMappingFromModel(mapping))
+}
+
+// DeleteMapping handles DELETE /api/v1/repos/:owner/:repo/settings/author-mappings/:id
+func (h *AuthorMappingHandler) DeleteMapping(c *gin.Context) {
+	repo, _, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid mapping ID",
+		})
+		return
+	}
+
+	if err := h.authorMappings.DeleteMapping(c.Request.Context(), repo, id); err != nil {
+		if apperrors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Author mapping not found",
+			})
+			return
+		}
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListUnmappedAuthors handles GET /api/v1/repos/:owner/:repo/settings/author-mappings/unmapped
+func (h *AuthorMappingHandler) ListUnmappedAuthors(c *gin.Context) {
+	repo, _, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.authorMappings.ListUnmappedAuthors(c.Request.Context(), repo)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// getAdministeredRepository loads the repository from the path and checks that
+// the authenticated user administers it (owner, site admin or admin collaborator).
+// It writes the error response and returns false if the request cannot proceed.
+func (h *AuthorMappingHandler) getAdministeredRepository(c *gin.Context) (*models.Repository, *models.User, bool) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return nil, nil, false
+	}
+
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return nil, nil, false
+	}
+
+	permission := h.repoService.RepositoryPermission(c.Request.Context(), user, repo)
+	if !permission.Allows(models.RepoPermissionAdmin) {
+		// Do not reveal private repositories to users who cannot read them
+		if !permission.Allows(models.RepoPermissionRead) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Repository not found",
+			})
+			return nil, nil, false
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Only repository administrators can manage author mappings",
+		})
+		return nil, nil, false
+	}
+
+	return repo, user, true
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *AuthorMappingHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Author mapping request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
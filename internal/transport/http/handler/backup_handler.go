@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// BackupHandler handles repository backup HTTP requests
+type BackupHandler struct {
+	backups *service.BackupService
+	log     *logger.Logger
+}
+
+// NewBackupHandler creates a new BackupHandler instance
+func NewBackupHandler(backups *service.BackupService) *BackupHandler {
+	return &BackupHandler{
+		backups: backups,
+		log:     logger.Get().WithFields(logger.Component("backup-handler")),
+	}
+}
+
+// Backup handles POST /api/v1/admin/backups
+func (h *BackupHandler) Backup(c *gin.Context) {
+	var req dto.BackupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	// A backup must not be abandoned halfway because the client went away
+	ctx := context.WithoutCancel(c.Request.Context())
+	results, err := h.backups.Backup(ctx, req.Owner, req.Repo, middleware.GetUserFromContext(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	resp := dto.BackupResponse{Results: make([]dto.BackupResultResponse, len(results))}
+	for i, result := range results {
+		resp.Results[i] = dto.BackupResultResponse{
+			Repository: result.Repository,
+			Status:     result.Status,
+			Size:       result.Size,
+			SHA256:     result.SHA256,
+			Error:      result.Error,
+		}
+		if result.Status == service.BackupStatusFailed {
+			resp.Failed++
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Restore handles POST /api/v1/admin/backups/restore
+func (h *BackupHandler) Restore(c *gin.Context) {
+	var req dto.RestoreBackupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	ctx := context.WithoutCancel(c.Request.Context())
+	results, err := h.backups.Restore(ctx, req.Owner, req.Repo, req.OnConflict, middleware.GetUserFromContext(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	resp := dto.RestoreBackupResponse{Results: make([]dto.RestoreResultResponse, len(results))}
+	for i, result := range results {
+		resp.Results[i] = dto.RestoreResultResponse{
+			Repository: result.Repository,
+			Status:     result.Status,
+			Error:      result.Error,
+			Warnings:   result.Warnings,
+		}
+		if result.Status == service.RestoreStatusFailed {
+			resp.Failed++
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleError handles errors and sends appropriate HTTP responses
+func (h *BackupHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsConflict(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "conflict",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Backup request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
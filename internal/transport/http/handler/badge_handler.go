@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// BadgeHandler handles third-party badge proxy HTTP requests
+type BadgeHandler struct {
+	badgeProxyService *service.BadgeProxyService
+}
+
+// NewBadgeHandler creates a new BadgeHandler instance
+func NewBadgeHandler(badgeProxyService *service.BadgeProxyService) *BadgeHandler {
+	return &BadgeHandler{
+		badgeProxyService: badgeProxyService,
+	}
+}
+
+// ProxyBadge handles GET /api/badge-proxy?url=...
+func (h *BadgeHandler) ProxyBadge(c *gin.Context) {
+	badge, err := h.badgeProxyService.GetBadge(c.Request.Context(), c.Query("url"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	maxAge := max(int(time.Until(badge.ExpiresAt).Seconds()), 0)
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+	c.Header("X-Content-Type-Options", "nosniff")
+	// SVG can carry scripts; never let a proxied badge execute or load anything
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	c.Data(http.StatusOK, badge.ContentType, badge.Content)
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *BadgeHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Badge proxy is not enabled",
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsForbidden(err) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, service.ErrBadgeUpstream) {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "bad_gateway",
+			"message": "Failed to fetch badge from upstream",
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
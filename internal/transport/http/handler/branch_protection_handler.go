@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// BranchProtectionHandler handles branch protection HTTP requests
+type BranchProtectionHandler struct {
+	repoService *service.RepoService
+	branches    *service.BranchProtectionService
+	log         *logger.Logger
+}
+
+// NewBranchProtectionHandler creates a new BranchProtectionHandler instance
+func NewBranchProtectionHandler(
+	repoService *service.RepoService,
+	branches *service.BranchProtectionService,
+) *BranchProtectionHandler {
+	return &BranchProtectionHandler{
+		repoService: repoService,
+		branches:    branches,
+		log:         logger.Get().WithFields(logger.Component("branch-protection-handler")),
+	}
+}
+
+// ListProtections handles GET /api/v1/repos/:owner/:repo/branch_protections
+func (h *BranchProtectionHandler) ListProtections(c *gin.Context) {
+	repo, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	protections, err := h.branches.ListProtections(c.Request.Context(), repo)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BranchProtectionListFromModels(protections))
+}
+
+// GetProtection handles GET /api/v1/repos/:owner/:repo/branch_protections/:id
+func (h *BranchProtectionHandler) GetProtection(c *gin.Context) {
+	repo, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	id, ok := h.protectionID(c)
+	if !ok {
+		return
+	}
+
+	protection, err := h.branches.GetProtection(c.Request.Context(), repo, id)
+	if err != nil {
+		h.handleProtectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BranchProtectionFromModel(protection))
+}
+
+// CreateProtection handles POST /api/v1/repos/:owner/:repo/branch_protections
+func (h *BranchProtectionHandler) CreateProtection(c *gin.Context) {
+	repo, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	var req dto.BranchProtectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	protection := req.ToModel()
+	if err := h.branches.CreateProtection(c.Request.Context(), repo, protection); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.BranchProtectionFromModel(protection))
+}
+
+// UpdateProtection handles PUT /api/v1/repos/:owner/:repo/branch_protections/:id
+func (h *BranchProtectionHandler) UpdateProtection(c *gin.Context) {
+	repo, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	id, ok := h.protectionID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.BranchProtectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	protection, err := h.branches.UpdateProtection(c.Request.Context(), repo, id, req.ToModel())
+	if err != nil {
+		h.handleProtectionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.BranchProtectionFromModel(protection))
+}
+
+// DeleteProtection handles DELETE /api/v1/repos/:owner/:repo/branch_protections/:id
+func (h *BranchProtectionHandler) DeleteProtection(c *gin.Context) {
+	repo, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	id, ok := h.protectionID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.branches.DeleteProtection(c.Request.Context(), repo, id); err != nil {
+		h.handleProtectionError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// protectionID parses the branch protection ID from the path.
+// It writes the error response and returns false if the ID is invalid.
+func (h *BranchProtectionHandler) protectionID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid branch protection ID",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// getAdministeredRepository loads the repository from the path and checks that
+// the authenticated user administers it (owner, site admin or admin collaborator).
+// It writes the error response and returns false if the request cannot proceed.
+func (h *BranchProtectionHandler) getAdministeredRepository(c *gin.Context) (*models.Repository, bool) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return nil, false
+	}
+
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return nil, false
+	}
+
+	permission := h.repoService.RepositoryPermission(c.Request.Context(), user, repo)
+	if !permission.Allows(models.RepoPermissionAdmin) {
+		// Do not reveal private repositories to users who cannot read them
+		if !permission.Allows(models.RepoPermissionRead) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Repository not found",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Only repository administrators can manage branch protections",
+		})
+		return nil, false
+	}
+
+	return repo, true
+}
+
+// handleProtectionError handles errors of requests on a single branch protection
+func (h *BranchProtectionHandler) handleProtectionError(c *gin.Context, err error) {
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Branch protection not found",
+		})
+		return
+	}
+	h.handleError(c, err)
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *BranchProtectionHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsConflict(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "conflict",
+			"message": "A branch protection with this pattern already exists",
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Branch protection request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
@@ -2,14 +2,21 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/bravo68web/stasis/internal/application/dto"
 	"github.com/bravo68web/stasis/internal/application/service"
 	"github.com/bravo68web/stasis/internal/domain/models"
 	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
 	"github.com/bravo68web/stasis/pkg/logger"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -17,17 +24,19 @@ import (
 
 // CIHandler handles CI-related HTTP requests
 type CIHandler struct {
-	ciService *service.CIService
-	repoRepo  repository.RepoRepository
-	log       *logger.Logger
+	ciService   *service.CIService
+	repoService *service.RepoService
+	repoRepo    repository.RepoRepository
+	log         *logger.Logger
 }
 
 // NewCIHandler creates a new CI handler
-func NewCIHandler(ciService *service.CIService, repoRepo repository.RepoRepository) *CIHandler {
+func NewCIHandler(ciService *service.CIService, repoService *service.RepoService) *CIHandler {
 	return &CIHandler{
-		ciService: ciService,
-		repoRepo:  repoRepo,
-		log:       logger.Get(),
+		ciService:   ciService,
+		repoService: repoService,
+		repoRepo:    repoService.GetRepoRepository(),
+		log:         logger.Get(),
 	}
 }
 
@@ -59,8 +68,8 @@ func (h *CIHandler) TriggerJob(c *gin.Context) {
 		return
 	}
 
-	// Check permissions (owner or collaborator)
-	if repo.OwnerID != currentUser.ID {
+	// Check permissions (owner or collaborator with write access)
+	if !h.repoService.HasPermission(c.Request.Context(), currentUser, repo, models.RepoPermissionWrite) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
 		return
 	}
@@ -94,7 +103,12 @@ func (h *CIHandler) TriggerJob(c *gin.Context) {
 		Metadata:     map[string]string{},
 	})
 	if err != nil {
-		h.log.Error("Failed to trigger CI job",
+		if apperrors.IsBadRequest(err) {
+			// Invalid stage configuration
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.WithContext(c.Request.Context()).Error("Failed to trigger CI job",
 			logger.Error(err),
 			logger.String("owner", owner),
 			logger.String("repo", repoName),
@@ -125,19 +139,82 @@ func (h *CIHandler) ListJobs(c *gin.Context) {
 	}
 
 	// Parse pagination
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
-	if limit > 100 {
-		limit = 100
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
+	}
+
+	// Parse status filter (comma-separated)
+	statuses, err := service.ParseCIJobStatusFilter(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get jobs from CI server
+	jobs, total, err := h.ciService.ListJobsByRepository(c.Request.Context(), repo.ID, statuses, page.PerPage, page.Offset)
+	if err != nil {
+		h.log.WithContext(c.Request.Context()).Error("Failed to list CI jobs",
+			logger.Error(err),
+			logger.String("owner", owner),
+			logger.String("repo", repoName),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list jobs"})
+		return
+	}
+
+	// Build response
+	jobResponses := make([]gin.H, 0, len(jobs))
+	for _, job := range jobs {
+		jobResponses = append(jobResponses, h.formatJobResponse(job))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":       jobResponses,
+		"total":      total,
+		"pagination": page.Counted(len(jobResponses), total),
+	})
+}
+
+// ListJobsByRef lists CI jobs for a branch or tag of a repository.
+// The ref is taken from a wildcard so names containing slashes (feature/foo) work.
+// GET /api/v1/repos/:owner/:repo/ci/refs/*ref (matched as /refs/{ref}/jobs)
+func (h *CIHandler) ListJobsByRef(c *gin.Context) {
+	owner := c.Param("owner")
+	repoName := c.Param("repo")
+
+	refPath, ok := strings.CutSuffix(c.Param("ref"), "/jobs")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	refName, err := url.PathUnescape(strings.TrimPrefix(refPath, "/"))
+	if err != nil || refName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ref"})
+		return
+	}
+
+	// Get repository
+	repo, err := h.repoRepo.FindByOwnerUsernameAndName(c.Request.Context(), owner, repoName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "repository not found"})
+		return
+	}
+
+	// Parse pagination
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
 	}
 
 	// Get jobs from CI server
-	jobs, total, err := h.ciService.ListJobsByRepository(c.Request.Context(), repo.ID, limit, offset)
+	jobs, total, err := h.ciService.ListJobsByRef(c.Request.Context(), repo.ID, refName, page.PerPage, page.Offset)
 	if err != nil {
-		h.log.Error("Failed to list CI jobs",
+		h.log.WithContext(c.Request.Context()).Error("Failed to list CI jobs by ref",
 			logger.Error(err),
 			logger.String("owner", owner),
 			logger.String("repo", repoName),
+			logger.String("ref", refName),
 		)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list jobs"})
 		return
@@ -150,12 +227,9 @@ func (h *CIHandler) ListJobs(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"jobs":  jobResponses,
-		"total": total,
-		"pagination": gin.H{
-			"limit":  limit,
-			"offset": offset,
-		},
+		"jobs":       jobResponses,
+		"total":      total,
+		"pagination": page.Counted(len(jobResponses), total),
 	})
 }
 
@@ -214,16 +288,15 @@ func (h *CIHandler) GetJobLogs(c *gin.Context) {
 	}
 
 	// Parse pagination
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "1000"))
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
-	if limit > 10000 {
-		limit = 10000
+	page, ok := pagination.FromRequest(c, pagination.Logs)
+	if !ok {
+		return
 	}
 
 	// Get logs from CI server
-	logs, total, err := h.ciService.GetJobLogs(c.Request.Context(), jobID, limit, offset)
+	logs, total, err := h.ciService.GetJobLogs(c.Request.Context(), jobID, page.PerPage, page.Offset)
 	if err != nil {
-		h.log.Error("Failed to get job logs",
+		h.log.WithContext(c.Request.Context()).Error("Failed to get job logs",
 			logger.Error(err),
 			logger.String("job_id", jobIDStr),
 		)
@@ -244,18 +317,26 @@ func (h *CIHandler) GetJobLogs(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"job_id": jobID,
-		"logs":   logResponses,
-		"total":  total,
-		"pagination": gin.H{
-			"limit":  limit,
-			"offset": offset,
-		},
+		"job_id":     jobID,
+		"logs":       logResponses,
+		"total":      total,
+		"pagination": page.Counted(len(logResponses), total),
 	})
 }
 
+// ciLogBackfillBatch is the number of log lines fetched from the CI runner at
+// a time when a stream catches up
+const ciLogBackfillBatch = 500
+
 // StreamLogs streams logs for a CI job via SSE
 // GET /api/v1/repos/:owner/:repo/ci/jobs/:job_id/stream
+//
+// The stream first sends the logs the client is missing: those after the
+// sequence in the Last-Event-ID header or the after_sequence query
+// parameter, or all of them. Lines are sent in batches, one logs event per
+// batch with the sequence of its last line as its id, so browsers resume
+// where they left off when they reconnect. Once the job
+// finishes a "complete" event is sent and the stream ends.
 func (h *CIHandler) StreamLogs(c *gin.Context) {
 	owner := c.Param("owner")
 	repoName := c.Param("repo")
@@ -267,6 +348,21 @@ func (h *CIHandler) StreamLogs(c *gin.Context) {
 		return
 	}
 
+	// Resume point: Last-Event-ID wins, as browsers send it on reconnect
+	var after *uint64
+	resume := c.GetHeader("Last-Event-ID")
+	if resume == "" {
+		resume = c.Query("after_sequence")
+	}
+	if resume != "" {
+		sequence, err := strconv.ParseUint(resume, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid log sequence to resume after"})
+			return
+		}
+		after = &sequence
+	}
+
 	// Get repository (for validation)
 	_, err = h.repoRepo.FindByOwnerUsernameAndName(c.Request.Context(), owner, repoName)
 	if err != nil {
@@ -280,7 +376,8 @@ func (h *CIHandler) StreamLogs(c *gin.Context) {
 	c.Header("Connection", "keep-alive")
 	c.Header("X-Accel-Buffering", "no")
 
-	// Subscribe to job events
+	// Subscribe to job events before the backfill, so no log falls between
+	// the two
 	eventCh := h.ciService.Subscribe(jobID)
 	defer h.ciService.Unsubscribe(jobID, eventCh)
 
@@ -293,6 +390,14 @@ func (h *CIHandler) StreamLogs(c *gin.Context) {
 		h.sendSSE(w, "status", h.formatJobResponse(job))
 	}
 
+	if after, err = h.backfillLogs(c, jobID, after); err != nil {
+		return
+	}
+	if job != nil && job.IsFinished() {
+		h.sendComplete(w, jobID, job.Status)
+		return
+	}
+
 	// Stream events
 	for {
 		select {
@@ -302,11 +407,114 @@ func (h *CIHandler) StreamLogs(c *gin.Context) {
 			if !ok {
 				return
 			}
-			h.sendSSE(w, event.Type, event)
+
+			switch event.Type {
+			case "logs":
+				var logs []*service.CILog
+				if err := json.Unmarshal(event.Data, &logs); err != nil {
+					continue
+				}
+				// Drop the lines already sent by the backfill
+				fresh := logs[:0]
+				for _, log := range logs {
+					if after == nil || log.Sequence > *after {
+						fresh = append(fresh, log)
+					}
+				}
+				if len(fresh) == 0 {
+					continue
+				}
+				if after != nil && fresh[0].Sequence > *after+1 {
+					// Lines were dropped while the client was slow
+					if after, err = h.backfillLogs(c, jobID, after); err != nil {
+						return
+					}
+					continue
+				}
+				h.sendLogs(w, jobID, fresh)
+				after = &fresh[len(fresh)-1].Sequence
+
+			case "status":
+				h.sendSSE(w, event.Type, event)
+				var status struct {
+					Status string `json:"status"`
+				}
+				if err := json.Unmarshal(event.Data, &status); err != nil {
+					continue
+				}
+				if (&service.CIJob{Status: status.Status}).IsFinished() {
+					// Logs published after the status would be lost
+					if _, err := h.backfillLogs(c, jobID, after); err != nil {
+						return
+					}
+					h.sendComplete(w, jobID, status.Status)
+					return
+				}
+
+			default:
+				h.sendSSE(w, event.Type, event)
+			}
+		}
+	}
+}
+
+// backfillLogs sends the logs of a job after a sequence, or all of them if
+// after is nil, in batches, and returns the sequence of the last log sent
+func (h *CIHandler) backfillLogs(c *gin.Context, jobID uuid.UUID, after *uint64) (*uint64, error) {
+	ctx := c.Request.Context()
+	for offset := 0; ; {
+		var logs []*service.CILog
+		var err error
+		if after == nil {
+			logs, _, err = h.ciService.GetJobLogs(ctx, jobID, ciLogBackfillBatch, offset)
+			offset += len(logs)
+		} else {
+			logs, err = h.ciService.GetJobLogsAfterSequence(ctx, jobID, *after, ciLogBackfillBatch)
+		}
+		if err != nil {
+			h.log.WithContext(c.Request.Context()).Warn("Failed to backfill CI job logs",
+				logger.Error(err),
+				logger.String("job_id", jobID.String()),
+			)
+			return after, err
+		}
+
+		if len(logs) > 0 {
+			h.sendLogs(c.Writer, jobID, logs)
+			sequence := logs[len(logs)-1].Sequence
+			after = &sequence
+		}
+		if len(logs) < ciLogBackfillBatch {
+			return after, nil
 		}
 	}
 }
 
+// sendLogs sends a batch of log lines as one logs event, with the sequence
+// of its last line as the SSE id
+func (h *CIHandler) sendLogs(w http.ResponseWriter, jobID uuid.UUID, logs []*service.CILog) {
+	data, err := json.Marshal(logs)
+	if err != nil {
+		return
+	}
+	last := logs[len(logs)-1]
+	fmt.Fprintf(w, "id: %d\n", last.Sequence)
+	h.sendSSE(w, "logs", &service.JobEvent{
+		Type:      "logs",
+		JobID:     jobID,
+		Timestamp: last.Timestamp,
+		Data:      data,
+	})
+}
+
+// sendComplete sends the terminal event of a stream, once its job finished
+func (h *CIHandler) sendComplete(w http.ResponseWriter, jobID uuid.UUID, status string) {
+	h.sendSSE(w, "complete", gin.H{
+		"job_id": jobID,
+		"status": status,
+	})
+}
+
 // CancelJob cancels a running CI job
 // POST /api/v1/repos/:owner/:repo/ci/jobs/:job_id/cancel
 func (h *CIHandler) CancelJob(c *gin.Context) {
@@ -336,14 +544,14 @@ func (h *CIHandler) CancelJob(c *gin.Context) {
 	}
 
 	// Check permissions
-	if repo.OwnerID != currentUser.ID {
+	if !h.repoService.HasPermission(c.Request.Context(), currentUser, repo, models.RepoPermissionWrite) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
 		return
 	}
 
 	// Cancel the job
 	if err := h.ciService.CancelJob(c.Request.Context(), jobID); err != nil {
-		h.log.Error("Failed to cancel CI job",
+		h.log.WithContext(c.Request.Context()).Error("Failed to cancel CI job",
 			logger.Error(err),
 			logger.String("job_id", jobIDStr),
 		)
@@ -386,7 +594,7 @@ func (h *CIHandler) RetryJob(c *gin.Context) {
 	}
 
 	// Check permissions
-	if repo.OwnerID != currentUser.ID {
+	if !h.repoService.HasPermission(c.Request.Context(), currentUser, repo, models.RepoPermissionWrite) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
 		return
 	}
@@ -394,7 +602,7 @@ func (h *CIHandler) RetryJob(c *gin.Context) {
 	// Retry the job
 	newJob, err := h.ciService.RetryJob(c.Request.Context(), jobID, currentUser.Username)
 	if err != nil {
-		h.log.Error("Failed to retry CI job",
+		h.log.WithContext(c.Request.Context()).Error("Failed to retry CI job",
 			logger.Error(err),
 			logger.String("job_id", jobIDStr),
 		)
@@ -411,6 +619,100 @@ func (h *CIHandler) RetryJob(c *gin.Context) {
 	})
 }
 
+// GetRun gets the stage progress of a staged CI run
+// GET /api/v1/repos/:owner/:repo/ci/runs/:run_id
+func (h *CIHandler) GetRun(c *gin.Context) {
+	owner := c.Param("owner")
+	repoName := c.Param("repo")
+
+	runID, err := uuid.Parse(c.Param("run_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid run ID"})
+		return
+	}
+
+	// Get repository
+	repo, err := h.repoRepo.FindByOwnerUsernameAndName(c.Request.Context(), owner, repoName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "repository not found"})
+		return
+	}
+
+	run, err := h.ciService.GetRun(c.Request.Context(), runID)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "run not found"})
+			return
+		}
+		h.log.WithContext(c.Request.Context()).Error("Failed to get CI run",
+			logger.Error(err),
+			logger.String("run_id", runID.String()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get run"})
+		return
+	}
+	if run.RepositoryID != repo.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "run not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.formatRunResponse(run))
+}
+
+// CancelRun cancels all unfinished jobs of a staged CI run, including blocked ones
+// POST /api/v1/repos/:owner/:repo/ci/runs/:run_id/cancel
+func (h *CIHandler) CancelRun(c *gin.Context) {
+	owner := c.Param("owner")
+	repoName := c.Param("repo")
+
+	runID, err := uuid.Parse(c.Param("run_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid run ID"})
+		return
+	}
+
+	// Get authenticated user
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	currentUser := user.(*models.User)
+
+	// Get repository
+	repo, err := h.repoRepo.FindByOwnerUsernameAndName(c.Request.Context(), owner, repoName)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "repository not found"})
+		return
+	}
+
+	// Check permissions
+	if !h.repoService.HasPermission(c.Request.Context(), currentUser, repo, models.RepoPermissionWrite) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
+		return
+	}
+
+	run, err := h.ciService.GetRun(c.Request.Context(), runID)
+	if err != nil || run.RepositoryID != repo.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "run not found"})
+		return
+	}
+
+	if err := h.ciService.CancelRun(c.Request.Context(), runID); err != nil {
+		h.log.WithContext(c.Request.Context()).Error("Failed to cancel CI run",
+			logger.Error(err),
+			logger.String("run_id", runID.String()),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel run"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Run cancelled successfully",
+		"run_id":  runID,
+	})
+}
+
 // GetLatestJob gets the latest CI job for a repository
 // GET /api/v1/repos/:owner/:repo/ci/latest
 func (h *CIHandler) GetLatestJob(c *gin.Context) {
@@ -427,7 +729,7 @@ func (h *CIHandler) GetLatestJob(c *gin.Context) {
 	// Get latest job from CI server
 	job, err := h.ciService.GetLatestJobByRepository(c.Request.Context(), repo.ID)
 	if err != nil {
-		h.log.Error("Failed to get latest job",
+		h.log.WithContext(c.Request.Context()).Error("Failed to get latest job",
 			logger.Error(err),
 			logger.String("owner", owner),
 			logger.String("repo", repoName),
@@ -467,7 +769,7 @@ func (h *CIHandler) ListArtifacts(c *gin.Context) {
 	// Get artifacts from CI server
 	artifacts, err := h.ciService.GetJobArtifacts(c.Request.Context(), jobID)
 	if err != nil {
-		h.log.Error("Failed to get artifacts",
+		h.log.WithContext(c.Request.Context()).Error("Failed to get artifacts",
 			logger.Error(err),
 			logger.String("job_id", jobID.String()),
 		)
@@ -491,7 +793,8 @@ func (h *CIHandler) ListArtifacts(c *gin.Context) {
 	})
 }
 
-// DownloadArtifact proxies artifact download from CI runner
+// DownloadArtifact streams an artifact of a job, from the artifacts stored
+// on the server first, then from the CI runner
 // GET /api/v1/repos/:owner/:repo/ci/jobs/:job_id/artifacts/:artifact_name
 func (h *CIHandler) DownloadArtifact(c *gin.Context) {
 	owner := c.Param("owner")
@@ -506,33 +809,77 @@ func (h *CIHandler) DownloadArtifact(c *gin.Context) {
 	}
 
 	// Get repository (for validation)
-	_, err = h.repoRepo.FindByOwnerUsernameAndName(c.Request.Context(), owner, repoName)
+	repo, err := h.repoRepo.FindByOwnerUsernameAndName(c.Request.Context(), owner, repoName)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "repository not found"})
 		return
 	}
 
-	// Download artifact from CI runner
-	data, contentType, err := h.ciService.DownloadArtifact(c.Request.Context(), jobID, artifactName)
+	download, err := h.ciService.DownloadArtifact(c.Request.Context(), jobID, repo.ID, artifactName)
 	if err != nil {
-		h.log.Error("Failed to download artifact",
+		if apperrors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "artifact not found"})
+			return
+		}
+		var appErr *apperrors.AppError
+		if errors.As(err, &appErr) && appErr.HTTPStatus() < http.StatusInternalServerError {
+			c.JSON(appErr.HTTPStatus(), gin.H{"error": appErr.Message})
+			return
+		}
+		h.log.WithContext(c.Request.Context()).Error("Failed to download artifact",
 			logger.Error(err),
 			logger.String("job_id", jobID.String()),
 			logger.String("artifact", artifactName),
 		)
-		if err.Error() == "artifact not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "artifact not found"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to download artifact"})
+		return
+	}
+	defer download.Body.Close()
+
+	c.DataFromReader(http.StatusOK, download.Size, download.ContentType, download.Body, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=\"%s\"", artifactName),
+	})
+}
+
+// UploadArtifact stores an artifact the CI runner streams to the server, so
+// it outlives the workspace of the runner. The runner declares the SHA-256
+// of the content in X-Checksum-Sha256.
+// PUT /api/v1/ci/jobs/:job_id/artifacts/:name
+func (h *CIHandler) UploadArtifact(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("job_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job ID"})
+		return
+	}
+
+	artifact, err := h.ciService.StoreArtifact(
+		c.Request.Context(),
+		jobID,
+		c.Param("name"),
+		c.ContentType(),
+		c.GetHeader("X-Checksum-Sha256"),
+		c.Request.Body,
+	)
+	if err != nil {
+		var appErr *apperrors.AppError
+		if errors.As(err, &appErr) && appErr.HTTPStatus() < http.StatusInternalServerError {
+			c.JSON(appErr.HTTPStatus(), gin.H{"error": appErr.Message})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to download artifact"})
+		h.log.WithContext(c.Request.Context()).Error("Failed to store artifact",
+			logger.Error(err),
+			logger.String("job_id", jobID.String()),
+			logger.String("artifact", c.Param("name")),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store artifact"})
 		return
 	}
 
-	// Set response headers
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", artifactName))
-	c.Header("Content-Type", contentType)
-	c.Header("Content-Length", strconv.Itoa(len(data)))
-	c.Data(http.StatusOK, contentType, data)
+	c.JSON(http.StatusCreated, dto.CIArtifactResponse{
+		Name:     artifact.Name,
+		Size:     artifact.Size,
+		Checksum: artifact.Checksum,
+	})
 }
 
 // Helper methods
@@ -558,10 +905,41 @@ func (h *CIHandler) formatJobResponse(job *service.CIJob) gin.H {
 	if duration := job.Duration(); duration != nil {
 		response["duration_seconds"] = duration.Seconds()
 	}
+	if job.TransferBytes != nil {
+		response["transfer_bytes"] = *job.TransferBytes
+	}
 
 	return response
 }
 
+func (h *CIHandler) formatRunResponse(run *service.CIRun) dto.CIRunResponse {
+	response := dto.CIRunResponse{
+		RunID:  run.RunID,
+		Status: run.Status,
+		Stages: make([]dto.CIStageResponse, 0, len(run.Stages)),
+	}
+	for _, stage := range run.Stages {
+		s := dto.CIStageResponse{
+			Name:   stage.Name,
+			Needs:  stage.Needs,
+			Status: stage.Status,
+			Jobs:   make([]dto.CIRunJobResponse, 0, len(stage.Jobs)),
+		}
+		if s.Needs == nil {
+			s.Needs = []string{}
+		}
+		for _, job := range stage.Jobs {
+			j := dto.CIRunJobResponse{ID: job.ID, Name: job.Name, Status: job.Status}
+			if job.Error != nil {
+				j.Error = *job.Error
+			}
+			s.Jobs = append(s.Jobs, j)
+		}
+		response.Stages = append(response.Stages, s)
+	}
+	return response
+}
+
 func (h *CIHandler) formatStepsResponse(steps []service.CIStep) []gin.H {
 	result := make([]gin.H, 0, len(steps))
 	for _, step := range steps {
@@ -625,20 +1003,23 @@ func (h *CIHandler) ReceiveLogs(c *gin.Context) {
 		return
 	}
 
-	// Broadcast log entries to SSE subscribers
-	for _, entry := range entries {
-		h.ciService.BroadcastLogEvent(jobID, &service.CILog{
+	// Broadcast the batch to SSE subscribers as one event
+	logs := make([]*service.CILog, len(entries))
+	for i, entry := range entries {
+		logs[i] = &service.CILog{
 			Timestamp: entry.Timestamp,
 			Level:     entry.Level,
 			StepName:  entry.StepName,
 			Message:   entry.Message,
 			Sequence:  entry.Sequence,
-		})
+		}
 	}
+	accepted := h.ciService.BroadcastLogs(jobID, logs)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Logs received",
-		"count":   len(entries),
+		"count":   accepted,
+		"dropped": len(entries) - accepted,
 	})
 }
 
@@ -666,7 +1047,7 @@ func (h *CIHandler) CompleteJob(c *gin.Context) {
 		return
 	}
 
-	h.log.Info("Received job completion event",
+	h.log.WithContext(c.Request.Context()).Info("Received job completion event",
 		logger.String("job_id", jobIDStr),
 		logger.String("status", completion.Status),
 	)
@@ -684,8 +1065,16 @@ func (h *CIHandler) CompleteJob(c *gin.Context) {
 		}
 	}
 
+	var jobErr *string
+	if completion.Error != "" {
+		jobErr = &completion.Error
+	}
+
 	// Broadcast completion event to SSE subscribers
-	h.ciService.BroadcastStatusEvent(jobID, completion.Status, startedAt, finishedAt)
+	h.ciService.BroadcastStatusEvent(c.Request.Context(), jobID, completion.Status, jobErr, startedAt, finishedAt)
+
+	// Unblock or skip later stages of staged runs
+	h.ciService.RecordJobStatus(c.Request.Context(), jobID, completion.Status, jobErr)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Completion event received",
@@ -706,15 +1095,20 @@ func (h *CIHandler) WebhookJobUpdate(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !middleware.CICallbackAllows(c, update.JobID) {
+		middleware.RejectCICallback(c, h.log, "callback token of another job")
+		return
+	}
 
-	h.log.Info("Received CI webhook",
+	h.log.WithContext(c.Request.Context()).Info("Received CI webhook",
 		logger.String("job_id", update.JobID.String()),
 		logger.String("status", update.Status),
 		logger.String("event", update.Event),
 	)
 
 	// Broadcast the update
-	h.ciService.BroadcastStatusEvent(update.JobID, update.Status, nil, nil)
+	h.ciService.BroadcastStatusEvent(c.Request.Context(), update.JobID, update.Status, nil, nil, nil)
+	h.ciService.RecordJobStatus(c.Request.Context(), update.JobID, update.Status, nil)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Webhook received",
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// CollaboratorHandler handles repository collaborator HTTP requests
+type CollaboratorHandler struct {
+	repoService   *service.RepoService
+	collaborators *service.CollaboratorService
+	auditEvents   *service.AuditEventService
+	log           *logger.Logger
+}
+
+// NewCollaboratorHandler creates a new CollaboratorHandler instance
+func NewCollaboratorHandler(
+	repoService *service.RepoService,
+	collaborators *service.CollaboratorService,
+	auditEvents *service.AuditEventService,
+) *CollaboratorHandler {
+	return &CollaboratorHandler{
+		repoService:   repoService,
+		collaborators: collaborators,
+		auditEvents:   auditEvents,
+		log:           logger.Get().WithFields(logger.Component("collaborator-handler")),
+	}
+}
+
+// ListCollaborators handles GET /api/v1/repos/:owner/:repo/collaborators
+func (h *CollaboratorHandler) ListCollaborators(c *gin.Context) {
+	repo, ok := h.getRepository(c, models.RepoPermissionWrite, "Only users with write access can list collaborators")
+	if !ok {
+		return
+	}
+
+	collaborators, err := h.collaborators.ListCollaborators(c.Request.Context(), repo)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.CollaboratorListFromModels(collaborators))
+}
+
+// AddCollaborator handles POST /api/v1/repos/:owner/:repo/collaborators
+func (h *CollaboratorHandler) AddCollaborator(c *gin.Context) {
+	repo, ok := h.getRepository(c, models.RepoPermissionAdmin, "Only repository administrators can manage collaborators")
+	if !ok {
+		return
+	}
+
+	var req dto.AddCollaboratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	collaborator, err := h.collaborators.AddCollaborator(c.Request.Context(), repo, req.Username, models.RepoPermission(req.Permission))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	recordAuditEvent(c, h.auditEvents, models.AuditActionCollaboratorAdd, repo, map[string]any{
+		"collaborator": req.Username,
+		"permission":   req.Permission,
+	})
+
+	c.JSON(http.StatusOK, dto.CollaboratorFromModel(collaborator))
+}
+
+// RemoveCollaborator handles DELETE /api/v1/repos/:owner/:repo/collaborators/:username
+func (h *CollaboratorHandler) RemoveCollaborator(c *gin.Context) {
+	repo, ok := h.getRepository(c, models.RepoPermissionAdmin, "Only repository administrators can manage collaborators")
+	if !ok {
+		return
+	}
+
+	if err := h.collaborators.RemoveCollaborator(c.Request.Context(), repo, c.Param("username")); err != nil {
+		if apperrors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Collaborator not found",
+			})
+			return
+		}
+		h.handleError(c, err)
+		return
+	}
+	recordAuditEvent(c, h.auditEvents, models.AuditActionCollaboratorRemove, repo, map[string]any{
+		"collaborator": c.Param("username"),
+	})
+
+	c.Status(http.StatusNoContent)
+}
+
+// getRepository loads the repository from the path and checks that the
+// authenticated user has the required permission on it.
+// It writes the error response and returns false if the request cannot proceed.
+func (h *CollaboratorHandler) getRepository(c *gin.Context, required models.RepoPermission, forbidden string) (*models.Repository, bool) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return nil, false
+	}
+
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return nil, false
+	}
+
+	permission := h.repoService.RepositoryPermission(c.Request.Context(), user, repo)
+	if !permission.Allows(required) {
+		// Do not reveal private repositories to users who cannot read them
+		if !permission.Allows(models.RepoPermissionRead) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Repository not found",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": forbidden,
+		})
+		return nil, false
+	}
+
+	return repo, true
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *CollaboratorHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Collaborator request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
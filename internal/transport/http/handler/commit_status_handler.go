@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// CommitStatusHandler handles commit status HTTP requests
+type CommitStatusHandler struct {
+	repoService *service.RepoService
+	statuses    *service.CommitStatusService
+	log         *logger.Logger
+}
+
+// NewCommitStatusHandler creates a new CommitStatusHandler instance
+func NewCommitStatusHandler(
+	repoService *service.RepoService,
+	statuses *service.CommitStatusService,
+) *CommitStatusHandler {
+	return &CommitStatusHandler{
+		repoService: repoService,
+		statuses:    statuses,
+		log:         logger.Get().WithFields(logger.Component("commit-status-handler")),
+	}
+}
+
+// CreateStatus handles POST /api/v1/repos/:owner/:repo/statuses/:sha
+func (h *CommitStatusHandler) CreateStatus(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	permission := h.repoService.RepositoryPermission(c.Request.Context(), user, repo)
+	if !permission.Allows(models.RepoPermissionWrite) {
+		// Do not reveal private repositories to users who cannot read them
+		if !permission.Allows(models.RepoPermissionRead) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Repository not found",
+			})
+			return
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Write access is required to set commit statuses",
+		})
+		return
+	}
+
+	var req dto.CreateCommitStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	status := req.ToModel(c.Param("sha"))
+	if err := h.statuses.CreateStatus(c.Request.Context(), repo, status, user); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.CommitStatusFromModel(status))
+}
+
+// ListStatuses handles GET /api/v1/repos/:owner/:repo/statuses/:sha
+func (h *CommitStatusHandler) ListStatuses(c *gin.Context) {
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return
+	}
+
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
+	}
+
+	statuses, err := h.statuses.ListStatuses(c.Request.Context(), repo.ID, c.Param("sha"), page.Probe(), page.Offset)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	statuses, info := pagination.Trim(page, statuses)
+
+	c.JSON(http.StatusOK, dto.ListCommitStatusesResponse{
+		Statuses:   dto.CommitStatusesFromModels(statuses),
+		Pagination: info,
+	})
+}
+
+// GetCombinedStatus handles GET /api/v1/repos/:owner/:repo/commits/:sha/status
+func (h *CommitStatusHandler) GetCombinedStatus(c *gin.Context) {
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return
+	}
+
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
+	}
+
+	combined, err := h.statuses.GetCombinedStatus(c.Request.Context(), repo.ID, c.Param("sha"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// The state covers every context; only the statuses are paged
+	total := len(combined.Statuses)
+	statuses := combined.Statuses[min(page.Offset, total):min(page.Offset+page.PerPage, total)]
+
+	c.JSON(http.StatusOK, dto.CombinedCommitStatusResponse{
+		SHA:        strings.ToLower(c.Param("sha")),
+		State:      combined.State,
+		TotalCount: total,
+		Statuses:   dto.CommitStatusesFromModels(statuses),
+		Pagination: page.Counted(len(statuses), int64(total)),
+	})
+}
+
+// getReadableRepository loads the repository from the path and checks that the
+// user can read it. It writes the error response and returns false if the
+// request cannot proceed.
+func (h *CommitStatusHandler) getReadableRepository(c *gin.Context) (*models.Repository, bool) {
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return nil, false
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return nil, false
+	}
+	return repo, true
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *CommitStatusHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) && appErr.HTTPStatus() == http.StatusUnprocessableEntity {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "unprocessable_entity",
+			"message": appErr.Message,
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Commit status request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/infrastructure/eventbus"
+)
+
+// EventHandler handles event bus administration HTTP requests
+type EventHandler struct {
+	bus *eventbus.Bus
+}
+
+// NewEventHandler creates a new EventHandler instance
+func NewEventHandler(bus *eventbus.Bus) *EventHandler {
+	return &EventHandler{
+		bus: bus,
+	}
+}
+
+// GetMetrics handles GET /api/v1/admin/events/metrics
+func (h *EventHandler) GetMetrics(c *gin.Context) {
+	metrics := h.bus.Metrics()
+
+	types := make([]dto.EventTypeMetricsResponse, len(metrics))
+	for i, m := range metrics {
+		types[i] = dto.EventTypeMetricsResponse{
+			Type:      m.Type,
+			Published: m.Published,
+			Consumed:  m.Consumed,
+			Dropped:   m.Dropped,
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.EventMetricsResponse{Types: types})
+}
@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// feedMaxAge is how long clients may reuse a feed, matching how long the
+// server caches it
+const feedMaxAge = "max-age=60"
+
+// FeedHandler handles the release and activity feeds of repositories and the
+// feed tokens of users
+type FeedHandler struct {
+	repoService *service.RepoService
+	feeds       *service.FeedService
+	auditEvents *service.AuditEventService
+	log         *logger.Logger
+}
+
+// NewFeedHandler creates a new FeedHandler instance
+func NewFeedHandler(
+	repoService *service.RepoService,
+	feeds *service.FeedService,
+	auditEvents *service.AuditEventService,
+) *FeedHandler {
+	return &FeedHandler{
+		repoService: repoService,
+		feeds:       feeds,
+		auditEvents: auditEvents,
+		log:         logger.Get().WithFields(logger.Component("feed-handler")),
+	}
+}
+
+// ReleasesAtom handles GET /api/v1/repos/:owner/:repo/releases.atom
+func (h *FeedHandler) ReleasesAtom(c *gin.Context) {
+	h.serveFeed(c, service.FeedReleases, false)
+}
+
+// ReleasesJSON handles GET /api/v1/repos/:owner/:repo/releases.json
+func (h *FeedHandler) ReleasesJSON(c *gin.Context) {
+	h.serveFeed(c, service.FeedReleases, true)
+}
+
+// ActivityAtom handles GET /api/v1/repos/:owner/:repo/activity.atom
+func (h *FeedHandler) ActivityAtom(c *gin.Context) {
+	h.serveFeed(c, service.FeedActivity, false)
+}
+
+// ActivityJSON handles GET /api/v1/repos/:owner/:repo/activity.json
+func (h *FeedHandler) ActivityJSON(c *gin.Context) {
+	h.serveFeed(c, service.FeedActivity, true)
+}
+
+// serveFeed writes a feed of a repository as Atom or JSON Feed. Feeds of
+// private repositories are only served to users who can read them, either
+// signed in or through the ?token= feed token of the user.
+func (h *FeedHandler) serveFeed(c *gin.Context, kind string, asJSON bool) {
+	ctx := c.Request.Context()
+	repo, err := h.repoService.GetRepository(ctx, c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if token := c.Query("token"); token != "" {
+		user, err = h.feeds.AuthenticateFeedToken(ctx, token)
+		if err != nil && !apperrors.IsUnauthorized(err) {
+			h.handleError(c, err)
+			return
+		}
+	}
+	if !h.repoService.HasPermission(ctx, user, repo, models.RepoPermissionRead) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	feed, err := h.feeds.GetFeed(ctx, repo, kind)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	links := newFeedLinks(c, repo)
+	var body []byte
+	if asJSON {
+		c.Header("Content-Type", "application/feed+json; charset=utf-8")
+		body, err = json.MarshalIndent(jsonFeed(feed, links), "", "  ")
+	} else {
+		c.Header("Content-Type", "application/atom+xml; charset=utf-8")
+		body, err = xml.MarshalIndent(atomFeed(feed, links), "", "  ")
+		body = append([]byte(xml.Header), body...)
+	}
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	c.Header("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	if repo.IsPrivate {
+		c.Header("Cache-Control", "private, "+feedMaxAge)
+	} else {
+		c.Header("Cache-Control", "public, "+feedMaxAge)
+	}
+	// Answers conditional requests with 304 Not Modified
+	http.ServeContent(c.Writer, c.Request, "", feed.Updated, bytes.NewReader(body))
+}
+
+// feedLinks are the absolute URLs a feed links to
+type feedLinks struct {
+	self string // The feed itself, without the feed token
+	repo string // The repository
+}
+
+// newFeedLinks builds the links of a feed of a repository as seen by the client
+func newFeedLinks(c *gin.Context, repo *models.Repository) feedLinks {
+	base := requestBaseURL(c)
+	return feedLinks{
+		self: base + c.Request.URL.Path,
+		repo: base + "/api/v1/repos/" + url.PathEscape(repo.Owner.Username) + "/" + url.PathEscape(repo.Name),
+	}
+}
+
+// entryURL returns the URL an entry of a feed links to: the tree of a
+// release, the activity list otherwise
+func (l feedLinks) entryURL(entry service.FeedEntry) string {
+	if entry.Ref != "" {
+		return l.repo + "/tree/" + url.PathEscape(entry.Ref)
+	}
+	return l.repo + "/events"
+}
+
+// atomFeed converts a feed to Atom
+func atomFeed(feed *service.Feed, links feedLinks) dto.AtomFeed {
+	atom := dto.AtomFeed{
+		ID:      feed.ID,
+		Title:   feed.Title,
+		Updated: feedTime(feed.Updated),
+		Author:  dto.AtomPerson{Name: feed.Owner},
+		Links: []dto.AtomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: links.self},
+			{Rel: "alternate", Type: "application/json", Href: links.repo},
+		},
+		Entries: make([]dto.AtomEntry, 0, len(feed.Entries)),
+	}
+	for _, entry := range feed.Entries {
+		atomEntry := dto.AtomEntry{
+			ID:        entry.ID,
+			Title:     entry.Title,
+			Updated:   feedTime(entry.Updated),
+			Published: feedTime(entry.Published),
+			Links:     []dto.AtomLink{{Rel: "alternate", Href: links.entryURL(entry)}},
+			Content:   dto.AtomContent{Type: "html", Body: entry.ContentHTML},
+		}
+		if entry.Author != "" {
+			atomEntry.Author = &dto.AtomPerson{Name: entry.Author}
+		}
+		atom.Entries = append(atom.Entries, atomEntry)
+	}
+	return atom
+}
+
+// jsonFeed converts a feed to JSON Feed
+func jsonFeed(feed *service.Feed, links feedLinks) dto.JSONFeed {
+	result := dto.JSONFeed{
+		Version:     dto.JSONFeedVersion,
+		Title:       feed.Title,
+		HomePageURL: links.repo,
+		FeedURL:     links.self,
+		Authors:     []dto.JSONFeedAuthor{{Name: feed.Owner}},
+		Items:       make([]dto.JSONFeedItem, 0, len(feed.Entries)),
+	}
+	for _, entry := range feed.Entries {
+		item := dto.JSONFeedItem{
+			ID:            entry.ID,
+			URL:           links.entryURL(entry),
+			Title:         entry.Title,
+			ContentHTML:   entry.ContentHTML,
+			DatePublished: feedTime(entry.Published),
+			DateModified:  feedTime(entry.Updated),
+		}
+		if entry.Author != "" {
+			item.Authors = []dto.JSONFeedAuthor{{Name: entry.Author}}
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result
+}
+
+// feedTime formats a timestamp of a feed
+func feedTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// GetFeedToken handles GET /api/v1/users/feed-token
+func (h *FeedHandler) GetFeedToken(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+
+	token, err := h.feeds.FeedToken(c.Request.Context(), user.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.FeedTokenResponse{Token: token})
+}
+
+// CreateFeedToken handles POST /api/v1/users/feed-token
+func (h *FeedHandler) CreateFeedToken(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+
+	token, err := h.feeds.CreateFeedToken(c.Request.Context(), user.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	recordAuditEvent(c, h.auditEvents, models.AuditActionFeedTokenCreate, nil, nil)
+
+	c.JSON(http.StatusCreated, dto.FeedTokenResponse{Token: token})
+}
+
+// RevokeFeedToken handles DELETE /api/v1/users/feed-token
+func (h *FeedHandler) RevokeFeedToken(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+
+	if err := h.feeds.RevokeFeedToken(c.Request.Context(), user.ID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	recordAuditEvent(c, h.auditEvents, models.AuditActionFeedTokenDelete, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Feed token revoked successfully",
+	})
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *FeedHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Feed request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
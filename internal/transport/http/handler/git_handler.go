@@ -1,17 +1,23 @@
 package handler
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/events"
 	"github.com/bravo68web/stasis/internal/domain/models"
 	domainservice "github.com/bravo68web/stasis/internal/domain/service"
 	"github.com/bravo68web/stasis/internal/infrastructure/git"
 	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
 	"github.com/bravo68web/stasis/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
@@ -20,28 +26,69 @@ import (
 type GitHandler struct {
 	gitService  domainservice.GitService
 	repoService *service.RepoService
+	tagProtect  *service.TagProtectionService
+	branches    *service.BranchProtectionService
+	contribs    *service.ContributionService
+	licenses    *service.LicenseService
+	pinnedLinks *service.PinnedLinkService
+	languages   *service.LanguageService
 	authService domainservice.AuthService
-	storage     domainservice.StorageService
+	storage     *service.StorageBackendService
 	ciService   *service.CIService
+	ciJobTokens *service.CIJobTokenService
 	gitProtocol *git.GitProtocol
+	pushes      *service.PushAttemptService
+	lfs         *service.LFSService
+	quotas      *service.QuotaService
+	degraded    *service.DegradedModeService
+	publisher   events.Publisher
 	log         *logger.Logger
+
+	// createdOnPush holds IDs of repositories created during receive-pack
+	// discovery that have not been announced to the pusher yet
+	createdOnPush sync.Map
 }
 
 // NewGitHandler creates a new GitHandler instance
 func NewGitHandler(
 	gitService domainservice.GitService,
 	repoService *service.RepoService,
+	tagProtect *service.TagProtectionService,
+	branches *service.BranchProtectionService,
+	contribs *service.ContributionService,
+	licenses *service.LicenseService,
+	pinnedLinks *service.PinnedLinkService,
+	languages *service.LanguageService,
 	authService domainservice.AuthService,
-	storage domainservice.StorageService,
+	storage *service.StorageBackendService,
 	ciService *service.CIService,
+	ciJobTokens *service.CIJobTokenService,
+	gitProtocol *git.GitProtocol,
+	pushes *service.PushAttemptService,
+	lfs *service.LFSService,
+	quotas *service.QuotaService,
+	degraded *service.DegradedModeService,
+	publisher events.Publisher,
 ) *GitHandler {
 	return &GitHandler{
 		gitService:  gitService,
 		repoService: repoService,
+		tagProtect:  tagProtect,
+		branches:    branches,
+		contribs:    contribs,
+		licenses:    licenses,
+		pinnedLinks: pinnedLinks,
+		languages:   languages,
 		authService: authService,
 		storage:     storage,
 		ciService:   ciService,
-		gitProtocol: git.NewGitProtocol(),
+		ciJobTokens: ciJobTokens,
+		gitProtocol: gitProtocol,
+		pushes:      pushes,
+		lfs:         lfs,
+		quotas:      quotas,
+		degraded:    degraded,
+		publisher:   publisher,
 		log:         logger.Get().WithFields(logger.Component("git-handler")),
 	}
 }
@@ -64,46 +111,64 @@ func (h *GitHandler) HandleInfoRefs(c *gin.Context) {
 		return
 	}
 
-	// Get repository
-	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "not_found",
-			"message": "Repository not found",
-		})
-		return
-	}
-
 	// Check authorization
 	user := middleware.GetUserFromContext(c)
 	isWriteOperation := serviceName == "git-receive-pack"
 
-	if !h.checkRepoAccess(c, user, repo, isWriteOperation) {
-		return
+	// Get repository, creating it if this is a push into the user's own namespace
+	repo, err := h.getRepository(c, owner, repoName)
+	if err != nil {
+		if !isWriteOperation || !apperrors.IsNotFound(err) {
+			h.repositoryLookupFailed(c, err)
+			return
+		}
+
+		var ok bool
+		repo, ok = h.createRepositoryOnPush(c, user, owner, repoName)
+		if !ok {
+			return
+		}
 	}
 
-	// Get info/refs from git
-	service := git.NormalizeServiceName(serviceName)
-	response, err := h.gitProtocol.GetInfoRefs(c.Request.Context(), git.InfoRefsRequest{
-		RepoPath: repo.GitPath,
-		Service:  service,
-	})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "internal_error",
-			"message": "Failed to get repository info",
-		})
+	if !h.checkRepoAccess(c, user, repo, isWriteOperation) {
 		return
 	}
 
 	// Set response headers
-	c.Header("Content-Type", response.ContentType)
+	service := git.NormalizeServiceName(serviceName)
+	c.Header("Content-Type", git.AdvertisementContentType(service))
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Pragma", "no-cache")
 
-	// Write response
-	c.Writer.WriteHeader(http.StatusOK)
-	c.Writer.Write(response.Body)
+	// Stream info/refs from git, or the advertisement pinned while the
+	// repository is being collected
+	req := git.InfoRefsRequest{
+		RepoPath:    repo.GitPath,
+		Service:     service,
+		GitProtocol: c.GetHeader("Git-Protocol"),
+		UploadPack:  repo.UploadPack,
+	}
+	if service == git.ServiceUploadPack {
+		req.Snapshot = h.storage.PinnedAdvertisement(repo.ID)
+	}
+	err = h.gitProtocol.WriteInfoRefs(c.Request.Context(), req, flushWriter{c.Writer})
+	if err != nil {
+		h.log.WithContext(c.Request.Context()).Error("Failed to advertise refs",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "internal_error",
+				"message": "Failed to get repository info",
+			})
+		}
+		return
+	}
+
+	// The ref advertisement is part of the negotiation of a CI job's fetch
+	h.recordCIJobTransfer(c, repo)
 }
 
 // HandleUploadPack handles POST /{owner}/{repo}/git-upload-pack (fetch/clone)
@@ -115,12 +180,9 @@ func (h *GitHandler) HandleUploadPack(c *gin.Context) {
 	repoName = strings.TrimSuffix(repoName, ".git")
 
 	// Get repository
-	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
+	repo, err := h.getRepository(c, owner, repoName)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "not_found",
-			"message": "Repository not found",
-		})
+		h.repositoryLookupFailed(c, err)
 		return
 	}
 
@@ -140,15 +202,44 @@ func (h *GitHandler) HandleUploadPack(c *gin.Context) {
 		return
 	}
 
+	body, ok := gitRequestBody(c)
+	if !ok {
+		return
+	}
+	defer body.Close()
+
+	release, ok := h.acquireRepository(c, repo, false)
+	if !ok {
+		return
+	}
+	defer release()
+
 	// Set response headers
 	c.Header("Content-Type", "application/x-git-upload-pack-result")
 	c.Header("Cache-Control", "no-cache")
 
-	// Handle upload-pack
-	if err := h.gitProtocol.HandleUploadPack(c.Request.Context(), repo.GitPath, c.Request.Body, c.Writer); err != nil {
+	// Handle upload-pack, streaming the pack as git writes it; the request
+	// context kills git if the client goes away. Git-Protocol carries the
+	// protocol version the client negotiated in info/refs (v2).
+	gitProtocol := c.GetHeader("Git-Protocol")
+	if err := h.gitProtocol.HandleUploadPack(c.Request.Context(), repo.GitPath, body, flushWriter{c.Writer}, gitProtocol, repo.UploadPack); err != nil {
 		// Response already started, can't send error JSON
 		return
 	}
+
+	h.recordCIJobTransfer(c, repo)
+}
+
+// recordCIJobTransfer attributes the bytes of a response to the CI job whose
+// token the request authenticated with, if any
+func (h *GitHandler) recordCIJobTransfer(c *gin.Context, repo *models.Repository) {
+	jobToken := middleware.GetCIJobTokenFromContext(c)
+	if jobToken == nil {
+		return
+	}
+	// The client may be gone already; the transfer still happened
+	ctx := context.WithoutCancel(c.Request.Context())
+	h.ciJobTokens.RecordTransfer(ctx, jobToken, repo.GetFullName(), int64(max(c.Writer.Size(), 0)))
 }
 
 // HandleReceivePack handles POST /{owner}/{repo}/git-receive-pack (push)
@@ -160,12 +251,9 @@ func (h *GitHandler) HandleReceivePack(c *gin.Context) {
 	repoName = strings.TrimSuffix(repoName, ".git")
 
 	// Get repository
-	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
+	repo, err := h.repoService.ResolveRepository(c.Request.Context(), owner, repoName)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "not_found",
-			"message": "Repository not found",
-		})
+		h.repositoryLookupFailed(c, err)
 		return
 	}
 
@@ -185,20 +273,62 @@ func (h *GitHandler) HandleReceivePack(c *gin.Context) {
 		return
 	}
 
+	backend, err := h.storage.ForRepo(repo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to get repository info",
+		})
+		return
+	}
+	requestBody, ok := gitRequestBody(c)
+	if !ok {
+		return
+	}
+	defer requestBody.Close()
+
+	release, ok := h.acquireRepository(c, repo, true)
+	if !ok {
+		return
+	}
+	defer release()
+
 	// Set response headers
 	c.Header("Content-Type", "application/x-git-receive-pack-result")
 	c.Header("Cache-Control", "no-cache")
 
+	body := bufio.NewReaderSize(requestBody, 64*1024)
+
+	// Tell the pusher about a repository created during discovery
+	if _, created := h.createdOnPush.LoadAndDelete(repo.ID); created {
+		caps := git.PeekCommandCapabilities(body)
+		if caps.Has("side-band-64k") || caps.Has("side-band") {
+			_ = git.WriteSideBandProgress(c.Writer, git.CreatedOnPushMessage(owner, repoName, h.repoService.RepositoryWebURL(owner, repoName), requestCloneURL(c, owner, repoName)))
+		}
+	}
+
 	// Handle receive-pack
-	if err := h.gitProtocol.HandleReceivePack(c.Request.Context(), repo.GitPath, c.Request.Body, c.Writer); err != nil {
+	startedAt := time.Now()
+	check := domainservice.CombineRefChecks(
+		h.tagProtect.RefCommandCheck(repo, user, "http"),
+		h.branches.RefCommandCheck(c.Request.Context(), repo, user, "http"),
+		h.quotas.RefCommandCheck(c.Request.Context(), repo, "http"),
+	)
+	result, err := h.gitProtocol.HandleReceivePack(c.Request.Context(), repo.GitPath, body, flushWriter{c.Writer}, check, git.ReceiveOptions{
+		SkipLargeFileHints: repo.LargeFileHintsDisabled,
+		Exclusive:          h.storage.OperationsInFlight(repo.ID) == 1,
+		PushPolicy:         repo.PushPolicy,
+	})
+	h.pushes.Record(repo, user, "http", startedAt, result, pushFailure(err))
+	if err != nil {
 		// Response already started, can't send error JSON
 		return
 	}
 
 	// Sync to remote storage (S3) after successful push
 	// This runs synchronously to ensure data is persisted before returning
-	if err := h.storage.SyncToRemote(repo.GitPath); err != nil {
-		h.log.Error("Failed to sync repository to remote storage",
+	if err := backend.SyncToRemote(repo.GitPath); err != nil {
+		h.log.WithContext(c.Request.Context()).Error("Failed to sync repository to remote storage",
 			logger.Error(err),
 			logger.String("repo", repo.Name),
 			logger.String("path", repo.GitPath),
@@ -209,55 +339,92 @@ func (h *GitHandler) HandleReceivePack(c *gin.Context) {
 	// Set default branch if not already set (first push)
 	h.repoService.SetDefaultBranchOnPush(c.Request.Context(), repo)
 
-	// Trigger CI after successful push (runs asynchronously)
-	h.triggerCIAfterPush(c.Request.Context(), repo, user, owner, repoName)
+	// Record the new size of the repository for quotas
+	h.quotas.RecordSizeAsync(repo)
+
+	// Credit new default branch commits to their authors
+	h.contribs.IndexRepositoryAsync(repo)
+
+	// Detect the license again if the push changed it
+	h.licenses.DetectLicenseAfterPush(repo, result.Updates)
+
+	// Flag pinned links whose paths the push removed or restored
+	h.pinnedLinks.CheckLinksAfterPush(repo, result.Updates)
+
+	// Recompute the languages of the default branch
+	h.languages.RefreshAfterPush(repo, result.Updates)
+
+	h.publishPush(repo, user, c.ClientIP(), result)
+
+	// Trigger CI for the pushed refs (runs asynchronously)
+	h.triggerCIAfterPush(repo, user, owner, repoName, result.Updates)
 }
 
-// triggerCIAfterPush triggers CI jobs after a successful push
-func (h *GitHandler) triggerCIAfterPush(ctx context.Context, repo *models.Repository, user *models.User, owner, repoName string) {
-	// Check if CI service is enabled
-	if h.ciService == nil || !h.ciService.IsEnabled() {
-		h.log.Debug("CI service is not enabled, skipping CI trigger",
-			logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
-		)
+// publishPush publishes the ref updates of a push and the large files it added
+func (h *GitHandler) publishPush(repo *models.Repository, user *models.User, remoteIP string, result *domainservice.PushResult) {
+	updates := result.Updates
+	if len(updates) == 0 {
 		return
 	}
 
-	// Get the default branch (or HEAD)
-	defaultBranch, err := h.gitService.GetHEADBranch(ctx, repo.GitPath)
-	if err != nil {
-		h.log.Warn("Failed to get default branch for CI trigger",
-			logger.Error(err),
-			logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
-		)
-		return
+	event := events.RepositoryPushed{
+		RepositoryID: repo.ID,
+		Owner:        repo.Owner.Username,
+		Name:         repo.Name,
+		Pusher:       "anonymous",
+		Transport:    "http",
+		RemoteIP:     remoteIP,
+		Refs:         make([]events.RefChange, len(updates)),
+	}
+	if user != nil {
+		event.PusherID = &user.ID
+		event.Pusher = user.Username
+	}
+	for i, update := range updates {
+		event.Refs[i] = events.RefChange{
+			RefName: update.RefName,
+			OldHash: update.OldHash,
+			NewHash: update.NewHash,
+		}
+	}
+	for _, rejected := range result.RejectedCommands() {
+		event.Rejected = append(event.Rejected, events.RefRejected{
+			RefName: rejected.RefName,
+			OldHash: rejected.OldHash,
+			NewHash: rejected.NewHash,
+			Reason:  rejected.Reason,
+		})
+	}
+	for _, file := range result.LargeFiles {
+		event.LargeFiles = append(event.LargeFiles, events.LargeFile{
+			Path: file.Path,
+			Hash: file.Hash,
+			Size: file.Size,
+		})
 	}
+	h.publisher.Publish(event)
+}
 
-	// Get the latest commit on the default branch
-	commits, err := h.gitService.GetCommits(ctx, repo.GitPath, defaultBranch, 1, 0)
-	if err != nil || len(commits) == 0 {
-		h.log.Warn("Failed to get latest commit for CI trigger",
-			logger.Error(err),
-			logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
-			logger.String("branch", defaultBranch),
-		)
-		return
+// pushFailure returns the error of a push that failed on the server, or nil
+// if it completed or was refused (the refusal is part of its result)
+func pushFailure(err error) error {
+	if errors.Is(err, git.ErrPushRejected) {
+		return nil
 	}
-	latestCommit := commits[0]
+	return err
+}
 
-	// Check if CI config file exists in the repository
-	ciConfigPath := h.ciService.GetConfigPath()
-	_, err = h.gitService.GetFileContent(ctx, repo.GitPath, defaultBranch, ciConfigPath)
-	if err != nil {
-		h.log.Debug("CI config file not found, skipping CI trigger",
+// triggerCIAfterPush triggers CI jobs for the branches and tags updated by a push
+func (h *GitHandler) triggerCIAfterPush(repo *models.Repository, user *models.User, owner, repoName string, updates []domainservice.RefUpdate) {
+	if h.ciService == nil || !h.ciService.IsEnabled() {
+		h.log.Debug("CI service is not enabled, skipping CI trigger",
 			logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
-			logger.String("config_path", ciConfigPath),
 		)
 		return
 	}
-
-	// Build the clone URL for CI runner
-	cloneURL := h.ciService.BuildCloneURL(owner, repoName)
+	if len(updates) == 0 {
+		return
+	}
 
 	// Determine trigger actor
 	triggerActor := "anonymous"
@@ -265,55 +432,102 @@ func (h *GitHandler) triggerCIAfterPush(ctx context.Context, repo *models.Reposi
 		triggerActor = user.Username
 	}
 
-	// Trigger the CI job asynchronously
-	go func() {
-		triggerCtx := context.Background()
+	// The push response is already sent, so the jobs outlive the request
+	go h.ciService.TriggerPushJobs(context.Background(), repo, owner, repoName, triggerActor, updates)
+}
 
-		h.log.Info("Triggering CI job after push",
-			logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
-			logger.String("branch", defaultBranch),
-			logger.String("commit", latestCommit.Hash),
-			logger.String("actor", triggerActor),
-			logger.String("clone_url", cloneURL),
-			logger.String("config_path", ciConfigPath),
-		)
+// createRepositoryOnPush creates a missing repository for a push into the
+// authenticated user's own namespace. It writes the error response and returns
+// false if the repository cannot be created.
+func (h *GitHandler) createRepositoryOnPush(c *gin.Context, user *models.User, owner, repoName string) (*models.Repository, bool) {
+	if !h.repoService.IsCreateOnPushEnabled() {
+		repositoryNotFound(c)
+		return nil, false
+	}
 
-		job, err := h.ciService.TriggerJob(triggerCtx, &service.TriggerJobRequest{
-			RepositoryID: repo.ID,
-			Owner:        owner,
-			RepoName:     repoName,
-			CloneURL:     cloneURL,
-			CommitSHA:    latestCommit.Hash,
-			RefName:      defaultBranch,
-			RefType:      models.CIRefTypeBranch,
-			TriggerType:  models.CITriggerTypePush,
-			TriggerActor: triggerActor,
-			Metadata: map[string]string{
-				"commit_message": latestCommit.Message,
-				"author":         latestCommit.Author,
-				"author_email":   latestCommit.AuthorEmail,
-			},
+	// Ask git for credentials; anonymous pushes never create repositories
+	if user == nil {
+		c.Header("WWW-Authenticate", `Basic realm="Git Server"`)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
 		})
+		return nil, false
+	}
 
-		if err != nil {
-			h.log.Error("Failed to trigger CI job after push",
-				logger.Error(err),
-				logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
-				logger.String("commit", latestCommit.Hash),
-			)
-			return
-		}
+	if !h.repoService.CanCreateOnPush(user, owner) {
+		repositoryNotFound(c)
+		return nil, false
+	}
 
-		h.log.Info("CI job triggered successfully",
-			logger.String("job_id", job.ID.String()),
+	repo, err := h.repoService.CreateRepositoryOnPush(c.Request.Context(), user, owner, repoName)
+	if err != nil {
+		if apperrors.IsBadRequest(err) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "bad_request",
+				"message": err.Error(),
+			})
+			return nil, false
+		}
+		// Git prints a plain text body to the user
+		if apperrors.IsForbidden(err) {
+			c.Data(http.StatusForbidden, "text/plain; charset=utf-8", []byte("cannot create repository: "+err.Error()+"\n"))
+			return nil, false
+		}
+		h.log.WithContext(c.Request.Context()).Error("Failed to create repository on push",
+			logger.Error(err),
 			logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
-			logger.String("commit", latestCommit.Hash),
+			logger.String("user_id", user.ID.String()),
 		)
-	}()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to create repository",
+		})
+		return nil, false
+	}
+
+	h.createdOnPush.Store(repo.ID, struct{}{})
+	return repo, true
+}
+
+// requestCloneURL builds the HTTP clone URL of a repository as seen by the client
+func requestCloneURL(c *gin.Context, owner, repoName string) string {
+	return fmt.Sprintf("%s/%s/%s.git", requestBaseURL(c), owner, repoName)
+}
+
+// requestBaseURL returns the scheme and host of the server as seen by the
+// client. X-Forwarded-Proto is only believed from a trusted proxy, and only
+// for http or https, so clients cannot pick the scheme of the URLs served.
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if middleware.FromTrustedProxy(c) {
+		// A chain of proxies lists the scheme the client used first
+		proto, _, _ := strings.Cut(c.GetHeader("X-Forwarded-Proto"), ",")
+		switch proto = strings.ToLower(strings.TrimSpace(proto)); proto {
+		case "http", "https":
+			scheme = proto
+		}
+	}
+	return scheme + "://" + c.Request.Host
 }
 
 // checkRepoAccess checks if the user can access the repository
 func (h *GitHandler) checkRepoAccess(c *gin.Context, user *models.User, repo *models.Repository, isWrite bool) bool {
+	// CI job tokens only allow fetching the repository of their job
+	if jobToken := middleware.GetCIJobTokenFromContext(c); jobToken != nil {
+		if isWrite || jobToken.RepositoryID != repo.ID {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "forbidden",
+				"message": "CI job tokens can only be used to clone the repository of their job",
+			})
+			return false
+		}
+		return true
+	}
+
 	// Public repos allow read access to everyone
 	if !repo.IsPrivate && !isWrite {
 		return true
@@ -329,25 +543,17 @@ func (h *GitHandler) checkRepoAccess(c *gin.Context, user *models.User, repo *mo
 		return false
 	}
 
-	// Check if user has access
-	var hasAccess bool
-	if user.IsAdmin {
-		hasAccess = true
-	} else if user.ID == repo.OwnerID {
-		hasAccess = true
-	} else if !isWrite && !repo.IsPrivate {
-		// Read access to public repos
-		hasAccess = true
-	}
-	// TODO: Check collaborator permissions
-
-	if !hasAccess {
-		// Return 404 for private repos to avoid leaking existence
-		if repo.IsPrivate {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":   "not_found",
-				"message": "Repository not found",
-			})
+	// Check the user's permission as owner, site admin or collaborator
+	required := models.RepoPermissionRead
+	if isWrite {
+		required = models.RepoPermissionWrite
+	}
+	permission := h.repoService.RepositoryPermission(c.Request.Context(), user, repo)
+
+	if !permission.Allows(required) {
+		// Return 404 to users who cannot read the repository to avoid leaking existence
+		if !permission.Allows(models.RepoPermissionRead) {
+			repositoryNotFound(c)
 		} else {
 			c.JSON(http.StatusForbidden, gin.H{
 				"error":   "forbidden",
@@ -360,15 +566,83 @@ func (h *GitHandler) checkRepoAccess(c *gin.Context, user *models.User, repo *mo
 	return true
 }
 
+// getRepository returns the repository of a git request, following the
+// former name of a renamed repository, or from the snapshot of degraded mode
+// if the database is unavailable
+func (h *GitHandler) getRepository(c *gin.Context, owner, repoName string) (*models.Repository, error) {
+	if middleware.IsDegraded(c) {
+		return h.degraded.Lookup(owner, repoName)
+	}
+	return h.repoService.ResolveRepository(c.Request.Context(), owner, repoName)
+}
+
+// repositoryLookupFailed writes the response to a failed lookup of the
+// repository of a git request
+func (h *GitHandler) repositoryLookupFailed(c *gin.Context, err error) {
+	if apperrors.IsNotFound(err) {
+		repositoryNotFound(c)
+		return
+	}
+	if apperrors.IsUnavailable(err) {
+		c.Header("Retry-After", "30")
+		c.Data(http.StatusServiceUnavailable, "text/plain; charset=utf-8", []byte(err.Error()+"\n"))
+		return
+	}
+	h.log.WithContext(c.Request.Context()).Error("Failed to look up repository",
+		logger.Error(err),
+		logger.String("repo", fmt.Sprintf("%s/%s", c.Param("owner"), strings.TrimSuffix(c.Param("repo"), ".git"))),
+	)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "Failed to get repository info",
+	})
+}
+
+// repositoryNotFound answers a git request for a repository that does not
+// exist, under an owner that may not exist either, or that the user cannot
+// read. Git prints a plain text body of an error response to the user.
+func repositoryNotFound(c *gin.Context) {
+	c.Data(http.StatusNotFound, "text/plain; charset=utf-8", []byte(git.RepositoryNotFoundMessage+"\n"))
+}
+
+// acquireRepository registers a git operation on a repository, a push if
+// push is set, answering 503 while the repository is being migrated to
+// another storage backend
+func (h *GitHandler) acquireRepository(c *gin.Context, repo *models.Repository, push bool) (func(), bool) {
+	acquire := h.storage.AcquireRepository
+	if push {
+		acquire = h.storage.AcquirePush
+	}
+	release, err := acquire(repo)
+	if err != nil {
+		c.Header("Retry-After", "30")
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "service_unavailable",
+			"message": "Repository storage is being migrated, try again shortly",
+		})
+		return nil, false
+	}
+	return release, true
+}
+
+// readRepoFile reads a file of a repository from its storage backend
+func (h *GitHandler) readRepoFile(repo *models.Repository, path string) ([]byte, error) {
+	backend, err := h.storage.ForRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	return backend.ReadFile(path)
+}
+
 // HandleGetHEAD handles GET /{owner}/{repo}/HEAD (dumb protocol fallback)
 func (h *GitHandler) HandleGetHEAD(c *gin.Context) {
 	owner := c.Param("owner")
 	repoName := c.Param("repo")
 	repoName = strings.TrimSuffix(repoName, ".git")
 
-	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
+	repo, err := h.getRepository(c, owner, repoName)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		h.repositoryLookupFailed(c, err)
 		return
 	}
 
@@ -378,9 +652,9 @@ func (h *GitHandler) HandleGetHEAD(c *gin.Context) {
 	}
 
 	headPath := fmt.Sprintf("%s/HEAD", repo.GitPath)
-	data, err := h.storage.ReadFile(headPath)
+	data, err := h.readRepoFile(repo, headPath)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		c.Status(http.StatusNotFound)
 		return
 	}
 
@@ -405,9 +679,9 @@ func (h *GitHandler) HandleGetObject(c *gin.Context) {
 		objectPath = dir + "/" + file
 	}
 
-	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
+	repo, err := h.getRepository(c, owner, repoName)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		h.repositoryLookupFailed(c, err)
 		return
 	}
 
@@ -420,9 +694,14 @@ func (h *GitHandler) HandleGetObject(c *gin.Context) {
 	fullPath := fmt.Sprintf("%s/objects/%s", repo.GitPath, objectPath)
 
 	// Open and stream file
-	reader, err := h.storage.OpenFile(fullPath)
+	backend, err := h.storage.ForRepo(repo)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	reader, err := backend.OpenFile(fullPath)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		c.Status(http.StatusNotFound)
 		return
 	}
 	defer reader.Close()
@@ -453,9 +732,9 @@ func (h *GitHandler) HandleGetRefs(c *gin.Context) {
 		refPath = "tags/" + tag
 	}
 
-	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
+	repo, err := h.getRepository(c, owner, repoName)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		h.repositoryLookupFailed(c, err)
 		return
 	}
 
@@ -466,9 +745,9 @@ func (h *GitHandler) HandleGetRefs(c *gin.Context) {
 
 	fullPath := fmt.Sprintf("%s/refs/%s", repo.GitPath, refPath)
 
-	data, err := h.storage.ReadFile(fullPath)
+	data, err := h.readRepoFile(repo, fullPath)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		c.Status(http.StatusNotFound)
 		return
 	}
 
@@ -482,9 +761,9 @@ func (h *GitHandler) HandleGetInfoPacks(c *gin.Context) {
 	repoName := c.Param("repo")
 	repoName = strings.TrimSuffix(repoName, ".git")
 
-	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
+	repo, err := h.getRepository(c, owner, repoName)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		h.repositoryLookupFailed(c, err)
 		return
 	}
 
@@ -495,7 +774,7 @@ func (h *GitHandler) HandleGetInfoPacks(c *gin.Context) {
 
 	packsPath := fmt.Sprintf("%s/objects/info/packs", repo.GitPath)
 
-	data, err := h.storage.ReadFile(packsPath)
+	data, err := h.readRepoFile(repo, packsPath)
 	if err != nil {
 		// Return empty response if file doesn't exist
 		c.Header("Content-Type", "text/plain")
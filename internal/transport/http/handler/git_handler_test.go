@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+)
+
+// Only a trusted proxy picks the scheme of the URLs served, and only http or
+// https
+func TestRequestBaseURL(t *testing.T) {
+	proxies := []string{"10.0.0.0/8"}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		tls        bool
+		proto      string
+		want       string
+	}{
+		{name: "plain", remoteAddr: "203.0.113.7:40000", want: "http://git.example.com"},
+		{name: "TLS", remoteAddr: "203.0.113.7:40000", tls: true, want: "https://git.example.com"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.2:40000", proto: "https", want: "https://git.example.com"},
+		{name: "trusted proxy downgrading", remoteAddr: "10.0.0.2:40000", tls: true, proto: "http", want: "http://git.example.com"},
+		{name: "trusted proxy chain", remoteAddr: "10.0.0.2:40000", proto: "HTTPS, http", want: "https://git.example.com"},
+		{name: "spoofed by a client", remoteAddr: "203.0.113.7:40000", proto: "https", want: "http://git.example.com"},
+		{name: "spoofed over TLS", remoteAddr: "203.0.113.7:40000", tls: true, proto: "http", want: "https://git.example.com"},
+		{name: "other scheme", remoteAddr: "10.0.0.2:40000", proto: "javascript", want: "http://git.example.com"},
+		{name: "scheme with a host", remoteAddr: "10.0.0.2:40000", proto: "https://evil.example", want: "http://git.example.com"},
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.TrustedProxyMiddleware(proxies))
+	engine.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, requestBaseURL(c))
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://git.example.com/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			if tt.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("requestBaseURL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// flushWriter writes git output to the client as it comes instead of
+// letting the response buffer it, so clones and fetches of large
+// repositories start at once and progress messages arrive live. Responses
+// have no Content-Length, so net/http sends them chunked.
+type flushWriter struct {
+	w gin.ResponseWriter
+}
+
+// Write implements io.Writer
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if n > 0 {
+		fw.w.Flush()
+	}
+	return n, err
+}
+
+// gitRequestBody returns the body of a smart HTTP request, decompressed if
+// the client sent it gzipped as git does for large requests. It answers 400
+// and returns false if the body is not valid gzip.
+func gitRequestBody(c *gin.Context) (io.ReadCloser, bool) {
+	switch strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding"))) {
+	case "", "identity":
+		return c.Request.Body, true
+	case "gzip", "x-gzip":
+		body, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "bad_request",
+				"message": "Invalid gzip request body",
+			})
+			return nil, false
+		}
+		return body, true
+	default:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error":   "unsupported_media_type",
+			"message": "Unsupported content encoding",
+		})
+		return nil, false
+	}
+}
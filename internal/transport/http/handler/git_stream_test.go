@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/infrastructure/git"
+)
+
+// newStreamRepo returns a repository with one commit and the commit hash
+func newStreamRepo(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := filepath.Join(t.TempDir(), "repo")
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL=/dev/null")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	run("init", "--quiet", "--initial-branch=main", dir)
+	run("-C", dir, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--quiet", "--allow-empty", "-m", "Initial commit")
+	return filepath.Join(dir, ".git"), run("-C", dir, "rev-parse", "HEAD")
+}
+
+// uploadPackResult is a request handled by the server of newUploadPackServer
+type uploadPackResult struct {
+	transferEncoding []string
+	err              error
+}
+
+// newUploadPackServer serves upload-pack of repoPath like HandleUploadPack,
+// sending on done once a request has been handled
+func newUploadPackServer(t *testing.T, repoPath string, done chan<- uploadPackResult) *httptest.Server {
+	t.Helper()
+	protocol, err := git.NewGitProtocol(git.ReceiveLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/git-upload-pack", func(c *gin.Context) {
+		body, ok := gitRequestBody(c)
+		if !ok {
+			return
+		}
+		defer body.Close()
+		err := protocol.HandleUploadPack(c.Request.Context(), repoPath, body, flushWriter{c.Writer}, "", models.DefaultUploadPackSettings())
+		done <- uploadPackResult{transferEncoding: c.Request.TransferEncoding, err: err}
+	})
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// gitChildren returns the git processes this process started that are still
+// running
+func gitChildren(t *testing.T) []string {
+	t.Helper()
+	stats, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ppid := strconv.Itoa(os.Getpid())
+	var children []string
+	for _, stat := range stats {
+		data, err := os.ReadFile(stat)
+		if err != nil {
+			continue
+		}
+		// pid (comm) state ppid ...
+		end := bytes.LastIndexByte(data, ')')
+		start := bytes.IndexByte(data, '(')
+		if start < 0 || end < start {
+			continue
+		}
+		fields := strings.Fields(string(data[end+1:]))
+		if len(fields) > 1 && fields[1] == ppid && strings.HasPrefix(string(data[start+1:end]), "git") {
+			children = append(children, strings.TrimSpace(string(data[:start])))
+		}
+	}
+	return children
+}
+
+// Requests of unknown length arrive chunked, plain or gzipped as git sends
+// them, and reach git whole
+func TestUploadPackChunkedBody(t *testing.T) {
+	repoPath, tip := newStreamRepo(t)
+	done := make(chan uploadPackResult, 1)
+	server := newUploadPackServer(t, repoPath, done)
+	request := git.EncodePktLine("want "+tip+" ofs-delta\n") + git.FlushPacket() + git.EncodePktLine("done\n")
+
+	tests := []struct {
+		name     string
+		encoding string
+		encode   func(w io.Writer) io.WriteCloser
+	}{
+		{name: "plain", encode: func(w io.Writer) io.WriteCloser { return nopWriteCloser{w} }},
+		{name: "gzip", encoding: "gzip", encode: func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr, pw := io.Pipe()
+			go func() {
+				w := tt.encode(pw)
+				// Written in pieces, so the body is sent in several chunks
+				for _, line := range strings.SplitAfter(request, "\n") {
+					if _, err := io.WriteString(w, line); err != nil {
+						pw.CloseWithError(err)
+						return
+					}
+				}
+				pw.CloseWithError(w.Close())
+			}()
+
+			req, err := http.NewRequest(http.MethodPost, server.URL+"/git-upload-pack", pr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/x-git-upload-pack-request")
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result := <-done; !slices.Equal(result.transferEncoding, []string{"chunked"}) || result.err != nil {
+				t.Errorf("request sent with Transfer-Encoding %v failed: %v", result.transferEncoding, result.err)
+			}
+			if resp.StatusCode != http.StatusOK || !bytes.Contains(body, []byte("PACK")) {
+				t.Errorf("status %d without a pack: %q", resp.StatusCode, body)
+			}
+		})
+	}
+}
+
+// A client going away in the middle of its request kills git, which would
+// otherwise wait for the rest of it
+func TestUploadPackCanceledKillsGit(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("processes cannot be listed without /proc")
+	}
+	repoPath, tip := newStreamRepo(t)
+	done := make(chan uploadPackResult, 1)
+	server := newUploadPackServer(t, repoPath, done)
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/git-upload-pack", pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-git-upload-pack-request")
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	// Half a request: git waits for the rest
+	if _, err := io.WriteString(pw, git.EncodePktLine("want "+tip+" ofs-delta\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(gitChildren(t)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("git upload-pack did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case result := <-done:
+		if result.err == nil {
+			t.Error("HandleUploadPack succeeded for a canceled request")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("HandleUploadPack still running after the client went away")
+	}
+	if children := gitChildren(t); len(children) != 0 {
+		t.Errorf("git processes still running after the client went away: %v", children)
+	}
+}
+
+// nopWriteCloser adds a no-op Close to an io.Writer
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
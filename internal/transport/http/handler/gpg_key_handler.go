@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// GPGKeyHandler handles GPG key-related HTTP requests
+type GPGKeyHandler struct {
+	gpgKeyService *service.GPGKeyService
+	auditEvents   *service.AuditEventService
+}
+
+// NewGPGKeyHandler creates a new GPGKeyHandler instance
+func NewGPGKeyHandler(gpgKeyService *service.GPGKeyService, auditEvents *service.AuditEventService) *GPGKeyHandler {
+	return &GPGKeyHandler{
+		gpgKeyService: gpgKeyService,
+		auditEvents:   auditEvents,
+	}
+}
+
+// AddGPGKey handles POST /api/v1/gpg-keys
+func (h *GPGKeyHandler) AddGPGKey(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+
+	var req dto.AddGPGKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	key, err := h.gpgKeyService.AddGPGKey(c.Request.Context(), user.ID, req.ArmoredPublicKey)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	recordAuditEvent(c, h.auditEvents, models.AuditActionGPGKeyAdd, nil, map[string]any{
+		"key_id":      key.ID.String(),
+		"gpg_key_id":  key.KeyID,
+		"fingerprint": key.Fingerprint,
+	})
+
+	c.JSON(http.StatusCreated, dto.GPGKeyFromModel(key))
+}
+
+// ListGPGKeys handles GET /api/v1/gpg-keys
+func (h *GPGKeyHandler) ListGPGKeys(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+
+	keys, err := h.gpgKeyService.ListGPGKeys(c.Request.Context(), user.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	keyInfos := make([]dto.GPGKeyInfo, len(keys))
+	for i, key := range keys {
+		keyInfos[i] = dto.GPGKeyFromModel(key)
+	}
+
+	c.JSON(http.StatusOK, dto.ListGPGKeysResponse{
+		Keys:  keyInfos,
+		Total: len(keys),
+	})
+}
+
+// GetGPGKey handles GET /api/v1/gpg-keys/:id
+func (h *GPGKeyHandler) GetGPGKey(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid GPG key ID",
+		})
+		return
+	}
+
+	key, err := h.gpgKeyService.GetGPGKey(c.Request.Context(), keyID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// Verify ownership
+	if key.UserID != user.ID && !user.IsAdmin {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "GPG key not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.GPGKeyFromModel(key))
+}
+
+// DeleteGPGKey handles DELETE /api/v1/gpg-keys/:id
+func (h *GPGKeyHandler) DeleteGPGKey(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid GPG key ID",
+		})
+		return
+	}
+
+	// Admin can delete any key
+	if user.IsAdmin {
+		err = h.gpgKeyService.DeleteGPGKeyAdmin(c.Request.Context(), keyID)
+	} else {
+		err = h.gpgKeyService.DeleteGPGKey(c.Request.Context(), user.ID, keyID)
+	}
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	recordAuditEvent(c, h.auditEvents, models.AuditActionGPGKeyDelete, nil, map[string]any{
+		"key_id": keyID.String(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "GPG key deleted successfully",
+	})
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *GPGKeyHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsConflict(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "conflict",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An unexpected error occurred",
+	})
+}
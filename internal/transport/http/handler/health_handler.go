@@ -1,9 +1,95 @@
 package handler
 
-import "github.com/gin-gonic/gin"
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/server"
+)
+
+// readinessPingTimeout bounds the database ping of a readiness check
+const readinessPingTimeout = 2 * time.Second
 
 func HealthHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Write([]byte("OK!"))
 	}
 }
+
+// LivenessHandler answers 200 as long as the process serves requests, ready
+// or not, so orchestrators do not restart a server waiting for its database
+func LivenessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// ReadinessHandler answers 200 once the server is ready and 503 before, with
+// the startup state in the body. A ready server whose database does not answer
+// ping is reported degraded; ping may be nil before the database is connected.
+// With degraded mode enabled, degradedMode is not nil and its state is in the
+// body: a degraded server serving public repositories from its snapshot
+// answers 200, so load balancers keep sending it fetches.
+func ReadinessHandler(readiness *server.Readiness, ping func(context.Context) error, degradedMode *service.DegradedModeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state, reason := readiness.Get()
+		if state == server.StateReady && ping != nil {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), readinessPingTimeout)
+			err := ping(ctx)
+			cancel()
+			if err != nil {
+				state, reason = server.StateDegraded, "database is unreachable"
+			}
+		}
+
+		resp := dto.ReadinessResponse{State: string(state), Reason: reason}
+		status := http.StatusOK
+		if state != server.StateReady {
+			status = http.StatusServiceUnavailable
+		}
+
+		if degradedMode != nil {
+			degraded := degradedMode.Status()
+			resp.DegradedMode = degradedModeResponse(degraded)
+			if state == server.StateDegraded && degraded.Serving {
+				resp.Reason = "database is unreachable, serving public repositories read-only"
+				status = http.StatusOK
+			}
+		}
+		c.JSON(status, resp)
+	}
+}
+
+// degradedModeResponse converts the state of degraded mode to its response
+func degradedModeResponse(status service.DegradedModeStatus) *dto.DegradedModeResponse {
+	return &dto.DegradedModeResponse{
+		Active:               status.Active,
+		Serving:              status.Serving,
+		Since:                status.Since,
+		SnapshotTakenAt:      status.SnapshotTakenAt,
+		SnapshotRepositories: status.SnapshotRepositories,
+		Metrics: dto.DegradedModeMetrics{
+			Episodes:         status.Metrics.Episodes,
+			DegradedSeconds:  status.Metrics.DegradedTime.Seconds(),
+			ServedFetches:    status.Metrics.ServedFetches,
+			RejectedRequests: status.Metrics.RejectedRequests,
+		},
+	}
+}
+
+// NotReadyHandler answers 503 for the routes that are not served until the
+// server is ready
+func NotReadyHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Retry-After", "10")
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "service_unavailable",
+			"message": "Server is starting, try again shortly",
+		})
+	}
+}
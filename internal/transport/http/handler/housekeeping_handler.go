@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// HousekeepingHandler handles housekeeping scheduler HTTP requests
+type HousekeepingHandler struct {
+	housekeeping *service.HousekeepingService
+	log          *logger.Logger
+}
+
+// NewHousekeepingHandler creates a new HousekeepingHandler instance
+func NewHousekeepingHandler(housekeeping *service.HousekeepingService) *HousekeepingHandler {
+	return &HousekeepingHandler{
+		housekeeping: housekeeping,
+		log:          logger.Get().WithFields(logger.Component("housekeeping-handler")),
+	}
+}
+
+// ListTasks handles GET /api/v1/admin/housekeeping
+func (h *HousekeepingHandler) ListTasks(c *gin.Context) {
+	statuses, err := h.housekeeping.ListTasks(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	tasks := make([]dto.HousekeepingTaskResponse, len(statuses))
+	for i, status := range statuses {
+		task := dto.HousekeepingTaskResponse{
+			Name:         status.Task.Name,
+			Description:  status.Task.Description,
+			IntervalSecs: int64(status.Task.Interval.Seconds()),
+			JitterSecs:   int64(status.Task.Jitter.Seconds()),
+			Metrics: dto.HousekeepingMetricsResponse{
+				Runs:            status.Metrics.Runs,
+				Failures:        status.Metrics.Failures,
+				Skipped:         status.Metrics.Skipped,
+				TotalDurationMs: status.Metrics.TotalDuration.Milliseconds(),
+			},
+		}
+		if state := status.State; state != nil {
+			task.NextRunAt = &state.NextRunAt
+			task.LastStartedAt = state.LastStartedAt
+			task.LastDurationMs = state.LastDurationMs
+			task.LastOutcome = state.LastOutcome
+			task.LastError = state.LastError
+			task.LastRunBy = state.LastRunBy
+			task.RunCount = state.RunCount
+			task.FailureCount = state.FailureCount
+		}
+		tasks[i] = task
+	}
+
+	c.JSON(http.StatusOK, dto.HousekeepingTaskListResponse{Tasks: tasks})
+}
+
+// TriggerTask handles POST /api/v1/admin/housekeeping/:name/run
+func (h *HousekeepingHandler) TriggerTask(c *gin.Context) {
+	if err := h.housekeeping.Trigger(c.Request.Context(), c.Param("name")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Housekeeping task scheduled",
+	})
+}
+
+// TriggerCICleanup handles POST /api/v1/admin/ci/cleanup
+func (h *HousekeepingHandler) TriggerCICleanup(c *gin.Context) {
+	names, err := h.housekeeping.TriggerGroup(c.Request.Context(), "ci")
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "CI cleanup scheduled",
+		"tasks":   names,
+	})
+}
+
+// handleError handles errors and sends appropriate HTTP responses
+func (h *HousekeepingHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Housekeeping task not found",
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Housekeeping request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
@@ -0,0 +1,384 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// IssueHandler handles issue and issue comment HTTP requests
+type IssueHandler struct {
+	repoService *service.RepoService
+	issues      *service.IssueService
+	log         *logger.Logger
+}
+
+// NewIssueHandler creates a new IssueHandler instance
+func NewIssueHandler(
+	repoService *service.RepoService,
+	issues *service.IssueService,
+) *IssueHandler {
+	return &IssueHandler{
+		repoService: repoService,
+		issues:      issues,
+		log:         logger.Get().WithFields(logger.Component("issue-handler")),
+	}
+}
+
+// ListIssues handles GET /api/v1/repos/:owner/:repo/issues
+func (h *IssueHandler) ListIssues(c *gin.Context) {
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return
+	}
+
+	filter := repository.IssueFilter{State: c.Query("state")}
+	if filter.State != "" && filter.State != models.IssueOpen && filter.State != models.IssueClosed {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "State must be open or closed",
+		})
+		return
+	}
+	if labels := c.Query("labels"); labels != "" {
+		normalized, err := service.NormalizeIssueLabels(strings.Split(labels, ","))
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+		filter.Labels = normalized
+	}
+
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
+	}
+
+	issues, total, err := h.issues.ListIssues(c.Request.Context(), repo, filter, page.PerPage, page.Offset)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	responses := make([]dto.IssueResponse, 0, len(issues))
+	for _, issue := range issues {
+		responses = append(responses, dto.IssueFromModel(issue))
+	}
+
+	c.JSON(http.StatusOK, dto.IssueListResponse{
+		Issues:     responses,
+		Pagination: page.Counted(len(responses), total),
+	})
+}
+
+// GetIssue handles GET /api/v1/repos/:owner/:repo/issues/:number
+func (h *IssueHandler) GetIssue(c *gin.Context) {
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return
+	}
+
+	issue, ok := h.getIssue(c, repo)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.IssueFromModel(issue))
+}
+
+// CreateIssue handles POST /api/v1/repos/:owner/:repo/issues
+func (h *IssueHandler) CreateIssue(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateIssueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	issue := req.ToModel()
+	if err := h.issues.CreateIssue(c.Request.Context(), repo, user, issue); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.IssueFromModel(issue))
+}
+
+// UpdateIssue handles PATCH /api/v1/repos/:owner/:repo/issues/:number
+func (h *IssueHandler) UpdateIssue(c *gin.Context) {
+	repo, issue, ok := h.getManageableIssue(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateIssueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	update := service.IssueUpdate{
+		Title:  req.Title,
+		Body:   req.Body,
+		Labels: req.Labels,
+		State:  req.State,
+	}
+	if err := h.issues.UpdateIssue(c.Request.Context(), repo, issue, update); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.IssueFromModel(issue))
+}
+
+// CloseIssue handles POST /api/v1/repos/:owner/:repo/issues/:number/close
+func (h *IssueHandler) CloseIssue(c *gin.Context) {
+	repo, issue, ok := h.getManageableIssue(c)
+	if !ok {
+		return
+	}
+
+	if err := h.issues.CloseIssue(c.Request.Context(), repo, issue); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.IssueFromModel(issue))
+}
+
+// ListComments handles GET /api/v1/repos/:owner/:repo/issues/:number/comments
+func (h *IssueHandler) ListComments(c *gin.Context) {
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return
+	}
+
+	issue, ok := h.getIssue(c, repo)
+	if !ok {
+		return
+	}
+
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
+	}
+
+	comments, total, err := h.issues.ListComments(c.Request.Context(), issue, page.PerPage, page.Offset)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	responses := make([]dto.IssueCommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		responses = append(responses, dto.IssueCommentFromModel(comment))
+	}
+
+	c.JSON(http.StatusOK, dto.IssueCommentListResponse{
+		Comments:   responses,
+		Pagination: page.Counted(len(responses), total),
+	})
+}
+
+// CreateComment handles POST /api/v1/repos/:owner/:repo/issues/:number/comments
+func (h *IssueHandler) CreateComment(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return
+	}
+
+	issue, ok := h.getIssue(c, repo)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateIssueCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	comment, err := h.issues.CreateComment(c.Request.Context(), issue, user, req.Body)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.IssueCommentFromModel(comment))
+}
+
+// getReadableRepository loads the repository from the path and checks that the
+// user, if any, can read it. It writes the error response and returns false if
+// the request cannot proceed.
+func (h *IssueHandler) getReadableRepository(c *gin.Context) (*models.Repository, bool) {
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return nil, false
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return nil, false
+	}
+
+	return repo, true
+}
+
+// getIssue loads the issue numbered in the path.
+// It writes the error response and returns false if there is none.
+func (h *IssueHandler) getIssue(c *gin.Context, repo *models.Repository) (*models.Issue, bool) {
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid issue number",
+		})
+		return nil, false
+	}
+
+	issue, err := h.issues.GetIssue(c.Request.Context(), repo, number)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Issue not found",
+			})
+			return nil, false
+		}
+		h.handleError(c, err)
+		return nil, false
+	}
+	return issue, true
+}
+
+// getManageableIssue loads the issue numbered in the path and checks that the
+// user may edit or close it: its author, or an administrator of the
+// repository such as its owner or an instance admin. It writes the error
+// response and returns false if the request cannot proceed.
+func (h *IssueHandler) getManageableIssue(c *gin.Context) (*models.Repository, *models.Issue, bool) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return nil, nil, false
+	}
+
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	issue, ok := h.getIssue(c, repo)
+	if !ok {
+		return nil, nil, false
+	}
+
+	isAuthor := issue.AuthorID != nil && *issue.AuthorID == user.ID
+	if !isAuthor && !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Only the author or a repository admin can change this issue",
+		})
+		return nil, nil, false
+	}
+
+	return repo, issue, true
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *IssueHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsForbidden(err) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsConflict(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "conflict",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Issue request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
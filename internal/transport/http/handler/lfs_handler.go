@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// lfsContentType is the media type of Git LFS API requests and responses
+	lfsContentType = "application/vnd.git-lfs+json"
+
+	// maxLFSBatchObjects is the most objects a Git LFS batch request may list
+	maxLFSBatchObjects = 1000
+)
+
+// HandleLFSBatch handles POST /{owner}/{repo}/info/lfs/objects/batch.
+// It tells the client where to upload or download each object, using the
+// basic transfer adapter and the object endpoints below.
+func (h *GitHandler) HandleLFSBatch(c *gin.Context) {
+	owner := c.Param("owner")
+	repoName := strings.TrimSuffix(c.Param("repo"), ".git")
+
+	var req dto.LFSBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lfsJSON(c, http.StatusBadRequest, dto.LFSErrorResponse{Message: "Invalid request body"})
+		return
+	}
+	if req.Operation != "upload" && req.Operation != "download" {
+		lfsJSON(c, http.StatusUnprocessableEntity, dto.LFSErrorResponse{Message: "Operation must be upload or download"})
+		return
+	}
+	if req.HashAlgo != "" && req.HashAlgo != "sha256" {
+		lfsJSON(c, http.StatusConflict, dto.LFSErrorResponse{Message: "Only the sha256 hash algorithm is supported"})
+		return
+	}
+	if len(req.Objects) > maxLFSBatchObjects {
+		lfsJSON(c, http.StatusRequestEntityTooLarge, dto.LFSErrorResponse{
+			Message: "A batch request may list at most " + strconv.Itoa(maxLFSBatchObjects) + " objects",
+		})
+		return
+	}
+
+	repo, err := h.repoService.ResolveRepository(c.Request.Context(), owner, repoName)
+	if err != nil {
+		lfsJSON(c, http.StatusNotFound, dto.LFSErrorResponse{Message: "Repository not found"})
+		return
+	}
+
+	user := middleware.GetUserFromContext(c)
+	upload := req.Operation == "upload"
+	if !h.checkRepoAccess(c, user, repo, upload) {
+		return
+	}
+
+	// The object endpoints authenticate like this request, so the client
+	// sends them the credentials it used here
+	var header map[string]string
+	if auth := c.GetHeader("Authorization"); auth != "" {
+		header = map[string]string{"Authorization": auth}
+	}
+	objectsURL := requestCloneURL(c, owner, repoName) + "/info/lfs/objects/"
+
+	// The uploads of a batch are held to the quota together
+	var budget *service.LFSUploadBudget
+	if upload {
+		budget = h.lfs.UploadBudget(c.Request.Context(), repo)
+	}
+
+	resp := dto.LFSBatchResponse{
+		Transfer: "basic",
+		Objects:  make([]dto.LFSObjectResponse, len(req.Objects)),
+		HashAlgo: "sha256",
+	}
+	for i, object := range req.Objects {
+		obj := service.LFSObject{OID: object.OID, Size: object.Size}
+		out := dto.LFSObjectResponse{OID: obj.OID, Size: obj.Size, Authenticated: header != nil}
+
+		if err := h.lfs.ValidateObject(obj); err != nil {
+			out.Error = &dto.LFSObjectError{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+			resp.Objects[i] = out
+			continue
+		}
+
+		size, exists, err := h.lfs.Stat(repo, obj.OID)
+		switch {
+		case err != nil:
+			h.log.WithContext(c.Request.Context()).Error("Failed to look up LFS object",
+				logger.Error(err),
+				logger.String("repo_id", repo.ID.String()),
+				logger.String("oid", obj.OID),
+			)
+			out.Error = &dto.LFSObjectError{Code: http.StatusInternalServerError, Message: "Failed to look up object"}
+		case upload && !exists:
+			if err := budget.Reserve(obj); err != nil {
+				var appErr *apperrors.AppError
+				errors.As(err, &appErr)
+				out.Error = &dto.LFSObjectError{Code: appErr.HTTPStatus(), Message: appErr.Message}
+				break
+			}
+			out.Actions = map[string]dto.LFSAction{
+				"upload": {Href: objectsURL + obj.OID, Header: header},
+				"verify": {Href: objectsURL + "verify", Header: header},
+			}
+		case upload:
+			// Already stored: nothing to transfer
+		case !exists:
+			out.Error = &dto.LFSObjectError{Code: http.StatusNotFound, Message: "Object does not exist"}
+		case size != obj.Size:
+			out.Error = &dto.LFSObjectError{Code: http.StatusUnprocessableEntity, Message: "Object size does not match"}
+		default:
+			out.Actions = map[string]dto.LFSAction{
+				"download": {Href: objectsURL + obj.OID, Header: header},
+			}
+		}
+		resp.Objects[i] = out
+	}
+
+	lfsJSON(c, http.StatusOK, resp)
+}
+
+// HandleLFSUpload handles PUT /{owner}/{repo}/info/lfs/objects/:oid.
+// The body must be the object itself: its length is taken from Content-Length
+// and its SHA-256 hash must be the object id.
+func (h *GitHandler) HandleLFSUpload(c *gin.Context) {
+	repoName := strings.TrimSuffix(c.Param("repo"), ".git")
+
+	repo, err := h.repoService.ResolveRepository(c.Request.Context(), c.Param("owner"), repoName)
+	if err != nil {
+		lfsJSON(c, http.StatusNotFound, dto.LFSErrorResponse{Message: "Repository not found"})
+		return
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if !h.checkRepoAccess(c, user, repo, true) {
+		return
+	}
+
+	if c.Request.ContentLength < 0 {
+		lfsJSON(c, http.StatusLengthRequired, dto.LFSErrorResponse{Message: "Content-Length is required"})
+		return
+	}
+
+	release, ok := h.acquireRepository(c, repo, false)
+	if !ok {
+		return
+	}
+	defer release()
+
+	obj := service.LFSObject{OID: c.Param("oid"), Size: c.Request.ContentLength}
+	if err := h.lfs.Upload(c.Request.Context(), repo, obj, c.Request.Body); err != nil {
+		h.handleLFSError(c, err)
+		return
+	}
+
+	// Record the new size of the repository for quotas
+	h.quotas.RecordSizeAsync(repo)
+
+	c.Status(http.StatusOK)
+}
+
+// HandleLFSVerify handles POST /{owner}/{repo}/info/lfs/objects/verify, with
+// which the client confirms an upload arrived whole
+func (h *GitHandler) HandleLFSVerify(c *gin.Context) {
+	repoName := strings.TrimSuffix(c.Param("repo"), ".git")
+
+	var req dto.LFSObjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		lfsJSON(c, http.StatusBadRequest, dto.LFSErrorResponse{Message: "Invalid request body"})
+		return
+	}
+
+	repo, err := h.repoService.ResolveRepository(c.Request.Context(), c.Param("owner"), repoName)
+	if err != nil {
+		lfsJSON(c, http.StatusNotFound, dto.LFSErrorResponse{Message: "Repository not found"})
+		return
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if !h.checkRepoAccess(c, user, repo, true) {
+		return
+	}
+
+	if err := h.lfs.Verify(repo, service.LFSObject{OID: req.OID, Size: req.Size}); err != nil {
+		h.handleLFSError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// HandleLFSDownload handles GET /{owner}/{repo}/info/lfs/objects/:oid
+func (h *GitHandler) HandleLFSDownload(c *gin.Context) {
+	repoName := strings.TrimSuffix(c.Param("repo"), ".git")
+
+	repo, err := h.repoService.ResolveRepository(c.Request.Context(), c.Param("owner"), repoName)
+	if err != nil {
+		lfsJSON(c, http.StatusNotFound, dto.LFSErrorResponse{Message: "Repository not found"})
+		return
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if !h.checkRepoAccess(c, user, repo, false) {
+		return
+	}
+
+	release, ok := h.acquireRepository(c, repo, false)
+	if !ok {
+		return
+	}
+	defer release()
+
+	reader, size, err := h.lfs.Open(repo, c.Param("oid"))
+	if err != nil {
+		h.handleLFSError(c, err)
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Length", strconv.FormatInt(size, 10))
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		h.log.WithContext(c.Request.Context()).Warn("Failed to send LFS object",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+	}
+}
+
+// handleLFSError answers a failed Git LFS object request
+func (h *GitHandler) handleLFSError(c *gin.Context, err error) {
+	var appErr *apperrors.AppError
+	switch {
+	case apperrors.IsNotFound(err):
+		lfsJSON(c, http.StatusNotFound, dto.LFSErrorResponse{Message: "Object does not exist"})
+	case apperrors.IsBadRequest(err):
+		lfsJSON(c, http.StatusBadRequest, dto.LFSErrorResponse{Message: err.Error()})
+	case errors.As(err, &appErr) && (appErr.HTTPStatus() == http.StatusUnprocessableEntity || appErr.HTTPStatus() == http.StatusRequestEntityTooLarge):
+		lfsJSON(c, appErr.HTTPStatus(), dto.LFSErrorResponse{Message: err.Error()})
+	default:
+		h.log.WithContext(c.Request.Context()).Error("LFS request failed", logger.Error(err))
+		lfsJSON(c, http.StatusInternalServerError, dto.LFSErrorResponse{Message: "An internal error occurred"})
+	}
+}
+
+// lfsJSON writes a Git LFS API response
+func lfsJSON(c *gin.Context, status int, body any) {
+	c.Header("Content-Type", lfsContentType)
+	c.JSON(status, body)
+}
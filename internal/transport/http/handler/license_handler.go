@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// LicenseHandler handles repository license administration HTTP requests
+type LicenseHandler struct {
+	licenses *service.LicenseService
+	log      *logger.Logger
+}
+
+// NewLicenseHandler creates a new LicenseHandler instance
+func NewLicenseHandler(licenses *service.LicenseService) *LicenseHandler {
+	return &LicenseHandler{
+		licenses: licenses,
+		log:      logger.Get().WithFields(logger.Component("license-handler")),
+	}
+}
+
+// ListLicenseCounts handles GET /api/v1/admin/licenses
+func (h *LicenseHandler) ListLicenseCounts(c *gin.Context) {
+	counts, err := h.licenses.CountByLicense(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to count repositories by license",
+		})
+		return
+	}
+
+	resp := dto.LicenseCountListResponse{Licenses: make([]dto.LicenseCountResponse, len(counts))}
+	for i, count := range counts {
+		license := count.License
+		if license == "" {
+			license = service.LicenseFilterNone
+		}
+		resp.Licenses[i] = dto.LicenseCountResponse{License: license, Count: count.Count}
+		resp.Total += count.Count
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
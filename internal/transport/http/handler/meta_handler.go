@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	domainservice "github.com/bravo68web/stasis/internal/domain/service"
+	"github.com/gin-gonic/gin"
+)
+
+// MetaHandler handles requests for public information about the server
+type MetaHandler struct {
+	hostKeys *service.SSHHostKeyService
+	signer   domainservice.CommitSigner
+}
+
+// NewMetaHandler creates a new MetaHandler instance. signer is the instance
+// signing key, nil if the server does not sign the commits it creates.
+func NewMetaHandler(hostKeys *service.SSHHostKeyService, signer domainservice.CommitSigner) *MetaHandler {
+	return &MetaHandler{
+		hostKeys: hostKeys,
+		signer:   signer,
+	}
+}
+
+// GetMeta handles GET /api/v1/meta
+func (h *MetaHandler) GetMeta(c *gin.Context) {
+	sshInfo, ok := h.sshHostKeys(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MetaResponse{
+		SSH: sshInfo,
+	})
+}
+
+// GetSSHHostKeys handles GET /api/v1/ssh-host-keys
+func (h *MetaHandler) GetSSHHostKeys(c *gin.Context) {
+	sshInfo, ok := h.sshHostKeys(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, sshInfo)
+}
+
+// GetSigningKey handles GET /api/v1/meta/signing-key
+func (h *MetaHandler) GetSigningKey(c *gin.Context) {
+	if h.signer == nil {
+		c.JSON(http.StatusOK, dto.SigningKeyResponse{Enabled: false})
+		return
+	}
+
+	committer := h.signer.Committer()
+	c.JSON(http.StatusOK, dto.SigningKeyResponse{
+		Enabled:        true,
+		Format:         h.signer.Format(),
+		PublicKey:      h.signer.PublicKey(),
+		KeyID:          h.signer.KeyID(),
+		CommitterName:  committer.Name,
+		CommitterEmail: committer.Email,
+	})
+}
+
+// sshHostKeys builds the SSH host key response.
+// It writes the error response and returns false if the keys cannot be read.
+func (h *MetaHandler) sshHostKeys(c *gin.Context) (dto.SSHHostKeysResponse, bool) {
+	keys, err := h.hostKeys.HostKeys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to read SSH host keys",
+		})
+		return dto.SSHHostKeysResponse{}, false
+	}
+
+	resp := dto.SSHHostKeysResponse{
+		Enabled:  h.hostKeys.Enabled(),
+		Port:     h.hostKeys.Port(),
+		HostKeys: make([]dto.SSHHostKeyResponse, len(keys)),
+	}
+	for i, key := range keys {
+		resp.HostKeys[i] = dto.SSHHostKeyResponse{
+			Type:        key.Type,
+			PublicKey:   key.PublicKey,
+			Fingerprint: key.Fingerprint,
+		}
+	}
+	return resp, true
+}
@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// NotificationHandler handles notification HTTP requests of the current user
+type NotificationHandler struct {
+	notifications *service.NotificationService
+	emails        *service.EmailService
+	log           *logger.Logger
+}
+
+// NewNotificationHandler creates a new NotificationHandler instance
+func NewNotificationHandler(notifications *service.NotificationService, emails *service.EmailService) *NotificationHandler {
+	return &NotificationHandler{
+		notifications: notifications,
+		emails:        emails,
+		log:           logger.Get().WithFields(logger.Component("notification-handler")),
+	}
+}
+
+// ListNotifications handles GET /api/v1/notifications
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	unreadOnly := false
+	if value := c.Query("unread"); value != "" {
+		var err error
+		if unreadOnly, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "bad_request",
+				"message": "unread must be true or false",
+			})
+			return
+		}
+	}
+
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
+	}
+
+	notifications, total, err := h.notifications.ListNotifications(c.Request.Context(), user.ID, unreadOnly, page.PerPage, page.Offset)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	responses := make([]dto.NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		responses = append(responses, dto.NotificationFromModel(notification))
+	}
+
+	c.JSON(http.StatusOK, dto.NotificationListResponse{
+		Notifications: responses,
+		Pagination:    page.Counted(len(responses), total),
+	})
+}
+
+// UnreadCount handles GET /api/v1/notifications/unread_count
+func (h *NotificationHandler) UnreadCount(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	count, err := h.notifications.UnreadCount(c.Request.Context(), user.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NotificationCountResponse{Unread: count})
+}
+
+// MarkRead handles POST /api/v1/notifications/:id/read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid notification ID",
+		})
+		return
+	}
+
+	if err := h.notifications.MarkRead(c.Request.Context(), user.ID, id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// MarkAllRead handles POST /api/v1/notifications/read
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	marked, err := h.notifications.MarkAllRead(c.Request.Context(), user.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NotificationsMarkedResponse{Marked: marked})
+}
+
+// GetPreferences handles GET /api/v1/users/notification-preferences
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	preference, err := h.emails.Preferences(c.Request.Context(), user.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NotificationPreferencesFromModel(preference))
+}
+
+// UpdatePreferences handles PATCH /api/v1/users/notification-preferences
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	var req dto.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	preference, err := h.emails.UpdatePreferences(c.Request.Context(), user.ID, service.NotificationPreferenceUpdate{
+		EmailCIFailures:    req.EmailCIFailures,
+		EmailCollaborators: req.EmailCollaborators,
+		EmailTransfers:     req.EmailTransfers,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NotificationPreferencesFromModel(preference))
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *NotificationHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Notification not found",
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Notification request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
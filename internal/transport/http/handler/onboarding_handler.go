@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// OnboardingHandler handles the onboarding of the current user
+type OnboardingHandler struct {
+	onboarding *service.OnboardingService
+	baseURL    string
+	sshHost    string
+	sshPort    int
+	log        *logger.Logger
+}
+
+// NewOnboardingHandler creates a new OnboardingHandler instance
+func NewOnboardingHandler(
+	onboarding *service.OnboardingService,
+	baseURL string,
+	sshHost string,
+	sshPort int,
+) *OnboardingHandler {
+	return &OnboardingHandler{
+		onboarding: onboarding,
+		baseURL:    baseURL,
+		sshHost:    sshHost,
+		sshPort:    sshPort,
+		log:        logger.Get().WithFields(logger.Component("onboarding-handler")),
+	}
+}
+
+// GetOnboarding handles GET /api/v1/users/onboarding
+func (h *OnboardingHandler) GetOnboarding(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+
+	status, err := h.onboarding.Status(c.Request.Context(), user.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.toResponse(status))
+}
+
+// Onboard handles POST /api/v1/users/onboard
+func (h *OnboardingHandler) Onboard(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+
+	status, err := h.onboarding.Onboard(c.Request.Context(), user.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.toResponse(status))
+}
+
+// Skip handles POST /api/v1/users/onboard/skip
+func (h *OnboardingHandler) Skip(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+
+	status, err := h.onboarding.Skip(c.Request.Context(), user.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.toResponse(status))
+}
+
+// toResponse converts an onboarding state to its response DTO
+func (h *OnboardingHandler) toResponse(status *service.OnboardingStatus) dto.OnboardingResponse {
+	response := dto.OnboardingResponse{
+		Enabled:     status.Enabled,
+		Onboarded:   status.OnboardedAt != nil,
+		OnboardedAt: status.OnboardedAt,
+	}
+	if status.Repository != nil {
+		repo := dto.RepoFromModel(status.Repository, h.baseURL, h.sshHost, h.sshPort)
+		response.Repository = &repo
+	}
+	return response
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *OnboardingHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) && !h.onboarding.IsEnabled() {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Onboarding is not enabled",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsCommitSigning(err) {
+		h.log.WithContext(c.Request.Context()).Error("Failed to sign commit, check the signing configuration", logger.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "signing_failed",
+			"message": "The commit could not be signed: the instance signing key is misconfigured",
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Onboarding request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
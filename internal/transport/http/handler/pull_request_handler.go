@@ -0,0 +1,321 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// PullRequestHandler handles pull request HTTP requests
+type PullRequestHandler struct {
+	repoService  *service.RepoService
+	pullRequests *service.PullRequestService
+	log          *logger.Logger
+}
+
+// NewPullRequestHandler creates a new PullRequestHandler instance
+func NewPullRequestHandler(
+	repoService *service.RepoService,
+	pullRequests *service.PullRequestService,
+) *PullRequestHandler {
+	return &PullRequestHandler{
+		repoService:  repoService,
+		pullRequests: pullRequests,
+		log:          logger.Get().WithFields(logger.Component("pull-request-handler")),
+	}
+}
+
+// ListPullRequests handles GET /api/v1/repos/:owner/:repo/pulls
+func (h *PullRequestHandler) ListPullRequests(c *gin.Context) {
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return
+	}
+
+	state := c.Query("state")
+	if state != "" && state != models.PullRequestOpen && state != models.PullRequestClosed && state != models.PullRequestMerged {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "State must be open, closed or merged",
+		})
+		return
+	}
+
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
+	}
+
+	prs, total, err := h.pullRequests.ListPullRequests(c.Request.Context(), repo, state, page.PerPage, page.Offset)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	responses := make([]dto.PullRequestResponse, 0, len(prs))
+	for _, pr := range prs {
+		responses = append(responses, dto.PullRequestFromModel(pr))
+	}
+
+	c.JSON(http.StatusOK, dto.PullRequestListResponse{
+		PullRequests: responses,
+		Page:         page.Page,
+		PerPage:      page.PerPage,
+		Total:        total,
+		Pagination:   page.Counted(len(responses), total),
+	})
+}
+
+// GetPullRequest handles GET /api/v1/repos/:owner/:repo/pulls/:number
+func (h *PullRequestHandler) GetPullRequest(c *gin.Context) {
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return
+	}
+
+	pr, ok := h.getPullRequest(c, repo)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PullRequestFromModel(pr))
+}
+
+// CreatePullRequest handles POST /api/v1/repos/:owner/:repo/pulls
+func (h *PullRequestHandler) CreatePullRequest(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CreatePullRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	pr := req.ToModel()
+	if err := h.pullRequests.CreatePullRequest(c.Request.Context(), repo, user, pr); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.PullRequestFromModel(pr))
+}
+
+// ClosePullRequest handles POST /api/v1/repos/:owner/:repo/pulls/:number/close
+func (h *PullRequestHandler) ClosePullRequest(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return
+	}
+
+	pr, ok := h.getPullRequest(c, repo)
+	if !ok {
+		return
+	}
+
+	// Authors may close their own pull requests
+	isAuthor := pr.AuthorID != nil && *pr.AuthorID == user.ID
+	if !isAuthor && !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionWrite) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Only the author or users with write access can close this pull request",
+		})
+		return
+	}
+
+	if err := h.pullRequests.ClosePullRequest(c.Request.Context(), repo, pr, user); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PullRequestFromModel(pr))
+}
+
+// MergePullRequest handles POST /api/v1/repos/:owner/:repo/pulls/:number/merge
+func (h *PullRequestHandler) MergePullRequest(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	repo, ok := h.getReadableRepository(c)
+	if !ok {
+		return
+	}
+
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionWrite) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Write access is required to merge pull requests",
+		})
+		return
+	}
+
+	pr, ok := h.getPullRequest(c, repo)
+	if !ok {
+		return
+	}
+
+	if err := h.pullRequests.MergePullRequest(c.Request.Context(), repo, pr, user); err != nil {
+		if errors.Is(err, apperrors.ErrMergeConflict) {
+			resp := gin.H{
+				"error":   "merge_conflict",
+				"message": "The branches cannot be merged without resolving conflicts",
+			}
+			var appErr *apperrors.AppError
+			if errors.As(err, &appErr) {
+				resp["conflicts"] = appErr.Details["conflicts"]
+			}
+			c.JSON(http.StatusConflict, resp)
+			return
+		}
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PullRequestFromModel(pr))
+}
+
+// getReadableRepository loads the repository from the path and checks that the
+// user, if any, can read it. It writes the error response and returns false if
+// the request cannot proceed.
+func (h *PullRequestHandler) getReadableRepository(c *gin.Context) (*models.Repository, bool) {
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return nil, false
+	}
+
+	user := middleware.GetUserFromContext(c)
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return nil, false
+	}
+
+	return repo, true
+}
+
+// getPullRequest loads the pull request numbered in the path.
+// It writes the error response and returns false if there is none.
+func (h *PullRequestHandler) getPullRequest(c *gin.Context, repo *models.Repository) (*models.PullRequest, bool) {
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid pull request number",
+		})
+		return nil, false
+	}
+
+	pr, err := h.pullRequests.GetPullRequest(c.Request.Context(), repo, number)
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Pull request not found",
+			})
+			return nil, false
+		}
+		h.handleError(c, err)
+		return nil, false
+	}
+	return pr, true
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *PullRequestHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsForbidden(err) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsConflict(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "conflict",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsCommitSigning(err) {
+		h.log.WithContext(c.Request.Context()).Error("Failed to sign commit, check the signing configuration", logger.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "signing_failed",
+			"message": "The commit could not be signed: the instance signing key is misconfigured",
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Pull request request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
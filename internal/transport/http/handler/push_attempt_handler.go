@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// PushAttemptHandler handles push attempt HTTP requests
+type PushAttemptHandler struct {
+	repoService  *service.RepoService
+	pushAttempts *service.PushAttemptService
+	log          *logger.Logger
+}
+
+// NewPushAttemptHandler creates a new PushAttemptHandler instance
+func NewPushAttemptHandler(
+	repoService *service.RepoService,
+	pushAttempts *service.PushAttemptService,
+) *PushAttemptHandler {
+	return &PushAttemptHandler{
+		repoService:  repoService,
+		pushAttempts: pushAttempts,
+		log:          logger.Get().WithFields(logger.Component("push-attempt-handler")),
+	}
+}
+
+// ListPushAttempts handles GET /api/v1/repos/:owner/:repo/push-attempts
+func (h *PushAttemptHandler) ListPushAttempts(c *gin.Context) {
+	repo, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	outcome := c.Query("outcome")
+	if outcome != "" && outcome != models.PushOutcomeAccepted && outcome != models.PushOutcomeRejected {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Outcome must be accepted or rejected",
+		})
+		return
+	}
+
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
+	}
+
+	attempts, err := h.pushAttempts.ListPushAttempts(c.Request.Context(), repo.ID, outcome, page.Probe(), page.Offset)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	attempts, info := pagination.Trim(page, attempts)
+
+	responses := make([]dto.PushAttemptResponse, 0, len(attempts))
+	for _, attempt := range attempts {
+		responses = append(responses, dto.PushAttemptFromModel(attempt))
+	}
+
+	c.JSON(http.StatusOK, dto.ListPushAttemptsResponse{
+		PushAttempts: responses,
+		Page:         page.Page,
+		PerPage:      page.PerPage,
+		Total:        len(responses),
+		Pagination:   info,
+	})
+}
+
+// getAdministeredRepository loads the repository from the path and checks that
+// the authenticated user administers it (owner, site admin or admin collaborator).
+// It writes the error response and returns false if the request cannot proceed.
+func (h *PushAttemptHandler) getAdministeredRepository(c *gin.Context) (*models.Repository, bool) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return nil, false
+	}
+
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return nil, false
+	}
+
+	permission := h.repoService.RepositoryPermission(c.Request.Context(), user, repo)
+	if !permission.Allows(models.RepoPermissionAdmin) {
+		// Do not reveal private repositories to users who cannot read them
+		if !permission.Allows(models.RepoPermissionRead) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Repository not found",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Only repository administrators can view push attempts",
+		})
+		return nil, false
+	}
+
+	return repo, true
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *PushAttemptHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Push attempt request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// QuotaHandler handles repository size quota administration HTTP requests
+type QuotaHandler struct {
+	repoService *service.RepoService
+	quotas      *service.QuotaService
+	log         *logger.Logger
+}
+
+// NewQuotaHandler creates a new QuotaHandler instance
+func NewQuotaHandler(repoService *service.RepoService, quotas *service.QuotaService) *QuotaHandler {
+	return &QuotaHandler{
+		repoService: repoService,
+		quotas:      quotas,
+		log:         logger.Get().WithFields(logger.Component("quota-handler")),
+	}
+}
+
+// GetQuota handles GET /api/v1/admin/repos/:owner/:repo/quota
+func (h *QuotaHandler) GetQuota(c *gin.Context) {
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.quotaResponse(repo))
+}
+
+// UpdateQuota handles PATCH /api/v1/admin/repos/:owner/:repo/quota
+func (h *QuotaHandler) UpdateQuota(c *gin.Context) {
+	var req dto.UpdateRepoQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	repo, err = h.quotas.SetQuota(c.Request.Context(), repo.ID, req.QuotaBytes, middleware.GetUserFromContext(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.quotaResponse(repo))
+}
+
+// quotaResponse describes the quota of a repository
+func (h *QuotaHandler) quotaResponse(repo *models.Repository) dto.RepoQuotaResponse {
+	quota := h.quotas.Quota(repo)
+	return dto.RepoQuotaResponse{
+		ID:         repo.ID.String(),
+		FullName:   repo.GetFullName(),
+		SizeBytes:  quota.SizeBytes,
+		QuotaBytes: quota.QuotaBytes,
+		Override:   quota.Override,
+	}
+}
+
+// handleError handles errors and sends appropriate HTTP responses
+func (h *QuotaHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Quota request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
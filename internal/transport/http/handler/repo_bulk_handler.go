@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// RepoBulkHandler handles repository bulk operation HTTP requests
+type RepoBulkHandler struct {
+	bulk *service.RepoBulkService
+	log  *logger.Logger
+}
+
+// NewRepoBulkHandler creates a new RepoBulkHandler instance
+func NewRepoBulkHandler(bulk *service.RepoBulkService) *RepoBulkHandler {
+	return &RepoBulkHandler{
+		bulk: bulk,
+		log:  logger.Get().WithFields(logger.Component("repo-bulk-handler")),
+	}
+}
+
+// SubmitBulk handles POST /api/v1/admin/repos/bulk
+func (h *RepoBulkHandler) SubmitBulk(c *gin.Context) {
+	var req dto.RepoBulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	op := service.BulkOperation{
+		Operation:    req.Operation,
+		IsPrivate:    req.IsPrivate,
+		NewOwner:     req.NewOwner,
+		Repositories: req.Repositories,
+	}
+	if req.Filter != nil {
+		op.Filter = &service.BulkFilter{
+			Owner:           req.Filter.Owner,
+			AnnotationKey:   req.Filter.AnnotationKey,
+			AnnotationValue: req.Filter.AnnotationValue,
+			License:         req.Filter.License,
+		}
+	}
+
+	task, err := h.bulk.Plan(c.Request.Context(), op)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if req.DryRun {
+		preview := dto.RepoBulkPreviewResponse{
+			Operation:    task.Operation,
+			Repositories: make([]string, 0, len(task.Targets)),
+			NotFound:     make([]string, 0, len(task.Results)),
+		}
+		for _, target := range task.Targets {
+			preview.Repositories = append(preview.Repositories, target.FullName)
+		}
+		for _, result := range task.Results {
+			preview.NotFound = append(preview.NotFound, result.FullName)
+		}
+		c.JSON(http.StatusOK, preview)
+		return
+	}
+
+	if err := h.bulk.Submit(c.Request.Context(), task, middleware.GetUserFromContext(c), c.ClientIP()); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.RepoBulkTaskFromModel(task))
+}
+
+// GetTask handles GET /api/v1/admin/tasks/:id
+func (h *RepoBulkHandler) GetTask(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid task ID",
+		})
+		return
+	}
+
+	task, err := h.bulk.GetTask(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.RepoBulkTaskFromModel(task))
+}
+
+// handleError handles errors and sends appropriate HTTP responses
+func (h *RepoBulkHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Task not found",
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Bulk operation request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
@@ -1,13 +1,24 @@
 package handler
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bravo68web/stasis/internal/application/dto"
 	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/repository"
+	"github.com/bravo68web/stasis/internal/transport/http/apiversion"
 	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
 	apperrors "github.com/bravo68web/stasis/pkg/errors"
 	"github.com/bravo68web/stasis/pkg/logger"
 	"github.com/gin-gonic/gin"
@@ -18,6 +29,16 @@ import (
 type RepoHandler struct {
 	repoService       *service.RepoService
 	mirrorSyncService *service.MirrorSyncService
+	annotationService *service.AnnotationService
+	tagProtection     *service.TagProtectionService
+	highlight         *service.HighlightService
+	authorMappings    *service.AuthorMappingService
+	signatures        *service.CommitSignatureService
+	licenses          *service.LicenseService
+	topics            *service.TopicService
+	auditEvents       *service.AuditEventService
+	largeFiles        *service.LargeFileService
+	languages         *service.LanguageService
 	baseURL           string
 	sshHost           string
 	sshPort           int
@@ -28,6 +49,16 @@ type RepoHandler struct {
 func NewRepoHandler(
 	repoService *service.RepoService,
 	mirrorSyncService *service.MirrorSyncService,
+	annotationService *service.AnnotationService,
+	tagProtection *service.TagProtectionService,
+	highlight *service.HighlightService,
+	authorMappings *service.AuthorMappingService,
+	signatures *service.CommitSignatureService,
+	licenses *service.LicenseService,
+	topics *service.TopicService,
+	auditEvents *service.AuditEventService,
+	largeFiles *service.LargeFileService,
+	languages *service.LanguageService,
 	baseURL string,
 	sshHost string,
 	sshPort int,
@@ -35,6 +66,16 @@ func NewRepoHandler(
 	return &RepoHandler{
 		repoService:       repoService,
 		mirrorSyncService: mirrorSyncService,
+		annotationService: annotationService,
+		tagProtection:     tagProtection,
+		highlight:         highlight,
+		authorMappings:    authorMappings,
+		signatures:        signatures,
+		licenses:          licenses,
+		topics:            topics,
+		auditEvents:       auditEvents,
+		largeFiles:        largeFiles,
+		languages:         languages,
 		baseURL:           baseURL,
 		sshHost:           sshHost,
 		sshPort:           sshPort,
@@ -46,7 +87,7 @@ func NewRepoHandler(
 func (h *RepoHandler) CreateRepository(c *gin.Context) {
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
-		h.log.Warn("Create repository attempted without authentication",
+		h.log.WithContext(c.Request.Context()).Warn("Create repository attempted without authentication",
 			logger.ClientIP(c.ClientIP()),
 		)
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -56,14 +97,22 @@ func (h *RepoHandler) CreateRepository(c *gin.Context) {
 		return
 	}
 
-	h.log.Debug("Create repository request received",
+	if !user.TokenAllows(models.RepoPermissionWrite) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "The token does not have the repo:write scope",
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Debug("Create repository request received",
 		logger.String("user_id", user.ID.String()),
 		logger.String("username", user.Username),
 	)
 
 	var req dto.CreateRepoRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Warn("Invalid create repository request body",
+		h.log.WithContext(c.Request.Context()).Warn("Invalid create repository request body",
 			logger.Error(err),
 			logger.String("user_id", user.ID.String()),
 		)
@@ -77,7 +126,7 @@ func (h *RepoHandler) CreateRepository(c *gin.Context) {
 
 	// Validate request
 	if err := req.Validate(); err != nil {
-		h.log.Warn("Repository validation failed",
+		h.log.WithContext(c.Request.Context()).Warn("Repository validation failed",
 			logger.Error(err),
 			logger.String("name", req.Name),
 		)
@@ -88,7 +137,7 @@ func (h *RepoHandler) CreateRepository(c *gin.Context) {
 		return
 	}
 
-	h.log.Info("Creating repository",
+	h.log.WithContext(c.Request.Context()).Info("Creating repository",
 		logger.String("name", req.Name),
 		logger.String("owner", user.Username),
 		logger.Bool("is_private", req.IsPrivate),
@@ -103,7 +152,7 @@ func (h *RepoHandler) CreateRepository(c *gin.Context) {
 		req.IsPrivate,
 	)
 	if err != nil {
-		h.log.Error("Failed to create repository",
+		h.log.WithContext(c.Request.Context()).Error("Failed to create repository",
 			logger.Error(err),
 			logger.String("name", req.Name),
 			logger.String("owner", user.Username),
@@ -112,11 +161,14 @@ func (h *RepoHandler) CreateRepository(c *gin.Context) {
 		return
 	}
 
-	h.log.Info("Repository created successfully",
+	h.log.WithContext(c.Request.Context()).Info("Repository created successfully",
 		logger.String("repo_id", repo.ID.String()),
 		logger.String("name", repo.Name),
 		logger.String("owner", user.Username),
 	)
+	recordAuditEvent(c, h.auditEvents, models.AuditActionRepoCreate, repo, map[string]any{
+		"is_private": repo.IsPrivate,
+	})
 
 	// Build response
 	response := dto.RepoFromModel(repo, h.baseURL, h.sshHost, h.sshPort)
@@ -124,11 +176,11 @@ func (h *RepoHandler) CreateRepository(c *gin.Context) {
 	c.JSON(http.StatusCreated, response)
 }
 
-// ImportRepository imports a repository from an external Git source
-func (h *RepoHandler) ImportRepository(c *gin.Context) {
+// ListRepositories lists all repositories for the authenticated user
+func (h *RepoHandler) ListRepositories(c *gin.Context) {
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
-		h.log.Warn("Import repository attempted without authentication",
+		h.log.WithContext(c.Request.Context()).Warn("List repositories attempted without authentication",
 			logger.ClientIP(c.ClientIP()),
 		)
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -138,189 +190,250 @@ func (h *RepoHandler) ImportRepository(c *gin.Context) {
 		return
 	}
 
-	h.log.Debug("Import repository request received",
+	h.log.WithContext(c.Request.Context()).Debug("Listing repositories for user",
 		logger.String("user_id", user.ID.String()),
 		logger.String("username", user.Username),
 	)
 
-	var req dto.ImportRepoRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Warn("Invalid import repository request body",
-			logger.Error(err),
-			logger.String("user_id", user.ID.String()),
-		)
+	filter, filtered, err := h.listFilter(c)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "bad_request",
-			"message": "Invalid request body",
-			"details": err.Error(),
+			"message": err.Error(),
 		})
 		return
 	}
 
-	// Validate request
-	if err := req.Validate(); err != nil {
-		h.log.Warn("Repository import validation failed",
+	var repos []*models.Repository
+	if filtered {
+		repos, err = h.repoService.ListUserRepositoriesFiltered(c.Request.Context(), user.ID, filter)
+	} else {
+		repos, err = h.repoService.ListUserRepositories(c.Request.Context(), user.ID)
+	}
+	if err != nil {
+		h.log.WithContext(c.Request.Context()).Error("Failed to list user repositories",
 			logger.Error(err),
-			logger.String("name", req.Name),
-			logger.String("clone_url", req.CloneURL),
+			logger.String("user_id", user.ID.String()),
 		)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "validation_error",
-			"message": err.Error(),
-		})
+		h.handleError(c, err)
 		return
 	}
 
-	h.log.Info("Importing repository",
-		logger.String("name", req.Name),
-		logger.String("owner", user.Username),
-		logger.String("clone_url", req.CloneURL),
-		logger.Bool("is_private", req.IsPrivate),
-		logger.Bool("mirror", req.Mirror),
+	h.log.WithContext(c.Request.Context()).Debug("Found repositories",
+		logger.String("username", user.Username),
+		logger.Int("count", len(repos)),
 	)
 
-	// Import repository
-	repo, err := h.repoService.ImportRepository(
-		c.Request.Context(),
-		user.ID,
-		req.Name,
-		req.Description,
-		req.CloneURL,
-		req.Username,
-		req.Password,
-		req.IsPrivate,
-		req.Mirror,
+	// Convert to response DTOs
+	responses := h.reposToResponses(c, repos)
+
+	c.JSON(http.StatusOK, gin.H{
+		"repositories": responses,
+		"total":        len(responses),
+	})
+}
+
+// ListPublicRepositories handles GET /api/repos/public
+func (h *RepoHandler) ListPublicRepositories(c *gin.Context) {
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Debug("Listing public repositories",
+		logger.Int("page", page.Page),
+		logger.Int("per_page", page.PerPage),
 	)
+
+	filter, filtered, err := h.listFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	var repos []*models.Repository
+	if filtered {
+		repos, err = h.repoService.ListPublicRepositoriesFiltered(c.Request.Context(), filter, page.Probe(), page.Offset)
+	} else {
+		repos, err = h.repoService.ListPublicRepositories(c.Request.Context(), page.Probe(), page.Offset)
+	}
 	if err != nil {
-		h.log.Error("Failed to import repository",
+		h.log.WithContext(c.Request.Context()).Error("Failed to list public repositories",
 			logger.Error(err),
-			logger.String("name", req.Name),
-			logger.String("owner", user.Username),
-			logger.String("clone_url", req.CloneURL),
 		)
 		h.handleError(c, err)
 		return
 	}
 
-	h.log.Info("Repository imported successfully",
-		logger.String("repo_id", repo.ID.String()),
-		logger.String("name", repo.Name),
-		logger.String("owner", user.Username),
-		logger.String("clone_url", req.CloneURL),
-		logger.Bool("mirror", req.Mirror),
-	)
+	repos, info := pagination.Trim(page, repos)
 
-	// Build response
-	response := dto.RepoFromModel(repo, h.baseURL, h.sshHost, h.sshPort)
+	h.log.WithContext(c.Request.Context()).Debug("Found public repositories",
+		logger.Int("count", len(repos)),
+	)
 
-	c.JSON(http.StatusCreated, response)
+	// Convert to response DTOs
+	responses := h.reposToResponses(c, repos)
+
+	c.JSON(http.StatusOK, dto.RepoPageResponse{
+		Repositories: responses,
+		Page:         page.Page,
+		PerPage:      page.PerPage,
+		Total:        len(responses),
+		Pagination:   info,
+	})
 }
 
-// ListRepositories lists all repositories for the authenticated user
-func (h *RepoHandler) ListRepositories(c *gin.Context) {
+// ListForks handles GET /api/repos/:owner/:repo/forks
+func (h *RepoHandler) ListForks(c *gin.Context) {
+	owner := c.Param("owner")
+	repoName := c.Param("repo")
+
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
+	}
+
+	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
 	user := middleware.GetUserFromContext(c)
-	if user == nil {
-		h.log.Warn("List repositories attempted without authentication",
-			logger.ClientIP(c.ClientIP()),
-		)
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":   "unauthorized",
-			"message": "Authentication required",
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
 		})
 		return
 	}
 
-	h.log.Debug("Listing repositories for user",
-		logger.String("user_id", user.ID.String()),
-		logger.String("username", user.Username),
-	)
+	var viewerID *uuid.UUID
+	if user != nil {
+		viewerID = &user.ID
+	}
 
-	repos, err := h.repoService.ListUserRepositories(c.Request.Context(), user.ID)
+	repos, err := h.repoService.ListForks(c.Request.Context(), repo.ID, viewerID, page.Probe(), page.Offset)
 	if err != nil {
-		h.log.Error("Failed to list user repositories",
+		h.log.WithContext(c.Request.Context()).Error("Failed to list forks",
 			logger.Error(err),
-			logger.String("user_id", user.ID.String()),
+			logger.String("repo_id", repo.ID.String()),
 		)
 		h.handleError(c, err)
 		return
 	}
 
-	h.log.Debug("Found repositories",
-		logger.String("username", user.Username),
-		logger.Int("count", len(repos)),
-	)
+	repos, info := pagination.Trim(page, repos)
+	responses := h.reposToResponses(c, repos)
 
-	// Convert to response DTOs
-	responses := make([]dto.RepoResponse, len(repos))
-	for i, repo := range repos {
-		responses[i] = dto.RepoFromModel(repo, h.baseURL, h.sshHost, h.sshPort)
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"repositories": responses,
-		"total":        len(responses),
+	c.JSON(http.StatusOK, dto.RepoPageResponse{
+		Repositories: responses,
+		Page:         page.Page,
+		PerPage:      page.PerPage,
+		Total:        len(responses),
+		Pagination:   info,
 	})
 }
 
-// ListPublicRepositories handles GET /api/repos/public
-func (h *RepoHandler) ListPublicRepositories(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
-
-	if page < 1 {
-		page = 1
+// SearchRepositories handles GET /api/repos/search
+func (h *RepoHandler) SearchRepositories(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Search query is required",
+		})
+		return
 	}
-	if perPage < 1 || perPage > 100 {
-		perPage = 20
+
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
 	}
 
-	offset := (page - 1) * perPage
+	filter, _, err := h.listFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
 
-	h.log.Debug("Listing public repositories",
-		logger.Int("page", page),
-		logger.Int("per_page", perPage),
+	h.log.WithContext(c.Request.Context()).Debug("Searching repositories",
+		logger.String("query", query),
+		logger.Int("page", page.Page),
+		logger.Int("per_page", page.PerPage),
 	)
 
-	repos, err := h.repoService.ListPublicRepositories(c.Request.Context(), perPage, offset)
+	repos, err := h.repoService.SearchRepositories(c.Request.Context(), query, filter, middleware.GetUserFromContext(c), page.Probe(), page.Offset)
 	if err != nil {
-		h.log.Error("Failed to list public repositories",
+		h.log.WithContext(c.Request.Context()).Error("Failed to search repositories",
 			logger.Error(err),
+			logger.String("query", query),
 		)
 		h.handleError(c, err)
 		return
 	}
 
-	h.log.Debug("Found public repositories",
-		logger.Int("count", len(repos)),
-	)
-
-	// Convert to response DTOs
-	responses := make([]dto.RepoResponse, len(repos))
-	for i, repo := range repos {
-		responses[i] = dto.RepoFromModel(repo, h.baseURL, h.sshHost, h.sshPort)
-	}
+	repos, info := pagination.Trim(page, repos)
+	responses := h.reposToResponses(c, repos)
 
-	c.JSON(http.StatusOK, gin.H{
-		"repositories": responses,
-		"page":         page,
-		"per_page":     perPage,
-		"total":        len(responses),
+	c.JSON(http.StatusOK, dto.RepoPageResponse{
+		Repositories: responses,
+		Query:        query,
+		Page:         page.Page,
+		PerPage:      page.PerPage,
+		Total:        len(responses),
+		Pagination:   info,
 	})
 }
 
+// listFilter builds the filter of a repository listing from its annotation
+// (key:value), license (SPDX identifier or "none") and topic query
+// parameters. It returns false if none is set.
+func (h *RepoHandler) listFilter(c *gin.Context) (repository.RepoFilter, bool, error) {
+	var filter repository.RepoFilter
+	if value := c.Query("annotation"); value != "" {
+		key, annotationValue, err := h.annotationService.ParseFilter(value)
+		if err != nil {
+			return filter, false, err
+		}
+		filter.AnnotationKey, filter.AnnotationValue = key, annotationValue
+	}
+	if value := c.Query("license"); value != "" {
+		id, err := h.licenses.ParseFilter(value)
+		if err != nil {
+			return filter, false, err
+		}
+		filter.License = &id
+	}
+	if value := c.Query("topic"); value != "" {
+		topic, err := h.topics.ParseFilter(value)
+		if err != nil {
+			return filter, false, err
+		}
+		filter.Topic = topic
+	}
+	return filter, filter.AnnotationKey != "" || filter.License != nil || filter.Topic != "", nil
+}
+
 // GetRepository handles GET /api/repos/:owner/:repo
 func (h *RepoHandler) GetRepository(c *gin.Context) {
 	owner := c.Param("owner")
 	repoName := c.Param("repo")
 
-	h.log.Debug("Getting repository",
+	h.log.WithContext(c.Request.Context()).Debug("Getting repository",
 		logger.String("owner", owner),
 		logger.String("repo", repoName),
 	)
 
 	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
 	if err != nil {
-		h.log.Debug("Repository not found",
+		h.log.WithContext(c.Request.Context()).Debug("Repository not found",
 			logger.String("owner", owner),
 			logger.String("repo", repoName),
 			logger.Error(err),
@@ -333,7 +446,7 @@ func (h *RepoHandler) GetRepository(c *gin.Context) {
 	user := middleware.GetUserFromContext(c)
 	if repo.IsPrivate {
 		if user == nil {
-			h.log.Debug("Anonymous user attempted to access private repository",
+			h.log.WithContext(c.Request.Context()).Debug("Anonymous user attempted to access private repository",
 				logger.String("owner", owner),
 				logger.String("repo", repoName),
 			)
@@ -343,8 +456,8 @@ func (h *RepoHandler) GetRepository(c *gin.Context) {
 			})
 			return
 		}
-		if user.ID != repo.OwnerID && !user.IsAdmin {
-			h.log.Debug("User attempted to access private repository without permission",
+		if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
+			h.log.WithContext(c.Request.Context()).Debug("User attempted to access private repository without permission",
 				logger.String("user_id", user.ID.String()),
 				logger.String("owner", owner),
 				logger.String("repo", repoName),
@@ -357,13 +470,27 @@ func (h *RepoHandler) GetRepository(c *gin.Context) {
 		}
 	}
 
-	h.log.Debug("Repository retrieved successfully",
+	h.log.WithContext(c.Request.Context()).Debug("Repository retrieved successfully",
 		logger.String("repo_id", repo.ID.String()),
 		logger.String("owner", owner),
 		logger.String("repo", repoName),
 	)
 
-	response := dto.RepoFromModel(repo, h.baseURL, h.sshHost, h.sshPort)
+	// A private parent is not disclosed to those who cannot read it
+	if repo.ForkedFrom != nil && repo.ForkedFrom.IsPrivate &&
+		!h.repoService.HasPermission(c.Request.Context(), user, repo.ForkedFrom, models.RepoPermissionRead) {
+		repo.ForkedFrom = nil
+	}
+
+	response := h.reposToResponses(c, []*models.Repository{repo})[0]
+	if empty, err := h.repoService.IsEmpty(c.Request.Context(), repo); err == nil {
+		response.IsEmpty = &empty
+	} else {
+		h.log.WithContext(c.Request.Context()).Warn("Failed to check whether repository is empty",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+	}
 	c.JSON(http.StatusOK, response)
 }
 
@@ -374,7 +501,7 @@ func (h *RepoHandler) UpdateRepository(c *gin.Context) {
 
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
-		h.log.Warn("Update repository attempted without authentication",
+		h.log.WithContext(c.Request.Context()).Warn("Update repository attempted without authentication",
 			logger.String("owner", owner),
 			logger.String("repo", repoName),
 		)
@@ -385,7 +512,7 @@ func (h *RepoHandler) UpdateRepository(c *gin.Context) {
 		return
 	}
 
-	h.log.Debug("Update repository request",
+	h.log.WithContext(c.Request.Context()).Debug("Update repository request",
 		logger.String("user_id", user.ID.String()),
 		logger.String("owner", owner),
 		logger.String("repo", repoName),
@@ -393,7 +520,7 @@ func (h *RepoHandler) UpdateRepository(c *gin.Context) {
 
 	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
 	if err != nil {
-		h.log.Debug("Repository not found for update",
+		h.log.WithContext(c.Request.Context()).Debug("Repository not found for update",
 			logger.String("owner", owner),
 			logger.String("repo", repoName),
 			logger.Error(err),
@@ -402,9 +529,9 @@ func (h *RepoHandler) UpdateRepository(c *gin.Context) {
 		return
 	}
 
-	// Check ownership
-	if user.ID != repo.OwnerID && !user.IsAdmin {
-		h.log.Warn("User attempted to update repository without permission",
+	// Check admin access
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionAdmin) {
+		h.log.WithContext(c.Request.Context()).Warn("User attempted to update repository without permission",
 			logger.String("user_id", user.ID.String()),
 			logger.String("owner", owner),
 			logger.String("repo", repoName),
@@ -418,7 +545,7 @@ func (h *RepoHandler) UpdateRepository(c *gin.Context) {
 
 	var req dto.UpdateRepoRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Warn("Invalid update repository request body",
+		h.log.WithContext(c.Request.Context()).Warn("Invalid update repository request body",
 			logger.Error(err),
 		)
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -427,22 +554,60 @@ func (h *RepoHandler) UpdateRepository(c *gin.Context) {
 		})
 		return
 	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
 
-	h.log.Info("Updating repository",
+	var licenseOverride string
+	if req.License != nil {
+		licenseOverride, err = h.licenses.ParseOverride(*req.License)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "bad_request",
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	h.log.WithContext(c.Request.Context()).Info("Updating repository",
 		logger.String("repo_id", repo.ID.String()),
 		logger.String("owner", owner),
 		logger.String("repo", repoName),
 	)
 
+	// Rename first: a name already taken fails the request before anything changes
+	if req.Name != nil && *req.Name != repo.Name {
+		renamed, err := h.repoService.RenameRepository(c.Request.Context(), repo.ID, *req.Name)
+		if err != nil {
+			h.log.WithContext(c.Request.Context()).Error("Failed to rename repository",
+				logger.Error(err),
+				logger.String("repo_id", repo.ID.String()),
+				logger.String("new_name", *req.Name),
+			)
+			h.handleError(c, err)
+			return
+		}
+		recordAuditEvent(c, h.auditEvents, models.AuditActionRepoRename, renamed, map[string]any{
+			"old_name": repoName,
+			"new_name": renamed.Name,
+		})
+	}
+
 	updatedRepo, err := h.repoService.UpdateRepository(
 		c.Request.Context(),
 		repo.ID,
 		req.Description,
 		req.IsPrivate,
 		req.DefaultBranch,
+		req.LargeFileHintsDisabled,
 	)
 	if err != nil {
-		h.log.Error("Failed to update repository",
+		h.log.WithContext(c.Request.Context()).Error("Failed to update repository",
 			logger.Error(err),
 			logger.String("repo_id", repo.ID.String()),
 		)
@@ -450,7 +615,18 @@ func (h *RepoHandler) UpdateRepository(c *gin.Context) {
 		return
 	}
 
-	h.log.Info("Repository updated successfully",
+	if req.License != nil {
+		if err := h.licenses.SetOverride(c.Request.Context(), updatedRepo, licenseOverride); err != nil {
+			h.handleError(c, err)
+			return
+		}
+	}
+	if req.DefaultBranch != nil {
+		// The license file may differ on the new default branch
+		h.licenses.DetectLicenseAsync(updatedRepo)
+	}
+
+	h.log.WithContext(c.Request.Context()).Info("Repository updated successfully",
 		logger.String("repo_id", repo.ID.String()),
 		logger.String("owner", owner),
 		logger.String("repo", repoName),
@@ -467,7 +643,7 @@ func (h *RepoHandler) DeleteRepository(c *gin.Context) {
 
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
-		h.log.Warn("Delete repository attempted without authentication",
+		h.log.WithContext(c.Request.Context()).Warn("Delete repository attempted without authentication",
 			logger.String("owner", owner),
 			logger.String("repo", repoName),
 		)
@@ -478,7 +654,7 @@ func (h *RepoHandler) DeleteRepository(c *gin.Context) {
 		return
 	}
 
-	h.log.Debug("Delete repository request",
+	h.log.WithContext(c.Request.Context()).Debug("Delete repository request",
 		logger.String("user_id", user.ID.String()),
 		logger.String("owner", owner),
 		logger.String("repo", repoName),
@@ -486,54 +662,198 @@ func (h *RepoHandler) DeleteRepository(c *gin.Context) {
 
 	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
 	if err != nil {
-		h.log.Debug("Repository not found for deletion",
+		h.log.WithContext(c.Request.Context()).Debug("Repository not found for deletion",
+			logger.String("owner", owner),
+			logger.String("repo", repoName),
+			logger.Error(err),
+		)
+		h.handleError(c, err)
+		return
+	}
+
+	// Check ownership; admin collaborators cannot delete the repository
+	if (user.ID != repo.OwnerID && !user.IsAdmin) || !user.TokenAllows(models.RepoPermissionAdmin) {
+		h.log.WithContext(c.Request.Context()).Warn("User attempted to delete repository without permission",
+			logger.String("user_id", user.ID.String()),
+			logger.String("owner", owner),
+			logger.String("repo", repoName),
+		)
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "You don't have permission to delete this repository",
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Info("Deleting repository",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("owner", owner),
+		logger.String("repo", repoName),
+		logger.String("deleted_by", user.Username),
+	)
+
+	if err := h.repoService.DeleteRepository(c.Request.Context(), repo.ID); err != nil {
+		h.log.WithContext(c.Request.Context()).Error("Failed to delete repository",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+		)
+		h.handleError(c, err)
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Info("Repository deleted successfully",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("owner", owner),
+		logger.String("repo", repoName),
+	)
+	recordAuditEvent(c, h.auditEvents, models.AuditActionRepoDelete, repo, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Repository deleted successfully",
+	})
+}
+
+// ForkRepository handles POST /api/repos/:owner/:repo/fork
+func (h *RepoHandler) ForkRepository(c *gin.Context) {
+	owner := c.Param("owner")
+	repoName := c.Param("repo")
+
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		h.log.WithContext(c.Request.Context()).Warn("Fork repository attempted without authentication",
 			logger.String("owner", owner),
 			logger.String("repo", repoName),
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	if !user.TokenAllows(models.RepoPermissionWrite) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "The token does not have the repo:write scope",
+		})
+		return
+	}
+
+	// The body is optional
+	var req dto.ForkRepoRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	source, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// Do not reveal repositories the user cannot read
+	if !h.repoService.HasPermission(c.Request.Context(), user, source, models.RepoPermissionRead) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	fork, err := h.repoService.ForkRepository(c.Request.Context(), source.ID, user.ID, req.Name)
+	if err != nil {
+		h.log.WithContext(c.Request.Context()).Error("Failed to fork repository",
 			logger.Error(err),
+			logger.String("source_repo_id", source.ID.String()),
+			logger.String("username", user.Username),
+		)
+		h.handleError(c, err)
+		return
+	}
+
+	recordAuditEvent(c, h.auditEvents, models.AuditActionRepoFork, fork, map[string]any{
+		"source": source.GetFullName(),
+	})
+
+	c.JSON(http.StatusCreated, dto.RepoFromModel(fork, h.baseURL, h.sshHost, h.sshPort))
+}
+
+// TransferRepository handles POST /api/repos/:owner/:repo/transfer
+func (h *RepoHandler) TransferRepository(c *gin.Context) {
+	owner := c.Param("owner")
+	repoName := c.Param("repo")
+
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		h.log.WithContext(c.Request.Context()).Warn("Transfer repository attempted without authentication",
+			logger.String("owner", owner),
+			logger.String("repo", repoName),
 		)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	var req dto.TransferRepoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
+	if err != nil {
 		h.handleError(c, err)
 		return
 	}
 
-	// Check ownership
-	if user.ID != repo.OwnerID && !user.IsAdmin {
-		h.log.Warn("User attempted to delete repository without permission",
+	// Check ownership; admin collaborators cannot transfer the repository
+	if (user.ID != repo.OwnerID && !user.IsAdmin) || !user.TokenAllows(models.RepoPermissionAdmin) {
+		h.log.WithContext(c.Request.Context()).Warn("User attempted to transfer repository without permission",
 			logger.String("user_id", user.ID.String()),
 			logger.String("owner", owner),
 			logger.String("repo", repoName),
 		)
 		c.JSON(http.StatusForbidden, gin.H{
 			"error":   "forbidden",
-			"message": "You don't have permission to delete this repository",
+			"message": "You don't have permission to transfer this repository",
 		})
 		return
 	}
 
-	h.log.Info("Deleting repository",
-		logger.String("repo_id", repo.ID.String()),
-		logger.String("owner", owner),
-		logger.String("repo", repoName),
-		logger.String("deleted_by", user.Username),
-	)
-
-	if err := h.repoService.DeleteRepository(c.Request.Context(), repo.ID); err != nil {
-		h.log.Error("Failed to delete repository",
+	transferred, err := h.repoService.TransferRepositoryToUser(c.Request.Context(), repo.ID, req.NewOwner)
+	if err != nil {
+		h.log.WithContext(c.Request.Context()).Error("Failed to transfer repository",
 			logger.Error(err),
 			logger.String("repo_id", repo.ID.String()),
+			logger.String("new_owner", req.NewOwner),
 		)
 		h.handleError(c, err)
 		return
 	}
 
-	h.log.Info("Repository deleted successfully",
-		logger.String("repo_id", repo.ID.String()),
-		logger.String("owner", owner),
-		logger.String("repo", repoName),
-	)
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Repository deleted successfully",
+	recordAuditEvent(c, h.auditEvents, models.AuditActionRepoTransfer, transferred, map[string]any{
+		"new_owner": transferred.Owner.Username,
 	})
+
+	c.JSON(http.StatusOK, dto.RepoFromModel(transferred, h.baseURL, h.sshHost, h.sshPort))
 }
 
 // ListBranches handles GET /api/repos/:owner/:repo/branches
@@ -549,7 +869,7 @@ func (h *RepoHandler) ListBranches(c *gin.Context) {
 
 	// Check access
 	user := middleware.GetUserFromContext(c)
-	if repo.IsPrivate && (user == nil || (user.ID != repo.OwnerID && !user.IsAdmin)) {
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
 			"message": "Repository not found",
@@ -599,7 +919,7 @@ func (h *RepoHandler) CreateBranch(c *gin.Context) {
 	}
 
 	// Check write access
-	if user.ID != repo.OwnerID && !user.IsAdmin {
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionWrite) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error":   "forbidden",
 			"message": "You don't have permission to create branches",
@@ -617,10 +937,22 @@ func (h *RepoHandler) CreateBranch(c *gin.Context) {
 	}
 
 	if err := h.repoService.CreateBranch(c.Request.Context(), repo, req.Name, req.CommitHash); err != nil {
+		if errors.Is(err, apperrors.ErrRefLimitExceeded) {
+			h.refLimitExceeded(c, err, "branches", gin.H{
+				"error":   "branch_limit_exceeded",
+				"message": "This repository has reached its branch limit",
+			})
+			return
+		}
 		h.handleError(c, err)
 		return
 	}
 
+	recordAuditEvent(c, h.auditEvents, models.AuditActionBranchCreate, repo, map[string]any{
+		"branch": req.Name,
+		"commit": req.CommitHash,
+	})
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Branch created successfully",
 		"branch":  req.Name,
@@ -649,7 +981,7 @@ func (h *RepoHandler) DeleteBranch(c *gin.Context) {
 	}
 
 	// Check write access
-	if user.ID != repo.OwnerID && !user.IsAdmin {
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionWrite) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error":   "forbidden",
 			"message": "You don't have permission to delete branches",
@@ -661,6 +993,9 @@ func (h *RepoHandler) DeleteBranch(c *gin.Context) {
 		h.handleError(c, err)
 		return
 	}
+	recordAuditEvent(c, h.auditEvents, models.AuditActionBranchDelete, repo, map[string]any{
+		"branch": branchName,
+	})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Branch deleted successfully",
@@ -680,7 +1015,7 @@ func (h *RepoHandler) ListTags(c *gin.Context) {
 
 	// Check access
 	user := middleware.GetUserFromContext(c)
-	if repo.IsPrivate && (user == nil || (user.ID != repo.OwnerID && !user.IsAdmin)) {
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
 			"message": "Repository not found",
@@ -701,6 +1036,7 @@ func (h *RepoHandler) ListTags(c *gin.Context) {
 			Hash:        tag.Hash,
 			Message:     tag.Message,
 			Tagger:      tag.Tagger,
+			Date:        tag.Date,
 			IsAnnotated: !tag.IsLight,
 		}
 	}
@@ -732,7 +1068,7 @@ func (h *RepoHandler) CreateTag(c *gin.Context) {
 	}
 
 	// Check write access
-	if user.ID != repo.OwnerID && !user.IsAdmin {
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionWrite) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error":   "forbidden",
 			"message": "You don't have permission to create tags",
@@ -750,10 +1086,22 @@ func (h *RepoHandler) CreateTag(c *gin.Context) {
 	}
 
 	if err := h.repoService.CreateTag(c.Request.Context(), repo, req.Name, req.CommitHash, req.Message); err != nil {
+		if errors.Is(err, apperrors.ErrRefLimitExceeded) {
+			h.refLimitExceeded(c, err, "tags", gin.H{
+				"error":   "tag_limit_exceeded",
+				"message": "This repository has reached its tag limit",
+			})
+			return
+		}
 		h.handleError(c, err)
 		return
 	}
 
+	recordAuditEvent(c, h.auditEvents, models.AuditActionTagCreate, repo, map[string]any{
+		"tag":    req.Name,
+		"commit": req.CommitHash,
+	})
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Tag created successfully",
 		"tag":     req.Name,
@@ -782,7 +1130,7 @@ func (h *RepoHandler) DeleteTag(c *gin.Context) {
 	}
 
 	// Check write access
-	if user.ID != repo.OwnerID && !user.IsAdmin {
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionWrite) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error":   "forbidden",
 			"message": "You don't have permission to delete tags",
@@ -790,16 +1138,42 @@ func (h *RepoHandler) DeleteTag(c *gin.Context) {
 		return
 	}
 
+	// Protected tags can only be deleted by users on the override list
+	if err := h.tagProtection.CheckTagDeletion(repo, user, tagName); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
 	if err := h.repoService.DeleteTag(c.Request.Context(), repo, tagName); err != nil {
 		h.handleError(c, err)
 		return
 	}
+	recordAuditEvent(c, h.auditEvents, models.AuditActionTagDelete, repo, map[string]any{
+		"tag": tagName,
+	})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Tag deleted successfully",
 	})
 }
 
+// refLimitExceeded responds 422 to the creation of a branch or tag in a
+// repository at its limit, adding the limit and where to delete unused refs to
+// resp. collection is the path segment of the refs (branches, tags).
+func (h *RepoHandler) refLimitExceeded(c *gin.Context, err error, collection string, resp gin.H) {
+	base := "/api/v1/repos/" + c.Param("owner") + "/" + c.Param("repo") + "/" + collection
+	resp["cleanup"] = gin.H{
+		"list":   "GET " + base,
+		"delete": "DELETE " + base + "/{name}",
+	}
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		resp["limit"] = appErr.Details["limit"]
+		resp["count"] = appErr.Details["count"]
+	}
+	c.JSON(http.StatusUnprocessableEntity, resp)
+}
+
 // GetRepositoryStats handles GET /api/repos/:owner/:repo/stats
 func (h *RepoHandler) GetRepositoryStats(c *gin.Context) {
 	owner := c.Param("owner")
@@ -813,7 +1187,7 @@ func (h *RepoHandler) GetRepositoryStats(c *gin.Context) {
 
 	// Check access
 	user := middleware.GetUserFromContext(c)
-	if repo.IsPrivate && (user == nil || (user.ID != repo.OwnerID && !user.IsAdmin)) {
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
 			"message": "Repository not found",
@@ -826,10 +1200,120 @@ func (h *RepoHandler) GetRepositoryStats(c *gin.Context) {
 		h.handleError(c, err)
 		return
 	}
+	stats.LargeFilesAdded, err = h.largeFiles.CountRecent(c.Request.Context(), repo.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, stats)
 }
 
+// GetContributorStats handles GET /api/repos/:owner/:repo/stats/contributors
+func (h *RepoHandler) GetContributorStats(c *gin.Context) {
+	owner := c.Param("owner")
+	repoName := c.Param("repo")
+
+	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// Check access
+	user := middleware.GetUserFromContext(c)
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	since, sinceOK := parseTimeQuery(c.Query("since"))
+	until, untilOK := parseTimeQuery(c.Query("until"))
+	if !sinceOK || !untilOK {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Since and until must be RFC 3339 times or YYYY-MM-DD dates",
+		})
+		return
+	}
+
+	ref := c.Query("ref")
+	activity, err := h.repoService.GetContributorStats(c.Request.Context(), repo, ref, since, until)
+	if err != nil {
+		h.log.WithContext(c.Request.Context()).Debug("Failed to compute contributor stats",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+			logger.String("ref", ref),
+		)
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Revision not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.ContributorStatsFromService(activity, ref))
+}
+
+// GetLanguages handles GET /api/repos/:owner/:repo/languages
+func (h *RepoHandler) GetLanguages(c *gin.Context) {
+	owner := c.Param("owner")
+	repoName := c.Param("repo")
+
+	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// Check access
+	user := middleware.GetUserFromContext(c)
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	ref := c.Query("ref")
+	breakdown, err := h.languages.GetLanguages(c.Request.Context(), repo, ref)
+	if err != nil {
+		h.log.WithContext(c.Request.Context()).Debug("Failed to compute languages",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+			logger.String("ref", ref),
+		)
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Revision not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.LanguagesFromService(breakdown, ref))
+}
+
+// parseTimeQuery parses an RFC 3339 time or a YYYY-MM-DD date (UTC
+// midnight). An empty value is the zero time; ok is false if value is invalid.
+func parseTimeQuery(value string) (t time.Time, ok bool) {
+	if value == "" {
+		return time.Time{}, true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
 // ListCommits handles GET /api/repos/:owner/:repo/commits
 func (h *RepoHandler) ListCommits(c *gin.Context) {
 	owner := c.Param("owner")
@@ -843,7 +1327,7 @@ func (h *RepoHandler) ListCommits(c *gin.Context) {
 
 	// Check access
 	user := middleware.GetUserFromContext(c)
-	if repo.IsPrivate && (user == nil || (user.ID != repo.OwnerID && !user.IsAdmin)) {
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
 			"message": "Repository not found",
@@ -853,30 +1337,36 @@ func (h *RepoHandler) ListCommits(c *gin.Context) {
 
 	// Get query parameters
 	ref := c.DefaultQuery("ref", "")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "30"))
-
-	if page < 1 {
-		page = 1
-	}
-	if perPage < 1 || perPage > 100 {
-		perPage = 30
+	page, ok := pagination.FromRequest(c, pagination.Commits)
+	if !ok {
+		return
 	}
 
-	offset := (page - 1) * perPage
-
-	commits, err := h.repoService.GetCommits(c.Request.Context(), repo, ref, perPage, offset)
+	commits, err := h.repoService.GetCommits(c.Request.Context(), repo, ref, page.PerPage, page.Offset)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	total, err := h.repoService.CountCommits(c.Request.Context(), repo, ref)
 	if err != nil {
 		h.handleError(c, err)
 		return
 	}
+	info := page.Counted(len(commits), int64(total))
 
 	response := dto.CommitListFromService(commits, ref)
+	response.Pagination = info
+	h.authorMappings.ResolveCommits(c.Request.Context(), repo, response.Commits)
+	h.signatures.VerifyCommits(c.Request.Context(), repo, response.Commits)
 	response.Ref = ref
 	if ref == "" {
 		response.Ref = "HEAD"
 	}
 
+	if apiversion.AtLeast(c, apiversion.CommitPeople) {
+		c.JSON(http.StatusOK, response.Split())
+		return
+	}
 	c.JSON(http.StatusOK, response)
 }
 
@@ -894,7 +1384,7 @@ func (h *RepoHandler) GetCommit(c *gin.Context) {
 
 	// Check access
 	user := middleware.GetUserFromContext(c)
-	if repo.IsPrivate && (user == nil || (user.ID != repo.OwnerID && !user.IsAdmin)) {
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
 			"message": "Repository not found",
@@ -912,7 +1402,14 @@ func (h *RepoHandler) GetCommit(c *gin.Context) {
 	}
 
 	response := dto.CommitFromService(*commit)
-	c.JSON(http.StatusOK, response)
+	resolved := []dto.CommitResponse{response}
+	h.authorMappings.ResolveCommits(c.Request.Context(), repo, resolved)
+	h.signatures.VerifyCommits(c.Request.Context(), repo, resolved)
+	if apiversion.AtLeast(c, apiversion.CommitPeople) {
+		c.JSON(http.StatusOK, resolved[0].Split())
+		return
+	}
+	c.JSON(http.StatusOK, resolved[0])
 }
 
 // GetDiff handles GET /api/v1/repos/:owner/:repo/diff/:hash
@@ -930,7 +1427,7 @@ func (h *RepoHandler) GetDiff(c *gin.Context) {
 
 	// Access control: allow public reads; require auth for private repos
 	user := middleware.GetUserFromContext(c)
-	if repo.IsPrivate && (user == nil || (user.ID != repo.OwnerID && !user.IsAdmin)) {
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
 			"message": "Repository not found",
@@ -961,15 +1458,23 @@ func (h *RepoHandler) GetDiff(c *gin.Context) {
 }
 
 // GetCompareDiff handles GET /api/v1/repos/:owner/:repo/compare/:range
+// The range is either <from>..<to>, or <base>...[<fork-owner>:]<branch> to
+// compare a branch, optionally of a fork in the same fork network, against
+// its merge base with base.
 func (h *RepoHandler) GetCompareDiff(c *gin.Context) {
 	owner := c.Param("owner")
 	repoName := c.Param("repo")
 	rng := c.Param("range")
-	parts := strings.Split(rng, "..")
-	if len(parts) != 2 {
+
+	base, head, threeDot := strings.Cut(rng, "...")
+	var parts []string
+	if !threeDot {
+		parts = strings.Split(rng, "..")
+	}
+	if (threeDot && (base == "" || head == "")) || (!threeDot && len(parts) != 2) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "bad_request",
-			"message": "Range must be in format <from>..<to>",
+			"message": "Range must be in format <from>..<to> or <base>...[<owner>:]<branch>",
 		})
 		return
 	}
@@ -981,7 +1486,7 @@ func (h *RepoHandler) GetCompareDiff(c *gin.Context) {
 	}
 
 	user := middleware.GetUserFromContext(c)
-	if repo.IsPrivate && (user == nil || (user.ID != repo.OwnerID && !user.IsAdmin)) {
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
 			"message": "Repository not found",
@@ -989,6 +1494,11 @@ func (h *RepoHandler) GetCompareDiff(c *gin.Context) {
 		return
 	}
 
+	if threeDot {
+		h.getMergeBaseCompareDiff(c, user, repo, base, head)
+		return
+	}
+
 	diffResult, err := h.repoService.GetCompareDiff(c.Request.Context(), repo, parts[0], parts[1])
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -1003,6 +1513,100 @@ func (h *RepoHandler) GetCompareDiff(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// getMergeBaseCompareDiff writes the changes of head since its merge base with
+// base. A head of the form <owner>:<branch> names a branch of the repository
+// owned by owner in the fork network of repo, which the user must be able to read.
+// Within repo itself, the commits of head missing from base are listed too.
+func (h *RepoHandler) getMergeBaseCompareDiff(c *gin.Context, user *models.User, repo *models.Repository, base, head string) {
+	fork := repo
+	branch := head
+	if forkOwner, forkBranch, ok := strings.Cut(head, ":"); ok {
+		branch = forkBranch
+		if forkOwner != repo.Owner.Username {
+			found, err := h.repoService.FindForkByOwner(c.Request.Context(), repo, forkOwner)
+			if err != nil && !apperrors.IsNotFound(err) {
+				h.handleError(c, err)
+				return
+			}
+			// Do not reveal forks the user cannot read
+			if err != nil || !h.repoService.HasPermission(c.Request.Context(), user, found, models.RepoPermissionRead) {
+				c.JSON(http.StatusNotFound, gin.H{
+					"error":   "not_found",
+					"message": "Fork not found",
+				})
+				return
+			}
+			fork = found
+		}
+	}
+
+	if fork == repo {
+		h.compareRefs(c, repo, base, branch)
+		return
+	}
+
+	diffResult, err := h.repoService.GetForkCompareDiff(c.Request.Context(), repo, base, fork, branch)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNoMergeBase) {
+			// Unrelated histories are a valid answer, not a failure
+			c.JSON(http.StatusOK, dto.DiffResponse{
+				CommitHash:  base + "..." + head,
+				NoMergeBase: true,
+			})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Diff not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response := dto.DiffFromService(diffResult)
+	c.JSON(http.StatusOK, response)
+}
+
+// compareRefs writes the comparison of two revisions of repo
+func (h *RepoHandler) compareRefs(c *gin.Context, repo *models.Repository, base, head string) {
+	comparison, err := h.repoService.CompareRefs(c.Request.Context(), repo, base, head)
+	if err != nil {
+		switch {
+		case errors.Is(err, apperrors.ErrNoMergeBase):
+			// Unrelated histories are a valid answer, not a failure
+			h.writeComparison(c, dto.CompareResponse{
+				DiffResponse: dto.DiffResponse{
+					CommitHash:  base + "..." + head,
+					NoMergeBase: true,
+				},
+				Commits: []dto.CommitResponse{},
+			})
+		case apperrors.IsNotFound(err):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Revision not found",
+			})
+		default:
+			h.handleError(c, err)
+		}
+		return
+	}
+
+	response := dto.CompareFromService(comparison)
+	h.authorMappings.ResolveCommits(c.Request.Context(), repo, response.Commits)
+	h.writeComparison(c, response)
+}
+
+// writeComparison writes a comparison in the commit shape of the negotiated
+// API version
+func (h *RepoHandler) writeComparison(c *gin.Context, response dto.CompareResponse) {
+	if apiversion.AtLeast(c, apiversion.CommitPeople) {
+		c.JSON(http.StatusOK, response.Split())
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
 // GetTree handles GET /api/repos/:owner/:repo/tree/:ref/*path
 func (h *RepoHandler) GetTree(c *gin.Context) {
 	owner := c.Param("owner")
@@ -1018,7 +1622,7 @@ func (h *RepoHandler) GetTree(c *gin.Context) {
 
 	// Check access
 	user := middleware.GetUserFromContext(c)
-	if repo.IsPrivate && (user == nil || (user.ID != repo.OwnerID && !user.IsAdmin)) {
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
 			"message": "Repository not found",
@@ -1055,7 +1659,7 @@ func (h *RepoHandler) GetFileContent(c *gin.Context) {
 
 	// Check access
 	user := middleware.GetUserFromContext(c)
-	if repo.IsPrivate && (user == nil || (user.ID != repo.OwnerID && !user.IsAdmin)) {
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
 			"message": "Repository not found",
@@ -1074,9 +1678,120 @@ func (h *RepoHandler) GetFileContent(c *gin.Context) {
 	}
 
 	response := dto.FileContentFromService(fileContent, ref)
+	response.RawURL = rawFileURL(owner, repoName, ref, fileContent.Path)
+	if highlight, _ := strconv.ParseBool(c.Query("highlight")); highlight {
+		response.Highlight = h.highlight.Highlight(fileContent)
+	}
 	c.JSON(http.StatusOK, response)
 }
 
+// GetRawFile handles GET /api/repos/:owner/:repo/raw/:ref/*path. It streams
+// the file with a Content-Type from its extension or sniffed from its start,
+// the blob hash as ETag and support for Range requests.
+func (h *RepoHandler) GetRawFile(c *gin.Context) {
+	owner := c.Param("owner")
+	repoName := c.Param("repo")
+	ref := c.Param("ref")
+	path := c.Param("path")
+
+	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// Check access
+	user := middleware.GetUserFromContext(c)
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	allowLarge, _ := strconv.ParseBool(c.Query("allow_large"))
+	blob, err := h.repoService.OpenRawFile(c.Request.Context(), repo, ref, path, allowLarge)
+	if errors.Is(err, service.ErrRawFileTooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":   "file_too_large",
+			"message": "File is too large to serve, pass allow_large=true to download it anyway",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "File not found",
+			"details": err.Error(),
+		})
+		return
+	}
+	defer blob.Content.Close()
+
+	contentType, err := rawContentType(blob.Name, blob.Content)
+	if err != nil {
+		h.log.WithContext(c.Request.Context()).Error("Failed to read raw file",
+			logger.Error(err),
+			logger.String("repo_id", repo.ID.String()),
+			logger.String("path", blob.Path),
+		)
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("ETag", `"`+blob.Hash+`"`)
+	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": blob.Name}))
+	// Files are served from the API origin: never let HTML or SVG run scripts there
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	// Handles Range, If-Range, If-None-Match against the ETag, and HEAD
+	http.ServeContent(c.Writer, c.Request, blob.Name, time.Time{}, blob.Content)
+}
+
+// rawFileURL returns the API path of the raw file endpoint serving a file
+func rawFileURL(owner, repoName, ref, filePath string) string {
+	segments := strings.Split(strings.TrimPrefix(filePath, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return "/api/v1/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repoName) +
+		"/raw/" + url.PathEscape(ref) + "/" + strings.Join(segments, "/")
+}
+
+// rawContentType returns the Content-Type of a raw file from the extension of
+// its name, or sniffed from the start of its content, which is then rewound
+func rawContentType(name string, content io.ReadSeeker) (string, error) {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType, nil
+	}
+
+	var head [512]byte
+	n, err := io.ReadFull(content, head[:])
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
+}
+
+// GetHighlightStylesheet handles GET /api/v1/highlight/styles/:style
+func (h *RepoHandler) GetHighlightStylesheet(c *gin.Context) {
+	var css bytes.Buffer
+	if err := h.highlight.WriteStylesheet(&css, strings.TrimSuffix(c.Param("style"), ".css")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, "text/css; charset=utf-8", css.Bytes())
+}
+
 // GetBlame handles GET /api/repos/:owner/:repo/blame/:ref/*path
 func (h *RepoHandler) GetBlame(c *gin.Context) {
 	owner := c.Param("owner")
@@ -1092,7 +1807,7 @@ func (h *RepoHandler) GetBlame(c *gin.Context) {
 
 	// Check access
 	user := middleware.GetUserFromContext(c)
-	if repo.IsPrivate && (user == nil || (user.ID != repo.OwnerID && !user.IsAdmin)) {
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionRead) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
 			"message": "Repository not found",
@@ -1118,7 +1833,7 @@ func (h *RepoHandler) GetBlame(c *gin.Context) {
 func (h *RepoHandler) UpdateMirrorSettings(c *gin.Context) {
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
-		h.log.Warn("Update mirror settings attempted without authentication",
+		h.log.WithContext(c.Request.Context()).Warn("Update mirror settings attempted without authentication",
 			logger.ClientIP(c.ClientIP()),
 		)
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -1131,7 +1846,7 @@ func (h *RepoHandler) UpdateMirrorSettings(c *gin.Context) {
 	owner := c.Param("owner")
 	repoName := c.Param("repo")
 
-	h.log.Debug("Update mirror settings request received",
+	h.log.WithContext(c.Request.Context()).Debug("Update mirror settings request received",
 		logger.String("owner", owner),
 		logger.String("repo", repoName),
 		logger.String("user_id", user.ID.String()),
@@ -1140,7 +1855,7 @@ func (h *RepoHandler) UpdateMirrorSettings(c *gin.Context) {
 	// Get repository
 	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
 	if err != nil {
-		h.log.Error("Failed to get repository",
+		h.log.WithContext(c.Request.Context()).Error("Failed to get repository",
 			logger.Error(err),
 			logger.String("owner", owner),
 			logger.String("repo", repoName),
@@ -1149,9 +1864,9 @@ func (h *RepoHandler) UpdateMirrorSettings(c *gin.Context) {
 		return
 	}
 
-	// Verify user has access (owner or admin)
-	if repo.OwnerID != user.ID && !user.IsAdmin {
-		h.log.Warn("User does not have permission to update mirror settings",
+	// Verify user has admin access
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionAdmin) {
+		h.log.WithContext(c.Request.Context()).Warn("User does not have permission to update mirror settings",
 			logger.String("user_id", user.ID.String()),
 			logger.String("repo_id", repo.ID.String()),
 		)
@@ -1165,7 +1880,7 @@ func (h *RepoHandler) UpdateMirrorSettings(c *gin.Context) {
 	// Parse request body
 	var req dto.UpdateMirrorSettingsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.Warn("Invalid update mirror settings request body",
+		h.log.WithContext(c.Request.Context()).Warn("Invalid update mirror settings request body",
 			logger.Error(err),
 			logger.String("user_id", user.ID.String()),
 		)
@@ -1178,7 +1893,7 @@ func (h *RepoHandler) UpdateMirrorSettings(c *gin.Context) {
 	}
 
 	// Update mirror settings
-	h.log.Info("Updating mirror settings",
+	h.log.WithContext(c.Request.Context()).Info("Updating mirror settings",
 		logger.String("repo_id", repo.ID.String()),
 		logger.String("owner", owner),
 		logger.String("repo", repoName),
@@ -1190,7 +1905,7 @@ func (h *RepoHandler) UpdateMirrorSettings(c *gin.Context) {
 		&req,
 	)
 	if err != nil {
-		h.log.Error("Failed to update mirror settings",
+		h.log.WithContext(c.Request.Context()).Error("Failed to update mirror settings",
 			logger.Error(err),
 			logger.String("repo_id", repo.ID.String()),
 		)
@@ -1201,7 +1916,7 @@ func (h *RepoHandler) UpdateMirrorSettings(c *gin.Context) {
 		return
 	}
 
-	h.log.Info("Mirror settings updated successfully",
+	h.log.WithContext(c.Request.Context()).Info("Mirror settings updated successfully",
 		logger.String("repo_id", repo.ID.String()),
 		logger.String("owner", owner),
 		logger.String("repo", repoName),
@@ -1219,7 +1934,7 @@ func (h *RepoHandler) GetMirrorSettings(c *gin.Context) {
 	owner := c.Param("owner")
 	repoName := c.Param("repo")
 
-	h.log.Debug("Get mirror settings request received",
+	h.log.WithContext(c.Request.Context()).Debug("Get mirror settings request received",
 		logger.String("owner", owner),
 		logger.String("repo", repoName),
 	)
@@ -1227,7 +1942,7 @@ func (h *RepoHandler) GetMirrorSettings(c *gin.Context) {
 	// Get repository
 	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
 	if err != nil {
-		h.log.Error("Failed to get repository",
+		h.log.WithContext(c.Request.Context()).Error("Failed to get repository",
 			logger.Error(err),
 			logger.String("owner", owner),
 			logger.String("repo", repoName),
@@ -1274,7 +1989,7 @@ func (h *RepoHandler) GetMirrorSettings(c *gin.Context) {
 func (h *RepoHandler) SyncMirror(c *gin.Context) {
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
-		h.log.Warn("Sync mirror attempted without authentication",
+		h.log.WithContext(c.Request.Context()).Warn("Sync mirror attempted without authentication",
 			logger.ClientIP(c.ClientIP()),
 		)
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -1287,7 +2002,7 @@ func (h *RepoHandler) SyncMirror(c *gin.Context) {
 	owner := c.Param("owner")
 	repoName := c.Param("repo")
 
-	h.log.Debug("Sync mirror request received",
+	h.log.WithContext(c.Request.Context()).Debug("Sync mirror request received",
 		logger.String("owner", owner),
 		logger.String("repo", repoName),
 		logger.String("user_id", user.ID.String()),
@@ -1296,7 +2011,7 @@ func (h *RepoHandler) SyncMirror(c *gin.Context) {
 	// Get repository
 	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
 	if err != nil {
-		h.log.Error("Failed to get repository",
+		h.log.WithContext(c.Request.Context()).Error("Failed to get repository",
 			logger.Error(err),
 			logger.String("owner", owner),
 			logger.String("repo", repoName),
@@ -1305,9 +2020,9 @@ func (h *RepoHandler) SyncMirror(c *gin.Context) {
 		return
 	}
 
-	// Verify user has access (owner or admin)
-	if repo.OwnerID != user.ID && !user.IsAdmin {
-		h.log.Warn("User does not have permission to sync repository",
+	// Verify user has admin access
+	if !h.repoService.HasPermission(c.Request.Context(), user, repo, models.RepoPermissionAdmin) {
+		h.log.WithContext(c.Request.Context()).Warn("User does not have permission to sync repository",
 			logger.String("user_id", user.ID.String()),
 			logger.String("repo_id", repo.ID.String()),
 		)
@@ -1320,7 +2035,7 @@ func (h *RepoHandler) SyncMirror(c *gin.Context) {
 
 	// Verify mirror is enabled
 	if !repo.MirrorEnabled {
-		h.log.Warn("Repository mirror is not enabled",
+		h.log.WithContext(c.Request.Context()).Warn("Repository mirror is not enabled",
 			logger.String("repo_id", repo.ID.String()),
 			logger.String("repo_name", repo.Name),
 		)
@@ -1332,14 +2047,14 @@ func (h *RepoHandler) SyncMirror(c *gin.Context) {
 	}
 
 	// Trigger sync
-	h.log.Info("Triggering mirror sync",
+	h.log.WithContext(c.Request.Context()).Info("Triggering mirror sync",
 		logger.String("repo_id", repo.ID.String()),
 		logger.String("owner", owner),
 		logger.String("repo", repoName),
 	)
 
 	if err := h.mirrorSyncService.SyncRepository(c.Request.Context(), repo.ID); err != nil {
-		h.log.Error("Failed to trigger sync",
+		h.log.WithContext(c.Request.Context()).Error("Failed to trigger sync",
 			logger.Error(err),
 			logger.String("repo_id", repo.ID.String()),
 		)
@@ -1350,7 +2065,7 @@ func (h *RepoHandler) SyncMirror(c *gin.Context) {
 		return
 	}
 
-	h.log.Info("Mirror sync triggered successfully",
+	h.log.WithContext(c.Request.Context()).Info("Mirror sync triggered successfully",
 		logger.String("repo_id", repo.ID.String()),
 		logger.String("owner", owner),
 		logger.String("repo", repoName),
@@ -1368,7 +2083,7 @@ func (h *RepoHandler) GetMirrorStatus(c *gin.Context) {
 	owner := c.Param("owner")
 	repoName := c.Param("repo")
 
-	h.log.Debug("Get mirror status request received",
+	h.log.WithContext(c.Request.Context()).Debug("Get mirror status request received",
 		logger.String("owner", owner),
 		logger.String("repo", repoName),
 	)
@@ -1376,7 +2091,7 @@ func (h *RepoHandler) GetMirrorStatus(c *gin.Context) {
 	// Get repository
 	repo, err := h.repoService.GetRepository(c.Request.Context(), owner, repoName)
 	if err != nil {
-		h.log.Error("Failed to get repository",
+		h.log.WithContext(c.Request.Context()).Error("Failed to get repository",
 			logger.Error(err),
 			logger.String("owner", owner),
 			logger.String("repo", repoName),
@@ -1412,7 +2127,7 @@ func (h *RepoHandler) GetMirrorStatus(c *gin.Context) {
 	// Get sync status
 	status, err := h.mirrorSyncService.GetSyncStatus(c.Request.Context(), repo.ID)
 	if err != nil {
-		h.log.Error("Failed to get sync status",
+		h.log.WithContext(c.Request.Context()).Error("Failed to get sync status",
 			logger.Error(err),
 			logger.String("repo_id", repo.ID.String()),
 		)
@@ -1426,12 +2141,47 @@ func (h *RepoHandler) GetMirrorStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, status)
 }
 
+// reposToResponses converts repositories to response DTOs including their
+// listed annotations and primary language
+func (h *RepoHandler) reposToResponses(c *gin.Context, repos []*models.Repository) []dto.RepoResponse {
+	annotations, err := h.annotationService.ListedAnnotations(c.Request.Context(), repos)
+	if err != nil {
+		// Annotations are supplementary; don't fail the listing
+		h.log.WithContext(c.Request.Context()).Warn("Failed to load repository annotations",
+			logger.Error(err),
+		)
+	}
+
+	languages, err := h.languages.PrimaryLanguages(c.Request.Context(), repos)
+	if err != nil {
+		h.log.WithContext(c.Request.Context()).Warn("Failed to load repository languages",
+			logger.Error(err),
+		)
+	}
+
+	responses := make([]dto.RepoResponse, len(repos))
+	for i, repo := range repos {
+		responses[i] = dto.RepoFromModel(repo, h.baseURL, h.sshHost, h.sshPort)
+		responses[i].Annotations = annotations[repo.ID]
+		responses[i].PrimaryLanguage = languages[repo.ID]
+	}
+	return responses
+}
+
 // handleError handles errors and returns appropriate HTTP responses
 func (h *RepoHandler) handleError(c *gin.Context, err error) {
-	h.log.Debug("Handling error response",
+	h.log.WithContext(c.Request.Context()).Debug("Handling error response",
 		logger.Error(err),
 		logger.Path(c.Request.URL.Path),
 	)
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
 	if apperrors.IsNotFound(err) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
@@ -1456,6 +2206,14 @@ func (h *RepoHandler) handleError(c *gin.Context, err error) {
 		return
 	}
 
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusInternalServerError, gin.H{
 		"error":   "internal_error",
 		"message": "An internal error occurred",
@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+const (
+	// maxImportFieldLength is the longest form field read from an archive upload
+	maxImportFieldLength = 1024
+
+	// maxArchiveNameLength is the longest archive file name kept for display
+	maxArchiveNameLength = 255
+)
+
+// RepoImportHandler handles repository imports of the current user
+type RepoImportHandler struct {
+	imports *service.RepoImportService
+	log     *logger.Logger
+}
+
+// NewRepoImportHandler creates a new RepoImportHandler instance
+func NewRepoImportHandler(imports *service.RepoImportService) *RepoImportHandler {
+	return &RepoImportHandler{
+		imports: imports,
+		log:     logger.Get().WithFields(logger.Component("repo-import-handler")),
+	}
+}
+
+// ImportRepository queues the import of a repository and returns its status.
+// A JSON body clones a remote URL; a multipart/form-data body uploads an
+// archive whose files become the initial commit.
+// POST /api/v1/repos/import
+func (h *RepoImportHandler) ImportRepository(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		h.log.WithContext(c.Request.Context()).Warn("Import repository attempted without authentication",
+			logger.ClientIP(c.ClientIP()),
+		)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	if !user.TokenAllows(models.RepoPermissionWrite) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "The token does not have the repo:write scope",
+		})
+		return
+	}
+
+	if c.ContentType() == "multipart/form-data" {
+		h.importArchive(c, user)
+		return
+	}
+
+	var req dto.ImportRepoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.WithContext(c.Request.Context()).Warn("Invalid import repository request body",
+			logger.Error(err),
+			logger.String("user_id", user.ID.String()),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		h.log.WithContext(c.Request.Context()).Warn("Repository import validation failed",
+			logger.Error(err),
+			logger.String("name", req.Name),
+			logger.String("clone_url", logger.Redact(req.CloneURL)),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	imp, err := h.imports.ImportFromURL(c.Request.Context(), user, service.URLImport{
+		Name:        req.Name,
+		Description: req.Description,
+		IsPrivate:   req.IsPrivate,
+		CloneURL:    req.CloneURL,
+		Username:    req.Username,
+		Password:    req.Password,
+		Mirror:      req.Mirror,
+	}, c.ClientIP())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.RepoImportFromModel(imp, user.Username))
+}
+
+// importArchive reads the form fields of an archive upload, which must come
+// before the archive, and queues the import of the archive as it is received
+func (h *RepoImportHandler) importArchive(c *gin.Context, user *models.User) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid multipart body",
+		})
+		return
+	}
+
+	var req dto.ImportArchiveRequest
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "validation_error",
+				"message": "The archive field is required",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "bad_request",
+				"message": "Invalid multipart body",
+			})
+			return
+		}
+
+		if part.FormName() == "archive" {
+			h.queueArchive(c, user, &req, part.FileName(), part)
+			return
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, maxImportFieldLength+1))
+		if err != nil || len(value) > maxImportFieldLength {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "bad_request",
+				"message": "Invalid multipart body",
+			})
+			return
+		}
+		switch part.FormName() {
+		case "name":
+			req.Name = string(value)
+		case "description":
+			req.Description = string(value)
+		case "is_private":
+			if req.IsPrivate, err = strconv.ParseBool(string(value)); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "validation_error",
+					"message": "is_private must be true or false",
+				})
+				return
+			}
+		}
+	}
+}
+
+// queueArchive validates the fields of an archive upload and queues the
+// import of the archive read from r
+func (h *RepoImportHandler) queueArchive(c *gin.Context, user *models.User, req *dto.ImportArchiveRequest, fileName string, r io.Reader) {
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+	if len(fileName) > maxArchiveNameLength {
+		fileName = fileName[:maxArchiveNameLength]
+	}
+
+	imp, err := h.imports.ImportFromArchive(c.Request.Context(), user, service.ArchiveImport{
+		Name:        req.Name,
+		Description: req.Description,
+		IsPrivate:   req.IsPrivate,
+		ArchiveName: fileName,
+	}, r, c.ClientIP())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.RepoImportFromModel(imp, user.Username))
+}
+
+// ListImports handles GET /api/v1/repos/imports
+func (h *RepoImportHandler) ListImports(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
+	}
+
+	imports, total, err := h.imports.ListImports(c.Request.Context(), user.ID, page.PerPage, page.Offset)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	responses := make([]dto.RepoImportResponse, 0, len(imports))
+	for _, imp := range imports {
+		responses = append(responses, dto.RepoImportFromModel(imp, user.Username))
+	}
+
+	c.JSON(http.StatusOK, dto.RepoImportListResponse{
+		Imports:    responses,
+		Pagination: page.Counted(len(responses), total),
+	})
+}
+
+// GetImport handles GET /api/v1/repos/imports/:id
+func (h *RepoImportHandler) GetImport(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Import not found",
+		})
+		return
+	}
+
+	imp, err := h.imports.GetImport(c.Request.Context(), user.ID, id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.RepoImportFromModel(imp, user.Username))
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *RepoImportHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Import not found",
+		})
+		return
+	}
+
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		switch {
+		case apperrors.IsConflict(err):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "conflict",
+				"message": appErr.Message,
+			})
+			return
+		case apperrors.IsForbidden(err):
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "forbidden",
+				"message": appErr.Message,
+			})
+			return
+		case apperrors.IsBadRequest(err):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "bad_request",
+				"message": appErr.Message,
+			})
+			return
+		}
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Repository import request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/bravo68web/stasis/internal/application/dto"
 	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
 	"github.com/bravo68web/stasis/internal/transport/http/middleware"
 	apperrors "github.com/bravo68web/stasis/pkg/errors"
 )
@@ -15,16 +16,18 @@ import (
 // SSHKeyHandler handles SSH key-related HTTP requests
 type SSHKeyHandler struct {
 	sshKeyService *service.SSHKeyService
+	auditEvents   *service.AuditEventService
 }
 
 // NewSSHKeyHandler creates a new SSHKeyHandler instance
-func NewSSHKeyHandler(sshKeyService *service.SSHKeyService) *SSHKeyHandler {
+func NewSSHKeyHandler(sshKeyService *service.SSHKeyService, auditEvents *service.AuditEventService) *SSHKeyHandler {
 	return &SSHKeyHandler{
 		sshKeyService: sshKeyService,
+		auditEvents:   auditEvents,
 	}
 }
 
-// AddSSHKey handles POST /api/v1/user/ssh-keys
+// AddSSHKey handles POST /api/v1/ssh-keys
 func (h *SSHKeyHandler) AddSSHKey(c *gin.Context) {
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
@@ -54,19 +57,19 @@ func (h *SSHKeyHandler) AddSSHKey(c *gin.Context) {
 		h.handleError(c, err)
 		return
 	}
+	recordAuditEvent(c, h.auditEvents, models.AuditActionSSHKeyAdd, nil, map[string]any{
+		"key_id":      resp.Key.ID.String(),
+		"title":       resp.Key.Title,
+		"fingerprint": resp.Key.Fingerprint,
+	})
 
 	c.JSON(http.StatusCreated, dto.AddSSHKeyResponse{
-		Key: dto.SSHKeyInfo{
-			ID:          resp.Key.ID,
-			Title:       resp.Key.Title,
-			Fingerprint: resp.Key.Fingerprint,
-			CreatedAt:   resp.Key.CreatedAt,
-		},
+		Key:     dto.SSHKeyInfoFromModel(resp.Key),
 		Message: "SSH key added successfully",
 	})
 }
 
-// ListSSHKeys handles GET /api/v1/user/ssh-keys
+// ListSSHKeys handles GET /api/v1/ssh-keys
 func (h *SSHKeyHandler) ListSSHKeys(c *gin.Context) {
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
@@ -85,12 +88,7 @@ func (h *SSHKeyHandler) ListSSHKeys(c *gin.Context) {
 
 	keyInfos := make([]dto.SSHKeyInfo, len(keys))
 	for i, key := range keys {
-		keyInfos[i] = dto.SSHKeyInfo{
-			ID:          key.ID,
-			Title:       key.Title,
-			Fingerprint: key.Fingerprint,
-			CreatedAt:   key.CreatedAt,
-		}
+		keyInfos[i] = dto.SSHKeyInfoFromModel(key)
 	}
 
 	c.JSON(http.StatusOK, dto.ListSSHKeysResponse{
@@ -99,7 +97,7 @@ func (h *SSHKeyHandler) ListSSHKeys(c *gin.Context) {
 	})
 }
 
-// GetSSHKey handles GET /api/v1/user/ssh-keys/:id
+// GetSSHKey handles GET /api/v1/ssh-keys/:id
 func (h *SSHKeyHandler) GetSSHKey(c *gin.Context) {
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
@@ -135,15 +133,10 @@ func (h *SSHKeyHandler) GetSSHKey(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, dto.SSHKeyInfo{
-		ID:          key.ID,
-		Title:       key.Title,
-		Fingerprint: key.Fingerprint,
-		CreatedAt:   key.CreatedAt,
-	})
+	c.JSON(http.StatusOK, dto.SSHKeyInfoFromModel(key))
 }
 
-// DeleteSSHKey handles DELETE /api/v1/user/ssh-keys/:id
+// DeleteSSHKey handles DELETE /api/v1/ssh-keys/:id
 func (h *SSHKeyHandler) DeleteSSHKey(c *gin.Context) {
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
@@ -176,6 +169,9 @@ func (h *SSHKeyHandler) DeleteSSHKey(c *gin.Context) {
 			return
 		}
 	}
+	recordAuditEvent(c, h.auditEvents, models.AuditActionSSHKeyDelete, nil, map[string]any{
+		"key_id": keyID.String(),
+	})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "SSH key deleted successfully",
@@ -184,6 +180,14 @@ func (h *SSHKeyHandler) DeleteSSHKey(c *gin.Context) {
 
 // handleError handles errors and returns appropriate HTTP responses
 func (h *SSHKeyHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
 	if apperrors.IsNotFound(err) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// StorageHandler handles storage backend administration HTTP requests
+type StorageHandler struct {
+	repoService *service.RepoService
+	storage     *service.StorageBackendService
+	staleFiles  *service.StaleFileService
+	gc          *service.GarbageCollectionService
+	log         *logger.Logger
+}
+
+// NewStorageHandler creates a new StorageHandler instance
+func NewStorageHandler(repoService *service.RepoService, storage *service.StorageBackendService, staleFiles *service.StaleFileService, gc *service.GarbageCollectionService) *StorageHandler {
+	return &StorageHandler{
+		repoService: repoService,
+		storage:     storage,
+		staleFiles:  staleFiles,
+		gc:          gc,
+		log:         logger.Get().WithFields(logger.Component("storage-handler")),
+	}
+}
+
+// ListBackends handles GET /api/v1/admin/storage/backends
+func (h *StorageHandler) ListBackends(c *gin.Context) {
+	backends, err := h.storage.ListBackends(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	resp := dto.StorageBackendListResponse{Backends: make([]dto.StorageBackendResponse, len(backends))}
+	for i, backend := range backends {
+		resp.Backends[i] = dto.StorageBackendResponse{
+			Name:         backend.Name,
+			Type:         backend.Type,
+			BasePath:     backend.BasePath,
+			Configured:   backend.Type != "",
+			Repositories: backend.Repositories,
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// MigrateRepository handles POST /api/v1/admin/repos/:owner/:repo/storage
+func (h *StorageHandler) MigrateRepository(c *gin.Context) {
+	var req dto.MigrateRepoStorageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// A migration must not be abandoned halfway because the client went away
+	ctx := context.WithoutCancel(c.Request.Context())
+	repo, err = h.storage.MigrateRepository(ctx, repo.ID, req.Backend, middleware.GetUserFromContext(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.RepoStorageResponse{
+		ID:             repo.ID.String(),
+		FullName:       repo.GetFullName(),
+		StorageBackend: repo.StorageBackend,
+		GitPath:        repo.GitPath,
+	})
+}
+
+// RemoveStaleFiles handles POST /api/v1/admin/repos/:owner/:repo/stale-files/remove
+func (h *StorageHandler) RemoveStaleFiles(c *gin.Context) {
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	removed, err := h.staleFiles.CleanRepository(c.Request.Context(), repo, middleware.GetUserFromContext(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	resp := dto.StaleFilesResponse{
+		FullName: repo.GetFullName(),
+		Removed:  make([]dto.StaleFileResponse, len(removed)),
+	}
+	for i, file := range removed {
+		resp.Removed[i] = dto.StaleFileResponse{
+			Path:       file.Path,
+			Size:       file.Size,
+			ModifiedAt: file.ModTime,
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CollectGarbage handles POST /api/v1/admin/repos/:owner/:repo/gc
+func (h *StorageHandler) CollectGarbage(c *gin.Context) {
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// A collection must not be abandoned halfway because the client went away
+	ctx := context.WithoutCancel(c.Request.Context())
+	result, err := h.gc.CollectRepository(ctx, repo, middleware.GetUserFromContext(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.RepoGCResponse{
+		FullName:   repo.GetFullName(),
+		Strategy:   result.Strategy,
+		DurationMS: result.Duration.Milliseconds(),
+		Before:     dto.ObjectCountsFromService(result.Before),
+		After:      dto.ObjectCountsFromService(result.After),
+	})
+}
+
+// handleError handles errors and sends appropriate HTTP responses
+func (h *StorageHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsConflict(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "conflict",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Storage request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// TagProtectionHandler handles protected tag settings HTTP requests
+type TagProtectionHandler struct {
+	repoService   *service.RepoService
+	tagProtection *service.TagProtectionService
+	log           *logger.Logger
+}
+
+// NewTagProtectionHandler creates a new TagProtectionHandler instance
+func NewTagProtectionHandler(
+	repoService *service.RepoService,
+	tagProtection *service.TagProtectionService,
+) *TagProtectionHandler {
+	return &TagProtectionHandler{
+		repoService:   repoService,
+		tagProtection: tagProtection,
+		log:           logger.Get().WithFields(logger.Component("tag-protection-handler")),
+	}
+}
+
+// GetProtectedTags handles GET /api/v1/repos/:owner/:repo/settings/protected-tags
+func (h *TagProtectionHandler) GetProtectedTags(c *gin.Context) {
+	repo, _, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.ProtectedTagsFromModel(repo))
+}
+
+// UpdateProtectedTags handles PUT /api/v1/repos/:owner/:repo/settings/protected-tags
+func (h *TagProtectionHandler) UpdateProtectedTags(c *gin.Context) {
+	repo, user, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateProtectedTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.tagProtection.UpdateProtectedTags(c.Request.Context(), repo, req.Patterns, req.Overrides); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Info("Protected tag settings changed",
+		logger.String("repo_id", repo.ID.String()),
+		logger.String("user_id", user.ID.String()),
+	)
+
+	c.JSON(http.StatusOK, dto.ProtectedTagsFromModel(repo))
+}
+
+// getAdministeredRepository loads the repository from the path and checks that
+// the authenticated user administers it (owner, site admin or admin collaborator).
+// It writes the error response and returns false if the request cannot proceed.
+func (h *TagProtectionHandler) getAdministeredRepository(c *gin.Context) (*models.Repository, *models.User, bool) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return nil, nil, false
+	}
+
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return nil, nil, false
+	}
+
+	permission := h.repoService.RepositoryPermission(c.Request.Context(), user, repo)
+	if !permission.Allows(models.RepoPermissionAdmin) {
+		// Do not reveal private repositories to users who cannot read them
+		if !permission.Allows(models.RepoPermissionRead) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Repository not found",
+			})
+			return nil, nil, false
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Only repository administrators can manage protected tags",
+		})
+		return nil, nil, false
+	}
+
+	return repo, user, true
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *TagProtectionHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Protected tag request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
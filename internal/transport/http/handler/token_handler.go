@@ -9,6 +9,7 @@ import (
 
 	"github.com/bravo68web/stasis/internal/application/dto"
 	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
 	"github.com/bravo68web/stasis/internal/transport/http/middleware"
 	apperrors "github.com/bravo68web/stasis/pkg/errors"
 )
@@ -16,12 +17,14 @@ import (
 // TokenHandler handles personal access token HTTP requests
 type TokenHandler struct {
 	tokenService *service.TokenService
+	auditEvents  *service.AuditEventService
 }
 
 // NewTokenHandler creates a new TokenHandler instance
-func NewTokenHandler(tokenService *service.TokenService) *TokenHandler {
+func NewTokenHandler(tokenService *service.TokenService, auditEvents *service.AuditEventService) *TokenHandler {
 	return &TokenHandler{
 		tokenService: tokenService,
+		auditEvents:  auditEvents,
 	}
 }
 
@@ -54,15 +57,21 @@ func (h *TokenHandler) CreateToken(c *gin.Context) {
 	}
 
 	resp, err := h.tokenService.CreateToken(c.Request.Context(), service.CreateTokenRequest{
-		UserID:    user.ID,
-		Name:      req.Name,
-		Scopes:    req.Scopes,
-		ExpiresAt: expiresAt,
+		UserID:      user.ID,
+		Name:        req.Name,
+		Scopes:      req.Scopes,
+		ExpiresAt:   expiresAt,
+		CreatedWith: user.Token,
 	})
 	if err != nil {
 		h.handleError(c, err)
 		return
 	}
+	recordAuditEvent(c, h.auditEvents, models.AuditActionTokenCreate, nil, map[string]any{
+		"token_id": resp.Token.ID.String(),
+		"name":     resp.Token.Name,
+		"scopes":   []string(resp.Token.Scope),
+	})
 
 	// Return the raw token (only shown once)
 	c.JSON(http.StatusCreated, gin.H{
@@ -128,6 +137,9 @@ func (h *TokenHandler) DeleteToken(c *gin.Context) {
 		h.handleError(c, err)
 		return
 	}
+	recordAuditEvent(c, h.auditEvents, models.AuditActionTokenDelete, nil, map[string]any{
+		"token_id": tokenID.String(),
+	})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Token deleted successfully",
@@ -136,6 +148,14 @@ func (h *TokenHandler) DeleteToken(c *gin.Context) {
 
 // handleError handles errors and returns appropriate HTTP responses
 func (h *TokenHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
 	if apperrors.IsNotFound(err) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// UserAdminHandler handles user management HTTP requests of site
+// administrators
+type UserAdminHandler struct {
+	userAdmin   *service.UserAdminService
+	auditEvents *service.AuditEventService
+	log         *logger.Logger
+}
+
+// NewUserAdminHandler creates a new UserAdminHandler instance
+func NewUserAdminHandler(
+	userAdmin *service.UserAdminService,
+	auditEvents *service.AuditEventService,
+) *UserAdminHandler {
+	return &UserAdminHandler{
+		userAdmin:   userAdmin,
+		auditEvents: auditEvents,
+		log:         logger.Get().WithFields(logger.Component("user-admin-handler")),
+	}
+}
+
+// ListUsers handles GET /api/v1/admin/users
+func (h *UserAdminHandler) ListUsers(c *gin.Context) {
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
+	}
+
+	users, total, err := h.userAdmin.ListUsers(c.Request.Context(), c.Query("q"), page.PerPage, page.Offset)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	responses := make([]dto.AdminUserResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, dto.AdminUserFromModel(user))
+	}
+
+	c.JSON(http.StatusOK, dto.ListAdminUsersResponse{
+		Users:      responses,
+		Pagination: page.Counted(len(responses), total),
+	})
+}
+
+// GetUser handles GET /api/v1/admin/users/:username
+func (h *UserAdminHandler) GetUser(c *gin.Context) {
+	user, err := h.userAdmin.GetUser(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	resp, err := h.userResponse(c, user)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateUser handles POST /api/v1/admin/users
+func (h *UserAdminHandler) CreateUser(c *gin.Context) {
+	var req dto.CreateAdminUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	user, err := h.userAdmin.ProvisionUser(c.Request.Context(), req.Username, req.Email, req.IsAdmin)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	recordAuditEvent(c, h.auditEvents, models.AuditActionUserCreate, nil, map[string]any{
+		"user_id":  user.ID.String(),
+		"username": user.Username,
+		"email":    user.Email,
+		"is_admin": user.IsAdmin,
+	})
+
+	resp, err := h.userResponse(c, user)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// UpdateUser handles PATCH /api/v1/admin/users/:username
+func (h *UserAdminHandler) UpdateUser(c *gin.Context) {
+	var req dto.UpdateAdminUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	user, err := h.userAdmin.GetUser(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	before := *user
+
+	updated, err := h.userAdmin.UpdateUser(c.Request.Context(), user, service.AdminUpdateUserRequest{
+		Email:   req.Email,
+		IsAdmin: req.IsAdmin,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	changes := map[string]any{}
+	if updated.Email != before.Email {
+		changes["email"] = map[string]any{"from": before.Email, "to": updated.Email}
+	}
+	if updated.IsAdmin != before.IsAdmin {
+		changes["is_admin"] = map[string]any{"from": before.IsAdmin, "to": updated.IsAdmin}
+	}
+	if len(changes) > 0 {
+		recordAuditEvent(c, h.auditEvents, models.AuditActionUserUpdate, nil, map[string]any{
+			"user_id":  updated.ID.String(),
+			"username": updated.Username,
+			"changes":  changes,
+		})
+	}
+
+	resp, err := h.userResponse(c, updated)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DeleteUser handles DELETE /api/v1/admin/users/:username. Users owning
+// repositories are only deleted with ?force=true, which deletes the
+// repositories too.
+func (h *UserAdminHandler) DeleteUser(c *gin.Context) {
+	force := false
+	if value := c.Query("force"); value != "" {
+		var err error
+		if force, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "bad_request",
+				"message": "force must be true or false",
+			})
+			return
+		}
+	}
+
+	user, err := h.userAdmin.GetUser(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	deletion, err := h.userAdmin.DeleteUser(c.Request.Context(), user, force)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	names := make([]string, 0, len(deletion.Repositories))
+	for _, repo := range deletion.Repositories {
+		recordAuditEvent(c, h.auditEvents, models.AuditActionRepoDelete, repo, map[string]any{
+			"reason": "owner deleted",
+		})
+		names = append(names, repo.Name)
+	}
+	recordAuditEvent(c, h.auditEvents, models.AuditActionUserDelete, nil, map[string]any{
+		"user_id":      user.ID.String(),
+		"username":     user.Username,
+		"email":        user.Email,
+		"repositories": names,
+	})
+
+	c.JSON(http.StatusOK, dto.DeleteAdminUserResponse{
+		Message:             "User deleted successfully",
+		DeletedRepositories: names,
+	})
+}
+
+// userResponse returns the response of a single user, with the number of
+// repositories they own
+func (h *UserAdminHandler) userResponse(c *gin.Context, user *models.User) (dto.AdminUserResponse, error) {
+	resp := dto.AdminUserFromModel(user)
+	count, err := h.userAdmin.CountRepositories(c.Request.Context(), user)
+	if err != nil {
+		return resp, err
+	}
+	resp.Repositories = &count
+	return resp, nil
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *UserAdminHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "User not found",
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if apperrors.IsConflict(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "conflict",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("User administration request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// UserExportHandler handles user data export HTTP requests
+type UserExportHandler struct {
+	userService *service.UserService
+	exports     *service.UserExportService
+	log         *logger.Logger
+}
+
+// NewUserExportHandler creates a new UserExportHandler instance
+func NewUserExportHandler(userService *service.UserService, exports *service.UserExportService) *UserExportHandler {
+	return &UserExportHandler{
+		userService: userService,
+		exports:     exports,
+		log:         logger.Get().WithFields(logger.Component("user-export-handler")),
+	}
+}
+
+// RequestExport handles POST /api/v1/users/export
+func (h *UserExportHandler) RequestExport(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	var req dto.RequestUserExportRequest
+	if !h.bindOptionalJSON(c, &req) {
+		return
+	}
+
+	export, err := h.exports.RequestExport(c.Request.Context(), user, nil, req.IncludeRepositories)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.UserExportFromModel(export, ""))
+}
+
+// RequestExportForUser handles POST /api/v1/admin/users/:username/export
+func (h *UserExportHandler) RequestExportForUser(c *gin.Context) {
+	admin := middleware.GetUserFromContext(c)
+
+	var req dto.RequestUserExportRequest
+	if !h.bindOptionalJSON(c, &req) {
+		return
+	}
+
+	user, err := h.userService.GetUserByUsername(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		if apperrors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "User not found",
+			})
+			return
+		}
+		h.handleError(c, err)
+		return
+	}
+
+	export, err := h.exports.RequestExport(c.Request.Context(), user, admin, req.IncludeRepositories)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.UserExportFromModel(export, ""))
+}
+
+// ListExports handles GET /api/v1/users/exports
+func (h *UserExportHandler) ListExports(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	exports, err := h.exports.ListExports(c.Request.Context(), user.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	responses := make([]dto.UserExportResponse, 0, len(exports))
+	for _, export := range exports {
+		responses = append(responses, dto.UserExportFromModel(export, h.exports.DownloadURL(export)))
+	}
+
+	c.JSON(http.StatusOK, dto.ListUserExportsResponse{Exports: responses})
+}
+
+// GetExport handles GET /api/v1/users/exports/:id
+func (h *UserExportHandler) GetExport(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Export not found",
+		})
+		return
+	}
+
+	export, err := h.exports.GetExport(c.Request.Context(), user.ID, id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.UserExportFromModel(export, h.exports.DownloadURL(export)))
+}
+
+// DownloadExport handles GET /api/v1/users/exports/:id/download. The link is
+// authorized by its signature rather than by a session, so it can be followed
+// from a notification.
+func (h *UserExportHandler) DownloadExport(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Export not found",
+		})
+		return
+	}
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Invalid download link",
+		})
+		return
+	}
+
+	export, file, err := h.exports.OpenDownload(c.Request.Context(), id, expires, c.Query("signature"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, export.Size, "application/zip", file, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="export-%s.zip"`, export.ID),
+		"Cache-Control":       "no-store",
+	})
+}
+
+// bindOptionalJSON binds the JSON body of a request, if it has one
+func (h *UserExportHandler) bindOptionalJSON(c *gin.Context, obj any) bool {
+	if err := c.ShouldBindJSON(obj); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *UserExportHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if errors.Is(err, apperrors.ErrExportRateLimited) {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   "rate_limited",
+			"message": "A data export can be requested once per day",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Export not found",
+		})
+		return
+	}
+
+	if apperrors.IsForbidden(err) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("User export request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
@@ -9,17 +9,20 @@ import (
 	"github.com/bravo68web/stasis/internal/application/service"
 	"github.com/bravo68web/stasis/internal/transport/http/middleware"
 	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/i18n"
 )
 
 // UserHandler handles user HTTP requests
 type UserHandler struct {
-	userService *service.UserService
+	userService         *service.UserService
+	contributionService *service.ContributionService
 }
 
 // NewUserHandler creates a new UserHandler instance
-func NewUserHandler(userService *service.UserService) *UserHandler {
+func NewUserHandler(userService *service.UserService, contributionService *service.ContributionService) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:         userService,
+		contributionService: contributionService,
 	}
 }
 
@@ -56,8 +59,88 @@ func (h *UserHandler) UpdateCurrentUsername(c *gin.Context) {
 	})
 }
 
+// GetSettings handles GET /api/v1/users/settings
+func (h *UserHandler) GetSettings(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.UserSettingsResponse{
+		ShowPrivateContributions: user.ShowPrivateContributions,
+		Locale:                   user.Locale,
+		AvailableLocales:         i18n.Default().Locales(),
+	})
+}
+
+// UpdateSettings handles PUT /api/v1/users/settings
+func (h *UserHandler) UpdateSettings(c *gin.Context) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return
+	}
+
+	var req dto.UpdateUserSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid request body",
+		})
+		return
+	}
+
+	updated, err := h.userService.UpdateUser(c.Request.Context(), user.ID, service.UpdateUserRequest{
+		ShowPrivateContributions: req.ShowPrivateContributions,
+		Locale:                   req.Locale,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.UserSettingsResponse{
+		ShowPrivateContributions: updated.ShowPrivateContributions,
+		Locale:                   updated.Locale,
+		AvailableLocales:         i18n.Default().Locales(),
+	})
+}
+
+// GetContributions handles GET /api/v1/users/:username/contributions
+func (h *UserHandler) GetContributions(c *gin.Context) {
+	profile, err := h.userService.GetUserByUsername(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	viewer := middleware.GetUserFromContext(c)
+	resp, err := h.contributionService.GetContributions(c.Request.Context(), profile, viewer, c.Query("from"), c.Query("to"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 // handleError handles errors and returns appropriate HTTP responses
 func (h *UserHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
 	if apperrors.IsNotFound(err) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// WebhookHandler handles webhook HTTP requests
+type WebhookHandler struct {
+	repoService *service.RepoService
+	webhooks    *service.WebhookService
+	log         *logger.Logger
+}
+
+// NewWebhookHandler creates a new WebhookHandler instance
+func NewWebhookHandler(
+	repoService *service.RepoService,
+	webhooks *service.WebhookService,
+) *WebhookHandler {
+	return &WebhookHandler{
+		repoService: repoService,
+		webhooks:    webhooks,
+		log:         logger.Get().WithFields(logger.Component("webhook-handler")),
+	}
+}
+
+// ListHooks handles GET /api/v1/repos/:owner/:repo/hooks
+func (h *WebhookHandler) ListHooks(c *gin.Context) {
+	repo, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	hooks, err := h.webhooks.ListHooks(c.Request.Context(), repo)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.WebhookListFromModels(hooks))
+}
+
+// GetHook handles GET /api/v1/repos/:owner/:repo/hooks/:id
+func (h *WebhookHandler) GetHook(c *gin.Context) {
+	repo, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	id, ok := h.hookID(c)
+	if !ok {
+		return
+	}
+
+	hook, err := h.webhooks.GetHook(c.Request.Context(), repo, id)
+	if err != nil {
+		h.handleHookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.WebhookFromModel(hook))
+}
+
+// CreateHook handles POST /api/v1/repos/:owner/:repo/hooks
+func (h *WebhookHandler) CreateHook(c *gin.Context) {
+	repo, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	var req dto.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	hook := req.ToModel()
+	if err := h.webhooks.CreateHook(c.Request.Context(), repo, hook); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.WebhookFromModel(hook))
+}
+
+// UpdateHook handles PUT /api/v1/repos/:owner/:repo/hooks/:id
+func (h *WebhookHandler) UpdateHook(c *gin.Context) {
+	repo, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	id, ok := h.hookID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	hook, err := h.webhooks.UpdateHook(c.Request.Context(), repo, id, req.ToModel())
+	if err != nil {
+		h.handleHookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.WebhookFromModel(hook))
+}
+
+// DeleteHook handles DELETE /api/v1/repos/:owner/:repo/hooks/:id
+func (h *WebhookHandler) DeleteHook(c *gin.Context) {
+	repo, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	id, ok := h.hookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhooks.DeleteHook(c.Request.Context(), repo, id); err != nil {
+		h.handleHookError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /api/v1/repos/:owner/:repo/hooks/:id/deliveries
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	repo, ok := h.getAdministeredRepository(c)
+	if !ok {
+		return
+	}
+
+	id, ok := h.hookID(c)
+	if !ok {
+		return
+	}
+
+	page, ok := pagination.FromRequest(c, pagination.Resources)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.webhooks.ListDeliveries(c.Request.Context(), repo, id, page.Probe(), page.Offset)
+	if err != nil {
+		h.handleHookError(c, err)
+		return
+	}
+	deliveries, info := pagination.Trim(page, deliveries)
+
+	responses := make([]dto.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		responses = append(responses, dto.WebhookDeliveryFromModel(delivery))
+	}
+
+	c.JSON(http.StatusOK, dto.ListWebhookDeliveriesResponse{
+		Deliveries: responses,
+		Pagination: info,
+	})
+}
+
+// hookID parses the webhook ID from the path.
+// It writes the error response and returns false if the ID is invalid.
+func (h *WebhookHandler) hookID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid webhook ID",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// getAdministeredRepository loads the repository from the path and checks that
+// the authenticated user administers it (owner, site admin or admin collaborator).
+// It writes the error response and returns false if the request cannot proceed.
+func (h *WebhookHandler) getAdministeredRepository(c *gin.Context) (*models.Repository, bool) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthorized",
+			"message": "Authentication required",
+		})
+		return nil, false
+	}
+
+	repo, err := h.repoService.GetRepository(c.Request.Context(), c.Param("owner"), c.Param("repo"))
+	if err != nil {
+		h.handleError(c, err)
+		return nil, false
+	}
+
+	permission := h.repoService.RepositoryPermission(c.Request.Context(), user, repo)
+	if !permission.Allows(models.RepoPermissionAdmin) {
+		// Do not reveal private repositories to users who cannot read them
+		if !permission.Allows(models.RepoPermissionRead) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Repository not found",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Only repository administrators can manage webhooks",
+		})
+		return nil, false
+	}
+
+	return repo, true
+}
+
+// handleHookError handles errors of requests on a single webhook
+func (h *WebhookHandler) handleHookError(c *gin.Context, err error) {
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Webhook not found",
+		})
+		return
+	}
+	h.handleError(c, err)
+}
+
+// handleError handles errors and returns appropriate HTTP responses
+func (h *WebhookHandler) handleError(c *gin.Context, err error) {
+	if apperrors.IsTimeout(err) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout",
+			"message": "The request timed out",
+		})
+		return
+	}
+
+	if apperrors.IsNotFound(err) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Repository not found",
+		})
+		return
+	}
+
+	if apperrors.IsBadRequest(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).Error("Webhook request failed", logger.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "internal_error",
+		"message": "An internal error occurred",
+	})
+}
@@ -25,6 +25,14 @@ const (
 
 	// IsAuthenticatedKey is the key for storing authentication status
 	IsAuthenticatedKey ContextKey = "is_authenticated"
+
+	// CIJobTokenContextKey is the key for storing the CI job token a git
+	// request authenticated with
+	CIJobTokenContextKey ContextKey = "ci_job_token"
+
+	// resolvedUserKey caches the result of authenticating a request (nil for
+	// anonymous requests), so the credentials are checked once per request
+	resolvedUserKey ContextKey = "resolved_user"
 )
 
 // AuthMiddleware handles authentication for HTTP requests
@@ -44,10 +52,27 @@ func NewAuthMiddleware(authService service.AuthService) *AuthMiddleware {
 // Authenticate attempts to authenticate the request but doesn't require it
 // This is useful for endpoints that work differently for authenticated vs anonymous users
 func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
+	return m.authenticate(false)
+}
+
+// AuthenticateGit is Authenticate for the git HTTP routes. CI job tokens are
+// accepted on the upload-pack routes, where the handler checks they are for
+// the repository of their job, and rejected on all others.
+func (m *AuthMiddleware) AuthenticateGit() gin.HandlerFunc {
+	return m.authenticate(true)
+}
+
+// authenticate returns the optional authentication middleware, accepting CI
+// job tokens on the upload-pack routes if gitRoutes is set
+func (m *AuthMiddleware) authenticate(gitRoutes bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if !(gitRoutes && isUploadPackRequest(c)) && rejectCIJobToken(c) {
+			return
+		}
+
 		user := m.extractAndValidateUser(c)
 		if user != nil {
-			m.log.Debug("User authenticated (optional auth)",
+			m.log.WithContext(c.Request.Context()).Debug("User authenticated (optional auth)",
 				logger.String("user_id", user.ID.String()),
 				logger.String("username", user.Username),
 				logger.Path(c.Request.URL.Path),
@@ -61,9 +86,13 @@ func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 // RequireAuth requires authentication for the endpoint
 func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if rejectCIJobToken(c) {
+			return
+		}
+
 		user := m.extractAndValidateUser(c)
 		if user == nil {
-			m.log.Warn("Authentication required but not provided",
+			m.log.WithContext(c.Request.Context()).Warn("Authentication required but not provided",
 				logger.Path(c.Request.URL.Path),
 				logger.Method(c.Request.Method),
 				logger.ClientIP(c.ClientIP()),
@@ -75,7 +104,7 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
-		m.log.Debug("User authenticated successfully",
+		m.log.WithContext(c.Request.Context()).Debug("User authenticated successfully",
 			logger.String("user_id", user.ID.String()),
 			logger.String("username", user.Username),
 			logger.Path(c.Request.URL.Path),
@@ -88,9 +117,13 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 // RequireAdmin requires admin privileges
 func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if rejectCIJobToken(c) {
+			return
+		}
+
 		user := m.extractAndValidateUser(c)
 		if user == nil {
-			m.log.Warn("Admin access attempted without authentication",
+			m.log.WithContext(c.Request.Context()).Warn("Admin access attempted without authentication",
 				logger.Path(c.Request.URL.Path),
 				logger.Method(c.Request.Method),
 				logger.ClientIP(c.ClientIP()),
@@ -103,7 +136,7 @@ func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
 		}
 
 		if !user.IsAdmin {
-			m.log.Warn("Non-admin user attempted to access admin endpoint",
+			m.log.WithContext(c.Request.Context()).Warn("Non-admin user attempted to access admin endpoint",
 				logger.String("user_id", user.ID.String()),
 				logger.String("username", user.Username),
 				logger.Path(c.Request.URL.Path),
@@ -116,7 +149,16 @@ func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
 			return
 		}
 
-		m.log.Debug("Admin user authenticated",
+		if !user.TokenHasScope(models.TokenScopeAdmin) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "forbidden",
+				"message": "The token does not have the required scope",
+				"details": models.TokenScopeAdmin,
+			})
+			return
+		}
+
+		m.log.WithContext(c.Request.Context()).Debug("Admin user authenticated",
 			logger.String("user_id", user.ID.String()),
 			logger.String("username", user.Username),
 			logger.Path(c.Request.URL.Path),
@@ -126,18 +168,94 @@ func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
 	}
 }
 
-// extractAndValidateUser extracts and validates the user from the request
+// RequireScope requires a session or a token with the scope. It follows
+// RequireAuth on routes outside of repositories, whose permissions the
+// repository service checks against the token instead: account routes require
+// the admin scope, so a token cannot add SSH keys, mint tokens or rename its
+// user to gain more than it was granted.
+func (m *AuthMiddleware) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := GetUserFromContext(c)
+		if user == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":   "unauthorized",
+				"message": "authentication required",
+			})
+			return
+		}
+
+		if !user.TokenHasScope(scope) {
+			m.log.WithContext(c.Request.Context()).Warn("Token without the required scope rejected",
+				logger.String("user_id", user.ID.String()),
+				logger.String("scope", scope),
+				logger.Path(c.Request.URL.Path),
+				logger.Method(c.Request.Method),
+			)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "forbidden",
+				"message": "The token does not have the required scope",
+				"details": scope,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimitKey identifies the client of a request for rate limiting: the user
+// its credentials authenticate, or else the client IP. The credentials are
+// checked here, ahead of the route's authentication, which reuses the result
+// cached on the request. Requests with invalid credentials share the budget
+// of their IP.
+func (m *AuthMiddleware) RateLimitKey(c *gin.Context) string {
+	if user := m.extractAndValidateUser(c); user != nil {
+		return "user:" + user.ID.String()
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// extractAndValidateUser returns the user the request authenticates as, or nil.
+// The result is cached on the request. Requests served in degraded mode are
+// anonymous, the credentials cannot be checked without the database.
+func (m *AuthMiddleware) extractAndValidateUser(c *gin.Context) *models.User {
+	if IsDegraded(c) {
+		return nil
+	}
+	if cached, ok := c.Get(string(resolvedUserKey)); ok {
+		user, _ := cached.(*models.User)
+		return user
+	}
+
+	user := m.authenticateRequest(c)
+	c.Set(string(resolvedUserKey), user)
+	return user
+}
+
+// authenticateRequest extracts and validates the user from the request
 // Supports:
 // - Bearer token (session JWT from OIDC or PAT)
+// - token scheme (Authorization: token <PAT>)
 // - Basic Auth (username:password where password is a PAT for git operations)
 // - Query parameter access_token (for git operations)
-func (m *AuthMiddleware) extractAndValidateUser(c *gin.Context) *models.User {
+func (m *AuthMiddleware) authenticateRequest(c *gin.Context) *models.User {
 	ctx := c.Request.Context()
+	origin := service.RequestOrigin{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+
+	// CI job tokens never authenticate a user; a valid one is kept for the
+	// git handler, which only lets it fetch the repository of its job
+	if token := ciJobCredential(c); token != "" {
+		jobToken, err := m.authService.AuthenticateCIJobToken(ctx, token)
+		if err == nil {
+			m.log.WithContext(c.Request.Context()).Debug("Request authenticated via CI job token",
+				logger.String("job_id", jobToken.JobID.String()),
+			)
+			c.Set(string(CIJobTokenContextKey), jobToken)
+		}
+		return nil
+	}
 
 	authHeader := c.GetHeader("Authorization")
 
-	println("authHeader", authHeader)
-
 	// Try Bearer token first (Authorization header)
 	if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
 		token := strings.TrimPrefix(authHeader, "Bearer ")
@@ -145,7 +263,7 @@ func (m *AuthMiddleware) extractAndValidateUser(c *gin.Context) *models.User {
 		// Try session token authentication first (OIDC JWT)
 		user, err := m.authService.AuthenticateSession(ctx, token)
 		if err == nil && user != nil {
-			m.log.Debug("User authenticated via session token",
+			m.log.WithContext(c.Request.Context()).Debug("User authenticated via session token",
 				logger.String("user_id", user.ID.String()),
 				logger.String("auth_method", "session_token"),
 			)
@@ -153,9 +271,9 @@ func (m *AuthMiddleware) extractAndValidateUser(c *gin.Context) *models.User {
 		}
 
 		// If session auth fails, try PAT (Personal Access Token) authentication
-		user, err = m.authService.AuthenticateToken(ctx, token)
+		user, err = m.authService.AuthenticateToken(ctx, token, origin)
 		if err == nil && user != nil {
-			m.log.Debug("User authenticated via PAT",
+			m.log.WithContext(c.Request.Context()).Debug("User authenticated via PAT",
 				logger.String("user_id", user.ID.String()),
 				logger.String("auth_method", "pat"),
 			)
@@ -163,12 +281,24 @@ func (m *AuthMiddleware) extractAndValidateUser(c *gin.Context) *models.User {
 		}
 	}
 
+	// Try the token scheme, which only carries PATs
+	if scheme, token, ok := strings.Cut(authHeader, " "); ok && strings.EqualFold(scheme, "token") {
+		user, err := m.authService.AuthenticateToken(ctx, strings.TrimSpace(token), origin)
+		if err == nil && user != nil {
+			m.log.WithContext(c.Request.Context()).Debug("User authenticated via PAT",
+				logger.String("user_id", user.ID.String()),
+				logger.String("auth_method", "token"),
+			)
+			return user
+		}
+	}
+
 	// Try Basic Auth (for Git HTTP protocol)
 	// Git sends credentials as Basic Auth with username and password/token
 	if authHeader != "" && strings.HasPrefix(authHeader, "Basic ") {
-		user := m.authenticateBasic(ctx, authHeader)
+		user := m.authenticateBasic(ctx, authHeader, origin)
 		if user != nil {
-			m.log.Debug("User authenticated via Basic Auth",
+			m.log.WithContext(c.Request.Context()).Debug("User authenticated via Basic Auth",
 				logger.String("user_id", user.ID.String()),
 				logger.String("auth_method", "basic_auth"),
 			)
@@ -181,7 +311,7 @@ func (m *AuthMiddleware) extractAndValidateUser(c *gin.Context) *models.User {
 		// Try session token authentication first
 		user, err := m.authService.AuthenticateSession(ctx, token)
 		if err == nil && user != nil {
-			m.log.Debug("User authenticated via query param session token",
+			m.log.WithContext(c.Request.Context()).Debug("User authenticated via query param session token",
 				logger.String("user_id", user.ID.String()),
 				logger.String("auth_method", "query_session_token"),
 			)
@@ -189,9 +319,9 @@ func (m *AuthMiddleware) extractAndValidateUser(c *gin.Context) *models.User {
 		}
 
 		// Try PAT authentication
-		user, err = m.authService.AuthenticateToken(ctx, token)
+		user, err = m.authService.AuthenticateToken(ctx, token, origin)
 		if err == nil && user != nil {
-			m.log.Debug("User authenticated via query param PAT",
+			m.log.WithContext(c.Request.Context()).Debug("User authenticated via query param PAT",
 				logger.String("user_id", user.ID.String()),
 				logger.String("auth_method", "query_pat"),
 			)
@@ -204,7 +334,7 @@ func (m *AuthMiddleware) extractAndValidateUser(c *gin.Context) *models.User {
 
 // authenticateBasic handles Basic authentication for Git HTTP protocol
 // The password field can be a Personal Access Token (PAT)
-func (m *AuthMiddleware) authenticateBasic(ctx context.Context, authHeader string) *models.User {
+func (m *AuthMiddleware) authenticateBasic(ctx context.Context, authHeader string, origin service.RequestOrigin) *models.User {
 	// Decode Basic auth header
 	encoded := strings.TrimPrefix(authHeader, "Basic ")
 	decoded, err := base64.StdEncoding.DecodeString(encoded)
@@ -222,9 +352,9 @@ func (m *AuthMiddleware) authenticateBasic(ctx context.Context, authHeader strin
 
 	// For Git operations, the "password" is typically a Personal Access Token
 	// Try authenticating the password as a PAT
-	user, err := m.authService.AuthenticateToken(ctx, password)
+	user, err := m.authService.AuthenticateToken(ctx, password, origin)
 	if err == nil && user != nil {
-		m.log.Debug("User authenticated via Basic Auth PAT",
+		m.log.WithContext(ctx).Debug("User authenticated via Basic Auth PAT",
 			logger.String("user_id", user.ID.String()),
 		)
 		return user
@@ -233,16 +363,67 @@ func (m *AuthMiddleware) authenticateBasic(ctx context.Context, authHeader strin
 	// Also try as a session token (for OIDC users)
 	user, err = m.authService.AuthenticateSession(ctx, password)
 	if err == nil && user != nil {
-		m.log.Debug("User authenticated via Basic Auth session token",
+		m.log.WithContext(ctx).Debug("User authenticated via Basic Auth session token",
 			logger.String("user_id", user.ID.String()),
 		)
 		return user
 	}
 
-	m.log.Debug("Basic auth failed - no valid credentials")
+	m.log.WithContext(ctx).Debug("Basic auth failed - no valid credentials")
 	return nil
 }
 
+// ciJobCredential returns the CI job token presented by a request, or "".
+// Git sends the credentials of a clone URL with Basic authentication.
+func ciJobCredential(c *gin.Context) string {
+	var presented []string
+	if scheme, value, ok := strings.Cut(c.GetHeader("Authorization"), " "); ok {
+		switch {
+		case strings.EqualFold(scheme, "Basic"):
+			if decoded, err := base64.StdEncoding.DecodeString(value); err == nil {
+				_, password, _ := strings.Cut(string(decoded), ":")
+				presented = append(presented, password)
+			}
+		case strings.EqualFold(scheme, "Bearer"), strings.EqualFold(scheme, "token"):
+			presented = append(presented, strings.TrimSpace(value))
+		}
+	}
+	presented = append(presented, c.Query("access_token"))
+
+	for _, token := range presented {
+		if strings.HasPrefix(token, models.CIJobTokenPrefix) {
+			return token
+		}
+	}
+	return ""
+}
+
+// isUploadPackRequest returns true for the requests of a fetch or clone over
+// the smart HTTP protocol
+func isUploadPackRequest(c *gin.Context) bool {
+	path := c.Request.URL.Path
+	switch {
+	case c.Request.Method == http.MethodGet && strings.HasSuffix(path, "/info/refs"):
+		return c.Query("service") == "git-upload-pack"
+	case c.Request.Method == http.MethodPost:
+		return strings.HasSuffix(path, "/git-upload-pack")
+	}
+	return false
+}
+
+// rejectCIJobToken answers 403 to a request presenting a CI job token where
+// it cannot be used and returns true if it did
+func rejectCIJobToken(c *gin.Context) bool {
+	if ciJobCredential(c) == "" {
+		return false
+	}
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error":   "forbidden",
+		"message": "CI job tokens can only be used to clone the repository of their job",
+	})
+	return true
+}
+
 // setUserContext sets the user in the gin context
 func (m *AuthMiddleware) setUserContext(c *gin.Context, user *models.User) {
 	c.Set(string(UserContextKey), user)
@@ -264,6 +445,17 @@ func GetUserFromContext(c *gin.Context) *models.User {
 	return nil
 }
 
+// GetCIJobTokenFromContext retrieves the CI job token a git request
+// authenticated with, or nil
+func GetCIJobTokenFromContext(c *gin.Context) *models.CIJobToken {
+	if token, exists := c.Get(string(CIJobTokenContextKey)); exists {
+		if t, ok := token.(*models.CIJobToken); ok {
+			return t
+		}
+	}
+	return nil
+}
+
 // IsAuthenticated checks if the request is authenticated
 func IsAuthenticated(c *gin.Context) bool {
 	if authenticated, exists := c.Get(string(IsAuthenticatedKey)); exists {
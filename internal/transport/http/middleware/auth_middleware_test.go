@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/domain/service"
+)
+
+// fakeAuthService authenticates the sessions and tokens it was given and
+// counts the credentials it checked
+type fakeAuthService struct {
+	sessions map[string]*models.User
+	tokens   map[string]*models.User
+	checks   int
+}
+
+func (f *fakeAuthService) AuthenticateToken(_ context.Context, token string, _ service.RequestOrigin) (*models.User, error) {
+	f.checks++
+	if user, ok := f.tokens[token]; ok {
+		return user, nil
+	}
+	return nil, errors.New("invalid token")
+}
+
+func (f *fakeAuthService) AuthenticateSSH(context.Context, []byte) (*models.User, error) {
+	return nil, errors.New("not supported")
+}
+
+func (f *fakeAuthService) AuthenticateSession(_ context.Context, token string) (*models.User, error) {
+	f.checks++
+	if user, ok := f.sessions[token]; ok {
+		return user, nil
+	}
+	return nil, errors.New("invalid session")
+}
+
+func (f *fakeAuthService) AuthenticateCIJobToken(context.Context, string) (*models.CIJobToken, error) {
+	return nil, errors.New("not supported")
+}
+
+// tokenUser returns a user authenticated by a token with scopes
+func tokenUser(admin bool, scopes ...string) *models.User {
+	return &models.User{
+		ID:       uuid.New(),
+		Username: "alice",
+		IsAdmin:  admin,
+		Token:    &models.Token{Scope: scopes},
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	auth := &fakeAuthService{
+		sessions: map[string]*models.User{"session": {ID: uuid.New(), Username: "alice"}},
+		tokens: map[string]*models.User{
+			"unscoped":   tokenUser(false),
+			"admin":      tokenUser(false, models.TokenScopeAdmin),
+			"repo-read":  tokenUser(false, models.TokenScopeRepoRead),
+			"repo-write": tokenUser(false, models.TokenScopeRepoWrite),
+			"repo-admin": tokenUser(false, models.TokenScopeRepoRead, models.TokenScopeRepoAdmin),
+		},
+	}
+	m := NewAuthMiddleware(auth)
+	engine := gin.New()
+	engine.POST("/api/v1/ssh-keys", m.RequireAuth(), m.RequireScope(models.TokenScopeAdmin), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"anonymous", "", http.StatusUnauthorized},
+		{"session", "Bearer session", http.StatusCreated},
+		{"token without scopes", "token unscoped", http.StatusForbidden},
+		{"admin token", "Bearer admin", http.StatusCreated},
+		{"repo:read token", "token repo-read", http.StatusForbidden},
+		{"repo:read token as bearer", "Bearer repo-read", http.StatusForbidden},
+		{"repo:write token", "token repo-write", http.StatusForbidden},
+		{"repo:admin token", "token repo-admin", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/ssh-keys", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireAdminChecksTokenScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	auth := &fakeAuthService{
+		sessions: map[string]*models.User{"session": {ID: uuid.New(), Username: "root", IsAdmin: true}},
+		tokens: map[string]*models.User{
+			"admin":     tokenUser(true, models.TokenScopeAdmin),
+			"repo-read": tokenUser(true, models.TokenScopeRepoRead),
+			"member":    tokenUser(false, models.TokenScopeAdmin),
+		},
+	}
+	m := NewAuthMiddleware(auth)
+	engine := gin.New()
+	engine.GET("/api/v1/admin/users", m.RequireAdmin(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"admin session", "Bearer session", http.StatusOK},
+		{"admin token with the admin scope", "token admin", http.StatusOK},
+		{"admin token with repo:read", "token repo-read", http.StatusForbidden},
+		{"member token with the admin scope", "token member", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users", nil)
+			req.Header.Set("Authorization", tt.authorization)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+// Users behind one IP get a budget each, anonymous requests and invalid
+// credentials share the budget of the IP, and the credentials are checked
+// once per request
+func TestRateLimitKeyPerUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	alice, bob := tokenUser(false, models.TokenScopeRepoRead), tokenUser(false, models.TokenScopeRepoRead)
+	auth := &fakeAuthService{tokens: map[string]*models.User{"alice": alice, "bob": bob}}
+	m := NewAuthMiddleware(auth)
+	engine := gin.New()
+	engine.Use(RateLimitMiddleware(NewRateLimiter(60, 2), m.RateLimitKey))
+	engine.GET("/key", m.Authenticate(), func(c *gin.Context) {
+		c.String(http.StatusOK, m.RateLimitKey(c))
+	})
+
+	get := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/key", nil)
+		req.RemoteAddr = "203.0.113.7:40000"
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	requests := []struct {
+		authorization string
+		wantStatus    int
+		wantKey       string
+	}{
+		{"token alice", http.StatusOK, "user:" + alice.ID.String()},
+		{"token alice", http.StatusOK, "user:" + alice.ID.String()},
+		{"token alice", http.StatusTooManyRequests, ""},
+		{"token bob", http.StatusOK, "user:" + bob.ID.String()},
+		{"", http.StatusOK, "ip:203.0.113.7"},
+		{"token invalid", http.StatusOK, "ip:203.0.113.7"},
+		{"token other-invalid", http.StatusTooManyRequests, ""},
+		{"token bob", http.StatusOK, "user:" + bob.ID.String()},
+	}
+	for i, r := range requests {
+		checks := auth.checks
+		w := get(r.authorization)
+		if w.Code != r.wantStatus {
+			t.Fatalf("request %d (%s): status = %d, want %d", i, r.authorization, w.Code, r.wantStatus)
+		}
+		if w.Code == http.StatusOK && w.Body.String() != r.wantKey {
+			t.Errorf("request %d (%s): key = %q, want %q", i, r.authorization, w.Body.String(), r.wantKey)
+		}
+		if r.authorization != "" && auth.checks != checks+1 {
+			t.Errorf("request %d (%s): checked %d credentials, want 1", i, r.authorization, auth.checks-checks)
+		}
+	}
+}
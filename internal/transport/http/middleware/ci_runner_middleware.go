@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// CICallbackJobContextKey is the key for storing the ID of the job whose
+// callback token authenticated a CI runner callback
+const CICallbackJobContextKey ContextKey = "ci_callback_job"
+
+// CICallbackAuthenticator checks the callback tokens of CI jobs, see
+// service.CIJobTokenService
+type CICallbackAuthenticator interface {
+	AuthenticateCallback(ctx context.Context, token string) (*models.CIJobToken, error)
+}
+
+// CIRunnerMiddleware authenticates the callbacks of the CI runner, with
+// either the callback token of the job (Authorization: Bearer Sc...) or the
+// configured CI API key (X-API-Key), which allows reporting any job
+type CIRunnerMiddleware struct {
+	tokens CICallbackAuthenticator
+	apiKey string
+	log    *logger.Logger
+}
+
+// NewCIRunnerMiddleware creates a new CIRunnerMiddleware instance. An empty
+// apiKey only accepts callback tokens.
+func NewCIRunnerMiddleware(tokens CICallbackAuthenticator, apiKey string) *CIRunnerMiddleware {
+	return &CIRunnerMiddleware{
+		tokens: tokens,
+		apiKey: apiKey,
+		log:    logger.Get().WithFields(logger.Component("ci-runner-middleware")),
+	}
+}
+
+// RequireRunner requires the CI API key or a callback token. A callback
+// token is only accepted for its own job: on routes with a :job_id, the
+// middleware checks it; other handlers check it with CICallbackAllows.
+func (m *CIRunnerMiddleware) RequireRunner() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m.apiKey != "" {
+			if key := c.GetHeader("X-API-Key"); key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(m.apiKey)) == 1 {
+				c.Next()
+				return
+			}
+		}
+
+		scheme, token, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		token = strings.TrimSpace(token)
+		if !strings.EqualFold(scheme, "Bearer") || !strings.HasPrefix(token, models.CICallbackTokenPrefix) {
+			RejectCICallback(c, m.log, "missing credentials")
+			return
+		}
+
+		jobToken, err := m.tokens.AuthenticateCallback(c.Request.Context(), token)
+		if err != nil {
+			RejectCICallback(c, m.log, err.Error())
+			return
+		}
+
+		c.Set(string(CICallbackJobContextKey), jobToken.JobID)
+		if jobID, err := uuid.Parse(c.Param("job_id")); err == nil && !CICallbackAllows(c, jobID) {
+			RejectCICallback(c, m.log, "callback token of another job")
+			return
+		}
+		c.Next()
+	}
+}
+
+// CICallbackAllows returns true if the credentials of a CI runner callback
+// allow reporting a job: the CI API key allows any job, a callback token
+// only its own
+func CICallbackAllows(c *gin.Context, jobID uuid.UUID) bool {
+	value, exists := c.Get(string(CICallbackJobContextKey))
+	if !exists {
+		return true
+	}
+	tokenJobID, ok := value.(uuid.UUID)
+	return ok && tokenJobID == jobID
+}
+
+// RejectCICallback answers 401 to a CI runner callback and logs it with the
+// reason and the source of the request
+func RejectCICallback(c *gin.Context, log *logger.Logger, reason string) {
+	log.Warn("Rejected unauthenticated CI runner callback",
+		logger.String("reason", reason),
+		logger.Path(c.Request.URL.Path),
+		logger.Method(c.Request.Method),
+		logger.ClientIP(c.ClientIP()),
+	)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error":   "unauthorized",
+		"message": "the CI API key or the callback token of the job is required",
+	})
+}
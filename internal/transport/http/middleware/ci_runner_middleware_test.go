@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+)
+
+// fakeCallbackAuthenticator authenticates the callback tokens it was given;
+// the others are invalid or expired
+type fakeCallbackAuthenticator struct {
+	tokens map[string]*models.CIJobToken
+}
+
+func (f *fakeCallbackAuthenticator) AuthenticateCallback(_ context.Context, token string) (*models.CIJobToken, error) {
+	if jobToken, ok := f.tokens[token]; ok {
+		return jobToken, nil
+	}
+	return nil, errors.New("CI callback token has expired")
+}
+
+func TestRequireRunner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	job, otherJob := uuid.New(), uuid.New()
+	tokens := &fakeCallbackAuthenticator{tokens: map[string]*models.CIJobToken{
+		"Sc-job":   {JobID: job},
+		"Sc-other": {JobID: otherJob},
+	}}
+	newEngine := func(apiKey string) *gin.Engine {
+		m := NewCIRunnerMiddleware(tokens, apiKey)
+		engine := gin.New()
+		engine.POST("/api/v1/ci/jobs/:job_id/logs", m.RequireRunner(), func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+		// The job of a job update is in its body
+		engine.POST("/api/v1/ci/webhook", m.RequireRunner(), func(c *gin.Context) {
+			if !CICallbackAllows(c, uuid.MustParse(c.Query("job_id"))) {
+				RejectCICallback(c, m.log, "callback token of another job")
+				return
+			}
+			c.Status(http.StatusNoContent)
+		})
+		return engine
+	}
+	withKey, withoutKey := newEngine("runner-key"), newEngine("")
+
+	tests := []struct {
+		name          string
+		engine        *gin.Engine
+		path          string
+		apiKey        string
+		authorization string
+		want          int
+	}{
+		{name: "no credentials", engine: withKey, path: "/api/v1/ci/jobs/" + job.String() + "/logs", want: http.StatusUnauthorized},
+		{name: "runner key", engine: withKey, path: "/api/v1/ci/jobs/" + job.String() + "/logs", apiKey: "runner-key", want: http.StatusNoContent},
+		{name: "runner key for any job", engine: withKey, path: "/api/v1/ci/webhook?job_id=" + otherJob.String(), apiKey: "runner-key", want: http.StatusNoContent},
+		{name: "wrong runner key", engine: withKey, path: "/api/v1/ci/jobs/" + job.String() + "/logs", apiKey: "runner-key2", want: http.StatusUnauthorized},
+		{name: "runner key not configured", engine: withoutKey, path: "/api/v1/ci/jobs/" + job.String() + "/logs", apiKey: "runner-key", want: http.StatusUnauthorized},
+		{name: "wrong runner key with a callback token", engine: withKey, path: "/api/v1/ci/jobs/" + job.String() + "/logs", apiKey: "wrong", authorization: "Bearer Sc-job", want: http.StatusNoContent},
+		{name: "callback token", engine: withoutKey, path: "/api/v1/ci/jobs/" + job.String() + "/logs", authorization: "Bearer Sc-job", want: http.StatusNoContent},
+		{name: "callback token in lower case scheme", engine: withoutKey, path: "/api/v1/ci/jobs/" + job.String() + "/logs", authorization: "bearer Sc-job", want: http.StatusNoContent},
+		{name: "callback token of another job", engine: withoutKey, path: "/api/v1/ci/jobs/" + otherJob.String() + "/logs", authorization: "Bearer Sc-job", want: http.StatusUnauthorized},
+		{name: "callback token in the body", engine: withoutKey, path: "/api/v1/ci/webhook?job_id=" + job.String(), authorization: "Bearer Sc-job", want: http.StatusNoContent},
+		{name: "callback token of another job in the body", engine: withoutKey, path: "/api/v1/ci/webhook?job_id=" + job.String(), authorization: "Bearer Sc-other", want: http.StatusUnauthorized},
+		{name: "expired callback token", engine: withoutKey, path: "/api/v1/ci/jobs/" + job.String() + "/logs", authorization: "Bearer Sc-expired", want: http.StatusUnauthorized},
+		{name: "job token", engine: withoutKey, path: "/api/v1/ci/jobs/" + job.String() + "/logs", authorization: "Bearer Sj-job", want: http.StatusUnauthorized},
+		{name: "callback token with the token scheme", engine: withoutKey, path: "/api/v1/ci/jobs/" + job.String() + "/logs", authorization: "token Sc-job", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			tt.engine.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
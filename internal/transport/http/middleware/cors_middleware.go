@@ -1,35 +1,69 @@
 package middleware
 
 import (
+	"strings"
+	"time"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/config"
 )
 
-func CORSMiddleware(allowedOrigins []string) gin.HandlerFunc {
-	// Apply CORS middleware
-	return cors.New(cors.Config{
-		AllowOrigins: allowedOrigins,
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS", "HEAD"},
-		AllowHeaders: []string{
-			"Origin",
-			"Content-Length",
-			"Content-Type",
-			"Authorization",
-			"Accept",
-			"Accept-Encoding",
-			"Accept-Language",
-			"Cache-Control",
-			"Cookie",
-			"X-Requested-With",
-			"X-Auth-Token",
-		},
+// CORSMiddleware applies the CORS policy of cfg to the REST API (/api) and
+// leaves other routes, the git smart HTTP endpoints among them, alone.
+// extraOrigins are allowed on top of the configured origins. Preflight
+// requests are answered here, before any authentication.
+func CORSMiddleware(cfg *config.CORSConfig, extraOrigins ...string) gin.HandlerFunc {
+	corsCfg := cors.Config{
+		AllowMethods:     cfg.AllowedMethods,
+		AllowHeaders:     cfg.AllowedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		AllowWildcard:    true,
 		ExposeHeaders: []string{
+			"Content-Language",
 			"Content-Length",
 			"Content-Type",
 			"Set-Cookie",
 			"Authorization",
+			"Retry-After",
+			"X-RateLimit-Remaining",
+			"X-Githut-Api-Version",
+			"X-Request-ID",
 		},
-		AllowCredentials: true,
-		MaxAge:           12 * 60 * 60, // 12 hours preflight cache
-	})
+		MaxAge: time.Duration(cfg.MaxAgeSeconds) * time.Second,
+	}
+	if cfg.AllowsAllOrigins() {
+		corsCfg.AllowAllOrigins = true
+	} else {
+		corsCfg.AllowOrigins = append(append([]string{}, cfg.AllowedOrigins...), extraOrigins...)
+	}
+
+	apply := cors.New(corsCfg)
+	return func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			apply(c)
+		}
+	}
+}
+
+// SecurityHeadersMiddleware sets the configured security headers on every
+// response
+func SecurityHeadersMiddleware(cfg *config.SecurityHeadersConfig) gin.HandlerFunc {
+	headers := map[string]string{
+		"X-Content-Type-Options": cfg.ContentTypeOptions,
+		"X-Frame-Options":        cfg.FrameOptions,
+		"Referrer-Policy":        cfg.ReferrerPolicy,
+	}
+	for name, value := range headers {
+		if value == "" {
+			delete(headers, name)
+		}
+	}
+
+	return func(c *gin.Context) {
+		for name, value := range headers {
+			c.Header(name, value)
+		}
+	}
 }
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DegradedContextKey is the key marking a git request served from the snapshot
+// of degraded mode. Such requests are anonymous: credentials cannot be
+// checked without the database.
+const DegradedContextKey ContextKey = "degraded"
+
+// degradedRetryAfter is the Retry-After, in seconds, of requests refused in
+// degraded mode
+const degradedRetryAfter = 30
+
+// DegradedMode is the state of degraded mode, see
+// service.DegradedModeService
+type DegradedMode interface {
+	Active() bool
+	RecordRejection()
+}
+
+// DegradedModeMiddleware lets only anonymous fetches through while mode is
+// active. Fetches on the git routes, under gitPrefix, are marked with
+// DegradedContextKey for the git handler to serve from the snapshot; pushes
+// and LFS requests get 503 Service Unavailable, and so does the REST API,
+// with "degraded": true. Other routes (docs, health) are left alone.
+func DegradedModeMiddleware(mode DegradedMode, gitPrefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mode.Active() {
+			return
+		}
+
+		switch {
+		case strings.HasPrefix(c.Request.URL.Path, "/api/"):
+			mode.RecordRejection()
+			c.Header("Retry-After", strconv.Itoa(degradedRetryAfter))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error":    "service_unavailable",
+				"message":  "The database is unavailable, only public repositories can be fetched",
+				"degraded": true,
+			})
+		case strings.HasPrefix(c.FullPath(), gitPrefix):
+			if isFetchRequest(c) {
+				c.Set(string(DegradedContextKey), true)
+				return
+			}
+			mode.RecordRejection()
+			c.Header("Retry-After", strconv.Itoa(degradedRetryAfter))
+			c.Abort()
+			c.Data(http.StatusServiceUnavailable, "text/plain; charset=utf-8",
+				[]byte("the database is unavailable, repositories are read-only\n"))
+		}
+	}
+}
+
+// IsDegraded returns true if the request is served from the snapshot of
+// degraded mode
+func IsDegraded(c *gin.Context) bool {
+	return c.GetBool(string(DegradedContextKey))
+}
+
+// isFetchRequest returns true for the git requests reading a repository: the
+// upload-pack requests of the smart HTTP protocol and the GET requests of the
+// dumb one. LFS downloads are not, as LFS objects are tracked in the database.
+func isFetchRequest(c *gin.Context) bool {
+	if isUploadPackRequest(c) {
+		return true
+	}
+	if c.Request.Method != http.MethodGet || strings.Contains(c.FullPath(), "/info/lfs/") {
+		return false
+	}
+	return !strings.HasSuffix(c.Request.URL.Path, "/info/refs") || c.Query("service") == ""
+}
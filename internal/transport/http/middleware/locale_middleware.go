@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/pkg/i18n"
+)
+
+// LocaleMiddleware translates the message field of JSON error responses
+// ({"error": "<code>", "message": "<text>"}). The locale is the authenticated
+// user's preference if set, otherwise the best match for Accept-Language.
+// The error code and all other fields are left untouched, as are successful
+// and non-JSON responses, so git protocol output stays English.
+func LocaleMiddleware(bundle *i18n.Bundle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Language")
+
+		w := &localizedWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+
+		c.Next()
+
+		if !w.buffering {
+			return
+		}
+
+		body := w.buf.Bytes()
+		locale := requestLocale(c, bundle)
+		if locale != i18n.DefaultLocale {
+			if translated, ok := translateErrorBody(bundle, locale, body); ok {
+				body = translated
+				w.Header().Set("Content-Language", locale)
+			}
+		}
+		_, _ = w.ResponseWriter.Write(body)
+	}
+}
+
+// requestLocale returns the locale for the response of a request
+func requestLocale(c *gin.Context, bundle *i18n.Bundle) string {
+	if user := GetUserFromContext(c); user != nil && user.Locale != "" && bundle.Supports(user.Locale) {
+		return strings.ToLower(user.Locale)
+	}
+	return bundle.Match(c.GetHeader("Accept-Language"))
+}
+
+// translateErrorBody translates the message of a JSON error body. It returns
+// false if the body is not an error object or nothing was translated.
+func translateErrorBody(bundle *i18n.Bundle, locale string, body []byte) ([]byte, bool) {
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, false
+	}
+
+	message, ok := payload["message"].(string)
+	if !ok {
+		return nil, false
+	}
+	translated := bundle.Translate(locale, message)
+	if translated == message {
+		return nil, false
+	}
+	payload["message"] = translated
+
+	out, err := json.Marshal(payload)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// localizedWriter holds back JSON error bodies so their message can be
+// translated once the handler (and authentication) has run
+type localizedWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	decided   bool
+	buffering bool
+}
+
+// Write buffers JSON error bodies and passes everything else through
+func (w *localizedWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		w.buffering = w.Status() >= http.StatusBadRequest &&
+			!w.Written() &&
+			strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	}
+	if w.buffering {
+		return w.buf.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString buffers like Write
+func (w *localizedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
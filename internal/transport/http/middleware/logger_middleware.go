@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/bravo68web/stasis/pkg/logger"
@@ -133,10 +134,13 @@ func LoggerMiddlewareWithConfig(cfg *LoggerConfig) gin.HandlerFunc {
 		// Start timer
 		start := time.Now()
 
-		// Extract request ID
-		requestID := c.GetHeader(cfg.RequestIDHeader)
+		// Use the request ID assigned by RequestIDMiddleware, if it runs
+		requestID := GetRequestID(c)
 		if requestID == "" {
-			requestID = generateRequestID()
+			requestID = c.GetHeader(cfg.RequestIDHeader)
+		}
+		if requestID == "" {
+			requestID = uuid.NewString()
 			c.Header(cfg.RequestIDHeader, requestID)
 		}
 
@@ -226,6 +230,11 @@ func LoggerMiddlewareWithConfig(cfg *LoggerConfig) gin.HandlerFunc {
 			fields = append(fields, logger.SpanID(spanID))
 		}
 
+		// Attribute the fetches of CI jobs to the job
+		if jobToken := GetCIJobTokenFromContext(c); jobToken != nil {
+			fields = append(fields, logger.String("ci_job_id", jobToken.JobID.String()))
+		}
+
 		// Add referer if present
 		if referer := c.Request.Referer(); referer != "" {
 			fields = append(fields, logger.Referer(referer))
@@ -280,11 +289,6 @@ func LoggerMiddlewareWithConfig(cfg *LoggerConfig) gin.HandlerFunc {
 	}
 }
 
-// generateRequestID generates a unique request ID
-func generateRequestID() string {
-	return time.Now().Format("20060102150405.000000000")
-}
-
 // GetRequestID retrieves the request ID from the gin context
 func GetRequestID(c *gin.Context) string {
 	if id, exists := c.Get("request_id"); exists {
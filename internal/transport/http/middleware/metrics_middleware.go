@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/pkg/metrics"
+)
+
+// MetricsMiddleware records the duration and status of every request in the
+// HTTP metrics, by route group. Routes under gitPrefix form the git group.
+func MetricsMiddleware(gitPrefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		metrics.ObserveHTTPRequest(RouteGroup(c.FullPath(), gitPrefix), c.Request.Method, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// RouteGroup returns the group of a route, keeping the number of label values
+// bounded: api/<resource> for the REST API (api/repos, api/ci), git for the
+// git transport under gitPrefix, the first path element for other routes, and
+// unmatched for requests no route matched
+func RouteGroup(route, gitPrefix string) string {
+	if route == "" {
+		return "unmatched"
+	}
+	if strings.HasPrefix(route, gitPrefix) {
+		return "git"
+	}
+
+	parts := strings.Split(strings.Trim(route, "/"), "/")
+	switch {
+	case parts[0] == "":
+		return "root"
+	case parts[0] == "api" && len(parts) >= 3:
+		return "api/" + parts[2]
+	default:
+		return parts[0]
+	}
+}
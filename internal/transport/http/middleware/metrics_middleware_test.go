@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/pkg/metrics"
+)
+
+// requestCount returns the requests of a route group the served metrics
+// counted by method and status
+func requestCount(t *testing.T, group, method string, status int) float64 {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	series := `stasis_http_request_duration_seconds_count{group="` + group + `",method="` + method + `",status="` + strconv.Itoa(status) + `"} `
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), series); ok {
+			count, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return count
+		}
+	}
+	return 0
+}
+
+func TestRouteGroup(t *testing.T) {
+	tests := []struct {
+		route string
+		want  string
+	}{
+		{route: "", want: "unmatched"},
+		{route: "/", want: "root"},
+		{route: "/api/v1/repos/:owner/:repo/branches", want: "api/repos"},
+		{route: "/api/v1/ci/jobs/:id", want: "api/ci"},
+		{route: "/api/v1", want: "api"},
+		{route: "/api/openapi.json", want: "api"},
+		{route: "/:owner/:repo/info/refs", want: "git"},
+		{route: "/:owner/:repo/git-upload-pack", want: "git"},
+		{route: "/healthz", want: "healthz"},
+		{route: "/metrics", want: "metrics"},
+	}
+	for _, tt := range tests {
+		if got := RouteGroup(tt.route, "/:owner/:repo"); got != tt.want {
+			t.Errorf("RouteGroup(%q) = %q, want %q", tt.route, got, tt.want)
+		}
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(MetricsMiddleware("/:owner/:repo"))
+	engine.GET("/api/v1/users/:username", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.POST("/api/v1/users/:username", func(c *gin.Context) { c.Status(http.StatusForbidden) })
+	engine.POST("/:owner/:repo/git-upload-pack", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		method string
+		target string
+		group  string
+		status int
+	}{
+		{method: http.MethodGet, target: "/api/v1/users/alice", group: "api/users", status: http.StatusOK},
+		{method: http.MethodPost, target: "/api/v1/users/bob", group: "api/users", status: http.StatusForbidden},
+		{method: http.MethodPost, target: "/alice/app.git/git-upload-pack", group: "git", status: http.StatusOK},
+		{method: http.MethodGet, target: "/no/such/route", group: "unmatched", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		before := requestCount(t, tt.group, tt.method, tt.status)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+		if rec.Code != tt.status {
+			t.Fatalf("%s %s = %d, want %d", tt.method, tt.target, rec.Code, tt.status)
+		}
+		if got := requestCount(t, tt.group, tt.method, tt.status) - before; got != 1 {
+			t.Errorf("%s %s counted %v times in group %s, want once", tt.method, tt.target, got, tt.group)
+		}
+	}
+}
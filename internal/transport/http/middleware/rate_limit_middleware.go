@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitCleanupInterval is the time between removals of idle buckets
+const rateLimitCleanupInterval = time.Minute
+
+// RateLimiter is an in-memory token bucket rate limiter keyed by client.
+// Buckets refill continuously; a bucket that has refilled completely carries
+// no state and is removed by a background cleanup.
+type RateLimiter struct {
+	rate    float64 // Tokens added per second
+	burst   float64 // Bucket capacity
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// tokenBucket is the budget of a client
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a RateLimiter allowing requestsPerMinute requests with
+// bursts of up to burst requests, and starts its cleanup for the lifetime of
+// the process
+func NewRateLimiter(requestsPerMinute, burst int) *RateLimiter {
+	l := &RateLimiter{
+		rate:    float64(requestsPerMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+	go l.cleanupLoop()
+	return l
+}
+
+// Allow takes a token from the bucket of key. It returns whether the request
+// is allowed, how many requests remain in the bucket and, if not allowed, how
+// long until the next token.
+func (l *RateLimiter) Allow(key string) (bool, int, time.Duration) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, int(b.tokens), 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, 0, wait
+}
+
+// cleanupLoop removes idle buckets every rateLimitCleanupInterval
+func (l *RateLimiter) cleanupLoop() {
+	ticker := time.NewTicker(rateLimitCleanupInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		l.cleanup(now)
+	}
+}
+
+// cleanup removes the buckets that have refilled completely by now
+func (l *RateLimiter) cleanup(now time.Time) {
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimitMiddleware rejects requests over the budget of their client with
+// 429 Too Many Requests and a Retry-After header. key identifies the client of
+// a request. Every response carries X-RateLimit-Remaining.
+func RateLimitMiddleware(limiter *RateLimiter, key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, remaining, wait := limiter.Allow(key(c))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if allowed {
+			return
+		}
+
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error":   "rate_limited",
+			"message": "Too many requests, please retry later",
+		})
+	}
+}
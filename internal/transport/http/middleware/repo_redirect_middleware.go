@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+)
+
+// repoAPIRoutePrefix is the route prefix of the REST API of a repository
+const repoAPIRoutePrefix = "/api/v1/repos/:owner/:repo"
+
+// RepoRedirects finds repositories by name and the repository the former
+// name of a renamed repository redirects to, see service.RepoService
+type RepoRedirects interface {
+	RepositoryExists(ctx context.Context, ownerUsername, repoName string) (bool, error)
+	FindRedirect(ctx context.Context, ownerUsername, repoName string) (*models.Repository, error)
+}
+
+// RepoRedirectMiddleware redirects REST API requests for the former name of a
+// renamed repository to its current name: 301 Moved Permanently for reads and
+// 308 Permanent Redirect for writes, which clients repeat with the same
+// method and body. Private repositories are not redirected, so their new name
+// is not revealed to callers who cannot read them; such requests get the 404
+// of the handler. Redirects are only looked up for names no repository has.
+func RepoRedirectMiddleware(redirects RepoRedirects) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.FullPath(), repoAPIRoutePrefix) {
+			return
+		}
+
+		owner, name := c.Param("owner"), c.Param("repo")
+		if exists, err := redirects.RepositoryExists(c.Request.Context(), owner, name); exists || err != nil {
+			return
+		}
+		repo, err := redirects.FindRedirect(c.Request.Context(), owner, name)
+		if err != nil || repo.IsPrivate {
+			return
+		}
+
+		status := http.StatusPermanentRedirect
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		rest := strings.TrimPrefix(c.Request.URL.Path, "/api/v1/repos/"+owner+"/"+name)
+		location := "/api/v1/repos/" + repo.Owner.Username + "/" + repo.Name + rest
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(status, location)
+		c.Abort()
+	}
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// fakeRepoRedirects finds the repositories and redirects it was given, keyed
+// by owner/name, and counts the redirects looked up
+type fakeRepoRedirects struct {
+	repos     map[string]bool
+	redirects map[string]*models.Repository
+	err       error
+	lookups   int
+}
+
+func (f *fakeRepoRedirects) RepositoryExists(_ context.Context, owner, name string) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	return f.repos[owner+"/"+name], nil
+}
+
+func (f *fakeRepoRedirects) FindRedirect(_ context.Context, owner, name string) (*models.Repository, error) {
+	f.lookups++
+	if repo, ok := f.redirects[owner+"/"+name]; ok {
+		return repo, nil
+	}
+	return nil, apperrors.NotFound("repository", apperrors.ErrNotFound)
+}
+
+func TestRepoRedirectMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	renamed := &models.Repository{Name: "app", Owner: models.User{Username: "alice"}}
+	hidden := &models.Repository{Name: "secret", Owner: models.User{Username: "alice"}, IsPrivate: true}
+
+	tests := []struct {
+		name         string
+		method       string
+		target       string
+		err          error
+		want         int
+		wantLocation string
+		wantLookups  int
+	}{
+		{name: "current name", method: http.MethodGet, target: "/api/v1/repos/alice/app/branches", want: http.StatusOK},
+		{name: "name taken again", method: http.MethodGet, target: "/api/v1/repos/alice/taken/branches", want: http.StatusOK},
+		{
+			name:         "former name read",
+			method:       http.MethodGet,
+			target:       "/api/v1/repos/alice/old-app/branches?page=2",
+			want:         http.StatusMovedPermanently,
+			wantLocation: "/api/v1/repos/alice/app/branches?page=2",
+			wantLookups:  1,
+		},
+		{
+			name:         "former name write",
+			method:       http.MethodPost,
+			target:       "/api/v1/repos/alice/old-app/branches",
+			want:         http.StatusPermanentRedirect,
+			wantLocation: "/api/v1/repos/alice/app/branches",
+			wantLookups:  1,
+		},
+		{name: "former name of a private repository", method: http.MethodGet, target: "/api/v1/repos/alice/old-secret/branches", want: http.StatusOK, wantLookups: 1},
+		{name: "unknown name", method: http.MethodGet, target: "/api/v1/repos/alice/missing/branches", want: http.StatusOK, wantLookups: 1},
+		{name: "lookup failure", method: http.MethodGet, target: "/api/v1/repos/alice/old-app/branches", err: errors.New("database down"), want: http.StatusOK},
+		{name: "other route", method: http.MethodGet, target: "/api/v1/users/alice", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redirects := &fakeRepoRedirects{
+				repos: map[string]bool{"alice/app": true, "alice/taken": true},
+				redirects: map[string]*models.Repository{
+					"alice/old-app":    renamed,
+					"alice/taken":      renamed,
+					"alice/old-secret": hidden,
+				},
+				err: tt.err,
+			}
+			engine := gin.New()
+			engine.Use(RepoRedirectMiddleware(redirects))
+			ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+			engine.GET("/api/v1/repos/:owner/:repo/branches", ok)
+			engine.POST("/api/v1/repos/:owner/:repo/branches", ok)
+			engine.GET("/api/v1/users/:username", ok)
+
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			if redirects.lookups != tt.wantLookups {
+				t.Errorf("looked up %d redirects, want %d", redirects.lookups, tt.wantLookups)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// RequestIDHeader is the header carrying the request ID, in requests and
+// responses
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs accepted from clients
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware assigns every request an ID correlating its log lines:
+// the X-Request-ID of the request if it is a sensible ID, a new UUID
+// otherwise. The ID is returned in X-Request-ID, stored in the gin context
+// (GetRequestID) and in the request context, where logger.WithContext and the
+// git subprocesses pick it up.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Header(RequestIDHeader, requestID)
+		c.Set("request_id", requestID)
+		c.Request = c.Request.WithContext(logger.ContextWithRequestID(c.Request.Context(), requestID))
+		c.Next()
+	}
+}
+
+// validRequestID returns true if id, from a client, can be used as the ID of
+// its request: not empty, bounded, and printable ASCII without spaces so it
+// cannot forge log lines or headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/netip"
+
+	"github.com/gin-gonic/gin"
+)
+
+// trustedProxyKey marks requests that arrived through a trusted proxy
+const trustedProxyKey = "trusted_proxy"
+
+// TrustedProxyMiddleware marks the requests whose peer is one of the reverse
+// proxies of server.trusted_proxies, IP addresses or CIDR ranges, so that
+// handlers only believe the X-Forwarded-* headers such a proxy sets
+// (FromTrustedProxy). Entries that do not parse are ignored; the
+// configuration is validated at startup.
+func TrustedProxyMiddleware(proxies []string) gin.HandlerFunc {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(proxy); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+		}
+	}
+
+	return func(c *gin.Context) {
+		if addr, err := netip.ParseAddr(c.RemoteIP()); err == nil {
+			addr = addr.Unmap()
+			for _, prefix := range prefixes {
+				if prefix.Contains(addr) {
+					c.Set(trustedProxyKey, true)
+					break
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+// FromTrustedProxy returns true if the request arrived through one of the
+// reverse proxies of server.trusted_proxies
+func FromTrustedProxy(c *gin.Context) bool {
+	return c.GetBool(trustedProxyKey)
+}
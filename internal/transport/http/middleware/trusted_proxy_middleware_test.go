@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTrustedProxyMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		proxies    []string
+		remoteAddr string
+		want       bool
+	}{
+		{name: "no proxies", remoteAddr: "10.0.0.1:40000", want: false},
+		{name: "in a range", proxies: []string{"10.0.0.0/8"}, remoteAddr: "10.1.2.3:40000", want: true},
+		{name: "outside the ranges", proxies: []string{"10.0.0.0/8", "192.168.0.1"}, remoteAddr: "203.0.113.7:40000", want: false},
+		{name: "single address", proxies: []string{"10.0.0.0/8", "192.168.0.1"}, remoteAddr: "192.168.0.1:40000", want: true},
+		{name: "IPv6 range", proxies: []string{"fd00::/8"}, remoteAddr: "[fd12::1]:40000", want: true},
+		{name: "IPv4-mapped peer", proxies: []string{"192.168.0.1"}, remoteAddr: "[::ffff:192.168.0.1]:40000", want: true},
+		{name: "invalid entry ignored", proxies: []string{"proxy.internal", "10.0.0.1"}, remoteAddr: "10.0.0.1:40000", want: true},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(TrustedProxyMiddleware(tt.proxies))
+			engine.GET("/", func(c *gin.Context) {
+				c.String(http.StatusOK, strconv.FormatBool(FromTrustedProxy(c)))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if got := w.Body.String(); got != strconv.FormatBool(tt.want) {
+				t.Errorf("FromTrustedProxy = %s, want %v", got, tt.want)
+			}
+		})
+	}
+}
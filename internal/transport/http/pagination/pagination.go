@@ -0,0 +1,213 @@
+// Package pagination parses the paging parameters of list endpoints and
+// describes the returned page, the same way for every endpoint.
+//
+// Clients page with page (1-based) and per_page. The older limit and offset
+// parameters are accepted until they are removed, but the two styles cannot
+// be mixed in one request. Invalid values are rejected with 400 instead of
+// being replaced by defaults.
+//
+// List responses keep their items under the name of the resource
+// (repositories, jobs, ...) and describe the page in a pagination object.
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// Class holds the page size default and maximum of a kind of list endpoint
+type Class struct {
+	DefaultPerPage int
+	MaxPerPage     int
+}
+
+var (
+	// Resources is the class of listings of API resources: repositories,
+	// pull requests, CI jobs, audit events
+	Resources = Class{DefaultPerPage: 20, MaxPerPage: 100}
+
+	// Commits is the class of commit histories
+	Commits = Class{DefaultPerPage: 30, MaxPerPage: 100}
+
+	// Logs is the class of CI job log lines
+	Logs = Class{DefaultPerPage: 1000, MaxPerPage: 10000}
+)
+
+// Params is a validated page request
+type Params struct {
+	Page    int // 1-based; derived from offset for limit/offset requests
+	PerPage int
+	Offset  int
+}
+
+// Error describes an invalid paging parameter
+type Error struct {
+	Parameter string
+	Reason    string
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Parameter, e.Reason)
+}
+
+// Parse reads the paging parameters of a request
+func Parse(c *gin.Context, class Class) (Params, error) {
+	_, hasPage := c.GetQuery("page")
+	_, hasPerPage := c.GetQuery("per_page")
+	_, hasLimit := c.GetQuery("limit")
+	_, hasOffset := c.GetQuery("offset")
+
+	if (hasPage || hasPerPage) && (hasLimit || hasOffset) {
+		return Params{}, &Error{Parameter: "limit", Reason: "cannot be combined with page and per_page"}
+	}
+
+	if hasLimit || hasOffset {
+		limit, err := parseParam(c, "limit", class.DefaultPerPage, 1, class.MaxPerPage)
+		if err != nil {
+			return Params{}, err
+		}
+		offset, err := parseParam(c, "offset", 0, 0, 0)
+		if err != nil {
+			return Params{}, err
+		}
+		return Params{Page: offset/limit + 1, PerPage: limit, Offset: offset}, nil
+	}
+
+	page, err := parseParam(c, "page", 1, 1, 0)
+	if err != nil {
+		return Params{}, err
+	}
+	perPage, err := parseParam(c, "per_page", class.DefaultPerPage, 1, class.MaxPerPage)
+	if err != nil {
+		return Params{}, err
+	}
+	return Params{Page: page, PerPage: perPage, Offset: (page - 1) * perPage}, nil
+}
+
+// FromRequest reads the paging parameters of a request. It writes the error
+// response and returns false if they are invalid.
+func FromRequest(c *gin.Context, class Class) (Params, bool) {
+	params, err := Parse(c, class)
+	if err != nil {
+		perr := err.(*Error)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "bad_request",
+			"message": "Invalid pagination parameter",
+			"details": gin.H{
+				"parameter": perr.Parameter,
+				"reason":    perr.Reason,
+			},
+		})
+		return Params{}, false
+	}
+	return params, true
+}
+
+// Parameters documents the paging parameters of the list endpoints of the
+// class
+func (class Class) Parameters() []openapi.Parameter {
+	return []openapi.Parameter{
+		{
+			Name:        "page",
+			In:          "query",
+			Description: "Page to return, starting at 1",
+			Schema:      &openapi.Schema{Type: "integer", Minimum: intPtr(1), Default: 1},
+		},
+		{
+			Name:        "per_page",
+			In:          "query",
+			Description: "Items per page",
+			Schema:      &openapi.Schema{Type: "integer", Minimum: intPtr(1), Maximum: intPtr(class.MaxPerPage), Default: class.DefaultPerPage},
+		},
+		{
+			Name:        "limit",
+			In:          "query",
+			Description: "Items per page; cannot be combined with page and per_page",
+			Deprecated:  true,
+			Schema:      &openapi.Schema{Type: "integer", Minimum: intPtr(1), Maximum: intPtr(class.MaxPerPage), Default: class.DefaultPerPage},
+		},
+		{
+			Name:        "offset",
+			In:          "query",
+			Description: "Items to skip; cannot be combined with page and per_page",
+			Deprecated:  true,
+			Schema:      &openapi.Schema{Type: "integer", Minimum: intPtr(0), Default: 0},
+		},
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+// Probe returns the number of items to fetch for the page: one more than
+// the page size, so Trim can tell whether a next page exists without
+// counting.
+func (p Params) Probe() int {
+	return p.PerPage + 1
+}
+
+// Counted describes a page of count items out of total
+func (p Params) Counted(count int, total int64) dto.Pagination {
+	info := p.info()
+	info.Total = &total
+	info.HasMore = int64(p.Offset+count) < total
+	return info
+}
+
+// Trim cuts items fetched with Probe down to the page and describes it
+func Trim[T any](p Params, items []T) ([]T, dto.Pagination) {
+	info := p.info()
+	if len(items) > p.PerPage {
+		items = items[:p.PerPage]
+		info.HasMore = true
+	}
+	return items, info
+}
+
+// info describes the page without its total
+func (p Params) info() dto.Pagination {
+	return dto.Pagination{
+		Page:    p.Page,
+		PerPage: p.PerPage,
+		Limit:   p.PerPage,
+		Offset:  p.Offset,
+	}
+}
+
+// parseParam parses a non-negative integer query parameter between lo and
+// hi (no maximum if hi is 0), returning def if it is absent
+func parseParam(c *gin.Context, name string, def, lo, hi int) (int, error) {
+	raw, ok := c.GetQuery(name)
+	if !ok {
+		return def, nil
+	}
+
+	// 32 bits keep page * per_page far from overflowing
+	value, err := strconv.ParseInt(raw, 10, 32)
+	if errors.Is(err, strconv.ErrRange) && !strings.HasPrefix(raw, "-") {
+		return 0, &Error{Parameter: name, Reason: "is too large"}
+	}
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, &Error{Parameter: name, Reason: "must be an integer"}
+	}
+	if value < 0 {
+		return 0, &Error{Parameter: name, Reason: "must not be negative"}
+	}
+	if value < int64(lo) {
+		return 0, &Error{Parameter: name, Reason: fmt.Sprintf("must be at least %d", lo)}
+	}
+	if hi > 0 && value > int64(hi) {
+		return 0, &Error{Parameter: name, Reason: fmt.Sprintf("must be at most %d", hi)}
+	}
+	return int(value), nil
+}
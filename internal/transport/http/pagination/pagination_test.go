@@ -0,0 +1,163 @@
+package pagination
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+)
+
+// testContext returns the context of a GET request with a query string
+func testContext(query string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/repos?"+query, nil)
+	return c, w
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		class   Class
+		want    Params
+		wantErr *Error
+	}{
+		{name: "defaults", want: Params{Page: 1, PerPage: 20}},
+		{name: "class defaults", class: Commits, want: Params{Page: 1, PerPage: 30}},
+		{name: "page", query: "page=3&per_page=10", want: Params{Page: 3, PerPage: 10, Offset: 20}},
+		{name: "page only", query: "page=2", want: Params{Page: 2, PerPage: 20, Offset: 20}},
+		{name: "per_page at the maximum", query: "per_page=100", want: Params{Page: 1, PerPage: 100}},
+		{name: "log lines", query: "per_page=10000", class: Logs, want: Params{Page: 1, PerPage: 10000}},
+		{name: "limit and offset", query: "limit=10&offset=25", want: Params{Page: 3, PerPage: 10, Offset: 25}},
+		{name: "offset only", query: "offset=40", want: Params{Page: 3, PerPage: 20, Offset: 40}},
+
+		// Out of range values are refused, not clamped
+		{name: "per_page over the maximum", query: "per_page=101", wantErr: &Error{Parameter: "per_page", Reason: "must be at most 100"}},
+		{name: "limit over the maximum", query: "limit=101", wantErr: &Error{Parameter: "limit", Reason: "must be at most 100"}},
+		{name: "log lines over the maximum", query: "limit=10001", class: Logs, wantErr: &Error{Parameter: "limit", Reason: "must be at most 10000"}},
+		{name: "page 0", query: "page=0", wantErr: &Error{Parameter: "page", Reason: "must be at least 1"}},
+		{name: "per_page 0", query: "per_page=0", wantErr: &Error{Parameter: "per_page", Reason: "must be at least 1"}},
+		{name: "limit 0", query: "limit=0", wantErr: &Error{Parameter: "limit", Reason: "must be at least 1"}},
+
+		{name: "negative page", query: "page=-1", wantErr: &Error{Parameter: "page", Reason: "must not be negative"}},
+		{name: "negative offset", query: "offset=-5", wantErr: &Error{Parameter: "offset", Reason: "must not be negative"}},
+		{name: "very negative offset", query: "offset=-99999999999", wantErr: &Error{Parameter: "offset", Reason: "must not be negative"}},
+		{name: "page too large", query: "page=99999999999", wantErr: &Error{Parameter: "page", Reason: "is too large"}},
+		{name: "not a number", query: "page=two", wantErr: &Error{Parameter: "page", Reason: "must be an integer"}},
+		{name: "decimal", query: "per_page=2.5", wantErr: &Error{Parameter: "per_page", Reason: "must be an integer"}},
+		{name: "empty", query: "limit=", wantErr: &Error{Parameter: "limit", Reason: "must be an integer"}},
+		{name: "with spaces", query: "offset=%2010", wantErr: &Error{Parameter: "offset", Reason: "must be an integer"}},
+
+		{name: "mixed styles", query: "page=2&limit=10", wantErr: &Error{Parameter: "limit", Reason: "cannot be combined with page and per_page"}},
+		{name: "mixed styles, per_page and offset", query: "per_page=10&offset=10", wantErr: &Error{Parameter: "limit", Reason: "cannot be combined with page and per_page"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class := tt.class
+			if class == (Class{}) {
+				class = Resources
+			}
+			c, _ := testContext(tt.query)
+			got, err := Parse(c, class)
+			if tt.wantErr != nil {
+				if perr, ok := err.(*Error); !ok || *perr != *tt.wantErr {
+					t.Fatalf("Parse(%q) = %+v, %v, want error %q", tt.query, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.query, err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromRequestRejectsInvalidParameters(t *testing.T) {
+	c, w := testContext("per_page=abc")
+	if _, ok := FromRequest(c, Resources); ok {
+		t.Fatal("FromRequest accepted per_page=abc")
+	}
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	var body struct {
+		Error   string            `json:"error"`
+		Details map[string]string `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"parameter": "per_page", "reason": "must be an integer"}
+	if body.Error != "bad_request" || !reflect.DeepEqual(body.Details, want) {
+		t.Errorf("body = %s, want a bad_request naming the parameter", w.Body.String())
+	}
+}
+
+func TestTrim(t *testing.T) {
+	params := Params{Page: 2, PerPage: 3, Offset: 3}
+	tests := []struct {
+		name     string
+		fetched  []int
+		want     []int
+		wantMore bool
+	}{
+		{name: "more pages", fetched: []int{4, 5, 6, 7}, want: []int{4, 5, 6}, wantMore: true},
+		{name: "last full page", fetched: []int{4, 5, 6}, want: []int{4, 5, 6}},
+		{name: "last page", fetched: []int{4}, want: []int{4}},
+		{name: "past the end", fetched: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if probe := params.Probe(); probe != 4 {
+				t.Fatalf("Probe() = %d, want 4", probe)
+			}
+			items, info := Trim(params, tt.fetched)
+			if !reflect.DeepEqual(items, tt.want) {
+				t.Errorf("items = %v, want %v", items, tt.want)
+			}
+			want := dto.Pagination{Page: 2, PerPage: 3, Limit: 3, Offset: 3, HasMore: tt.wantMore}
+			if !reflect.DeepEqual(info, want) {
+				t.Errorf("pagination = %+v, want %+v", info, want)
+			}
+		})
+	}
+}
+
+func TestCounted(t *testing.T) {
+	params := Params{Page: 2, PerPage: 10, Offset: 10}
+	tests := []struct {
+		name     string
+		count    int
+		total    int64
+		wantMore bool
+	}{
+		{name: "more pages", count: 10, total: 25, wantMore: true},
+		{name: "last full page", count: 10, total: 20},
+		{name: "last page", count: 5, total: 15},
+		{name: "past the end", count: 0, total: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := params.Counted(tt.count, tt.total)
+			if info.Total == nil || *info.Total != tt.total {
+				t.Errorf("total = %v, want %d", info.Total, tt.total)
+			}
+			if info.HasMore != tt.wantMore {
+				t.Errorf("has_more = %v, want %v", info.HasMore, tt.wantMore)
+			}
+			if info.Page != 2 || info.PerPage != 10 || info.Limit != 10 || info.Offset != 10 {
+				t.Errorf("pagination = %+v, want page 2 of 10 items", info)
+			}
+		})
+	}
+}
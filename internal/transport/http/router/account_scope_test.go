@@ -0,0 +1,75 @@
+package router_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+// Account routes must refuse tokens without the admin scope: a repo:read
+// token adding an SSH key or minting a token would gain push access.
+func TestAccountRoutesRequireAdminScope(t *testing.T) {
+	env := testutil.SharedEnv(t)
+	user := env.CreateUser(t, "scoped")
+	readToken := env.CreateToken(t, user, models.TokenScopeRepoRead)
+	unscopedToken := env.CreateToken(t, user)
+	adminToken := env.CreateToken(t, user, models.TokenScopeAdmin)
+
+	routes := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPost, "/api/v1/ssh-keys", `{"title":"laptop","public_key":"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl"}`},
+		{http.MethodGet, "/api/v1/ssh-keys", ""},
+		{http.MethodPost, "/api/v1/tokens", `{"name":"escalated"}`},
+		{http.MethodGet, "/api/v1/tokens", ""},
+		{http.MethodDelete, "/api/v1/tokens/00000000-0000-0000-0000-000000000000", ""},
+		{http.MethodPost, "/api/v1/gpg-keys", `{"public_key":""}`},
+		{http.MethodPut, "/api/v1/users/username", `{"username":"renamed"}`},
+		{http.MethodGet, "/api/v1/users/settings", ""},
+		{http.MethodPut, "/api/v1/users/settings", `{}`},
+		{http.MethodPost, "/api/v1/users/feed-token", ""},
+		{http.MethodPatch, "/api/v1/users/notification-preferences", `{}`},
+		{http.MethodPost, "/api/v1/users/onboard/skip", ""},
+		{http.MethodPost, "/api/v1/users/export", ""},
+	}
+	for _, route := range routes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			if status := do(t, env, route.method, route.path, readToken, route.body); status != http.StatusForbidden {
+				t.Fatalf("repo:read token: status = %d, want %d", status, http.StatusForbidden)
+			}
+			// A token without scopes may only read and write repositories
+			if status := do(t, env, route.method, route.path, unscopedToken, route.body); status != http.StatusForbidden {
+				t.Fatalf("token without scopes: status = %d, want %d", status, http.StatusForbidden)
+			}
+		})
+	}
+
+	if status := do(t, env, http.MethodGet, "/api/v1/ssh-keys", adminToken, ""); status != http.StatusOK {
+		t.Fatalf("admin token: status = %d, want %d", status, http.StatusOK)
+	}
+}
+
+// do sends a request with a token and returns the response status
+func do(t *testing.T, env *testutil.Env, method, path, token, body string) int {
+	t.Helper()
+
+	req, err := http.NewRequest(method, env.URL(path), strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "token "+token)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
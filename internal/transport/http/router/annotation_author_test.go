@@ -0,0 +1,41 @@
+package router_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+// Deleting a user who annotated a repository keeps the annotation, without
+// its author, rather than refusing the deletion
+func TestDeleteAnnotationAuthor(t *testing.T) {
+	env := testutil.SharedEnv(t)
+	owner := env.CreateUser(t, "annotated")
+	author := env.CreateUser(t, "annotator")
+	admin := env.CreateAdmin(t, "remover")
+	repo, _ := env.CreateRepository(t, owner, "notes", false)
+	if _, err := env.Deps.Collaborators.AddCollaborator(context.Background(), repo, author.Username, models.RepoPermissionAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	path := "/api/v1/repos/" + owner.Username + "/notes/annotations"
+	authorToken := env.CreateToken(t, author, models.TokenScopeRepoRead, models.TokenScopeRepoWrite)
+	if status := do(t, env, http.MethodPost, path, authorToken, `{"key":"oncall","value":"team-a"}`); status != http.StatusCreated {
+		t.Fatalf("create annotation: status = %d, want %d", status, http.StatusCreated)
+	}
+
+	adminToken := env.CreateToken(t, admin, models.TokenScopeAdmin)
+	if status := do(t, env, http.MethodDelete, "/api/v1/admin/users/"+author.Username, adminToken, ""); status != http.StatusOK {
+		t.Fatalf("delete author: status = %d, want %d", status, http.StatusOK)
+	}
+
+	var annotation dto.AnnotationResponse
+	getJSON(t, env, path+"/oncall", env.CreateToken(t, owner, models.TokenScopeRepoRead), &annotation)
+	if annotation.Value != "team-a" || annotation.AuthorID != nil || annotation.Author != "" {
+		t.Errorf("annotation after deleting its author = %+v, want team-a without author", annotation)
+	}
+}
@@ -0,0 +1,136 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// annotationRouter sets up repository annotation routes
+func (r *Router) annotationRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewAnnotationHandler(
+		r.Deps.RepoService,
+		r.Deps.AnnotationService,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/annotations", openapi.RouteDocs{
+		Summary:     "List annotations",
+		Description: "Get all operational annotations of a repository",
+		Tags:        []string{"Annotations"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.AnnotationListResponse{},
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/annotations", openapi.RouteDocs{
+		Summary:     "Create annotation",
+		Description: "Add an operational annotation to a repository. Keys are unique per repository.",
+		Tags:        []string{"Annotations"},
+		RequestBody: dto.CreateAnnotationRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			201: {
+				Description: "Annotation created successfully",
+				Model:       dto.AnnotationResponse{},
+			},
+			400: {
+				Description: "Invalid key or value",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+			409: {
+				Description: "Annotation already exists",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/annotations/:key", openapi.RouteDocs{
+		Summary:     "Get annotation",
+		Description: "Get a single annotation of a repository by key",
+		Tags:        []string{"Annotations"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.AnnotationResponse{},
+			},
+			404: {
+				Description: "Repository or annotation not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PUT", "/api/v1/repos/:owner/:repo/annotations/:key", openapi.RouteDocs{
+		Summary:     "Update annotation",
+		Description: "Replace the value of an existing annotation",
+		Tags:        []string{"Annotations"},
+		RequestBody: dto.UpdateAnnotationRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Annotation updated successfully",
+				Model:       dto.AnnotationResponse{},
+			},
+			400: {
+				Description: "Invalid value",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository or annotation not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("DELETE", "/api/v1/repos/:owner/:repo/annotations/:key", openapi.RouteDocs{
+		Summary:     "Delete annotation",
+		Description: "Remove an annotation from a repository",
+		Tags:        []string{"Annotations"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Annotation deleted successfully",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository or annotation not found",
+			},
+		},
+	})
+
+	// Annotation routes
+	annotations := v1.Group("/repos/:owner/:repo/annotations")
+	{
+		annotations.GET("", authMiddleware.Authenticate(), h.ListAnnotations)
+		annotations.POST("", authMiddleware.RequireAuth(), h.CreateAnnotation)
+		annotations.GET("/:key", authMiddleware.Authenticate(), h.GetAnnotation)
+		annotations.PUT("/:key", authMiddleware.RequireAuth(), h.UpdateAnnotation)
+		annotations.DELETE("/:key", authMiddleware.RequireAuth(), h.DeleteAnnotation)
+	}
+}
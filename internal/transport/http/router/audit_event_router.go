@@ -0,0 +1,77 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// auditEventRouter sets up the activity feed and audit trail routes
+func (r *Router) auditEventRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewAuditEventHandler(
+		r.Deps.RepoService,
+		r.Deps.AuditEvents,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/events", openapi.RouteDocs{
+		Summary:     "List repository activity",
+		Description: "List the recorded actions on a repository, most recent first: creation, deletion, branch and tag changes, collaborator changes and pushes. Filter with ?action= (an action such as branch.delete, or a category such as branch); paginate with ?page= and ?per_page= (default 20, max 100). Client IP addresses are only shown to repository administrators.",
+		Tags:        []string{"Repositories"},
+		Parameters:  pagination.Resources.Parameters(),
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.ListAuditEventsResponse{},
+			},
+			400: {
+				Description: "Invalid pagination parameter",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/admin/events", openapi.RouteDocs{
+		Summary:     "List audit events",
+		Description: "List the recorded actions of all users, most recent first, including token and SSH key changes. Filter with ?action= (an action or a category), ?repository_id= and ?actor_id=; paginate with ?page= and ?per_page= (default 20, max 100).",
+		Tags:        []string{"Admin"},
+		Parameters:  pagination.Resources.Parameters(),
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.ListAuditEventsResponse{},
+			},
+			400: {
+				Description: "Invalid repository_id, actor_id or pagination parameter",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+		},
+	})
+
+	// Repository activity routes
+	repos := v1.Group("/repos/:owner/:repo")
+	{
+		repos.GET("/events", authMiddleware.Authenticate(), h.ListRepositoryEvents)
+	}
+
+	// Admin audit event routes
+	admin := v1.Group("/admin", authMiddleware.RequireAdmin())
+	{
+		admin.GET("/events", h.ListEvents)
+	}
+}
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// auditRouter sets up audit export administration routes
+func (r *Router) auditRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewAuditHandler(r.Deps.AuditDispatcher)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/admin/audit/sinks", openapi.RouteDocs{
+		Summary:     "List audit sinks",
+		Description: "Get the delivery health of every configured audit sink (last success, last error, backlog)",
+		Tags:        []string{"Admin"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.AuditSinkListResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+		},
+	})
+
+	// Admin audit routes
+	admin := v1.Group("/admin/audit", authMiddleware.RequireAdmin())
+	{
+		admin.GET("/sinks", h.ListSinks)
+	}
+}
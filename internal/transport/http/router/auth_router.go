@@ -51,11 +51,42 @@ func (r *Router) authRouter() {
 
 	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/auth/oidc/logout", openapi.RouteDocs{
 		Summary:     "Logout",
-		Description: "Invalidate the current session",
+		Description: "Same as POST /api/v1/auth/logout, kept for existing clients",
 		Tags:        []string{"Authentication"},
 		Responses: map[int]openapi.ResponseDoc{
 			200: {
 				Description: "Logged out successfully",
+				Model:       dto.LogoutResponse{},
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/auth/logout", openapi.RouteDocs{
+		Summary:     "Logout",
+		Description: "Revoke the session of the session token in the Authorization header, expired or not. logout_url, if set, is the logout URL of the identity provider, which the frontend redirects to so it ends its session too.",
+		Tags:        []string{"Authentication"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Logged out successfully",
+				Model:       dto.LogoutResponse{},
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/auth/refresh", openapi.RouteDocs{
+		Summary:     "Refresh session",
+		Description: "Mint a new session token from the session token in the Authorization header, expired or not, while its session is active: not logged out, within oidc.sessions.absolute_lifetime of the login and used within oidc.sessions.idle_timeout. The session is refreshed at the identity provider too if it issued a refresh token.",
+		Tags:        []string{"Authentication"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Session refreshed",
+				Model:       dto.SessionRefreshResponse{},
+			},
+			401: {
+				Description: "Invalid session token, or the session has ended",
+			},
+			503: {
+				Description: "The identity provider could not be reached",
 			},
 		},
 	})
@@ -95,10 +126,14 @@ func (r *Router) authRouter() {
 			// OIDC callback - handles response from identity provider
 			oidc.GET("/callback", h.OIDCCallback)
 
-			// Logout - invalidates session and optionally redirects to provider logout
-			oidc.POST("/logout", h.OIDCLogout)
+			// Logout - kept for existing clients, same as /auth/logout
+			oidc.POST("/logout", h.Logout)
 		}
 
+		// Session routes, authenticated by the session token even once expired
+		auth.POST("/refresh", h.RefreshSession)
+		auth.POST("/logout", h.Logout)
+
 		// Protected auth routes
 		auth.GET("/me", authMiddleware.RequireAuth(), h.GetCurrentUser)
 	}
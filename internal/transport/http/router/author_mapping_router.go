@@ -0,0 +1,137 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// authorMappingRouter sets up contributor and author mapping routes
+func (r *Router) authorMappingRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewAuthorMappingHandler(
+		r.Deps.RepoService,
+		r.Deps.AuthorMappings,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/contributors", openapi.RouteDocs{
+		Summary:     "List contributors",
+		Description: "List the commit authors of the default branch with their commit counts. Identities are merged using the repository's .mailmap and author mappings.",
+		Tags:        []string{"Commits"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.ContributorListResponse{},
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/settings/author-mappings", openapi.RouteDocs{
+		Summary:     "List author mappings",
+		Description: "List the server-side mappings of commit emails to users, which supplement the repository's .mailmap",
+		Tags:        []string{"Commits"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.AuthorMappingListResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PUT", "/api/v1/repos/:owner/:repo/settings/author-mappings", openapi.RouteDocs{
+		Summary:     "Set author mapping",
+		Description: "Map a commit email to a user, replacing any existing mapping of the email",
+		Tags:        []string{"Commits"},
+		RequestBody: dto.SetAuthorMappingRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Author mapping saved",
+				Model:       dto.AuthorMappingResponse{},
+			},
+			400: {
+				Description: "Invalid email or unknown user",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("DELETE", "/api/v1/repos/:owner/:repo/settings/author-mappings/:id", openapi.RouteDocs{
+		Summary:     "Delete author mapping",
+		Description: "Remove a server-side author mapping",
+		Tags:        []string{"Commits"},
+		Responses: map[int]openapi.ResponseDoc{
+			204: {
+				Description: "Author mapping deleted",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository or mapping not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/settings/author-mappings/unmapped", openapi.RouteDocs{
+		Summary:     "List unmapped authors",
+		Description: "List author emails of recent default branch history that do not resolve to a user",
+		Tags:        []string{"Commits"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.UnmappedAuthorListResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	// Contributor routes
+	v1.GET("/repos/:owner/:repo/contributors", authMiddleware.Authenticate(), h.ListContributors)
+
+	// Author mapping routes
+	settings := v1.Group("/repos/:owner/:repo/settings")
+	{
+		settings.GET("/author-mappings", authMiddleware.RequireAuth(), h.ListMappings)
+		settings.PUT("/author-mappings", authMiddleware.RequireAuth(), h.SetMapping)
+		settings.GET("/author-mappings/unmapped", authMiddleware.RequireAuth(), h.ListUnmappedAuthors)
+		settings.DELETE("/author-mappings/:id", authMiddleware.RequireAuth(), h.DeleteMapping)
+	}
+}
@@ -0,0 +1,82 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// backupRouter sets up repository backup routes
+func (r *Router) backupRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewBackupHandler(r.Deps.Backups)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/admin/backups", openapi.RouteDocs{
+		Summary: "Back up repositories",
+		Description: "Write a git bundle and a manifest of the metadata (visibility, settings, collaborators) of a repository, the repositories of an owner, or every repository, under backups.directory on the default storage backend. " +
+			"A new bundle is only written when the refs of a repository changed since its previous backup. The manifest records the size and SHA-256 of the bundle so restores can check it.",
+		Tags:        []string{"Admin"},
+		RequestBody: dto.BackupRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "The outcome per repository; failures of single repositories are listed, not returned as errors",
+				Model:       dto.BackupResponse{},
+			},
+			400: {
+				Description: "Invalid selection",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+			404: {
+				Description: "Owner or repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/admin/backups/restore", openapi.RouteDocs{
+		Summary: "Restore repositories from backups",
+		Description: "Recreate backed up repositories from their bundle and manifest, after checking the bundle against its checksum. The owner accounts must exist; collaborators without an account are reported as warnings. " +
+			"Repositories that exist already are skipped, or with on_conflict fail nothing is restored.",
+		Tags:        []string{"Admin"},
+		RequestBody: dto.RestoreBackupRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "The outcome per repository",
+				Model:       dto.RestoreBackupResponse{},
+			},
+			400: {
+				Description: "Invalid selection",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+			404: {
+				Description: "No backup found",
+			},
+			409: {
+				Description: "Repositories exist already and on_conflict is fail",
+			},
+		},
+	})
+
+	// Admin backup routes
+	admin := v1.Group("/admin", authMiddleware.RequireAdmin())
+	{
+		admin.POST("/backups", h.Backup)
+		admin.POST("/backups/restore", h.Restore)
+	}
+}
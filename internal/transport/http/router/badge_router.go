@@ -0,0 +1,40 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// badgeRouter sets up the third-party badge proxy route when it is enabled
+func (r *Router) badgeRouter() {
+	if !r.Deps.BadgeProxyService.IsEnabled() {
+		return
+	}
+
+	// Initialize handler
+	h := handler.NewBadgeHandler(r.Deps.BadgeProxyService)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", service.BadgeProxyPath, openapi.RouteDocs{
+		Summary:     "Proxy badge",
+		Description: "Fetch a third-party README badge through the server. Only allowlisted badge hosts are proxied and responses are cached.",
+		Tags:        []string{"Badges"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Badge image",
+			},
+			400: {
+				Description: "Missing or invalid badge URL",
+			},
+			403: {
+				Description: "Badge host not allowed",
+			},
+			502: {
+				Description: "Upstream badge host failed",
+			},
+		},
+	})
+
+	r.server.GET(service.BadgeProxyPath, h.ProxyBadge)
+}
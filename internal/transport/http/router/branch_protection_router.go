@@ -0,0 +1,158 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// branchProtectionRouter sets up branch protection routes
+func (r *Router) branchProtectionRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewBranchProtectionHandler(
+		r.Deps.RepoService,
+		r.Deps.BranchProtection,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/branch_protections", openapi.RouteDocs{
+		Summary:     "List branch protections",
+		Description: "List the branch protections of a repository. Requires admin access.",
+		Tags:        []string{"Branch Protection"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.BranchProtectionListResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/branch_protections", openapi.RouteDocs{
+		Summary:     "Create branch protection",
+		Description: "Protect the branches matching a glob pattern (e.g. main or release/*). Pushes that force-push or delete a protected branch are rejected, for administrators too; both are blocked unless disabled. With require_admin only repository administrators may push to matching branches. Requires admin access.",
+		Tags:        []string{"Branch Protection"},
+		RequestBody: dto.BranchProtectionRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			201: {
+				Description: "Branch protection created",
+				Model:       dto.BranchProtectionResponse{},
+			},
+			400: {
+				Description: "Invalid pattern",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+			409: {
+				Description: "A protection with the pattern already exists",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/branch_protections/:id", openapi.RouteDocs{
+		Summary:     "Get branch protection",
+		Description: "Get a branch protection of a repository. Requires admin access.",
+		Tags:        []string{"Branch Protection"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.BranchProtectionResponse{},
+			},
+			400: {
+				Description: "Invalid branch protection ID",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository or branch protection not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PUT", "/api/v1/repos/:owner/:repo/branch_protections/:id", openapi.RouteDocs{
+		Summary:     "Update branch protection",
+		Description: "Replace the pattern and rules of a branch protection. Omitted block_force_push and block_deletion default to true. Requires admin access.",
+		Tags:        []string{"Branch Protection"},
+		RequestBody: dto.BranchProtectionRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Branch protection updated",
+				Model:       dto.BranchProtectionResponse{},
+			},
+			400: {
+				Description: "Invalid pattern or branch protection ID",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository or branch protection not found",
+			},
+			409: {
+				Description: "Another protection with the pattern already exists",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("DELETE", "/api/v1/repos/:owner/:repo/branch_protections/:id", openapi.RouteDocs{
+		Summary:     "Delete branch protection",
+		Description: "Remove a branch protection. Requires admin access.",
+		Tags:        []string{"Branch Protection"},
+		Responses: map[int]openapi.ResponseDoc{
+			204: {
+				Description: "Branch protection deleted",
+			},
+			400: {
+				Description: "Invalid branch protection ID",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository or branch protection not found",
+			},
+		},
+	})
+
+	// Branch protection routes
+	protections := v1.Group("/repos/:owner/:repo/branch_protections")
+	protections.Use(authMiddleware.RequireAuth())
+	{
+		protections.GET("", h.ListProtections)
+		protections.POST("", h.CreateProtection)
+		protections.GET("/:id", h.GetProtection)
+		protections.PUT("/:id", h.UpdateProtection)
+		protections.DELETE("/:id", h.DeleteProtection)
+	}
+}
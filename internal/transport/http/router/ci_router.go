@@ -4,6 +4,7 @@ import (
 	"github.com/bravo68web/stasis/internal/application/dto"
 	"github.com/bravo68web/stasis/internal/transport/http/handler"
 	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
 	"github.com/bravo68web/stasis/pkg/openapi"
 )
 
@@ -13,7 +14,7 @@ func (r *Router) ciRouter() {
 	ciService := r.Deps.CIService
 
 	// Initialize CI handler
-	ciHandler := handler.NewCIHandler(ciService, r.Deps.RepoService.GetRepoRepository())
+	ciHandler := handler.NewCIHandler(ciService, r.Deps.RepoService)
 
 	// Initialize auth middleware
 	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
@@ -39,13 +40,39 @@ func (r *Router) ciRouter() {
 
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/ci/jobs", openapi.RouteDocs{
 		Summary:     "List jobs",
-		Description: "List CI jobs for a repository",
+		Description: "List CI jobs for a repository. Filter by status with ?status=, a comma-separated list of pending, queued, running, success, failed, cancelled, timed_out or error (e.g. status=failed,error); total counts the matching jobs. Paginate with ?page= and ?per_page= (default 20, max 100); the deprecated ?limit= and ?offset= are still accepted",
 		Tags:        []string{"CI"},
+		Parameters:  pagination.Resources.Parameters(),
 		Responses: map[int]openapi.ResponseDoc{
 			200: {
 				Description: "Successful response",
 				Model:       dto.CIJobListResponse{},
 			},
+			400: {
+				Description: "Invalid status filter or pagination parameter",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/ci/refs/*ref", openapi.RouteDocs{
+		Summary:     "List jobs by ref",
+		Description: "List CI jobs for a branch or tag. Request /ci/refs/{ref}/jobs; the ref may contain slashes (e.g. feature/foo) and may be URL-encoded. Paginate with ?page= and ?per_page= (default 20, max 100); the deprecated ?limit= and ?offset= are still accepted",
+		Tags:        []string{"CI"},
+		Parameters:  pagination.Resources.Parameters(),
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.CIJobListResponse{},
+			},
+			400: {
+				Description: "Invalid ref or pagination parameter",
+			},
 			401: {
 				Description: "Unauthorized",
 			},
@@ -75,13 +102,17 @@ func (r *Router) ciRouter() {
 
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/ci/jobs/:job_id/logs", openapi.RouteDocs{
 		Summary:     "Get job logs",
-		Description: "Get logs for a specific CI job",
+		Description: "Get logs for a specific CI job. Paginate with ?page= and ?per_page= (default 1000, max 10000); the deprecated ?limit= and ?offset= are still accepted",
 		Tags:        []string{"CI"},
+		Parameters:  pagination.Logs.Parameters(),
 		Responses: map[int]openapi.ResponseDoc{
 			200: {
 				Description: "Successful response",
 				Model:       dto.CILogsResponse{},
 			},
+			400: {
+				Description: "Invalid pagination parameter",
+			},
 			401: {
 				Description: "Unauthorized",
 			},
@@ -111,7 +142,7 @@ func (r *Router) ciRouter() {
 
 	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/ci/jobs", openapi.RouteDocs{
 		Summary:     "Trigger job",
-		Description: "Trigger a new CI job",
+		Description: "Trigger a new CI job. If the CI config defines stages, a job is created per stage and later stages stay blocked until the stages they need succeed",
 		Tags:        []string{"CI"},
 		RequestBody: handler.TriggerJobRequest{},
 		Responses: map[int]openapi.ResponseDoc{
@@ -120,7 +151,7 @@ func (r *Router) ciRouter() {
 				Model:       dto.CIJobTriggerResponse{},
 			},
 			400: {
-				Description: "Invalid request",
+				Description: "Invalid request or unknown/circular stage reference in the CI config",
 			},
 			401: {
 				Description: "Unauthorized",
@@ -166,14 +197,52 @@ func (r *Router) ciRouter() {
 		},
 	})
 
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/ci/runs/:run_id", openapi.RouteDocs{
+		Summary:     "Get run",
+		Description: "Get the stage progress of a staged CI run",
+		Tags:        []string{"CI"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.CIRunResponse{},
+			},
+			404: {
+				Description: "Repository or run not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/ci/runs/:run_id/cancel", openapi.RouteDocs{
+		Summary:     "Cancel run",
+		Description: "Cancel all unfinished jobs of a staged CI run, including jobs blocked on earlier stages",
+		Tags:        []string{"CI"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Run cancelled successfully",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Permission denied",
+			},
+			404: {
+				Description: "Repository or run not found",
+			},
+		},
+	})
+
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/ci/jobs/:job_id/stream", openapi.RouteDocs{
 		Summary:     "Stream logs",
-		Description: "Stream logs for a CI job via SSE",
+		Description: "Stream logs for a CI job via SSE. The logs after the sequence in the Last-Event-ID header or the after_sequence query parameter, or all logs, are sent first. Lines are sent in batches as logs events, each carrying an array of lines and the sequence of its last line as the SSE id, so browsers resume on reconnect. A complete event is sent and the stream ends once the job finishes.",
 		Tags:        []string{"CI"},
 		Responses: map[int]openapi.ResponseDoc{
 			200: {
 				Description: "Successful stream",
 			},
+			400: {
+				Description: "Invalid job ID or resume sequence",
+			},
 			401: {
 				Description: "Unauthorized",
 			},
@@ -185,12 +254,15 @@ func (r *Router) ciRouter() {
 
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/ci/jobs/:job_id/artifacts/:artifact_name", openapi.RouteDocs{
 		Summary:     "Download artifact",
-		Description: "Download a specific artifact",
+		Description: "Download a specific artifact, from the artifacts stored on the server first, then from the CI runner",
 		Tags:        []string{"CI"},
 		Responses: map[int]openapi.ResponseDoc{
 			200: {
 				Description: "Successful download",
 			},
+			400: {
+				Description: "Invalid job ID or artifact name",
+			},
 			401: {
 				Description: "Unauthorized",
 			},
@@ -202,7 +274,7 @@ func (r *Router) ciRouter() {
 
 	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/ci/jobs/:job_id/logs", openapi.RouteDocs{
 		Summary:     "Receive logs",
-		Description: "Receive logs from CI runner",
+		Description: "Receive logs from CI runner. The batch is streamed to subscribers as one event. Lines past ci.log_limit_lines or ci.log_limit_mb for the job are dropped and counted in dropped",
 		Tags:        []string{"CI Internal"},
 		RequestBody: []dto.CIRunnerLogEntryRequest{},
 		Responses: map[int]openapi.ResponseDoc{
@@ -212,6 +284,36 @@ func (r *Router) ciRouter() {
 			400: {
 				Description: "Invalid request",
 			},
+			401: {
+				Description: "Missing or wrong CI API key or callback token",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PUT", "/api/v1/ci/jobs/:job_id/artifacts/:name", openapi.RouteDocs{
+		Summary:     "Upload artifact",
+		Description: "Store an artifact of a job on the server, streamed as the request body, so it outlives the workspace of the runner. The SHA-256 of the content, in hex, is required in the X-Checksum-Sha256 header; an upload that does not match it is discarded. Uploading an artifact again replaces it. Stored artifacts are kept for ci.retention_days",
+		Tags:        []string{"CI Internal"},
+		Responses: map[int]openapi.ResponseDoc{
+			201: {
+				Description: "Artifact stored",
+				Model:       dto.CIArtifactResponse{},
+			},
+			400: {
+				Description: "Invalid job ID, artifact name or checksum",
+			},
+			401: {
+				Description: "Missing or wrong CI API key or callback token",
+			},
+			404: {
+				Description: "Job not found",
+			},
+			413: {
+				Description: "The artifact is larger than ci.max_artifact_size",
+			},
+			422: {
+				Description: "The content does not match the declared checksum",
+			},
 		},
 	})
 
@@ -227,6 +329,9 @@ func (r *Router) ciRouter() {
 			400: {
 				Description: "Invalid request",
 			},
+			401: {
+				Description: "Missing or wrong CI API key or callback token",
+			},
 		},
 	})
 
@@ -242,6 +347,9 @@ func (r *Router) ciRouter() {
 			400: {
 				Description: "Invalid request",
 			},
+			401: {
+				Description: "Missing or wrong CI API key or callback token",
+			},
 		},
 	})
 
@@ -259,23 +367,31 @@ func (r *Router) ciRouter() {
 		repoGroup.GET("/jobs/:job_id/stream", authMiddleware.Authenticate(), ciHandler.StreamLogs)
 		repoGroup.GET("/jobs/:job_id/artifacts", authMiddleware.Authenticate(), ciHandler.ListArtifacts)
 		repoGroup.GET("/jobs/:job_id/artifacts/:artifact_name", authMiddleware.Authenticate(), ciHandler.DownloadArtifact)
+		repoGroup.GET("/runs/:run_id", authMiddleware.Authenticate(), ciHandler.GetRun)
+		repoGroup.GET("/refs/*ref", authMiddleware.Authenticate(), ciHandler.ListJobsByRef)
 
 		// Protected routes (require authentication)
 		repoGroup.POST("/jobs", authMiddleware.RequireAuth(), ciHandler.TriggerJob)
 		repoGroup.POST("/jobs/:job_id/cancel", authMiddleware.RequireAuth(), ciHandler.CancelJob)
 		repoGroup.POST("/jobs/:job_id/retry", authMiddleware.RequireAuth(), ciHandler.RetryJob)
+		repoGroup.POST("/runs/:run_id/cancel", authMiddleware.RequireAuth(), ciHandler.CancelRun)
 	}
 
 	// ========================================
 	// Internal CI routes (called by CI runner)
 	// ========================================
-	// These routes are called by the CI runner to report status and logs
-	ciInternalGroup := r.server.Group("/api/v1/ci")
+	// These routes are called by the CI runner to report status and logs. The
+	// runner authenticates with the callback token of the job, sent with the
+	// job, or the CI API key in X-API-Key
+	runnerMiddleware := middleware.NewCIRunnerMiddleware(r.Deps.CIJobTokens, r.server.Config.CI.APIKey)
+	ciInternalGroup := r.server.Group("/api/v1/ci", runnerMiddleware.RequireRunner())
 	{
 		// Receive logs from CI runner
-		// The CI runner authenticates using Bearer token or API key
 		ciInternalGroup.POST("/jobs/:job_id/logs", ciHandler.ReceiveLogs)
 
+		// Store artifacts uploaded by the CI runner
+		ciInternalGroup.PUT("/jobs/:job_id/artifacts/:name", ciHandler.UploadArtifact)
+
 		// Receive job completion events from CI runner
 		ciInternalGroup.POST("/jobs/:job_id/complete", ciHandler.CompleteJob)
 
@@ -0,0 +1,99 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// collaboratorRouter sets up repository collaborator routes
+func (r *Router) collaboratorRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewCollaboratorHandler(
+		r.Deps.RepoService,
+		r.Deps.Collaborators,
+		r.Deps.AuditEvents,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/collaborators", openapi.RouteDocs{
+		Summary:     "List collaborators",
+		Description: "List the users granted access to a repository besides its owner. Requires write access.",
+		Tags:        []string{"Collaborators"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.CollaboratorListResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/collaborators", openapi.RouteDocs{
+		Summary:     "Add collaborator",
+		Description: "Grant a user read, write or admin permission on a repository, replacing any permission they had. Write allows pushing and managing branches and tags; admin also allows changing settings and collaborators. Requires admin access.",
+		Tags:        []string{"Collaborators"},
+		RequestBody: dto.AddCollaboratorRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Collaborator saved",
+				Model:       dto.CollaboratorResponse{},
+			},
+			400: {
+				Description: "Invalid permission or unknown user",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("DELETE", "/api/v1/repos/:owner/:repo/collaborators/:username", openapi.RouteDocs{
+		Summary:     "Remove collaborator",
+		Description: "Revoke the access of a collaborator. Requires admin access.",
+		Tags:        []string{"Collaborators"},
+		Responses: map[int]openapi.ResponseDoc{
+			204: {
+				Description: "Collaborator removed",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository or collaborator not found",
+			},
+		},
+	})
+
+	// Collaborator routes
+	collaborators := v1.Group("/repos/:owner/:repo/collaborators")
+	collaborators.Use(authMiddleware.RequireAuth())
+	{
+		collaborators.GET("", h.ListCollaborators)
+		collaborators.POST("", h.AddCollaborator)
+		collaborators.DELETE("/:username", h.RemoveCollaborator)
+	}
+}
@@ -0,0 +1,100 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// commitStatusRouter sets up commit status routes
+func (r *Router) commitStatusRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewCommitStatusHandler(
+		r.Deps.RepoService,
+		r.Deps.CommitStatuses,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/statuses/:sha", openapi.RouteDocs{
+		Summary: "Create a commit status",
+		Description: "Report the outcome of a check on a commit, identified by its full hash. Statuses are never updated: post a new one with the same context, " +
+			"and the latest one of each context counts. CI sets statuses on the commits it builds under the ci context, or ci/<stage>/<job> for staged runs. Requires write access.",
+		Tags:        []string{"Repositories"},
+		RequestBody: dto.CreateCommitStatusRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			201: {
+				Description: "Status created",
+				Model:       dto.CommitStatusResponse{},
+			},
+			400: {
+				Description: "Invalid hash, state, context, description or target URL",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Write access required",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+			422: {
+				Description: "Commit not found in the repository",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/statuses/:sha", openapi.RouteDocs{
+		Summary:     "List commit statuses",
+		Description: "List every status reported for a commit, most recent first, including those replaced by a later status of the same context. Paginate with ?page= and ?per_page= (default 20, max 100).",
+		Tags:        []string{"Repositories"},
+		Parameters:  pagination.Resources.Parameters(),
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.ListCommitStatusesResponse{},
+			},
+			400: {
+				Description: "Invalid pagination parameter",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/commits/:sha/status", openapi.RouteDocs{
+		Summary: "Get the combined status of a commit",
+		Description: "Get the latest status of each context of a commit and the state they add up to: failure if any context failed or errored, " +
+			"pending if any is pending or there are none, success if all succeeded. The statuses are paginated with ?page= and ?per_page= (default 20, max 100); the state covers all of them.",
+		Tags:       []string{"Repositories"},
+		Parameters: pagination.Resources.Parameters(),
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.CombinedCommitStatusResponse{},
+			},
+			400: {
+				Description: "Invalid pagination parameter",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	// Commit status routes
+	repos := v1.Group("/repos/:owner/:repo")
+	{
+		repos.POST("/statuses/:sha", authMiddleware.RequireAuth(), h.CreateStatus)
+		repos.GET("/statuses/:sha", authMiddleware.Authenticate(), h.ListStatuses)
+		repos.GET("/commits/:sha/status", authMiddleware.Authenticate(), h.GetCombinedStatus)
+	}
+}
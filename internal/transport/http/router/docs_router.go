@@ -2,17 +2,75 @@ package router
 
 import (
 	"net/http"
+	"strings"
+	"sync"
 
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/apiversion"
 	"github.com/bravo68web/stasis/pkg/openapi"
 	"github.com/gin-gonic/gin"
 )
 
+// Security schemes of the OpenAPI spec
+const (
+	bearerAuth   = "bearerAuth"
+	basicAuth    = "basicAuth"
+	runnerAPIKey = "runnerApiKey"
+)
+
+// securitySchemes are the ways requests authenticate, see AuthMiddleware and
+// CIRunnerMiddleware
+var securitySchemes = map[string]openapi.SecurityScheme{
+	bearerAuth: {
+		Type:        "http",
+		Scheme:      "bearer",
+		Description: "A session token from the OIDC login or a personal access token. Personal access tokens are also accepted as Authorization: token <PAT>.",
+	},
+	basicAuth: {
+		Type:        "http",
+		Scheme:      "basic",
+		Description: "Git over HTTP: any username with a personal access token as the password.",
+	},
+	runnerAPIKey: {
+		Type:        "apiKey",
+		In:          "header",
+		Name:        "X-API-Key",
+		Description: "The CI API key (ci.api_key), allowing the CI runner to report any job. A job's own callback token is sent as a bearer token instead.",
+	},
+}
+
+// routeSecurity returns the credentials a route accepts. REST API routes
+// documenting a 401 response need a token, at least for private
+// repositories; other REST API routes take one optionally.
+func routeSecurity(method, path string, docs openapi.RouteDocs) []openapi.SecurityRequirement {
+	switch {
+	case strings.HasPrefix(path, "/api/v1/ci/"):
+		return []openapi.SecurityRequirement{{runnerAPIKey: {}}, {bearerAuth: {}}}
+	case strings.HasPrefix(path, "/api/"):
+		if _, ok := docs.Responses[http.StatusUnauthorized]; ok {
+			return []openapi.SecurityRequirement{{bearerAuth: {}}}
+		}
+		return []openapi.SecurityRequirement{{bearerAuth: {}}, {}}
+	case strings.HasPrefix(path, gitRoutePrefix+"/"):
+		return []openapi.SecurityRequirement{{basicAuth: {}}, {}}
+	}
+	return nil
+}
+
+// OpenAPIVersionFile returns the path of the OpenAPI spec of an API version,
+// relative to the working directory and to the server root
+func OpenAPIVersionFile(version string) string {
+	return "docs/openapi-" + version + ".yaml"
+}
+
 func (r *Router) docsRouter() {
+	r.server.OpenAPIGenerator.SetSecurity(securitySchemes, routeSecurity)
+	r.server.OpenAPIGenerator.SetErrorSchema("Error", dto.ErrorResponse{})
 
 	// Register OpenAPI Docs for the spec file
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/docs/openapi.yaml", openapi.RouteDocs{
 		Summary:     "Get OpenAPI Spec",
-		Description: "Download the OpenAPI specification in YAML format",
+		Description: "Download the OpenAPI specification in YAML format, for the API version of requests without the X-Githut-Api-Version header. The spec of each version is at /docs/openapi-<version>.yaml",
 		Tags:        []string{"Documentation"},
 		Responses: map[int]openapi.ResponseDoc{
 			200: {
@@ -35,6 +93,64 @@ func (r *Router) docsRouter() {
 	// Serve the OpenAPI spec file
 	r.server.Engine.StaticFile("/docs/openapi.yaml", "docs/openapi.yaml")
 
+	// Serve the spec of each API version
+	for _, version := range apiversion.Supported {
+		r.server.OpenAPIGenerator.RegisterDocs("GET", "/"+OpenAPIVersionFile(version), openapi.RouteDocs{
+			Summary:     "Get OpenAPI Spec of API version " + version,
+			Description: "Download the OpenAPI specification of requests sending X-Githut-Api-Version: " + version,
+			Tags:        []string{"Documentation"},
+			Responses: map[int]openapi.ResponseDoc{
+				200: {
+					Description: "OpenAPI YAML file",
+				},
+			},
+		})
+		r.server.OpenAPIGenerator.RegisterDocs("HEAD", "/"+OpenAPIVersionFile(version), openapi.RouteDocs{
+			Summary:     "Check OpenAPI Spec of API version " + version,
+			Description: "Check availability of the OpenAPI specification of API version " + version,
+			Tags:        []string{"Documentation"},
+			Responses: map[int]openapi.ResponseDoc{
+				200: {
+					Description: "OpenAPI YAML file is available",
+				},
+			},
+		})
+		r.server.Engine.StaticFile("/"+OpenAPIVersionFile(version), OpenAPIVersionFile(version))
+	}
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/openapi.json", openapi.RouteDocs{
+		Summary:     "Get OpenAPI Spec as JSON",
+		Description: "Get the OpenAPI specification in JSON format, for the API version of the request (X-Githut-Api-Version header)",
+		Tags:        []string{"Documentation"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "OpenAPI JSON document",
+			},
+		},
+	})
+
+	// Serve the spec as JSON, generated once per API version: every route
+	// is registered before the server takes requests
+	var (
+		specsMu sync.Mutex
+		specs   = make(map[string]*openapi.OpenAPI)
+	)
+	r.server.Engine.GET("/api/openapi.json", func(c *gin.Context) {
+		version := apiversion.FromContext(c.Request.Context())
+		specsMu.Lock()
+		spec, ok := specs[version]
+		if !ok {
+			spec = r.server.OpenAPIGenerator.GenerateVersion(version)
+			specs[version] = spec
+		}
+		specsMu.Unlock()
+		c.JSON(http.StatusOK, spec)
+	})
+
+	if r.server.Config.Server.SwaggerUI {
+		r.swaggerUIRouter()
+	}
+
 	// Register OpenAPI Docs for the documentation UI
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/docs", openapi.RouteDocs{
 		Summary:     "API Documentation",
@@ -77,3 +193,45 @@ func (r *Router) docsRouter() {
 		c.String(http.StatusOK, html)
 	})
 }
+
+// swaggerUIRouter serves a Swagger UI of the JSON spec
+func (r *Router) swaggerUIRouter() {
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/docs", openapi.RouteDocs{
+		Summary:     "Swagger UI",
+		Description: "View the API documentation in Swagger UI, enabled with server.swagger_ui",
+		Tags:        []string{"Documentation"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "HTML Documentation",
+			},
+		},
+	})
+
+	r.server.Engine.GET("/api/docs", func(c *gin.Context) {
+		html := `
+<!doctype html>
+<html>
+  <head>
+    <title>Stasis API Documentation</title>
+    <meta charset="utf-8" />
+    <meta
+      name="viewport"
+      content="width=device-width, initial-scale=1" />
+    <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui.css" />
+  </head>
+  <body>
+    <div id="swagger-ui"></div>
+    <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+    <script>
+      window.ui = SwaggerUIBundle({
+        url: "/api/openapi.json",
+        dom_id: "#swagger-ui",
+      });
+    </script>
+  </body>
+</html>
+`
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.String(http.StatusOK, html)
+	})
+}
@@ -0,0 +1,141 @@
+package router_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/application/service"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+// getJSON sends a GET request with a token, fails the test unless it
+// succeeds, and decodes the response into v. It returns the raw response.
+func getJSON(t *testing.T, env *testutil.Env, path, token string, v any) []byte {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, env.URL(path), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "token "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		t.Fatalf("GET %s: status %d, invalid JSON: %v", path, resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: status %d, want 200: %s", path, resp.StatusCode, raw)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	return raw
+}
+
+// A repository created through the API reads as empty, with well-formed
+// empty lists rather than errors, until its first push
+func TestEmptyRepositoryUntilFirstPush(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	env := testutil.SharedEnv(t)
+	owner := env.CreateUser(t, "empty")
+	token := env.CreateToken(t, owner, models.TokenScopeRepoRead, models.TokenScopeRepoWrite)
+
+	if status := do(t, env, http.MethodPost, "/api/v1/repos", token, `{"name":"fresh","is_private":true}`); status != http.StatusCreated {
+		t.Fatalf("create repository: status = %d, want %d", status, http.StatusCreated)
+	}
+	base := "/api/v1/repos/" + owner.Username + "/fresh"
+
+	// isEmpty checks every read of the repository against its state
+	isEmpty := func(t *testing.T, want bool) {
+		t.Helper()
+		var repo dto.RepoResponse
+		getJSON(t, env, base, token, &repo)
+		if repo.IsEmpty == nil || *repo.IsEmpty != want {
+			t.Errorf("is_empty = %v, want %v", repo.IsEmpty, want)
+		}
+
+		var branches dto.BranchListResponse
+		raw := getJSON(t, env, base+"/branches", token, &branches)
+		if want && (!strings.Contains(string(raw), `"branches":[]`) || branches.Total != 0) {
+			t.Errorf("branches of an empty repository = %s, want []", raw)
+		}
+		if !want && (len(branches.Branches) != 1 || branches.Branches[0].Name != "main") {
+			t.Errorf("branches after the push = %s, want main", raw)
+		}
+
+		var tags dto.TagListResponse
+		if raw := getJSON(t, env, base+"/tags", token, &tags); !strings.Contains(string(raw), `"tags":[]`) {
+			t.Errorf("tags = %s, want []", raw)
+		}
+
+		var commits dto.CommitListResponse
+		raw = getJSON(t, env, base+"/commits", token, &commits)
+		if want && (!strings.Contains(string(raw), `"commits":[]`) || commits.Pagination.Total == nil || *commits.Pagination.Total != 0) {
+			t.Errorf("commits of an empty repository = %s, want [] with a total of 0", raw)
+		}
+		if !want && (len(commits.Commits) != 1 || strings.TrimSpace(commits.Commits[0].Message) != "Initial commit") {
+			t.Errorf("commits after the push = %s, want the pushed one", raw)
+		}
+
+		var tree dto.TreeResponse
+		raw = getJSON(t, env, base+"/tree/HEAD", token, &tree)
+		if want && !strings.Contains(string(raw), `"entries":[]`) {
+			t.Errorf("tree of an empty repository = %s, want []", raw)
+		}
+		if !want && (len(tree.Entries) != 1 || tree.Entries[0].Name != "README.md") {
+			t.Errorf("tree after the push = %s, want README.md", raw)
+		}
+
+		var stats service.RepositoryStats
+		getJSON(t, env, base+"/stats", token, &stats)
+		wantCount := 1
+		if want {
+			wantCount = 0
+		}
+		if stats.BranchCount != wantCount || stats.TotalCommits != wantCount || stats.TagCount != 0 {
+			t.Errorf("stats = %d branches, %d commits, %d tags, want %d, %d, 0",
+				stats.BranchCount, stats.TotalCommits, stats.TagCount, wantCount, wantCount)
+		}
+
+		var contributors dto.ContributorStatsResponse
+		getJSON(t, env, base+"/stats/contributors", token, &contributors)
+		if len(contributors.Contributors) != wantCount {
+			t.Errorf("%d contributors, want %d", len(contributors.Contributors), wantCount)
+		}
+		var languages any
+		getJSON(t, env, base+"/languages", token, &languages)
+	}
+
+	t.Run("before the first push", func(t *testing.T) { isEmpty(t, true) })
+
+	// The advertisement of an empty repository lists no refs, and takes the
+	// first push
+	cloneURL := strings.Replace(env.CloneURL(owner.Username, "fresh"), "://", "://"+owner.Username+":"+token+"@", 1)
+	work := t.TempDir()
+	if refs := git(t, work, nil, "ls-remote", cloneURL); refs != "" {
+		t.Fatalf("refs of an empty repository:\n%s", refs)
+	}
+	git(t, work, nil, "init", "--quiet", "--initial-branch=main")
+	if err := os.WriteFile(filepath.Join(work, "README.md"), []byte("# fresh\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, work, nil, "add", "README.md")
+	git(t, work, nil, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "Initial commit")
+	git(t, work, nil, "push", "--quiet", cloneURL, "main")
+
+	t.Run("after the first push", func(t *testing.T) { isEmpty(t, false) })
+}
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// eventRouter sets up event bus administration routes
+func (r *Router) eventRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewEventHandler(r.Deps.EventBus)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/admin/events/metrics", openapi.RouteDocs{
+		Summary:     "Get event bus metrics",
+		Description: "Get the number of internal events published, consumed and dropped per event type since the server started",
+		Tags:        []string{"Admin"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.EventMetricsResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+		},
+	})
+
+	// Admin event routes
+	admin := v1.Group("/admin/events", authMiddleware.RequireAdmin())
+	{
+		admin.GET("/metrics", h.GetMetrics)
+	}
+}
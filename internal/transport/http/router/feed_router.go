@@ -0,0 +1,179 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// feedAccessDocs documents the access and caching rules shared by all feeds
+const feedAccessDocs = "Feeds of private repositories need the ?token= feed token of a user who can read the repository. Feeds are cached for a minute and support ETag and Last-Modified conditional requests."
+
+// feedRouter sets up the repository feed and feed token routes
+func (r *Router) feedRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewFeedHandler(
+		r.Deps.RepoService,
+		r.Deps.Feeds,
+		r.Deps.AuditEvents,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/releases.atom", openapi.RouteDocs{
+		Summary:     "Repository releases feed (Atom)",
+		Description: "Atom feed of the 50 newest tags of a repository, with tag messages rendered from markdown. " + feedAccessDocs,
+		Tags:        []string{"Feeds"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "The feed",
+				Model:       dto.AtomFeed{},
+			},
+			http.StatusNotModified: {
+				Description: "The feed has not changed",
+			},
+			http.StatusNotFound: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/releases.json", openapi.RouteDocs{
+		Summary:     "Repository releases feed (JSON Feed)",
+		Description: "JSON Feed of the 50 newest tags of a repository, with tag messages rendered from markdown. " + feedAccessDocs,
+		Tags:        []string{"Feeds"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "The feed",
+				Model:       dto.JSONFeed{},
+			},
+			http.StatusNotModified: {
+				Description: "The feed has not changed",
+			},
+			http.StatusNotFound: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/activity.atom", openapi.RouteDocs{
+		Summary:     "Repository activity feed (Atom)",
+		Description: "Atom feed of the 50 newest recorded actions on a repository. Client IP addresses are never included. " + feedAccessDocs,
+		Tags:        []string{"Feeds"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "The feed",
+				Model:       dto.AtomFeed{},
+			},
+			http.StatusNotModified: {
+				Description: "The feed has not changed",
+			},
+			http.StatusNotFound: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/activity.json", openapi.RouteDocs{
+		Summary:     "Repository activity feed (JSON Feed)",
+		Description: "JSON Feed of the 50 newest recorded actions on a repository. Client IP addresses are never included. " + feedAccessDocs,
+		Tags:        []string{"Feeds"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "The feed",
+				Model:       dto.JSONFeed{},
+			},
+			http.StatusNotModified: {
+				Description: "The feed has not changed",
+			},
+			http.StatusNotFound: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/users/feed-token", openapi.RouteDocs{
+		Summary:     "Get feed token",
+		Description: "Returns the feed token of the authenticated user",
+		Tags:        []string{"Feeds"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Feed token",
+				Model:       dto.FeedTokenResponse{},
+			},
+			http.StatusUnauthorized: {
+				Description: "Authentication required",
+			},
+			http.StatusNotFound: {
+				Description: "The user has no feed token",
+			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/users/feed-token", openapi.RouteDocs{
+		Summary:     "Create feed token",
+		Description: "Creates a feed token for the authenticated user, revoking the previous one. The token only grants reading the feeds of the repositories the user can read.",
+		Tags:        []string{"Feeds"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusCreated: {
+				Description: "Feed token created",
+				Model:       dto.FeedTokenResponse{},
+			},
+			http.StatusUnauthorized: {
+				Description: "Authentication required",
+			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("DELETE", "/api/v1/users/feed-token", openapi.RouteDocs{
+		Summary:     "Revoke feed token",
+		Description: "Revokes the feed token of the authenticated user",
+		Tags:        []string{"Feeds"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Feed token revoked successfully",
+				Model:       map[string]string{"message": "Feed token revoked successfully"},
+			},
+			http.StatusUnauthorized: {
+				Description: "Authentication required",
+			},
+			http.StatusNotFound: {
+				Description: "The user has no feed token",
+			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	// Repository feed routes
+	repos := v1.Group("/repos/:owner/:repo")
+	{
+		repos.GET("/releases.atom", authMiddleware.Authenticate(), h.ReleasesAtom)
+		repos.GET("/releases.json", authMiddleware.Authenticate(), h.ReleasesJSON)
+		repos.GET("/activity.atom", authMiddleware.Authenticate(), h.ActivityAtom)
+		repos.GET("/activity.json", authMiddleware.Authenticate(), h.ActivityJSON)
+	}
+
+	// Feed token routes (require authentication)
+	users := v1.Group("/users", authMiddleware.RequireAuth(), authMiddleware.RequireScope(models.TokenScopeAdmin))
+	{
+		users.GET("/feed-token", h.GetFeedToken)
+		users.POST("/feed-token", h.CreateFeedToken)
+		users.DELETE("/feed-token", h.RevokeFeedToken)
+	}
+}
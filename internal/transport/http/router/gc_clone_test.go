@@ -0,0 +1,73 @@
+package router_test
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/testutil"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// Clones must succeed while the repository is repacked under them, its packs
+// replaced and its loose objects pruned; v0 clients are served the ref
+// advertisement pinned when the collection started.
+func TestCloneDuringRepack(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	env := testutil.SharedEnv(t)
+	owner := env.CreateAdmin(t, "collector")
+	repo, b := env.CreateRepository(t, owner, "churn", false)
+	for i := range 50 {
+		b.Commit("main", fmt.Sprintf("Commit %d", i),
+			testutil.File(fmt.Sprintf("src/file%d.txt", i%10), fmt.Sprintf("revision %d\n", i)))
+	}
+	want := git(t, b.Path(), nil, "rev-parse", "main")
+	url := env.CloneURL(owner.Username, repo.Name)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var collections int
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for ctx.Err() == nil {
+			// Each collection writes a new pack and deletes the previous one
+			_, err := env.Deps.GarbageCollection.CollectRepository(ctx, repo, owner)
+			if err != nil && !apperrors.IsConflict(err) && ctx.Err() == nil {
+				t.Errorf("CollectRepository: %v", err)
+				return
+			}
+			collections++
+		}
+	}()
+
+	t.Run("clones", func(t *testing.T) {
+		for _, version := range []string{"0", "2"} {
+			for i := range 5 {
+				t.Run(fmt.Sprintf("v%s/%d", version, i), func(t *testing.T) {
+					t.Parallel()
+					dir := t.TempDir()
+					git(t, dir, nil, "-c", "protocol.version="+version, "clone", "--quiet", "--bare", url, "clone.git")
+					clone := filepath.Join(dir, "clone.git")
+					if got := git(t, clone, nil, "rev-parse", "main"); got != want {
+						t.Errorf("main at %s, want %s", got, want)
+					}
+					git(t, clone, nil, "fsck", "--no-progress")
+				})
+			}
+		}
+	})
+	cancel()
+	<-collected
+
+	if collections == 0 {
+		t.Error("no collection ran during the clones")
+	}
+	if got := env.Deps.StorageBackends.PinnedAdvertisement(repo.ID); got != nil {
+		t.Errorf("advertisement still pinned after the collections: %q", got)
+	}
+}
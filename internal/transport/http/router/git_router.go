@@ -3,11 +3,15 @@ package router
 import (
 	"net/http"
 
+	"github.com/bravo68web/stasis/internal/application/dto"
 	"github.com/bravo68web/stasis/internal/transport/http/handler"
 	"github.com/bravo68web/stasis/internal/transport/http/middleware"
 	"github.com/bravo68web/stasis/pkg/openapi"
 )
 
+// gitRoutePrefix is the path prefix of the git HTTP transport routes
+const gitRoutePrefix = "/:owner/:repo"
+
 func (r *Router) gitRouter() {
 	// Initialize auth middleware
 	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
@@ -16,9 +20,22 @@ func (r *Router) gitRouter() {
 	h := handler.NewGitHandler(
 		r.Deps.GitService,
 		r.Deps.RepoService,
+		r.Deps.TagProtection,
+		r.Deps.BranchProtection,
+		r.Deps.Contributions,
+		r.Deps.Licenses,
+		r.Deps.PinnedLinks,
+		r.Deps.Languages,
 		r.Deps.AuthService,
-		r.Deps.Storage,
+		r.Deps.StorageBackends,
 		r.Deps.CIService,
+		r.Deps.CIJobTokens,
+		r.Deps.GitProtocol,
+		r.Deps.PushAttempts,
+		r.Deps.LFS,
+		r.Deps.Quotas,
+		r.Deps.DegradedMode,
+		r.Deps.EventBus,
 	)
 
 	// Register Docs
@@ -29,6 +46,7 @@ func (r *Router) gitRouter() {
 		Responses: map[int]openapi.ResponseDoc{
 			http.StatusOK:           {Description: "Git references advertisement"},
 			http.StatusUnauthorized: {Description: "Authentication required"},
+			http.StatusNotFound:     {Description: "Repository not found"},
 		},
 	})
 
@@ -39,6 +57,7 @@ func (r *Router) gitRouter() {
 		Responses: map[int]openapi.ResponseDoc{
 			http.StatusOK:           {Description: "Pack data"},
 			http.StatusUnauthorized: {Description: "Authentication required"},
+			http.StatusNotFound:     {Description: "Repository not found"},
 		},
 	})
 
@@ -49,6 +68,55 @@ func (r *Router) gitRouter() {
 		Responses: map[int]openapi.ResponseDoc{
 			http.StatusOK:           {Description: "Push status"},
 			http.StatusUnauthorized: {Description: "Authentication required"},
+			http.StatusNotFound:     {Description: "Repository not found"},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/:owner/:repo/info/lfs/objects/batch", openapi.RouteDocs{
+		Summary:     "Git LFS batch",
+		Description: "Git LFS batch API: returns the upload or download actions for the listed objects, using the basic transfer adapter",
+		Tags:        []string{"Git Protocol"},
+		RequestBody: dto.LFSBatchRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK:           {Description: "Object actions", Model: dto.LFSBatchResponse{}},
+			http.StatusUnauthorized: {Description: "Authentication required"},
+			http.StatusNotFound:     {Description: "Repository not found"},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PUT", "/:owner/:repo/info/lfs/objects/:oid", openapi.RouteDocs{
+		Summary:     "Upload Git LFS object",
+		Description: "Store a Git LFS object. The body must hash to the object id (SHA-256) and be as long as Content-Length. Objects larger than repos.max_lfs_object_size, or that would take the repository or its owner over quota, are refused.",
+		Tags:        []string{"Git Protocol"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK:                    {Description: "Object stored"},
+			http.StatusBadRequest:            {Description: "Content does not match the object id or length"},
+			http.StatusUnauthorized:          {Description: "Authentication required"},
+			http.StatusRequestEntityTooLarge: {Description: "Object is larger than the maximum object size"},
+			http.StatusUnprocessableEntity:   {Description: "Object would exceed the size quota"},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/:owner/:repo/info/lfs/objects/verify", openapi.RouteDocs{
+		Summary:     "Verify Git LFS object",
+		Description: "Confirm a Git LFS object was stored with the expected size",
+		Tags:        []string{"Git Protocol"},
+		RequestBody: dto.LFSObjectRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK:           {Description: "Object stored"},
+			http.StatusNotFound:     {Description: "Object does not exist"},
+			http.StatusUnauthorized: {Description: "Authentication required"},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/:owner/:repo/info/lfs/objects/:oid", openapi.RouteDocs{
+		Summary:     "Download Git LFS object",
+		Description: "Download a Git LFS object",
+		Tags:        []string{"Git Protocol"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK:           {Description: "Object content"},
+			http.StatusNotFound:     {Description: "Object does not exist"},
+			http.StatusUnauthorized: {Description: "Authentication required"},
 		},
 	})
 
@@ -59,6 +127,7 @@ func (r *Router) gitRouter() {
 		Responses: map[int]openapi.ResponseDoc{
 			http.StatusOK:           {Description: "HEAD content"},
 			http.StatusUnauthorized: {Description: "Authentication required"},
+			http.StatusNotFound:     {Description: "Repository not found"},
 		},
 	})
 
@@ -69,6 +138,7 @@ func (r *Router) gitRouter() {
 		Responses: map[int]openapi.ResponseDoc{
 			http.StatusOK:           {Description: "Packs info content"},
 			http.StatusUnauthorized: {Description: "Authentication required"},
+			http.StatusNotFound:     {Description: "Repository not found"},
 		},
 	})
 
@@ -79,6 +149,7 @@ func (r *Router) gitRouter() {
 		Responses: map[int]openapi.ResponseDoc{
 			http.StatusOK:           {Description: "Alternates info content"},
 			http.StatusUnauthorized: {Description: "Authentication required"},
+			http.StatusNotFound:     {Description: "Repository not found"},
 		},
 	})
 
@@ -89,6 +160,7 @@ func (r *Router) gitRouter() {
 		Responses: map[int]openapi.ResponseDoc{
 			http.StatusOK:           {Description: "Pack file content"},
 			http.StatusUnauthorized: {Description: "Authentication required"},
+			http.StatusNotFound:     {Description: "Repository not found"},
 		},
 	})
 
@@ -99,6 +171,7 @@ func (r *Router) gitRouter() {
 		Responses: map[int]openapi.ResponseDoc{
 			http.StatusOK:           {Description: "Object content"},
 			http.StatusUnauthorized: {Description: "Authentication required"},
+			http.StatusNotFound:     {Description: "Repository not found"},
 		},
 	})
 
@@ -109,6 +182,7 @@ func (r *Router) gitRouter() {
 		Responses: map[int]openapi.ResponseDoc{
 			http.StatusOK:           {Description: "Branch reference content"},
 			http.StatusUnauthorized: {Description: "Authentication required"},
+			http.StatusNotFound:     {Description: "Repository not found"},
 		},
 	})
 
@@ -119,6 +193,7 @@ func (r *Router) gitRouter() {
 		Responses: map[int]openapi.ResponseDoc{
 			http.StatusOK:           {Description: "Tag reference content"},
 			http.StatusUnauthorized: {Description: "Authentication required"},
+			http.StatusNotFound:     {Description: "Repository not found"},
 		},
 	})
 
@@ -128,8 +203,8 @@ func (r *Router) gitRouter() {
 
 	// Create a group for git operations
 	// Pattern: /:owner/:repo.git/... (repos accessed with .git suffix for git operations)
-	gitGroup := r.server.Group("/:owner/:repo")
-	gitGroup.Use(authMiddleware.Authenticate())
+	gitGroup := r.server.Group(gitRoutePrefix)
+	gitGroup.Use(authMiddleware.AuthenticateGit())
 	{
 		// Git info/refs endpoint - used for capability advertisement
 		// GET /:owner/:repo/info/refs?service=git-upload-pack|git-receive-pack
@@ -143,6 +218,13 @@ func (r *Router) gitRouter() {
 		// POST /:owner/:repo/git-receive-pack
 		gitGroup.POST("/git-receive-pack", h.HandleReceivePack)
 
+		// Git LFS batch API and basic transfer object endpoints
+		// POST /:owner/:repo/info/lfs/objects/batch
+		gitGroup.POST("/info/lfs/objects/batch", h.HandleLFSBatch)
+		gitGroup.POST("/info/lfs/objects/verify", h.HandleLFSVerify)
+		gitGroup.PUT("/info/lfs/objects/:oid", h.HandleLFSUpload)
+		gitGroup.GET("/info/lfs/objects/:oid", h.HandleLFSDownload)
+
 		// Dumb HTTP Protocol fallback routes
 		// These are used by older git clients or when smart protocol is not available
 
@@ -0,0 +1,117 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// gpgKeyRouter sets up GPG key management routes
+func (r *Router) gpgKeyRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	gpgKeyHandler := handler.NewGPGKeyHandler(r.Deps.GPGKeys, r.Deps.AuditEvents)
+
+	// Register Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/gpg-keys", openapi.RouteDocs{
+		Summary:     "List GPG keys",
+		Description: "Returns all GPG keys for the authenticated user",
+		Tags:        []string{"GPG Keys"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "List of GPG keys",
+				Model:       dto.ListGPGKeysResponse{},
+			},
+			http.StatusUnauthorized: {
+				Description: "Authentication required",
+			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/gpg-keys", openapi.RouteDocs{
+		Summary:     "Add GPG key",
+		Description: "Adds an ASCII-armored OpenPGP public key for the authenticated user. Commits whose author email is both an email of the key and the email of the user are verified with it.",
+		Tags:        []string{"GPG Keys"},
+		RequestBody: dto.AddGPGKeyRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusCreated: {
+				Description: "GPG key added successfully",
+				Model:       dto.GPGKeyInfo{},
+			},
+			http.StatusBadRequest: {
+				Description: "Invalid GPG key",
+			},
+			http.StatusUnauthorized: {
+				Description: "Authentication required",
+			},
+			http.StatusConflict: {
+				Description: "GPG key already exists",
+			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/gpg-keys/:id", openapi.RouteDocs{
+		Summary:     "Get GPG key",
+		Description: "Returns a specific GPG key by ID",
+		Tags:        []string{"GPG Keys"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "GPG key information",
+				Model:       dto.GPGKeyInfo{},
+			},
+			http.StatusUnauthorized: {
+				Description: "Authentication required",
+			},
+			http.StatusNotFound: {
+				Description: "GPG key not found",
+			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("DELETE", "/api/v1/gpg-keys/:id", openapi.RouteDocs{
+		Summary:     "Delete GPG key",
+		Description: "Deletes a GPG key by ID",
+		Tags:        []string{"GPG Keys"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "GPG key deleted successfully",
+				Model:       map[string]string{"message": "GPG key deleted successfully"},
+			},
+			http.StatusUnauthorized: {
+				Description: "Authentication required",
+			},
+			http.StatusNotFound: {
+				Description: "GPG key not found",
+			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	// GPG key routes (require authentication)
+	gpgKeyGroup := v1.Group("/gpg-keys", authMiddleware.RequireAuth(), authMiddleware.RequireScope(models.TokenScopeAdmin))
+	{
+		gpgKeyGroup.POST("", gpgKeyHandler.AddGPGKey)
+		gpgKeyGroup.GET("", gpgKeyHandler.ListGPGKeys)
+		gpgKeyGroup.GET("/:id", gpgKeyHandler.GetGPGKey)
+		gpgKeyGroup.DELETE("/:id", gpgKeyHandler.DeleteGPGKey)
+	}
+}
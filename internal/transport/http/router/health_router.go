@@ -3,6 +3,10 @@ package router
 import (
 	"net/http"
 
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/server"
 	"github.com/bravo68web/stasis/internal/transport/http/handler"
 	"github.com/bravo68web/stasis/pkg/openapi"
 )
@@ -19,5 +23,49 @@ func (r *Router) healthRouter() {
 		},
 	})
 
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/healthz", openapi.RouteDocs{
+		Summary:     "Liveness check",
+		Description: "Returns 200 while the process serves requests, including while it waits for its database",
+		Tags:        []string{"Health"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Process is alive",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/readyz", openapi.RouteDocs{
+		Summary:     "Readiness check",
+		Description: "Returns the startup state of the server: starting, degraded (health endpoints only, database not available) or ready. With degraded mode enabled, the body carries its state and metrics, and a server serving public repositories read-only from its snapshot while the database is unreachable answers 200 with state degraded.",
+		Tags:        []string{"Health"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Server is ready, or serving public repositories read-only in degraded mode",
+				Model:       dto.ReadinessResponse{},
+			},
+			http.StatusServiceUnavailable: {
+				Description: "Server is starting or degraded",
+				Model:       dto.ReadinessResponse{},
+			},
+		},
+	})
+
 	r.server.GET("/", handler.HealthHandler())
+	r.server.GET("/healthz", handler.LivenessHandler())
+	r.server.GET("/readyz", handler.ReadinessHandler(r.server.Readiness, r.server.DB.Ping, r.Deps.DegradedMode))
+}
+
+// DegradedHandler returns what the HTTP server serves until the database is
+// available: the health endpoints, and 503 with Retry-After for every other
+// route since they all need the database
+func DegradedHandler(s *server.Server) http.Handler {
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+
+	engine.GET("/", handler.HealthHandler())
+	engine.GET("/healthz", handler.LivenessHandler())
+	engine.GET("/readyz", handler.ReadinessHandler(s.Readiness, nil, nil))
+	engine.NoRoute(handler.NotReadyHandler())
+
+	return engine
 }
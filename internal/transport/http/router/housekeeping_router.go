@@ -0,0 +1,87 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// housekeepingRouter sets up housekeeping scheduler routes
+func (r *Router) housekeepingRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewHousekeepingHandler(r.Deps.Housekeeping)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/admin/housekeeping", openapi.RouteDocs{
+		Summary: "List housekeeping tasks",
+		Description: "List the periodic maintenance tasks with their interval, next run and the outcome of their last run on any replica. " +
+			"Metrics count the runs of the replica serving the request since it started.",
+		Tags: []string{"Admin"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.HousekeepingTaskListResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/admin/housekeeping/:name/run", openapi.RouteDocs{
+		Summary:     "Run a housekeeping task",
+		Description: "Make a housekeeping task due now. It runs in the background on one replica; its outcome is shown in the task list.",
+		Tags:        []string{"Admin"},
+		Responses: map[int]openapi.ResponseDoc{
+			202: {
+				Description: "Task scheduled",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+			404: {
+				Description: "Housekeeping task not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/admin/ci/cleanup", openapi.RouteDocs{
+		Summary:     "Run the CI cleanup",
+		Description: "Make the CI housekeeping tasks (ci.*: clone tokens, stored artifacts, artifacts of deleted jobs, staged runs) due now. They run in the background, each on one replica and never twice at once; their outcome is shown in the housekeeping task list.",
+		Tags:        []string{"Admin"},
+		Responses: map[int]openapi.ResponseDoc{
+			202: {
+				Description: "Tasks scheduled",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+			404: {
+				Description: "No CI housekeeping task is registered",
+			},
+		},
+	})
+
+	// Admin housekeeping routes
+	admin := v1.Group("/admin/housekeeping", authMiddleware.RequireAdmin())
+	{
+		admin.GET("", h.ListTasks)
+		admin.POST("/:name/run", h.TriggerTask)
+	}
+	v1.POST("/admin/ci/cleanup", authMiddleware.RequireAdmin(), h.TriggerCICleanup)
+}
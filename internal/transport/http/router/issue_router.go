@@ -0,0 +1,188 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// issueRouter sets up issue and issue comment routes
+func (r *Router) issueRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewIssueHandler(
+		r.Deps.RepoService,
+		r.Deps.Issues,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/issues", openapi.RouteDocs{
+		Summary:     "List issues",
+		Description: "List the issues of a repository, newest first. Filter with state (open or closed) and labels (comma separated, issues must carry all of them), and paginate with page and per_page (default 20, max 100).",
+		Tags:        []string{"Issues"},
+		Parameters:  pagination.Resources.Parameters(),
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.IssueListResponse{},
+			},
+			400: {
+				Description: "Invalid state, labels or pagination parameter",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/issues", openapi.RouteDocs{
+		Summary:     "Create issue",
+		Description: "Open an issue against a repository. Issues are numbered sequentially per repository and bodies are stored as raw Markdown. Requires read access.",
+		Tags:        []string{"Issues"},
+		RequestBody: dto.CreateIssueRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			201: {
+				Description: "Issue created",
+				Model:       dto.IssueResponse{},
+			},
+			400: {
+				Description: "Invalid title, body or labels",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/issues/:number", openapi.RouteDocs{
+		Summary:     "Get issue",
+		Description: "Get an issue of a repository by number",
+		Tags:        []string{"Issues"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.IssueResponse{},
+			},
+			400: {
+				Description: "Invalid issue number",
+			},
+			404: {
+				Description: "Repository or issue not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PATCH", "/api/v1/repos/:owner/:repo/issues/:number", openapi.RouteDocs{
+		Summary:     "Update issue",
+		Description: "Change the title, body, labels or state of an issue. Omitted fields are left unchanged and labels replace the existing ones. Requires being its author or an admin of the repository.",
+		Tags:        []string{"Issues"},
+		RequestBody: dto.UpdateIssueRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Issue updated",
+				Model:       dto.IssueResponse{},
+			},
+			400: {
+				Description: "Invalid issue number, title, body, labels or state",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository or issue not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/issues/:number/close", openapi.RouteDocs{
+		Summary:     "Close issue",
+		Description: "Close an open issue. Requires being its author or an admin of the repository.",
+		Tags:        []string{"Issues"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Issue closed",
+				Model:       dto.IssueResponse{},
+			},
+			400: {
+				Description: "Invalid issue number",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository or issue not found",
+			},
+			409: {
+				Description: "Issue is already closed",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/issues/:number/comments", openapi.RouteDocs{
+		Summary:     "List issue comments",
+		Description: "List the comments on an issue, oldest first. Paginate with page and per_page (default 20, max 100).",
+		Tags:        []string{"Issues"},
+		Parameters:  pagination.Resources.Parameters(),
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.IssueCommentListResponse{},
+			},
+			400: {
+				Description: "Invalid issue number or pagination parameter",
+			},
+			404: {
+				Description: "Repository or issue not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/issues/:number/comments", openapi.RouteDocs{
+		Summary:     "Comment on issue",
+		Description: "Add a comment to an issue. The body is stored as raw Markdown. Requires read access.",
+		Tags:        []string{"Issues"},
+		RequestBody: dto.CreateIssueCommentRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			201: {
+				Description: "Comment created",
+				Model:       dto.IssueCommentResponse{},
+			},
+			400: {
+				Description: "Invalid issue number or body",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			404: {
+				Description: "Repository or issue not found",
+			},
+		},
+	})
+
+	// Issue routes
+	issues := v1.Group("/repos/:owner/:repo/issues")
+	{
+		issues.GET("", authMiddleware.Authenticate(), h.ListIssues)
+		issues.POST("", authMiddleware.RequireAuth(), h.CreateIssue)
+		issues.GET("/:number", authMiddleware.Authenticate(), h.GetIssue)
+		issues.PATCH("/:number", authMiddleware.RequireAuth(), h.UpdateIssue)
+		issues.POST("/:number/close", authMiddleware.RequireAuth(), h.CloseIssue)
+		issues.GET("/:number/comments", authMiddleware.Authenticate(), h.ListComments)
+		issues.POST("/:number/comments", authMiddleware.RequireAuth(), h.CreateComment)
+	}
+}
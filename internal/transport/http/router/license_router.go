@@ -0,0 +1,44 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// licenseRouter sets up repository license administration routes
+func (r *Router) licenseRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewLicenseHandler(r.Deps.Licenses)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/admin/licenses", openapi.RouteDocs{
+		Summary:     "Count repositories by license",
+		Description: "Get the number of repositories per effective license across the instance. Repositories without a detected license are counted as \"none\"",
+		Tags:        []string{"Admin"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.LicenseCountListResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+		},
+	})
+
+	// Admin license routes
+	admin := v1.Group("/admin", authMiddleware.RequireAdmin())
+	{
+		admin.GET("/licenses", h.ListLicenseCounts)
+	}
+}
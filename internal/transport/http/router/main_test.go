@@ -0,0 +1,9 @@
+package router_test
+
+import (
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+func TestMain(m *testing.M) { testutil.Main(m) }
@@ -0,0 +1,66 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// metaRouter sets up the public server information routes
+func (r *Router) metaRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize handler
+	h := handler.NewMetaHandler(r.Deps.SSHHostKeys, r.Deps.CommitSigner)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/meta", openapi.RouteDocs{
+		Summary:     "Get server metadata",
+		Description: "Returns public information about the server, including the SSH port and host keys",
+		Tags:        []string{"Meta"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Server metadata",
+				Model:       dto.MetaResponse{},
+			},
+			http.StatusInternalServerError: {
+				Description: "SSH host keys could not be read",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/ssh-host-keys", openapi.RouteDocs{
+		Summary: "Get SSH host keys",
+		Description: "Returns the public host keys and fingerprints of the SSH server, read from the key file the server loads, " +
+			"so clients can add them to known_hosts instead of trusting the server on first use",
+		Tags: []string{"Meta"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "SSH port and host keys",
+				Model:       dto.SSHHostKeysResponse{},
+			},
+			http.StatusInternalServerError: {
+				Description: "SSH host keys could not be read",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/meta/signing-key", openapi.RouteDocs{
+		Summary: "Get commit signing key",
+		Description: "Returns the public instance key the server signs the commits it creates with (pull request merges, " +
+			"initial commits), and the committer identity of those commits, so clients can verify them",
+		Tags: []string{"Meta"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Signing key, or enabled: false if the server does not sign commits",
+				Model:       dto.SigningKeyResponse{},
+			},
+		},
+	})
+
+	v1.GET("/meta", h.GetMeta)
+	v1.GET("/meta/signing-key", h.GetSigningKey)
+	v1.GET("/ssh-host-keys", h.GetSSHHostKeys)
+}
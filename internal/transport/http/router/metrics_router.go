@@ -0,0 +1,42 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/metrics"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// metricsRouter serves the Prometheus metrics, if enabled, and records the
+// HTTP request metrics
+func (r *Router) metricsRouter() {
+	cfg := &r.server.Config.Server.Metrics
+	if !cfg.Enabled {
+		return
+	}
+
+	r.server.Use(middleware.MetricsMiddleware(gitRoutePrefix))
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", cfg.Path, openapi.RouteDocs{
+		Summary:     "Prometheus metrics",
+		Description: "Returns the metrics of the server in the Prometheus text format: HTTP requests by route group, git operations and bytes by transport, CI jobs by status, CI event stream subscribers and storage operation latency. Requires basic auth if server.metrics.username is set.",
+		Tags:        []string{"Health"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Metrics in the Prometheus text format",
+			},
+			http.StatusUnauthorized: {
+				Description: "Basic auth is required",
+			},
+		},
+	})
+
+	handlers := []gin.HandlerFunc{gin.WrapH(metrics.Handler())}
+	if cfg.RequiresAuth() {
+		handlers = append([]gin.HandlerFunc{gin.BasicAuth(gin.Accounts{cfg.Username: cfg.Password})}, handlers...)
+	}
+	r.server.GET(cfg.Path, handlers...)
+}
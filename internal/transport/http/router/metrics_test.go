@@ -0,0 +1,101 @@
+package router_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+// After a clone and an API call, the metrics endpoint serves the HTTP, git
+// transport and runtime metrics, to clients with the configured basic auth
+func TestMetricsEndpoint(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	env, err := testutil.StartEnv(context.Background(), func(cfg *config.Config) {
+		cfg.Server.Metrics = config.MetricsConfig{Enabled: true, Path: "/metrics", Username: "prometheus", Password: "scrape"}
+	})
+	if errors.Is(err, testutil.ErrNoDatabase) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = env.Close() })
+
+	owner := env.CreateUser(t, "measured")
+	repo, b := env.CreateRepository(t, owner, "measured", false)
+	b.Commit("main", "Initial commit", testutil.File("README.md", "# demo\n"))
+
+	git(t, t.TempDir(), nil, "clone", "--quiet", "--bare", env.CloneURL(owner.Username, repo.Name), "clone.git")
+
+	req, err := http.NewRequest(http.MethodGet, env.URL("/api/v1/repos/"+owner.Username+"/"+repo.Name+"/branches"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "token "+env.CreateToken(t, owner))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("listing branches = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	scrape := func(username, password string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, env.URL("/metrics"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	if status, _ := scrape("", ""); status != http.StatusUnauthorized {
+		t.Errorf("scrape without credentials = %d, want %d", status, http.StatusUnauthorized)
+	}
+	if status, _ := scrape("prometheus", "wrong"); status != http.StatusUnauthorized {
+		t.Errorf("scrape with a wrong password = %d, want %d", status, http.StatusUnauthorized)
+	}
+	status, body := scrape("prometheus", "scrape")
+	if status != http.StatusOK {
+		t.Fatalf("scrape = %d, want %d", status, http.StatusOK)
+	}
+
+	fullName := owner.Username + "/" + repo.Name
+	for _, series := range []string{
+		`stasis_http_request_duration_seconds_count{group="api/repos",method="GET",status="200"}`,
+		`stasis_http_request_duration_seconds_count{group="git",method="GET",status="200"}`,
+		`stasis_http_request_duration_seconds_count{group="git",method="POST",status="200"}`,
+		`stasis_git_operations_total{repository="` + fullName + `",result="success",service="git-upload-pack",transport="http"}`,
+		`stasis_git_operation_duration_seconds_count{repository="` + fullName + `",service="git-upload-pack",transport="http"}`,
+		`stasis_git_transfer_bytes_total{direction="in",service="git-upload-pack",transport="http"}`,
+		`stasis_git_transfer_bytes_total{direction="out",service="git-upload-pack",transport="http"}`,
+		`go_goroutines`,
+		`process_cpu_seconds_total`,
+	} {
+		if !strings.Contains(body, "\n"+series+" ") {
+			t.Errorf("metrics have no series %s", series)
+		}
+	}
+}
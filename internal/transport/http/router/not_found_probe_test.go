@@ -0,0 +1,140 @@
+package router_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gitinfra "github.com/bravo68web/stasis/internal/infrastructure/git"
+	"github.com/bravo68web/stasis/internal/testutil"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
+)
+
+// storageSnapshot lists every file and directory under root with its size
+// and modification time
+func storageSnapshot(t *testing.T, root string) map[string]string {
+	t.Helper()
+	snapshot := make(map[string]string)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		snapshot[path] = fmt.Sprintf("%v %d %s", info.Mode(), info.Size(), info.ModTime().Format(time.RFC3339Nano))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return snapshot
+}
+
+// Probing repositories that do not exist, under owners that do or do not,
+// answers the same not found message over HTTP and SSH and creates nothing
+// on disk or in the database
+func TestProbingMissingRepositories(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	env := testutil.SharedEnv(t)
+	owner := env.CreateUser(t, "prober")
+	existing, b := env.CreateRepository(t, owner, "present", false)
+	b.Commit("main", "Initial commit", testutil.File("README.md", "# demo\n"))
+	key := env.AddSSHKey(t, owner)
+	sshCommand := "GIT_SSH_COMMAND=ssh -i " + key + " -o IdentitiesOnly=yes -o BatchMode=yes" +
+		" -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -o LogLevel=ERROR"
+	token := env.CreateToken(t, owner, "repo:read", "repo:write")
+
+	// Half under the real owner, half under owners that do not exist
+	type probe struct{ owner, name string }
+	probes := make([]probe, 0, 50)
+	for i := range 25 {
+		probes = append(probes,
+			probe{owner.Username, fmt.Sprintf("missing-%d", i)},
+			probe{fmt.Sprintf("%s-ghost-%d", owner.Username, i), existing.Name},
+		)
+	}
+
+	// Pushes are made from a repository with a commit to push
+	work := t.TempDir()
+	git(t, work, nil, "init", "--quiet")
+	git(t, work, nil, "-c", "user.name=Prober", "-c", "user.email=prober@example.com", "commit", "--quiet", "--allow-empty", "-m", "Probe")
+
+	before := storageSnapshot(t, env.StorageRoot)
+	ctx := context.Background()
+	for _, p := range probes {
+		t.Run(p.owner+"/"+p.name, func(t *testing.T) {
+			httpURL := env.CloneURL(p.owner, p.name)
+			authURL := strings.Replace(httpURL, "://", "://"+owner.Username+":"+token+"@", 1)
+
+			for _, service := range []string{"git-upload-pack", "git-receive-pack"} {
+				req, err := http.NewRequest(http.MethodGet, httpURL+"/info/refs?service="+service, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.SetBasicAuth(owner.Username, token)
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusNotFound || string(body) != gitinfra.RepositoryNotFoundMessage+"\n" {
+					t.Errorf("%s info/refs: %d %q, want 404 %q", service, resp.StatusCode, body, gitinfra.RepositoryNotFoundMessage)
+				}
+			}
+
+			transports := []struct {
+				name string
+				url  string
+				env  []string
+			}{
+				{"http", authURL, nil},
+				{"ssh", env.SSHCloneURL(t, p.owner, p.name), []string{sshCommand}},
+			}
+			for _, transport := range transports {
+				for _, command := range [][]string{{"ls-remote"}, {"push", "--dry-run"}} {
+					cmd := exec.Command("git", append(command, transport.url, "HEAD")...)
+					cmd.Dir = work
+					cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL=/dev/null", "GIT_TERMINAL_PROMPT=0")
+					cmd.Env = append(cmd.Env, transport.env...)
+					var stderr strings.Builder
+					cmd.Stderr = &stderr
+					if err := cmd.Run(); err == nil {
+						t.Errorf("%s git %s succeeded", transport.name, command[0])
+					}
+					if !strings.Contains(stderr.String(), gitinfra.RepositoryNotFoundMessage) {
+						t.Errorf("%s git %s stderr does not say %q:\n%s", transport.name, command[0], gitinfra.RepositoryNotFoundMessage, stderr.String())
+					}
+				}
+			}
+
+			if _, err := env.Deps.RepoService.ResolveRepository(ctx, p.owner, p.name); !apperrors.IsNotFound(err) {
+				t.Errorf("ResolveRepository after probing = %v, want not found", err)
+			}
+		})
+	}
+
+	after := storageSnapshot(t, env.StorageRoot)
+	for path, state := range after {
+		if before[path] != state {
+			t.Errorf("probing changed %s: %q, was %q", path, state, before[path])
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			t.Errorf("probing removed %s", path)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// notificationRouter sets up the notification routes of the current user
+func (r *Router) notificationRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewNotificationHandler(r.Deps.Notifications, r.Deps.Emails)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/notifications", openapi.RouteDocs{
+		Summary:     "List notifications",
+		Description: "List the notifications of the current user, newest first: @mentions in issues, comments and pull requests, merged or closed pull requests they opened, and failed CI jobs they triggered. Set unread=true for unread ones only, and paginate with page and per_page (default 20, max 100).",
+		Tags:        []string{"Notifications"},
+		Parameters:  pagination.Resources.Parameters(),
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.NotificationListResponse{},
+			},
+			400: {
+				Description: "Invalid unread or pagination parameter",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/notifications/unread_count", openapi.RouteDocs{
+		Summary:     "Count unread notifications",
+		Description: "Get the number of unread notifications of the current user. Cheap enough to poll.",
+		Tags:        []string{"Notifications"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.NotificationCountResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/notifications/read", openapi.RouteDocs{
+		Summary:     "Mark all notifications read",
+		Description: "Mark every notification of the current user as read",
+		Tags:        []string{"Notifications"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Notifications marked read",
+				Model:       dto.NotificationsMarkedResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/notifications/:id/read", openapi.RouteDocs{
+		Summary:     "Mark notification read",
+		Description: "Mark a notification of the current user as read",
+		Tags:        []string{"Notifications"},
+		Responses: map[int]openapi.ResponseDoc{
+			204: {
+				Description: "Notification marked read",
+			},
+			400: {
+				Description: "Invalid notification ID",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			404: {
+				Description: "Notification not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/users/notification-preferences", openapi.RouteDocs{
+		Summary:     "Get notification preferences",
+		Description: "Get which email notifications the current user receives. All are on until the user turns them off.",
+		Tags:        []string{"Notifications"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.NotificationPreferencesResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PATCH", "/api/v1/users/notification-preferences", openapi.RouteDocs{
+		Summary:     "Update notification preferences",
+		Description: "Turn email notifications of the current user on or off per category. Omitted categories are left unchanged.",
+		Tags:        []string{"Notifications"},
+		RequestBody: dto.UpdateNotificationPreferencesRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Preferences updated",
+				Model:       dto.NotificationPreferencesResponse{},
+			},
+			400: {
+				Description: "Invalid request body",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	// Notification routes
+	notifications := v1.Group("/notifications", authMiddleware.RequireAuth())
+	{
+		notifications.GET("", h.ListNotifications)
+		notifications.GET("/unread_count", h.UnreadCount)
+		notifications.POST("/read", h.MarkAllRead)
+		notifications.POST("/:id/read", h.MarkRead)
+	}
+
+	users := v1.Group("/users", authMiddleware.RequireAuth(), authMiddleware.RequireScope(models.TokenScopeAdmin))
+	{
+		users.GET("/notification-preferences", h.GetPreferences)
+		users.PATCH("/notification-preferences", h.UpdatePreferences)
+	}
+}
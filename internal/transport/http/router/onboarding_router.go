@@ -0,0 +1,96 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// onboardingRouter sets up the onboarding routes of the current user
+func (r *Router) onboardingRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewOnboardingHandler(
+		r.Deps.Onboarding,
+		r.server.Config.Server.Host,
+		r.server.Config.SSH.Host,
+		r.server.Config.SSH.Port,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/users/onboarding", openapi.RouteDocs{
+		Summary:     "Get onboarding status",
+		Description: "Returns whether onboarding is enabled, whether the current user was onboarded or skipped it, and their playground repository if it exists",
+		Tags:        []string{"Users"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Onboarding status",
+				Model:       dto.OnboardingResponse{},
+			},
+			http.StatusUnauthorized: {
+				Description: "Authentication required",
+			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/users/onboard", openapi.RouteDocs{
+		Summary:     "Onboard the current user",
+		Description: "Creates the private playground repository of the current user from the configured template, with a README and a sample CI pipeline, and marks the user onboarded. The same happens automatically on a user's first sign-in. Onboarding runs once: later calls return the status unchanged. The repository counts toward quotas like any other. A user.onboarded notification reminds users without SSH keys to add one.",
+		Tags:        []string{"Users"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Onboarding status",
+				Model:       dto.OnboardingResponse{},
+			},
+			http.StatusUnauthorized: {
+				Description: "Authentication required",
+			},
+			http.StatusNotFound: {
+				Description: "Onboarding is not enabled",
+			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/users/onboard/skip", openapi.RouteDocs{
+		Summary:     "Skip onboarding",
+		Description: "Marks the current user onboarded without creating a playground repository",
+		Tags:        []string{"Users"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Onboarding status",
+				Model:       dto.OnboardingResponse{},
+			},
+			http.StatusUnauthorized: {
+				Description: "Authentication required",
+			},
+			http.StatusNotFound: {
+				Description: "Onboarding is not enabled",
+			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	// Onboarding routes (require authentication)
+	users := v1.Group("/users", authMiddleware.RequireAuth(), authMiddleware.RequireScope(models.TokenScopeAdmin))
+	{
+		users.GET("/onboarding", h.GetOnboarding)
+		users.POST("/onboard", h.Onboard)
+		users.POST("/onboard/skip", h.Skip)
+	}
+}
@@ -0,0 +1,196 @@
+package router_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/server"
+	"github.com/bravo68web/stasis/internal/testutil"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// ginParam matches the path parameters of gin routes
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z_]+)`)
+
+// operations returns the operations of a path of the spec by method
+func operations(item *openapi.PathItem) map[string]*openapi.Operation {
+	ops := map[string]*openapi.Operation{
+		http.MethodGet:     item.Get,
+		http.MethodPut:     item.Put,
+		http.MethodPost:    item.Post,
+		http.MethodDelete:  item.Delete,
+		http.MethodOptions: item.Options,
+		http.MethodHead:    item.Head,
+		http.MethodPatch:   item.Patch,
+		http.MethodTrace:   item.Trace,
+	}
+	for method, op := range ops {
+		if op == nil {
+			delete(ops, method)
+		}
+	}
+	return ops
+}
+
+// parameter returns the parameter of an operation with a name
+func parameter(op *openapi.Operation, name string) *openapi.Parameter {
+	for i := range op.Parameters {
+		if op.Parameters[i].Name == name {
+			return &op.Parameters[i]
+		}
+	}
+	return nil
+}
+
+// The served spec must declare how requests authenticate, the error envelope
+// and the paging parameters, and document every registered route.
+func TestOpenAPISpec(t *testing.T) {
+	env := testutil.SharedEnv(t)
+	checkOpenAPISpec(t, env.Server)
+}
+
+// checkOpenAPISpec checks the spec s serves at /api/openapi.json
+func checkOpenAPISpec(t *testing.T, s *server.Server) {
+	rec := httptest.NewRecorder()
+	s.Engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/openapi.json = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var spec openapi.OpenAPI
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+
+	t.Run("security schemes", func(t *testing.T) {
+		want := map[string]openapi.SecurityScheme{
+			"bearerAuth":   {Type: "http", Scheme: "bearer"},
+			"basicAuth":    {Type: "http", Scheme: "basic"},
+			"runnerApiKey": {Type: "apiKey", In: "header", Name: "X-API-Key"},
+		}
+		for name, scheme := range spec.Components.SecuritySchemes {
+			scheme.Description = ""
+			if scheme != want[name] {
+				t.Errorf("security scheme %s = %+v, want %+v", name, scheme, want[name])
+			}
+		}
+		if len(spec.Components.SecuritySchemes) != len(want) {
+			t.Errorf("%d security schemes, want %d", len(spec.Components.SecuritySchemes), len(want))
+		}
+
+		for path, item := range spec.Paths {
+			for method, op := range operations(item) {
+				switch {
+				case strings.HasPrefix(path, "/api/v1/ci/"):
+					if !slices.ContainsFunc(op.Security, func(r openapi.SecurityRequirement) bool { _, ok := r["runnerApiKey"]; return ok }) {
+						t.Errorf("%s %s does not accept the runner API key", method, path)
+					}
+				case strings.HasPrefix(path, "/api/v1/"):
+					if !slices.ContainsFunc(op.Security, func(r openapi.SecurityRequirement) bool { _, ok := r["bearerAuth"]; return ok }) {
+						t.Errorf("%s %s does not accept bearer tokens", method, path)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("error envelope", func(t *testing.T) {
+		envelope := spec.Components.Schemas["Error"]
+		if envelope == nil {
+			t.Fatal("no Error schema")
+		}
+		for _, field := range []string{"error", "message", "details"} {
+			if envelope.Properties[field] == nil {
+				t.Errorf("Error schema has no %s field", field)
+			}
+		}
+
+		for path, item := range spec.Paths {
+			for method, op := range operations(item) {
+				for status, resp := range op.Responses {
+					if code, _ := strconv.Atoi(status); code < 400 {
+						continue
+					}
+					if media, ok := resp.Content["application/json"]; !ok || media.Schema == nil {
+						t.Errorf("%s %s: %s response has no schema", method, path, status)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		paged := 0
+		for path, item := range spec.Paths {
+			for method, op := range operations(item) {
+				page := parameter(op, "page")
+				if page == nil {
+					continue
+				}
+				paged++
+
+				perPage, limit, offset := parameter(op, "per_page"), parameter(op, "limit"), parameter(op, "offset")
+				if perPage == nil || limit == nil || offset == nil {
+					t.Errorf("%s %s documents page without per_page, limit and offset", method, path)
+					continue
+				}
+				if !limit.Deprecated || !offset.Deprecated || page.Deprecated || perPage.Deprecated {
+					t.Errorf("%s %s: only limit and offset must be deprecated", method, path)
+				}
+				if perPage.Schema.Maximum == nil || limit.Schema.Maximum == nil || *perPage.Schema.Maximum != *limit.Schema.Maximum {
+					t.Errorf("%s %s: per_page and limit must share their maximum", method, path)
+				}
+
+				var body *openapi.Schema
+				if media, ok := op.Responses["200"].Content["application/json"]; ok {
+					body = media.Schema
+				}
+				if body == nil || body.Properties["pagination"] == nil {
+					t.Errorf("%s %s: paginated response has no pagination object", method, path)
+					continue
+				}
+				for _, field := range []string{"page", "per_page", "has_more"} {
+					if body.Properties["pagination"].Properties[field] == nil {
+						t.Errorf("%s %s: pagination object has no %s field", method, path, field)
+					}
+				}
+			}
+		}
+		if paged == 0 {
+			t.Error("no operation documents paging parameters")
+		}
+	})
+
+	t.Run("routes", func(t *testing.T) {
+		if routes := s.OpenAPIGenerator.Undocumented(); len(routes) > 0 {
+			t.Errorf("routes without docs: %v", routes)
+		}
+		if docs := s.OpenAPIGenerator.Unrouted(); len(docs) > 0 {
+			t.Errorf("docs of missing routes: %v", docs)
+		}
+
+		ids := map[string]string{}
+		for _, route := range s.Engine.Routes() {
+			path := ginParam.ReplaceAllString(route.Path, "{$1}")
+			item, ok := spec.Paths[path]
+			if !ok {
+				t.Errorf("%s %s is not in the spec", route.Method, path)
+				continue
+			}
+			op, ok := operations(item)[route.Method]
+			if !ok {
+				t.Errorf("%s %s is not in the spec", route.Method, path)
+				continue
+			}
+			if other, ok := ids[op.OperationID]; ok {
+				t.Errorf("%s %s and %s share the operation ID %s", route.Method, path, other, op.OperationID)
+			}
+			ids[op.OperationID] = route.Method + " " + path
+		}
+	})
+}
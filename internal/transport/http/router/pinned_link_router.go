@@ -0,0 +1,70 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// pinnedLinkRouter sets up pinned link settings routes
+func (r *Router) pinnedLinkRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewPinnedLinkHandler(
+		r.Deps.RepoService,
+		r.Deps.PinnedLinks,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/settings/pinned-links", openapi.RouteDocs{
+		Summary:     "Get pinned links",
+		Description: "Get the quick links shown on the repository page, in display order. Links to paths that no longer exist on the default branch are flagged broken.",
+		Tags:        []string{"Repositories"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.PinnedLinksResponse{},
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PUT", "/api/v1/repos/:owner/:repo/settings/pinned-links", openapi.RouteDocs{
+		Summary:     "Update pinned links",
+		Description: "Replace the pinned links of a repository, at most 10, in display order. Each link has a title and either a path within the repository, which must exist on the default branch, or an absolute http(s) URL.",
+		Tags:        []string{"Repositories"},
+		RequestBody: dto.UpdatePinnedLinksRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Pinned links updated successfully",
+				Model:       dto.PinnedLinksResponse{},
+			},
+			400: {
+				Description: "Invalid link, missing path or too many links",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	// Pinned link routes
+	settings := v1.Group("/repos/:owner/:repo/settings")
+	{
+		settings.GET("/pinned-links", authMiddleware.Authenticate(), h.GetPinnedLinks)
+		settings.PUT("/pinned-links", authMiddleware.RequireAuth(), h.UpdatePinnedLinks)
+	}
+}
@@ -0,0 +1,153 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// pullRequestRouter sets up pull request routes
+func (r *Router) pullRequestRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewPullRequestHandler(
+		r.Deps.RepoService,
+		r.Deps.PullRequests,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/pulls", openapi.RouteDocs{
+		Summary:     "List pull requests",
+		Description: "List the pull requests of a repository, newest first. Filter with state (open, closed or merged) and paginate with page and per_page (default 20, max 100).",
+		Tags:        []string{"Pull Requests"},
+		Parameters:  pagination.Resources.Parameters(),
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.PullRequestListResponse{},
+			},
+			400: {
+				Description: "Invalid state or pagination parameter",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/pulls", openapi.RouteDocs{
+		Summary:     "Create pull request",
+		Description: "Propose merging a branch of the repository into another. Pull requests are numbered sequentially per repository. Requires read access.",
+		Tags:        []string{"Pull Requests"},
+		RequestBody: dto.CreatePullRequestRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			201: {
+				Description: "Pull request created",
+				Model:       dto.PullRequestResponse{},
+			},
+			400: {
+				Description: "Invalid title or branches",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+			409: {
+				Description: "A pull request between the branches is already open",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/pulls/:number", openapi.RouteDocs{
+		Summary:     "Get pull request",
+		Description: "Get a pull request of a repository by number",
+		Tags:        []string{"Pull Requests"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.PullRequestResponse{},
+			},
+			400: {
+				Description: "Invalid pull request number",
+			},
+			404: {
+				Description: "Repository or pull request not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/pulls/:number/close", openapi.RouteDocs{
+		Summary:     "Close pull request",
+		Description: "Close an open pull request without merging it. Requires being its author or write access.",
+		Tags:        []string{"Pull Requests"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Pull request closed",
+				Model:       dto.PullRequestResponse{},
+			},
+			400: {
+				Description: "Invalid pull request number",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository or pull request not found",
+			},
+			409: {
+				Description: "Pull request is not open",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/pulls/:number/merge", openapi.RouteDocs{
+		Summary:     "Merge pull request",
+		Description: "Merge the source branch into the target branch with a merge commit authored by the user, and record who merged it. Branch protections of the target apply. If the branches conflict nothing is merged and the response lists the conflicting files in conflicts. Requires write access.",
+		Tags:        []string{"Pull Requests"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Pull request merged",
+				Model:       dto.PullRequestResponse{},
+			},
+			400: {
+				Description: "Invalid pull request number",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden, or the target branch is protected",
+			},
+			404: {
+				Description: "Repository or pull request not found",
+			},
+			409: {
+				Description: "Merge conflict, nothing to merge, or the pull request is not open",
+			},
+			429: {
+				Description: "Too many writes to the repository",
+			},
+		},
+	})
+
+	// Pull request routes
+	pulls := v1.Group("/repos/:owner/:repo/pulls")
+	{
+		pulls.GET("", authMiddleware.Authenticate(), h.ListPullRequests)
+		pulls.POST("", authMiddleware.RequireAuth(), h.CreatePullRequest)
+		pulls.GET("/:number", authMiddleware.Authenticate(), h.GetPullRequest)
+		pulls.POST("/:number/close", authMiddleware.RequireAuth(), h.ClosePullRequest)
+		pulls.POST("/:number/merge", authMiddleware.RequireAuth(), r.objectWriteLimit(), h.MergePullRequest)
+	}
+}
@@ -0,0 +1,55 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// pushAttemptRouter sets up push attempt routes
+func (r *Router) pushAttemptRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewPushAttemptHandler(
+		r.Deps.RepoService,
+		r.Deps.PushAttempts,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/push-attempts", openapi.RouteDocs{
+		Summary:     "List push attempts",
+		Description: "List recent pushes to a repository, most recent first, with the pusher, the refs attempted, whether the push was accepted and the output of server-side checks (secrets redacted). Filter with ?outcome=accepted|rejected; paginate with ?page= and ?per_page= (default 20, max 100).",
+		Tags:        []string{"Repositories"},
+		Parameters:  pagination.Resources.Parameters(),
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.ListPushAttemptsResponse{},
+			},
+			400: {
+				Description: "Invalid outcome or pagination parameter",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	// Push attempt routes
+	repos := v1.Group("/repos/:owner/:repo")
+	{
+		repos.GET("/push-attempts", authMiddleware.RequireAuth(), h.ListPushAttempts)
+	}
+}
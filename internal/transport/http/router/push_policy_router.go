@@ -0,0 +1,102 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// pushPolicyRouter sets up push policy routes
+func (r *Router) pushPolicyRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewPushPolicyHandler(
+		r.Deps.RepoService,
+		r.Deps.PushPolicies,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/settings/push-policy", openapi.RouteDocs{
+		Summary:     "Get push policy",
+		Description: "Get the rules every push to a repository must follow",
+		Tags:        []string{"Repositories"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.PushPolicyResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PUT", "/api/v1/repos/:owner/:repo/settings/push-policy", openapi.RouteDocs{
+		Summary:     "Update push policy",
+		Description: "Replace the push policy of a repository. Pushes adding or changing files larger than max_file_size bytes or matching a blocked path glob, with a commit message not matching commit_message_pattern, or rewriting a branch with deny_non_fast_forward are refused, and the offending commits and rules are reported to the pusher. A policy without rules removes it.",
+		Tags:        []string{"Repositories"},
+		RequestBody: dto.PushPolicyRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Push policy updated successfully",
+				Model:       dto.PushPolicyResponse{},
+			},
+			400: {
+				Description: "Invalid rule",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/settings/push-policy/check", openapi.RouteDocs{
+		Summary:     "Check push policy",
+		Description: "Dry run the push policy of a repository, or the policy of the request, against a push of head to ref over base: the commits reachable from head but not from base are checked, and deny_non_fast_forward applies if ref is a branch. Nothing is changed.",
+		Tags:        []string{"Repositories"},
+		RequestBody: dto.CheckPushPolicyRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.CheckPushPolicyResponse{},
+			},
+			400: {
+				Description: "Invalid request or rule",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository or revision not found",
+			},
+		},
+	})
+
+	// Push policy routes
+	settings := v1.Group("/repos/:owner/:repo/settings")
+	{
+		settings.GET("/push-policy", authMiddleware.RequireAuth(), h.GetPushPolicy)
+		settings.PUT("/push-policy", authMiddleware.RequireAuth(), h.UpdatePushPolicy)
+		settings.POST("/push-policy/check", authMiddleware.RequireAuth(), h.CheckPushPolicy)
+	}
+}
@@ -0,0 +1,73 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// quotaRouter sets up repository size quota administration routes
+func (r *Router) quotaRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewQuotaHandler(r.Deps.RepoService, r.Deps.Quotas)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/admin/repos/:owner/:repo/quota", openapi.RouteDocs{
+		Summary:     "Get repository quota",
+		Description: "Get the disk usage of a repository, as measured after its last push, and the size quota pushes into it are held to.",
+		Tags:        []string{"Admin"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.RepoQuotaResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PATCH", "/api/v1/admin/repos/:owner/:repo/quota", openapi.RouteDocs{
+		Summary:     "Override repository quota",
+		Description: "Set the size quota of a repository in bytes, replacing both the configured repository quota and the quota of its owner. 0 lifts the limit; null removes the override so the configured quotas apply again.",
+		Tags:        []string{"Admin"},
+		RequestBody: dto.UpdateRepoQuotaRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Quota updated",
+				Model:       dto.RepoQuotaResponse{},
+			},
+			400: {
+				Description: "Invalid quota",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	// Admin quota routes
+	admin := v1.Group("/admin", authMiddleware.RequireAdmin())
+	{
+		admin.GET("/repos/:owner/:repo/quota", h.GetQuota)
+		admin.PATCH("/repos/:owner/:repo/quota", h.UpdateQuota)
+	}
+}
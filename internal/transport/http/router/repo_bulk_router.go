@@ -0,0 +1,76 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// repoBulkRouter sets up repository bulk operation routes
+func (r *Router) repoBulkRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewRepoBulkHandler(r.Deps.RepoBulk)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/admin/repos/bulk", openapi.RouteDocs{
+		Summary: "Run a bulk repository operation",
+		Description: "Set the visibility of, transfer or delete many repositories, named as owner/name or selected by a filter on their owner, annotations and license. " +
+			"The operation runs in the background as a task; each repository goes through the same checks, locks and audit events as the single-repository endpoints, " +
+			"and a failure on one does not stop the others. With dry_run the repositories the operation would apply to are listed and nothing is changed.",
+		Tags:        []string{"Admin"},
+		RequestBody: dto.RepoBulkRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Dry run: repositories the operation would apply to",
+				Model:       dto.RepoBulkPreviewResponse{},
+			},
+			202: {
+				Description: "Task queued",
+				Model:       dto.RepoBulkTaskResponse{},
+			},
+			400: {
+				Description: "Invalid operation, unknown user or no matching repositories",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/admin/tasks/:id", openapi.RouteDocs{
+		Summary:     "Get a bulk task",
+		Description: "Get the status of a bulk repository operation and the outcome for each repository processed so far. Finished tasks are kept for repos.bulk_task_retention_days.",
+		Tags:        []string{"Admin"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.RepoBulkTaskResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+			404: {
+				Description: "Task not found or expired",
+			},
+		},
+	})
+
+	// Admin bulk routes
+	admin := v1.Group("/admin", authMiddleware.RequireAdmin())
+	{
+		admin.POST("/repos/bulk", h.SubmitBulk)
+		admin.GET("/tasks/:id", h.GetTask)
+	}
+}
@@ -0,0 +1,90 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// repoImportRouter sets up the repository import routes of the current user
+func (r *Router) repoImportRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewRepoImportHandler(r.Deps.RepoImports)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/import", openapi.RouteDocs{
+		Summary:     "Import repository",
+		Description: "Queue the creation of a repository for the current user, run in the background. A JSON body clones every ref of clone_url into the new repository, like git clone --mirror; username and password authenticate the clone and are kept only if mirror is set, for the repository to keep syncing. A multipart/form-data body with the fields name, description and is_private followed by an archive file (tar, tar.gz or zip, at most repos.max_import_archive_size bytes) commits the files of the archive as the initial commit of the default branch; a single top-level directory is unwrapped and .git directories are skipped. Poll GET /api/v1/repos/imports/:id for the outcome; a failed import leaves no repository behind.",
+		Tags:        []string{"Repositories"},
+		RequestBody: dto.ImportRepoRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			202: {
+				Description: "Import queued",
+				Model:       dto.RepoImportResponse{},
+			},
+			400: {
+				Description: "Invalid request, or archive too large or empty",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Token without the repo:write scope, or repository limit reached",
+			},
+			409: {
+				Description: "Repository exists, an import of it is in progress, or too many imports are in progress",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/imports", openapi.RouteDocs{
+		Summary:     "List repository imports",
+		Description: "List the imports of the current user, newest first. Paginate with page and per_page (default 20, max 100).",
+		Tags:        []string{"Repositories"},
+		Parameters:  pagination.Resources.Parameters(),
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.RepoImportListResponse{},
+			},
+			400: {
+				Description: "Invalid pagination parameter",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/imports/:id", openapi.RouteDocs{
+		Summary:     "Get repository import",
+		Description: "Get the status of an import of the current user: pending while it waits for a free import slot, running, completed with the new repository, or failed with the reason in error. Imports interrupted by a server restart fail and must be started again.",
+		Tags:        []string{"Repositories"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.RepoImportResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			404: {
+				Description: "Import not found",
+			},
+		},
+	})
+
+	imports := v1.Group("/repos", authMiddleware.RequireAuth())
+	{
+		imports.POST("/import", h.ImportRepository)
+		imports.GET("/imports", h.ListImports)
+		imports.GET("/imports/:id", h.GetImport)
+	}
+}
@@ -2,8 +2,10 @@ package router
 
 import (
 	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/apiversion"
 	"github.com/bravo68web/stasis/internal/transport/http/handler"
 	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
 	"github.com/bravo68web/stasis/pkg/openapi"
 )
 
@@ -17,6 +19,16 @@ func (r *Router) repoRouter() {
 	h := handler.NewRepoHandler(
 		r.Deps.RepoService,
 		r.Deps.MirrorSyncService,
+		r.Deps.AnnotationService,
+		r.Deps.TagProtection,
+		r.Deps.Highlight,
+		r.Deps.AuthorMappings,
+		r.Deps.CommitSignatures,
+		r.Deps.Licenses,
+		r.Deps.Topics,
+		r.Deps.AuditEvents,
+		r.Deps.LargeFiles,
+		r.Deps.Languages,
 		r.server.Config.Server.Host,
 		r.server.Config.SSH.Host,
 		r.server.Config.SSH.Port,
@@ -25,43 +37,44 @@ func (r *Router) repoRouter() {
 	// Register OpenAPI Docs
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/public", openapi.RouteDocs{
 		Summary:     "List public repositories",
-		Description: "Get a list of public repositories with pagination",
+		Description: "Get a list of public repositories, paginated with ?page= and ?per_page= (default 20, max 100). Filter with ?annotation=key:value, ?license=<SPDX identifier> (\"none\" for repositories without a detected license) and ?topic=<topic>",
 		Tags:        []string{"Repositories"},
+		Parameters:  pagination.Resources.Parameters(),
 		Responses: map[int]openapi.ResponseDoc{
 			200: {
 				Description: "Successful response",
-				Model:       dto.RepoListResponse{},
+				Model:       dto.RepoPageResponse{},
+			},
+			400: {
+				Description: "Invalid filter or pagination parameter",
 			},
 		},
 	})
 
-	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos", openapi.RouteDocs{
-		Summary:     "Create repository",
-		Description: "Create a new repository for the authenticated user",
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/search", openapi.RouteDocs{
+		Summary:     "Search repositories",
+		Description: "Find repositories whose name or description contains ?q=, case insensitively, most recently updated first. Anonymous callers only see public repositories; authenticated callers also see the private ones they own or collaborate on, and site administrators all of them. Paginate with ?page= and ?per_page= (default 20, max 100). Narrow with ?topic=, ?annotation=key:value and ?license=<SPDX identifier>",
 		Tags:        []string{"Repositories"},
-		RequestBody: dto.CreateRepoRequest{},
+		Parameters:  pagination.Resources.Parameters(),
 		Responses: map[int]openapi.ResponseDoc{
-			201: {
-				Description: "Repository created successfully",
-				Model:       dto.RepoResponse{},
+			200: {
+				Description: "Successful response",
+				Model:       dto.RepoPageResponse{},
 			},
 			400: {
-				Description: "Invalid request",
-			},
-			401: {
-				Description: "Unauthorized",
+				Description: "Missing search query or invalid pagination parameter",
 			},
 		},
 	})
 
-	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/import", openapi.RouteDocs{
-		Summary:     "Import repository",
-		Description: "Import a repository from an external Git source",
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos", openapi.RouteDocs{
+		Summary:     "Create repository",
+		Description: "Create a new repository for the authenticated user",
 		Tags:        []string{"Repositories"},
-		RequestBody: dto.ImportRepoRequest{},
+		RequestBody: dto.CreateRepoRequest{},
 		Responses: map[int]openapi.ResponseDoc{
 			201: {
-				Description: "Repository imported successfully",
+				Description: "Repository created successfully",
 				Model:       dto.RepoResponse{},
 			},
 			400: {
@@ -75,7 +88,7 @@ func (r *Router) repoRouter() {
 
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos", openapi.RouteDocs{
 		Summary:     "List user repositories",
-		Description: "Get a list of repositories for the authenticated user",
+		Description: "Get a list of repositories for the authenticated user. Filter with ?annotation=key:value, ?license=<SPDX identifier> (\"none\" for repositories without a detected license) and ?topic=<topic>",
 		Tags:        []string{"Repositories"},
 		Responses: map[int]openapi.ResponseDoc{
 			200: {
@@ -105,7 +118,7 @@ func (r *Router) repoRouter() {
 
 	r.server.OpenAPIGenerator.RegisterDocs("PATCH", "/api/v1/repos/:owner/:repo", openapi.RouteDocs{
 		Summary:     "Update repository",
-		Description: "Update repository details",
+		Description: "Update repository details. Set large_file_hints_disabled to stop warning pushers about large files not stored with Git LFS. Set name to rename the repository: for repos.rename_redirect_days, git clients using the former name are served the repository and API requests for it are redirected to the new name (public repositories only).",
 		Tags:        []string{"Repositories"},
 		RequestBody: dto.UpdateRepoRequest{},
 		Responses: map[int]openapi.ResponseDoc{
@@ -113,12 +126,18 @@ func (r *Router) repoRouter() {
 				Description: "Repository updated successfully",
 				Model:       dto.RepoResponse{},
 			},
+			400: {
+				Description: "Invalid repository name",
+			},
 			401: {
 				Description: "Unauthorized",
 			},
 			404: {
 				Description: "Repository not found",
 			},
+			409: {
+				Description: "The owner already has a repository with the new name",
+			},
 		},
 	})
 
@@ -139,9 +158,81 @@ func (r *Router) repoRouter() {
 		},
 	})
 
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/fork", openapi.RouteDocs{
+		Summary:     "Fork repository",
+		Description: "Fork a repository into the namespace of the authenticated user. The name defaults to the name of the source repository. The response includes the source as parent.",
+		Tags:        []string{"Repositories"},
+		RequestBody: dto.ForkRepoRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			201: {
+				Description: "Repository forked successfully",
+				Model:       dto.RepoResponse{},
+			},
+			400: {
+				Description: "Invalid repository name",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+			409: {
+				Description: "The user already has a repository with this name",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/forks", openapi.RouteDocs{
+		Summary:     "List forks",
+		Description: "List the direct forks of a repository, oldest first. Anonymous callers only see public forks; authenticated callers also see their own private ones. Paginate with ?page= and ?per_page= (default 20, max 100)",
+		Tags:        []string{"Repositories"},
+		Parameters:  pagination.Resources.Parameters(),
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.RepoPageResponse{},
+			},
+			400: {
+				Description: "Invalid pagination parameter",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/transfer", openapi.RouteDocs{
+		Summary:     "Transfer repository",
+		Description: "Transfer a repository to another user. Only the owner of the repository or an admin can transfer it.",
+		Tags:        []string{"Repositories"},
+		RequestBody: dto.TransferRepoRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Repository transferred successfully",
+				Model:       dto.RepoResponse{},
+			},
+			400: {
+				Description: "Invalid request or unknown new owner",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Not the owner of the repository",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+			409: {
+				Description: "The new owner already has a repository with this name",
+			},
+		},
+	})
+
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/stats", openapi.RouteDocs{
 		Summary:     "Get repository stats",
-		Description: "Get statistics for a repository",
+		Description: "Get statistics for a repository. large_files_added counts the files above repos.large_file_warning_size pushed without Git LFS in the last 30 days. fork_count counts the direct forks, including private ones.",
 		Tags:        []string{"Repositories"},
 		Responses: map[int]openapi.ResponseDoc{
 			200: {
@@ -157,6 +248,45 @@ func (r *Router) repoRouter() {
 		},
 	})
 
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/stats/contributors", openapi.RouteDocs{
+		Summary:     "Get contributor stats",
+		Description: "Get the commits, additions and deletions per author email of the non-merge commits reachable from ?ref= (default branch if empty), most commits first, following .mailmap. Narrow with ?since= and ?until= (RFC 3339 times or YYYY-MM-DD dates). weeks holds the commits of all authors in each of the last 52 weeks, starting on Sunday UTC. Results are cached per tip commit.",
+		Tags:        []string{"Repositories"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.ContributorStatsResponse{},
+			},
+			400: {
+				Description: "Invalid since or until",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			404: {
+				Description: "Repository or ref not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/languages", openapi.RouteDocs{
+		Summary:     "Get languages",
+		Description: "Get the bytes of source code per language in the tree at ?ref= (default branch if empty), most bytes first, with their percentage of the total. Languages are told from file names and extensions; vendored directories (vendor/, node_modules/, dist/, ...) and binary files are left out. Results are cached per tip commit, and the breakdown of the default branch is stored for the primary_language of repository listings.",
+		Tags:        []string{"Repositories"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.LanguagesResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			404: {
+				Description: "Repository or ref not found",
+			},
+		},
+	})
+
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/branches", openapi.RouteDocs{
 		Summary:     "List branches",
 		Description: "List all branches in the repository",
@@ -177,7 +307,7 @@ func (r *Router) repoRouter() {
 
 	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/branches", openapi.RouteDocs{
 		Summary:     "Create branch",
-		Description: "Create a new branch",
+		Description: "Create a new branch. Creations are rate limited per user and repository, and refused once the repository has as many branches as the server allows.",
 		Tags:        []string{"Branches"},
 		RequestBody: dto.BranchRequest{},
 		Responses: map[int]openapi.ResponseDoc{
@@ -194,6 +324,12 @@ func (r *Router) repoRouter() {
 			404: {
 				Description: "Repository not found",
 			},
+			422: {
+				Description: "The repository has reached its branch limit; the response links the endpoints to list and delete branches",
+			},
+			429: {
+				Description: "Too many branch creations, retry after the Retry-After delay",
+			},
 		},
 	})
 
@@ -234,7 +370,7 @@ func (r *Router) repoRouter() {
 
 	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/tags", openapi.RouteDocs{
 		Summary:     "Create tag",
-		Description: "Create a new tag",
+		Description: "Create a new tag. Creations are rate limited per user and repository, and refused once the repository has as many tags as the server allows.",
 		Tags:        []string{"Tags"},
 		RequestBody: dto.TagRequest{},
 		Responses: map[int]openapi.ResponseDoc{
@@ -251,12 +387,18 @@ func (r *Router) repoRouter() {
 			404: {
 				Description: "Repository not found",
 			},
+			422: {
+				Description: "The repository has reached its tag limit; the response links the endpoints to list and delete tags",
+			},
+			429: {
+				Description: "Too many tag creations, retry after the Retry-After delay",
+			},
 		},
 	})
 
 	r.server.OpenAPIGenerator.RegisterDocs("DELETE", "/api/v1/repos/:owner/:repo/tags/:tag", openapi.RouteDocs{
 		Summary:     "Delete tag",
-		Description: "Delete a tag",
+		Description: "Delete a tag. Tags matching a protected tag pattern can only be deleted by users on the override list.",
 		Tags:        []string{"Tags"},
 		Responses: map[int]openapi.ResponseDoc{
 			200: {
@@ -265,6 +407,9 @@ func (r *Router) repoRouter() {
 			401: {
 				Description: "Unauthorized",
 			},
+			403: {
+				Description: "Forbidden or tag is protected",
+			},
 			404: {
 				Description: "Repository not found",
 			},
@@ -273,12 +418,19 @@ func (r *Router) repoRouter() {
 
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/commits", openapi.RouteDocs{
 		Summary:     "List commits",
-		Description: "List commits in the repository",
+		Description: "List commits in the repository. Each commit includes the author resolved through the repository's .mailmap and author mappings. Paginate with ?page= and ?per_page= (default 30, max 100); pagination.total is the number of commits reachable from the ref",
 		Tags:        []string{"Commits"},
+		Parameters:  pagination.Commits.Parameters(),
 		Responses: map[int]openapi.ResponseDoc{
 			200: {
 				Description: "Successful response",
 				Model:       dto.CommitListResponse{},
+				Versions: map[string]interface{}{
+					apiversion.CommitPeople: dto.SplitCommitListResponse{},
+				},
+			},
+			400: {
+				Description: "Invalid pagination parameter",
 			},
 			401: {
 				Description: "Unauthorized",
@@ -297,6 +449,9 @@ func (r *Router) repoRouter() {
 			200: {
 				Description: "Successful response",
 				Model:       dto.CommitResponse{},
+				Versions: map[string]interface{}{
+					apiversion.CommitPeople: dto.SplitCommitResponse{},
+				},
 			},
 			401: {
 				Description: "Unauthorized",
@@ -325,19 +480,31 @@ func (r *Router) repoRouter() {
 		},
 	})
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/compare/:range", openapi.RouteDocs{
-		Summary:     "Compare diff",
-		Description: "Compare changes between two commits",
-		Tags:        []string{"Commits"},
+		Summary: "Compare diff",
+		Description: "Compare changes between two commits. The range is either <from>..<to>, or " +
+			"<base>...[<owner>:]<branch> to compare a branch against its merge base with base. " +
+			"With <owner>:<branch>, the branch is taken from the repository of owner in the same fork network. " +
+			"If base and branch share no history, the response has no_merge_base set and no changes. " +
+			"Within the repository itself, <base>...<head> accepts any revisions and the response also lists " +
+			"the commits of head missing from base (at most 250, total_commits counts them all). " +
+			"Diff content beyond 5 MB is cut and truncated is set.",
+		Tags: []string{"Commits"},
 		Responses: map[int]openapi.ResponseDoc{
 			200: {
-				Description: "Successful response",
-				Model:       dto.DiffResponse{},
+				Description: "Successful response; base, head and commits are only set for <base>...<head> within the repository",
+				Model:       dto.CompareResponse{},
+				Versions: map[string]interface{}{
+					apiversion.CommitPeople: dto.SplitCompareResponse{},
+				},
+			},
+			400: {
+				Description: "Invalid range",
 			},
 			401: {
 				Description: "Unauthorized",
 			},
 			404: {
-				Description: "Repository or commit not found",
+				Description: "Repository, fork or revision not found",
 			},
 		},
 	})
@@ -380,7 +547,7 @@ func (r *Router) repoRouter() {
 
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/blob/:ref/*path", openapi.RouteDocs{
 		Summary:     "Get file content",
-		Description: "Get content of a specific file",
+		Description: "Get content of a specific file. With ?highlight=true the response also includes syntax-highlighted HTML with #L<n> line anchors; files above the configured size cap are reported as skipped",
 		Tags:        []string{"Code"},
 		Responses: map[int]openapi.ResponseDoc{
 			200: {
@@ -396,6 +563,72 @@ func (r *Router) repoRouter() {
 		},
 	})
 
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/raw/:ref/*path", openapi.RouteDocs{
+		Summary:     "Get raw file",
+		Description: "Stream the bytes of a file, with a Content-Type from its extension or sniffed from its content and the blob hash as ETag. Supports Range and If-None-Match requests. Files above repos.max_raw_file_size are refused unless ?allow_large=true",
+		Tags:        []string{"Code"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "File content",
+			},
+			206: {
+				Description: "Requested range of the file content",
+			},
+			304: {
+				Description: "Not modified since the given ETag",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			404: {
+				Description: "Repository or file not found",
+			},
+			413: {
+				Description: "File above the configured size, without ?allow_large=true",
+			},
+			416: {
+				Description: "Range not satisfiable",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("HEAD", "/api/v1/repos/:owner/:repo/raw/:ref/*path", openapi.RouteDocs{
+		Summary:     "Check raw file",
+		Description: "Get the headers of a raw file (Content-Type, Content-Length, ETag) without its content",
+		Tags:        []string{"Code"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "File exists",
+			},
+			304: {
+				Description: "Not modified since the given ETag",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			404: {
+				Description: "Repository or file not found",
+			},
+			413: {
+				Description: "File above the configured size, without ?allow_large=true",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/highlight/styles/:style", openapi.RouteDocs{
+		Summary:     "Get highlight stylesheet",
+		Description: "Get the CSS for highlighted file content in a named style (e.g. github, monokai)",
+		Tags:        []string{"Code"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Stylesheet (text/css)",
+			},
+			404: {
+				Description: "Style not found",
+			},
+		},
+	})
+
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/blame/:ref/*path", openapi.RouteDocs{
 		Summary:     "Get blame",
 		Description: "Get blame information for a file",
@@ -437,6 +670,46 @@ func (r *Router) repoRouter() {
 		},
 	})
 
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/mirror", openapi.RouteDocs{
+		Summary:     "Get mirror settings",
+		Description: "Get the mirror settings of a repository. Passwords are never returned.",
+		Tags:        []string{"Repositories"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.MirrorSettingsResponse{},
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PATCH", "/api/v1/repos/:owner/:repo/mirror", openapi.RouteDocs{
+		Summary:     "Update mirror settings",
+		Description: "Update the mirror settings of a repository. Requires admin permission on the repository.",
+		Tags:        []string{"Repositories"},
+		RequestBody: dto.UpdateMirrorSettingsRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Mirror settings updated",
+				Model:       dto.RepoResponse{},
+			},
+			400: {
+				Description: "Invalid request body",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/mirror/status", openapi.RouteDocs{
 		Summary:     "Get mirror status",
 		Description: "Get sync status of a mirror repository",
@@ -457,33 +730,46 @@ func (r *Router) repoRouter() {
 		},
 	})
 
+	// Highlight stylesheets (no auth required)
+	v1.GET("/highlight/styles/:style", h.GetHighlightStylesheet)
+
 	// Repository routes
 	repos := v1.Group("/repos")
 	{
 		// List public repositories (no auth required)
 		repos.GET("/public", h.ListPublicRepositories)
 
+		// Search repositories (private ones only for those who can read them)
+		repos.GET("/search", authMiddleware.Authenticate(), h.SearchRepositories)
+
 		// Protected repository routes
 		repos.POST("", authMiddleware.RequireAuth(), h.CreateRepository)
-		repos.POST("/import", authMiddleware.RequireAuth(), h.ImportRepository)
 		repos.GET("", authMiddleware.RequireAuth(), h.ListRepositories)
 
+		// Branch and tag creation writes to the repository and is limited on its own
+		writeLimit := r.objectWriteLimit()
+
 		// Repository-specific routes
 		repoRoutes := repos.Group("/:owner/:repo")
 		{
 			repoRoutes.GET("", authMiddleware.Authenticate(), h.GetRepository)
 			repoRoutes.PATCH("", authMiddleware.RequireAuth(), h.UpdateRepository)
 			repoRoutes.DELETE("", authMiddleware.RequireAuth(), h.DeleteRepository)
+			repoRoutes.POST("/fork", authMiddleware.RequireAuth(), h.ForkRepository)
+			repoRoutes.GET("/forks", authMiddleware.Authenticate(), h.ListForks)
+			repoRoutes.POST("/transfer", authMiddleware.RequireAuth(), h.TransferRepository)
 			repoRoutes.GET("/stats", authMiddleware.Authenticate(), h.GetRepositoryStats)
+			repoRoutes.GET("/stats/contributors", authMiddleware.Authenticate(), h.GetContributorStats)
+			repoRoutes.GET("/languages", authMiddleware.Authenticate(), h.GetLanguages)
 
 			// Branch routes
 			repoRoutes.GET("/branches", authMiddleware.Authenticate(), h.ListBranches)
-			repoRoutes.POST("/branches", authMiddleware.RequireAuth(), h.CreateBranch)
+			repoRoutes.POST("/branches", authMiddleware.RequireAuth(), writeLimit, h.CreateBranch)
 			repoRoutes.DELETE("/branches/:branch", authMiddleware.RequireAuth(), h.DeleteBranch)
 
 			// Tag routes
 			repoRoutes.GET("/tags", authMiddleware.Authenticate(), h.ListTags)
-			repoRoutes.POST("/tags", authMiddleware.RequireAuth(), h.CreateTag)
+			repoRoutes.POST("/tags", authMiddleware.RequireAuth(), writeLimit, h.CreateTag)
 			repoRoutes.DELETE("/tags/:tag", authMiddleware.RequireAuth(), h.DeleteTag)
 
 			// Commit routes
@@ -498,6 +784,8 @@ func (r *Router) repoRouter() {
 
 			// File content routes
 			repoRoutes.GET("/blob/:ref/*path", authMiddleware.Authenticate(), h.GetFileContent)
+			repoRoutes.GET("/raw/:ref/*path", authMiddleware.Authenticate(), h.GetRawFile)
+			repoRoutes.HEAD("/raw/:ref/*path", authMiddleware.Authenticate(), h.GetRawFile)
 
 			// Blame routes
 			repoRoutes.GET("/blame/:ref/*path", authMiddleware.Authenticate(), h.GetBlame)
@@ -0,0 +1,45 @@
+package router_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+// Search lists a private repository to those who can read it, as they can
+// open it directly, and to nobody else
+func TestSearchPrivateRepository(t *testing.T) {
+	env := testutil.SharedEnv(t)
+	owner := env.CreateUser(t, "searched")
+	collaborator := env.CreateUser(t, "collaborating")
+	stranger := env.CreateUser(t, "stranger")
+	admin := env.CreateAdmin(t, "siteadmin")
+	repo, _ := env.CreateRepository(t, owner, "hidden-"+owner.Username, true)
+	if _, err := env.Deps.Collaborators.AddCollaborator(context.Background(), repo, collaborator.Username, models.RepoPermissionRead); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		found bool
+	}{
+		{"owner", env.CreateToken(t, owner, models.TokenScopeRepoRead), true},
+		{"collaborator", env.CreateToken(t, collaborator, models.TokenScopeRepoRead), true},
+		{"administrator", env.CreateToken(t, admin, models.TokenScopeRepoRead), true},
+		{"stranger", env.CreateToken(t, stranger, models.TokenScopeRepoRead), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var page dto.RepoPageResponse
+			getJSON(t, env, "/api/v1/repos/search?q="+repo.Name, tt.token, &page)
+			found := len(page.Repositories) == 1 && page.Repositories[0].Name == repo.Name
+			if found != tt.found || len(page.Repositories) > 1 {
+				t.Errorf("search by the %s found %d repositories, want found = %v", tt.name, len(page.Repositories), tt.found)
+			}
+		})
+	}
+}
@@ -1,9 +1,15 @@
 package router
 
 import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
 	"github.com/bravo68web/stasis/internal/injectable"
 	"github.com/bravo68web/stasis/internal/server"
+	"github.com/bravo68web/stasis/internal/transport/http/apiversion"
 	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/i18n"
 )
 
 type Router struct {
@@ -11,48 +17,106 @@ type Router struct {
 	Deps   *injectable.Dependencies
 }
 
-// NewRouter creates a new Router instance.
-func NewRouter(s *server.Server) *Router {
-	deps := injectable.LoadDependencies(s.Config, s.DB)
-
+// NewRouter creates a new Router instance serving the services of deps.
+func NewRouter(s *server.Server, deps *injectable.Dependencies) *Router {
 	return &Router{
 		server: s,
-		Deps:   &deps,
+		Deps:   deps,
 	}
 }
 
 // RegisterRoutes sets up the routes and middleware for the server.
 func (r *Router) RegisterRoutes() {
-	// Get allowed origins from config, default to localhost for development
-	allowedOrigins := []string{
-		"http://localhost:3000",
-		"http://127.0.0.1:3000",
-	}
+	// Setup logging and recovery middleware
+	r.setupHTTPLoggerAndRecovery()
+
+	// Believe the X-Forwarded-* headers of the configured proxies only
+	r.server.Use(middleware.TrustedProxyMiddleware(r.server.Config.Server.TrustedProxies))
 
-	// Add configured frontend URL if available (from OIDC config)
+	// Serve the metrics and record every request after this point
+	r.metricsRouter()
+
+	// Set the security headers on every response, preflight responses included
+	r.server.Use(middleware.SecurityHeadersMiddleware(&r.server.Config.Server.SecurityHeaders))
+
+	// Apply the CORS policy to the REST API, allowing the OIDC frontend.
+	// Preflight requests are answered here, before route authentication.
+	var frontendOrigins []string
 	if r.server.Config.OIDC.FrontendURL != "" {
-		allowedOrigins = append(allowedOrigins, r.server.Config.OIDC.FrontendURL)
+		frontendOrigins = append(frontendOrigins, r.server.Config.OIDC.FrontendURL)
 	}
+	r.server.Use(middleware.CORSMiddleware(&r.server.Config.Server.CORS, frontendOrigins...))
 
-	// Setup logging and recovery middleware
-	r.setupHTTPLoggerAndRecovery()
+	// Translate error messages per user preference or Accept-Language
+	r.server.Use(middleware.LocaleMiddleware(i18n.Default()))
+
+	// Negotiate the API version of REST API requests
+	negotiateVersion := apiversion.Middleware(apiversion.Default(r.server.Config.Server.APIVersionDefault))
+	r.server.Use(func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			negotiateVersion(c)
+		}
+	})
 
-	// Apply CORS middleware
-	r.server.Use(middleware.CORSMiddleware(allowedOrigins))
+	// Serve only anonymous fetches while the database is unavailable
+	if r.Deps.DegradedMode != nil {
+		r.server.Use(middleware.DegradedModeMiddleware(r.Deps.DegradedMode, gitRoutePrefix))
+	}
+
+	// Limit the request rate of each client
+	r.setupRateLimits()
+
+	// Redirect API requests for the former names of renamed repositories
+	r.server.Use(middleware.RepoRedirectMiddleware(r.Deps.RepoService))
 
 	r.docsRouter()
 
 	r.healthRouter()
+	r.metaRouter()
 	r.authRouter()
 	r.repoRouter()
+	r.repoImportRouter()
+	r.annotationRouter()
+	r.tagProtectionRouter()
+	r.pushPolicyRouter()
+	r.uploadPackRouter()
+	r.pinnedLinkRouter()
+	r.topicRouter()
+	r.branchProtectionRouter()
+	r.webhookRouter()
+	r.pullRequestRouter()
+	r.issueRouter()
+	r.notificationRouter()
+	r.pushAttemptRouter()
+	r.authorMappingRouter()
+	r.collaboratorRouter()
 	r.gitRouter()
 	r.sshKeyRouter()
+	r.gpgKeyRouter()
 	r.tokenRouter()
 	r.ciRouter()
 	r.userRouter()
+	r.userExportRouter()
+	r.userAdminRouter()
+	r.badgeRouter()
+	r.auditRouter()
+	r.eventRouter()
+	r.auditEventRouter()
+	r.feedRouter()
+	r.onboardingRouter()
+	r.licenseRouter()
+	r.storageRouter()
+	r.backupRouter()
+	r.quotaRouter()
+	r.repoBulkRouter()
+	r.housekeepingRouter()
+	r.commitStatusRouter()
 }
 
 func (r *Router) setupHTTPLoggerAndRecovery() {
+	// Correlate the log lines of each request, git subprocesses included
+	r.server.Use(middleware.RequestIDMiddleware())
+
 	// Add custom logging middleware
 	loggerMiddlewareCfg := &middleware.LoggerConfig{
 		Logger:           r.server.Logger,
@@ -62,7 +126,7 @@ func (r *Router) setupHTTPLoggerAndRecovery() {
 		LogResponseBody:  false,
 		MaxBodyLogSize:   1024,
 		TraceIDHeader:    "X-Trace-ID",
-		RequestIDHeader:  "X-Request-ID",
+		RequestIDHeader:  middleware.RequestIDHeader,
 		IncludeHeaders:   r.server.Config.Logging.Development,
 		SensitiveHeaders: []string{"Authorization", "Cookie", "X-API-Key", "X-Auth-Token"},
 	}
@@ -76,3 +140,52 @@ func (r *Router) setupHTTPLoggerAndRecovery() {
 	}
 	r.server.Use(middleware.RecoveryMiddlewareWithConfig(recoveryCfg))
 }
+
+// setupRateLimits limits API and git transport requests per authenticated
+// user, or per client IP for anonymous requests, each with its own budget.
+// Other routes (docs, health) are not limited.
+func (r *Router) setupRateLimits() {
+	cfg := &r.server.Config.Server.RateLimit
+	if !cfg.Enabled {
+		return
+	}
+
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+	apiLimit := middleware.RateLimitMiddleware(
+		middleware.NewRateLimiter(cfg.API.RequestsPerMinute, cfg.API.Burst),
+		authMiddleware.RateLimitKey,
+	)
+	gitLimit := middleware.RateLimitMiddleware(
+		middleware.NewRateLimiter(cfg.Git.RequestsPerMinute, cfg.Git.Burst),
+		authMiddleware.RateLimitKey,
+	)
+
+	r.server.Use(func(c *gin.Context) {
+		switch {
+		case strings.HasPrefix(c.Request.URL.Path, "/api/"):
+			apiLimit(c)
+		case strings.HasPrefix(c.FullPath(), gitRoutePrefix):
+			gitLimit(c)
+		}
+	})
+}
+
+// objectWriteLimit returns a middleware limiting the API calls that create git
+// objects (branches, tags) per user and repository, so a runaway script cannot
+// bloat a repository within its overall API budget. It goes after RequireAuth,
+// which authenticates the user it is keyed on.
+func (r *Router) objectWriteLimit() gin.HandlerFunc {
+	cfg := &r.server.Config.Server.RateLimit
+	if !cfg.Enabled {
+		return func(c *gin.Context) {}
+	}
+
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+	return middleware.RateLimitMiddleware(
+		middleware.NewRateLimiter(cfg.Writes.RequestsPerMinute, cfg.Writes.Burst),
+		func(c *gin.Context) string {
+			repo := strings.ToLower(c.Param("owner") + "/" + c.Param("repo"))
+			return authMiddleware.RateLimitKey(c) + ":" + repo
+		},
+	)
+}
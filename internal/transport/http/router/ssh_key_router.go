@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/domain/models"
 	"github.com/bravo68web/stasis/internal/transport/http/handler"
 	"github.com/bravo68web/stasis/internal/transport/http/middleware"
 	"github.com/bravo68web/stasis/pkg/openapi"
@@ -17,12 +18,12 @@ func (r *Router) sshKeyRouter() {
 	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
 
 	// Initialize handler
-	sshKeyHandler := handler.NewSSHKeyHandler(r.Deps.SSHKeyService)
+	sshKeyHandler := handler.NewSSHKeyHandler(r.Deps.SSHKeyService, r.Deps.AuditEvents)
 
 	// Register Docs
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/ssh-keys", openapi.RouteDocs{
 		Summary:     "List SSH keys",
-		Description: "Returns all SSH keys for the authenticated user",
+		Description: "Returns all SSH keys for the authenticated user with their type and SHA256 fingerprint, which match the output of ssh-add -l",
 		Tags:        []string{"SSH Keys"},
 		Responses: map[int]openapi.ResponseDoc{
 			http.StatusOK: {
@@ -32,12 +33,15 @@ func (r *Router) sshKeyRouter() {
 			http.StatusUnauthorized: {
 				Description: "Authentication required",
 			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
 		},
 	})
 
 	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/ssh-keys", openapi.RouteDocs{
 		Summary:     "Add SSH key",
-		Description: "Adds a new SSH public key for the authenticated user",
+		Description: "Adds a new SSH public key for the authenticated user. key is a single line in authorized_keys format (e.g. the content of ~/.ssh/id_ed25519.pub). A key can only belong to one user.",
 		Tags:        []string{"SSH Keys"},
 		RequestBody: dto.AddSSHKeyRequest{},
 		Responses: map[int]openapi.ResponseDoc{
@@ -54,6 +58,9 @@ func (r *Router) sshKeyRouter() {
 			http.StatusConflict: {
 				Description: "SSH key already exists",
 			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
 		},
 	})
 
@@ -72,12 +79,15 @@ func (r *Router) sshKeyRouter() {
 			http.StatusNotFound: {
 				Description: "SSH key not found",
 			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
 		},
 	})
 
 	r.server.OpenAPIGenerator.RegisterDocs("DELETE", "/api/v1/ssh-keys/:id", openapi.RouteDocs{
 		Summary:     "Delete SSH key",
-		Description: "Deletes an SSH key by ID",
+		Description: "Deletes an SSH key by ID. Keys are looked up on every SSH connection, so the key stops working on the next connection.",
 		Tags:        []string{"SSH Keys"},
 		Responses: map[int]openapi.ResponseDoc{
 			http.StatusOK: {
@@ -90,15 +100,18 @@ func (r *Router) sshKeyRouter() {
 			http.StatusNotFound: {
 				Description: "SSH key not found",
 			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
 		},
 	})
 
 	// SSH key routes (require authentication)
-	sshKeyGroup := v1.Group("/ssh-keys")
+	sshKeyGroup := v1.Group("/ssh-keys", authMiddleware.RequireAuth(), authMiddleware.RequireScope(models.TokenScopeAdmin))
 	{
-		sshKeyGroup.POST("", authMiddleware.RequireAuth(), sshKeyHandler.AddSSHKey)
-		sshKeyGroup.GET("", authMiddleware.RequireAuth(), sshKeyHandler.ListSSHKeys)
-		sshKeyGroup.GET("/:id", authMiddleware.RequireAuth(), sshKeyHandler.GetSSHKey)
-		sshKeyGroup.DELETE("/:id", authMiddleware.RequireAuth(), sshKeyHandler.DeleteSSHKey)
+		sshKeyGroup.POST("", sshKeyHandler.AddSSHKey)
+		sshKeyGroup.GET("", sshKeyHandler.ListSSHKeys)
+		sshKeyGroup.GET("/:id", sshKeyHandler.GetSSHKey)
+		sshKeyGroup.DELETE("/:id", sshKeyHandler.DeleteSSHKey)
 	}
 }
@@ -0,0 +1,125 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// storageRouter sets up storage backend administration routes
+func (r *Router) storageRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewStorageHandler(r.Deps.RepoService, r.Deps.StorageBackends, r.Deps.StaleFiles, r.Deps.GarbageCollection)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/admin/storage/backends", openapi.RouteDocs{
+		Summary:     "List storage backends",
+		Description: "Get the configured storage backends and the number of repositories living on each. Backends that repositories still reference but that are no longer configured are listed as not configured.",
+		Tags:        []string{"Admin"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.StorageBackendListResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/admin/repos/:owner/:repo/storage", openapi.RouteDocs{
+		Summary:     "Migrate repository storage",
+		Description: "Move a repository to another storage backend. Git operations on the repository are refused while it is copied; the copy is verified before the repository is switched over and the source is deleted. Without a backend the placement rules pick one for the current size of the repository.",
+		Tags:        []string{"Admin"},
+		RequestBody: dto.MigrateRepoStorageRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Repository migrated",
+				Model:       dto.RepoStorageResponse{},
+			},
+			400: {
+				Description: "Unknown storage backend",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+			409: {
+				Description: "Repository already lives on the backend or is being migrated",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/admin/repos/:owner/:repo/stale-files/remove", openapi.RouteDocs{
+		Summary: "Remove stale lock and temporary files",
+		Description: "Remove the lock files (e.g. refs/heads/main.lock) and temporary packs and objects a killed git process left in a repository, which make later pushes fail with \"cannot lock ref\". " +
+			"Only files older than repos.stale_lock_minutes are removed. The git.stale_files housekeeping task does the same for every repository.",
+		Tags: []string{"Admin"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "The removed files",
+				Model:       dto.StaleFilesResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+			409: {
+				Description: "A git operation is in progress on the repository, or stale file cleanup is disabled",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/admin/repos/:owner/:repo/gc", openapi.RouteDocs{
+		Summary: "Collect repository garbage",
+		Description: "Pack the loose objects and merge the packs of a repository now with the strategy of repos.gc_strategy: git gc, or git repack -a -d and git prune-packed. " +
+			"The git.gc housekeeping task does the same every repos.gc_interval_minutes for repositories above the thresholds. Object counts before and after are returned.",
+		Tags: []string{"Admin"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Repository collected",
+				Model:       dto.RepoGCResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+			409: {
+				Description: "A push into the repository or a collection of it is in progress, or it is being migrated",
+			},
+		},
+	})
+
+	// Admin storage routes
+	admin := v1.Group("/admin", authMiddleware.RequireAdmin())
+	{
+		admin.GET("/storage/backends", h.ListBackends)
+		admin.POST("/repos/:owner/:repo/storage", h.MigrateRepository)
+		admin.POST("/repos/:owner/:repo/stale-files/remove", h.RemoveStaleFiles)
+		admin.POST("/repos/:owner/:repo/gc", h.CollectGarbage)
+	}
+}
@@ -0,0 +1,76 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// tagProtectionRouter sets up protected tag settings routes
+func (r *Router) tagProtectionRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewTagProtectionHandler(
+		r.Deps.RepoService,
+		r.Deps.TagProtection,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/settings/protected-tags", openapi.RouteDocs{
+		Summary:     "Get protected tags",
+		Description: "Get the protected tag patterns of a repository and the users or roles allowed to override them",
+		Tags:        []string{"Tags"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.ProtectedTagsResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PUT", "/api/v1/repos/:owner/:repo/settings/protected-tags", openapi.RouteDocs{
+		Summary:     "Update protected tags",
+		Description: "Replace the protected tag patterns of a repository. Tags matching a pattern can be created but not moved or deleted, except by users or roles (role:admin, role:owner) on the override list.",
+		Tags:        []string{"Tags"},
+		RequestBody: dto.UpdateProtectedTagsRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Protected tags updated successfully",
+				Model:       dto.ProtectedTagsResponse{},
+			},
+			400: {
+				Description: "Invalid pattern or override",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	// Protected tag routes
+	settings := v1.Group("/repos/:owner/:repo/settings")
+	{
+		settings.GET("/protected-tags", authMiddleware.RequireAuth(), h.GetProtectedTags)
+		settings.PUT("/protected-tags", authMiddleware.RequireAuth(), h.UpdateProtectedTags)
+	}
+}
@@ -0,0 +1,69 @@
+package router_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+// A forced push of protected and unprotected tags together is refused as a
+// whole: no tag moves, not even the unprotected one
+func TestForcedPushOfProtectedTags(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	env := testutil.SharedEnv(t)
+	owner := env.CreateUser(t, "tagger")
+	repo, b := env.CreateRepository(t, owner, "releases", false)
+	first := b.Commit("main", "Initial commit", testutil.File("README.md", "# demo\n"))
+	b.LightweightTag("v1.0.0", first)
+	b.LightweightTag("v1.1.0", first)
+	b.LightweightTag("nightly", first)
+	if err := env.Deps.TagProtection.UpdateProtectedTags(context.Background(), repo, []string{"v*", "v1.*"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	token := env.CreateToken(t, owner, "repo:read", "repo:write")
+	url := strings.Replace(env.CloneURL(owner.Username, repo.Name), "://", "://"+owner.Username+":"+token+"@", 1)
+	dir := filepath.Join(t.TempDir(), "work")
+	git(t, "", nil, "clone", "--quiet", url, dir)
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# demo\n\nmore\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	identity := []string{"-c", "user.name=Tagger", "-c", "user.email=tagger@example.com"}
+	git(t, dir, nil, append(identity, "commit", "--quiet", "-am", "Second commit")...)
+	second := git(t, dir, nil, "rev-parse", "HEAD")
+
+	tags := func() string {
+		return git(t, b.Path(), nil, "for-each-ref", "--format=%(refname) %(objectname)", "refs/tags")
+	}
+	before := tags()
+
+	push := exec.Command("git", "push", "--force", "--porcelain", "origin",
+		"HEAD:refs/tags/v1.0.0", ":refs/tags/v1.1.0", "HEAD:refs/tags/nightly")
+	push.Dir = dir
+	push.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL=/dev/null", "GIT_TERMINAL_PROMPT=0")
+	out, err := push.CombinedOutput()
+	if err == nil {
+		t.Fatalf("forced push of protected tags succeeded:\n%s", out)
+	}
+	for _, want := range []string{"protected tag cannot be updated", "protected tag cannot be deleted"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("push output does not say %q:\n%s", want, out)
+		}
+	}
+	if after := tags(); after != before {
+		t.Errorf("tags changed by a refused push\nbefore:\n%s\nafter:\n%s", before, after)
+	}
+
+	// On its own, the unprotected tag can be moved
+	git(t, dir, nil, "push", "--quiet", "--force", "origin", "HEAD:refs/tags/nightly")
+	if got := git(t, b.Path(), nil, "rev-parse", "refs/tags/nightly"); got != second {
+		t.Errorf("nightly at %s after a forced push, want %s", got, second)
+	}
+}
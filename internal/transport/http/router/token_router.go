@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/domain/models"
 	"github.com/bravo68web/stasis/internal/transport/http/handler"
 	"github.com/bravo68web/stasis/internal/transport/http/middleware"
 	"github.com/bravo68web/stasis/pkg/openapi"
@@ -17,12 +18,12 @@ func (r *Router) tokenRouter() {
 	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
 
 	// Initialize handler
-	tokenHandler := handler.NewTokenHandler(r.Deps.TokenService)
+	tokenHandler := handler.NewTokenHandler(r.Deps.TokenService, r.Deps.AuditEvents)
 
 	// Register Docs
 	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/tokens", openapi.RouteDocs{
 		Summary:     "List tokens",
-		Description: "Returns all personal access tokens for the authenticated user",
+		Description: "Returns all personal access tokens for the authenticated user with their last use (time, client IP and user agent) and an approximate usage count",
 		Tags:        []string{"Tokens"},
 		Responses: map[int]openapi.ResponseDoc{
 			http.StatusOK: {
@@ -32,12 +33,15 @@ func (r *Router) tokenRouter() {
 			http.StatusUnauthorized: {
 				Description: "Authentication required",
 			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
 		},
 	})
 
 	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/tokens", openapi.RouteDocs{
 		Summary:     "Create token",
-		Description: "Creates a new personal access token",
+		Description: "Creates a new personal access token; the raw token is only returned in this response. Scopes limit what the token can do: repo:read (clone, fetch and browse), repo:write (also push and create repositories), repo:admin (also manage repository settings) and admin (also site administration). A token created without scopes gets repo:read and repo:write; admin is only granted when listed. Send it as Authorization: token <value>, as a Bearer token, or as the password of Basic auth for git over HTTP",
 		Tags:        []string{"Tokens"},
 		RequestBody: dto.CreateTokenRequest{},
 		Responses: map[int]openapi.ResponseDoc{
@@ -50,6 +54,9 @@ func (r *Router) tokenRouter() {
 			http.StatusUnauthorized: {
 				Description: "Authentication required",
 			},
+			http.StatusForbidden: {
+				Description: "The token used cannot create a token with more access than its own",
+			},
 		},
 	})
 
@@ -68,14 +75,17 @@ func (r *Router) tokenRouter() {
 			http.StatusNotFound: {
 				Description: "Token not found",
 			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
 		},
 	})
 
 	// Token routes (require authentication)
-	tokenGroup := v1.Group("/tokens")
+	tokenGroup := v1.Group("/tokens", authMiddleware.RequireAuth(), authMiddleware.RequireScope(models.TokenScopeAdmin))
 	{
-		tokenGroup.POST("", authMiddleware.RequireAuth(), tokenHandler.CreateToken)
-		tokenGroup.GET("", authMiddleware.RequireAuth(), tokenHandler.ListTokens)
-		tokenGroup.DELETE("/:id", authMiddleware.RequireAuth(), tokenHandler.DeleteToken)
+		tokenGroup.POST("", tokenHandler.CreateToken)
+		tokenGroup.GET("", tokenHandler.ListTokens)
+		tokenGroup.DELETE("/:id", tokenHandler.DeleteToken)
 	}
 }
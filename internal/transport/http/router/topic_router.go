@@ -0,0 +1,67 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// topicRouter sets up repository topic routes
+func (r *Router) topicRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewTopicHandler(
+		r.Deps.RepoService,
+		r.Deps.Topics,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("PUT", "/api/v1/repos/:owner/:repo/topics", openapi.RouteDocs{
+		Summary:     "Replace topics",
+		Description: "Replace the topics of a repository, at most 20. Topics are lowercased, spaces become hyphens and duplicates are dropped; each must then be at most 35 letters, digits and hyphens, starting with a letter or digit.",
+		Tags:        []string{"Repositories"},
+		RequestBody: dto.UpdateTopicsRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Topics updated successfully",
+				Model:       dto.TopicsResponse{},
+			},
+			400: {
+				Description: "Invalid topic or too many topics",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/topics", openapi.RouteDocs{
+		Summary:     "List popular topics",
+		Description: "List the topics of public repositories with the number of repositories tagged with each, most used first. limit defaults to 30, at most 100.",
+		Tags:        []string{"Repositories"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.TopicCountListResponse{},
+			},
+			400: {
+				Description: "Invalid limit",
+			},
+		},
+	})
+
+	// Topic routes
+	v1.PUT("/repos/:owner/:repo/topics", authMiddleware.RequireAuth(), h.UpdateTopics)
+	v1.GET("/topics", h.ListPopularTopics)
+}
@@ -0,0 +1,97 @@
+package router_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+// gitArchive runs git archive --remote as the owner of key and returns the
+// names of the files of the tar archive it received
+func gitArchive(t *testing.T, key, url string, args ...string) ([]string, error) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"archive", "--format=tar", "--remote=" + url}, args...)...)
+	cmd.Dir = t.TempDir()
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL=/dev/null", "GIT_TERMINAL_PROMPT=0",
+		"GIT_SSH_COMMAND=ssh -i "+key+" -o IdentitiesOnly=yes -o BatchMode=yes"+
+			" -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -o LogLevel=ERROR")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.New(strings.TrimSpace(stderr.String()))
+	}
+
+	var names []string
+	r := tar.NewReader(bytes.NewReader(out))
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			return names, nil
+		}
+		if err != nil {
+			t.Fatalf("read archive: %v", err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			names = append(names, header.Name)
+		}
+	}
+}
+
+// git archive --remote must serve the readers of a repository over SSH, and
+// refuse everyone else the way upload-pack does
+func TestUploadArchiveSSH(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	env := testutil.SharedEnv(t)
+	owner := env.CreateUser(t, "archiver")
+	reader := env.CreateUser(t, "reader")
+	outsider := env.CreateUser(t, "outsider")
+	repo, b := env.CreateRepository(t, owner, "secret", true)
+	b.Commit("main", "Initial commit", testutil.File("README.md", "# secret\n"), testutil.File("docs/guide.md", "# guide\n"))
+	if _, err := env.Deps.Collaborators.AddCollaborator(context.Background(), repo, reader.Username, models.RepoPermissionRead); err != nil {
+		t.Fatal(err)
+	}
+	url := env.SSHCloneURL(t, owner.Username, repo.Name)
+
+	tests := []struct {
+		name string
+		user *models.User
+		args []string
+		want []string // nil if refused
+	}{
+		{name: "owner", user: owner, args: []string{"main"}, want: []string{"README.md", "docs/guide.md"}},
+		{name: "owner, subdirectory", user: owner, args: []string{"main", "docs"}, want: []string{"docs/guide.md"}},
+		{name: "collaborator with read access", user: reader, args: []string{"main"}, want: []string{"README.md", "docs/guide.md"}},
+		{name: "outsider", user: outsider, args: []string{"main"}},
+		{name: "unknown ref", user: owner, args: []string{"missing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, err := gitArchive(t, env.AddSSHKey(t, tt.user), url, tt.args...)
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("git archive --remote %v succeeded with %v, want it refused", tt.args, names)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("git archive --remote %v: %v", tt.args, err)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("archived %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// uploadPackRouter sets up upload-pack settings routes
+func (r *Router) uploadPackRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewUploadPackHandler(
+		r.Deps.RepoService,
+		r.Deps.UploadPack,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/settings/upload-pack", openapi.RouteDocs{
+		Summary:     "Get upload-pack settings",
+		Description: "Get the fetches clients of a repository may make: partial clones and fetches of objects by ID",
+		Tags:        []string{"Repositories"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.UploadPackSettingsResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PUT", "/api/v1/repos/:owner/:repo/settings/upload-pack", openapi.RouteDocs{
+		Summary:     "Update upload-pack settings",
+		Description: "Update the upload-pack settings of a repository, applied to the next fetches over HTTP and SSH and written to its git config. allow_filter allows partial clones (--filter=blob:none); allow_reachable_sha1_in_want allows fetching objects by ID if a ref reaches them, which partial clones need to fetch the objects they left out; allow_any_sha1_in_want allows fetching any object by ID, even one no ref reaches. Settings left out keep their value.",
+		Tags:        []string{"Repositories"},
+		RequestBody: dto.UploadPackSettingsRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Upload-pack settings updated successfully",
+				Model:       dto.UploadPackSettingsResponse{},
+			},
+			400: {
+				Description: "Invalid request body",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	// Upload-pack settings routes
+	settings := v1.Group("/repos/:owner/:repo/settings")
+	{
+		settings.GET("/upload-pack", authMiddleware.RequireAuth(), h.GetSettings)
+		settings.PUT("/upload-pack", authMiddleware.RequireAuth(), h.UpdateSettings)
+	}
+}
@@ -0,0 +1,132 @@
+package router_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/testutil"
+)
+
+// git runs git in dir with the environment of the test and returns its
+// standard output
+func git(t *testing.T, dir string, env []string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL=/dev/null", "GIT_TERMINAL_PROMPT=0")
+	cmd.Env = append(cmd.Env, env...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// objectCount returns the number of objects stored in a repository
+func objectCount(t *testing.T, dir string) int {
+	t.Helper()
+	out := git(t, dir, nil, "cat-file", "--batch-all-objects", "--batch-check")
+	if out == "" {
+		return 0
+	}
+	return len(strings.Split(out, "\n"))
+}
+
+// commitCount returns the number of commits HEAD reaches in a repository
+func commitCount(t *testing.T, dir string) int {
+	t.Helper()
+	n, err := strconv.Atoi(git(t, dir, nil, "rev-list", "--count", "HEAD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// Shallow, deepened and partial fetches must get the objects they asked for
+// over both transports and protocol versions. The history has 3 commits,
+// 4 trees and 3 blobs; its tip holds 2 trees and 2 blobs.
+func TestUploadPackFetches(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	env := testutil.SharedEnv(t)
+	owner := env.CreateUser(t, "fetcher")
+	repo, b := env.CreateRepository(t, owner, "history", false)
+	b.Commit("main", "Initial commit", testutil.File("README.md", "# demo\n"))
+	b.Commit("main", "Add guide", testutil.File("docs/guide.md", "# guide\n"))
+	b.Commit("main", "Update readme", testutil.File("README.md", "# demo\n\nA demo.\n"))
+
+	key := env.AddSSHKey(t, owner)
+	sshCommand := "GIT_SSH_COMMAND=ssh -i " + key + " -o IdentitiesOnly=yes -o BatchMode=yes" +
+		" -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -o LogLevel=ERROR"
+	transports := []struct {
+		name string
+		url  string
+		env  []string
+	}{
+		{"http", env.CloneURL(owner.Username, repo.Name), nil},
+		{"ssh", env.SSHCloneURL(t, owner.Username, repo.Name), []string{sshCommand}},
+	}
+
+	for _, transport := range transports {
+		for _, version := range []string{"0", "2"} {
+			t.Run(transport.name+"/v"+version, func(t *testing.T) {
+				dir := t.TempDir()
+				clone := func(name string, args ...string) string {
+					args = append([]string{"-c", "protocol.version=" + version, "clone", "--quiet", "--bare"}, args...)
+					git(t, dir, transport.env, append(args, transport.url, name)...)
+					return filepath.Join(dir, name)
+				}
+
+				shallow := clone("shallow.git", "--depth", "1")
+				if commits, objects := commitCount(t, shallow), objectCount(t, shallow); commits != 1 || objects != 5 {
+					t.Errorf("depth 1 clone: %d commits and %d objects, want 1 and 5", commits, objects)
+				}
+
+				git(t, shallow, transport.env, "-c", "protocol.version="+version, "fetch", "--quiet", "--deepen", "2", "origin", "main")
+				if commits, objects := commitCount(t, shallow), objectCount(t, shallow); commits != 3 || objects != 10 {
+					t.Errorf("deepened by 2: %d commits and %d objects, want 3 and 10", commits, objects)
+				}
+
+				partial := clone("partial.git", "--filter", "blob:none")
+				if objects := objectCount(t, partial); objects != 7 {
+					t.Errorf("blob:none clone: %d objects, want 7", objects)
+				}
+				var missing int
+				for _, line := range strings.Split(git(t, partial, nil, "rev-list", "--objects", "--all", "--missing=print"), "\n") {
+					if strings.HasPrefix(line, "?") {
+						missing++
+					}
+				}
+				if missing != 3 {
+					t.Errorf("blob:none clone: %d missing objects, want the 3 blobs", missing)
+				}
+			})
+		}
+	}
+
+	// Without filters, a partial clone gets every object
+	settings := models.DefaultUploadPackSettings()
+	settings.AllowFilter = false
+	if err := env.Deps.UploadPack.UpdateSettings(context.Background(), repo, settings); err != nil {
+		t.Fatal(err)
+	}
+	for _, transport := range transports {
+		t.Run(transport.name+"/filter disabled", func(t *testing.T) {
+			dir := t.TempDir()
+			git(t, dir, transport.env, "clone", "--quiet", "--bare", "--filter", "blob:none", transport.url, "full.git")
+			if objects := objectCount(t, filepath.Join(dir, "full.git")); objects != 10 {
+				t.Errorf("blob:none clone with filters disabled: %d objects, want 10", objects)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// userAdminRouter sets up user administration routes
+func (r *Router) userAdminRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewUserAdminHandler(r.Deps.UserAdmin, r.Deps.AuditEvents)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/admin/users", openapi.RouteDocs{
+		Summary:     "List users",
+		Description: "List the users of the server by username. Filter with ?q= (a case-insensitive substring of the username or email); paginate with ?page= and ?per_page= (default 20, max 100).",
+		Tags:        []string{"Admin"},
+		Parameters:  pagination.Resources.Parameters(),
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.ListAdminUsersResponse{},
+			},
+			400: {
+				Description: "Invalid pagination parameter",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/admin/users", openapi.RouteDocs{
+		Summary:     "Provision user",
+		Description: "Create a user ahead of their first sign-in. The user is linked to their OIDC identity by email when they first sign in.",
+		Tags:        []string{"Admin"},
+		RequestBody: dto.CreateAdminUserRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			201: {
+				Description: "User created",
+				Model:       dto.AdminUserResponse{},
+			},
+			400: {
+				Description: "Invalid username or email",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+			409: {
+				Description: "Username or email already taken",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/admin/users/:username", openapi.RouteDocs{
+		Summary:     "Get user",
+		Description: "Get a user by username or ID, with the number of repositories they own.",
+		Tags:        []string{"Admin"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.AdminUserResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+			404: {
+				Description: "User not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PATCH", "/api/v1/admin/users/:username", openapi.RouteDocs{
+		Summary:     "Update user",
+		Description: "Change the email or admin status of a user, given by username or ID. The last administrator cannot be demoted.",
+		Tags:        []string{"Admin"},
+		RequestBody: dto.UpdateAdminUserRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "User updated",
+				Model:       dto.AdminUserResponse{},
+			},
+			400: {
+				Description: "Invalid email",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+			404: {
+				Description: "User not found",
+			},
+			409: {
+				Description: "Email already taken, or the user is the last administrator",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("DELETE", "/api/v1/admin/users/:username", openapi.RouteDocs{
+		Summary:     "Delete user",
+		Description: "Delete a user, given by username or ID, with their access tokens and SSH keys. A user owning repositories is only deleted with ?force=true, which deletes the repositories too. The last administrator cannot be deleted.",
+		Tags:        []string{"Admin"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "User deleted",
+				Model:       dto.DeleteAdminUserResponse{},
+			},
+			400: {
+				Description: "Invalid force parameter",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Admin privileges required",
+			},
+			404: {
+				Description: "User not found",
+			},
+			409: {
+				Description: "The user owns repositories, is the last administrator, or is still referenced",
+			},
+		},
+	})
+
+	// Admin user routes
+	admin := v1.Group("/admin", authMiddleware.RequireAdmin())
+	{
+		admin.GET("/users", h.ListUsers)
+		admin.POST("/users", h.CreateUser)
+		admin.GET("/users/:username", h.GetUser)
+		admin.PATCH("/users/:username", h.UpdateUser)
+		admin.DELETE("/users/:username", h.DeleteUser)
+	}
+}
@@ -0,0 +1,135 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/domain/models"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// userExportRouter sets up user data export routes
+func (r *Router) userExportRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewUserExportHandler(r.Deps.UserService, r.Deps.UserExports)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/users/export", openapi.RouteDocs{
+		Summary:     "Request a data export",
+		Description: "Queues an export of the current user's data: profile, emails, SSH key and token metadata (never secrets), owned repositories (with git bundles if include_repositories is set), pull requests, contribution events, pushes and audit events, packaged as a zip. A user.export_ready notification with a signed download link is sent when the archive is ready. One export per day; archives are deleted after the configured retention.",
+		Tags:        []string{"Users"},
+		RequestBody: dto.RequestUserExportRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusAccepted: {
+				Description: "Export queued",
+				Model:       dto.UserExportResponse{},
+			},
+			http.StatusUnauthorized: {
+				Description: "Authentication required",
+			},
+			http.StatusTooManyRequests: {
+				Description: "An export was already requested in the last day",
+			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/users/exports", openapi.RouteDocs{
+		Summary:     "List data exports",
+		Description: "Lists the current user's data exports, newest first. Ready exports carry a signed download_url valid until expires_at.",
+		Tags:        []string{"Users"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Data exports",
+				Model:       dto.ListUserExportsResponse{},
+			},
+			http.StatusUnauthorized: {
+				Description: "Authentication required",
+			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/users/exports/:id", openapi.RouteDocs{
+		Summary:     "Get a data export",
+		Description: "Returns a data export of the current user and, once it is ready, its signed download_url.",
+		Tags:        []string{"Users"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Data export",
+				Model:       dto.UserExportResponse{},
+			},
+			http.StatusUnauthorized: {
+				Description: "Authentication required",
+			},
+			http.StatusNotFound: {
+				Description: "Export not found",
+			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/users/exports/:id/download", openapi.RouteDocs{
+		Summary:     "Download a data export",
+		Description: "Streams the zip archive of a ready export. Authorized by the expires and signature query parameters of the download link rather than by a session.",
+		Tags:        []string{"Users"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Zip archive",
+			},
+			http.StatusForbidden: {
+				Description: "Invalid or expired download link",
+			},
+			http.StatusNotFound: {
+				Description: "Export not found or deleted",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/admin/users/:username/export", openapi.RouteDocs{
+		Summary:     "Request a data export of a user",
+		Description: "Queues an export of the data of any user, as POST /api/v1/users/export does for the current user. Not subject to the daily limit. The download link is sent to the exported user. Requires administrator privileges.",
+		Tags:        []string{"Admin"},
+		RequestBody: dto.RequestUserExportRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusAccepted: {
+				Description: "Export queued",
+				Model:       dto.UserExportResponse{},
+			},
+			http.StatusForbidden: {
+				Description: "Administrator privileges required",
+			},
+			http.StatusNotFound: {
+				Description: "User not found",
+			},
+		},
+	})
+
+	// Signed download links work without a session
+	v1.GET("/users/exports/:id/download", h.DownloadExport)
+
+	exports := v1.Group("/users", authMiddleware.RequireAuth(), authMiddleware.RequireScope(models.TokenScopeAdmin))
+	{
+		exports.POST("/export", h.RequestExport)
+		exports.GET("/exports", h.ListExports)
+		exports.GET("/exports/:id", h.GetExport)
+	}
+
+	admin := v1.Group("/admin", authMiddleware.RequireAdmin())
+	{
+		admin.POST("/users/:username/export", h.RequestExportForUser)
+	}
+}
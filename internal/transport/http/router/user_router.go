@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/domain/models"
 	"github.com/bravo68web/stasis/internal/transport/http/handler"
 	"github.com/bravo68web/stasis/internal/transport/http/middleware"
 	"github.com/bravo68web/stasis/pkg/openapi"
@@ -15,7 +16,7 @@ func (r *Router) userRouter() {
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
 	// Initialize handlers
-	userHandler := handler.NewUserHandler(r.Deps.UserService)
+	userHandler := handler.NewUserHandler(r.Deps.UserService, r.Deps.Contributions)
 
 	// Register Docs
 	r.server.OpenAPIGenerator.RegisterDocs("PUT", "/api/v1/users/username", openapi.RouteDocs{
@@ -37,13 +38,79 @@ func (r *Router) userRouter() {
 			http.StatusConflict: {
 				Description: "Username already taken",
 			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
 		},
 	})
 
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/users/settings", openapi.RouteDocs{
+		Summary:     "Get user settings",
+		Description: "Returns the current user's settings",
+		Tags:        []string{"Users"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Current settings",
+				Model:       dto.UserSettingsResponse{},
+			},
+			http.StatusUnauthorized: {
+				Description: "Authentication required",
+			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PUT", "/api/v1/users/settings", openapi.RouteDocs{
+		Summary:     "Update user settings",
+		Description: "Updates the current user's settings. show_private_contributions exposes anonymous counts of private repository contributions on the public contribution calendar. locale selects the language of API error messages (one of available_locales; empty follows Accept-Language).",
+		Tags:        []string{"Users"},
+		RequestBody: dto.UpdateUserSettingsRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Settings updated successfully",
+				Model:       dto.UserSettingsResponse{},
+			},
+			http.StatusBadRequest: {
+				Description: "Invalid request",
+			},
+			http.StatusUnauthorized: {
+				Description: "Authentication required",
+			},
+			http.StatusForbidden: {
+				Description: "Token without the admin scope",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/users/:username/contributions", openapi.RouteDocs{
+		Summary:     "Get contribution calendar",
+		Description: "Returns daily contribution counts of a user between from and to (YYYY-MM-DD, inclusive, at most one year; defaults to the year ending today). Private repository contributions are only counted for the user themselves unless they enabled show_private_contributions.",
+		Tags:        []string{"Users"},
+		Responses: map[int]openapi.ResponseDoc{
+			http.StatusOK: {
+				Description: "Contribution calendar",
+				Model:       dto.ContributionCalendarResponse{},
+			},
+			http.StatusBadRequest: {
+				Description: "Invalid or too wide date range",
+			},
+			http.StatusNotFound: {
+				Description: "User not found",
+			},
+		},
+	})
+
+	// Public user routes
+	v1.GET("/users/:username/contributions", authMiddleware.Authenticate(), userHandler.GetContributions)
+
 	// Register user routes
 	userGroup := v1.Group("/users")
 	{
-		userGroup.Use(authMiddleware.RequireAuth())
+		userGroup.Use(authMiddleware.RequireAuth(), authMiddleware.RequireScope(models.TokenScopeAdmin))
 		userGroup.PUT("/username", userHandler.UpdateCurrentUsername)
+		userGroup.GET("/settings", userHandler.GetSettings)
+		userGroup.PUT("/settings", userHandler.UpdateSettings)
 	}
 }
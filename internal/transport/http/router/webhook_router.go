@@ -0,0 +1,179 @@
+package router
+
+import (
+	"github.com/bravo68web/stasis/internal/application/dto"
+	"github.com/bravo68web/stasis/internal/transport/http/handler"
+	"github.com/bravo68web/stasis/internal/transport/http/middleware"
+	"github.com/bravo68web/stasis/internal/transport/http/pagination"
+	"github.com/bravo68web/stasis/pkg/openapi"
+)
+
+// webhookRouter sets up webhook routes
+func (r *Router) webhookRouter() {
+	v1 := r.server.Group("/api/v1")
+
+	// Initialize auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(r.Deps.AuthService)
+
+	// Initialize handler
+	h := handler.NewWebhookHandler(
+		r.Deps.RepoService,
+		r.Deps.Webhooks,
+	)
+
+	// Register OpenAPI Docs
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/hooks", openapi.RouteDocs{
+		Summary:     "List webhooks",
+		Description: "List the webhooks of a repository. Secrets are never returned. Requires admin access.",
+		Tags:        []string{"Webhooks"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.WebhookListResponse{},
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("POST", "/api/v1/repos/:owner/:repo/hooks", openapi.RouteDocs{
+		Summary:     "Create webhook",
+		Description: "Post the CI job events of a repository to a URL: ci_job.queued, ci_job.started, ci_job.succeeded, ci_job.failed (failed, timed out or errored) and ci_job.cancelled, optionally only for branches or tags matching ref_pattern (e.g. main or release/*). Each delivery carries X-Stasis-Event, X-Stasis-Delivery (a delivery ID that stays the same across retries) and X-Stasis-Signature-256, sha256= followed by the hex HMAC-SHA256 of the body keyed with the secret. Errors and non-2xx responses are retried with backoff up to webhooks.max_attempts. Requires admin access.",
+		Tags:        []string{"Webhooks"},
+		RequestBody: dto.WebhookRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			201: {
+				Description: "Webhook created",
+				Model:       dto.WebhookResponse{},
+			},
+			400: {
+				Description: "Invalid URL, secret, events or ref pattern",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/hooks/:id", openapi.RouteDocs{
+		Summary:     "Get webhook",
+		Description: "Get a webhook of a repository. Requires admin access.",
+		Tags:        []string{"Webhooks"},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.WebhookResponse{},
+			},
+			400: {
+				Description: "Invalid webhook ID",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository or webhook not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("PUT", "/api/v1/repos/:owner/:repo/hooks/:id", openapi.RouteDocs{
+		Summary:     "Update webhook",
+		Description: "Replace the URL, events, ref pattern and state of a webhook. The secret is kept when omitted. Requires admin access.",
+		Tags:        []string{"Webhooks"},
+		RequestBody: dto.WebhookRequest{},
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Webhook updated",
+				Model:       dto.WebhookResponse{},
+			},
+			400: {
+				Description: "Invalid webhook ID, URL, secret, events or ref pattern",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository or webhook not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("DELETE", "/api/v1/repos/:owner/:repo/hooks/:id", openapi.RouteDocs{
+		Summary:     "Delete webhook",
+		Description: "Remove a webhook and its deliveries. Requires admin access.",
+		Tags:        []string{"Webhooks"},
+		Responses: map[int]openapi.ResponseDoc{
+			204: {
+				Description: "Webhook deleted",
+			},
+			400: {
+				Description: "Invalid webhook ID",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository or webhook not found",
+			},
+		},
+	})
+
+	r.server.OpenAPIGenerator.RegisterDocs("GET", "/api/v1/repos/:owner/:repo/hooks/:id/deliveries", openapi.RouteDocs{
+		Summary:     "List webhook deliveries",
+		Description: "List the recent deliveries of a webhook, most recent first, with the payload posted, the attempts made and the response status of the last one. Deliveries are kept for webhooks.retention_days. Requires admin access.",
+		Tags:        []string{"Webhooks"},
+		Parameters:  pagination.Resources.Parameters(),
+		Responses: map[int]openapi.ResponseDoc{
+			200: {
+				Description: "Successful response",
+				Model:       dto.ListWebhookDeliveriesResponse{},
+			},
+			400: {
+				Description: "Invalid webhook ID or pagination parameter",
+			},
+			401: {
+				Description: "Unauthorized",
+			},
+			403: {
+				Description: "Forbidden",
+			},
+			404: {
+				Description: "Repository or webhook not found",
+			},
+		},
+	})
+
+	// Webhook routes
+	hooks := v1.Group("/repos/:owner/:repo/hooks")
+	hooks.Use(authMiddleware.RequireAuth())
+	{
+		hooks.GET("", h.ListHooks)
+		hooks.POST("", h.CreateHook)
+		hooks.GET("/:id", h.GetHook)
+		hooks.PUT("/:id", h.UpdateHook)
+		hooks.DELETE("/:id", h.DeleteHook)
+		hooks.GET("/:id/deliveries", h.ListDeliveries)
+	}
+}
@@ -0,0 +1,126 @@
+package ssh
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// rsaHostKeyBits is the size of generated RSA host keys
+const rsaHostKeyBits = 4096
+
+// withHostKeys loads the configured host keys, generating missing ones if
+// enabled, and returns the option installing them on the server
+func (s *Server) withHostKeys() (ssh.Option, error) {
+	signers := make([]gossh.Signer, 0, 2)
+
+	signer, err := s.loadHostKey(s.config.HostKeyPath, "ed25519")
+	if err != nil {
+		return nil, err
+	}
+	signers = append(signers, signer)
+
+	if s.config.RSAHostKeyPath != "" {
+		signer, err := s.loadHostKey(s.config.RSAHostKeyPath, "rsa")
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	}
+
+	return func(srv *ssh.Server) error {
+		for _, signer := range signers {
+			srv.AddHostKey(signer)
+		}
+		return nil
+	}, nil
+}
+
+// loadHostKey reads the host key at path. A missing key of keyType is
+// generated if ssh.auto_generate_host_key is set.
+func (s *Server) loadHostKey(path, keyType string) (gossh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if !s.config.AutoGenerateHostKey {
+			return nil, fmt.Errorf("SSH host key %s does not exist and ssh.auto_generate_host_key is disabled", path)
+		}
+		return s.generateHostKey(path, keyType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH host key %s: %w", path, err)
+	}
+
+	signer, err := gossh.ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("SSH host key %s is not an unencrypted OpenSSH or PEM private key: %w", path, err)
+	}
+
+	s.log.Info("Loaded SSH host key",
+		logger.String("host_key_path", path),
+		logger.String("type", signer.PublicKey().Type()),
+		logger.String("fingerprint", gossh.FingerprintSHA256(signer.PublicKey())),
+	)
+	return signer, nil
+}
+
+// generateHostKey writes a new host key of keyType (ed25519 or rsa) to path,
+// readable by the server only, and logs its fingerprint so operators can pin it
+func (s *Server) generateHostKey(path, keyType string) (gossh.Signer, error) {
+	var key crypto.Signer
+	var err error
+	switch keyType {
+	case "rsa":
+		key, err = rsa.GenerateKey(rand.Reader, rsaHostKeyBits)
+	default:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate SSH host key: %w", err)
+	}
+
+	block, err := gossh.MarshalPrivateKey(key, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SSH host key: %w", err)
+	}
+	signer, err := gossh.NewSignerFromSigner(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate SSH host key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create SSH host key directory: %w", err)
+	}
+	// O_EXCL: never overwrite a key another process wrote meanwhile
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write SSH host key %s: %w", path, err)
+	}
+	if _, err := file.Write(pem.EncodeToMemory(block)); err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write SSH host key %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write SSH host key %s: %w", path, err)
+	}
+
+	s.log.Info("Generated SSH host key",
+		logger.String("host_key_path", path),
+		logger.String("type", signer.PublicKey().Type()),
+		logger.String("fingerprint", gossh.FingerprintSHA256(signer.PublicKey())),
+	)
+	return signer, nil
+}
@@ -0,0 +1,134 @@
+package ssh
+
+import (
+	"slices"
+
+	"github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/bravo68web/stasis/pkg/logger"
+)
+
+// Request types refused unless enabled in the configuration
+const (
+	ptyRequestType   = "pty-req"
+	agentRequestType = "auth-agent-req@openssh.com"
+	envRequestType   = "env"
+	x11RequestType   = "x11-req"
+)
+
+// forwardingRequestTypes are the global requests that set up port or socket
+// forwarding, which the server always refuses
+var forwardingRequestTypes = []string{
+	"tcpip-forward",
+	"cancel-tcpip-forward",
+	"streamlocal-forward@openssh.com",
+	"cancel-streamlocal-forward@openssh.com",
+}
+
+// withRequestPolicy installs the channel and request handlers that keep
+// sessions to running git commands: session requests go through
+// allowSessionRequest, other channel types and forwarding requests are refused
+func (s *Server) withRequestPolicy() ssh.Option {
+	return func(srv *ssh.Server) error {
+		srv.ChannelHandlers = map[string]ssh.ChannelHandler{
+			"session": s.handleSessionChannel,
+			"default": s.rejectChannel,
+		}
+		srv.RequestHandlers = map[string]ssh.RequestHandler{}
+		for _, requestType := range forwardingRequestTypes {
+			srv.RequestHandlers[requestType] = s.denyGlobalRequest
+		}
+		return nil
+	}
+}
+
+// handleSessionChannel serves a session channel, dropping the requests the
+// configuration does not allow before the session sees them
+func (s *Server) handleSessionChannel(srv *ssh.Server, conn *gossh.ServerConn, newChan gossh.NewChannel, ctx ssh.Context) {
+	ssh.DefaultSessionHandler(srv, conn, &filteredChannel{
+		NewChannel: newChan,
+		allow:      func(req *gossh.Request) bool { return s.allowSessionRequest(ctx, req) },
+	}, ctx)
+}
+
+// allowSessionRequest returns true if a request of a session channel may
+// reach the session, logging the ones it refuses
+func (s *Server) allowSessionRequest(ctx ssh.Context, req *gossh.Request) bool {
+	switch req.Type {
+	case ptyRequestType:
+		if s.config.AllowPTY {
+			return true
+		}
+	case agentRequestType:
+		if s.config.AllowAgentForwarding {
+			return true
+		}
+	case envRequestType:
+		var kv struct{ Key, Value string }
+		if err := gossh.Unmarshal(req.Payload, &kv); err == nil && slices.Contains(s.config.AllowedEnv, kv.Key) {
+			return true
+		}
+		s.log.Info("Denied SSH environment variable",
+			logger.String("session_id", ctx.SessionID()),
+			logger.String("name", kv.Key),
+		)
+		return false
+	case x11RequestType:
+	default:
+		return true
+	}
+
+	s.log.Info("Denied SSH session request",
+		logger.String("session_id", ctx.SessionID()),
+		logger.String("request_type", req.Type),
+	)
+	return false
+}
+
+// rejectChannel refuses channels other than sessions, such as direct-tcpip
+// port forwarding
+func (s *Server) rejectChannel(_ *ssh.Server, _ *gossh.ServerConn, newChan gossh.NewChannel, ctx ssh.Context) {
+	s.log.Info("Denied SSH channel",
+		logger.String("session_id", ctx.SessionID()),
+		logger.String("channel_type", newChan.ChannelType()),
+	)
+	_ = newChan.Reject(gossh.Prohibited, "channel type not allowed")
+}
+
+// denyGlobalRequest refuses a forwarding request
+func (s *Server) denyGlobalRequest(ctx ssh.Context, _ *ssh.Server, req *gossh.Request) (bool, []byte) {
+	s.log.Info("Denied SSH request",
+		logger.String("session_id", ctx.SessionID()),
+		logger.String("request_type", req.Type),
+	)
+	return false, nil
+}
+
+// filteredChannel is a session channel whose requests are vetted by allow;
+// refused requests are answered with a failure and never delivered
+type filteredChannel struct {
+	gossh.NewChannel
+	allow func(*gossh.Request) bool
+}
+
+// Accept implements gossh.NewChannel
+func (c *filteredChannel) Accept() (gossh.Channel, <-chan *gossh.Request, error) {
+	ch, reqs, err := c.NewChannel.Accept()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allowed := make(chan *gossh.Request)
+	go func() {
+		defer close(allowed)
+		for req := range reqs {
+			if c.allow(req) {
+				allowed <- req
+			} else if req.WantReply {
+				_ = req.Reply(false, nil)
+			}
+		}
+	}()
+	return ch, allowed, nil
+}
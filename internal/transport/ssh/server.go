@@ -2,6 +2,7 @@ package ssh
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"strings"
@@ -11,24 +12,41 @@ import (
 
 	"github.com/bravo68web/stasis/internal/application/service"
 	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/internal/domain/events"
 	"github.com/bravo68web/stasis/internal/domain/models"
 	domainservice "github.com/bravo68web/stasis/internal/domain/service"
 	"github.com/bravo68web/stasis/internal/infrastructure/git"
+	apperrors "github.com/bravo68web/stasis/pkg/errors"
 	"github.com/bravo68web/stasis/pkg/logger"
 	"github.com/charmbracelet/ssh"
 	"github.com/charmbracelet/wish"
 )
 
+// errRepositoryNotFound is returned for a repository that does not exist or
+// that the user cannot read; the client is shown git.RepositoryNotFoundMessage
+var errRepositoryNotFound = errors.New("repository not found")
+
 // Server represents the SSH server for Git operations
 type Server struct {
 	server      *ssh.Server
 	config      *config.SSHConfig
 	authService domainservice.AuthService
 	repoService *service.RepoService
+	tagProtect  *service.TagProtectionService
+	branches    *service.BranchProtectionService
+	contribs    *service.ContributionService
+	licenses    *service.LicenseService
+	pinnedLinks *service.PinnedLinkService
+	languages   *service.LanguageService
 	ciService   *service.CIService
 	gitService  domainservice.GitService
 	gitProtocol *git.GitProtocol
-	storage     domainservice.StorageService
+	storage     *service.StorageBackendService
+	pushes      *service.PushAttemptService
+	quotas      *service.QuotaService
+	degraded    *service.DegradedModeService
+	publisher   events.Publisher
+	sessions    *sessionLimiter
 	log         *logger.Logger
 }
 
@@ -38,9 +56,20 @@ func NewServer(
 	storageCfg *config.StorageConfig,
 	authService domainservice.AuthService,
 	repoService *service.RepoService,
+	tagProtect *service.TagProtectionService,
+	branches *service.BranchProtectionService,
+	contribs *service.ContributionService,
+	licenses *service.LicenseService,
+	pinnedLinks *service.PinnedLinkService,
+	languages *service.LanguageService,
 	ciService *service.CIService,
 	gitService domainservice.GitService,
-	storage domainservice.StorageService,
+	gitProtocol *git.GitProtocol,
+	storage *service.StorageBackendService,
+	pushes *service.PushAttemptService,
+	quotas *service.QuotaService,
+	degraded *service.DegradedModeService,
+	publisher events.Publisher,
 ) (*Server, error) {
 	log := logger.Get().WithFields(logger.Component("ssh-server"))
 
@@ -54,18 +83,38 @@ func NewServer(
 		config:      cfg,
 		authService: authService,
 		repoService: repoService,
+		tagProtect:  tagProtect,
+		branches:    branches,
+		contribs:    contribs,
+		licenses:    licenses,
+		pinnedLinks: pinnedLinks,
+		languages:   languages,
 		ciService:   ciService,
 		gitService:  gitService,
-		gitProtocol: git.NewGitProtocol(),
+		gitProtocol: gitProtocol,
 		storage:     storage,
+		pushes:      pushes,
+		quotas:      quotas,
+		degraded:    degraded,
+		publisher:   publisher,
+		sessions:    newSessionLimiter(cfg),
 		log:         log,
 	}
 
+	hostKeys, err := s.withHostKeys()
+	if err != nil {
+		log.Error("Failed to load SSH host keys",
+			logger.Error(err),
+		)
+		return nil, err
+	}
+
 	// Create the wish server with options
 	server, err := wish.NewServer(
 		wish.WithAddress(net.JoinHostPort(cfg.Host, fmt.Sprintf("%d", cfg.Port))),
-		wish.WithHostKeyPath(cfg.HostKeyPath),
+		hostKeys,
 		wish.WithPublicKeyAuth(s.publicKeyHandler),
+		s.withRequestPolicy(),
 		wish.WithMiddleware(
 			s.gitMiddleware,
 			s.loggingMiddleware,
@@ -130,8 +179,16 @@ func (s *Server) publicKeyHandler(ctx ssh.Context, key ssh.PublicKey) bool {
 		logger.String("key_type", key.Type()),
 	)
 
+	// Keys cannot be checked without the database: in degraded mode every
+	// client is let in as anonymous, which only allows fetching public
+	// repositories
+	if s.degraded.Active() {
+		ctx.SetValue("degraded", true)
+		return true
+	}
+
 	// Authenticate using the fingerprint
-	user, err := s.authService.AuthenticateSSH(context.Background(), []byte(fingerprint))
+	user, err := s.authService.AuthenticateSSH(ctx, []byte(fingerprint))
 	if err != nil {
 		s.log.Warn("SSH authentication failed",
 			logger.String("fingerprint", fingerprint),
@@ -158,6 +215,20 @@ func (s *Server) publicKeyHandler(ctx ssh.Context, key ssh.PublicKey) bool {
 // gitMiddleware handles Git SSH protocol commands
 func (s *Server) gitMiddleware(next ssh.Handler) ssh.Handler {
 	return func(sess ssh.Session) {
+		// Refuse sessions beyond the session limits, telling the client to retry
+		release, err := s.sessions.acquire(sessionKey(sess, s.getUserFromSession(sess)))
+		if err != nil {
+			s.log.Warn("SSH session refused",
+				logger.String("session_id", sess.Context().SessionID()),
+				logger.String("remote_addr", sess.RemoteAddr().String()),
+				logger.Error(err),
+			)
+			fmt.Fprintf(sess.Stderr(), "Error: %v\n", err)
+			sess.Exit(1)
+			return
+		}
+		defer release()
+
 		cmd := sess.Command()
 
 		// If no command, show welcome message
@@ -197,15 +268,34 @@ func (s *Server) gitMiddleware(next ssh.Handler) ssh.Handler {
 			logger.String("repo_path", repoPath),
 		)
 
+		// Stop the git command of a session that lasts too long or goes idle
+		watched := newWatchedSession(sess)
+		ctx, stopWatching := watched.watch(sess.Context(), s.config.MaxSessionDuration(), s.config.IdleTimeout())
+		defer stopWatching()
+
 		// Handle Git operation
-		if err := s.handleGitCommand(sess, gitCmd, repoPath); err != nil {
+		if err := s.handleGitCommand(ctx, watched, gitCmd, repoPath); err != nil {
+			if isSessionTimeout(ctx) {
+				// The client was told and the session closed
+				s.log.Warn("SSH session timed out",
+					logger.String("session_id", sess.Context().SessionID()),
+					logger.String("git_cmd", gitCmd),
+					logger.String("repo_path", repoPath),
+					logger.Error(context.Cause(ctx)),
+				)
+				return
+			}
 			s.log.Error("Git command failed",
 				logger.String("session_id", sess.Context().SessionID()),
 				logger.String("git_cmd", gitCmd),
 				logger.String("repo_path", repoPath),
 				logger.Error(err),
 			)
-			fmt.Fprintf(sess.Stderr(), "Error: %v\n", err)
+			if errors.Is(err, errRepositoryNotFound) {
+				fmt.Fprintln(sess.Stderr(), git.RepositoryNotFoundMessage)
+			} else {
+				fmt.Fprintf(sess.Stderr(), "Error: %v\n", err)
+			}
 			sess.Exit(1)
 			return
 		}
@@ -238,11 +328,14 @@ func (s *Server) handleWelcome(sess ssh.Session) {
 	fmt.Fprintf(sess, "  git clone ssh://<host>:<port>/<owner>/<repo>.git\n")
 	fmt.Fprintf(sess, "  git push origin <branch>\n")
 	fmt.Fprintf(sess, "  git pull origin <branch>\n")
+	fmt.Fprintf(sess, "  git archive --remote=ssh://<host>:<port>/<owner>/<repo>.git <ref>\n")
 }
 
-// handleGitCommand processes Git SSH protocol commands
-func (s *Server) handleGitCommand(sess ssh.Session, gitCmd, repoPath string) error {
-	ctx := context.Background()
+// handleGitCommand processes Git SSH protocol commands, until ctx is cancelled
+func (s *Server) handleGitCommand(ctx context.Context, sess ssh.Session, gitCmd, repoPath string) error {
+	// The session ID correlates the log lines of the session, git subprocesses
+	// included, as the request ID does over HTTP
+	ctx = logger.ContextWithRequestID(ctx, sess.Context().SessionID())
 
 	// Parse repository path (format: /owner/repo.git or owner/repo.git)
 	repoPath = strings.TrimPrefix(repoPath, "/")
@@ -251,7 +344,7 @@ func (s *Server) handleGitCommand(sess ssh.Session, gitCmd, repoPath string) err
 
 	parts := strings.SplitN(repoPath, "/", 2)
 	if len(parts) != 2 {
-		s.log.Warn("Invalid repository path format",
+		s.log.WithContext(ctx).Warn("Invalid repository path format",
 			logger.String("session_id", sess.Context().SessionID()),
 			logger.String("repo_path", repoPath),
 		)
@@ -261,70 +354,137 @@ func (s *Server) handleGitCommand(sess ssh.Session, gitCmd, repoPath string) err
 	owner := parts[0]
 	repoName := parts[1]
 
-	s.log.Debug("Looking up repository",
+	s.log.WithContext(ctx).Debug("Looking up repository",
 		logger.String("owner", owner),
 		logger.String("repo", repoName),
 	)
 
-	// Get repository
-	repo, err := s.repoService.GetRepository(ctx, owner, repoName)
+	// Check access permissions
+	user := s.getUserFromSession(sess)
+	isWriteOperation := gitCmd == "git-receive-pack"
+
+	// Get repository, from the snapshot of degraded mode for the sessions
+	// opened in it
+	var repo *models.Repository
+	var err error
+	if degraded, _ := ctx.Value("degraded").(bool); degraded {
+		if isWriteOperation {
+			s.degraded.RecordRejection()
+			return fmt.Errorf("the database is unavailable, repositories are read-only, try again shortly")
+		}
+		if repo, err = s.degraded.Lookup(owner, repoName); err != nil {
+			return err
+		}
+	} else {
+		repo, err = s.repoService.ResolveRepository(ctx, owner, repoName)
+	}
+	if err != nil && isWriteOperation && apperrors.IsNotFound(err) && s.repoService.CanCreateOnPush(user, owner) {
+		// Push into the user's own namespace creates the repository
+		repo, err = s.repoService.CreateRepositoryOnPush(ctx, user, owner, repoName)
+		if err == nil {
+			cloneURL := fmt.Sprintf("ssh://git@%s/%s/%s.git", sess.LocalAddr().String(), owner, repoName)
+			fmt.Fprint(sess.Stderr(), git.CreatedOnPushMessage(owner, repoName, s.repoService.RepositoryWebURL(owner, repoName), cloneURL))
+		} else if apperrors.IsBadRequest(err) || apperrors.IsForbidden(err) {
+			return fmt.Errorf("cannot create repository %s/%s: %w", owner, repoName, err)
+		}
+	}
+	if apperrors.IsNotFound(err) {
+		s.log.WithContext(ctx).Debug("Repository not found",
+			logger.String("owner", owner),
+			logger.String("repo", repoName),
+		)
+		return errRepositoryNotFound
+	}
 	if err != nil {
-		s.log.Warn("Repository not found",
+		s.log.WithContext(ctx).Error("Failed to open repository",
 			logger.String("owner", owner),
 			logger.String("repo", repoName),
 			logger.Error(err),
 		)
-		return fmt.Errorf("repository not found: %s/%s", owner, repoName)
+		return fmt.Errorf("repository %s/%s is unavailable, try again shortly", owner, repoName)
 	}
 
-	// Check access permissions
-	user := s.getUserFromSession(sess)
-	isWriteOperation := gitCmd == "git-receive-pack"
-
 	username := "anonymous"
 	if user != nil {
 		username = user.Username
 	}
 
-	s.log.Debug("Checking repository access",
+	s.log.WithContext(ctx).Debug("Checking repository access",
 		logger.String("user", username),
 		logger.String("repo", repo.Name),
 		logger.Bool("is_private", repo.IsPrivate),
 		logger.Bool("is_write", isWriteOperation),
 	)
 
-	if !s.checkRepoAccess(user, repo, isWriteOperation) {
-		s.log.Warn("Repository access denied",
+	if !s.checkRepoAccess(ctx, user, repo, isWriteOperation) {
+		s.log.WithContext(ctx).Warn("Repository access denied",
 			logger.String("user", username),
 			logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
 			logger.Bool("is_write", isWriteOperation),
 			logger.Bool("is_private", repo.IsPrivate),
 		)
 		if repo.IsPrivate {
-			return fmt.Errorf("repository not found")
+			return errRepositoryNotFound
 		}
 		return fmt.Errorf("permission denied")
 	}
 
-	s.log.Info("Executing Git operation",
+	s.log.WithContext(ctx).Info("Executing Git operation",
 		logger.String("user", username),
 		logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
 		logger.String("operation", gitCmd),
 		logger.String("git_path", repo.GitPath),
 	)
 
+	backend, err := s.storage.ForRepo(repo)
+	if err != nil {
+		s.log.WithContext(ctx).Error("Failed to resolve repository storage",
+			logger.Error(err),
+			logger.String("repo", repo.Name),
+		)
+		return fmt.Errorf("repository storage is unavailable")
+	}
+
+	// Refuse git operations while the repository is migrated to another backend
+	acquire := s.storage.AcquireRepository
+	if gitCmd == "git-receive-pack" {
+		acquire = s.storage.AcquirePush
+	}
+	release, err := acquire(repo)
+	if err != nil {
+		return fmt.Errorf("repository storage is being migrated, try again shortly")
+	}
+	defer release()
+
 	// Execute Git command
 	switch gitCmd {
 	case "git-upload-pack":
-		return s.gitProtocol.HandleUploadPackSSH(ctx, repo.GitPath, sess, sess)
+		// GIT_PROTOCOL passes the protocol version the client asked for (v2)
+		return s.gitProtocol.HandleUploadPackSSH(ctx, repo.GitPath, sess, sess, sessionEnv(sess, "GIT_PROTOCOL"), repo.UploadPack)
 	case "git-receive-pack":
-		err := s.gitProtocol.HandleReceivePackSSH(ctx, repo.GitPath, sess, sess)
+		startedAt := time.Now()
+		check := domainservice.CombineRefChecks(
+			s.tagProtect.RefCommandCheck(repo, user, "ssh"),
+			s.branches.RefCommandCheck(ctx, repo, user, "ssh"),
+			s.quotas.RefCommandCheck(ctx, repo, "ssh"),
+		)
+		result, err := s.gitProtocol.HandleReceivePackSSH(ctx, repo.GitPath, sess, sess, check, git.ReceiveOptions{
+			SkipLargeFileHints: repo.LargeFileHintsDisabled,
+			Exclusive:          s.storage.OperationsInFlight(repo.ID) == 1,
+			PushPolicy:         repo.PushPolicy,
+		})
+		if errors.Is(err, git.ErrPushRejected) {
+			// The client already received the refusal
+			s.pushes.Record(repo, user, "ssh", startedAt, result, nil)
+			return nil
+		}
+		s.pushes.Record(repo, user, "ssh", startedAt, result, err)
 		if err != nil {
 			return err
 		}
 		// Sync to remote storage (S3) after successful push
-		if err := s.storage.SyncToRemote(repo.GitPath); err != nil {
-			s.log.Error("Failed to sync repository to remote storage",
+		if err := backend.SyncToRemote(repo.GitPath); err != nil {
+			s.log.WithContext(ctx).Error("Failed to sync repository to remote storage",
 				logger.Error(err),
 				logger.String("repo", repo.Name),
 				logger.String("path", repo.GitPath),
@@ -333,14 +493,23 @@ func (s *Server) handleGitCommand(sess ssh.Session, gitCmd, repoPath string) err
 		}
 		// Set default branch if not already set (first push)
 		s.repoService.SetDefaultBranchOnPush(ctx, repo)
-		// Trigger CI after successful push
-		s.triggerCIAfterPush(ctx, repo, user, owner, repoName)
+		// Record the new size of the repository for quotas
+		s.quotas.RecordSizeAsync(repo)
+		// Credit new default branch commits to their authors
+		s.contribs.IndexRepositoryAsync(repo)
+		// Detect the license again if the push changed it
+		s.licenses.DetectLicenseAfterPush(repo, result.Updates)
+		// Flag pinned links whose paths the push removed or restored
+		s.pinnedLinks.CheckLinksAfterPush(repo, result.Updates)
+		// Recompute the languages of the default branch
+		s.languages.RefreshAfterPush(repo, result.Updates)
+		s.publishPush(repo, user, remoteIP(sess.RemoteAddr()), result)
+		// Trigger CI for the pushed refs
+		s.triggerCIAfterPush(repo, user, owner, repoName, result.Updates)
 		return nil
 	case "git-upload-archive":
-		s.log.Warn("Unsupported Git command: git-upload-archive",
-			logger.String("session_id", sess.Context().SessionID()),
-		)
-		return fmt.Errorf("git-upload-archive is not supported")
+		// git archive --remote, with the read access of a fetch
+		return s.gitProtocol.HandleUploadArchiveSSH(ctx, repo.GitPath, sess, sess)
 	default:
 		return fmt.Errorf("unknown git command: %s", gitCmd)
 	}
@@ -355,105 +524,29 @@ func (s *Server) getUserFromSession(sess ssh.Session) *models.User {
 	return nil
 }
 
-// triggerCIAfterPush triggers CI jobs after a successful SSH push
-func (s *Server) triggerCIAfterPush(ctx context.Context, repo *models.Repository, user *models.User, owner, repoName string) {
-	// Check if CI service is enabled
+// triggerCIAfterPush triggers CI jobs for the branches and tags updated by an SSH push
+func (s *Server) triggerCIAfterPush(repo *models.Repository, user *models.User, owner, repoName string, updates []domainservice.RefUpdate) {
 	if s.ciService == nil || !s.ciService.IsEnabled() {
 		s.log.Debug("CI service is not enabled, skipping CI trigger",
 			logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
 		)
 		return
 	}
-
-	// Get the default branch (or HEAD)
-	defaultBranch, err := s.gitService.GetHEADBranch(ctx, repo.GitPath)
-	if err != nil {
-		s.log.Warn("Failed to get default branch for CI trigger",
-			logger.Error(err),
-			logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
-		)
-		return
-	}
-
-	// Get the latest commit on the default branch
-	commits, err := s.gitService.GetCommits(ctx, repo.GitPath, defaultBranch, 1, 0)
-	if err != nil || len(commits) == 0 {
-		s.log.Warn("Failed to get latest commit for CI trigger",
-			logger.Error(err),
-			logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
-			logger.String("branch", defaultBranch),
-		)
-		return
-	}
-	latestCommit := commits[0]
-
-	// Check if CI config file exists in the repository
-	ciConfigPath := s.ciService.GetConfigPath()
-	_, err = s.gitService.GetFileContent(ctx, repo.GitPath, defaultBranch, ciConfigPath)
-	if err != nil {
-		s.log.Debug("CI config file not found, skipping CI trigger",
-			logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
-			logger.String("config_path", ciConfigPath),
-		)
+	if len(updates) == 0 {
 		return
 	}
 
-	// Build the clone URL for CI runner
-	cloneURL := s.ciService.BuildCloneURL(owner, repoName)
-
 	// Determine trigger actor
 	triggerActor := "anonymous"
 	if user != nil {
 		triggerActor = user.Username
 	}
 
-	// Trigger the CI job asynchronously
-	go func() {
-		triggerCtx := context.Background()
-
-		s.log.Info("Triggering CI job after SSH push",
-			logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
-			logger.String("branch", defaultBranch),
-			logger.String("commit", latestCommit.Hash),
-			logger.String("actor", triggerActor),
-		)
-
-		job, err := s.ciService.TriggerJob(triggerCtx, &service.TriggerJobRequest{
-			RepositoryID: repo.ID,
-			Owner:        owner,
-			RepoName:     repoName,
-			CloneURL:     cloneURL,
-			CommitSHA:    latestCommit.Hash,
-			RefName:      defaultBranch,
-			RefType:      models.CIRefTypeBranch,
-			TriggerType:  models.CITriggerTypePush,
-			TriggerActor: triggerActor,
-			Metadata: map[string]string{
-				"commit_message": latestCommit.Message,
-				"author":         latestCommit.Author,
-				"author_email":   latestCommit.AuthorEmail,
-			},
-		})
-
-		if err != nil {
-			s.log.Error("Failed to trigger CI job after SSH push",
-				logger.Error(err),
-				logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
-				logger.String("commit", latestCommit.Hash),
-			)
-			return
-		}
-
-		s.log.Info("CI job triggered successfully via SSH",
-			logger.String("job_id", job.ID.String()),
-			logger.String("repo", fmt.Sprintf("%s/%s", owner, repoName)),
-			logger.String("commit", latestCommit.Hash),
-		)
-	}()
+	go s.ciService.TriggerPushJobs(context.Background(), repo, owner, repoName, triggerActor, updates)
 }
 
 // checkRepoAccess checks if the user can access the repository
-func (s *Server) checkRepoAccess(user *models.User, repo *models.Repository, isWrite bool) bool {
+func (s *Server) checkRepoAccess(ctx context.Context, user *models.User, repo *models.Repository, isWrite bool) bool {
 	// Public repos allow read access to everyone
 	if !repo.IsPrivate && !isWrite {
 		return true
@@ -464,23 +557,12 @@ func (s *Server) checkRepoAccess(user *models.User, repo *models.Repository, isW
 		return false
 	}
 
-	// Check if user has access
-	if user.IsAdmin {
-		return true
+	// Check the user's permission as owner, site admin or collaborator
+	required := models.RepoPermissionRead
+	if isWrite {
+		required = models.RepoPermissionWrite
 	}
-
-	if user.ID == repo.OwnerID {
-		return true
-	}
-
-	// Read access to public repos for authenticated users
-	if !isWrite && !repo.IsPrivate {
-		return true
-	}
-
-	// TODO: Check collaborator permissions
-
-	return false
+	return s.repoService.HasPermission(ctx, user, repo, required)
 }
 
 // ListenAndServe starts the SSH server
@@ -491,6 +573,15 @@ func (s *Server) ListenAndServe() error {
 	return s.server.ListenAndServe()
 }
 
+// Serve accepts SSH connections on a listener instead of the configured
+// address, e.g. an ephemeral port in tests
+func (s *Server) Serve(l net.Listener) error {
+	s.log.Info("Starting SSH server",
+		logger.String("address", l.Addr().String()),
+	)
+	return s.server.Serve(l)
+}
+
 // Shutdown gracefully shuts down the SSH server
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.log.Info("Shutting down SSH server...")
@@ -517,3 +608,77 @@ func (s *Server) ShutdownWithTimeout(timeout time.Duration) error {
 	defer cancel()
 	return s.Shutdown(ctx)
 }
+
+// publishPush publishes the ref updates of an SSH push and the large files it added
+func (s *Server) publishPush(repo *models.Repository, user *models.User, ip string, result *domainservice.PushResult) {
+	updates := result.Updates
+	if len(updates) == 0 {
+		return
+	}
+
+	event := events.RepositoryPushed{
+		RepositoryID: repo.ID,
+		Owner:        repo.Owner.Username,
+		Name:         repo.Name,
+		Pusher:       "anonymous",
+		Transport:    "ssh",
+		RemoteIP:     ip,
+		Refs:         make([]events.RefChange, len(updates)),
+	}
+	if user != nil {
+		event.PusherID = &user.ID
+		event.Pusher = user.Username
+	}
+	for i, update := range updates {
+		event.Refs[i] = events.RefChange{
+			RefName: update.RefName,
+			OldHash: update.OldHash,
+			NewHash: update.NewHash,
+		}
+	}
+	for _, rejected := range result.RejectedCommands() {
+		event.Rejected = append(event.Rejected, events.RefRejected{
+			RefName: rejected.RefName,
+			OldHash: rejected.OldHash,
+			NewHash: rejected.NewHash,
+			Reason:  rejected.Reason,
+		})
+	}
+	for _, file := range result.LargeFiles {
+		event.LargeFiles = append(event.LargeFiles, events.LargeFile{
+			Path: file.Path,
+			Hash: file.Hash,
+			Size: file.Size,
+		})
+	}
+	s.publisher.Publish(event)
+}
+
+// sessionKey identifies the user of a session for ssh.max_sessions_per_user:
+// the user, or the client address of anonymous sessions
+func sessionKey(sess ssh.Session, user *models.User) string {
+	if user != nil {
+		return "user:" + user.ID.String()
+	}
+	return "addr:" + remoteIP(sess.RemoteAddr())
+}
+
+// remoteIP returns the IP address of a client address
+func remoteIP(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// sessionEnv returns the value of an environment variable the client set on
+// the session, or "" if it is unset or was not allowed
+func sessionEnv(sess ssh.Session, name string) string {
+	for _, kv := range sess.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok && key == name {
+			return value
+		}
+	}
+	return ""
+}
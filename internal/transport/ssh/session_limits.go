@@ -0,0 +1,221 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+
+	"github.com/bravo68web/stasis/internal/config"
+	"github.com/bravo68web/stasis/pkg/metrics"
+)
+
+var (
+	// errSessionIdle stops the git command of a session without traffic for
+	// ssh.idle_timeout
+	errSessionIdle = errors.New("session idle for too long")
+
+	// errSessionTooLong stops the git command of a session open for
+	// ssh.max_session_duration
+	errSessionTooLong = errors.New("session lasted too long")
+)
+
+// sessionLimiter counts the open sessions, in total and per user, and refuses
+// sessions beyond ssh.max_sessions and ssh.max_sessions_per_user
+type sessionLimiter struct {
+	maxTotal   int
+	maxPerUser int
+
+	mu      sync.Mutex
+	total   int
+	perUser map[string]int
+}
+
+// newSessionLimiter creates a sessionLimiter with the limits of cfg
+func newSessionLimiter(cfg *config.SSHConfig) *sessionLimiter {
+	return &sessionLimiter{
+		maxTotal:   cfg.MaxSessions,
+		maxPerUser: cfg.MaxSessionsPerUser,
+		perUser:    make(map[string]int),
+	}
+}
+
+// acquire counts a new session of the user identified by key and returns the
+// function ending it, or an error telling the client to retry if a limit is
+// reached
+func (l *sessionLimiter) acquire(key string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxTotal > 0 && l.total >= l.maxTotal {
+		metrics.SSHSessionRefused("global")
+		return nil, fmt.Errorf("the server has too many open sessions, retry in a moment")
+	}
+	if l.maxPerUser > 0 && l.perUser[key] >= l.maxPerUser {
+		metrics.SSHSessionRefused("user")
+		return nil, fmt.Errorf("you have %d open sessions, the most allowed; retry when one of them ends", l.maxPerUser)
+	}
+
+	l.total++
+	l.perUser[key]++
+	metrics.SetSSHSessions(l.total, len(l.perUser))
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.release(key)
+		})
+	}, nil
+}
+
+// release ends a session of the user identified by key
+func (l *sessionLimiter) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.total--
+	if l.perUser[key]--; l.perUser[key] <= 0 {
+		delete(l.perUser, key)
+	}
+	metrics.SetSSHSessions(l.total, len(l.perUser))
+}
+
+// watchedSession records the time of the last data sent or received on a
+// session, for the idle timeout
+type watchedSession struct {
+	ssh.Session
+	lastActivity atomic.Int64
+}
+
+// newWatchedSession wraps a session, active as of now
+func newWatchedSession(sess ssh.Session) *watchedSession {
+	w := &watchedSession{Session: sess}
+	w.touch()
+	return w
+}
+
+// touch records activity now
+func (w *watchedSession) touch() {
+	w.lastActivity.Store(time.Now().UnixNano())
+}
+
+// idleSince returns the time of the last activity
+func (w *watchedSession) idleSince() time.Time {
+	return time.Unix(0, w.lastActivity.Load())
+}
+
+// Read reads data the client sent
+func (w *watchedSession) Read(p []byte) (int, error) {
+	n, err := w.Session.Read(p)
+	if n > 0 {
+		w.touch()
+	}
+	return n, err
+}
+
+// Write sends data to the client
+func (w *watchedSession) Write(p []byte) (int, error) {
+	n, err := w.Session.Write(p)
+	if n > 0 {
+		w.touch()
+	}
+	return n, err
+}
+
+// Stderr returns the stderr stream of the session, recording its traffic
+func (w *watchedSession) Stderr() io.ReadWriter {
+	return &watchedStream{stream: w.Session.Stderr(), session: w}
+}
+
+// watchedStream records the traffic of a secondary stream of a session
+type watchedStream struct {
+	stream  io.ReadWriter
+	session *watchedSession
+}
+
+// Read reads data the client sent
+func (s *watchedStream) Read(p []byte) (int, error) {
+	n, err := s.stream.Read(p)
+	if n > 0 {
+		s.session.touch()
+	}
+	return n, err
+}
+
+// Write sends data to the client
+func (s *watchedStream) Write(p []byte) (int, error) {
+	n, err := s.stream.Write(p)
+	if n > 0 {
+		s.session.touch()
+	}
+	return n, err
+}
+
+// watch returns a context of the session that is cancelled once the session
+// lasted maxDuration or went idle for idleTimeout, with errSessionTooLong or
+// errSessionIdle as cause; zero durations do not limit. The client is then
+// told why and the session is closed, which also ends the reads of a git
+// command waiting on the client. The returned function stops watching and
+// must be called.
+func (w *watchedSession) watch(ctx context.Context, maxDuration, idleTimeout time.Duration) (context.Context, func()) {
+	if maxDuration <= 0 && idleTimeout <= 0 {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	go func() {
+		var deadline, idle <-chan time.Time
+		if maxDuration > 0 {
+			timer := time.NewTimer(maxDuration)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+		var idleTimer *time.Timer
+		if idleTimeout > 0 {
+			idleTimer = time.NewTimer(idleTimeout)
+			defer idleTimer.Stop()
+			idle = idleTimer.C
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-deadline:
+				metrics.SSHSessionTimedOut("max_duration")
+				w.stop(cancel, errSessionTooLong, fmt.Sprintf("session closed after reaching the maximum duration of %s", maxDuration))
+				return
+			case <-idle:
+				remaining := idleTimeout - time.Since(w.idleSince())
+				if remaining > 0 {
+					idleTimer.Reset(remaining)
+					continue
+				}
+				metrics.SSHSessionTimedOut("idle")
+				w.stop(cancel, errSessionIdle, fmt.Sprintf("session closed after %s without activity", idleTimeout))
+				return
+			}
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// stop cancels the git command of the session with cause, tells the client
+// why and closes the session
+func (w *watchedSession) stop(cancel context.CancelCauseFunc, cause error, message string) {
+	cancel(cause)
+	fmt.Fprintf(w.Session.Stderr(), "Error: %s\n", message)
+	_ = w.Session.Exit(1)
+}
+
+// isSessionTimeout returns true if the context of a session was cancelled by
+// one of its timeouts
+func isSessionTimeout(ctx context.Context) bool {
+	cause := context.Cause(ctx)
+	return errors.Is(cause, errSessionIdle) || errors.Is(cause, errSessionTooLong)
+}
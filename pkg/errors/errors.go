@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -41,6 +42,12 @@ var (
 	// ErrInvalidSSHKey indicates the SSH key format is invalid
 	ErrInvalidSSHKey = errors.New("invalid ssh key")
 
+	// ErrGPGKeyExists indicates a GPG key with the same fingerprint already exists
+	ErrGPGKeyExists = errors.New("gpg key already exists")
+
+	// ErrInvalidGPGKey indicates the GPG key is not a valid armored public key
+	ErrInvalidGPGKey = errors.New("invalid gpg key")
+
 	// ErrBranchNotFound indicates the branch was not found
 	ErrBranchNotFound = errors.New("branch not found")
 
@@ -67,6 +74,50 @@ var (
 
 	// ErrDatabaseError indicates a database operation failed
 	ErrDatabaseError = errors.New("database error")
+
+	// ErrAnnotationExists indicates an annotation with the same key already exists
+	ErrAnnotationExists = errors.New("annotation already exists")
+
+	// ErrBranchProtectionExists indicates a branch protection with the same pattern already exists
+	ErrBranchProtectionExists = errors.New("branch protection already exists")
+
+	// ErrRefLimitExceeded indicates a repository has as many branches or tags as it may have
+	ErrRefLimitExceeded = errors.New("ref limit exceeded")
+
+	// ErrNoMergeBase indicates two commits being compared share no history
+	ErrNoMergeBase = errors.New("no merge base")
+
+	// ErrMergeConflict indicates two commits cannot be merged without resolving conflicts
+	ErrMergeConflict = errors.New("merge conflict")
+
+	// ErrNothingToMerge indicates the commits to merge are already part of the target
+	ErrNothingToMerge = errors.New("nothing to merge")
+
+	// ErrRefChanged indicates a ref no longer points at the commit an update expected
+	ErrRefChanged = errors.New("ref changed")
+
+	// ErrPullRequestExists indicates an open pull request between the same branches already exists
+	ErrPullRequestExists = errors.New("pull request already exists")
+
+	// ErrPullRequestNotOpen indicates a pull request was already closed or merged
+	ErrPullRequestNotOpen = errors.New("pull request is not open")
+
+	// ErrIssueClosed indicates an issue was already closed
+	ErrIssueClosed = errors.New("issue is closed")
+
+	// ErrExportRateLimited indicates a user already requested a data export recently
+	ErrExportRateLimited = errors.New("export requested too recently")
+
+	// ErrTimeout indicates an operation did not complete within its deadline
+	ErrTimeout = errors.New("operation timed out")
+
+	// ErrUnavailable indicates a dependency of an operation, such as the
+	// database, is temporarily unavailable
+	ErrUnavailable = errors.New("service unavailable")
+
+	// ErrCommitSigning indicates a commit could not be signed with the
+	// instance signing key
+	ErrCommitSigning = errors.New("commit signing failed")
 )
 
 // ErrorCode represents HTTP-like error codes
@@ -78,8 +129,11 @@ const (
 	CodeForbidden           ErrorCode = http.StatusForbidden
 	CodeNotFound            ErrorCode = http.StatusNotFound
 	CodeConflict            ErrorCode = http.StatusConflict
+	CodeTooLarge            ErrorCode = http.StatusRequestEntityTooLarge
+	CodeUnprocessable       ErrorCode = http.StatusUnprocessableEntity
 	CodeInternalServerError ErrorCode = http.StatusInternalServerError
 	CodeServiceUnavailable  ErrorCode = http.StatusServiceUnavailable
+	CodeGatewayTimeout      ErrorCode = http.StatusGatewayTimeout
 )
 
 // AppError represents an application-level error with additional context
@@ -165,6 +219,16 @@ func Conflict(message string, err error) *AppError {
 	return NewAppError(CodeConflict, message, err)
 }
 
+// TooLarge creates a new error for requests whose content exceeds a size limit
+func TooLarge(message string, err error) *AppError {
+	return NewAppError(CodeTooLarge, message, err)
+}
+
+// Unprocessable creates a new error for valid requests that cannot be carried out
+func Unprocessable(message string, err error) *AppError {
+	return NewAppError(CodeUnprocessable, message, err)
+}
+
 // InternalError creates a new internal server error
 func InternalError(message string, err error) *AppError {
 	if message == "" {
@@ -173,11 +237,26 @@ func InternalError(message string, err error) *AppError {
 	return NewAppError(CodeInternalServerError, message, err)
 }
 
-// DatabaseError creates a new database error
+// DatabaseError creates a new database error. Errors wrapping ErrTimeout or
+// context.DeadlineExceeded become timeout errors.
 func DatabaseError(operation string, err error) *AppError {
+	if errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return Timeout(fmt.Sprintf("database %s", operation), err)
+	}
 	return NewAppError(CodeInternalServerError, fmt.Sprintf("database %s failed", operation), err)
 }
 
+// Timeout creates a new timeout error
+func Timeout(operation string, err error) *AppError {
+	return NewAppError(CodeGatewayTimeout, fmt.Sprintf("%s timed out", operation), err)
+}
+
+// Unavailable creates a new error for operations that cannot be carried out
+// until a dependency is available again
+func Unavailable(message string, err error) *AppError {
+	return NewAppError(CodeServiceUnavailable, message, err)
+}
+
 // StorageError creates a new storage error
 func StorageError(operation string, err error) *AppError {
 	return NewAppError(CodeInternalServerError, fmt.Sprintf("storage %s failed", operation), err)
@@ -233,6 +312,30 @@ func IsConflict(err error) bool {
 		errors.Is(err, ErrTagExists)
 }
 
+// IsTimeout checks if an error is a timeout error
+func IsTimeout(err error) bool {
+	var appErr *AppError
+	if errors.As(err, &appErr) && appErr.Code == CodeGatewayTimeout {
+		return true
+	}
+	return errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// IsUnavailable checks if an error is a service unavailable error
+func IsUnavailable(err error) bool {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr.Code == CodeServiceUnavailable
+	}
+	return errors.Is(err, ErrUnavailable)
+}
+
+// IsCommitSigning checks if an error is a failure to sign a commit with the
+// instance signing key
+func IsCommitSigning(err error) bool {
+	return errors.Is(err, ErrCommitSigning)
+}
+
 // IsBadRequest checks if an error is a bad request error
 func IsBadRequest(err error) bool {
 	var appErr *AppError
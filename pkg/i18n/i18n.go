@@ -0,0 +1,144 @@
+// Package i18n translates user-facing messages. Catalogs are keyed by the
+// English message, so code keeps producing English text and any message
+// without a translation falls back to English.
+package i18n
+
+//go:generate go run ../../cmd/i18n-check -root ../..
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is the locale of messages in code and the fallback for all others
+const DefaultLocale = "en"
+
+// LocalesDir is the directory of the embedded catalogs, one <locale>.json per language
+const LocalesDir = "locales"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// Bundle holds the message catalogs of all supported locales
+type Bundle struct {
+	catalogs map[string]map[string]string
+	locales  []string // DefaultLocale first, then sorted
+	matcher  language.Matcher
+}
+
+var (
+	defaultBundle     *Bundle
+	defaultBundleOnce sync.Once
+)
+
+// Default returns the bundle of the embedded catalogs. It panics if they are
+// malformed, which the i18n-check generator catches before a build ships.
+func Default() *Bundle {
+	defaultBundleOnce.Do(func() {
+		b, err := Load()
+		if err != nil {
+			panic(err)
+		}
+		defaultBundle = b
+	})
+	return defaultBundle
+}
+
+// Load parses the embedded catalogs
+func Load() (*Bundle, error) {
+	entries, err := localeFS.ReadDir(LocalesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read locale catalogs: %w", err)
+	}
+
+	b := &Bundle{catalogs: make(map[string]map[string]string)}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || path.Ext(name) != ".json" {
+			continue
+		}
+		locale := strings.ToLower(strings.TrimSuffix(name, ".json"))
+		if _, err := language.Parse(locale); err != nil {
+			return nil, fmt.Errorf("invalid locale catalog name %q: %w", name, err)
+		}
+
+		data, err := localeFS.ReadFile(path.Join(LocalesDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read locale catalog %s: %w", name, err)
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("failed to parse locale catalog %s: %w", name, err)
+		}
+		b.catalogs[locale] = catalog
+	}
+
+	if _, ok := b.catalogs[DefaultLocale]; !ok {
+		return nil, fmt.Errorf("missing %s locale catalog", DefaultLocale)
+	}
+
+	b.locales = append(b.locales, DefaultLocale)
+	for locale := range b.catalogs {
+		if locale != DefaultLocale {
+			b.locales = append(b.locales, locale)
+		}
+	}
+	sort.Strings(b.locales[1:])
+
+	tags := make([]language.Tag, len(b.locales))
+	for i, locale := range b.locales {
+		tags[i] = language.MustParse(locale)
+	}
+	b.matcher = language.NewMatcher(tags)
+
+	return b, nil
+}
+
+// Locales returns the supported locales, DefaultLocale first
+func (b *Bundle) Locales() []string {
+	return append([]string(nil), b.locales...)
+}
+
+// Supports reports whether a locale has a catalog
+func (b *Bundle) Supports(locale string) bool {
+	_, ok := b.catalogs[strings.ToLower(locale)]
+	return ok
+}
+
+// Match returns the supported locale best matching an Accept-Language header,
+// or DefaultLocale if none matches
+func (b *Bundle) Match(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return DefaultLocale
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLocale
+	}
+
+	_, index, confidence := b.matcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLocale
+	}
+	return b.locales[index]
+}
+
+// Translate returns a message in the given locale. Messages without a
+// translation, and unsupported locales, fall back to the English message.
+func (b *Bundle) Translate(locale, message string) string {
+	if translated, ok := b.catalogs[strings.ToLower(locale)][message]; ok && translated != "" {
+		return translated
+	}
+	if translated, ok := b.catalogs[DefaultLocale][message]; ok && translated != "" {
+		return translated
+	}
+	return message
+}
@@ -0,0 +1,135 @@
+// Package language classifies the files of a repository by programming
+// language, from their name or extension, for the language breakdown of a
+// repository. Files of vendored and generated directories are not counted.
+package language
+
+import (
+	"path"
+	"strings"
+)
+
+// extensions maps lowercased file extensions, with the dot, to a language
+var extensions = map[string]string{
+	".c":        "C",
+	".h":        "C",
+	".cc":       "C++",
+	".cpp":      "C++",
+	".cxx":      "C++",
+	".hh":       "C++",
+	".hpp":      "C++",
+	".hxx":      "C++",
+	".cs":       "C#",
+	".clj":      "Clojure",
+	".cljs":     "Clojure",
+	".cljc":     "Clojure",
+	".css":      "CSS",
+	".dart":     "Dart",
+	".ex":       "Elixir",
+	".exs":      "Elixir",
+	".elm":      "Elm",
+	".erl":      "Erlang",
+	".hrl":      "Erlang",
+	".fs":       "F#",
+	".fsx":      "F#",
+	".go":       "Go",
+	".gradle":   "Groovy",
+	".groovy":   "Groovy",
+	".hs":       "Haskell",
+	".html":     "HTML",
+	".htm":      "HTML",
+	".java":     "Java",
+	".js":       "JavaScript",
+	".cjs":      "JavaScript",
+	".mjs":      "JavaScript",
+	".jsx":      "JavaScript",
+	".json":     "JSON",
+	".jl":       "Julia",
+	".kt":       "Kotlin",
+	".kts":      "Kotlin",
+	".less":     "Less",
+	".lua":      "Lua",
+	".md":       "Markdown",
+	".markdown": "Markdown",
+	".m":        "Objective-C",
+	".mm":       "Objective-C",
+	".ml":       "OCaml",
+	".mli":      "OCaml",
+	".pl":       "Perl",
+	".pm":       "Perl",
+	".php":      "PHP",
+	".ps1":      "PowerShell",
+	".proto":    "Protocol Buffers",
+	".py":       "Python",
+	".pyi":      "Python",
+	".r":        "R",
+	".rb":       "Ruby",
+	".rs":       "Rust",
+	".sass":     "Sass",
+	".scss":     "SCSS",
+	".scala":    "Scala",
+	".sh":       "Shell",
+	".bash":     "Shell",
+	".zsh":      "Shell",
+	".sql":      "SQL",
+	".svelte":   "Svelte",
+	".swift":    "Swift",
+	".tf":       "HCL",
+	".hcl":      "HCL",
+	".toml":     "TOML",
+	".ts":       "TypeScript",
+	".cts":      "TypeScript",
+	".mts":      "TypeScript",
+	".tsx":      "TypeScript",
+	".vue":      "Vue",
+	".xml":      "XML",
+	".yaml":     "YAML",
+	".yml":      "YAML",
+	".zig":      "Zig",
+}
+
+// filenames maps lowercased file names without a telling extension to a language
+var filenames = map[string]string{
+	"dockerfile":     "Dockerfile",
+	"containerfile":  "Dockerfile",
+	"makefile":       "Makefile",
+	"gnumakefile":    "Makefile",
+	"cmakelists.txt": "CMake",
+	"gemfile":        "Ruby",
+	"rakefile":       "Ruby",
+	"jenkinsfile":    "Groovy",
+}
+
+// vendoredDirs are directories whose files are third-party or generated code
+var vendoredDirs = map[string]bool{
+	"vendor":           true,
+	"node_modules":     true,
+	"dist":             true,
+	"third_party":      true,
+	"bower_components": true,
+}
+
+// Detect returns the language of a file from its path, or false if the file
+// is not source code of a known language
+func Detect(filePath string) (string, bool) {
+	name := strings.ToLower(path.Base(filePath))
+	if language, ok := filenames[name]; ok {
+		return language, true
+	}
+	if strings.HasPrefix(name, "dockerfile.") {
+		return "Dockerfile", true
+	}
+	language, ok := extensions[path.Ext(name)]
+	return language, ok
+}
+
+// IsVendored returns true if a file lies in a vendored or generated
+// directory (vendor/, node_modules/, dist/, ...), at any depth
+func IsVendored(filePath string) bool {
+	dirs := strings.Split(path.Dir(strings.TrimPrefix(filePath, "/")), "/")
+	for _, dir := range dirs {
+		if vendoredDirs[dir] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+// Package license identifies the license of a repository from its license file.
+// Texts are compared with embedded SPDX license templates after normalization
+// (case, punctuation, wrapping, copyright lines and the "how to apply" appendix
+// are ignored), so differing copyright holders and years still match.
+package license
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Threshold is the minimum confidence (0-1) for a text to be identified as a license
+const Threshold = 0.9
+
+// TemplatesDir is the directory of the embedded templates, one <SPDX id>.txt per license
+const TemplatesDir = "templates"
+
+//go:embed templates/*.txt
+var templateFS embed.FS
+
+// Match is the license identified in a file
+type Match struct {
+	ID         string  // SPDX identifier, e.g. GPL-3.0
+	Confidence float64 // Similarity to the license template, from Threshold to 1
+	File       string  // Name of the file the license was identified in
+}
+
+// template is the normalized form of a license text
+type template struct {
+	id       string
+	shingles map[string]struct{}
+}
+
+var (
+	templates     []template
+	templatesErr  error
+	templatesOnce sync.Once
+)
+
+// aliases maps other SPDX identifiers to the identifier a license is detected as
+var aliases = map[string]string{
+	"agpl-3.0-only":     "AGPL-3.0",
+	"agpl-3.0-or-later": "AGPL-3.0",
+	"gpl-2.0-only":      "GPL-2.0",
+	"gpl-2.0-or-later":  "GPL-2.0",
+	"gpl-3.0-only":      "GPL-3.0",
+	"gpl-3.0-or-later":  "GPL-3.0",
+	"lgpl-2.1-only":     "LGPL-2.1",
+	"lgpl-2.1-or-later": "LGPL-2.1",
+	"lgpl-3.0-only":     "LGPL-3.0",
+	"lgpl-3.0-or-later": "LGPL-3.0",
+}
+
+// loadTemplates parses the embedded templates once
+func loadTemplates() ([]template, error) {
+	templatesOnce.Do(func() {
+		entries, err := templateFS.ReadDir(TemplatesDir)
+		if err != nil {
+			templatesErr = fmt.Errorf("failed to read license templates: %w", err)
+			return
+		}
+		for _, entry := range entries {
+			data, err := templateFS.ReadFile(path.Join(TemplatesDir, entry.Name()))
+			if err != nil {
+				templatesErr = fmt.Errorf("failed to read license template %s: %w", entry.Name(), err)
+				return
+			}
+			templates = append(templates, template{
+				id:       strings.TrimSuffix(entry.Name(), ".txt"),
+				shingles: shingles(normalize(string(data))),
+			})
+		}
+	})
+	return templates, templatesErr
+}
+
+// Known returns the SPDX identifiers of the licenses that can be detected, sorted
+func Known() []string {
+	tmpls, _ := loadTemplates()
+	ids := make([]string, len(tmpls))
+	for i, t := range tmpls {
+		ids[i] = t.id
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Canonical returns the identifier a license is detected as, matching case-insensitively
+// and mapping the -only and -or-later variants of GNU licenses. It returns false for
+// licenses that cannot be detected.
+func Canonical(id string) (string, bool) {
+	lower := strings.ToLower(strings.TrimSpace(id))
+	if alias, ok := aliases[lower]; ok {
+		return alias, true
+	}
+	for _, known := range Known() {
+		if strings.ToLower(known) == lower {
+			return known, true
+		}
+	}
+	return "", false
+}
+
+// Identify returns the SPDX identifier of a license text and the confidence of
+// the match. It returns an empty identifier if no template matches at least
+// Threshold.
+func Identify(text string) (string, float64, error) {
+	tmpls, err := loadTemplates()
+	if err != nil {
+		return "", 0, err
+	}
+
+	words := normalize(text)
+	if len(words) == 0 {
+		return "", 0, nil
+	}
+	input := shingles(words)
+
+	bestID, best := "", 0.0
+	for _, t := range tmpls {
+		if score := dice(input, t.shingles); score > best {
+			bestID, best = t.id, score
+		}
+	}
+	if best < Threshold {
+		return "", best, nil
+	}
+	return bestID, best, nil
+}
+
+// licenseFilePattern matches conventional license file names. The submatch is
+// the base name, which decides the order files are tried in.
+var licenseFilePattern = regexp.MustCompile(`^(?i)(copying\.lesser|licen[cs]e|unlicense|copying)(\.(md|markdown|txt|rst))?$`)
+
+// filePriority orders license files: COPYING.LESSER first, since LGPL projects
+// ship the GPL they supplement as COPYING
+var filePriority = map[string]int{
+	"copying.lesser": 0,
+	"license":        1,
+	"licence":        1,
+	"unlicense":      2,
+	"copying":        3,
+}
+
+// IsLicenseFile reports whether a file name is a conventional license file name
+func IsLicenseFile(name string) bool {
+	return licenseFilePattern.MatchString(path.Base(name))
+}
+
+// Candidates returns the license files among the file names of a directory,
+// in the order they should be tried
+func Candidates(names []string) []string {
+	var candidates []string
+	for _, name := range names {
+		if licenseFilePattern.MatchString(name) {
+			candidates = append(candidates, name)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return priority(candidates[i]) < priority(candidates[j])
+	})
+	return candidates
+}
+
+// priority returns the filePriority of a license file name
+func priority(name string) int {
+	base := strings.ToLower(licenseFilePattern.FindStringSubmatch(name)[1])
+	return filePriority[base]
+}
+
+// Detect identifies the license of a directory from the file names it contains.
+// read returns the content of a file. It returns nil if no license file exists
+// or none of them matches a known license.
+func Detect(names []string, read func(name string) ([]byte, error)) (*Match, error) {
+	for _, name := range Candidates(names) {
+		content, err := read(name)
+		if err != nil {
+			return nil, err
+		}
+		id, confidence, err := Identify(string(content))
+		if err != nil {
+			return nil, err
+		}
+		if id != "" {
+			return &Match{ID: id, Confidence: confidence, File: name}, nil
+		}
+	}
+	return nil, nil
+}
+
+// endOfTerms marks the end of the license terms in GNU and Apache licenses;
+// the appendix after it is often left out
+var endOfTerms = []string{"end", "of", "terms", "and", "conditions"}
+
+// normalize returns the words of a license text, ignoring case, punctuation,
+// copyright lines and anything after the end of the terms
+func normalize(text string) []string {
+	var words []string
+	for _, line := range strings.Split(strings.ToLower(text), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "copyright") || strings.HasPrefix(trimmed, "(c)") ||
+			strings.HasPrefix(trimmed, "©") || strings.HasPrefix(trimmed, "all rights reserved") {
+			continue
+		}
+		for _, word := range strings.FieldsFunc(trimmed, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			switch word {
+			case "licence":
+				word = "license"
+			case "https":
+				word = "http"
+			}
+			words = append(words, word)
+		}
+	}
+
+	for i := 0; i+len(endOfTerms) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(endOfTerms)], endOfTerms) {
+			return words[:i]
+		}
+	}
+	return words
+}
+
+// shingles returns the set of adjacent word pairs
+func shingles(words []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(words))
+	for i := 0; i+1 < len(words); i++ {
+		set[words[i]+" "+words[i+1]] = struct{}{}
+	}
+	if len(words) == 1 {
+		set[words[0]] = struct{}{}
+	}
+	return set
+}
+
+// dice returns the Sørensen–Dice coefficient of two sets
+func dice(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	common := 0
+	for s := range a {
+		if _, ok := b[s]; ok {
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(a)+len(b))
+}
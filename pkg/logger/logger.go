@@ -119,6 +119,9 @@ func New(cfg *Config) (*Logger, error) {
 		core = createConsoleCore(cfg, level, encoderConfig)
 	}
 
+	// Keep secrets out of the output
+	core = newRedactCore(core)
+
 	// Build zap options
 	opts := buildZapOptions(cfg)
 
@@ -143,6 +146,7 @@ func NewWithCore(cfg *Config, core zapcore.Core, closers ...io.Closer) *Logger {
 		cfg = DefaultConfig()
 	}
 
+	core = newRedactCore(core)
 	opts := buildZapOptions(cfg)
 	zapLogger := zap.New(core, opts...)
 
@@ -205,33 +209,30 @@ func (l *Logger) Core() zapcore.Core {
 	return l.core
 }
 
-// WithContext returns a logger with trace information from the context
+// WithContext returns a logger with the request ID and trace information
+// from the context
 func (l *Logger) WithContext(ctx context.Context) *Logger {
 	if ctx == nil {
 		return l
 	}
 
-	// Extract trace and span IDs from the context
-	span := trace.SpanFromContext(ctx)
-	if !span.SpanContext().IsValid() {
-		return l
+	var fields []zap.Field
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		fields = append(fields, RequestID(requestID))
 	}
 
-	traceID := span.SpanContext().TraceID().String()
-	spanID := span.SpanContext().SpanID().String()
-
-	newLogger := l.With(
-		zap.String("trace_id", traceID),
-		zap.String("span_id", spanID),
-	)
+	// Extract trace and span IDs from the context
+	if span := trace.SpanFromContext(ctx); span.SpanContext().IsValid() {
+		fields = append(fields,
+			zap.String("trace_id", span.SpanContext().TraceID().String()),
+			zap.String("span_id", span.SpanContext().SpanID().String()),
+		)
+	}
 
-	return &Logger{
-		Logger:  newLogger,
-		sugar:   newLogger.Sugar(),
-		config:  l.config,
-		core:    l.core,
-		closers: l.closers,
+	if len(fields) == 0 {
+		return l
 	}
+	return l.WithFields(fields...)
 }
 
 // WithFields returns a logger with additional fields
@@ -0,0 +1,149 @@
+// Package mailmap parses git .mailmap files and maps commit identities to
+// canonical names and emails, following the rules of git-check-mailmap.
+package mailmap
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// Mailmap maps commit author or committer identities to canonical ones
+type Mailmap struct {
+	entries map[string]*entry // keyed by lowercased commit email
+}
+
+// entry holds the mappings for one commit email
+type entry struct {
+	name  string            // canonical name for any commit name
+	email string            // canonical email for any commit name
+	names map[string]*alias // mappings for a specific commit name (lowercased)
+}
+
+// alias is the canonical identity for a specific commit name and email
+type alias struct {
+	name  string
+	email string
+}
+
+// Parse parses the content of a .mailmap file. Lines that cannot be parsed are
+// ignored, as git does. Supported forms are:
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+func Parse(content []byte) *Mailmap {
+	m := &Mailmap{entries: make(map[string]*entry)}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 4096), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		name1, email1, rest, ok := parseIdentity(line)
+		if !ok {
+			continue
+		}
+		name2, email2, _, ok := parseIdentity(rest)
+		if !ok {
+			// Single identity: replace the name for this email
+			m.add(name1, "", "", email1)
+			continue
+		}
+		m.add(name1, email1, name2, email2)
+	}
+
+	return m
+}
+
+// add records a mapping of (oldName, oldEmail) to (newName, newEmail).
+// An empty oldName matches any name; empty new values keep the original.
+func (m *Mailmap) add(newName, newEmail, oldName, oldEmail string) {
+	key := strings.ToLower(oldEmail)
+	e, ok := m.entries[key]
+	if !ok {
+		e = &entry{names: make(map[string]*alias)}
+		m.entries[key] = e
+	}
+
+	if oldName == "" {
+		if newName != "" {
+			e.name = newName
+		}
+		if newEmail != "" {
+			e.email = newEmail
+		}
+		return
+	}
+
+	a, ok := e.names[strings.ToLower(oldName)]
+	if !ok {
+		a = &alias{}
+		e.names[strings.ToLower(oldName)] = a
+	}
+	if newName != "" {
+		a.name = newName
+	}
+	if newEmail != "" {
+		a.email = newEmail
+	}
+}
+
+// Map returns the canonical name and email of a commit identity. Identities
+// without a mapping are returned unchanged.
+func (m *Mailmap) Map(name, email string) (string, string) {
+	if m == nil {
+		return name, email
+	}
+
+	e, ok := m.entries[strings.ToLower(email)]
+	if !ok {
+		return name, email
+	}
+
+	if a, ok := e.names[strings.ToLower(name)]; ok && (a.name != "" || a.email != "") {
+		return pick(a.name, name), pick(a.email, email)
+	}
+	return pick(e.name, name), pick(e.email, email)
+}
+
+// Len returns the number of commit emails with a mapping
+func (m *Mailmap) Len() int {
+	if m == nil {
+		return 0
+	}
+	return len(m.entries)
+}
+
+// parseIdentity parses "Name <email>" from the start of s and returns the rest.
+// The name may be empty; the email is required.
+func parseIdentity(s string) (name, email, rest string, ok bool) {
+	open := strings.IndexByte(s, '<')
+	if open < 0 {
+		return "", "", "", false
+	}
+	end := strings.IndexByte(s[open:], '>')
+	if end < 0 {
+		return "", "", "", false
+	}
+	end += open
+
+	name = strings.TrimSpace(s[:open])
+	email = strings.TrimSpace(s[open+1 : end])
+	if email == "" {
+		return "", "", "", false
+	}
+	return name, email, s[end+1:], true
+}
+
+// pick returns value if set, otherwise fallback
+func pick(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
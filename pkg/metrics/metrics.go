@@ -0,0 +1,288 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the names of all metrics
+const namespace = "stasis"
+
+// Transports of git operations
+const (
+	TransportHTTP = "http"
+	TransportSSH  = "ssh"
+)
+
+// Reasons the CI cleanup deletes rows for
+const (
+	CICleanupExpired  = "expired"  // Older than ci.retention_days
+	CICleanupOrphaned = "orphaned" // Of a job that no longer exists
+)
+
+// registry holds the metrics of the server, with the Go runtime and process
+// metrics, apart from the global registry of the prometheus package
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of the HTTP requests, by route group, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"group", "method", "status"})
+
+	gitOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "git",
+		Name:      "operations_total",
+		Help:      "Git upload-pack, receive-pack and upload-archive operations, by service, transport, repository and result.",
+	}, []string{"service", "transport", "repository", "result"})
+
+	gitOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "git",
+		Name:      "operation_duration_seconds",
+		Help:      "Duration of the git upload-pack, receive-pack and upload-archive operations, by service, transport and repository.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"service", "transport", "repository"})
+
+	gitTransferBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "git",
+		Name:      "transfer_bytes_total",
+		Help:      "Bytes of git operations received from (in) and sent to (out) clients, by service and transport.",
+	}, []string{"service", "transport", "direction"})
+
+	ciJobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ci",
+		Name:      "jobs_total",
+		Help:      "CI jobs that reached a status: queued when submitted to the runner, then as the runner reports them.",
+	}, []string{"status"})
+
+	ciCleanupDeleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ci",
+		Name:      "cleanup_deleted_rows_total",
+		Help:      "Rows deleted by the CI cleanup tasks, by table and reason (expired or orphaned).",
+	}, []string{"table", "reason"})
+
+	ciSSESubscribers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ci",
+		Name:      "sse_subscribers",
+		Help:      "Clients following the events of a CI job.",
+	})
+
+	sshSessions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ssh",
+		Name:      "sessions",
+		Help:      "Open SSH sessions.",
+	})
+
+	sshSessionUsers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ssh",
+		Name:      "session_users",
+		Help:      "Users, and addresses of anonymous clients, with open SSH sessions.",
+	})
+
+	sshSessionsRefused = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ssh",
+		Name:      "sessions_refused_total",
+		Help:      "SSH sessions refused by the session limits, by limit (global or user).",
+	}, []string{"limit"})
+
+	sshSessionsTimedOut = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ssh",
+		Name:      "sessions_timed_out_total",
+		Help:      "SSH sessions closed by a timeout, by timeout (idle or max_duration).",
+	}, []string{"timeout"})
+
+	storageOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "operation_duration_seconds",
+		Help:      "Duration of the storage operations, by backend, operation and result.",
+		Buckets:   []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"backend", "operation", "result"})
+
+	s3Requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "s3",
+		Name:      "requests_total",
+		Help:      "Operations of the S3 client, by operation and result after all retries.",
+	}, []string{"operation", "result"})
+
+	s3Retries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "s3",
+		Name:      "retries_total",
+		Help:      "Attempts of S3 client operations beyond the first one, by operation.",
+	}, []string{"operation"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		gitOperations,
+		gitOperationDuration,
+		gitTransferBytes,
+		ciJobs,
+		ciCleanupDeleted,
+		ciSSESubscribers,
+		sshSessions,
+		sshSessionUsers,
+		sshSessionsRefused,
+		sshSessionsTimedOut,
+		storageOperationDuration,
+		s3Requests,
+		s3Retries,
+	)
+}
+
+// Handler returns the handler serving the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTPRequest records an HTTP request of a route group
+func ObserveHTTPRequest(group, method string, status int, duration time.Duration) {
+	httpRequestDuration.WithLabelValues(group, method, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// CIJobStatus records a CI job reaching a status
+func CIJobStatus(status string) {
+	ciJobs.WithLabelValues(status).Inc()
+}
+
+// CICleanupDeleted records rows of a table deleted by the CI cleanup for a
+// reason
+func CICleanupDeleted(table, reason string, count int64) {
+	ciCleanupDeleted.WithLabelValues(table, reason).Add(float64(count))
+}
+
+// CISSESubscriberAdded records a client starting to follow a CI job
+func CISSESubscriberAdded() {
+	ciSSESubscribers.Inc()
+}
+
+// CISSESubscriberRemoved records a client no longer following a CI job
+func CISSESubscriberRemoved() {
+	ciSSESubscribers.Dec()
+}
+
+// SetSSHSessions records the open SSH sessions and the users they belong to
+func SetSSHSessions(sessions, users int) {
+	sshSessions.Set(float64(sessions))
+	sshSessionUsers.Set(float64(users))
+}
+
+// SSHSessionRefused records an SSH session refused by a session limit
+func SSHSessionRefused(limit string) {
+	sshSessionsRefused.WithLabelValues(limit).Inc()
+}
+
+// SSHSessionTimedOut records an SSH session closed by a timeout
+func SSHSessionTimedOut(timeout string) {
+	sshSessionsTimedOut.WithLabelValues(timeout).Inc()
+}
+
+// ObserveStorageOperation records a storage operation of a backend started at
+// start, failed if err is set
+func ObserveStorageOperation(backend, operation string, start time.Time, err error) {
+	storageOperationDuration.WithLabelValues(backend, operation, result(err)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveS3Request records an operation of the S3 client that was retried
+// retries times, failed if err is set
+func ObserveS3Request(operation string, retries int, err error) {
+	s3Requests.WithLabelValues(operation, result(err)).Inc()
+	if retries > 0 {
+		s3Retries.WithLabelValues(operation).Add(float64(retries))
+	}
+}
+
+// GitOperation measures a git upload-pack or receive-pack operation. The
+// operation must read its input from Input and write its output to Output so
+// the bytes transferred are counted, and call Done when it ends.
+type GitOperation struct {
+	Input  io.Reader
+	Output io.Writer
+
+	service    string
+	transport  string
+	repository string
+	start      time.Time
+	in         atomic.Int64
+	out        atomic.Int64
+}
+
+// StartGitOperation starts measuring a git operation of service
+// (git-upload-pack, git-receive-pack, git-upload-archive) on repository over
+// transport
+func StartGitOperation(service, transport, repository string, input io.Reader, output io.Writer) *GitOperation {
+	op := &GitOperation{
+		service:    service,
+		transport:  transport,
+		repository: repository,
+		start:      time.Now(),
+	}
+	op.Input = &countingReader{r: input, n: &op.in}
+	op.Output = &countingWriter{w: output, n: &op.out}
+	return op
+}
+
+// Done records the operation, failed if err is set
+func (op *GitOperation) Done(err error) {
+	gitOperations.WithLabelValues(op.service, op.transport, op.repository, result(err)).Inc()
+	gitOperationDuration.WithLabelValues(op.service, op.transport, op.repository).Observe(time.Since(op.start).Seconds())
+	gitTransferBytes.WithLabelValues(op.service, op.transport, "in").Add(float64(op.in.Load()))
+	gitTransferBytes.WithLabelValues(op.service, op.transport, "out").Add(float64(op.out.Load()))
+}
+
+// countingReader counts the bytes read from r
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// countingWriter counts the bytes written to w
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// result is the result label of an operation that returned err
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
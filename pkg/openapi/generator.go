@@ -1,6 +1,7 @@
 package openapi
 
 import (
+	"sort"
 	"strconv"
 	"strings"
 
@@ -12,6 +13,7 @@ type RouteDocs struct {
 	Description string
 	Tags        []string
 	RequestBody interface{} // Struct for request body schema
+	Parameters  []Parameter // Query and header parameters, after the path parameters
 	Responses   map[int]ResponseDoc
 }
 
@@ -19,14 +21,27 @@ type ResponseDoc struct {
 	Description string
 	Model       interface{} // Struct for response schema
 	Example     interface{} // Example value
+
+	// Versions holds the response schema of API versions that changed it,
+	// keyed by the version (YYYY-MM-DD) it applies from
+	Versions map[string]interface{}
 }
 
+// SecurityFunc returns the security requirements of a route
+type SecurityFunc func(method, path string, docs RouteDocs) []SecurityRequirement
+
 type Generator struct {
 	engine    *gin.Engine
 	info      Info
 	servers   []Server
 	tags      []Tag
 	routeDocs map[string]RouteDocs
+
+	securitySchemes map[string]SecurityScheme
+	security        SecurityFunc
+
+	errorSchemaName string
+	errorModel      interface{}
 }
 
 func NewGenerator(engine *gin.Engine, info Info, servers []Server, tags []Tag) *Generator {
@@ -47,7 +62,60 @@ func (g *Generator) RegisterDocs(method, path string, docs RouteDocs) {
 	g.routeDocs[key] = docs
 }
 
+// SetSecurity sets the security schemes of the spec and the function
+// choosing the requirements of each operation
+func (g *Generator) SetSecurity(schemes map[string]SecurityScheme, security SecurityFunc) {
+	g.securitySchemes = schemes
+	g.security = security
+}
+
+// SetErrorSchema sets the error envelope of the API. It is added to the
+// component schemas as name and used by the error responses (4xx and 5xx)
+// registered without a model.
+func (g *Generator) SetErrorSchema(name string, model interface{}) {
+	g.errorSchemaName = name
+	g.errorModel = model
+}
+
+// Undocumented returns the routes ("METHOD /path") registered without docs
+func (g *Generator) Undocumented() []string {
+	var routes []string
+	for _, route := range g.engine.Routes() {
+		key := route.Method + " " + route.Path
+		if _, ok := g.routeDocs[key]; !ok {
+			routes = append(routes, key)
+		}
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// Unrouted returns the docs registered for routes that do not exist, which
+// the spec leaves out
+func (g *Generator) Unrouted() []string {
+	routes := make(map[string]bool)
+	for _, route := range g.engine.Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	var keys []string
+	for key := range g.routeDocs {
+		if !routes[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Generate generates the spec with the unversioned response schemas
 func (g *Generator) Generate() *OpenAPI {
+	return g.GenerateVersion("")
+}
+
+// GenerateVersion generates the spec as seen by clients of an API version:
+// each response uses the schema of the newest of its versions not after the
+// requested one
+func (g *Generator) GenerateVersion(version string) *OpenAPI {
 	spec := &OpenAPI{
 		OpenAPI: "3.0.3",
 		Info:    g.info,
@@ -55,10 +123,18 @@ func (g *Generator) Generate() *OpenAPI {
 		Tags:    g.tags,
 		Paths:   make(map[string]*PathItem),
 		Components: Components{
-			Schemas: make(map[string]*Schema),
+			Schemas:         make(map[string]*Schema),
+			SecuritySchemes: g.securitySchemes,
 		},
 	}
 
+	var errorRef *Schema
+	if g.errorModel != nil {
+		spec.Components.Schemas[g.errorSchemaName] = GenerateSchema(g.errorModel)
+		errorRef = &Schema{Ref: "#/components/schemas/" + g.errorSchemaName}
+	}
+
+	usedIDs := make(map[string]bool)
 	for _, route := range g.engine.Routes() {
 		// Convert Gin path to OpenAPI path
 		// e.g., /api/repos/:owner/:repo -> /api/repos/{owner}/{repo}
@@ -78,7 +154,7 @@ func (g *Generator) Generate() *OpenAPI {
 
 		operation := &Operation{
 			Summary:     route.Handler,
-			OperationID: getOperationID(route.Handler),
+			OperationID: uniqueOperationID(getOperationID(route.Handler), route.Method, usedIDs),
 			Parameters:  pathParams,
 			Responses:   make(map[string]Response),
 		}
@@ -93,6 +169,7 @@ func (g *Generator) Generate() *OpenAPI {
 			if len(docs.Tags) > 0 {
 				operation.Tags = docs.Tags
 			}
+			operation.Parameters = append(operation.Parameters, docs.Parameters...)
 
 			// Handle Request Body
 			if docs.RequestBody != nil {
@@ -113,10 +190,11 @@ func (g *Generator) Generate() *OpenAPI {
 					Description: respDoc.Description,
 				}
 
-				if respDoc.Model != nil || respDoc.Example != nil {
+				model := versionedModel(respDoc, version)
+				if model != nil || respDoc.Example != nil {
 					mediaType := MediaType{}
-					if respDoc.Model != nil {
-						mediaType.Schema = GenerateSchema(respDoc.Model)
+					if model != nil {
+						mediaType.Schema = GenerateSchema(model)
 						if respDoc.Example != nil {
 							mediaType.Schema.Example = respDoc.Example
 						}
@@ -124,6 +202,10 @@ func (g *Generator) Generate() *OpenAPI {
 					resp.Content = map[string]MediaType{
 						"application/json": mediaType,
 					}
+				} else if status >= 400 && errorRef != nil {
+					resp.Content = map[string]MediaType{
+						"application/json": {Schema: errorRef},
+					}
 				}
 
 				operation.Responses[strconv.Itoa(status)] = resp
@@ -137,6 +219,10 @@ func (g *Generator) Generate() *OpenAPI {
 			}
 		}
 
+		if g.security != nil {
+			operation.Security = g.security(route.Method, route.Path, docs)
+		}
+
 		// Assign operation to method
 		switch route.Method {
 		case "GET":
@@ -156,9 +242,24 @@ func (g *Generator) Generate() *OpenAPI {
 		}
 	}
 
+	if version != "" {
+		spec.Info.Version = version
+	}
 	return spec
 }
 
+// versionedModel returns the response model of an API version
+func versionedModel(respDoc ResponseDoc, version string) interface{} {
+	model, since := respDoc.Model, ""
+	for v, m := range respDoc.Versions {
+		// Versions are ISO dates, which order as strings
+		if version != "" && v <= version && v > since {
+			model, since = m, v
+		}
+	}
+	return model
+}
+
 func convertPath(ginPath string) string {
 	parts := strings.Split(ginPath, "/")
 	for i, part := range parts {
@@ -198,6 +299,19 @@ func extractPathParams(ginPath string) []Parameter {
 	return params
 }
 
+// uniqueOperationID makes an operation ID unique within the spec, for
+// handlers serving several routes
+func uniqueOperationID(id, method string, used map[string]bool) string {
+	if used[id] {
+		id += "_" + method
+	}
+	for n, base := 2, id; used[id]; n++ {
+		id = base + "_" + strconv.Itoa(n)
+	}
+	used[id] = true
+	return id
+}
+
 func getOperationID(handlerName string) string {
 	// handlerName is usually "github.com/bravo68web/stasis/internal/transport/http/handler.(*RepoHandler).GetRepository-fm"
 	// We want something cleaner like "RepoHandler_GetRepository"
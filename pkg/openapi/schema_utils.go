@@ -45,6 +45,19 @@ func typeToSchema(t reflect.Type) *Schema {
 				continue
 			}
 
+			// Embedded structs contribute their fields, which the fields
+			// of the outer struct override
+			if field.Anonymous && jsonTag == "" {
+				if embedded := typeToSchema(field.Type); embedded != nil {
+					for name, prop := range embedded.Properties {
+						if _, exists := schema.Properties[name]; !exists {
+							schema.Properties[name] = prop
+						}
+					}
+				}
+				continue
+			}
+
 			// Parse json tag name
 			name := field.Name
 			if jsonTag != "" {
@@ -87,6 +100,9 @@ func typeToSchema(t reflect.Type) *Schema {
 	case reflect.Ptr:
 		return typeToSchema(t.Elem())
 
+	case reflect.Interface:
+		return &Schema{} // Any value
+
 	default:
 		return &Schema{Type: "string"} // Fallback
 	}
@@ -67,6 +67,24 @@ type Operation struct {
 	Parameters  []Parameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
 	RequestBody *RequestBody        `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
 	Responses   map[string]Response `json:"responses" yaml:"responses"`
+
+	// Security lists the alternative credentials of the operation; an empty
+	// requirement makes them optional
+	Security []SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+}
+
+// SecurityRequirement names the security schemes a request must satisfy
+// together, with the scopes of each
+type SecurityRequirement map[string][]string
+
+// SecurityScheme describes a way of authenticating requests
+type SecurityScheme struct {
+	Type         string `json:"type" yaml:"type"`                                     // "http" or "apiKey"
+	Scheme       string `json:"scheme,omitempty" yaml:"scheme,omitempty"`             // "bearer" or "basic", for http
+	BearerFormat string `json:"bearerFormat,omitempty" yaml:"bearerFormat,omitempty"` // For bearer
+	In           string `json:"in,omitempty" yaml:"in,omitempty"`                     // "header", "query" or "cookie", for apiKey
+	Name         string `json:"name,omitempty" yaml:"name,omitempty"`                 // Header or parameter name, for apiKey
+	Description  string `json:"description,omitempty" yaml:"description,omitempty"`
 }
 
 type Parameter struct {
@@ -74,6 +92,7 @@ type Parameter struct {
 	In          string  `json:"in" yaml:"in"` // "query", "header", "path", "cookie"
 	Description string  `json:"description,omitempty" yaml:"description,omitempty"`
 	Required    bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Deprecated  bool    `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
 	Schema      *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
 }
 
@@ -93,17 +112,21 @@ type MediaType struct {
 }
 
 type Schema struct {
+	Ref         string             `json:"$ref,omitempty" yaml:"$ref,omitempty"` // Reference to a component schema, replacing the other fields
 	Type        string             `json:"type,omitempty" yaml:"type,omitempty"`
 	Format      string             `json:"format,omitempty" yaml:"format,omitempty"`
 	Properties  map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
 	Items       *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
 	Description string             `json:"description,omitempty" yaml:"description,omitempty"`
 	Example     interface{}        `json:"example,omitempty" yaml:"example,omitempty"`
+	Minimum     *int               `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Maximum     *int               `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	Default     interface{}        `json:"default,omitempty" yaml:"default,omitempty"`
 }
 
 type Components struct {
-	Schemas         map[string]*Schema     `json:"schemas,omitempty" yaml:"schemas,omitempty"`
-	SecuritySchemes map[string]interface{} `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+	Schemas         map[string]*Schema        `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
 }
 
 // SaveToFile saves the OpenAPI spec to a YAML file
@@ -1,4 +1,4 @@
-import { getBlob, getBlame } from "@/lib/api";
+import { getBlob, getBlame, getRawFileUrl } from "@/lib/api";
 import Link from "next/link";
 import { BlameLine } from "@/lib/types";
 import Image from "next/image";
@@ -32,6 +32,8 @@ export default async function BlobPage({
   let failed = false;
   let isBinary = false;
   let encoding = "utf-8";
+  let truncated = false;
+  let size = 0;
 
   try {
     if (isBlame) {
@@ -46,6 +48,8 @@ export default async function BlobPage({
       path = data.path;
       isBinary = data.is_binary || false;
       encoding = data.encoding || "utf-8";
+      truncated = data.truncated || false;
+      size = data.size || 0;
     }
   } catch {
     failed = true;
@@ -122,7 +126,20 @@ export default async function BlobPage({
           </Link>
         </div>
       </div>
-      {isBinary ? (
+      {truncated && !isBlame ? (
+        <div className="text-center text-muted py-8">
+          <p className="text-lg mb-2">File too large to display</p>
+          <p className="text-sm">
+            This file is {(size / (1024 * 1024)).toFixed(1)} MB.{" "}
+            <a
+              href={getRawFileUrl(username, repo, ref, path)}
+              className="text-accent hover:underline"
+            >
+              View raw
+            </a>
+          </p>
+        </div>
+      ) : isBinary ? (
         <div className="p-4">
           {isImage ? (
             <div className="flex justify-center">
@@ -264,21 +264,29 @@ export default function CIJobDetailPage() {
       jobId,
       (event: CIJobEvent) => {
         switch (event.type) {
-          case "log":
-            const logData = event.data as CIJobLog;
+          case "logs": {
+            const batch = event.data as CIJobLog[];
             setLogs((prev) => {
-              // Check if log already exists
-              if (prev.some((l) => l.sequence === logData.sequence)) {
+              // Skip the logs already shown
+              const seen = new Set(prev.map((l) => l.sequence));
+              const fresh = batch.filter((l) => !seen.has(l.sequence));
+              if (fresh.length === 0) {
                 return prev;
               }
               // Add and sort by sequence
-              return [...prev, logData].sort((a, b) => a.sequence - b.sequence);
+              return [...prev, ...fresh].sort((a, b) => a.sequence - b.sequence);
             });
             break;
+          }
           case "status":
             // Refresh job data on status change
             getCIJob(username, repo, jobId).then(setJob).catch(console.error);
             break;
+          case "complete":
+            // The stream ended with the job
+            setIsStreaming(false);
+            getCIJob(username, repo, jobId).then(setJob).catch(console.error);
+            break;
         }
       },
       (error) => {
@@ -3,7 +3,24 @@
 import { useState } from "react";
 import { useRouter } from "next/navigation";
 import Link from "next/link";
-import { importRepository } from "@/lib/api";
+import { getRepositoryImport, importRepository } from "@/lib/api";
+import { RepoImportResponse } from "@/lib/types";
+
+// How often the status of a running import is checked
+const IMPORT_POLL_INTERVAL_MS = 2000;
+
+// waitForImport polls an import until it completed or failed
+async function waitForImport(
+  imp: RepoImportResponse,
+): Promise<RepoImportResponse> {
+  while (imp.status === "pending" || imp.status === "running") {
+    await new Promise((resolve) =>
+      setTimeout(resolve, IMPORT_POLL_INTERVAL_MS),
+    );
+    imp = await getRepositoryImport(imp.id);
+  }
+  return imp;
+}
 
 export default function ImportRepositoryPage() {
   const router = useRouter();
@@ -72,7 +89,7 @@ export default function ImportRepositoryPage() {
     setLoading(true);
 
     try {
-      const repo = await importRepository({
+      const queued = await importRepository({
         name: formData.name,
         clone_url: formData.clone_url,
         description: formData.description || undefined,
@@ -82,8 +99,15 @@ export default function ImportRepositoryPage() {
         mirror: formData.mirror,
       });
 
+      // Imports run in the background; wait for the repository
+      const imp = await waitForImport(queued);
+      if (imp.status === "failed" || !imp.repository) {
+        setError(imp.error || "Failed to import repository");
+        return;
+      }
+
       // Redirect to the new repository
-      router.push(`/${repo.owner}/${repo.name}`);
+      router.push(`/${imp.repository}`);
     } catch (err) {
       setError(
         err instanceof Error ? err.message : "Failed to import repository",
@@ -4,15 +4,27 @@ import {
   OIDCConfigResponse,
   OIDCCallbackResponse,
   OIDCLogoutResponse,
+  SessionRefreshResponse,
   CreateRepoRequest,
   ImportRepoRequest,
+  RepoImportResponse,
   UpdateRepoRequest,
   UpdateMirrorSettingsRequest,
   MirrorSettingsResponse,
   RepoResponse,
   RepoListResponse,
   PublicRepoListResponse,
+  TopicsResponse,
+  TopicCountListResponse,
   RepoStats,
+  ContributorStatsResponse,
+  LanguagesResponse,
+  PushPolicy,
+  PushPolicyResponse,
+  CheckPushPolicyRequest,
+  CheckPushPolicyResponse,
+  UploadPackSettings,
+  UpdateUploadPackSettingsRequest,
   BranchRequest,
   BranchResponse,
   BranchListResponse,
@@ -159,13 +171,28 @@ async function apiRequest<T>(
     (headers as Record<string, string>)["Authorization"] = `Bearer ${token}`;
   }
 
-  const res = await fetch(`${getApiUrl()}${endpoint}`, {
+  let res = await fetch(`${getApiUrl()}${endpoint}`, {
     ...options,
     headers,
     credentials: "include", // Include cookies for cross-origin requests
     cache: "no-store",
   });
 
+  // Session tokens are short-lived: refresh an expired one once and retry
+  if (res.status === 401 && token && !isServer()) {
+    const refreshed = await refreshSession();
+    if (refreshed) {
+      (headers as Record<string, string>)["Authorization"] =
+        `Bearer ${refreshed}`;
+      res = await fetch(`${getApiUrl()}${endpoint}`, {
+        ...options,
+        headers,
+        credentials: "include",
+        cache: "no-store",
+      });
+    }
+  }
+
   if (!res.ok) {
     const errorData: ErrorResponse = await res.json().catch(() => ({
       error: "unknown_error",
@@ -177,6 +204,39 @@ async function apiRequest<T>(
   return res.json();
 }
 
+// Refresh in flight, shared by the requests failing at the same time
+let refreshInFlight: Promise<string | null> | null = null;
+
+/**
+ * Refresh the session token with the session it belongs to
+ * Returns the new token, or null if the session has ended
+ */
+function refreshSession(): Promise<string | null> {
+  const token = getToken();
+  if (!token) return Promise.resolve(null);
+
+  if (!refreshInFlight) {
+    refreshInFlight = fetch(`${getApiUrl()}/v1/auth/refresh`, {
+      method: "POST",
+      headers: { Authorization: `Bearer ${token}` },
+      credentials: "include",
+      cache: "no-store",
+    })
+      .then(async (res) => {
+        if (!res.ok) return null;
+        const data: SessionRefreshResponse = await res.json();
+        setToken(data.token);
+        setUserInfo(data.user);
+        return data.token;
+      })
+      .catch(() => null)
+      .finally(() => {
+        refreshInFlight = null;
+      });
+  }
+  return refreshInFlight;
+}
+
 // ============================================================================
 // Health API
 // ============================================================================
@@ -288,7 +348,7 @@ export async function logout(
       ? `?redirect_uri=${encodeURIComponent(redirectUri)}`
       : "";
     const response = await apiRequest<OIDCLogoutResponse>(
-      `/v1/auth/oidc/logout${params}`,
+      `/v1/auth/logout${params}`,
       { method: "POST" },
     );
 
@@ -403,24 +463,58 @@ export async function createRepository(
 
 export async function importRepository(
   data: ImportRepoRequest,
-): Promise<RepoResponse> {
+): Promise<RepoImportResponse> {
   return apiRequest("/v1/repos/import", {
     method: "POST",
     body: JSON.stringify(data),
   });
 }
 
+export async function getRepositoryImport(
+  id: string,
+): Promise<RepoImportResponse> {
+  return apiRequest(`/v1/repos/imports/${encodeURIComponent(id)}`);
+}
+
 export async function listPublicRepositories(
   page: number = 1,
   perPage: number = 20,
+  topic?: string,
 ): Promise<PublicRepoListResponse> {
   const params = new URLSearchParams({
     page: page.toString(),
     per_page: perPage.toString(),
   });
+  if (topic) {
+    params.set("topic", topic);
+  }
   return apiRequest(`/v1/repos/public?${params}`);
 }
 
+export async function updateRepositoryTopics(
+  owner: string,
+  repo: string,
+  topics: string[],
+): Promise<TopicsResponse> {
+  return apiRequest(
+    `/v1/repos/${encodeURIComponent(owner)}/${encodeURIComponent(repo)}/topics`,
+    {
+      method: "PUT",
+      body: JSON.stringify({ topics }),
+    },
+  );
+}
+
+export async function listPopularTopics(
+  limit?: number,
+): Promise<TopicCountListResponse> {
+  const params = new URLSearchParams();
+  if (limit) {
+    params.set("limit", limit.toString());
+  }
+  return apiRequest(`/v1/topics?${params}`);
+}
+
 export async function getRepository(
   owner: string,
   repo: string,
@@ -465,6 +559,92 @@ export async function getRepositoryStats(
   );
 }
 
+export async function getContributorStats(
+  owner: string,
+  repo: string,
+  options: { ref?: string; since?: string; until?: string } = {},
+): Promise<ContributorStatsResponse> {
+  const params = new URLSearchParams();
+  if (options.ref) params.set("ref", options.ref);
+  if (options.since) params.set("since", options.since);
+  if (options.until) params.set("until", options.until);
+  return apiRequest(
+    `/v1/repos/${encodeURIComponent(owner)}/${encodeURIComponent(repo)}/stats/contributors?${params}`,
+  );
+}
+
+export async function getLanguages(
+  owner: string,
+  repo: string,
+  ref?: string,
+): Promise<LanguagesResponse> {
+  const params = new URLSearchParams();
+  if (ref) params.set("ref", ref);
+  return apiRequest(
+    `/v1/repos/${encodeURIComponent(owner)}/${encodeURIComponent(repo)}/languages?${params}`,
+  );
+}
+
+export async function getPushPolicy(
+  owner: string,
+  repo: string,
+): Promise<PushPolicyResponse> {
+  return apiRequest(
+    `/v1/repos/${encodeURIComponent(owner)}/${encodeURIComponent(repo)}/settings/push-policy`,
+  );
+}
+
+export async function updatePushPolicy(
+  owner: string,
+  repo: string,
+  data: PushPolicy,
+): Promise<PushPolicyResponse> {
+  return apiRequest(
+    `/v1/repos/${encodeURIComponent(owner)}/${encodeURIComponent(repo)}/settings/push-policy`,
+    {
+      method: "PUT",
+      body: JSON.stringify(data),
+    },
+  );
+}
+
+export async function checkPushPolicy(
+  owner: string,
+  repo: string,
+  data: CheckPushPolicyRequest,
+): Promise<CheckPushPolicyResponse> {
+  return apiRequest(
+    `/v1/repos/${encodeURIComponent(owner)}/${encodeURIComponent(repo)}/settings/push-policy/check`,
+    {
+      method: "POST",
+      body: JSON.stringify(data),
+    },
+  );
+}
+
+export async function getUploadPackSettings(
+  owner: string,
+  repo: string,
+): Promise<UploadPackSettings> {
+  return apiRequest(
+    `/v1/repos/${encodeURIComponent(owner)}/${encodeURIComponent(repo)}/settings/upload-pack`,
+  );
+}
+
+export async function updateUploadPackSettings(
+  owner: string,
+  repo: string,
+  data: UpdateUploadPackSettingsRequest,
+): Promise<UploadPackSettings> {
+  return apiRequest(
+    `/v1/repos/${encodeURIComponent(owner)}/${encodeURIComponent(repo)}/settings/upload-pack`,
+    {
+      method: "PUT",
+      body: JSON.stringify(data),
+    },
+  );
+}
+
 export async function getMirrorSettings(
   owner: string,
   repo: string,
@@ -680,6 +860,8 @@ export async function getBlob(
   content: string;
   is_binary?: boolean;
   encoding?: string;
+  size?: number;
+  truncated?: boolean;
 }> {
   // Parse urlPath to extract ref and path
   // urlPath format: "ref/path/to/file"
@@ -705,9 +887,24 @@ export async function getBlob(
     content: data.content,
     is_binary: data.is_binary,
     encoding: data.encoding,
+    size: data.size,
+    truncated: data.truncated,
   };
 }
 
+/**
+ * Get the URL streaming the raw bytes of a file, usable as an image source
+ * or download link
+ */
+export function getRawFileUrl(
+  owner: string,
+  name: string,
+  ref: string,
+  path: string,
+): string {
+  return `${getApiUrl()}/v1/repos/${encodeURIComponent(owner)}/${encodeURIComponent(name)}/raw/${encodeURIComponent(ref)}/${path.split("/").map(encodeURIComponent).join("/")}`;
+}
+
 export async function getCommits(
   owner: string,
   name: string,
@@ -1010,13 +1207,13 @@ export function subscribeToCIJobStream(
     }
   });
 
-  // Handle log entries
-  eventSource.addEventListener("log", (e: MessageEvent) => {
+  // Handle log entries, sent in batches
+  eventSource.addEventListener("logs", (e: MessageEvent) => {
     try {
-      const data: CIJobLog = JSON.parse(e.data);
-      onEvent({ type: "log", job_id: jobId, data });
+      const event: { data: CIJobLog[] } = JSON.parse(e.data);
+      onEvent({ type: "logs", job_id: jobId, data: event.data });
     } catch (err) {
-      console.error("Failed to parse log event:", err);
+      console.error("Failed to parse logs event:", err);
     }
   });
 
@@ -1040,6 +1237,17 @@ export function subscribeToCIJobStream(
     }
   });
 
+  // The job finished: close the stream, or the browser would reconnect
+  eventSource.addEventListener("complete", (e: MessageEvent) => {
+    eventSource.close();
+    try {
+      const data = JSON.parse(e.data);
+      onEvent({ type: "complete", job_id: jobId, data });
+    } catch (err) {
+      console.error("Failed to parse complete event:", err);
+    }
+  });
+
   // Handle errors
   eventSource.onerror = (e) => {
     if (onError) {
@@ -31,6 +31,11 @@ export interface OIDCLogoutResponse {
   logout_url?: string;
 }
 
+export interface SessionRefreshResponse {
+  token: string;
+  user: UserInfo;
+}
+
 // Repository types
 export interface CreateRepoRequest {
   name: string;
@@ -48,6 +53,23 @@ export interface ImportRepoRequest {
   mirror?: boolean;
 }
 
+export interface RepoImportResponse {
+  id: string;
+  name: string;
+  source: "url" | "archive";
+  clone_url?: string;
+  archive_name?: string;
+  mirror: boolean;
+  is_private: boolean;
+  status: "pending" | "running" | "completed" | "failed";
+  error?: string;
+  repository_id?: string;
+  repository?: string;
+  created_at: string;
+  started_at?: string;
+  completed_at?: string;
+}
+
 export interface UpdateMirrorSettingsRequest {
   mirror_enabled?: boolean;
   mirror_direction?: string;
@@ -77,6 +99,7 @@ export interface MirrorSettingsResponse {
 }
 
 export interface UpdateRepoRequest {
+  name?: string;
   description?: string;
   is_private?: boolean;
 }
@@ -100,10 +123,25 @@ export interface RepoResponse {
   last_synced_at?: string;
   next_sync_at?: string;
   sync_status?: string;
+  topics: string[];
+  primary_language?: string;
   created_at: string;
   updated_at: string;
 }
 
+export interface TopicsResponse {
+  topics: string[];
+}
+
+export interface TopicCount {
+  topic: string;
+  count: number;
+}
+
+export interface TopicCountListResponse {
+  topics: TopicCount[];
+}
+
 export interface RepoListResponse {
   repositories: RepoResponse[];
   total: number;
@@ -116,6 +154,80 @@ export interface PublicRepoListResponse {
   total: number;
 }
 
+export interface ContributorStat {
+  name: string;
+  email: string;
+  commits: number;
+  additions: number;
+  deletions: number;
+  first_commit: string;
+  last_commit: string;
+}
+
+export interface WeeklyCommits {
+  week: string;
+  commits: number;
+}
+
+export interface ContributorStatsResponse {
+  ref: string;
+  commit: string;
+  contributors: ContributorStat[];
+  weeks: WeeklyCommits[];
+}
+
+export interface LanguageStat {
+  language: string;
+  bytes: number;
+  percentage: number;
+}
+
+export interface LanguagesResponse {
+  ref: string;
+  commit: string;
+  languages: LanguageStat[];
+}
+
+export interface PushPolicy {
+  max_file_size?: number;
+  blocked_paths?: string[];
+  commit_message_pattern?: string;
+  deny_non_fast_forward?: boolean;
+}
+
+export interface PushPolicyResponse extends PushPolicy {
+  enabled: boolean;
+  blocked_paths: string[];
+}
+
+export interface CheckPushPolicyRequest {
+  ref?: string;
+  base?: string;
+  head: string;
+  policy?: PushPolicy;
+}
+
+export interface PushPolicyViolation {
+  ref?: string;
+  commit: string;
+  rule: string;
+  path?: string;
+  message: string;
+}
+
+export interface CheckPushPolicyResponse {
+  allowed: boolean;
+  violations: PushPolicyViolation[];
+}
+
+export interface UploadPackSettings {
+  allow_filter: boolean;
+  allow_reachable_sha1_in_want: boolean;
+  allow_any_sha1_in_want: boolean;
+}
+
+export type UpdateUploadPackSettingsRequest = Partial<UploadPackSettings>;
+
 export interface RepoStats {
   commits: number;
   branches: number;
@@ -127,6 +239,11 @@ export interface RepoStats {
   total_commits?: number;
   disk_usage?: number;
   language_usage_perc?: Record<string, number>;
+  loose_objects?: number;
+  packed_objects?: number;
+  pack_count?: number;
+  last_gc_at?: string | null;
+  last_gc_duration_ms?: number;
 }
 
 // Branch types
@@ -225,6 +342,8 @@ export interface FileContentResponse {
   is_binary: boolean;
   encoding: string;
   ref: string;
+  truncated: boolean;
+  raw_url: string;
 }
 
 export interface Branch {
@@ -434,7 +553,7 @@ export interface TriggerCIJobResponse {
 }
 
 export interface CIJobEvent {
-  type: "connected" | "status" | "log" | "step" | "artifact";
+  type: "connected" | "status" | "logs" | "step" | "artifact" | "complete";
   job_id: string;
-  data: CIJobLog | CIJob | unknown;
+  data: CIJobLog[] | CIJob | unknown;
 }